@@ -1,7 +1,9 @@
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // TLSConfig controls HTTPS for an Application.
@@ -12,6 +14,63 @@ type TLSConfig struct {
 	Enabled *bool `json:"enabled,omitempty"`
 }
 
+// AccessConfig restricts who can reach an Application and how often.
+type AccessConfig struct {
+	// IPAllowList limits requests to these source IPs or CIDR ranges.
+	// When empty, all sources are allowed.
+	// +kubebuilder:validation:MaxItems=50
+	// +optional
+	IPAllowList []string `json:"ipAllowList,omitempty"`
+
+	// RequestsPerSecond is the average request rate allowed per client IP.
+	// Bursts of up to twice this rate are tolerated. Zero disables rate limiting.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RequestsPerSecond int32 `json:"requestsPerSecond,omitempty"`
+}
+
+// UptimeCheckConfig configures synthetic HTTP probing of an Application's URL.
+type UptimeCheckConfig struct {
+	// Path is requested on the application URL. Defaults to "/".
+	// +kubebuilder:validation:MaxLength=256
+	// +kubebuilder:validation:Pattern=`^/[A-Za-z0-9/._~%!$&'()*+,;=:@?-]*$`
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// IntervalSeconds is how often the URL is probed.
+	// +kubebuilder:validation:Minimum=30
+	// +kubebuilder:validation:Maximum=3600
+	// +kubebuilder:default=60
+	// +optional
+	IntervalSeconds int32 `json:"intervalSeconds,omitempty"`
+}
+
+// BuildCacheType selects where kpack keeps an Application's build cache.
+type BuildCacheType string
+
+const (
+	// BuildCacheVolume keeps the cache on a PersistentVolumeClaim in the
+	// application's namespace.
+	BuildCacheVolume BuildCacheType = "volume"
+	// BuildCacheRegistry keeps the cache as an image next to the built image.
+	BuildCacheRegistry BuildCacheType = "registry"
+	// BuildCacheNone disables the build cache.
+	BuildCacheNone BuildCacheType = "none"
+)
+
+// BuildCacheConfig configures the cache kpack reuses between builds of an
+// Application. Unset fields fall back to the platform defaults.
+type BuildCacheConfig struct {
+	// Type is where the cache is kept: volume, registry, or none.
+	// +kubebuilder:validation:Enum=volume;registry;none
+	// +optional
+	Type BuildCacheType `json:"type,omitempty"`
+
+	// VolumeSize is the size of the cache volume. Only used with type volume.
+	// +optional
+	VolumeSize *resource.Quantity `json:"volumeSize,omitempty"`
+}
+
 // IsTLSEnabled returns true when TLS should be enabled for the given application.
 // TLS is on by default; set spec.tls.enabled=false to opt out.
 func IsTLSEnabled(app *Application) bool {
@@ -21,6 +80,137 @@ func IsTLSEnabled(app *Application) bool {
 	return *app.Spec.TLS.Enabled
 }
 
+// ProxyConfig controls the platform's outbound HTTP proxy for an Application.
+type ProxyConfig struct {
+	// Enabled controls whether the platform proxy settings (HTTP_PROXY,
+	// HTTPS_PROXY, NO_PROXY) are set in the application's builds and pods.
+	// When nil or true they are set whenever the platform has a proxy. Set
+	// to false to opt out.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// IsProxyEnabled returns true when the platform proxy settings apply to the
+// given application. They apply by default; set spec.proxy.enabled=false to
+// opt out.
+func IsProxyEnabled(app *Application) bool {
+	if app.Spec.Proxy == nil || app.Spec.Proxy.Enabled == nil {
+		return true
+	}
+	return *app.Spec.Proxy.Enabled
+}
+
+// CABundleConfig controls the platform CA bundle for an Application.
+type CABundleConfig struct {
+	// Enabled controls whether the platform CA bundle is mounted in the
+	// application's containers and SSL_CERT_FILE points at it. When nil or
+	// true it is mounted whenever the platform has a CA bundle. Set to false
+	// to opt out.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// IsCABundleEnabled returns true when the platform CA bundle applies to the
+// given application. It applies by default; set spec.caBundle.enabled=false
+// to opt out.
+func IsCABundleEnabled(app *Application) bool {
+	if app.Spec.CABundle == nil || app.Spec.CABundle.Enabled == nil {
+		return true
+	}
+	return *app.Spec.CABundle.Enabled
+}
+
+// BackendTLSConfig controls TLS between the ingress and an Application's
+// pods.
+type BackendTLSConfig struct {
+	// Enabled makes the platform issue the application a serving
+	// certificate, mounted in its container, and makes the ingress connect
+	// to the application over TLS, verifying that certificate. The
+	// application must serve TLS on its port with the mounted certificate.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// IsBackendTLSEnabled returns true when the ingress connects to the given
+// application's pods over TLS. It is off by default.
+func IsBackendTLSEnabled(app *Application) bool {
+	return app.Spec.BackendTLS != nil && app.Spec.BackendTLS.Enabled
+}
+
+// ApplicationProtocol selects how traffic is routed to an Application.
+type ApplicationProtocol string
+
+const (
+	// ProtocolHTTP routes plain HTTP/1.1 traffic (the default).
+	ProtocolHTTP ApplicationProtocol = "http"
+	// ProtocolWebSocket routes HTTP traffic that upgrades to WebSocket connections.
+	ProtocolWebSocket ApplicationProtocol = "websocket"
+	// ProtocolGRPC routes HTTP/2 traffic to a cleartext (h2c) gRPC backend.
+	ProtocolGRPC ApplicationProtocol = "grpc"
+	// ProtocolTCP routes raw TCP traffic using TLS SNI to select the application.
+	ProtocolTCP ApplicationProtocol = "tcp"
+)
+
+// AppProtocol returns the routing protocol for the given application.
+// An empty spec.protocol is treated as http.
+func AppProtocol(app *Application) ApplicationProtocol {
+	if app.Spec.Protocol == "" {
+		return ProtocolHTTP
+	}
+	return app.Spec.Protocol
+}
+
+// ApplicationArchitecture selects the CPU architecture an Application is
+// built for and scheduled on.
+type ApplicationArchitecture string
+
+const (
+	// ArchitectureAMD64 builds for and runs on amd64 nodes.
+	ArchitectureAMD64 ApplicationArchitecture = "amd64"
+	// ArchitectureARM64 builds for and runs on arm64 nodes.
+	ArchitectureARM64 ApplicationArchitecture = "arm64"
+	// ArchitectureMulti builds a multi-architecture image that runs on amd64
+	// or arm64 nodes.
+	ArchitectureMulti ApplicationArchitecture = "multi"
+)
+
+// WorkloadClass selects the operator-defined node placement profile of an
+// Application's pods.
+type WorkloadClass string
+
+const (
+	// WorkloadClassStandard runs on the platform's default nodes.
+	WorkloadClassStandard WorkloadClass = "standard"
+	// WorkloadClassBurst runs on nodes the operator set aside for bursty or
+	// short-lived workloads.
+	WorkloadClassBurst WorkloadClass = "burst"
+	// WorkloadClassGPU runs on the operator's GPU nodes.
+	WorkloadClassGPU WorkloadClass = "gpu"
+)
+
+// ApplicationAuthentication selects how inbound requests to an Application are authenticated.
+type ApplicationAuthentication string
+
+const (
+	// AuthenticationNone exposes the application without authentication (the default).
+	AuthenticationNone ApplicationAuthentication = "none"
+	// AuthenticationBasic protects the application with HTTP basic auth using
+	// platform-generated credentials.
+	AuthenticationBasic ApplicationAuthentication = "basic"
+	// AuthenticationOAuthProxy protects the application with an oauth2-proxy
+	// sidecar wired to the platform identity provider.
+	AuthenticationOAuthProxy ApplicationAuthentication = "oauth-proxy"
+)
+
+// AppAuthentication returns the authentication mode for the given application.
+// An empty spec.authentication is treated as none.
+func AppAuthentication(app *Application) ApplicationAuthentication {
+	if app.Spec.Authentication == "" {
+		return AuthenticationNone
+	}
+	return app.Spec.Authentication
+}
+
 // ApplicationSpec defines the desired state of an Application.
 type ApplicationSpec struct {
 	// Image is a pre-built container image reference (e.g., "nginx:latest").
@@ -39,6 +229,19 @@ type ApplicationSpec struct {
 	// +optional
 	Blob string `json:"blob,omitempty"`
 
+	// BlobSubPath is the directory of the Blob archive to build, for
+	// archives holding several services. Defaults to the archive root.
+	// +kubebuilder:validation:MaxLength=1024
+	// +optional
+	BlobSubPath string `json:"blobSubPath,omitempty"`
+
+	// RegistryCredential names a registry credential Secret in the same
+	// namespace (created with add_registry_credential) used to pull Image
+	// or the built image. It is added to the pod's imagePullSecrets.
+	// +kubebuilder:validation:MaxLength=63
+	// +optional
+	RegistryCredential string `json:"registryCredential,omitempty"`
+
 	// Port is the container port the application listens on.
 	// +kubebuilder:default=8080
 	// +optional
@@ -49,10 +252,66 @@ type ApplicationSpec struct {
 	// +optional
 	Replicas int32 `json:"replicas,omitempty"`
 
+	// Suspended scales the application to zero replicas while keeping its
+	// configuration, image and route. The phase becomes Suspended.
+	// +optional
+	Suspended bool `json:"suspended,omitempty"`
+
+	// IdleTimeout scales the application to zero after it has served no
+	// requests for this long; the next request wakes it. Zero disables idling.
+	// When unset, the platform default from org standards applies.
+	// +optional
+	IdleTimeout *metav1.Duration `json:"idleTimeout,omitempty"`
+
+	// TTL deletes the application once it has existed for this long,
+	// measured from its creation. Intended for demos and previews.
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+
+	// DeletionProtected refuses deletion of the application, by agents and
+	// by kubectl, until it is cleared. A TTL that elapses while it is set
+	// waits for it to be cleared.
+	// +optional
+	DeletionProtected bool `json:"deletionProtected,omitempty"`
+
 	// Env specifies environment variables for the application container.
 	// +optional
 	Env []EnvVar `json:"env,omitempty"`
 
+	// BuildEnv specifies environment variables for kpack builds of Git or
+	// Blob sources, such as buildpack settings (BP_GO_TARGETS, NODE_ENV).
+	// They are not set in the application container.
+	// +optional
+	BuildEnv []EnvVar `json:"buildEnv,omitempty"`
+
+	// Builder is the kpack ClusterBuilder that builds Git or Blob sources.
+	// It must be one of the builders the operator allows; when unset, the
+	// platform default builder is used.
+	// +kubebuilder:validation:MaxLength=253
+	// +optional
+	Builder string `json:"builder,omitempty"`
+
+	// Architecture is the CPU architecture the application is built for and
+	// scheduled on. It must be one the platform offers; it selects that
+	// architecture's ClusterBuilder unless Builder is set. When unset, builds
+	// use the default builder and pods may run on any node.
+	// +kubebuilder:validation:Enum=amd64;arm64;multi
+	// +optional
+	Architecture ApplicationArchitecture `json:"architecture,omitempty"`
+
+	// WorkloadClass selects the node placement profile the operator defined
+	// for burst or GPU workloads, on top of the platform's default placement.
+	// When unset, the application uses the standard class.
+	// +kubebuilder:validation:Enum=standard;burst;gpu
+	// +optional
+	WorkloadClass WorkloadClass `json:"workloadClass,omitempty"`
+
+	// Metadata records who owns the application and why it runs. The
+	// controller copies it as labels onto every object it generates for the
+	// application, for chargeback and cleanup.
+	// +optional
+	Metadata *OwnershipMetadata `json:"metadata,omitempty"`
+
 	// Host is the hostname for routing. Defaults to "{name}.localhost".
 	// +optional
 	Host string `json:"host,omitempty"`
@@ -62,6 +321,49 @@ type ApplicationSpec struct {
 	// +optional
 	TLS *TLSConfig `json:"tls,omitempty"`
 
+	// Protocol selects how Traefik routes traffic to the application:
+	// http (default), websocket, grpc (h2c to the pod), or tcp (TLS SNI routing).
+	// +kubebuilder:validation:Enum=http;websocket;grpc;tcp
+	// +kubebuilder:default=http
+	// +optional
+	Protocol ApplicationProtocol `json:"protocol,omitempty"`
+
+	// StickySessions pins each client to a single pod via a Traefik cookie.
+	// Useful for websocket apps that keep per-connection state. Ignored for tcp.
+	// +optional
+	StickySessions bool `json:"stickySessions,omitempty"`
+
+	// Authentication protects the application's route: none (default), basic
+	// (Traefik BasicAuth middleware with generated credentials), or oauth-proxy
+	// (oauth2-proxy sidecar using the platform identity provider). Not supported for tcp.
+	// +kubebuilder:validation:Enum=none;basic;oauth-proxy
+	// +kubebuilder:default=none
+	// +optional
+	Authentication ApplicationAuthentication `json:"authentication,omitempty"`
+
+	// Access restricts inbound traffic by source IP and request rate.
+	// Not supported for tcp.
+	// +optional
+	Access *AccessConfig `json:"access,omitempty"`
+
+	// UptimeCheck probes the application URL from the platform blackbox
+	// exporter. Results appear in app_status and can drive uptime alerts.
+	// Not supported for tcp.
+	// +optional
+	UptimeCheck *UptimeCheckConfig `json:"uptimeCheck,omitempty"`
+
+	// VerifyConformance has the platform verify each newly deployed image
+	// against the organisation standards once it is Running: the health
+	// and /metrics endpoints, JSON logs and exported spans. The outcome is
+	// reported in status.conformance. Not supported for tcp.
+	// +optional
+	VerifyConformance bool `json:"verifyConformance,omitempty"`
+
+	// BuildCache configures the cache reused between kpack builds of Git or
+	// Blob sources. When unset, the platform default applies.
+	// +optional
+	BuildCache *BuildCacheConfig `json:"buildCache,omitempty"`
+
 	// AttachedDataSources lists data sources attached to this application.
 	// The controller injects credentials from each DataSource as env vars into the Deployment.
 	// Use the attach_data_source MCP tool to add entries here.
@@ -73,6 +375,196 @@ type ApplicationSpec struct {
 	// Use the bind_service MCP tool to add entries here.
 	// +optional
 	BoundManagedServices []BoundManagedService `json:"boundManagedServices,omitempty"`
+
+	// EnvGroups lists session environment groups bound to this application.
+	// The controller adds every variable in each group's Secret to the
+	// container environment; variables set in Env, attached data sources and
+	// bound services take precedence. Changing a group rolls every bound app.
+	// Use the bind_env_group MCP tool to add entries here.
+	// +kubebuilder:validation:MaxItems=10
+	// +optional
+	EnvGroups []string `json:"envGroups,omitempty"`
+
+	// ConfigFiles are files mounted read-only into the application container,
+	// for frameworks that read configuration from files rather than env vars.
+	// Changing a file, or the ConfigMap key it references, rolls the app.
+	// +kubebuilder:validation:MaxItems=20
+	// +optional
+	ConfigFiles []ConfigFile `json:"configFiles,omitempty"`
+
+	// Command overrides the entrypoint of the application container (e.g.
+	// ["node", "worker.js"]). Images built from source run it through the
+	// buildpack launcher, so the buildpack environment applies. When unset,
+	// the image's entrypoint runs, or the web process of a built image.
+	// +kubebuilder:validation:MaxItems=32
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// Args are the arguments of Command, or of the image's entrypoint when
+	// Command is unset.
+	// +kubebuilder:validation:MaxItems=32
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// ReleaseCommand is the command run_migration runs when it is given none,
+	// such as a database migration (e.g. ["npm", "run", "migrate"]). It runs
+	// once per call in a Job with the app's image, environment and bindings;
+	// it is never run automatically on deploy.
+	// +kubebuilder:validation:MaxItems=32
+	// +optional
+	ReleaseCommand []string `json:"releaseCommand,omitempty"`
+
+	// HostAliases are extra /etc/hosts entries in the application's pods,
+	// for on-prem systems that are not in DNS. Cluster-internal names may
+	// not be overridden. Only accepted by the API when the platform enables
+	// custom DNS.
+	// +kubebuilder:validation:MaxItems=10
+	// +optional
+	HostAliases []HostAlias `json:"hostAliases,omitempty"`
+
+	// DNSConfig adds nameservers, search domains and resolver options to
+	// the pods' cluster DNS settings; cluster DNS stays first. Only accepted
+	// by the API when the platform enables custom DNS.
+	// +optional
+	DNSConfig *DNSConfig `json:"dnsConfig,omitempty"`
+
+	// Shutdown tunes connection draining when pods stop. When unset, pods
+	// keep serving for 5 seconds after they are marked for removal and then
+	// have 30 seconds to finish in-flight requests.
+	// +optional
+	Shutdown *ShutdownConfig `json:"shutdown,omitempty"`
+
+	// Proxy opts the application out of the platform's outbound HTTP proxy.
+	// When unset, builds and pods get the proxy settings whenever the
+	// platform has one.
+	// +optional
+	Proxy *ProxyConfig `json:"proxy,omitempty"`
+
+	// CABundle opts the application out of the platform's CA bundle. When
+	// unset, pods trust the platform CA bundle whenever the platform has one.
+	// +optional
+	CABundle *CABundleConfig `json:"caBundle,omitempty"`
+
+	// BackendTLS encrypts traffic from the ingress to the application's
+	// pods. Not supported with protocol tcp or authentication oauth-proxy.
+	// +optional
+	BackendTLS *BackendTLSConfig `json:"backendTLS,omitempty"`
+
+	// Overrides are operator-supplied patches merged into the objects the
+	// controller generates. Use them instead of editing the Deployment or
+	// Service directly: direct edits to fields the controller manages are
+	// reverted and reported through the Drifted condition.
+	// +optional
+	Overrides *ApplicationOverrides `json:"overrides,omitempty"`
+}
+
+// OwnershipMetadata identifies the owner of an Application. Every field
+// becomes a label value, so each is limited to 63 characters.
+type OwnershipMetadata struct {
+	// Team is the owning team, as a lowercase DNS label (e.g. "payments").
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`
+	// +optional
+	Team string `json:"team,omitempty"`
+
+	// Owner is the email address of the person responsible.
+	// +kubebuilder:validation:MaxLength=60
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?@[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)+$`
+	// +optional
+	Owner string `json:"owner,omitempty"`
+
+	// Purpose says briefly why the application runs, as a lowercase slug
+	// (e.g. "customer-portal" or "load-test").
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`
+	// +optional
+	Purpose string `json:"purpose,omitempty"`
+
+	// CostCenter is the cost centre the application is charged to (e.g.
+	// "CC-1042").
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?$`
+	// +optional
+	CostCenter string `json:"costCenter,omitempty"`
+}
+
+// ApplicationOverrides holds strategic merge patches for generated objects.
+// Patches may not change object identity, selectors, or the pod security
+// settings the platform enforces.
+type ApplicationOverrides struct {
+	// Deployment is a strategic merge patch for the generated Deployment,
+	// e.g. {"spec":{"template":{"spec":{"tolerations":[...]}}}}.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Deployment *runtime.RawExtension `json:"deployment,omitempty"`
+
+	// Service is a strategic merge patch for the generated Service.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Service *runtime.RawExtension `json:"service,omitempty"`
+}
+
+// HostAlias maps hostnames to an IP address in the pods' /etc/hosts.
+type HostAlias struct {
+	// IP is the address the hostnames resolve to.
+	IP string `json:"ip"`
+
+	// Hostnames are fully qualified names resolved to IP. Names under the
+	// cluster domain, *.svc, localhost and single-label names are rejected.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=10
+	Hostnames []string `json:"hostnames"`
+}
+
+// DNSConfig holds resolver settings added to an Application's pods.
+type DNSConfig struct {
+	// Nameservers are extra DNS server IP addresses, queried after cluster DNS.
+	// +kubebuilder:validation:MaxItems=2
+	// +optional
+	Nameservers []string `json:"nameservers,omitempty"`
+
+	// Searches are extra search domains, appended after the cluster's own.
+	// +kubebuilder:validation:MaxItems=3
+	// +optional
+	Searches []string `json:"searches,omitempty"`
+
+	// Options are resolver options such as ndots or timeout.
+	// +kubebuilder:validation:MaxItems=5
+	// +optional
+	Options []DNSOption `json:"options,omitempty"`
+}
+
+// DNSOption is a resolver option from resolv.conf(5).
+type DNSOption struct {
+	// Name is the option: ndots, timeout, attempts, rotate, edns0,
+	// single-request, single-request-reopen or use-vc.
+	Name string `json:"name"`
+
+	// Value is required for ndots, timeout and attempts, and not allowed
+	// for the others.
+	// +optional
+	Value string `json:"value,omitempty"`
+}
+
+// ShutdownConfig controls how an Application's pods stop during rollouts,
+// scale-downs and deletion.
+type ShutdownConfig struct {
+	// GracePeriodSeconds is how long a stopping pod has to finish in-flight
+	// requests after SIGTERM before it is killed, including the pre-stop
+	// delay. Defaults to 30.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=600
+	// +optional
+	GracePeriodSeconds *int32 `json:"gracePeriodSeconds,omitempty"`
+
+	// PreStopDelaySeconds is how long a stopping pod keeps serving before it
+	// receives SIGTERM, so the router stops sending it requests first.
+	// Must be less than GracePeriodSeconds; 0 disables the delay. Defaults
+	// to 5.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=60
+	// +optional
+	PreStopDelaySeconds *int32 `json:"preStopDelaySeconds,omitempty"`
 }
 
 // AttachedDataSource records a DataSource attached to an Application.
@@ -85,6 +577,19 @@ type AttachedDataSource struct {
 	SecretName string `json:"secretName"`
 }
 
+// GitTrackMode selects whether an Application follows new commits on its
+// git revision.
+// +kubebuilder:validation:Enum=revision;branch
+type GitTrackMode string
+
+const (
+	// GitTrackRevision builds the revision once. New commits on a branch
+	// are not deployed until the revision changes.
+	GitTrackRevision GitTrackMode = "revision"
+	// GitTrackBranch builds and deploys every new commit on the branch.
+	GitTrackBranch GitTrackMode = "branch"
+)
+
 // GitSource specifies a git repository source for building.
 type GitSource struct {
 	// URL is the git repository URL.
@@ -94,6 +599,65 @@ type GitSource struct {
 	// +kubebuilder:default="main"
 	// +optional
 	Revision string `json:"revision,omitempty"`
+
+	// SubPath is the directory of the repository to build, for monorepos
+	// holding several services. Defaults to the repository root.
+	// +kubebuilder:validation:MaxLength=1024
+	// +optional
+	SubPath string `json:"subPath,omitempty"`
+
+	// Track is "revision" (default) to build Revision once, or "branch" to
+	// build and deploy each new commit on the Revision branch.
+	// +optional
+	Track GitTrackMode `json:"track,omitempty"`
+
+	// Paused stops auto-deploys of a tracked branch: the application stays
+	// on the commit it runs until Paused is cleared.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+// GitStatus reports the commits of an Application built from git.
+type GitStatus struct {
+	// URL and Revision are the spec.git source the commits were built from.
+	URL      string `json:"url"`
+	Revision string `json:"revision"`
+
+	// Commit is the SHA of the commit the deployed image was built from.
+	// +optional
+	Commit string `json:"commit,omitempty"`
+
+	// LatestCommit is the SHA of the newest build, which may still be
+	// running or have failed.
+	// +optional
+	LatestCommit string `json:"latestCommit,omitempty"`
+
+	// AutoDeploy reports whether new commits on the branch are built and
+	// deployed.
+	// +optional
+	AutoDeploy bool `json:"autoDeploy,omitempty"`
+}
+
+// TLSStatus reports the cert-manager Certificate of an Application.
+type TLSStatus struct {
+	// Issuer is the ClusterIssuer that signs the certificate.
+	Issuer string `json:"issuer"`
+
+	// Ready reports whether a valid certificate is issued and stored in the
+	// application's TLS Secret.
+	Ready bool `json:"ready"`
+
+	// NotAfter is when the current certificate expires.
+	// +optional
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+
+	// RenewalTime is when cert-manager will renew the certificate.
+	// +optional
+	RenewalTime *metav1.Time `json:"renewalTime,omitempty"`
+
+	// Message explains why the certificate is not ready, from cert-manager.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // EnvVar represents an environment variable.
@@ -112,6 +676,104 @@ type BoundManagedService struct {
 	ServiceName string `json:"serviceName"`
 	// SecretName is the name of the CNPG connection Secret in the same namespace.
 	SecretName string `json:"secretName"`
+	// Mode selects the endpoints injected: rw (the primary, the default),
+	// ro (the read replicas) or both. ro and both need a plan with replicas.
+	// +kubebuilder:validation:Enum=rw;ro;both
+	// +optional
+	Mode BindMode `json:"mode,omitempty"`
+	// EnvPrefix is prepended to the names of the injected env vars, such as
+	// "ORDERS_" for ORDERS_DATABASE_URL, so several services can be bound
+	// to one app.
+	// +kubebuilder:validation:Pattern=`^[A-Za-z_][A-Za-z0-9_]*$`
+	// +kubebuilder:validation:MaxLength=32
+	// +optional
+	EnvPrefix string `json:"envPrefix,omitempty"`
+	// Projection selects how the credentials reach the app: env (env vars,
+	// the default), files (a servicebinding.io binding directory under
+	// SERVICE_BINDING_ROOT) or both. The binding directory describes the
+	// primary, so files cannot be combined with mode ro.
+	// +kubebuilder:validation:Enum=env;files;both
+	// +optional
+	Projection BindingProjection `json:"projection,omitempty"`
+	// Formats are connection string variants injected next to DATABASE_URL
+	// for frameworks that want another format, such as jdbc for
+	// JDBC_DATABASE_URL. The controller derives them from the connection
+	// Secret into an owned Secret. They describe the primary and are env
+	// vars, so they need mode rw or both and projection env or both.
+	// +listType=set
+	// +optional
+	Formats []ConnectionFormat `json:"formats,omitempty"`
+}
+
+// ConnectionFormat is a framework-specific connection string variant.
+// +kubebuilder:validation:Enum=jdbc;sqlalchemy
+type ConnectionFormat string
+
+const (
+	// ConnectionFormatJDBC injects JDBC_DATABASE_URL,
+	// JDBC_DATABASE_USERNAME and JDBC_DATABASE_PASSWORD.
+	ConnectionFormatJDBC ConnectionFormat = "jdbc"
+	// ConnectionFormatSQLAlchemy injects SQLALCHEMY_DATABASE_URI.
+	ConnectionFormatSQLAlchemy ConnectionFormat = "sqlalchemy"
+)
+
+// BindingProjection selects how a binding projects credentials into an app.
+type BindingProjection string
+
+const (
+	// BindingProjectionEnv injects env vars.
+	BindingProjectionEnv BindingProjection = "env"
+	// BindingProjectionFiles mounts a servicebinding.io binding directory.
+	BindingProjectionFiles BindingProjection = "files"
+	// BindingProjectionBoth does both.
+	BindingProjectionBoth BindingProjection = "both"
+)
+
+// BindingProjectsEnv reports whether b injects env vars.
+func BindingProjectsEnv(b BoundManagedService) bool {
+	return b.Projection != BindingProjectionFiles
+}
+
+// BindingProjectsFiles reports whether b mounts a binding directory.
+func BindingProjectsFiles(b BoundManagedService) bool {
+	return b.Projection == BindingProjectionFiles || b.Projection == BindingProjectionBoth
+}
+
+// BindMode selects which endpoints of a managed service a binding injects.
+type BindMode string
+
+const (
+	// BindModeReadWrite injects the primary's endpoint.
+	BindModeReadWrite BindMode = "rw"
+	// BindModeReadOnly injects the read replicas' endpoint.
+	BindModeReadOnly BindMode = "ro"
+	// BindModeBoth injects both endpoints.
+	BindModeBoth BindMode = "both"
+)
+
+// ConfigFile is a file mounted into the application container. Its content
+// is given inline or read from a ConfigMap in the application namespace.
+type ConfigFile struct {
+	// Path is the absolute path of the file in the container.
+	// +kubebuilder:validation:Pattern=`^/`
+	// +kubebuilder:validation:MaxLength=1024
+	Path string `json:"path"`
+
+	// Content is the file content. Mutually exclusive with ConfigMap.
+	// +optional
+	Content string `json:"content,omitempty"`
+
+	// ConfigMap mounts a key of a ConfigMap instead of inline content.
+	// +optional
+	ConfigMap *ConfigMapKeyRef `json:"configMap,omitempty"`
+}
+
+// ConfigMapKeyRef selects a key of a ConfigMap in the application namespace.
+type ConfigMapKeyRef struct {
+	// Name is the name of the ConfigMap.
+	Name string `json:"name"`
+	// Key is the key whose value becomes the file content.
+	Key string `json:"key"`
 }
 
 // ApplicationPhase represents the current lifecycle phase of an Application.
@@ -123,6 +785,24 @@ const (
 	ApplicationPhaseDeploying ApplicationPhase = "Deploying"
 	ApplicationPhaseRunning   ApplicationPhase = "Running"
 	ApplicationPhaseFailed    ApplicationPhase = "Failed"
+	ApplicationPhaseSuspended ApplicationPhase = "Suspended"
+	ApplicationPhaseSleeping  ApplicationPhase = "Sleeping"
+	ApplicationPhaseDeleted   ApplicationPhase = "Deleted"
+)
+
+const (
+	// IdleAnnotation records idle auto-sleep state. The idler sets it to
+	// IdleSleeping after the idle timeout; a request to the sleeping app sets
+	// it to IdleWaking, and the controller removes it once the app is Running.
+	IdleAnnotation = "iaf.io/idle"
+	IdleSleeping   = "sleeping"
+	IdleWaking     = "waking"
+
+	// DeletedAtAnnotation marks an application deleted by delete_app, with
+	// the RFC 3339 time of the deletion. The controller keeps it scaled to
+	// zero until the platform's retention window has passed, then deletes
+	// it; removing the annotation restores it.
+	DeletedAtAnnotation = "iaf.io/deleted-at"
 )
 
 // ApplicationStatus defines the observed state of an Application.
@@ -139,19 +819,208 @@ type ApplicationStatus struct {
 	// +optional
 	LatestImage string `json:"latestImage,omitempty"`
 
-	// BuildStatus is the kpack build status: Building, Succeeded, or Failed.
+	// ImageDigest is the sha256 digest of the deployed image. Empty when a
+	// pre-built image is deployed by tag because its digest could not be
+	// resolved.
+	// +optional
+	ImageDigest string `json:"imageDigest,omitempty"`
+
+	// Revisions lists the images the application has deployed, newest
+	// first, up to 10.
+	// +optional
+	Revisions []ImageRevision `json:"revisions,omitempty"`
+
+	// Git reports the deployed and latest built commits of an application
+	// built from git.
+	// +optional
+	Git *GitStatus `json:"git,omitempty"`
+
+	// BuildStatus is the kpack build status: Queued, Building, Succeeded,
+	// or Failed. Queued builds wait for a slot under the platform's limits
+	// on concurrent builds.
 	// +optional
 	BuildStatus string `json:"buildStatus,omitempty"`
 
+	// BuildQueuedAt is when the pending build was queued. Only set while
+	// BuildStatus is Queued.
+	// +optional
+	BuildQueuedAt *metav1.Time `json:"buildQueuedAt,omitempty"`
+
+	// BuildQueuePosition is the 1-based position of the pending build in the
+	// platform build queue. Only set while BuildStatus is Queued.
+	// +optional
+	BuildQueuePosition int32 `json:"buildQueuePosition,omitempty"`
+
 	// AvailableReplicas is the number of available pod replicas.
 	// +optional
 	AvailableReplicas int32 `json:"availableReplicas,omitempty"`
 
+	// Rollout reports the progress of the application's latest Deployment
+	// rollout.
+	// +optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+
+	// Scheduling reports the application's pods that no node can take.
+	// Unset while every pod is scheduled.
+	// +optional
+	Scheduling *SchedulingStatus `json:"scheduling,omitempty"`
+
+	// TLS reports the certificate serving the application's hostname. Only
+	// set when the platform issues certificates and TLS is enabled.
+	// +optional
+	TLS *TLSStatus `json:"tls,omitempty"`
+
+	// ExpiresAt is when the application will be deleted. Only set when
+	// spec.ttl is.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// PurgeAt is when a deleted application is removed for good. Only set
+	// while the phase is Deleted.
+	// +optional
+	PurgeAt *metav1.Time `json:"purgeAt,omitempty"`
+
+	// Conformance reports the post-deploy verification of the running
+	// image. Only set when spec.verifyConformance is.
+	// +optional
+	Conformance *ConformanceReport `json:"conformance,omitempty"`
+
 	// Conditions represent the latest available observations of the application's state.
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+// ImageRevision records an image an Application deployed.
+type ImageRevision struct {
+	// Image is the deployed image reference, pinned to Digest when it is set.
+	Image string `json:"image"`
+
+	// Digest is the sha256 digest of the image.
+	// +optional
+	Digest string `json:"digest,omitempty"`
+
+	// DeployedAt is when the controller started deploying the image.
+	DeployedAt metav1.Time `json:"deployedAt"`
+}
+
+// RolloutState is the state of a Deployment rollout.
+// +kubebuilder:validation:Enum=Progressing;Complete;Stalled
+type RolloutState string
+
+const (
+	// RolloutProgressing means new pods are still being rolled out.
+	RolloutProgressing RolloutState = "Progressing"
+	// RolloutComplete means every replica runs the latest pod template.
+	RolloutComplete RolloutState = "Complete"
+	// RolloutStalled means the rollout stopped making progress: its
+	// progress deadline passed or pods could not be created.
+	RolloutStalled RolloutState = "Stalled"
+)
+
+// RolloutStatus summarizes the Deployment rollout of an Application.
+type RolloutStatus struct {
+	// State is Progressing, Complete or Stalled.
+	State RolloutState `json:"state"`
+
+	// Reason is the Deployment condition reason behind State, such as
+	// ProgressDeadlineExceeded or FailedCreate.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message describes the rollout's progress or why it stalled.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// DesiredReplicas is the number of replicas the rollout is heading for.
+	DesiredReplicas int32 `json:"desiredReplicas"`
+
+	// UpdatedReplicas is the number of replicas running the latest pod
+	// template.
+	UpdatedReplicas int32 `json:"updatedReplicas"`
+
+	// ReadyReplicas is the number of replicas passing their readiness checks.
+	ReadyReplicas int32 `json:"readyReplicas"`
+
+	// AvailableReplicas is the number of replicas ready for at least the
+	// Deployment's minReadySeconds.
+	AvailableReplicas int32 `json:"availableReplicas"`
+}
+
+// SchedulingStatus describes the pods of an Application that the scheduler
+// cannot place on any node.
+type SchedulingStatus struct {
+	// UnschedulablePods is the number of pods waiting for a node.
+	UnschedulablePods int32 `json:"unschedulablePods"`
+
+	// Reasons summarize why no node fits, such as "insufficient memory" or
+	// "no nodes match selector".
+	// +optional
+	Reasons []string `json:"reasons,omitempty"`
+
+	// ScalingUp is set when a cluster autoscaler or Karpenter is adding a
+	// node for the pods, so they may still be scheduled without changes.
+	// +optional
+	ScalingUp bool `json:"scalingUp,omitempty"`
+
+	// Message is the scheduler's message for one of the pods.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ConformanceResult is the outcome of a conformance verification or of one
+// of its checks.
+// +kubebuilder:validation:Enum=Pending;Passed;Failed;Skipped
+type ConformanceResult string
+
+const (
+	// ConformancePending means the check waits for the app to produce
+	// output, such as logs or spans, after the deploy.
+	ConformancePending ConformanceResult = "Pending"
+	// ConformancePassed means the app meets the standard.
+	ConformancePassed ConformanceResult = "Passed"
+	// ConformanceFailed means the app does not meet the standard.
+	ConformanceFailed ConformanceResult = "Failed"
+	// ConformanceSkipped means the check does not apply to the app or the
+	// platform cannot run it.
+	ConformanceSkipped ConformanceResult = "Skipped"
+)
+
+// ConformanceReport is the outcome of verifying a deployed image against
+// the organisation standards.
+type ConformanceReport struct {
+	// Image is the image verified. A new image is verified again.
+	Image string `json:"image"`
+
+	// Result is Pending until every check has run, then Failed if any
+	// check failed and Passed otherwise.
+	Result ConformanceResult `json:"result"`
+
+	// StartedAt is when the verification of Image started.
+	StartedAt metav1.Time `json:"startedAt"`
+
+	// CheckedAt is when the checks last ran.
+	CheckedAt metav1.Time `json:"checkedAt"`
+
+	// Checks are the outcomes of the individual checks.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Checks []ConformanceCheck `json:"checks,omitempty"`
+}
+
+// ConformanceCheck is the outcome of one conformance check.
+type ConformanceCheck struct {
+	// Name is the check: health, metrics, logs or traces.
+	Name string `json:"name"`
+
+	// Result is Pending, Passed, Failed or Skipped.
+	Result ConformanceResult `json:"result"`
+
+	// Message explains the result.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
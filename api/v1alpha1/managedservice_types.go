@@ -18,16 +18,18 @@ const (
 	ManagedServicePhaseDeleting ManagedServicePhase = "Deleting"
 )
 
-// ServicePlan represents the resource tier for a managed service.
-type ServicePlan string
+// ServicePlanName names the resource tier of a managed service: a
+// ServicePlan, or one of the built-in plans.
+// +kubebuilder:validation:Pattern=`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`
+type ServicePlanName string
 
 const (
 	// ServicePlanMicro is a minimal single-instance plan for development.
-	ServicePlanMicro ServicePlan = "micro"
+	ServicePlanMicro ServicePlanName = "micro"
 	// ServicePlanSmall is a single-instance plan for light production workloads.
-	ServicePlanSmall ServicePlan = "small"
+	ServicePlanSmall ServicePlanName = "small"
 	// ServicePlanHA is a three-instance high-availability plan.
-	ServicePlanHA ServicePlan = "ha"
+	ServicePlanHA ServicePlanName = "ha"
 )
 
 // ManagedServiceSpec defines the desired state of a ManagedService.
@@ -36,9 +38,64 @@ type ManagedServiceSpec struct {
 	// +kubebuilder:validation:Enum=postgres
 	Type string `json:"type"`
 
-	// Plan is the resource tier: micro, small, or ha.
-	// +kubebuilder:validation:Enum=micro;small;ha
-	Plan ServicePlan `json:"plan"`
+	// Plan is the resource tier: the name of a ServicePlan for the type, or
+	// one of the built-in plans micro, small and ha.
+	Plan ServicePlanName `json:"plan"`
+
+	// TTL deletes the service and its data once it has existed for this
+	// long, measured from its creation. Deletion waits while applications
+	// are still bound to the service.
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+
+	// DeletionProtected refuses deprovisioning the service, by agents and
+	// by kubectl, until it is cleared. A TTL that elapses while it is set
+	// waits for it to be cleared.
+	// +optional
+	DeletionProtected bool `json:"deletionProtected,omitempty"`
+
+	// Version is the PostgreSQL major version, such as "16". The platform
+	// runs the latest minor release of it it offers. Defaults to the
+	// platform's newest version; a major upgrade needs a new service.
+	// +kubebuilder:validation:Pattern=`^[0-9]+$`
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="version cannot be changed; provision a new service for a major upgrade"
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// MaintenanceWindow is when minor version upgrades, which restart the
+	// database, may start. Unset: upgrades start as soon as they are offered.
+	// +optional
+	MaintenanceWindow *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+}
+
+// MaintenanceWindow is a recurring period, in UTC, in which disruptive
+// maintenance of a ManagedService may start.
+type MaintenanceWindow struct {
+	// Day is the weekday the window opens on. Unset: every day.
+	// +kubebuilder:validation:Enum=Monday;Tuesday;Wednesday;Thursday;Friday;Saturday;Sunday
+	// +optional
+	Day string `json:"day,omitempty"`
+
+	// Start is the time of day the window opens, as HH:MM in UTC.
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	Start string `json:"start"`
+
+	// Duration is how long the window stays open.
+	// +kubebuilder:default="1h"
+	// +optional
+	Duration metav1.Duration `json:"duration,omitempty"`
+}
+
+// VersionUpgrade records a version upgrade applied to a ManagedService.
+type VersionUpgrade struct {
+	// From is the version the service ran before the upgrade.
+	From string `json:"from"`
+
+	// To is the version the upgrade moved the service to.
+	To string `json:"to"`
+
+	// StartedAt is when the upgrade was applied to the database cluster.
+	StartedAt metav1.Time `json:"startedAt"`
 }
 
 // ManagedServiceStatus defines the observed state of a ManagedService.
@@ -61,15 +118,96 @@ type ManagedServiceStatus struct {
 	// +optional
 	BoundApps []string `json:"boundApps,omitempty"`
 
+	// Instances is the number of database instances the plan runs. More
+	// than one means the service has read replicas.
+	// +optional
+	Instances int32 `json:"instances,omitempty"`
+
+	// Progress reports how far the database cluster has come, from
+	// CloudNativePG, its volumes and recent events.
+	// +optional
+	Progress *ManagedServiceProgress `json:"progress,omitempty"`
+
+	// ExpiresAt is when the service will be deleted. Only set when spec.ttl is.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// CurrentVersion is the PostgreSQL version the database cluster runs.
+	// +optional
+	CurrentVersion string `json:"currentVersion,omitempty"`
+
+	// TargetVersion is the latest minor release of spec.version the
+	// platform offers. It differs from CurrentVersion while an upgrade
+	// waits for the maintenance window.
+	// +optional
+	TargetVersion string `json:"targetVersion,omitempty"`
+
+	// UpgradeHistory lists the most recent version upgrades, oldest first.
+	// +optional
+	UpgradeHistory []VersionUpgrade `json:"upgradeHistory,omitempty"`
+
 	// Conditions represent the latest available observations of the service's state.
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+// ManagedServiceProgress reports the progress of the database cluster of a
+// ManagedService, so a slow provisioning can be told apart from a stuck one.
+type ManagedServiceProgress struct {
+	// ClusterPhase is the CloudNativePG phase of the cluster, such as
+	// "Setting up primary" or "Cluster in healthy state".
+	// +optional
+	ClusterPhase string `json:"clusterPhase,omitempty"`
+
+	// ClusterPhaseReason explains ClusterPhase.
+	// +optional
+	ClusterPhaseReason string `json:"clusterPhaseReason,omitempty"`
+
+	// ReadyInstances is the number of database instances ready, out of
+	// status.instances.
+	ReadyInstances int32 `json:"readyInstances"`
+
+	// CurrentPrimary is the pod of the primary instance.
+	// +optional
+	CurrentPrimary string `json:"currentPrimary,omitempty"`
+
+	// Volumes is the number of volumes claimed for the instances.
+	Volumes int32 `json:"volumes"`
+
+	// VolumesBound is the number of those volumes provisioned and bound.
+	VolumesBound int32 `json:"volumesBound"`
+
+	// Events are the most recent events on the cluster, its pods, volumes
+	// and jobs, newest last. Only kept while the service is not Ready.
+	// +optional
+	Events []ManagedServiceEvent `json:"events,omitempty"`
+}
+
+// ManagedServiceEvent is a Kubernetes event on a resource of a
+// ManagedService's database cluster.
+type ManagedServiceEvent struct {
+	// Time is when the event last occurred.
+	Time metav1.Time `json:"time"`
+
+	// Type is Normal or Warning.
+	Type string `json:"type"`
+
+	// Reason is the event's reason, such as "FailedScheduling" or "Pulled".
+	Reason string `json:"reason"`
+
+	// Object is the kind and name of the resource, such as "Pod/db-1".
+	Object string `json:"object"`
+
+	// Message is the event's message.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
 // +kubebuilder:printcolumn:name="Plan",type=string,JSONPath=`.spec.plan`
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.status.currentVersion`
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
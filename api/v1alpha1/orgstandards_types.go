@@ -0,0 +1,201 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FrameworkStandard describes a web or application framework.
+type FrameworkStandard struct {
+	Name string `json:"name"`
+	// +optional
+	MinVersion string `json:"minVersion,omitempty"`
+	// +optional
+	Notes string `json:"notes,omitempty"`
+	// Reason explains why a prohibited framework must not be used.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// LibraryStandard describes a library or package.
+type LibraryStandard struct {
+	Name string `json:"name"`
+	// +optional
+	MinVersion string `json:"minVersion,omitempty"`
+	// +optional
+	Notes string `json:"notes,omitempty"`
+	// +optional
+	Category string `json:"category,omitempty"`
+	// Reason explains why a prohibited library must not be used.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// LanguageStandards holds the standards for one language. An omitted list
+// keeps the lower layer's entries; an empty list clears them.
+type LanguageStandards struct {
+	// +optional
+	Notes []string `json:"notes"`
+	// +optional
+	ApprovedFrameworks []FrameworkStandard `json:"approvedFrameworks"`
+	// +optional
+	ProhibitedFrameworks []FrameworkStandard `json:"prohibitedFrameworks"`
+	// +optional
+	ApprovedLibraries []LibraryStandard `json:"approvedLibraries"`
+	// +optional
+	ProhibitedLibraries []LibraryStandard `json:"prohibitedLibraries"`
+	// RuntimeVersions are the runtime versions apps may build with, e.g.
+	// ["20", "22"]; the first is the default.
+	// +optional
+	RuntimeVersions []string `json:"runtimeVersions"`
+}
+
+// OrgStandardsSpec overrides part of the organisation coding standards.
+// Every field is optional: omitted fields keep the value from the platform
+// defaults (or the standards file) and from lower-priority OrgStandards.
+// Lists replace the lower layer's list when present, so an empty list
+// clears it. PerLanguage merges per language.
+type OrgStandardsSpec struct {
+	// Priority orders the merge when several OrgStandards exist: higher
+	// priorities are applied later and win. Ties are broken by name.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// +optional
+	HealthCheckPath string `json:"healthCheckPath,omitempty"`
+
+	// +optional
+	LoggingFormat string `json:"loggingFormat,omitempty"`
+
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	DefaultPort int32 `json:"defaultPort,omitempty"`
+
+	// +optional
+	EnvVarNaming string `json:"envVarNaming,omitempty"`
+
+	// +optional
+	RequiredEnvVars []string `json:"requiredEnvVars"`
+
+	// +optional
+	BestPractices []string `json:"bestPractices"`
+
+	// +optional
+	PerLanguage map[string]LanguageStandards `json:"perLanguage,omitempty"`
+
+	// IdleTimeout is the default idle window (e.g. "2h") after which apps
+	// without spec.idleTimeout are scaled to zero. "0" disables idling.
+	// +optional
+	IdleTimeout string `json:"idleTimeout,omitempty"`
+
+	// PackageMirrors point builds and agents at internal package registries.
+	// Each set mirror replaces the lower layer's.
+	// +optional
+	PackageMirrors *PackageMirrors `json:"packageMirrors,omitempty"`
+
+	// Dependencies are the packages apps may and may not depend on. Each
+	// present list replaces the lower layer's.
+	// +optional
+	Dependencies *DependencyPolicy `json:"dependencies,omitempty"`
+}
+
+// DependencyPolicy blocks dependencies at push_code and in the SBOM of
+// each build.
+type DependencyPolicy struct {
+	// Deny blocks the dependencies matching any rule.
+	// +optional
+	Deny []DependencyRule `json:"deny"`
+	// Allow, when it has rules for an ecosystem, blocks that ecosystem's
+	// dependencies matching none of them.
+	// +optional
+	Allow []DependencyRule `json:"allow"`
+	// Waivers exempt apps from the rules.
+	// +optional
+	Waivers []DependencyWaiver `json:"waivers"`
+}
+
+// DependencyRule matches dependencies of one package ecosystem.
+type DependencyRule struct {
+	// +kubebuilder:validation:Enum=go;npm;pypi;maven;rubygems
+	Ecosystem string `json:"ecosystem"`
+	// Package is the package name, where * matches any characters, e.g.
+	// "event-stream", "@evil/*" or "org.apache.logging.log4j:log4j-core".
+	// +kubebuilder:validation:MinLength=1
+	Package string `json:"package"`
+	// Versions, where * matches any characters, limits the rule to these
+	// versions, e.g. "2.14.*". Empty matches every version.
+	// +optional
+	Versions string `json:"versions,omitempty"`
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// DependencyWaiver exempts a dependency from the rules for some apps.
+type DependencyWaiver struct {
+	// +kubebuilder:validation:Enum=go;npm;pypi;maven;rubygems
+	Ecosystem string `json:"ecosystem"`
+	// Package is the package name, where * matches any characters.
+	// +kubebuilder:validation:MinLength=1
+	Package string `json:"package"`
+	// Namespace and App limit the waiver to matching apps. Empty matches
+	// every namespace or app.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// +optional
+	App string `json:"app,omitempty"`
+	// Reason records why the exception was granted.
+	// +kubebuilder:validation:MinLength=1
+	Reason string `json:"reason"`
+	// Expires is the date (YYYY-MM-DD) after which the waiver no longer
+	// applies. Empty never expires.
+	// +kubebuilder:validation:Pattern=`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`
+	// +optional
+	Expires string `json:"expires,omitempty"`
+}
+
+// PackageMirrors are internal mirror URLs per package ecosystem.
+type PackageMirrors struct {
+	// +kubebuilder:validation:Pattern=`^https?://`
+	// +optional
+	NPM string `json:"npm,omitempty"`
+	// +kubebuilder:validation:Pattern=`^https?://`
+	// +optional
+	PyPI string `json:"pypi,omitempty"`
+	// +kubebuilder:validation:Pattern=`^https?://`
+	// +optional
+	GoProxy string `json:"goproxy,omitempty"`
+	// +kubebuilder:validation:Pattern=`^https?://`
+	// +optional
+	Maven string `json:"maven,omitempty"`
+	// +kubebuilder:validation:Pattern=`^https?://`
+	// +optional
+	RubyGems string `json:"rubygems,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Priority",type=integer,JSONPath=`.spec.priority`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// OrgStandards is the Schema for the orgstandards API. Operators create
+// these to override the coding standards served to agents; every replica
+// of the coach server and the controller watches them.
+type OrgStandards struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec OrgStandardsSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// OrgStandardsList contains a list of OrgStandards.
+type OrgStandardsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OrgStandards `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&OrgStandards{}, &OrgStandardsList{})
+}
@@ -0,0 +1,91 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PolicyOperation is an agent action that platform policies are checked on.
+// +kubebuilder:validation:Enum=deploy;push_code;create_repo
+type PolicyOperation string
+
+const (
+	// PolicyOperationDeploy covers creating or changing an application from
+	// an image or git repository: deploy_app, deploy_stack, create_preview
+	// and the REST create and update endpoints.
+	PolicyOperationDeploy PolicyOperation = "deploy"
+	// PolicyOperationPushCode covers uploading source with push_code or the
+	// REST source endpoint.
+	PolicyOperationPushCode PolicyOperation = "push_code"
+	// PolicyOperationCreateRepo covers setup_github_repo.
+	PolicyOperationCreateRepo PolicyOperation = "create_repo"
+)
+
+// PolicyRule is one CEL check. The expression sees these variables:
+//
+//   - operation: the PolicyOperation being checked
+//   - sessionNamespace: the session namespace
+//   - app: the Application as it would be stored (metadata and spec);
+//     empty for create_repo
+//   - files: the uploaded file paths for push_code; empty otherwise
+//   - repo: {"name": ..., "visibility": ...} for create_repo; empty otherwise
+type PolicyRule struct {
+	// Name identifies the rule in violation reports.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// Expression is a CEL expression that must evaluate to true for the
+	// operation to be allowed, e.g.
+	// `!has(app.spec.image) || app.spec.image.startsWith("registry.corp/")`.
+	// +kubebuilder:validation:MinLength=1
+	Expression string `json:"expression"`
+
+	// Message is returned to the agent when the rule is violated. It should
+	// say how to comply.
+	// +kubebuilder:validation:MinLength=1
+	Message string `json:"message"`
+}
+
+// PlatformPolicySpec defines the rules of a PlatformPolicy.
+type PlatformPolicySpec struct {
+	// Description explains the policy to operators.
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Operations limits the policy to these operations. Empty applies it to
+	// all of them.
+	// +optional
+	Operations []PolicyOperation `json:"operations,omitempty"`
+
+	// Rules are all evaluated; every rule that is not satisfied is reported.
+	// +kubebuilder:validation:MinItems=1
+	Rules []PolicyRule `json:"rules"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Description",type=string,JSONPath=`.spec.description`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PlatformPolicy is the Schema for the platformpolicies API. Operators
+// create these to reject agent operations that break organisational rules;
+// agents can neither create nor change them.
+type PlatformPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PlatformPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PlatformPolicyList contains a list of PlatformPolicy.
+type PlatformPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PlatformPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PlatformPolicy{}, &PlatformPolicyList{})
+}
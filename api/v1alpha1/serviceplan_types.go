@@ -0,0 +1,86 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ServicePlanSpec defines the resources of the managed services on a plan.
+type ServicePlanSpec struct {
+	// Type is the managed service type the plan is for.
+	// +kubebuilder:validation:Enum=postgres
+	Type string `json:"type"`
+
+	// Description says what the plan is for, shown to agents choosing one.
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Instances is the number of database instances. With more than one,
+	// the service has read replicas.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=9
+	Instances int32 `json:"instances"`
+
+	// Storage is the volume size of each instance, such as "5Gi".
+	Storage resource.Quantity `json:"storage"`
+
+	// Resources are the CPU and memory requests and limits of each instance.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Backup backs up the services on the plan. Unset: no backups.
+	// +optional
+	Backup *ServicePlanBackup `json:"backup,omitempty"`
+}
+
+// ServicePlanBackup is the backup policy of a ServicePlan.
+type ServicePlanBackup struct {
+	// Schedule is when backups run, as a six-field cron expression with
+	// seconds first, such as "0 0 2 * * *" for 02:00 every day.
+	Schedule string `json:"schedule"`
+
+	// RetentionPolicy is how long backups are kept, such as "30d".
+	// +kubebuilder:validation:Pattern=`^[1-9][0-9]*[dwm]$`
+	RetentionPolicy string `json:"retentionPolicy"`
+
+	// BarmanObjectStore is the CloudNativePG barmanObjectStore configuration
+	// of the object store backups are written to: its destinationPath and
+	// credentials. Each service writes under its own server name,
+	// <namespace>-<name>, unless serverName is set.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	BarmanObjectStore runtime.RawExtension `json:"barmanObjectStore"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="Instances",type=integer,JSONPath=`.spec.instances`
+// +kubebuilder:printcolumn:name="Storage",type=string,JSONPath=`.spec.storage`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ServicePlan is the Schema for the serviceplans API.
+// ServicePlans are defined by platform operators via kubectl and offered to
+// agents by name in provision_service and the list_service_plans MCP tool.
+// A ServicePlan named micro, small or ha replaces that built-in plan.
+// Agents cannot create or modify ServicePlans.
+type ServicePlan struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ServicePlanSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ServicePlanList contains a list of ServicePlan.
+type ServicePlanList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ServicePlan `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ServicePlan{}, &ServicePlanList{})
+}
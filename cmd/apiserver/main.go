@@ -4,21 +4,29 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/dlapiduz/iaf/internal/api"
+	"github.com/dlapiduz/iaf/internal/audit"
 	"github.com/dlapiduz/iaf/internal/auth"
 	"github.com/dlapiduz/iaf/internal/config"
+	"github.com/dlapiduz/iaf/internal/cost"
+	"github.com/dlapiduz/iaf/internal/grpcapi"
 	iafgithub "github.com/dlapiduz/iaf/internal/github"
 	"github.com/dlapiduz/iaf/internal/k8s"
 	iafmcp "github.com/dlapiduz/iaf/internal/mcp"
+	"github.com/dlapiduz/iaf/internal/orgstandards"
 	"github.com/dlapiduz/iaf/internal/sessiongc"
 	"github.com/dlapiduz/iaf/internal/sourcestore"
+	"github.com/dlapiduz/iaf/internal/uptime"
 	"github.com/labstack/echo/v4"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
 	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func main() {
@@ -30,6 +38,10 @@ func main() {
 		logger.Error("failed to load config", "error", err)
 		os.Exit(1)
 	}
+	if err := cfg.ValidateOffline(); err != nil {
+		logger.Error("invalid offline configuration", "error", err)
+		os.Exit(1)
+	}
 
 	// Create K8s clients
 	k8sClient, err := k8s.NewClient(cfg.KubeConfig)
@@ -55,6 +67,35 @@ func main() {
 		logger.Error("failed to create source store", "error", err)
 		os.Exit(1)
 	}
+	store.SetSigningKey([]byte(cfg.SourceSigningKey))
+	store.SetURLTTL(cfg.SourceURLTTL)
+	maxSource, err := cfg.MaxSourceBytes()
+	if err != nil {
+		logger.Error("invalid source store settings", "error", err)
+		os.Exit(1)
+	}
+	store.SetMaxUploadSize(maxSource)
+	maxUploads, err := cfg.OpenUploadsLimit()
+	if err != nil {
+		logger.Error("invalid source store settings", "error", err)
+		os.Exit(1)
+	}
+	store.SetMaxOpenUploads(maxUploads)
+	maxToolResult, err := cfg.MaxToolResultBytes()
+	if err != nil {
+		logger.Error("invalid MCP settings", "error", err)
+		os.Exit(1)
+	}
+	toolTimeouts, err := cfg.ToolTimeoutMap()
+	if err != nil {
+		logger.Error("invalid MCP settings", "error", err)
+		os.Exit(1)
+	}
+	knownHosts, err := cfg.GitKnownHosts()
+	if err != nil {
+		logger.Error("invalid MCP settings", "error", err)
+		os.Exit(1)
+	}
 
 	// Create session store
 	sessionsPath := filepath.Join(cfg.SourceStoreDir, "sessions.json")
@@ -63,12 +104,67 @@ func main() {
 		logger.Error("failed to create session store", "error", err)
 		os.Exit(1)
 	}
+	history, err := audit.New(filepath.Join(cfg.SourceStoreDir, "history"))
+	if err != nil {
+		logger.Error("failed to create session history", "error", err)
+		os.Exit(1)
+	}
+	securityAudit, err := cfg.SecurityAudit("apiserver", filepath.Join(cfg.SourceStoreDir, "security-audit"), logger)
+	if err != nil {
+		logger.Error("failed to create security audit", "error", err)
+		os.Exit(1)
+	}
+	defer securityAudit.Close()
+
+	// Uptime and cost reporting read the platform Prometheus (optional).
+	var uptimeQuerier uptime.Querier
+	var costs *cost.Estimator
+	if cfg.PrometheusURL != "" {
+		if uptimeQuerier, err = uptime.NewPrometheus(cfg.PrometheusURL); err != nil {
+			logger.Error("failed to set up uptime reporting", "error", err)
+			os.Exit(1)
+		}
+		usage, err := cost.NewPrometheus(cfg.PrometheusURL)
+		if err != nil {
+			logger.Error("failed to set up cost reporting", "error", err)
+			os.Exit(1)
+		}
+		costs = &cost.Estimator{Usage: usage, Rates: cfg.CostRates()}
+	}
+
+	// Checks of the systems the platform depends on, for GET
+	// /platform/health and the iaf://platform resource.
+	platformHealth := cfg.PlatformHealth(k8sClient, store)
+
+	// In maintenance only read-only calls are served.
+	maint, err := cfg.MaintenanceMode()
+	if err != nil {
+		logger.Error("invalid maintenance settings", "error", err)
+		os.Exit(1)
+	}
+	if maint.Active() {
+		logger.Warn("platform is in maintenance; refusing changes", "until", cfg.MaintenanceUntil)
+	}
 
 	// Create and configure Echo server
-	e := api.NewServer(cfg.APITokens, logger)
+	tokens := append(slices.Clone(cfg.APITokens), cfg.AdminTokens...)
+	e := api.NewServer(tokens, maint, logger)
 
 	// Register REST API routes
-	api.RegisterRoutes(e, k8sClient, clientset, sessions, store)
+	if err := api.RegisterRoutes(e, k8sClient, clientset, sessions, store, api.RouteOptions{
+		Grafana:       cfg.Grafana(),
+		CustomDNS:     cfg.AllowCustomDNS,
+		SessionTTL:    cfg.SessionTTL,
+		WebhookSecret: cfg.GitHubWebhookSecret,
+		WakeSecret:    cfg.WakeSecret,
+		AdminTokens:   cfg.AdminTokens,
+		Costs:         costs,
+		Platform:      platformHealth,
+		Logger:        logger,
+	}); err != nil {
+		logger.Error("failed to register routes", "error", err)
+		os.Exit(1)
+	}
 
 	// Mount source store file server
 	e.GET("/sources/*", echo.WrapHandler(http.StripPrefix("/sources/", store.Handler())))
@@ -76,21 +172,110 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Apps pushed before blob URLs were signed get a signed URL for their
+	// current source, which the source handler above now requires.
+	go func() {
+		n, err := k8s.ResignLegacyBlobs(ctx, k8sClient, store, logger)
+		if err != nil {
+			logger.Error("failed to sign legacy blob URLs", "error", err)
+		} else if n > 0 {
+			logger.Info("signed legacy blob URLs", "applications", n)
+		}
+	}()
+
 	// Start session GC if TTL and GC interval are configured.
 	if cfg.SessionTTL > 0 && cfg.SessionGCInterval > 0 {
-		cleaner := sessiongc.New(k8sClient, store, sessions, logger)
+		cleaner := sessiongc.New(k8sClient, store, sessions, history, logger)
 		go cleaner.Start(ctx, cfg.SessionGCInterval)
 		logger.Info("session GC started", "ttl", cfg.SessionTTL, "interval", cfg.SessionGCInterval)
 	}
 
+	// Give new sessions the operator's shared git credentials.
+	sessions.SetSharedGitCredentials(cfg.SharedGitCredentialsNamespace)
+
+	// Keep warm session namespaces ready if a pool is configured.
+	if cfg.NamespacePoolSize > 0 {
+		pool := auth.NewNamespacePool(k8sClient, cfg.NamespacePoolSize, logger)
+		sessions.SetPool(pool)
+		go pool.Start(ctx)
+		logger.Info("namespace pool started", "size", cfg.NamespacePoolSize)
+	}
+
 	// Create GitHub client if configured.
 	var ghClient iafgithub.Client
-	if cfg.GitHubToken != "" && cfg.GitHubOrg != "" {
-		ghClient = iafgithub.NewHTTPClient(cfg.GitHubToken)
+	if cfg.GitHubEnabled() {
+		client, err := cfg.GitHubClient()
+		if err != nil {
+			logger.Error("invalid GitHub settings", "error", err)
+			os.Exit(1)
+		}
+		ghClient = client
+	} else if cfg.Offline {
+		logger.Info("GitHub tools disabled in offline mode")
+	}
+	ghTemplates, err := iafgithub.LoadRepoTemplates(cfg.GitHubTemplatesDir)
+	if err != nil {
+		logger.Error("invalid github templates", "error", err)
+		os.Exit(1)
+	}
+
+	alertRuleLabels, err := cfg.AlertRuleLabelMap()
+	if err != nil {
+		logger.Error("invalid alert rule labels", "error", err)
+		os.Exit(1)
+	}
+	architectureBuilders, err := cfg.ArchitectureBuilders()
+	if err != nil {
+		logger.Error("invalid architectures", "error", err)
+		os.Exit(1)
+	}
+	workloadClasses, err := cfg.WorkloadClasses()
+	if err != nil {
+		logger.Error("invalid workload classes", "error", err)
+		os.Exit(1)
+	}
+
+	// Org standards for standards_check: the file, merged with the
+	// OrgStandards resources the controller also watches.
+	standards := orgstandards.New(cfg.OrgStandardsFile, logger)
+	go standards.Start(ctx)
+	if watchClient, ok := k8sClient.(client.WithWatch); ok {
+		go standards.WatchCluster(ctx, watchClient)
 	}
 
 	// Create MCP server and mount as Streamable HTTP endpoint
-	mcpServer := iafmcp.NewServer(k8sClient, sessions, store, cfg.BaseDomain, ghClient, cfg.GitHubOrg, cfg.GitHubToken, cfg.TempoURL, cfg.SessionTTL, clientset)
+	mcpServer := iafmcp.NewServer(k8sClient, sessions, store, iafmcp.Options{
+		BaseDomain:      cfg.BaseDomain,
+		GitHub:          ghClient,
+		GitHubOrg:       cfg.GitHubOrg,
+		GitHubToken:     cfg.GitHubToken,
+		GitHubTemplates: ghTemplates,
+		Grafana:         cfg.Grafana(),
+		AlertRuleLabels: alertRuleLabels,
+		Uptime:          uptimeQuerier,
+		Costs:           costs,
+		Builders:        cfg.Builders(),
+		Architectures:   slices.Sorted(maps.Keys(architectureBuilders)),
+		WorkloadClasses: slices.Sorted(maps.Keys(workloadClasses)),
+		CustomDNS:       cfg.AllowCustomDNS,
+		Offline:         cfg.Offline,
+		Proxy:           cfg.HTTPProxy != "" || cfg.HTTPSProxy != "",
+		KubeAPIServer:   cfg.KubeAPIServer,
+		SessionTTL:      cfg.SessionTTL,
+		OrgStandards:    standards,
+		Features:        cfg.Features(),
+		EnabledTools:    cfg.EnabledTools,
+		DisabledTools:   cfg.DisabledTools,
+		MaxResultBytes:  maxToolResult,
+		History:         history,
+		SecurityAudit:   securityAudit,
+		Health:          platformHealth,
+		Maintenance:     maint,
+		ToolTimeout:     cfg.ToolTimeout,
+		ToolTimeouts:    toolTimeouts,
+		KnownHosts:      knownHosts,
+		Clientset:       clientset,
+	})
 
 	// If a coach URL is configured, enumerate coach prompts/resources and register
 	// forwarding closures on the platform server so agents see them transparently.
@@ -106,6 +291,39 @@ func main() {
 	}, &gomcp.StreamableHTTPOptions{Stateless: true})
 	e.Any("/mcp", echo.WrapHandler(mcpHandler))
 
+	// Serve the gRPC API and its JSON gateway on a second port. The
+	// gateway calls the gRPC server over loopback so both share auth.
+	if cfg.GRPCPort > 0 {
+		grpcServer := grpcapi.NewServer(k8sClient, sessions, store, grpcapi.Options{
+			Tokens:      tokens,
+			SessionTTL:  cfg.SessionTTL,
+			Grafana:     cfg.Grafana(),
+			CustomDNS:   cfg.AllowCustomDNS,
+			Maintenance: maint,
+			Logger:      logger,
+		})
+		gateway, err := grpcapi.NewGateway(ctx, fmt.Sprintf("localhost:%d", cfg.GRPCPort))
+		if err != nil {
+			logger.Error("failed to create gRPC gateway", "error", err)
+			os.Exit(1)
+		}
+		var protocols http.Protocols
+		protocols.SetHTTP1(true)
+		protocols.SetUnencryptedHTTP2(true)
+		grpcHTTP := &http.Server{
+			Addr:      fmt.Sprintf(":%d", cfg.GRPCPort),
+			Handler:   grpcapi.Handler(grpcServer, gateway),
+			Protocols: &protocols,
+		}
+		go func() {
+			logger.Info("starting gRPC server", "addr", grpcHTTP.Addr)
+			if err := grpcHTTP.ListenAndServe(); err != nil {
+				logger.Error("gRPC server exited with error", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
 	addr := fmt.Sprintf(":%d", cfg.APIPort)
 	logger.Info("starting API server", "addr", addr, "mcp", fmt.Sprintf("http://localhost%s/mcp", addr))
 	if err := e.Start(addr); err != nil {
@@ -9,9 +9,11 @@ import (
 	"os"
 	"strings"
 
+	"github.com/dlapiduz/iaf/internal/k8s"
 	"github.com/dlapiduz/iaf/internal/mcp/coach"
 	"github.com/dlapiduz/iaf/internal/orgstandards"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func main() {
@@ -47,6 +49,21 @@ func main() {
 	defer cancel()
 
 	go orgLoader.Start(ctx)
+	// Replicas running in-cluster share operator overrides through
+	// OrgStandards resources instead of a file on each pod.
+	if os.Getenv("COACH_WATCH_ORG_STANDARDS") == "true" {
+		restConfig, err := k8s.GetConfig("")
+		if err != nil {
+			logger.Error("failed to get kubernetes config for org standards", "error", err)
+			os.Exit(1)
+		}
+		watchClient, err := client.NewWithWatch(restConfig, client.Options{Scheme: k8s.NewScheme()})
+		if err != nil {
+			logger.Error("failed to create org standards client", "error", err)
+			os.Exit(1)
+		}
+		go orgLoader.WatchCluster(ctx, watchClient)
+	}
 	deps.OrgStandards = orgLoader
 
 	server := coach.NewServer(deps)
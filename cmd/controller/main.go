@@ -1,20 +1,60 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
+	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
 	"github.com/dlapiduz/iaf/internal/config"
+	"github.com/dlapiduz/iaf/internal/conformance"
 	"github.com/dlapiduz/iaf/internal/controller"
+	"github.com/dlapiduz/iaf/internal/idle"
 	"github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+	"github.com/dlapiduz/iaf/internal/platformhealth"
+	"github.com/dlapiduz/iaf/internal/policy"
+	"github.com/dlapiduz/iaf/internal/registry"
+	"github.com/dlapiduz/iaf/internal/sbom"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
+// checkOfflineBuilders fails when a ClusterBuilder apps may build with is
+// published to a public registry. Missing builders only warn: builds using
+// them fail with their own error.
+func checkOfflineBuilders(ctx context.Context, c client.Reader, builders []string, logger *slog.Logger) error {
+	for _, name := range builders {
+		if name == "" {
+			continue
+		}
+		image, err := k8s.ClusterBuilderImage(ctx, c, name)
+		if err != nil {
+			logger.Warn("cannot check ClusterBuilder for offline mode", "builder", name, "error", err)
+			continue
+		}
+		if registry.IsPublic(image) {
+			return fmt.Errorf("ClusterBuilder %q is published to public registry image %q; offline mode needs an internal registry", name, image)
+		}
+	}
+	return nil
+}
+
 func main() {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	slog.SetDefault(logger)
@@ -25,6 +65,10 @@ func main() {
 		logger.Error("failed to load config", "error", err)
 		os.Exit(1)
 	}
+	if err := cfg.ValidateOffline(); err != nil {
+		logger.Error("invalid offline configuration", "error", err)
+		os.Exit(1)
+	}
 
 	scheme := runtime.NewScheme()
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
@@ -36,21 +80,164 @@ func main() {
 		os.Exit(1)
 	}
 
+	shardSelector, err := cfg.Shard()
+	if err != nil {
+		logger.Error("invalid shard configuration", "error", err)
+		os.Exit(1)
+	}
+
+	wildcardTLS, err := cfg.WildcardTLS()
+	if err != nil {
+		logger.Error("invalid TLS configuration", "error", err)
+		os.Exit(1)
+	}
+
+	caBundle, err := cfg.CABundle()
+	if err != nil {
+		logger.Error("invalid CA bundle configuration", "error", err)
+		os.Exit(1)
+	}
+
+	shard := controller.Shard{Selector: shardSelector}
+	// Every shard reads the CA bundle and the wildcard certificate.
+	for _, ns := range []string{caBundle.Namespace, wildcardTLS.Namespace} {
+		if ns != "" && !slices.Contains(shard.Namespaces, ns) {
+			shard.Namespaces = append(shard.Namespaces, ns)
+		}
+	}
+	if shardSelector != nil {
+		logger.Info("reconciling one shard of the session namespaces", "selector", shardSelector.String(), "shard", cfg.ShardName)
+	}
+
+	// The policy admission webhook needs a serving certificate. Without one
+	// the API server and MCP tools remain the only check of platform
+	// policies.
+	webhookEnabled := false
+	if cfg.WebhookCertDir != "" {
+		if _, err := os.Stat(filepath.Join(cfg.WebhookCertDir, "tls.crt")); err == nil {
+			webhookEnabled = true
+		} else {
+			logger.Warn("platform policy admission webhook disabled: no serving certificate", "dir", cfg.WebhookCertDir, "error", err)
+		}
+	}
+
 	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
-		Scheme: scheme,
+		Scheme:                 scheme,
+		Cache:                  shard.CacheOptions(),
+		NewCache:               shard.NewCache,
+		Metrics:                metricsserver.Options{BindAddress: cfg.MetricsBindAddress},
+		HealthProbeBindAddress: cfg.HealthProbeBindAddress,
+		LeaderElection:         cfg.LeaderElect,
+		LeaderElectionID:       platformhealth.ShardLeaseName(cfg.ShardName),
+		WebhookServer:          webhook.NewServer(webhook.Options{Port: cfg.WebhookPort, CertDir: cfg.WebhookCertDir}),
 	})
 	if err != nil {
 		logger.Error("failed to create manager", "error", err)
 		os.Exit(1)
 	}
 
+	suspendedPage, err := k8s.ParseServiceRef(cfg.SuspendedPageService)
+	if err != nil {
+		logger.Error("invalid IAF_SUSPENDED_PAGE_SERVICE", "error", err)
+		os.Exit(1)
+	}
+
+	probeLabels, err := cfg.ProbeLabelMap()
+	if err != nil {
+		logger.Error("invalid probe labels", "error", err)
+		os.Exit(1)
+	}
+
+	buildCache, err := cfg.BuildCache()
+	if err != nil {
+		logger.Error("invalid build cache settings", "error", err)
+		os.Exit(1)
+	}
+
+	architectureBuilders, err := cfg.ArchitectureBuilders()
+	if err != nil {
+		logger.Error("invalid architectures", "error", err)
+		os.Exit(1)
+	}
+
+	if cfg.Offline {
+		// The manager's cache is not started yet, so read through the API.
+		builders := append(cfg.Builders(), slices.Collect(maps.Values(architectureBuilders))...)
+		if err := checkOfflineBuilders(context.Background(), mgr.GetAPIReader(), builders, logger); err != nil {
+			logger.Error("invalid offline configuration", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	proxy, err := cfg.Proxy()
+	if err != nil {
+		logger.Error("invalid proxy configuration", "error", err)
+		os.Exit(1)
+	}
+
+	placement, err := cfg.Placement()
+	if err != nil {
+		logger.Error("invalid node placement", "error", err)
+		os.Exit(1)
+	}
+	workloadClasses, err := cfg.WorkloadClasses()
+	if err != nil {
+		logger.Error("invalid workload classes", "error", err)
+		os.Exit(1)
+	}
+
+	// The reconciler pins build runtimes to the org standards, which the
+	// leader-only runnable below keeps up to date.
+	standards := orgstandards.New(cfg.OrgStandardsFile, logger)
+	securityAudit, err := cfg.SecurityAudit("controller", "", logger)
+	if err != nil {
+		logger.Error("failed to create security audit", "error", err)
+		os.Exit(1)
+	}
+	defer securityAudit.Close()
 	reconciler := &controller.ApplicationReconciler{
-		Client:         mgr.GetClient(),
-		Scheme:         mgr.GetScheme(),
-		ClusterBuilder: cfg.ClusterBuilder,
-		RegistryPrefix: cfg.RegistryPrefix,
-		BaseDomain:     cfg.BaseDomain,
-		TLSIssuer:      cfg.TLSIssuer,
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		ClusterBuilder:   cfg.ClusterBuilder,
+		Builders:         cfg.Builders(),
+		RegistryPrefix:   cfg.RegistryPrefix,
+		BuildCache:       buildCache,
+		BaseDomain:       cfg.BaseDomain,
+		TLSIssuer:        cfg.TLSIssuer,
+		WildcardTLS:      wildcardTLS,
+		BackendTLSIssuer: cfg.BackendTLSIssuer,
+
+		OAuthProxyImage:  cfg.OAuthProxyImage,
+		OIDCIssuerURL:    cfg.OIDCIssuerURL,
+		OIDCClientID:     cfg.OIDCClientID,
+		OIDCClientSecret: cfg.OIDCClientSecret,
+
+		RequeueBase: cfg.RequeueBaseInterval,
+		RequeueMax:  cfg.RequeueMaxInterval,
+
+		SuspendedPage:    suspendedPage,
+		WakeSecret:       []byte(cfg.WakeSecret),
+		DeletedRetention: cfg.DeletedAppRetention,
+
+		BlackboxExporter: cfg.BlackboxExporter,
+		BlackboxModule:   cfg.BlackboxModule,
+		ProbeLabels:      probeLabels,
+
+		MaxBuilds:             cfg.MaxConcurrentBuilds,
+		MaxBuildsPerNamespace: cfg.MaxConcurrentBuildsPerNamespace,
+		ImageResolver:         cfg.ImageResolver(),
+		Proxy:                 proxy,
+		CABundle:              caBundle,
+		APIReader:             mgr.GetAPIReader(),
+		Standards:             standards.Get,
+		SBOM:                  sbom.Registry{Client: cfg.RegistryClient()},
+
+		ArchitectureBuilders: architectureBuilders,
+		Placement:            placement,
+		WorkloadClasses:      workloadClasses,
+
+		Shard:         shard,
+		SecurityAudit: securityAudit,
 	}
 
 	if err := reconciler.SetupWithManager(mgr); err != nil {
@@ -58,16 +245,115 @@ func main() {
 		os.Exit(1)
 	}
 
+	postgres, err := cfg.Postgres()
+	if err != nil {
+		logger.Error("invalid postgres configuration", "error", err)
+		os.Exit(1)
+	}
 	msReconciler := &controller.ManagedServiceReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Postgres:  postgres,
+		APIReader: mgr.GetAPIReader(),
+		Shard:     shard,
 	}
 	if err := msReconciler.SetupWithManager(mgr); err != nil {
 		logger.Error("failed to setup managed service controller", "error", err)
 		os.Exit(1)
 	}
 
-	logger.Info("starting controller manager")
+	// The webhook runs on every replica, leader or not, so each can answer
+	// the API server.
+	if webhookEnabled {
+		mgr.GetWebhookServer().Register(policy.AdmissionPath, &webhook.Admission{Handler: &policy.Admission{
+			Engine:  policy.New(mgr.GetClient()),
+			Decoder: admission.NewDecoder(mgr.GetScheme()),
+		}})
+		if err := mgr.AddReadyzCheck("webhook", mgr.GetWebhookServer().StartedChecker()); err != nil {
+			logger.Error("failed to set up webhook ready check", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// The manager's cached client cannot watch; OrgStandards changes are
+	// rare, so a direct watch is enough.
+	watchClient, err := client.NewWithWatch(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme()})
+	if err != nil {
+		logger.Error("failed to create org standards client", "error", err)
+		os.Exit(1)
+	}
+	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		logger.Error("failed to create kubernetes clientset", "error", err)
+		os.Exit(1)
+	}
+	var spans conformance.SpanFinder
+	if cfg.TempoQueryURL != "" {
+		spans = conformance.NewTempo(cfg.TempoQueryURL)
+	} else {
+		logger.Info("conformance trace checks disabled: set IAF_TEMPO_QUERY_URL to enable")
+	}
+	// A sharded manager caches only its own namespaces, so the platform-wide
+	// verifier and idler read every shard through the API instead.
+	var platformClient client.Client = mgr.GetClient()
+	if shardSelector != nil {
+		platformClient = watchClient
+	}
+	verifier := conformance.New(platformClient, conformance.PodLogs{Clientset: clientset}, spans, standards.Get, logger)
+	verifier.APIReader = mgr.GetAPIReader()
+	// Runnables added to the manager run only on the elected leader. The
+	// idler reads the standards this one keeps up to date. Named shards keep
+	// the standards for their own builds but leave the platform-wide
+	// verifier, idler and shared git credential sync to the unnamed
+	// deployment.
+	platformWide := cfg.ShardName == ""
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		go standards.Start(ctx)
+		go standards.WatchCluster(ctx, watchClient)
+		if !platformWide {
+			<-ctx.Done()
+			return nil
+		}
+		if cfg.SharedGitCredentialsNamespace != "" {
+			go k8s.WatchSharedGitCredentials(ctx, watchClient, cfg.SharedGitCredentialsNamespace, logger)
+		}
+		return verifier.Start(ctx, cfg.ConformanceCheckInterval)
+	})); err != nil {
+		logger.Error("failed to add conformance verifier", "error", err)
+		os.Exit(1)
+	}
+
+	if !platformWide {
+		logger.Info("idle auto-sleep and conformance verification run in the controller without IAF_SHARD_NAME")
+	} else if cfg.PrometheusURL != "" && cfg.WakeSecret != "" && !suspendedPage.IsZero() {
+		requests, err := idle.NewPrometheusCounter(cfg.PrometheusURL)
+		if err != nil {
+			logger.Error("failed to set up idle auto-sleep", "error", err)
+			os.Exit(1)
+		}
+		idler := idle.New(platformClient, requests, func() time.Duration {
+			return standards.Get().IdleTimeoutDuration()
+		}, logger)
+		if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			return idler.Start(ctx, cfg.IdleCheckInterval)
+		})); err != nil {
+			logger.Error("failed to add idler", "error", err)
+			os.Exit(1)
+		}
+	} else {
+		logger.Info("idle auto-sleep disabled: set IAF_PROMETHEUS_URL, IAF_WAKE_SECRET and IAF_SUSPENDED_PAGE_SERVICE to enable")
+	}
+
+	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+		logger.Error("failed to set up health check", "error", err)
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+		logger.Error("failed to set up ready check", "error", err)
+		os.Exit(1)
+	}
+
+	logger.Info("starting controller manager", "metrics", cfg.MetricsBindAddress, "leaderElection", cfg.LeaderElect, "policyWebhook", webhookEnabled)
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		logger.Error("controller manager exited with error", "error", err)
 		os.Exit(1)
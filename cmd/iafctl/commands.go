@@ -0,0 +1,430 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dlapiduz/iaf/pkg/client"
+)
+
+func newFlagSet(name string, stdout io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	return fs
+}
+
+// --- login / register ---
+
+func runLogin(args []string, stdout io.Writer) error {
+	fs := newFlagSet("login", stdout)
+	server := fs.String("server", "", "IAF API server URL (e.g. https://iaf.example.com)")
+	token := fs.String("token", "", "API token")
+	name := fs.String("name", "", "optional friendly name for the new session")
+	session := fs.String("session", "", "reuse an existing session ID instead of registering a new one")
+	format := outputFlag(fs)
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if *server != "" {
+		cfg.Server = *server
+	}
+	if *token != "" {
+		cfg.Token = *token
+	}
+	if *session != "" {
+		cfg.SessionID = *session
+		cfg.Namespace = ""
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+		return render(stdout, *format, cfg.public(), func(tw *tabwriter.Writer) {
+			row(tw, "Logged in to", cfg.Server, "with session", cfg.SessionID)
+		})
+	}
+	return registerSession(cfg, *name, *format, stdout)
+}
+
+func runRegister(args []string, stdout io.Writer) error {
+	fs := newFlagSet("register", stdout)
+	name := fs.String("name", "", "optional friendly name for the new session")
+	format := outputFlag(fs)
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	return registerSession(cfg, *name, *format, stdout)
+}
+
+// registerSession creates a new session on the server and saves it as the
+// current session.
+func registerSession(cfg *cliConfig, name, format string, stdout io.Writer) error {
+	c, err := newAPIClient(cfg)
+	if err != nil {
+		return err
+	}
+	sess, err := c.RegisterSession(context.Background(), name)
+	if err != nil {
+		return fmt.Errorf("registering session: %w", err)
+	}
+	cfg.SessionID = sess.SessionID
+	cfg.Namespace = sess.Namespace
+	if err := saveConfig(cfg); err != nil {
+		return err
+	}
+	return render(stdout, format, sess, func(tw *tabwriter.Writer) {
+		row(tw, "SESSION", "NAMESPACE")
+		row(tw, sess.SessionID, sess.Namespace)
+	})
+}
+
+// public returns the config without the API token, for display.
+func (c *cliConfig) public() map[string]string {
+	return map[string]string{
+		"server":    c.Server,
+		"sessionId": c.SessionID,
+	}
+}
+
+// newAPIClient returns an API client for the configured server.
+func newAPIClient(cfg *cliConfig) (*client.Client, error) {
+	if cfg.Server == "" {
+		return nil, fmt.Errorf("no server configured: run 'iafctl login --server URL --token TOKEN' or set IAF_URL")
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("no API token configured: run 'iafctl login' or set IAF_TOKEN")
+	}
+	return client.New(cfg.Server, cfg.Token), nil
+}
+
+// sessionClient returns an API client for commands that act on the session.
+func sessionClient() (*client.Client, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	c, err := newAPIClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SessionID == "" {
+		return nil, fmt.Errorf("no session: run 'iafctl login' or 'iafctl register', or set IAF_SESSION")
+	}
+	return c.ForSession(cfg.SessionID), nil
+}
+
+// --- apps ---
+
+func runApps(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: iafctl apps list|status|deploy|logs|delete")
+	}
+	switch args[0] {
+	case "list", "ls":
+		return runAppsList(args[1:], stdout)
+	case "status", "get":
+		return runAppsStatus(args[1:], stdout)
+	case "deploy":
+		return runAppsDeploy(args[1:], stdout)
+	case "logs":
+		return runAppsLogs(args[1:], stdout)
+	case "delete", "rm":
+		return runAppsDelete(args[1:], stdout)
+	default:
+		return fmt.Errorf("unknown apps command %q", args[0])
+	}
+}
+
+// singleName validates that exactly one application name was given.
+func singleName(cmd string, positional []string) (string, error) {
+	if len(positional) != 1 {
+		return "", fmt.Errorf("usage: iafctl apps %s NAME", cmd)
+	}
+	return positional[0], nil
+}
+
+func runAppsList(args []string, stdout io.Writer) error {
+	fs := newFlagSet("apps list", stdout)
+	format := outputFlag(fs)
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	c, err := sessionClient()
+	if err != nil {
+		return err
+	}
+	apps, err := c.ListApplications(context.Background())
+	if err != nil {
+		return err
+	}
+	return render(stdout, *format, apps, func(tw *tabwriter.Writer) {
+		row(tw, "NAME", "PHASE", "READY", "URL")
+		for _, app := range apps {
+			row(tw, app.Name, orDash(app.Phase), fmt.Sprintf("%d/%d", app.AvailableReplicas, app.Replicas), orDash(app.URL))
+		}
+	})
+}
+
+func runAppsStatus(args []string, stdout io.Writer) error {
+	fs := newFlagSet("apps status", stdout)
+	format := outputFlag(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	name, err := singleName("status", positional)
+	if err != nil {
+		return err
+	}
+	c, err := sessionClient()
+	if err != nil {
+		return err
+	}
+	app, err := c.GetApplication(context.Background(), name)
+	if err != nil {
+		return err
+	}
+	return render(stdout, *format, app, func(tw *tabwriter.Writer) {
+		source := app.Image
+		if app.GitURL != "" {
+			source = app.GitURL + "@" + app.GitRevision
+		} else if app.Blob != "" {
+			source = "uploaded source"
+		}
+		row(tw, "Name:", app.Name)
+		row(tw, "Phase:", orDash(app.Phase))
+		row(tw, "URL:", orDash(app.URL))
+		row(tw, "Source:", orDash(source))
+		row(tw, "Replicas:", fmt.Sprintf("%d/%d available", app.AvailableReplicas, app.Replicas))
+		row(tw, "Protocol:", app.Protocol)
+		row(tw, "Authentication:", app.Authentication)
+		if app.BuildStatus != "" {
+			row(tw, "Build:", app.BuildStatus)
+		}
+		if app.LatestImage != "" {
+			row(tw, "Image:", app.LatestImage)
+		}
+		if app.LogExploreURL != "" {
+			row(tw, "Logs:", app.LogExploreURL)
+		}
+		if app.TraceExploreURL != "" {
+			row(tw, "Traces:", app.TraceExploreURL)
+		}
+		if app.MetricsDashboardURL != "" {
+			row(tw, "Dashboard:", app.MetricsDashboardURL)
+		}
+		for _, cond := range app.Conditions {
+			row(tw, "Condition:", fmt.Sprintf("%s=%s (%s) %s", cond.Type, cond.Status, cond.Reason, cond.Message))
+		}
+	})
+}
+
+func runAppsDeploy(args []string, stdout io.Writer) error {
+	fs := newFlagSet("apps deploy", stdout)
+	image := fs.String("image", "", "container image to deploy")
+	gitURL := fs.String("git-url", "", "git repository to build from")
+	gitRevision := fs.String("git-revision", "", "git branch, tag, or commit (default: main)")
+	port := fs.Int("port", 0, "port the app listens on (default: 8080)")
+	replicas := fs.Int("replicas", 0, "number of replicas (default: 1)")
+	var env stringList
+	fs.Var(&env, "env", "environment variable as KEY=VALUE (repeatable)")
+	format := outputFlag(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	name, err := singleName("deploy", positional)
+	if err != nil {
+		return err
+	}
+	if (*image == "") == (*gitURL == "") {
+		return fmt.Errorf("provide exactly one of --image or --git-url")
+	}
+
+	req := client.ApplicationRequest{
+		Name:     name,
+		Image:    *image,
+		GitURL:   *gitURL,
+		Port:     int32(*port),
+		Replicas: int32(*replicas),
+	}
+	if *gitURL != "" {
+		req.GitRevision = *gitRevision
+		if req.GitRevision == "" {
+			req.GitRevision = "main"
+		}
+	}
+	for _, kv := range env {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("invalid --env %q: expected KEY=VALUE", kv)
+		}
+		req.Env = append(req.Env, client.EnvVar{Name: k, Value: v})
+	}
+
+	c, err := sessionClient()
+	if err != nil {
+		return err
+	}
+
+	// Deploy is create-or-update so CI pipelines can run it on every commit.
+	app, created, err := c.ApplyApplication(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	action := "updated"
+	if created {
+		action = "created"
+	}
+	return render(stdout, *format, app, func(tw *tabwriter.Writer) {
+		row(tw, fmt.Sprintf("Application %q %s.", app.Name, action))
+		row(tw, "Run 'iafctl apps status "+app.Name+"' to follow the rollout.")
+	})
+}
+
+func runAppsLogs(args []string, stdout io.Writer) error {
+	fs := newFlagSet("apps logs", stdout)
+	lines := fs.Int("lines", 100, "number of log lines to fetch")
+	pod := fs.String("pod", "", "fetch logs from a specific pod")
+	build := fs.Bool("build", false, "show build logs instead of runtime logs")
+	follow := fs.Bool("follow", false, "keep streaming new log lines until interrupted")
+	fs.BoolVar(follow, "f", false, "shorthand for --follow")
+	format := outputFlag(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	name, err := singleName("logs", positional)
+	if err != nil {
+		return err
+	}
+	c, err := sessionClient()
+	if err != nil {
+		return err
+	}
+	opts := client.LogOptions{Lines: *lines, Pod: *pod}
+
+	switch {
+	case *build:
+		logs, err := c.GetBuildLogs(context.Background(), name)
+		if err != nil {
+			return err
+		}
+		if *format == "json" {
+			return render(stdout, *format, logs, nil)
+		}
+		_, err = io.WriteString(stdout, logs.BuildLogs)
+		return err
+	case *follow:
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		return c.FollowLogs(ctx, name, opts, 0, func(line client.LogLine) error {
+			if *format == "json" {
+				return render(stdout, *format, line, nil)
+			}
+			_, err := fmt.Fprintln(stdout, line.Message)
+			return err
+		})
+	default:
+		logs, err := c.GetLogs(context.Background(), name, opts)
+		if err != nil {
+			return err
+		}
+		if *format == "json" {
+			return render(stdout, *format, logs, nil)
+		}
+		_, err = io.WriteString(stdout, logs.Logs)
+		return err
+	}
+}
+
+func runAppsDelete(args []string, stdout io.Writer) error {
+	fs := newFlagSet("apps delete", stdout)
+	format := outputFlag(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	name, err := singleName("delete", positional)
+	if err != nil {
+		return err
+	}
+	c, err := sessionClient()
+	if err != nil {
+		return err
+	}
+	if err := c.DeleteApplication(context.Background(), name); err != nil {
+		return err
+	}
+	return render(stdout, *format, map[string]string{"name": name, "status": "deleted"}, func(tw *tabwriter.Writer) {
+		row(tw, fmt.Sprintf("Application %q deleted.", name))
+	})
+}
+
+// --- services ---
+
+func runServices(args []string, stdout io.Writer) error {
+	if len(args) == 0 || (args[0] != "list" && args[0] != "ls") {
+		return fmt.Errorf("usage: iafctl services list")
+	}
+	fs := newFlagSet("services list", stdout)
+	format := outputFlag(fs)
+	if _, err := parseArgs(fs, args[1:]); err != nil {
+		return err
+	}
+	c, err := sessionClient()
+	if err != nil {
+		return err
+	}
+	services, err := c.ListServices(context.Background())
+	if err != nil {
+		return err
+	}
+	return render(stdout, *format, services, func(tw *tabwriter.Writer) {
+		row(tw, "NAME", "TYPE", "PLAN", "PHASE", "BOUND APPS")
+		for _, svc := range services {
+			row(tw, svc.Name, svc.Type, svc.Plan, orDash(svc.Phase), orDash(strings.Join(svc.BoundApps, ",")))
+		}
+	})
+}
+
+// --- data sources ---
+
+func runDataSources(args []string, stdout io.Writer) error {
+	if len(args) == 0 || (args[0] != "list" && args[0] != "ls") {
+		return fmt.Errorf("usage: iafctl data-sources list [--kind KIND]")
+	}
+	fs := newFlagSet("data-sources list", stdout)
+	kind := fs.String("kind", "", "filter by data source kind (e.g. postgres, s3)")
+	format := outputFlag(fs)
+	if _, err := parseArgs(fs, args[1:]); err != nil {
+		return err
+	}
+	c, err := sessionClient()
+	if err != nil {
+		return err
+	}
+	sources, err := c.ListDataSources(context.Background(), *kind)
+	if err != nil {
+		return err
+	}
+	return render(stdout, *format, sources, func(tw *tabwriter.Writer) {
+		row(tw, "NAME", "KIND", "ENV VARS", "DESCRIPTION")
+		for _, ds := range sources {
+			row(tw, ds.Name, ds.Kind, orDash(strings.Join(ds.EnvVarNames, ",")), orDash(ds.Description))
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// cliConfig is persisted by "iafctl login" so later commands can reach the
+// API server without repeating flags. Environment variables take precedence:
+// IAF_URL, IAF_TOKEN, and IAF_SESSION.
+type cliConfig struct {
+	Server    string `json:"server"`
+	Token     string `json:"token"`
+	SessionID string `json:"sessionId"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// configPath returns the config file location. IAFCTL_CONFIG overrides the
+// default of <user config dir>/iafctl/config.json.
+func configPath() (string, error) {
+	if p := os.Getenv("IAFCTL_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locating config directory: %w", err)
+	}
+	return filepath.Join(dir, "iafctl", "config.json"), nil
+}
+
+// loadConfig reads the saved config, if any, and applies environment overrides.
+func loadConfig() (*cliConfig, error) {
+	cfg := &cliConfig{}
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parsing config %s: %w", path, err)
+		}
+	}
+
+	if v := os.Getenv("IAF_URL"); v != "" {
+		cfg.Server = v
+	}
+	if v := os.Getenv("IAF_TOKEN"); v != "" {
+		cfg.Token = v
+	}
+	if v := os.Getenv("IAF_SESSION"); v != "" {
+		cfg.SessionID = v
+	}
+	return cfg, nil
+}
+
+// saveConfig writes the config with owner-only permissions since it holds
+// the API token.
+func saveConfig(cfg *cliConfig) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	return nil
+}
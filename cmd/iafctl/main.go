@@ -0,0 +1,67 @@
+// Command iafctl is a command-line client for the IAF REST API, for human
+// developers and CI pipelines that do not speak MCP.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `iafctl - command-line client for the IAF platform
+
+Usage:
+  iafctl login --server URL --token TOKEN [--name NAME] [--session ID]
+  iafctl register [--name NAME]
+  iafctl apps list
+  iafctl apps status NAME
+  iafctl apps deploy NAME (--image IMAGE | --git-url URL [--git-revision REV]) [--port N] [--replicas N] [--env KEY=VALUE]...
+  iafctl apps logs NAME [--lines N] [--pod POD] [--build | --follow]
+  iafctl apps delete NAME
+  iafctl services list
+  iafctl data-sources list [--kind KIND]
+
+Every command accepts -o table (default) or -o json.
+
+Configuration is saved by "login" to $IAFCTL_CONFIG or <user config dir>/iafctl/config.json.
+IAF_URL, IAF_TOKEN, and IAF_SESSION override the saved values, which lets CI
+pipelines run without a config file.
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// run dispatches a command line to the matching subcommand.
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stdout, usage)
+		return nil
+	}
+
+	switch args[0] {
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	case "login":
+		return runLogin(args[1:], stdout)
+	case "register":
+		return runRegister(args[1:], stdout)
+	case "apps", "app":
+		return runApps(args[1:], stdout)
+	case "services", "service":
+		return runServices(args[1:], stdout)
+	case "data-sources", "data-source":
+		return runDataSources(args[1:], stdout)
+	default:
+		return fmt.Errorf("unknown command %q; run 'iafctl help' for usage", args[0])
+	}
+}
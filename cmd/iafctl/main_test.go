@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/api"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	"github.com/dlapiduz/iaf/pkg/client"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testToken = "test-token"
+
+// setupCLITest serves the real REST routes backed by a fake cluster and points
+// the CLI config at a temporary file.
+func setupCLITest(t *testing.T) *httptest.Server {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := iafv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := sourcestore.New(t.TempDir(), "http://localhost", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := api.NewServer([]string{testToken}, nil, slog.Default())
+	if err := api.RegisterRoutes(e, k8sClient, kubefake.NewSimpleClientset(), sessions, store, api.RouteOptions{Logger: slog.Default()}); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(e)
+	t.Cleanup(srv.Close)
+
+	t.Setenv("IAFCTL_CONFIG", filepath.Join(t.TempDir(), "config.json"))
+	t.Setenv("IAF_URL", "")
+	t.Setenv("IAF_TOKEN", "")
+	t.Setenv("IAF_SESSION", "")
+	return srv
+}
+
+func runCLI(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	err := run(args, &out)
+	return out.String(), err
+}
+
+func TestLoginAndDeploy(t *testing.T) {
+	srv := setupCLITest(t)
+
+	if _, err := runCLI(t, "login", "--server", srv.URL, "--token", testToken, "--name", "ci"); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SessionID == "" || cfg.Token != testToken {
+		t.Fatalf("config not saved after login: %+v", cfg)
+	}
+	path, _ := configPath()
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("config file mode = %v (err %v), want 0600", info.Mode().Perm(), err)
+	}
+
+	out, err := runCLI(t, "apps", "deploy", "web", "--image", "nginx:latest", "--env", "MODE=prod")
+	if err != nil {
+		t.Fatalf("deploy: %v", err)
+	}
+	if !strings.Contains(out, `"web" created`) {
+		t.Errorf("deploy output = %q, want created message", out)
+	}
+
+	// A second deploy of the same name updates instead of failing.
+	out, err = runCLI(t, "apps", "deploy", "web", "--image", "nginx:1.27", "--replicas", "2")
+	if err != nil {
+		t.Fatalf("redeploy: %v", err)
+	}
+	if !strings.Contains(out, `"web" updated`) {
+		t.Errorf("redeploy output = %q, want updated message", out)
+	}
+
+	// Flags may follow the application name.
+	out, err = runCLI(t, "apps", "status", "web", "-o", "json")
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	var app client.Application
+	if err := json.Unmarshal([]byte(out), &app); err != nil {
+		t.Fatalf("status output is not JSON: %v\n%s", err, out)
+	}
+	if app.Image != "nginx:1.27" || app.Replicas != 2 || len(app.Env) != 1 {
+		t.Errorf("app = %+v, want updated image and replicas with env kept", app)
+	}
+
+	out, err = runCLI(t, "apps", "list")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if !strings.HasPrefix(out, "NAME") || !strings.Contains(out, "web") {
+		t.Errorf("list output = %q, want table with web", out)
+	}
+
+	if _, err := runCLI(t, "services", "list"); err != nil {
+		t.Errorf("services list: %v", err)
+	}
+	if _, err := runCLI(t, "data-sources", "list", "--kind", "postgres"); err != nil {
+		t.Errorf("data-sources list: %v", err)
+	}
+
+	if _, err := runCLI(t, "apps", "delete", "web"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := runCLI(t, "apps", "status", "web"); err == nil || !strings.Contains(err.Error(), "HTTP 404") {
+		t.Errorf("status after delete: err = %v, want HTTP 404", err)
+	}
+}
+
+func TestEnvOverrides(t *testing.T) {
+	srv := setupCLITest(t)
+	t.Setenv("IAF_URL", srv.URL)
+	t.Setenv("IAF_TOKEN", "wrong-token")
+	t.Setenv("IAF_SESSION", "some-session")
+
+	_, err := runCLI(t, "apps", "list")
+	if err == nil || !strings.Contains(err.Error(), "HTTP 401") {
+		t.Errorf("err = %v, want HTTP 401 from the env-configured server", err)
+	}
+}
+
+func TestCommandErrors(t *testing.T) {
+	setupCLITest(t)
+	t.Setenv("IAF_URL", "http://127.0.0.1:1")
+	t.Setenv("IAF_TOKEN", testToken)
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "unknown command", args: []string{"bogus"}, wantErr: "unknown command"},
+		{name: "no session", args: []string{"apps", "list"}, wantErr: "no session"},
+		{name: "deploy needs a source", args: []string{"apps", "deploy", "web"}, wantErr: "--image or --git-url"},
+		{name: "deploy rejects both sources", args: []string{"apps", "deploy", "web", "--image", "x", "--git-url", "y"}, wantErr: "--image or --git-url"},
+		{name: "bad env", args: []string{"apps", "deploy", "web", "--image", "x", "--env", "NOVALUE"}, wantErr: "KEY=VALUE"},
+		{name: "status needs a name", args: []string{"apps", "status"}, wantErr: "usage"},
+		{name: "bad output format", args: []string{"login", "--session", "abc", "-o", "yaml"}, wantErr: "unknown output format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runCLI(t, tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
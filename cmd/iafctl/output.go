@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// outputFlag registers the -o/--output flag shared by every command.
+func outputFlag(fs *flag.FlagSet) *string {
+	format := fs.String("o", "table", "output format: table or json")
+	fs.StringVar(format, "output", "table", "output format: table or json")
+	return format
+}
+
+// parseArgs parses fs and returns the positional arguments. Unlike
+// flag.FlagSet.Parse it accepts flags after positional arguments, so
+// "iafctl apps status web -o json" works.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// render writes v as indented JSON when format is "json", and otherwise
+// calls table to print a human-readable view.
+func render(w io.Writer, format string, v any, table func(tw *tabwriter.Writer)) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "table", "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		table(tw)
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q: use table or json", format)
+	}
+}
+
+// row writes one tab-separated table row.
+func row(tw *tabwriter.Writer, cols ...any) {
+	parts := make([]string, len(cols))
+	for i, c := range cols {
+		parts[i] = fmt.Sprint(c)
+	}
+	fmt.Fprintln(tw, strings.Join(parts, "\t"))
+}
+
+// orDash substitutes "-" for empty table cells.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
@@ -3,18 +3,25 @@ package main
 import (
 	"context"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 
+	"github.com/dlapiduz/iaf/internal/audit"
 	"github.com/dlapiduz/iaf/internal/auth"
 	"github.com/dlapiduz/iaf/internal/config"
+	"github.com/dlapiduz/iaf/internal/cost"
 	iafgithub "github.com/dlapiduz/iaf/internal/github"
 	"github.com/dlapiduz/iaf/internal/k8s"
 	iafmcp "github.com/dlapiduz/iaf/internal/mcp"
+	"github.com/dlapiduz/iaf/internal/orgstandards"
 	"github.com/dlapiduz/iaf/internal/sessiongc"
 	"github.com/dlapiduz/iaf/internal/sourcestore"
+	"github.com/dlapiduz/iaf/internal/uptime"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
 	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func main() {
@@ -26,6 +33,10 @@ func main() {
 		logger.Error("failed to load config", "error", err)
 		os.Exit(1)
 	}
+	if err := cfg.ValidateOffline(); err != nil {
+		logger.Error("invalid offline configuration", "error", err)
+		os.Exit(1)
+	}
 
 	k8sClient, err := k8s.NewClient(cfg.KubeConfig)
 	if err != nil {
@@ -38,6 +49,35 @@ func main() {
 		logger.Error("failed to create source store", "error", err)
 		os.Exit(1)
 	}
+	store.SetSigningKey([]byte(cfg.SourceSigningKey))
+	store.SetURLTTL(cfg.SourceURLTTL)
+	maxSource, err := cfg.MaxSourceBytes()
+	if err != nil {
+		logger.Error("invalid source store settings", "error", err)
+		os.Exit(1)
+	}
+	store.SetMaxUploadSize(maxSource)
+	maxUploads, err := cfg.OpenUploadsLimit()
+	if err != nil {
+		logger.Error("invalid source store settings", "error", err)
+		os.Exit(1)
+	}
+	store.SetMaxOpenUploads(maxUploads)
+	maxToolResult, err := cfg.MaxToolResultBytes()
+	if err != nil {
+		logger.Error("invalid MCP settings", "error", err)
+		os.Exit(1)
+	}
+	toolTimeouts, err := cfg.ToolTimeoutMap()
+	if err != nil {
+		logger.Error("invalid MCP settings", "error", err)
+		os.Exit(1)
+	}
+	knownHosts, err := cfg.GitKnownHosts()
+	if err != nil {
+		logger.Error("invalid MCP settings", "error", err)
+		os.Exit(1)
+	}
 
 	sessionsPath := filepath.Join(cfg.SourceStoreDir, "sessions.json")
 	sessions, err := auth.NewSessionStore(sessionsPath)
@@ -45,20 +85,54 @@ func main() {
 		logger.Error("failed to create session store", "error", err)
 		os.Exit(1)
 	}
+	history, err := audit.New(filepath.Join(cfg.SourceStoreDir, "history"))
+	if err != nil {
+		logger.Error("failed to create session history", "error", err)
+		os.Exit(1)
+	}
+	securityAudit, err := cfg.SecurityAudit("mcpserver", filepath.Join(cfg.SourceStoreDir, "security-audit"), logger)
+	if err != nil {
+		logger.Error("failed to create security audit", "error", err)
+		os.Exit(1)
+	}
+	defer securityAudit.Close()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// Start session GC if TTL and GC interval are configured.
 	if cfg.SessionTTL > 0 && cfg.SessionGCInterval > 0 {
-		cleaner := sessiongc.New(k8sClient, store, sessions, logger)
+		cleaner := sessiongc.New(k8sClient, store, sessions, history, logger)
 		go cleaner.Start(ctx, cfg.SessionGCInterval)
 		logger.Info("session GC started", "ttl", cfg.SessionTTL, "interval", cfg.SessionGCInterval)
 	}
 
+	// Give new sessions the operator's shared git credentials.
+	sessions.SetSharedGitCredentials(cfg.SharedGitCredentialsNamespace)
+
+	// Keep warm session namespaces ready if a pool is configured.
+	if cfg.NamespacePoolSize > 0 {
+		pool := auth.NewNamespacePool(k8sClient, cfg.NamespacePoolSize, logger)
+		sessions.SetPool(pool)
+		go pool.Start(ctx)
+		logger.Info("namespace pool started", "size", cfg.NamespacePoolSize)
+	}
+
 	var ghClient iafgithub.Client
-	if cfg.GitHubToken != "" && cfg.GitHubOrg != "" {
-		ghClient = iafgithub.NewHTTPClient(cfg.GitHubToken)
+	if cfg.GitHubEnabled() {
+		client, err := cfg.GitHubClient()
+		if err != nil {
+			logger.Error("invalid GitHub settings", "error", err)
+			os.Exit(1)
+		}
+		ghClient = client
+	} else if cfg.Offline {
+		logger.Info("GitHub tools disabled in offline mode")
+	}
+	ghTemplates, err := iafgithub.LoadRepoTemplates(cfg.GitHubTemplatesDir)
+	if err != nil {
+		logger.Error("invalid github templates", "error", err)
+		os.Exit(1)
 	}
 
 	// Attempt to create a Kubernetes clientset for log streaming.
@@ -77,7 +151,86 @@ func main() {
 		}
 	}
 
-	server := iafmcp.NewServer(k8sClient, sessions, store, cfg.BaseDomain, ghClient, cfg.GitHubOrg, cfg.GitHubToken, cfg.TempoURL, cfg.SessionTTL, clientset)
+	// In maintenance only read-only calls are served.
+	maint, err := cfg.MaintenanceMode()
+	if err != nil {
+		logger.Error("invalid maintenance settings", "error", err)
+		os.Exit(1)
+	}
+	if maint.Active() {
+		logger.Warn("platform is in maintenance; refusing changes", "until", cfg.MaintenanceUntil)
+	}
+
+	alertRuleLabels, err := cfg.AlertRuleLabelMap()
+	if err != nil {
+		logger.Error("invalid alert rule labels", "error", err)
+		os.Exit(1)
+	}
+	architectureBuilders, err := cfg.ArchitectureBuilders()
+	if err != nil {
+		logger.Error("invalid architectures", "error", err)
+		os.Exit(1)
+	}
+	workloadClasses, err := cfg.WorkloadClasses()
+	if err != nil {
+		logger.Error("invalid workload classes", "error", err)
+		os.Exit(1)
+	}
+	var uptimeQuerier uptime.Querier
+	var costs *cost.Estimator
+	if cfg.PrometheusURL != "" {
+		if uptimeQuerier, err = uptime.NewPrometheus(cfg.PrometheusURL); err != nil {
+			logger.Error("failed to set up uptime reporting", "error", err)
+			os.Exit(1)
+		}
+		usage, err := cost.NewPrometheus(cfg.PrometheusURL)
+		if err != nil {
+			logger.Error("failed to set up cost reporting", "error", err)
+			os.Exit(1)
+		}
+		costs = &cost.Estimator{Usage: usage, Rates: cfg.CostRates()}
+	}
+
+	// Org standards for standards_check: the file, merged with the
+	// OrgStandards resources the controller also watches.
+	standards := orgstandards.New(cfg.OrgStandardsFile, logger)
+	go standards.Start(ctx)
+	if watchClient, ok := k8sClient.(client.WithWatch); ok {
+		go standards.WatchCluster(ctx, watchClient)
+	}
+
+	server := iafmcp.NewServer(k8sClient, sessions, store, iafmcp.Options{
+		BaseDomain:      cfg.BaseDomain,
+		GitHub:          ghClient,
+		GitHubOrg:       cfg.GitHubOrg,
+		GitHubToken:     cfg.GitHubToken,
+		GitHubTemplates: ghTemplates,
+		Grafana:         cfg.Grafana(),
+		AlertRuleLabels: alertRuleLabels,
+		Uptime:          uptimeQuerier,
+		Costs:           costs,
+		Builders:        cfg.Builders(),
+		Architectures:   slices.Sorted(maps.Keys(architectureBuilders)),
+		WorkloadClasses: slices.Sorted(maps.Keys(workloadClasses)),
+		CustomDNS:       cfg.AllowCustomDNS,
+		Offline:         cfg.Offline,
+		Proxy:           cfg.HTTPProxy != "" || cfg.HTTPSProxy != "",
+		KubeAPIServer:   cfg.KubeAPIServer,
+		SessionTTL:      cfg.SessionTTL,
+		OrgStandards:    standards,
+		Features:        cfg.Features(),
+		EnabledTools:    cfg.EnabledTools,
+		DisabledTools:   cfg.DisabledTools,
+		MaxResultBytes:  maxToolResult,
+		History:         history,
+		SecurityAudit:   securityAudit,
+		Health:          cfg.PlatformHealth(k8sClient, store),
+		Maintenance:     maint,
+		ToolTimeout:     cfg.ToolTimeout,
+		ToolTimeouts:    toolTimeouts,
+		KnownHosts:      knownHosts,
+		Clientset:       clientset,
+	})
 
 	logger.Info("starting MCP server", "transport", cfg.MCPTransport)
 
@@ -0,0 +1,165 @@
+// Renders the OpenAPI document of this API server. It is served by the
+// API server itself, so the page works without internet access, and it
+// builds the page with DOM APIs only, never from HTML strings.
+"use strict";
+
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [key, value] of Object.entries(attrs || {})) {
+    if (key === "class") node.className = value;
+    else node.setAttribute(key, value);
+  }
+  for (const child of children) {
+    if (child == null) continue;
+    node.append(child instanceof Node ? child : String(child));
+  }
+  return node;
+}
+
+function resolve(spec, value) {
+  const seen = new Set();
+  while (value && value.$ref && !seen.has(value.$ref)) {
+    seen.add(value.$ref);
+    value = value.$ref.replace(/^#\//, "").split("/").reduce((node, key) => node && node[key], spec);
+  }
+  return value;
+}
+
+// expand inlines $refs for display, stopping at cycles.
+function expand(spec, value, stack = []) {
+  if (Array.isArray(value)) return value.map((item) => expand(spec, item, stack));
+  if (!value || typeof value !== "object") return value;
+  if (value.$ref) {
+    if (stack.includes(value.$ref)) return { $ref: value.$ref };
+    return expand(spec, resolve(spec, value), [...stack, value.$ref]);
+  }
+  const out = {};
+  for (const [key, item] of Object.entries(value)) out[key] = expand(spec, item, stack);
+  return out;
+}
+
+function example(schema, depth = 0) {
+  if (!schema || depth > 6) return null;
+  if (schema.example !== undefined) return schema.example;
+  if (schema.default !== undefined) return schema.default;
+  if (schema.enum) return schema.enum[0];
+  const type = Array.isArray(schema.type) ? schema.type.find((t) => t !== "null") : schema.type;
+  switch (type) {
+    case "object": {
+      const out = {};
+      for (const name of schema.required || []) {
+        out[name] = example((schema.properties || {})[name], depth + 1);
+      }
+      return out;
+    }
+    case "array": return [];
+    case "integer": case "number": return 0;
+    case "boolean": return false;
+    case "string": return "";
+    default: return null;
+  }
+}
+
+function parametersTable(params) {
+  if (!params.length) return null;
+  const rows = params.map((p) => el("tr", null,
+    el("td", { class: "path" }, p.name, p.required ? " *" : ""),
+    el("td", null, p.in),
+    el("td", null, p.description || ""),
+    el("td", null, el("input", { "data-param": p.name, "data-in": p.in, placeholder: p.name }))));
+  return el("table", null, el("tr", null, el("th", null, "Name"), el("th", null, "In"), el("th", null, "Description"), el("th", null, "Value")), ...rows);
+}
+
+function operation(spec, path, method, op) {
+  const params = (op.parameters || []).map((p) => resolve(spec, p));
+  const body = op.requestBody && resolve(spec, op.requestBody);
+  const bodySchema = body && body.content && body.content["application/json"] && body.content["application/json"].schema;
+  const bodyInput = bodySchema ? el("textarea", { spellcheck: "false" }) : null;
+  if (bodyInput) bodyInput.value = JSON.stringify(example(expand(spec, bodySchema)), null, 2);
+  const result = el("div");
+  const button = el("button", { type: "button" }, "Try it");
+  button.addEventListener("click", () => tryIt(path, method, params, section, bodyInput, result));
+
+  const responses = Object.entries(op.responses || {}).map(([status, response]) => {
+    response = resolve(spec, response);
+    const schema = response.content && response.content["application/json"] && response.content["application/json"].schema;
+    return el("div", null, el("p", null, el("span", { class: "status" }, status), " ", response.description || ""),
+      schema ? el("pre", null, JSON.stringify(expand(spec, schema), null, 2)) : null);
+  });
+
+  const section = el("details", null,
+    el("summary", null, el("span", { class: `method ${method}` }, method), el("span", { class: "path" }, path), el("span", { class: "summary" }, op.summary || "")),
+    el("div", { class: "body" },
+      op.description ? el("p", null, op.description) : null,
+      parametersTable(params),
+      bodySchema ? el("div", null, el("h4", null, "Request body"), el("pre", null, JSON.stringify(expand(spec, bodySchema), null, 2)), bodyInput) : null,
+      el("p", null, button),
+      result,
+      el("h4", null, "Responses"),
+      ...responses));
+  return section;
+}
+
+async function tryIt(path, method, params, section, bodyInput, result) {
+  let url = path;
+  const query = new URLSearchParams();
+  const headers = { Accept: "application/json" };
+  for (const input of section.querySelectorAll("input[data-param]")) {
+    const value = input.value;
+    if (!value) continue;
+    const name = input.dataset.param;
+    switch (input.dataset.in) {
+      case "path": url = url.replace(`{${name}}`, encodeURIComponent(value)); break;
+      case "query": query.set(name, value); break;
+      case "header": headers[name] = value; break;
+    }
+  }
+  const token = document.getElementById("token").value;
+  if (token) headers.Authorization = `Bearer ${token}`;
+  const session = document.getElementById("session").value;
+  if (session && !headers["X-IAF-Session"]) headers["X-IAF-Session"] = session;
+  const init = { method: method.toUpperCase(), headers };
+  if (bodyInput) {
+    headers["Content-Type"] = "application/json";
+    init.body = bodyInput.value;
+  }
+  if ([...query].length) url += `?${query}`;
+  result.replaceChildren(el("p", null, "Sending…"));
+  try {
+    const response = await fetch(url, init);
+    let text = await response.text();
+    try { text = JSON.stringify(JSON.parse(text), null, 2); } catch (_) { /* not JSON */ }
+    result.replaceChildren(el("p", null, el("span", { class: "status" }, response.status), ` ${init.method} ${url}`), el("pre", null, text));
+  } catch (err) {
+    result.replaceChildren(el("p", null, `Request failed: ${err}`));
+  }
+}
+
+function groupOf(path) {
+  const parts = path.replace(/^\/api\/v1\//, "").split("/");
+  return path.startsWith("/api/v1/") ? parts[0].replace(/:.*$/, "") : "platform";
+}
+
+async function render() {
+  const main = document.getElementById("operations");
+  try {
+    const response = await fetch(document.body.dataset.spec);
+    const spec = await response.json();
+    document.getElementById("title").textContent = `${spec.info.title} ${spec.info.version}`;
+    document.getElementById("description").textContent = spec.info.description || "";
+    const groups = new Map();
+    for (const path of Object.keys(spec.paths).sort()) {
+      for (const [method, op] of Object.entries(spec.paths[path])) {
+        if (method === "parameters") continue;
+        const group = groupOf(path);
+        if (!groups.has(group)) groups.set(group, []);
+        groups.get(group).push(operation(spec, path, method, op));
+      }
+    }
+    main.replaceChildren(...[...groups].flatMap(([group, ops]) => [el("h2", null, group), ...ops]));
+  } catch (err) {
+    main.replaceChildren(el("p", null, `Could not load the API document: ${err}`));
+  }
+}
+
+document.addEventListener("DOMContentLoaded", render);
@@ -3,348 +3,421 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
 	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/grafana"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/policy"
+	"github.com/dlapiduz/iaf/internal/service"
 	"github.com/dlapiduz/iaf/internal/sourcestore"
-	"github.com/dlapiduz/iaf/internal/validation"
 	"github.com/labstack/echo/v4"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type ApplicationHandler struct {
 	client   client.Client
 	sessions *auth.SessionStore
-	store    *sourcestore.Store
+	// namespaces resolves the session of a request to its namespace.
+	namespaces *service.Sessions
+	store      *sourcestore.Store
+	apps       *service.Applications
+	grafana    grafana.Config
 }
 
-func NewApplicationHandler(c client.Client, sessions *auth.SessionStore, store *sourcestore.Store) *ApplicationHandler {
+// NewApplicationHandler returns the application handlers. Host aliases and
+// DNS settings are accepted only when customDNS is set.
+func NewApplicationHandler(c client.Client, sessions *auth.SessionStore, store *sourcestore.Store, grafanaCfg grafana.Config, customDNS bool) *ApplicationHandler {
 	return &ApplicationHandler{
-		client:   c,
-		sessions: sessions,
-		store:    store,
+		client:     c,
+		sessions:   sessions,
+		namespaces: service.NewSessions(c, sessions, 0),
+		store:      store,
+		apps:       service.NewApplications(c, store, customDNS),
+		grafana:    grafanaCfg,
 	}
 }
 
-func (h *ApplicationHandler) resolveNamespace(c echo.Context) (string, error) {
-	sessionID := c.Request().Header.Get("X-IAF-Session")
-	if sessionID == "" {
-		sessionID = c.QueryParam("session_id")
+// requestSessionID returns the session ID of the X-IAF-Session header or
+// session_id query parameter.
+func requestSessionID(c echo.Context) string {
+	if id := c.Request().Header.Get("X-IAF-Session"); id != "" {
+		return id
 	}
-	if sessionID == "" {
-		return "", fmt.Errorf("missing session ID: provide X-IAF-Session header or session_id query parameter")
-	}
-	sess, ok := h.sessions.Lookup(sessionID)
-	if !ok {
-		return "", fmt.Errorf("session not found, call register first")
-	}
-	return sess.Namespace, nil
+	return c.QueryParam("session_id")
 }
 
 // ApplicationResponse is the API representation of an Application.
 type ApplicationResponse struct {
-	Name              string                        `json:"name"`
-	Phase             string                        `json:"phase"`
-	URL               string                        `json:"url"`
-	Image             string                        `json:"image,omitempty"`
-	GitURL            string                        `json:"gitUrl,omitempty"`
-	GitRevision       string                        `json:"gitRevision,omitempty"`
-	Blob              string                        `json:"blob,omitempty"`
-	Port              int32                         `json:"port"`
-	Replicas          int32                         `json:"replicas"`
-	AvailableReplicas int32                         `json:"availableReplicas"`
-	LatestImage       string                        `json:"latestImage,omitempty"`
-	BuildStatus       string                        `json:"buildStatus,omitempty"`
-	Env               []iafv1alpha1.EnvVar          `json:"env,omitempty"`
-	Host              string                        `json:"host,omitempty"`
-	Conditions        []metav1.Condition            `json:"conditions,omitempty"`
-	CreatedAt         string                        `json:"createdAt"`
+	Name              string                         `json:"name"`
+	UID               string                         `json:"uid"`
+	Version           int64                          `json:"version"`
+	Phase             string                         `json:"phase"`
+	URL               string                         `json:"url"`
+	Image             string                         `json:"image,omitempty"`
+	GitURL            string                         `json:"gitUrl,omitempty"`
+	GitRevision       string                         `json:"gitRevision,omitempty"`
+	GitTrack          string                         `json:"gitTrack,omitempty"`
+	GitPaused         bool                           `json:"gitPaused,omitempty"`
+	Git               *iafv1alpha1.GitStatus         `json:"git,omitempty"`
+	Blob              string                         `json:"blob,omitempty"`
+	SubPath           string                         `json:"subPath,omitempty"`
+	Port              int32                          `json:"port"`
+	Replicas          int32                          `json:"replicas"`
+	Suspended         bool                           `json:"suspended,omitempty"`
+	DeletionProtected bool                           `json:"deletionProtected,omitempty"`
+	AvailableReplicas int32                          `json:"availableReplicas"`
+	Rollout           *iafv1alpha1.RolloutStatus     `json:"rollout,omitempty"`
+	Scheduling        *iafv1alpha1.SchedulingStatus  `json:"scheduling,omitempty"`
+	TLS               *iafv1alpha1.TLSStatus         `json:"tls,omitempty"`
+	LatestImage       string                         `json:"latestImage,omitempty"`
+	ImageDigest       string                         `json:"imageDigest,omitempty"`
+	BuildStatus       string                         `json:"buildStatus,omitempty"`
+	QueuePosition     int32                          `json:"queuePosition,omitempty"`
+	Env               []iafv1alpha1.EnvVar           `json:"env,omitempty"`
+	BuildEnv          []iafv1alpha1.EnvVar           `json:"buildEnv,omitempty"`
+	Host              string                         `json:"host,omitempty"`
+	Protocol          string                         `json:"protocol"`
+	StickySessions    bool                           `json:"stickySessions,omitempty"`
+	Authentication    string                         `json:"authentication"`
+	BackendTLS        bool                           `json:"backendTLS,omitempty"`
+	Access            *iafv1alpha1.AccessConfig      `json:"access,omitempty"`
+	Metadata          *iafv1alpha1.OwnershipMetadata `json:"metadata,omitempty"`
+	Conditions        []metav1.Condition             `json:"conditions,omitempty"`
+	CreatedAt         string                         `json:"createdAt"`
+	// PurgeAt is when an app deleted with delete_app is removed for good.
+	PurgeAt string `json:"purgeAt,omitempty"`
+	// Grafana deep links, returned by Get when Grafana is configured.
+	LogExploreURL       string `json:"logExploreUrl,omitempty"`
+	TraceExploreURL     string `json:"traceExploreUrl,omitempty"`
+	MetricsDashboardURL string `json:"metricsDashboardUrl,omitempty"`
 }
 
 // CreateApplicationRequest is the request body for creating an application.
 type CreateApplicationRequest struct {
-	Name        string               `json:"name" validate:"required"`
-	Image       string               `json:"image,omitempty"`
-	GitURL      string               `json:"gitUrl,omitempty"`
-	GitRevision string               `json:"gitRevision,omitempty"`
-	Port        int32                `json:"port,omitempty"`
-	Replicas    int32                `json:"replicas,omitempty"`
-	Env         []iafv1alpha1.EnvVar `json:"env,omitempty"`
-	Host        string               `json:"host,omitempty"`
+	Name           string                    `json:"name" validate:"required"`
+	Image          string                    `json:"image,omitempty"`
+	GitURL         string                    `json:"gitUrl,omitempty"`
+	GitRevision    string                    `json:"gitRevision,omitempty"`
+	GitTrack       string                    `json:"gitTrack,omitempty"`
+	GitPaused      *bool                     `json:"gitPaused,omitempty"`
+	SubPath        *string                   `json:"subPath,omitempty"`
+	Port           int32                     `json:"port,omitempty"`
+	Replicas       int32                     `json:"replicas,omitempty"`
+	Env            []iafv1alpha1.EnvVar      `json:"env,omitempty"`
+	BuildEnv       []iafv1alpha1.EnvVar      `json:"buildEnv,omitempty"`
+	Host           string                    `json:"host,omitempty"`
+	Protocol       string                    `json:"protocol,omitempty"`
+	StickySessions *bool                     `json:"stickySessions,omitempty"`
+	Authentication string                    `json:"authentication,omitempty"`
+	BackendTLS     *bool                     `json:"backendTLS,omitempty"`
+	Access         *iafv1alpha1.AccessConfig `json:"access,omitempty"`
+	HostAliases    []iafv1alpha1.HostAlias   `json:"hostAliases,omitempty"`
+	DNSConfig      *iafv1alpha1.DNSConfig    `json:"dnsConfig,omitempty"`
+	// Metadata defaults to the metadata the session registered with; an
+	// empty object removes it.
+	Metadata *iafv1alpha1.OwnershipMetadata `json:"metadata,omitempty"`
+}
+
+// UpdateApplicationRequest is the request body for updating an application.
+// When ExpectedVersion is set, the update fails with a version_conflict error
+// unless the application is still at that version.
+type UpdateApplicationRequest struct {
+	CreateApplicationRequest
+	ExpectedVersion int64 `json:"expectedVersion,omitempty"`
 }
 
 // UploadSourceRequest is the request body for uploading source files as JSON.
+// BinaryFiles holds files that are not text, base64-encoded.
 type UploadSourceRequest struct {
-	Files map[string]string `json:"files" validate:"required"`
+	Files       map[string]string `json:"files,omitempty"`
+	BinaryFiles map[string]string `json:"binaryFiles,omitempty"`
+}
+
+// SourceUploadResponse is the body returned by UploadSource.
+type SourceUploadResponse struct {
+	Message string `json:"message"`
+	BlobURL string `json:"blobUrl"`
+}
+
+// PolicyViolationResponse is returned with 403 when a platform policy blocks
+// the request.
+type PolicyViolationResponse struct {
+	ErrorResponse
+	Violations []policy.Violation `json:"violations"`
+}
+
+// UpdatePlanResponse is the body returned by Plan.
+type UpdatePlanResponse struct {
+	Name string `json:"name"`
+	iafk8s.UpdatePlan
+}
+
+// MessageResponse is a body carrying only a human-readable message.
+type MessageResponse struct {
+	Message string `json:"message"`
 }
 
 func toResponse(app *iafv1alpha1.Application) ApplicationResponse {
 	resp := ApplicationResponse{
 		Name:              app.Name,
+		UID:               string(app.UID),
+		Version:           iafk8s.AppVersion(app),
 		Phase:             string(app.Status.Phase),
 		URL:               app.Status.URL,
 		Image:             app.Spec.Image,
 		Blob:              app.Spec.Blob,
 		Port:              app.Spec.Port,
 		Replicas:          app.Spec.Replicas,
+		Suspended:         app.Spec.Suspended,
+		DeletionProtected: app.Spec.DeletionProtected,
 		AvailableReplicas: app.Status.AvailableReplicas,
+		Rollout:           app.Status.Rollout,
+		Scheduling:        app.Status.Scheduling,
+		TLS:               app.Status.TLS,
 		LatestImage:       app.Status.LatestImage,
+		ImageDigest:       app.Status.ImageDigest,
 		BuildStatus:       app.Status.BuildStatus,
+		QueuePosition:     app.Status.BuildQueuePosition,
 		Env:               app.Spec.Env,
+		BuildEnv:          app.Spec.BuildEnv,
 		Host:              app.Spec.Host,
+		Protocol:          string(iafv1alpha1.AppProtocol(app)),
+		StickySessions:    app.Spec.StickySessions,
+		Authentication:    string(iafv1alpha1.AppAuthentication(app)),
+		BackendTLS:        iafv1alpha1.IsBackendTLSEnabled(app),
+		Access:            app.Spec.Access,
+		Metadata:          app.Spec.Metadata,
 		Conditions:        app.Status.Conditions,
 		CreatedAt:         app.CreationTimestamp.Format("2006-01-02T15:04:05Z"),
 	}
 	if app.Spec.Git != nil {
 		resp.GitURL = app.Spec.Git.URL
 		resp.GitRevision = app.Spec.Git.Revision
+		resp.GitTrack = string(app.Spec.Git.Track)
+		resp.GitPaused = app.Spec.Git.Paused
+		resp.Git = app.Status.Git
+	}
+	resp.SubPath = iafk8s.SourceSubPath(app)
+	if app.Status.PurgeAt != nil {
+		resp.PurgeAt = app.Status.PurgeAt.UTC().Format(time.RFC3339)
 	}
 	return resp
 }
 
 // List returns all applications.
 func (h *ApplicationHandler) List(c echo.Context) error {
-	namespace, err := h.resolveNamespace(c)
+	namespace, err := sessionNamespace(c, h.namespaces)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return writeServiceError(c, err)
 	}
 
-	var list iafv1alpha1.ApplicationList
-	if err := h.client.List(c.Request().Context(), &list, client.InNamespace(namespace)); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	list, err := h.apps.List(c.Request().Context(), namespace)
+	if err != nil {
+		return writeServiceError(c, err)
 	}
 
-	apps := make([]ApplicationResponse, 0, len(list.Items))
-	for i := range list.Items {
-		apps = append(apps, toResponse(&list.Items[i]))
+	apps := make([]ApplicationResponse, 0, len(list))
+	for i := range list {
+		apps = append(apps, toResponse(&list[i]))
 	}
 	return c.JSON(http.StatusOK, apps)
 }
 
 // Get returns a single application.
 func (h *ApplicationHandler) Get(c echo.Context) error {
-	namespace, err := h.resolveNamespace(c)
+	namespace, err := sessionNamespace(c, h.namespaces)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return writeServiceError(c, err)
 	}
 
-	name := c.Param("name")
-	var app iafv1alpha1.Application
-	if err := h.client.Get(c.Request().Context(), types.NamespacedName{Name: name, Namespace: namespace}, &app); err != nil {
-		if apierrors.IsNotFound(err) {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "application not found"})
-		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
-	}
-	return c.JSON(http.StatusOK, toResponse(&app))
+	app, err := h.apps.Get(c.Request().Context(), namespace, c.Param("name"))
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	setETag(c, app)
+	resp := toResponse(app)
+	links := h.grafana.AppLinks(app.Namespace, app.Name)
+	resp.LogExploreURL = links.LogExploreURL
+	resp.TraceExploreURL = links.TraceExploreURL
+	resp.MetricsDashboardURL = links.MetricsDashboardURL
+	return c.JSON(http.StatusOK, resp)
 }
 
 // Create creates a new application.
 func (h *ApplicationHandler) Create(c echo.Context) error {
-	namespace, err := h.resolveNamespace(c)
+	namespace, err := sessionNamespace(c, h.namespaces)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return writeServiceError(c, err)
 	}
 
 	var req CreateApplicationRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	if err := bindJSON(c, &req); err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
 	}
 
-	if err := validation.ValidateAppName(req.Name); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
-	}
-	for _, e := range req.Env {
-		if err := validation.ValidateEnvVarName(e.Name); err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
-		}
-	}
-	if req.Image == "" && req.GitURL == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "either image or gitUrl is required"})
+	if sess, ok := h.sessions.Lookup(requestSessionID(c)); ok && req.Metadata == nil && sess.Metadata != nil {
+		req.Metadata = sess.Metadata.DeepCopy()
 	}
 
-	app := &iafv1alpha1.Application{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      req.Name,
-			Namespace: namespace,
-		},
-		Spec: iafv1alpha1.ApplicationSpec{
-			Image:    req.Image,
-			Port:     req.Port,
-			Replicas: req.Replicas,
-			Env:      req.Env,
-			Host:     req.Host,
-		},
+	app, err := h.apps.Create(c.Request().Context(), namespace, service.AppInput(req))
+	if err != nil {
+		return writeServiceError(c, err)
 	}
+	setETag(c, app)
+	return c.JSON(http.StatusCreated, toResponse(app))
+}
 
-	if req.GitURL != "" {
-		app.Spec.Git = &iafv1alpha1.GitSource{
-			URL:      req.GitURL,
-			Revision: req.GitRevision,
-		}
+// Update updates an existing application (PATCH). Omitted fields are left
+// unchanged; an empty "access" object removes IP and rate-limit
+// restrictions.
+func (h *ApplicationHandler) Update(c echo.Context) error {
+	namespace, err := sessionNamespace(c, h.namespaces)
+	if err != nil {
+		return writeServiceError(c, err)
 	}
 
-	if app.Spec.Port == 0 {
-		app.Spec.Port = 8080
-	}
-	if app.Spec.Replicas == 0 {
-		app.Spec.Replicas = 1
+	var req UpdateApplicationRequest
+	if err := bindJSONPatch(c, &req); err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
 	}
 
-	if err := h.client.Create(c.Request().Context(), app); err != nil {
-		if apierrors.IsAlreadyExists(err) {
-			return c.JSON(http.StatusConflict, map[string]string{"error": "application already exists"})
-		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	name := c.Param("name")
+	expected, err := h.expectedVersion(c, namespace, name, req.ExpectedVersion)
+	if err != nil {
+		return writePreconditionError(c, err)
 	}
-
-	return c.JSON(http.StatusCreated, toResponse(app))
+	app, err := h.apps.Update(c.Request().Context(), namespace, name, service.AppInput(req.CreateApplicationRequest), expected)
+	if err != nil {
+		return writePreconditionError(c, err)
+	}
+	setETag(c, app)
+	return c.JSON(http.StatusOK, toResponse(app))
 }
 
-// Update updates an existing application.
-func (h *ApplicationHandler) Update(c echo.Context) error {
-	namespace, err := h.resolveNamespace(c)
+// Replace creates or replaces an application (PUT). The body is the whole
+// configuration: omitted fields return to their defaults, and the
+// application is created with 201 when it does not exist. If-Match makes
+// it replace only the version read, and If-None-Match: * only create.
+func (h *ApplicationHandler) Replace(c echo.Context) error {
+	namespace, err := sessionNamespace(c, h.namespaces)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return writeServiceError(c, err)
 	}
 
-	name := c.Param("name")
-	if err := validation.ValidateAppName(name); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
-	}
-	var req CreateApplicationRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	var req UpdateApplicationRequest
+	if err := bindJSONPatch(c, &req); err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
 	}
-	for _, e := range req.Env {
-		if err := validation.ValidateEnvVarName(e.Name); err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
-		}
+	name := c.Param("name")
+	if req.Name != "" && req.Name != name {
+		return errorJSON(c, http.StatusBadRequest, fmt.Sprintf("name %q in the body does not match application %q in the path", req.Name, name))
 	}
 
-	var app iafv1alpha1.Application
-	if err := h.client.Get(c.Request().Context(), types.NamespacedName{Name: name, Namespace: namespace}, &app); err != nil {
-		if apierrors.IsNotFound(err) {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "application not found"})
+	ctx := c.Request().Context()
+	in := service.AppInput(req.CreateApplicationRequest)
+	if c.Request().Header.Get("If-None-Match") == "*" {
+		if ifMatch(c) || req.ExpectedVersion != 0 {
+			return errorJSON(c, http.StatusBadRequest, "If-None-Match: * creates the application; it cannot be combined with If-Match or expectedVersion")
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
-	}
-
-	if req.Image != "" {
-		app.Spec.Image = req.Image
-		app.Spec.Git = nil
-		app.Spec.Blob = ""
-	}
-	if req.GitURL != "" {
-		app.Spec.Git = &iafv1alpha1.GitSource{
-			URL:      req.GitURL,
-			Revision: req.GitRevision,
+		in.Name = name
+		app, err := h.apps.Create(ctx, namespace, in)
+		if err != nil {
+			if e := apierror.From(err); e.Code == apierror.CodeNameTaken {
+				failed := e.WithHint("GET the application and retry with its ETag in If-Match to replace it")
+				failed.Code = apierror.CodePreconditionFailed
+				return writeError(c, http.StatusPreconditionFailed, failed)
+			}
+			return writeServiceError(c, err)
 		}
-		app.Spec.Image = ""
-		app.Spec.Blob = ""
+		setETag(c, app)
+		return c.JSON(http.StatusCreated, toResponse(app))
 	}
-	if req.Port > 0 {
-		app.Spec.Port = req.Port
+
+	expected, err := h.expectedVersion(c, namespace, name, req.ExpectedVersion)
+	if err != nil {
+		return writePreconditionError(c, err)
 	}
-	if req.Replicas > 0 {
-		app.Spec.Replicas = req.Replicas
+	app, created, err := h.apps.Replace(ctx, namespace, name, in, expected)
+	if err != nil {
+		return writePreconditionError(c, err)
 	}
-	if req.Env != nil {
-		app.Spec.Env = req.Env
+	setETag(c, app)
+	if created {
+		return c.JSON(http.StatusCreated, toResponse(app))
 	}
-	if req.Host != "" {
-		app.Spec.Host = req.Host
+	return c.JSON(http.StatusOK, toResponse(app))
+}
+
+// Plan previews an update. It takes the same body as Update and reports the
+// spec fields that would change and whether applying them rebuilds,
+// restarts or only rescales the application, without saving anything.
+func (h *ApplicationHandler) Plan(c echo.Context) error {
+	namespace, err := sessionNamespace(c, h.namespaces)
+	if err != nil {
+		return writeServiceError(c, err)
 	}
 
-	if err := h.client.Update(c.Request().Context(), &app); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	var req UpdateApplicationRequest
+	if err := bindJSONPatch(c, &req); err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
 	}
 
-	return c.JSON(http.StatusOK, toResponse(&app))
+	name := c.Param("name")
+	plan, err := h.apps.Plan(c.Request().Context(), namespace, name, service.AppInput(req.CreateApplicationRequest), req.ExpectedVersion)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(http.StatusOK, UpdatePlanResponse{Name: name, UpdatePlan: plan})
 }
 
 // Delete deletes an application.
 func (h *ApplicationHandler) Delete(c echo.Context) error {
-	namespace, err := h.resolveNamespace(c)
+	namespace, err := sessionNamespace(c, h.namespaces)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return writeServiceError(c, err)
 	}
 
 	name := c.Param("name")
-	app := &iafv1alpha1.Application{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: namespace,
-		},
+	expected, err := h.expectedVersion(c, namespace, name, 0)
+	if err != nil {
+		return writePreconditionError(c, err)
 	}
-
-	if err := h.client.Delete(c.Request().Context(), app); err != nil {
-		if apierrors.IsNotFound(err) {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "application not found"})
-		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	if err := h.apps.Delete(c.Request().Context(), namespace, name, expected); err != nil {
+		return writePreconditionError(c, err)
 	}
-
-	// Clean up source store
-	_ = h.store.Delete(namespace, name)
-
-	return c.JSON(http.StatusOK, map[string]string{"message": fmt.Sprintf("application %s deleted", name)})
+	return c.JSON(http.StatusOK, MessageResponse{Message: fmt.Sprintf("application %s deleted", name)})
 }
 
-// UploadSource handles source code upload for an application.
+// UploadSource handles source code upload for an application, either as a
+// JSON map of files or as a raw gzipped tarball.
 func (h *ApplicationHandler) UploadSource(c echo.Context) error {
-	namespace, err := h.resolveNamespace(c)
+	namespace, err := sessionNamespace(c, h.namespaces)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return writeServiceError(c, err)
 	}
 
+	ctx := c.Request().Context()
 	name := c.Param("name")
-
-	// Check if application exists
-	var app iafv1alpha1.Application
-	if err := h.client.Get(c.Request().Context(), types.NamespacedName{Name: name, Namespace: namespace}, &app); err != nil {
-		if apierrors.IsNotFound(err) {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "application not found"})
-		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
-	}
-
-	contentType := c.Request().Header.Get("Content-Type")
-
 	var blobURL string
-
-	if contentType == "application/json" {
-		// JSON body with file contents
+	if c.Request().Header.Get("Content-Type") == "application/json" {
 		var req UploadSourceRequest
-		if err := c.Bind(&req); err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		if err := bindJSON(c, &req); err != nil {
+			return errorJSON(c, http.StatusBadRequest, err.Error())
 		}
-		if len(req.Files) == 0 {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "files map is required"})
-		}
-		blobURL, err = h.store.StoreFiles(namespace, name, req.Files)
+		blobURL, err = h.apps.UploadFiles(ctx, namespace, name, req.Files, req.BinaryFiles)
 	} else {
-		// Raw tarball upload
-		blobURL, err = h.store.StoreTarball(namespace, name, c.Request().Body)
+		blobURL, err = h.apps.UploadTarball(ctx, namespace, name, c.Request().Body)
 	}
-
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
-	}
-
-	// Update application with blob URL
-	app.Spec.Blob = blobURL
-	app.Spec.Image = ""
-	app.Spec.Git = nil
-	if err := h.client.Update(c.Request().Context(), &app); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return writeServiceError(c, err)
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{
-		"message": "source uploaded",
-		"blobUrl": blobURL,
+	return c.JSON(http.StatusOK, SourceUploadResponse{
+		Message: "source uploaded",
+		BlobURL: blobURL,
 	})
 }
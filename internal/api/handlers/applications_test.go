@@ -4,16 +4,19 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
-	"github.com/dlapiduz/iaf/internal/auth"
 	"github.com/dlapiduz/iaf/internal/api/handlers"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/grafana"
 	"github.com/dlapiduz/iaf/internal/sourcestore"
 	"github.com/labstack/echo/v4"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -52,7 +55,7 @@ func setupHandlerTest(t *testing.T) *handlerTestEnv {
 		t.Fatal(err)
 	}
 
-	h := handlers.NewApplicationHandler(k8sClient, sessions, store)
+	h := handlers.NewApplicationHandler(k8sClient, sessions, store, grafana.Config{}, false)
 	e := echo.New()
 
 	return &handlerTestEnv{
@@ -119,6 +122,12 @@ func TestApplicationHandler_Create(t *testing.T) {
 			body:       map[string]any{"name": "myapp", "image": "nginx:latest"},
 			wantStatus: http.StatusBadRequest,
 		},
+		{
+			name:       "unknown session returns 404",
+			sessionID:  "expired-session",
+			body:       map[string]any{"name": "myapp", "image": "nginx:latest"},
+			wantStatus: http.StatusNotFound,
+		},
 		{
 			name:       "no image or gitUrl returns 400",
 			body:       map[string]any{"name": "myapp"},
@@ -129,6 +138,26 @@ func TestApplicationHandler_Create(t *testing.T) {
 			body:       map[string]any{"name": "Invalid_Name!", "image": "nginx:latest"},
 			wantStatus: http.StatusBadRequest,
 		},
+		{
+			name:       "access restrictions accepted",
+			body:       map[string]any{"name": "myapp", "image": "nginx:latest", "access": map[string]any{"ipAllowList": []string{"10.0.0.0/8"}, "requestsPerSecond": 20}},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "wrongly typed field returns 400",
+			body:       map[string]any{"name": "myapp", "image": "nginx:latest", "port": "8080"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown field returns 400",
+			body:       map[string]any{"name": "myapp", "image": "nginx:latest", "git_url": "https://github.com/example/repo"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid allowlist CIDR returns 400",
+			body:       map[string]any{"name": "myapp", "image": "nginx:latest", "access": map[string]any{"ipAllowList": []string{"10.0.0.0/33"}}},
+			wantStatus: http.StatusBadRequest,
+		},
 	}
 
 	for _, tc := range tests {
@@ -136,11 +165,14 @@ func TestApplicationHandler_Create(t *testing.T) {
 			env := setupHandlerTest(t)
 
 			var sessionID string
-			if tc.sessionID == "skip" {
+			switch tc.sessionID {
+			case "skip":
 				sessionID = "" // omit session header
-			} else {
+			case "":
 				sid, _ := env.newSession(t, "agent")
 				sessionID = sid
+			default:
+				sessionID = tc.sessionID
 			}
 
 			rec, c := env.jsonRequest(http.MethodPost, "/api/v1/applications", sessionID, tc.body)
@@ -262,9 +294,46 @@ func TestApplicationHandler_Get(t *testing.T) {
 		if rec.Code != http.StatusNotFound {
 			t.Errorf("status %d, want 404", rec.Code)
 		}
+		var resp handlers.ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Code != "app_not_found" || resp.Category != "not_found" || resp.Retryable || resp.Error == "" {
+			t.Errorf("unexpected error body %+v", resp)
+		}
 	})
 }
 
+func TestApplicationHandler_Get_GrafanaLinks(t *testing.T) {
+	env := setupHandlerTest(t)
+	ctx := context.Background()
+	sid, ns := env.newSession(t, "agent")
+	cfg := grafana.Config{URL: "https://grafana.example.com", LokiUID: "loki", TempoUID: "tempo", DashboardUID: "iaf-app"}
+	h := handlers.NewApplicationHandler(env.client, env.sessions, env.store, cfg, false)
+
+	obj := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: ns},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest"},
+	}
+	if err := env.client.Create(ctx, obj); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, c := env.jsonRequest(http.MethodGet, "/api/v1/applications/myapp", sid, nil)
+	setParam(c, "name", "myapp")
+	if err := h.Get(c); err != nil {
+		t.Fatal(err)
+	}
+	var app handlers.ApplicationResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &app); err != nil {
+		t.Fatal(err)
+	}
+	want := cfg.AppLinks(ns, "myapp")
+	if app.LogExploreURL != want.LogExploreURL || app.TraceExploreURL != want.TraceExploreURL || app.MetricsDashboardURL != want.MetricsDashboardURL {
+		t.Errorf("unexpected links: %+v", app)
+	}
+}
+
 func TestApplicationHandler_Delete(t *testing.T) {
 	env := setupHandlerTest(t)
 	ctx := context.Background()
@@ -278,6 +347,24 @@ func TestApplicationHandler_Delete(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	t.Run("deletion protected returns 409", func(t *testing.T) {
+		protected := &iafv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "keep", Namespace: ns},
+			Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest", DeletionProtected: true},
+		}
+		if err := env.client.Create(ctx, protected); err != nil {
+			t.Fatal(err)
+		}
+		rec, c := env.jsonRequest(http.MethodDelete, "/api/v1/applications/keep", sid, nil)
+		setParam(c, "name", "keep")
+		if err := env.handler.Delete(c); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), `"deletion_protected"`) {
+			t.Fatalf("status %d (body: %s), want 409 deletion_protected", rec.Code, rec.Body.String())
+		}
+	})
+
 	t.Run("deleted successfully", func(t *testing.T) {
 		rec, c := env.jsonRequest(http.MethodDelete, "/api/v1/applications/myapp", sid, nil)
 		setParam(c, "name", "myapp")
@@ -343,6 +430,38 @@ func TestApplicationHandler_UploadSource(t *testing.T) {
 		}
 	})
 
+	t.Run("binary files are decoded", func(t *testing.T) {
+		body := map[string]any{
+			"files":       map[string]string{"index.html": "<img src=logo.png>"},
+			"binaryFiles": map[string]string{"logo.png": "iVBORw0KGgoA/w=="},
+		}
+		rec, c := env.jsonRequest(http.MethodPost, "/api/v1/applications/myapp/source", sid, body)
+		setParam(c, "name", "myapp")
+		if err := env.handler.UploadSource(c); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d (body: %s)", rec.Code, rec.Body.String())
+		}
+		files, err := env.store.ReadFiles(ns, "myapp")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if files["logo.png"] != "\x89PNG\r\n\x1a\n\x00\xff" {
+			t.Errorf("stored logo.png = %q", files["logo.png"])
+		}
+
+		body["binaryFiles"] = map[string]string{"logo.png": "not base64!"}
+		rec, c = env.jsonRequest(http.MethodPost, "/api/v1/applications/myapp/source", sid, body)
+		setParam(c, "name", "myapp")
+		if err := env.handler.UploadSource(c); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("invalid base64: status %d, want 400", rec.Code)
+		}
+	})
+
 	t.Run("app not found returns 404", func(t *testing.T) {
 		body := map[string]any{"files": map[string]string{"f.go": "pkg"}}
 		rec, c := env.jsonRequest(http.MethodPost, "/api/v1/applications/noapp/source", sid, body)
@@ -414,3 +533,615 @@ func TestApplicationHandler_TarballUpload(t *testing.T) {
 		t.Error("expected a non-zero HTTP status code")
 	}
 }
+
+func TestApplicationHandler_Update_Access(t *testing.T) {
+	env := setupHandlerTest(t)
+	ctx := context.Background()
+	sid, ns := env.newSession(t, "agent")
+
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: ns},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest", Port: 8080, Replicas: 1},
+	}
+	if err := env.client.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	update := func(body any) *httptest.ResponseRecorder {
+		t.Helper()
+		rec, c := env.jsonRequest(http.MethodPatch, "/api/v1/applications/myapp", sid, body)
+		setParam(c, "name", "myapp")
+		if err := env.handler.Update(c); err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+
+	rec := update(map[string]any{"access": map[string]any{"ipAllowList": []string{"203.0.113.7"}, "requestsPerSecond": 5}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+	}
+	var got iafv1alpha1.Application
+	if err := env.client.Get(ctx, ctrlclient.ObjectKey{Name: "myapp", Namespace: ns}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Spec.Access == nil || got.Spec.Access.RequestsPerSecond != 5 || len(got.Spec.Access.IPAllowList) != 1 {
+		t.Fatalf("expected access to be set, got %+v", got.Spec.Access)
+	}
+
+	// Omitting access leaves it unchanged.
+	if rec := update(map[string]any{"replicas": 2}); rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", rec.Code)
+	}
+	if err := env.client.Get(ctx, ctrlclient.ObjectKey{Name: "myapp", Namespace: ns}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Spec.Access == nil {
+		t.Fatal("expected access to be preserved when omitted")
+	}
+
+	// An empty access object clears restrictions.
+	if rec := update(map[string]any{"access": map[string]any{}}); rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", rec.Code)
+	}
+	if err := env.client.Get(ctx, ctrlclient.ObjectKey{Name: "myapp", Namespace: ns}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Spec.Access != nil {
+		t.Errorf("expected access to be cleared, got %+v", got.Spec.Access)
+	}
+
+	if rec := update(map[string]any{"access": map[string]any{"ipAllowList": []string{"not-an-ip"}}}); rec.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want 400 for invalid allowlist entry", rec.Code)
+	}
+}
+
+func TestApplicationHandler_Update_GitTrack(t *testing.T) {
+	env := setupHandlerTest(t)
+	ctx := context.Background()
+	sid, ns := env.newSession(t, "agent")
+
+	for _, app := range []*iafv1alpha1.Application{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "staging", Namespace: ns},
+			Spec:       iafv1alpha1.ApplicationSpec{Git: &iafv1alpha1.GitSource{URL: "https://github.com/example/web", Revision: "develop"}, Port: 8080, Replicas: 1},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "img", Namespace: ns},
+			Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest", Port: 8080, Replicas: 1},
+		},
+	} {
+		if err := env.client.Create(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+	}
+	update := func(name string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		rec, c := env.jsonRequest(http.MethodPatch, "/api/v1/applications/"+name, sid, body)
+		setParam(c, "name", name)
+		if err := env.handler.Update(c); err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+
+	rec := update("staging", map[string]any{"gitTrack": "branch"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+	}
+	var resp handlers.ApplicationResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.GitTrack != "branch" || resp.GitRevision != "develop" || resp.GitPaused {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	// Pausing keeps the track mode.
+	if rec := update("staging", map[string]any{"gitPaused": true}); rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", rec.Code)
+	}
+	var got iafv1alpha1.Application
+	if err := env.client.Get(ctx, ctrlclient.ObjectKey{Name: "staging", Namespace: ns}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Spec.Git.Track != iafv1alpha1.GitTrackBranch || !got.Spec.Git.Paused {
+		t.Errorf("expected a paused branch track, got %+v", got.Spec.Git)
+	}
+
+	if rec := update("img", map[string]any{"gitTrack": "branch"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want 400 for an app without a git source", rec.Code)
+	}
+	if rec := update("staging", map[string]any{"gitTrack": "tag"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want 400 for an unknown track mode", rec.Code)
+	}
+}
+
+func TestApplicationHandler_SubPath(t *testing.T) {
+	env := setupHandlerTest(t)
+	ctx := context.Background()
+	sid, ns := env.newSession(t, "agent")
+
+	rec, c := env.jsonRequest(http.MethodPost, "/api/v1/applications", sid, map[string]any{
+		"name": "billing", "gitUrl": "https://github.com/org/platform", "subPath": "services/billing",
+	})
+	if err := env.handler.Create(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status %d, want 201 (body: %s)", rec.Code, rec.Body.String())
+	}
+	var resp handlers.ApplicationResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.SubPath != "services/billing" {
+		t.Errorf("subPath = %q, want services/billing", resp.SubPath)
+	}
+
+	update := func(name string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		rec, c := env.jsonRequest(http.MethodPatch, "/api/v1/applications/"+name, sid, body)
+		setParam(c, "name", name)
+		if err := env.handler.Update(c); err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+	// An empty subPath builds the repository root again.
+	if rec := update("billing", map[string]any{"subPath": ""}); rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+	}
+	var got iafv1alpha1.Application
+	if err := env.client.Get(ctx, ctrlclient.ObjectKey{Name: "billing", Namespace: ns}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Spec.Git.SubPath != "" {
+		t.Errorf("expected the sub path to be cleared, got %q", got.Spec.Git.SubPath)
+	}
+	if rec := update("billing", map[string]any{"subPath": "../other"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want 400 for a path outside the repository", rec.Code)
+	}
+
+	img := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "img", Namespace: ns},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest", Port: 8080, Replicas: 1},
+	}
+	if err := env.client.Create(ctx, img); err != nil {
+		t.Fatal(err)
+	}
+	if rec := update("img", map[string]any{"subPath": "web"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want 400 for an image app", rec.Code)
+	}
+}
+
+func TestApplicationHandler_Create_CustomDNS(t *testing.T) {
+	env := setupHandlerTest(t)
+	ctx := context.Background()
+	sid, ns := env.newSession(t, "agent")
+	body := map[string]any{
+		"name":        "myapp",
+		"image":       "nginx:latest",
+		"hostAliases": []map[string]any{{"ip": "10.20.0.5", "hostnames": []string{"ldap.corp.example.com"}}},
+		"dnsConfig":   map[string]any{"searches": []string{"corp.example.com"}, "options": []map[string]any{{"name": "ndots", "value": "2"}}},
+	}
+	create := func(h *handlers.ApplicationHandler, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		rec, c := env.jsonRequest(http.MethodPost, "/api/v1/applications", sid, body)
+		if err := h.Create(c); err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+
+	// Rejected unless the platform enables custom DNS.
+	if rec := create(env.handler, body); rec.Code != http.StatusNotImplemented {
+		t.Fatalf("status %d, want 501 with custom DNS disabled (body: %s)", rec.Code, rec.Body.String())
+	}
+
+	h := handlers.NewApplicationHandler(env.client, env.sessions, env.store, grafana.Config{}, true)
+	if rec := create(h, body); rec.Code != http.StatusCreated {
+		t.Fatalf("status %d, want 201 (body: %s)", rec.Code, rec.Body.String())
+	}
+	var got iafv1alpha1.Application
+	if err := env.client.Get(ctx, ctrlclient.ObjectKey{Name: "myapp", Namespace: ns}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Spec.HostAliases) != 1 || got.Spec.DNSConfig == nil || got.Spec.DNSConfig.Options[0].Value != "2" {
+		t.Errorf("expected the DNS settings on the spec, got %+v %+v", got.Spec.HostAliases, got.Spec.DNSConfig)
+	}
+
+	// Cluster-internal names cannot be overridden.
+	body["name"] = "other"
+	body["hostAliases"] = []map[string]any{{"ip": "10.20.0.5", "hostnames": []string{"api.default.svc.cluster.local"}}}
+	if rec := create(h, body); rec.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want 400 for a cluster-internal host alias", rec.Code)
+	}
+}
+
+func TestApplicationHandler_Update_ExpectedVersion(t *testing.T) {
+	env := setupHandlerTest(t)
+	ctx := context.Background()
+	sid, ns := env.newSession(t, "agent")
+
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: ns, Generation: 3},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest", Port: 8080, Replicas: 1},
+	}
+	if err := env.client.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, c := env.jsonRequest(http.MethodGet, "/api/v1/applications/myapp", sid, nil)
+	setParam(c, "name", "myapp")
+	if err := env.handler.Get(c); err != nil {
+		t.Fatal(err)
+	}
+	var got handlers.ApplicationResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != 3 {
+		t.Fatalf("version = %d, want 3", got.Version)
+	}
+
+	update := func(body any) *httptest.ResponseRecorder {
+		t.Helper()
+		rec, c := env.jsonRequest(http.MethodPatch, "/api/v1/applications/myapp", sid, body)
+		setParam(c, "name", "myapp")
+		if err := env.handler.Update(c); err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+
+	rec = update(map[string]any{"replicas": 2, "expectedVersion": 2})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status %d, want 409 (body: %s)", rec.Code, rec.Body.String())
+	}
+	var conflict struct {
+		Code    string `json:"code"`
+		Details struct {
+			CurrentVersion int64                       `json:"currentVersion"`
+			Current        iafv1alpha1.ApplicationSpec `json:"current"`
+		} `json:"details"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &conflict); err != nil {
+		t.Fatal(err)
+	}
+	if conflict.Code != "version_conflict" || conflict.Details.CurrentVersion != 3 || conflict.Details.Current.Image != "nginx:latest" {
+		t.Errorf("unexpected conflict body %s", rec.Body.String())
+	}
+	var stored iafv1alpha1.Application
+	if err := env.client.Get(ctx, ctrlclient.ObjectKey{Name: "myapp", Namespace: ns}, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Spec.Replicas != 1 {
+		t.Errorf("expected a rejected update to leave replicas at 1, got %d", stored.Spec.Replicas)
+	}
+
+	if rec := update(map[string]any{"replicas": 2, "expectedVersion": 3}); rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+	}
+	if rec := update(map[string]any{"replicas": 3}); rec.Code != http.StatusOK {
+		t.Errorf("status %d, want 200 without expectedVersion", rec.Code)
+	}
+}
+
+func TestApplicationHandler_Replace(t *testing.T) {
+	env := setupHandlerTest(t)
+	ctx := context.Background()
+	sid, ns := env.newSession(t, "agent")
+
+	replace := func(body any, header ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec, c := env.jsonRequest(http.MethodPut, "/api/v1/applications/myapp", sid, body)
+		for i := 0; i+1 < len(header); i += 2 {
+			c.Request().Header.Set(header[i], header[i+1])
+		}
+		setParam(c, "name", "myapp")
+		if err := env.handler.Replace(c); err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+
+	// A PUT to a missing application creates it.
+	rec := replace(map[string]any{"image": "nginx:1.27", "replicas": 3, "stickySessions": true})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status %d, want 201 (body: %s)", rec.Code, rec.Body.String())
+	}
+	if etag := rec.Header().Get("ETag"); etag == "" {
+		t.Error("expected an ETag")
+	}
+
+	// Replacing again resets omitted fields to their defaults.
+	rec = replace(map[string]any{"name": "myapp", "image": "nginx:1.28"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+	}
+	var stored iafv1alpha1.Application
+	if err := env.client.Get(ctx, ctrlclient.ObjectKey{Name: "myapp", Namespace: ns}, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Spec.Image != "nginx:1.28" || stored.Spec.Replicas != 1 || stored.Spec.Port != 8080 || stored.Spec.StickySessions {
+		t.Errorf("expected a full replace, got %+v", stored.Spec)
+	}
+
+	if rec := replace(map[string]any{"name": "other", "image": "nginx:1.28"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want 400 for a name mismatch", rec.Code)
+	}
+	if rec := replace(map[string]any{"replicas": 2}); rec.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want 400 without a source", rec.Code)
+	}
+
+	// If-None-Match: * only creates.
+	rec = replace(map[string]any{"image": "nginx:1.28"}, "If-None-Match", "*")
+	var body struct {
+		Code string `json:"code"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &body)
+	if rec.Code != http.StatusPreconditionFailed || body.Code != "precondition_failed" {
+		t.Errorf("status %d (%s), want 412 precondition_failed", rec.Code, rec.Body.String())
+	}
+}
+
+func TestApplicationHandler_IfMatch(t *testing.T) {
+	env := setupHandlerTest(t)
+	ctx := context.Background()
+	sid, ns := env.newSession(t, "agent")
+
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: ns, Generation: 3},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest", Port: 8080, Replicas: 1},
+	}
+	if err := env.client.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, c := env.jsonRequest(http.MethodGet, "/api/v1/applications/myapp", sid, nil)
+	setParam(c, "name", "myapp")
+	if err := env.handler.Get(c); err != nil {
+		t.Fatal(err)
+	}
+	if etag := rec.Header().Get("ETag"); etag != `"3"` {
+		t.Fatalf("ETag = %q, want \"3\"", etag)
+	}
+
+	call := func(method string, handle func(echo.Context) error, name, ifMatch string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		rec, c := env.jsonRequest(method, "/api/v1/applications/"+name, sid, body)
+		c.Request().Header.Set("If-Match", ifMatch)
+		setParam(c, "name", name)
+		if err := handle(c); err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+	status := func(rec *httptest.ResponseRecorder) (int, string) {
+		var body struct {
+			Code string `json:"code"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body.Code
+	}
+
+	cases := []struct {
+		name       string
+		method     string
+		handle     func(echo.Context) error
+		app        string
+		ifMatch    string
+		body       any
+		wantStatus int
+		wantCode   string
+	}{
+		{"stale patch", http.MethodPatch, env.handler.Update, "myapp", `"2"`, map[string]any{"replicas": 2}, http.StatusPreconditionFailed, "precondition_failed"},
+		{"stale put", http.MethodPut, env.handler.Replace, "myapp", `"2"`, map[string]any{"image": "nginx:1.28"}, http.StatusPreconditionFailed, "precondition_failed"},
+		{"stale delete", http.MethodDelete, env.handler.Delete, "myapp", `"2"`, nil, http.StatusPreconditionFailed, "precondition_failed"},
+		{"missing app", http.MethodPut, env.handler.Replace, "noapp", "*", map[string]any{"image": "nginx:1.28"}, http.StatusPreconditionFailed, "precondition_failed"},
+		{"weak etag", http.MethodPatch, env.handler.Update, "myapp", `W/"3"`, map[string]any{"replicas": 2}, http.StatusBadRequest, "invalid_request"},
+		{"both versions", http.MethodPatch, env.handler.Update, "myapp", `"3"`, map[string]any{"replicas": 2, "expectedVersion": 3}, http.StatusBadRequest, "invalid_request"},
+		{"current patch", http.MethodPatch, env.handler.Update, "myapp", `"3"`, map[string]any{"replicas": 2}, http.StatusOK, ""},
+		{"any version", http.MethodPut, env.handler.Replace, "myapp", "*", map[string]any{"image": "nginx:1.28"}, http.StatusOK, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotStatus, gotCode := status(call(tc.method, tc.handle, tc.app, tc.ifMatch, tc.body))
+			if gotStatus != tc.wantStatus || gotCode != tc.wantCode {
+				t.Errorf("got %d %q, want %d %q", gotStatus, gotCode, tc.wantStatus, tc.wantCode)
+			}
+		})
+	}
+
+	// The stale delete left the application in place; a current one deletes it.
+	var stored iafv1alpha1.Application
+	if err := env.client.Get(ctx, ctrlclient.ObjectKey{Name: "myapp", Namespace: ns}, &stored); err != nil {
+		t.Fatal(err)
+	}
+	etag := fmt.Sprintf("%q", fmt.Sprint(stored.Generation))
+	if code, _ := status(call(http.MethodDelete, env.handler.Delete, "myapp", etag, nil)); code != http.StatusOK {
+		t.Errorf("status %d, want 200 deleting with the current ETag", code)
+	}
+}
+
+func TestApplicationHandler_Plan(t *testing.T) {
+	env := setupHandlerTest(t)
+	ctx := context.Background()
+	sid, ns := env.newSession(t, "agent")
+
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: ns},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:1.25", Port: 8080, Replicas: 1},
+	}
+	if err := env.client.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	plan := func(name string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		rec, c := env.jsonRequest(http.MethodPost, "/api/v1/applications/"+name+"/plan", sid, body)
+		setParam(c, "name", name)
+		if err := env.handler.Plan(c); err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+
+	tests := []struct {
+		name       string
+		body       any
+		wantEffect string
+		wantFields []string
+	}{
+		{"no change", map[string]any{"port": 8080}, "none", nil},
+		{"scale", map[string]any{"replicas": 3}, "scale", []string{"replicas"}},
+		{"new image", map[string]any{"image": "nginx:1.26", "stickySessions": true}, "restart", []string{"image", "stickySessions"}},
+		{"switch to git", map[string]any{"gitUrl": "https://github.com/org/app"}, "rebuild", []string{"image", "git.url"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := plan("myapp", tt.body)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+			}
+			var resp handlers.UpdatePlanResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if string(resp.Effect) != tt.wantEffect {
+				t.Errorf("effect = %q, want %q", resp.Effect, tt.wantEffect)
+			}
+			var fields []string
+			for _, c := range resp.Changes {
+				fields = append(fields, c.Field)
+			}
+			if !slices.Equal(fields, tt.wantFields) {
+				t.Errorf("changed fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+
+	// Planning saves nothing.
+	var got iafv1alpha1.Application
+	if err := env.client.Get(ctx, ctrlclient.ObjectKey{Name: "myapp", Namespace: ns}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Spec.Image != "nginx:1.25" || got.Spec.Replicas != 1 {
+		t.Errorf("expected the application to be unchanged, got %+v", got.Spec)
+	}
+
+	if rec := plan("missing", map[string]any{"replicas": 2}); rec.Code != http.StatusNotFound {
+		t.Errorf("status %d, want 404 for a missing app", rec.Code)
+	}
+	if rec := plan("myapp", map[string]any{"protocol": "udp"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want 400 for an invalid protocol", rec.Code)
+	}
+}
+
+func TestApplicationHandler_PolicyViolation(t *testing.T) {
+	env := setupHandlerTest(t)
+	ctx := context.Background()
+	sid, ns := env.newSession(t, "agent")
+
+	pol := &iafv1alpha1.PlatformPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "limits"},
+		Spec: iafv1alpha1.PlatformPolicySpec{
+			Rules: []iafv1alpha1.PolicyRule{
+				{Name: "replicas", Expression: `!has(app.spec.replicas) || app.spec.replicas <= 2`, Message: "At most 2 replicas"},
+				{Name: "no-env-files", Expression: `!files.exists(f, f.endsWith(".env"))`, Message: "Do not upload .env files"},
+			},
+		},
+	}
+	if err := env.client.Create(ctx, pol); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, c := env.jsonRequest(http.MethodPost, "/api/v1/applications", sid, map[string]any{"name": "myapp", "image": "nginx:latest", "replicas": 5})
+	if err := env.handler.Create(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp handlers.PolicyViolationResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != "policy_violation" || resp.Category != "validation" {
+		t.Errorf("unexpected error code %q category %q", resp.Code, resp.Category)
+	}
+	if len(resp.Violations) != 1 || resp.Violations[0].Rule != "replicas" {
+		t.Errorf("unexpected violations %+v", resp.Violations)
+	}
+
+	rec, c = env.jsonRequest(http.MethodPost, "/api/v1/applications", sid, map[string]any{"name": "myapp", "image": "nginx:latest"})
+	if err := env.handler.Create(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec, c = env.jsonRequest(http.MethodPost, "/api/v1/applications/myapp/source", sid, map[string]any{"files": map[string]string{".env": "SECRET=1"}})
+	setParam(c, "name", "myapp")
+	if err := env.handler.UploadSource(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for the source upload, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var app iafv1alpha1.Application
+	if err := env.client.Get(ctx, ctrlclient.ObjectKey{Name: "myapp", Namespace: ns}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if app.Spec.Blob != "" || app.Spec.Image != "nginx:latest" {
+		t.Errorf("expected the blocked upload to leave the app unchanged, got %+v", app.Spec)
+	}
+}
+
+func TestApplicationHandler_Metadata(t *testing.T) {
+	env := setupHandlerTest(t)
+	ctx := context.Background()
+	sid, ns := env.newSession(t, "agent")
+	if err := env.sessions.SetMetadata(sid, &iafv1alpha1.OwnershipMetadata{Team: "payments", Owner: "jane@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	call := func(handler echo.HandlerFunc, method, name string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		rec, c := env.jsonRequest(method, "/api/v1/applications", sid, body)
+		if name != "" {
+			c.SetParamNames("name")
+			c.SetParamValues(name)
+		}
+		if err := handler(c); err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+	get := func(name string) *iafv1alpha1.Application {
+		t.Helper()
+		var app iafv1alpha1.Application
+		if err := env.client.Get(ctx, ctrlclient.ObjectKey{Name: name, Namespace: ns}, &app); err != nil {
+			t.Fatal(err)
+		}
+		return &app
+	}
+
+	if rec := call(env.handler.Create, http.MethodPost, "", map[string]any{"name": "web", "image": "nginx:latest"}); rec.Code != http.StatusCreated {
+		t.Fatalf("status %d, want 201 (body: %s)", rec.Code, rec.Body.String())
+	}
+	if m := get("web").Spec.Metadata; m == nil || m.Team != "payments" || m.Owner != "jane@example.com" {
+		t.Errorf("expected the session metadata, got %+v", m)
+	}
+
+	if rec := call(env.handler.Update, http.MethodPatch, "web", map[string]any{"metadata": map[string]any{"costCenter": "bad value"}}); rec.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want 400 for an invalid cost center", rec.Code)
+	}
+	if rec := call(env.handler.Update, http.MethodPatch, "web", map[string]any{"metadata": map[string]any{}}); rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+	}
+	if m := get("web").Spec.Metadata; m != nil {
+		t.Errorf("expected an empty object to remove the metadata, got %+v", m)
+	}
+}
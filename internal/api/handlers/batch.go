@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/validation"
+	"github.com/labstack/echo/v4"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// maxBatchNames bounds the names accepted by a single batch request.
+const maxBatchNames = 100
+
+// BatchDeleteRequest selects the applications removed by BatchDelete.
+// Exactly one of Names or All must be set.
+type BatchDeleteRequest struct {
+	Names  []string `json:"names,omitempty"`
+	All    bool     `json:"all,omitempty"`
+	DryRun bool     `json:"dryRun,omitempty"`
+}
+
+// BatchRequest is the body of the admin session endpoints.
+type BatchRequest struct {
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// BatchItem reports why one application was skipped or failed.
+type BatchItem struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// BatchResponse summarizes a bulk operation. With DryRun set, Affected
+// lists what would have changed and nothing was modified.
+type BatchResponse struct {
+	Action    string      `json:"action"`
+	Namespace string      `json:"namespace"`
+	DryRun    bool        `json:"dryRun"`
+	Affected  []string    `json:"affected"`
+	Skipped   []BatchItem `json:"skipped,omitempty"`
+	Failed    []BatchItem `json:"failed,omitempty"`
+}
+
+// BatchDelete deletes several applications in the session, or all of them.
+// Per-application failures are reported in the summary rather than
+// aborting the batch.
+func (h *ApplicationHandler) BatchDelete(c echo.Context) error {
+	namespace, err := sessionNamespace(c, h.namespaces)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	var req BatchDeleteRequest
+	if err := bindJSON(c, &req); err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+	if req.All == (len(req.Names) > 0) {
+		return errorJSON(c, http.StatusBadRequest, "set either names or all=true")
+	}
+	if len(req.Names) > maxBatchNames {
+		return errorJSON(c, http.StatusBadRequest, fmt.Sprintf("at most %d names per request", maxBatchNames))
+	}
+	for _, name := range req.Names {
+		if err := validation.ValidateAppName(name); err != nil {
+			return errorJSON(c, http.StatusBadRequest, fmt.Sprintf("invalid name %q: %v", name, err))
+		}
+	}
+
+	ctx := c.Request().Context()
+	apps, missing, err := selectApps(ctx, h.client, namespace, req.Names)
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, err.Error())
+	}
+	resp := BatchResponse{Action: "delete", Namespace: namespace, DryRun: req.DryRun, Affected: []string{}, Skipped: missing}
+	for i := range apps {
+		app := &apps[i]
+		if app.Spec.DeletionProtected {
+			resp.Skipped = append(resp.Skipped, BatchItem{Name: app.Name, Reason: "deletion protected"})
+			continue
+		}
+		if !req.DryRun {
+			if err := h.client.Delete(ctx, app); err != nil && !apierrors.IsNotFound(err) {
+				resp.Failed = append(resp.Failed, BatchItem{Name: app.Name, Reason: err.Error()})
+				continue
+			}
+			if h.store != nil {
+				_ = h.store.Delete(namespace, app.Name)
+			}
+		}
+		resp.Affected = append(resp.Affected, app.Name)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// AdminHandler serves operator endpoints that act on a whole session. Its
+// routes are mounted only when admin tokens are configured.
+type AdminHandler struct {
+	client   client.Client
+	sessions *auth.SessionStore
+}
+
+func NewAdminHandler(c client.Client, sessions *auth.SessionStore) *AdminHandler {
+	return &AdminHandler{client: c, sessions: sessions}
+}
+
+// SuspendSession scales every application in the session to zero replicas
+// by setting spec.suspended. Configuration, images and routes are kept.
+func (h *AdminHandler) SuspendSession(c echo.Context) error {
+	return h.setSuspended(c, true)
+}
+
+// ResumeSession clears spec.suspended on every application in the session.
+func (h *AdminHandler) ResumeSession(c echo.Context) error {
+	return h.setSuspended(c, false)
+}
+
+func (h *AdminHandler) setSuspended(c echo.Context, suspended bool) error {
+	sess, ok := h.sessions.Lookup(c.Param("id"))
+	if !ok {
+		return writeError(c, http.StatusNotFound, apierror.NotFound(apierror.CodeSessionNotFound, "session not found"))
+	}
+	var req BatchRequest
+	if err := bindJSON(c, &req); err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	ctx := c.Request().Context()
+	apps, _, err := selectApps(ctx, h.client, sess.Namespace, nil)
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, err.Error())
+	}
+	resp := BatchResponse{Action: "resume", Namespace: sess.Namespace, DryRun: req.DryRun, Affected: []string{}}
+	skipReason := "not suspended"
+	if suspended {
+		resp.Action, skipReason = "suspend", "already suspended"
+	}
+	for i := range apps {
+		app := &apps[i]
+		if app.Spec.Suspended == suspended {
+			resp.Skipped = append(resp.Skipped, BatchItem{Name: app.Name, Reason: skipReason})
+			continue
+		}
+		if !req.DryRun {
+			app.Spec.Suspended = suspended
+			if err := h.client.Update(ctx, app); err != nil {
+				resp.Failed = append(resp.Failed, BatchItem{Name: app.Name, Reason: err.Error()})
+				continue
+			}
+		}
+		resp.Affected = append(resp.Affected, app.Name)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// selectApps lists the applications in namespace, restricted to names when
+// given. Requested names that do not exist are returned as skipped items.
+func selectApps(ctx context.Context, c client.Client, namespace string, names []string) ([]iafv1alpha1.Application, []BatchItem, error) {
+	var list iafv1alpha1.ApplicationList
+	if err := c.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		return nil, nil, fmt.Errorf("listing applications: %w", err)
+	}
+	if len(names) == 0 {
+		return list.Items, nil, nil
+	}
+	var selected []iafv1alpha1.Application
+	var missing []BatchItem
+	for _, name := range names {
+		if slices.ContainsFunc(selected, func(a iafv1alpha1.Application) bool { return a.Name == name }) {
+			continue
+		}
+		i := slices.IndexFunc(list.Items, func(a iafv1alpha1.Application) bool { return a.Name == name })
+		if i < 0 {
+			missing = append(missing, BatchItem{Name: name, Reason: "not found"})
+			continue
+		}
+		selected = append(selected, list.Items[i])
+	}
+	return selected, missing, nil
+}
@@ -0,0 +1,148 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/api/handlers"
+	"github.com/dlapiduz/iaf/internal/grafana"
+	"github.com/labstack/echo/v4"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func batchRequest(t *testing.T, handler echo.HandlerFunc, body, sessionID, pathID string) (*httptest.ResponseRecorder, handlers.BatchResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if sessionID != "" {
+		req.Header.Set("X-IAF-Session", sessionID)
+	}
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	if pathID != "" {
+		c.SetParamNames("id")
+		c.SetParamValues(pathID)
+	}
+	if err := handler(c); err != nil {
+		t.Fatal(err)
+	}
+	var resp handlers.BatchResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec, resp
+}
+
+func createApps(t *testing.T, c ctrlclient.Client, namespace string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		app := &iafv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest"},
+		}
+		if err := c.Create(t.Context(), app); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestApplicationHandler_BatchDelete(t *testing.T) {
+	k8sClient, sessions := setupListTest(t)
+	sess, err := sessions.Register("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	createApps(t, k8sClient, sess.Namespace, "web", "api", "worker")
+	h := handlers.NewApplicationHandler(k8sClient, sessions, nil, grafana.Config{}, false)
+
+	for _, body := range []string{`{}`, `{"all":true,"names":["web"]}`, `{"names":["Bad_Name"]}`} {
+		if rec, _ := batchRequest(t, h.BatchDelete, body, sess.ID, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("body %s: status = %d, want 400", body, rec.Code)
+		}
+	}
+
+	rec, resp := batchRequest(t, h.BatchDelete, `{"names":["web","missing"],"dryRun":true}`, sess.ID, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("dry run status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if !resp.DryRun || !slices.Equal(resp.Affected, []string{"web"}) || len(resp.Skipped) != 1 || resp.Skipped[0].Name != "missing" {
+		t.Errorf("dry run response = %+v", resp)
+	}
+	var app iafv1alpha1.Application
+	if err := k8sClient.Get(t.Context(), types.NamespacedName{Name: "web", Namespace: sess.Namespace}, &app); err != nil {
+		t.Errorf("dry run deleted web: %v", err)
+	}
+
+	keep := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "keep", Namespace: sess.Namespace},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest", DeletionProtected: true},
+	}
+	if err := k8sClient.Create(t.Context(), keep); err != nil {
+		t.Fatal(err)
+	}
+	_, resp = batchRequest(t, h.BatchDelete, `{"all":true}`, sess.ID, "")
+	slices.Sort(resp.Affected)
+	if !slices.Equal(resp.Affected, []string{"api", "web", "worker"}) {
+		t.Errorf("affected = %v", resp.Affected)
+	}
+	if len(resp.Skipped) != 1 || resp.Skipped[0].Name != "keep" || resp.Skipped[0].Reason != "deletion protected" {
+		t.Errorf("skipped = %+v, want the protected app", resp.Skipped)
+	}
+	var list iafv1alpha1.ApplicationList
+	if err := k8sClient.List(t.Context(), &list, ctrlclient.InNamespace(sess.Namespace)); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Name != "keep" {
+		t.Errorf("expected only the protected app to remain, got %d apps", len(list.Items))
+	}
+}
+
+func TestAdminHandler_SuspendResume(t *testing.T) {
+	k8sClient, sessions := setupListTest(t)
+	sess, err := sessions.Register("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	createApps(t, k8sClient, sess.Namespace, "web", "api")
+	h := handlers.NewAdminHandler(k8sClient, sessions)
+
+	if rec, _ := batchRequest(t, h.SuspendSession, `{}`, "", "unknown"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown session: status = %d, want 404", rec.Code)
+	}
+
+	suspended := func(name string) bool {
+		var app iafv1alpha1.Application
+		if err := k8sClient.Get(t.Context(), types.NamespacedName{Name: name, Namespace: sess.Namespace}, &app); err != nil {
+			t.Fatal(err)
+		}
+		return app.Spec.Suspended
+	}
+
+	_, resp := batchRequest(t, h.SuspendSession, `{"dryRun":true}`, "", sess.ID)
+	if len(resp.Affected) != 2 || suspended("web") {
+		t.Errorf("dry run: affected = %v, web suspended = %v", resp.Affected, suspended("web"))
+	}
+
+	_, resp = batchRequest(t, h.SuspendSession, ``, "", sess.ID)
+	if resp.Action != "suspend" || len(resp.Affected) != 2 || !suspended("web") || !suspended("api") {
+		t.Errorf("suspend response = %+v", resp)
+	}
+	_, resp = batchRequest(t, h.SuspendSession, `{}`, "", sess.ID)
+	if len(resp.Affected) != 0 || len(resp.Skipped) != 2 {
+		t.Errorf("second suspend should skip both apps: %+v", resp)
+	}
+
+	_, resp = batchRequest(t, h.ResumeSession, `{}`, "", sess.ID)
+	if resp.Action != "resume" || len(resp.Affected) != 2 || suspended("web") {
+		t.Errorf("resume response = %+v", resp)
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/cost"
+	"github.com/dlapiduz/iaf/internal/features"
+	"github.com/labstack/echo/v4"
+)
+
+// defaultCostDays is how many days the cost roll-up covers by default.
+const defaultCostDays = 7
+
+// CostHandler serves the operator cost roll-up. Its route is mounted only
+// when admin tokens are configured.
+type CostHandler struct {
+	sessions  *auth.SessionStore
+	estimator *cost.Estimator
+}
+
+// NewCostHandler returns a CostHandler. A nil estimator makes every request
+// fail with 503 Service Unavailable.
+func NewCostHandler(sessions *auth.SessionStore, estimator *cost.Estimator) *CostHandler {
+	return &CostHandler{sessions: sessions, estimator: estimator}
+}
+
+// CostReportResponse is the estimated spend of every session, most expensive
+// first.
+type CostReportResponse struct {
+	Days         int                   `json:"days"`
+	Currency     string                `json:"currency"`
+	CPUCoreHour  float64               `json:"cpuCoreHour"`
+	MemoryGBHour float64               `json:"memoryGbHour"`
+	Total        float64               `json:"total"`
+	Sessions     []SessionCostResponse `json:"sessions"`
+}
+
+// SessionCostResponse is the estimated spend of one session namespace. The
+// session fields are empty once the session has been deleted.
+type SessionCostResponse struct {
+	SessionID     string              `json:"sessionId,omitempty"`
+	Name          string              `json:"name,omitempty"`
+	Namespace     string              `json:"namespace"`
+	CPUCoreHours  float64             `json:"cpuCoreHours"`
+	MemoryGBHours float64             `json:"memoryGbHours"`
+	Cost          float64             `json:"cost"`
+	Daily         []DailyCostResponse `json:"daily"`
+}
+
+// DailyCostResponse is the estimated spend of a session on one UTC day.
+type DailyCostResponse struct {
+	Date          string  `json:"date"`
+	CPUCoreHours  float64 `json:"cpuCoreHours"`
+	MemoryGBHours float64 `json:"memoryGbHours"`
+	Cost          float64 `json:"cost"`
+}
+
+// Report returns the estimated spend per session per day over the last
+// ?days=N days (default 7, max 31).
+func (h *CostHandler) Report(c echo.Context) error {
+	if h.estimator == nil {
+		return writeError(c, http.StatusNotImplemented, features.Disabled(features.Costs))
+	}
+	days := defaultCostDays
+	if v := c.QueryParam("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > cost.MaxDays {
+			return errorJSON(c, http.StatusBadRequest, "days must be an integer between 1 and 31")
+		}
+		days = n
+	}
+
+	usage, err := h.estimator.Usage.DailyUsage(c.Request().Context(), days)
+	if err != nil {
+		return errorJSON(c, http.StatusBadGateway, err.Error())
+	}
+	byNamespace := map[string]*auth.Session{}
+	for _, sess := range h.sessions.List() {
+		byNamespace[sess.Namespace] = sess
+	}
+
+	rates := h.estimator.Rates
+	resp := CostReportResponse{
+		Days:         days,
+		Currency:     rates.Currency,
+		CPUCoreHour:  rates.CPUCoreHour,
+		MemoryGBHour: rates.MemoryGBHour,
+		Sessions:     []SessionCostResponse{},
+	}
+	for namespace, daily := range usage {
+		item := SessionCostResponse{Namespace: namespace, Daily: make([]DailyCostResponse, 0, len(daily))}
+		if sess, ok := byNamespace[namespace]; ok {
+			item.SessionID, item.Name = sess.ID, sess.Name
+		}
+		var total cost.Usage
+		for _, d := range daily {
+			total = total.Add(d.Usage)
+			item.Daily = append(item.Daily, DailyCostResponse{
+				Date:          d.Day.Format("2006-01-02"),
+				CPUCoreHours:  cost.Round(d.CPUCoreHours),
+				MemoryGBHours: cost.Round(d.MemoryGBHours),
+				Cost:          rates.Cost(d.Usage),
+			})
+		}
+		item.CPUCoreHours = cost.Round(total.CPUCoreHours)
+		item.MemoryGBHours = cost.Round(total.MemoryGBHours)
+		item.Cost = rates.Cost(total)
+		resp.Total += item.Cost
+		resp.Sessions = append(resp.Sessions, item)
+	}
+	resp.Total = cost.Round(resp.Total)
+	sort.Slice(resp.Sessions, func(i, j int) bool {
+		if resp.Sessions[i].Cost != resp.Sessions[j].Cost {
+			return resp.Sessions[i].Cost > resp.Sessions[j].Cost
+		}
+		return resp.Sessions[i].Namespace < resp.Sessions[j].Namespace
+	})
+	return c.JSON(http.StatusOK, resp)
+}
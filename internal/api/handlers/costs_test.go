@@ -0,0 +1,100 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dlapiduz/iaf/internal/api/handlers"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/cost"
+	"github.com/labstack/echo/v4"
+)
+
+// fakeDailyUsage is a cost.Querier returning fixed daily usage.
+type fakeDailyUsage struct {
+	daily map[string][]cost.DailyUsage
+	days  int
+}
+
+func (f *fakeDailyUsage) Usage(context.Context, string, string, time.Duration) (cost.Usage, error) {
+	return cost.Usage{}, nil
+}
+
+func (f *fakeDailyUsage) DailyUsage(_ context.Context, days int) (map[string][]cost.DailyUsage, error) {
+	f.days = days
+	return f.daily, nil
+}
+
+func costRequest(t *testing.T, h *handlers.CostHandler, query string) (*httptest.ResponseRecorder, handlers.CostReportResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/costs"+query, nil)
+	rec := httptest.NewRecorder()
+	if err := h.Report(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatal(err)
+	}
+	var resp handlers.CostReportResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec, resp
+}
+
+func TestCostHandler_Report(t *testing.T) {
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess, err := sessions.Register("agent-a", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day1 := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	usage := &fakeDailyUsage{daily: map[string][]cost.DailyUsage{
+		sess.Namespace: {
+			{Day: day1, Usage: cost.Usage{CPUCoreHours: 10, MemoryGBHours: 100}},
+			{Day: day2, Usage: cost.Usage{CPUCoreHours: 5, MemoryGBHours: 50}},
+		},
+		"iaf-00000000000000000000000000000000": {
+			{Day: day2, Usage: cost.Usage{CPUCoreHours: 100}},
+		},
+	}}
+	h := handlers.NewCostHandler(sessions, &cost.Estimator{
+		Usage: usage,
+		Rates: cost.Rates{CPUCoreHour: 0.04, MemoryGBHour: 0.005, Currency: "USD"},
+	})
+
+	rec, resp := costRequest(t, h, "?days=2")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	if usage.days != 2 || resp.Days != 2 || resp.Currency != "USD" || resp.Total != 5.35 {
+		t.Errorf("unexpected report %+v", resp)
+	}
+	if len(resp.Sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(resp.Sessions))
+	}
+	// The deleted session's namespace costs more, so it comes first.
+	if resp.Sessions[0].SessionID != "" || resp.Sessions[0].Cost != 4 {
+		t.Errorf("unexpected first session %+v", resp.Sessions[0])
+	}
+	got := resp.Sessions[1]
+	if got.SessionID != sess.ID || got.Name != "agent-a" || got.Cost != 1.35 || len(got.Daily) != 2 || got.Daily[0].Date != "2026-03-09" || got.Daily[0].Cost != 0.9 {
+		t.Errorf("unexpected session %+v", got)
+	}
+
+	if rec, _ := costRequest(t, h, "?days=0"); rec.Code != http.StatusBadRequest {
+		t.Errorf("days=0: status = %d, want 400", rec.Code)
+	}
+	if rec, _ := costRequest(t, handlers.NewCostHandler(sessions, nil), ""); rec.Code != http.StatusNotImplemented || !strings.Contains(rec.Body.String(), `"capability_disabled"`) {
+		t.Errorf("not configured: status = %d, body %s, want 501 capability_disabled", rec.Code, rec.Body)
+	}
+}
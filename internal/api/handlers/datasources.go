@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/service"
+	"github.com/labstack/echo/v4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type DataSourceHandler struct {
+	client   client.Client
+	sessions *service.Sessions
+}
+
+func NewDataSourceHandler(c client.Client, sessions *auth.SessionStore) *DataSourceHandler {
+	return &DataSourceHandler{
+		client:   c,
+		sessions: service.NewSessions(c, sessions, 0),
+	}
+}
+
+// DataSourceResponse is the API representation of a DataSource. Only metadata
+// is returned — never the referenced Secret or its location.
+type DataSourceResponse struct {
+	Name        string   `json:"name"`
+	Kind        string   `json:"kind"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	EnvVarNames []string `json:"envVarNames"`
+}
+
+// List returns the platform data sources. Accepts an optional "kind" query
+// param. A valid session is required even though DataSources are cluster-scoped.
+func (h *DataSourceHandler) List(c echo.Context) error {
+	if _, err := sessionNamespace(c, h.sessions); err != nil {
+		return writeServiceError(c, err)
+	}
+	kind := c.QueryParam("kind")
+
+	var list iafv1alpha1.DataSourceList
+	if err := h.client.List(c.Request().Context(), &list); err != nil {
+		return errorJSON(c, http.StatusInternalServerError, err.Error())
+	}
+
+	sources := make([]DataSourceResponse, 0, len(list.Items))
+	for _, ds := range list.Items {
+		if kind != "" && ds.Spec.Kind != kind {
+			continue
+		}
+		envVarNames := make([]string, 0, len(ds.Spec.EnvVarMapping))
+		for _, v := range ds.Spec.EnvVarMapping {
+			envVarNames = append(envVarNames, v)
+		}
+		sort.Strings(envVarNames)
+		sources = append(sources, DataSourceResponse{
+			Name:        ds.Name,
+			Kind:        ds.Spec.Kind,
+			Description: ds.Spec.Description,
+			Tags:        ds.Spec.Tags,
+			EnvVarNames: envVarNames,
+		})
+	}
+	return c.JSON(http.StatusOK, sources)
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/policy"
+	"github.com/labstack/echo/v4"
+)
+
+// ErrorResponse documents the body returned by every handler on failure. It
+// is the same structured error the MCP tools return.
+type ErrorResponse = apierror.Response
+
+// errorJSON writes an error response with message, classified by status.
+func errorJSON(c echo.Context, status int, message string) error {
+	return writeError(c, status, apierror.FromStatus(status, message))
+}
+
+// writeError writes err as the error response, for failures with a more
+// specific code than the status implies.
+func writeError(c echo.Context, status int, err *apierror.Error) error {
+	return c.JSON(status, err.Response())
+}
+
+// writeServiceError writes an error returned by the service layer with the
+// status its classification implies. Policy violations are answered with
+// 403 and the rules that were broken.
+func writeServiceError(c echo.Context, err error) error {
+	var violation *policy.ViolationError
+	if errors.As(err, &violation) {
+		return c.JSON(http.StatusForbidden, PolicyViolationResponse{
+			ErrorResponse: apierror.Validation(apierror.CodePolicyViolation, "%s", err.Error()).Response(),
+			Violations:    violation.Violations,
+		})
+	}
+	e := apierror.From(err)
+	return writeError(c, apierror.HTTPStatus(e), e)
+}
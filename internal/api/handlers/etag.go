@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/labstack/echo/v4"
+)
+
+// An application's ETag is its version in quotes, so it changes exactly
+// when the configuration does and not while the app builds or scales.
+// Clients send it back in If-Match to write only the version they read.
+
+// setETag sets the ETag header of a response carrying app.
+func setETag(c echo.Context, app *iafv1alpha1.Application) {
+	c.Response().Header().Set("ETag", strconv.Quote(strconv.FormatInt(iafk8s.AppVersion(app), 10)))
+}
+
+// ifMatch reports whether the request has an If-Match header.
+func ifMatch(c echo.Context) bool {
+	return c.Request().Header.Get("If-Match") != ""
+}
+
+// expectedVersion returns the version a write to the named application
+// requires: the one in If-Match, or bodyVersion (expectedVersion in the
+// body) without it. If-Match: * requires the application to exist, at any
+// version. 0 means any version.
+func (h *ApplicationHandler) expectedVersion(c echo.Context, namespace, name string, bodyVersion int64) (int64, error) {
+	header := strings.TrimSpace(c.Request().Header.Get("If-Match"))
+	if header == "" {
+		return bodyVersion, nil
+	}
+	if bodyVersion != 0 {
+		return 0, apierror.Validation(apierror.CodeInvalidRequest, "set either the If-Match header or expectedVersion, not both")
+	}
+	if header == "*" {
+		app, err := h.apps.Get(c.Request().Context(), namespace, name)
+		if err != nil {
+			return 0, err
+		}
+		return iafk8s.AppVersion(app), nil
+	}
+	// Versions are compared strongly, so weak ETags never match.
+	unquoted, err := strconv.Unquote(header)
+	version, perr := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || perr != nil || version <= 0 {
+		return 0, apierror.Validation(apierror.CodeInvalidRequest, "If-Match %s is not an application ETag; send the ETag header of a GET, such as \"3\", or *", header)
+	}
+	return version, nil
+}
+
+// writePreconditionError writes an error returned by a write to an
+// application. When the request has an If-Match header, a version conflict
+// or a missing application fails the precondition and is answered with
+// 412; otherwise err is written as is.
+func writePreconditionError(c echo.Context, err error) error {
+	e := apierror.From(err)
+	var failed *apierror.Error
+	if ifMatch(c) {
+		switch e.Code {
+		case apierror.CodeVersionConflict:
+			failed = e.WithHint("GET the application for its current ETag, reapply your change to it, and retry with that ETag in If-Match")
+		case apierror.CodeAppNotFound:
+			failed = e.WithHint("the application does not exist; drop If-Match to create it")
+		}
+	}
+	if failed == nil {
+		return writeServiceError(c, err)
+	}
+	failed.Code, failed.Category = apierror.CodePreconditionFailed, apierror.CategoryConflict
+	failed.Message = fmt.Sprintf("If-Match precondition failed: %s", e.Message)
+	return writeError(c, apierror.HTTPStatus(failed), failed)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/export"
+	"github.com/labstack/echo/v4"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ExportResponse is the API representation of an exported application.
+// Manifests is set for the yaml format and Files for the helm format.
+type ExportResponse struct {
+	Name           string            `json:"name"`
+	Format         string            `json:"format"`
+	Manifests      string            `json:"manifests,omitempty"`
+	Files          map[string]string `json:"files,omitempty"`
+	OmittedSecrets []string          `json:"omittedSecrets"`
+}
+
+// Export renders the application's Kubernetes objects as YAML or a Helm
+// chart skeleton. It is the REST equivalent of the export_app MCP tool.
+func (h *ApplicationHandler) Export(c echo.Context) error {
+	namespace, err := sessionNamespace(c, h.namespaces)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "yaml"
+	}
+	if !slices.Contains(export.Formats, format) {
+		return errorJSON(c, http.StatusBadRequest, fmt.Sprintf("unsupported format %q: use one of %s", format, strings.Join(export.Formats, ", ")))
+	}
+
+	ctx := c.Request().Context()
+	var app iafv1alpha1.Application
+	if err := h.client.Get(ctx, types.NamespacedName{Name: c.Param("name"), Namespace: namespace}, &app); err != nil {
+		if apierrors.IsNotFound(err) {
+			return writeError(c, http.StatusNotFound, apierror.NotFound(apierror.CodeAppNotFound, "application not found"))
+		}
+		return errorJSON(c, http.StatusInternalServerError, err.Error())
+	}
+
+	bundle, err := export.Collect(ctx, h.client, &app)
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, err.Error())
+	}
+	if len(bundle.Objects) == 0 {
+		return errorJSON(c, http.StatusConflict, "application has no deployed resources yet")
+	}
+
+	resp := ExportResponse{Name: app.Name, Format: format, OmittedSecrets: bundle.Secrets}
+	if format == "helm" {
+		resp.Files, err = bundle.HelmChart()
+	} else {
+		resp.Manifests, err = bundle.YAML()
+	}
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, resp)
+}
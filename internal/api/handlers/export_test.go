@@ -0,0 +1,93 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/api/handlers"
+	"github.com/dlapiduz/iaf/internal/grafana"
+	"github.com/labstack/echo/v4"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func exportRequest(t *testing.T, h *handlers.ApplicationHandler, name, query, sessionID string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/applications/"+name+"/export"+query, nil)
+	req.Header.Set("X-IAF-Session", sessionID)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("name")
+	c.SetParamValues(name)
+	if err := h.Export(c); err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestApplicationHandler_Export(t *testing.T) {
+	k8sClient, sessions := setupListTest(t)
+	sess, err := sessions.Register("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"web", "pending"} {
+		app := &iafv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: sess.Namespace},
+			Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest"},
+		}
+		if err := k8sClient.Create(t.Context(), app); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc := &unstructured.Unstructured{}
+	svc.SetAPIVersion("v1")
+	svc.SetKind("Service")
+	svc.SetName("web")
+	svc.SetNamespace(sess.Namespace)
+	_ = unstructured.SetNestedField(svc.Object, "10.0.0.1", "spec", "clusterIP")
+	if err := k8sClient.Create(t.Context(), svc); err != nil {
+		t.Fatal(err)
+	}
+	h := handlers.NewApplicationHandler(k8sClient, sessions, nil, grafana.Config{}, false)
+
+	rec := exportRequest(t, h, "web", "", sess.ID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp handlers.ExportResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Format != "yaml" || !strings.Contains(resp.Manifests, "kind: Service") || strings.Contains(resp.Manifests, "clusterIP") {
+		t.Errorf("response = %+v, want cleaned Service manifest", resp)
+	}
+
+	rec = exportRequest(t, h, "web", "?format=helm", sess.ID)
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || resp.Files["Chart.yaml"] == "" || resp.Files["templates/service-web.yaml"] == "" {
+		t.Errorf("helm export = %d %+v", rec.Code, resp)
+	}
+
+	tests := []struct {
+		name, app, query string
+		want             int
+	}{
+		{name: "bad format", app: "web", query: "?format=kustomize", want: http.StatusBadRequest},
+		{name: "missing app", app: "nope", want: http.StatusNotFound},
+		{name: "not deployed yet", app: "pending", want: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := exportRequest(t, h, tt.app, tt.query, sess.ID); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/graphqlapi"
+	"github.com/dlapiduz/iaf/internal/service"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	"github.com/labstack/echo/v4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type GraphQLHandler struct {
+	schema   *graphqlapi.Schema
+	sessions *service.Sessions
+}
+
+func NewGraphQLHandler(c client.Client, sessions *auth.SessionStore, store *sourcestore.Store) (*GraphQLHandler, error) {
+	schema, err := graphqlapi.New(c, store)
+	if err != nil {
+		return nil, err
+	}
+	return &GraphQLHandler{schema: schema, sessions: service.NewSessions(c, sessions, 0)}, nil
+}
+
+// GraphQLRequest is the request body of a GraphQL query.
+type GraphQLRequest struct {
+	Query         string         `json:"query" validate:"required"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// GraphQLResponse is a GraphQL result. Query errors are reported in errors
+// with status 200, as GraphQL clients expect.
+type GraphQLResponse struct {
+	Data   map[string]any   `json:"data,omitempty"`
+	Errors []map[string]any `json:"errors,omitempty"`
+}
+
+// Query runs a read-only GraphQL query against the session namespace.
+func (h *GraphQLHandler) Query(c echo.Context) error {
+	namespace, err := sessionNamespace(c, h.sessions)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+
+	var req GraphQLRequest
+	if err := bindJSON(c, &req); err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, h.schema.Exec(c.Request().Context(), namespace, req.Query, req.OperationName, req.Variables))
+}
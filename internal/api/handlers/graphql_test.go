@@ -0,0 +1,95 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/api/handlers"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	"github.com/labstack/echo/v4"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGraphQLHandler_Query(t *testing.T) {
+	k8sClient, sessions := setupListTest(t)
+	sess, err := sessions.Register("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := sessions.Register("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, app := range []*iafv1alpha1.Application{
+		{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: sess.Namespace}, Spec: iafv1alpha1.ApplicationSpec{Image: "nginx:latest", Port: 8080}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "hidden", Namespace: other.Namespace}, Spec: iafv1alpha1.ApplicationSpec{Image: "nginx:latest"}},
+	} {
+		if err := k8sClient.Create(t.Context(), app); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := handlers.NewGraphQLHandler(k8sClient, sessions, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := func(sessionID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if sessionID != "" {
+			req.Header.Set("X-IAF-Session", sessionID)
+		}
+		rec := httptest.NewRecorder()
+		if err := h.Query(echo.New().NewContext(req, rec)); err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+
+	rec := query(sess.ID, `{"query":"query Apps($n: String!) { applications { name port } application(name: $n) { name } }","variables":{"n":"hidden"}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d (body: %s)", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Applications []struct {
+				Name string
+				Port int
+			}
+			Application *struct{ Name string }
+		}
+		Errors []any
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Errors) > 0 {
+		t.Fatalf("errors: %v", resp.Errors)
+	}
+	if len(resp.Data.Applications) != 1 || resp.Data.Applications[0].Name != "web" || resp.Data.Applications[0].Port != 8080 {
+		t.Errorf("applications = %+v", resp.Data.Applications)
+	}
+	if resp.Data.Application != nil {
+		t.Errorf("application from another session resolved: %+v", resp.Data.Application)
+	}
+
+	if rec := query("", `{"query":"{ applications { name } }"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing session: status %d, want 400", rec.Code)
+	}
+	if rec := query(sess.ID, `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing query: status %d, want 400", rec.Code)
+	}
+	rec = query(sess.ID, `{"query":"mutation { deleteApplication(name: \"web\") }"}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"errors"`) {
+		t.Errorf("mutation: status %d body %s, want a GraphQL error", rec.Code, rec.Body.String())
+	}
+}
@@ -3,13 +3,21 @@ package handlers
 import (
 	"net/http"
 
+	"github.com/dlapiduz/iaf/internal/platformhealth"
 	"github.com/labstack/echo/v4"
 )
 
-type HealthHandler struct{}
+// PlatformHealthResponse is the health of the platform's components.
+type PlatformHealthResponse = platformhealth.Report
 
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+type HealthHandler struct {
+	platform *platformhealth.Checker
+}
+
+// NewHealthHandler returns the health endpoints. platform checks the
+// systems behind GET /platform/health; when nil that endpoint answers 503.
+func NewHealthHandler(platform *platformhealth.Checker) *HealthHandler {
+	return &HealthHandler{platform: platform}
 }
 
 func (h *HealthHandler) Health(c echo.Context) error {
@@ -19,3 +27,17 @@ func (h *HealthHandler) Health(c echo.Context) error {
 func (h *HealthHandler) Ready(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]string{"status": "ready"})
 }
+
+// Platform reports the health of the systems the platform depends on. It
+// answers 503 when a required one is failing, so it can back a monitor.
+func (h *HealthHandler) Platform(c echo.Context) error {
+	if h.platform == nil {
+		return errorJSON(c, http.StatusServiceUnavailable, "platform health checks are not configured")
+	}
+	report := h.platform.Check(c.Request().Context())
+	status := http.StatusOK
+	if report.Status == platformhealth.StatusFailing {
+		status = http.StatusServiceUnavailable
+	}
+	return c.JSON(status, report)
+}
@@ -6,13 +6,17 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
 	"github.com/dlapiduz/iaf/internal/auth"
 	k8shelper "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/service"
 	"github.com/labstack/echo/v4"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -21,41 +25,43 @@ import (
 type LogsHandler struct {
 	client    client.Client
 	clientset kubernetes.Interface
-	sessions  *auth.SessionStore
+	sessions  *service.Sessions
+}
+
+// LogsResponse is the body returned by GetLogs.
+type LogsResponse struct {
+	Logs          string   `json:"logs"`
+	Pods          int      `json:"pods"`
+	PodName       string   `json:"podName,omitempty"`
+	AvailablePods []string `json:"availablePods"`
+}
+
+// BuildLogsResponse is the body returned by GetBuildLogs.
+type BuildLogsResponse struct {
+	BuildLogs   string `json:"buildLogs"`
+	BuildStatus string `json:"buildStatus"`
+	PodName     string `json:"podName,omitempty"`
 }
 
 func NewLogsHandler(c client.Client, cs kubernetes.Interface, sessions *auth.SessionStore) *LogsHandler {
 	return &LogsHandler{
 		client:    c,
 		clientset: cs,
-		sessions:  sessions,
-	}
-}
-
-func (h *LogsHandler) resolveNamespace(c echo.Context) (string, error) {
-	sessionID := c.Request().Header.Get("X-IAF-Session")
-	if sessionID == "" {
-		sessionID = c.QueryParam("session_id")
-	}
-	if sessionID == "" {
-		return "", fmt.Errorf("missing session ID: provide X-IAF-Session header or session_id query parameter")
+		sessions:  service.NewSessions(c, sessions, 0),
 	}
-	sess, ok := h.sessions.Lookup(sessionID)
-	if !ok {
-		return "", fmt.Errorf("session not found, call register first")
-	}
-	return sess.Namespace, nil
 }
 
 // GetLogs returns logs for an application's pods. Accepts optional query params:
 //   - lines: number of log lines (default 100)
 //   - pod_name: fetch logs from a specific pod (validated against app's label selector)
+//   - since_time: only return lines logged at or after this RFC 3339 time
+//   - timestamps: prefix each line with its RFC 3339 timestamp ("true")
 //
 // Returns the selected pod's name and a list of all available pods.
 func (h *LogsHandler) GetLogs(c echo.Context) error {
-	namespace, err := h.resolveNamespace(c)
+	namespace, err := sessionNamespace(c, h.sessions)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return writeServiceError(c, err)
 	}
 
 	name := c.Param("name")
@@ -66,14 +72,26 @@ func (h *LogsHandler) GetLogs(c echo.Context) error {
 		}
 	}
 	podName := c.QueryParam("pod_name")
+	opts := &corev1.PodLogOptions{
+		Container:  "app",
+		TailLines:  &lines,
+		Timestamps: c.QueryParam("timestamps") == "true",
+	}
+	if since := c.QueryParam("since_time"); since != "" {
+		t, err := time.Parse(time.RFC3339Nano, since)
+		if err != nil {
+			return errorJSON(c, http.StatusBadRequest, "since_time must be an RFC 3339 timestamp")
+		}
+		opts.SinceTime = &metav1.Time{Time: t}
+	}
 
 	// Verify application exists
 	var app iafv1alpha1.Application
 	if err := h.client.Get(c.Request().Context(), types.NamespacedName{Name: name, Namespace: namespace}, &app); err != nil {
 		if apierrors.IsNotFound(err) {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "application not found"})
+			return writeError(c, http.StatusNotFound, apierror.NotFound(apierror.CodeAppNotFound, "application not found"))
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return errorJSON(c, http.StatusInternalServerError, err.Error())
 	}
 
 	// Get pods for the application
@@ -82,14 +100,12 @@ func (h *LogsHandler) GetLogs(c echo.Context) error {
 		client.InNamespace(namespace),
 		client.MatchingLabels{"iaf.io/application": name},
 	); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return errorJSON(c, http.StatusInternalServerError, err.Error())
 	}
 
 	if len(podList.Items) == 0 {
-		return c.JSON(http.StatusOK, map[string]any{
-			"logs":          "",
-			"pods":          0,
-			"availablePods": []string{},
+		return c.JSON(http.StatusOK, LogsResponse{
+			AvailablePods: []string{},
 		})
 	}
 
@@ -99,31 +115,31 @@ func (h *LogsHandler) GetLogs(c echo.Context) error {
 	if podName != "" {
 		pod, err = k8shelper.FindPodByName(podList.Items, podName, "iaf.io/application", name)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return errorJSON(c, http.StatusBadRequest, err.Error())
 		}
 	} else {
 		pod = k8shelper.SelectMostRecentPod(podList.Items)
 	}
 
-	logs, err := h.getPodLogs(c.Request().Context(), namespace, pod.Name, "app", lines)
+	logs, err := h.streamPodLogs(c.Request().Context(), namespace, pod.Name, opts)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return errorJSON(c, http.StatusInternalServerError, err.Error())
 	}
 
-	return c.JSON(http.StatusOK, map[string]any{
-		"logs":          logs,
-		"pods":          len(podList.Items),
-		"podName":       pod.Name,
-		"availablePods": availablePods,
+	return c.JSON(http.StatusOK, LogsResponse{
+		Logs:          logs,
+		Pods:          len(podList.Items),
+		PodName:       pod.Name,
+		AvailablePods: availablePods,
 	})
 }
 
 // GetBuildLogs returns kpack build logs for an application.
 // Uses the most recently started build pod (sort by CreationTimestamp descending).
 func (h *LogsHandler) GetBuildLogs(c echo.Context) error {
-	namespace, err := h.resolveNamespace(c)
+	namespace, err := sessionNamespace(c, h.sessions)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return writeServiceError(c, err)
 	}
 
 	name := c.Param("name")
@@ -132,24 +148,23 @@ func (h *LogsHandler) GetBuildLogs(c echo.Context) error {
 	var app iafv1alpha1.Application
 	if err := h.client.Get(c.Request().Context(), types.NamespacedName{Name: name, Namespace: namespace}, &app); err != nil {
 		if apierrors.IsNotFound(err) {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "application not found"})
+			return writeError(c, http.StatusNotFound, apierror.NotFound(apierror.CodeAppNotFound, "application not found"))
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return errorJSON(c, http.StatusInternalServerError, err.Error())
 	}
 
 	// Look for kpack build pods
 	podList := &corev1.PodList{}
 	if err := h.client.List(c.Request().Context(), podList,
 		client.InNamespace(namespace),
-		client.MatchingLabels{"image.kpack.io/image": name},
+		client.MatchingLabels{k8shelper.LabelKpackImage: name},
 	); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return errorJSON(c, http.StatusInternalServerError, err.Error())
 	}
 
 	if len(podList.Items) == 0 {
-		return c.JSON(http.StatusOK, map[string]any{
-			"buildLogs":   "",
-			"buildStatus": app.Status.BuildStatus,
+		return c.JSON(http.StatusOK, BuildLogsResponse{
+			BuildStatus: app.Status.BuildStatus,
 		})
 	}
 
@@ -164,18 +179,21 @@ func (h *LogsHandler) GetBuildLogs(c echo.Context) error {
 		allLogs += fmt.Sprintf("=== %s ===\n%s\n", container.Name, logs)
 	}
 
-	return c.JSON(http.StatusOK, map[string]any{
-		"buildLogs":   allLogs,
-		"buildStatus": app.Status.BuildStatus,
-		"podName":     pod.Name,
+	return c.JSON(http.StatusOK, BuildLogsResponse{
+		BuildLogs:   allLogs,
+		BuildStatus: app.Status.BuildStatus,
+		PodName:     pod.Name,
 	})
 }
 
 func (h *LogsHandler) getPodLogs(ctx context.Context, namespace, podName, container string, lines int64) (string, error) {
-	opts := &corev1.PodLogOptions{
+	return h.streamPodLogs(ctx, namespace, podName, &corev1.PodLogOptions{
 		Container: container,
 		TailLines: &lines,
-	}
+	})
+}
+
+func (h *LogsHandler) streamPodLogs(ctx context.Context, namespace, podName string, opts *corev1.PodLogOptions) (string, error) {
 	req := h.clientset.CoreV1().Pods(namespace).GetLogs(podName, opts)
 	stream, err := req.Stream(ctx)
 	if err != nil {
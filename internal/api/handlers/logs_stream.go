@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	k8shelper "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/labstack/echo/v4"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Intervals of a log stream. Variables so tests can shorten them.
+var (
+	// streamPodPollInterval is how often a followed stream looks for new
+	// pods, such as replicas started by a rollout or scale-up.
+	streamPodPollInterval = 5 * time.Second
+	// streamHeartbeatInterval is how often an idle stream sends a comment
+	// line so proxies do not close it.
+	streamHeartbeatInterval = 15 * time.Second
+)
+
+// maxStreamLine bounds a single log line; a longer line ends that pod's
+// stream with an error.
+const maxStreamLine = 64 << 10
+
+// LogLineEvent is the data of a "log" event sent by StreamLogs.
+type LogLineEvent struct {
+	Pod  string `json:"pod"`
+	Line string `json:"line"`
+}
+
+// LogPodEvent is the data of the "pod_started" and "pod_ended" events sent
+// by StreamLogs. Error is set when the pod's log stream failed.
+type LogPodEvent struct {
+	Pod   string `json:"pod"`
+	Error string `json:"error,omitempty"`
+}
+
+// StreamLogs streams an application's logs as server-sent events, merging
+// the logs of up to k8shelper.MaxAvailablePods replicas at a time. Accepts
+// optional query params:
+//   - lines: trailing lines to send from each pod before following (default 100)
+//   - follow: keep streaming new lines and pick up new pods ("true", the default)
+//   - pod_name: stream a single pod (validated against the app's label selector)
+//   - timestamps: prefix each line with its RFC 3339 timestamp ("true")
+//
+// Each line is a "log" event; "pod_started" and "pod_ended" mark the pods
+// joining and leaving the stream. Without follow, a final "done" event is
+// sent once every pod's logs have been sent.
+func (h *LogsHandler) StreamLogs(c echo.Context) error {
+	namespace, err := sessionNamespace(c, h.sessions)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+
+	name := c.Param("name")
+	lines := int64(100)
+	if l := c.QueryParam("lines"); l != "" {
+		parsed, err := strconv.ParseInt(l, 10, 64)
+		if err != nil || parsed < 0 {
+			return errorJSON(c, http.StatusBadRequest, "lines must be a non-negative integer")
+		}
+		lines = parsed
+	}
+	follow := c.QueryParam("follow") != "false"
+	podName := c.QueryParam("pod_name")
+	timestamps := c.QueryParam("timestamps") == "true"
+
+	ctx := c.Request().Context()
+	var app iafv1alpha1.Application
+	if err := h.client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &app); err != nil {
+		if apierrors.IsNotFound(err) {
+			return writeError(c, http.StatusNotFound, apierror.NotFound(apierror.CodeAppNotFound, "application not found"))
+		}
+		return errorJSON(c, http.StatusInternalServerError, err.Error())
+	}
+	pods, err := h.appPods(ctx, namespace, name, podName)
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events := make(chan sseEvent)
+	var wg sync.WaitGroup
+	// streaming records every pod ever streamed, so a pod is not relayed
+	// twice; active counts the relays that have not sent pod_ended yet.
+	streaming := map[string]bool{}
+	active := 0
+	start := func(pods []corev1.Pod) {
+		for _, pod := range pods {
+			if streaming[pod.Name] || active >= k8shelper.MaxAvailablePods {
+				continue
+			}
+			streaming[pod.Name] = true
+			active++
+			opts := &corev1.PodLogOptions{Container: "app", Follow: follow, Timestamps: timestamps, TailLines: &lines}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				h.relayPodLogs(ctx, namespace, pod.Name, opts, events)
+			}()
+		}
+	}
+	start(pods)
+
+	poll := time.NewTicker(streamPodPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+	defer func() {
+		// Unblock and wait for the relays before the response is released.
+		cancel()
+		go func() {
+			for range events {
+			}
+		}()
+		wg.Wait()
+		close(events)
+	}()
+
+	if !follow && active == 0 {
+		writeSSE(w, sseEvent{name: "done", data: struct{}{}})
+		return nil
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev := <-events:
+			if err := writeSSE(w, ev); err != nil {
+				return nil
+			}
+			if ev.name == "pod_ended" {
+				active--
+				if !follow && active == 0 {
+					writeSSE(w, sseEvent{name: "done", data: struct{}{}})
+					return nil
+				}
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return nil
+			}
+			w.Flush()
+		case <-poll.C:
+			if !follow || podName != "" {
+				continue
+			}
+			if pods, err := h.appPods(ctx, namespace, name, ""); err == nil {
+				start(pods)
+			}
+		}
+	}
+}
+
+// appPods lists the application's pods, or just podName when it is set.
+func (h *LogsHandler) appPods(ctx context.Context, namespace, name, podName string) ([]corev1.Pod, error) {
+	var list corev1.PodList
+	if err := h.client.List(ctx, &list, client.InNamespace(namespace), client.MatchingLabels{"iaf.io/application": name}); err != nil {
+		return nil, err
+	}
+	if podName == "" {
+		return list.Items, nil
+	}
+	pod, err := k8shelper.FindPodByName(list.Items, podName, "iaf.io/application", name)
+	if err != nil {
+		return nil, err
+	}
+	return []corev1.Pod{*pod}, nil
+}
+
+// relayPodLogs sends a pod's log lines as events until its stream ends or
+// ctx is cancelled.
+func (h *LogsHandler) relayPodLogs(ctx context.Context, namespace, pod string, opts *corev1.PodLogOptions, events chan<- sseEvent) {
+	send := func(ev sseEvent) bool {
+		select {
+		case events <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	if !send(sseEvent{name: "pod_started", data: LogPodEvent{Pod: pod}}) {
+		return
+	}
+	ended := LogPodEvent{Pod: pod}
+	defer func() { send(sseEvent{name: "pod_ended", data: ended}) }()
+
+	stream, err := h.clientset.CoreV1().Pods(namespace).GetLogs(pod, opts).Stream(ctx)
+	if err != nil {
+		ended.Error = fmt.Sprintf("opening log stream: %v", err)
+		return
+	}
+	defer stream.Close()
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 0, 4096), maxStreamLine)
+	for scanner.Scan() {
+		if !send(sseEvent{name: "log", data: LogLineEvent{Pod: pod, Line: scanner.Text()}}) {
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		ended.Error = fmt.Sprintf("reading logs: %v", err)
+	}
+}
+
+// sseEvent is one server-sent event; data is sent as JSON.
+type sseEvent struct {
+	name string
+	data any
+}
+
+func writeSSE(w *echo.Response, ev sseEvent) error {
+	data, err := json.Marshal(ev.data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.name, data); err != nil {
+		return err
+	}
+	w.Flush()
+	return nil
+}
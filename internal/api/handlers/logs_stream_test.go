@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/labstack/echo/v4"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func setupStreamTest(t *testing.T, pods ...string) (*LogsHandler, ctrlclient.Client, *auth.Session) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := iafv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess, err := sessions.Register("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs := []ctrlclient.Object{
+		&iafv1alpha1.Application{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: sess.Namespace}},
+	}
+	for _, name := range pods {
+		objs = append(objs, streamTestPod(name, sess.Namespace, "web"))
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+	return NewLogsHandler(c, kubefake.NewSimpleClientset(), sessions), c, sess
+}
+
+func streamTestPod(name, namespace, app string) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"iaf.io/application": app}}}
+}
+
+func streamRequest(t *testing.T, h *LogsHandler, sessionID, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/applications/web/logs/stream?"+query, nil)
+	req.Header.Set("X-IAF-Session", sessionID)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("name")
+	c.SetParamValues("web")
+	if err := h.StreamLogs(c); err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestStreamLogs_MultiplexesReplicas(t *testing.T) {
+	h, _, sess := setupStreamTest(t, "web-1", "web-2")
+
+	rec := streamRequest(t, h, sess.ID, "follow=false")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d (body: %s)", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("content type = %q", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`event: pod_started` + "\n" + `data: {"pod":"web-1"}`,
+		`event: pod_started` + "\n" + `data: {"pod":"web-2"}`,
+		`event: log` + "\n" + `data: {"pod":"web-1","line":"fake logs"}`,
+		`event: log` + "\n" + `data: {"pod":"web-2","line":"fake logs"}`,
+		`event: pod_ended` + "\n" + `data: {"pod":"web-1"}`,
+		`event: pod_ended` + "\n" + `data: {"pod":"web-2"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+	if !strings.HasSuffix(body, "event: done\ndata: {}\n\n") {
+		t.Errorf("stream should end with done:\n%s", body)
+	}
+}
+
+func TestStreamLogs_Errors(t *testing.T) {
+	h, c, sess := setupStreamTest(t, "web-1")
+	if err := c.Create(context.Background(), streamTestPod("other-1", sess.Namespace, "other")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, session, query string
+		want                 int
+	}{
+		{"missing session", "", "", http.StatusBadRequest},
+		{"bad lines", sess.ID, "lines=-1", http.StatusBadRequest},
+		{"pod of another app", sess.ID, "pod_name=other-1", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := streamRequest(t, h, tt.session, tt.query); rec.Code != tt.want {
+				t.Errorf("status %d, want %d (body: %s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-IAF-Session", sess.ID)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("name")
+	ctx.SetParamValues("missing")
+	if err := h.StreamLogs(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("missing app: status %d, want 404", rec.Code)
+	}
+}
+
+func TestStreamLogs_FollowPicksUpNewPods(t *testing.T) {
+	defer func(d time.Duration) { streamPodPollInterval = d }(streamPodPollInterval)
+	streamPodPollInterval = 10 * time.Millisecond
+
+	h, c, sess := setupStreamTest(t, "web-1")
+	e := echo.New()
+	e.GET("/api/v1/applications/:name/logs/stream", h.StreamLogs)
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v1/applications/web/logs/stream", nil)
+	req.Header.Set("X-IAF-Session", sess.ID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		defer close(lines)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	waitFor := func(want string) {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					t.Fatalf("stream closed before %q", want)
+				}
+				if line == want {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("timed out waiting for %q", want)
+			}
+		}
+	}
+
+	waitFor(`data: {"pod":"web-1"}`)
+	if err := c.Create(context.Background(), streamTestPod("web-2", sess.Namespace, "web")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(`data: {"pod":"web-2","line":"fake logs"}`)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"embed"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"path"
+
+	"github.com/labstack/echo/v4"
+)
+
+// OpenAPIHandler serves the OpenAPI document and a page documenting it.
+type OpenAPIHandler struct {
+	spec []byte
+}
+
+// NewOpenAPIHandler returns a handler serving spec, which is marshalled once.
+func NewOpenAPIHandler(spec map[string]any) (*OpenAPIHandler, error) {
+	data, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &OpenAPIHandler{spec: data}, nil
+}
+
+// Spec serves the OpenAPI document.
+func (h *OpenAPIHandler) Spec(c echo.Context) error {
+	return c.JSONBlob(http.StatusOK, h.spec)
+}
+
+// docsPolicy keeps the docs page, which is public, to the assets this
+// server embeds: no third-party scripts can reach the token typed into it.
+const docsPolicy = "default-src 'none'; script-src 'self'; style-src 'self'; connect-src 'self'; img-src 'self' data:; base-uri 'none'; form-action 'none'; frame-ancestors 'none'"
+
+//go:embed apidocs
+var apiDocs embed.FS
+
+// Docs serves a page that renders /openapi.json. Its Try it button sends
+// requests with the Bearer token and session ID typed into the page.
+func (h *OpenAPIHandler) Docs(c echo.Context) error {
+	page, err := apiDocs.ReadFile("apidocs/index.html")
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set("Content-Security-Policy", docsPolicy)
+	return c.HTMLBlob(http.StatusOK, page)
+}
+
+// DocsAsset serves the page's script and stylesheet from /docs/assets/.
+func (h *OpenAPIHandler) DocsAsset(c echo.Context) error {
+	name := c.Param("name")
+	if name != "docs.js" && name != "docs.css" {
+		return errorJSON(c, http.StatusNotFound, "not found")
+	}
+	data, err := apiDocs.ReadFile("apidocs/" + name)
+	if err != nil {
+		return err
+	}
+	return c.Blob(http.StatusOK, mime.TypeByExtension(path.Ext(name)), data)
+}
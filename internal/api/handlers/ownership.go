@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/labstack/echo/v4"
+)
+
+// AdminApplicationResponse is an application in the admin listing, with the
+// namespace and session it belongs to. The session ID is empty once the
+// session has been deleted.
+type AdminApplicationResponse struct {
+	Namespace string `json:"namespace"`
+	SessionID string `json:"sessionId,omitempty"`
+	ApplicationResponse
+}
+
+// ListApplications returns the applications of every session, filtered by
+// the ?team=, ?owner=, ?purpose= and ?costCenter= ownership metadata when
+// given. An application matches only when it has every requested value.
+func (h *AdminHandler) ListApplications(c echo.Context) error {
+	want := iafv1alpha1.OwnershipMetadata{
+		Team:       c.QueryParam("team"),
+		Owner:      c.QueryParam("owner"),
+		Purpose:    c.QueryParam("purpose"),
+		CostCenter: c.QueryParam("costCenter"),
+	}
+
+	var list iafv1alpha1.ApplicationList
+	if err := h.client.List(c.Request().Context(), &list); err != nil {
+		return errorJSON(c, http.StatusInternalServerError, err.Error())
+	}
+	sessionIDs := map[string]string{}
+	for _, sess := range h.sessions.List() {
+		sessionIDs[sess.Namespace] = sess.ID
+	}
+
+	resp := []AdminApplicationResponse{}
+	for i := range list.Items {
+		app := &list.Items[i]
+		if !ownedBy(app.Spec.Metadata, want) {
+			continue
+		}
+		resp = append(resp, AdminApplicationResponse{
+			Namespace:           app.Namespace,
+			SessionID:           sessionIDs[app.Namespace],
+			ApplicationResponse: toResponse(app),
+		})
+	}
+	sort.Slice(resp, func(i, j int) bool {
+		if resp[i].Namespace != resp[j].Namespace {
+			return resp[i].Namespace < resp[j].Namespace
+		}
+		return resp[i].Name < resp[j].Name
+	})
+	return c.JSON(http.StatusOK, resp)
+}
+
+// ownedBy reports whether m has every field set in want. Owners compare
+// case-insensitively, as email addresses do.
+func ownedBy(m *iafv1alpha1.OwnershipMetadata, want iafv1alpha1.OwnershipMetadata) bool {
+	if m == nil {
+		return want == iafv1alpha1.OwnershipMetadata{}
+	}
+	return (want.Team == "" || want.Team == m.Team) &&
+		(want.Owner == "" || strings.EqualFold(want.Owner, m.Owner)) &&
+		(want.Purpose == "" || want.Purpose == m.Purpose) &&
+		(want.CostCenter == "" || want.CostCenter == m.CostCenter)
+}
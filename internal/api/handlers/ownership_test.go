@@ -0,0 +1,75 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/api/handlers"
+	"github.com/labstack/echo/v4"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAdminHandler_ListApplications(t *testing.T) {
+	k8sClient, sessions := setupListTest(t)
+	sess, err := sessions.Register("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, app := range []*iafv1alpha1.Application{
+		{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: sess.Namespace}, Spec: iafv1alpha1.ApplicationSpec{
+			Image:    "nginx:latest",
+			Metadata: &iafv1alpha1.OwnershipMetadata{Team: "payments", Owner: "jane@example.com", CostCenter: "CC-1"},
+		}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "iaf-other"}, Spec: iafv1alpha1.ApplicationSpec{
+			Image:    "nginx:latest",
+			Metadata: &iafv1alpha1.OwnershipMetadata{Team: "payments", CostCenter: "CC-2"},
+		}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: sess.Namespace}, Spec: iafv1alpha1.ApplicationSpec{Image: "nginx:latest"}},
+	} {
+		if err := k8sClient.Create(t.Context(), app); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := handlers.NewAdminHandler(k8sClient, sessions)
+
+	list := func(query string) []handlers.AdminApplicationResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/?"+query, nil), rec)
+		if err := h.ListApplications(c); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+		var resp []handlers.AdminApplicationResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if got := list(""); len(got) != 3 {
+		t.Errorf("unfiltered: got %d applications, want 3", len(got))
+	}
+	byName := map[string]handlers.AdminApplicationResponse{}
+	for _, app := range list("team=payments") {
+		byName[app.Name] = app
+	}
+	web, api := byName["web"], byName["api"]
+	if len(byName) != 2 || web.Namespace != sess.Namespace || api.Namespace != "iaf-other" {
+		t.Fatalf("team=payments: got %+v", byName)
+	}
+	if web.SessionID != sess.ID || api.SessionID != "" || web.Metadata == nil || web.Metadata.CostCenter != "CC-1" {
+		t.Errorf("expected session IDs and metadata, got %+v", byName)
+	}
+	if got := list("team=payments&owner=JANE@example.com"); len(got) != 1 || got[0].Name != "web" {
+		t.Errorf("owner filter: got %+v", got)
+	}
+	if got := list("costCenter=CC-3"); len(got) != 0 {
+		t.Errorf("unknown cost center: got %+v", got)
+	}
+}
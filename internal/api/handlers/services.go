@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/service"
+	"github.com/labstack/echo/v4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type ServiceHandler struct {
+	services *service.Services
+	sessions *service.Sessions
+}
+
+func NewServiceHandler(c client.Client, sessions *auth.SessionStore) *ServiceHandler {
+	return &ServiceHandler{
+		services: service.NewServices(c),
+		sessions: service.NewSessions(c, sessions, 0),
+	}
+}
+
+// ServiceResponse is the API representation of a ManagedService. Connection
+// credentials are never included; bind the service to an app instead.
+type ServiceResponse struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Plan      string   `json:"plan"`
+	Phase     string   `json:"phase"`
+	Message   string   `json:"message,omitempty"`
+	BoundApps []string `json:"boundApps,omitempty"`
+}
+
+// List returns the managed services in the session namespace.
+func (h *ServiceHandler) List(c echo.Context) error {
+	namespace, err := sessionNamespace(c, h.sessions)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+
+	list, err := h.services.List(c.Request().Context(), namespace)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+
+	services := make([]ServiceResponse, 0, len(list))
+	for _, svc := range list {
+		services = append(services, ServiceResponse{
+			Name:      svc.Name,
+			Type:      svc.Spec.Type,
+			Plan:      string(svc.Spec.Plan),
+			Phase:     string(svc.Status.Phase),
+			Message:   svc.Status.Message,
+			BoundApps: svc.Status.BoundApps,
+		})
+	}
+	return c.JSON(http.StatusOK, services)
+}
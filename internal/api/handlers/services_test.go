@@ -0,0 +1,174 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/api/handlers"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/topology"
+	"github.com/labstack/echo/v4"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func setupListTest(t *testing.T, objs ...ctrlclient.Object) (ctrlclient.Client, *auth.SessionStore) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := iafv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k8sClient, sessions
+}
+
+func listRequest(t *testing.T, handler echo.HandlerFunc, path, sessionID string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if sessionID != "" {
+		req.Header.Set("X-IAF-Session", sessionID)
+	}
+	rec := httptest.NewRecorder()
+	if err := handler(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestServiceHandler_List(t *testing.T) {
+	k8sClient, sessions := setupListTest(t)
+	sess, err := sessions.Register("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := sessions.Register("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, svc := range []*iafv1alpha1.ManagedService{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: sess.Namespace},
+			Spec:       iafv1alpha1.ManagedServiceSpec{Type: "postgres", Plan: iafv1alpha1.ServicePlanMicro},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-db", Namespace: other.Namespace},
+			Spec:       iafv1alpha1.ManagedServiceSpec{Type: "postgres", Plan: iafv1alpha1.ServicePlanSmall},
+		},
+	} {
+		if err := k8sClient.Create(t.Context(), svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := handlers.NewServiceHandler(k8sClient, sessions)
+
+	rec := listRequest(t, h.List, "/api/v1/services", sess.ID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var services []handlers.ServiceResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &services); err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 1 || services[0].Name != "db" || services[0].Plan != "micro" {
+		t.Errorf("services = %+v, want only db from the session namespace", services)
+	}
+
+	if rec := listRequest(t, h.List, "/api/v1/services", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing session: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestDataSourceHandler_List(t *testing.T) {
+	newDS := func(name, kind string) *iafv1alpha1.DataSource {
+		return &iafv1alpha1.DataSource{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: iafv1alpha1.DataSourceSpec{
+				Kind:          kind,
+				EnvVarMapping: map[string]string{"url": "WAREHOUSE_URL", "password": "WAREHOUSE_PASSWORD"},
+				SecretRef:     iafv1alpha1.DataSourceSecretRef{Name: "creds", Namespace: "iaf-system"},
+			},
+		}
+	}
+	k8sClient, sessions := setupListTest(t, newDS("warehouse", "postgres"), newDS("assets", "s3"))
+	sess, err := sessions.Register("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handlers.NewDataSourceHandler(k8sClient, sessions)
+
+	rec := listRequest(t, h.List, "/api/v1/data-sources?kind=postgres", sess.ID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var sources []handlers.DataSourceResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &sources); err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 1 || sources[0].Name != "warehouse" {
+		t.Fatalf("sources = %+v, want only warehouse", sources)
+	}
+	if got := sources[0].EnvVarNames; len(got) != 2 || got[0] != "WAREHOUSE_PASSWORD" {
+		t.Errorf("envVarNames = %v, want sorted env var names", got)
+	}
+	if body := rec.Body.String(); strings.Contains(body, "creds") || strings.Contains(body, "iaf-system") {
+		t.Errorf("response leaks secret reference: %s", body)
+	}
+
+	if rec := listRequest(t, h.List, "/api/v1/data-sources", "bogus"); rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "session_not_found") {
+		t.Errorf("unknown session: status = %d %s, want 404 session_not_found", rec.Code, rec.Body.String())
+	}
+}
+
+func TestTopologyHandler_Get(t *testing.T) {
+	k8sClient, sessions := setupListTest(t)
+	sess, err := sessions.Register("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, obj := range []ctrlclient.Object{
+		&iafv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: sess.Namespace},
+			Spec: iafv1alpha1.ApplicationSpec{
+				Env:                  []iafv1alpha1.EnvVar{{Name: "API_URL", Value: "http://api:8080"}},
+				BoundManagedServices: []iafv1alpha1.BoundManagedService{{ServiceName: "db"}},
+			},
+		},
+		&iafv1alpha1.Application{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: sess.Namespace}},
+		&iafv1alpha1.ManagedService{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: sess.Namespace},
+			Spec:       iafv1alpha1.ManagedServiceSpec{Type: "postgres", Plan: iafv1alpha1.ServicePlanMicro},
+		},
+	} {
+		if err := k8sClient.Create(t.Context(), obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := handlers.NewTopologyHandler(k8sClient, sessions)
+
+	rec := listRequest(t, h.Get, "/api/v1/topology", sess.ID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var graph topology.Graph
+	if err := json.Unmarshal(rec.Body.Bytes(), &graph); err != nil {
+		t.Fatal(err)
+	}
+	if len(graph.Nodes) != 3 || len(graph.Edges) != 2 || graph.Edges[1].Kind != topology.EdgeCall {
+		t.Errorf("unexpected graph %+v", graph)
+	}
+
+	if rec := listRequest(t, h.Get, "/api/v1/topology", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing session: status = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/service"
+	"github.com/labstack/echo/v4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type SessionHandler struct {
+	sessions *service.Sessions
+}
+
+func NewSessionHandler(c client.Client, sessions *auth.SessionStore, ttl time.Duration) *SessionHandler {
+	return &SessionHandler{
+		sessions: service.NewSessions(c, sessions, ttl),
+	}
+}
+
+// CreateSessionRequest is the request body for registering a session.
+type CreateSessionRequest struct {
+	Name string `json:"name,omitempty"`
+	// Metadata is the default ownership of the applications created in
+	// the session.
+	Metadata *iafv1alpha1.OwnershipMetadata `json:"metadata,omitempty"`
+}
+
+// SessionResponse is the API representation of a newly registered session.
+type SessionResponse struct {
+	SessionID  string `json:"sessionId"`
+	Namespace  string `json:"namespace"`
+	TTLSeconds int64  `json:"ttlSeconds,omitempty"`
+}
+
+// Create registers a new session and its namespace. It is the REST
+// equivalent of the register MCP tool.
+func (h *SessionHandler) Create(c echo.Context) error {
+	var req CreateSessionRequest
+	if err := bindJSON(c, &req); err != nil {
+		return errorJSON(c, http.StatusBadRequest, err.Error())
+	}
+
+	sess, err := h.sessions.Register(c.Request().Context(), req.Name, req.Metadata)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, SessionResponse{
+		SessionID:  sess.ID,
+		Namespace:  sess.Namespace,
+		TTLSeconds: int64(h.sessions.TTL().Seconds()),
+	})
+}
+
+// sessionNamespace resolves the namespace for the session named by the
+// X-IAF-Session header or session_id query parameter. Write its error with
+// writeServiceError.
+func sessionNamespace(c echo.Context, sessions *service.Sessions) (string, error) {
+	return sessions.Namespace(requestSessionID(c))
+}
@@ -0,0 +1,63 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dlapiduz/iaf/internal/api/handlers"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/labstack/echo/v4"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSessionHandler_Create(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handlers.NewSessionHandler(k8sClient, sessions, time.Hour)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/sessions", bytes.NewReader([]byte(`{"name":"ci"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	if err := h.Create(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+
+	var resp handlers.SessionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.TTLSeconds != 3600 {
+		t.Errorf("ttlSeconds = %d, want 3600", resp.TTLSeconds)
+	}
+	sess, ok := sessions.Lookup(resp.SessionID)
+	if !ok {
+		t.Fatalf("session %q not stored", resp.SessionID)
+	}
+	if sess.Namespace != resp.Namespace {
+		t.Errorf("namespace = %q, want %q", resp.Namespace, sess.Namespace)
+	}
+
+	var ns corev1.Namespace
+	if err := k8sClient.Get(context.Background(), types.NamespacedName{Name: resp.Namespace}, &ns); err != nil {
+		t.Errorf("namespace not created: %v", err)
+	}
+}
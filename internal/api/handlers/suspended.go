@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SuspendedPage renders the page Traefik shows in place of a suspended app.
+// It is fetched by the app route's Errors middleware, so it is public and
+// deliberately static: it reveals nothing about the app or its session.
+func SuspendedPage(c echo.Context) error {
+	c.Response().Header().Set("Retry-After", "3600")
+	return c.HTML(http.StatusServiceUnavailable, suspendedPage)
+}
+
+const suspendedPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>Application suspended</title>
+  <style>
+    body { font-family: system-ui, sans-serif; color: #333; display: flex; min-height: 100vh; margin: 0; align-items: center; justify-content: center; }
+    main { max-width: 32rem; padding: 2rem; text-align: center; }
+    h1 { font-size: 1.5rem; }
+  </style>
+</head>
+<body>
+  <main>
+    <h1>This application is suspended</h1>
+    <p>It has been scaled down to save resources. Its owner can bring it back with <code>resume_app</code>.</p>
+  </main>
+</body>
+</html>
+`
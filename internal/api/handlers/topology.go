@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/service"
+	"github.com/dlapiduz/iaf/internal/topology"
+	"github.com/labstack/echo/v4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type TopologyHandler struct {
+	client   client.Client
+	sessions *service.Sessions
+}
+
+func NewTopologyHandler(c client.Client, sessions *auth.SessionStore) *TopologyHandler {
+	return &TopologyHandler{
+		client:   c,
+		sessions: service.NewSessions(c, sessions, 0),
+	}
+}
+
+// Get returns the graph of the session's applications, managed services and
+// data sources. It is the REST equivalent of the describe_topology MCP tool.
+func (h *TopologyHandler) Get(c echo.Context) error {
+	namespace, err := sessionNamespace(c, h.sessions)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	graph, err := topology.Build(c.Request().Context(), h.client, namespace)
+	if err != nil {
+		return errorJSON(c, http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, graph)
+}
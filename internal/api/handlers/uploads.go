@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	"github.com/labstack/echo/v4"
+)
+
+// UploadOffsetHeader carries the offset of a tarball chunk on requests and
+// the size received so far on responses.
+const UploadOffsetHeader = "Upload-Offset"
+
+// SourceUploadSessionResponse is a chunked source upload in progress.
+type SourceUploadSessionResponse struct {
+	UploadID string `json:"uploadId"`
+	// Offset is the number of bytes received, where the next chunk starts.
+	Offset int64 `json:"offset"`
+	// MaxSize is the largest tarball accepted, in bytes; 0 means no limit.
+	MaxSize   int64     `json:"maxSize,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (h *ApplicationHandler) uploadResponse(c echo.Context, status int, up sourcestore.Upload) error {
+	c.Response().Header().Set(UploadOffsetHeader, strconv.FormatInt(up.Size, 10))
+	return c.JSON(status, SourceUploadSessionResponse{
+		UploadID:  up.ID,
+		Offset:    up.Size,
+		MaxSize:   h.store.MaxUploadSize(),
+		ExpiresAt: up.CreatedAt.Add(sourcestore.UploadTTL),
+	})
+}
+
+// StartSourceUpload begins a chunked tarball upload, for sources too large
+// to send to UploadSource in one request.
+func (h *ApplicationHandler) StartSourceUpload(c echo.Context) error {
+	namespace, err := sessionNamespace(c, h.namespaces)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	up, err := h.apps.StartUpload(c.Request().Context(), namespace, c.Param("name"))
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return h.uploadResponse(c, http.StatusCreated, up)
+}
+
+// GetSourceUpload returns a chunked upload, to find the offset to resume
+// from after a failed chunk.
+func (h *ApplicationHandler) GetSourceUpload(c echo.Context) error {
+	namespace, err := sessionNamespace(c, h.namespaces)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	up, err := h.apps.GetUpload(namespace, c.Param("name"), c.Param("id"))
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return h.uploadResponse(c, http.StatusOK, up)
+}
+
+// AppendSourceUpload adds the tarball chunk in the request body to an
+// upload. The Upload-Offset header must give the upload's current size.
+func (h *ApplicationHandler) AppendSourceUpload(c echo.Context) error {
+	namespace, err := sessionNamespace(c, h.namespaces)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	offset, err := strconv.ParseInt(c.Request().Header.Get(UploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		return errorJSON(c, http.StatusBadRequest, fmt.Sprintf("the %s header must give the offset of the chunk", UploadOffsetHeader))
+	}
+	up, err := h.apps.AppendUpload(namespace, c.Param("name"), c.Param("id"), offset, c.Request().Body)
+	if err != nil {
+		c.Response().Header().Set(UploadOffsetHeader, strconv.FormatInt(up.Size, 10))
+		return writeServiceError(c, err)
+	}
+	return h.uploadResponse(c, http.StatusOK, up)
+}
+
+// CompleteSourceUpload uploads the tarball an upload's chunks make up, as
+// UploadSource does, and triggers a build.
+func (h *ApplicationHandler) CompleteSourceUpload(c echo.Context) error {
+	namespace, err := sessionNamespace(c, h.namespaces)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	blobURL, err := h.apps.CompleteUpload(c.Request().Context(), namespace, c.Param("name"), c.Param("id"))
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(http.StatusOK, SourceUploadResponse{
+		Message: "source uploaded",
+		BlobURL: blobURL,
+	})
+}
+
+// AbortSourceUpload discards a chunked upload.
+func (h *ApplicationHandler) AbortSourceUpload(c echo.Context) error {
+	namespace, err := sessionNamespace(c, h.namespaces)
+	if err != nil {
+		return writeServiceError(c, err)
+	}
+	if err := h.apps.AbortUpload(namespace, c.Param("name"), c.Param("id")); err != nil {
+		return writeServiceError(c, err)
+	}
+	return c.JSON(http.StatusOK, MessageResponse{Message: "upload discarded"})
+}
@@ -0,0 +1,168 @@
+package handlers_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/api/handlers"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/labstack/echo/v4"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestApplicationHandler_ChunkedSourceUpload(t *testing.T) {
+	env := setupHandlerTest(t)
+	ctx := context.Background()
+	sid, ns := env.newSession(t, "agent")
+	if err := env.client.Create(ctx, &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: ns},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	content := []byte("package main\nfunc main() {}\n")
+	if err := tw.WriteHeader(&tar.Header{Name: "main.go", Mode: 0o644, Size: int64(len(content))}); err != nil {
+		t.Fatal(err)
+	}
+	tw.Write(content)
+	tw.Close()
+	gz.Close()
+	tarball := buf.Bytes()
+
+	request := func(method, path, id string, offset int64, body io.Reader) (*httptest.ResponseRecorder, echo.Context) {
+		req := httptest.NewRequest(method, path, body)
+		req.Header.Set("X-IAF-Session", sid)
+		if offset >= 0 {
+			req.Header.Set(handlers.UploadOffsetHeader, strconv.FormatInt(offset, 10))
+		}
+		rec := httptest.NewRecorder()
+		c := env.e.NewContext(req, rec)
+		if id == "" {
+			setParam(c, "name", "myapp")
+		} else {
+			c.SetParamNames("name", "id")
+			c.SetParamValues("myapp", id)
+		}
+		return rec, c
+	}
+	decode := func(rec *httptest.ResponseRecorder) handlers.SourceUploadSessionResponse {
+		t.Helper()
+		var out handlers.SourceUploadSessionResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	rec, c := request(http.MethodPost, "/api/v1/applications/myapp/source/uploads", "", -1, nil)
+	if err := env.handler.StartSourceUpload(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("start: status %d (body: %s)", rec.Code, rec.Body.String())
+	}
+	id := decode(rec).UploadID
+
+	half := int64(len(tarball) / 2)
+	rec, c = request(http.MethodPatch, "/", id, 0, bytes.NewReader(tarball[:half]))
+	if err := env.handler.AppendSourceUpload(c); err != nil {
+		t.Fatal(err)
+	}
+	if got := decode(rec).Offset; rec.Code != http.StatusOK || got != half {
+		t.Fatalf("first chunk: status %d, offset %d", rec.Code, got)
+	}
+
+	// Resending the first chunk, as after a lost response, is refused with
+	// the offset to resume from.
+	rec, c = request(http.MethodPatch, "/", id, 0, bytes.NewReader(tarball[:half]))
+	if err := env.handler.AppendSourceUpload(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusConflict || rec.Header().Get(handlers.UploadOffsetHeader) != strconv.FormatInt(half, 10) {
+		t.Fatalf("repeated chunk: status %d, offset header %q", rec.Code, rec.Header().Get(handlers.UploadOffsetHeader))
+	}
+
+	rec, c = request(http.MethodGet, "/", id, -1, nil)
+	if err := env.handler.GetSourceUpload(c); err != nil {
+		t.Fatal(err)
+	}
+	if got := decode(rec).Offset; got != half {
+		t.Fatalf("get: offset %d, want %d", got, half)
+	}
+
+	rec, c = request(http.MethodPatch, "/", id, half, bytes.NewReader(tarball[half:]))
+	if err := env.handler.AppendSourceUpload(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("second chunk: status %d (body: %s)", rec.Code, rec.Body.String())
+	}
+
+	rec, c = request(http.MethodPost, "/", id, -1, nil)
+	if err := env.handler.CompleteSourceUpload(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("complete: status %d (body: %s)", rec.Code, rec.Body.String())
+	}
+	var app iafv1alpha1.Application
+	if err := env.client.Get(ctx, ctrlclient.ObjectKey{Name: "myapp", Namespace: ns}, &app); err != nil {
+		t.Fatal(err)
+	}
+	files, err := env.store.ReadFiles(ns, "myapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.Spec.Blob == "" || files["main.go"] != string(content) {
+		t.Errorf("expected the assembled tarball stored, blob %q, files %v", app.Spec.Blob, files)
+	}
+
+	// The upload is gone once completed.
+	rec, c = request(http.MethodGet, "/", id, -1, nil)
+	if err := env.handler.GetSourceUpload(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("completed upload: status %d, want 404", rec.Code)
+	}
+
+	// Chunks past the maximum size are refused.
+	env.store.SetMaxUploadSize(int64(len(tarball)) - 1)
+	rec, c = request(http.MethodPost, "/api/v1/applications/myapp/source/uploads", "", -1, nil)
+	if err := env.handler.StartSourceUpload(c); err != nil {
+		t.Fatal(err)
+	}
+	id = decode(rec).UploadID
+	rec, c = request(http.MethodPatch, "/", id, 0, bytes.NewReader(tarball))
+	if err := env.handler.AppendSourceUpload(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusRequestEntityTooLarge || rec.Header().Get(handlers.UploadOffsetHeader) != "0" {
+		t.Errorf("oversized chunk: status %d, offset header %q", rec.Code, rec.Header().Get(handlers.UploadOffsetHeader))
+	}
+
+	// A session cannot leave more uploads open than the limit.
+	env.store.SetMaxOpenUploads(1)
+	rec, c = request(http.MethodPost, "/api/v1/applications/myapp/source/uploads", "", -1, nil)
+	if err := env.handler.StartSourceUpload(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusTooManyRequests || !strings.Contains(rec.Body.String(), apierror.CodeTooManyUploads) {
+		t.Errorf("upload over the open limit: status %d (body: %s)", rec.Code, rec.Body.String())
+	}
+}
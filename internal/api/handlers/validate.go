@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/dlapiduz/iaf/internal/api/schema"
+	"github.com/labstack/echo/v4"
+)
+
+// maxRequestBody bounds JSON request bodies read for validation.
+const maxRequestBody = 32 << 20
+
+// bindJSON validates the request body against the published schema for T
+// and decodes it into req. The returned error is safe to show to clients.
+func bindJSON[T any](c echo.Context, req *T) error {
+	return decodeValidated(c, req, schema.Validate[T])
+}
+
+// bindJSONPatch is like bindJSON for partial updates, where no field is required.
+func bindJSONPatch[T any](c echo.Context, req *T) error {
+	return decodeValidated(c, req, schema.ValidatePatch[T])
+}
+
+func decodeValidated[T any](c echo.Context, req *T, validate func([]byte) error) error {
+	data, err := io.ReadAll(io.LimitReader(c.Request().Body, maxRequestBody+1))
+	if err != nil {
+		return fmt.Errorf("reading request body: %w", err)
+	}
+	if len(data) > maxRequestBody {
+		return fmt.Errorf("request body exceeds %d bytes", maxRequestBody)
+	}
+	if err := validate(data); err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, req)
+}
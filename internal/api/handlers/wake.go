@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/idle"
+	"github.com/labstack/echo/v4"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WakeHandler wakes idle applications. Traefik calls it from a sleeping
+// app's route; requests are authenticated by the HMAC in the path, which
+// only the controller can produce, instead of a Bearer token.
+type WakeHandler struct {
+	client client.Client
+	secret []byte
+	logger *slog.Logger
+}
+
+func NewWakeHandler(c client.Client, secret string, logger *slog.Logger) *WakeHandler {
+	return &WakeHandler{client: c, secret: []byte(secret), logger: logger}
+}
+
+// Wake handles GET /wake/:namespace/:name/:sig. A sleeping app is marked
+// as waking so the controller scales it back up; the response is a holding
+// page that reloads until the app serves again.
+func (h *WakeHandler) Wake(c echo.Context) error {
+	namespace, name := c.Param("namespace"), c.Param("name")
+	if !idle.VerifyWake(h.secret, namespace, name, c.Param("sig")) {
+		return errorJSON(c, http.StatusNotFound, "not found")
+	}
+
+	ctx := c.Request().Context()
+	var app iafv1alpha1.Application
+	if err := h.client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &app); err != nil {
+		if apierrors.IsNotFound(err) {
+			return errorJSON(c, http.StatusNotFound, "not found")
+		}
+		h.logger.Error("wake: getting application", "namespace", namespace, "app", name, "error", err)
+		return errorJSON(c, http.StatusInternalServerError, "failed to wake application")
+	}
+	if app.Annotations[iafv1alpha1.IdleAnnotation] == iafv1alpha1.IdleSleeping {
+		patch := client.MergeFrom(app.DeepCopy())
+		app.Annotations[iafv1alpha1.IdleAnnotation] = iafv1alpha1.IdleWaking
+		if err := h.client.Patch(ctx, &app, patch); err != nil {
+			h.logger.Error("wake: marking application as waking", "namespace", namespace, "app", name, "error", err)
+			return errorJSON(c, http.StatusInternalServerError, "failed to wake application")
+		}
+		h.logger.Info("wake: application woken by request", "namespace", namespace, "app", name)
+	}
+
+	c.Response().Header().Set("Retry-After", "5")
+	return c.HTML(http.StatusServiceUnavailable, wakingPage)
+}
+
+const wakingPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <meta http-equiv="refresh" content="5">
+  <title>Starting up</title>
+  <style>
+    body { font-family: system-ui, sans-serif; color: #333; display: flex; min-height: 100vh; margin: 0; align-items: center; justify-content: center; }
+    main { max-width: 32rem; padding: 2rem; text-align: center; }
+    h1 { font-size: 1.5rem; }
+  </style>
+</head>
+<body>
+  <main>
+    <h1>Waking up this application</h1>
+    <p>It was asleep after a period without visitors. This page reloads automatically and should show the application within a minute.</p>
+  </main>
+</body>
+</html>
+`
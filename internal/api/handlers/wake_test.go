@@ -0,0 +1,67 @@
+package handlers_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/api/handlers"
+	"github.com/dlapiduz/iaf/internal/idle"
+	"github.com/labstack/echo/v4"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestWakeHandler_Wake(t *testing.T) {
+	const secret = "wake-secret"
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	sleeping := &iafv1alpha1.Application{ObjectMeta: metav1.ObjectMeta{
+		Name: "myapp", Namespace: "iaf-test",
+		Annotations: map[string]string{iafv1alpha1.IdleAnnotation: iafv1alpha1.IdleSleeping},
+	}}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sleeping).Build()
+
+	e := echo.New()
+	h := handlers.NewWakeHandler(k8sClient, secret, slog.Default())
+	e.GET(idle.WakePathPrefix+":namespace/:name/:sig", h.Wake)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	annotation := func() string {
+		var app iafv1alpha1.Application
+		if err := k8sClient.Get(context.Background(), types.NamespacedName{Name: "myapp", Namespace: "iaf-test"}, &app); err != nil {
+			t.Fatal(err)
+		}
+		return app.Annotations[iafv1alpha1.IdleAnnotation]
+	}
+
+	// A forged or foreign signature is rejected and changes nothing.
+	forged := strings.TrimSuffix(idle.WakePath([]byte("other"), "iaf-test", "myapp"), "/")
+	if rec := get(forged); rec.Code != http.StatusNotFound {
+		t.Errorf("forged signature: expected 404, got %d", rec.Code)
+	}
+	if annotation() != iafv1alpha1.IdleSleeping {
+		t.Fatal("forged request must not wake the app")
+	}
+
+	rec := get(idle.WakePath([]byte(secret), "iaf-test", "myapp"))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "Waking up") {
+		t.Errorf("expected holding page with 503, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := annotation(); got != iafv1alpha1.IdleWaking {
+		t.Errorf("expected app to be waking, got %q", got)
+	}
+
+	if rec := get(idle.WakePath([]byte(secret), "iaf-test", "missing")); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown app: expected 404, got %d", rec.Code)
+	}
+}
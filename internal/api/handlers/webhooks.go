@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	k8shelper "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/labstack/echo/v4"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// maxWebhookBodyBytes bounds the GitHub webhook payload read into memory.
+const maxWebhookBodyBytes = 5 << 20
+
+// WebhookHandler receives GitHub webhook deliveries. Requests are authenticated
+// with the X-Hub-Signature-256 HMAC instead of a Bearer token.
+type WebhookHandler struct {
+	client client.Client
+	secret []byte
+	logger *slog.Logger
+}
+
+func NewWebhookHandler(c client.Client, secret string, logger *slog.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		client: c,
+		secret: []byte(secret),
+		logger: logger,
+	}
+}
+
+// githubPullRequestEvent is the subset of the pull_request webhook payload IAF uses.
+type githubPullRequestEvent struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Merged bool `json:"merged"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+		HTMLURL  string `json:"html_url"`
+	} `json:"repository"`
+}
+
+// GitHub handles POST /webhooks/github. When a pull request is closed (merged
+// or not), every preview Application created for that PR of that repository is
+// deleted, in whichever session namespace it lives.
+func (h *WebhookHandler) GitHub(c echo.Context) error {
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxWebhookBodyBytes))
+	if err != nil {
+		return errorJSON(c, http.StatusBadRequest, "reading request body")
+	}
+	if !h.validSignature(c.Request().Header.Get("X-Hub-Signature-256"), body) {
+		return errorJSON(c, http.StatusUnauthorized, "invalid webhook signature")
+	}
+
+	event := c.Request().Header.Get("X-GitHub-Event")
+	if event == "ping" {
+		return c.JSON(http.StatusOK, map[string]string{"status": "pong"})
+	}
+	if event != "pull_request" {
+		return c.JSON(http.StatusAccepted, map[string]string{"status": "ignored", "reason": "unsupported event " + event})
+	}
+
+	var payload githubPullRequestEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return errorJSON(c, http.StatusBadRequest, "invalid pull_request payload")
+	}
+	if payload.Action != "closed" {
+		return c.JSON(http.StatusAccepted, map[string]string{"status": "ignored", "reason": "action " + payload.Action})
+	}
+
+	ctx := c.Request().Context()
+	var list iafv1alpha1.ApplicationList
+	if err := h.client.List(ctx, &list, client.MatchingLabels{k8shelper.LabelPreviewPR: strconv.Itoa(payload.Number)}); err != nil {
+		return errorJSON(c, http.StatusInternalServerError, err.Error())
+	}
+
+	repo := k8shelper.NormalizeRepoURL(payload.Repository.HTMLURL)
+	deleted := []string{}
+	for i := range list.Items {
+		app := &list.Items[i]
+		if app.Annotations[k8shelper.AnnotationPreviewRepo] != repo {
+			continue
+		}
+		if app.Spec.DeletionProtected {
+			h.logger.Info("kept deletion-protected preview for closed pull request",
+				"repository", payload.Repository.FullName, "pr", payload.Number,
+				"app", app.Name, "namespace", app.Namespace)
+			continue
+		}
+		if err := h.client.Delete(ctx, app); err != nil && !apierrors.IsNotFound(err) {
+			return errorJSON(c, http.StatusInternalServerError, err.Error())
+		}
+		h.logger.Info("deleted preview for closed pull request",
+			"repository", payload.Repository.FullName, "pr", payload.Number,
+			"app", app.Name, "namespace", app.Namespace)
+		deleted = append(deleted, app.Name)
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{"status": "processed", "deleted": deleted})
+}
+
+// validSignature checks header against the HMAC-SHA256 of body using the
+// configured secret, in constant time.
+func (h *WebhookHandler) validSignature(header string, body []byte) bool {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok || len(h.secret) == 0 {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, h.secret)
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
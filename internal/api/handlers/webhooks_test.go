@@ -0,0 +1,132 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/api/handlers"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/labstack/echo/v4"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testWebhookSecret = "webhook-secret"
+
+func sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func setupWebhookTest(t *testing.T, objs ...ctrlclient.Object) (*handlers.WebhookHandler, ctrlclient.Client) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := iafv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+	return handlers.NewWebhookHandler(k8sClient, testWebhookSecret, slog.Default()), k8sClient
+}
+
+func previewApp(name, namespace, pr, repo string) *iafv1alpha1.Application {
+	return &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      map[string]string{iafk8s.LabelPreviewOf: "web", iafk8s.LabelPreviewPR: pr},
+			Annotations: map[string]string{iafk8s.AnnotationPreviewRepo: repo},
+		},
+	}
+}
+
+func webhookRequest(t *testing.T, h *handlers.WebhookHandler, event string, payload any, signature string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(payload)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader(body))
+	req.Header.Set("X-GitHub-Event", event)
+	if signature == "" {
+		signature = sign(body)
+	}
+	req.Header.Set("X-Hub-Signature-256", signature)
+	rec := httptest.NewRecorder()
+	if err := h.GitHub(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func closedPR(number int, repoURL string) map[string]any {
+	return map[string]any{
+		"action":       "closed",
+		"number":       number,
+		"pull_request": map[string]any{"merged": true},
+		"repository":   map[string]any{"full_name": "org/web", "html_url": repoURL},
+	}
+}
+
+func TestWebhook_ClosedPRDeletesPreviews(t *testing.T) {
+	h, k8sClient := setupWebhookTest(t,
+		previewApp("web-pr-7", "iaf-a", "7", "github.com/org/web"),
+		previewApp("other-pr-7", "iaf-b", "7", "github.com/org/other"),
+		previewApp("web-pr-8", "iaf-a", "8", "github.com/org/web"),
+	)
+
+	rec := webhookRequest(t, h, "pull_request", closedPR(7, "https://github.com/org/web"), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+	}
+
+	ctx := context.Background()
+	var app iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, ctrlclient.ObjectKey{Name: "web-pr-7", Namespace: "iaf-a"}, &app); !apierrors.IsNotFound(err) {
+		t.Errorf("expected web-pr-7 to be deleted, got err=%v", err)
+	}
+	if err := k8sClient.Get(ctx, ctrlclient.ObjectKey{Name: "other-pr-7", Namespace: "iaf-b"}, &app); err != nil {
+		t.Errorf("preview of a different repository must not be deleted: %v", err)
+	}
+	if err := k8sClient.Get(ctx, ctrlclient.ObjectKey{Name: "web-pr-8", Namespace: "iaf-a"}, &app); err != nil {
+		t.Errorf("preview of a different PR must not be deleted: %v", err)
+	}
+}
+
+func TestWebhook_InvalidSignature(t *testing.T) {
+	h, k8sClient := setupWebhookTest(t, previewApp("web-pr-7", "iaf-a", "7", "github.com/org/web"))
+
+	rec := webhookRequest(t, h, "pull_request", closedPR(7, "https://github.com/org/web"), "sha256=deadbeef")
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status %d, want 401", rec.Code)
+	}
+	var app iafv1alpha1.Application
+	if err := k8sClient.Get(context.Background(), ctrlclient.ObjectKey{Name: "web-pr-7", Namespace: "iaf-a"}, &app); err != nil {
+		t.Errorf("preview must survive an unsigned request: %v", err)
+	}
+}
+
+func TestWebhook_IgnoresOtherEvents(t *testing.T) {
+	h, _ := setupWebhookTest(t)
+
+	if rec := webhookRequest(t, h, "push", map[string]any{}, ""); rec.Code != http.StatusAccepted {
+		t.Errorf("push: status %d, want 202", rec.Code)
+	}
+	opened := closedPR(7, "https://github.com/org/web")
+	opened["action"] = "opened"
+	if rec := webhookRequest(t, h, "pull_request", opened, ""); rec.Code != http.StatusAccepted {
+		t.Errorf("opened: status %d, want 202", rec.Code)
+	}
+	if rec := webhookRequest(t, h, "ping", map[string]any{}, ""); rec.Code != http.StatusOK {
+		t.Errorf("ping: status %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,288 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dlapiduz/iaf/internal/api/handlers"
+	"github.com/dlapiduz/iaf/internal/api/schema"
+	"github.com/dlapiduz/iaf/internal/topology"
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// operation documents one REST route. Request and response schemas are
+// derived from the handler types so the spec tracks the code.
+type operation struct {
+	method  string
+	path    string // Echo syntax, e.g. /api/v1/applications/:name
+	summary string
+	public  bool // no Bearer token required
+	admin   bool // requires an admin token (IAF_ADMIN_TOKENS)
+	session bool // requires X-IAF-Session
+	policy  bool // may be rejected by a platform policy (403)
+	precond bool // honours If-Match, and If-None-Match on PUT (412 when they fail)
+	query   []queryParam
+	// request names a component schema for the JSON body, if any.
+	request string
+	// response names a component schema; a "[]" prefix means an array of it.
+	response string
+	status   int
+}
+
+type queryParam struct {
+	name, typ, description string
+}
+
+var operations = []operation{
+	{method: "GET", path: "/health", summary: "Liveness check", public: true, status: 200},
+	{method: "GET", path: "/ready", summary: "Readiness check", public: true, status: 200},
+	{method: "GET", path: "/platform/health", summary: "Health of the systems the platform depends on: controller leader, kpack, cert-manager, registry, source store, Prometheus, Loki and Tempo. Answers 503 when a required one is failing", response: "PlatformHealth", status: 200},
+	{method: "POST", path: "/api/v1/sessions", summary: "Register a session and provision its namespace", request: "SessionRequest", response: "Session", status: 201},
+	{method: "GET", path: "/api/v1/applications", summary: "List applications in the session", session: true, response: "[]Application", status: 200},
+	{method: "POST", path: "/api/v1/applications", summary: "Create an application from an image or git repository", session: true, policy: true, request: "ApplicationRequest", response: "Application", status: 201},
+	{method: "POST", path: `/api/v1/applications\:batchDelete`, summary: "Delete the named applications, or all with all=true; dryRun reports what would be deleted", session: true, request: "BatchDeleteRequest", response: "BatchResult", status: 200},
+	{method: "GET", path: "/api/v1/applications/:name", summary: "Get an application", session: true, response: "Application", status: 200},
+	{method: "PUT", path: "/api/v1/applications/:name", summary: "Create or replace an application; omitted fields return to their defaults. Answers 201 when it creates the application", session: true, policy: true, precond: true, request: "ApplicationUpdate", response: "Application", status: 200},
+	{method: "PATCH", path: "/api/v1/applications/:name", summary: "Update an application; omitted fields are unchanged", session: true, policy: true, precond: true, request: "ApplicationUpdate", response: "Application", status: 200},
+	{method: "POST", path: "/api/v1/applications/:name/plan", summary: "Preview an update: the spec fields it changes and whether it rebuilds, restarts or rescales the app; nothing is saved", session: true, request: "ApplicationUpdate", response: "UpdatePlan", status: 200},
+	{method: "DELETE", path: "/api/v1/applications/:name", summary: "Delete an application", session: true, precond: true, response: "Message", status: 200},
+	{method: "POST", path: "/api/v1/applications/:name/source", summary: "Upload source files (JSON) or a tarball and trigger a build", session: true, policy: true, request: "SourceUpload", response: "SourceUploadResult", status: 200},
+	{method: "POST", path: "/api/v1/applications/:name/source/uploads", summary: "Start a chunked tarball upload for sources too large for one request (429 when the session has too many unfinished uploads)", session: true, response: "SourceUploadSession", status: 201},
+	{method: "GET", path: "/api/v1/applications/:name/source/uploads/:id", summary: "Get a chunked upload; its offset is where to resume", session: true, response: "SourceUploadSession", status: 200},
+	{method: "PATCH", path: "/api/v1/applications/:name/source/uploads/:id", summary: "Append the tarball chunk in the body; the Upload-Offset header must equal the upload's offset (409 otherwise)", session: true, response: "SourceUploadSession", status: 200},
+	{method: "DELETE", path: "/api/v1/applications/:name/source/uploads/:id", summary: "Discard a chunked upload", session: true, response: "Message", status: 200},
+	{method: "POST", path: "/api/v1/applications/:name/source/uploads/:id/complete", summary: "Finish a chunked upload and trigger a build", session: true, policy: true, response: "SourceUploadResult", status: 200},
+	{method: "GET", path: "/api/v1/applications/:name/logs", summary: "Get recent runtime logs", session: true, query: []queryParam{
+		{"lines", "integer", "number of trailing lines (default 100)"},
+		{"pod_name", "string", "fetch logs from a specific pod"},
+		{"since_time", "string", "only lines logged at or after this RFC 3339 time"},
+		{"timestamps", "boolean", "prefix each line with its RFC 3339 timestamp"},
+	}, response: "Logs", status: 200},
+	{method: "GET", path: "/api/v1/applications/:name/logs/stream", summary: "Stream runtime logs from every replica as server-sent events (text/event-stream): log, pod_started, pod_ended and, without follow, done", session: true, query: []queryParam{
+		{"lines", "integer", "trailing lines to send from each pod before following (default 100)"},
+		{"follow", "boolean", "keep streaming new lines and pods (default true)"},
+		{"pod_name", "string", "stream a single pod"},
+		{"timestamps", "boolean", "prefix each line with its RFC 3339 timestamp"},
+	}, status: 200},
+	{method: "GET", path: "/api/v1/applications/:name/export", summary: "Export the application's Kubernetes objects as YAML or a Helm chart skeleton (Secrets excluded)", session: true, query: []queryParam{
+		{"format", "string", "yaml (default) or helm"},
+	}, response: "Export", status: 200},
+	{method: "GET", path: "/api/v1/applications/:name/build", summary: "Get logs from the most recent build", session: true, response: "BuildLogs", status: 200},
+	{method: "GET", path: "/api/v1/services", summary: "List managed services in the session", session: true, response: "[]Service", status: 200},
+	{method: "GET", path: "/api/v1/data-sources", summary: "List platform data sources (metadata only)", session: true, query: []queryParam{
+		{"kind", "string", "filter by data source kind"},
+	}, response: "[]DataSource", status: 200},
+	{method: "GET", path: "/api/v1/topology", summary: "Graph of the session's applications, managed services and attached data sources, with bindings, attachments and calls between applications inferred from their env vars", session: true, response: "Topology", status: 200},
+	{method: "POST", path: "/api/v1/graphql", summary: "Run a read-only GraphQL query over the session's applications, pods, events and services; query errors are returned in errors with status 200", session: true, request: "GraphQLRequest", response: "GraphQLResponse", status: 200},
+	{method: "POST", path: "/api/v1/admin/sessions/:id/suspend", summary: "Suspend every application in a session (scale to zero, keep configuration)", admin: true, request: "BatchRequest", response: "BatchResult", status: 200},
+	{method: "POST", path: "/api/v1/admin/sessions/:id/resume", summary: "Resume every suspended application in a session", admin: true, request: "BatchRequest", response: "BatchResult", status: 200},
+	{method: "GET", path: "/api/v1/admin/applications", summary: "List the applications of every session, filtered by ownership metadata", admin: true, query: []queryParam{
+		{"team", "string", "only applications of this team"},
+		{"owner", "string", "only applications of this owner email"},
+		{"purpose", "string", "only applications with this purpose"},
+		{"costCenter", "string", "only applications charged to this cost center"},
+	}, response: "[]AdminApplication", status: 200},
+	{method: "GET", path: "/api/v1/admin/costs", summary: "Estimated spend per session per day, from pod CPU and memory use and the configured rates", admin: true, query: []queryParam{
+		{"days", "integer", "number of UTC days to cover, today included (default 7, max 31)"},
+	}, response: "CostReport", status: 200},
+	{method: "GET", path: "/wake/:namespace/:name/:sig", summary: "Wake an idle app and render a holding page; called by Traefik, authenticated by the HMAC in the path", public: true, status: 503},
+	{method: "POST", path: "/webhooks/github", summary: "GitHub webhook receiver, authenticated by X-Hub-Signature-256", public: true, status: 200},
+}
+
+// componentSchemas returns the named schemas referenced by operations.
+func componentSchemas() (map[string]*jsonschema.Schema, error) {
+	builders := map[string]func() (*jsonschema.Schema, error){
+		"Session":             schema.For[handlers.SessionResponse],
+		"SessionRequest":      schema.For[handlers.CreateSessionRequest],
+		"Application":         schema.For[handlers.ApplicationResponse],
+		"ApplicationRequest":  schema.For[handlers.CreateApplicationRequest],
+		"ApplicationUpdate":   schema.PatchFor[handlers.UpdateApplicationRequest],
+		"UpdatePlan":          schema.For[handlers.UpdatePlanResponse],
+		"SourceUpload":        schema.For[handlers.UploadSourceRequest],
+		"Service":             schema.For[handlers.ServiceResponse],
+		"DataSource":          schema.For[handlers.DataSourceResponse],
+		"Topology":            schema.For[topology.Graph],
+		"Logs":                schema.For[handlers.LogsResponse],
+		"BuildLogs":           schema.For[handlers.BuildLogsResponse],
+		"SourceUploadResult":  schema.For[handlers.SourceUploadResponse],
+		"SourceUploadSession": schema.For[handlers.SourceUploadSessionResponse],
+		"Message":             schema.For[handlers.MessageResponse],
+		"Export":              schema.For[handlers.ExportResponse],
+		"BatchDeleteRequest":  schema.For[handlers.BatchDeleteRequest],
+		"BatchRequest":        schema.For[handlers.BatchRequest],
+		"BatchResult":         schema.For[handlers.BatchResponse],
+		"CostReport":          schema.For[handlers.CostReportResponse],
+		"AdminApplication":    schema.For[handlers.AdminApplicationResponse],
+		"GraphQLRequest":      schema.For[handlers.GraphQLRequest],
+		"GraphQLResponse":     schema.For[handlers.GraphQLResponse],
+		"Error":               schema.For[handlers.ErrorResponse],
+		"PolicyViolation":     schema.For[handlers.PolicyViolationResponse],
+		"PlatformHealth":      schema.For[handlers.PlatformHealthResponse],
+	}
+	schemas := make(map[string]*jsonschema.Schema, len(builders))
+	for name, build := range builders {
+		s, err := build()
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+		schemas[name] = s
+	}
+	return schemas, nil
+}
+
+// OpenAPISpec builds the OpenAPI 3.1 document describing the REST API.
+func OpenAPISpec() (map[string]any, error) {
+	schemas, err := componentSchemas()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := map[string]map[string]any{}
+	for _, op := range operations {
+		path, params := openAPIPath(op.path)
+		for _, q := range op.query {
+			params = append(params, map[string]any{
+				"name": q.name, "in": "query", "description": q.description,
+				"schema": map[string]any{"type": q.typ},
+			})
+		}
+		if op.session {
+			params = append(params, map[string]any{"$ref": "#/components/parameters/Session"})
+		}
+		if op.method == "POST" && strings.HasPrefix(op.path, "/api/v1/") {
+			params = append(params, map[string]any{"$ref": "#/components/parameters/IdempotencyKey"})
+		}
+		if op.precond {
+			params = append(params, map[string]any{"$ref": "#/components/parameters/IfMatch"})
+			if op.method == "PUT" {
+				params = append(params, map[string]any{"$ref": "#/components/parameters/IfNoneMatch"})
+			}
+		}
+
+		response := map[string]any{"description": "Success"}
+		if op.response != "" {
+			response["content"] = jsonContent(op.response)
+		}
+		doc := map[string]any{
+			"summary":     op.summary,
+			"operationId": operationID(op),
+			"responses": map[string]any{
+				fmt.Sprint(op.status): response,
+				"default": map[string]any{
+					"description": "Error",
+					"content":     jsonContent("Error"),
+				},
+			},
+		}
+		if op.policy {
+			doc["responses"].(map[string]any)["403"] = map[string]any{
+				"description": "Blocked by a platform policy",
+				"content":     jsonContent("PolicyViolation"),
+			}
+		}
+		if op.precond {
+			doc["responses"].(map[string]any)["412"] = map[string]any{
+				"description": "If-Match or If-None-Match failed: the application changed, is missing, or already exists",
+				"content":     jsonContent("Error"),
+			}
+		}
+		if len(params) > 0 {
+			doc["parameters"] = params
+		}
+		if op.request != "" {
+			doc["requestBody"] = map[string]any{"required": true, "content": jsonContent(op.request)}
+		}
+		if op.public {
+			doc["security"] = []any{}
+		}
+		if op.admin {
+			doc["description"] = "Operator endpoint: requires a Bearer token listed in IAF_ADMIN_TOKENS. Not mounted when no admin tokens are configured."
+		}
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+		paths[path][strings.ToLower(op.method)] = doc
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "IAF REST API",
+			"version":     "v1",
+			"description": "REST API for the Intelligent Application Fabric. Request bodies are validated against the schemas below; unknown fields are rejected.",
+		},
+		"paths":    paths,
+		"security": []any{map[string]any{"bearerAuth": []any{}}},
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+			"parameters": map[string]any{
+				"Session": map[string]any{
+					"name": "X-IAF-Session", "in": "header", "required": true,
+					"description": "Session ID from POST /api/v1/sessions. May instead be sent as the session_id query parameter.",
+					"schema":      map[string]any{"type": "string"},
+				},
+				"IdempotencyKey": map[string]any{
+					"name": "Idempotency-Key", "in": "header",
+					"description": "Optional client-chosen key, such as a UUID. Retrying with the same key, path and body within 24 hours returns the first successful response, marked Idempotent-Replayed: true, instead of repeating the request. Reusing a key for a different request is rejected with 409.",
+					"schema":      map[string]any{"type": "string", "maxLength": 255},
+				},
+				"IfMatch": map[string]any{
+					"name": "If-Match", "in": "header",
+					"description": "Optional ETag from a previous response, such as \"3\", or * for any existing version. The request fails with 412 precondition_failed if the application is missing or changed since. Replaces expectedVersion in the body.",
+					"schema":      map[string]any{"type": "string"},
+				},
+				"IfNoneMatch": map[string]any{
+					"name": "If-None-Match", "in": "header",
+					"description": "Optional; * makes the request only create the application, failing with 412 precondition_failed if it exists.",
+					"schema":      map[string]any{"type": "string", "enum": []string{"*"}},
+				},
+			},
+		},
+	}, nil
+}
+
+// openAPIPath converts an Echo path to OpenAPI syntax and returns its path
+// parameters. Escaped colons (custom methods such as :batchDelete) are
+// literal.
+func openAPIPath(echoPath string) (string, []any) {
+	var params []any
+	segments := strings.Split(echoPath, "/")
+	for i, seg := range segments {
+		if name, ok := strings.CutPrefix(seg, ":"); ok {
+			segments[i] = "{" + name + "}"
+			params = append(params, map[string]any{
+				"name": name, "in": "path", "required": true,
+				"schema": map[string]any{"type": "string"},
+			})
+		}
+	}
+	return strings.ReplaceAll(strings.Join(segments, "/"), `\:`, ":"), params
+}
+
+func jsonContent(ref string) map[string]any {
+	var s map[string]any
+	if name, ok := strings.CutPrefix(ref, "[]"); ok {
+		s = map[string]any{"type": "array", "items": map[string]any{"$ref": "#/components/schemas/" + name}}
+	} else {
+		s = map[string]any{"$ref": "#/components/schemas/" + ref}
+	}
+	return map[string]any{"application/json": map[string]any{"schema": s}}
+}
+
+// operationID derives a stable ID such as "getApplicationsName".
+func operationID(op operation) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(op.method))
+	for _, seg := range strings.Split(strings.TrimPrefix(op.path, "/api/v1"), "/") {
+		seg = strings.TrimPrefix(seg, ":")
+		for _, part := range strings.FieldsFunc(seg, func(r rune) bool { return r == '-' || r == '\\' || r == ':' }) {
+			if part != "" {
+				b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	"github.com/labstack/echo/v4"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestServer(t *testing.T) *echo.Echo {
+	t.Helper()
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := sourcestore.New(t.TempDir(), "http://localhost", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewServer([]string{"token"}, nil, slog.Default())
+	if err := RegisterRoutes(e, fake.NewClientBuilder().Build(), kubefake.NewSimpleClientset(), sessions, store, RouteOptions{WebhookSecret: "secret", WakeSecret: "wake-secret", AdminTokens: []string{"admin-token"}, Logger: slog.Default()}); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// TestOpenAPISpec_CoversRoutes fails when a route is added without being
+// documented, or documented without being registered.
+func TestOpenAPISpec_CoversRoutes(t *testing.T) {
+	e := newTestServer(t)
+	spec, err := OpenAPISpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := spec["paths"].(map[string]map[string]any)
+
+	undocumented := map[string]bool{"/openapi.json": true, "/docs": true, "/docs/assets/:name": true, "/suspended": true}
+	registered := map[string]bool{}
+	for _, r := range e.Routes() {
+		if undocumented[r.Path] {
+			continue
+		}
+		path, _ := openAPIPath(r.Path)
+		registered[r.Method+" "+path] = true
+		if _, ok := paths[path][strings.ToLower(r.Method)]; !ok {
+			t.Errorf("route %s %s is not in the OpenAPI spec", r.Method, r.Path)
+		}
+	}
+	ids := map[string]bool{}
+	for _, op := range operations {
+		path, _ := openAPIPath(op.path)
+		if !registered[op.method+" "+path] {
+			t.Errorf("spec documents %s %s, which is not registered", op.method, op.path)
+		}
+		if id := operationID(op); ids[id] {
+			t.Errorf("duplicate operationId %q", id)
+		} else {
+			ids[id] = true
+		}
+	}
+}
+
+func TestOpenAPIEndpoints(t *testing.T) {
+	e := newTestServer(t)
+
+	// Documentation is readable without a token.
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("/openapi.json status = %d", rec.Code)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("spec is not JSON: %v", err)
+	}
+	if doc["openapi"] != "3.1.0" {
+		t.Errorf("openapi = %v", doc["openapi"])
+	}
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	app := schemas["ApplicationRequest"].(map[string]any)
+	if _, ok := app["properties"].(map[string]any)["gitUrl"]; !ok {
+		t.Errorf("ApplicationRequest schema lacks gitUrl: %v", app)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/docs", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "/openapi.json") {
+		t.Errorf("/docs status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+	if csp := rec.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "script-src 'self'") {
+		t.Errorf("/docs Content-Security-Policy = %q", csp)
+	}
+	if strings.Contains(rec.Body.String(), "https://") {
+		t.Errorf("/docs loads assets from another origin: %q", rec.Body.String())
+	}
+	for path, contentType := range map[string]string{"/docs/assets/docs.js": "javascript", "/docs/assets/docs.css": "text/css"} {
+		req = httptest.NewRequest(http.MethodGet, path, nil)
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Header().Get("Content-Type"), contentType) || rec.Body.Len() == 0 {
+			t.Errorf("%s status = %d, content type = %q", path, rec.Code, rec.Header().Get("Content-Type"))
+		}
+	}
+	req = httptest.NewRequest(http.MethodGet, "/docs/assets/index.html", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("/docs/assets/index.html status = %d, want 404", rec.Code)
+	}
+}
@@ -1,30 +1,130 @@
 package api
 
 import (
+	"log/slog"
+	"time"
+
 	"github.com/dlapiduz/iaf/internal/api/handlers"
 	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/cost"
+	"github.com/dlapiduz/iaf/internal/grafana"
+	"github.com/dlapiduz/iaf/internal/idle"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/middleware"
+	"github.com/dlapiduz/iaf/internal/platformhealth"
 	"github.com/dlapiduz/iaf/internal/sourcestore"
 	"github.com/labstack/echo/v4"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// RouteOptions configures the routes RegisterRoutes registers.
+type RouteOptions struct {
+	// Grafana drives the Grafana deep links in application responses.
+	Grafana grafana.Config
+	// CustomDNS lets applications set host aliases and DNS settings.
+	CustomDNS bool
+	// SessionTTL is the idle lifetime of sessions registered over REST;
+	// 0 disables expiry.
+	SessionTTL time.Duration
+	// WebhookSecret verifies GitHub webhook deliveries; the webhook
+	// endpoint is registered only when it is set.
+	WebhookSecret string
+	// WakeSecret authenticates the idle wake endpoint, registered only
+	// when it is set.
+	WakeSecret string
+	// AdminTokens are accepted on the /api/v1/admin endpoints, registered
+	// only when there are some.
+	AdminTokens []string
+	// Costs prices the admin cost roll-up; when nil the endpoint answers
+	// 503.
+	Costs *cost.Estimator
+	// Platform backs GET /platform/health; when nil that endpoint answers
+	// 503.
+	Platform *platformhealth.Checker
+	Logger   *slog.Logger
+}
+
 // RegisterRoutes registers all API routes on the Echo server.
-func RegisterRoutes(e *echo.Echo, c client.Client, cs kubernetes.Interface, sessions *auth.SessionStore, store *sourcestore.Store) {
-	health := handlers.NewHealthHandler()
+// It fails only if the OpenAPI document or GraphQL schema cannot be built.
+func RegisterRoutes(e *echo.Echo, c client.Client, cs kubernetes.Interface, sessions *auth.SessionStore, store *sourcestore.Store, opts RouteOptions) error {
+	health := handlers.NewHealthHandler(opts.Platform)
 	e.GET("/health", health.Health)
 	e.GET("/ready", health.Ready)
+	e.GET("/platform/health", health.Platform)
+
+	spec, err := OpenAPISpec()
+	if err != nil {
+		return err
+	}
+	docs, err := handlers.NewOpenAPIHandler(spec)
+	if err != nil {
+		return err
+	}
+	e.GET("/openapi.json", docs.Spec)
+	e.GET("/docs", docs.Docs)
+	e.GET("/docs/assets/:name", docs.DocsAsset)
+	e.GET(iafk8s.SuspendedPagePath, handlers.SuspendedPage)
 
-	apps := handlers.NewApplicationHandler(c, sessions, store)
 	api := e.Group("/api/v1")
+	sessionHandler := handlers.NewSessionHandler(c, sessions, opts.SessionTTL)
+	api.POST("/sessions", sessionHandler.Create)
+
+	apps := handlers.NewApplicationHandler(c, sessions, store, opts.Grafana, opts.CustomDNS)
 	api.GET("/applications", apps.List)
 	api.POST("/applications", apps.Create)
+	api.POST(`/applications\:batchDelete`, apps.BatchDelete)
 	api.GET("/applications/:name", apps.Get)
-	api.PUT("/applications/:name", apps.Update)
+	api.PUT("/applications/:name", apps.Replace)
+	api.PATCH("/applications/:name", apps.Update)
+	api.POST("/applications/:name/plan", apps.Plan)
 	api.DELETE("/applications/:name", apps.Delete)
 	api.POST("/applications/:name/source", apps.UploadSource)
+	api.POST("/applications/:name/source/uploads", apps.StartSourceUpload)
+	api.GET("/applications/:name/source/uploads/:id", apps.GetSourceUpload)
+	api.PATCH("/applications/:name/source/uploads/:id", apps.AppendSourceUpload)
+	api.DELETE("/applications/:name/source/uploads/:id", apps.AbortSourceUpload)
+	api.POST("/applications/:name/source/uploads/:id/complete", apps.CompleteSourceUpload)
+	api.GET("/applications/:name/export", apps.Export)
 
 	logs := handlers.NewLogsHandler(c, cs, sessions)
 	api.GET("/applications/:name/logs", logs.GetLogs)
+	api.GET("/applications/:name/logs/stream", logs.StreamLogs)
 	api.GET("/applications/:name/build", logs.GetBuildLogs)
+
+	services := handlers.NewServiceHandler(c, sessions)
+	api.GET("/services", services.List)
+
+	dataSources := handlers.NewDataSourceHandler(c, sessions)
+	api.GET("/data-sources", dataSources.List)
+
+	topology := handlers.NewTopologyHandler(c, sessions)
+	api.GET("/topology", topology.Get)
+
+	graphql, err := handlers.NewGraphQLHandler(c, sessions, store)
+	if err != nil {
+		return err
+	}
+	api.POST("/graphql", graphql.Query)
+
+	if len(opts.AdminTokens) > 0 {
+		admin := handlers.NewAdminHandler(c, sessions)
+		requireAdmin := middleware.RequireToken(opts.AdminTokens)
+		api.POST("/admin/sessions/:id/suspend", admin.SuspendSession, requireAdmin)
+		api.POST("/admin/sessions/:id/resume", admin.ResumeSession, requireAdmin)
+		api.GET("/admin/applications", admin.ListApplications, requireAdmin)
+		costReport := handlers.NewCostHandler(sessions, opts.Costs)
+		api.GET("/admin/costs", costReport.Report, requireAdmin)
+	}
+
+	if opts.WakeSecret != "" {
+		wake := handlers.NewWakeHandler(c, opts.WakeSecret, opts.Logger)
+		e.GET(idle.WakePathPrefix+":namespace/:name/:sig", wake.Wake)
+	}
+
+	if opts.WebhookSecret != "" {
+		webhooks := handlers.NewWebhookHandler(c, opts.WebhookSecret, opts.Logger)
+		e.POST("/webhooks/github", webhooks.GitHub)
+	}
+	return nil
 }
@@ -0,0 +1,100 @@
+// Package schema derives JSON Schemas from REST API request and response
+// types and validates request bodies against them. The same schemas are
+// published in the OpenAPI document, so the documented contract and the
+// enforced one cannot drift apart.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// typeSchemas overrides inference for types whose JSON form differs from
+// their Go structure.
+var typeSchemas = map[reflect.Type]*jsonschema.Schema{
+	reflect.TypeFor[metav1.Time](): {Type: "string", Format: "date-time"},
+}
+
+type entry struct {
+	schema   *jsonschema.Schema
+	resolved *jsonschema.Resolved
+	// patch is schema without top-level required properties, for partial
+	// updates.
+	patch         *jsonschema.Schema
+	patchResolved *jsonschema.Resolved
+	err           error
+}
+
+var cache sync.Map // reflect.Type → *entry
+
+func lookup(t reflect.Type) *entry {
+	if e, ok := cache.Load(t); ok {
+		return e.(*entry)
+	}
+	e := &entry{}
+	e.schema, e.err = jsonschema.ForType(t, &jsonschema.ForOptions{TypeSchemas: typeSchemas})
+	if e.err == nil {
+		e.resolved, e.err = e.schema.Resolve(nil)
+	}
+	if e.err == nil {
+		e.patch = e.schema.CloneSchemas()
+		e.patch.Required = nil
+		e.patchResolved, e.err = e.patch.Resolve(nil)
+	}
+	actual, _ := cache.LoadOrStore(t, e)
+	return actual.(*entry)
+}
+
+// For returns the JSON Schema for T. Callers must not modify the result;
+// use its CloneSchemas method first.
+func For[T any]() (*jsonschema.Schema, error) {
+	e := lookup(reflect.TypeFor[T]())
+	return e.schema, e.err
+}
+
+// PatchFor is like For but no property is required, matching update
+// endpoints where omitted fields are left unchanged.
+func PatchFor[T any]() (*jsonschema.Schema, error) {
+	e := lookup(reflect.TypeFor[T]())
+	return e.patch, e.err
+}
+
+// Validate checks that data is a JSON document matching the schema for T.
+// Unknown fields and wrongly typed values are rejected. An empty body is
+// treated as an empty object.
+func Validate[T any](data []byte) error {
+	e := lookup(reflect.TypeFor[T]())
+	if e.err != nil {
+		return fmt.Errorf("building schema: %w", e.err)
+	}
+	return validate(e.resolved, data)
+}
+
+// ValidatePatch is like Validate but against the PatchFor schema.
+func ValidatePatch[T any](data []byte) error {
+	e := lookup(reflect.TypeFor[T]())
+	if e.err != nil {
+		return fmt.Errorf("building schema: %w", e.err)
+	}
+	return validate(e.patchResolved, data)
+}
+
+func validate(rs *jsonschema.Resolved, data []byte) error {
+	if len(strings.TrimSpace(string(data))) == 0 {
+		data = []byte("{}")
+	}
+	var instance any
+	if err := json.Unmarshal(data, &instance); err != nil {
+		return fmt.Errorf("request body is not valid JSON: %w", err)
+	}
+	if err := rs.Validate(instance); err != nil {
+		return fmt.Errorf("request body does not match schema: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+type testRequest struct {
+	Name     string   `json:"name"`
+	Port     int32    `json:"port,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Enabled  *bool    `json:"enabled,omitempty"`
+	internal string
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		patch   bool
+		wantErr string
+	}{
+		{name: "valid", body: `{"name":"web","port":8080,"tags":["a"]}`},
+		{name: "null pointer allowed", body: `{"name":"web","enabled":null}`},
+		{name: "missing required field", body: `{"port":8080}`, wantErr: "name"},
+		{name: "wrong type", body: `{"name":"web","port":"8080"}`, wantErr: "port"},
+		{name: "unknown field", body: `{"name":"web","replicaz":2}`, wantErr: "replicaz"},
+		{name: "not JSON", body: `name=web`, wantErr: "not valid JSON"},
+		{name: "empty body requires fields", body: ``, wantErr: "name"},
+		{name: "patch allows missing fields", body: `{"port":8080}`, patch: true},
+		{name: "patch still checks types", body: `{"port":true}`, patch: true, wantErr: "port"},
+		{name: "patch accepts empty body", body: ``, patch: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validate := Validate[testRequest]
+			if tt.patch {
+				validate = ValidatePatch[testRequest]
+			}
+			err := validate([]byte(tt.body))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want mention of %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFor_Cached(t *testing.T) {
+	a, err := For[testRequest]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := For[testRequest]()
+	if a != b {
+		t.Error("For returned a different schema on the second call")
+	}
+	patch, _ := PatchFor[testRequest]()
+	if len(a.Required) != 1 || len(patch.Required) != 0 {
+		t.Errorf("required = %v, patch required = %v", a.Required, patch.Required)
+	}
+}
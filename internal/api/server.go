@@ -1,28 +1,62 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
 
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/idempotency"
+	"github.com/dlapiduz/iaf/internal/maintenance"
 	"github.com/dlapiduz/iaf/internal/middleware"
 	"github.com/labstack/echo/v4"
 	echomiddleware "github.com/labstack/echo/v4/middleware"
 )
 
-// NewServer creates a new Echo server with middleware configured.
-func NewServer(tokens []string, logger *slog.Logger) *echo.Echo {
+// NewServer creates a new Echo server with middleware configured. While
+// maint is non-nil the platform is in maintenance and requests that could
+// change something are refused.
+func NewServer(tokens []string, maint *maintenance.Mode, logger *slog.Logger) *echo.Echo {
 	e := echo.New()
 	e.HideBanner = true
+	e.HTTPErrorHandler = errorHandler
 
 	// Middleware
 	e.Use(echomiddleware.Recover())
 	e.Use(middleware.RequestID())
 	e.Use(echomiddleware.CORSWithConfig(echomiddleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders: []string{"Authorization", "Content-Type"},
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:  []string{"Authorization", "Content-Type", "If-Match", "If-None-Match", middleware.HeaderIdempotencyKey},
+		ExposeHeaders: []string{"ETag"},
 	}))
 	e.Use(middleware.Auth(tokens))
 	e.Use(middleware.Audit(logger))
+	e.Use(middleware.Maintenance(maint))
+	e.Use(middleware.Idempotency(idempotency.DefaultTTL))
 
 	return e
 }
+
+// errorHandler writes errors returned by handlers and middleware, such as
+// unknown routes and malformed bodies, in the same structured form as the
+// handlers' own error responses. Other errors are not shown to the caller.
+func errorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+	status, message := http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		status, message = he.Code, fmt.Sprint(he.Message)
+	}
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(status)
+	} else {
+		err = c.JSON(status, apierror.FromStatus(status, message).Response())
+	}
+	if err != nil {
+		c.Logger().Error(err)
+	}
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dlapiduz/iaf/internal/apierror"
+)
+
+// TestServer_ErrorFormat checks that errors raised by Echo itself use the
+// same structured body as the handlers.
+func TestServer_ErrorFormat(t *testing.T) {
+	e := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/nothing-here", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", rec.Code)
+	}
+	var resp apierror.Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != apierror.CodeNotFound || resp.Category != apierror.CategoryNotFound || resp.Error == "" {
+		t.Errorf("unexpected error body %s", rec.Body.String())
+	}
+}
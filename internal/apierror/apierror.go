@@ -0,0 +1,268 @@
+// Package apierror is the error model shared by the MCP tools and the REST
+// API. Every failure is reported with a stable code, a category, whether
+// retrying the same request can succeed, and a hint, so agents and clients
+// can branch on the error instead of parsing its message.
+package apierror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Category groups error codes by what the caller should do about them.
+type Category string
+
+const (
+	// CategoryValidation: the request is malformed or not allowed. Change it
+	// before retrying.
+	CategoryValidation Category = "validation"
+	// CategoryNotFound: a named session, app or other resource does not exist.
+	CategoryNotFound Category = "not_found"
+	// CategoryConflict: the request clashes with the current state, such as a
+	// name already in use or an operation already in progress.
+	CategoryConflict Category = "conflict"
+	// CategoryQuota: a per-session limit or rate limit was reached.
+	CategoryQuota Category = "quota"
+	// CategoryPlatform: the platform or a system it depends on failed.
+	CategoryPlatform Category = "platform"
+)
+
+// Well-known codes. Codes are stable snake_case identifiers; new ones may be
+// added, so callers should fall back to the category for unknown codes.
+const (
+	CodeInvalidRequest       = "invalid_request"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodePolicyViolation      = "policy_violation"
+	CodeNotFound             = "not_found"
+	CodeSessionNotFound      = "session_not_found"
+	CodeAppNotFound          = "app_not_found"
+	CodeConflict             = "conflict"
+	CodeNameTaken            = "name_taken"
+	CodeVersionConflict      = "version_conflict"
+	CodePreconditionFailed   = "precondition_failed"
+	CodeIdempotencyKeyReused = "idempotency_key_reused"
+	CodeRequestInProgress    = "request_in_progress"
+	CodeQuotaExceeded        = "quota_exceeded"
+	CodeRateLimited          = "rate_limited"
+	CodeInternal             = "internal"
+	CodeUnavailable          = "unavailable"
+	CodeUpstreamError        = "upstream_error"
+	CodeDeadlineExceeded     = "deadline_exceeded"
+	CodeUploadNotFound       = "upload_not_found"
+	CodeUploadOffsetMismatch = "upload_offset_mismatch"
+	CodeSourceTooLarge       = "source_too_large"
+	CodeTooManyUploads       = "too_many_uploads"
+	CodeCapabilityDisabled   = "capability_disabled"
+	CodeDeletionProtected    = "deletion_protected"
+	CodeMaintenance          = "maintenance"
+)
+
+// Error is a classified error.
+type Error struct {
+	Code      string
+	Category  Category
+	Message   string
+	Retryable bool
+	Hint      string
+	// Details is extra machine-readable context for the code, such as the
+	// current state of a resource on version_conflict.
+	Details any
+	// Err is the underlying cause, if any. It is not shown to callers
+	// beyond what Message says.
+	Err error
+}
+
+func (e *Error) Error() string { return e.Message }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// WithHint returns a copy of e with hint set.
+func (e *Error) WithHint(hint string) *Error {
+	c := *e
+	c.Hint = hint
+	return &c
+}
+
+// WithDetails returns a copy of e with details set.
+func (e *Error) WithDetails(details any) *Error {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// Response is the JSON body of an error, in both REST responses and MCP
+// tool results. Error holds the human-readable message, so clients that
+// only read it keep working.
+type Response struct {
+	Error     string   `json:"error"`
+	Code      string   `json:"code"`
+	Category  Category `json:"category"`
+	Retryable bool     `json:"retryable"`
+	Hint      string   `json:"hint,omitempty"`
+	Details   any      `json:"details,omitempty"`
+}
+
+// Response returns the JSON body for e.
+func (e *Error) Response() Response {
+	return Response{Error: e.Message, Code: e.Code, Category: e.Category, Retryable: e.Retryable, Hint: e.Hint, Details: e.Details}
+}
+
+func newError(code string, category Category, format string, args []any) *Error {
+	err := fmt.Errorf(format, args...)
+	return &Error{Code: code, Category: category, Message: err.Error(), Err: errors.Unwrap(err)}
+}
+
+// Validation returns a validation error with the given code.
+func Validation(code, format string, args ...any) *Error {
+	return newError(code, CategoryValidation, format, args)
+}
+
+// NotFound returns a not-found error with the given code.
+func NotFound(code, format string, args ...any) *Error {
+	return newError(code, CategoryNotFound, format, args)
+}
+
+// Conflict returns a conflict error with the given code.
+func Conflict(code, format string, args ...any) *Error {
+	return newError(code, CategoryConflict, format, args)
+}
+
+// Quota returns a quota error with the given code.
+func Quota(code, format string, args ...any) *Error {
+	return newError(code, CategoryQuota, format, args)
+}
+
+// Platform returns a platform error with the given code.
+func Platform(code string, retryable bool, format string, args ...any) *Error {
+	e := newError(code, CategoryPlatform, format, args)
+	e.Retryable = retryable
+	return e
+}
+
+// From classifies err. A typed *Error anywhere in the chain is returned as
+// is. Kubernetes API errors and context errors are mapped by kind; the
+// message is kept. Any other error is a validation error when it is a
+// plain message, and a platform failure otherwise.
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		if e.Message != err.Error() {
+			// Keep the context added while the error was returned.
+			c := *e
+			c.Message, c.Err = err.Error(), err
+			return &c
+		}
+		return e
+	}
+	msg := err.Error()
+	classified := func(code string, category Category, retryable bool) *Error {
+		return &Error{Code: code, Category: category, Message: msg, Retryable: retryable, Err: err}
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return classified(CodeDeadlineExceeded, CategoryPlatform, true)
+	case errors.Is(err, context.Canceled):
+		return classified(CodeUnavailable, CategoryPlatform, true)
+	case apierrors.IsNotFound(err):
+		return classified(CodeNotFound, CategoryNotFound, false)
+	case apierrors.IsAlreadyExists(err):
+		return classified(CodeConflict, CategoryConflict, false)
+	case apierrors.IsConflict(err):
+		// Another writer updated the object first; a fresh read succeeds.
+		return classified(CodeConflict, CategoryConflict, true)
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+		return classified(CodeInvalidRequest, CategoryValidation, false)
+	case apierrors.IsTooManyRequests(err):
+		return classified(CodeRateLimited, CategoryQuota, true)
+	case apierrors.IsServerTimeout(err), apierrors.IsTimeout(err), apierrors.IsServiceUnavailable(err):
+		return classified(CodeUnavailable, CategoryPlatform, true)
+	}
+	// The tools report bad input with errors.New or fmt.Errorf, possibly
+	// wrapped with context; anything else at the root, such as a network or
+	// decoding error, is a platform failure.
+	root := err
+	for next := errors.Unwrap(root); next != nil; next = errors.Unwrap(root) {
+		root = next
+	}
+	if reflect.TypeOf(root) == plainErrorType {
+		return classified(CodeInvalidRequest, CategoryValidation, false)
+	}
+	return classified(CodeInternal, CategoryPlatform, false)
+}
+
+// plainErrorType is the type of errors made by errors.New, and by
+// fmt.Errorf without %w.
+var plainErrorType = reflect.TypeOf(errors.New(""))
+
+// FromStatus returns an error with message classified by the HTTP status a
+// REST handler responds with.
+func FromStatus(status int, message string) *Error {
+	e := &Error{Message: message}
+	switch status {
+	case http.StatusUnauthorized:
+		e.Code, e.Category = CodeUnauthorized, CategoryValidation
+	case http.StatusForbidden:
+		e.Code, e.Category = CodeForbidden, CategoryValidation
+	case http.StatusNotFound:
+		e.Code, e.Category = CodeNotFound, CategoryNotFound
+	case http.StatusConflict:
+		e.Code, e.Category = CodeConflict, CategoryConflict
+	case http.StatusPreconditionFailed:
+		e.Code, e.Category = CodePreconditionFailed, CategoryConflict
+	case http.StatusTooManyRequests:
+		e.Code, e.Category, e.Retryable = CodeRateLimited, CategoryQuota, true
+	case http.StatusBadGateway:
+		e.Code, e.Category, e.Retryable = CodeUpstreamError, CategoryPlatform, true
+	case http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		e.Code, e.Category, e.Retryable = CodeUnavailable, CategoryPlatform, true
+	default:
+		if status >= 500 {
+			e.Code, e.Category = CodeInternal, CategoryPlatform
+		} else {
+			e.Code, e.Category = CodeInvalidRequest, CategoryValidation
+		}
+	}
+	return e
+}
+
+// HTTPStatus returns the HTTP status a REST handler responds to e with.
+func HTTPStatus(e *Error) int {
+	switch e.Code {
+	case CodeUnauthorized:
+		return http.StatusUnauthorized
+	case CodeForbidden, CodePolicyViolation:
+		return http.StatusForbidden
+	case CodePreconditionFailed:
+		return http.StatusPreconditionFailed
+	case CodeUpstreamError:
+		return http.StatusBadGateway
+	case CodeUnavailable, CodeMaintenance:
+		return http.StatusServiceUnavailable
+	case CodeDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case CodeSourceTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case CodeCapabilityDisabled:
+		return http.StatusNotImplemented
+	}
+	switch e.Category {
+	case CategoryValidation:
+		return http.StatusBadRequest
+	case CategoryNotFound:
+		return http.StatusNotFound
+	case CategoryConflict:
+		return http.StatusConflict
+	case CategoryQuota:
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
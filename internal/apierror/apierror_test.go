@@ -0,0 +1,110 @@
+package apierror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestFrom(t *testing.T) {
+	gr := schema.GroupResource{Group: "iaf.io", Resource: "applications"}
+	typed := NotFound(CodeAppNotFound, "application %q not found", "web").WithHint("list_apps shows your applications")
+
+	tests := []struct {
+		name          string
+		err           error
+		wantCode      string
+		wantCategory  Category
+		wantRetryable bool
+		wantMessage   string
+	}{
+		{"typed", typed, CodeAppNotFound, CategoryNotFound, false, `application "web" not found`},
+		{"wrapped typed keeps context", fmt.Errorf("deleting: %w", typed), CodeAppNotFound, CategoryNotFound, false, `deleting: application "web" not found`},
+		{"plain message", errors.New("name is required"), CodeInvalidRequest, CategoryValidation, false, "name is required"},
+		{"wrapped plain message", fmt.Errorf("invalid app name: %w", errors.New("too long")), CodeInvalidRequest, CategoryValidation, false, "invalid app name: too long"},
+		{"other root cause", fmt.Errorf("reading source: %w", &os.PathError{Op: "open", Path: "/src", Err: syscall.ENOENT}), CodeInternal, CategoryPlatform, false, ""},
+		{"deadline", fmt.Errorf("listing pods: %w", context.DeadlineExceeded), CodeDeadlineExceeded, CategoryPlatform, true, "listing pods: context deadline exceeded"},
+		{"k8s not found", apierrors.NewNotFound(gr, "web"), CodeNotFound, CategoryNotFound, false, ""},
+		{"k8s already exists", apierrors.NewAlreadyExists(gr, "web"), CodeConflict, CategoryConflict, false, ""},
+		{"k8s conflict", fmt.Errorf("updating: %w", apierrors.NewConflict(gr, "web", errors.New("modified"))), CodeConflict, CategoryConflict, true, ""},
+		{"k8s invalid", apierrors.NewBadRequest("bad"), CodeInvalidRequest, CategoryValidation, false, ""},
+		{"k8s throttled", apierrors.NewTooManyRequests("slow down", 1), CodeRateLimited, CategoryQuota, true, ""},
+		{"k8s unavailable", apierrors.NewServiceUnavailable("down"), CodeUnavailable, CategoryPlatform, true, ""},
+		{"k8s forbidden", apierrors.NewForbidden(gr, "web", errors.New("denied")), CodeInternal, CategoryPlatform, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := From(tt.err)
+			if got.Code != tt.wantCode || got.Category != tt.wantCategory || got.Retryable != tt.wantRetryable {
+				t.Errorf("From() = %s/%s retryable=%v, want %s/%s retryable=%v", got.Code, got.Category, got.Retryable, tt.wantCode, tt.wantCategory, tt.wantRetryable)
+			}
+			want := tt.wantMessage
+			if want == "" {
+				want = tt.err.Error()
+			}
+			if got.Message != want {
+				t.Errorf("Message = %q, want %q", got.Message, want)
+			}
+			if !errors.Is(got, tt.err) && !errors.Is(tt.err, got) {
+				t.Error("the classified error should keep the original in its chain")
+			}
+		})
+	}
+	if From(fmt.Errorf("x: %w", typed)).Hint != typed.Hint {
+		t.Error("wrapping should keep the hint")
+	}
+	if From(nil) != nil {
+		t.Error("From(nil) should be nil")
+	}
+}
+
+func TestFromStatus(t *testing.T) {
+	tests := []struct {
+		status        int
+		wantCode      string
+		wantCategory  Category
+		wantRetryable bool
+	}{
+		{http.StatusBadRequest, CodeInvalidRequest, CategoryValidation, false},
+		{http.StatusUnauthorized, CodeUnauthorized, CategoryValidation, false},
+		{http.StatusForbidden, CodeForbidden, CategoryValidation, false},
+		{http.StatusNotFound, CodeNotFound, CategoryNotFound, false},
+		{http.StatusConflict, CodeConflict, CategoryConflict, false},
+		{http.StatusTooManyRequests, CodeRateLimited, CategoryQuota, true},
+		{http.StatusInternalServerError, CodeInternal, CategoryPlatform, false},
+		{http.StatusBadGateway, CodeUpstreamError, CategoryPlatform, true},
+		{http.StatusServiceUnavailable, CodeUnavailable, CategoryPlatform, true},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			got := FromStatus(tt.status, "boom").Response()
+			if got.Code != tt.wantCode || got.Category != tt.wantCategory || got.Retryable != tt.wantRetryable || got.Error != "boom" {
+				t.Errorf("FromStatus(%d) = %+v", tt.status, got)
+			}
+		})
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	for _, status := range []int{400, 401, 403, 404, 409, 412, 429, 500, 502, 503} {
+		if got := HTTPStatus(FromStatus(status, "boom")); got != status {
+			t.Errorf("HTTPStatus(FromStatus(%d)) = %d", status, got)
+		}
+	}
+	if got := HTTPStatus(Validation(CodePolicyViolation, "blocked")); got != http.StatusForbidden {
+		t.Errorf("policy violation: got %d, want 403", got)
+	}
+	if got := HTTPStatus(Conflict(CodeVersionConflict, "stale")); got != http.StatusConflict {
+		t.Errorf("version conflict: got %d, want 409", got)
+	}
+	if got := HTTPStatus(From(context.DeadlineExceeded)); got != http.StatusGatewayTimeout {
+		t.Errorf("deadline exceeded: got %d, want 504", got)
+	}
+}
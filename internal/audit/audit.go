@@ -0,0 +1,276 @@
+// Package audit keeps the history of the tool calls made in each session,
+// so an agent resuming work with a fresh context can see what it already
+// did on the platform. Each session's calls are appended to a JSON lines
+// file, which survives restarts and is deleted with the session. It also
+// writes the security audit stream of credential and Secret access, see
+// SecurityLog.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxEntries is how many calls are kept per session; older ones are
+	// dropped.
+	MaxEntries = 1000
+
+	// MaxValueLength is the longest string argument or summary recorded;
+	// longer ones are cut.
+	MaxValueLength = 200
+
+	// compactBytes is the file size beyond which a session's file is
+	// rewritten with only its newest MaxEntries calls.
+	compactBytes = 1 << 20
+)
+
+// Outcome is how a tool call ended.
+type Outcome string
+
+const (
+	OutcomeOK    Outcome = "ok"
+	OutcomeError Outcome = "error"
+)
+
+// Entry is one recorded tool call.
+type Entry struct {
+	Time time.Time `json:"time"`
+	Tool string    `json:"tool"`
+	// Args are the key arguments of the call, see Args.
+	Args    map[string]any `json:"args,omitempty"`
+	Outcome Outcome        `json:"outcome"`
+	// Code is the error code of a failed call.
+	Code string `json:"code,omitempty"`
+	// Summary is the message of the result or error, cut to
+	// MaxValueLength.
+	Summary string `json:"summary,omitempty"`
+}
+
+// Query selects the entries List returns.
+type Query struct {
+	// Since leaves out calls made before it, when set.
+	Since time.Time
+	// Tool only returns calls of the named tool, when set.
+	Tool string
+	// Limit returns only the newest Limit matching calls, when positive.
+	Limit int
+}
+
+// sensitiveArgs are arguments whose values are never recorded.
+var sensitiveArgs = []string{
+	"password", "private_key", "content", "kubeconfig", "continuation", "token",
+}
+
+// skippedArgs are arguments left out of the record altogether.
+var skippedArgs = []string{"session_id"}
+
+// Args returns the key arguments of a tool call from its raw JSON
+// arguments. Strings, numbers and booleans are kept, strings cut to
+// MaxValueLength; lists and objects, such as env vars and source files,
+// are only counted; and secrets are replaced by "[redacted]". Invalid JSON
+// yields nil.
+func Args(raw json.RawMessage) map[string]any {
+	var args map[string]any
+	if len(raw) == 0 || json.Unmarshal(raw, &args) != nil {
+		return nil
+	}
+	out := map[string]any{}
+	for k, v := range args {
+		switch {
+		case slices.Contains(skippedArgs, k) || v == nil:
+		case slices.Contains(sensitiveArgs, k):
+			out[k] = "[redacted]"
+		default:
+			switch v := v.(type) {
+			case string:
+				out[k] = Cut(v)
+			case []any:
+				out[k] = fmt.Sprintf("%d items", len(v))
+			case map[string]any:
+				out[k] = fmt.Sprintf("%d entries", len(v))
+			default:
+				out[k] = v
+			}
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// Cut returns s cut to MaxValueLength bytes on a rune boundary, marked with
+// "..." when it was cut.
+func Cut(s string) string {
+	if len(s) <= MaxValueLength {
+		return s
+	}
+	n := MaxValueLength - len("...")
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
+
+// validID matches the session IDs the session store generates, so an ID
+// can never name a file outside the log's directory.
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// Log stores the tool call history of every session under a directory. A
+// nil *Log records nothing.
+type Log struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// New returns a log keeping its files in dir, creating it if needed.
+func New(dir string) (*Log, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating history directory: %w", err)
+	}
+	return &Log{dir: dir}, nil
+}
+
+func (l *Log) path(sessionID string) (string, error) {
+	if !validID.MatchString(sessionID) {
+		return "", fmt.Errorf("invalid session ID %q", sessionID)
+	}
+	return filepath.Join(l.dir, sessionID+".jsonl"), nil
+}
+
+// Record appends e to the history of the session.
+func (l *Log) Record(sessionID string, e Entry) error {
+	if l == nil {
+		return nil
+	}
+	path, err := l.path(sessionID)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening history: %w", err)
+	}
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("recording tool call: %w", err)
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() > compactBytes {
+		return l.compactLocked(path)
+	}
+	return nil
+}
+
+// compactLocked rewrites the file at path with its newest MaxEntries
+// entries. Caller must hold l.mu.
+func (l *Log) compactLocked(path string) error {
+	entries, err := read(path)
+	if err != nil {
+		return err
+	}
+	entries = entries[max(0, len(entries)-MaxEntries):]
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("compacting history: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// List returns the calls of the session matching q, oldest first, and how
+// many calls matched before q.Limit was applied. A session without calls
+// has an empty history.
+func (l *Log) List(sessionID string, q Query) ([]Entry, int, error) {
+	if l == nil {
+		return nil, 0, nil
+	}
+	path, err := l.path(sessionID)
+	if err != nil {
+		return nil, 0, err
+	}
+	l.mu.Lock()
+	entries, err := read(path)
+	l.mu.Unlock()
+	if err != nil {
+		return nil, 0, err
+	}
+	entries = entries[max(0, len(entries)-MaxEntries):]
+	entries = slices.DeleteFunc(entries, func(e Entry) bool {
+		return (!q.Since.IsZero() && e.Time.Before(q.Since)) || (q.Tool != "" && e.Tool != q.Tool)
+	})
+	total := len(entries)
+	if q.Limit > 0 && len(entries) > q.Limit {
+		entries = entries[len(entries)-q.Limit:]
+	}
+	return entries, total, nil
+}
+
+// read returns the entries in the file at path, none when it does not
+// exist. Lines that do not decode, such as one cut short by a crash, are
+// skipped.
+func read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading history: %w", err)
+	}
+	defer f.Close()
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var e Entry
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading history: %w", err)
+	}
+	return entries, nil
+}
+
+// Delete removes the history of the session.
+func (l *Log) Delete(sessionID string) error {
+	if l == nil {
+		return nil
+	}
+	path, err := l.path(sessionID)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("deleting history: %w", err)
+	}
+	return nil
+}
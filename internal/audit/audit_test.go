@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArgs(t *testing.T) {
+	raw := json.RawMessage(`{
+		"session_id": "abc",
+		"name": "web",
+		"replicas": 2,
+		"env": [{"name": "DB_PASSWORD", "value": "hunter2"}],
+		"files": {"main.go": "package main"},
+		"password": "s3cret",
+		"image": "` + strings.Repeat("x", 300) + `"
+	}`)
+	args := Args(raw)
+	if _, ok := args["session_id"]; ok {
+		t.Error("expected session_id left out")
+	}
+	if args["name"] != "web" || args["replicas"] != float64(2) {
+		t.Errorf("expected scalars kept, got %v", args)
+	}
+	if args["env"] != "1 items" || args["files"] != "1 entries" || args["password"] != "[redacted]" {
+		t.Errorf("expected lists counted and secrets redacted, got %v", args)
+	}
+	if image := args["image"].(string); len(image) != MaxValueLength || !strings.HasSuffix(image, "...") {
+		t.Errorf("expected a long string cut, got %d bytes", len(image))
+	}
+	if Args(json.RawMessage(`not json`)) != nil || Args(json.RawMessage(`{"session_id": "abc"}`)) != nil {
+		t.Error("expected nil args")
+	}
+}
+
+func TestLog(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	for i, tool := range []string{"register", "deploy_app", "app_status", "deploy_app"} {
+		e := Entry{Time: start.Add(time.Duration(i) * time.Minute), Tool: tool, Outcome: OutcomeOK}
+		if err := l.Record("s1", e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, total, err := l.List("s1", Query{})
+	if err != nil || total != 4 || len(all) != 4 || all[0].Tool != "register" {
+		t.Fatalf("unexpected history %v (%d), %v", all, total, err)
+	}
+	deploys, total, _ := l.List("s1", Query{Tool: "deploy_app", Limit: 1})
+	if total != 2 || len(deploys) != 1 || !deploys[0].Time.Equal(start.Add(3*time.Minute)) {
+		t.Errorf("expected the newest of 2 deploys, got %v (%d)", deploys, total)
+	}
+	recent, _, _ := l.List("s1", Query{Since: start.Add(2 * time.Minute)})
+	if len(recent) != 2 {
+		t.Errorf("expected 2 calls since, got %d", len(recent))
+	}
+	if other, _, _ := l.List("s2", Query{}); len(other) != 0 {
+		t.Error("expected another session to have an empty history")
+	}
+
+	// The history survives a restart.
+	reopened, _ := New(dir)
+	if _, total, _ := reopened.List("s1", Query{}); total != 4 {
+		t.Errorf("expected 4 calls after reopening, got %d", total)
+	}
+
+	if err := l.Delete("s1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, total, _ := l.List("s1", Query{}); total != 0 {
+		t.Error("expected the history deleted")
+	}
+	if err := l.Record("../escape", Entry{}); err == nil {
+		t.Error("expected an invalid session ID to be refused")
+	}
+}
+
+func TestLog_Compact(t *testing.T) {
+	l, _ := New(t.TempDir())
+	summary := strings.Repeat("x", MaxValueLength)
+	for i := range 2 * MaxEntries * 3 {
+		if err := l.Record("s1", Entry{Tool: fmt.Sprintf("tool-%d", i), Summary: summary}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	info, err := os.Stat(filepath.Join(l.dir, "s1.jsonl"))
+	if err != nil || info.Size() > compactBytes {
+		t.Errorf("expected the file compacted, got %v, %v", info.Size(), err)
+	}
+	entries, total, _ := l.List("s1", Query{})
+	if total != MaxEntries || entries[len(entries)-1].Tool != fmt.Sprintf("tool-%d", 2*MaxEntries*3-1) {
+		t.Errorf("expected the newest %d calls, got %d", MaxEntries, total)
+	}
+}
@@ -0,0 +1,366 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SecurityAction is what a security audit event records.
+type SecurityAction string
+
+const (
+	// ActionCredentialCreated and ActionCredentialDeleted record a
+	// credential, such as a git or registry credential, stored or removed.
+	ActionCredentialCreated SecurityAction = "credential.created"
+	ActionCredentialDeleted SecurityAction = "credential.deleted"
+	// ActionCredentialIssued records a short-lived credential handed out,
+	// such as a namespace kubeconfig.
+	ActionCredentialIssued SecurityAction = "credential.issued"
+	// ActionSecretRead records secret material returned to a caller.
+	ActionSecretRead SecurityAction = "secret.read"
+	// ActionSecretCopied records a Secret copied into a session namespace,
+	// such as the credentials of an attached data source.
+	ActionSecretCopied SecurityAction = "secret.copied"
+	// ActionSecretMounted and ActionSecretUnmounted record an app's pods
+	// starting or stopping to use a Secret.
+	ActionSecretMounted   SecurityAction = "secret.mounted"
+	ActionSecretUnmounted SecurityAction = "secret.unmounted"
+	// ActionSecretRotated records new content of Secrets an app uses,
+	// which rolls its pods.
+	ActionSecretRotated SecurityAction = "secret.rotated"
+)
+
+// SecurityEvent is one entry of the security audit stream. It never holds
+// secret material, only names.
+type SecurityEvent struct {
+	// Stream names the process writing the stream, such as
+	// "apiserver-<pod>". Seq numbers its events from 1 without gaps.
+	Stream string         `json:"stream"`
+	Seq    uint64         `json:"seq"`
+	Time   time.Time      `json:"time"`
+	Action SecurityAction `json:"action"`
+	// Actor is who acted: a session ID, or "controller".
+	Actor     string `json:"actor"`
+	Namespace string `json:"namespace"`
+	// Kind and Name identify the credential or Secret acted on, such as
+	// git_credential and its name.
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Secret is the Secret holding the material, when it is not Name.
+	Secret string `json:"secret,omitempty"`
+	// Source is the namespace/name of the Secret a copy was made from.
+	Source string `json:"source,omitempty"`
+	// App is the app that uses the Secret.
+	App string `json:"app,omitempty"`
+	// Expires is when an issued credential stops working.
+	Expires time.Time `json:"expires,omitzero"`
+	// Prev is the Hash of the event before in the stream, empty for the
+	// first. Hash is the SHA-256 of the event's JSON without Hash, so
+	// changing, removing or reordering events breaks the chain.
+	Prev string `json:"prev"`
+	Hash string `json:"hash,omitempty"`
+}
+
+// hash returns the chain hash of e.
+func (e SecurityEvent) hash() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// webhookQueueSize is how many events wait for delivery to the webhook
+// before new ones are dropped.
+const webhookQueueSize = 1024
+
+// SecurityLog is the security audit stream of one process. Each event is
+// numbered and chained to the one before, logged as a security_audit line,
+// appended to a file when the log has one, and posted to a webhook when it
+// has one. A nil *SecurityLog records nothing.
+type SecurityLog struct {
+	stream  string
+	path    string
+	webhook *Webhook
+	logger  *slog.Logger
+
+	mu   sync.Mutex
+	seq  uint64
+	prev string
+
+	queue chan []byte
+	done  chan struct{}
+}
+
+// NewSecurityLog returns the stream named stream. With a path, events are
+// appended to that file, and numbering and the chain continue from its
+// last event. With a webhook, events are posted to it in the background.
+func NewSecurityLog(stream, path string, webhook *Webhook, logger *slog.Logger) (*SecurityLog, error) {
+	l := &SecurityLog{stream: stream, path: path, webhook: webhook, logger: logger}
+	if path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, fmt.Errorf("creating security audit directory: %w", err)
+		}
+		last, err := lastSecurityEvent(path)
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			l.seq, l.prev = last.Seq, last.Hash
+		}
+	}
+	if webhook != nil {
+		l.queue = make(chan []byte, webhookQueueSize)
+		l.done = make(chan struct{})
+		go l.deliver(l.queue)
+	}
+	return l, nil
+}
+
+// lastSecurityEvent returns the last event in the file at path, or nil
+// when there is none.
+func lastSecurityEvent(path string) (*SecurityEvent, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading security audit: %w", err)
+	}
+	defer f.Close()
+	var last *SecurityEvent
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var e SecurityEvent
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			last = &e
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading security audit: %w", err)
+	}
+	return last, nil
+}
+
+// Record adds e to the stream, filling in its stream, number, time and
+// chain. Recording never fails the action recorded: errors are logged.
+func (l *SecurityLog) Record(e SecurityEvent) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e.Stream = l.stream
+	e.Seq = l.seq + 1
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	e.Prev = l.prev
+	hash, err := e.hash()
+	if err != nil {
+		l.logger.Error("failed to record security audit event", "action", e.Action, "error", err)
+		return
+	}
+	e.Hash = hash
+	line, err := json.Marshal(e)
+	if err != nil {
+		l.logger.Error("failed to record security audit event", "action", e.Action, "error", err)
+		return
+	}
+	if l.path != "" {
+		if err := appendLine(l.path, line); err != nil {
+			l.logger.Error("failed to record security audit event", "action", e.Action, "error", err)
+			return
+		}
+	}
+	l.seq, l.prev = e.Seq, e.Hash
+
+	args := []any{
+		"stream", e.Stream,
+		"seq", e.Seq,
+		"action", e.Action,
+		"actor", e.Actor,
+		"namespace", e.Namespace,
+		"kind", e.Kind,
+		"name", e.Name,
+		"secret", e.Secret,
+		"source", e.Source,
+		"app", e.App,
+		"hash", e.Hash,
+	}
+	if !e.Expires.IsZero() {
+		args = append(args, "expires", e.Expires)
+	}
+	l.logger.Info("security_audit", args...)
+	if l.queue != nil {
+		select {
+		case l.queue <- line:
+		default:
+			// The gap in the numbering shows the SIEM what it missed.
+			l.logger.Warn("security audit webhook queue full, event not sent", "seq", e.Seq)
+		}
+	}
+}
+
+func appendLine(path string, line []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening security audit: %w", err)
+	}
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// deliver posts the events on queue to the webhook until Close.
+func (l *SecurityLog) deliver(queue <-chan []byte) {
+	defer close(l.done)
+	for body := range queue {
+		if err := l.webhook.send(body); err != nil {
+			l.logger.Error("failed to send security audit event to webhook", "error", err)
+		}
+	}
+}
+
+// Close sends the events still queued for the webhook and stops.
+func (l *SecurityLog) Close() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	queue := l.queue
+	l.queue = nil
+	l.mu.Unlock()
+	if queue == nil {
+		return
+	}
+	close(queue)
+	<-l.done
+}
+
+// VerifySecurityLog checks the numbering and chain of the events of one
+// stream read from r, such as a security audit file, and returns how many
+// it read. The first event may follow earlier ones that were removed on
+// purpose, so only its own hash is checked.
+func VerifySecurityLog(r io.Reader) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	var prev *SecurityEvent
+	n := 0
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		n++
+		var e SecurityEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return n, fmt.Errorf("line %d: %w", n, err)
+		}
+		hash, err := e.hash()
+		if err != nil {
+			return n, fmt.Errorf("line %d: %w", n, err)
+		}
+		if hash != e.Hash {
+			return n, fmt.Errorf("event %d: hash does not match its content", e.Seq)
+		}
+		if prev != nil {
+			if e.Seq != prev.Seq+1 {
+				return n, fmt.Errorf("event %d follows event %d: events are missing", e.Seq, prev.Seq)
+			}
+			if e.Prev != prev.Hash {
+				return n, fmt.Errorf("event %d: chain broken after event %d", e.Seq, prev.Seq)
+			}
+		}
+		prev = &e
+	}
+	if err := scanner.Err(); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// WebhookSignatureHeader carries the HMAC-SHA256 of the body of a webhook
+// request as sha256=<hex>, when the webhook has a secret.
+const WebhookSignatureHeader = "X-IAF-Signature-256"
+
+// webhookAttempts is how many times an event is posted before it is given
+// up on.
+const webhookAttempts = 3
+
+// Webhook posts security audit events, one JSON event per request, to a
+// SIEM or log collector.
+type Webhook struct {
+	URL string
+	// Secret signs each body in WebhookSignatureHeader when set.
+	Secret string
+	// Client sends the requests; nil means a client with a 10 second
+	// timeout.
+	Client *http.Client
+	// Backoff is the wait before the first retry, doubled for each
+	// retry after it; zero means one second.
+	Backoff time.Duration
+}
+
+// send posts body, retrying failed attempts.
+func (w *Webhook) send(body []byte) error {
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	backoff := w.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	var err error
+	for attempt := range webhookAttempts {
+		if attempt > 0 {
+			time.Sleep(backoff << (attempt - 1))
+		}
+		if err = w.post(client, body); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (w *Webhook) post(client *http.Client, body []byte) error {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.Secret))
+		mac.Write(body)
+		req.Header.Set(WebhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
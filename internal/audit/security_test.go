@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSecurityLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "apiserver.jsonl")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	l, err := NewSecurityLog("apiserver", path, nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Record(SecurityEvent{Action: ActionCredentialCreated, Actor: "s1", Namespace: "iaf-s1", Kind: "git_credential", Name: "github"})
+	l.Record(SecurityEvent{Action: ActionSecretCopied, Actor: "s1", Namespace: "iaf-s1", Kind: "data_source", Name: "orders", Secret: "web-orders", Source: "iaf-data/orders", App: "web"})
+
+	// A restart continues the numbering and the chain.
+	l, err = NewSecurityLog("apiserver", path, nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Record(SecurityEvent{Action: ActionCredentialDeleted, Actor: "s1", Namespace: "iaf-s1", Kind: "git_credential", Name: "github"})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+	if n, err := VerifySecurityLog(bytes.NewReader(data)); n != 3 || err != nil {
+		t.Fatalf("expected an intact stream of 3 events, got %d, %v", n, err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	var last SecurityEvent
+	if err := json.Unmarshal([]byte(lines[2]), &last); err != nil {
+		t.Fatal(err)
+	}
+	if last.Seq != 3 || last.Stream != "apiserver" || last.Prev == "" || last.Time.IsZero() {
+		t.Errorf("unexpected event %+v", last)
+	}
+
+	tampered := strings.Replace(string(data), `"name":"orders"`, `"name":"invoices"`, 1)
+	if _, err := VerifySecurityLog(strings.NewReader(tampered)); err == nil {
+		t.Error("expected a changed event to be detected")
+	}
+	removed := lines[0] + "\n" + lines[2] + "\n"
+	if _, err := VerifySecurityLog(strings.NewReader(removed)); err == nil {
+		t.Error("expected a removed event to be detected")
+	}
+	if n, err := VerifySecurityLog(strings.NewReader(lines[1] + "\n" + lines[2])); n != 2 || err != nil {
+		t.Errorf("expected a stream cut at its start to verify, got %d, %v", n, err)
+	}
+
+	var nilLog *SecurityLog
+	nilLog.Record(SecurityEvent{Action: ActionSecretRead})
+	nilLog.Close()
+}
+
+func TestSecurityLog_Webhook(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []SecurityEvent
+		attempts int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts == 1 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		mac := hmac.New(sha256.New, []byte("shh"))
+		mac.Write(body)
+		if r.Header.Get(WebhookSignatureHeader) != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+			t.Errorf("unexpected signature %q", r.Header.Get(WebhookSignatureHeader))
+		}
+		var e SecurityEvent
+		if err := json.Unmarshal(body, &e); err != nil {
+			t.Error(err)
+		}
+		received = append(received, e)
+	}))
+	defer srv.Close()
+
+	webhook := &Webhook{URL: srv.URL, Secret: "shh", Backoff: time.Millisecond}
+	l, err := NewSecurityLog("controller", "", webhook, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Record(SecurityEvent{Action: ActionSecretMounted, Actor: "controller", Namespace: "iaf-s1", Kind: "secret", Name: "web-orders", App: "web"})
+	l.Record(SecurityEvent{Action: ActionSecretRotated, Actor: "controller", Namespace: "iaf-s1", Kind: "managed_service_credentials", Name: "web", App: "web"})
+	l.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 || received[0].Seq != 1 || received[1].Seq != 2 || received[1].Prev != received[0].Hash {
+		t.Fatalf("expected both events in order after a retry, got %+v", received)
+	}
+}
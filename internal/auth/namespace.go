@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"fmt"
+	"maps"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -12,6 +13,12 @@ import (
 
 // EnsureNamespace creates the namespace and a kpack service account if they don't exist.
 func EnsureNamespace(ctx context.Context, c client.Client, namespace string) error {
+	return ensureNamespace(ctx, c, namespace, nil)
+}
+
+// ensureNamespace is EnsureNamespace creating the namespace with extra
+// labels.
+func ensureNamespace(ctx context.Context, c client.Client, namespace string, labels map[string]string) error {
 	ns := &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: namespace,
@@ -20,6 +27,7 @@ func EnsureNamespace(ctx context.Context, c client.Client, namespace string) err
 			},
 		},
 	}
+	maps.Copy(ns.Labels, labels)
 	if err := c.Create(ctx, ns); err != nil && !apierrors.IsAlreadyExists(err) {
 		return fmt.Errorf("creating namespace %q: %w", namespace, err)
 	}
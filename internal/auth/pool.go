@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// LabelPool marks a pre-provisioned namespace no session has claimed yet.
+const LabelPool = "iaf.io/pool"
+
+// poolWarm is the LabelPool value of an unclaimed namespace.
+const poolWarm = "warm"
+
+// poolResync is how often the pool is topped up besides after each claim,
+// which covers claims made by other replicas.
+const poolResync = time.Minute
+
+// NamespacePool keeps a number of session namespaces provisioned ahead of
+// time so registering does not wait for them. Warm namespaces are labelled
+// with LabelPool and named like session namespaces; a session claims one by
+// removing the label, which the API server's optimistic concurrency keeps
+// to one claimant even across replicas. A nil *NamespacePool is an empty
+// pool.
+type NamespacePool struct {
+	client client.Client
+	size   int
+	logger *slog.Logger
+	refill chan struct{}
+}
+
+// NewNamespacePool returns a pool of size namespaces. Start keeps it full.
+func NewNamespacePool(c client.Client, size int, logger *slog.Logger) *NamespacePool {
+	return &NamespacePool{client: c, size: size, logger: logger, refill: make(chan struct{}, 1)}
+}
+
+// Start brings the existing warm namespaces up to date, then tops the pool
+// up after every claim and every minute. It blocks until ctx is cancelled.
+func (p *NamespacePool) Start(ctx context.Context) {
+	warm, err := p.warm(ctx)
+	if err != nil {
+		p.logger.Error("listing warm namespaces", "error", err)
+	}
+	for _, ns := range warm {
+		if err := EnsureNamespace(ctx, p.client, ns.Name); err != nil {
+			p.logger.Error("updating warm namespace", "namespace", ns.Name, "error", err)
+		}
+	}
+	ticker := time.NewTicker(poolResync)
+	defer ticker.Stop()
+	for {
+		if err := p.Fill(ctx); err != nil {
+			p.logger.Error("filling namespace pool", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.refill:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Fill creates warm namespaces until the pool holds its size and deletes
+// the newest ones beyond it.
+func (p *NamespacePool) Fill(ctx context.Context) error {
+	warm, err := p.warm(ctx)
+	if err != nil {
+		return err
+	}
+	for _, ns := range slices.Backward(warm[min(p.size, len(warm)):]) {
+		if err := p.client.Delete(ctx, &ns); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("deleting surplus namespace %q: %w", ns.Name, err)
+		}
+	}
+	for range p.size - len(warm) {
+		id, err := generateID()
+		if err != nil {
+			return fmt.Errorf("generating namespace ID: %w", err)
+		}
+		if err := ensureNamespace(ctx, p.client, "iaf-"+id, map[string]string{LabelPool: poolWarm}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Claim takes a warm namespace out of the pool and returns the session ID
+// its name carries, or false when none is ready.
+func (p *NamespacePool) Claim(ctx context.Context) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	warm, err := p.warm(ctx)
+	if err != nil {
+		p.logger.Error("listing warm namespaces", "error", err)
+		return "", false
+	}
+	for _, ns := range warm {
+		id, ok := strings.CutPrefix(ns.Name, "iaf-")
+		if !ok {
+			continue
+		}
+		delete(ns.Labels, LabelPool)
+		if err := p.client.Update(ctx, &ns); err != nil {
+			// Another replica claimed it first, or it is going away.
+			continue
+		}
+		select {
+		case p.refill <- struct{}{}:
+		default:
+		}
+		return id, true
+	}
+	return "", false
+}
+
+// warm lists the unclaimed namespaces that are not being deleted, oldest
+// first.
+func (p *NamespacePool) warm(ctx context.Context) ([]corev1.Namespace, error) {
+	var list corev1.NamespaceList
+	if err := p.client.List(ctx, &list, client.MatchingLabels{LabelPool: poolWarm}); err != nil {
+		return nil, fmt.Errorf("listing warm namespaces: %w", err)
+	}
+	list.Items = slices.DeleteFunc(list.Items, func(ns corev1.Namespace) bool { return ns.DeletionTimestamp != nil })
+	slices.SortFunc(list.Items, func(a, b corev1.Namespace) int {
+		if c := a.CreationTimestamp.Compare(b.CreationTimestamp.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return list.Items, nil
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNamespacePool(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	ctx := context.Background()
+	pool := NewNamespacePool(k8sClient, 2, slog.Default())
+
+	warmCount := func() int {
+		t.Helper()
+		var list corev1.NamespaceList
+		if err := k8sClient.List(ctx, &list, client.MatchingLabels{LabelPool: poolWarm}); err != nil {
+			t.Fatal(err)
+		}
+		return len(list.Items)
+	}
+
+	if err := pool.Fill(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := warmCount(); n != 2 {
+		t.Fatalf("expected 2 warm namespaces, got %d", n)
+	}
+
+	sessions, err := NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions.SetPool(pool)
+	sess, err := sessions.Provision(ctx, k8sClient, "agent", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sess.Namespace != "iaf-"+sess.ID {
+		t.Errorf("namespace %q does not match session %q", sess.Namespace, sess.ID)
+	}
+	var ns corev1.Namespace
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: sess.Namespace}, &ns); err != nil {
+		t.Fatalf("claimed namespace missing: %v", err)
+	}
+	if _, ok := ns.Labels[LabelPool]; ok || ns.Labels["app.kubernetes.io/managed-by"] != "iaf" {
+		t.Errorf("claimed namespace labels = %v", ns.Labels)
+	}
+	var sa corev1.ServiceAccount
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "iaf-kpack-sa", Namespace: sess.Namespace}, &sa); err != nil {
+		t.Errorf("warm namespace has no kpack service account: %v", err)
+	}
+	if n := warmCount(); n != 1 {
+		t.Errorf("expected 1 warm namespace after a claim, got %d", n)
+	}
+
+	if err := pool.Fill(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := warmCount(); n != 2 {
+		t.Errorf("expected the pool refilled to 2, got %d", n)
+	}
+
+	pool.size = 0
+	if err := pool.Fill(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := warmCount(); n != 0 {
+		t.Errorf("expected surplus namespaces deleted, got %d", n)
+	}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: sess.Namespace}, &ns); err != nil {
+		t.Errorf("claimed namespace deleted with the surplus: %v", err)
+	}
+
+	// An empty pool falls back to creating the namespace.
+	sess, err = sessions.Provision(ctx, k8sClient, "agent", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: sess.Namespace}, &ns); err != nil {
+		t.Errorf("namespace not created without a warm one: %v", err)
+	}
+}
@@ -1,14 +1,23 @@
 package auth
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // Session represents an agent session with its associated namespace.
@@ -19,6 +28,9 @@ type Session struct {
 	CreatedAt      time.Time     `json:"created_at"`
 	LastActivityAt time.Time     `json:"last_activity_at"`
 	TTL            time.Duration `json:"ttl"` // 0 = no expiry
+	// Metadata is the ownership metadata given at registration, the
+	// default of applications created in the session.
+	Metadata *iafv1alpha1.OwnershipMetadata `json:"metadata,omitempty"`
 }
 
 // Expired returns true if the session has a TTL and has been inactive beyond it.
@@ -38,6 +50,10 @@ type SessionStore struct {
 	mu       sync.RWMutex
 	sessions map[string]*Session
 	path     string
+	pool     *NamespacePool
+	// sharedGitCredentials is the namespace of the git credentials copied
+	// into every new session namespace; empty copies none.
+	sharedGitCredentials string
 }
 
 // NewSessionStore creates a new session store that persists to the given file path.
@@ -62,6 +78,60 @@ func NewSessionStore(path string) (*SessionStore, error) {
 	return s, nil
 }
 
+// SetPool makes Provision hand out warm namespaces from pool.
+func (s *SessionStore) SetPool(pool *NamespacePool) {
+	s.pool = pool
+}
+
+// SetSharedGitCredentials makes Provision copy the git credentials in
+// namespace into each new session namespace.
+func (s *SessionStore) SetSharedGitCredentials(namespace string) {
+	s.sharedGitCredentials = namespace
+}
+
+// Provision registers a session like Register and readies its namespace:
+// a warm one claimed from the pool when one is ready, or else one created
+// now. Either way it gets the shared git credentials. The namespace is
+// readied before the session is stored, and deleted again when readying it
+// fails, so a failed Provision leaves neither behind.
+func (s *SessionStore) Provision(ctx context.Context, c client.Client, name string, ttl time.Duration) (*Session, error) {
+	id, ok := s.pool.Claim(ctx)
+	if !ok {
+		var err error
+		if id, err = generateID(); err != nil {
+			return nil, fmt.Errorf("generating session ID: %w", err)
+		}
+	}
+	namespace := "iaf-" + id
+	err := s.readyNamespace(ctx, c, namespace, !ok)
+	if err == nil {
+		var sess *Session
+		if sess, err = s.register(id, name, ttl); err == nil {
+			return sess, nil
+		}
+	}
+	// Nothing refers to the namespace yet.
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+	if delErr := c.Delete(ctx, ns); delErr != nil && !apierrors.IsNotFound(delErr) {
+		err = errors.Join(err, fmt.Errorf("deleting namespace %q: %w", namespace, delErr))
+	}
+	return nil, err
+}
+
+// readyNamespace creates namespace when create is set and copies the shared
+// git credentials into it.
+func (s *SessionStore) readyNamespace(ctx context.Context, c client.Client, namespace string, create bool) error {
+	if create {
+		if err := EnsureNamespace(ctx, c, namespace); err != nil {
+			return err
+		}
+	}
+	if s.sharedGitCredentials != "" {
+		return iafk8s.CopySharedGitCredentials(ctx, c, s.sharedGitCredentials, namespace)
+	}
+	return nil
+}
+
 // Register creates a new session with an auto-generated ID, namespace, and optional TTL.
 // ttl == 0 means sessions never expire.
 func (s *SessionStore) Register(name string, ttl time.Duration) (*Session, error) {
@@ -69,7 +139,11 @@ func (s *SessionStore) Register(name string, ttl time.Duration) (*Session, error
 	if err != nil {
 		return nil, fmt.Errorf("generating session ID: %w", err)
 	}
+	return s.register(id, name, ttl)
+}
 
+// register stores a new session with the given ID.
+func (s *SessionStore) register(id, name string, ttl time.Duration) (*Session, error) {
 	now := time.Now().UTC()
 	sess := &Session{
 		ID:             id,
@@ -82,7 +156,10 @@ func (s *SessionStore) Register(name string, ttl time.Duration) (*Session, error
 
 	s.mu.Lock()
 	s.sessions[id] = sess
-	err = s.persistLocked()
+	err := s.persistLocked()
+	if err != nil {
+		delete(s.sessions, id)
+	}
 	s.mu.Unlock()
 
 	if err != nil {
@@ -102,6 +179,18 @@ func (s *SessionStore) Touch(sessionID string) {
 	}
 }
 
+// SetMetadata sets the ownership metadata of the session.
+func (s *SessionStore) SetMetadata(sessionID string, m *iafv1alpha1.OwnershipMetadata) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[sessionID]
+	if !ok {
+		return fmt.Errorf("session %q not found", sessionID)
+	}
+	sess.Metadata = m
+	return s.persistLocked()
+}
+
 // Delete removes the session from the store.
 func (s *SessionStore) Delete(sessionID string) error {
 	s.mu.Lock()
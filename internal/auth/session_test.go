@@ -1,9 +1,23 @@
 package auth
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"path/filepath"
 	"sync"
 	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 )
 
 func TestRegisterAndLookup(t *testing.T) {
@@ -62,6 +76,9 @@ func TestSessionPersistence(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	if err := store1.SetMetadata(sess.ID, &iafv1alpha1.OwnershipMetadata{Team: "payments"}); err != nil {
+		t.Fatal(err)
+	}
 
 	// Create a new store from the same file — should load the session
 	store2, err := NewSessionStore(path)
@@ -75,6 +92,9 @@ func TestSessionPersistence(t *testing.T) {
 	if found.Name != "test" {
 		t.Errorf("expected name test, got %s", found.Name)
 	}
+	if found.Metadata == nil || found.Metadata.Team != "payments" {
+		t.Errorf("expected metadata to survive reload, got %+v", found.Metadata)
+	}
 }
 
 func TestRegisterEmptyName(t *testing.T) {
@@ -133,3 +153,196 @@ func TestConcurrentRegister(t *testing.T) {
 		ids[s.ID] = true
 	}
 }
+
+func TestProvision_SharedGitCredentials(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	shared := func(name string, secretType corev1.SecretType, server string) *corev1.Secret {
+		s := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "iaf-system", Labels: map[string]string{iafk8s.LabelCredentialType: "git"}},
+			Type:       secretType,
+			Data:       map[string][]byte{"password": []byte("org-token")},
+		}
+		if server != "" {
+			s.Annotations = map[string]string{iafk8s.AnnotationKpackGit: server}
+		}
+		return s
+	}
+	org := shared("github-org", corev1.SecretTypeBasicAuth, "https://github.com/acme")
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		org,
+		shared("no-server", corev1.SecretTypeSSHAuth, ""),
+		shared("opaque", corev1.SecretTypeOpaque, "https://github.com"),
+	).Build()
+	ctx := context.Background()
+
+	sessions, err := NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions.SetSharedGitCredentials("iaf-system")
+	sess, err := sessions.Provision(ctx, k8sClient, "agent", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var copied corev1.SecretList
+	if err := k8sClient.List(ctx, &copied, client.InNamespace(sess.Namespace)); err != nil {
+		t.Fatal(err)
+	}
+	if len(copied.Items) != 1 {
+		t.Fatalf("expected only the usable shared credential to be copied, got %d", len(copied.Items))
+	}
+	got := copied.Items[0]
+	if got.Name != "github-org" || got.Type != corev1.SecretTypeBasicAuth || string(got.Data["password"]) != "org-token" {
+		t.Errorf("unexpected copy %+v", got)
+	}
+	if got.Labels[iafk8s.LabelCredentialType] != "git" || got.Labels[iafk8s.LabelManagedCredential] != "true" || got.Annotations[iafk8s.AnnotationKpackGit] != "https://github.com/acme" {
+		t.Errorf("unexpected copy metadata %v %v", got.Labels, got.Annotations)
+	}
+	var sa corev1.ServiceAccount
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: iafk8s.KpackServiceAccount, Namespace: sess.Namespace}, &sa); err != nil {
+		t.Fatal(err)
+	}
+	if len(sa.Secrets) != 1 || sa.Secrets[0].Name != "github-org" {
+		t.Errorf("expected the copy on the kpack service account, got %v", sa.Secrets)
+	}
+
+	// Copying again refreshes the copy, but never a session's own
+	// credential of the same name.
+	org.Data = map[string][]byte{"password": []byte("rotated")}
+	if err := k8sClient.Update(ctx, org); err != nil {
+		t.Fatal(err)
+	}
+	own := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "github-org", Namespace: "iaf-other", Labels: map[string]string{iafk8s.LabelCredentialType: "git"}},
+		Type:       corev1.SecretTypeBasicAuth,
+		Data:       map[string][]byte{"password": []byte("mine")},
+	}
+	if err := k8sClient.Create(ctx, own); err != nil {
+		t.Fatal(err)
+	}
+	if err := EnsureNamespace(ctx, k8sClient, "iaf-other"); err != nil {
+		t.Fatal(err)
+	}
+	for _, ns := range []string{sess.Namespace, "iaf-other"} {
+		if err := iafk8s.CopySharedGitCredentials(ctx, k8sClient, "iaf-system", ns); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for ns, want := range map[string]string{sess.Namespace: "rotated", "iaf-other": "mine"} {
+		var s corev1.Secret
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: "github-org", Namespace: ns}, &s); err != nil {
+			t.Fatal(err)
+		}
+		if string(s.Data["password"]) != want {
+			t.Errorf("%s: password = %q, want %q", ns, s.Data["password"], want)
+		}
+	}
+}
+
+// TestSyncSharedGitCredentials verifies sessions registered before a shared
+// credential changed pick up the rotation and lose the copy once the
+// shared credential is removed.
+func TestSyncSharedGitCredentials(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	org := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "github-org",
+			Namespace:   "iaf-system",
+			Labels:      map[string]string{iafk8s.LabelCredentialType: "git"},
+			Annotations: map[string]string{iafk8s.AnnotationKpackGit: "https://github.com/acme"},
+		},
+		Type: corev1.SecretTypeBasicAuth,
+		Data: map[string][]byte{"password": []byte("org-token")},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(org).Build()
+	ctx := context.Background()
+
+	sessions, err := NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions.SetSharedGitCredentials("iaf-system")
+	var namespaces []string
+	for range 2 {
+		sess, err := sessions.Provision(ctx, k8sClient, "agent", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		namespaces = append(namespaces, sess.Namespace)
+	}
+
+	org.Data = map[string][]byte{"password": []byte("rotated")}
+	if err := k8sClient.Update(ctx, org); err != nil {
+		t.Fatal(err)
+	}
+	if err := iafk8s.SyncSharedGitCredentials(ctx, k8sClient, "iaf-system", slog.Default()); err != nil {
+		t.Fatal(err)
+	}
+	for _, ns := range namespaces {
+		var s corev1.Secret
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: "github-org", Namespace: ns}, &s); err != nil {
+			t.Fatal(err)
+		}
+		if string(s.Data["password"]) != "rotated" {
+			t.Errorf("%s: expected the rotated password, got %q", ns, s.Data["password"])
+		}
+	}
+
+	if err := k8sClient.Delete(ctx, org); err != nil {
+		t.Fatal(err)
+	}
+	if err := iafk8s.SyncSharedGitCredentials(ctx, k8sClient, "iaf-system", slog.Default()); err != nil {
+		t.Fatal(err)
+	}
+	for _, ns := range namespaces {
+		var s corev1.Secret
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: "github-org", Namespace: ns}, &s); !apierrors.IsNotFound(err) {
+			t.Errorf("%s: expected the revoked copy to be deleted, got %v", ns, err)
+		}
+		var sa corev1.ServiceAccount
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: iafk8s.KpackServiceAccount, Namespace: ns}, &sa); err != nil {
+			t.Fatal(err)
+		}
+		if len(sa.Secrets) != 0 {
+			t.Errorf("%s: expected the revoked copy off the kpack service account, got %v", ns, sa.Secrets)
+		}
+	}
+}
+
+// TestProvision_RollsBackOnCopyFailure verifies a session whose shared
+// credentials cannot be copied is neither stored nor left with a namespace.
+func TestProvision_RollsBackOnCopyFailure(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			if _, ok := list.(*corev1.SecretList); ok {
+				return errors.New("secrets unavailable")
+			}
+			return c.List(ctx, list, opts...)
+		},
+	}).Build()
+	ctx := context.Background()
+
+	sessions, err := NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions.SetSharedGitCredentials("iaf-system")
+	if _, err := sessions.Provision(ctx, k8sClient, "agent", 0); err == nil {
+		t.Fatal("expected Provision to fail when the shared credentials cannot be copied")
+	}
+	if got := len(sessions.List()); got != 0 {
+		t.Errorf("expected no session stored, got %d", got)
+	}
+	var namespaces corev1.NamespaceList
+	if err := k8sClient.List(ctx, &namespaces); err != nil {
+		t.Fatal(err)
+	}
+	if len(namespaces.Items) != 0 {
+		t.Errorf("expected the namespace to be deleted, got %v", namespaces.Items)
+	}
+}
@@ -0,0 +1,405 @@
+// Package budget fits tool results into a size budget, so a long log or a
+// large list does not overflow an agent's context window. JSON results are
+// shortened where it matters least: the longest list keeps its first items,
+// and the longest text keeps its head and tail. What was left out can be
+// read page by page with a continuation token.
+package budget
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// PageTTL is how long the rest of a shortened result can be read.
+	PageTTL = 15 * time.Minute
+
+	// MaxPagesPerScope bounds the shortened results kept per session; the
+	// oldest are forgotten first.
+	MaxPagesPerScope = 20
+
+	// MinBytes is the smallest budget. Smaller ones are raised to it so a
+	// page always has room for some data.
+	MinBytes = 1024
+
+	// TruncatedKey is the field added to a JSON object result that was
+	// shortened, holding its Truncation.
+	TruncatedKey = "truncated"
+)
+
+// ErrNotFound is returned for a continuation token that is unknown, expired,
+// already read to the end or issued to another scope.
+var ErrNotFound = errors.New("continuation not found")
+
+// Unit is what a Truncation counts.
+type Unit string
+
+const (
+	UnitItems Unit = "items"
+	UnitBytes Unit = "bytes"
+)
+
+// Truncation describes a shortened result.
+type Truncation struct {
+	// Field is the JSON field that was shortened; empty when the whole
+	// result was cut as text.
+	Field string `json:"field,omitempty"`
+	Unit  Unit   `json:"unit"`
+	// Total is the number of items or bytes in the full field or result,
+	// and Returned how many of them were returned so far.
+	Total    int `json:"total"`
+	Returned int `json:"returned"`
+	// Continuation reads the next page; empty when nothing is left or the
+	// rest was not kept.
+	Continuation string `json:"continuation,omitempty"`
+	Message      string `json:"message"`
+}
+
+// rest is the part of a result left out so far.
+type rest struct {
+	field    string
+	items    []json.RawMessage
+	text     string
+	unit     Unit
+	total    int
+	returned int
+}
+
+type page struct {
+	scope   string
+	created time.Time
+	rest    *rest
+}
+
+// Budget shortens results larger than its size and keeps what it left out
+// for continuation. Pages live in memory, so they are lost when the process
+// restarts. A nil *Budget leaves results unchanged.
+type Budget struct {
+	max int
+
+	mu    sync.Mutex
+	pages map[string]*page
+	now   func() time.Time
+}
+
+// New returns a budget of maxBytes per result, or nil for no limit when
+// maxBytes is 0 or less.
+func New(maxBytes int) *Budget {
+	if maxBytes <= 0 {
+		return nil
+	}
+	return &Budget{max: max(maxBytes, MinBytes), pages: map[string]*page{}, now: time.Now}
+}
+
+// MaxBytes returns the size results are fitted to, or 0 for no limit.
+func (b *Budget) MaxBytes() int {
+	if b == nil {
+		return 0
+	}
+	return b.max
+}
+
+// Fit returns text shortened to the budget, and how it was shortened, or
+// text unchanged and nil when it fits. The rest is kept for Continue under
+// scope; an empty scope keeps nothing.
+func (b *Budget) Fit(scope, text string) (string, *Truncation) {
+	if b == nil || len(text) <= b.max {
+		return text, nil
+	}
+	token := ""
+	if scope != "" {
+		token = newToken()
+	}
+	out, r, t := fit(text, b.max, token)
+	if r != nil && token != "" {
+		b.put(token, scope, r)
+	}
+	return out, t
+}
+
+// Continue returns the next page of a result shortened by Fit for scope.
+func (b *Budget) Continue(scope, token string) (string, *Truncation, error) {
+	if b == nil {
+		return "", nil, ErrNotFound
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p := b.pages[token]
+	if p == nil || p.scope != scope || b.now().Sub(p.created) > PageTTL {
+		return "", nil, ErrNotFound
+	}
+	out, next, t := p.rest.next(b.max, token)
+	if next == nil {
+		delete(b.pages, token)
+	} else {
+		p.rest = next
+	}
+	return out, t, nil
+}
+
+func (b *Budget) put(token, scope string, r *rest) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	var oldest string
+	count := 0
+	for t, p := range b.pages {
+		if now.Sub(p.created) > PageTTL {
+			delete(b.pages, t)
+			continue
+		}
+		if p.scope == scope {
+			count++
+			if oldest == "" || p.created.Before(b.pages[oldest].created) {
+				oldest = t
+			}
+		}
+	}
+	if count >= MaxPagesPerScope {
+		delete(b.pages, oldest)
+	}
+	b.pages[token] = &page{scope: scope, created: now, rest: r}
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// fit shortens text to limit bytes. A JSON object is shortened in its largest
+// field when that is a list or a string; anything else is cut as text.
+func fit(text string, limit int, token string) (string, *rest, *Truncation) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(text), &obj); err == nil && len(obj) > 0 {
+		field := largestField(obj)
+		var items []json.RawMessage
+		var s string
+		if json.Unmarshal(obj[field], &items) == nil && len(items) > 0 {
+			if out, r, t, ok := fitItems(obj, field, items, 0, len(items), limit, token); ok {
+				return out, r, t
+			}
+		} else if json.Unmarshal(obj[field], &s) == nil {
+			if out, r, t, ok := fitString(obj, field, s, limit, token); ok {
+				return out, r, t
+			}
+		}
+	}
+	return fitText(text, limit, token)
+}
+
+func largestField(obj map[string]json.RawMessage) string {
+	field := ""
+	for k, v := range obj {
+		if field == "" || len(v) > len(obj[field]) || (len(v) == len(obj[field]) && k < field) {
+			field = k
+		}
+	}
+	return field
+}
+
+// render returns obj with field set to value and the truncation added.
+func render(obj map[string]json.RawMessage, field string, value any, t *Truncation) string {
+	out := make(map[string]any, len(obj)+1)
+	for k, v := range obj {
+		out[k] = v
+	}
+	out[field] = value
+	out[TruncatedKey] = t
+	data, _ := json.MarshalIndent(out, "", "  ")
+	return string(data)
+}
+
+// fitItems keeps as many of items as fit. returned and total count the items
+// of the whole list.
+func fitItems(obj map[string]json.RawMessage, field string, items []json.RawMessage, returned, total, limit int, token string) (string, *rest, *Truncation, bool) {
+	truncation := func(k int) *Truncation {
+		t := &Truncation{Field: field, Unit: UnitItems, Total: total, Returned: returned + k}
+		if k == len(items) {
+			t.Message = fmt.Sprintf("Last page of %s: items %d to %d of %d.", field, returned+1, total, total)
+			return t
+		}
+		t.Continuation = token
+		if token == "" {
+			t.Message = fmt.Sprintf("%s holds %d of %d items to fit the response size limit; narrow the request to see the rest.", field, returned+k, total)
+		} else {
+			t.Message = fmt.Sprintf("%s holds %d of %d items to fit the response size limit; call continue_result with this continuation for the next ones.", field, returned+k, total)
+		}
+		return t
+	}
+	k := largest(len(items), func(k int) bool {
+		return len(render(obj, field, items[:k], truncation(k))) <= limit
+	})
+	if k <= 0 {
+		return "", nil, nil, false
+	}
+	t := truncation(k)
+	out := render(obj, field, items[:k], t)
+	if k == len(items) {
+		return out, nil, t, true
+	}
+	return out, &rest{field: field, items: items[k:], unit: UnitItems, total: total, returned: returned + k}, t, true
+}
+
+// fitString keeps the head and tail of the string field s.
+func fitString(obj map[string]json.RawMessage, field, s string, limit int, token string) (string, *rest, *Truncation, bool) {
+	var head, tail string
+	truncation := func() *Truncation {
+		left := len(s) - len(head) - len(tail)
+		t := &Truncation{Field: field, Unit: UnitBytes, Total: len(s), Returned: len(head) + len(tail), Continuation: token}
+		if token == "" {
+			t.Message = fmt.Sprintf("The middle %d bytes of %s were left out to fit the response size limit; narrow the request to see them.", left, field)
+		} else {
+			t.Message = fmt.Sprintf("The middle %d bytes of %s were left out to fit the response size limit; call continue_result with this continuation to read them.", left, field)
+		}
+		return t
+	}
+	value := func() string {
+		return head + fmt.Sprintf("\n[... %d bytes left out ...]\n", len(s)-len(head)-len(tail)) + tail
+	}
+	n := largest(len(s)-1, func(n int) bool {
+		head, tail = headTail(s, n)
+		return len(render(obj, field, value(), truncation())) <= limit
+	})
+	if n <= 0 {
+		return "", nil, nil, false
+	}
+	head, tail = headTail(s, n)
+	t := truncation()
+	r := &rest{field: field, text: s[len(head) : len(s)-len(tail)], unit: UnitBytes, total: len(s), returned: len(head) + len(tail)}
+	return render(obj, field, value(), t), r, t, true
+}
+
+// fitText keeps the head and tail of text, which need not be JSON.
+func fitText(text string, limit int, token string) (string, *rest, *Truncation) {
+	var head, tail string
+	marker := func() string {
+		left := len(text) - len(head) - len(tail)
+		if token == "" {
+			return fmt.Sprintf("\n[... %d bytes left out to fit the response size limit ...]\n", left)
+		}
+		return fmt.Sprintf("\n[... %d bytes left out to fit the response size limit; call continue_result with continuation %q to read them ...]\n", left, token)
+	}
+	n := largest(len(text)-1, func(n int) bool {
+		head, tail = headTail(text, n)
+		return len(head)+len(marker())+len(tail) <= limit
+	})
+	head, tail = headTail(text, max(n, 0))
+	out := head + marker() + tail
+	t := &Truncation{Unit: UnitBytes, Total: len(text), Returned: len(head) + len(tail), Continuation: token, Message: strings.TrimSpace(marker())}
+	return out, &rest{text: text[len(head) : len(text)-len(tail)], unit: UnitBytes, total: len(text), returned: len(head) + len(tail)}, t
+}
+
+// next renders the next page of r, and what is left after it.
+func (r *rest) next(limit int, token string) (string, *rest, *Truncation) {
+	if r.unit == UnitItems {
+		if out, next, t, ok := fitItems(map[string]json.RawMessage{}, r.field, r.items, r.returned, r.total, limit, token); ok {
+			return out, next, t
+		}
+		// A single item larger than the budget is cut as text.
+		out, _, _ := fitText(string(r.items[0]), limit, "")
+		next := &rest{field: r.field, items: r.items[1:], unit: r.unit, total: r.total, returned: r.returned + 1}
+		t := &Truncation{Field: r.field, Unit: r.unit, Total: r.total, Returned: next.returned, Message: "This item was cut to fit the response size limit."}
+		if len(next.items) == 0 {
+			return out, nil, t
+		}
+		t.Continuation = token
+		return out, next, t
+	}
+
+	field := r.field
+	if field == "" {
+		field = "text"
+	}
+	var chunk string
+	truncation := func() *Truncation {
+		t := &Truncation{Field: field, Unit: UnitBytes, Total: r.total, Returned: r.returned + len(chunk)}
+		if len(chunk) == len(r.text) {
+			t.Message = "This is the last of the bytes that were left out."
+			return t
+		}
+		t.Continuation = token
+		t.Message = fmt.Sprintf("%d more bytes were left out; call continue_result with this continuation to read them.", len(r.text)-len(chunk))
+		return t
+	}
+	obj := map[string]json.RawMessage{}
+	n := largest(len(r.text), func(n int) bool {
+		chunk = cutHead(r.text, n)
+		return len(render(obj, field, chunk, truncation())) <= limit
+	})
+	chunk = cutHead(r.text, max(n, 1))
+	if chunk == "" {
+		// Not even one rune fits next to the truncation; send it anyway.
+		_, size := utf8.DecodeRuneInString(r.text)
+		chunk = r.text[:size]
+	}
+	t := truncation()
+	out := render(obj, field, chunk, t)
+	if len(chunk) == len(r.text) {
+		return out, nil, t
+	}
+	return out, &rest{field: r.field, text: r.text[len(chunk):], unit: r.unit, total: r.total, returned: t.Returned}, t
+}
+
+// largest returns the largest n in [0, hi] for which fits is true, assuming
+// fits is true up to some n and false after it, or -1 when fits(0) is false.
+func largest(hi int, fits func(int) bool) int {
+	lo, found := 0, -1
+	for lo <= hi {
+		mid := lo + (hi-lo)/2
+		if fits(mid) {
+			found, lo = mid, mid+1
+		} else {
+			hi = mid - 1
+		}
+	}
+	return found
+}
+
+// headTail splits a budget of n bytes of s between its head and its tail,
+// cutting at line ends where possible so log lines stay whole.
+func headTail(s string, n int) (string, string) {
+	head := cutHead(s, n/2)
+	return head, cutTail(s[len(head):], n-len(head))
+}
+
+// cutHead returns at most the first n bytes of s, ending after a newline
+// when there is one in the second half, and never inside a rune.
+func cutHead(s string, n int) string {
+	if n >= len(s) {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	if i := strings.LastIndexByte(s[:n], '\n'); i >= n/2 {
+		n = i + 1
+	}
+	return s[:n]
+}
+
+// cutTail returns at most the last n bytes of s, starting after a newline
+// when there is one in the first half, and never inside a rune.
+func cutTail(s string, n int) string {
+	if n >= len(s) {
+		return s
+	}
+	if n <= 0 {
+		return ""
+	}
+	start := len(s) - n
+	for start < len(s) && !utf8.RuneStart(s[start]) {
+		start++
+	}
+	if i := strings.IndexByte(s[start:], '\n'); i >= 0 && i < (len(s)-start)/2 {
+		start += i + 1
+	}
+	return s[start:]
+}
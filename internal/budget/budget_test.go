@@ -0,0 +1,133 @@
+package budget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFit_Unchanged(t *testing.T) {
+	var unlimited *Budget
+	if out, tr := unlimited.Fit("s1", strings.Repeat("x", 1<<20)); len(out) != 1<<20 || tr != nil {
+		t.Error("expected a nil budget to leave results unchanged")
+	}
+	if New(0) != nil {
+		t.Error("expected no budget for 0")
+	}
+	if out, tr := New(4096).Fit("s1", `{"ok": true}`); out != `{"ok": true}` || tr != nil {
+		t.Errorf("expected a small result unchanged, got %q", out)
+	}
+}
+
+func TestFit_List(t *testing.T) {
+	b := New(2048)
+	var apps []map[string]any
+	for i := range 300 {
+		apps = append(apps, map[string]any{"name": fmt.Sprintf("app-%03d", i), "phase": "Running"})
+	}
+	data, _ := json.MarshalIndent(map[string]any{"apps": apps, "total": 300}, "", "  ")
+
+	var page struct {
+		Apps      []map[string]any `json:"apps"`
+		Total     int              `json:"total"`
+		Truncated *Truncation      `json:"truncated"`
+	}
+	out, tr := b.Fit("s1", string(data))
+	if len(out) > 2048 || tr == nil || tr.Continuation == "" {
+		t.Fatalf("expected a shortened result with a continuation, got %d bytes, %+v", len(out), tr)
+	}
+	if err := json.Unmarshal([]byte(out), &page); err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 300 || page.Truncated.Field != "apps" || page.Truncated.Returned != len(page.Apps) {
+		t.Fatalf("unexpected first page %+v", page.Truncated)
+	}
+	names := []any{}
+	for _, a := range page.Apps {
+		names = append(names, a["name"])
+	}
+
+	if _, _, err := b.Continue("s2", tr.Continuation); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected another session to be refused, got %v", err)
+	}
+	token := tr.Continuation
+	for token != "" {
+		out, tr, err := b.Continue("s1", token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(out) > 2048 {
+			t.Fatalf("page of %d bytes exceeds the budget", len(out))
+		}
+		page.Apps = nil
+		if err := json.Unmarshal([]byte(out), &page); err != nil {
+			t.Fatal(err)
+		}
+		for _, a := range page.Apps {
+			names = append(names, a["name"])
+		}
+		token = tr.Continuation
+	}
+	if len(names) != 300 || names[0] != "app-000" || names[299] != "app-299" {
+		t.Errorf("expected every app once in order, got %d", len(names))
+	}
+	if _, _, err := b.Continue("s1", tr.Continuation); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a finished continuation to be gone, got %v", err)
+	}
+}
+
+func TestFit_Logs(t *testing.T) {
+	b := New(4096)
+	var lines []string
+	for i := range 1000 {
+		lines = append(lines, fmt.Sprintf("2026-10-17T10:00:00Z line %04d", i))
+	}
+	logs := strings.Join(lines, "\n")
+	data, _ := json.Marshal(map[string]any{"app": "web", "logs": logs})
+
+	out, tr := b.Fit("s1", string(data))
+	if len(out) > 4096 || tr == nil || tr.Field != "logs" || tr.Unit != UnitBytes {
+		t.Fatalf("unexpected truncation %+v (%d bytes)", tr, len(out))
+	}
+	var page map[string]any
+	if err := json.Unmarshal([]byte(out), &page); err != nil {
+		t.Fatal(err)
+	}
+	shown := page["logs"].(string)
+	if page["app"] != "web" || !strings.HasPrefix(shown, lines[0]+"\n") || !strings.HasSuffix(shown, "\n"+lines[999]) {
+		t.Errorf("expected the head and tail of the logs, got %q", shown)
+	}
+	head, tail, _ := strings.Cut(shown, "\n[... ")
+	tail = tail[strings.Index(tail, "...]\n")+len("...]\n"):]
+
+	middle := ""
+	for token := tr.Continuation; token != ""; {
+		out, tr, err := b.Continue("s1", token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal([]byte(out), &page); err != nil {
+			t.Fatal(err)
+		}
+		middle += page["logs"].(string)
+		token = tr.Continuation
+	}
+	if head+middle+tail != logs {
+		t.Error("expected head, continuation pages and tail to make up the logs")
+	}
+}
+
+func TestFit_Text(t *testing.T) {
+	b := New(1024)
+	text := strings.Repeat("héllo wörld ", 500)
+	out, tr := b.Fit("", text)
+	if len(out) > 1024 || tr == nil || tr.Continuation != "" || !strings.Contains(out, "bytes left out") || !utf8.ValidString(out) {
+		t.Errorf("unexpected result %+v (%d bytes)", tr, len(out))
+	}
+	if !strings.HasPrefix(text, out[:strings.Index(out, "\n[...")]) {
+		t.Error("expected the head of the text")
+	}
+}
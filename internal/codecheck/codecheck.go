@@ -0,0 +1,275 @@
+// Package codecheck statically checks application source against the
+// organisation coding standards: a health-check endpoint, a /metrics
+// endpoint, a structured JSON logging library and the PORT environment
+// variable. It looks for the patterns the coach guides teach, so unusual
+// code can fail a rule it satisfies; a failure is a prompt to verify, not
+// proof.
+package codecheck
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+)
+
+// Rule names.
+const (
+	RuleHealthEndpoint  = "health-endpoint"
+	RuleMetricsEndpoint = "metrics-endpoint"
+	RuleJSONLogging     = "json-logging"
+	RulePortEnv         = "port-env"
+)
+
+// Status is the outcome of a rule.
+type Status string
+
+const (
+	StatusPass    Status = "pass"
+	StatusFail    Status = "fail"
+	StatusSkipped Status = "skipped"
+)
+
+// maxFilesListed is how many matching files a result names.
+const maxFilesListed = 3
+
+// Result is the outcome of one rule.
+type Result struct {
+	Rule    string `json:"rule"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	// Hint says how to fix a failing rule.
+	Hint string `json:"hint,omitempty"`
+	// Files are the files where the rule was satisfied.
+	Files []string `json:"files,omitempty"`
+}
+
+// Report is the outcome of checking a source tree.
+type Report struct {
+	// Language is the detected language, or "" when none was.
+	Language string `json:"language"`
+	// Passed is set when no rule failed.
+	Passed  bool     `json:"passed"`
+	Results []Result `json:"results"`
+}
+
+// language describes how the rules recognise one language's source.
+type language struct {
+	// manifests mark a tree as this language; the first found wins.
+	manifests []string
+	// extensions are the source and config files searched.
+	extensions []string
+	port       *regexp.Regexp
+	logging    *regexp.Regexp
+	// metricsLibraries mount /metrics without the path in the source.
+	metricsLibraries *regexp.Regexp
+	hints            map[string]string
+}
+
+// languages are checked in this order when detecting a tree's language.
+var languageOrder = []string{"go", "nodejs", "python", "java", "ruby"}
+
+var languages = map[string]language{
+	"go": {
+		manifests:  []string{"go.mod"},
+		extensions: []string{".go", "go.mod"},
+		port:       regexp.MustCompile(`(Getenv|LookupEnv)\(\s*"PORT"\s*\)`),
+		logging:    regexp.MustCompile(`slog\.NewJSONHandler|go\.uber\.org/zap|github\.com/rs/zerolog|JSONFormatter`),
+		hints: map[string]string{
+			RuleHealthEndpoint:  `register a handler, e.g. http.HandleFunc("%s", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })`,
+			RuleMetricsEndpoint: `expose Prometheus metrics: http.Handle("/metrics", promhttp.Handler()) from github.com/prometheus/client_golang (metrics-guide)`,
+			RuleJSONLogging:     `log through slog.New(slog.NewJSONHandler(os.Stdout, nil)) (logging-guide)`,
+			RulePortEnv:         `listen on os.Getenv("PORT"), defaulting to %d`,
+		},
+	},
+	"nodejs": {
+		manifests:        []string{"package.json"},
+		extensions:       []string{".js", ".mjs", ".cjs", ".ts", ".jsx", ".tsx", "package.json"},
+		port:             regexp.MustCompile(`process\.env\.PORT\b|process\.env\[\s*['"]PORT['"]\s*\]`),
+		logging:          regexp.MustCompile(`['"](pino|winston|bunyan)['"]`),
+		metricsLibraries: regexp.MustCompile(`['"]express-prom-bundle['"]`),
+		hints: map[string]string{
+			RuleHealthEndpoint:  `add a route, e.g. app.get("%s", (req, res) => res.sendStatus(200))`,
+			RuleMetricsEndpoint: `expose Prometheus metrics at /metrics with prom-client: app.get("/metrics", ...) returning register.metrics() (metrics-guide)`,
+			RuleJSONLogging:     `log with pino, which writes JSON to stdout (logging-guide)`,
+			RulePortEnv:         `listen on process.env.PORT || %d`,
+		},
+	},
+	"python": {
+		manifests:        []string{"requirements.txt", "pyproject.toml", "Pipfile"},
+		extensions:       []string{".py", "requirements.txt", "pyproject.toml", "Pipfile"},
+		port:             regexp.MustCompile(`(getenv|environ\.get|environ)\s*[(\[]\s*['"]PORT['"]`),
+		logging:          regexp.MustCompile(`structlog|pythonjsonlogger|python-json-logger|json_log_formatter|json-log-formatter`),
+		metricsLibraries: regexp.MustCompile(`prometheus[-_]fastapi[-_]instrumentator|prometheus[-_]flask[-_]exporter`),
+		hints: map[string]string{
+			RuleHealthEndpoint:  `add a route, e.g. @app.get("%s") returning {"status": "ok"}`,
+			RuleMetricsEndpoint: `expose Prometheus metrics at /metrics, e.g. with prometheus-fastapi-instrumentator or prometheus_client's make_wsgi_app (metrics-guide)`,
+			RuleJSONLogging:     `log with structlog or python-json-logger so records are JSON (logging-guide)`,
+			RulePortEnv:         `listen on int(os.environ.get("PORT", "%d"))`,
+		},
+	},
+	"java": {
+		manifests:  []string{"pom.xml", "build.gradle", "build.gradle.kts"},
+		extensions: []string{".java", ".kt", ".properties", ".yml", ".yaml", "pom.xml", "build.gradle", "build.gradle.kts"},
+		port:       regexp.MustCompile(`getenv\(\s*"PORT"\s*\)|\$\{PORT`),
+		logging:    regexp.MustCompile(`logstash-logback-encoder|LogstashEncoder|ecs-logging|JsonTemplateLayout|JsonLayout`),
+		hints: map[string]string{
+			RuleHealthEndpoint:  `add an endpoint, e.g. @GetMapping("%s") returning "ok"`,
+			RuleMetricsEndpoint: `serve Prometheus metrics at /metrics, e.g. micrometer-registry-prometheus with management.endpoints.web.path-mapping.prometheus=metrics (metrics-guide)`,
+			RuleJSONLogging:     `add logstash-logback-encoder and a LogstashEncoder appender (logging-guide)`,
+			RulePortEnv:         `set server.port=${PORT:%d}`,
+		},
+	},
+	"ruby": {
+		manifests:  []string{"Gemfile"},
+		extensions: []string{".rb", ".ru", "Gemfile"},
+		port:       regexp.MustCompile(`ENV(\.fetch\(|\[)\s*['"]PORT['"]`),
+		logging:    regexp.MustCompile(`semantic_logger|lograge|ougai`),
+		hints: map[string]string{
+			RuleHealthEndpoint:  `add a route, e.g. get "%s" do "ok" end`,
+			RuleMetricsEndpoint: `serve Prometheus metrics at /metrics with the prometheus-client gem's Prometheus::Middleware::Exporter (metrics-guide)`,
+			RuleJSONLogging:     `log with semantic_logger in JSON format (logging-guide)`,
+			RulePortEnv:         `listen on ENV.fetch("PORT", %d)`,
+		},
+	},
+}
+
+// skippedDirs hold dependencies rather than the app's own source.
+var skippedDirs = []string{"node_modules", "vendor", ".git", "venv", ".venv", "target", "build", "dist"}
+
+// DetectLanguage returns the language of a source tree from its manifest
+// files, or "" when it has none.
+func DetectLanguage(files map[string]string) string {
+	for _, name := range languageOrder {
+		for p := range files {
+			if slices.Contains(languages[name].manifests, path.Base(p)) && !skipped(p) {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// Check runs every rule over files, a map of paths to contents, against
+// the standards s.
+func Check(files map[string]string, s *orgstandards.OrgStandards) Report {
+	lang := DetectLanguage(files)
+	spec, known := languages[lang]
+	sources := map[string]string{}
+	for p, content := range files {
+		if skipped(p) || (known && !matchesExtension(p, spec.extensions)) {
+			continue
+		}
+		sources[p] = content
+	}
+
+	healthPath := s.HealthCheckPath
+	if healthPath == "" {
+		healthPath = "/health"
+	}
+	port := s.DefaultPort
+	if port == 0 {
+		port = 8080
+	}
+	hint := func(rule string, args ...any) string {
+		if !known {
+			return ""
+		}
+		return fmt.Sprintf(spec.hints[rule], args...)
+	}
+
+	report := Report{Language: lang, Passed: true}
+	add := func(r Result) {
+		if r.Status == StatusFail {
+			report.Passed = false
+		}
+		report.Results = append(report.Results, r)
+	}
+
+	health := find(sources, quoted(healthPath))
+	add(result(RuleHealthEndpoint, health,
+		fmt.Sprintf("serves the health check at %s", healthPath),
+		fmt.Sprintf("no route for %s found; the platform probes it to decide whether the app is up", healthPath),
+		hint(RuleHealthEndpoint, healthPath)))
+
+	metricsPatterns := []*regexp.Regexp{quoted("/metrics")}
+	if spec.metricsLibraries != nil {
+		metricsPatterns = append(metricsPatterns, spec.metricsLibraries)
+	}
+	add(result(RuleMetricsEndpoint, find(sources, metricsPatterns...),
+		"exposes /metrics",
+		"no /metrics endpoint found; the platform scrapes it for request rates, errors and latency",
+		hint(RuleMetricsEndpoint)))
+
+	switch {
+	case s.LoggingFormat != "" && s.LoggingFormat != "json":
+		add(Result{Rule: RuleJSONLogging, Status: StatusSkipped, Message: fmt.Sprintf("the organisation logging format is %s", s.LoggingFormat)})
+	case !known:
+		add(Result{Rule: RuleJSONLogging, Status: StatusSkipped, Message: "language not detected: add the dependency manifest (go.mod, package.json, requirements.txt, pom.xml or Gemfile)"})
+	default:
+		add(result(RuleJSONLogging, find(sources, spec.logging),
+			"uses a structured JSON logging library",
+			"no structured JSON logging library found; plain-text logs cannot be searched by field",
+			hint(RuleJSONLogging)))
+	}
+
+	if known {
+		add(result(RulePortEnv, find(sources, spec.port),
+			"listens on the PORT environment variable",
+			fmt.Sprintf("PORT is not read; the platform sets it and routes traffic to it (default %d)", port),
+			hint(RulePortEnv, port)))
+	} else {
+		add(Result{Rule: RulePortEnv, Status: StatusSkipped, Message: "language not detected: add the dependency manifest (go.mod, package.json, requirements.txt, pom.xml or Gemfile)"})
+	}
+	return report
+}
+
+// result builds the result of a rule satisfied in the files found, if any.
+func result(rule string, found []string, pass, fail, hint string) Result {
+	if len(found) == 0 {
+		return Result{Rule: rule, Status: StatusFail, Message: fail, Hint: hint}
+	}
+	if len(found) > maxFilesListed {
+		found = found[:maxFilesListed]
+	}
+	return Result{Rule: rule, Status: StatusPass, Message: pass, Files: found}
+}
+
+// find returns the sorted paths of the sources matching any pattern.
+func find(sources map[string]string, patterns ...*regexp.Regexp) []string {
+	var found []string
+	for p, content := range sources {
+		if slices.ContainsFunc(patterns, func(re *regexp.Regexp) bool { return re.MatchString(content) }) {
+			found = append(found, p)
+		}
+	}
+	slices.Sort(found)
+	return found
+}
+
+// quoted matches urlPath as a string literal, with or without a trailing
+// slash.
+func quoted(urlPath string) *regexp.Regexp {
+	return regexp.MustCompile("[\"'`]" + regexp.QuoteMeta(strings.TrimSuffix(urlPath, "/")) + "/?[\"'`]")
+}
+
+// matchesExtension reports whether p ends with one of the suffixes, which
+// are extensions or whole file names.
+func matchesExtension(p string, suffixes []string) bool {
+	return slices.ContainsFunc(suffixes, func(s string) bool {
+		if strings.HasPrefix(s, ".") {
+			return path.Ext(p) == s
+		}
+		return path.Base(p) == s
+	})
+}
+
+// skipped reports whether p is inside a dependency or build directory.
+func skipped(p string) bool {
+	return slices.ContainsFunc(strings.Split(path.Clean(p), "/"), func(dir string) bool {
+		return slices.Contains(skippedDirs, dir)
+	})
+}
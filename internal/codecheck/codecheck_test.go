@@ -0,0 +1,91 @@
+package codecheck
+
+import (
+	"testing"
+
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+)
+
+func defaults() *orgstandards.OrgStandards {
+	return &orgstandards.OrgStandards{HealthCheckPath: "/health", LoggingFormat: "json", DefaultPort: 8080}
+}
+
+func statuses(r Report) map[string]Status {
+	m := map[string]Status{}
+	for _, res := range r.Results {
+		m[res.Rule] = res.Status
+	}
+	return m
+}
+
+func TestCheck_Conforming(t *testing.T) {
+	for name, files := range map[string]map[string]string{
+		"go": {
+			"go.mod": "module app\n\nrequire github.com/prometheus/client_golang v1.19.0\n",
+			"main.go": `package main
+import ("log/slog"; "net/http"; "os"; "github.com/prometheus/client_golang/prometheus/promhttp")
+func main() {
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {})
+	http.Handle("/metrics", promhttp.Handler())
+	http.ListenAndServe(":"+os.Getenv("PORT"), nil)
+}`,
+		},
+		"nodejs": {
+			"package.json":  `{"dependencies": {"express": "^4", "pino": "^9", "express-prom-bundle": "^7"}}`,
+			"src/server.js": `const app = require('express')(); app.get('/health/', (req, res) => res.sendStatus(200)); app.listen(process.env.PORT || 8080);`,
+		},
+		"python": {
+			"requirements.txt": "fastapi\nstructlog\nprometheus-fastapi-instrumentator\n",
+			"main.py":          "import os\n@app.get(\"/health\")\ndef health(): return {}\nport = int(os.environ.get('PORT', '8080'))\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := Check(files, defaults())
+			if r.Language != name || !r.Passed {
+				t.Errorf("expected %s to pass, got %+v", name, r)
+			}
+		})
+	}
+}
+
+func TestCheck_Failures(t *testing.T) {
+	files := map[string]string{
+		"go.mod":  "module app\n",
+		"main.go": `package main; import "net/http"; func main() { http.HandleFunc("/healthz", nil); http.ListenAndServe(":8080", nil) }`,
+		// Dependencies do not count.
+		"vendor/github.com/x/y.go": `os.Getenv("PORT"); "/health"; "/metrics"; slog.NewJSONHandler`,
+	}
+	r := Check(files, defaults())
+	if r.Passed {
+		t.Fatal("expected the check to fail")
+	}
+	for rule, want := range map[string]Status{
+		RuleHealthEndpoint: StatusFail, RuleMetricsEndpoint: StatusFail, RuleJSONLogging: StatusFail, RulePortEnv: StatusFail,
+	} {
+		if got := statuses(r)[rule]; got != want {
+			t.Errorf("%s: got %s, want %s", rule, got, want)
+		}
+	}
+	for _, res := range r.Results {
+		if res.Hint == "" {
+			t.Errorf("%s: expected a hint", res.Rule)
+		}
+	}
+
+	// The organisation's own health path and logging format apply.
+	s := defaults()
+	s.HealthCheckPath, s.LoggingFormat = "/healthz", "text"
+	got := statuses(Check(files, s))
+	if got[RuleHealthEndpoint] != StatusPass || got[RuleJSONLogging] != StatusSkipped {
+		t.Errorf("expected /healthz to pass and logging to be skipped, got %v", got)
+	}
+}
+
+func TestCheck_UnknownLanguage(t *testing.T) {
+	r := Check(map[string]string{"index.html": "<a href=\"/health\">"}, defaults())
+	got := statuses(r)
+	if r.Language != "" || got[RulePortEnv] != StatusSkipped || got[RuleJSONLogging] != StatusSkipped || got[RuleHealthEndpoint] != StatusPass {
+		t.Errorf("unexpected report %+v", r)
+	}
+}
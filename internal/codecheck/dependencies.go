@@ -0,0 +1,210 @@
+package codecheck
+
+import (
+	"encoding/json"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+)
+
+// dependencyParser lists the dependencies a manifest or lockfile declares.
+type dependencyParser struct {
+	ecosystem string
+	parse     func(content string) []orgstandards.Dependency
+}
+
+// dependencyParsers are keyed by file name.
+var dependencyParsers = map[string]dependencyParser{
+	"go.mod":            {orgstandards.EcosystemGo, parseGoMod},
+	"package.json":      {orgstandards.EcosystemNPM, parsePackageJSON},
+	"package-lock.json": {orgstandards.EcosystemNPM, parsePackageLock},
+	"requirements.txt":  {orgstandards.EcosystemPyPI, parseRequirements},
+	"pyproject.toml":    {orgstandards.EcosystemPyPI, parsePyproject},
+	"pom.xml":           {orgstandards.EcosystemMaven, parsePom},
+	"build.gradle":      {orgstandards.EcosystemMaven, parseGradle},
+	"build.gradle.kts":  {orgstandards.EcosystemMaven, parseGradle},
+	"Gemfile":           {orgstandards.EcosystemRubyGems, parseGemfile},
+	"Gemfile.lock":      {orgstandards.EcosystemRubyGems, parseGemfileLock},
+}
+
+// Dependencies returns the dependencies the manifests and lockfiles in
+// files declare, in every ecosystem, sorted by file. Versions are as
+// declared, so they may be ranges; lockfiles give the installed ones.
+func Dependencies(files map[string]string) []orgstandards.Dependency {
+	var deps []orgstandards.Dependency
+	for _, p := range slices.Sorted(maps.Keys(files)) {
+		parser, ok := dependencyParsers[path.Base(p)]
+		if !ok || skipped(p) {
+			continue
+		}
+		for _, d := range parser.parse(files[p]) {
+			d.Ecosystem, d.Source = parser.ecosystem, p
+			deps = append(deps, d)
+		}
+	}
+	return deps
+}
+
+var (
+	goRequireBlock = regexp.MustCompile(`(?ms)^require\s*\((.*?)^\)`)
+	goRequireLine  = regexp.MustCompile(`(?m)^require[ \t]+([^\s(]\S*)[ \t]+(\S+)`)
+	goRequireEntry = regexp.MustCompile(`(?m)^\s*(\S+)\s+(v\S+)`)
+)
+
+func parseGoMod(content string) []orgstandards.Dependency {
+	var deps []orgstandards.Dependency
+	for _, m := range goRequireLine.FindAllStringSubmatch(content, -1) {
+		deps = append(deps, orgstandards.Dependency{Name: m[1], Version: m[2]})
+	}
+	for _, block := range goRequireBlock.FindAllStringSubmatch(content, -1) {
+		for _, m := range goRequireEntry.FindAllStringSubmatch(block[1], -1) {
+			deps = append(deps, orgstandards.Dependency{Name: m[1], Version: m[2]})
+		}
+	}
+	return deps
+}
+
+func parsePackageJSON(content string) []orgstandards.Dependency {
+	var pkg map[string]json.RawMessage
+	if json.Unmarshal([]byte(content), &pkg) != nil {
+		return nil
+	}
+	var deps []orgstandards.Dependency
+	for _, field := range []string{"dependencies", "devDependencies", "optionalDependencies"} {
+		var versions map[string]string
+		if json.Unmarshal(pkg[field], &versions) != nil {
+			continue
+		}
+		for _, name := range slices.Sorted(maps.Keys(versions)) {
+			deps = append(deps, orgstandards.Dependency{Name: name, Version: versions[name]})
+		}
+	}
+	return deps
+}
+
+// parsePackageLock reads the installed packages of a version 2 or 3
+// lockfile, which include transitive dependencies.
+func parsePackageLock(content string) []orgstandards.Dependency {
+	var lock struct {
+		Packages map[string]struct {
+			Version string `json:"version"`
+		} `json:"packages"`
+	}
+	if json.Unmarshal([]byte(content), &lock) != nil {
+		return nil
+	}
+	var deps []orgstandards.Dependency
+	for _, key := range slices.Sorted(maps.Keys(lock.Packages)) {
+		i := strings.LastIndex(key, "node_modules/")
+		if i < 0 {
+			continue
+		}
+		deps = append(deps, orgstandards.Dependency{Name: key[i+len("node_modules/"):], Version: lock.Packages[key].Version})
+	}
+	return deps
+}
+
+// pythonRequirement parses a PEP 508 requirement, capturing the name and
+// the version of an == pin.
+var pythonRequirement = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:\[[^\]]*\])?\s*(?:===?\s*([^\s;,#]+))?`)
+
+func parseRequirement(line string) (orgstandards.Dependency, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+		return orgstandards.Dependency{}, false
+	}
+	m := pythonRequirement.FindStringSubmatch(line)
+	if m == nil {
+		return orgstandards.Dependency{}, false
+	}
+	return orgstandards.Dependency{Name: m[1], Version: m[2]}, true
+}
+
+func parseRequirements(content string) []orgstandards.Dependency {
+	var deps []orgstandards.Dependency
+	for _, line := range strings.Split(content, "\n") {
+		if d, ok := parseRequirement(line); ok {
+			deps = append(deps, d)
+		}
+	}
+	return deps
+}
+
+var (
+	pyprojectDependencies = regexp.MustCompile(`(?ms)^dependencies\s*=\s*\[(.*?)\]`)
+	quotedString          = regexp.MustCompile(`["']([^"']+)["']`)
+)
+
+// parsePyproject reads the PEP 621 [project] dependencies array.
+func parsePyproject(content string) []orgstandards.Dependency {
+	var deps []orgstandards.Dependency
+	for _, block := range pyprojectDependencies.FindAllStringSubmatch(content, -1) {
+		for _, m := range quotedString.FindAllStringSubmatch(block[1], -1) {
+			if d, ok := parseRequirement(m[1]); ok {
+				deps = append(deps, d)
+			}
+		}
+	}
+	return deps
+}
+
+var (
+	pomDependency = regexp.MustCompile(`(?s)<dependency>(.*?)</dependency>`)
+	pomGroupID    = regexp.MustCompile(`<groupId>\s*([^<\s]+)\s*</groupId>`)
+	pomArtifactID = regexp.MustCompile(`<artifactId>\s*([^<\s]+)\s*</artifactId>`)
+	pomVersion    = regexp.MustCompile(`<version>\s*([^<\s]+)\s*</version>`)
+)
+
+// parsePom reads Maven dependencies as group:artifact. Versions set
+// through properties are unknown.
+func parsePom(content string) []orgstandards.Dependency {
+	var deps []orgstandards.Dependency
+	for _, m := range pomDependency.FindAllStringSubmatch(content, -1) {
+		group, artifact := pomGroupID.FindStringSubmatch(m[1]), pomArtifactID.FindStringSubmatch(m[1])
+		if group == nil || artifact == nil {
+			continue
+		}
+		d := orgstandards.Dependency{Name: group[1] + ":" + artifact[1]}
+		if v := pomVersion.FindStringSubmatch(m[1]); v != nil && !strings.Contains(v[1], "${") {
+			d.Version = v[1]
+		}
+		deps = append(deps, d)
+	}
+	return deps
+}
+
+var gradleDependency = regexp.MustCompile(`(?m)\b(?:implementation|api|compileOnly|runtimeOnly|annotationProcessor|testImplementation|testRuntimeOnly)\s*\(?\s*["']([^:"'\s]+):([^:"'\s]+)(?::([^:"'\s@]+))?`)
+
+func parseGradle(content string) []orgstandards.Dependency {
+	var deps []orgstandards.Dependency
+	for _, m := range gradleDependency.FindAllStringSubmatch(content, -1) {
+		deps = append(deps, orgstandards.Dependency{Name: m[1] + ":" + m[2], Version: m[3]})
+	}
+	return deps
+}
+
+var gemDeclaration = regexp.MustCompile(`(?m)^\s*gem\s+["']([^"']+)["'](?:\s*,\s*["']([^"']+)["'])?`)
+
+func parseGemfile(content string) []orgstandards.Dependency {
+	var deps []orgstandards.Dependency
+	for _, m := range gemDeclaration.FindAllStringSubmatch(content, -1) {
+		deps = append(deps, orgstandards.Dependency{Name: m[1], Version: m[2]})
+	}
+	return deps
+}
+
+// gemSpec matches the installed gems of a Gemfile.lock, indented by four
+// spaces under specs.
+var gemSpec = regexp.MustCompile(`(?m)^    ([^\s(]+) \(([^)]+)\)$`)
+
+func parseGemfileLock(content string) []orgstandards.Dependency {
+	var deps []orgstandards.Dependency
+	for _, m := range gemSpec.FindAllStringSubmatch(content, -1) {
+		deps = append(deps, orgstandards.Dependency{Name: m[1], Version: m[2]})
+	}
+	return deps
+}
@@ -0,0 +1,48 @@
+package codecheck
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+)
+
+func TestDependencies(t *testing.T) {
+	files := map[string]string{
+		"go.mod":                                "module app\n\ngo 1.23\n\nrequire github.com/a/b v1.2.0\n\nrequire (\n\tgithub.com/c/d v0.3.1 // indirect\n\tgolang.org/x/e v0.1.0\n)\n",
+		"web/package.json":                      `{"dependencies": {"express": "^4.19.0"}, "devDependencies": {"jest": "29.7.0"}}`,
+		"web/package-lock.json":                 `{"lockfileVersion": 3, "packages": {"": {}, "node_modules/express": {"version": "4.19.2"}, "node_modules/express/node_modules/qs": {"version": "6.11.0"}}}`,
+		"web/node_modules/express/package.json": `{"dependencies": {"vendored": "1.0.0"}}`,
+		"api/requirements.txt":                  "# pinned\nFlask[async]==3.0.0\nrequests>=2.31\n-r base.txt\n",
+		"api/pyproject.toml":                    "[project]\nname = \"api\"\ndependencies = [\n  \"django==5.0\",\n  'uvicorn',\n]\n",
+		"jvm/pom.xml":                           "<dependencies><dependency><groupId>org.apache.logging.log4j</groupId><artifactId>log4j-core</artifactId><version>2.14.1</version></dependency><dependency><groupId>com.acme</groupId><artifactId>lib</artifactId><version>${acme.version}</version></dependency></dependencies>",
+		"jvm/build.gradle.kts":                  "dependencies {\n    implementation(\"com.google.guava:guava:33.0.0-jre\")\n    testImplementation(\"junit:junit\")\n}\n",
+		"rb/Gemfile":                            "source 'https://rubygems.org'\ngem 'rails', '~> 7.1'\ngem \"puma\"\n",
+		"rb/Gemfile.lock":                       "GEM\n  specs:\n    rails (7.1.3)\n      actionpack (= 7.1.3)\n    puma (6.4.2)\n",
+	}
+	want := []orgstandards.Dependency{
+		{Ecosystem: "pypi", Name: "django", Version: "5.0", Source: "api/pyproject.toml"},
+		{Ecosystem: "pypi", Name: "uvicorn", Source: "api/pyproject.toml"},
+		{Ecosystem: "pypi", Name: "Flask", Version: "3.0.0", Source: "api/requirements.txt"},
+		{Ecosystem: "pypi", Name: "requests", Source: "api/requirements.txt"},
+		{Ecosystem: "go", Name: "github.com/a/b", Version: "v1.2.0", Source: "go.mod"},
+		{Ecosystem: "go", Name: "github.com/c/d", Version: "v0.3.1", Source: "go.mod"},
+		{Ecosystem: "go", Name: "golang.org/x/e", Version: "v0.1.0", Source: "go.mod"},
+		{Ecosystem: "maven", Name: "com.google.guava:guava", Version: "33.0.0-jre", Source: "jvm/build.gradle.kts"},
+		{Ecosystem: "maven", Name: "junit:junit", Source: "jvm/build.gradle.kts"},
+		{Ecosystem: "maven", Name: "org.apache.logging.log4j:log4j-core", Version: "2.14.1", Source: "jvm/pom.xml"},
+		{Ecosystem: "maven", Name: "com.acme:lib", Source: "jvm/pom.xml"},
+		{Ecosystem: "rubygems", Name: "rails", Version: "~> 7.1", Source: "rb/Gemfile"},
+		{Ecosystem: "rubygems", Name: "puma", Source: "rb/Gemfile"},
+		{Ecosystem: "rubygems", Name: "rails", Version: "7.1.3", Source: "rb/Gemfile.lock"},
+		{Ecosystem: "rubygems", Name: "puma", Version: "6.4.2", Source: "rb/Gemfile.lock"},
+		{Ecosystem: "npm", Name: "express", Version: "4.19.2", Source: "web/package-lock.json"},
+		{Ecosystem: "npm", Name: "qs", Version: "6.11.0", Source: "web/package-lock.json"},
+		{Ecosystem: "npm", Name: "express", Version: "^4.19.0", Source: "web/package.json"},
+		{Ecosystem: "npm", Name: "jest", Version: "29.7.0", Source: "web/package.json"},
+	}
+	got := Dependencies(files)
+	if !slices.Equal(got, want) {
+		t.Errorf("Dependencies:\n got %+v\nwant %+v", got, want)
+	}
+}
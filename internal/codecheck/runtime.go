@@ -0,0 +1,97 @@
+package codecheck
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// runtimeSource reads the runtime version a file declares, or "" if it
+// declares none.
+type runtimeSource struct {
+	file    string
+	version func(content string) string
+}
+
+// runtimeSources are where each language declares its runtime version, in
+// the order the buildpacks prefer them.
+var runtimeSources = map[string][]runtimeSource{
+	"go": {
+		{"go.mod", submatch(regexp.MustCompile(`(?m)^go\s+(\S+)\s*$`))},
+	},
+	"nodejs": {
+		{"package.json", func(content string) string {
+			var pkg struct {
+				Engines struct {
+					Node string `json:"node"`
+				} `json:"engines"`
+			}
+			_ = json.Unmarshal([]byte(content), &pkg)
+			return pkg.Engines.Node
+		}},
+		{".nvmrc", firstLine},
+		{".node-version", firstLine},
+	},
+	"python": {
+		{".python-version", firstLine},
+		{"runtime.txt", func(content string) string { return strings.TrimPrefix(firstLine(content), "python-") }},
+		{"Pipfile", submatch(regexp.MustCompile(`(?m)^python_(?:full_)?version\s*=\s*["']([^"']+)["']`))},
+	},
+	"java": {
+		{"pom.xml", submatch(regexp.MustCompile(`<(?:java\.version|maven\.compiler\.release)>\s*([^<\s]+)\s*<`))},
+		{"build.gradle", submatch(regexp.MustCompile(`JavaLanguageVersion\.of\(\s*(\d+)\s*\)`))},
+		{"build.gradle.kts", submatch(regexp.MustCompile(`JavaLanguageVersion\.of\(\s*(\d+)\s*\)`))},
+	},
+	"ruby": {
+		{".ruby-version", func(content string) string { return strings.TrimPrefix(firstLine(content), "ruby-") }},
+		{"Gemfile", submatch(regexp.MustCompile(`(?m)^\s*ruby\s+["']([^"']+)["']`))},
+	},
+}
+
+// RuntimeVersion returns the runtime version a source tree of language
+// lang declares and the file declaring it, or "" when it declares none.
+// The declaration nearest the root wins, so a monorepo's top-level files
+// take precedence over those of nested packages.
+func RuntimeVersion(files map[string]string, lang string) (version, file string) {
+	var paths []string
+	for p := range files {
+		if !skipped(p) {
+			paths = append(paths, p)
+		}
+	}
+	slices.SortFunc(paths, func(a, b string) int {
+		if d := strings.Count(path.Clean(a), "/") - strings.Count(path.Clean(b), "/"); d != 0 {
+			return d
+		}
+		return strings.Compare(a, b)
+	})
+	for _, src := range runtimeSources[lang] {
+		for _, p := range paths {
+			if path.Base(p) != src.file {
+				continue
+			}
+			if v := strings.TrimSpace(src.version(files[p])); v != "" {
+				return v, p
+			}
+		}
+	}
+	return "", ""
+}
+
+// submatch returns a reader of the first capture group of re.
+func submatch(re *regexp.Regexp) func(string) string {
+	return func(content string) string {
+		if m := re.FindStringSubmatch(content); m != nil {
+			return m[1]
+		}
+		return ""
+	}
+}
+
+// firstLine returns the first line of content, trimmed.
+func firstLine(content string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	return strings.TrimSpace(line)
+}
@@ -0,0 +1,31 @@
+package codecheck
+
+import "testing"
+
+func TestRuntimeVersion(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		files      map[string]string
+		lang       string
+		version    string
+		declaredBy string
+	}{
+		{"go directive", map[string]string{"go.mod": "module app\n\ngo 1.23.4\n\ntoolchain go1.23.5\n"}, "go", "1.23.4", "go.mod"},
+		{"node engines", map[string]string{"package.json": `{"engines": {"node": "^20.11"}}`, ".nvmrc": "18"}, "nodejs", "^20.11", "package.json"},
+		{"nvmrc", map[string]string{"package.json": `{"name": "app"}`, ".nvmrc": "v22\n"}, "nodejs", "v22", ".nvmrc"},
+		{"python version file", map[string]string{"requirements.txt": "", ".python-version": "3.12.1\n"}, "python", "3.12.1", ".python-version"},
+		{"runtime.txt", map[string]string{"requirements.txt": "", "runtime.txt": "python-3.11.9"}, "python", "3.11.9", "runtime.txt"},
+		{"pom", map[string]string{"pom.xml": "<properties><java.version>21</java.version></properties>"}, "java", "21", "pom.xml"},
+		{"gemfile", map[string]string{"Gemfile": "source 'https://rubygems.org'\nruby '3.3.0'\n"}, "ruby", "3.3.0", "Gemfile"},
+		{"nearest the root", map[string]string{"svc/go.mod": "go 1.21\n", "go.mod": "go 1.23\n"}, "go", "1.23", "go.mod"},
+		{"dependencies skipped", map[string]string{"package.json": "{}", "node_modules/x/.nvmrc": "16"}, "nodejs", "", ""},
+		{"undeclared", map[string]string{"go.mod": "module app\n"}, "go", "", ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			version, file := RuntimeVersion(tt.files, tt.lang)
+			if version != tt.version || file != tt.declaredBy {
+				t.Errorf("got %q from %q, want %q from %q", version, file, tt.version, tt.declaredBy)
+			}
+		})
+	}
+}
@@ -1,10 +1,35 @@
 package config
 
 import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/audit"
+	"github.com/dlapiduz/iaf/internal/cost"
+	"github.com/dlapiduz/iaf/internal/features"
+	iafgithub "github.com/dlapiduz/iaf/internal/github"
+	"github.com/dlapiduz/iaf/internal/grafana"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/maintenance"
+	"github.com/dlapiduz/iaf/internal/platformhealth"
+	"github.com/dlapiduz/iaf/internal/registry"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	"github.com/dlapiduz/iaf/internal/validation"
 	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // Config holds all configuration for IAF components.
@@ -12,6 +37,13 @@ type Config struct {
 	// API server settings
 	APIPort   int      `mapstructure:"api_port"`
 	APITokens []string `mapstructure:"api_tokens"`
+	// AdminTokens (IAF_ADMIN_TOKENS) are Bearer tokens for the operator-only
+	// /api/v1/admin endpoints, which are not mounted when empty. Admin tokens
+	// are also accepted everywhere an API token is.
+	AdminTokens []string `mapstructure:"admin_tokens"`
+	// GRPCPort (IAF_GRPC_PORT) serves the gRPC API and its grpc-gateway
+	// JSON mapping on a second port. 0 disables it.
+	GRPCPort int `mapstructure:"grpc_port"`
 
 	// MCP server settings
 	MCPTransport string `mapstructure:"mcp_transport"` // "stdio" or "http"
@@ -24,16 +56,183 @@ type Config struct {
 	// kpack settings
 	ClusterBuilder string `mapstructure:"cluster_builder"`
 	RegistryPrefix string `mapstructure:"registry_prefix"`
+	// ClusterBuilders (IAF_CLUSTER_BUILDERS) are comma-separated
+	// ClusterBuilders apps may select with spec.builder instead of
+	// ClusterBuilder, e.g. a tiny or Java-native stack.
+	ClusterBuilders []string `mapstructure:"cluster_builders"`
+	// Architectures (IAF_ARCHITECTURES) are comma-separated arch=ClusterBuilder
+	// pairs declaring the CPU architectures apps may select with
+	// spec.architecture (amd64, arm64, multi) and the builder for each. An
+	// empty builder means ClusterBuilder.
+	Architectures string `mapstructure:"architectures"`
+	// Default build cache for apps that do not set spec.buildCache.
+	// IAF_BUILD_CACHE_TYPE: volume, registry, or none.
+	// IAF_BUILD_CACHE_SIZE: size of volume caches (e.g. "2Gi").
+	BuildCacheType string `mapstructure:"build_cache_type"`
+	BuildCacheSize string `mapstructure:"build_cache_size"`
+	// Concurrent build limits; builds over them queue. 0 = unlimited.
+	// IAF_MAX_CONCURRENT_BUILDS: builds running at once across the cluster.
+	// IAF_MAX_CONCURRENT_BUILDS_PER_NAMESPACE: builds running at once per session.
+	MaxConcurrentBuilds             int `mapstructure:"max_concurrent_builds"`
+	MaxConcurrentBuildsPerNamespace int `mapstructure:"max_concurrent_builds_per_namespace"`
+	// Image digest pinning for pre-built images; built images are always
+	// deployed by digest.
+	// IAF_PIN_IMAGE_DIGESTS: resolve image tags to digests at deploy time.
+	// IAF_INSECURE_REGISTRIES: comma-separated registry hosts queried over
+	// plain HTTP, such as an in-cluster development registry.
+	PinImageDigests    bool     `mapstructure:"pin_image_digests"`
+	InsecureRegistries []string `mapstructure:"insecure_registries"`
+
+	// Outbound proxy set in the build and pod env of apps (optional).
+	// IAF_HTTP_PROXY / IAF_HTTPS_PROXY: proxy URLs for http and https requests.
+	// IAF_NO_PROXY: comma-separated extra hosts, domains and CIDRs reached directly.
+	// IAF_CLUSTER_CIDRS: comma-separated pod and service CIDRs, added to NO_PROXY.
+	HTTPProxy    string   `mapstructure:"http_proxy"`
+	HTTPSProxy   string   `mapstructure:"https_proxy"`
+	NoProxy      []string `mapstructure:"no_proxy"`
+	ClusterCIDRs []string `mapstructure:"cluster_cidrs"`
+
+	// CA bundle trusted by app pods for outbound TLS (optional).
+	// IAF_CA_BUNDLE_CONFIGMAP: namespace/name of the ConfigMap holding the PEM bundle.
+	// IAF_CA_BUNDLE_KEY: key of the bundle in that ConfigMap. Default: "ca.crt".
+	CABundleConfigMap string `mapstructure:"ca_bundle_configmap"`
+	CABundleKey       string `mapstructure:"ca_bundle_key"`
+
+	// Node placement of app pods; empty places them on any node.
+	// IAF_NODE_SELECTOR: comma-separated key=value node labels every app pod requires.
+	// IAF_TOLERATIONS: comma-separated key[=value][:effect] taints every app pod tolerates.
+	// IAF_BURST_NODE_SELECTOR / IAF_BURST_TOLERATIONS and IAF_GPU_NODE_SELECTOR /
+	// IAF_GPU_TOLERATIONS: added for apps in the burst and gpu workload classes.
+	// A class is available only when one of its settings is set.
+	NodeSelector      string `mapstructure:"node_selector"`
+	Tolerations       string `mapstructure:"tolerations"`
+	BurstNodeSelector string `mapstructure:"burst_node_selector"`
+	BurstTolerations  string `mapstructure:"burst_tolerations"`
+	GPUNodeSelector   string `mapstructure:"gpu_node_selector"`
+	GPUTolerations    string `mapstructure:"gpu_tolerations"`
 
 	// Source store settings
 	SourceStoreDir string `mapstructure:"source_store_dir"`
 	SourceStoreURL string `mapstructure:"source_store_url"`
+	// SourceSigningKey signs blob URLs. When empty, a key generated in
+	// SourceStoreDir is used. SourceURLTTL makes the URLs expire; zero
+	// means never.
+	SourceSigningKey string        `mapstructure:"source_signing_key"`
+	SourceURLTTL     time.Duration `mapstructure:"source_url_ttl"`
+	// MaxSourceSize (IAF_MAX_SOURCE_SIZE) is the largest source upload
+	// accepted, as a positive quantity such as "512Mi".
+	MaxSourceSize string `mapstructure:"max_source_size"`
+	// MaxOpenUploads (IAF_MAX_OPEN_UPLOADS) is how many unfinished chunked
+	// uploads a namespace may have at once.
+	MaxOpenUploads int `mapstructure:"max_open_uploads"`
 
 	// Routing
 	BaseDomain string `mapstructure:"base_domain"`
 	// TLSIssuer is the ClusterIssuer name for cert-manager. Default: "selfsigned-issuer".
 	// Set to "" to disable TLS certificate provisioning (e.g., cert-manager not installed).
 	TLSIssuer string `mapstructure:"tls_issuer"`
+	// IAF_TLS_MODE: "per-app" (a Certificate per app, any challenge type) or
+	// "wildcard" (one *.BaseDomain certificate shared by all apps under the
+	// base domain; TLSIssuer must solve DNS-01).
+	// IAF_TLS_WILDCARD_NAMESPACE: namespace of the wildcard Certificate and
+	// the Traefik default TLSStore. Default: "iaf-system".
+	TLSMode              string `mapstructure:"tls_mode"`
+	TLSWildcardNamespace string `mapstructure:"tls_wildcard_namespace"`
+	// BackendTLSIssuer (IAF_BACKEND_TLS_ISSUER) is the CA ClusterIssuer of
+	// the serving certificates of apps with spec.backendTLS, which Traefik
+	// connects to over TLS. Empty: such apps fail.
+	BackendTLSIssuer string `mapstructure:"backend_tls_issuer"`
+
+	// App authentication (optional — oauth-proxy apps fail closed when OIDCIssuerURL is empty).
+	// IAF_OAUTH_PROXY_IMAGE: oauth2-proxy sidecar image.
+	// IAF_OIDC_ISSUER_URL / IAF_OIDC_CLIENT_ID / IAF_OIDC_CLIENT_SECRET: platform IdP client.
+	OAuthProxyImage  string `mapstructure:"oauth_proxy_image"`
+	OIDCIssuerURL    string `mapstructure:"oidc_issuer_url"`
+	OIDCClientID     string `mapstructure:"oidc_client_id"`
+	OIDCClientSecret string `mapstructure:"oidc_client_secret"`
+
+	// Controller requeue backoff while an app waits for a build or replicas.
+	// IAF_REQUEUE_BASE_INTERVAL: first requeue delay (e.g. "5s"); doubles per pass.
+	// IAF_REQUEUE_MAX_INTERVAL: cap on the requeue delay (e.g. "5m").
+	RequeueBaseInterval time.Duration `mapstructure:"requeue_base_interval"`
+	RequeueMaxInterval  time.Duration `mapstructure:"requeue_max_interval"`
+
+	// Controller manager endpoints and HA.
+	// IAF_METRICS_BIND_ADDRESS: Prometheus metrics listener ("0" disables it).
+	// IAF_HEALTH_PROBE_BIND_ADDRESS: /healthz and /readyz listener.
+	// IAF_LEADER_ELECT: enable leader election so several controller replicas can run.
+	MetricsBindAddress     string `mapstructure:"metrics_bind_address"`
+	HealthProbeBindAddress string `mapstructure:"health_probe_bind_address"`
+	LeaderElect            bool   `mapstructure:"leader_elect"`
+
+	// Controller admission webhook enforcing PlatformPolicy deploy rules on
+	// every Application write.
+	// IAF_WEBHOOK_CERT_DIR: directory holding tls.crt and tls.key; the
+	// webhook is served only when it holds them.
+	// IAF_WEBHOOK_PORT: port the webhook listens on.
+	WebhookCertDir string `mapstructure:"webhook_cert_dir"`
+	WebhookPort    int    `mapstructure:"webhook_port"`
+
+	// ControllerNamespace (IAF_CONTROLLER_NAMESPACE) is the namespace the
+	// controller runs in. The platform health check reads its leader
+	// election Lease there.
+	ControllerNamespace string `mapstructure:"controller_namespace"`
+
+	// Controller sharding for very large installations. Each controller
+	// deployment reconciles only the session namespaces matching its
+	// selector.
+	// IAF_SHARD_SELECTOR: namespace label selector, e.g. "iaf.io/shard=b";
+	// empty reconciles every namespace.
+	// IAF_SHARD_NAME: names the shard's leader election Lease. Exactly one
+	// deployment leaves it empty; it runs the platform-wide jobs.
+	ShardSelector string `mapstructure:"shard_selector"`
+	ShardName     string `mapstructure:"shard_name"`
+
+	// SuspendedPageService (IAF_SUSPENDED_PAGE_SERVICE) is the
+	// "namespace/name:port" Service that renders the page shown on suspended
+	// apps' routes, normally the API server. Empty leaves Traefik's plain 503.
+	SuspendedPageService string `mapstructure:"suspended_page_service"`
+
+	// Idle auto-sleep (optional — disabled unless PrometheusURL, WakeSecret
+	// and SuspendedPageService are all set).
+	// IAF_PROMETHEUS_URL: Prometheus that scrapes Traefik's request metrics.
+	// IAF_IDLE_CHECK_INTERVAL: how often the controller looks for idle apps.
+	// IAF_WAKE_SECRET: HMAC key for wake links; shared by controller and API server.
+	PrometheusURL     string        `mapstructure:"prometheus_url"`
+	IdleCheckInterval time.Duration `mapstructure:"idle_check_interval"`
+	WakeSecret        string        `mapstructure:"wake_secret"`
+
+	// Post-deploy conformance verification of apps with
+	// spec.verifyConformance.
+	// IAF_CONFORMANCE_CHECK_INTERVAL: how often the controller verifies
+	// newly deployed images; 0 disables verification.
+	// IAF_TEMPO_QUERY_URL: Tempo search API the traces check queries; the
+	// check is skipped when empty.
+	ConformanceCheckInterval time.Duration `mapstructure:"conformance_check_interval"`
+	TempoQueryURL            string        `mapstructure:"tempo_query_url"`
+
+	// DeletedAppRetention (IAF_DELETED_APP_RETENTION) is how long the
+	// controller keeps an application deleted with delete_app, scaled to
+	// zero, so restore_app can bring it back. 0 removes it at once.
+	DeletedAppRetention time.Duration `mapstructure:"deleted_app_retention"`
+
+	// AllowCustomDNS (IAF_ALLOW_CUSTOM_DNS) lets apps set hostAliases and
+	// dnsConfig through the API, for reaching on-prem systems outside
+	// cluster DNS. Cluster-internal names can never be overridden.
+	AllowCustomDNS bool `mapstructure:"allow_custom_dns"`
+
+	// PostgreSQL versions of managed services.
+	// IAF_POSTGRES_IMAGE: image repository of the PostgreSQL operand images.
+	// IAF_POSTGRES_VERSIONS: comma-separated image tags of the minor release
+	// offered for each major version (e.g. "17.2,16.6"). Services upgrade to
+	// a newer tag in their maintenance window; the newest major is the default.
+	PostgresImage    string `mapstructure:"postgres_image"`
+	PostgresVersions string `mapstructure:"postgres_versions"`
+
+	// KubeAPIServer (IAF_KUBE_API_SERVER) is the Kubernetes API server URL
+	// agents can reach. When set, get_namespace_credentials issues
+	// read-only kubeconfigs for it, scoped to the session namespace.
+	KubeAPIServer string `mapstructure:"kube_api_server"`
 
 	// Org standards
 	OrgStandardsFile string `mapstructure:"org_standards_file"`
@@ -44,13 +243,117 @@ type Config struct {
 	SessionTTL        time.Duration `mapstructure:"session_ttl"`
 	SessionGCInterval time.Duration `mapstructure:"session_gc_interval"`
 
-	// GitHub integration (optional — GitHub features are disabled when token is empty)
+	// NamespacePoolSize (IAF_NAMESPACE_POOL_SIZE) is how many session
+	// namespaces are kept provisioned ahead of register. 0 disables the
+	// pool.
+	NamespacePoolSize int `mapstructure:"namespace_pool_size"`
+
+	// SharedGitCredentialsNamespace (IAF_SHARED_GIT_CREDENTIALS_NAMESPACE)
+	// holds the git credentials copied into every new session namespace:
+	// the Secrets there labelled as git credentials. The controller keeps
+	// the copies in existing sessions current.
+	SharedGitCredentialsNamespace string `mapstructure:"shared_git_credentials_namespace"`
+
+	// GitKnownHostsConfigMap (IAF_GIT_KNOWN_HOSTS_CONFIGMAP) is the
+	// namespace/name of the ConfigMap whose known_hosts key holds the SSH
+	// host keys of trusted git servers. A missing ConfigMap holds none.
+	GitKnownHostsConfigMap string `mapstructure:"git_known_hosts_configmap"`
+
+	// MaxToolResultSize (IAF_MAX_TOOL_RESULT_SIZE) is the largest MCP tool
+	// result sent, as a quantity such as "64Ki"; larger ones are shortened.
+	// Empty or 0 means no limit.
+	MaxToolResultSize string `mapstructure:"max_tool_result_size"`
+
+	// EnabledTools (IAF_ENABLED_TOOLS) are comma-separated MCP tools, the
+	// only ones offered when set. DisabledTools (IAF_DISABLED_TOOLS) are
+	// never offered. register is always offered.
+	EnabledTools  []string `mapstructure:"enabled_tools"`
+	DisabledTools []string `mapstructure:"disabled_tools"`
+
+	// ToolTimeout (IAF_TOOL_TIMEOUT) is how long an MCP tool call may run
+	// before it fails with code deadline_exceeded; 0 means no limit.
+	// deploy_stack always gets longer than its longest wait. ToolTimeouts
+	// (IAF_TOOL_TIMEOUTS) sets the limits of single tools as
+	// comma-separated name=duration pairs, such as "app_logs=2m".
+	ToolTimeout  time.Duration `mapstructure:"tool_timeout"`
+	ToolTimeouts string        `mapstructure:"tool_timeouts"`
+
+	// Maintenance (IAF_MAINTENANCE) puts the API and MCP servers in
+	// read-only mode for upgrades: calls that would change anything fail
+	// with code maintenance. MaintenanceUntil (IAF_MAINTENANCE_UNTIL) is
+	// the RFC 3339 time it is expected to end, reported in those errors.
+	Maintenance      bool   `mapstructure:"maintenance"`
+	MaintenanceUntil string `mapstructure:"maintenance_until"`
+
+	// SecurityAuditWebhookURL (IAF_SECURITY_AUDIT_WEBHOOK_URL) receives
+	// every security audit event, such as credentials created or Secrets
+	// mounted, as a JSON POST for a SIEM. SecurityAuditWebhookSecret
+	// (IAF_SECURITY_AUDIT_WEBHOOK_SECRET) signs the bodies with
+	// HMAC-SHA256. The events are logged either way.
+	SecurityAuditWebhookURL    string `mapstructure:"security_audit_webhook_url"`
+	SecurityAuditWebhookSecret string `mapstructure:"security_audit_webhook_secret"`
+
+	// Offline (IAF_OFFLINE) runs the platform in an air-gapped cluster:
+	// GitHub tooling is disabled and the registry prefix, oauth-proxy image
+	// and ClusterBuilders must point at internal mirrors.
+	Offline bool `mapstructure:"offline"`
+
+	// GitHub integration (optional — GitHub features are disabled when
+	// neither a token nor a GitHub App is configured, or the org is empty)
 	GitHubToken string `mapstructure:"github_token"`
 	GitHubOrg   string `mapstructure:"github_org"`
+	// GitHub App authentication, used instead of GitHubToken when
+	// GitHubAppID is set.
+	// IAF_GITHUB_APP_ID: the App's ID.
+	// IAF_GITHUB_APP_INSTALLATION_ID: the ID of its installation on GitHubOrg.
+	// IAF_GITHUB_APP_PRIVATE_KEY: the App's PEM-encoded private key.
+	GitHubAppID             int64  `mapstructure:"github_app_id"`
+	GitHubAppInstallationID int64  `mapstructure:"github_app_installation_id"`
+	GitHubAppPrivateKey     string `mapstructure:"github_app_private_key"`
+	// GitHubTemplatesDir (IAF_GITHUB_TEMPLATES_DIR) holds the CODEOWNERS,
+	// pull request and issue templates, dependabot config and LICENSE
+	// setup_github_repo seeds, at their repository paths. Files there
+	// replace the built-in templates of their kind.
+	GitHubTemplatesDir string `mapstructure:"github_templates_dir"`
+	// GitHubWebhookSecret verifies GitHub webhook deliveries (IAF_GITHUB_WEBHOOK_SECRET).
+	// The /webhooks/github endpoint is disabled when empty.
+	GitHubWebhookSecret string `mapstructure:"github_webhook_secret"`
 
 	// Observability (optional — features are disabled when URLs are empty)
-	// TempoURL is the Grafana base URL for trace explore links (IAF_TEMPO_URL).
+	// GrafanaURL is the Grafana base URL for app deep links (IAF_GRAFANA_URL).
+	GrafanaURL string `mapstructure:"grafana_url"`
+	// TempoURL is the former name of GrafanaURL (IAF_TEMPO_URL), used when
+	// GrafanaURL is empty.
 	TempoURL string `mapstructure:"tempo_url"`
+	// GrafanaLokiUID and GrafanaTempoUID are the datasource UIDs used in
+	// Explore links (IAF_GRAFANA_LOKI_UID, IAF_GRAFANA_TEMPO_UID).
+	GrafanaLokiUID  string `mapstructure:"grafana_loki_uid"`
+	GrafanaTempoUID string `mapstructure:"grafana_tempo_uid"`
+	// GrafanaDashboardUID is a per-app dashboard with "namespace" and "app"
+	// variables (IAF_GRAFANA_DASHBOARD_UID). Dashboard links are omitted when empty.
+	GrafanaDashboardUID string `mapstructure:"grafana_dashboard_uid"`
+	// AlertRuleLabels (IAF_ALERT_RULE_LABELS) are comma-separated key=value
+	// labels added to the PrometheusRules behind set_alert so the platform
+	// Prometheus selects them.
+	AlertRuleLabels string `mapstructure:"alert_rule_labels"`
+
+	// Uptime checks (optional — disabled when BlackboxExporter is empty).
+	// IAF_BLACKBOX_EXPORTER: host:port of the blackbox exporter Probes use.
+	// IAF_BLACKBOX_MODULE: blackbox exporter module for uptime checks.
+	// IAF_PROBE_LABELS: comma-separated key=value labels added to Probes so
+	// the platform Prometheus selects them.
+	BlackboxExporter string `mapstructure:"blackbox_exporter"`
+	BlackboxModule   string `mapstructure:"blackbox_module"`
+	ProbeLabels      string `mapstructure:"probe_labels"`
+
+	// Cost estimation (optional — session_cost, app_cost and the admin cost
+	// roll-up need PrometheusURL).
+	// IAF_COST_CPU_CORE_HOUR: price of one CPU core used for one hour.
+	// IAF_COST_MEMORY_GB_HOUR: price of one GB of memory used for one hour.
+	// IAF_COST_CURRENCY: currency the prices are in.
+	CostCPUCoreHour  float64 `mapstructure:"cost_cpu_core_hour"`
+	CostMemoryGBHour float64 `mapstructure:"cost_memory_gb_hour"`
+	CostCurrency     string  `mapstructure:"cost_currency"`
 
 	// Coach server proxy (optional — coaching proxy is disabled when CoachURL is empty).
 	// IAF_COACH_URL:   Streamable-HTTP MCP endpoint of the coach server (e.g. http://coach.iaf-system/mcp).
@@ -65,21 +368,103 @@ func Load() (*Config, error) {
 
 	v.SetDefault("api_port", 8080)
 	v.SetDefault("api_tokens", []string{"iaf-dev-key"})
+	v.SetDefault("admin_tokens", []string{})
+	v.SetDefault("grpc_port", 9090)
 	v.SetDefault("mcp_transport", "stdio")
 	v.SetDefault("mcp_port", 8081)
 	v.SetDefault("default_namespace", "iaf-apps")
 	v.SetDefault("cluster_builder", "iaf-cluster-builder")
+	v.SetDefault("cluster_builders", []string{})
+	v.SetDefault("architectures", "")
 	v.SetDefault("registry_prefix", "registry.localhost:5000/iaf")
+	v.SetDefault("build_cache_type", "volume")
+	v.SetDefault("build_cache_size", "2Gi")
+	v.SetDefault("max_concurrent_builds", 10)
+	v.SetDefault("namespace_pool_size", 0)
+	v.SetDefault("shared_git_credentials_namespace", "iaf-system")
+	v.SetDefault("git_known_hosts_configmap", "iaf-system/iaf-git-known-hosts")
+	v.SetDefault("max_concurrent_builds_per_namespace", 2)
+	v.SetDefault("pin_image_digests", true)
+	v.SetDefault("insecure_registries", []string{})
+	v.SetDefault("http_proxy", "")
+	v.SetDefault("https_proxy", "")
+	v.SetDefault("no_proxy", []string{})
+	v.SetDefault("cluster_cidrs", []string{})
+	v.SetDefault("ca_bundle_configmap", "")
+	v.SetDefault("ca_bundle_key", "ca.crt")
+	v.SetDefault("node_selector", "")
+	v.SetDefault("tolerations", "")
+	v.SetDefault("burst_node_selector", "")
+	v.SetDefault("burst_tolerations", "")
+	v.SetDefault("gpu_node_selector", "")
+	v.SetDefault("gpu_tolerations", "")
 	v.SetDefault("source_store_dir", "/tmp/iaf-sources")
 	v.SetDefault("source_store_url", "http://iaf-source-store.iaf-system.svc.cluster.local")
+	v.SetDefault("source_signing_key", "")
+	v.SetDefault("source_url_ttl", "0s")
+	v.SetDefault("max_source_size", "512Mi")
+	v.SetDefault("max_open_uploads", 10)
 	v.SetDefault("base_domain", "localhost")
 	v.SetDefault("tls_issuer", "")
+	v.SetDefault("tls_mode", "per-app")
+	v.SetDefault("tls_wildcard_namespace", "iaf-system")
+	v.SetDefault("backend_tls_issuer", "")
+	v.SetDefault("oauth_proxy_image", "quay.io/oauth2-proxy/oauth2-proxy:v7.6.0")
+	v.SetDefault("oidc_issuer_url", "")
+	v.SetDefault("oidc_client_id", "")
+	v.SetDefault("oidc_client_secret", "")
+	v.SetDefault("requeue_base_interval", "5s")
+	v.SetDefault("requeue_max_interval", "5m")
+	v.SetDefault("metrics_bind_address", ":8080")
+	v.SetDefault("health_probe_bind_address", ":8081")
+	v.SetDefault("webhook_port", 9443)
+	v.SetDefault("leader_elect", false)
+	v.SetDefault("shard_selector", "")
+	v.SetDefault("shard_name", "")
+	v.SetDefault("controller_namespace", "iaf-system")
+	v.SetDefault("suspended_page_service", "")
+	v.SetDefault("prometheus_url", "")
+	v.SetDefault("idle_check_interval", "5m")
+	v.SetDefault("conformance_check_interval", "1m")
+	v.SetDefault("tempo_query_url", "")
+	v.SetDefault("deleted_app_retention", "24h")
+	v.SetDefault("wake_secret", "")
+	v.SetDefault("allow_custom_dns", false)
+	v.SetDefault("postgres_image", "ghcr.io/cloudnative-pg/postgresql")
+	v.SetDefault("postgres_versions", "17.6,16.10")
+	v.SetDefault("kube_api_server", "")
 	v.SetDefault("org_standards_file", "")
+	v.SetDefault("offline", false)
 	v.SetDefault("github_token", "")
+	v.SetDefault("github_app_id", 0)
+	v.SetDefault("github_app_installation_id", 0)
+	v.SetDefault("github_app_private_key", "")
 	v.SetDefault("github_org", "")
+	v.SetDefault("github_templates_dir", "")
+	v.SetDefault("github_webhook_secret", "")
+	v.SetDefault("grafana_url", "")
 	v.SetDefault("tempo_url", "")
+	v.SetDefault("grafana_loki_uid", "loki")
+	v.SetDefault("grafana_tempo_uid", "tempo")
+	v.SetDefault("grafana_dashboard_uid", "")
+	v.SetDefault("alert_rule_labels", "release=kube-prometheus-stack")
+	v.SetDefault("blackbox_exporter", "")
+	v.SetDefault("blackbox_module", "http_2xx")
+	v.SetDefault("probe_labels", "release=kube-prometheus-stack")
+	v.SetDefault("cost_cpu_core_hour", 0.04)
+	v.SetDefault("cost_memory_gb_hour", 0.005)
+	v.SetDefault("cost_currency", "USD")
 	v.SetDefault("session_ttl", 0)
 	v.SetDefault("session_gc_interval", 0)
+	v.SetDefault("max_tool_result_size", "64Ki")
+	v.SetDefault("enabled_tools", []string{})
+	v.SetDefault("disabled_tools", []string{})
+	v.SetDefault("tool_timeout", time.Minute)
+	v.SetDefault("tool_timeouts", "")
+	v.SetDefault("maintenance", false)
+	v.SetDefault("maintenance_until", "")
+	v.SetDefault("security_audit_webhook_url", "")
+	v.SetDefault("security_audit_webhook_secret", "")
 	v.SetDefault("coach_url", "")
 	v.SetDefault("coach_token", "")
 
@@ -91,5 +476,454 @@ func Load() (*Config, error) {
 	if err := v.Unmarshal(cfg); err != nil {
 		return nil, err
 	}
+	if cfg.GrafanaURL == "" {
+		cfg.GrafanaURL = cfg.TempoURL
+	}
 	return cfg, nil
 }
+
+// Grafana returns the settings for Grafana deep links.
+func (c *Config) Grafana() grafana.Config {
+	return grafana.Config{
+		URL:          c.GrafanaURL,
+		LokiUID:      c.GrafanaLokiUID,
+		TempoUID:     c.GrafanaTempoUID,
+		DashboardUID: c.GrafanaDashboardUID,
+	}
+}
+
+// SecurityAudit returns the security audit stream of this process of
+// component, such as "controller", named after the component and host.
+// With a dir, events are also kept in a file there.
+func (c *Config) SecurityAudit(component, dir string, logger *slog.Logger) (*audit.SecurityLog, error) {
+	var webhook *audit.Webhook
+	if c.SecurityAuditWebhookURL != "" {
+		u, err := url.Parse(c.SecurityAuditWebhookURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, fmt.Errorf("invalid IAF_SECURITY_AUDIT_WEBHOOK_URL %q: must be an http or https URL", c.SecurityAuditWebhookURL)
+		}
+		webhook = &audit.Webhook{URL: c.SecurityAuditWebhookURL, Secret: c.SecurityAuditWebhookSecret}
+	}
+	stream := component
+	if host, err := os.Hostname(); err == nil && host != "" {
+		stream += "-" + host
+	}
+	var path string
+	if dir != "" {
+		path = filepath.Join(dir, stream+".jsonl")
+	}
+	return audit.NewSecurityLog(stream, path, webhook, logger)
+}
+
+// PlatformHealth returns the checker behind GET /platform/health and the
+// health section of the iaf://platform resource.
+func (c *Config) PlatformHealth(k8sClient client.Client, store *sourcestore.Store) *platformhealth.Checker {
+	return &platformhealth.Checker{
+		Client:              k8sClient,
+		Store:               store,
+		Registry:            c.RegistryClient(),
+		HTTP:                &http.Client{Timeout: 5 * time.Second},
+		ControllerNamespace: c.ControllerNamespace,
+		ClusterBuilder:      c.ClusterBuilder,
+		RegistryPrefix:      c.RegistryPrefix,
+		TLSIssuer:           c.TLSIssuer,
+		PrometheusURL:       c.PrometheusURL,
+		TempoQueryURL:       c.TempoQueryURL,
+		GrafanaURL:          c.Grafana().URL,
+		LokiUID:             c.GrafanaLokiUID,
+	}
+}
+
+// Features returns the optional features this configuration turns on, for
+// the iaf://platform resource and get_capabilities.
+func (c *Config) Features() features.Flags {
+	_, postgresErr := c.Postgres()
+	grafanaCfg := c.Grafana()
+	return features.Flags{
+		features.TLS:                  c.TLSIssuer != "",
+		features.BackendTLS:           c.BackendTLSIssuer != "",
+		features.OAuthProxy:           c.OIDCIssuerURL != "",
+		features.CustomDNS:            c.AllowCustomDNS,
+		features.Idling:               c.PrometheusURL != "" && c.WakeSecret != "" && c.SuspendedPageService != "",
+		features.ManagedServices:      postgresErr == nil,
+		features.GitHub:               c.GitHubEnabled(),
+		features.Logs:                 grafanaCfg.URL != "" && grafanaCfg.LokiUID != "",
+		features.Traces:               grafanaCfg.URL != "" && grafanaCfg.TempoUID != "",
+		features.Dashboards:           grafanaCfg.URL != "" && grafanaCfg.DashboardUID != "",
+		features.Uptime:               c.BlackboxExporter != "",
+		features.Costs:                c.PrometheusURL != "",
+		features.NamespaceCredentials: c.KubeAPIServer != "",
+	}
+}
+
+// CostRates returns the prices used to estimate costs.
+func (c *Config) CostRates() cost.Rates {
+	return cost.Rates{CPUCoreHour: c.CostCPUCoreHour, MemoryGBHour: c.CostMemoryGBHour, Currency: c.CostCurrency}
+}
+
+// BuildCache returns the default build cache.
+// The size only applies to volume caches.
+func (c *Config) BuildCache() (iafk8s.BuildCache, error) {
+	cacheType := iafv1alpha1.BuildCacheType(c.BuildCacheType)
+	if cacheType == iafv1alpha1.BuildCacheVolume && c.BuildCacheSize == "" {
+		return iafk8s.BuildCache{}, fmt.Errorf("IAF_BUILD_CACHE_SIZE is required when IAF_BUILD_CACHE_TYPE is volume")
+	}
+	sizeValue := ""
+	if cacheType == iafv1alpha1.BuildCacheVolume {
+		sizeValue = c.BuildCacheSize
+	}
+	size, err := validation.ValidateBuildCache(c.BuildCacheType, sizeValue)
+	if err != nil {
+		return iafk8s.BuildCache{}, fmt.Errorf("invalid build cache configuration: %w", err)
+	}
+	cache := iafk8s.BuildCache{Type: cacheType}
+	if size != nil {
+		cache.VolumeSize = *size
+	}
+	return cache, nil
+}
+
+// MaxSourceBytes returns MaxSourceSize in bytes. Sources are always
+// limited, so a size that is not positive is an error.
+func (c *Config) MaxSourceBytes() (int64, error) {
+	q, err := resource.ParseQuantity(c.MaxSourceSize)
+	if err != nil || q.Sign() <= 0 {
+		return 0, fmt.Errorf("invalid IAF_MAX_SOURCE_SIZE %q: must be a positive size such as 512Mi", c.MaxSourceSize)
+	}
+	return q.Value(), nil
+}
+
+// OpenUploadsLimit returns MaxOpenUploads, which must be positive.
+func (c *Config) OpenUploadsLimit() (int, error) {
+	if c.MaxOpenUploads <= 0 {
+		return 0, fmt.Errorf("invalid IAF_MAX_OPEN_UPLOADS %d: must be positive", c.MaxOpenUploads)
+	}
+	return c.MaxOpenUploads, nil
+}
+
+// MaxToolResultBytes returns MaxToolResultSize in bytes, or 0 for no limit.
+func (c *Config) MaxToolResultBytes() (int, error) {
+	if c.MaxToolResultSize == "" {
+		return 0, nil
+	}
+	q, err := resource.ParseQuantity(c.MaxToolResultSize)
+	if err != nil || q.Sign() < 0 || q.Value() > math.MaxInt32 {
+		return 0, fmt.Errorf("invalid IAF_MAX_TOOL_RESULT_SIZE %q: must be a size such as 64Ki", c.MaxToolResultSize)
+	}
+	return int(q.Value()), nil
+}
+
+// ToolTimeoutMap parses ToolTimeouts into the limit of each named tool.
+func (c *Config) ToolTimeoutMap() (map[string]time.Duration, error) {
+	pairs, err := parseLabels("IAF_TOOL_TIMEOUTS", c.ToolTimeouts)
+	if err != nil {
+		return nil, err
+	}
+	if c.ToolTimeout < 0 {
+		return nil, fmt.Errorf("invalid IAF_TOOL_TIMEOUT %s: must not be negative", c.ToolTimeout)
+	}
+	limits := make(map[string]time.Duration, len(pairs))
+	for name, value := range pairs {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid IAF_TOOL_TIMEOUTS entry %s=%s: must be a duration such as 2m", name, value)
+		}
+		limits[name] = d
+	}
+	return limits, nil
+}
+
+// ImageResolver returns the resolver the controller pins image tags to
+// digests with, or nil when PinImageDigests is off.
+func (c *Config) ImageResolver() registry.Resolver {
+	if !c.PinImageDigests {
+		return nil
+	}
+	return c.RegistryClient()
+}
+
+// RegistryClient returns a registry client that reaches the
+// InsecureRegistries over plain HTTP.
+func (c *Config) RegistryClient() *registry.Client {
+	var insecure []string
+	for _, h := range c.InsecureRegistries {
+		if h = strings.TrimSpace(h); h != "" {
+			insecure = append(insecure, h)
+		}
+	}
+	return registry.NewClient(insecure)
+}
+
+// Proxy returns the outbound proxy for app builds and pods. NO_PROXY covers
+// the cluster CIDRs and the app domain, so traffic between apps stays direct.
+func (c *Config) Proxy() (iafk8s.Proxy, error) {
+	p := iafk8s.Proxy{HTTPProxy: strings.TrimSpace(c.HTTPProxy), HTTPSProxy: strings.TrimSpace(c.HTTPSProxy)}
+	for _, v := range []struct{ env, url string }{{"IAF_HTTP_PROXY", p.HTTPProxy}, {"IAF_HTTPS_PROXY", p.HTTPSProxy}} {
+		if v.url == "" {
+			continue
+		}
+		if u, err := url.Parse(v.url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return iafk8s.Proxy{}, fmt.Errorf("invalid %s: not an http(s) URL", v.env)
+		}
+	}
+	if p.IsZero() {
+		return p, nil
+	}
+	for _, cidr := range c.ClusterCIDRs {
+		if cidr = strings.TrimSpace(cidr); cidr == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return iafk8s.Proxy{}, fmt.Errorf("invalid IAF_CLUSTER_CIDRS: %w", err)
+		}
+		p.NoProxy = append(p.NoProxy, cidr)
+	}
+	if c.BaseDomain != "" {
+		p.NoProxy = append(p.NoProxy, "."+c.BaseDomain)
+	}
+	for _, host := range c.NoProxy {
+		if host = strings.TrimSpace(host); host == "" {
+			continue
+		}
+		if strings.ContainsAny(host, " \t\"'") {
+			return iafk8s.Proxy{}, fmt.Errorf("invalid IAF_NO_PROXY entry %q", host)
+		}
+		p.NoProxy = append(p.NoProxy, host)
+	}
+	return p, nil
+}
+
+// CABundle returns the CA bundle mounted in app pods, or the zero value when
+// none is configured.
+func (c *Config) CABundle() (iafk8s.CABundle, error) {
+	ref := strings.TrimSpace(c.CABundleConfigMap)
+	if ref == "" {
+		return iafk8s.CABundle{}, nil
+	}
+	namespace, name, ok := strings.Cut(ref, "/")
+	if !ok || len(k8svalidation.IsDNS1123Label(namespace)) > 0 || len(k8svalidation.IsDNS1123Subdomain(name)) > 0 {
+		return iafk8s.CABundle{}, fmt.Errorf("invalid IAF_CA_BUNDLE_CONFIGMAP %q: want namespace/name", ref)
+	}
+	key := strings.TrimSpace(c.CABundleKey)
+	if len(k8svalidation.IsConfigMapKey(key)) > 0 {
+		return iafk8s.CABundle{}, fmt.Errorf("invalid IAF_CA_BUNDLE_KEY %q", key)
+	}
+	return iafk8s.CABundle{Namespace: namespace, Name: name, Key: key}, nil
+}
+
+// GitKnownHosts returns the known_hosts ConfigMap of ssh git credentials,
+// or the zero value when none is configured.
+func (c *Config) GitKnownHosts() (iafk8s.KnownHosts, error) {
+	ref := strings.TrimSpace(c.GitKnownHostsConfigMap)
+	if ref == "" {
+		return iafk8s.KnownHosts{}, nil
+	}
+	namespace, name, ok := strings.Cut(ref, "/")
+	if !ok || len(k8svalidation.IsDNS1123Label(namespace)) > 0 || len(k8svalidation.IsDNS1123Subdomain(name)) > 0 {
+		return iafk8s.KnownHosts{}, fmt.Errorf("invalid IAF_GIT_KNOWN_HOSTS_CONFIGMAP %q: want namespace/name", ref)
+	}
+	return iafk8s.KnownHosts{Namespace: namespace, Name: name}, nil
+}
+
+// WildcardTLS returns the wildcard certificate mode, or the zero value when
+// apps get a Certificate each.
+func (c *Config) WildcardTLS() (iafk8s.WildcardTLS, error) {
+	switch strings.TrimSpace(c.TLSMode) {
+	case "", "per-app":
+		return iafk8s.WildcardTLS{}, nil
+	case "wildcard":
+	default:
+		return iafk8s.WildcardTLS{}, fmt.Errorf("invalid IAF_TLS_MODE %q: want per-app or wildcard", c.TLSMode)
+	}
+	if c.TLSIssuer == "" {
+		return iafk8s.WildcardTLS{}, fmt.Errorf("IAF_TLS_MODE=wildcard needs IAF_TLS_ISSUER set to a ClusterIssuer with a DNS-01 solver")
+	}
+	if !strings.Contains(c.BaseDomain, ".") {
+		return iafk8s.WildcardTLS{}, fmt.Errorf("IAF_TLS_MODE=wildcard needs a registered IAF_BASE_DOMAIN, got %q", c.BaseDomain)
+	}
+	namespace := strings.TrimSpace(c.TLSWildcardNamespace)
+	if len(k8svalidation.IsDNS1123Label(namespace)) > 0 {
+		return iafk8s.WildcardTLS{}, fmt.Errorf("invalid IAF_TLS_WILDCARD_NAMESPACE %q", namespace)
+	}
+	return iafk8s.WildcardTLS{Namespace: namespace}, nil
+}
+
+// Postgres returns the PostgreSQL versions managed services run.
+func (c *Config) Postgres() (iafk8s.PostgresVersions, error) {
+	v, err := iafk8s.ParsePostgresVersions(c.PostgresImage, c.PostgresVersions)
+	if err != nil {
+		return iafk8s.PostgresVersions{}, fmt.Errorf("invalid IAF_POSTGRES_IMAGE or IAF_POSTGRES_VERSIONS: %w", err)
+	}
+	return v, nil
+}
+
+// GitHubEnabled reports whether the GitHub integration is configured and
+// allowed; it is always off in offline mode.
+func (c *Config) GitHubEnabled() bool {
+	return !c.Offline && (c.GitHubToken != "" || c.GitHubAppID != 0) && c.GitHubOrg != ""
+}
+
+// GitHubClient returns the client of the GitHub integration: authenticated
+// as the GitHub App installation when IAF_GITHUB_APP_ID is set, with
+// IAF_GITHUB_TOKEN otherwise. Call it only when GitHubEnabled.
+func (c *Config) GitHubClient() (*iafgithub.HTTPClient, error) {
+	if c.GitHubAppID == 0 {
+		return iafgithub.NewHTTPClient(c.GitHubToken), nil
+	}
+	if c.GitHubToken != "" {
+		return nil, fmt.Errorf("set either IAF_GITHUB_TOKEN or IAF_GITHUB_APP_ID, not both")
+	}
+	if c.GitHubAppInstallationID == 0 || c.GitHubAppPrivateKey == "" {
+		return nil, fmt.Errorf("IAF_GITHUB_APP_ID needs IAF_GITHUB_APP_INSTALLATION_ID and IAF_GITHUB_APP_PRIVATE_KEY")
+	}
+	client, err := iafgithub.NewAppClient(c.GitHubAppID, c.GitHubAppInstallationID, []byte(c.GitHubAppPrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub App settings: %w", err)
+	}
+	return client, nil
+}
+
+// ValidateOffline checks that, in offline mode, the images the platform
+// pulls or pushes come from internal registries rather than the internet.
+func (c *Config) ValidateOffline() error {
+	if !c.Offline {
+		return nil
+	}
+	if registry.IsPublic(c.RegistryPrefix) {
+		return fmt.Errorf("IAF_REGISTRY_PREFIX %q is a public registry; offline mode needs an internal registry", c.RegistryPrefix)
+	}
+	if c.OAuthProxyImage != "" && registry.IsPublic(c.OAuthProxyImage) {
+		return fmt.Errorf("IAF_OAUTH_PROXY_IMAGE %q is on a public registry; offline mode needs an internal mirror", c.OAuthProxyImage)
+	}
+	if registry.IsPublic(c.PostgresImage) {
+		return fmt.Errorf("IAF_POSTGRES_IMAGE %q is on a public registry; offline mode needs an internal mirror", c.PostgresImage)
+	}
+	return nil
+}
+
+// Builders returns the ClusterBuilders apps may select: the default
+// builder followed by ClusterBuilders, without duplicates or empty names.
+func (c *Config) Builders() []string {
+	var builders []string
+	for _, b := range append([]string{c.ClusterBuilder}, c.ClusterBuilders...) {
+		if b = strings.TrimSpace(b); b != "" && !slices.Contains(builders, b) {
+			builders = append(builders, b)
+		}
+	}
+	return builders
+}
+
+// ArchitectureBuilders parses Architectures into the ClusterBuilder for each
+// available architecture.
+func (c *Config) ArchitectureBuilders() (map[string]string, error) {
+	builders := map[string]string{}
+	for _, pair := range strings.Split(c.Architectures, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		arch, builder, _ := strings.Cut(pair, "=")
+		arch, builder = strings.TrimSpace(arch), strings.TrimSpace(builder)
+		switch iafv1alpha1.ApplicationArchitecture(arch) {
+		case iafv1alpha1.ArchitectureAMD64, iafv1alpha1.ArchitectureARM64, iafv1alpha1.ArchitectureMulti:
+		default:
+			return nil, fmt.Errorf("invalid IAF_ARCHITECTURES: unknown architecture %q (use amd64, arm64 or multi)", arch)
+		}
+		if _, dup := builders[arch]; dup {
+			return nil, fmt.Errorf("invalid IAF_ARCHITECTURES: architecture %q listed twice", arch)
+		}
+		if builder == "" {
+			builder = c.ClusterBuilder
+		}
+		builders[arch] = builder
+	}
+	return builders, nil
+}
+
+// Placement returns the node placement of every app pod.
+func (c *Config) Placement() (iafk8s.Placement, error) {
+	return parsePlacement("IAF_NODE_SELECTOR", c.NodeSelector, "IAF_TOLERATIONS", c.Tolerations)
+}
+
+// WorkloadClasses returns the placement each configured workload class adds
+// to Placement. The standard class adds none and is always available.
+func (c *Config) WorkloadClasses() (map[string]iafk8s.Placement, error) {
+	classes := map[string]iafk8s.Placement{string(iafv1alpha1.WorkloadClassStandard): {}}
+	burst, err := parsePlacement("IAF_BURST_NODE_SELECTOR", c.BurstNodeSelector, "IAF_BURST_TOLERATIONS", c.BurstTolerations)
+	if err != nil {
+		return nil, err
+	}
+	if !burst.IsZero() {
+		classes[string(iafv1alpha1.WorkloadClassBurst)] = burst
+	}
+	gpu, err := parsePlacement("IAF_GPU_NODE_SELECTOR", c.GPUNodeSelector, "IAF_GPU_TOLERATIONS", c.GPUTolerations)
+	if err != nil {
+		return nil, err
+	}
+	if !gpu.IsZero() {
+		classes[string(iafv1alpha1.WorkloadClassGPU)] = gpu
+	}
+	return classes, nil
+}
+
+// parsePlacement parses a node selector and tolerations from the variables
+// selectorEnv and tolerationsEnv.
+func parsePlacement(selectorEnv, selector, tolerationsEnv, tolerations string) (iafk8s.Placement, error) {
+	var p iafk8s.Placement
+	nodeSelector, err := parseLabels(selectorEnv, selector)
+	if err != nil {
+		return p, err
+	}
+	if len(nodeSelector) > 0 {
+		p.NodeSelector = nodeSelector
+	}
+	if p.Tolerations, err = iafk8s.ParseTolerations(tolerations); err != nil {
+		return p, fmt.Errorf("invalid %s: %w", tolerationsEnv, err)
+	}
+	return p, nil
+}
+
+// MaintenanceMode returns the read-only mode Maintenance and
+// MaintenanceUntil set, nil when maintenance is off.
+func (c *Config) MaintenanceMode() (*maintenance.Mode, error) {
+	return maintenance.Parse(c.Maintenance, c.MaintenanceUntil)
+}
+
+// Shard parses ShardSelector, nil when the controller reconciles every
+// namespace. A named shard must select a subset of them.
+func (c *Config) Shard() (labels.Selector, error) {
+	selector, err := labels.Parse(c.ShardSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IAF_SHARD_SELECTOR: %w", err)
+	}
+	if c.ShardName != "" {
+		if errs := k8svalidation.IsDNS1123Label(c.ShardName); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid IAF_SHARD_NAME %q: %s", c.ShardName, strings.Join(errs, "; "))
+		}
+		if selector.Empty() {
+			return nil, fmt.Errorf("IAF_SHARD_NAME %q needs IAF_SHARD_SELECTOR", c.ShardName)
+		}
+	}
+	if selector.Empty() {
+		return nil, nil
+	}
+	return selector, nil
+}
+
+// AlertRuleLabelMap parses AlertRuleLabels.
+func (c *Config) AlertRuleLabelMap() (map[string]string, error) {
+	return parseLabels("IAF_ALERT_RULE_LABELS", c.AlertRuleLabels)
+}
+
+// ProbeLabelMap parses ProbeLabels.
+func (c *Config) ProbeLabelMap() (map[string]string, error) {
+	return parseLabels("IAF_PROBE_LABELS", c.ProbeLabels)
+}
+
+// parseLabels parses comma-separated key=value labels from the variable env.
+func parseLabels(env, value string) (map[string]string, error) {
+	m, err := labels.ConvertSelectorToLabelsMap(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", env, err)
+	}
+	return m, nil
+}
@@ -1,8 +1,23 @@
 package config
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/dlapiduz/iaf/internal/audit"
+	"github.com/dlapiduz/iaf/internal/features"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/registry"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // TestLoad_TLSIssuerDefaultsToEmpty is a regression test for the bug where
@@ -33,3 +48,697 @@ func TestLoad_TLSIssuerRespectedWhenSet(t *testing.T) {
 		t.Errorf("expected TLSIssuer=%q, got %q", "selfsigned-issuer", cfg.TLSIssuer)
 	}
 }
+
+// TestLoad_RequeueIntervals verifies the controller backoff defaults and
+// that operators can override them with duration strings.
+func TestLoad_RequeueIntervals(t *testing.T) {
+	os.Unsetenv("IAF_REQUEUE_BASE_INTERVAL")
+	t.Setenv("IAF_REQUEUE_MAX_INTERVAL", "2m")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.RequeueBaseInterval != 5*time.Second {
+		t.Errorf("expected default base interval 5s, got %v", cfg.RequeueBaseInterval)
+	}
+	if cfg.RequeueMaxInterval != 2*time.Minute {
+		t.Errorf("expected max interval 2m, got %v", cfg.RequeueMaxInterval)
+	}
+}
+
+// TestLoad_GrafanaURLFallsBackToTempoURL verifies deployments that still set
+// TestConfig_MaintenanceMode verifies maintenance is off by default and
+// that an end time must be an RFC 3339 time.
+func TestConfig_MaintenanceMode(t *testing.T) {
+	os.Unsetenv("IAF_MAINTENANCE")
+	os.Unsetenv("IAF_MAINTENANCE_UNTIL")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, err := cfg.MaintenanceMode(); m.Active() || err != nil {
+		t.Errorf("expected maintenance off by default, got %+v, %v", m, err)
+	}
+
+	t.Setenv("IAF_MAINTENANCE", "true")
+	t.Setenv("IAF_MAINTENANCE_UNTIL", "2026-05-01T18:00:00Z")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	m, err := cfg.MaintenanceMode()
+	if err != nil || !m.Active() || !m.Until.Equal(time.Date(2026, 5, 1, 18, 0, 0, 0, time.UTC)) {
+		t.Errorf("MaintenanceMode = %+v, %v", m, err)
+	}
+	cfg.MaintenanceUntil = "6pm"
+	if _, err := cfg.MaintenanceMode(); err == nil {
+		t.Error("expected an invalid end time to be rejected")
+	}
+}
+
+// TestLoad_DeletedAppRetention verifies deleted apps are kept for a day by
+// default and that operators can shorten the window.
+func TestLoad_DeletedAppRetention(t *testing.T) {
+	os.Unsetenv("IAF_DELETED_APP_RETENTION")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DeletedAppRetention != 24*time.Hour {
+		t.Errorf("expected default retention 24h, got %v", cfg.DeletedAppRetention)
+	}
+	t.Setenv("IAF_DELETED_APP_RETENTION", "0s")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DeletedAppRetention != 0 {
+		t.Errorf("expected retention 0, got %v", cfg.DeletedAppRetention)
+	}
+}
+
+// the older IAF_TEMPO_URL keep their Grafana deep links.
+func TestLoad_GrafanaURLFallsBackToTempoURL(t *testing.T) {
+	os.Unsetenv("IAF_GRAFANA_URL")
+	t.Setenv("IAF_TEMPO_URL", "https://grafana.example.com")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := cfg.Grafana()
+	if g.URL != "https://grafana.example.com" || g.LokiUID != "loki" || g.TempoUID != "tempo" {
+		t.Errorf("unexpected Grafana config %+v", g)
+	}
+
+	t.Setenv("IAF_GRAFANA_URL", "https://grafana.corp")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GrafanaURL != "https://grafana.corp" {
+		t.Errorf("expected IAF_GRAFANA_URL to take precedence, got %q", cfg.GrafanaURL)
+	}
+}
+
+func TestConfig_AlertRuleLabelMap(t *testing.T) {
+	cfg := &Config{AlertRuleLabels: "release=kube-prometheus-stack, team=platform"}
+	m, err := cfg.AlertRuleLabelMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 2 || m["release"] != "kube-prometheus-stack" || m["team"] != "platform" {
+		t.Errorf("unexpected labels %v", m)
+	}
+
+	cfg.AlertRuleLabels = "release"
+	if _, err := cfg.AlertRuleLabelMap(); err == nil {
+		t.Error("expected an error for a label without a value")
+	}
+}
+
+func TestConfig_CostRates(t *testing.T) {
+	t.Setenv("IAF_COST_CPU_CORE_HOUR", "0.1")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rates := cfg.CostRates()
+	if rates.CPUCoreHour != 0.1 || rates.MemoryGBHour != 0.005 || rates.Currency != "USD" {
+		t.Errorf("unexpected rates %+v", rates)
+	}
+}
+
+func TestConfig_BuildCache(t *testing.T) {
+	os.Unsetenv("IAF_BUILD_CACHE_TYPE")
+	os.Unsetenv("IAF_BUILD_CACHE_SIZE")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, err := cfg.BuildCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache.Type != "volume" || cache.VolumeSize.String() != "2Gi" {
+		t.Errorf("expected a 2Gi volume cache by default, got %s %s", cache.Type, cache.VolumeSize.String())
+	}
+
+	// The default size does not get in the way of other cache types.
+	t.Setenv("IAF_BUILD_CACHE_TYPE", "registry")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if cache, err = cfg.BuildCache(); err != nil || cache.Type != "registry" {
+		t.Errorf("expected a registry cache, got %+v, %v", cache, err)
+	}
+
+	for _, bad := range []Config{
+		{BuildCacheType: "disk", BuildCacheSize: "2Gi"},
+		{BuildCacheType: "volume", BuildCacheSize: ""},
+		{BuildCacheType: "volume", BuildCacheSize: "1Ti"},
+	} {
+		if _, err := bad.BuildCache(); err == nil {
+			t.Errorf("expected an error for %s/%q", bad.BuildCacheType, bad.BuildCacheSize)
+		}
+	}
+}
+
+func TestLoad_BuildLimits(t *testing.T) {
+	os.Unsetenv("IAF_MAX_CONCURRENT_BUILDS")
+	t.Setenv("IAF_MAX_CONCURRENT_BUILDS_PER_NAMESPACE", "0")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxConcurrentBuilds != 10 || cfg.MaxConcurrentBuildsPerNamespace != 0 {
+		t.Errorf("unexpected build limits %d/%d", cfg.MaxConcurrentBuilds, cfg.MaxConcurrentBuildsPerNamespace)
+	}
+}
+
+func TestConfig_MaxToolResultBytes(t *testing.T) {
+	os.Unsetenv("IAF_MAX_TOOL_RESULT_SIZE")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := cfg.MaxToolResultBytes(); err != nil || n != 65536 {
+		t.Errorf("expected a 64Ki default, got %d, %v", n, err)
+	}
+	for size, want := range map[string]int{"": 0, "0": 0, "16k": 16000} {
+		if n, err := (&Config{MaxToolResultSize: size}).MaxToolResultBytes(); err != nil || n != want {
+			t.Errorf("%q: expected %d, got %d, %v", size, want, n, err)
+		}
+	}
+	for _, size := range []string{"lots", "-1Ki", "4Gi"} {
+		if _, err := (&Config{MaxToolResultSize: size}).MaxToolResultBytes(); err == nil {
+			t.Errorf("expected an error for %q", size)
+		}
+	}
+}
+
+func TestConfig_UploadLimits(t *testing.T) {
+	os.Unsetenv("IAF_MAX_SOURCE_SIZE")
+	os.Unsetenv("IAF_MAX_OPEN_UPLOADS")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := cfg.MaxSourceBytes(); err != nil || n != 512<<20 {
+		t.Errorf("expected a 512Mi default, got %d, %v", n, err)
+	}
+	if n, err := cfg.OpenUploadsLimit(); err != nil || n != 10 {
+		t.Errorf("expected 10 open uploads by default, got %d, %v", n, err)
+	}
+	// Uploads are always limited.
+	for _, size := range []string{"", "0", "lots", "-1Mi"} {
+		if _, err := (&Config{MaxSourceSize: size}).MaxSourceBytes(); err == nil {
+			t.Errorf("expected an error for %q", size)
+		}
+	}
+	for _, n := range []int{0, -1} {
+		if _, err := (&Config{MaxOpenUploads: n}).OpenUploadsLimit(); err == nil {
+			t.Errorf("expected an error for %d open uploads", n)
+		}
+	}
+}
+
+func TestConfig_ToolTimeouts(t *testing.T) {
+	os.Unsetenv("IAF_TOOL_TIMEOUT")
+	os.Unsetenv("IAF_TOOL_TIMEOUTS")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits, err := cfg.ToolTimeoutMap(); cfg.ToolTimeout != time.Minute || len(limits) != 0 || err != nil {
+		t.Errorf("expected a 1m default and no tool limits, got %s, %v, %v", cfg.ToolTimeout, limits, err)
+	}
+	limits, err := (&Config{ToolTimeouts: "app_logs=2m, deploy_stack=0s"}).ToolTimeoutMap()
+	if err != nil || limits["app_logs"] != 2*time.Minute || limits["deploy_stack"] != 0 || len(limits) != 2 {
+		t.Errorf("ToolTimeoutMap = %v, %v", limits, err)
+	}
+	for _, bad := range []Config{
+		{ToolTimeouts: "app_logs=soon"},
+		{ToolTimeouts: "app_logs=-1m"},
+		{ToolTimeouts: "app_logs"},
+		{ToolTimeout: -time.Second},
+	} {
+		if _, err := bad.ToolTimeoutMap(); err == nil {
+			t.Errorf("expected an error for %+v", bad)
+		}
+	}
+}
+
+func TestConfig_SecurityAudit(t *testing.T) {
+	dir := t.TempDir()
+	l, err := (&Config{}).SecurityAudit("apiserver", dir, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Record(audit.SecurityEvent{Action: audit.ActionCredentialCreated, Kind: "git_credential", Name: "github"})
+	if files, _ := filepath.Glob(filepath.Join(dir, "apiserver*.jsonl")); len(files) != 1 {
+		t.Errorf("expected one stream file, got %v", files)
+	}
+	for _, bad := range []string{"ftp://siem.example.com", "siem.example.com/events", "https://"} {
+		if _, err := (&Config{SecurityAuditWebhookURL: bad}).SecurityAudit("controller", "", slog.Default()); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+	l, err = (&Config{SecurityAuditWebhookURL: "https://siem.example.com/events"}).SecurityAudit("controller", "", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+}
+
+func TestConfig_Builders(t *testing.T) {
+	os.Unsetenv("IAF_CLUSTER_BUILDER")
+	os.Unsetenv("IAF_CLUSTER_BUILDERS")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Builders(); !slices.Equal(got, []string{"iaf-cluster-builder"}) {
+		t.Errorf("expected only the default builder, got %v", got)
+	}
+
+	t.Setenv("IAF_CLUSTER_BUILDERS", "tiny, java-native,iaf-cluster-builder")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Builders(); !slices.Equal(got, []string{"iaf-cluster-builder", "tiny", "java-native"}) {
+		t.Errorf("unexpected builders %v", got)
+	}
+}
+
+func TestConfig_ImageResolver(t *testing.T) {
+	os.Unsetenv("IAF_PIN_IMAGE_DIGESTS")
+	t.Setenv("IAF_INSECURE_REGISTRIES", "registry.localhost:5000, ")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, ok := cfg.ImageResolver().(*registry.Client)
+	if !ok {
+		t.Fatalf("expected digest pinning on by default, got %T", cfg.ImageResolver())
+	}
+	if !slices.Equal(c.Insecure, []string{"registry.localhost:5000"}) {
+		t.Errorf("unexpected insecure registries %v", c.Insecure)
+	}
+
+	t.Setenv("IAF_PIN_IMAGE_DIGESTS", "false")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ImageResolver() != nil {
+		t.Error("expected no resolver with pinning off")
+	}
+}
+
+func TestConfig_Offline(t *testing.T) {
+	os.Unsetenv("IAF_OFFLINE")
+	os.Unsetenv("IAF_REGISTRY_PREFIX")
+	os.Unsetenv("IAF_OAUTH_PROXY_IMAGE")
+	t.Setenv("IAF_GITHUB_TOKEN", "token")
+	t.Setenv("IAF_GITHUB_ORG", "org")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.GitHubEnabled() {
+		t.Error("expected GitHub enabled when online with a token and org")
+	}
+	if err := cfg.ValidateOffline(); err != nil {
+		t.Errorf("expected no offline checks when online, got %v", err)
+	}
+
+	t.Setenv("IAF_OFFLINE", "true")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GitHubEnabled() {
+		t.Error("expected GitHub disabled in offline mode")
+	}
+	if err := cfg.ValidateOffline(); err == nil || !strings.Contains(err.Error(), "IAF_OAUTH_PROXY_IMAGE") {
+		t.Errorf("expected the default quay.io oauth-proxy image to be rejected, got %v", err)
+	}
+
+	t.Setenv("IAF_OAUTH_PROXY_IMAGE", "harbor.corp.example/mirror/oauth2-proxy:v7.6.0")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.ValidateOffline(); err == nil || !strings.Contains(err.Error(), "IAF_POSTGRES_IMAGE") {
+		t.Errorf("expected the default ghcr.io postgres image to be rejected, got %v", err)
+	}
+
+	t.Setenv("IAF_POSTGRES_IMAGE", "harbor.corp.example/mirror/postgresql")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.ValidateOffline(); err != nil {
+		t.Errorf("expected internal images to pass, got %v", err)
+	}
+
+	t.Setenv("IAF_REGISTRY_PREFIX", "ghcr.io/corp")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.ValidateOffline(); err == nil || !strings.Contains(err.Error(), "IAF_REGISTRY_PREFIX") {
+		t.Errorf("expected a public registry prefix to be rejected, got %v", err)
+	}
+}
+
+func TestConfig_ArchitectureBuilders(t *testing.T) {
+	cfg := Config{ClusterBuilder: "iaf-cluster-builder", Architectures: "amd64, arm64=iaf-arm64-builder"}
+	builders, err := cfg.ArchitectureBuilders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builders) != 2 || builders["amd64"] != "iaf-cluster-builder" || builders["arm64"] != "iaf-arm64-builder" {
+		t.Errorf("unexpected architecture builders %v", builders)
+	}
+
+	cfg.Architectures = ""
+	if builders, err = cfg.ArchitectureBuilders(); err != nil || len(builders) != 0 {
+		t.Errorf("expected no architectures by default, got %v, %v", builders, err)
+	}
+
+	for _, bad := range []string{"x86_64=builder", "amd64,amd64=other"} {
+		cfg.Architectures = bad
+		if _, err := cfg.ArchitectureBuilders(); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestConfig_Placement(t *testing.T) {
+	cfg := Config{
+		NodeSelector:    "pool=agents",
+		Tolerations:     "dedicated=agents:NoSchedule",
+		GPUNodeSelector: "accelerator=nvidia",
+		GPUTolerations:  "nvidia.com/gpu:NoSchedule",
+	}
+	placement, err := cfg.Placement()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if placement.NodeSelector["pool"] != "agents" || len(placement.Tolerations) != 1 || placement.Tolerations[0].Value != "agents" {
+		t.Errorf("unexpected placement %+v", placement)
+	}
+	classes, err := cfg.WorkloadClasses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := classes["burst"]; ok {
+		t.Error("expected the unconfigured burst class to be unavailable")
+	}
+	if _, ok := classes["standard"]; !ok {
+		t.Error("expected the standard class to be available")
+	}
+	if gpu := classes["gpu"]; gpu.NodeSelector["accelerator"] != "nvidia" || len(gpu.Tolerations) != 1 {
+		t.Errorf("unexpected gpu placement %+v", gpu)
+	}
+
+	if placement, err := (&Config{}).Placement(); err != nil || !placement.IsZero() {
+		t.Errorf("expected no placement by default, got %+v, %v", placement, err)
+	}
+	for _, bad := range []Config{{NodeSelector: "pool"}, {Tolerations: "gpu:Sometimes"}, {BurstNodeSelector: "a=b=c"}} {
+		if _, err := bad.Placement(); err == nil {
+			if _, err := bad.WorkloadClasses(); err == nil {
+				t.Errorf("expected an error for %+v", bad)
+			}
+		}
+	}
+}
+
+func TestConfig_Proxy(t *testing.T) {
+	os.Unsetenv("IAF_HTTP_PROXY")
+	os.Unsetenv("IAF_HTTPS_PROXY")
+	t.Setenv("IAF_CLUSTER_CIDRS", "10.42.0.0/16,10.43.0.0/16")
+	t.Setenv("IAF_BASE_DOMAIN", "apps.corp.example")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := cfg.Proxy(); err != nil || !p.IsZero() || p.NoProxy != nil {
+		t.Errorf("expected no proxy by default, got %+v, %v", p, err)
+	}
+
+	t.Setenv("IAF_HTTPS_PROXY", "http://proxy.corp.example:3128")
+	t.Setenv("IAF_NO_PROXY", "ldap.corp.example, ")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	p, err := cfg.Proxy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.HTTPSProxy != "http://proxy.corp.example:3128" || p.HTTPProxy != "" {
+		t.Errorf("unexpected proxy URLs %+v", p)
+	}
+	if want := []string{"10.42.0.0/16", "10.43.0.0/16", ".apps.corp.example", "ldap.corp.example"}; !slices.Equal(p.NoProxy, want) {
+		t.Errorf("NoProxy = %v, want %v", p.NoProxy, want)
+	}
+
+	t.Setenv("IAF_CLUSTER_CIDRS", "10.42.0.0")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.Proxy(); err == nil {
+		t.Error("expected an invalid CIDR to be rejected")
+	}
+
+	t.Setenv("IAF_CLUSTER_CIDRS", "")
+	t.Setenv("IAF_HTTP_PROXY", "proxy.corp.example:3128")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.Proxy(); err == nil || !strings.Contains(err.Error(), "IAF_HTTP_PROXY") {
+		t.Errorf("expected a proxy without a scheme to be rejected, got %v", err)
+	}
+}
+
+func TestConfig_GitHubApp(t *testing.T) {
+	os.Unsetenv("IAF_OFFLINE")
+	os.Unsetenv("IAF_GITHUB_TOKEN")
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	t.Setenv("IAF_GITHUB_ORG", "acme")
+	t.Setenv("IAF_GITHUB_APP_ID", "7")
+	t.Setenv("IAF_GITHUB_APP_INSTALLATION_ID", "42")
+	t.Setenv("IAF_GITHUB_APP_PRIVATE_KEY", string(keyPEM))
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.GitHubEnabled() {
+		t.Error("expected GitHub enabled with a GitHub App and org")
+	}
+	if _, err := cfg.GitHubClient(); err != nil {
+		t.Errorf("expected a GitHub App client, got %v", err)
+	}
+
+	for name, env := range map[string][2]string{
+		"token too":          {"IAF_GITHUB_TOKEN", "ghp_x"},
+		"no installation ID": {"IAF_GITHUB_APP_INSTALLATION_ID", "0"},
+		"bad key":            {"IAF_GITHUB_APP_PRIVATE_KEY", "not a key"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(env[0], env[1])
+			cfg, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := cfg.GitHubClient(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestConfig_GitKnownHosts(t *testing.T) {
+	os.Unsetenv("IAF_GIT_KNOWN_HOSTS_CONFIGMAP")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := cfg.GitKnownHosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (iafk8s.KnownHosts{Namespace: "iaf-system", Name: "iaf-git-known-hosts"}); k != want {
+		t.Errorf("GitKnownHosts = %+v, want %+v", k, want)
+	}
+
+	for _, ref := range []string{"known-hosts", "iaf-system/", "Bad_NS/known-hosts"} {
+		t.Setenv("IAF_GIT_KNOWN_HOSTS_CONFIGMAP", ref)
+		if cfg, err = Load(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cfg.GitKnownHosts(); err == nil {
+			t.Errorf("expected %q to be rejected", ref)
+		}
+	}
+}
+
+func TestConfig_CABundle(t *testing.T) {
+	os.Unsetenv("IAF_CA_BUNDLE_CONFIGMAP")
+	os.Unsetenv("IAF_CA_BUNDLE_KEY")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := cfg.CABundle(); err != nil || !b.IsZero() {
+		t.Errorf("expected no CA bundle by default, got %+v, %v", b, err)
+	}
+
+	t.Setenv("IAF_CA_BUNDLE_CONFIGMAP", "iaf-system/corp-ca")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := cfg.CABundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (iafk8s.CABundle{Namespace: "iaf-system", Name: "corp-ca", Key: "ca.crt"}); b != want {
+		t.Errorf("CABundle = %+v, want %+v", b, want)
+	}
+
+	for _, ref := range []string{"corp-ca", "iaf-system/", "Bad_NS/corp-ca"} {
+		t.Setenv("IAF_CA_BUNDLE_CONFIGMAP", ref)
+		if cfg, err = Load(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cfg.CABundle(); err == nil {
+			t.Errorf("expected %q to be rejected", ref)
+		}
+	}
+}
+
+func TestConfig_WildcardTLS(t *testing.T) {
+	os.Unsetenv("IAF_TLS_MODE")
+	t.Setenv("IAF_BASE_DOMAIN", "apps.corp.example")
+	t.Setenv("IAF_TLS_ISSUER", "letsencrypt-dns")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, err := cfg.WildcardTLS(); err != nil || !w.IsZero() {
+		t.Errorf("expected per-app certificates by default, got %+v, %v", w, err)
+	}
+
+	t.Setenv("IAF_TLS_MODE", "wildcard")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if w, err := cfg.WildcardTLS(); err != nil || w.Namespace != "iaf-system" {
+		t.Errorf("expected wildcard mode in iaf-system, got %+v, %v", w, err)
+	}
+
+	for env, value := range map[string]string{"IAF_TLS_ISSUER": "", "IAF_BASE_DOMAIN": "localhost", "IAF_TLS_MODE": "acme"} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv(env, value)
+			cfg, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := cfg.WildcardTLS(); err == nil {
+				t.Errorf("expected %s=%q to be rejected", env, value)
+			}
+		})
+	}
+}
+
+func TestConfig_Postgres(t *testing.T) {
+	os.Unsetenv("IAF_POSTGRES_IMAGE")
+	t.Setenv("IAF_POSTGRES_VERSIONS", "16.6, 17.2")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pg, err := cfg.Postgres()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pg.Default != "17" || pg.Tags["16"] != "16.6" || pg.ImageFor("17.2") != "ghcr.io/cloudnative-pg/postgresql:17.2" {
+		t.Errorf("unexpected versions %+v", pg)
+	}
+
+	for _, versions := range []string{",", "latest", "16.4,16.6", "16.4@sha256:abc"} {
+		t.Setenv("IAF_POSTGRES_VERSIONS", versions)
+		if cfg, err = Load(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cfg.Postgres(); err == nil {
+			t.Errorf("expected IAF_POSTGRES_VERSIONS=%q to be rejected", versions)
+		}
+	}
+}
+
+func TestConfig_Features(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.TLSIssuer = "letsencrypt"
+	cfg.PrometheusURL = "http://prometheus:9090"
+	cfg.GrafanaURL, cfg.GrafanaLokiUID = "https://grafana.example.com", "loki"
+	cfg.GitHubToken, cfg.GitHubOrg, cfg.Offline = "token", "acme", true
+
+	flags := cfg.Features()
+	for name, want := range map[features.Name]bool{
+		features.TLS:             true,
+		features.Costs:           true,
+		features.Idling:          false,
+		features.Logs:            true,
+		features.Traces:          true,
+		features.Dashboards:      false,
+		features.GitHub:          false,
+		features.ManagedServices: true,
+		features.CustomDNS:       false,
+	} {
+		if flags.Enabled(name) != want {
+			t.Errorf("%s: enabled = %v, want %v", name, flags.Enabled(name), want)
+		}
+	}
+}
+
+func TestLoad_ToolLists(t *testing.T) {
+	t.Setenv("IAF_DISABLED_TOOLS", "delete_app,unregister")
+	os.Unsetenv("IAF_ENABLED_TOOLS")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(cfg.DisabledTools, []string{"delete_app", "unregister"}) || len(cfg.EnabledTools) != 0 {
+		t.Errorf("unexpected tool lists: enabled %v, disabled %v", cfg.EnabledTools, cfg.DisabledTools)
+	}
+}
+
+// TestConfig_Shard verifies controller shards parse their namespace
+// selector and that a named shard must select a subset of namespaces.
+func TestConfig_Shard(t *testing.T) {
+	cfg := &Config{}
+	if s, err := cfg.Shard(); s != nil || err != nil {
+		t.Errorf("expected no shard by default, got %v, %v", s, err)
+	}
+	cfg.ShardSelector = "iaf.io/shard=b"
+	cfg.ShardName = "b"
+	s, err := cfg.Shard()
+	if err != nil || s == nil || !s.Matches(labels.Set{"iaf.io/shard": "b"}) || s.Matches(labels.Set{}) {
+		t.Errorf("Shard = %v, %v", s, err)
+	}
+	cfg.ShardSelector = "!iaf.io/shard"
+	cfg.ShardName = ""
+	if s, err := cfg.Shard(); err != nil || !s.Matches(labels.Set{}) {
+		t.Errorf("expected the default shard to take unlabeled namespaces, got %v, %v", s, err)
+	}
+	for _, bad := range []Config{
+		{ShardSelector: "iaf.io/shard in (b"},
+		{ShardName: "b"},
+		{ShardSelector: "iaf.io/shard=b", ShardName: "Shard_B"},
+	} {
+		if _, err := bad.Shard(); err == nil {
+			t.Errorf("expected selector %q, name %q to be rejected", bad.ShardSelector, bad.ShardName)
+		}
+	}
+}
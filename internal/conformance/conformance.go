@@ -0,0 +1,398 @@
+// Package conformance verifies deployed applications against the
+// organisation standards. Once an app that asks for it runs a new image, the
+// Verifier probes its health and /metrics endpoints on one of its pods,
+// reads that pod's logs and looks for its spans in the trace backend, and
+// records the outcome in status.conformance.
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Check names.
+const (
+	CheckHealth  = "health"
+	CheckMetrics = "metrics"
+	CheckLogs    = "logs"
+	CheckTraces  = "traces"
+)
+
+// DefaultSettle is how long after a verification starts the Verifier keeps
+// waiting for logs and spans before failing those checks.
+const DefaultSettle = 2 * time.Minute
+
+const (
+	// logLines is how many of the most recent log lines are read.
+	logLines = 100
+	// jsonLogShare is the share of log lines that must be JSON objects;
+	// the rest allows for runtime banners written before logging starts.
+	jsonLogShare = 0.8
+	// probeTimeout bounds each HTTP request to the app.
+	probeTimeout = 5 * time.Second
+	// maxMetricsBytes bounds how much of /metrics is read.
+	maxMetricsBytes = 4 << 20
+)
+
+// requiredMetrics are the metrics the organisation metrics standard asks
+// every HTTP app to expose.
+var requiredMetrics = []string{"http_requests_total", "http_request_duration_seconds"}
+
+// LogReader reads the most recent lines a container of a pod wrote.
+type LogReader interface {
+	Logs(ctx context.Context, pod *corev1.Pod, container string, lines int64) (string, error)
+}
+
+// SpanFinder reports whether an application exported spans since a time.
+type SpanFinder interface {
+	HasSpans(ctx context.Context, namespace, name string, since time.Time) (bool, error)
+}
+
+// Verifier verifies running applications with spec.verifyConformance.
+type Verifier struct {
+	client client.Client
+	logs   LogReader
+	// spans is nil when the platform has no trace backend; the traces
+	// check is then skipped.
+	spans SpanFinder
+	// standards returns the organisation standards, for the health-check
+	// path and logging format.
+	standards func() *orgstandards.OrgStandards
+	// Settle is how long to wait for logs and spans. Zero uses
+	// DefaultSettle.
+	Settle time.Duration
+	// APIReader lists pods straight from the API server, so the manager
+	// does not cache every pod in the cluster. Nil reads through the client.
+	APIReader client.Reader
+	http      *http.Client
+	logger    *slog.Logger
+	now       func() time.Time
+}
+
+// New creates a Verifier. spans may be nil.
+func New(c client.Client, logs LogReader, spans SpanFinder, standards func() *orgstandards.OrgStandards, logger *slog.Logger) *Verifier {
+	return &Verifier{
+		client:    c,
+		logs:      logs,
+		spans:     spans,
+		standards: standards,
+		// Pods are reached directly, never through the outbound proxy.
+		http:   &http.Client{Timeout: probeTimeout, Transport: &http.Transport{Proxy: nil}},
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// RunOnce verifies every application whose running image has no final
+// report yet, and clears the reports of apps that no longer ask for one.
+func (v *Verifier) RunOnce(ctx context.Context) {
+	var apps iafv1alpha1.ApplicationList
+	if err := v.client.List(ctx, &apps); err != nil {
+		v.logger.Error("conformance: listing applications", "error", err)
+		return
+	}
+	for i := range apps.Items {
+		app := &apps.Items[i]
+		if !app.Spec.VerifyConformance {
+			if app.Status.Conformance != nil {
+				v.patch(ctx, app, nil)
+			}
+			continue
+		}
+		if !v.due(app) {
+			continue
+		}
+		report, err := v.Verify(ctx, app)
+		if err != nil {
+			v.logger.Warn("conformance: verifying app", "namespace", app.Namespace, "app", app.Name, "error", err)
+			continue
+		}
+		if report != nil {
+			v.patch(ctx, app, report)
+		}
+	}
+}
+
+// due reports whether app is a Running HTTP app whose latest rollout
+// completed and whose image has no final report yet.
+func (v *Verifier) due(app *iafv1alpha1.Application) bool {
+	if app.Spec.Suspended || app.Annotations[iafv1alpha1.IdleAnnotation] != "" ||
+		app.Status.Phase != iafv1alpha1.ApplicationPhaseRunning || app.Status.LatestImage == "" ||
+		iafv1alpha1.AppProtocol(app) == iafv1alpha1.ProtocolTCP ||
+		app.Status.Rollout == nil || app.Status.Rollout.State != iafv1alpha1.RolloutComplete {
+		return false
+	}
+	report := app.Status.Conformance
+	return report == nil || report.Image != app.Status.LatestImage || report.Result == iafv1alpha1.ConformancePending
+}
+
+// Verify runs the checks against one running pod of app and returns the
+// updated report, or nil when the app has no running pod to check yet.
+func (v *Verifier) Verify(ctx context.Context, app *iafv1alpha1.Application) (*iafv1alpha1.ConformanceReport, error) {
+	var reader client.Reader = v.client
+	if v.APIReader != nil {
+		reader = v.APIReader
+	}
+	var pods corev1.PodList
+	if err := reader.List(ctx, &pods, client.InNamespace(app.Namespace), client.MatchingLabels{"iaf.io/application": app.Name}); err != nil {
+		return nil, fmt.Errorf("listing pods: %w", err)
+	}
+	var running []corev1.Pod
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning && pod.Status.PodIP != "" && pod.DeletionTimestamp == nil {
+			running = append(running, pod)
+		}
+	}
+	pod := iafk8s.SelectMostRecentPod(running)
+	if pod == nil {
+		return nil, nil
+	}
+
+	now := v.now()
+	report := &iafv1alpha1.ConformanceReport{Image: app.Status.LatestImage, StartedAt: metav1.NewTime(now)}
+	if prev := app.Status.Conformance; prev != nil && prev.Image == app.Status.LatestImage {
+		report.StartedAt = prev.StartedAt
+	}
+	report.CheckedAt = metav1.NewTime(now)
+	settle := v.Settle
+	if settle == 0 {
+		settle = DefaultSettle
+	}
+	settled := now.Sub(report.StartedAt.Time) >= settle
+
+	standards := v.standards()
+	healthPath := standards.HealthCheckPath
+	if healthPath == "" {
+		healthPath = "/health"
+	}
+	report.Checks = []iafv1alpha1.ConformanceCheck{
+		v.checkHealth(ctx, app, pod, healthPath),
+		v.checkMetrics(ctx, app, pod),
+		v.checkLogs(ctx, pod, standards.LoggingFormat, settled),
+		v.checkTraces(ctx, app, report.StartedAt.Time, settled),
+	}
+
+	// A failure found early is final only once the pending checks have had
+	// their chance, so the final report lists every problem.
+	results := map[iafv1alpha1.ConformanceResult]bool{}
+	for _, check := range report.Checks {
+		results[check.Result] = true
+	}
+	switch {
+	case results[iafv1alpha1.ConformancePending]:
+		report.Result = iafv1alpha1.ConformancePending
+	case results[iafv1alpha1.ConformanceFailed]:
+		report.Result = iafv1alpha1.ConformanceFailed
+	default:
+		report.Result = iafv1alpha1.ConformancePassed
+	}
+	return report, nil
+}
+
+// probeURL returns the URL of urlPath on the app's port of pod, or "" when
+// the app serves TLS or gRPC, which are not probed.
+func probeURL(app *iafv1alpha1.Application, pod *corev1.Pod, urlPath string) string {
+	if iafv1alpha1.IsBackendTLSEnabled(app) || iafv1alpha1.AppProtocol(app) == iafv1alpha1.ProtocolGRPC {
+		return ""
+	}
+	port := app.Spec.Port
+	if port == 0 {
+		port = 8080
+	}
+	return "http://" + net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(int(port))) + urlPath
+}
+
+func (v *Verifier) checkHealth(ctx context.Context, app *iafv1alpha1.Application, pod *corev1.Pod, healthPath string) iafv1alpha1.ConformanceCheck {
+	url := probeURL(app, pod, healthPath)
+	if url == "" {
+		return skipped(CheckHealth, "apps serving TLS or gRPC are not probed")
+	}
+	status, _, err := v.get(ctx, url)
+	switch {
+	case err != nil:
+		return failed(CheckHealth, fmt.Sprintf("GET %s: %v", healthPath, err))
+	case status < 200 || status > 299:
+		return failed(CheckHealth, fmt.Sprintf("GET %s returned %d, want 2xx", healthPath, status))
+	}
+	return passed(CheckHealth, fmt.Sprintf("GET %s returned %d", healthPath, status))
+}
+
+func (v *Verifier) checkMetrics(ctx context.Context, app *iafv1alpha1.Application, pod *corev1.Pod) iafv1alpha1.ConformanceCheck {
+	url := probeURL(app, pod, "/metrics")
+	if url == "" {
+		return skipped(CheckMetrics, "apps serving TLS or gRPC are not probed")
+	}
+	status, body, err := v.get(ctx, url)
+	switch {
+	case err != nil:
+		return failed(CheckMetrics, fmt.Sprintf("GET /metrics: %v", err))
+	case status < 200 || status > 299:
+		return failed(CheckMetrics, fmt.Sprintf("GET /metrics returned %d, want 2xx", status))
+	}
+	parser := expfmt.NewTextParser(model.UTF8Validation)
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(body))
+	if err != nil {
+		return failed(CheckMetrics, fmt.Sprintf("/metrics is not in the Prometheus text format: %v", err))
+	}
+	var missing []string
+	for _, name := range requiredMetrics {
+		if families[name] == nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return failed(CheckMetrics, fmt.Sprintf("/metrics lacks %s from iaf://org/metrics-standards", strings.Join(missing, " and ")))
+	}
+	return passed(CheckMetrics, fmt.Sprintf("/metrics serves %d metric families in the Prometheus text format", len(families)))
+}
+
+func (v *Verifier) checkLogs(ctx context.Context, pod *corev1.Pod, format string, settled bool) iafv1alpha1.ConformanceCheck {
+	if format != "" && format != "json" {
+		return skipped(CheckLogs, fmt.Sprintf("the organisation logging format is %s", format))
+	}
+	out, err := v.logs.Logs(ctx, pod, "app", logLines)
+	if err != nil {
+		return failed(CheckLogs, fmt.Sprintf("reading logs of pod %s: %v", pod.Name, err))
+	}
+	var total, structured int
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		total++
+		var fields map[string]any
+		if json.Unmarshal([]byte(line), &fields) == nil {
+			structured++
+		}
+	}
+	switch {
+	case total == 0 && !settled:
+		return pending(CheckLogs, "waiting for the app to write logs")
+	case total == 0:
+		return failed(CheckLogs, "the app wrote no logs to stdout")
+	case float64(structured) < jsonLogShare*float64(total):
+		return failed(CheckLogs, fmt.Sprintf("%d of the last %d log lines are JSON objects; log JSON to stdout (iaf://org/logging-standards)", structured, total))
+	}
+	return passed(CheckLogs, fmt.Sprintf("%d of the last %d log lines are JSON objects", structured, total))
+}
+
+func (v *Verifier) checkTraces(ctx context.Context, app *iafv1alpha1.Application, since time.Time, settled bool) iafv1alpha1.ConformanceCheck {
+	if v.spans == nil {
+		return skipped(CheckTraces, "the platform has no trace backend configured")
+	}
+	found, err := v.spans.HasSpans(ctx, app.Namespace, app.Name, since)
+	switch {
+	case err != nil:
+		return failed(CheckTraces, fmt.Sprintf("searching spans: %v", err))
+	case found:
+		return passed(CheckTraces, "the trace backend received spans from the app")
+	case !settled:
+		return pending(CheckTraces, "waiting for spans from the app")
+	}
+	return failed(CheckTraces, fmt.Sprintf("no spans with service.name %q since the deploy; export them with OTLP to OTEL_EXPORTER_OTLP_ENDPOINT (iaf://org/tracing-standards)", app.Name))
+}
+
+// get requests url and returns the status and up to maxMetricsBytes of the
+// body.
+func (v *Verifier) get(ctx context.Context, url string) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	resp, err := v.http.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxMetricsBytes))
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, body, nil
+}
+
+// patch writes report, or removes it when nil, to app's status.
+func (v *Verifier) patch(ctx context.Context, app *iafv1alpha1.Application, report *iafv1alpha1.ConformanceReport) {
+	patch := client.MergeFrom(app.DeepCopy())
+	app.Status.Conformance = report
+	if err := v.client.Status().Patch(ctx, app, patch); err != nil {
+		v.logger.Error("conformance: updating status", "namespace", app.Namespace, "app", app.Name, "error", err)
+		return
+	}
+	if report != nil && report.Result != iafv1alpha1.ConformancePending {
+		v.logger.Info("conformance: app verified", "namespace", app.Namespace, "app", app.Name, "image", report.Image, "result", report.Result)
+	}
+}
+
+// Start runs RunOnce every interval until ctx is cancelled. A zero interval
+// disables the verifier.
+func (v *Verifier) Start(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return nil
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			v.RunOnce(ctx)
+		}
+	}
+}
+
+func passed(name, message string) iafv1alpha1.ConformanceCheck {
+	return iafv1alpha1.ConformanceCheck{Name: name, Result: iafv1alpha1.ConformancePassed, Message: message}
+}
+
+func failed(name, message string) iafv1alpha1.ConformanceCheck {
+	return iafv1alpha1.ConformanceCheck{Name: name, Result: iafv1alpha1.ConformanceFailed, Message: message}
+}
+
+func pending(name, message string) iafv1alpha1.ConformanceCheck {
+	return iafv1alpha1.ConformanceCheck{Name: name, Result: iafv1alpha1.ConformancePending, Message: message}
+}
+
+func skipped(name, message string) iafv1alpha1.ConformanceCheck {
+	return iafv1alpha1.ConformanceCheck{Name: name, Result: iafv1alpha1.ConformanceSkipped, Message: message}
+}
+
+// PodLogs is a LogReader backed by the Kubernetes API.
+type PodLogs struct {
+	Clientset kubernetes.Interface
+}
+
+// Logs returns the last lines of container in pod.
+func (p PodLogs) Logs(ctx context.Context, pod *corev1.Pod, container string, lines int64) (string, error) {
+	stream, err := p.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container: container,
+		TailLines: &lines,
+	}).Stream(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+	data, err := io.ReadAll(stream)
+	return string(data), err
+}
@@ -0,0 +1,219 @@
+package conformance_test
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/conformance"
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeLogs returns canned logs keyed by pod name.
+type fakeLogs map[string]string
+
+func (f fakeLogs) Logs(_ context.Context, pod *corev1.Pod, _ string, _ int64) (string, error) {
+	return f[pod.Name], nil
+}
+
+// fakeSpans reports spans for the app names it holds.
+type fakeSpans map[string]bool
+
+func (f fakeSpans) HasSpans(_ context.Context, _, name string, _ time.Time) (bool, error) {
+	return f[name], nil
+}
+
+const goodMetrics = `# TYPE http_requests_total counter
+http_requests_total{method="GET",path="/",status_code="200"} 3
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{method="GET",path="/",le="+Inf"} 3
+http_request_duration_seconds_sum{method="GET",path="/"} 0.01
+http_request_duration_seconds_count{method="GET",path="/"} 3
+`
+
+// serve starts an app answering the health path and /metrics and returns
+// its port.
+func serve(t *testing.T, healthStatus int, metrics string) int32 {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(healthStatus) })
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(metrics)) })
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return int32(p)
+}
+
+func runningApp(name string, port int32) (*iafv1alpha1.Application, *corev1.Pod) {
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "iaf-test"},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "registry/" + name + ":v1", Port: port, VerifyConformance: true},
+		Status: iafv1alpha1.ApplicationStatus{
+			Phase:       iafv1alpha1.ApplicationPhaseRunning,
+			LatestImage: "registry/" + name + ":v1",
+			Rollout:     &iafv1alpha1.RolloutStatus{State: iafv1alpha1.RolloutComplete},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name + "-abc-def", Namespace: "iaf-test", Labels: map[string]string{"iaf.io/application": name}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "127.0.0.1"},
+	}
+	return app, pod
+}
+
+func report(t *testing.T, c client.Client, name string) *iafv1alpha1.ConformanceReport {
+	t.Helper()
+	var app iafv1alpha1.Application
+	if err := c.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "iaf-test"}, &app); err != nil {
+		t.Fatal(err)
+	}
+	return app.Status.Conformance
+}
+
+func results(r *iafv1alpha1.ConformanceReport) map[string]iafv1alpha1.ConformanceResult {
+	m := map[string]iafv1alpha1.ConformanceResult{}
+	for _, c := range r.Checks {
+		m[c.Name] = c.Result
+	}
+	return m
+}
+
+func TestVerifier_RunOnce(t *testing.T) {
+	ctx := context.Background()
+	good, goodPod := runningApp("good", serve(t, http.StatusOK, goodMetrics))
+	bad, badPod := runningApp("bad", serve(t, http.StatusNotFound, "http_requests_total 1\n"))
+	quiet, quietPod := runningApp("quiet", serve(t, http.StatusOK, goodMetrics))
+	optedOut, optedOutPod := runningApp("opted-out", 1)
+	optedOut.Spec.VerifyConformance = false
+	optedOut.Status.Conformance = &iafv1alpha1.ConformanceReport{Image: "old", Result: iafv1alpha1.ConformanceFailed}
+	building, _ := runningApp("building", 1)
+	building.Status.Phase = iafv1alpha1.ApplicationPhaseBuilding
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithStatusSubresource(&iafv1alpha1.Application{}).
+		WithObjects(good, goodPod, bad, badPod, quiet, quietPod, optedOut, optedOutPod, building).Build()
+
+	logs := fakeLogs{
+		goodPod.Name: "Starting app\n{\"level\":\"info\",\"msg\":\"listening\"}\n{\"level\":\"info\",\"msg\":\"GET /health\"}\n{\"level\":\"info\",\"msg\":\"GET /metrics\"}\n{\"level\":\"info\",\"msg\":\"ready\"}\n",
+		badPod.Name:  "listening on 8080\nGET /health 404\n",
+	}
+	standards := func() *orgstandards.OrgStandards {
+		return &orgstandards.OrgStandards{HealthCheckPath: "/health", LoggingFormat: "json"}
+	}
+	v := conformance.New(k8sClient, logs, fakeSpans{"good": true}, standards, slog.Default())
+	v.Settle = time.Hour
+	v.RunOnce(ctx)
+
+	if r := report(t, k8sClient, "good"); r == nil || r.Result != iafv1alpha1.ConformancePassed || r.Image != good.Status.LatestImage {
+		t.Errorf("expected good to pass, got %+v", r)
+	}
+	// The endpoint failures are known, but logs and spans may still come.
+	r := report(t, k8sClient, "bad")
+	if r == nil || r.Result != iafv1alpha1.ConformancePending {
+		t.Fatalf("expected bad to be pending, got %+v", r)
+	}
+	for check, want := range map[string]iafv1alpha1.ConformanceResult{
+		conformance.CheckHealth: iafv1alpha1.ConformanceFailed, conformance.CheckMetrics: iafv1alpha1.ConformanceFailed,
+		conformance.CheckLogs: iafv1alpha1.ConformanceFailed, conformance.CheckTraces: iafv1alpha1.ConformancePending,
+	} {
+		if got := results(r)[check]; got != want {
+			t.Errorf("bad %s: got %s, want %s", check, got, want)
+		}
+	}
+	if r := report(t, k8sClient, "quiet"); r == nil || results(r)[conformance.CheckLogs] != iafv1alpha1.ConformancePending {
+		t.Errorf("expected quiet to wait for logs, got %+v", r)
+	}
+	if r := report(t, k8sClient, "opted-out"); r != nil {
+		t.Errorf("expected the report of opted-out to be removed, got %+v", r)
+	}
+	if r := report(t, k8sClient, "building"); r != nil {
+		t.Errorf("expected building not to be verified, got %+v", r)
+	}
+
+	// Once the settle time has passed, missing output fails the check.
+	started := report(t, k8sClient, "bad").StartedAt
+	v.Settle = time.Nanosecond
+	v.RunOnce(ctx)
+	r = report(t, k8sClient, "bad")
+	if r.Result != iafv1alpha1.ConformanceFailed || results(r)[conformance.CheckTraces] != iafv1alpha1.ConformanceFailed {
+		t.Errorf("expected bad to fail, got %+v", r)
+	}
+	if !r.StartedAt.Equal(&started) {
+		t.Errorf("expected the verification to keep its start time %v, got %v", started, r.StartedAt)
+	}
+	if r := report(t, k8sClient, "quiet"); r.Result != iafv1alpha1.ConformanceFailed || results(r)[conformance.CheckLogs] != iafv1alpha1.ConformanceFailed {
+		t.Errorf("expected quiet to fail without logs, got %+v", r)
+	}
+
+	// A final report stands until a new image is deployed.
+	checked := report(t, k8sClient, "good").CheckedAt
+	v.RunOnce(ctx)
+	if r := report(t, k8sClient, "good"); !r.CheckedAt.Equal(&checked) {
+		t.Error("expected a final report not to be checked again")
+	}
+}
+
+// TestVerifier_Skips verifies checks that do not apply are skipped.
+func TestVerifier_Skips(t *testing.T) {
+	app, pod := runningApp("grpc", 1)
+	app.Spec.Protocol = iafv1alpha1.ProtocolGRPC
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pod).Build()
+
+	standards := func() *orgstandards.OrgStandards { return &orgstandards.OrgStandards{LoggingFormat: "text"} }
+	r, err := conformance.New(k8sClient, fakeLogs{}, nil, standards, slog.Default()).Verify(context.Background(), app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for check, result := range results(r) {
+		if result != iafv1alpha1.ConformanceSkipped {
+			t.Errorf("%s: got %s, want Skipped", check, result)
+		}
+	}
+	if r.Result != iafv1alpha1.ConformancePassed {
+		t.Errorf("expected a report with only skipped checks to pass, got %s", r.Result)
+	}
+}
+
+func TestTempo_HasSpans(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("q")
+		if r.URL.Path != "/api/search" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("start") == "" {
+			w.Write([]byte(`{"traces": []}`))
+			return
+		}
+		w.Write([]byte(`{"traces": [{"traceID": "abc"}]}`))
+	}))
+	defer srv.Close()
+
+	found, err := conformance.NewTempo(srv.URL+"/").HasSpans(context.Background(), "iaf-test", "web", time.Now().Add(-time.Minute))
+	if err != nil || !found {
+		t.Fatalf("expected spans, got %v, %v", found, err)
+	}
+	if want := `{resource.service.name="web" && resource.k8s.namespace.name="iaf-test"}`; query != want {
+		t.Errorf("query = %s, want %s", query, want)
+	}
+}
@@ -0,0 +1,53 @@
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tempo is a SpanFinder backed by the search API of a Grafana Tempo server.
+type Tempo struct {
+	url  string
+	http *http.Client
+}
+
+// NewTempo returns a SpanFinder for the Tempo server at baseURL.
+func NewTempo(baseURL string) *Tempo {
+	return &Tempo{url: strings.TrimSuffix(baseURL, "/"), http: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// HasSpans searches for a trace with a span of the app since the given time.
+// The platform sets OTEL_SERVICE_NAME to the app name; the namespace keeps
+// same-named apps of other sessions out of the results.
+func (t *Tempo) HasSpans(ctx context.Context, namespace, name string, since time.Time) (bool, error) {
+	params := url.Values{}
+	params.Set("q", fmt.Sprintf(`{resource.service.name=%q && resource.k8s.namespace.name=%q}`, name, namespace))
+	params.Set("start", strconv.FormatInt(since.Unix(), 10))
+	params.Set("end", strconv.FormatInt(time.Now().Unix(), 10))
+	params.Set("limit", "1")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.url+"/api/search?"+params.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := t.http.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("querying tempo: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("querying tempo: status %d", resp.StatusCode)
+	}
+	var result struct {
+		Traces []json.RawMessage `json:"traces"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("decoding tempo response: %w", err)
+	}
+	return len(result.Traces) > 0, nil
+}
@@ -3,39 +3,73 @@ package controller
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/audit"
+	"github.com/dlapiduz/iaf/internal/idle"
 	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+	"github.com/dlapiduz/iaf/internal/registry"
+	"github.com/dlapiduz/iaf/internal/sbom"
 	iafvalidation "github.com/dlapiduz/iaf/internal/validation"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 // +kubebuilder:rbac:groups=iaf.io,resources=applications,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=iaf.io,resources=applications/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=iaf.io,resources=applications/finalizers,verbs=update
 // +kubebuilder:rbac:groups=iaf.io,resources=datasources,verbs=get;list;watch
+// +kubebuilder:rbac:groups=iaf.io,resources=orgstandards,verbs=get;list;watch
+// +kubebuilder:rbac:groups=iaf.io,resources=platformpolicies,verbs=get;list;watch
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups="",resources=secrets,verbs=create;get;list;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=create;get;list;watch;update;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=create;get;update;patch
-// +kubebuilder:rbac:groups="",resources=namespaces,verbs=create;get
+// +kubebuilder:rbac:groups="",resources=serviceaccounts/token,verbs=create
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles,verbs=create;get;update
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=create
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=create;delete;get;list;update;watch
 // +kubebuilder:rbac:groups="",resources=pods,verbs=get;list
 // +kubebuilder:rbac:groups="",resources=pods/log,verbs=get
+// +kubebuilder:rbac:groups="",resources=events,verbs=list
 // +kubebuilder:rbac:groups=kpack.io,resources=images,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=kpack.io,resources=builds,verbs=get;list;watch
+// +kubebuilder:rbac:groups=kpack.io,resources=clusterbuilders,verbs=get
 // +kubebuilder:rbac:groups=traefik.io,resources=ingressroutes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=traefik.io,resources=ingressroutetcps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=traefik.io,resources=middlewares,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=traefik.io,resources=tlsstores,verbs=get;create;update
+// +kubebuilder:rbac:groups=traefik.io,resources=serverstransports,verbs=get;create;update;delete
 // +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cert-manager.io,resources=clusterissuers,verbs=get
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheusrules,verbs=create;get;list;update;delete
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=probes,verbs=create;get;update;delete
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=create;get
 
 // managedServicePGEnvVars maps CNPG Secret keys to PG* environment variable names
 // injected when a ManagedService is bound to an Application.
@@ -53,75 +87,352 @@ type ApplicationReconciler struct {
 	client.Client
 	Scheme         *runtime.Scheme
 	ClusterBuilder string
+	// Builders are the ClusterBuilders apps may select with spec.builder.
+	// Apps that leave it empty build with ClusterBuilder.
+	Builders       []string
+	// ArchitectureBuilders maps each CPU architecture apps may select with
+	// spec.architecture to the ClusterBuilder that builds for it. Apps asking
+	// for an architecture missing from it fail.
+	ArchitectureBuilders map[string]string
+	// Placement confines every app's pods to the operator's nodes, and
+	// WorkloadClasses holds the placement each spec.workloadClass adds to it.
+	// Apps asking for a class missing from WorkloadClasses fail, except
+	// standard, which adds nothing.
+	Placement       iafk8s.Placement
+	WorkloadClasses map[string]iafk8s.Placement
 	RegistryPrefix string
-	BaseDomain     string
+	// BuildCache is the platform default build cache for apps that do not
+	// set spec.buildCache. The zero value builds without a cache.
+	BuildCache iafk8s.BuildCache
+	BaseDomain string
 	// TLSIssuer is the name of the ClusterIssuer used to provision TLS certificates.
 	// Defaults to "selfsigned-issuer". Set to "" to disable certificate reconciliation
 	// (e.g., when cert-manager is not installed).
 	TLSIssuer string
+	// WildcardTLS, when set, serves every app under BaseDomain with one
+	// certificate for *.BaseDomain issued by TLSIssuer, instead of one
+	// Certificate per app. Apps on other hosts keep their own Certificate.
+	WildcardTLS iafk8s.WildcardTLS
+	// BackendTLSIssuer is the CA ClusterIssuer of the serving certificates
+	// of apps with spec.backendTLS, which the ingress connects to over TLS.
+	// When empty, such apps fail rather than receive plaintext traffic.
+	BackendTLSIssuer string
+	// OAuthProxyImage is the oauth2-proxy image used for apps with
+	// authentication set to oauth-proxy.
+	OAuthProxyImage string
+	// OIDCIssuerURL, OIDCClientID and OIDCClientSecret identify the platform
+	// identity provider used by oauth-proxy sidecars. oauth-proxy apps fail
+	// closed (phase Failed) when OIDCIssuerURL is empty.
+	OIDCIssuerURL    string
+	OIDCClientID     string
+	OIDCClientSecret string
+	// RequeueBase and RequeueMax bound the exponential backoff used while an
+	// app waits for a build or for replicas. Zero uses 5s and 5m.
+	RequeueBase time.Duration
+	RequeueMax  time.Duration
+	// SuspendedPage is the Service that renders the page shown on the route
+	// of a suspended app. The zero value leaves Traefik's plain 503.
+	SuspendedPage iafk8s.ServiceRef
+	// WakeSecret signs the wake paths on sleeping apps' routes. Requests to a
+	// sleeping app wake it only when both WakeSecret and SuspendedPage are set.
+	WakeSecret []byte
+	// DeletedRetention is how long an app marked deleted by delete_app is
+	// kept, scaled to zero, before it is removed. Zero removes it at once.
+	DeletedRetention time.Duration
+	// Shard limits the reconciler to the namespaces of one controller
+	// deployment. The zero value reconciles every namespace.
+	Shard Shard
+	// SecurityAudit records the Secrets apps' pods start and stop using and
+	// rotated managed service credentials. Nil records nothing.
+	SecurityAudit *audit.SecurityLog
+	// BlackboxExporter is the host:port of the blackbox exporter that uptime
+	// check Probes use, and BlackboxModule the module they request. Apps
+	// asking for uptime checks report them unavailable when it is empty.
+	BlackboxExporter string
+	BlackboxModule   string
+	// ProbeLabels are added to Probes so the platform Prometheus selects them.
+	ProbeLabels map[string]string
+	// MaxBuilds and MaxBuildsPerNamespace limit how many kpack builds the
+	// controller starts at once, cluster-wide and per namespace. Apps over
+	// the limit wait in a queue with build status Queued. Zero is unlimited.
+	MaxBuilds             int
+	MaxBuildsPerNamespace int
+	// ImageResolver resolves the tags of pre-built images to digests so apps
+	// deploy an immutable image. Nil deploys tags as given.
+	ImageResolver registry.Resolver
+	// Proxy is the outbound HTTP proxy set in the build env and pod env of
+	// apps that do not opt out. The zero value sets nothing.
+	Proxy iafk8s.Proxy
+	// CABundle is the platform CA bundle mounted in the pods of apps that do
+	// not opt out, with SSL_CERT_FILE pointing at it. The zero value mounts
+	// nothing.
+	CABundle iafk8s.CABundle
+	// APIReader reads pods and events straight from the API server while a
+	// rollout is in progress, so the manager does not cache every pod in the
+	// cluster. Nil reads through the client.
+	APIReader client.Reader
+	// Standards returns the organisation standards whose allowed runtime
+	// versions builds are pinned to. Nil pins nothing.
+	Standards func() *orgstandards.OrgStandards
+	// SBOM reads the packages in built images, which are checked against
+	// the dependency policy of Standards before they deploy. Nil skips the
+	// check.
+	SBOM sbom.Reader
+
+	backoff     requeueBackoff
+	sboms       sbomCache
+	dataSources dataSourceCache
 }
 
 // Reconcile is the main reconciliation loop for Application CRs.
 func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	if !r.Shard.contains(ctx, r.Client, req.Namespace) {
+		return ctrl.Result{}, nil
+	}
 	var app iafv1alpha1.Application
 	if err := r.Get(ctx, req.NamespacedName, &app); err != nil {
 		if apierrors.IsNotFound(err) {
+			r.backoff.forget(req.NamespacedName)
+			r.sboms.forget(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, fmt.Errorf("getting application: %w", err)
 	}
 
+	// The passes below only change app.Status; it is written once, here,
+	// also when a pass fails part way.
+	read := app.DeepCopy()
+	result, err := r.reconcile(ctx, &app)
+	if statusErr := patchStatus(ctx, r.Client, read, &app); statusErr != nil {
+		return ctrl.Result{}, errors.Join(err, fmt.Errorf("updating application status: %w", statusErr))
+	}
+	return result, err
+}
+
+// reconcile converges app and records the outcome in its status.
+func (r *ApplicationReconciler) reconcile(ctx context.Context, app *iafv1alpha1.Application) (ctrl.Result, error) {
+	key := types.NamespacedName{Name: app.Name, Namespace: app.Namespace}
+	if _, deleted := app.Annotations[iafv1alpha1.DeletedAtAnnotation]; deleted {
+		return r.reconcileDeleted(ctx, app)
+	}
+	app.Status.PurgeAt = nil
+
+	expiresAt, hasTTL := expiry(app, app.Spec.TTL)
+	if !hasTTL {
+		app.Status.ExpiresAt = nil
+		meta.RemoveStatusCondition(&app.Status.Conditions, conditionExpiring)
+		return r.reconcileApp(ctx, app)
+	}
+	now := time.Now()
+	var state expiryState
+	switch {
+	case now.Before(expiresAt):
+		state = newExpiryState(expiresAt, app.Spec.TTL.Duration, now)
+	case app.Spec.DeletionProtected:
+		state = protectedExpiryState(expiresAt)
+	default:
+		// Owner references cascade the deletion to everything the app owns.
+		if err := r.Delete(ctx, app); err != nil && !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("deleting expired application: %w", err)
+		}
+		log.FromContext(ctx).Info("deleted application after its TTL elapsed", "ttl", app.Spec.TTL.Duration)
+		r.backoff.forget(key)
+		return ctrl.Result{}, nil
+	}
+	app.Status.ExpiresAt = &metav1.Time{Time: expiresAt}
+	setCondition(app, conditionExpiring, state.status, state.reason, state.message)
+	result, err := r.reconcileApp(ctx, app)
+	if err != nil || state.next == 0 {
+		return result, err
+	}
+	return requeueBy(result, state.next), nil
+}
+
+// reconcileApp converges the resources of an application that has not
+// expired. Every path ends with the status Reconcile writes, next to the
+// Expiring condition set by reconcile.
+func (r *ApplicationReconciler) reconcileApp(ctx context.Context, app *iafv1alpha1.Application) (ctrl.Result, error) {
+	key := types.NamespacedName{Name: app.Name, Namespace: app.Namespace}
+
+	// Only schedule and build for architectures and workload classes the
+	// platform offers.
+	if reason, message := r.unavailablePlacement(app); reason != "" {
+		app.Status.Phase = iafv1alpha1.ApplicationPhaseFailed
+		setCondition(app, "Ready", metav1.ConditionFalse, reason, message)
+		r.backoff.forget(key)
+		return ctrl.Result{}, nil
+	}
+
+	// Never render host aliases or DNS settings that could override
+	// cluster-internal names, however the spec was written.
+	if err := iafk8s.ValidatePodDNS(app.Spec.HostAliases, app.Spec.DNSConfig); err != nil {
+		app.Status.Phase = iafv1alpha1.ApplicationPhaseFailed
+		setCondition(app, "Ready", metav1.ConditionFalse, "InvalidDNSConfig", err.Error())
+		r.backoff.forget(key)
+		return ctrl.Result{}, nil
+	}
+
+	if err := iafk8s.ValidateShutdown(app); err != nil {
+		app.Status.Phase = iafv1alpha1.ApplicationPhaseFailed
+		setCondition(app, "Ready", metav1.ConditionFalse, "InvalidShutdown", err.Error())
+		r.backoff.forget(key)
+		return ctrl.Result{}, nil
+	}
+
 	// Resolve the container image to deploy.
-	image, buildStatus, err := r.resolveImage(ctx, &app)
+	image, buildStatus, err := r.resolveImage(ctx, app)
+	var imageFailure string
+	switch {
+	case errors.Is(err, iafk8s.ErrInvalidRegistryPrefix):
+		imageFailure = "InvalidRegistryPrefix"
+	case errors.Is(err, errImageOutsideRegistry):
+		imageFailure = "ImageOutsideRegistry"
+	case errors.Is(err, errBuilderNotAllowed):
+		imageFailure = "BuilderNotAllowed"
+	case errors.Is(err, errRuntimeNotAllowed):
+		imageFailure = "RuntimeNotAllowed"
+	case errors.Is(err, errDependencyNotAllowed):
+		imageFailure = "DependencyNotAllowed"
+	}
+	if imageFailure != "" {
+		app.Status.Phase = iafv1alpha1.ApplicationPhaseFailed
+		setCondition(app, "Ready", metav1.ConditionFalse, imageFailure, err.Error())
+		r.backoff.forget(key)
+		return ctrl.Result{}, nil
+	}
 	if err != nil {
 		return ctrl.Result{}, err
 	}
+	recordBuildTransition(app.Namespace, app.Status.BuildStatus, buildStatus)
+	if buildStatus != buildStatusQueued {
+		app.Status.BuildQueuedAt = nil
+		app.Status.BuildQueuePosition = 0
+	}
 
 	// If we are still waiting for a build, update build status and requeue.
 	if image == "" {
-		if err := r.setBuildingStatus(ctx, &app, buildStatus); err != nil {
-			return ctrl.Result{}, err
+		setBuildingStatus(app, buildStatus)
+		if buildStatus == buildStatusQueued {
+			return ctrl.Result{RequeueAfter: buildQueueRequeue}, nil
 		}
-		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: r.requeueAfter(app, iafv1alpha1.ApplicationPhaseBuilding)}, nil
 	}
+	image = r.pinImage(ctx, app, image)
 
 	// Set Deploying phase before creating/updating the Deployment (if not already past that).
 	if app.Status.Phase == iafv1alpha1.ApplicationPhaseBuilding ||
 		app.Status.Phase == iafv1alpha1.ApplicationPhasePending ||
 		app.Status.Phase == "" {
-		if err := r.setDeployingPhaseOnly(ctx, &app); err != nil {
-			return ctrl.Result{}, err
-		}
+		setDeployingPhaseOnly(app)
 	}
 
 	// TLS requires both the app opting in (default true) AND a TLSIssuer being configured.
 	// When TLSIssuer is empty (cert-manager not installed) the controller degrades gracefully
 	// to HTTP-only mode without crashing.
-	tlsEnabled := iafv1alpha1.IsTLSEnabled(&app) && r.TLSIssuer != ""
+	tlsEnabled := iafv1alpha1.IsTLSEnabled(app) && r.TLSIssuer != ""
+
+	// Never expose an oauth-proxy app without its proxy: fail closed when the
+	// platform identity provider is not configured.
+	if iafv1alpha1.AppAuthentication(app) == iafv1alpha1.AuthenticationOAuthProxy && r.OIDCIssuerURL == "" {
+		app.Status.Phase = iafv1alpha1.ApplicationPhaseFailed
+		setCondition(app, "Ready", metav1.ConditionFalse, "AuthenticationUnavailable",
+			"authentication 'oauth-proxy' is not available: the platform identity provider is not configured; use 'basic' instead")
+		r.backoff.forget(key)
+		return ctrl.Result{}, nil
+	}
+	if err := r.reconcileAuthentication(ctx, app); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.reconcileAccess(ctx, app); err != nil {
+		return ctrl.Result{}, err
+	}
 
-	// Create or update the Deployment, Service, Certificate, and IngressRoute.
-	dep, err := r.reconcileDeployment(ctx, &app, image)
+	// Apply the Deployment and Service, then create or update the
+	// Certificate and IngressRoute.
+	var drifted []string
+	dep, depDrifted, err := r.reconcileDeployment(ctx, app, image)
+	if err == nil {
+		var svcDrifted bool
+		svcDrifted, err = r.reconcileService(ctx, app)
+		if depDrifted {
+			drifted = append(drifted, fmt.Sprintf("Deployment %q", app.Name))
+		}
+		if svcDrifted {
+			drifted = append(drifted, fmt.Sprintf("Service %q", app.Name))
+		}
+	}
+	var deployFailure string
+	switch {
+	case errors.Is(err, errInvalidOverrides):
+		deployFailure = "InvalidOverrides"
+	case errors.Is(err, errConfigFileUnavailable):
+		deployFailure = "ConfigFileUnavailable"
+	case errors.Is(err, errCABundleUnavailable):
+		deployFailure = "CABundleUnavailable"
+	case errors.Is(err, errBackendTLSUnavailable):
+		deployFailure = "BackendTLSUnavailable"
+	}
+	if deployFailure != "" {
+		app.Status.Phase = iafv1alpha1.ApplicationPhaseFailed
+		setCondition(app, "Ready", metav1.ConditionFalse, deployFailure, err.Error())
+		r.backoff.forget(key)
+		return ctrl.Result{}, nil
+	}
 	if err != nil {
 		return ctrl.Result{}, err
 	}
-	if err := r.reconcileService(ctx, &app); err != nil {
+	recordDrift(app, drifted)
+	cert, err := r.reconcileCertificate(ctx, app, tlsEnabled)
+	if err != nil {
 		return ctrl.Result{}, err
 	}
-	if err := r.reconcileCertificate(ctx, &app, tlsEnabled); err != nil {
+	certNext := recordCertificateStatus(app, cert, r.TLSIssuer, time.Now())
+	if err := r.reconcileIngressRoute(ctx, app, tlsEnabled); err != nil {
 		return ctrl.Result{}, err
 	}
-	if err := r.reconcileIngressRoute(ctx, &app, tlsEnabled); err != nil {
+	if err := r.reconcileUptimeCheck(ctx, app, tlsEnabled); err != nil {
 		return ctrl.Result{}, err
 	}
 
 	// Update status based on current Deployment availability.
-	return r.reconcileStatus(ctx, &app, image, buildStatus, dep, tlsEnabled)
+	result, err := r.reconcileStatus(ctx, app, image, buildStatus, dep, tlsEnabled)
+	if err != nil {
+		return result, err
+	}
+	// Check the certificate again when it is due to expire soon.
+	if certNext > 0 {
+		result = requeueBy(result, certNext)
+	}
+	if buildStatus == buildStatusQueued {
+		result = requeueBy(result, buildQueueRequeue)
+	}
+	return result, nil
+}
+
+// unavailablePlacement returns the condition reason and message for an app
+// asking for an architecture or workload class the platform does not offer,
+// or empty strings when it may run.
+func (r *ApplicationReconciler) unavailablePlacement(app *iafv1alpha1.Application) (reason, message string) {
+	if arch := string(app.Spec.Architecture); arch != "" {
+		if _, ok := r.ArchitectureBuilders[arch]; !ok {
+			return "ArchitectureUnavailable", fmt.Sprintf("architecture %q is not available on this platform", arch)
+		}
+	}
+	if class := app.Spec.WorkloadClass; class != "" && class != iafv1alpha1.WorkloadClassStandard {
+		if _, ok := r.WorkloadClasses[string(class)]; !ok {
+			return "WorkloadClassUnavailable", fmt.Sprintf("workload class %q is not available on this platform", class)
+		}
+	}
+	return "", ""
 }
 
 // resolveImage returns the container image to deploy.
 // For pre-built images, it returns immediately. For kpack builds, it reads
 // the kpack Image CR status. Returns ("", ...) while the build is in progress.
 func (r *ApplicationReconciler) resolveImage(ctx context.Context, app *iafv1alpha1.Application) (image, buildStatus string, err error) {
+	if app.Spec.Git == nil {
+		app.Status.Git = nil
+	}
 	if app.Spec.Image != "" {
 		return app.Spec.Image, "NotRequired", nil
 	}
@@ -130,8 +441,42 @@ func (r *ApplicationReconciler) resolveImage(ctx context.Context, app *iafv1alph
 		return "", "Unknown", fmt.Errorf("application %q has no image, git, or blob source", app.Name)
 	}
 
+	// Builds push under the namespace's registry prefix, which operators
+	// may override per namespace.
+	registryPrefix, err := iafk8s.ResolveRegistryPrefix(ctx, r, app.Namespace, r.RegistryPrefix)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Only build with a ClusterBuilder the operator allows. An explicit
+	// builder takes precedence over the architecture's builder.
+	builder := r.ClusterBuilder
+	if app.Spec.Architecture != "" {
+		builder = r.ArchitectureBuilders[string(app.Spec.Architecture)]
+	}
+	if app.Spec.Builder != "" {
+		if err := iafvalidation.ValidateBuilder(app.Spec.Builder, r.Builders); err != nil {
+			return "", "", fmt.Errorf("%w: %v", errBuilderNotAllowed, err)
+		}
+		builder = app.Spec.Builder
+	}
+
+	// Pin every language's runtime to a version the org allows, unless
+	// the app's build env already selects an allowed one.
+	buildApp := app
+	if r.Standards != nil {
+		runtimeEnv, err := r.Standards().RuntimeBuildEnv(app.Spec.BuildEnv)
+		if err != nil {
+			return "", "", fmt.Errorf("%w: %v", errRuntimeNotAllowed, err)
+		}
+		if len(runtimeEnv) > 0 {
+			buildApp = app.DeepCopy()
+			buildApp.Spec.BuildEnv = append(buildApp.Spec.BuildEnv, runtimeEnv...)
+		}
+	}
+
 	// Ensure kpack Image CR exists.
-	kpackImage := iafk8s.BuildKpackImage(app, r.ClusterBuilder, r.RegistryPrefix)
+	kpackImage := iafk8s.BuildKpackImage(buildApp, builder, registryPrefix, iafk8s.ResolveBuildCache(app, r.BuildCache), r.Proxy)
 	existing := &unstructured.Unstructured{}
 	existing.SetGroupVersionKind(iafk8s.KpackImageGVK)
 	err = r.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, existing)
@@ -139,50 +484,125 @@ func (r *ApplicationReconciler) resolveImage(ctx context.Context, app *iafv1alph
 		if !apierrors.IsNotFound(err) {
 			return "", "", fmt.Errorf("getting kpack image: %w", err)
 		}
+		admitted, position, err := r.admitBuild(ctx, app)
+		if err != nil {
+			return "", "", err
+		}
+		if !admitted {
+			queueBuild(app, position)
+			return "", buildStatusQueued, nil
+		}
 		if err := r.Create(ctx, kpackImage); err != nil && !apierrors.IsAlreadyExists(err) {
 			return "", "", fmt.Errorf("creating kpack image: %w", err)
 		}
+		if app.Spec.Git != nil {
+			if app.Status.Git, err = r.gitStatus(ctx, app, nil, true); err != nil {
+				return "", "", err
+			}
+		}
 		return "", "Building", nil
 	}
 
-	// Update source URL if the blob changed (re-push).
+	// kpack does not allow changing an Image's tag or shrinking its cache
+	// volume: when the registry prefix changed or the cache got smaller,
+	// delete the Image and rebuild.
 	existingSpec, _ := existing.Object["spec"].(map[string]any)
 	newSpec := kpackImage.Object["spec"].(map[string]any)
+	existingCache, _ := existingSpec["cache"].(map[string]any)
+	newCache, _ := newSpec["cache"].(map[string]any)
+	if existingSpec["tag"] != newSpec["tag"] || iafk8s.KpackCacheShrinks(existingCache, newCache) {
+		if existing.GetDeletionTimestamp() == nil {
+			if err := r.Delete(ctx, existing); err != nil && !apierrors.IsNotFound(err) {
+				return "", "", fmt.Errorf("deleting kpack image: %w", err)
+			}
+		}
+		return "", "Building", nil
+	}
+
+	// Update source URL if the blob changed (re-push), the builder or build
+	// env if they changed, and the cache if it was added or grown. The update
+	// starts a new build, so it waits for a slot; meanwhile the app keeps
+	// running its last image. Pinning a git source to the commit the app
+	// already runs builds nothing and needs no slot.
 	existingSource, _ := existingSpec["source"].(map[string]any)
 	newSource, _ := newSpec["source"].(map[string]any)
-	if fmt.Sprintf("%v", existingSource) != fmt.Sprintf("%v", newSource) {
-		existing.Object["spec"] = newSpec
+	sourceChanged := fmt.Sprintf("%v", existingSource) != fmt.Sprintf("%v", newSource)
+	configChanged := fmt.Sprintf("%v", existingSpec["builder"]) != fmt.Sprintf("%v", newSpec["builder"]) ||
+		fmt.Sprintf("%v", existingSpec["build"]) != fmt.Sprintf("%v", newSpec["build"]) ||
+		fmt.Sprintf("%v", existingCache) != fmt.Sprintf("%v", newCache)
+	queued, updated := false, false
+	relabeled := iafk8s.CopyOwnershipLabels(existing, kpackImage)
+	if sourceChanged || configChanged {
+		admitted, position := true, 0
+		if configChanged || !pinsDeployedCommit(app, existingSource, newSource) {
+			if admitted, position, err = r.admitBuild(ctx, app); err != nil {
+				return "", "", err
+			}
+		}
+		if admitted {
+			existing.Object["spec"] = newSpec
+			if err := r.Update(ctx, existing); err != nil {
+				return "", "", fmt.Errorf("updating kpack image: %w", err)
+			}
+			updated = true
+		} else {
+			queueBuild(app, position)
+			queued = true
+		}
+	}
+	// Relabelling builds nothing, so it needs no slot.
+	if relabeled && !updated {
 		if err := r.Update(ctx, existing); err != nil {
 			return "", "", fmt.Errorf("updating kpack image: %w", err)
 		}
 	}
+	if app.Spec.Git != nil {
+		if app.Status.Git, err = r.gitStatus(ctx, app, existing, updated || queued); err != nil {
+			return "", "", err
+		}
+	}
 
 	buildSt, latestImage := iafk8s.GetKpackImageStatus(existing)
+	if queued {
+		buildSt = buildStatusQueued
+	}
 	if latestImage == "" {
 		return "", buildSt, nil
 	}
+	// Never deploy an image kpack reports outside the allowed prefix.
+	if !iafk8s.ImageUnderPrefix(latestImage, registryPrefix) {
+		return "", "", fmt.Errorf("%w: built image %q is not under %q", errImageOutsideRegistry, latestImage, registryPrefix)
+	}
+	if err := r.checkDependencies(ctx, app, latestImage); err != nil {
+		return "", "", err
+	}
 	return latestImage, buildSt, nil
 }
 
 // setBuildingStatus updates the Application status to Building phase.
-func (r *ApplicationReconciler) setBuildingStatus(ctx context.Context, app *iafv1alpha1.Application, buildStatus string) error {
+func setBuildingStatus(app *iafv1alpha1.Application, buildStatus string) {
 	app.Status.Phase = iafv1alpha1.ApplicationPhaseBuilding
 	app.Status.BuildStatus = buildStatus
-	setCondition(app, "Ready", metav1.ConditionFalse, "Building", "Waiting for container image build to complete")
-	return r.Status().Update(ctx, app)
+	if buildStatus == buildStatusQueued {
+		setCondition(app, "Ready", metav1.ConditionFalse, "BuildQueued",
+			fmt.Sprintf("Waiting for a build slot: position %d in the build queue", app.Status.BuildQueuePosition))
+	} else {
+		setCondition(app, "Ready", metav1.ConditionFalse, "Building", "Waiting for container image build to complete")
+	}
 }
 
 // setDeployingPhaseOnly sets phase to Deploying without touching AvailableReplicas.
-// Called once before reconcileDeployment to give agents an accurate intermediate state.
-func (r *ApplicationReconciler) setDeployingPhaseOnly(ctx context.Context, app *iafv1alpha1.Application) error {
+// Called once before reconcileDeployment, so agents see Deploying even when
+// applying the Deployment fails.
+func setDeployingPhaseOnly(app *iafv1alpha1.Application) {
 	app.Status.Phase = iafv1alpha1.ApplicationPhaseDeploying
 	setCondition(app, "Ready", metav1.ConditionFalse, "Deploying", "Waiting for pod replicas to become available")
-	return r.Status().Update(ctx, app)
 }
 
-// reconcileDeployment creates or updates the Deployment for the application.
-// Returns the current Deployment object (with up-to-date status).
-func (r *ApplicationReconciler) reconcileDeployment(ctx context.Context, app *iafv1alpha1.Application, image string) (*appsv1.Deployment, error) {
+// reconcileDeployment server-side applies the Deployment for the application.
+// Returns the current Deployment object (with up-to-date status) and whether
+// external changes to managed fields were reverted.
+func (r *ApplicationReconciler) reconcileDeployment(ctx context.Context, app *iafv1alpha1.Application, image string) (*appsv1.Deployment, bool, error) {
 	port := app.Spec.Port
 	if port == 0 {
 		port = 8080
@@ -191,25 +611,31 @@ func (r *ApplicationReconciler) reconcileDeployment(ctx context.Context, app *ia
 	if replicas == 0 {
 		replicas = 1
 	}
+	if app.Spec.Suspended || isAsleep(app) {
+		replicas = 0
+	}
 
 	envVars := make([]corev1.EnvVar, 0, len(app.Spec.Env))
 	for _, e := range app.Spec.Env {
 		envVars = append(envVars, corev1.EnvVar{Name: e.Name, Value: e.Value})
 	}
+	envVars = append(envVars, iafk8s.ProxyEnv(app, r.Proxy, app.Spec.Env)...)
 
 	// Inject env vars from attached data sources.
 	logger := log.FromContext(ctx)
 	for _, ads := range app.Spec.AttachedDataSources {
-		var ds iafv1alpha1.DataSource
-		if err := r.Get(ctx, types.NamespacedName{Name: ads.DataSourceName}, &ds); err != nil {
-			if apierrors.IsNotFound(err) {
-				// DataSource may have been deleted after attachment — skip gracefully.
-				logger.V(1).Info("DataSource not found, skipping env injection", "datasource", ads.DataSourceName)
-				continue
-			}
-			return nil, fmt.Errorf("getting datasource %q: %w", ads.DataSourceName, err)
+		envMapping, found, err := r.dataSources.envMapping(ctx, r.Client, ads.DataSourceName)
+		if err != nil {
+			return nil, false, err
+		}
+		if !found {
+			// DataSource may have been deleted after attachment — skip gracefully.
+			logger.V(1).Info("DataSource not found, skipping env injection", "datasource", ads.DataSourceName)
+			continue
 		}
-		for secretKey, envVarName := range ds.Spec.EnvVarMapping {
+		// Iterate in key order so the applied spec is stable across passes.
+		for _, secretKey := range slices.Sorted(maps.Keys(envMapping)) {
+			envVarName := envMapping[secretKey]
 			if err := iafvalidation.ValidateEnvVarName(envVarName); err != nil {
 				// Defence-in-depth: skip invalid env var names added by misconfigured operators.
 				logger.V(1).Info("invalid env var name in DataSource mapping, skipping",
@@ -230,27 +656,67 @@ func (r *ApplicationReconciler) reconcileDeployment(ctx context.Context, app *ia
 
 	// Inject env vars from bound managed services (postgres: CNPG secret keys → PG* env vars).
 	for _, bms := range app.Spec.BoundManagedServices {
-		for secretKey, envVarName := range managedServicePGEnvVars {
-			envVars = append(envVars, corev1.EnvVar{
-				Name: envVarName,
-				ValueFrom: &corev1.EnvVarSource{
-					SecretKeyRef: &corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{Name: bms.SecretName},
-						Key:                 secretKey,
-					},
-				},
-			})
+		if iafv1alpha1.BindingProjectsEnv(bms) {
+			envVars = append(envVars, managedServiceEnv(bms)...)
+			envVars = append(envVars, connectionFormatEnv(app, bms)...)
 		}
 	}
+	// Derive the connection string variants they reference.
+	if err := r.reconcileConnectionFormats(ctx, app); err != nil {
+		return nil, false, err
+	}
+
+	// Hash the connection Secrets of the bound managed services.
+	managedServicesHash, err := r.managedServicesHash(ctx, app)
+	if err != nil {
+		return nil, false, err
+	}
 
+	// Load every variable of the bound environment groups.
+	envFrom, envGroupsHash, err := r.envGroupSources(ctx, app)
+	if err != nil {
+		return nil, false, err
+	}
+	// Mount the config files at their paths.
+	volumes, volumeMounts, configFilesHash, err := r.reconcileConfigFiles(ctx, app)
+	if err != nil {
+		return nil, false, err
+	}
+	// Mount the binding directories of managed services projected as files.
+	bindingVolumes, bindingMounts, bindingEnv, err := r.reconcileServiceBindings(ctx, app)
+	if err != nil {
+		return nil, false, err
+	}
+	volumes = append(volumes, bindingVolumes...)
+	volumeMounts = append(volumeMounts, bindingMounts...)
+	envVars = append(envVars, bindingEnv...)
+	// Trust the platform CA bundle for outbound TLS.
+	caVolume, caMount, caBundleHash, err := r.reconcileCABundle(ctx, app)
+	if err != nil {
+		return nil, false, err
+	}
+	if caVolume != nil {
+		volumes = append(volumes, *caVolume)
+		volumeMounts = append(volumeMounts, *caMount)
+		envVars = append(envVars, iafk8s.CABundleEnv(app.Spec.Env)...)
+	}
+	// Serve TLS to the ingress with the app's serving certificate.
+	backendTLSVolume, backendTLSMount, backendTLSHash, err := r.reconcileBackendTLS(ctx, app)
+	if err != nil {
+		return nil, false, err
+	}
+	if backendTLSVolume != nil {
+		volumes = append(volumes, *backendTLSVolume)
+		volumeMounts = append(volumeMounts, *backendTLSMount)
+		envVars = append(envVars, iafk8s.BackendTLSEnv()...)
+	}
+
+	command, args := iafk8s.ContainerCommand(app)
 	desired := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      app.Name,
 			Namespace: app.Namespace,
-			Labels: map[string]string{
-				"app.kubernetes.io/managed-by": "iaf",
-				"iaf.io/application":          app.Name,
-			},
+			Labels:    iafk8s.AppLabels(app),
 			OwnerReferences: []metav1.OwnerReference{
 				{
 					APIVersion: iafv1alpha1.GroupVersion.String(),
@@ -268,7 +734,7 @@ func (r *ApplicationReconciler) reconcileDeployment(ctx context.Context, app *ia
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{"iaf.io/application": app.Name},
+					Labels: iafk8s.PodLabels(app),
 				},
 				Spec: corev1.PodSpec{
 					SecurityContext: &corev1.PodSecurityContext{
@@ -276,12 +742,16 @@ func (r *ApplicationReconciler) reconcileDeployment(ctx context.Context, app *ia
 					},
 					Containers: []corev1.Container{
 						{
-							Name:  "app",
-							Image: image,
+							Name:    "app",
+							Image:   image,
+							Command: command,
+							Args:    args,
 							Ports: []corev1.ContainerPort{
 								{ContainerPort: port, Protocol: corev1.ProtocolTCP},
 							},
-							Env: envVars,
+							Env:          envVars,
+							EnvFrom:      envFrom,
+							VolumeMounts: volumeMounts,
 							SecurityContext: &corev1.SecurityContext{
 								AllowPrivilegeEscalation: boolPtr(false),
 							},
@@ -292,42 +762,102 @@ func (r *ApplicationReconciler) reconcileDeployment(ctx context.Context, app *ia
 		},
 	}
 
-	existing := &appsv1.Deployment{}
-	err := r.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, existing)
-	if err != nil {
-		if !apierrors.IsNotFound(err) {
-			return nil, fmt.Errorf("getting deployment: %w", err)
+	// Place pods on the operator's nodes for the app's workload class, and
+	// on nodes of its architecture.
+	placement := r.Placement.Merge(r.WorkloadClasses[string(app.Spec.WorkloadClass)])
+	archSelector, archAffinity := iafk8s.ArchitectureScheduling(app.Spec.Architecture)
+	placement = placement.Merge(iafk8s.Placement{NodeSelector: archSelector})
+	desired.Spec.Template.Spec.NodeSelector = placement.NodeSelector
+	desired.Spec.Template.Spec.Tolerations = placement.Tolerations
+	desired.Spec.Template.Spec.Affinity = archAffinity
+
+	desired.Spec.Template.Spec.Volumes = volumes
+	desired.Spec.Template.Spec.HostAliases, desired.Spec.Template.Spec.DNSConfig = iafk8s.PodDNS(app)
+
+	// Roll the pods when the credentials of a bound managed service, a bound
+	// environment group, a config file, the CA bundle or the serving
+	// certificate change.
+	if managedServicesHash != "" || envGroupsHash != "" || configFilesHash != "" || caBundleHash != "" || backendTLSHash != "" {
+		annotations := map[string]string{}
+		if managedServicesHash != "" {
+			annotations[managedServicesHashAnnotation] = managedServicesHash
 		}
-		if err := r.Create(ctx, desired); err != nil {
-			return nil, fmt.Errorf("creating deployment: %w", err)
+		if envGroupsHash != "" {
+			annotations[iafk8s.AnnotationEnvGroupsHash] = envGroupsHash
 		}
-		// Return the just-created Deployment (status will be empty, so available=0).
-		return desired, nil
+		if configFilesHash != "" {
+			annotations[configFilesHashAnnotation] = configFilesHash
+		}
+		if caBundleHash != "" {
+			annotations[caBundleHashAnnotation] = caBundleHash
+		}
+		if backendTLSHash != "" {
+			annotations[backendTLSHashAnnotation] = backendTLSHash
+		}
+		desired.Spec.Template.Annotations = annotations
 	}
 
-	// Update the existing Deployment spec.
-	existing.Spec = desired.Spec
-	if err := r.Update(ctx, existing); err != nil {
-		return nil, fmt.Errorf("updating deployment: %w", err)
+	if app.Spec.RegistryCredential != "" {
+		desired.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: app.Spec.RegistryCredential}}
 	}
-	return existing, nil
+
+	if iafv1alpha1.AppAuthentication(app) == iafv1alpha1.AuthenticationOAuthProxy {
+		podSpec := &desired.Spec.Template.Spec
+		podSpec.Containers = append(podSpec.Containers,
+			iafk8s.BuildOAuthProxyContainer(app, r.OAuthProxyImage, r.OIDCIssuerURL, r.OIDCClientID, port))
+	}
+
+	// Drain connections on shutdown: every container keeps serving for the
+	// pre-stop delay while the pod is removed from the Service endpoints,
+	// then gets the rest of the grace period to finish after SIGTERM.
+	grace, preStop := iafk8s.ShutdownTiming(app)
+	desired.Spec.Template.Spec.TerminationGracePeriodSeconds = &grace
+	for i := range desired.Spec.Template.Spec.Containers {
+		desired.Spec.Template.Spec.Containers[i].Lifecycle = iafk8s.PreStopLifecycle(preStop)
+	}
+
+	if err := overrideDeployment(app, desired); err != nil {
+		return nil, false, err
+	}
+
+	before, err := r.liveDeployment(ctx, desired)
+	if err != nil {
+		return nil, false, err
+	}
+	applied, drifted, err := r.applyOwned(ctx, desired)
+	if err != nil {
+		return nil, false, err
+	}
+	dep := &appsv1.Deployment{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(applied.Object, dep); err != nil {
+		return nil, false, fmt.Errorf("converting deployment: %w", err)
+	}
+	r.recordSecretUse(app, before, dep)
+	return dep, drifted, nil
 }
 
-// reconcileService creates or updates the Service for the application.
-func (r *ApplicationReconciler) reconcileService(ctx context.Context, app *iafv1alpha1.Application) error {
+// reconcileService server-side applies the Service for the application and
+// reports whether external changes to managed fields were reverted.
+func (r *ApplicationReconciler) reconcileService(ctx context.Context, app *iafv1alpha1.Application) (bool, error) {
 	port := app.Spec.Port
 	if port == 0 {
 		port = 8080
 	}
 
+	servicePort := corev1.ServicePort{Port: port, Protocol: corev1.ProtocolTCP}
+	if iafv1alpha1.AppProtocol(app) == iafv1alpha1.ProtocolGRPC {
+		servicePort.AppProtocol = stringPtr("kubernetes.io/h2c")
+	}
+	if iafv1alpha1.AppAuthentication(app) == iafv1alpha1.AuthenticationOAuthProxy {
+		// Route all traffic through the oauth2-proxy sidecar.
+		servicePort.TargetPort = intstr.FromInt32(iafk8s.OAuthProxyPort)
+	}
+
 	desired := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      app.Name,
 			Namespace: app.Namespace,
-			Labels: map[string]string{
-				"app.kubernetes.io/managed-by": "iaf",
-				"iaf.io/application":          app.Name,
-			},
+			Labels:    iafk8s.AppLabels(app),
 			OwnerReferences: []metav1.OwnerReference{
 				{
 					APIVersion: iafv1alpha1.GroupVersion.String(),
@@ -340,64 +870,261 @@ func (r *ApplicationReconciler) reconcileService(ctx context.Context, app *iafv1
 		},
 		Spec: corev1.ServiceSpec{
 			Selector: map[string]string{"iaf.io/application": app.Name},
-			Ports: []corev1.ServicePort{
-				{Port: port, Protocol: corev1.ProtocolTCP},
-			},
+			Ports:    []corev1.ServicePort{servicePort},
 		},
 	}
 
-	existing := &corev1.Service{}
-	err := r.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, existing)
+	if err := overrideService(app, desired); err != nil {
+		return false, err
+	}
+	_, drifted, err := r.applyOwned(ctx, desired)
+	return drifted, err
+}
+
+// reconcileAuthentication ensures the Secrets and Traefik Middleware required by
+// spec.authentication exist and removes any left over from a previous mode.
+// Generated credentials are never overwritten once created.
+func (r *ApplicationReconciler) reconcileAuthentication(ctx context.Context, app *iafv1alpha1.Application) error {
+	mode := iafv1alpha1.AppAuthentication(app)
+
+	middleware := iafk8s.BuildBasicAuthMiddleware(app)
+	if mode == iafv1alpha1.AuthenticationBasic {
+		password, err := generateSecret(16)
+		if err != nil {
+			return err
+		}
+		if err := r.createIfMissing(ctx, iafk8s.BuildBasicAuthSecret(app, password)); err != nil {
+			return fmt.Errorf("ensuring basic-auth secret: %w", err)
+		}
+		if err := r.createIfMissing(ctx, middleware); err != nil {
+			return fmt.Errorf("ensuring basic-auth middleware: %w", err)
+		}
+	} else {
+		if err := r.deleteIfExists(ctx, middleware); err != nil {
+			return fmt.Errorf("deleting basic-auth middleware: %w", err)
+		}
+		if err := r.deleteOwnedSecret(ctx, app, iafk8s.BasicAuthSecretName(app.Name)); err != nil {
+			return fmt.Errorf("deleting basic-auth secret: %w", err)
+		}
+	}
+
+	if mode == iafv1alpha1.AuthenticationOAuthProxy {
+		cookieSecret, err := generateSecret(16)
+		if err != nil {
+			return err
+		}
+		if err := r.createIfMissing(ctx, iafk8s.BuildOAuthProxySecret(app, r.OIDCClientSecret, cookieSecret)); err != nil {
+			return fmt.Errorf("ensuring oauth-proxy secret: %w", err)
+		}
+	} else if err := r.deleteOwnedSecret(ctx, app, iafk8s.OAuthProxySecretName(app.Name)); err != nil {
+		return fmt.Errorf("deleting oauth-proxy secret: %w", err)
+	}
+	return nil
+}
+
+// reconcileAccess creates, updates, or removes the IP allowlist and rate limit
+// Middlewares that implement spec.access, and the suspended page and wake
+// Middlewares.
+func (r *ApplicationReconciler) reconcileAccess(ctx context.Context, app *iafv1alpha1.Application) error {
+	allowList := iafk8s.BuildIPAllowListMiddleware(app)
+	if iafk8s.HasIPAllowList(app) {
+		if err := r.applyUnstructured(ctx, allowList); err != nil {
+			return fmt.Errorf("applying ip allowlist middleware: %w", err)
+		}
+	} else if err := r.deleteIfExists(ctx, allowList); err != nil {
+		return fmt.Errorf("deleting ip allowlist middleware: %w", err)
+	}
+
+	rateLimit := iafk8s.BuildRateLimitMiddleware(app)
+	if iafk8s.HasRateLimit(app) {
+		if err := r.applyUnstructured(ctx, rateLimit); err != nil {
+			return fmt.Errorf("applying rate limit middleware: %w", err)
+		}
+	} else if err := r.deleteIfExists(ctx, rateLimit); err != nil {
+		return fmt.Errorf("deleting rate limit middleware: %w", err)
+	}
+
+	suspended := iafk8s.BuildSuspendedMiddleware(app, r.SuspendedPage)
+	if r.showSuspendedPage(app) {
+		if err := r.applyUnstructured(ctx, suspended); err != nil {
+			return fmt.Errorf("applying suspended page middleware: %w", err)
+		}
+	} else if err := r.deleteIfExists(ctx, suspended); err != nil {
+		return fmt.Errorf("deleting suspended page middleware: %w", err)
+	}
+
+	wake := iafk8s.BuildWakeMiddleware(app, r.SuspendedPage, idle.WakePath(r.WakeSecret, app.Namespace, app.Name))
+	if r.showWakePage(app) {
+		if err := r.applyUnstructured(ctx, wake); err != nil {
+			return fmt.Errorf("applying wake middleware: %w", err)
+		}
+	} else if err := r.deleteIfExists(ctx, wake); err != nil {
+		return fmt.Errorf("deleting wake middleware: %w", err)
+	}
+	return nil
+}
+
+// showSuspendedPage reports whether the app's HTTP route should serve the
+// platform's suspended page instead of Traefik's bare 503.
+func (r *ApplicationReconciler) showSuspendedPage(app *iafv1alpha1.Application) bool {
+	return app.Spec.Suspended && !r.SuspendedPage.IsZero() &&
+		iafv1alpha1.AppProtocol(app) != iafv1alpha1.ProtocolTCP
+}
+
+// showWakePage reports whether the app's route should wake it on request:
+// it is asleep, or woken but not yet Running.
+func (r *ApplicationReconciler) showWakePage(app *iafv1alpha1.Application) bool {
+	return !app.Spec.Suspended && app.Annotations[iafv1alpha1.IdleAnnotation] != "" &&
+		!r.SuspendedPage.IsZero() && len(r.WakeSecret) > 0 &&
+		iafv1alpha1.AppProtocol(app) != iafv1alpha1.ProtocolTCP
+}
+
+// isAsleep reports whether the idler has put the app to sleep. Suspension
+// takes precedence over idle state.
+func isAsleep(app *iafv1alpha1.Application) bool {
+	return !app.Spec.Suspended && app.Annotations[iafv1alpha1.IdleAnnotation] == iafv1alpha1.IdleSleeping
+}
+
+// applyUnstructured creates desired, or replaces the spec and ownership
+// labels of the existing object.
+func (r *ApplicationReconciler) applyUnstructured(ctx context.Context, desired *unstructured.Unstructured) error {
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(desired.GroupVersionKind())
+	err := r.Get(ctx, types.NamespacedName{Name: desired.GetName(), Namespace: desired.GetNamespace()}, existing)
 	if err != nil {
 		if !apierrors.IsNotFound(err) {
-			return fmt.Errorf("getting service: %w", err)
+			return err
 		}
 		return r.Create(ctx, desired)
 	}
-	existing.Spec.Ports = desired.Spec.Ports
-	existing.Spec.Selector = desired.Spec.Selector
+	if !replaceSpec(existing, desired) {
+		return nil
+	}
 	return r.Update(ctx, existing)
 }
 
-// reconcileCertificate creates or updates the cert-manager Certificate for the application.
-// It is a no-op when TLS is disabled or when TLSIssuer is not configured (cert-manager absent).
-func (r *ApplicationReconciler) reconcileCertificate(ctx context.Context, app *iafv1alpha1.Application, tlsEnabled bool) error {
-	if !tlsEnabled || r.TLSIssuer == "" {
+// replaceSpec sets the spec and ownership labels of existing to those of
+// desired and reports whether that changed anything, so unchanged objects
+// are not written on every pass.
+func replaceSpec(existing, desired *unstructured.Unstructured) bool {
+	changed := !equality.Semantic.DeepEqual(existing.Object["spec"], desired.Object["spec"])
+	existing.Object["spec"] = desired.Object["spec"]
+	labelsChanged := iafk8s.CopyOwnershipLabels(existing, desired)
+	return changed || labelsChanged
+}
+
+// createIfMissing creates obj unless an object with the same name already
+// exists, in which case only its ownership labels are brought up to date.
+func (r *ApplicationReconciler) createIfMissing(ctx context.Context, obj client.Object) error {
+	err := r.Create(ctx, obj)
+	if !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	existing := obj.DeepCopyObject().(client.Object)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(obj), existing); err != nil {
+		return err
+	}
+	if !iafk8s.CopyOwnershipLabels(existing, obj) {
 		return nil
 	}
+	return r.Update(ctx, existing)
+}
+
+// deleteIfExists deletes obj, ignoring objects (or CRDs) that do not exist.
+func (r *ApplicationReconciler) deleteIfExists(ctx context.Context, obj client.Object) error {
+	if err := r.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return err
+	}
+	return nil
+}
+
+// deleteOwnedSecret deletes the named Secret only if the platform generated it
+// for this application, so user-created Secrets with a colliding name survive.
+func (r *ApplicationReconciler) deleteOwnedSecret(ctx context.Context, app *iafv1alpha1.Application, name string) error {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: app.Namespace}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if secret.Labels["iaf.io/application"] != app.Name || secret.Labels["app.kubernetes.io/managed-by"] != "iaf" {
+		return nil
+	}
+	return r.deleteIfExists(ctx, secret)
+}
+
+// reconcileCertificate creates or updates the cert-manager Certificate for the application
+// and returns it. It is a no-op returning nil when TLS is disabled or when TLSIssuer is not
+// configured (cert-manager absent). In wildcard mode, apps under the base domain are served
+// by the shared wildcard Certificate, which is returned instead.
+func (r *ApplicationReconciler) reconcileCertificate(ctx context.Context, app *iafv1alpha1.Application, tlsEnabled bool) (*unstructured.Unstructured, error) {
+	if !tlsEnabled || r.TLSIssuer == "" {
+		return nil, nil
+	}
 
 	host := app.Spec.Host
 	if host == "" {
 		host = fmt.Sprintf("%s.%s", app.Name, r.BaseDomain)
 	}
 
-	desired := iafk8s.BuildCertificate(app, host, r.TLSIssuer)
+	if r.usesWildcardCertificate(app) {
+		if err := r.deleteAppCertificate(ctx, app); err != nil {
+			return nil, err
+		}
+		return r.reconcileWildcardCertificate(ctx)
+	}
+	return r.applyCertificate(ctx, iafk8s.BuildCertificate(app, host, r.TLSIssuer))
+}
 
+// applyCertificate creates desired, or updates the spec of the existing
+// Certificate, and returns the Certificate with its current status.
+func (r *ApplicationReconciler) applyCertificate(ctx context.Context, desired *unstructured.Unstructured) (*unstructured.Unstructured, error) {
 	existing := &unstructured.Unstructured{}
 	existing.SetGroupVersionKind(iafk8s.CertificateGVK)
-	err := r.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, existing)
+	err := r.Get(ctx, types.NamespacedName{Name: desired.GetName(), Namespace: desired.GetNamespace()}, existing)
 	if err != nil {
 		if !apierrors.IsNotFound(err) {
-			return fmt.Errorf("getting certificate: %w", err)
+			return nil, fmt.Errorf("getting certificate: %w", err)
 		}
 		if err := r.Create(ctx, desired); err != nil && !apierrors.IsAlreadyExists(err) {
-			return fmt.Errorf("creating certificate: %w", err)
+			return nil, fmt.Errorf("creating certificate: %w", err)
 		}
-		return nil
+		return desired, nil
+	}
+	if !replaceSpec(existing, desired) {
+		return existing, nil
 	}
-	existing.Object["spec"] = desired.Object["spec"]
 	if err := r.Update(ctx, existing); err != nil {
-		return fmt.Errorf("updating certificate: %w", err)
+		return nil, fmt.Errorf("updating certificate: %w", err)
 	}
-	return nil
+	return existing, nil
 }
 
-// reconcileIngressRoute creates or updates the Traefik IngressRoute for the application.
+// reconcileIngressRoute creates or updates the Traefik route for the application.
+// The route kind follows spec.protocol (IngressRouteTCP for tcp, IngressRoute otherwise);
+// a route of the other kind left over from a protocol change is removed.
 func (r *ApplicationReconciler) reconcileIngressRoute(ctx context.Context, app *iafv1alpha1.Application, tlsEnabled bool) error {
 	desired := iafk8s.BuildIngressRoute(app, r.BaseDomain, tlsEnabled)
+	if tlsEnabled && r.usesWildcardCertificate(app) {
+		iafk8s.UseDefaultCertificate(desired)
+	}
+	if iafv1alpha1.IsBackendTLSEnabled(app) && desired.GroupVersionKind() == iafk8s.TraefikIngressRouteGVK {
+		iafk8s.UseBackendTLS(desired, app)
+	}
+	if r.showSuspendedPage(app) {
+		iafk8s.AddRouteMiddleware(desired, iafk8s.SuspendedMiddlewareName(app.Name))
+	}
+	if r.showWakePage(app) {
+		iafk8s.AddRouteMiddleware(desired, iafk8s.WakeMiddlewareName(app.Name))
+	}
+
+	if err := r.deleteStaleRoute(ctx, app, desired.GroupVersionKind()); err != nil {
+		return err
+	}
 
 	existing := &unstructured.Unstructured{}
-	existing.SetGroupVersionKind(iafk8s.TraefikIngressRouteGVK)
+	existing.SetGroupVersionKind(desired.GroupVersionKind())
 	err := r.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, existing)
 	if err != nil {
 		if !apierrors.IsNotFound(err) {
@@ -405,47 +1132,179 @@ func (r *ApplicationReconciler) reconcileIngressRoute(ctx context.Context, app *
 		}
 		return r.Create(ctx, desired)
 	}
-	existing.Object["spec"] = desired.Object["spec"]
+	if !replaceSpec(existing, desired) {
+		return nil
+	}
 	return r.Update(ctx, existing)
 }
 
-// reconcileStatus reads the current Deployment availability and updates the Application status.
-// It sets phase to Running if at least one replica is available, or Deploying otherwise.
-func (r *ApplicationReconciler) reconcileStatus(ctx context.Context, app *iafv1alpha1.Application, image, buildStatus string, dep *appsv1.Deployment, tlsEnabled bool) (ctrl.Result, error) {
-	available := dep.Status.AvailableReplicas
+// deleteStaleRoute removes the route of the kind not currently in use, if present.
+func (r *ApplicationReconciler) deleteStaleRoute(ctx context.Context, app *iafv1alpha1.Application, current schema.GroupVersionKind) error {
+	stale := iafk8s.TraefikIngressRouteTCPGVK
+	if current == iafk8s.TraefikIngressRouteTCPGVK {
+		stale = iafk8s.TraefikIngressRouteGVK
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(stale)
+	obj.SetName(app.Name)
+	obj.SetNamespace(app.Namespace)
+	if err := r.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return fmt.Errorf("deleting stale %s: %w", stale.Kind, err)
+	}
+	return nil
+}
+
+// reconcileUptimeCheck creates, updates, or removes the Probe implementing
+// spec.uptimeCheck and reports it in the UptimeCheck condition. Suspended
+// apps are not probed.
+func (r *ApplicationReconciler) reconcileUptimeCheck(ctx context.Context, app *iafv1alpha1.Application, tlsEnabled bool) error {
+	url := r.appURL(app, tlsEnabled) + iafk8s.UptimeCheckPath(app)
+	probe := iafk8s.BuildProbe(app, url, r.BlackboxExporter, r.BlackboxModule, r.ProbeLabels)
+
+	switch {
+	case !iafk8s.HasUptimeCheck(app):
+		meta.RemoveStatusCondition(&app.Status.Conditions, "UptimeCheck")
+	case r.BlackboxExporter == "":
+		setCondition(app, "UptimeCheck", metav1.ConditionFalse, "ProberNotConfigured",
+			"uptime checks are not available: the platform blackbox exporter is not configured")
+	case app.Spec.Suspended:
+		setCondition(app, "UptimeCheck", metav1.ConditionFalse, "Suspended", "uptime checks are paused while the application is suspended")
+	default:
+		err := r.applyUnstructured(ctx, probe)
+		if meta.IsNoMatchError(err) {
+			setCondition(app, "UptimeCheck", metav1.ConditionFalse, "ProbeUnavailable",
+				"uptime checks are not available: the Prometheus Operator is not installed")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("applying uptime check probe: %w", err)
+		}
+		setCondition(app, "UptimeCheck", metav1.ConditionTrue, "Probing",
+			fmt.Sprintf("Probing %s every %ds", url, iafk8s.UptimeCheckInterval(app)))
+		return nil
+	}
+
+	if err := r.deleteIfExists(ctx, probe); err != nil {
+		return fmt.Errorf("deleting uptime check probe: %w", err)
+	}
+	return nil
+}
 
+// appURL returns the routable URL of the application.
+func (r *ApplicationReconciler) appURL(app *iafv1alpha1.Application, tlsEnabled bool) string {
 	host := app.Spec.Host
 	if host == "" {
 		host = fmt.Sprintf("%s.%s", app.Name, r.BaseDomain)
 	}
-
+	if iafv1alpha1.AppProtocol(app) == iafv1alpha1.ProtocolTCP {
+		// TCP routes are matched by TLS SNI on the websecure entrypoint.
+		return fmt.Sprintf("tcp://%s:443", host)
+	}
 	scheme := "https"
 	if !tlsEnabled {
 		scheme = "http"
 	}
+	return fmt.Sprintf("%s://%s", scheme, host)
+}
+
+// reconcileStatus reads the current Deployment availability and rollout
+// progress and updates the Application status. It sets phase to Running if
+// at least one replica is available, Failed if none is and the rollout has
+// stalled, or Deploying otherwise.
+func (r *ApplicationReconciler) reconcileStatus(ctx context.Context, app *iafv1alpha1.Application, image, buildStatus string, dep *appsv1.Deployment, tlsEnabled bool) (ctrl.Result, error) {
+	available := dep.Status.AvailableReplicas
 
 	// Always write accurate status fields.
 	app.Status.AvailableReplicas = available
 	app.Status.LatestImage = image
+	recordRevision(app, image)
 	app.Status.BuildStatus = buildStatus
-	app.Status.URL = fmt.Sprintf("%s://%s", scheme, host)
+	app.Status.URL = r.appURL(app, tlsEnabled)
+	rollout := iafk8s.Rollout(dep)
+	app.Status.Rollout = rollout
+	switch rollout.State {
+	case iafv1alpha1.RolloutComplete:
+		setCondition(app, "Rollout", metav1.ConditionTrue, "Complete", rollout.Message)
+	case iafv1alpha1.RolloutStalled:
+		setCondition(app, "Rollout", metav1.ConditionFalse, rollout.Reason, rollout.Message)
+	default:
+		setCondition(app, "Rollout", metav1.ConditionFalse, "Progressing", rollout.Message)
+	}
+
+	// The Deployment does not say why pods stay Pending; their scheduling
+	// conditions and events do.
+	var scheduling *iafv1alpha1.SchedulingStatus
+	if rollout.State != iafv1alpha1.RolloutComplete && !app.Spec.Suspended && !isAsleep(app) {
+		var err error
+		if scheduling, err = r.podScheduling(ctx, app); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	app.Status.Scheduling = scheduling
+	if scheduling != nil {
+		setCondition(app, conditionScheduled, metav1.ConditionFalse, reasonUnschedulable, iafk8s.SchedulingMessage(scheduling))
+	} else {
+		meta.RemoveStatusCondition(&app.Status.Conditions, conditionScheduled)
+	}
+
+	if app.Spec.Suspended {
+		app.Status.Phase = iafv1alpha1.ApplicationPhaseSuspended
+		setCondition(app, "Ready", metav1.ConditionFalse, "Suspended", "Application is suspended and scaled to zero replicas")
+		r.backoff.forget(types.NamespacedName{Name: app.Name, Namespace: app.Namespace})
+		return ctrl.Result{}, nil
+	}
+
+	if isAsleep(app) {
+		app.Status.Phase = iafv1alpha1.ApplicationPhaseSleeping
+		setCondition(app, "Ready", metav1.ConditionFalse, "Idle", "Application is asleep after its idle timeout; the next request wakes it")
+		r.backoff.forget(types.NamespacedName{Name: app.Name, Namespace: app.Namespace})
+		return ctrl.Result{}, nil
+	}
 
 	if available >= 1 {
 		app.Status.Phase = iafv1alpha1.ApplicationPhaseRunning
 		setCondition(app, "Ready", metav1.ConditionTrue, "Available", fmt.Sprintf("%d replica(s) available", available))
-		if err := r.Status().Update(ctx, app); err != nil {
-			return ctrl.Result{}, fmt.Errorf("updating status to Running: %w", err)
+		r.backoff.forget(types.NamespacedName{Name: app.Name, Namespace: app.Namespace})
+		// A woken app is awake once it serves again; drop the wake route.
+		// Patch a copy: the response would replace the status not yet
+		// written.
+		if app.Annotations[iafv1alpha1.IdleAnnotation] == iafv1alpha1.IdleWaking {
+			awake := app.DeepCopy()
+			patch := client.MergeFrom(app)
+			delete(awake.Annotations, iafv1alpha1.IdleAnnotation)
+			if err := r.Patch(ctx, awake, patch); err != nil {
+				return ctrl.Result{}, fmt.Errorf("clearing idle annotation: %w", err)
+			}
 		}
 		return ctrl.Result{}, nil
 	}
 
+	// No replicas available and the rollout gave up: report why instead of
+	// polling. A later Deployment change reconciles the app again.
+	if rollout.State == iafv1alpha1.RolloutStalled {
+		app.Status.Phase = iafv1alpha1.ApplicationPhaseFailed
+		if scheduling != nil {
+			setCondition(app, "Ready", metav1.ConditionFalse, reasonUnschedulable, iafk8s.SchedulingMessage(scheduling))
+		} else {
+			setCondition(app, "Ready", metav1.ConditionFalse, rollout.Reason, rollout.Message)
+		}
+		r.backoff.forget(types.NamespacedName{Name: app.Name, Namespace: app.Namespace})
+		return ctrl.Result{}, nil
+	}
+
 	// No replicas available: stay in (or return to) Deploying.
 	app.Status.Phase = iafv1alpha1.ApplicationPhaseDeploying
-	setCondition(app, "Ready", metav1.ConditionFalse, "Deploying", "Waiting for pod replicas to become available")
-	if err := r.Status().Update(ctx, app); err != nil {
-		return ctrl.Result{}, fmt.Errorf("updating status to Deploying: %w", err)
+	if scheduling != nil {
+		setCondition(app, "Ready", metav1.ConditionFalse, reasonUnschedulable, iafk8s.SchedulingMessage(scheduling))
+	} else {
+		setCondition(app, "Ready", metav1.ConditionFalse, "Deploying", "Waiting for pod replicas to become available")
 	}
-	return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	return ctrl.Result{RequeueAfter: r.requeueAfter(app, iafv1alpha1.ApplicationPhaseDeploying)}, nil
+}
+
+// requeueAfter returns the backoff delay before app is polled again in phase.
+func (r *ApplicationReconciler) requeueAfter(app *iafv1alpha1.Application, phase iafv1alpha1.ApplicationPhase) time.Duration {
+	return r.backoff.next(app, phase, r.RequeueBase, r.RequeueMax)
 }
 
 // SetupWithManager registers the controller with the manager and configures watches.
@@ -453,11 +1312,16 @@ func (r *ApplicationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	// Watch kpack Image CRs so build completion triggers immediate reconciliation.
 	kpackImageType := &unstructured.Unstructured{}
 	kpackImageType.SetGroupVersionKind(iafk8s.KpackImageGVK)
+	// Builds are owned by the Image, not the Application; map them back via
+	// the kpack image label so build progress also arrives as events.
+	kpackBuildType := &unstructured.Unstructured{}
+	kpackBuildType.SetGroupVersionKind(iafk8s.KpackBuildGVK)
 
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&iafv1alpha1.Application{}).
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&iafv1alpha1.Application{}, r.Shard.forOptions(mgr.GetClient())...).
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
+		Owns(&corev1.ConfigMap{}).
 		Watches(
 			kpackImageType,
 			handler.EnqueueRequestForOwner(
@@ -467,18 +1331,65 @@ func (r *ApplicationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 				handler.OnlyControllerOwner(),
 			),
 		).
-		Complete(r)
+		Watches(kpackBuildType, handler.EnqueueRequestsFromMapFunc(mapBuildToApplication)).
+		// Environment groups are shared by several apps in a session;
+		// changing one rolls every app bound to it.
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.mapEnvGroupToApplications),
+			builder.WithPredicates(predicate.NewPredicateFuncs(isEnvGroupSecret)),
+		).
+		// Managed services roll their bound apps when they change or
+		// their connection Secret is created or rotated.
+		Watches(&iafv1alpha1.ManagedService{}, handler.EnqueueRequestsFromMapFunc(r.mapManagedServiceToApplications)).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.mapManagedServiceSecretToApplications),
+			builder.WithPredicates(predicate.NewPredicateFuncs(isManagedServiceSecret)),
+		).
+		// ConfigMaps referenced by config files roll the apps mounting them,
+		// and the platform CA bundle rolls every app trusting it.
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.mapConfigMapToApplications)).
+		// DataSources feed the reconciler's cache of their env var
+		// mappings and roll the apps attached to one that changes.
+		Watches(&iafv1alpha1.DataSource{}, r.dataSourceHandler())
+	// Certificates report issuance and renewal in their status. The
+	// Certificate kind only exists when cert-manager is installed.
+	if r.TLSIssuer != "" || r.BackendTLSIssuer != "" {
+		certificateType := &unstructured.Unstructured{}
+		certificateType.SetGroupVersionKind(iafk8s.CertificateGVK)
+		b = b.Watches(certificateType, handler.EnqueueRequestForOwner(mgr.GetScheme(), mgr.GetRESTMapper(), &iafv1alpha1.Application{}))
+		// The shared wildcard Certificate has no owner; it updates the
+		// status of every app it serves.
+		if !r.WildcardTLS.IsZero() {
+			b = b.Watches(certificateType, handler.EnqueueRequestsFromMapFunc(r.mapWildcardCertificateToApplications))
+		}
+	}
+	b = r.Shard.watchNamespaces(b, listApplications(mgr.GetClient()))
+	return b.Complete(r)
 }
 
-// setCondition upserts a condition on the Application status.
+// mapBuildToApplication enqueues the Application whose kpack Image produced a Build.
+func mapBuildToApplication(_ context.Context, obj client.Object) []reconcile.Request {
+	name := obj.GetLabels()[iafk8s.LabelKpackImage]
+	if name == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: obj.GetNamespace()}}}
+}
+
+// setCondition upserts a condition on the Application status. The
+// transition time only moves when the condition's status does.
 func setCondition(app *iafv1alpha1.Application, condType string, status metav1.ConditionStatus, reason, message string) {
 	now := metav1.Now()
 	for i, c := range app.Status.Conditions {
 		if c.Type == condType {
+			if c.Status != status {
+				app.Status.Conditions[i].LastTransitionTime = now
+			}
 			app.Status.Conditions[i].Status = status
 			app.Status.Conditions[i].Reason = reason
 			app.Status.Conditions[i].Message = message
-			app.Status.Conditions[i].LastTransitionTime = now
 			return
 		}
 	}
@@ -492,3 +1403,14 @@ func setCondition(app *iafv1alpha1.Application, condType string, status metav1.C
 }
 
 func boolPtr(b bool) *bool { return &b }
+
+func stringPtr(s string) *string { return &s }
+
+// generateSecret returns n random bytes, hex-encoded.
+func generateSecret(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -2,24 +2,32 @@ package controller
 
 import (
 	"context"
+	"maps"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/idle"
 	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/orgstandards"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
-func newTestScheme(t *testing.T) *runtime.Scheme {
+func newTestScheme(t testing.TB) *runtime.Scheme {
 	t.Helper()
 	scheme := runtime.NewScheme()
 	if err := clientgoscheme.AddToScheme(scheme); err != nil {
@@ -218,13 +226,149 @@ func TestReconcile_RunningToDeploying(t *testing.T) {
 	if result.Status.Phase != iafv1alpha1.ApplicationPhaseDeploying {
 		t.Errorf("expected Deploying after replicas dropped to 0, got %q", result.Status.Phase)
 	}
-	if res.RequeueAfter != 10*time.Second {
-		t.Errorf("expected RequeueAfter=10s, got %v", res.RequeueAfter)
+	if res.RequeueAfter < defaultRequeueBase || res.RequeueAfter > defaultRequeueBase*11/10 {
+		t.Errorf("expected RequeueAfter≈%v, got %v", defaultRequeueBase, res.RequeueAfter)
+	}
+}
+
+// TestReconcile_RolloutStatus verifies the rollout's replica counts and
+// state are reported, that an app still serving during a wedged rollout
+// stays Running with a Rollout condition saying so, and that a stalled
+// rollout with no available replicas fails the app.
+func TestReconcile_RolloutStatus(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+
+	app := makeApp("myapp", "test-ns")
+	app.Spec.Replicas = 3
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	setDeploymentStatus := func(status appsv1.DeploymentStatus) {
+		t.Helper()
+		var dep appsv1.Deployment
+		if err := r.Get(ctx, key, &dep); err != nil {
+			t.Fatal(err)
+		}
+		status.ObservedGeneration = dep.Generation
+		dep.Status = status
+		if err := r.Status().Update(ctx, &dep); err != nil {
+			t.Fatal(err)
+		}
+		reconcileApp(t, r, "myapp", "test-ns")
+		if err := r.Get(ctx, key, app); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	setDeploymentStatus(appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, ReadyReplicas: 3, AvailableReplicas: 3})
+	rollout := app.Status.Rollout
+	if rollout == nil || rollout.State != iafv1alpha1.RolloutComplete || rollout.DesiredReplicas != 3 || rollout.UpdatedReplicas != 3 {
+		t.Fatalf("expected a complete rollout of 3 replicas, got %+v", rollout)
+	}
+	if c := meta.FindStatusCondition(app.Status.Conditions, "Rollout"); c == nil || c.Status != metav1.ConditionTrue {
+		t.Errorf("expected Rollout=True, got %+v", c)
+	}
+
+	stuck := appsv1.DeploymentCondition{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded", Message: `ReplicaSet "myapp-7d9" has timed out progressing.`}
+	setDeploymentStatus(appsv1.DeploymentStatus{Replicas: 4, UpdatedReplicas: 1, ReadyReplicas: 3, AvailableReplicas: 3, Conditions: []appsv1.DeploymentCondition{stuck}})
+	if app.Status.Phase != iafv1alpha1.ApplicationPhaseRunning {
+		t.Errorf("expected an app still serving to stay Running, got %s", app.Status.Phase)
+	}
+	if app.Status.Rollout.State != iafv1alpha1.RolloutStalled {
+		t.Errorf("expected a stalled rollout, got %+v", app.Status.Rollout)
+	}
+	if c := meta.FindStatusCondition(app.Status.Conditions, "Rollout"); c == nil || c.Status != metav1.ConditionFalse || c.Reason != "ProgressDeadlineExceeded" {
+		t.Errorf("expected Rollout=False with ProgressDeadlineExceeded, got %+v", c)
+	}
+
+	res := func() ctrl.Result {
+		setDeploymentStatus(appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, Conditions: []appsv1.DeploymentCondition{stuck}})
+		return reconcileApp(t, r, "myapp", "test-ns")
+	}()
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	if c := meta.FindStatusCondition(app.Status.Conditions, "Ready"); app.Status.Phase != iafv1alpha1.ApplicationPhaseFailed || c == nil || c.Reason != "ProgressDeadlineExceeded" {
+		t.Errorf("expected the app to fail with ProgressDeadlineExceeded, got %s %+v", app.Status.Phase, c)
+	}
+	if res.RequeueAfter != 0 {
+		t.Errorf("expected no polling once the rollout stalled, got RequeueAfter=%v", res.RequeueAfter)
+	}
+}
+
+// TestReconcile_UnschedulablePods verifies that pods no node can take are
+// reported in the Scheduled condition and the scheduling status, and that the
+// report clears once they are scheduled.
+func TestReconcile_UnschedulablePods(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+
+	app := makeApp("myapp", "test-ns")
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp-7d9-abcde", Namespace: "test-ns", Labels: map[string]string{"iaf.io/application": "myapp"}},
+		Status: corev1.PodStatus{Phase: corev1.PodPending, Conditions: []corev1.PodCondition{{
+			Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable,
+			Message: "0/3 nodes are available: 3 Insufficient memory. preemption: 0/3 nodes are available: 3 No preemption victims found for incoming pod.",
+		}}},
+	}
+	if err := r.Create(ctx, pod); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Create(ctx, &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "scale-up", Namespace: "test-ns"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: pod.Name, Namespace: "test-ns"},
+		Reason:         "TriggeredScaleUp",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileApp(t, r, "myapp", "test-ns")
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	if app.Status.Phase != iafv1alpha1.ApplicationPhaseDeploying {
+		t.Errorf("expected Deploying while a node may still be added, got %s", app.Status.Phase)
+	}
+	sched := app.Status.Scheduling
+	if sched == nil || sched.UnschedulablePods != 1 || !sched.ScalingUp || len(sched.Reasons) != 1 || sched.Reasons[0] != "insufficient memory" {
+		t.Fatalf("unexpected scheduling status %+v", sched)
+	}
+	for _, condType := range []string{conditionScheduled, "Ready"} {
+		c := meta.FindStatusCondition(app.Status.Conditions, condType)
+		if c == nil || c.Status != metav1.ConditionFalse || c.Reason != reasonUnschedulable || !strings.Contains(c.Message, "insufficient memory") {
+			t.Errorf("expected %s=False with reason Unschedulable, got %+v", condType, c)
+		}
+	}
+
+	pod.Spec.NodeName = "node-a"
+	pod.Status.Conditions[0].Status = corev1.ConditionTrue
+	if err := r.Update(ctx, pod); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	if app.Status.Scheduling != nil || meta.FindStatusCondition(app.Status.Conditions, conditionScheduled) != nil {
+		t.Errorf("expected the scheduling report to clear, got %+v %+v", app.Status.Scheduling, app.Status.Conditions)
+	}
+	if c := meta.FindStatusCondition(app.Status.Conditions, "Ready"); c == nil || c.Reason != "Deploying" {
+		t.Errorf("expected Ready reason Deploying, got %+v", c)
 	}
 }
 
 // TestReconcile_DeployingRequeues verifies that the controller requeues with
-// a 10s delay when in Deploying phase with no available replicas.
+// exponential backoff while in Deploying phase with no available replicas.
 func TestReconcile_DeployingRequeues(t *testing.T) {
 	scheme := newTestScheme(t)
 	r := newReconciler(scheme)
@@ -235,11 +379,15 @@ func TestReconcile_DeployingRequeues(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	// First reconcile: Deploying with 0 available replicas.
-	res := reconcileApp(t, r, "myapp", "test-ns")
+	r.RequeueBase = 2 * time.Second
+	r.RequeueMax = 6 * time.Second
 
-	if res.RequeueAfter != 10*time.Second {
-		t.Errorf("expected RequeueAfter=10s while Deploying, got %v", res.RequeueAfter)
+	// Deploying with 0 available replicas: 2s, 4s, then capped at 6s.
+	for _, want := range []time.Duration{2 * time.Second, 4 * time.Second, 6 * time.Second, 6 * time.Second} {
+		res := reconcileApp(t, r, "myapp", "test-ns")
+		if res.RequeueAfter < want || res.RequeueAfter > want*11/10 {
+			t.Errorf("expected RequeueAfter≈%v while Deploying, got %v", want, res.RequeueAfter)
+		}
 	}
 }
 
@@ -265,169 +413,1735 @@ func TestReconcile_CreatesService(t *testing.T) {
 	}
 }
 
-// TestReconcile_URLSetDuringDeploying verifies the URL field is populated
-// during the Deploying phase, not just at Running.
-func TestReconcile_URLSetDuringDeploying(t *testing.T) {
+// TestReconcile_RegistryCredential verifies the registry credential becomes
+// the pod's image pull secret.
+func TestReconcile_RegistryCredential(t *testing.T) {
 	scheme := newTestScheme(t)
 	r := newReconciler(scheme)
 	ctx := context.Background()
 
 	app := makeApp("myapp", "test-ns")
+	app.Spec.Image = "ghcr.io/team/app:1"
+	app.Spec.RegistryCredential = "ghcr"
 	if err := r.Create(ctx, app); err != nil {
 		t.Fatal(err)
 	}
 
 	reconcileApp(t, r, "myapp", "test-ns")
 
-	var result iafv1alpha1.Application
-	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &result); err != nil {
+	var dep appsv1.Deployment
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &dep); err != nil {
+		t.Fatalf("expected Deployment to be created: %v", err)
+	}
+	pullSecrets := dep.Spec.Template.Spec.ImagePullSecrets
+	if len(pullSecrets) != 1 || pullSecrets[0].Name != "ghcr" {
+		t.Errorf("expected image pull secret ghcr, got %v", pullSecrets)
+	}
+}
+
+// TestReconcile_RegistryPrefix verifies builds push under the namespace's
+// registry prefix and that an image reported elsewhere is never deployed.
+func TestReconcile_RegistryPrefix(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "test-ns",
+		Annotations: map[string]string{iafk8s.AnnotationRegistryPrefix: "ghcr.io/payments"},
+	}}
+	if err := r.Create(ctx, ns); err != nil {
 		t.Fatal(err)
 	}
-	if result.Status.URL == "" {
-		t.Error("expected URL to be set during Deploying phase")
+	app := makeApp("myapp", "test-ns")
+	app.Spec.Image = ""
+	app.Spec.Blob = "http://localhost:8080/sources/test-ns/myapp.tar.gz"
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
 	}
-	expected := "http://myapp.example.com"
-	if result.Status.URL != expected {
-		t.Errorf("expected URL %q, got %q", expected, result.Status.URL)
+
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	kpackImage := &unstructured.Unstructured{}
+	kpackImage.SetGroupVersionKind(iafk8s.KpackImageGVK)
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+	if err := r.Get(ctx, key, kpackImage); err != nil {
+		t.Fatalf("expected kpack Image to be created: %v", err)
+	}
+	if tag, _, _ := unstructured.NestedString(kpackImage.Object, "spec", "tag"); tag != "ghcr.io/payments/myapp" {
+		t.Errorf("expected tag under the namespace prefix, got %q", tag)
+	}
+
+	// kpack reports an image outside the prefix: the app fails instead of deploying.
+	kpackImage.Object["status"] = map[string]any{
+		"latestImage": "ghcr.io/other/myapp@sha256:abc",
+		"conditions":  []any{map[string]any{"type": "Ready", "status": "True"}},
+	}
+	if err := r.Update(ctx, kpackImage); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	var got iafv1alpha1.Application
+	if err := r.Get(ctx, key, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.Phase != iafv1alpha1.ApplicationPhaseFailed {
+		t.Errorf("expected Failed, got %s", got.Status.Phase)
+	}
+	if cond := meta.FindStatusCondition(got.Status.Conditions, "Ready"); cond == nil || cond.Reason != "ImageOutsideRegistry" {
+		t.Errorf("expected ImageOutsideRegistry condition, got %+v", cond)
+	}
+	var dep appsv1.Deployment
+	if err := r.Get(ctx, key, &dep); !apierrors.IsNotFound(err) {
+		t.Errorf("expected no Deployment, got %v", err)
+	}
+
+	// Changing the prefix replaces the kpack Image, whose tag is immutable.
+	ns.Annotations[iafk8s.AnnotationRegistryPrefix] = "ghcr.io/platform"
+	if err := r.Update(ctx, ns); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if err := r.Get(ctx, key, kpackImage); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected the kpack Image to be deleted, got %v", err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if err := r.Get(ctx, key, kpackImage); err != nil {
+		t.Fatal(err)
+	}
+	if tag, _, _ := unstructured.NestedString(kpackImage.Object, "spec", "tag"); tag != "ghcr.io/platform/myapp" {
+		t.Errorf("expected tag under the new prefix, got %q", tag)
 	}
 }
 
-// TestReconcile_NotFound is a sanity check that reconciling a deleted app
-// does not return an error.
-func TestReconcile_NotFound(t *testing.T) {
+// TestReconcile_BuildCache verifies the kpack Image gets the platform build
+// cache, that growing it updates the Image in place, and that shrinking it
+// replaces the Image, since kpack rejects smaller cache volumes.
+func TestReconcile_BuildCache(t *testing.T) {
 	scheme := newTestScheme(t)
 	r := newReconciler(scheme)
+	r.BuildCache = iafk8s.BuildCache{Type: iafv1alpha1.BuildCacheVolume, VolumeSize: resource.MustParse("2Gi")}
 	ctx := context.Background()
 
-	result, err := r.Reconcile(ctx, ctrl.Request{
-		NamespacedName: types.NamespacedName{Name: "gone", Namespace: "test-ns"},
-	})
-	if err != nil {
-		t.Fatalf("expected no error for missing app, got %v", err)
+	if err := r.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-ns"}}); err != nil {
+		t.Fatal(err)
 	}
-	if result.Requeue || result.RequeueAfter != 0 {
-		t.Errorf("expected empty result for missing app, got %+v", result)
+
+	app := makeApp("myapp", "test-ns")
+	app.Spec.Image = ""
+	app.Spec.Git = &iafv1alpha1.GitSource{URL: "https://github.com/example/myapp", Revision: "main"}
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
 	}
-}
+	reconcileApp(t, r, "myapp", "test-ns")
 
-// newReconcilerWithTLS returns a reconciler with TLSIssuer set so that
-// certificate reconciliation is active.
-func newReconcilerWithTLS(scheme *runtime.Scheme) *ApplicationReconciler {
-	k8sClient := fake.NewClientBuilder().
-		WithScheme(scheme).
-		WithStatusSubresource(&iafv1alpha1.Application{}).
-		Build()
-	return &ApplicationReconciler{
-		Client:         k8sClient,
-		Scheme:         scheme,
-		ClusterBuilder: "default",
-		RegistryPrefix: "registry.example.com",
-		BaseDomain:     "example.com",
-		TLSIssuer:      "selfsigned-issuer",
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+	cacheSize := func() string {
+		t.Helper()
+		kpackImage := &unstructured.Unstructured{}
+		kpackImage.SetGroupVersionKind(iafk8s.KpackImageGVK)
+		if err := r.Get(ctx, key, kpackImage); err != nil {
+			if apierrors.IsNotFound(err) {
+				return "deleted"
+			}
+			t.Fatal(err)
+		}
+		size, _, _ := unstructured.NestedString(kpackImage.Object, "spec", "cache", "volume", "size")
+		return size
+	}
+	if got := cacheSize(); got != "2Gi" {
+		t.Fatalf("expected the platform 2Gi cache, got %q", got)
+	}
+
+	setSize := func(size string) {
+		t.Helper()
+		if err := r.Get(ctx, key, app); err != nil {
+			t.Fatal(err)
+		}
+		q := resource.MustParse(size)
+		app.Spec.BuildCache = &iafv1alpha1.BuildCacheConfig{VolumeSize: &q}
+		if err := r.Update(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+		reconcileApp(t, r, "myapp", "test-ns")
+	}
+	setSize("5Gi")
+	if got := cacheSize(); got != "5Gi" {
+		t.Fatalf("expected the cache to grow to 5Gi, got %q", got)
+	}
+	setSize("3Gi")
+	if got := cacheSize(); got != "deleted" {
+		t.Fatalf("expected a smaller cache to replace the kpack Image, got %q", got)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if got := cacheSize(); got != "3Gi" {
+		t.Errorf("expected the new kpack Image to have a 3Gi cache, got %q", got)
 	}
 }
 
-// TestReconcile_TLS_HTTPSURLWhenIssuerSet verifies that when TLSIssuer is
-// configured, the status URL uses https:// and a cert-manager Certificate CR
-// is created.
-func TestReconcile_TLS_HTTPSURLWhenIssuerSet(t *testing.T) {
+// TestReconcile_BuildEnv verifies spec.buildEnv reaches the kpack Image
+// build, and that changing it updates the Image so kpack rebuilds.
+func TestReconcile_BuildEnv(t *testing.T) {
 	scheme := newTestScheme(t)
-	r := newReconcilerWithTLS(scheme)
+	r := newReconciler(scheme)
 	ctx := context.Background()
 
+	if err := r.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-ns"}}); err != nil {
+		t.Fatal(err)
+	}
 	app := makeApp("myapp", "test-ns")
+	app.Spec.Image = ""
+	app.Spec.Git = &iafv1alpha1.GitSource{URL: "https://github.com/example/myapp", Revision: "main"}
+	app.Spec.BuildEnv = []iafv1alpha1.EnvVar{{Name: "BP_GO_TARGETS", Value: "./cmd/server"}}
 	if err := r.Create(ctx, app); err != nil {
 		t.Fatal(err)
 	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+	buildEnv := func() []any {
+		t.Helper()
+		kpackImage := &unstructured.Unstructured{}
+		kpackImage.SetGroupVersionKind(iafk8s.KpackImageGVK)
+		if err := r.Get(ctx, key, kpackImage); err != nil {
+			t.Fatal(err)
+		}
+		env, _, _ := unstructured.NestedSlice(kpackImage.Object, "spec", "build", "env")
+		return env
+	}
+	env := buildEnv()
+	if len(env) != 1 || env[0].(map[string]any)["name"] != "BP_GO_TARGETS" || env[0].(map[string]any)["value"] != "./cmd/server" {
+		t.Fatalf("unexpected build env %v", env)
+	}
 
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	app.Spec.BuildEnv = append(app.Spec.BuildEnv, iafv1alpha1.EnvVar{Name: "NODE_ENV", Value: "production"})
+	if err := r.Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
 	reconcileApp(t, r, "myapp", "test-ns")
+	if env := buildEnv(); len(env) != 2 || env[1].(map[string]any)["name"] != "NODE_ENV" {
+		t.Errorf("expected the build env change to update the kpack Image, got %v", env)
+	}
+}
 
-	var result iafv1alpha1.Application
-	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &result); err != nil {
+// TestReconcile_Builder verifies the kpack Image builds with the app's
+// builder when the operator allows it, follows builder changes, and that a
+// builder outside the allowlist fails the app without building.
+func TestReconcile_Builder(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	r.Builders = []string{r.ClusterBuilder, "java-native"}
+	ctx := context.Background()
+
+	if err := r.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-ns"}}); err != nil {
 		t.Fatal(err)
 	}
-	expected := "https://myapp.example.com"
-	if result.Status.URL != expected {
-		t.Errorf("expected URL %q when TLS issuer is set, got %q", expected, result.Status.URL)
+	app := makeApp("myapp", "test-ns")
+	app.Spec.Image = ""
+	app.Spec.Git = &iafv1alpha1.GitSource{URL: "https://github.com/example/myapp", Revision: "main"}
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
 	}
+	reconcileApp(t, r, "myapp", "test-ns")
 
-	// A cert-manager Certificate CR should have been created.
-	cert := &unstructured.Unstructured{}
-	cert.SetGroupVersionKind(iafk8s.CertificateGVK)
-	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, cert); err != nil {
-		t.Fatalf("expected Certificate CR to be created: %v", err)
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+	builder := func() string {
+		t.Helper()
+		kpackImage := &unstructured.Unstructured{}
+		kpackImage.SetGroupVersionKind(iafk8s.KpackImageGVK)
+		if err := r.Get(ctx, key, kpackImage); err != nil {
+			t.Fatal(err)
+		}
+		name, _, _ := unstructured.NestedString(kpackImage.Object, "spec", "builder", "name")
+		return name
+	}
+	setBuilder := func(name string) {
+		t.Helper()
+		if err := r.Get(ctx, key, app); err != nil {
+			t.Fatal(err)
+		}
+		app.Spec.Builder = name
+		if err := r.Update(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+		reconcileApp(t, r, "myapp", "test-ns")
+	}
+	if got := builder(); got != r.ClusterBuilder {
+		t.Fatalf("expected the default builder, got %q", got)
+	}
+	setBuilder("java-native")
+	if got := builder(); got != "java-native" {
+		t.Fatalf("expected the builder change to update the kpack Image, got %q", got)
+	}
+
+	setBuilder("full")
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	if app.Status.Phase != iafv1alpha1.ApplicationPhaseFailed {
+		t.Errorf("expected phase Failed, got %s", app.Status.Phase)
+	}
+	if c := meta.FindStatusCondition(app.Status.Conditions, "Ready"); c == nil || c.Reason != "BuilderNotAllowed" {
+		t.Errorf("expected BuilderNotAllowed condition, got %+v", c)
+	}
+	if got := builder(); got != "java-native" {
+		t.Errorf("expected the kpack Image to keep its builder, got %q", got)
 	}
 }
 
-// TestReconcile_TLSIssuerEmpty_NoCertManager is a regression test for the bug
-// where tls_issuer defaulted to "selfsigned-issuer", causing every reconcile to
-// return "no matches for kind Certificate" on clusters without cert-manager.
-// When TLSIssuer is empty the controller must skip all Certificate operations,
-// the URL must be http://, and no Certificate CR must exist.
-func TestReconcile_TLSIssuerEmpty_NoCertManager(t *testing.T) {
-	// newReconciler leaves TLSIssuer unset (""), which is the post-fix default.
-	// cert-manager types are NOT registered in the scheme, so any attempt to
-	// Get/Create a Certificate would produce an "unregistered type" panic or error.
+// TestReconcile_RuntimeVersions verifies builds are pinned to the default
+// runtime version the org allows, that an allowed version in the app's
+// build env is kept, and that a disallowed one fails the app.
+func TestReconcile_RuntimeVersions(t *testing.T) {
 	scheme := newTestScheme(t)
 	r := newReconciler(scheme)
+	r.Standards = func() *orgstandards.OrgStandards {
+		return &orgstandards.OrgStandards{PerLanguage: map[string]orgstandards.PerLanguageStandards{
+			"nodejs": {RuntimeVersions: []string{"20", "22"}},
+		}}
+	}
 	ctx := context.Background()
 
+	if err := r.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-ns"}}); err != nil {
+		t.Fatal(err)
+	}
 	app := makeApp("myapp", "test-ns")
+	app.Spec.Image = ""
+	app.Spec.Git = &iafv1alpha1.GitSource{URL: "https://github.com/example/myapp", Revision: "main"}
 	if err := r.Create(ctx, app); err != nil {
 		t.Fatal(err)
 	}
-
-	// Must not return an error even though cert-manager is absent.
 	reconcileApp(t, r, "myapp", "test-ns")
 
-	var result iafv1alpha1.Application
-	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &result); err != nil {
-		t.Fatal(err)
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+	nodeVersion := func() any {
+		t.Helper()
+		kpackImage := &unstructured.Unstructured{}
+		kpackImage.SetGroupVersionKind(iafk8s.KpackImageGVK)
+		if err := r.Get(ctx, key, kpackImage); err != nil {
+			t.Fatal(err)
+		}
+		env, _, _ := unstructured.NestedSlice(kpackImage.Object, "spec", "build", "env")
+		for _, e := range env {
+			if e.(map[string]any)["name"] == "BP_NODE_VERSION" {
+				return e.(map[string]any)["value"]
+			}
+		}
+		return nil
 	}
-	if want := "http://myapp.example.com"; result.Status.URL != want {
-		t.Errorf("expected HTTP URL when TLSIssuer is empty, got %q", result.Status.URL)
+	setVersion := func(version string) {
+		t.Helper()
+		if err := r.Get(ctx, key, app); err != nil {
+			t.Fatal(err)
+		}
+		app.Spec.BuildEnv = []iafv1alpha1.EnvVar{{Name: "BP_NODE_VERSION", Value: version}}
+		if err := r.Update(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+		reconcileApp(t, r, "myapp", "test-ns")
+	}
+	if got := nodeVersion(); got != "20" {
+		t.Fatalf("expected the default runtime version 20, got %v", got)
+	}
+	setVersion("22.3.0")
+	if got := nodeVersion(); got != "22.3.0" {
+		t.Fatalf("expected the app's runtime version, got %v", got)
 	}
 
-	// No Certificate CR should have been created.
-	cert := &unstructured.Unstructured{}
-	cert.SetGroupVersionKind(iafk8s.CertificateGVK)
-	err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, cert)
-	if !apierrors.IsNotFound(err) {
-		t.Errorf("expected no Certificate CR when TLSIssuer is empty, got err=%v", err)
+	setVersion("18")
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	if app.Status.Phase != iafv1alpha1.ApplicationPhaseFailed {
+		t.Errorf("expected phase Failed, got %s", app.Status.Phase)
+	}
+	if c := meta.FindStatusCondition(app.Status.Conditions, "Ready"); c == nil || c.Reason != "RuntimeNotAllowed" || !strings.Contains(c.Message, "20, 22") {
+		t.Errorf("expected a RuntimeNotAllowed condition naming the allowed versions, got %+v", c)
+	}
+	if got := nodeVersion(); got != "22.3.0" {
+		t.Errorf("expected the kpack Image to keep its runtime version, got %v", got)
 	}
 }
 
-// TestReconcile_TLS_OptOut verifies that when an app sets tls.enabled=false,
-// the status URL uses http:// and no Certificate CR is created, even when
-// TLSIssuer is configured.
-func TestReconcile_TLS_OptOut(t *testing.T) {
+// TestReconcile_Architecture verifies an app targeting an architecture builds
+// with that architecture's builder and schedules on matching nodes, that
+// multi-architecture apps may run on amd64 or arm64 nodes, and that an
+// architecture the platform does not offer fails the app.
+func TestReconcile_Architecture(t *testing.T) {
 	scheme := newTestScheme(t)
-	r := newReconcilerWithTLS(scheme)
+	r := newReconciler(scheme)
+	r.ArchitectureBuilders = map[string]string{"amd64": r.ClusterBuilder, "arm64": "arm64-builder", "multi": "multiarch-builder"}
 	ctx := context.Background()
 
-	tlsOff := false
+	if err := r.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-ns"}}); err != nil {
+		t.Fatal(err)
+	}
 	app := makeApp("myapp", "test-ns")
-	app.Spec.TLS = &iafv1alpha1.TLSConfig{Enabled: &tlsOff}
+	app.Spec.Image = ""
+	app.Spec.Git = &iafv1alpha1.GitSource{URL: "https://github.com/example/myapp", Revision: "main"}
+	app.Spec.Architecture = iafv1alpha1.ArchitectureARM64
 	if err := r.Create(ctx, app); err != nil {
 		t.Fatal(err)
 	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+	kpackImage := &unstructured.Unstructured{}
+	kpackImage.SetGroupVersionKind(iafk8s.KpackImageGVK)
+	if err := r.Get(ctx, key, kpackImage); err != nil {
+		t.Fatal(err)
+	}
+	if name, _, _ := unstructured.NestedString(kpackImage.Object, "spec", "builder", "name"); name != "arm64-builder" {
+		t.Errorf("expected the arm64 builder, got %q", name)
+	}
+
+	// Pre-built images are only scheduled.
+	for _, tt := range []struct {
+		name         string
+		arch         iafv1alpha1.ApplicationArchitecture
+		nodeSelector string
+		affinity     []string
+	}{
+		{"web-any", "", "", nil},
+		{"web-arm64", iafv1alpha1.ArchitectureARM64, "arm64", nil},
+		{"web-multi", iafv1alpha1.ArchitectureMulti, "", []string{"amd64", "arm64"}},
+	} {
+		name := tt.name
+		img := makeApp(name, "test-ns")
+		img.Spec.Architecture = tt.arch
+		if err := r.Create(ctx, img); err != nil {
+			t.Fatal(err)
+		}
+		reconcileApp(t, r, name, "test-ns")
+		var dep appsv1.Deployment
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: "test-ns"}, &dep); err != nil {
+			t.Fatal(err)
+		}
+		podSpec := dep.Spec.Template.Spec
+		if got := podSpec.NodeSelector[corev1.LabelArchStable]; got != tt.nodeSelector {
+			t.Errorf("%s: expected node selector %q, got %q", name, tt.nodeSelector, got)
+		}
+		var affinity []string
+		if podSpec.Affinity != nil {
+			affinity = podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0].Values
+		}
+		if !slices.Equal(affinity, tt.affinity) {
+			t.Errorf("%s: expected node affinity %v, got %v", name, tt.affinity, affinity)
+		}
+	}
 
+	delete(r.ArchitectureBuilders, "arm64")
 	reconcileApp(t, r, "myapp", "test-ns")
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	if c := meta.FindStatusCondition(app.Status.Conditions, "Ready"); app.Status.Phase != iafv1alpha1.ApplicationPhaseFailed || c == nil || c.Reason != "ArchitectureUnavailable" {
+		t.Errorf("expected the app to fail with ArchitectureUnavailable, got %s %+v", app.Status.Phase, c)
+	}
+}
 
-	var result iafv1alpha1.Application
-	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &result); err != nil {
+// TestReconcile_Shutdown verifies pods drain connections with the default
+// timing, that spec.shutdown overrides it, and that a pre-stop delay
+// outside the grace period fails the app.
+func TestReconcile_Shutdown(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+
+	app := makeApp("myapp", "test-ns")
+	if err := r.Create(ctx, app); err != nil {
 		t.Fatal(err)
 	}
-	expected := "http://myapp.example.com"
-	if result.Status.URL != expected {
-		t.Errorf("expected URL %q with TLS opted out, got %q", expected, result.Status.URL)
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	timing := func() (int64, *corev1.Lifecycle) {
+		t.Helper()
+		var dep appsv1.Deployment
+		if err := r.Get(ctx, key, &dep); err != nil {
+			t.Fatalf("expected Deployment to be created: %v", err)
+		}
+		spec := dep.Spec.Template.Spec
+		if spec.TerminationGracePeriodSeconds == nil {
+			t.Fatal("expected a termination grace period")
+		}
+		return *spec.TerminationGracePeriodSeconds, spec.Containers[0].Lifecycle
+	}
+	grace, lifecycle := timing()
+	if grace != 30 || lifecycle == nil || lifecycle.PreStop == nil || lifecycle.PreStop.Sleep == nil || lifecycle.PreStop.Sleep.Seconds != 5 {
+		t.Errorf("expected the default 30s grace period and 5s pre-stop sleep, got %d %+v", grace, lifecycle)
 	}
 
-	// No Certificate CR should have been created.
-	cert := &unstructured.Unstructured{}
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	app.Spec.Shutdown = &iafv1alpha1.ShutdownConfig{GracePeriodSeconds: int32Ptr(90), PreStopDelaySeconds: int32Ptr(0)}
+	if err := r.Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if grace, lifecycle := timing(); grace != 90 || lifecycle != nil {
+		t.Errorf("expected a 90s grace period and no pre-stop hook, got %d %+v", grace, lifecycle)
+	}
+
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	app.Spec.Shutdown = &iafv1alpha1.ShutdownConfig{GracePeriodSeconds: int32Ptr(10), PreStopDelaySeconds: int32Ptr(10)}
+	if err := r.Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	if c := meta.FindStatusCondition(app.Status.Conditions, "Ready"); app.Status.Phase != iafv1alpha1.ApplicationPhaseFailed || c == nil || c.Reason != "InvalidShutdown" {
+		t.Errorf("expected the app to fail with InvalidShutdown, got %s %+v", app.Status.Phase, c)
+	}
+}
+
+// TestReconcile_PodDNS verifies host aliases and DNS settings reach the
+// pod template on top of cluster DNS, and that an alias overriding a
+// cluster-internal name fails the app.
+func TestReconcile_PodDNS(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+
+	app := makeApp("myapp", "test-ns")
+	app.Spec.HostAliases = []iafv1alpha1.HostAlias{{IP: "10.20.0.5", Hostnames: []string{"ldap.corp.example.com"}}}
+	app.Spec.DNSConfig = &iafv1alpha1.DNSConfig{
+		Nameservers: []string{"10.0.0.53"},
+		Searches:    []string{"corp.example.com"},
+		Options:     []iafv1alpha1.DNSOption{{Name: "ndots", Value: "2"}, {Name: "edns0"}},
+	}
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	var dep appsv1.Deployment
+	if err := r.Get(ctx, key, &dep); err != nil {
+		t.Fatalf("expected Deployment to be created: %v", err)
+	}
+	spec := dep.Spec.Template.Spec
+	if len(spec.HostAliases) != 1 || spec.HostAliases[0].IP != "10.20.0.5" || !slices.Equal(spec.HostAliases[0].Hostnames, []string{"ldap.corp.example.com"}) {
+		t.Errorf("unexpected host aliases %+v", spec.HostAliases)
+	}
+	if spec.DNSPolicy != "" && spec.DNSPolicy != corev1.DNSClusterFirst {
+		t.Errorf("expected the cluster DNS policy to be kept, got %q", spec.DNSPolicy)
+	}
+	dns := spec.DNSConfig
+	if dns == nil || !slices.Equal(dns.Nameservers, []string{"10.0.0.53"}) || !slices.Equal(dns.Searches, []string{"corp.example.com"}) || len(dns.Options) != 2 {
+		t.Fatalf("unexpected DNS config %+v", dns)
+	}
+	if dns.Options[0].Name != "ndots" || dns.Options[0].Value == nil || *dns.Options[0].Value != "2" || dns.Options[1].Value != nil {
+		t.Errorf("unexpected DNS options %+v", dns.Options)
+	}
+
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	app.Spec.HostAliases[0].Hostnames = []string{"kubernetes.default.svc.cluster.local"}
+	if err := r.Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	if c := meta.FindStatusCondition(app.Status.Conditions, "Ready"); app.Status.Phase != iafv1alpha1.ApplicationPhaseFailed || c == nil || c.Reason != "InvalidDNSConfig" {
+		t.Errorf("expected the app to fail with InvalidDNSConfig, got %s %+v", app.Status.Phase, c)
+	}
+}
+
+// TestReconcile_Placement verifies every app's pods get the platform node
+// selector and tolerations, that a workload class adds its own on top, and
+// that a class the platform does not offer fails the app.
+func TestReconcile_Placement(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	r.Placement = iafk8s.Placement{
+		NodeSelector: map[string]string{"pool": "agents"},
+		Tolerations:  []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "agents", Effect: corev1.TaintEffectNoSchedule}},
+	}
+	r.WorkloadClasses = map[string]iafk8s.Placement{
+		"standard": {},
+		"gpu": {
+			NodeSelector: map[string]string{"pool": "gpu"},
+			Tolerations:  []corev1.Toleration{{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists}},
+		},
+	}
+	r.ArchitectureBuilders = map[string]string{"arm64": r.ClusterBuilder}
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		class       iafv1alpha1.WorkloadClass
+		arch        iafv1alpha1.ApplicationArchitecture
+		selector    map[string]string
+		tolerations []string
+	}{
+		{"web", "", "", map[string]string{"pool": "agents"}, []string{"dedicated"}},
+		{"api", iafv1alpha1.WorkloadClassStandard, iafv1alpha1.ArchitectureARM64, map[string]string{"pool": "agents", corev1.LabelArchStable: "arm64"}, []string{"dedicated"}},
+		{"train", iafv1alpha1.WorkloadClassGPU, "", map[string]string{"pool": "gpu"}, []string{"dedicated", "nvidia.com/gpu"}},
+	}
+	for _, tt := range tests {
+		app := makeApp(tt.name, "test-ns")
+		app.Spec.WorkloadClass = tt.class
+		app.Spec.Architecture = tt.arch
+		if err := r.Create(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+		reconcileApp(t, r, tt.name, "test-ns")
+		var dep appsv1.Deployment
+		if err := r.Get(ctx, types.NamespacedName{Name: tt.name, Namespace: "test-ns"}, &dep); err != nil {
+			t.Fatal(err)
+		}
+		podSpec := dep.Spec.Template.Spec
+		if !maps.Equal(podSpec.NodeSelector, tt.selector) {
+			t.Errorf("%s: expected node selector %v, got %v", tt.name, tt.selector, podSpec.NodeSelector)
+		}
+		var keys []string
+		for _, tol := range podSpec.Tolerations {
+			keys = append(keys, tol.Key)
+		}
+		if !slices.Equal(keys, tt.tolerations) {
+			t.Errorf("%s: expected tolerations %v, got %v", tt.name, tt.tolerations, keys)
+		}
+	}
+
+	app := makeApp("batch", "test-ns")
+	app.Spec.WorkloadClass = iafv1alpha1.WorkloadClassBurst
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "batch", "test-ns")
+	if err := r.Get(ctx, types.NamespacedName{Name: "batch", Namespace: "test-ns"}, app); err != nil {
+		t.Fatal(err)
+	}
+	if c := meta.FindStatusCondition(app.Status.Conditions, "Ready"); app.Status.Phase != iafv1alpha1.ApplicationPhaseFailed || c == nil || c.Reason != "WorkloadClassUnavailable" {
+		t.Errorf("expected the app to fail with WorkloadClassUnavailable, got %s %+v", app.Status.Phase, c)
+	}
+	var dep appsv1.Deployment
+	if err := r.Get(ctx, types.NamespacedName{Name: "batch", Namespace: "test-ns"}, &dep); !apierrors.IsNotFound(err) {
+		t.Errorf("expected no Deployment for an unavailable class, got %v", err)
+	}
+}
+
+// TestReconcile_InvalidRegistryPrefix verifies a malformed namespace
+// annotation fails the app rather than falling back to the platform registry.
+func TestReconcile_InvalidRegistryPrefix(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "test-ns",
+		Annotations: map[string]string{iafk8s.AnnotationRegistryPrefix: "https://ghcr.io/payments"},
+	}}
+	if err := r.Create(ctx, ns); err != nil {
+		t.Fatal(err)
+	}
+	app := makeApp("myapp", "test-ns")
+	app.Spec.Image = ""
+	app.Spec.Blob = "http://localhost:8080/sources/test-ns/myapp.tar.gz"
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	var got iafv1alpha1.Application
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if cond := meta.FindStatusCondition(got.Status.Conditions, "Ready"); got.Status.Phase != iafv1alpha1.ApplicationPhaseFailed || cond == nil || cond.Reason != "InvalidRegistryPrefix" {
+		t.Errorf("expected Failed with InvalidRegistryPrefix, got %s %+v", got.Status.Phase, cond)
+	}
+}
+
+// TestReconcile_URLSetDuringDeploying verifies the URL field is populated
+// during the Deploying phase, not just at Running.
+func TestReconcile_URLSetDuringDeploying(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+
+	app := makeApp("myapp", "test-ns")
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	var result iafv1alpha1.Application
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Status.URL == "" {
+		t.Error("expected URL to be set during Deploying phase")
+	}
+	expected := "http://myapp.example.com"
+	if result.Status.URL != expected {
+		t.Errorf("expected URL %q, got %q", expected, result.Status.URL)
+	}
+}
+
+// TestReconcile_NotFound is a sanity check that reconciling a deleted app
+// does not return an error.
+func TestReconcile_NotFound(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+
+	result, err := r.Reconcile(ctx, ctrl.Request{
+		NamespacedName: types.NamespacedName{Name: "gone", Namespace: "test-ns"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error for missing app, got %v", err)
+	}
+	if result.Requeue || result.RequeueAfter != 0 {
+		t.Errorf("expected empty result for missing app, got %+v", result)
+	}
+}
+
+// newReconcilerWithTLS returns a reconciler with TLSIssuer set so that
+// certificate reconciliation is active.
+func newReconcilerWithTLS(scheme *runtime.Scheme) *ApplicationReconciler {
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&iafv1alpha1.Application{}).
+		Build()
+	return &ApplicationReconciler{
+		Client:         k8sClient,
+		Scheme:         scheme,
+		ClusterBuilder: "default",
+		RegistryPrefix: "registry.example.com",
+		BaseDomain:     "example.com",
+		TLSIssuer:      "selfsigned-issuer",
+	}
+}
+
+// TestReconcile_TLS_HTTPSURLWhenIssuerSet verifies that when TLSIssuer is
+// configured, the status URL uses https:// and a cert-manager Certificate CR
+// is created.
+func TestReconcile_TLS_HTTPSURLWhenIssuerSet(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconcilerWithTLS(scheme)
+	ctx := context.Background()
+
+	app := makeApp("myapp", "test-ns")
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	var result iafv1alpha1.Application
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &result); err != nil {
+		t.Fatal(err)
+	}
+	expected := "https://myapp.example.com"
+	if result.Status.URL != expected {
+		t.Errorf("expected URL %q when TLS issuer is set, got %q", expected, result.Status.URL)
+	}
+
+	// A cert-manager Certificate CR should have been created.
+	cert := &unstructured.Unstructured{}
+	cert.SetGroupVersionKind(iafk8s.CertificateGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, cert); err != nil {
+		t.Fatalf("expected Certificate CR to be created: %v", err)
+	}
+
+	// Until cert-manager issues it, the certificate is reported as issuing.
+	if result.Status.TLS == nil || result.Status.TLS.Issuer != "selfsigned-issuer" || result.Status.TLS.Ready {
+		t.Errorf("expected a pending TLS status, got %+v", result.Status.TLS)
+	}
+	if c := meta.FindStatusCondition(result.Status.Conditions, conditionCertificateReady); c == nil || c.Reason != "Issuing" {
+		t.Errorf("expected CertificateReady reason Issuing, got %+v", c)
+	}
+}
+
+// TestReconcile_TLSIssuerEmpty_NoCertManager is a regression test for the bug
+// where tls_issuer defaulted to "selfsigned-issuer", causing every reconcile to
+// return "no matches for kind Certificate" on clusters without cert-manager.
+// When TLSIssuer is empty the controller must skip all Certificate operations,
+// the URL must be http://, and no Certificate CR must exist.
+func TestReconcile_TLSIssuerEmpty_NoCertManager(t *testing.T) {
+	// newReconciler leaves TLSIssuer unset (""), which is the post-fix default.
+	// cert-manager types are NOT registered in the scheme, so any attempt to
+	// Get/Create a Certificate would produce an "unregistered type" panic or error.
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+
+	app := makeApp("myapp", "test-ns")
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	// Must not return an error even though cert-manager is absent.
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	var result iafv1alpha1.Application
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &result); err != nil {
+		t.Fatal(err)
+	}
+	if want := "http://myapp.example.com"; result.Status.URL != want {
+		t.Errorf("expected HTTP URL when TLSIssuer is empty, got %q", result.Status.URL)
+	}
+
+	// No Certificate CR should have been created.
+	cert := &unstructured.Unstructured{}
+	cert.SetGroupVersionKind(iafk8s.CertificateGVK)
+	err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, cert)
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected no Certificate CR when TLSIssuer is empty, got err=%v", err)
+	}
+}
+
+// TestReconcile_TLS_OptOut verifies that when an app sets tls.enabled=false,
+// the status URL uses http:// and no Certificate CR is created, even when
+// TLSIssuer is configured.
+func TestReconcile_TLS_OptOut(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconcilerWithTLS(scheme)
+	ctx := context.Background()
+
+	tlsOff := false
+	app := makeApp("myapp", "test-ns")
+	app.Spec.TLS = &iafv1alpha1.TLSConfig{Enabled: &tlsOff}
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	var result iafv1alpha1.Application
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &result); err != nil {
+		t.Fatal(err)
+	}
+	expected := "http://myapp.example.com"
+	if result.Status.URL != expected {
+		t.Errorf("expected URL %q with TLS opted out, got %q", expected, result.Status.URL)
+	}
+
+	// No Certificate CR should have been created.
+	cert := &unstructured.Unstructured{}
 	cert.SetGroupVersionKind(iafk8s.CertificateGVK)
 	err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, cert)
 	if !apierrors.IsNotFound(err) {
 		t.Errorf("expected no Certificate when TLS opted out, got err=%v", err)
 	}
 }
+
+// TestReconcile_TCPProtocol verifies that a tcp app gets an IngressRouteTCP and
+// a tcp:// URL, and that switching back to http replaces it with an IngressRoute.
+func TestReconcile_TCPProtocol(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconcilerWithTLS(scheme)
+	ctx := context.Background()
+
+	app := makeApp("myapp", "test-ns")
+	app.Spec.Protocol = iafv1alpha1.ProtocolTCP
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	var result iafv1alpha1.Application
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &result); err != nil {
+		t.Fatal(err)
+	}
+	if want := "tcp://myapp.example.com:443"; result.Status.URL != want {
+		t.Errorf("expected URL %q, got %q", want, result.Status.URL)
+	}
+
+	tcpRoute := &unstructured.Unstructured{}
+	tcpRoute.SetGroupVersionKind(iafk8s.TraefikIngressRouteTCPGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, tcpRoute); err != nil {
+		t.Fatalf("expected IngressRouteTCP to be created: %v", err)
+	}
+
+	result.Spec.Protocol = iafv1alpha1.ProtocolHTTP
+	if err := r.Update(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(iafk8s.TraefikIngressRouteGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, route); err != nil {
+		t.Fatalf("expected IngressRoute after switching to http: %v", err)
+	}
+	err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, tcpRoute)
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected stale IngressRouteTCP to be deleted, got err=%v", err)
+	}
+}
+
+// TestReconcile_BasicAuth verifies that basic authentication generates a
+// credential Secret and a Traefik Middleware, and that the credentials are not
+// regenerated on later reconciles.
+func TestReconcile_BasicAuth(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+
+	app := makeApp("myapp", "test-ns")
+	app.Spec.Authentication = iafv1alpha1.AuthenticationBasic
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	var secret corev1.Secret
+	key := types.NamespacedName{Name: iafk8s.BasicAuthSecretName("myapp"), Namespace: "test-ns"}
+	if err := r.Get(ctx, key, &secret); err != nil {
+		t.Fatalf("expected basic-auth Secret to be created: %v", err)
+	}
+	password := secret.StringData["password"]
+	if len(password) < 16 {
+		t.Errorf("expected a generated password, got %q", password)
+	}
+
+	mw := &unstructured.Unstructured{}
+	mw.SetGroupVersionKind(iafk8s.TraefikMiddlewareGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp-auth", Namespace: "test-ns"}, mw); err != nil {
+		t.Fatalf("expected Middleware to be created: %v", err)
+	}
+
+	reconcileApp(t, r, "myapp", "test-ns")
+	if err := r.Get(ctx, key, &secret); err != nil {
+		t.Fatal(err)
+	}
+	if secret.StringData["password"] != password {
+		t.Error("expected basic-auth password to be stable across reconciles")
+	}
+
+	// Switching to none removes the middleware and generated Secret.
+	var current iafv1alpha1.Application
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &current); err != nil {
+		t.Fatal(err)
+	}
+	current.Spec.Authentication = iafv1alpha1.AuthenticationNone
+	if err := r.Update(ctx, &current); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if err := r.Get(ctx, key, &secret); !apierrors.IsNotFound(err) {
+		t.Errorf("expected basic-auth Secret to be deleted, got err=%v", err)
+	}
+}
+
+// TestReconcile_OAuthProxy_NoIssuerFailsClosed verifies that an oauth-proxy app
+// is never deployed unprotected when the identity provider is not configured.
+func TestReconcile_OAuthProxy_NoIssuerFailsClosed(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+
+	app := makeApp("myapp", "test-ns")
+	app.Spec.Authentication = iafv1alpha1.AuthenticationOAuthProxy
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	var result iafv1alpha1.Application
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Status.Phase != iafv1alpha1.ApplicationPhaseFailed {
+		t.Errorf("expected phase Failed, got %q", result.Status.Phase)
+	}
+	var dep appsv1.Deployment
+	err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &dep)
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected no Deployment when oauth-proxy is unavailable, got err=%v", err)
+	}
+}
+
+// TestReconcile_OAuthProxy verifies the oauth2-proxy sidecar is added and the
+// Service targets it instead of the app container.
+func TestReconcile_OAuthProxy(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	r.OAuthProxyImage = "oauth2-proxy:test"
+	r.OIDCIssuerURL = "https://idp.example.com"
+	r.OIDCClientID = "iaf"
+	ctx := context.Background()
+
+	app := makeApp("myapp", "test-ns")
+	app.Spec.Authentication = iafv1alpha1.AuthenticationOAuthProxy
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	var dep appsv1.Deployment
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &dep); err != nil {
+		t.Fatal(err)
+	}
+	containers := dep.Spec.Template.Spec.Containers
+	if len(containers) != 2 || containers[1].Name != "oauth-proxy" {
+		t.Fatalf("expected app and oauth-proxy containers, got %d", len(containers))
+	}
+
+	var svc corev1.Service
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &svc); err != nil {
+		t.Fatal(err)
+	}
+	if got := svc.Spec.Ports[0].TargetPort.IntVal; got != iafk8s.OAuthProxyPort {
+		t.Errorf("expected Service targetPort %d, got %d", iafk8s.OAuthProxyPort, got)
+	}
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp-oauth-proxy", Namespace: "test-ns"}, &secret); err != nil {
+		t.Errorf("expected oauth-proxy Secret to be created: %v", err)
+	}
+}
+
+// TestReconcile_AccessMiddlewares verifies spec.access renders Traefik
+// middlewares that follow spec changes and are removed when cleared.
+func TestReconcile_AccessMiddlewares(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+
+	app := makeApp("myapp", "test-ns")
+	app.Spec.Access = &iafv1alpha1.AccessConfig{IPAllowList: []string{"10.0.0.0/8"}, RequestsPerSecond: 5}
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	getMiddleware := func(name string) (*unstructured.Unstructured, error) {
+		mw := &unstructured.Unstructured{}
+		mw.SetGroupVersionKind(iafk8s.TraefikMiddlewareGVK)
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: "test-ns"}, mw)
+		return mw, err
+	}
+	if _, err := getMiddleware("myapp-ipallowlist"); err != nil {
+		t.Fatalf("expected ip allowlist middleware: %v", err)
+	}
+	if _, err := getMiddleware("myapp-ratelimit"); err != nil {
+		t.Fatalf("expected rate limit middleware: %v", err)
+	}
+
+	var current iafv1alpha1.Application
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &current); err != nil {
+		t.Fatal(err)
+	}
+	current.Spec.Access = &iafv1alpha1.AccessConfig{IPAllowList: []string{"192.168.0.0/16"}}
+	if err := r.Update(ctx, &current); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	mw, err := getMiddleware("myapp-ipallowlist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceRange, _, _ := unstructured.NestedStringSlice(mw.Object, "spec", "ipAllowList", "sourceRange")
+	if len(sourceRange) != 1 || sourceRange[0] != "192.168.0.0/16" {
+		t.Errorf("expected updated sourceRange, got %v", sourceRange)
+	}
+	if _, err := getMiddleware("myapp-ratelimit"); !apierrors.IsNotFound(err) {
+		t.Errorf("expected rate limit middleware to be deleted, got err=%v", err)
+	}
+}
+
+// TestReconcile_UptimeCheck verifies spec.uptimeCheck renders a Probe for
+// the app URL that is removed when the check is cleared, and that apps
+// asking for a check report it unavailable without a blackbox exporter.
+func TestReconcile_UptimeCheck(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	r.BlackboxModule = "http_2xx"
+	r.ProbeLabels = map[string]string{"release": "kube-prometheus-stack"}
+	ctx := context.Background()
+
+	app := makeApp("myapp", "test-ns")
+	app.Spec.UptimeCheck = &iafv1alpha1.UptimeCheckConfig{Path: "/healthz"}
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	var current iafv1alpha1.Application
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &current); err != nil {
+		t.Fatal(err)
+	}
+	if c := meta.FindStatusCondition(current.Status.Conditions, "UptimeCheck"); c == nil || c.Reason != "ProberNotConfigured" {
+		t.Fatalf("expected UptimeCheck condition ProberNotConfigured, got %+v", c)
+	}
+
+	r.BlackboxExporter = "blackbox.monitoring:9115"
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	probe := &unstructured.Unstructured{}
+	probe.SetGroupVersionKind(iafk8s.ProbeGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, probe); err != nil {
+		t.Fatalf("expected uptime check Probe: %v", err)
+	}
+	targets, _, _ := unstructured.NestedStringSlice(probe.Object, "spec", "targets", "staticConfig", "static")
+	if len(targets) != 1 || targets[0] != "http://myapp.example.com/healthz" {
+		t.Errorf("unexpected probe targets %v", targets)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &current); err != nil {
+		t.Fatal(err)
+	}
+	if c := meta.FindStatusCondition(current.Status.Conditions, "UptimeCheck"); c == nil || c.Status != metav1.ConditionTrue {
+		t.Errorf("expected UptimeCheck condition True, got %+v", c)
+	}
+
+	current.Spec.UptimeCheck = nil
+	if err := r.Update(ctx, &current); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, probe); !apierrors.IsNotFound(err) {
+		t.Errorf("expected Probe to be deleted, got err=%v", err)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &current); err != nil {
+		t.Fatal(err)
+	}
+	if c := meta.FindStatusCondition(current.Status.Conditions, "UptimeCheck"); c != nil {
+		t.Errorf("expected no UptimeCheck condition, got %+v", c)
+	}
+}
+
+// TestReconcile_DriftReverted verifies that external edits to fields the
+// controller manages are reverted and reported, while fields it does not
+// manage (operator-added tolerations) survive reconciliation.
+func TestReconcile_DriftReverted(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+
+	app := makeApp("myapp", "test-ns")
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	var result iafv1alpha1.Application
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &result); err != nil {
+		t.Fatal(err)
+	}
+	if meta.FindStatusCondition(result.Status.Conditions, "Drifted") != nil {
+		t.Fatal("expected no Drifted condition without external changes")
+	}
+
+	var dep appsv1.Deployment
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &dep); err != nil {
+		t.Fatal(err)
+	}
+	replicas := int32(5)
+	dep.Spec.Replicas = &replicas
+	dep.Spec.Template.Spec.Tolerations = []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}}
+	if err := r.Update(ctx, &dep, client.FieldOwner("kubectl-edit")); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &dep); err != nil {
+		t.Fatal(err)
+	}
+	if *dep.Spec.Replicas != 1 {
+		t.Errorf("expected replicas restored to 1, got %d", *dep.Spec.Replicas)
+	}
+	if len(dep.Spec.Template.Spec.Tolerations) != 1 {
+		t.Errorf("expected operator toleration to survive, got %v", dep.Spec.Template.Spec.Tolerations)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &result); err != nil {
+		t.Fatal(err)
+	}
+	cond := meta.FindStatusCondition(result.Status.Conditions, "Drifted")
+	if cond == nil || cond.Status != metav1.ConditionTrue {
+		t.Fatalf("expected Drifted=True, got %+v", cond)
+	}
+	if !strings.Contains(cond.Message, `Deployment "myapp"`) {
+		t.Errorf("expected message to name the Deployment, got %q", cond.Message)
+	}
+}
+
+// TestReconcile_Overrides verifies spec.overrides patches are merged into the
+// generated objects and that patches weakening pod security fail the app.
+func TestReconcile_Overrides(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides iafv1alpha1.ApplicationOverrides
+		wantPhase iafv1alpha1.ApplicationPhase
+	}{
+		{
+			name: "tolerations and service annotation",
+			overrides: iafv1alpha1.ApplicationOverrides{
+				Deployment: &runtime.RawExtension{Raw: []byte(`{"spec":{"template":{"spec":{"tolerations":[{"key":"gpu","operator":"Exists"}]}}}}`)},
+				Service:    &runtime.RawExtension{Raw: []byte(`{"metadata":{"annotations":{"example.com/scrape":"true"}}}`)},
+			},
+			wantPhase: iafv1alpha1.ApplicationPhaseDeploying,
+		},
+		{
+			name: "host network rejected",
+			overrides: iafv1alpha1.ApplicationOverrides{
+				Deployment: &runtime.RawExtension{Raw: []byte(`{"spec":{"template":{"spec":{"hostNetwork":true}}}}`)},
+			},
+			wantPhase: iafv1alpha1.ApplicationPhaseFailed,
+		},
+		{
+			name: "root container rejected",
+			overrides: iafv1alpha1.ApplicationOverrides{
+				Deployment: &runtime.RawExtension{Raw: []byte(`{"spec":{"template":{"spec":{"containers":[{"name":"app","securityContext":{"runAsUser":0}}]}}}}`)},
+			},
+			wantPhase: iafv1alpha1.ApplicationPhaseFailed,
+		},
+		{
+			name: "node port rejected",
+			overrides: iafv1alpha1.ApplicationOverrides{
+				Service: &runtime.RawExtension{Raw: []byte(`{"spec":{"type":"NodePort"}}`)},
+			},
+			wantPhase: iafv1alpha1.ApplicationPhaseFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := newTestScheme(t)
+			r := newReconciler(scheme)
+			ctx := context.Background()
+
+			app := makeApp("myapp", "test-ns")
+			app.Spec.Overrides = &tt.overrides
+			if err := r.Create(ctx, app); err != nil {
+				t.Fatal(err)
+			}
+			reconcileApp(t, r, "myapp", "test-ns")
+
+			var result iafv1alpha1.Application
+			if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &result); err != nil {
+				t.Fatal(err)
+			}
+			if result.Status.Phase != tt.wantPhase {
+				t.Fatalf("expected phase %q, got %q (%+v)", tt.wantPhase, result.Status.Phase, result.Status.Conditions)
+			}
+			if tt.wantPhase == iafv1alpha1.ApplicationPhaseFailed {
+				if c := meta.FindStatusCondition(result.Status.Conditions, "Ready"); c == nil || c.Reason != "InvalidOverrides" {
+					t.Errorf("expected Ready reason InvalidOverrides, got %+v", c)
+				}
+				return
+			}
+
+			var dep appsv1.Deployment
+			if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &dep); err != nil {
+				t.Fatal(err)
+			}
+			if tol := dep.Spec.Template.Spec.Tolerations; len(tol) != 1 || tol[0].Key != "gpu" {
+				t.Errorf("expected gpu toleration, got %v", tol)
+			}
+			if dep.Spec.Template.Spec.Containers[0].Image != "nginx:latest" {
+				t.Errorf("expected generated container to be kept, got %+v", dep.Spec.Template.Spec.Containers)
+			}
+			var svc corev1.Service
+			if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &svc); err != nil {
+				t.Fatal(err)
+			}
+			if svc.Annotations["example.com/scrape"] != "true" {
+				t.Errorf("expected service annotation from overrides, got %v", svc.Annotations)
+			}
+		})
+	}
+}
+
+// TestReconcile_Suspended verifies a suspended app is scaled to zero and
+// reported as Suspended instead of requeueing in Deploying.
+func TestReconcile_Suspended(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	r.SuspendedPage = iafk8s.ServiceRef{Namespace: "iaf-system", Name: "iaf-apiserver", Port: 8080}
+	ctx := context.Background()
+
+	app := makeApp("myapp", "test-ns")
+	app.Spec.Replicas = 2
+	app.Spec.Suspended = true
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	res := reconcileApp(t, r, "myapp", "test-ns")
+	if res.RequeueAfter != 0 {
+		t.Errorf("expected no requeue while suspended, got %v", res.RequeueAfter)
+	}
+
+	var dep appsv1.Deployment
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &dep); err != nil {
+		t.Fatal(err)
+	}
+	if *dep.Spec.Replicas != 0 {
+		t.Errorf("expected 0 replicas while suspended, got %d", *dep.Spec.Replicas)
+	}
+	var result iafv1alpha1.Application
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Status.Phase != iafv1alpha1.ApplicationPhaseSuspended {
+		t.Errorf("expected phase Suspended, got %q", result.Status.Phase)
+	}
+
+	mw := &unstructured.Unstructured{}
+	mw.SetGroupVersionKind(iafk8s.TraefikMiddlewareGVK)
+	mwKey := types.NamespacedName{Name: "myapp-suspended", Namespace: "test-ns"}
+	if err := r.Get(ctx, mwKey, mw); err != nil {
+		t.Fatalf("expected suspended page middleware: %v", err)
+	}
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(iafk8s.TraefikIngressRouteGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, route); err != nil {
+		t.Fatal(err)
+	}
+	routes, _, _ := unstructured.NestedSlice(route.Object, "spec", "routes")
+	if middlewares, _ := routes[0].(map[string]any)["middlewares"].([]any); len(middlewares) != 1 {
+		t.Errorf("expected the route to use the suspended page middleware, got %v", middlewares)
+	}
+
+	// Resuming restores the replicas and removes the page.
+	result.Spec.Suspended = false
+	if err := r.Update(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &dep); err != nil {
+		t.Fatal(err)
+	}
+	if *dep.Spec.Replicas != 2 {
+		t.Errorf("expected 2 replicas after resume, got %d", *dep.Spec.Replicas)
+	}
+	if err := r.Get(ctx, mwKey, mw); !apierrors.IsNotFound(err) {
+		t.Errorf("expected suspended page middleware to be deleted, got %v", err)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Status.Phase != iafv1alpha1.ApplicationPhaseDeploying {
+		t.Errorf("expected phase Deploying after resume, got %q", result.Status.Phase)
+	}
+}
+
+func TestReconcile_IdleSleepAndWake(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	r.SuspendedPage = iafk8s.ServiceRef{Namespace: "iaf-system", Name: "iaf-apiserver", Port: 8080}
+	r.WakeSecret = []byte("wake-secret")
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+
+	app := makeApp("myapp", "test-ns")
+	app.Annotations = map[string]string{iafv1alpha1.IdleAnnotation: iafv1alpha1.IdleSleeping}
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	if res := reconcileApp(t, r, "myapp", "test-ns"); res.RequeueAfter != 0 {
+		t.Errorf("expected no requeue while asleep, got %v", res.RequeueAfter)
+	}
+
+	var dep appsv1.Deployment
+	if err := r.Get(ctx, key, &dep); err != nil {
+		t.Fatal(err)
+	}
+	if *dep.Spec.Replicas != 0 {
+		t.Errorf("expected 0 replicas while asleep, got %d", *dep.Spec.Replicas)
+	}
+	var result iafv1alpha1.Application
+	if err := r.Get(ctx, key, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Status.Phase != iafv1alpha1.ApplicationPhaseSleeping {
+		t.Errorf("expected phase Sleeping, got %q", result.Status.Phase)
+	}
+	mw := &unstructured.Unstructured{}
+	mw.SetGroupVersionKind(iafk8s.TraefikMiddlewareGVK)
+	mwKey := types.NamespacedName{Name: "myapp-wake", Namespace: "test-ns"}
+	if err := r.Get(ctx, mwKey, mw); err != nil {
+		t.Fatalf("expected wake middleware: %v", err)
+	}
+	query, _, _ := unstructured.NestedString(mw.Object, "spec", "errors", "query")
+	if query != idle.WakePath(r.WakeSecret, "test-ns", "myapp") {
+		t.Errorf("expected signed wake path, got %q", query)
+	}
+
+	// A request marks the app as waking: it scales up but keeps the holding
+	// page until it is Running.
+	result.Annotations[iafv1alpha1.IdleAnnotation] = iafv1alpha1.IdleWaking
+	if err := r.Update(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if err := r.Get(ctx, key, &dep); err != nil {
+		t.Fatal(err)
+	}
+	if *dep.Spec.Replicas != 1 {
+		t.Errorf("expected 1 replica while waking, got %d", *dep.Spec.Replicas)
+	}
+	if err := r.Get(ctx, mwKey, mw); err != nil {
+		t.Errorf("expected wake middleware to remain while waking: %v", err)
+	}
+
+	dep.Status.AvailableReplicas = 1
+	if err := r.Status().Update(ctx, &dep); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if err := r.Get(ctx, key, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Status.Phase != iafv1alpha1.ApplicationPhaseRunning {
+		t.Errorf("expected phase Running, got %q", result.Status.Phase)
+	}
+	if _, ok := result.Annotations[iafv1alpha1.IdleAnnotation]; ok {
+		t.Error("expected idle annotation to be cleared once Running")
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if err := r.Get(ctx, mwKey, mw); !apierrors.IsNotFound(err) {
+		t.Errorf("expected wake middleware to be deleted, got %v", err)
+	}
+}
+
+func TestReconcile_TTL(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "demo", Namespace: "test-ns"}
+
+	app := makeApp("demo", "test-ns")
+	app.CreationTimestamp = metav1.NewTime(time.Now().Add(-70 * time.Hour))
+	app.Spec.TTL = &metav1.Duration{Duration: 72 * time.Hour}
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	res := reconcileApp(t, r, "demo", "test-ns")
+	if res.RequeueAfter <= 0 || res.RequeueAfter > 2*time.Hour {
+		t.Errorf("expected requeue by expiry, got %v", res.RequeueAfter)
+	}
+	var result iafv1alpha1.Application
+	if err := r.Get(ctx, key, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Status.ExpiresAt == nil || !result.Status.ExpiresAt.Time.Equal(app.CreationTimestamp.Add(72*time.Hour).Truncate(time.Second)) {
+		t.Errorf("unexpected expiresAt %v", result.Status.ExpiresAt)
+	}
+	cond := meta.FindStatusCondition(result.Status.Conditions, conditionExpiring)
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.Reason != "ExpiresSoon" {
+		t.Fatalf("expected Expiring=True/ExpiresSoon, got %+v", cond)
+	}
+
+	// An elapsed TTL waits while the application is deletion protected.
+	result.Spec.TTL = &metav1.Duration{Duration: time.Hour}
+	result.Spec.DeletionProtected = true
+	if err := r.Update(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "demo", "test-ns")
+	if err := r.Get(ctx, key, &result); err != nil {
+		t.Fatalf("expected protected application to be kept, got %v", err)
+	}
+	cond = meta.FindStatusCondition(result.Status.Conditions, conditionExpiring)
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.Reason != "ExpiryBlocked" {
+		t.Fatalf("expected Expiring=True/ExpiryBlocked, got %+v", cond)
+	}
+
+	// Once the protection is cleared the application is deleted.
+	result.Spec.DeletionProtected = false
+	if err := r.Update(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "demo", "test-ns")
+	if err := r.Get(ctx, key, &result); !apierrors.IsNotFound(err) {
+		t.Errorf("expected expired application to be deleted, got %v", err)
+	}
+}
+
+// TestReconcile_SoftDelete verifies an app marked deleted is scaled to zero
+// and kept until its retention window passes, that removing the mark
+// restores it, and that it is removed once the window has passed.
+func TestReconcile_SoftDelete(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	r.DeletedRetention = 24 * time.Hour
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "demo", Namespace: "test-ns"}
+
+	if err := r.Create(ctx, makeApp("demo", "test-ns")); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "demo", "test-ns")
+
+	var app iafv1alpha1.Application
+	if err := r.Get(ctx, key, &app); err != nil {
+		t.Fatal(err)
+	}
+	deletedAt := time.Now().Add(-time.Hour).UTC()
+	app.Annotations = map[string]string{iafv1alpha1.DeletedAtAnnotation: deletedAt.Format(time.RFC3339)}
+	if err := r.Update(ctx, &app); err != nil {
+		t.Fatal(err)
+	}
+	res := reconcileApp(t, r, "demo", "test-ns")
+	if res.RequeueAfter < 22*time.Hour || res.RequeueAfter > 23*time.Hour {
+		t.Errorf("expected requeue at the end of the retention window, got %v", res.RequeueAfter)
+	}
+	if err := r.Get(ctx, key, &app); err != nil {
+		t.Fatal(err)
+	}
+	if app.Status.Phase != iafv1alpha1.ApplicationPhaseDeleted {
+		t.Errorf("expected phase Deleted, got %q", app.Status.Phase)
+	}
+	if want := deletedAt.Add(24 * time.Hour).Truncate(time.Second); app.Status.PurgeAt == nil || !app.Status.PurgeAt.Time.Equal(want) {
+		t.Errorf("expected purgeAt %v, got %v", want, app.Status.PurgeAt)
+	}
+	var dep appsv1.Deployment
+	if err := r.Get(ctx, key, &dep); err != nil {
+		t.Fatal(err)
+	}
+	if dep.Spec.Replicas == nil || *dep.Spec.Replicas != 0 {
+		t.Errorf("expected the deployment to be scaled to zero, got %v", dep.Spec.Replicas)
+	}
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(iafk8s.TraefikIngressRouteGVK)
+	if err := r.Get(ctx, key, route); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the route to be removed, got %v", err)
+	}
+
+	// Removing the mark restores the configured replicas.
+	delete(app.Annotations, iafv1alpha1.DeletedAtAnnotation)
+	if err := r.Update(ctx, &app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "demo", "test-ns")
+	if err := r.Get(ctx, key, &app); err != nil {
+		t.Fatal(err)
+	}
+	if app.Status.Phase == iafv1alpha1.ApplicationPhaseDeleted || app.Status.PurgeAt != nil {
+		t.Errorf("expected the restored app to leave phase Deleted, got %q purgeAt %v", app.Status.Phase, app.Status.PurgeAt)
+	}
+	if err := r.Get(ctx, key, &dep); err != nil {
+		t.Fatal(err)
+	}
+	if dep.Spec.Replicas == nil || *dep.Spec.Replicas != 1 {
+		t.Errorf("expected the deployment to be scaled back to 1, got %v", dep.Spec.Replicas)
+	}
+	if err := r.Get(ctx, key, route); err != nil {
+		t.Errorf("expected the route to be created again, got %v", err)
+	}
+
+	// Past the window a protected app is kept, and removed once the
+	// protection is cleared.
+	app.Annotations = map[string]string{iafv1alpha1.DeletedAtAnnotation: time.Now().Add(-25 * time.Hour).UTC().Format(time.RFC3339)}
+	app.Spec.DeletionProtected = true
+	if err := r.Update(ctx, &app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "demo", "test-ns")
+	if err := r.Get(ctx, key, &app); err != nil {
+		t.Fatalf("expected protected application to be kept, got %v", err)
+	}
+	app.Spec.DeletionProtected = false
+	if err := r.Update(ctx, &app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "demo", "test-ns")
+	if err := r.Get(ctx, key, &app); !apierrors.IsNotFound(err) {
+		t.Errorf("expected application to be purged, got %v", err)
+	}
+}
+
+func int32Ptr(i int32) *int32 { return &i }
+
+// TestReconcile_ProxyEnv verifies the platform proxy is set in the pod env
+// of apps, and left out for apps that opt out.
+func TestReconcile_ProxyEnv(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	r.Proxy = iafk8s.Proxy{HTTPSProxy: "http://proxy.corp:3128"}
+	ctx := context.Background()
+
+	disabled := false
+	for name, proxy := range map[string]*iafv1alpha1.ProxyConfig{"web": nil, "direct": {Enabled: &disabled}} {
+		app := makeApp(name, "test-ns")
+		app.Spec.Proxy = proxy
+		if err := r.Create(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+		reconcileApp(t, r, name, "test-ns")
+	}
+
+	for name, want := range map[string]string{"web": "http://proxy.corp:3128", "direct": ""} {
+		var dep appsv1.Deployment
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: "test-ns"}, &dep); err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		for _, e := range dep.Spec.Template.Spec.Containers[0].Env {
+			if e.Name == "HTTPS_PROXY" {
+				got = e.Value
+			}
+		}
+		if got != want {
+			t.Errorf("%s: HTTPS_PROXY = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// TestReconcile_GitTracking verifies a git app records the commit it was
+// built from and pins its kpack Image to that commit, that tracking the
+// branch lets kpack build each new commit, and that pausing pins it again.
+func TestReconcile_GitTracking(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+
+	if err := r.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-ns"}}); err != nil {
+		t.Fatal(err)
+	}
+	app := makeApp("myapp", "test-ns")
+	app.Spec.Image = ""
+	app.Spec.Git = &iafv1alpha1.GitSource{URL: "https://github.com/example/myapp", Revision: "main"}
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+	revision := func() string {
+		t.Helper()
+		kpackImage := &unstructured.Unstructured{}
+		kpackImage.SetGroupVersionKind(iafk8s.KpackImageGVK)
+		if err := r.Get(ctx, key, kpackImage); err != nil {
+			t.Fatal(err)
+		}
+		rev, _, _ := unstructured.NestedString(kpackImage.Object, "spec", "source", "git", "revision")
+		return rev
+	}
+	if got := revision(); got != "main" {
+		t.Fatalf("expected the first build from branch main, got %q", got)
+	}
+
+	// kpack builds commit 0123abc and reports the Image ready.
+	const commit, image = "0123abcdef0123abcdef0123abcdef0123abcdef", "registry.example.com/myapp@sha256:aaa"
+	build := &unstructured.Unstructured{}
+	build.SetGroupVersionKind(iafk8s.KpackBuildGVK)
+	build.SetName("myapp-build-1")
+	build.SetNamespace("test-ns")
+	build.SetLabels(map[string]string{iafk8s.LabelKpackImage: "myapp", iafk8s.LabelKpackBuildNumber: "1"})
+	build.Object["spec"] = map[string]any{"source": map[string]any{"git": map[string]any{"url": app.Spec.Git.URL, "revision": commit}}}
+	build.Object["status"] = map[string]any{
+		"latestImage": image,
+		"conditions":  []any{map[string]any{"type": "Succeeded", "status": "True"}},
+	}
+	if err := r.Create(ctx, build); err != nil {
+		t.Fatal(err)
+	}
+	kpackImage := &unstructured.Unstructured{}
+	kpackImage.SetGroupVersionKind(iafk8s.KpackImageGVK)
+	if err := r.Get(ctx, key, kpackImage); err != nil {
+		t.Fatal(err)
+	}
+	kpackImage.Object["status"] = map[string]any{
+		"observedGeneration": kpackImage.GetGeneration(),
+		"latestImage":        image,
+		"conditions":         []any{map[string]any{"type": "Ready", "status": "True"}},
+	}
+	if err := r.Update(ctx, kpackImage); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	if g := app.Status.Git; g == nil || g.Commit != commit || g.LatestCommit != commit || g.AutoDeploy {
+		t.Fatalf("expected deployed commit %s without auto-deploy, got %+v", commit, g)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if got := revision(); got != commit {
+		t.Fatalf("expected the kpack Image pinned to %s, got %q", commit, got)
+	}
+
+	setGit := func(track iafv1alpha1.GitTrackMode, paused bool) {
+		t.Helper()
+		if err := r.Get(ctx, key, app); err != nil {
+			t.Fatal(err)
+		}
+		app.Spec.Git.Track, app.Spec.Git.Paused = track, paused
+		if err := r.Update(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+		reconcileApp(t, r, "myapp", "test-ns")
+	}
+	setGit(iafv1alpha1.GitTrackBranch, false)
+	if got := revision(); got != "main" {
+		t.Errorf("expected tracking to build branch main, got %q", got)
+	}
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	if g := app.Status.Git; g == nil || !g.AutoDeploy || g.Commit != commit {
+		t.Errorf("expected auto-deploy keeping commit %s, got %+v", commit, g)
+	}
+
+	setGit(iafv1alpha1.GitTrackBranch, true)
+	if got := revision(); got != commit {
+		t.Errorf("expected pausing to pin %s, got %q", commit, got)
+	}
+}
+
+// TestReconcile_Command verifies spec.command and spec.args reach the app
+// container, and that removing them restores the image entrypoint.
+func TestReconcile_Command(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+
+	app := makeApp("worker", "test-ns")
+	app.Spec.Command = []string{"node", "worker.js"}
+	app.Spec.Args = []string{"--queue", "mail"}
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "worker", "test-ns")
+
+	key := types.NamespacedName{Name: "worker", Namespace: "test-ns"}
+	container := func() corev1.Container {
+		t.Helper()
+		var dep appsv1.Deployment
+		if err := r.Get(ctx, key, &dep); err != nil {
+			t.Fatal(err)
+		}
+		return dep.Spec.Template.Spec.Containers[0]
+	}
+	if c := container(); !slices.Equal(c.Command, []string{"node", "worker.js"}) || !slices.Equal(c.Args, []string{"--queue", "mail"}) {
+		t.Fatalf("unexpected command %q args %q", c.Command, c.Args)
+	}
+
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	app.Spec.Command, app.Spec.Args = nil, nil
+	if err := r.Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "worker", "test-ns")
+	if c := container(); c.Command != nil || c.Args != nil {
+		t.Errorf("expected the image entrypoint, got command %q args %q", c.Command, c.Args)
+	}
+}
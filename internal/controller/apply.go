@@ -0,0 +1,255 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// fieldOwner is the server-side apply field manager for generated objects.
+// Fields set by other managers (e.g. tolerations patched in by an operator)
+// are left alone; fields owned by fieldOwner are forced back on every pass.
+const fieldOwner = "iaf-controller"
+
+// desiredHashAnnotation records a hash of the last applied configuration so
+// a reconcile can tell its own spec changes apart from external edits.
+const desiredHashAnnotation = "iaf.io/desired-hash"
+
+// errInvalidOverrides marks spec.overrides patches the controller refuses to apply.
+var errInvalidOverrides = errors.New("invalid spec.overrides")
+
+// errImageOutsideRegistry marks a build whose image kpack reports outside the
+// registry prefix allowed for the application's namespace.
+var errImageOutsideRegistry = errors.New("image outside the allowed registry prefix")
+
+// errBuilderNotAllowed marks an application whose spec.builder is not one of
+// the ClusterBuilders the operator allows.
+var errBuilderNotAllowed = errors.New("builder not allowed")
+
+// errRuntimeNotAllowed marks an application whose build env selects a
+// runtime version the organisation standards do not allow.
+var errRuntimeNotAllowed = errors.New("runtime version not allowed")
+
+// errDependencyNotAllowed marks an application whose built image ships a
+// package the organisation dependency policy blocks.
+var errDependencyNotAllowed = errors.New("dependency not allowed")
+
+// applyOwned server-side applies desired and returns the resulting object.
+// It reports drift when the live object already carried the same desired
+// hash, i.e. the controller's intent is unchanged, yet applying it changed
+// the object's labels or spec: someone else modified fields the controller owns.
+func (r *ApplicationReconciler) applyOwned(ctx context.Context, desired client.Object) (*unstructured.Unstructured, bool, error) {
+	gvk, err := apiutil.GVKForObject(desired, r.Scheme)
+	if err != nil {
+		return nil, false, err
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		return nil, false, fmt.Errorf("converting %s: %w", gvk.Kind, err)
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(gvk)
+	// Zero-valued fields from the typed struct would otherwise claim ownership.
+	delete(u.Object, "status")
+	unstructured.RemoveNestedField(u.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(u.Object, "spec", "template", "metadata", "creationTimestamp")
+
+	data, err := json.Marshal(u.Object)
+	if err != nil {
+		return nil, false, fmt.Errorf("hashing %s: %w", gvk.Kind, err)
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:8])
+	annotations := u.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[desiredHashAnnotation] = hash
+	u.SetAnnotations(annotations)
+
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(gvk)
+	key := client.ObjectKeyFromObject(desired)
+	existed := true
+	if err := r.Get(ctx, key, live); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, false, fmt.Errorf("getting %s: %w", strings.ToLower(gvk.Kind), err)
+		}
+		existed = false
+	}
+
+	if err := r.Apply(ctx, client.ApplyConfigurationFromUnstructured(u), client.FieldOwner(fieldOwner), client.ForceOwnership); err != nil {
+		return nil, false, fmt.Errorf("applying %s: %w", strings.ToLower(gvk.Kind), err)
+	}
+	applied := &unstructured.Unstructured{}
+	applied.SetGroupVersionKind(gvk)
+	if err := r.Get(ctx, key, applied); err != nil {
+		return nil, false, fmt.Errorf("getting %s: %w", strings.ToLower(gvk.Kind), err)
+	}
+
+	drifted := existed && live.GetAnnotations()[desiredHashAnnotation] == hash &&
+		(!equality.Semantic.DeepEqual(live.GetLabels(), applied.GetLabels()) ||
+			!equality.Semantic.DeepEqual(live.Object["spec"], applied.Object["spec"]))
+	if drifted {
+		log.FromContext(ctx).Info("reverted external changes to managed fields", "kind", gvk.Kind, "name", key.Name)
+	}
+	return applied, drifted, nil
+}
+
+// recordDrift sets the Drifted condition when this pass reverted external
+// edits. The condition stays True until the application spec changes.
+func recordDrift(app *iafv1alpha1.Application, drifted []string) {
+	if len(drifted) > 0 {
+		setCondition(app, "Drifted", metav1.ConditionTrue, "ExternalChange",
+			fmt.Sprintf("%s modified outside IAF; managed fields were restored. Use spec.overrides to customize generated objects.",
+				strings.Join(drifted, " and ")))
+		meta.FindStatusCondition(app.Status.Conditions, "Drifted").ObservedGeneration = app.Generation
+		return
+	}
+	if c := meta.FindStatusCondition(app.Status.Conditions, "Drifted"); c != nil &&
+		c.Status == metav1.ConditionTrue && c.ObservedGeneration < app.Generation {
+		setCondition(app, "Drifted", metav1.ConditionFalse, "InSync", "Generated objects match the application spec")
+	}
+}
+
+// strategicMerge applies a strategic merge patch from spec.overrides to obj.
+func strategicMerge[T any](obj *T, patch *runtime.RawExtension) error {
+	if patch == nil || len(patch.Raw) == 0 {
+		return nil
+	}
+	original, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	var schema T
+	merged, err := strategicpatch.StrategicMergePatch(original, patch.Raw, schema)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errInvalidOverrides, err)
+	}
+	var out T
+	if err := json.Unmarshal(merged, &out); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidOverrides, err)
+	}
+	*obj = out
+	return nil
+}
+
+// overrideDeployment merges spec.overrides.deployment into desired and
+// rejects patches that change identity, selectors, or pod security settings.
+func overrideDeployment(app *iafv1alpha1.Application, desired *appsv1.Deployment) error {
+	if app.Spec.Overrides == nil || app.Spec.Overrides.Deployment == nil {
+		return nil
+	}
+	original := desired.DeepCopy()
+	if err := strategicMerge(desired, app.Spec.Overrides.Deployment); err != nil {
+		return err
+	}
+	if err := checkIdentity(&original.ObjectMeta, &desired.ObjectMeta); err != nil {
+		return fmt.Errorf("%w: deployment %v", errInvalidOverrides, err)
+	}
+	if !equality.Semantic.DeepEqual(original.Spec.Selector, desired.Spec.Selector) ||
+		desired.Spec.Template.Labels["iaf.io/application"] != app.Name {
+		return fmt.Errorf("%w: deployment patch may not change the pod selector or labels", errInvalidOverrides)
+	}
+	if err := checkPodSecurity(&desired.Spec.Template.Spec); err != nil {
+		return fmt.Errorf("%w: deployment %v", errInvalidOverrides, err)
+	}
+	return nil
+}
+
+// overrideService merges spec.overrides.service into desired. The Service
+// must stay cluster-internal so traffic keeps flowing through the ingress
+// route and its authentication and access middlewares.
+func overrideService(app *iafv1alpha1.Application, desired *corev1.Service) error {
+	if app.Spec.Overrides == nil || app.Spec.Overrides.Service == nil {
+		return nil
+	}
+	original := desired.DeepCopy()
+	if err := strategicMerge(desired, app.Spec.Overrides.Service); err != nil {
+		return err
+	}
+	if err := checkIdentity(&original.ObjectMeta, &desired.ObjectMeta); err != nil {
+		return fmt.Errorf("%w: service %v", errInvalidOverrides, err)
+	}
+	if !equality.Semantic.DeepEqual(original.Spec.Selector, desired.Spec.Selector) {
+		return fmt.Errorf("%w: service patch may not change the selector", errInvalidOverrides)
+	}
+	if (desired.Spec.Type != "" && desired.Spec.Type != corev1.ServiceTypeClusterIP) || len(desired.Spec.ExternalIPs) > 0 {
+		return fmt.Errorf("%w: service patch may not expose the service outside the cluster", errInvalidOverrides)
+	}
+	return nil
+}
+
+// checkIdentity rejects patches to the name, namespace, owner, or IAF labels.
+func checkIdentity(original, patched *metav1.ObjectMeta) error {
+	if original.Name != patched.Name || original.Namespace != patched.Namespace ||
+		!equality.Semantic.DeepEqual(original.OwnerReferences, patched.OwnerReferences) {
+		return errors.New("patch may not change the name, namespace, or owner")
+	}
+	for k, v := range original.Labels {
+		if patched.Labels[k] != v {
+			return fmt.Errorf("patch may not change label %q", k)
+		}
+	}
+	return nil
+}
+
+// checkPodSecurity enforces the platform's pod security baseline: non-root,
+// no privilege escalation or added capabilities, no host namespaces or host
+// paths, and the namespace's default service account.
+func checkPodSecurity(spec *corev1.PodSpec) error {
+	if spec.HostNetwork || spec.HostPID || spec.HostIPC {
+		return errors.New("patch may not use host namespaces")
+	}
+	if spec.ServiceAccountName != "" || spec.DeprecatedServiceAccount != "" {
+		return errors.New("patch may not set a service account")
+	}
+	if sc := spec.SecurityContext; sc == nil || sc.RunAsNonRoot == nil || !*sc.RunAsNonRoot ||
+		(sc.RunAsUser != nil && *sc.RunAsUser == 0) {
+		return errors.New("patch may not run the pod as root")
+	}
+	for _, v := range spec.Volumes {
+		if v.HostPath != nil {
+			return fmt.Errorf("patch may not mount host path volume %q", v.Name)
+		}
+	}
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, c := range containers {
+		sc := c.SecurityContext
+		if sc == nil {
+			continue
+		}
+		switch {
+		case sc.Privileged != nil && *sc.Privileged:
+			return fmt.Errorf("patch may not make container %q privileged", c.Name)
+		case sc.AllowPrivilegeEscalation != nil && *sc.AllowPrivilegeEscalation:
+			return fmt.Errorf("patch may not allow privilege escalation in container %q", c.Name)
+		case sc.RunAsNonRoot != nil && !*sc.RunAsNonRoot, sc.RunAsUser != nil && *sc.RunAsUser == 0:
+			return fmt.Errorf("patch may not run container %q as root", c.Name)
+		case sc.Capabilities != nil && len(sc.Capabilities.Add) > 0:
+			return fmt.Errorf("patch may not add capabilities to container %q", c.Name)
+		}
+	}
+	if len(spec.EphemeralContainers) > 0 {
+		return errors.New("patch may not add ephemeral containers")
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// errBackendTLSUnavailable marks an app asking for TLS from the ingress when
+// the platform has no issuer for serving certificates. The app fails rather
+// than receive plaintext traffic it does not expect.
+var errBackendTLSUnavailable = errors.New("backend TLS unavailable")
+
+// backendTLSHashAnnotation is set on the pod template to a hash of the app's
+// serving certificate, so a renewal rolls the Deployment and apps need not
+// reload it.
+const backendTLSHashAnnotation = "iaf.io/backend-tls-hash"
+
+// reconcileBackendTLS ensures the serving Certificate and the Traefik
+// ServersTransport of an app with spec.backendTLS, or deletes them when the
+// app has it off. It returns the volume and mount of the certificate and a
+// hash of it for the pod template; all zero when backend TLS is off. The
+// hash is empty until cert-manager issues the certificate, and pods wait for
+// its Secret to start.
+func (r *ApplicationReconciler) reconcileBackendTLS(ctx context.Context, app *iafv1alpha1.Application) (*corev1.Volume, *corev1.VolumeMount, string, error) {
+	if !iafv1alpha1.IsBackendTLSEnabled(app) {
+		return nil, nil, "", r.deleteBackendTLS(ctx, app)
+	}
+	if r.BackendTLSIssuer == "" {
+		return nil, nil, "", fmt.Errorf("%w: the platform has no issuer for serving certificates; turn backendTLS off", errBackendTLSUnavailable)
+	}
+	// The ServersTransport is created first and deleted last, so it marks
+	// an app that may have backend TLS objects to clean up.
+	if err := r.applyUnstructured(ctx, iafk8s.BuildServersTransport(app)); err != nil {
+		return nil, nil, "", fmt.Errorf("applying servers transport: %w", err)
+	}
+	if _, err := r.applyCertificate(ctx, iafk8s.BuildBackendCertificate(app, r.BackendTLSIssuer)); err != nil {
+		return nil, nil, "", err
+	}
+
+	var hash string
+	var secret corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{Name: iafk8s.BackendTLSName(app.Name), Namespace: app.Namespace}, &secret); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, nil, "", fmt.Errorf("getting serving certificate: %w", err)
+		}
+	} else if cert := secret.Data[corev1.TLSCertKey]; len(cert) > 0 {
+		sum := sha256.Sum256(cert)
+		hash = hex.EncodeToString(sum[:])
+	}
+	volume, mount := iafk8s.BackendTLSMount(app)
+	return &volume, &mount, hash, nil
+}
+
+// deleteBackendTLS deletes the ServersTransport of the app, its serving
+// Certificate and the Certificate's Secret, if backend TLS was on before.
+func (r *ApplicationReconciler) deleteBackendTLS(ctx context.Context, app *iafv1alpha1.Application) error {
+	name := iafk8s.BackendTLSName(app.Name)
+	transport := &unstructured.Unstructured{}
+	transport.SetGroupVersionKind(iafk8s.TraefikServersTransportGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: app.Namespace}, transport); err != nil {
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil
+		}
+		return fmt.Errorf("getting servers transport: %w", err)
+	}
+	if !ownedByApp(transport, app) {
+		return nil
+	}
+	cert := &unstructured.Unstructured{}
+	cert.SetGroupVersionKind(iafk8s.CertificateGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: app.Namespace}, cert); err != nil {
+		if !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return fmt.Errorf("getting serving certificate: %w", err)
+		}
+	} else if ownedByApp(cert, app) {
+		if err := r.deleteIfExists(ctx, cert); err != nil {
+			return fmt.Errorf("deleting serving certificate: %w", err)
+		}
+	}
+	if err := r.deleteOwnedSecret(ctx, app, name); err != nil {
+		return err
+	}
+	if err := r.deleteIfExists(ctx, transport); err != nil {
+		return fmt.Errorf("deleting servers transport: %w", err)
+	}
+	return nil
+}
+
+// ownedByApp reports whether app is among the owners of obj.
+func ownedByApp(obj *unstructured.Unstructured, app *iafv1alpha1.Application) bool {
+	return slices.ContainsFunc(obj.GetOwnerReferences(), func(ref metav1.OwnerReference) bool { return ref.UID == app.UID })
+}
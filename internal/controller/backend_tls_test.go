@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// TestReconcile_BackendTLS verifies an app with backend TLS gets a serving
+// Certificate and a ServersTransport, that its route connects over TLS and
+// its pods mount the certificate, that a renewal rolls the pods, and that
+// turning it off removes everything.
+func TestReconcile_BackendTLS(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	r.BackendTLSIssuer = "internal-ca"
+	ctx := context.Background()
+
+	app := makeApp("myapp", "test-ns")
+	app.Spec.BackendTLS = &iafv1alpha1.BackendTLSConfig{Enabled: true}
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	tlsKey := types.NamespacedName{Name: "myapp-backend-tls", Namespace: "test-ns"}
+	cert := &unstructured.Unstructured{}
+	cert.SetGroupVersionKind(iafk8s.CertificateGVK)
+	if err := r.Get(ctx, tlsKey, cert); err != nil {
+		t.Fatalf("expected the serving certificate: %v", err)
+	}
+	if issuer, _, _ := unstructured.NestedString(cert.Object, "spec", "issuerRef", "name"); issuer != "internal-ca" {
+		t.Errorf("issuer = %q, want internal-ca", issuer)
+	}
+	transport := &unstructured.Unstructured{}
+	transport.SetGroupVersionKind(iafk8s.TraefikServersTransportGVK)
+	if err := r.Get(ctx, tlsKey, transport); err != nil {
+		t.Fatalf("expected the servers transport: %v", err)
+	}
+
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(iafk8s.TraefikIngressRouteGVK)
+	if err := r.Get(ctx, key, route); err != nil {
+		t.Fatal(err)
+	}
+	routes, _, _ := unstructured.NestedSlice(route.Object, "spec", "routes")
+	service := routes[0].(map[string]any)["services"].([]any)[0].(map[string]any)
+	if service["scheme"] != "https" || service["serversTransport"] != "myapp-backend-tls" {
+		t.Errorf("unexpected route service %v", service)
+	}
+
+	getDeployment := func() *appsv1.Deployment {
+		t.Helper()
+		var dep appsv1.Deployment
+		if err := r.Get(ctx, key, &dep); err != nil {
+			t.Fatal(err)
+		}
+		return &dep
+	}
+	container := getDeployment().Spec.Template.Spec.Containers[0]
+	if len(container.VolumeMounts) != 1 || container.VolumeMounts[0].MountPath != iafk8s.BackendTLSDir {
+		t.Errorf("unexpected volume mounts %+v", container.VolumeMounts)
+	}
+	var certFile string
+	for _, e := range container.Env {
+		if e.Name == "IAF_TLS_CERT_FILE" {
+			certFile = e.Value
+		}
+	}
+	if certFile != iafk8s.BackendTLSDir+"/tls.crt" {
+		t.Errorf("IAF_TLS_CERT_FILE = %q", certFile)
+	}
+
+	// Issuing and renewing the certificate rolls the pods.
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myapp-backend-tls",
+			Namespace: "test-ns",
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "iaf", "iaf.io/application": "myapp"},
+		},
+		Data: map[string][]byte{corev1.TLSCertKey: []byte("cert-1"), corev1.TLSPrivateKeyKey: []byte("key")},
+	}
+	if err := r.Create(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	hash := getDeployment().Spec.Template.Annotations[backendTLSHashAnnotation]
+	if hash == "" {
+		t.Fatal("expected the certificate hash on the pod template")
+	}
+	secret.Data[corev1.TLSCertKey] = []byte("cert-2")
+	if err := r.Update(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if next := getDeployment().Spec.Template.Annotations[backendTLSHashAnnotation]; next == "" || next == hash {
+		t.Errorf("expected the hash to change with the certificate, got %q (was %q)", next, hash)
+	}
+
+	// Turning it off removes the mount, the objects and the Secret.
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	app.Spec.BackendTLS = nil
+	if err := r.Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if mounts := getDeployment().Spec.Template.Spec.Containers[0].VolumeMounts; len(mounts) != 0 {
+		t.Errorf("expected no mounts after turning backend TLS off, got %+v", mounts)
+	}
+	for _, obj := range []*unstructured.Unstructured{cert, transport} {
+		if err := r.Get(ctx, tlsKey, obj); !apierrors.IsNotFound(err) {
+			t.Errorf("expected %s to be deleted, got %v", obj.GetKind(), err)
+		}
+	}
+	if err := r.Get(ctx, tlsKey, &corev1.Secret{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the certificate Secret to be deleted, got %v", err)
+	}
+	if err := r.Get(ctx, key, route); err != nil {
+		t.Fatal(err)
+	}
+	routes, _, _ = unstructured.NestedSlice(route.Object, "spec", "routes")
+	if service := routes[0].(map[string]any)["services"].([]any)[0].(map[string]any); service["scheme"] != nil {
+		t.Errorf("expected a plaintext route service, got %v", service)
+	}
+}
+
+// TestReconcile_BackendTLS_NoIssuer verifies apps asking for backend TLS
+// fail when the platform has no issuer for it.
+func TestReconcile_BackendTLS_NoIssuer(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+
+	app := makeApp("myapp", "test-ns")
+	app.Spec.BackendTLS = &iafv1alpha1.BackendTLSConfig{Enabled: true}
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, app); err != nil {
+		t.Fatal(err)
+	}
+	c := meta.FindStatusCondition(app.Status.Conditions, "Ready")
+	if app.Status.Phase != iafv1alpha1.ApplicationPhaseFailed || c == nil || c.Reason != "BackendTLSUnavailable" {
+		t.Errorf("expected phase Failed with BackendTLSUnavailable, got %s %+v", app.Status.Phase, c)
+	}
+}
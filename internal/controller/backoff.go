@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	defaultRequeueBase = 5 * time.Second
+	defaultRequeueMax  = 5 * time.Minute
+)
+
+// requeueBackoff computes per-application requeue delays for phases that
+// have to poll (waiting for a build or for replicas). Watches on kpack
+// Images, Builds and Deployments deliver most progress as events, so the
+// requeue is only a safety net: it starts at base and doubles on every pass
+// spent in the same phase and generation, up to the cap, with 10% jitter so
+// apps created together do not poll in lockstep.
+type requeueBackoff struct {
+	mu    sync.Mutex
+	state map[types.NamespacedName]backoffState
+}
+
+type backoffState struct {
+	generation int64
+	phase      iafv1alpha1.ApplicationPhase
+	attempts   int
+}
+
+// next returns the delay before the next poll of app in phase.
+func (b *requeueBackoff) next(app *iafv1alpha1.Application, phase iafv1alpha1.ApplicationPhase, base, maxDelay time.Duration) time.Duration {
+	if base <= 0 {
+		base = defaultRequeueBase
+	}
+	if maxDelay <= 0 {
+		maxDelay = defaultRequeueMax
+	}
+	key := types.NamespacedName{Name: app.Name, Namespace: app.Namespace}
+
+	b.mu.Lock()
+	if b.state == nil {
+		b.state = map[types.NamespacedName]backoffState{}
+	}
+	s := b.state[key]
+	if s.generation != app.Generation || s.phase != phase {
+		s = backoffState{generation: app.Generation, phase: phase}
+	}
+	delay := base
+	for i := 0; i < s.attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	s.attempts++
+	b.state[key] = s
+	b.mu.Unlock()
+
+	delay = min(delay, maxDelay)
+	return delay + rand.N(delay/10+1)
+}
+
+// forget drops the backoff state for an application that reached a steady
+// state or was deleted.
+func (b *requeueBackoff) forget(key types.NamespacedName) {
+	b.mu.Lock()
+	delete(b.state, key)
+	b.mu.Unlock()
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestRequeueBackoff(t *testing.T) {
+	var b requeueBackoff
+	app := makeApp("myapp", "test-ns")
+	base, maxDelay := time.Second, 4*time.Second
+
+	within := func(got, want time.Duration) bool { return got >= want && got <= want*11/10 }
+	next := func() time.Duration {
+		return b.next(app, iafv1alpha1.ApplicationPhaseBuilding, base, maxDelay)
+	}
+
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		if got := next(); !within(got, want) {
+			t.Errorf("attempt %d: got %v, want ≈%v", i, got, want)
+		}
+	}
+
+	// A new phase starts over.
+	if got := b.next(app, iafv1alpha1.ApplicationPhaseDeploying, base, maxDelay); !within(got, base) {
+		t.Errorf("after phase change: got %v, want ≈%v", got, base)
+	}
+
+	// So does a spec change.
+	next()
+	app.Generation++
+	if got := b.next(app, iafv1alpha1.ApplicationPhaseDeploying, base, maxDelay); !within(got, base) {
+		t.Errorf("after generation change: got %v, want ≈%v", got, base)
+	}
+
+	// And reaching a steady state.
+	next()
+	b.forget(types.NamespacedName{Name: "myapp", Namespace: "test-ns"})
+	if got := next(); !within(got, base) {
+		t.Errorf("after forget: got %v, want ≈%v", got, base)
+	}
+
+	// Zero intervals fall back to the defaults.
+	var d requeueBackoff
+	if got := d.next(app, iafv1alpha1.ApplicationPhaseBuilding, 0, 0); !within(got, defaultRequeueBase) {
+		t.Errorf("defaults: got %v, want ≈%v", got, defaultRequeueBase)
+	}
+}
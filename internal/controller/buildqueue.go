@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// buildStatusQueued is the build status of an app waiting for a build
+	// slot.
+	buildStatusQueued = "Queued"
+	// buildQueueRequeue is how often queued apps check for a free slot.
+	// Finished builds do not enqueue the apps waiting behind them.
+	buildQueueRequeue = 10 * time.Second
+)
+
+// admitBuild reports whether app may start a build now, given the limits on
+// concurrent builds. Builds start in the order apps were queued: an app is
+// admitted only when the builds running plus the apps queued ahead of it
+// leave a slot in both its namespace and the cluster. Otherwise it returns
+// the app's 1-based position in the queue.
+func (r *ApplicationReconciler) admitBuild(ctx context.Context, app *iafv1alpha1.Application) (admitted bool, position int, err error) {
+	if r.MaxBuilds <= 0 && r.MaxBuildsPerNamespace <= 0 {
+		return true, 0, nil
+	}
+
+	// A kpack Image whose latest build has not finished holds a slot. An
+	// Image kpack has not reported on yet has just been created and is
+	// about to build.
+	var images unstructured.UnstructuredList
+	images.SetGroupVersionKind(iafk8s.KpackImageGVK.GroupVersion().WithKind(iafk8s.KpackImageGVK.Kind + "List"))
+	if err := r.List(ctx, &images); err != nil {
+		return false, 0, fmt.Errorf("listing kpack images: %w", err)
+	}
+	var running, runningInNamespace int
+	for i := range images.Items {
+		img := &images.Items[i]
+		if img.GetNamespace() == app.Namespace && img.GetName() == app.Name {
+			continue
+		}
+		if status, _ := iafk8s.GetKpackImageStatus(img); status == "Building" || status == "Unknown" {
+			running++
+			if img.GetNamespace() == app.Namespace {
+				runningInNamespace++
+			}
+		}
+	}
+
+	var apps iafv1alpha1.ApplicationList
+	if err := r.List(ctx, &apps); err != nil {
+		return false, 0, fmt.Errorf("listing applications: %w", err)
+	}
+	var queued []iafv1alpha1.Application
+	for _, a := range apps.Items {
+		if a.Status.BuildStatus == buildStatusQueued && a.Status.BuildQueuedAt != nil {
+			queued = append(queued, a)
+		}
+	}
+	sortBuildQueue(queued)
+	var ahead, aheadInNamespace int
+	for _, a := range queued {
+		if a.Namespace == app.Namespace && a.Name == app.Name {
+			break
+		}
+		ahead++
+		if a.Namespace == app.Namespace {
+			aheadInNamespace++
+		}
+	}
+
+	if (r.MaxBuilds <= 0 || running+ahead < r.MaxBuilds) &&
+		(r.MaxBuildsPerNamespace <= 0 || runningInNamespace+aheadInNamespace < r.MaxBuildsPerNamespace) {
+		return true, 0, nil
+	}
+	return false, ahead + 1, nil
+}
+
+// sortBuildQueue orders queued apps by when they were queued.
+func sortBuildQueue(apps []iafv1alpha1.Application) {
+	sort.SliceStable(apps, func(i, j int) bool {
+		ti, tj := apps[i].Status.BuildQueuedAt.Time, apps[j].Status.BuildQueuedAt.Time
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		if apps[i].Namespace != apps[j].Namespace {
+			return apps[i].Namespace < apps[j].Namespace
+		}
+		return apps[i].Name < apps[j].Name
+	})
+}
+
+// queueBuild records that app waits for a build slot at position. The
+// queue time is kept from the first attempt so the app keeps its place.
+func queueBuild(app *iafv1alpha1.Application, position int) {
+	if app.Status.BuildQueuedAt == nil {
+		app.Status.BuildQueuedAt = &metav1.Time{Time: time.Now()}
+	}
+	app.Status.BuildQueuePosition = int32(position)
+}
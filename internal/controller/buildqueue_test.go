@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// TestReconcile_BuildQueue verifies builds over the per-namespace and
+// cluster limits are queued with their position, and start in queue order
+// once running builds finish.
+func TestReconcile_BuildQueue(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	r.MaxBuilds = 2
+	r.MaxBuildsPerNamespace = 1
+	ctx := context.Background()
+
+	for _, ns := range []string{"ns-a", "ns-b", "ns-c"} {
+		if err := r.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	createSourceApp := func(name, namespace string) {
+		t.Helper()
+		app := makeApp(name, namespace)
+		app.Spec.Image = ""
+		app.Spec.Blob = "http://localhost:8080/sources/" + namespace + "/" + name + ".tar.gz"
+		if err := r.Create(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+		reconcileApp(t, r, name, namespace)
+	}
+	getApp := func(name, namespace string) *iafv1alpha1.Application {
+		t.Helper()
+		var app iafv1alpha1.Application
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &app); err != nil {
+			t.Fatal(err)
+		}
+		return &app
+	}
+	hasImage := func(name, namespace string) bool {
+		t.Helper()
+		img := &unstructured.Unstructured{}
+		img.SetGroupVersionKind(iafk8s.KpackImageGVK)
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, img)
+		if err != nil && !apierrors.IsNotFound(err) {
+			t.Fatal(err)
+		}
+		return err == nil
+	}
+	finishBuild := func(name, namespace string) {
+		t.Helper()
+		img := &unstructured.Unstructured{}
+		img.SetGroupVersionKind(iafk8s.KpackImageGVK)
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, img); err != nil {
+			t.Fatal(err)
+		}
+		img.Object["status"] = map[string]any{
+			"latestImage": "registry.example.com/" + name + "@sha256:abc",
+			"conditions":  []any{map[string]any{"type": "Ready", "status": "True"}},
+		}
+		if err := r.Update(ctx, img); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertQueued := func(name, namespace string, position int32) {
+		t.Helper()
+		app := getApp(name, namespace)
+		if app.Status.BuildStatus != "Queued" || app.Status.BuildQueuePosition != position || app.Status.BuildQueuedAt == nil {
+			t.Fatalf("%s/%s: expected queued at position %d, got %q position %d", namespace, name, position, app.Status.BuildStatus, app.Status.BuildQueuePosition)
+		}
+		if app.Status.Phase != iafv1alpha1.ApplicationPhaseBuilding {
+			t.Errorf("%s/%s: expected phase Building, got %s", namespace, name, app.Status.Phase)
+		}
+		if c := meta.FindStatusCondition(app.Status.Conditions, "Ready"); c == nil || c.Reason != "BuildQueued" {
+			t.Errorf("%s/%s: expected BuildQueued condition, got %+v", namespace, name, c)
+		}
+		if hasImage(name, namespace) {
+			t.Errorf("%s/%s: expected no kpack Image while queued", namespace, name)
+		}
+	}
+
+	// ns-a may run one build: the second app waits behind it.
+	createSourceApp("one", "ns-a")
+	if !hasImage("one", "ns-a") {
+		t.Fatal("expected the first build to start")
+	}
+	createSourceApp("two", "ns-a")
+	assertQueued("two", "ns-a", 1)
+
+	// The cluster may run two builds: ns-b gets the second slot only if no
+	// app is queued ahead of it, so it waits behind ns-a's app, and ns-c
+	// waits behind both.
+	createSourceApp("web", "ns-b")
+	assertQueued("web", "ns-b", 2)
+	createSourceApp("api", "ns-c")
+	assertQueued("api", "ns-c", 3)
+
+	// Once ns-a's build finishes, its queued app starts first even when the
+	// apps behind it reconcile before it.
+	finishBuild("one", "ns-a")
+	reconcileApp(t, r, "api", "ns-c")
+	assertQueued("api", "ns-c", 3)
+	reconcileApp(t, r, "two", "ns-a")
+	if !hasImage("two", "ns-a") {
+		t.Fatal("expected the queued build to start")
+	}
+	app := getApp("two", "ns-a")
+	if app.Status.BuildStatus == "Queued" || app.Status.BuildQueuedAt != nil || app.Status.BuildQueuePosition != 0 {
+		t.Errorf("expected the queue fields to be cleared, got %q %v %d", app.Status.BuildStatus, app.Status.BuildQueuedAt, app.Status.BuildQueuePosition)
+	}
+	reconcileApp(t, r, "web", "ns-b")
+	if !hasImage("web", "ns-b") {
+		t.Fatal("expected the next queued build to start")
+	}
+	reconcileApp(t, r, "api", "ns-c")
+	assertQueued("api", "ns-c", 1)
+}
+
+// TestReconcile_BuildQueue_Rebuild verifies a source change on a running
+// app waits for a slot while the app keeps serving its last image.
+func TestReconcile_BuildQueue_Rebuild(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	r.MaxBuildsPerNamespace = 1
+	ctx := context.Background()
+
+	if err := r.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-ns"}}); err != nil {
+		t.Fatal(err)
+	}
+	app := makeApp("myapp", "test-ns")
+	app.Spec.Image = ""
+	app.Spec.Blob = "http://localhost:8080/sources/test-ns/myapp.tar.gz?rev=1"
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+	img := &unstructured.Unstructured{}
+	img.SetGroupVersionKind(iafk8s.KpackImageGVK)
+	if err := r.Get(ctx, key, img); err != nil {
+		t.Fatal(err)
+	}
+	img.Object["status"] = map[string]any{
+		"latestImage": "registry.example.com/myapp@sha256:abc",
+		"conditions":  []any{map[string]any{"type": "Ready", "status": "True"}},
+	}
+	if err := r.Update(ctx, img); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another app in the namespace is building.
+	other := &unstructured.Unstructured{}
+	other.SetGroupVersionKind(iafk8s.KpackImageGVK)
+	other.SetName("other")
+	other.SetNamespace("test-ns")
+	if err := r.Create(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	app.Spec.Blob = "http://localhost:8080/sources/test-ns/myapp.tar.gz?rev=2"
+	if err := r.Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	result := reconcileApp(t, r, "myapp", "test-ns")
+	if result.RequeueAfter == 0 || result.RequeueAfter > buildQueueRequeue {
+		t.Errorf("expected a requeue within %s, got %s", buildQueueRequeue, result.RequeueAfter)
+	}
+
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	if app.Status.BuildStatus != "Queued" || app.Status.BuildQueuePosition != 1 {
+		t.Errorf("expected the rebuild to be queued, got %q position %d", app.Status.BuildStatus, app.Status.BuildQueuePosition)
+	}
+	if app.Status.LatestImage != "registry.example.com/myapp@sha256:abc" {
+		t.Errorf("expected the last image to stay deployed, got %q", app.Status.LatestImage)
+	}
+	if err := r.Get(ctx, key, img); err != nil {
+		t.Fatal(err)
+	}
+	if url, _, _ := unstructured.NestedString(img.Object, "spec", "source", "blob", "url"); url != "http://localhost:8080/sources/test-ns/myapp.tar.gz?rev=1" {
+		t.Errorf("expected the kpack Image source to be unchanged while queued, got %q", url)
+	}
+}
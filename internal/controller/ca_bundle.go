@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// errCABundleUnavailable marks a platform CA bundle whose ConfigMap or key
+// does not exist. Apps that trust it fail until it is created, rather than
+// start without the certificates they need.
+var errCABundleUnavailable = errors.New("CA bundle unavailable")
+
+// caBundleHashAnnotation is set on the pod template to a hash of the CA
+// bundle, so rotating a certificate rolls the Deployment.
+const caBundleHashAnnotation = "iaf.io/ca-bundle-hash"
+
+// reconcileCABundle copies the platform CA bundle into app's namespace, or
+// deletes the copy when the platform has none or the app opted out. It
+// returns the volume and mount of the copy and a hash of the bundle for the
+// pod template; all zero when no bundle applies.
+func (r *ApplicationReconciler) reconcileCABundle(ctx context.Context, app *iafv1alpha1.Application) (*corev1.Volume, *corev1.VolumeMount, string, error) {
+	if r.CABundle.IsZero() || !iafv1alpha1.IsCABundleEnabled(app) {
+		return nil, nil, "", r.deleteCABundle(ctx, app)
+	}
+	var source corev1.ConfigMap
+	if err := r.Get(ctx, types.NamespacedName{Name: r.CABundle.Name, Namespace: r.CABundle.Namespace}, &source); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil, "", fmt.Errorf("%w: ConfigMap %s/%s not found", errCABundleUnavailable, r.CABundle.Namespace, r.CABundle.Name)
+		}
+		return nil, nil, "", fmt.Errorf("getting CA bundle: %w", err)
+	}
+	pem, ok := source.Data[r.CABundle.Key]
+	if !ok || pem == "" {
+		return nil, nil, "", fmt.Errorf("%w: ConfigMap %s/%s has no key %q", errCABundleUnavailable, r.CABundle.Namespace, r.CABundle.Name, r.CABundle.Key)
+	}
+
+	desired := iafk8s.BuildCABundleConfigMap(app, pem)
+	desired.OwnerReferences = []metav1.OwnerReference{
+		{
+			APIVersion: iafv1alpha1.GroupVersion.String(),
+			Kind:       "Application",
+			Name:       app.Name,
+			UID:        app.UID,
+			Controller: boolPtr(true),
+		},
+	}
+	if _, _, err := r.applyOwned(ctx, desired); err != nil {
+		return nil, nil, "", err
+	}
+	volume, mount := iafk8s.CABundleMount(app)
+	sum := sha256.Sum256([]byte(pem))
+	return &volume, &mount, hex.EncodeToString(sum[:]), nil
+}
+
+// deleteCABundle deletes the app's copy of the CA bundle, if the controller
+// created one for it.
+func (r *ApplicationReconciler) deleteCABundle(ctx context.Context, app *iafv1alpha1.Application) error {
+	var cm corev1.ConfigMap
+	if err := r.Get(ctx, types.NamespacedName{Name: iafk8s.CABundleConfigMapName(app.Name), Namespace: app.Namespace}, &cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("getting CA bundle copy: %w", err)
+	}
+	if !metav1.IsControlledBy(&cm, app) {
+		return nil
+	}
+	return r.deleteIfExists(ctx, &cm)
+}
+
+// isCABundle reports whether obj is the platform CA bundle ConfigMap.
+func (r *ApplicationReconciler) isCABundle(obj client.Object) bool {
+	return !r.CABundle.IsZero() && obj.GetNamespace() == r.CABundle.Namespace && obj.GetName() == r.CABundle.Name
+}
+
+// mapCABundleToApplications enqueues every Application that trusts the
+// platform CA bundle, so changing it rolls them all.
+func (r *ApplicationReconciler) mapCABundleToApplications(ctx context.Context, obj client.Object) []reconcile.Request {
+	var apps iafv1alpha1.ApplicationList
+	if err := r.List(ctx, &apps); err != nil {
+		log.FromContext(ctx).Error(err, "listing applications for CA bundle", "configMap", obj.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, app := range apps.Items {
+		if iafv1alpha1.IsCABundleEnabled(&app) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: app.Name, Namespace: app.Namespace}})
+		}
+	}
+	return requests
+}
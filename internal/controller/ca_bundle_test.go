@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// TestReconcile_CABundle verifies the platform CA bundle is copied into the
+// app's namespace and mounted with SSL_CERT_FILE pointing at it, that
+// rotating it rolls the pods, and that an app can opt out.
+func TestReconcile_CABundle(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	r.CABundle = iafk8s.CABundle{Namespace: "iaf-system", Name: "corp-ca", Key: "ca.crt"}
+	ctx := context.Background()
+
+	source := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "corp-ca", Namespace: "iaf-system"},
+		Data:       map[string]string{"ca.crt": "-----BEGIN CERTIFICATE-----\nA\n"},
+	}
+	if err := r.Create(ctx, source); err != nil {
+		t.Fatal(err)
+	}
+	app := makeApp("myapp", "test-ns")
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	copyKey := types.NamespacedName{Name: "myapp-ca-bundle", Namespace: "test-ns"}
+	var cm corev1.ConfigMap
+	if err := r.Get(ctx, copyKey, &cm); err != nil {
+		t.Fatalf("expected the CA bundle copy: %v", err)
+	}
+	if cm.Data["ca.crt"] != source.Data["ca.crt"] || len(cm.OwnerReferences) != 1 || cm.OwnerReferences[0].Name != "myapp" {
+		t.Errorf("unexpected CA bundle copy %+v", cm)
+	}
+
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+	getDeployment := func() *appsv1.Deployment {
+		t.Helper()
+		var dep appsv1.Deployment
+		if err := r.Get(ctx, key, &dep); err != nil {
+			t.Fatal(err)
+		}
+		return &dep
+	}
+	dep := getDeployment()
+	container := dep.Spec.Template.Spec.Containers[0]
+	if len(container.VolumeMounts) != 1 || container.VolumeMounts[0].MountPath != iafk8s.CABundlePath {
+		t.Errorf("unexpected volume mounts %+v", container.VolumeMounts)
+	}
+	var certFile string
+	for _, e := range container.Env {
+		if e.Name == "SSL_CERT_FILE" {
+			certFile = e.Value
+		}
+	}
+	if certFile != iafk8s.CABundlePath {
+		t.Errorf("SSL_CERT_FILE = %q, want %q", certFile, iafk8s.CABundlePath)
+	}
+	hash := dep.Spec.Template.Annotations[caBundleHashAnnotation]
+	if hash == "" {
+		t.Fatal("expected the CA bundle hash on the pod template")
+	}
+
+	// Rotating the bundle enqueues every app trusting it and rolls the pods.
+	source.Data["ca.crt"] += "-----BEGIN CERTIFICATE-----\nB\n"
+	if err := r.Update(ctx, source); err != nil {
+		t.Fatal(err)
+	}
+	if requests := r.mapConfigMapToApplications(ctx, source); len(requests) != 1 || requests[0].NamespacedName != key {
+		t.Errorf("expected the CA bundle to enqueue myapp, got %v", requests)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if next := getDeployment().Spec.Template.Annotations[caBundleHashAnnotation]; next == "" || next == hash {
+		t.Errorf("expected the hash to change with the bundle, got %q (was %q)", next, hash)
+	}
+
+	// Opting out removes the mount, the env and the copy.
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	disabled := false
+	app.Spec.CABundle = &iafv1alpha1.CABundleConfig{Enabled: &disabled}
+	if err := r.Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	dep = getDeployment()
+	if mounts := dep.Spec.Template.Spec.Containers[0].VolumeMounts; len(mounts) != 0 {
+		t.Errorf("expected no mounts after opting out, got %+v", mounts)
+	}
+	if _, ok := dep.Spec.Template.Annotations[caBundleHashAnnotation]; ok {
+		t.Error("expected no CA bundle hash after opting out")
+	}
+	if err := r.Get(ctx, copyKey, &cm); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the CA bundle copy to be deleted, got %v", err)
+	}
+}
+
+// TestReconcile_CABundle_Missing verifies apps fail while the platform CA
+// bundle does not exist rather than start without it.
+func TestReconcile_CABundle_Missing(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	r.CABundle = iafk8s.CABundle{Namespace: "iaf-system", Name: "corp-ca", Key: "ca.crt"}
+	ctx := context.Background()
+
+	app := makeApp("myapp", "test-ns")
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, app); err != nil {
+		t.Fatal(err)
+	}
+	c := meta.FindStatusCondition(app.Status.Conditions, "Ready")
+	if app.Status.Phase != iafv1alpha1.ApplicationPhaseFailed || c == nil || c.Reason != "CABundleUnavailable" {
+		t.Errorf("expected phase Failed with CABundleUnavailable, got %s %+v", app.Status.Phase, c)
+	}
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// conditionCertificateReady reports the cert-manager Certificate of an
+	// application served over TLS.
+	conditionCertificateReady = "CertificateReady"
+
+	// certificateExpiryWarning is how long before expiry a certificate is
+	// reported as expiring soon. cert-manager renews a third of the lifetime
+	// before expiry, 30 days for a 90-day certificate, so one this close to
+	// expiry failed to renew.
+	certificateExpiryWarning = 14 * 24 * time.Hour
+)
+
+// recordCertificateStatus sets status.tls and the CertificateReady condition
+// of app from its Certificate, or clears them when cert is nil because the
+// app is not served over TLS. It returns the delay until the condition
+// changes with time alone, or zero when only a Certificate update changes it.
+func recordCertificateStatus(app *iafv1alpha1.Application, cert *unstructured.Unstructured, issuer string, now time.Time) time.Duration {
+	if cert == nil {
+		app.Status.TLS = nil
+		meta.RemoveStatusCondition(&app.Status.Conditions, conditionCertificateReady)
+		return 0
+	}
+	info := iafk8s.GetCertificateInfo(cert)
+	tls := &iafv1alpha1.TLSStatus{Issuer: issuer, Ready: info.Ready}
+	if !info.NotAfter.IsZero() {
+		tls.NotAfter = &metav1.Time{Time: info.NotAfter}
+	}
+	if !info.RenewalTime.IsZero() {
+		tls.RenewalTime = &metav1.Time{Time: info.RenewalTime}
+	}
+	app.Status.TLS = tls
+
+	expires := info.NotAfter.UTC().Format(time.RFC3339)
+	switch {
+	case !info.NotAfter.IsZero() && !now.Before(info.NotAfter):
+		tls.Ready = false
+		tls.Message = fmt.Sprintf("The certificate expired at %s and was not renewed; check the %q issuer", expires, issuer)
+		setCondition(app, conditionCertificateReady, metav1.ConditionFalse, "Expired", tls.Message)
+	case !info.Ready:
+		tls.Message = info.Message
+		reason, message := info.Reason, info.Message
+		if reason == "" {
+			reason = "Issuing"
+		}
+		if message == "" {
+			message = fmt.Sprintf("Waiting for the %q issuer to issue the certificate", issuer)
+		}
+		setCondition(app, conditionCertificateReady, metav1.ConditionFalse, reason, message)
+	case info.NotAfter.IsZero():
+		setCondition(app, conditionCertificateReady, metav1.ConditionTrue, "Issued", "The certificate is issued")
+	case now.Before(info.NotAfter.Add(-certificateExpiryWarning)):
+		message := "Valid until " + expires
+		if !info.RenewalTime.IsZero() {
+			message += "; renews at " + info.RenewalTime.UTC().Format(time.RFC3339)
+		}
+		setCondition(app, conditionCertificateReady, metav1.ConditionTrue, "Issued", message)
+		return info.NotAfter.Add(-certificateExpiryWarning).Sub(now)
+	default:
+		left := info.NotAfter.Sub(now)
+		setCondition(app, conditionCertificateReady, metav1.ConditionTrue, "ExpiresSoon",
+			fmt.Sprintf("The certificate expires in %s (at %s) and was not renewed; check the %q issuer", left.Round(time.Hour), expires, issuer))
+		return left
+	}
+	return 0
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRecordCertificateStatus(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	issued := func(notAfter time.Time) map[string]any {
+		return map[string]any{
+			"conditions": []any{map[string]any{"type": "Ready", "status": "True", "reason": "Ready"}},
+			"notAfter":   notAfter.Format(time.RFC3339),
+		}
+	}
+	tests := []struct {
+		name       string
+		status     map[string]any
+		wantStatus metav1.ConditionStatus
+		wantReason string
+		wantReady  bool
+		wantNext   time.Duration
+	}{
+		{
+			name:       "not processed yet",
+			wantStatus: metav1.ConditionFalse,
+			wantReason: "Issuing",
+		},
+		{
+			name: "issuance failed",
+			status: map[string]any{"conditions": []any{map[string]any{
+				"type": "Ready", "status": "False", "reason": "Failed", "message": "ACME challenge failed",
+			}}},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: "Failed",
+		},
+		{
+			name:       "valid",
+			status:     issued(now.Add(60 * 24 * time.Hour)),
+			wantStatus: metav1.ConditionTrue,
+			wantReason: "Issued",
+			wantReady:  true,
+			wantNext:   46 * 24 * time.Hour,
+		},
+		{
+			name:       "expires soon",
+			status:     issued(now.Add(3 * 24 * time.Hour)),
+			wantStatus: metav1.ConditionTrue,
+			wantReason: "ExpiresSoon",
+			wantReady:  true,
+			wantNext:   3 * 24 * time.Hour,
+		},
+		{
+			name:       "expired",
+			status:     issued(now.Add(-time.Hour)),
+			wantStatus: metav1.ConditionFalse,
+			wantReason: "Expired",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := makeApp("myapp", "test-ns")
+			cert := iafk8s.BuildCertificate(app, "myapp.example.com", "letsencrypt")
+			if tt.status != nil {
+				cert.Object["status"] = tt.status
+			}
+			next := recordCertificateStatus(app, cert, "letsencrypt", now)
+			if next != tt.wantNext {
+				t.Errorf("next = %v, want %v", next, tt.wantNext)
+			}
+			c := meta.FindStatusCondition(app.Status.Conditions, conditionCertificateReady)
+			if c == nil || c.Status != tt.wantStatus || c.Reason != tt.wantReason {
+				t.Fatalf("condition = %+v, want %s %s", c, tt.wantStatus, tt.wantReason)
+			}
+			if tls := app.Status.TLS; tls == nil || tls.Issuer != "letsencrypt" || tls.Ready != tt.wantReady {
+				t.Errorf("unexpected TLS status %+v", tls)
+			}
+		})
+	}
+
+	// Without a Certificate the status and condition are cleared.
+	app := makeApp("myapp", "test-ns")
+	app.Status.TLS = &iafv1alpha1.TLSStatus{Issuer: "letsencrypt"}
+	setCondition(app, conditionCertificateReady, metav1.ConditionTrue, "Issued", "")
+	if next := recordCertificateStatus(app, nil, "", now); next != 0 || app.Status.TLS != nil || len(app.Status.Conditions) != 0 {
+		t.Errorf("expected the TLS status to be cleared, got %+v %v", app.Status.TLS, app.Status.Conditions)
+	}
+}
@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// errConfigFileUnavailable marks a config file whose ConfigMap or key does
+// not exist. The app fails until it is created.
+var errConfigFileUnavailable = errors.New("config file unavailable")
+
+const (
+	// configFilesVolume is the volume of the ConfigMap holding inline config
+	// files.
+	configFilesVolume = "config-files"
+	// configFilesHashAnnotation is set on the pod template to a hash of the
+	// mounted config files, so changing one rolls the Deployment.
+	configFilesHashAnnotation = "iaf.io/config-files-hash"
+)
+
+// configFilesName returns the name of the ConfigMap holding the inline config
+// files of app.
+func configFilesName(app *iafv1alpha1.Application) string {
+	return app.Name + "-config-files"
+}
+
+// reconcileConfigFiles applies the ConfigMap holding app's inline config
+// files, or deletes it when there are none. It returns the volumes and mounts
+// that place every config file at its path, and a hash of their contents for
+// the pod template. Files read from a ConfigMap fail with
+// errConfigFileUnavailable while the ConfigMap or key is missing.
+func (r *ApplicationReconciler) reconcileConfigFiles(ctx context.Context, app *iafv1alpha1.Application) ([]corev1.Volume, []corev1.VolumeMount, string, error) {
+	var (
+		volumes []corev1.Volume
+		mounts  []corev1.VolumeMount
+		inline  = map[string]string{}
+		h       = sha256.New()
+	)
+	// One volume per ConfigMap; each file is mounted on its own with subPath.
+	volumeFor := map[string]string{}
+	for i, f := range app.Spec.ConfigFiles {
+		mount := corev1.VolumeMount{MountPath: f.Path, ReadOnly: true}
+		if f.ConfigMap == nil {
+			key := fmt.Sprintf("file-%d", i)
+			inline[key] = f.Content
+			mount.Name, mount.SubPath = configFilesVolume, key
+			fmt.Fprintf(h, "%s\x00%s\x00", f.Path, f.Content)
+		} else {
+			var cm corev1.ConfigMap
+			if err := r.Get(ctx, types.NamespacedName{Name: f.ConfigMap.Name, Namespace: app.Namespace}, &cm); err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil, nil, "", fmt.Errorf("%w: ConfigMap %q for %s not found", errConfigFileUnavailable, f.ConfigMap.Name, f.Path)
+				}
+				return nil, nil, "", fmt.Errorf("getting config map %q: %w", f.ConfigMap.Name, err)
+			}
+			value, ok := cm.Data[f.ConfigMap.Key]
+			binary, binaryOK := cm.BinaryData[f.ConfigMap.Key]
+			if !ok && !binaryOK {
+				return nil, nil, "", fmt.Errorf("%w: ConfigMap %q has no key %q for %s", errConfigFileUnavailable, f.ConfigMap.Name, f.ConfigMap.Key, f.Path)
+			}
+			name, seen := volumeFor[f.ConfigMap.Name]
+			if !seen {
+				name = fmt.Sprintf("config-map-%d", len(volumeFor))
+				volumeFor[f.ConfigMap.Name] = name
+				volumes = append(volumes, corev1.Volume{
+					Name: name,
+					VolumeSource: corev1.VolumeSource{
+						ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: f.ConfigMap.Name}},
+					},
+				})
+			}
+			mount.Name, mount.SubPath = name, f.ConfigMap.Key
+			fmt.Fprintf(h, "%s\x00%s/%s\x00%s%s\x00", f.Path, f.ConfigMap.Name, f.ConfigMap.Key, value, binary)
+		}
+		mounts = append(mounts, mount)
+	}
+
+	if len(inline) == 0 {
+		if err := r.deleteConfigFiles(ctx, app); err != nil {
+			return nil, nil, "", err
+		}
+	} else {
+		desired := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      configFilesName(app),
+				Namespace: app.Namespace,
+				Labels:    iafk8s.AppLabels(app),
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: iafv1alpha1.GroupVersion.String(),
+						Kind:       "Application",
+						Name:       app.Name,
+						UID:        app.UID,
+						Controller: boolPtr(true),
+					},
+				},
+			},
+			Data: inline,
+		}
+		if _, _, err := r.applyOwned(ctx, desired); err != nil {
+			return nil, nil, "", err
+		}
+		volumes = append([]corev1.Volume{{
+			Name: configFilesVolume,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: configFilesName(app)}},
+			},
+		}}, volumes...)
+	}
+
+	if len(mounts) == 0 {
+		return nil, nil, "", nil
+	}
+	return volumes, mounts, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// deleteConfigFiles deletes the inline config file ConfigMap of app, if the
+// controller created one for it.
+func (r *ApplicationReconciler) deleteConfigFiles(ctx context.Context, app *iafv1alpha1.Application) error {
+	var cm corev1.ConfigMap
+	if err := r.Get(ctx, types.NamespacedName{Name: configFilesName(app), Namespace: app.Namespace}, &cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("getting config files: %w", err)
+	}
+	if !metav1.IsControlledBy(&cm, app) {
+		return nil
+	}
+	return r.deleteIfExists(ctx, &cm)
+}
+
+// mapConfigMapToApplications enqueues the Applications that mount a key of a
+// ConfigMap, so changing it rolls them.
+func (r *ApplicationReconciler) mapConfigMapToApplications(ctx context.Context, obj client.Object) []reconcile.Request {
+	if r.isCABundle(obj) {
+		return r.mapCABundleToApplications(ctx, obj)
+	}
+	var apps iafv1alpha1.ApplicationList
+	if err := r.List(ctx, &apps, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "listing applications for config map", "configMap", obj.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, app := range apps.Items {
+		if slices.ContainsFunc(app.Spec.ConfigFiles, func(f iafv1alpha1.ConfigFile) bool {
+			return f.ConfigMap != nil && f.ConfigMap.Name == obj.GetName()
+		}) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: app.Name, Namespace: app.Namespace}})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// TestReconcile_ConfigFiles verifies inline config files are stored in an
+// owned ConfigMap, every file is mounted read-only at its path, and changing
+// a file or a referenced ConfigMap changes the pod template.
+func TestReconcile_ConfigFiles(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+
+	shared := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "test-ns"},
+		Data:       map[string]string{"logging.xml": "<configuration/>"},
+	}
+	if err := r.Create(ctx, shared); err != nil {
+		t.Fatal(err)
+	}
+	app := makeApp("myapp", "test-ns")
+	app.Spec.ConfigFiles = []iafv1alpha1.ConfigFile{
+		{Path: "/app/config.yaml", Content: "port: 8080\n"},
+		{Path: "/app/logback.xml", ConfigMap: &iafv1alpha1.ConfigMapKeyRef{Name: "shared", Key: "logging.xml"}},
+	}
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+	var cm corev1.ConfigMap
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp-config-files", Namespace: "test-ns"}, &cm); err != nil {
+		t.Fatalf("expected the config files ConfigMap: %v", err)
+	}
+	if cm.Data["file-0"] != "port: 8080\n" || len(cm.Data) != 1 {
+		t.Errorf("unexpected config files data %v", cm.Data)
+	}
+	if len(cm.OwnerReferences) != 1 || cm.OwnerReferences[0].Name != "myapp" {
+		t.Errorf("expected the ConfigMap to be owned by the app, got %v", cm.OwnerReferences)
+	}
+
+	getDeployment := func() *appsv1.Deployment {
+		t.Helper()
+		var dep appsv1.Deployment
+		if err := r.Get(ctx, key, &dep); err != nil {
+			t.Fatal(err)
+		}
+		return &dep
+	}
+	dep := getDeployment()
+	pod := dep.Spec.Template.Spec
+	if len(pod.Volumes) != 2 || pod.Volumes[0].ConfigMap.Name != "myapp-config-files" || pod.Volumes[1].ConfigMap.Name != "shared" {
+		t.Fatalf("unexpected volumes %+v", pod.Volumes)
+	}
+	mounts := pod.Containers[0].VolumeMounts
+	want := []corev1.VolumeMount{
+		{Name: "config-files", MountPath: "/app/config.yaml", SubPath: "file-0", ReadOnly: true},
+		{Name: pod.Volumes[1].Name, MountPath: "/app/logback.xml", SubPath: "logging.xml", ReadOnly: true},
+	}
+	if len(mounts) != len(want) || mounts[0] != want[0] || mounts[1] != want[1] {
+		t.Errorf("volume mounts = %+v, want %+v", mounts, want)
+	}
+	hash := dep.Spec.Template.Annotations[configFilesHashAnnotation]
+	if hash == "" {
+		t.Fatal("expected the config files hash on the pod template")
+	}
+
+	// A change to the referenced ConfigMap rolls the pods.
+	shared.Data["logging.xml"] = "<configuration debug=\"true\"/>"
+	if err := r.Update(ctx, shared); err != nil {
+		t.Fatal(err)
+	}
+	if requests := r.mapConfigMapToApplications(ctx, shared); len(requests) != 1 || requests[0].NamespacedName != key {
+		t.Errorf("expected the ConfigMap to enqueue myapp, got %v", requests)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	next := getDeployment().Spec.Template.Annotations[configFilesHashAnnotation]
+	if next == "" || next == hash {
+		t.Errorf("expected the hash to change with the ConfigMap, got %q (was %q)", next, hash)
+	}
+
+	// Removing the inline file deletes the generated ConfigMap.
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	app.Spec.ConfigFiles = app.Spec.ConfigFiles[1:]
+	if err := r.Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp-config-files", Namespace: "test-ns"}, &cm); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the config files ConfigMap to be deleted, got %v", err)
+	}
+	if vols := getDeployment().Spec.Template.Spec.Volumes; len(vols) != 1 || vols[0].ConfigMap.Name != "shared" {
+		t.Errorf("expected only the shared volume, got %+v", vols)
+	}
+}
+
+// TestReconcile_ConfigFiles_MissingConfigMap verifies an app mounting a
+// missing ConfigMap key fails until it exists.
+func TestReconcile_ConfigFiles_MissingConfigMap(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+
+	app := makeApp("myapp", "test-ns")
+	app.Spec.ConfigFiles = []iafv1alpha1.ConfigFile{
+		{Path: "/app/config.yaml", ConfigMap: &iafv1alpha1.ConfigMapKeyRef{Name: "settings", Key: "config.yaml"}},
+	}
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+	assertFailed := func() {
+		t.Helper()
+		reconcileApp(t, r, "myapp", "test-ns")
+		if err := r.Get(ctx, key, app); err != nil {
+			t.Fatal(err)
+		}
+		c := meta.FindStatusCondition(app.Status.Conditions, "Ready")
+		if app.Status.Phase != iafv1alpha1.ApplicationPhaseFailed || c == nil || c.Reason != "ConfigFileUnavailable" {
+			t.Errorf("expected phase Failed with ConfigFileUnavailable, got %s %+v", app.Status.Phase, c)
+		}
+	}
+	assertFailed()
+
+	settings := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "test-ns"},
+		Data:       map[string]string{"other.yaml": "x"},
+	}
+	if err := r.Create(ctx, settings); err != nil {
+		t.Fatal(err)
+	}
+	assertFailed()
+
+	settings.Data["config.yaml"] = "port: 8080\n"
+	if err := r.Update(ctx, settings); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	var dep appsv1.Deployment
+	if err := r.Get(ctx, key, &dep); err != nil {
+		t.Fatalf("expected a Deployment once the key exists: %v", err)
+	}
+}
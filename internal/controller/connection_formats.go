@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reconcileConnectionFormats keeps a Secret of connection string variants
+// for every binding of app with formats, derived from the service's
+// connection Secret, and deletes those of bindings without. A binding whose
+// connection Secret does not exist yet gets none; its creation enqueues the
+// app again.
+func (r *ApplicationReconciler) reconcileConnectionFormats(ctx context.Context, app *iafv1alpha1.Application) error {
+	desired := map[string]bool{}
+	for _, bms := range app.Spec.BoundManagedServices {
+		if len(bms.Formats) == 0 || !iafv1alpha1.BindingProjectsEnv(bms) {
+			continue
+		}
+		desired[iafk8s.ConnectionFormatsSecretName(app.Name, bms.ServiceName)] = true
+
+		var conn corev1.Secret
+		if err := r.Get(ctx, types.NamespacedName{Name: bms.SecretName, Namespace: app.Namespace}, &conn); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("getting connection secret of service %q: %w", bms.ServiceName, err)
+		}
+		secret := iafk8s.BuildConnectionFormatsSecret(app, bms.ServiceName, bms.Formats, &conn)
+		var existing corev1.Secret
+		if err := r.Get(ctx, client.ObjectKeyFromObject(secret), &existing); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return fmt.Errorf("getting connection formats of service %q: %w", bms.ServiceName, err)
+			}
+			if err := r.Create(ctx, secret); err != nil {
+				return fmt.Errorf("creating connection formats of service %q: %w", bms.ServiceName, err)
+			}
+			continue
+		}
+		if existing.Labels["iaf.io/application"] != app.Name || existing.Labels["app.kubernetes.io/managed-by"] != "iaf" {
+			return fmt.Errorf("secret %q exists and is not managed by iaf", secret.Name)
+		}
+		relabeled := iafk8s.CopyOwnershipLabels(&existing, secret)
+		if !relabeled && maps.EqualFunc(existing.Data, secret.Data, func(a, b []byte) bool { return string(a) == string(b) }) {
+			continue
+		}
+		existing.Data = secret.Data
+		if err := r.Update(ctx, &existing); err != nil {
+			return fmt.Errorf("updating connection formats of service %q: %w", bms.ServiceName, err)
+		}
+	}
+
+	var secrets corev1.SecretList
+	if err := r.List(ctx, &secrets, client.InNamespace(app.Namespace), client.HasLabels{iafk8s.LabelConnectionFormats},
+		client.MatchingLabels{"iaf.io/application": app.Name, "app.kubernetes.io/managed-by": "iaf"}); err != nil {
+		return fmt.Errorf("listing connection formats: %w", err)
+	}
+	for i := range secrets.Items {
+		if !desired[secrets.Items[i].Name] {
+			if err := r.deleteIfExists(ctx, &secrets.Items[i]); err != nil {
+				return fmt.Errorf("deleting connection formats %q: %w", secrets.Items[i].Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// connectionFormatEnv returns the env vars of the connection formats of a
+// binding of app, read from its formats Secret and, for the JDBC username
+// and password, from the connection Secret. Every name starts with the
+// binding's EnvPrefix.
+func connectionFormatEnv(app *iafv1alpha1.Application, bms iafv1alpha1.BoundManagedService) []corev1.EnvVar {
+	formats := iafk8s.ConnectionFormatsSecretName(app.Name, bms.ServiceName)
+	ref := func(name, secret, key string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: bms.EnvPrefix + name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secret},
+					Key:                  key,
+				},
+			},
+		}
+	}
+	var env []corev1.EnvVar
+	for _, format := range bms.Formats {
+		switch format {
+		case iafv1alpha1.ConnectionFormatJDBC:
+			env = append(env,
+				ref("JDBC_DATABASE_URL", formats, iafk8s.ConnectionFormatJDBCKey),
+				ref("JDBC_DATABASE_USERNAME", bms.SecretName, "username"),
+				ref("JDBC_DATABASE_PASSWORD", bms.SecretName, "password"),
+			)
+		case iafv1alpha1.ConnectionFormatSQLAlchemy:
+			env = append(env, ref("SQLALCHEMY_DATABASE_URI", formats, iafk8s.ConnectionFormatSQLAlchemyKey))
+		}
+	}
+	return env
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// dataSourceCache holds the env var mappings of DataSources, kept current
+// by the DataSource watch, so rendering an app's Deployment does not read
+// every attached DataSource again on each pass. The zero value is ready.
+type dataSourceCache struct {
+	mu      sync.RWMutex
+	entries map[string]dataSourceEntry
+}
+
+// dataSourceEntry is a cached DataSource. A missing DataSource is cached
+// too, so a deletion seen by the watch is not undone by a stale read.
+type dataSourceEntry struct {
+	found      bool
+	envMapping map[string]string
+}
+
+// envMapping returns the secret key to env var mapping of the named
+// DataSource and whether it exists. The first lookup of a name the watch
+// has not reported yet reads it through c.
+func (d *dataSourceCache) envMapping(ctx context.Context, c client.Reader, name string) (map[string]string, bool, error) {
+	d.mu.RLock()
+	entry, ok := d.entries[name]
+	d.mu.RUnlock()
+	if ok {
+		return entry.envMapping, entry.found, nil
+	}
+
+	var ds iafv1alpha1.DataSource
+	switch err := c.Get(ctx, types.NamespacedName{Name: name}, &ds); {
+	case apierrors.IsNotFound(err):
+		entry = dataSourceEntry{}
+	case err != nil:
+		return nil, false, fmt.Errorf("getting datasource %q: %w", name, err)
+	default:
+		entry = dataSourceEntry{found: true, envMapping: maps.Clone(ds.Spec.EnvVarMapping)}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// The watch may have reported a newer state while this read was in
+	// flight; it wins.
+	if current, ok := d.entries[name]; ok {
+		return current.envMapping, current.found, nil
+	}
+	if d.entries == nil {
+		d.entries = map[string]dataSourceEntry{}
+	}
+	d.entries[name] = entry
+	return entry.envMapping, entry.found, nil
+}
+
+// set records the current state of a DataSource reported by the watch.
+func (d *dataSourceCache) set(ds *iafv1alpha1.DataSource, found bool) {
+	entry := dataSourceEntry{found: found}
+	if found {
+		entry.envMapping = maps.Clone(ds.Spec.EnvVarMapping)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.entries == nil {
+		d.entries = map[string]dataSourceEntry{}
+	}
+	d.entries[ds.Name] = entry
+}
+
+// dataSourceHandler keeps the reconciler's DataSource cache current and
+// enqueues the apps attached to a DataSource that changes, so a new env
+// var mapping reaches their Deployments.
+func (r *ApplicationReconciler) dataSourceHandler() handler.EventHandler {
+	enqueue := func(ctx context.Context, obj client.Object, found bool, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+		ds, ok := obj.(*iafv1alpha1.DataSource)
+		if !ok {
+			return
+		}
+		r.dataSources.set(ds, found)
+		for _, req := range r.mapDataSourceToApplications(ctx, ds.Name) {
+			q.Add(req)
+		}
+	}
+	return handler.Funcs{
+		CreateFunc: func(ctx context.Context, e event.CreateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(ctx, e.Object, true, q)
+		},
+		UpdateFunc: func(ctx context.Context, e event.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(ctx, e.ObjectNew, true, q)
+		},
+		DeleteFunc: func(ctx context.Context, e event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(ctx, e.Object, false, q)
+		},
+	}
+}
+
+// mapDataSourceToApplications returns the apps attached to the named
+// DataSource.
+func (r *ApplicationReconciler) mapDataSourceToApplications(ctx context.Context, name string) []reconcile.Request {
+	var apps iafv1alpha1.ApplicationList
+	if err := r.List(ctx, &apps); err != nil {
+		log.FromContext(ctx).Error(err, "listing applications for datasource", "datasource", name)
+		return nil
+	}
+	var reqs []reconcile.Request
+	for _, app := range apps.Items {
+		for _, ads := range app.Spec.AttachedDataSources {
+			if ads.DataSourceName == name {
+				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: app.Name, Namespace: app.Namespace}})
+				break
+			}
+		}
+	}
+	return reqs
+}
@@ -0,0 +1,233 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// apiCalls counts the requests a reconciler makes through its client.
+type apiCalls struct {
+	dataSourceGets atomic.Int64
+	statusWrites   atomic.Int64
+	writes         atomic.Int64
+}
+
+// newCountingReconciler is newReconciler with a client that counts its
+// requests in calls.
+func newCountingReconciler(scheme *runtime.Scheme, calls *apiCalls) *ApplicationReconciler {
+	write := func() { calls.writes.Add(1) }
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&iafv1alpha1.Application{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if _, ok := obj.(*iafv1alpha1.DataSource); ok {
+					calls.dataSourceGets.Add(1)
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				write()
+				return c.Create(ctx, obj, opts...)
+			},
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				write()
+				return c.Update(ctx, obj, opts...)
+			},
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				write()
+				return c.Patch(ctx, obj, patch, opts...)
+			},
+			SubResourceUpdate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				write()
+				calls.statusWrites.Add(1)
+				return c.SubResource(subResource).Update(ctx, obj, opts...)
+			},
+			SubResourcePatch: func(ctx context.Context, c client.Client, subResource string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				write()
+				calls.statusWrites.Add(1)
+				return c.SubResource(subResource).Patch(ctx, obj, patch, opts...)
+			},
+		}).
+		Build()
+	r := newReconciler(scheme)
+	r.Client = k8sClient
+	return r
+}
+
+// runningAppWithDataSources creates an app attached to n DataSources and
+// reconciles it until it is Running.
+func runningAppWithDataSources(tb testing.TB, r *ApplicationReconciler, n int) {
+	tb.Helper()
+	ctx := context.Background()
+	app := makeApp("web", "test-ns")
+	for i := range n {
+		ds := &iafv1alpha1.DataSource{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("db-%d", i)},
+			Spec: iafv1alpha1.DataSourceSpec{
+				Kind:          "postgres",
+				SecretRef:     iafv1alpha1.DataSourceSecretRef{Name: "creds", Namespace: "iaf-data"},
+				EnvVarMapping: map[string]string{"url": fmt.Sprintf("DB_%d_URL", i)},
+			},
+		}
+		if err := r.Create(ctx, ds); err != nil {
+			tb.Fatal(err)
+		}
+		app.Spec.AttachedDataSources = append(app.Spec.AttachedDataSources, iafv1alpha1.AttachedDataSource{
+			DataSourceName: ds.Name,
+			SecretName:     "web-" + ds.Name,
+		})
+	}
+	if err := r.Create(ctx, app); err != nil {
+		tb.Fatal(err)
+	}
+	reconcile := func() {
+		if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "web", Namespace: "test-ns"}}); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	reconcile()
+	var dep appsv1.Deployment
+	if err := r.Get(ctx, types.NamespacedName{Name: "web", Namespace: "test-ns"}, &dep); err != nil {
+		tb.Fatal(err)
+	}
+	dep.Status.Replicas = 1
+	dep.Status.UpdatedReplicas = 1
+	dep.Status.ReadyReplicas = 1
+	dep.Status.AvailableReplicas = 1
+	dep.Status.ObservedGeneration = dep.Generation
+	if err := r.Status().Update(ctx, &dep); err != nil {
+		tb.Fatal(err)
+	}
+	reconcile()
+}
+
+// TestReconcile_SteadyState verifies a pass over a Running app that
+// changed nothing writes nothing and does not read its DataSources again.
+func TestReconcile_SteadyState(t *testing.T) {
+	var calls apiCalls
+	r := newCountingReconciler(newTestScheme(t), &calls)
+	runningAppWithDataSources(t, r, 3)
+	ctx := context.Background()
+
+	var app iafv1alpha1.Application
+	if err := r.Get(ctx, types.NamespacedName{Name: "web", Namespace: "test-ns"}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if app.Status.Phase != iafv1alpha1.ApplicationPhaseRunning {
+		t.Fatalf("expected phase Running, got %q", app.Status.Phase)
+	}
+	if got := calls.dataSourceGets.Load(); got != 3 {
+		t.Errorf("expected each DataSource to be read once, got %d reads", got)
+	}
+
+	calls.dataSourceGets.Store(0)
+	calls.statusWrites.Store(0)
+	calls.writes.Store(0)
+	reconcileApp(t, r, "web", "test-ns")
+	if got := calls.statusWrites.Load(); got != 0 {
+		t.Errorf("expected no status write for an unchanged app, got %d", got)
+	}
+	if got := calls.writes.Load(); got != 0 {
+		t.Errorf("expected no writes for an unchanged app, got %d", got)
+	}
+	if got := calls.dataSourceGets.Load(); got != 0 {
+		t.Errorf("expected cached DataSources, got %d reads", got)
+	}
+	var again iafv1alpha1.Application
+	if err := r.Get(ctx, types.NamespacedName{Name: "web", Namespace: "test-ns"}, &again); err != nil {
+		t.Fatal(err)
+	}
+	if again.ResourceVersion != app.ResourceVersion {
+		t.Errorf("expected the app not to be written, resourceVersion %s -> %s", app.ResourceVersion, again.ResourceVersion)
+	}
+}
+
+// TestDataSourceCache verifies the watch keeps cached mappings current and
+// that a deletion it reports is not undone by a lookup.
+func TestDataSourceCache(t *testing.T) {
+	var calls apiCalls
+	r := newCountingReconciler(newTestScheme(t), &calls)
+	ctx := context.Background()
+
+	ds := &iafv1alpha1.DataSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders"},
+		Spec:       iafv1alpha1.DataSourceSpec{EnvVarMapping: map[string]string{"url": "ORDERS_URL"}},
+	}
+	if err := r.Create(ctx, ds); err != nil {
+		t.Fatal(err)
+	}
+	var cache dataSourceCache
+	for range 2 {
+		mapping, found, err := cache.envMapping(ctx, r.Client, "orders")
+		if err != nil || !found || mapping["url"] != "ORDERS_URL" {
+			t.Fatalf("envMapping = %v, %v, %v", mapping, found, err)
+		}
+	}
+	if got := calls.dataSourceGets.Load(); got != 1 {
+		t.Errorf("expected one read, got %d", got)
+	}
+
+	ds.Spec.EnvVarMapping = map[string]string{"dsn": "ORDERS_DSN"}
+	cache.set(ds, true)
+	if mapping, _, _ := cache.envMapping(ctx, r.Client, "orders"); mapping["dsn"] != "ORDERS_DSN" || len(mapping) != 1 {
+		t.Errorf("expected the watched mapping, got %v", mapping)
+	}
+
+	// The DataSource still exists in the client, but the watch saw it go.
+	cache.set(ds, false)
+	if _, found, err := cache.envMapping(ctx, r.Client, "orders"); found || err != nil {
+		t.Errorf("expected the deleted DataSource to stay missing, got %v, %v", found, err)
+	}
+	if _, found, err := cache.envMapping(ctx, r.Client, "missing"); found || err != nil {
+		t.Errorf("expected a missing DataSource to be skipped, got %v, %v", found, err)
+	}
+
+	attached := makeApp("api", "test-ns")
+	attached.Spec.AttachedDataSources = []iafv1alpha1.AttachedDataSource{{DataSourceName: "orders", SecretName: "api-orders"}}
+	for _, app := range []*iafv1alpha1.Application{attached, makeApp("web", "test-ns")} {
+		if err := r.Create(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reqs := r.mapDataSourceToApplications(ctx, "orders")
+	if len(reqs) != 1 || reqs[0].Name != "api" {
+		t.Errorf("expected a changed DataSource to enqueue only the attached app, got %v", reqs)
+	}
+}
+
+// BenchmarkReconcile_SteadyState measures a pass over a Running app with
+// three attached DataSources that changed nothing. Reads come from the
+// manager cache in a cluster; writes reach the API server.
+func BenchmarkReconcile_SteadyState(b *testing.B) {
+	var calls apiCalls
+	r := newCountingReconciler(newTestScheme(b), &calls)
+	runningAppWithDataSources(b, r, 3)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "web", Namespace: "test-ns"}}
+	calls.writes.Store(0)
+	calls.dataSourceGets.Store(0)
+	calls.statusWrites.Store(0)
+
+	b.ResetTimer()
+	for range b.N {
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(calls.writes.Load())/float64(b.N), "writes/op")
+	b.ReportMetric(float64(calls.statusWrites.Load())/float64(b.N), "status-writes/op")
+	b.ReportMetric(float64(calls.dataSourceGets.Load())/float64(b.N), "datasource-gets/op")
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+	"github.com/dlapiduz/iaf/internal/sbom"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// conditionDependenciesChecked reports whether the packages in the SBOM
+	// of the built image were checked against the dependency policy.
+	conditionDependenciesChecked = "DependenciesChecked"
+
+	// sbomTimeout bounds reading an image's SBOM from the registry.
+	sbomTimeout = 30 * time.Second
+
+	// maxViolationsListed is how many blocked packages a condition names.
+	maxViolationsListed = 5
+)
+
+// sbomCache remembers the packages in the latest built image of each app,
+// so a policy change is checked without reading the SBOM again.
+type sbomCache struct {
+	mu      sync.Mutex
+	entries map[types.NamespacedName]sbomEntry
+}
+
+type sbomEntry struct {
+	image    string
+	packages []orgstandards.Dependency
+	// err is sbom.ErrNoSBOM for an image without an SBOM.
+	err error
+}
+
+// forget drops the packages remembered for the app key.
+func (c *sbomCache) forget(key types.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// checkDependencies checks the packages in the SBOM of image, which app
+// built, against the organisation dependency policy. It returns an error
+// wrapping errDependencyNotAllowed naming the blocked packages. Images
+// without an SBOM are deployed with the DependenciesChecked condition
+// False, since push_code already checked the dependencies they declare.
+func (r *ApplicationReconciler) checkDependencies(ctx context.Context, app *iafv1alpha1.Application, image string) error {
+	if r.Standards == nil || r.SBOM == nil {
+		return nil
+	}
+	policy := r.Standards().Dependencies
+	if policy.IsZero() {
+		meta.RemoveStatusCondition(&app.Status.Conditions, conditionDependenciesChecked)
+		return nil
+	}
+	packages, err := r.sbomPackages(ctx, app, image)
+	if errors.Is(err, sbom.ErrNoSBOM) {
+		setCondition(app, conditionDependenciesChecked, metav1.ConditionFalse, "SBOMUnavailable",
+			"The built image has no SBOM, so the packages it ships were not checked against the dependency policy")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading SBOM of %s: %w", image, err)
+	}
+	if violations := policy.Check(packages, app.Namespace, app.Name, time.Now()); len(violations) > 0 {
+		setCondition(app, conditionDependenciesChecked, metav1.ConditionFalse, "DependencyNotAllowed", describeViolations(violations))
+		return fmt.Errorf("%w: %s", errDependencyNotAllowed, describeViolations(violations))
+	}
+	setCondition(app, conditionDependenciesChecked, metav1.ConditionTrue, "Allowed",
+		fmt.Sprintf("The %d packages in the SBOM of the built image are allowed", len(packages)))
+	return nil
+}
+
+// sbomPackages returns the packages in the SBOM of image, reading it from
+// the registry only when app's latest image changed.
+func (r *ApplicationReconciler) sbomPackages(ctx context.Context, app *iafv1alpha1.Application, image string) ([]orgstandards.Dependency, error) {
+	key := types.NamespacedName{Namespace: app.Namespace, Name: app.Name}
+	r.sboms.mu.Lock()
+	entry, ok := r.sboms.entries[key]
+	r.sboms.mu.Unlock()
+	if ok && entry.image == image {
+		return entry.packages, entry.err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sbomTimeout)
+	defer cancel()
+	packages, err := r.SBOM.Packages(ctx, image)
+	if err != nil && !errors.Is(err, sbom.ErrNoSBOM) {
+		return nil, err
+	}
+	r.sboms.mu.Lock()
+	defer r.sboms.mu.Unlock()
+	if r.sboms.entries == nil {
+		r.sboms.entries = map[types.NamespacedName]sbomEntry{}
+	}
+	r.sboms.entries[key] = sbomEntry{image: image, packages: packages, err: err}
+	return packages, err
+}
+
+// describeViolations lists the first blocked packages with their reasons.
+func describeViolations(violations []orgstandards.DependencyViolation) string {
+	var parts []string
+	for i, v := range violations {
+		if i == maxViolationsListed {
+			parts = append(parts, fmt.Sprintf("and %d more", len(violations)-i))
+			break
+		}
+		name := v.Ecosystem + " package " + v.Name
+		if v.Version != "" {
+			name += " " + v.Version
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s)", name, v.Reason))
+	}
+	return "the built image ships blocked packages: " + strings.Join(parts, ", ")
+}
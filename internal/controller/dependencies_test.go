@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+	"github.com/dlapiduz/iaf/internal/sbom"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// fakeSBOM lists packages for every image, or fails with err.
+type fakeSBOM struct {
+	packages []orgstandards.Dependency
+	err      error
+	calls    int
+}
+
+func (f *fakeSBOM) Packages(ctx context.Context, image string) ([]orgstandards.Dependency, error) {
+	f.calls++
+	return f.packages, f.err
+}
+
+// TestReconcile_DependencyPolicy verifies a built image shipping a denied
+// package is not deployed, that a waiver lets it deploy, and that an image
+// without an SBOM deploys with the check reported as skipped.
+func TestReconcile_DependencyPolicy(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	standards := &orgstandards.OrgStandards{Dependencies: orgstandards.DependencyPolicy{
+		Deny: []orgstandards.DependencyRule{{Ecosystem: "npm", Package: "left-pad", Reason: "unmaintained"}},
+	}}
+	r.Standards = func() *orgstandards.OrgStandards { return standards }
+	reader := &fakeSBOM{packages: []orgstandards.Dependency{
+		{Ecosystem: "npm", Name: "express", Version: "4.19.2"},
+		{Ecosystem: "npm", Name: "left-pad", Version: "1.3.0"},
+	}}
+	r.SBOM = reader
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+
+	if err := r.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-ns"}}); err != nil {
+		t.Fatal(err)
+	}
+	app := makeApp("myapp", "test-ns")
+	app.Spec.Image = ""
+	app.Spec.Git = &iafv1alpha1.GitSource{URL: "https://github.com/example/myapp", Revision: "main"}
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	kpackImage := &unstructured.Unstructured{}
+	kpackImage.SetGroupVersionKind(iafk8s.KpackImageGVK)
+	if err := r.Get(ctx, key, kpackImage); err != nil {
+		t.Fatal(err)
+	}
+	kpackImage.Object["status"] = map[string]any{
+		"observedGeneration": kpackImage.GetGeneration(),
+		"latestImage":        "registry.example.com/myapp@sha256:aaa",
+		"conditions":         []any{map[string]any{"type": "Ready", "status": "True"}},
+	}
+	if err := r.Update(ctx, kpackImage); err != nil {
+		t.Fatal(err)
+	}
+	reconcile := func() *iafv1alpha1.Application {
+		t.Helper()
+		reconcileApp(t, r, "myapp", "test-ns")
+		var got iafv1alpha1.Application
+		if err := r.Get(ctx, key, &got); err != nil {
+			t.Fatal(err)
+		}
+		return &got
+	}
+
+	got := reconcile()
+	if got.Status.Phase != iafv1alpha1.ApplicationPhaseFailed {
+		t.Errorf("expected phase Failed, got %s", got.Status.Phase)
+	}
+	if c := meta.FindStatusCondition(got.Status.Conditions, "Ready"); c == nil || c.Reason != "DependencyNotAllowed" || !strings.Contains(c.Message, "left-pad 1.3.0 (denied: unmaintained)") {
+		t.Errorf("expected a DependencyNotAllowed condition naming left-pad, got %+v", c)
+	}
+	var dep appsv1.Deployment
+	if err := r.Get(ctx, key, &dep); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected no Deployment, got %v", err)
+	}
+
+	standards.Dependencies.Waivers = []orgstandards.DependencyWaiver{
+		{Ecosystem: "npm", Package: "left-pad", Namespace: "test-ns", App: "myapp", Reason: "migration"},
+	}
+	got = reconcile()
+	if c := meta.FindStatusCondition(got.Status.Conditions, conditionDependenciesChecked); c == nil || c.Status != metav1.ConditionTrue {
+		t.Errorf("expected the waived packages to be allowed, got %+v", c)
+	}
+	if err := r.Get(ctx, key, &dep); err != nil {
+		t.Fatalf("expected a Deployment once waived: %v", err)
+	}
+	if reader.calls != 1 {
+		t.Errorf("expected the SBOM of an unchanged image to be read once, got %d reads", reader.calls)
+	}
+
+	r.sboms.forget(key)
+	reader.err = sbom.ErrNoSBOM
+	got = reconcile()
+	if c := meta.FindStatusCondition(got.Status.Conditions, conditionDependenciesChecked); c == nil || c.Reason != "SBOMUnavailable" {
+		t.Errorf("expected an SBOMUnavailable condition, got %+v", c)
+	}
+	if c := meta.FindStatusCondition(got.Status.Conditions, "Ready"); c != nil && c.Reason == "DependencyNotAllowed" {
+		t.Errorf("expected an image without an SBOM to deploy, got %+v", c)
+	}
+}
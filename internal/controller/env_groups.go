@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// envGroupSources returns the envFrom entries for the environment groups
+// bound to app, and a hash of their variables for the pod template so a
+// change to any group rolls the Deployment. The hash is empty when no group
+// is bound.
+func (r *ApplicationReconciler) envGroupSources(ctx context.Context, app *iafv1alpha1.Application) ([]corev1.EnvFromSource, string, error) {
+	if len(app.Spec.EnvGroups) == 0 {
+		return nil, "", nil
+	}
+	logger := log.FromContext(ctx)
+	var sources []corev1.EnvFromSource
+	h := sha256.New()
+	for _, group := range app.Spec.EnvGroups {
+		var secret corev1.Secret
+		name := iafk8s.EnvGroupSecretName(group)
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: app.Namespace}, &secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				// The group may have been deleted after binding — skip gracefully.
+				logger.V(1).Info("environment group not found, skipping env injection", "envGroup", group)
+				continue
+			}
+			return nil, "", fmt.Errorf("getting environment group %q: %w", group, err)
+		}
+		// Defence-in-depth: only load Secrets the platform created for the
+		// group, never another Secret that happens to have its name.
+		if secret.Labels[iafk8s.LabelEnvGroup] != group {
+			logger.V(1).Info("secret is not an environment group, skipping env injection", "envGroup", group)
+			continue
+		}
+		sources = append(sources, corev1.EnvFromSource{
+			SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: name}},
+		})
+		fmt.Fprintf(h, "%s\x00", group)
+		for _, key := range slices.Sorted(maps.Keys(secret.Data)) {
+			fmt.Fprintf(h, "%s=%s\x00", key, secret.Data[key])
+		}
+	}
+	if len(sources) == 0 {
+		return nil, "", nil
+	}
+	return sources, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// isEnvGroupSecret reports whether obj is an environment group Secret.
+func isEnvGroupSecret(obj client.Object) bool {
+	return obj.GetLabels()[iafk8s.LabelEnvGroup] != ""
+}
+
+// mapEnvGroupToApplications enqueues the Applications bound to the
+// environment group stored in a Secret, so changing the group rolls them.
+func (r *ApplicationReconciler) mapEnvGroupToApplications(ctx context.Context, obj client.Object) []reconcile.Request {
+	group := obj.GetLabels()[iafk8s.LabelEnvGroup]
+	if group == "" {
+		return nil
+	}
+	var apps iafv1alpha1.ApplicationList
+	if err := r.List(ctx, &apps, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "listing applications for environment group", "envGroup", group)
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, app := range apps.Items {
+		if slices.Contains(app.Spec.EnvGroups, group) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: app.Name, Namespace: app.Namespace}})
+		}
+	}
+	return requests
+}
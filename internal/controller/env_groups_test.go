@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// TestReconcile_EnvGroups verifies bound environment groups are loaded with
+// envFrom, Secrets without the group label are ignored, and changing a group
+// changes the pod template so the Deployment rolls.
+func TestReconcile_EnvGroups(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+
+	group := iafk8s.BuildEnvGroupSecret("test-ns", "shared", map[string]string{"API_KEY": "one"})
+	if err := r.Create(ctx, group); err != nil {
+		t.Fatal(err)
+	}
+	// A Secret with a group's name but not created as a group.
+	if err := r.Create(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: iafk8s.EnvGroupSecretName("other"), Namespace: "test-ns"}}); err != nil {
+		t.Fatal(err)
+	}
+	app := makeApp("myapp", "test-ns")
+	app.Spec.EnvGroups = []string{"shared", "other", "missing"}
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	getDeployment := func() *appsv1.Deployment {
+		t.Helper()
+		var dep appsv1.Deployment
+		if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &dep); err != nil {
+			t.Fatal(err)
+		}
+		return &dep
+	}
+	dep := getDeployment()
+	envFrom := dep.Spec.Template.Spec.Containers[0].EnvFrom
+	if len(envFrom) != 1 || envFrom[0].SecretRef == nil || envFrom[0].SecretRef.Name != "iaf-env-shared" {
+		t.Fatalf("expected envFrom of the shared group only, got %+v", envFrom)
+	}
+	hash := dep.Spec.Template.Annotations[iafk8s.AnnotationEnvGroupsHash]
+	if hash == "" {
+		t.Fatal("expected the env groups hash on the pod template")
+	}
+
+	group.Data = map[string][]byte{"API_KEY": []byte("two")}
+	if err := r.Update(ctx, group); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if got := getDeployment().Spec.Template.Annotations[iafk8s.AnnotationEnvGroupsHash]; got == "" || got == hash {
+		t.Errorf("expected the hash to change with the group, got %q (was %q)", got, hash)
+	}
+}
+
+func TestMapEnvGroupToApplications(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+
+	for name, groups := range map[string][]string{"web": {"shared"}, "api": {"other", "shared"}, "worker": nil} {
+		app := makeApp(name, "test-ns")
+		app.Spec.EnvGroups = groups
+		if err := r.Create(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+	}
+	elsewhere := makeApp("web", "other-ns")
+	elsewhere.Spec.EnvGroups = []string{"shared"}
+	if err := r.Create(ctx, elsewhere); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := r.mapEnvGroupToApplications(ctx, iafk8s.BuildEnvGroupSecret("test-ns", "shared", nil))
+	got := map[string]bool{}
+	for _, req := range requests {
+		got[req.Namespace+"/"+req.Name] = true
+	}
+	if len(got) != 2 || !got["test-ns/web"] || !got["test-ns/api"] {
+		t.Errorf("expected test-ns/web and test-ns/api, got %v", requests)
+	}
+
+	plain := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "iaf-env-shared", Namespace: "test-ns"}}
+	if requests := r.mapEnvGroupToApplications(ctx, plain); len(requests) != 0 {
+		t.Errorf("expected no requests for an unlabelled Secret, got %v", requests)
+	}
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// gitStatus reports the commits of an app built from git, given its kpack
+// Image. The deployed commit is only read from a settled Image: one whose
+// status reflects its current spec, with a successful latest build that
+// pushed the image it reports. While a build runs, or right after the
+// controller changed the Image, the commit recorded for the same source is
+// kept, so KpackGitRevision never pins a new revision to an old commit.
+func (r *ApplicationReconciler) gitStatus(ctx context.Context, app *iafv1alpha1.Application, image *unstructured.Unstructured, updated bool) (*iafv1alpha1.GitStatus, error) {
+	status := &iafv1alpha1.GitStatus{
+		URL:        app.Spec.Git.URL,
+		Revision:   iafk8s.GitRevision(app),
+		AutoDeploy: iafk8s.GitAutoDeploy(app),
+	}
+	if prev := app.Status.Git; prev != nil && prev.URL == status.URL && prev.Revision == status.Revision {
+		status.Commit = prev.Commit
+		status.LatestCommit = prev.LatestCommit
+	}
+	if image == nil {
+		return status, nil
+	}
+
+	var builds unstructured.UnstructuredList
+	builds.SetGroupVersionKind(iafk8s.KpackBuildGVK.GroupVersion().WithKind(iafk8s.KpackBuildGVK.Kind + "List"))
+	if err := r.List(ctx, &builds, client.InNamespace(app.Namespace), client.MatchingLabels{iafk8s.LabelKpackImage: app.Name}); err != nil {
+		return nil, fmt.Errorf("listing kpack builds: %w", err)
+	}
+	latest := iafk8s.LatestKpackBuild(builds.Items)
+	if latest == nil || updated {
+		return status, nil
+	}
+	status.LatestCommit = latest.Commit
+
+	buildStatus, latestImage := iafk8s.GetKpackImageStatus(image)
+	observed, _, _ := unstructured.NestedInt64(image.Object, "status", "observedGeneration")
+	if buildStatus == "Succeeded" && observed == image.GetGeneration() && latest.Image == latestImage && latest.Commit != "" {
+		status.Commit = latest.Commit
+	}
+	return status, nil
+}
+
+// pinsDeployedCommit reports whether changing the source of the app's kpack
+// Image from current to desired only pins it to the commit the app already
+// runs, which builds nothing.
+func pinsDeployedCommit(app *iafv1alpha1.Application, current, desired map[string]any) bool {
+	revision, _, _ := unstructured.NestedString(desired, "git", "revision")
+	if current == nil || app.Status.Git == nil || app.Status.Git.Commit == "" || revision != app.Status.Git.Commit {
+		return false
+	}
+	pinned := runtime.DeepCopyJSON(current)
+	if err := unstructured.SetNestedField(pinned, revision, "git", "revision"); err != nil {
+		return false
+	}
+	return fmt.Sprintf("%v", pinned) == fmt.Sprintf("%v", desired)
+}
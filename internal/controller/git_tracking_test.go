@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+)
+
+func TestPinsDeployedCommit(t *testing.T) {
+	const commit = "0123abcdef0123abcdef0123abcdef0123abcdef"
+	source := func(revision, subPath string) map[string]any {
+		s := map[string]any{"git": map[string]any{"url": "https://github.com/example/web", "revision": revision}}
+		if subPath != "" {
+			s["subPath"] = subPath
+		}
+		return s
+	}
+	app := &iafv1alpha1.Application{Status: iafv1alpha1.ApplicationStatus{Git: &iafv1alpha1.GitStatus{Commit: commit}}}
+
+	tests := []struct {
+		name             string
+		current, desired map[string]any
+		want             bool
+	}{
+		{"pin branch", source("main", ""), source(commit, ""), true},
+		{"pin with sub path", source("main", "services/api"), source(commit, "services/api"), true},
+		{"new revision", source("main", ""), source("v2", ""), false},
+		{"sub path changed", source("main", ""), source(commit, "services/api"), false},
+		{"no current image", nil, source(commit, ""), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pinsDeployedCommit(app, tt.current, tt.desired); got != tt.want {
+				t.Errorf("pinsDeployedCommit = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if pinsDeployedCommit(&iafv1alpha1.Application{}, source("main", ""), source(commit, "")) {
+		t.Error("expected no pin before a commit is deployed")
+	}
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/registry"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// conditionImagePinned reports whether the deployed image is pinned to
+	// a digest.
+	conditionImagePinned = "ImagePinned"
+
+	// maxRevisions caps the image revisions kept in an Application's status.
+	maxRevisions = 10
+
+	// resolveTimeout bounds a registry lookup so an unreachable registry
+	// does not stall the reconcile.
+	resolveTimeout = 15 * time.Second
+)
+
+// pinImage returns image pinned to a digest and sets the ImagePinned
+// condition. Built images come from kpack already pinned. A pre-built tag is
+// resolved once: while spec.image is unchanged the digest recorded in the
+// latest revision is reused, so pushing the tag again never changes what a
+// running app deploys. When the digest cannot be resolved the tag is
+// deployed as given.
+func (r *ApplicationReconciler) pinImage(ctx context.Context, app *iafv1alpha1.Application, image string) string {
+	latestNote := ""
+	if registry.IsLatest(app.Spec.Image) {
+		latestNote = "; the mutable latest tag was requested, deploy a version tag or digest to control upgrades"
+	}
+	if digest := registry.Digest(image); digest != "" {
+		setCondition(app, conditionImagePinned, metav1.ConditionTrue, "Pinned", "Deploying digest "+digest+latestNote)
+		return image
+	}
+	if r.ImageResolver == nil {
+		setCondition(app, conditionImagePinned, metav1.ConditionFalse, "PinningDisabled",
+			fmt.Sprintf("Image %q is deployed by tag: the platform does not resolve digests%s", image, latestNote))
+		return image
+	}
+	if len(app.Status.Revisions) > 0 {
+		if rev := app.Status.Revisions[0]; rev.Digest != "" && rev.Image == registry.Pin(image, rev.Digest) {
+			setCondition(app, conditionImagePinned, metav1.ConditionTrue, "Pinned", "Deploying digest "+rev.Digest+latestNote)
+			return rev.Image
+		}
+	}
+
+	digest, err := r.resolveDigest(ctx, app, image)
+	if err != nil {
+		log.FromContext(ctx).Info("resolving image digest", "image", image, "error", err.Error())
+		setCondition(app, conditionImagePinned, metav1.ConditionFalse, "DigestResolutionFailed",
+			fmt.Sprintf("Image %q is deployed by tag: %v%s", image, err, latestNote))
+		return image
+	}
+	setCondition(app, conditionImagePinned, metav1.ConditionTrue, "Pinned", "Deploying digest "+digest+latestNote)
+	return registry.Pin(image, digest)
+}
+
+// resolveDigest looks up the digest of image, authenticating with the app's
+// registry credential when it has one.
+func (r *ApplicationReconciler) resolveDigest(ctx context.Context, app *iafv1alpha1.Application, image string) (string, error) {
+	var auths map[string]registry.Auth
+	if app.Spec.RegistryCredential != "" {
+		var secret corev1.Secret
+		if err := r.Get(ctx, types.NamespacedName{Name: app.Spec.RegistryCredential, Namespace: app.Namespace}, &secret); err != nil {
+			return "", fmt.Errorf("getting registry credential: %w", err)
+		}
+		var err error
+		if auths, err = registry.AuthsFromDockerConfig(secret.Data[corev1.DockerConfigJsonKey]); err != nil {
+			return "", err
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+	return r.ImageResolver.Resolve(ctx, image, auths)
+}
+
+// recordRevision records image as the app's deployed image, adding a
+// revision when it differs from the latest one.
+func recordRevision(app *iafv1alpha1.Application, image string) {
+	digest := registry.Digest(image)
+	app.Status.ImageDigest = digest
+	if len(app.Status.Revisions) > 0 && app.Status.Revisions[0].Image == image {
+		return
+	}
+	rev := iafv1alpha1.ImageRevision{Image: image, Digest: digest, DeployedAt: metav1.Now()}
+	app.Status.Revisions = append([]iafv1alpha1.ImageRevision{rev}, app.Status.Revisions...)
+	if len(app.Status.Revisions) > maxRevisions {
+		app.Status.Revisions = app.Status.Revisions[:maxRevisions]
+	}
+}
@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/registry"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	digestV1 = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	digestV2 = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+// fakeResolver resolves every image to digest, or fails with err.
+type fakeResolver struct {
+	digest string
+	err    error
+	calls  int
+}
+
+func (f *fakeResolver) Resolve(ctx context.Context, image string, auths map[string]registry.Auth) (string, error) {
+	f.calls++
+	return f.digest, f.err
+}
+
+func TestReconcile_PinsImageDigest(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	resolver := &fakeResolver{digest: digestV1}
+	r.ImageResolver = resolver
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+
+	app := makeApp("myapp", "test-ns")
+	app.Spec.Image = "ghcr.io/org/web:v1"
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	deployedImage := func() string {
+		t.Helper()
+		var dep appsv1.Deployment
+		if err := r.Get(ctx, key, &dep); err != nil {
+			t.Fatal(err)
+		}
+		return dep.Spec.Template.Spec.Containers[0].Image
+	}
+	if got := deployedImage(); got != "ghcr.io/org/web:v1@"+digestV1 {
+		t.Errorf("expected the pinned image deployed, got %q", got)
+	}
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	if app.Status.ImageDigest != digestV1 || len(app.Status.Revisions) != 1 || app.Status.Revisions[0].Digest != digestV1 {
+		t.Errorf("unexpected digest status %q %+v", app.Status.ImageDigest, app.Status.Revisions)
+	}
+	if c := meta.FindStatusCondition(app.Status.Conditions, conditionImagePinned); c == nil || c.Reason != "Pinned" {
+		t.Errorf("expected ImagePinned, got %+v", c)
+	}
+
+	// The tag is pushed again: the running app keeps its digest.
+	resolver.digest = digestV2
+	reconcileApp(t, r, "myapp", "test-ns")
+	if got := deployedImage(); got != "ghcr.io/org/web:v1@"+digestV1 {
+		t.Errorf("expected the recorded digest reused, got %q", got)
+	}
+	if resolver.calls != 1 {
+		t.Errorf("expected one registry lookup, got %d", resolver.calls)
+	}
+
+	// A new spec.image is resolved and recorded as a new revision.
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	app.Spec.Image = "ghcr.io/org/web:v2"
+	if err := r.Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if got := deployedImage(); got != "ghcr.io/org/web:v2@"+digestV2 {
+		t.Errorf("expected the new image pinned, got %q", got)
+	}
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	if len(app.Status.Revisions) != 2 || app.Status.Revisions[0].Digest != digestV2 || app.Status.Revisions[1].Digest != digestV1 {
+		t.Errorf("expected two revisions newest first, got %+v", app.Status.Revisions)
+	}
+}
+
+func TestReconcile_DeploysTagWhenDigestUnresolved(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	r.ImageResolver = &fakeResolver{err: errors.New("registry unreachable")}
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+
+	app := makeApp("myapp", "test-ns")
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	var dep appsv1.Deployment
+	if err := r.Get(ctx, key, &dep); err != nil {
+		t.Fatal(err)
+	}
+	if got := dep.Spec.Template.Spec.Containers[0].Image; got != "nginx:latest" {
+		t.Errorf("expected the tag deployed, got %q", got)
+	}
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	c := meta.FindStatusCondition(app.Status.Conditions, conditionImagePinned)
+	if c == nil || c.Reason != "DigestResolutionFailed" {
+		t.Fatalf("expected DigestResolutionFailed, got %+v", c)
+	}
+	if app.Status.ImageDigest != "" || len(app.Status.Revisions) != 1 {
+		t.Errorf("unexpected digest status %q %+v", app.Status.ImageDigest, app.Status.Revisions)
+	}
+}
+
+func TestRecordRevision_CapsHistory(t *testing.T) {
+	app := &iafv1alpha1.Application{}
+	for i := range maxRevisions + 3 {
+		recordRevision(app, registry.Pin("web", "sha256:"+string(rune('a'+i))))
+	}
+	if len(app.Status.Revisions) != maxRevisions {
+		t.Errorf("expected %d revisions, got %d", maxRevisions, len(app.Status.Revisions))
+	}
+	recordRevision(app, app.Status.Revisions[0].Image)
+	if len(app.Status.Revisions) != maxRevisions {
+		t.Errorf("expected redeploying the same image to add no revision")
+	}
+}
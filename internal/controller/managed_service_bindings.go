@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// managedServicesHashAnnotation is set on the pod template to a hash of the
+// connection Secrets of the app's bound managed services, so new or rotated
+// credentials roll the Deployment; env vars from Secrets are only read when
+// a container starts.
+const managedServicesHashAnnotation = "iaf.io/managed-services-hash"
+
+// labelCNPGCluster is set by CloudNativePG on the Secrets it generates for
+// a cluster.
+const labelCNPGCluster = "cnpg.io/cluster"
+
+// managedServicesHash returns a hash of the connection Secrets of the
+// managed services bound to app, or "" when none is bound. A Secret that
+// does not exist yet hashes differently from any content, so its creation
+// rolls the pods too.
+func (r *ApplicationReconciler) managedServicesHash(ctx context.Context, app *iafv1alpha1.Application) (string, error) {
+	if len(app.Spec.BoundManagedServices) == 0 {
+		return "", nil
+	}
+	h := sha256.New()
+	for _, bms := range app.Spec.BoundManagedServices {
+		fmt.Fprintf(h, "%s\x00%s\x00", bms.ServiceName, bms.SecretName)
+		var secret corev1.Secret
+		if err := r.Get(ctx, types.NamespacedName{Name: bms.SecretName, Namespace: app.Namespace}, &secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				fmt.Fprint(h, "missing\x00")
+				continue
+			}
+			return "", fmt.Errorf("getting connection secret of service %q: %w", bms.ServiceName, err)
+		}
+		for _, key := range slices.Sorted(maps.Keys(secret.Data)) {
+			fmt.Fprintf(h, "%s=%s\x00", key, secret.Data[key])
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// managedServicePrimaryKeys are the keys of managedServicePGEnvVars that
+// point at the primary, left out of read-only bindings.
+var managedServicePrimaryKeys = []string{"host", "uri"}
+
+// managedServiceEnv returns the env vars a binding injects: the PG*
+// variables from the service's connection Secret and, for read-only
+// bindings, PGHOST_RO and DATABASE_READ_URL for the CNPG "-ro" Service,
+// which balances over the replicas. DATABASE_READ_URL is assembled from the
+// variables before it, so the credentials stay in the Secret. Every name
+// starts with the binding's EnvPrefix.
+func managedServiceEnv(bms iafv1alpha1.BoundManagedService) []corev1.EnvVar {
+	readOnly := bms.Mode == iafv1alpha1.BindModeReadOnly
+	var env []corev1.EnvVar
+	for _, secretKey := range slices.Sorted(maps.Keys(managedServicePGEnvVars)) {
+		if readOnly && slices.Contains(managedServicePrimaryKeys, secretKey) {
+			continue
+		}
+		env = append(env, corev1.EnvVar{
+			Name: bms.EnvPrefix + managedServicePGEnvVars[secretKey],
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: bms.SecretName},
+					Key:                  secretKey,
+				},
+			},
+		})
+	}
+	if readOnly || bms.Mode == iafv1alpha1.BindModeBoth {
+		host, p := bms.ServiceName+"-ro", bms.EnvPrefix
+		env = append(env,
+			corev1.EnvVar{Name: p + "PGHOST_RO", Value: host},
+			corev1.EnvVar{Name: p + "DATABASE_READ_URL", Value: fmt.Sprintf("postgresql://$(%[1]sPGUSER):$(%[1]sPGPASSWORD)@%[2]s:$(%[1]sPGPORT)/$(%[1]sPGDATABASE)", p, host)},
+		)
+	}
+	return env
+}
+
+// isManagedServiceSecret reports whether obj is a connection Secret
+// generated for a managed service's database cluster.
+func isManagedServiceSecret(obj client.Object) bool {
+	return obj.GetLabels()[labelCNPGCluster] != ""
+}
+
+// mapManagedServiceSecretToApplications enqueues the Applications bound to
+// a managed service through a connection Secret, so rotating its
+// credentials rolls them.
+func (r *ApplicationReconciler) mapManagedServiceSecretToApplications(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.boundApplications(ctx, obj.GetNamespace(), func(bms iafv1alpha1.BoundManagedService) bool {
+		return bms.SecretName == obj.GetName()
+	})
+}
+
+// mapManagedServiceToApplications enqueues the Applications bound to a
+// ManagedService when it changes, such as when it becomes Ready again.
+func (r *ApplicationReconciler) mapManagedServiceToApplications(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.boundApplications(ctx, obj.GetNamespace(), func(bms iafv1alpha1.BoundManagedService) bool {
+		return bms.ServiceName == obj.GetName()
+	})
+}
+
+// boundApplications returns requests for the Applications in namespace
+// with a bound managed service matching match.
+func (r *ApplicationReconciler) boundApplications(ctx context.Context, namespace string, match func(iafv1alpha1.BoundManagedService) bool) []reconcile.Request {
+	var apps iafv1alpha1.ApplicationList
+	if err := r.List(ctx, &apps, client.InNamespace(namespace)); err != nil {
+		log.FromContext(ctx).Error(err, "listing applications for managed service")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, app := range apps.Items {
+		if slices.ContainsFunc(app.Spec.BoundManagedServices, match) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: app.Name, Namespace: app.Namespace}})
+		}
+	}
+	return requests
+}
@@ -2,20 +2,27 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
 	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 const managedServiceFinalizer = "iaf.io/managed-service-protection"
@@ -23,18 +30,38 @@ const managedServiceFinalizer = "iaf.io/managed-service-protection"
 // +kubebuilder:rbac:groups=iaf.io,resources=managedservices,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=iaf.io,resources=managedservices/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=iaf.io,resources=managedservices/finalizers,verbs=update
+// +kubebuilder:rbac:groups=iaf.io,resources=serviceplans,verbs=get;list;watch
 // +kubebuilder:rbac:groups=postgresql.cnpg.io,resources=clusters,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=postgresql.cnpg.io,resources=scheduledbackups,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=list
+// +kubebuilder:rbac:groups="",resources=events,verbs=list
 
 // ManagedServiceReconciler reconciles ManagedService CRs.
 type ManagedServiceReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// Postgres are the PostgreSQL versions offered. With none, clusters run
+	// the CNPG operator's default image and are never upgraded.
+	Postgres iafk8s.PostgresVersions
+
+	// APIReader reads the volumes and events of database clusters straight
+	// from the API server, so the manager does not cache every volume and
+	// event in the cluster. Nil reads through the client.
+	APIReader client.Reader
+
+	// Shard limits the reconciler to the namespaces of one controller
+	// deployment. The zero value reconciles every namespace.
+	Shard Shard
 }
 
 // Reconcile is the main reconciliation loop for ManagedService CRs.
 func (r *ManagedServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
+	if !r.Shard.contains(ctx, r.Client, req.Namespace) {
+		return ctrl.Result{}, nil
+	}
 
 	var svc iafv1alpha1.ManagedService
 	if err := r.Get(ctx, req.NamespacedName, &svc); err != nil {
@@ -59,43 +86,169 @@ func (r *ManagedServiceReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{Requeue: true}, nil
 	}
 
+	read := svc.DeepCopy()
+	expiresAt, hasTTL := expiry(&svc, svc.Spec.TTL)
+	var state expiryState
+	if hasTTL {
+		now := time.Now()
+		switch {
+		case now.Before(expiresAt):
+			state = newExpiryState(expiresAt, svc.Spec.TTL.Duration, now)
+		case svc.Spec.DeletionProtected:
+			state = protectedExpiryState(expiresAt)
+		default:
+			return r.expire(ctx, &svc)
+		}
+		svc.Status.ExpiresAt = &metav1.Time{Time: expiresAt}
+		meta.SetStatusCondition(&svc.Status.Conditions, metav1.Condition{
+			Type:    conditionExpiring,
+			Status:  state.status,
+			Reason:  state.reason,
+			Message: state.message,
+		})
+	} else {
+		svc.Status.ExpiresAt = nil
+		meta.RemoveStatusCondition(&svc.Status.Conditions, conditionExpiring)
+	}
+
+	// Resolve the plan the service is sized by. A plan that is not offered,
+	// for example a ServicePlan deleted since, fails the service until it
+	// is offered again.
+	servicePlan, err := iafk8s.LookupServicePlan(ctx, r.Client, svc.Spec.Type, svc.Spec.Plan)
+	if err != nil {
+		var apiErr *apierror.Error
+		if !errors.As(err, &apiErr) {
+			return ctrl.Result{}, err
+		}
+		svc.Status.Phase = iafv1alpha1.ManagedServicePhaseFailed
+		svc.Status.Message = apiErr.Message + "."
+		if err := patchStatus(ctx, r.Client, read, &svc); err != nil {
+			return ctrl.Result{}, fmt.Errorf("updating managed service status: %w", err)
+		}
+		return ctrl.Result{}, nil
+	}
+	svc.Status.Instances = servicePlan.Spec.Instances
+
 	// Create or update the CNPG Cluster CR.
-	if err := r.reconcileCNPGCluster(ctx, &svc); err != nil {
+	now := time.Now()
+	plan, created, err := r.reconcileCNPGCluster(ctx, &svc, servicePlan.Spec, now)
+	if err != nil {
 		return ctrl.Result{}, err
 	}
+	applyVersionStatus(&svc, plan, now)
+	if !created {
+		// The cluster cannot be created at the requested version.
+		svc.Status.Phase = iafv1alpha1.ManagedServicePhaseFailed
+		svc.Status.Message = plan.problem
+		if err := patchStatus(ctx, r.Client, read, &svc); err != nil {
+			return ctrl.Result{}, fmt.Errorf("updating managed service status: %w", err)
+		}
+		return ctrl.Result{}, nil
+	}
 
 	// Create or update the NetworkPolicy.
 	if err := r.reconcileNetworkPolicy(ctx, &svc); err != nil {
 		return ctrl.Result{}, err
 	}
 
+	// Schedule the backups of the plan.
+	if err := r.reconcileScheduledBackup(ctx, &svc, servicePlan.Spec.Backup); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Read cluster status and mirror it to ManagedService.Status.
-	phase, secretName, err := r.readClusterStatus(ctx, &svc)
+	phase, secretName, progress, err := r.readClusterStatus(ctx, &svc)
 	if err != nil {
 		logger.V(1).Info("cluster status not yet available", "error", err)
 		phase = string(iafv1alpha1.ManagedServicePhaseProvisioning)
 	}
 
 	svc.Status.Phase = iafv1alpha1.ManagedServicePhase(phase)
-	if phase == string(iafv1alpha1.ManagedServicePhaseReady) {
+	svc.Status.Progress = progress
+	switch {
+	case phase == string(iafv1alpha1.ManagedServicePhaseReady):
 		svc.Status.ConnectionSecretRef = secretName
 		svc.Status.Message = "Service is ready. Use bind_service to inject credentials into an application."
-	} else {
+	case progress != nil:
+		svc.Status.Message = iafk8s.CNPGProgressMessage(progress, svc.Status.Instances)
+	default:
 		svc.Status.Message = "Provisioning in progress. Poll service_status every 10s."
 	}
 
-	if err := r.Status().Update(ctx, &svc); err != nil {
+	if err := patchStatus(ctx, r.Client, read, &svc); err != nil {
 		return ctrl.Result{}, fmt.Errorf("updating managed service status: %w", err)
 	}
 
+	var result ctrl.Result
 	if phase != string(iafv1alpha1.ManagedServicePhaseReady) {
-		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		result.RequeueAfter = 10 * time.Second
+	}
+	if state.next > 0 {
+		result = requeueBy(result, state.next)
+	}
+	if plan.opensIn > 0 {
+		result = requeueBy(result, plan.opensIn)
+	}
+	return result, nil
+}
+
+// expire deletes a ManagedService whose TTL has elapsed. Like
+// deprovision_service it waits while applications that still exist are bound
+// to the service, and drops bindings to applications that are gone.
+func (r *ManagedServiceReconciler) expire(ctx context.Context, svc *iafv1alpha1.ManagedService) (ctrl.Result, error) {
+	// bind_service and unbind_service change the bound apps too: write them
+	// only over the list read here, and look again on a conflict.
+	read := svc.DeepCopy()
+	var stillBound []string
+	for _, appName := range svc.Status.BoundApps {
+		var app iafv1alpha1.Application
+		err := r.Get(ctx, types.NamespacedName{Name: appName, Namespace: svc.Namespace}, &app)
+		if err == nil {
+			stillBound = append(stillBound, appName)
+		} else if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("checking bound application %q: %w", appName, err)
+		}
 	}
+	if len(stillBound) > 0 {
+		svc.Status.BoundApps = stillBound
+		meta.SetStatusCondition(&svc.Status.Conditions, metav1.Condition{
+			Type:   conditionExpiring,
+			Status: metav1.ConditionTrue,
+			Reason: "ExpiryBlocked",
+			Message: fmt.Sprintf(
+				"TTL elapsed but the service is still bound to applications %v. It is deleted once they are unbound.",
+				stillBound,
+			),
+		})
+		if err := patchStatus(ctx, r.Client, read, svc, client.MergeFromWithOptimisticLock{}); err != nil {
+			if apierrors.IsConflict(err) {
+				return ctrl.Result{Requeue: true}, nil
+			}
+			return ctrl.Result{}, fmt.Errorf("updating status for blocked expiry: %w", err)
+		}
+		return ctrl.Result{RequeueAfter: expiryBlockedRequeue}, nil
+	}
+	if len(svc.Status.BoundApps) > 0 {
+		// All bound apps are gone — clear the stale list so the finalizer guard lets the deletion through.
+		svc.Status.BoundApps = nil
+		if err := patchStatus(ctx, r.Client, read, svc, client.MergeFromWithOptimisticLock{}); err != nil {
+			if apierrors.IsConflict(err) {
+				return ctrl.Result{Requeue: true}, nil
+			}
+			return ctrl.Result{}, fmt.Errorf("clearing stale bound apps: %w", err)
+		}
+	}
+
+	if err := r.Delete(ctx, svc); err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, fmt.Errorf("deleting expired managed service: %w", err)
+	}
+	log.FromContext(ctx).Info("deleted managed service after its TTL elapsed", "ttl", svc.Spec.TTL.Duration)
 	return ctrl.Result{}, nil
 }
 
 // reconcileDelete handles deletion of a ManagedService, enforcing the finalizer guard.
 func (r *ManagedServiceReconciler) reconcileDelete(ctx context.Context, svc *iafv1alpha1.ManagedService) (ctrl.Result, error) {
+	read := svc.DeepCopy()
 	if len(svc.Status.BoundApps) > 0 {
 		// Keep finalizer: service has bound apps.
 		svc.Status.Phase = iafv1alpha1.ManagedServicePhaseFailed
@@ -103,7 +256,7 @@ func (r *ManagedServiceReconciler) reconcileDelete(ctx context.Context, svc *iaf
 			"Cannot delete: service is still bound to applications %v. Use unbind_service to remove all bindings first.",
 			svc.Status.BoundApps,
 		)
-		if err := r.Status().Update(ctx, svc); err != nil {
+		if err := patchStatus(ctx, r.Client, read, svc); err != nil {
 			return ctrl.Result{}, fmt.Errorf("updating status for blocked deletion: %w", err)
 		}
 		return ctrl.Result{}, fmt.Errorf("service %q still bound to applications %v", svc.Name, svc.Status.BoundApps)
@@ -117,27 +270,33 @@ func (r *ManagedServiceReconciler) reconcileDelete(ctx context.Context, svc *iaf
 	return ctrl.Result{}, nil
 }
 
-// reconcileCNPGCluster creates or updates the CloudNativePG Cluster CR.
-func (r *ManagedServiceReconciler) reconcileCNPGCluster(ctx context.Context, svc *iafv1alpha1.ManagedService) error {
-	desired := iafk8s.BuildCNPGCluster(svc)
-
+// reconcileCNPGCluster creates or updates the CloudNativePG Cluster CR,
+// sized by servicePlan, at the version planVersion picks for now. created is
+// false when the cluster does not exist and cannot be created, as the
+// plan's problem explains.
+func (r *ManagedServiceReconciler) reconcileCNPGCluster(ctx context.Context, svc *iafv1alpha1.ManagedService, servicePlan iafv1alpha1.ServicePlanSpec, now time.Time) (plan versionPlan, created bool, err error) {
 	existing := &unstructured.Unstructured{}
 	existing.SetGroupVersionKind(iafk8s.CNPGClusterGVK)
-	err := r.Get(ctx, types.NamespacedName{Name: svc.Name, Namespace: svc.Namespace}, existing)
+	err = r.Get(ctx, types.NamespacedName{Name: svc.Name, Namespace: svc.Namespace}, existing)
 	if err != nil {
 		if !apierrors.IsNotFound(err) {
-			return fmt.Errorf("getting CNPG cluster: %w", err)
+			return plan, false, fmt.Errorf("getting CNPG cluster: %w", err)
 		}
-		if err := r.Create(ctx, desired); err != nil && !apierrors.IsAlreadyExists(err) {
-			return fmt.Errorf("creating CNPG cluster: %w", err)
+		plan = r.planVersion(svc, nil, now)
+		if plan.problem != "" {
+			return plan, false, nil
 		}
-		return nil
+		if err := r.Create(ctx, iafk8s.BuildCNPGCluster(svc, servicePlan, plan.image)); err != nil && !apierrors.IsAlreadyExists(err) {
+			return plan, false, fmt.Errorf("creating CNPG cluster: %w", err)
+		}
+		return plan, true, nil
 	}
-	existing.Object["spec"] = desired.Object["spec"]
+	plan = r.planVersion(svc, existing, now)
+	existing.Object["spec"] = iafk8s.BuildCNPGCluster(svc, servicePlan, plan.image).Object["spec"]
 	if err := r.Update(ctx, existing); err != nil {
-		return fmt.Errorf("updating CNPG cluster: %w", err)
+		return plan, true, fmt.Errorf("updating CNPG cluster: %w", err)
 	}
-	return nil
+	return plan, true, nil
 }
 
 // reconcileNetworkPolicy creates or updates the NetworkPolicy for the CNPG cluster.
@@ -162,21 +321,94 @@ func (r *ManagedServiceReconciler) reconcileNetworkPolicy(ctx context.Context, s
 	return nil
 }
 
-// readClusterStatus fetches the CNPG Cluster CR and extracts its phase and secret name.
-func (r *ManagedServiceReconciler) readClusterStatus(ctx context.Context, svc *iafv1alpha1.ManagedService) (phase, secretName string, err error) {
+// reconcileScheduledBackup creates or updates the CloudNativePG
+// ScheduledBackup of the service for backup, or deletes it when the plan
+// has no backups.
+func (r *ManagedServiceReconciler) reconcileScheduledBackup(ctx context.Context, svc *iafv1alpha1.ManagedService, backup *iafv1alpha1.ServicePlanBackup) error {
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(iafk8s.CNPGScheduledBackupGVK)
+	err := r.Get(ctx, types.NamespacedName{Name: svc.Name, Namespace: svc.Namespace}, existing)
+	if backup == nil {
+		if err == nil {
+			if err := r.Delete(ctx, existing); err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("deleting scheduled backup: %w", err)
+			}
+		} else if !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return fmt.Errorf("getting scheduled backup: %w", err)
+		}
+		return nil
+	}
+	desired := iafk8s.BuildCNPGScheduledBackup(svc, backup)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("getting scheduled backup: %w", err)
+		}
+		if err := r.Create(ctx, desired); err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("creating scheduled backup: %w", err)
+		}
+		return nil
+	}
+	existing.Object["spec"] = desired.Object["spec"]
+	if err := r.Update(ctx, existing); err != nil {
+		return fmt.Errorf("updating scheduled backup: %w", err)
+	}
+	return nil
+}
+
+// readClusterStatus fetches the CNPG Cluster CR and extracts its phase,
+// secret name and progress. The progress has recent events only while the
+// cluster is not ready.
+func (r *ManagedServiceReconciler) readClusterStatus(ctx context.Context, svc *iafv1alpha1.ManagedService) (phase, secretName string, progress *iafv1alpha1.ManagedServiceProgress, err error) {
 	existing := &unstructured.Unstructured{}
 	existing.SetGroupVersionKind(iafk8s.CNPGClusterGVK)
 	if err := r.Get(ctx, types.NamespacedName{Name: svc.Name, Namespace: svc.Namespace}, existing); err != nil {
-		return "", "", err
+		return "", "", nil, err
 	}
 	ph, sec := iafk8s.GetCNPGClusterStatus(existing)
-	return ph, sec, nil
+
+	var reader client.Reader = r.Client
+	if r.APIReader != nil {
+		reader = r.APIReader
+	}
+	var pvcs corev1.PersistentVolumeClaimList
+	if err := reader.List(ctx, &pvcs, client.InNamespace(svc.Namespace), client.MatchingLabels{"cnpg.io/cluster": svc.Name}); err != nil {
+		return "", "", nil, fmt.Errorf("listing cluster volumes: %w", err)
+	}
+	var events corev1.EventList
+	if ph != string(iafv1alpha1.ManagedServicePhaseReady) {
+		if err := reader.List(ctx, &events, client.InNamespace(svc.Namespace)); err != nil {
+			return "", "", nil, fmt.Errorf("listing events: %w", err)
+		}
+	}
+	return ph, sec, iafk8s.CNPGProgress(existing, pvcs.Items, events.Items), nil
 }
 
 // SetupWithManager registers the controller with the manager.
 func (r *ManagedServiceReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&iafv1alpha1.ManagedService{}).
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&iafv1alpha1.ManagedService{}, r.Shard.forOptions(mgr.GetClient())...).
 		Owns(&networkingv1.NetworkPolicy{}).
-		Complete(r)
+		// Changing a plan resizes the services on it.
+		Watches(&iafv1alpha1.ServicePlan{}, handler.EnqueueRequestsFromMapFunc(r.mapServicePlanToManagedServices))
+	return r.Shard.watchNamespaces(b, listManagedServices(mgr.GetClient())).Complete(r)
+}
+
+// mapServicePlanToManagedServices enqueues the managed services on a plan.
+func (r *ManagedServiceReconciler) mapServicePlanToManagedServices(ctx context.Context, obj client.Object) []reconcile.Request {
+	plan, ok := obj.(*iafv1alpha1.ServicePlan)
+	if !ok {
+		return nil
+	}
+	var list iafv1alpha1.ManagedServiceList
+	if err := r.List(ctx, &list); err != nil {
+		log.FromContext(ctx).Error(err, "listing managed services for service plan", "plan", plan.Name)
+		return nil
+	}
+	var reqs []reconcile.Request
+	for _, svc := range list.Items {
+		if string(svc.Spec.Plan) == plan.Name && svc.Spec.Type == plan.Spec.Type {
+			reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: svc.Name, Namespace: svc.Namespace}})
+		}
+	}
+	return reqs
 }
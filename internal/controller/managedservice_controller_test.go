@@ -2,17 +2,24 @@ package controller
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
 	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -156,6 +163,79 @@ func TestManagedServiceReconcile_StatusMirrored(t *testing.T) {
 	}
 }
 
+func TestManagedServiceReconcile_Progress(t *testing.T) {
+	scheme := newMSTestScheme(t)
+	r := newMSReconciler(scheme)
+	ctx := context.Background()
+
+	svc := makeManagedSvc("pgdb", "iaf-test")
+	svc.Finalizers = []string{managedServiceFinalizer}
+	if err := r.Create(ctx, svc); err != nil {
+		t.Fatal(err)
+	}
+	reconcileMS(t, r, "pgdb", "iaf-test")
+
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(iafk8s.CNPGClusterGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: "pgdb", Namespace: "iaf-test"}, cluster); err != nil {
+		t.Fatal(err)
+	}
+	cluster.Object["status"] = map[string]any{"phase": "Setting up primary", "readyInstances": int64(0)}
+	if err := r.Update(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "pgdb-1", Namespace: "iaf-test", Labels: map[string]string{"cnpg.io/cluster": "pgdb"}},
+		Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+	}
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "pgdb-1.1", Namespace: "iaf-test"},
+		InvolvedObject: corev1.ObjectReference{Kind: "PersistentVolumeClaim", Name: "pgdb-1"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "ProvisioningFailed",
+		Message:        `storageclass.storage.k8s.io "fast" not found`,
+		LastTimestamp:  metav1.Now(),
+	}
+	for _, obj := range []client.Object{pvc, event} {
+		if err := r.Create(ctx, obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reconcileMS(t, r, "pgdb", "iaf-test")
+
+	var got iafv1alpha1.ManagedService
+	if err := r.Get(ctx, types.NamespacedName{Name: "pgdb", Namespace: "iaf-test"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	p := got.Status.Progress
+	if p == nil || p.ClusterPhase != "Setting up primary" || p.Volumes != 1 || p.VolumesBound != 0 || len(p.Events) != 1 {
+		t.Fatalf("unexpected progress %+v", p)
+	}
+	if !strings.Contains(got.Status.Message, "0/1 volumes bound") || !strings.Contains(got.Status.Message, "ProvisioningFailed") {
+		t.Errorf("expected the message to name the unbound volume, got %q", got.Status.Message)
+	}
+
+	// Once ready, events are no longer kept.
+	if err := r.Get(ctx, types.NamespacedName{Name: "pgdb", Namespace: "iaf-test"}, cluster); err != nil {
+		t.Fatal(err)
+	}
+	cluster.Object["status"] = map[string]any{
+		"readyInstances": int64(1),
+		"currentPrimary": "pgdb-1",
+		"conditions":     []any{map[string]any{"type": "Ready", "status": "True"}},
+	}
+	if err := r.Update(ctx, cluster); err != nil {
+		t.Fatal(err)
+	}
+	reconcileMS(t, r, "pgdb", "iaf-test")
+	if err := r.Get(ctx, types.NamespacedName{Name: "pgdb", Namespace: "iaf-test"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if p := got.Status.Progress; got.Status.Phase != iafv1alpha1.ManagedServicePhaseReady || p == nil || p.ReadyInstances != 1 || p.CurrentPrimary != "pgdb-1" || len(p.Events) != 0 {
+		t.Errorf("unexpected ready status %+v", got.Status)
+	}
+}
+
 func TestManagedServiceReconcile_DeletionBlocked(t *testing.T) {
 	scheme := newMSTestScheme(t)
 	r := newMSReconciler(scheme)
@@ -239,3 +319,184 @@ func TestManagedServiceReconcile_DeletionAllowed(t *testing.T) {
 		}
 	}
 }
+
+func TestManagedServiceReconcile_TTL(t *testing.T) {
+	scheme := newMSTestScheme(t)
+	r := newMSReconciler(scheme)
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "demodb", Namespace: "iaf-test"}
+
+	app := makeApp("myapp", "iaf-test")
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	svc := makeManagedSvc("demodb", "iaf-test")
+	svc.Finalizers = []string{managedServiceFinalizer}
+	svc.CreationTimestamp = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	svc.Spec.TTL = &metav1.Duration{Duration: time.Hour}
+	if err := r.Create(ctx, svc); err != nil {
+		t.Fatal(err)
+	}
+	svc.Status.BoundApps = []string{"myapp", "gone"}
+	if err := r.Status().Update(ctx, svc); err != nil {
+		t.Fatal(err)
+	}
+
+	// A bound application blocks expiry.
+	res := reconcileMS(t, r, "demodb", "iaf-test")
+	if res.RequeueAfter != expiryBlockedRequeue {
+		t.Errorf("expected requeue after %v, got %v", expiryBlockedRequeue, res.RequeueAfter)
+	}
+	var updated iafv1alpha1.ManagedService
+	if err := r.Get(ctx, key, &updated); err != nil {
+		t.Fatal(err)
+	}
+	if !updated.DeletionTimestamp.IsZero() {
+		t.Fatal("expected bound service not to be deleted")
+	}
+	if len(updated.Status.BoundApps) != 1 || updated.Status.BoundApps[0] != "myapp" {
+		t.Errorf("expected stale binding to be dropped, got %v", updated.Status.BoundApps)
+	}
+	cond := meta.FindStatusCondition(updated.Status.Conditions, conditionExpiring)
+	if cond == nil || cond.Reason != "ExpiryBlocked" {
+		t.Fatalf("expected Expiring/ExpiryBlocked, got %+v", cond)
+	}
+
+	// Once the application is gone, deletion protection still blocks it.
+	if err := r.Delete(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	updated.Spec.DeletionProtected = true
+	if err := r.Update(ctx, &updated); err != nil {
+		t.Fatal(err)
+	}
+	reconcileMS(t, r, "demodb", "iaf-test")
+	if err := r.Get(ctx, key, &updated); err != nil {
+		t.Fatal(err)
+	}
+	cond = meta.FindStatusCondition(updated.Status.Conditions, conditionExpiring)
+	if !updated.DeletionTimestamp.IsZero() || cond == nil || cond.Reason != "ExpiryBlocked" || !strings.Contains(cond.Message, "deletion protection") {
+		t.Fatalf("expected protected service to be kept with Expiring/ExpiryBlocked, got %+v", cond)
+	}
+
+	// Once the protection is cleared the service is deleted.
+	updated.Spec.DeletionProtected = false
+	if err := r.Update(ctx, &updated); err != nil {
+		t.Fatal(err)
+	}
+	reconcileMS(t, r, "demodb", "iaf-test")
+	if err := r.Get(ctx, key, &updated); err != nil {
+		t.Fatal(err)
+	}
+	if updated.DeletionTimestamp.IsZero() {
+		t.Error("expected expired service to be deleted")
+	}
+}
+
+func TestManagedServiceReconcile_ServicePlan(t *testing.T) {
+	scheme := newMSTestScheme(t)
+	r := newMSReconciler(scheme)
+	ctx := context.Background()
+
+	plan := &iafv1alpha1.ServicePlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "large"},
+		Spec: iafv1alpha1.ServicePlanSpec{
+			Type:      "postgres",
+			Instances: 2,
+			Storage:   resource.MustParse("50Gi"),
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+			},
+			Backup: &iafv1alpha1.ServicePlanBackup{
+				Schedule:          "0 0 2 * * *",
+				RetentionPolicy:   "30d",
+				BarmanObjectStore: runtime.RawExtension{Raw: []byte(`{"destinationPath": "s3://backups/iaf"}`)},
+			},
+		},
+	}
+	if err := r.Create(ctx, plan); err != nil {
+		t.Fatal(err)
+	}
+	svc := makeManagedSvc("bigdb", "iaf-test")
+	svc.Spec.Plan = "large"
+	svc.Finalizers = []string{managedServiceFinalizer}
+	if err := r.Create(ctx, svc); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileMS(t, r, "bigdb", "iaf-test")
+
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(iafk8s.CNPGClusterGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: "bigdb", Namespace: "iaf-test"}, cluster); err != nil {
+		t.Fatal(err)
+	}
+	instances, _, _ := unstructured.NestedInt64(cluster.Object, "spec", "instances")
+	size, _, _ := unstructured.NestedString(cluster.Object, "spec", "storage", "size")
+	cpu, _, _ := unstructured.NestedString(cluster.Object, "spec", "resources", "requests", "cpu")
+	memory, _, _ := unstructured.NestedString(cluster.Object, "spec", "resources", "limits", "memory")
+	if instances != 2 || size != "50Gi" || cpu != "2" || memory != "4Gi" {
+		t.Errorf("expected the cluster sized by the plan, got %d instances, %s, cpu %s, memory %s", instances, size, cpu, memory)
+	}
+	retention, _, _ := unstructured.NestedString(cluster.Object, "spec", "backup", "retentionPolicy")
+	serverName, _, _ := unstructured.NestedString(cluster.Object, "spec", "backup", "barmanObjectStore", "serverName")
+	if retention != "30d" || serverName != "iaf-test-bigdb" {
+		t.Errorf("expected the backup policy of the plan, got %q, %q", retention, serverName)
+	}
+
+	backup := &unstructured.Unstructured{}
+	backup.SetGroupVersionKind(iafk8s.CNPGScheduledBackupGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: "bigdb", Namespace: "iaf-test"}, backup); err != nil {
+		t.Fatalf("expected a scheduled backup: %v", err)
+	}
+	if schedule, _, _ := unstructured.NestedString(backup.Object, "spec", "schedule"); schedule != "0 0 2 * * *" {
+		t.Errorf("unexpected schedule %q", schedule)
+	}
+
+	var updated iafv1alpha1.ManagedService
+	_ = r.Get(ctx, types.NamespacedName{Name: "bigdb", Namespace: "iaf-test"}, &updated)
+	if updated.Status.Instances != 2 {
+		t.Errorf("expected 2 instances in status, got %d", updated.Status.Instances)
+	}
+
+	// The plan's services are reconciled when it changes, and dropping its
+	// backup policy removes the schedule.
+	if reqs := r.mapServicePlanToManagedServices(ctx, plan); len(reqs) != 1 || reqs[0].Name != "bigdb" {
+		t.Errorf("expected the plan to map to bigdb, got %v", reqs)
+	}
+	plan.Spec.Backup = nil
+	if err := r.Update(ctx, plan); err != nil {
+		t.Fatal(err)
+	}
+	reconcileMS(t, r, "bigdb", "iaf-test")
+	if err := r.Get(ctx, types.NamespacedName{Name: "bigdb", Namespace: "iaf-test"}, backup); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the scheduled backup deleted, got %v", err)
+	}
+}
+
+func TestManagedServiceReconcile_UnknownPlan(t *testing.T) {
+	scheme := newMSTestScheme(t)
+	r := newMSReconciler(scheme)
+	ctx := context.Background()
+
+	svc := makeManagedSvc("gonedb", "iaf-test")
+	svc.Spec.Plan = "retired"
+	svc.Finalizers = []string{managedServiceFinalizer}
+	if err := r.Create(ctx, svc); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileMS(t, r, "gonedb", "iaf-test")
+
+	var updated iafv1alpha1.ManagedService
+	_ = r.Get(ctx, types.NamespacedName{Name: "gonedb", Namespace: "iaf-test"}, &updated)
+	if updated.Status.Phase != iafv1alpha1.ManagedServicePhaseFailed || !strings.Contains(updated.Status.Message, `"retired"`) {
+		t.Errorf("expected a failed service naming the plan, got %s: %s", updated.Status.Phase, updated.Status.Message)
+	}
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(iafk8s.CNPGClusterGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: "gonedb", Namespace: "iaf-test"}, cluster); !apierrors.IsNotFound(err) {
+		t.Errorf("expected no cluster, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// conditionUpgradePending is True while a minor version upgrade of a
+// ManagedService waits for its maintenance window.
+const conditionUpgradePending = "UpgradePending"
+
+// maxUpgradeHistory is how many upgrades a ManagedService's status keeps.
+const maxUpgradeHistory = 10
+
+// versionPlan is the PostgreSQL version a reconcile pass runs a managed
+// service at.
+type versionPlan struct {
+	// image is the image the cluster runs; "" leaves it to CloudNativePG.
+	image string
+	// current and target are the version tags the cluster runs after this
+	// pass and the newest one offered for its major version.
+	current, target string
+	// upgraded is set when this pass starts an upgrade from the version in from.
+	upgraded bool
+	from     string
+	// opensIn is how long until the maintenance window opens, when an
+	// upgrade waits for it.
+	opensIn time.Duration
+	// problem explains why the version cannot be reconciled.
+	problem string
+}
+
+// planVersion decides the image of a managed service's cluster at now.
+// existing is the current CNPG Cluster, or nil before it is created. A new
+// cluster gets the newest minor release of the service's major version; an
+// existing one is upgraded to it only while the maintenance window is open,
+// and never downgraded or moved to another major version.
+func (r *ManagedServiceReconciler) planVersion(svc *iafv1alpha1.ManagedService, existing *unstructured.Unstructured, now time.Time) versionPlan {
+	var plan versionPlan
+	currentImage := ""
+	if existing != nil {
+		currentImage = iafk8s.CNPGClusterImage(existing)
+		plan.image, _, _ = unstructured.NestedString(existing.Object, "spec", "imageName")
+	}
+	plan.current = iafk8s.PostgresImageTag(currentImage)
+	if len(r.Postgres.Tags) == 0 {
+		// Versions are not managed: keep whatever the cluster runs.
+		return plan
+	}
+
+	major := svc.Spec.Version
+	if major == "" {
+		major = iafk8s.PostgresMajor(plan.current)
+	}
+	if major == "" {
+		major = r.Postgres.Default
+	}
+	if plan.current != "" && iafk8s.PostgresMajor(plan.current) != major {
+		plan.problem = fmt.Sprintf("The database runs PostgreSQL %s, not version %s. Major upgrades are not done in place: provision a new service at version %s and migrate the data.", plan.current, major, major)
+		return plan
+	}
+	target, ok := r.Postgres.Tags[major]
+	if !ok {
+		plan.problem = fmt.Sprintf("PostgreSQL %s is not offered. Offered versions: %s.", major, strings.Join(r.Postgres.Majors(), ", "))
+		return plan
+	}
+	plan.target = target
+
+	switch {
+	case existing == nil:
+		plan.image, plan.current = r.Postgres.ImageFor(target), target
+	case plan.current == "":
+		// CloudNativePG has not reported the image of a cluster created
+		// without one yet; wait for it rather than guess.
+	case iafk8s.ComparePostgresVersions(target, plan.current) <= 0:
+		plan.image = currentImage
+	default:
+		open, opens, err := iafk8s.MaintenanceWindowAt(svc.Spec.MaintenanceWindow, now)
+		if err != nil {
+			plan.problem = err.Error()
+			return plan
+		}
+		if !open {
+			plan.image, plan.opensIn = currentImage, opens.Sub(now)
+			return plan
+		}
+		plan.upgraded, plan.from = true, plan.current
+		plan.image, plan.current = r.Postgres.ImageFor(target), target
+	}
+	return plan
+}
+
+// applyVersionStatus records plan in the status of svc.
+func applyVersionStatus(svc *iafv1alpha1.ManagedService, plan versionPlan, now time.Time) {
+	svc.Status.CurrentVersion, svc.Status.TargetVersion = plan.current, plan.target
+	if plan.upgraded {
+		svc.Status.UpgradeHistory = append(svc.Status.UpgradeHistory, iafv1alpha1.VersionUpgrade{
+			From:      plan.from,
+			To:        plan.current,
+			StartedAt: metav1.Time{Time: now},
+		})
+		if n := len(svc.Status.UpgradeHistory); n > maxUpgradeHistory {
+			svc.Status.UpgradeHistory = svc.Status.UpgradeHistory[n-maxUpgradeHistory:]
+		}
+	}
+
+	cond := metav1.Condition{Type: conditionUpgradePending, Status: metav1.ConditionFalse}
+	switch {
+	case plan.problem != "":
+		cond.Reason, cond.Message = "VersionUnavailable", plan.problem
+	case plan.opensIn > 0:
+		cond.Status, cond.Reason = metav1.ConditionTrue, "WaitingForMaintenanceWindow"
+		cond.Message = fmt.Sprintf("PostgreSQL %s is available; the upgrade from %s starts when the maintenance window opens at %s.",
+			plan.target, plan.current, now.Add(plan.opensIn).UTC().Format(time.RFC3339))
+	case plan.upgraded:
+		cond.Reason = "UpgradeStarted"
+		cond.Message = fmt.Sprintf("Upgrading from PostgreSQL %s to %s; the database restarts one instance at a time.", plan.from, plan.current)
+	case plan.target != "" && plan.current == plan.target:
+		cond.Reason, cond.Message = "UpToDate", fmt.Sprintf("Running PostgreSQL %s, the latest release offered.", plan.current)
+	default:
+		meta.RemoveStatusCondition(&svc.Status.Conditions, conditionUpgradePending)
+		return
+	}
+	meta.SetStatusCondition(&svc.Status.Conditions, cond)
+}
@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func testPostgres(t *testing.T, tags string) iafk8s.PostgresVersions {
+	t.Helper()
+	pg, err := iafk8s.ParsePostgresVersions("ghcr.io/cloudnative-pg/postgresql", tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pg
+}
+
+// clusterImage returns the spec.imageName of the service's CNPG Cluster.
+func clusterImage(t *testing.T, r *ManagedServiceReconciler, name string) string {
+	t.Helper()
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(iafk8s.CNPGClusterGVK)
+	if err := r.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "iaf-test"}, cluster); err != nil {
+		t.Fatal(err)
+	}
+	image, _, _ := unstructured.NestedString(cluster.Object, "spec", "imageName")
+	return image
+}
+
+// TestManagedServiceReconcile_MinorUpgrade verifies a new cluster runs the
+// newest release offered, and a newer release is applied only inside the
+// maintenance window and recorded in the upgrade history.
+func TestManagedServiceReconcile_MinorUpgrade(t *testing.T) {
+	r := newMSReconciler(newMSTestScheme(t))
+	r.Postgres = testPostgres(t, "17.2,16.4")
+	ctx := context.Background()
+
+	svc := makeManagedSvc("pgdb", "iaf-test")
+	svc.Finalizers = []string{managedServiceFinalizer}
+	svc.Spec.Version = "16"
+	// A daily window that opened two hours from now, yesterday: closed.
+	svc.Spec.MaintenanceWindow = &iafv1alpha1.MaintenanceWindow{
+		Start:    time.Now().UTC().Add(2 * time.Hour).Format("15:04"),
+		Duration: metav1.Duration{Duration: time.Hour},
+	}
+	if err := r.Create(ctx, svc); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileMS(t, r, "pgdb", "iaf-test")
+	if got := clusterImage(t, r, "pgdb"); got != "ghcr.io/cloudnative-pg/postgresql:16.4" {
+		t.Fatalf("expected the newest 16 release, got %q", got)
+	}
+
+	// A newer minor release waits for the window.
+	r.Postgres = testPostgres(t, "17.2,16.6")
+	result := reconcileMS(t, r, "pgdb", "iaf-test")
+	if got := clusterImage(t, r, "pgdb"); got != "ghcr.io/cloudnative-pg/postgresql:16.4" {
+		t.Errorf("expected no upgrade outside the window, got %q", got)
+	}
+	if result.RequeueAfter <= 0 || result.RequeueAfter > 2*time.Hour {
+		t.Errorf("expected a requeue when the window opens, got %v", result.RequeueAfter)
+	}
+	var got iafv1alpha1.ManagedService
+	if err := r.Get(ctx, types.NamespacedName{Name: "pgdb", Namespace: "iaf-test"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.CurrentVersion != "16.4" || got.Status.TargetVersion != "16.6" {
+		t.Errorf("expected 16.4 upgrading to 16.6, got %q and %q", got.Status.CurrentVersion, got.Status.TargetVersion)
+	}
+	if !meta.IsStatusConditionTrue(got.Status.Conditions, conditionUpgradePending) {
+		t.Errorf("expected UpgradePending, got %+v", got.Status.Conditions)
+	}
+
+	// Inside the window the upgrade is applied.
+	got.Spec.MaintenanceWindow.Start = time.Now().UTC().Add(-time.Minute).Format("15:04")
+	if err := r.Update(ctx, &got); err != nil {
+		t.Fatal(err)
+	}
+	reconcileMS(t, r, "pgdb", "iaf-test")
+	if image := clusterImage(t, r, "pgdb"); image != "ghcr.io/cloudnative-pg/postgresql:16.6" {
+		t.Errorf("expected the upgrade in the window, got %q", image)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "pgdb", Namespace: "iaf-test"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if h := got.Status.UpgradeHistory; len(h) != 1 || h[0].From != "16.4" || h[0].To != "16.6" {
+		t.Errorf("unexpected upgrade history %+v", h)
+	}
+	if got.Status.CurrentVersion != "16.6" || meta.IsStatusConditionTrue(got.Status.Conditions, conditionUpgradePending) {
+		t.Errorf("expected the upgrade to no longer be pending, got %+v", got.Status)
+	}
+
+	// Later passes do not record it again.
+	reconcileMS(t, r, "pgdb", "iaf-test")
+	if err := r.Get(ctx, types.NamespacedName{Name: "pgdb", Namespace: "iaf-test"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Status.UpgradeHistory) != 1 {
+		t.Errorf("expected one upgrade, got %+v", got.Status.UpgradeHistory)
+	}
+}
+
+func TestManagedServiceReconcile_VersionNotOffered(t *testing.T) {
+	r := newMSReconciler(newMSTestScheme(t))
+	r.Postgres = testPostgres(t, "17.2,16.4")
+	ctx := context.Background()
+
+	svc := makeManagedSvc("olddb", "iaf-test")
+	svc.Finalizers = []string{managedServiceFinalizer}
+	svc.Spec.Version = "12"
+	if err := r.Create(ctx, svc); err != nil {
+		t.Fatal(err)
+	}
+	reconcileMS(t, r, "olddb", "iaf-test")
+
+	var got iafv1alpha1.ManagedService
+	if err := r.Get(ctx, types.NamespacedName{Name: "olddb", Namespace: "iaf-test"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.Phase != iafv1alpha1.ManagedServicePhaseFailed {
+		t.Errorf("expected Failed, got %s: %s", got.Status.Phase, got.Status.Message)
+	}
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(iafk8s.CNPGClusterGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: "olddb", Namespace: "iaf-test"}, cluster); err == nil {
+		t.Error("expected no cluster for a version that is not offered")
+	}
+}
+
+func TestPlanVersion(t *testing.T) {
+	r := &ManagedServiceReconciler{Postgres: testPostgres(t, "17.2,16.6")}
+	now := time.Now()
+	existing := func(spec, status string) *unstructured.Unstructured {
+		plan, _ := iafk8s.BuiltinServicePlan(iafv1alpha1.ServicePlanMicro)
+		obj := iafk8s.BuildCNPGCluster(makeManagedSvc("db", "iaf-test"), plan.Spec, spec)
+		if status != "" {
+			_ = unstructured.SetNestedField(obj.Object, status, "status", "image")
+		}
+		return obj
+	}
+
+	for _, tc := range []struct {
+		name        string
+		version     string
+		cluster     *unstructured.Unstructured
+		wantImage   string
+		wantCurrent string
+		upgraded    bool
+		problem     bool
+	}{
+		{name: "new cluster at the default version", wantImage: "ghcr.io/cloudnative-pg/postgresql:17.2", wantCurrent: "17.2"},
+		{name: "legacy cluster keeps its major version", cluster: existing("", "ghcr.io/cloudnative-pg/postgresql:16.2"), wantImage: "ghcr.io/cloudnative-pg/postgresql:16.6", wantCurrent: "16.6", upgraded: true},
+		{name: "legacy cluster without a reported image", cluster: existing("", ""), wantImage: ""},
+		{name: "never downgraded", version: "16", cluster: existing("mirror.local/postgresql:16.8", ""), wantImage: "mirror.local/postgresql:16.8", wantCurrent: "16.8"},
+		{name: "major change refused", version: "17", cluster: existing("ghcr.io/cloudnative-pg/postgresql:16.6", ""), wantImage: "ghcr.io/cloudnative-pg/postgresql:16.6", wantCurrent: "16.6", problem: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := makeManagedSvc("db", "iaf-test")
+			svc.Spec.Version = tc.version
+			plan := r.planVersion(svc, tc.cluster, now)
+			if plan.image != tc.wantImage || plan.current != tc.wantCurrent || plan.upgraded != tc.upgraded || (plan.problem != "") != tc.problem {
+				t.Errorf("unexpected plan %+v", plan)
+			}
+		})
+	}
+
+	// Without versions configured the cluster image is left alone.
+	plan := (&ManagedServiceReconciler{}).planVersion(makeManagedSvc("db", "iaf-test"), nil, now)
+	if plan.image != "" || plan.problem != "" {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Build counters are exposed alongside the controller-runtime metrics on the
+// manager's metrics endpoint. They are labelled by session namespace only, so
+// cardinality stays bounded by the number of sessions.
+var (
+	buildsStarted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "iaf_builds_started_total",
+		Help: "Number of kpack builds started, by namespace.",
+	}, []string{"namespace"})
+	buildsSucceeded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "iaf_builds_succeeded_total",
+		Help: "Number of kpack builds that succeeded, by namespace.",
+	}, []string{"namespace"})
+	buildsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "iaf_builds_failed_total",
+		Help: "Number of kpack builds that failed, by namespace.",
+	}, []string{"namespace"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(buildsStarted, buildsSucceeded, buildsFailed)
+}
+
+// recordBuildTransition counts a build event when an app's build status
+// moves from previous (last persisted in status) to current. "Unknown" is
+// reported while kpack has not yet written status to a new Image and is not
+// a transition of its own.
+func recordBuildTransition(namespace, previous, current string) {
+	if current == previous || current == "Unknown" {
+		return
+	}
+	switch current {
+	case "Building":
+		if previous != "Unknown" {
+			buildsStarted.WithLabelValues(namespace).Inc()
+		}
+	case "Succeeded":
+		buildsSucceeded.WithLabelValues(namespace).Inc()
+	case "Failed":
+		buildsFailed.WithLabelValues(namespace).Inc()
+	}
+}
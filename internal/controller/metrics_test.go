@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecordBuildTransition(t *testing.T) {
+	const ns = "iaf-metrics-test"
+	steps := []struct {
+		previous, current string
+	}{
+		{"", "Building"},          // Image created: started
+		{"Building", "Unknown"},   // kpack has not written status yet
+		{"Unknown", "Building"},   // same build, not a new start
+		{"Building", "Building"},  // no change
+		{"Building", "Succeeded"}, // succeeded
+		{"Succeeded", "Building"}, // rebuild: started
+		{"Building", "Failed"},    // failed
+		{"", "NotRequired"},       // pre-built image apps are not counted
+	}
+	for _, s := range steps {
+		recordBuildTransition(ns, s.previous, s.current)
+	}
+
+	tests := []struct {
+		name    string
+		counter prometheus.Counter
+		want    float64
+	}{
+		{"started", buildsStarted.WithLabelValues(ns), 2},
+		{"succeeded", buildsSucceeded.WithLabelValues(ns), 1},
+		{"failed", buildsFailed.WithLabelValues(ns), 1},
+	}
+	for _, tt := range tests {
+		if got := testutil.ToFloat64(tt.counter); got != tt.want {
+			t.Errorf("builds %s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestReconcile_OwnershipLabels(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+
+	app := makeApp("myapp", "test-ns")
+	app.Spec.Authentication = iafv1alpha1.AuthenticationBasic
+	app.Spec.Metadata = &iafv1alpha1.OwnershipMetadata{Team: "payments", Owner: "jane@example.com", CostCenter: "CC-1042"}
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	var dep appsv1.Deployment
+	if err := r.Get(ctx, key, &dep); err != nil {
+		t.Fatal(err)
+	}
+	var svc corev1.Service
+	if err := r.Get(ctx, key, &svc); err != nil {
+		t.Fatal(err)
+	}
+	var secret corev1.Secret
+	secretKey := types.NamespacedName{Name: iafk8s.BasicAuthSecretName("myapp"), Namespace: "test-ns"}
+	if err := r.Get(ctx, secretKey, &secret); err != nil {
+		t.Fatal(err)
+	}
+	for kind, labels := range map[string]map[string]string{
+		"deployment": dep.Labels,
+		"pod":        dep.Spec.Template.Labels,
+		"service":    svc.Labels,
+		"secret":     secret.Labels,
+	} {
+		if labels[iafk8s.LabelTeam] != "payments" || labels[iafk8s.LabelOwner] != "jane_at_example.com" || labels[iafk8s.LabelCostCenter] != "CC-1042" {
+			t.Errorf("%s labels = %v, want the ownership labels", kind, labels)
+		}
+	}
+	if dep.Spec.Selector.MatchLabels[iafk8s.LabelTeam] != "" {
+		t.Errorf("the selector must not include ownership labels, got %v", dep.Spec.Selector.MatchLabels)
+	}
+
+	// Changing the metadata relabels existing objects, including ones that
+	// are otherwise never updated.
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	app.Spec.Metadata = &iafv1alpha1.OwnershipMetadata{Team: "billing"}
+	if err := r.Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	if err := r.Get(ctx, key, &dep); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Get(ctx, secretKey, &secret); err != nil {
+		t.Fatal(err)
+	}
+	for kind, labels := range map[string]map[string]string{"deployment": dep.Labels, "secret": secret.Labels} {
+		if labels[iafk8s.LabelTeam] != "billing" || labels[iafk8s.LabelOwner] != "" || labels[iafk8s.LabelCostCenter] != "" {
+			t.Errorf("%s labels = %v, want only team billing", kind, labels)
+		}
+		if labels["iaf.io/application"] != "myapp" {
+			t.Errorf("%s lost its application label: %v", kind, labels)
+		}
+	}
+}
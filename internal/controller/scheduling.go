@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// conditionScheduled is False while some of an Application's pods
+	// cannot be placed on any node. It is removed once they all are.
+	conditionScheduled = "Scheduled"
+
+	// reasonUnschedulable is the reason of the Scheduled condition, and of
+	// Ready while no replica is available because of it.
+	reasonUnschedulable = "Unschedulable"
+)
+
+// podScheduling reports the app's pods that no node can take, or nil when
+// every pod is scheduled. Events are only read when such pods exist.
+func (r *ApplicationReconciler) podScheduling(ctx context.Context, app *iafv1alpha1.Application) (*iafv1alpha1.SchedulingStatus, error) {
+	var reader client.Reader = r.Client
+	if r.APIReader != nil {
+		reader = r.APIReader
+	}
+	var pods corev1.PodList
+	if err := reader.List(ctx, &pods, client.InNamespace(app.Namespace), client.MatchingLabels{"iaf.io/application": app.Name}); err != nil {
+		return nil, fmt.Errorf("listing pods: %w", err)
+	}
+	if iafk8s.Scheduling(pods.Items, nil) == nil {
+		return nil, nil
+	}
+	var events corev1.EventList
+	if err := reader.List(ctx, &events, client.InNamespace(app.Namespace)); err != nil {
+		return nil, fmt.Errorf("listing events: %w", err)
+	}
+	return iafk8s.Scheduling(pods.Items, events.Items), nil
+}
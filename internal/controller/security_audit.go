@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/audit"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// securityAuditActor is the actor of the security audit events the
+// controller records.
+const securityAuditActor = "controller"
+
+// liveDeployment returns the current Deployment desired will replace, or
+// nil when there is none or nothing is audited.
+func (r *ApplicationReconciler) liveDeployment(ctx context.Context, desired *appsv1.Deployment) (*appsv1.Deployment, error) {
+	if r.SecurityAudit == nil {
+		return nil, nil
+	}
+	var live appsv1.Deployment
+	if err := r.Get(ctx, client.ObjectKeyFromObject(desired), &live); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getting deployment: %w", err)
+	}
+	return &live, nil
+}
+
+// recordSecretUse records in r.SecurityAudit the Secrets app's pods start
+// and stop using as its Deployment goes from before, nil for a new one, to
+// after, and a rotation when the connection Secrets of its bound managed
+// services changed while it kept using the same Secrets.
+func (r *ApplicationReconciler) recordSecretUse(app *iafv1alpha1.Application, before, after *appsv1.Deployment) {
+	if r.SecurityAudit == nil {
+		return
+	}
+	var previous []string
+	if before != nil {
+		previous = podSecrets(&before.Spec.Template.Spec)
+	}
+	current := podSecrets(&after.Spec.Template.Spec)
+	record := func(action audit.SecurityAction, kind, name, secret string) {
+		r.SecurityAudit.Record(audit.SecurityEvent{
+			Action:    action,
+			Actor:     securityAuditActor,
+			Namespace: app.Namespace,
+			Kind:      kind,
+			Name:      name,
+			Secret:    secret,
+			App:       app.Name,
+		})
+	}
+	for _, secret := range current {
+		if !slices.Contains(previous, secret) {
+			record(audit.ActionSecretMounted, "secret", secret, "")
+		}
+	}
+	for _, secret := range previous {
+		if !slices.Contains(current, secret) {
+			record(audit.ActionSecretUnmounted, "secret", secret, "")
+		}
+	}
+	if before == nil || !slices.Equal(previous, current) {
+		return
+	}
+	was := before.Spec.Template.Annotations[managedServicesHashAnnotation]
+	is := after.Spec.Template.Annotations[managedServicesHashAnnotation]
+	if was != "" && is != "" && was != is {
+		secrets := make([]string, 0, len(app.Spec.BoundManagedServices))
+		for _, bms := range app.Spec.BoundManagedServices {
+			secrets = append(secrets, bms.SecretName)
+		}
+		record(audit.ActionSecretRotated, "managed_service_credentials", app.Name, strings.Join(secrets, ","))
+	}
+}
+
+// podSecrets returns the sorted names of the Secrets spec reads, through
+// env vars, envFrom, volumes and image pull secrets.
+func podSecrets(spec *corev1.PodSpec) []string {
+	var names []string
+	add := func(name string) {
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	for _, c := range slices.Concat(spec.InitContainers, spec.Containers) {
+		for _, e := range c.Env {
+			if e.ValueFrom != nil && e.ValueFrom.SecretKeyRef != nil {
+				add(e.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+		for _, e := range c.EnvFrom {
+			if e.SecretRef != nil {
+				add(e.SecretRef.Name)
+			}
+		}
+	}
+	for _, v := range spec.Volumes {
+		if v.Secret != nil {
+			add(v.Secret.SecretName)
+		}
+		if v.Projected != nil {
+			for _, src := range v.Projected.Sources {
+				if src.Secret != nil {
+					add(src.Secret.Name)
+				}
+			}
+		}
+	}
+	for _, ref := range spec.ImagePullSecrets {
+		add(ref.Name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/audit"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// TestReconcile_SecurityAudit verifies the controller records the Secrets
+// an app's pods start and stop using and rotated managed service
+// credentials, and nothing on passes that change neither.
+func TestReconcile_SecurityAudit(t *testing.T) {
+	r := newReconciler(newTestScheme(t))
+	path := filepath.Join(t.TempDir(), "controller.jsonl")
+	securityAudit, err := audit.NewSecurityLog("controller", path, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.SecurityAudit = securityAudit
+	ctx := context.Background()
+
+	events := func() []audit.SecurityEvent {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := audit.VerifySecurityLog(bytes.NewReader(data)); err != nil {
+			t.Fatal(err)
+		}
+		var out []audit.SecurityEvent
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var e audit.SecurityEvent
+			if err := json.Unmarshal([]byte(line), &e); err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, e)
+		}
+		return out
+	}
+	summary := func(es []audit.SecurityEvent) []string {
+		var out []string
+		for _, e := range es {
+			if e.Actor != securityAuditActor || e.App != "myapp" || e.Namespace != "test-ns" {
+				t.Errorf("unexpected event %+v", e)
+			}
+			out = append(out, string(e.Action)+" "+e.Name)
+		}
+		return out
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-app", Namespace: "test-ns", Labels: map[string]string{labelCNPGCluster: "db"}},
+		Data:       map[string][]byte{"password": []byte("one")},
+	}
+	if err := r.Create(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+	app := makeApp("myapp", "test-ns")
+	app.Spec.BoundManagedServices = []iafv1alpha1.BoundManagedService{{ServiceName: "db", SecretName: "db-app"}}
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	reconcileApp(t, r, "myapp", "test-ns")
+	if got := summary(events()); !slices.Equal(got, []string{"secret.mounted db-app"}) {
+		t.Fatalf("expected the binding to be recorded once, got %v", got)
+	}
+
+	secret.Data["password"] = []byte("two")
+	if err := r.Update(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	got := events()
+	if last := got[len(got)-1]; last.Action != audit.ActionSecretRotated || last.Secret != "db-app" {
+		t.Errorf("expected the rotation to be recorded, got %+v", last)
+	}
+
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, app); err != nil {
+		t.Fatal(err)
+	}
+	app.Spec.BoundManagedServices = nil
+	if err := r.Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	want := []string{"secret.mounted db-app", "secret.rotated myapp", "secret.unmounted db-app"}
+	if got := summary(events()); !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	if strings.Contains(mustRead(t, path), "two") {
+		t.Error("the security audit must not contain secret material")
+	}
+}
+
+func TestPodSecrets(t *testing.T) {
+	spec := &corev1.PodSpec{
+		InitContainers: []corev1.Container{{EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "group"}}}}}},
+		Containers: []corev1.Container{{Env: []corev1.EnvVar{
+			{Name: "PLAIN", Value: "x"},
+			{Name: "DB", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "web-db"}, Key: "url"}}},
+		}}},
+		Volumes: []corev1.Volume{
+			{Name: "tls", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "web-tls"}}},
+			{Name: "binding", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+				{Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "web-db"}}},
+			}}}},
+		},
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "ghcr"}},
+	}
+	if got, want := podSecrets(spec), []string{"ghcr", "group", "web-db", "web-tls"}; !slices.Equal(got, want) {
+		t.Errorf("podSecrets = %v, want %v", got, want)
+	}
+}
+
+func mustRead(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// serviceBindingRoot is where binding directories are mounted, exposed
+	// to the app as SERVICE_BINDING_ROOT (https://servicebinding.io).
+	serviceBindingRoot = "/bindings"
+	// serviceBindingRootEnv names the variable frameworks read the binding
+	// root from.
+	serviceBindingRootEnv = "SERVICE_BINDING_ROOT"
+)
+
+// serviceBindingFiles maps CNPG Secret keys to the well-known entries of a
+// postgresql binding directory.
+var serviceBindingFiles = map[string]string{
+	"host":     "host",
+	"port":     "port",
+	"dbname":   "database",
+	"username": "username",
+	"password": "password",
+	"uri":      "uri",
+}
+
+// serviceBindingMetadata are the type and provider entries of every binding
+// directory, kept in a ConfigMap since a projected volume cannot hold
+// literal content.
+var serviceBindingMetadata = map[string]string{
+	"type":     "postgresql",
+	"provider": "cloudnative-pg",
+}
+
+// serviceBindingsName returns the name of the ConfigMap holding the binding
+// metadata of app.
+func serviceBindingsName(app *iafv1alpha1.Application) string {
+	return app.Name + "-service-bindings"
+}
+
+// reconcileServiceBindings applies the ConfigMap of binding metadata when a
+// bound managed service is projected as files, or deletes it when none is.
+// It returns one projected volume per such binding, mounted read-only at
+// /bindings/<service>, and the SERVICE_BINDING_ROOT env var.
+func (r *ApplicationReconciler) reconcileServiceBindings(ctx context.Context, app *iafv1alpha1.Application) ([]corev1.Volume, []corev1.VolumeMount, []corev1.EnvVar, error) {
+	var (
+		volumes []corev1.Volume
+		mounts  []corev1.VolumeMount
+	)
+	for i, bms := range app.Spec.BoundManagedServices {
+		if !iafv1alpha1.BindingProjectsFiles(bms) {
+			continue
+		}
+		var secretItems []corev1.KeyToPath
+		for _, key := range slices.Sorted(maps.Keys(serviceBindingFiles)) {
+			secretItems = append(secretItems, corev1.KeyToPath{Key: key, Path: serviceBindingFiles[key]})
+		}
+		var metadataItems []corev1.KeyToPath
+		for _, key := range slices.Sorted(maps.Keys(serviceBindingMetadata)) {
+			metadataItems = append(metadataItems, corev1.KeyToPath{Key: key, Path: key})
+		}
+		// Volume names are indexed: service names may be too long for one.
+		name := fmt.Sprintf("service-binding-%d", i)
+		volumes = append(volumes, corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				Projected: &corev1.ProjectedVolumeSource{
+					Sources: []corev1.VolumeProjection{
+						{Secret: &corev1.SecretProjection{
+							LocalObjectReference: corev1.LocalObjectReference{Name: bms.SecretName},
+							Items:                secretItems,
+						}},
+						{ConfigMap: &corev1.ConfigMapProjection{
+							LocalObjectReference: corev1.LocalObjectReference{Name: serviceBindingsName(app)},
+							Items:                metadataItems,
+						}},
+					},
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{Name: name, MountPath: path.Join(serviceBindingRoot, bms.ServiceName), ReadOnly: true})
+	}
+
+	if len(volumes) == 0 {
+		return nil, nil, nil, r.deleteServiceBindings(ctx, app)
+	}
+	desired := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceBindingsName(app),
+			Namespace: app.Namespace,
+			Labels:    iafk8s.AppLabels(app),
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: iafv1alpha1.GroupVersion.String(),
+					Kind:       "Application",
+					Name:       app.Name,
+					UID:        app.UID,
+					Controller: boolPtr(true),
+				},
+			},
+		},
+		Data: maps.Clone(serviceBindingMetadata),
+	}
+	if _, _, err := r.applyOwned(ctx, desired); err != nil {
+		return nil, nil, nil, err
+	}
+	return volumes, mounts, []corev1.EnvVar{{Name: serviceBindingRootEnv, Value: serviceBindingRoot}}, nil
+}
+
+// deleteServiceBindings deletes the binding metadata ConfigMap of app, if
+// the controller created one for it.
+func (r *ApplicationReconciler) deleteServiceBindings(ctx context.Context, app *iafv1alpha1.Application) error {
+	var cm corev1.ConfigMap
+	if err := r.Get(ctx, types.NamespacedName{Name: serviceBindingsName(app), Namespace: app.Namespace}, &cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("getting service bindings: %w", err)
+	}
+	if !metav1.IsControlledBy(&cm, app) {
+		return nil
+	}
+	return r.deleteIfExists(ctx, &cm)
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// TestReconcile_ServiceBindingFiles verifies a binding projected as files
+// mounts a servicebinding.io binding directory in place of the env vars, and
+// switching back to env vars removes it.
+func TestReconcile_ServiceBindingFiles(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	ctx := context.Background()
+
+	app := makeApp("myapp", "test-ns")
+	app.Spec.BoundManagedServices = []iafv1alpha1.BoundManagedService{
+		{ServiceName: "orders", SecretName: "orders-app", EnvPrefix: "ORDERS_"},
+		{ServiceName: "db", SecretName: "db-app", Projection: iafv1alpha1.BindingProjectionFiles},
+	}
+	if err := r.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	key := types.NamespacedName{Name: "myapp", Namespace: "test-ns"}
+	cmKey := types.NamespacedName{Name: "myapp-service-bindings", Namespace: "test-ns"}
+	var cm corev1.ConfigMap
+	if err := r.Get(ctx, cmKey, &cm); err != nil {
+		t.Fatalf("expected the binding metadata ConfigMap: %v", err)
+	}
+	if cm.Data["type"] != "postgresql" || cm.Data["provider"] != "cloudnative-pg" {
+		t.Errorf("unexpected binding metadata %v", cm.Data)
+	}
+
+	var dep appsv1.Deployment
+	if err := r.Get(ctx, key, &dep); err != nil {
+		t.Fatal(err)
+	}
+	pod := dep.Spec.Template.Spec
+	if len(pod.Volumes) != 1 || pod.Volumes[0].Projected == nil {
+		t.Fatalf("expected one projected volume, got %+v", pod.Volumes)
+	}
+	sources := pod.Volumes[0].Projected.Sources
+	if len(sources) != 2 || sources[0].Secret.Name != "db-app" || sources[1].ConfigMap.Name != "myapp-service-bindings" {
+		t.Errorf("unexpected volume sources %+v", sources)
+	}
+	paths := map[string]bool{}
+	for _, item := range sources[0].Secret.Items {
+		paths[item.Path] = true
+	}
+	for _, want := range []string{"host", "port", "database", "username", "password"} {
+		if !paths[want] {
+			t.Errorf("expected a %s entry, got %+v", want, sources[0].Secret.Items)
+		}
+	}
+	container := pod.Containers[0]
+	want := corev1.VolumeMount{Name: pod.Volumes[0].Name, MountPath: "/bindings/db", ReadOnly: true}
+	if len(container.VolumeMounts) != 1 || container.VolumeMounts[0] != want {
+		t.Errorf("volume mounts = %+v, want %+v", container.VolumeMounts, want)
+	}
+	env := map[string]string{}
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+	}
+	if env["SERVICE_BINDING_ROOT"] != "/bindings" {
+		t.Errorf("expected SERVICE_BINDING_ROOT=/bindings, got %v", env)
+	}
+	if _, ok := env["DATABASE_URL"]; ok {
+		t.Error("expected no env vars for a binding projected as files")
+	}
+	if _, ok := env["ORDERS_DATABASE_URL"]; !ok {
+		t.Error("expected the env vars of the other binding")
+	}
+
+	// Back to env vars: the directory and its ConfigMap go away.
+	if err := r.Get(ctx, key, app); err != nil {
+		t.Fatal(err)
+	}
+	app.Spec.BoundManagedServices[1].Projection = iafv1alpha1.BindingProjectionEnv
+	if err := r.Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+	if err := r.Get(ctx, cmKey, &cm); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the binding metadata ConfigMap to be deleted, got %v", err)
+	}
+	if err := r.Get(ctx, key, &dep); err != nil {
+		t.Fatal(err)
+	}
+	if len(dep.Spec.Template.Spec.Volumes) != 0 {
+		t.Errorf("expected no volumes, got %+v", dep.Spec.Template.Spec.Volumes)
+	}
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Shard is the set of session namespaces one controller deployment
+// reconciles, chosen by namespace label, so very large installations can
+// split reconciliation across several deployments. A nil Selector
+// reconciles every namespace.
+type Shard struct {
+	Selector labels.Selector
+	// Namespaces are cached by every shard whatever their labels, such as
+	// the namespaces holding the CA bundle and the wildcard certificate.
+	Namespaces []string
+}
+
+// enabled reports whether the shard is a subset of the namespaces.
+func (s Shard) enabled() bool {
+	return s.Selector != nil && !s.Selector.Empty()
+}
+
+// CacheOptions limits the manager's Namespace cache to the shard, so a
+// namespace leaving it reads as missing and one joining it arrives as a
+// create event.
+func (s Shard) CacheOptions() cache.Options {
+	if !s.enabled() {
+		return cache.Options{}
+	}
+	return cache.Options{ByObject: map[client.Object]cache.ByObject{
+		&corev1.Namespace{}: {Label: s.Selector},
+	}}
+}
+
+// contains reports whether the namespace belongs to the shard. Namespaces
+// that cannot be read are left to the shard that can.
+func (s Shard) contains(ctx context.Context, c client.Reader, namespace string) bool {
+	if !s.enabled() {
+		return true
+	}
+	var ns corev1.Namespace
+	if err := c.Get(ctx, types.NamespacedName{Name: namespace}, &ns); err != nil {
+		return false
+	}
+	return s.Selector.Matches(labels.Set(ns.Labels))
+}
+
+// forOptions drops events for resources in namespaces outside the shard
+// before they are queued. Related watches map into every namespace, so
+// Reconcile checks the shard again.
+func (s Shard) forOptions(c client.Reader) []builder.ForOption {
+	if !s.enabled() {
+		return nil
+	}
+	return []builder.ForOption{builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return s.contains(context.Background(), c, obj.GetNamespace())
+	}))}
+}
+
+// watchNamespaces enqueues the resources listed by list when their
+// namespace joins the shard, which the filtered cache reports as a create.
+func (s Shard) watchNamespaces(b *builder.Builder, list func(context.Context, string) []reconcile.Request) *builder.Builder {
+	if !s.enabled() {
+		return b
+	}
+	return b.Watches(
+		&corev1.Namespace{},
+		handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+			return list(ctx, obj.GetName())
+		}),
+		builder.WithPredicates(predicate.Funcs{
+			UpdateFunc:  func(event.UpdateEvent) bool { return false },
+			DeleteFunc:  func(event.DeleteEvent) bool { return false },
+			GenericFunc: func(event.GenericEvent) bool { return false },
+		}),
+	)
+}
+
+// listApplications returns a request for every Application in namespace.
+func listApplications(c client.Reader) func(context.Context, string) []reconcile.Request {
+	return func(ctx context.Context, namespace string) []reconcile.Request {
+		var list iafv1alpha1.ApplicationList
+		if err := c.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+			log.FromContext(ctx).Error(err, "listing applications for namespace joining the shard", "namespace", namespace)
+			return nil
+		}
+		reqs := make([]reconcile.Request, 0, len(list.Items))
+		for _, app := range list.Items {
+			reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: app.Name, Namespace: app.Namespace}})
+		}
+		return reqs
+	}
+}
+
+// listManagedServices returns a request for every ManagedService in
+// namespace.
+func listManagedServices(c client.Reader) func(context.Context, string) []reconcile.Request {
+	return func(ctx context.Context, namespace string) []reconcile.Request {
+		var list iafv1alpha1.ManagedServiceList
+		if err := c.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+			log.FromContext(ctx).Error(err, "listing managed services for namespace joining the shard", "namespace", namespace)
+			return nil
+		}
+		reqs := make([]reconcile.Request, 0, len(list.Items))
+		for _, svc := range list.Items {
+			reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: svc.Name, Namespace: svc.Namespace}})
+		}
+		return reqs
+	}
+}
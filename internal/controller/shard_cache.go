@@ -0,0 +1,616 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// NewCache builds the manager's cache. A sharded controller caches
+// namespaced objects only in the namespaces of its shard and in
+// Shard.Namespaces, one cache per namespace, started when a namespace joins
+// the shard and stopped when it leaves, so objects in other shards never
+// reach this deployment's memory. Cluster-scoped objects share one cache.
+func (s Shard) NewCache(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+	if !s.enabled() {
+		return cache.New(config, opts)
+	}
+	// Default what cache.New would, so every namespace cache shares them.
+	if opts.Scheme == nil {
+		opts.Scheme = clientgoscheme.Scheme
+	}
+	if opts.HTTPClient == nil {
+		httpClient, err := rest.HTTPClientFor(config)
+		if err != nil {
+			return nil, err
+		}
+		opts.HTTPClient = httpClient
+	}
+	if opts.Mapper == nil {
+		mapper, err := apiutil.NewDynamicRESTMapper(config, opts.HTTPClient)
+		if err != nil {
+			return nil, err
+		}
+		opts.Mapper = mapper
+	}
+	cluster, err := cache.New(config, opts)
+	if err != nil {
+		return nil, err
+	}
+	return newShardCache(s, cluster, opts.Scheme, opts.Mapper, func(namespace string) (cache.Cache, error) {
+		nsOpts := opts
+		nsOpts.DefaultNamespaces = map[string]cache.Config{namespace: {}}
+		return cache.New(config, nsOpts)
+	}), nil
+}
+
+// shardCache routes namespaced objects to the cache of their namespace and
+// everything else to the cluster cache, which also holds the shard's
+// Namespace objects.
+type shardCache struct {
+	cache.Cache
+
+	shard         Shard
+	scheme        *runtime.Scheme
+	mapper        apimeta.RESTMapper
+	newNamespaced func(namespace string) (cache.Cache, error)
+
+	// ready is closed once the namespaces in the shard at start have their
+	// caches.
+	ready chan struct{}
+
+	mu         sync.Mutex
+	ctx        context.Context
+	namespaces map[string]*namespaceCache
+	informers  map[schema.GroupVersionKind]*shardInformer
+	indexes    []fieldIndex
+}
+
+// namespaceCache is the cache of one namespace in the shard.
+type namespaceCache struct {
+	cache.Cache
+	cancel context.CancelFunc
+}
+
+// fieldIndex is a field index requested through IndexField, applied to
+// every namespace cache including those started later.
+type fieldIndex struct {
+	obj     client.Object
+	field   string
+	extract client.IndexerFunc
+}
+
+var _ cache.Cache = &shardCache{}
+
+func newShardCache(s Shard, cluster cache.Cache, scheme *runtime.Scheme, mapper apimeta.RESTMapper, newNamespaced func(string) (cache.Cache, error)) *shardCache {
+	return &shardCache{
+		Cache:         cluster,
+		shard:         s,
+		scheme:        scheme,
+		mapper:        mapper,
+		newNamespaced: newNamespaced,
+		ready:         make(chan struct{}),
+		namespaces:    map[string]*namespaceCache{},
+		informers:     map[schema.GroupVersionKind]*shardInformer{},
+	}
+}
+
+// Start starts the cluster cache and follows the Namespace objects in the
+// shard, starting and stopping their caches. It blocks until ctx is done.
+func (c *shardCache) Start(ctx context.Context) error {
+	c.mu.Lock()
+	c.ctx = ctx
+	c.mu.Unlock()
+
+	errs := make(chan error, 1)
+	go func() {
+		if err := c.Cache.Start(ctx); err != nil {
+			errs <- fmt.Errorf("starting cluster cache: %w", err)
+		}
+	}()
+
+	for _, ns := range c.shard.Namespaces {
+		if err := c.addNamespace(ns); err != nil {
+			return err
+		}
+	}
+	informer, err := c.Cache.GetInformer(ctx, &corev1.Namespace{})
+	if err != nil {
+		return fmt.Errorf("watching shard namespaces: %w", err)
+	}
+	reg, err := informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj any) { c.namespaceChanged(ctx, obj) },
+		UpdateFunc: func(_, obj any) { c.namespaceChanged(ctx, obj) },
+		DeleteFunc: func(obj any) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if ns, ok := obj.(*corev1.Namespace); ok {
+				c.removeNamespace(ns.Name)
+			}
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("watching shard namespaces: %w", err)
+	}
+	if !toolscache.WaitForCacheSync(ctx.Done(), reg.HasSynced) {
+		return nil
+	}
+	close(c.ready)
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errs:
+		return err
+	}
+}
+
+// namespaceChanged starts or stops the cache of a namespace as it joins or
+// leaves the shard. The cluster cache already filters Namespaces by the
+// shard selector; checking again keeps the partition correct without it.
+func (c *shardCache) namespaceChanged(ctx context.Context, obj any) {
+	ns, ok := obj.(*corev1.Namespace)
+	if !ok {
+		return
+	}
+	if !c.shard.Selector.Matches(labels.Set(ns.Labels)) {
+		c.removeNamespace(ns.Name)
+		return
+	}
+	if err := c.addNamespace(ns.Name); err != nil {
+		log.FromContext(ctx).Error(err, "starting cache for namespace joining the shard", "namespace", ns.Name)
+	}
+}
+
+// addNamespace starts the cache of namespace with every informer, handler
+// and index requested so far.
+func (c *shardCache) addNamespace(namespace string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.namespaces[namespace]; ok {
+		return nil
+	}
+	nc, err := c.newNamespaced(namespace)
+	if err != nil {
+		return fmt.Errorf("creating cache for namespace %s: %w", namespace, err)
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	// Informers added before the cache starts do not wait for a sync.
+	for _, idx := range c.indexes {
+		if err := nc.IndexField(ctx, idx.obj, idx.field, idx.extract); err != nil {
+			cancel()
+			return fmt.Errorf("indexing namespace %s: %w", namespace, err)
+		}
+	}
+	for _, si := range c.informers {
+		informer, err := nc.GetInformer(ctx, si.newObject(), cache.BlockUntilSynced(false))
+		if err != nil {
+			cancel()
+			return fmt.Errorf("creating informer in namespace %s: %w", namespace, err)
+		}
+		if err := si.attach(namespace, informer); err != nil {
+			cancel()
+			return err
+		}
+	}
+	c.namespaces[namespace] = &namespaceCache{Cache: nc, cancel: cancel}
+	go func() {
+		if err := nc.Start(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "namespace cache stopped", "namespace", namespace)
+		}
+	}()
+	return nil
+}
+
+// removeNamespace stops the cache of a namespace leaving the shard. Its
+// objects then read as missing. Shard.Namespaces stay cached.
+func (c *shardCache) removeNamespace(namespace string) {
+	if slices.Contains(c.shard.Namespaces, namespace) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	nc, ok := c.namespaces[namespace]
+	if !ok {
+		return
+	}
+	for _, si := range c.informers {
+		si.detach(namespace)
+	}
+	nc.cancel()
+	delete(c.namespaces, namespace)
+}
+
+// namespaced reports whether obj is a namespaced kind.
+func (c *shardCache) namespaced(obj runtime.Object) (bool, error) {
+	return apiutil.IsObjectNamespaced(obj, c.scheme, c.mapper)
+}
+
+// namespace returns the cache of namespace, nil when it is outside the
+// shard.
+func (c *shardCache) namespace(namespace string) cache.Cache {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if nc, ok := c.namespaces[namespace]; ok {
+		return nc.Cache
+	}
+	return nil
+}
+
+// WaitForCacheSync waits for the cluster cache and the caches of the
+// namespaces in the shard at start.
+func (c *shardCache) WaitForCacheSync(ctx context.Context) bool {
+	if !c.Cache.WaitForCacheSync(ctx) {
+		return false
+	}
+	select {
+	case <-c.ready:
+	case <-ctx.Done():
+		return false
+	}
+	c.mu.Lock()
+	caches := make([]cache.Cache, 0, len(c.namespaces))
+	for _, nc := range c.namespaces {
+		caches = append(caches, nc.Cache)
+	}
+	c.mu.Unlock()
+	for _, nc := range caches {
+		if !nc.WaitForCacheSync(ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *shardCache) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	namespaced, err := c.namespaced(obj)
+	if err != nil {
+		return err
+	}
+	if !namespaced {
+		return c.Cache.Get(ctx, key, obj, opts...)
+	}
+	nc := c.namespace(key.Namespace)
+	if nc == nil {
+		gvk, err := apiutil.GVKForObject(obj, c.scheme)
+		if err != nil {
+			return err
+		}
+		return apierrors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, key.Name)
+	}
+	return nc.Get(ctx, key, obj, opts...)
+}
+
+// List lists one namespace's cache, or every namespace in the shard when
+// no namespace is given.
+func (c *shardCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	var listOpts client.ListOptions
+	listOpts.ApplyOptions(opts)
+	if listOpts.Continue != "" {
+		return fmt.Errorf("continue list option is not supported by the cache")
+	}
+	gvk, err := apiutil.GVKForObject(list, c.scheme)
+	if err != nil {
+		return err
+	}
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	namespaced, err := apiutil.IsGVKNamespaced(gvk, c.mapper)
+	if err != nil {
+		return err
+	}
+	if !namespaced {
+		return c.Cache.List(ctx, list, opts...)
+	}
+	if listOpts.Namespace != corev1.NamespaceAll {
+		if nc := c.namespace(listOpts.Namespace); nc != nil {
+			return nc.List(ctx, list, opts...)
+		}
+		return apimeta.SetList(list, nil)
+	}
+
+	c.mu.Lock()
+	names := make([]string, 0, len(c.namespaces))
+	caches := make(map[string]cache.Cache, len(c.namespaces))
+	for name, nc := range c.namespaces {
+		names = append(names, name)
+		caches[name] = nc.Cache
+	}
+	c.mu.Unlock()
+	slices.Sort(names)
+
+	var items []runtime.Object
+	for _, name := range names {
+		nsList := list.DeepCopyObject().(client.ObjectList)
+		if err := caches[name].List(ctx, nsList, &listOpts); err != nil {
+			return err
+		}
+		nsItems, err := apimeta.ExtractList(nsList)
+		if err != nil {
+			return err
+		}
+		items = append(items, nsItems...)
+		if listOpts.Limit > 0 {
+			if listOpts.Limit -= int64(len(nsItems)); listOpts.Limit <= 0 {
+				break
+			}
+		}
+	}
+	return apimeta.SetList(list, items)
+}
+
+func (c *shardCache) GetInformer(ctx context.Context, obj client.Object, opts ...cache.InformerGetOption) (cache.Informer, error) {
+	namespaced, err := c.namespaced(obj)
+	if err != nil {
+		return nil, err
+	}
+	if !namespaced {
+		return c.Cache.GetInformer(ctx, obj, opts...)
+	}
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return nil, err
+	}
+	return c.informer(ctx, gvk, obj, opts...)
+}
+
+func (c *shardCache) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind, opts ...cache.InformerGetOption) (cache.Informer, error) {
+	namespaced, err := apiutil.IsGVKNamespaced(gvk, c.mapper)
+	if err != nil {
+		return nil, err
+	}
+	if !namespaced {
+		return c.Cache.GetInformerForKind(ctx, gvk, opts...)
+	}
+	obj, err := c.scheme.New(gvk)
+	if err != nil {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(gvk)
+		obj = u
+	}
+	return c.informer(ctx, gvk, obj.(client.Object), opts...)
+}
+
+// informer returns the informer of a namespaced kind spanning every
+// namespace in the shard, creating it in each namespace cache.
+func (c *shardCache) informer(ctx context.Context, gvk schema.GroupVersionKind, obj client.Object, opts ...cache.InformerGetOption) (cache.Informer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if si, ok := c.informers[gvk]; ok {
+		return si, nil
+	}
+	si := &shardInformer{prototype: obj.DeepCopyObject().(client.Object), informers: map[string]cache.Informer{}}
+	for name, nc := range c.namespaces {
+		informer, err := nc.GetInformer(ctx, si.newObject(), opts...)
+		if err != nil {
+			return nil, err
+		}
+		if err := si.attach(name, informer); err != nil {
+			return nil, err
+		}
+	}
+	c.informers[gvk] = si
+	return si, nil
+}
+
+func (c *shardCache) RemoveInformer(ctx context.Context, obj client.Object) error {
+	namespaced, err := c.namespaced(obj)
+	if err != nil {
+		return err
+	}
+	if !namespaced {
+		return c.Cache.RemoveInformer(ctx, obj)
+	}
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.informers, gvk)
+	for _, nc := range c.namespaces {
+		if err := nc.RemoveInformer(ctx, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *shardCache) IndexField(ctx context.Context, obj client.Object, field string, extract client.IndexerFunc) error {
+	namespaced, err := c.namespaced(obj)
+	if err != nil {
+		return err
+	}
+	if !namespaced {
+		return c.Cache.IndexField(ctx, obj, field, extract)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.indexes = append(c.indexes, fieldIndex{obj: obj, field: field, extract: extract})
+	for _, nc := range c.namespaces {
+		if err := nc.IndexField(ctx, obj, field, extract); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shardInformer is the informer of one namespaced kind across the shard.
+// Handlers added to it are added to the informer of every namespace in the
+// shard, including namespaces that join later.
+type shardInformer struct {
+	prototype client.Object
+
+	mu        sync.Mutex
+	informers map[string]cache.Informer
+	handlers  []*shardHandler
+	indexers  []toolscache.Indexers
+}
+
+// shardHandler is a handler added to a shardInformer and its registration
+// in each namespace.
+type shardHandler struct {
+	handler toolscache.ResourceEventHandler
+	options toolscache.HandlerOptions
+
+	mu   sync.Mutex
+	regs map[string]toolscache.ResourceEventHandlerRegistration
+}
+
+var _ cache.Informer = &shardInformer{}
+
+func (i *shardInformer) newObject() client.Object {
+	return i.prototype.DeepCopyObject().(client.Object)
+}
+
+// attach adds the handlers and indexers to the informer of a namespace
+// joining the shard.
+func (i *shardInformer) attach(namespace string, informer cache.Informer) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, indexers := range i.indexers {
+		if err := informer.AddIndexers(indexers); err != nil {
+			return err
+		}
+	}
+	for _, h := range i.handlers {
+		reg, err := informer.AddEventHandlerWithOptions(h.handler, h.options)
+		if err != nil {
+			return err
+		}
+		h.set(namespace, reg)
+	}
+	i.informers[namespace] = informer
+	return nil
+}
+
+// detach forgets the informer of a namespace leaving the shard.
+func (i *shardInformer) detach(namespace string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	informer, ok := i.informers[namespace]
+	if !ok {
+		return
+	}
+	for _, h := range i.handlers {
+		if reg := h.take(namespace); reg != nil {
+			_ = informer.RemoveEventHandler(reg)
+		}
+	}
+	delete(i.informers, namespace)
+}
+
+func (i *shardInformer) AddEventHandler(handler toolscache.ResourceEventHandler) (toolscache.ResourceEventHandlerRegistration, error) {
+	return i.AddEventHandlerWithOptions(handler, toolscache.HandlerOptions{})
+}
+
+func (i *shardInformer) AddEventHandlerWithResyncPeriod(handler toolscache.ResourceEventHandler, resyncPeriod time.Duration) (toolscache.ResourceEventHandlerRegistration, error) {
+	return i.AddEventHandlerWithOptions(handler, toolscache.HandlerOptions{ResyncPeriod: &resyncPeriod})
+}
+
+func (i *shardInformer) AddEventHandlerWithOptions(handler toolscache.ResourceEventHandler, options toolscache.HandlerOptions) (toolscache.ResourceEventHandlerRegistration, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	h := &shardHandler{handler: handler, options: options, regs: map[string]toolscache.ResourceEventHandlerRegistration{}}
+	for namespace, informer := range i.informers {
+		reg, err := informer.AddEventHandlerWithOptions(handler, options)
+		if err != nil {
+			return nil, err
+		}
+		h.set(namespace, reg)
+	}
+	i.handlers = append(i.handlers, h)
+	return h, nil
+}
+
+func (i *shardInformer) RemoveEventHandler(handle toolscache.ResourceEventHandlerRegistration) error {
+	h, ok := handle.(*shardHandler)
+	if !ok {
+		return fmt.Errorf("registration %T was not returned by this informer", handle)
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.handlers = slices.DeleteFunc(i.handlers, func(other *shardHandler) bool { return other == h })
+	for namespace, informer := range i.informers {
+		if reg := h.take(namespace); reg != nil {
+			if err := informer.RemoveEventHandler(reg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (i *shardInformer) AddIndexers(indexers toolscache.Indexers) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, informer := range i.informers {
+		if err := informer.AddIndexers(indexers); err != nil {
+			return err
+		}
+	}
+	i.indexers = append(i.indexers, indexers)
+	return nil
+}
+
+func (i *shardInformer) HasSynced() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, informer := range i.informers {
+		if !informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// IsStopped reports false: the informer follows the shard until the
+// manager stops.
+func (i *shardInformer) IsStopped() bool {
+	return false
+}
+
+func (h *shardHandler) set(namespace string, reg toolscache.ResourceEventHandlerRegistration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.regs[namespace] = reg
+}
+
+func (h *shardHandler) take(namespace string) toolscache.ResourceEventHandlerRegistration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	reg := h.regs[namespace]
+	delete(h.regs, namespace)
+	return reg
+}
+
+// HasSynced reports whether the handler has seen the initial objects of
+// every namespace in the shard.
+func (h *shardHandler) HasSynced() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, reg := range h.regs {
+		if !reg.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TestShardCache_Partition verifies a sharded cache starts a cache only
+// for the namespaces in its shard and the pinned platform namespaces, so
+// objects in other shards are never watched, and stops a namespace's cache
+// when it leaves the shard.
+func TestShardCache_Partition(t *testing.T) {
+	scheme := newTestScheme(t)
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Namespace"), meta.RESTScopeRoot)
+	mapper.Add(iafv1alpha1.GroupVersion.WithKind("Application"), meta.RESTScopeNamespace)
+	selector, err := labels.Parse("iaf.io/shard=b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cluster := &informertest.FakeInformers{Scheme: scheme}
+	var mu sync.Mutex
+	caches := map[string]*informertest.FakeInformers{}
+	c := newShardCache(Shard{Selector: selector, Namespaces: []string{"iaf-system"}}, cluster, scheme, mapper, func(namespace string) (cache.Cache, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		caches[namespace] = &informertest.FakeInformers{Scheme: scheme}
+		return caches[namespace], nil
+	})
+	cached := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		names := make([]string, 0, len(caches))
+		for name := range caches {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		if err := c.Start(ctx); err != nil {
+			t.Error(err)
+		}
+	}()
+	if !c.WaitForCacheSync(ctx) {
+		t.Fatal("cache did not sync")
+	}
+
+	var seen []string
+	informer, err := c.GetInformer(ctx, &iafv1alpha1.Application{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) { seen = append(seen, obj.(client.Object).GetNamespace()) },
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	namespaces, err := cluster.FakeInformerFor(ctx, &corev1.Namespace{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mine := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "iaf-mine", Labels: map[string]string{"iaf.io/shard": "b"}}}
+	namespaces.Add(mine)
+	namespaces.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "iaf-other", Labels: map[string]string{"iaf.io/shard": "a"}}})
+
+	if got := cached(); !slices.Equal(got, []string{"iaf-mine", "iaf-system"}) {
+		t.Fatalf("expected caches for the shard and the platform namespace only, got %v", got)
+	}
+
+	// A handler added before the namespace joined sees its objects.
+	mineApps, err := caches["iaf-mine"].FakeInformerFor(ctx, &iafv1alpha1.Application{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mineApps.Add(makeApp("web", "iaf-mine"))
+	if !slices.Equal(seen, []string{"iaf-mine"}) {
+		t.Errorf("expected the app in the shard to reach the handler, got %v", seen)
+	}
+
+	var app iafv1alpha1.Application
+	if err := c.Get(ctx, types.NamespacedName{Name: "web", Namespace: "iaf-other"}, &app); !apierrors.IsNotFound(err) {
+		t.Errorf("expected an app outside the shard to read as missing, got %v", err)
+	}
+	var list iafv1alpha1.ApplicationList
+	if err := c.List(ctx, &list, client.InNamespace("iaf-other")); err != nil || len(list.Items) != 0 {
+		t.Errorf("expected no apps listed outside the shard, got %d, %v", len(list.Items), err)
+	}
+
+	// Relabelling hands the namespace to another shard and stops its cache.
+	left := mine.DeepCopy()
+	left.Labels["iaf.io/shard"] = "a"
+	namespaces.Update(mine, left)
+	if err := c.Get(ctx, types.NamespacedName{Name: "web", Namespace: "iaf-mine"}, &app); !apierrors.IsNotFound(err) {
+		t.Errorf("expected an app in a namespace leaving the shard to read as missing, got %v", err)
+	}
+	namespaces.Delete(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "iaf-system"}})
+	if c.namespace("iaf-system") == nil {
+		t.Error("expected the platform namespace to stay cached")
+	}
+}
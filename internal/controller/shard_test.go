@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// TestReconcile_Shard verifies a sharded controller reconciles apps in the
+// namespaces matching its selector and leaves the others alone.
+func TestReconcile_Shard(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconciler(scheme)
+	selector, err := labels.Parse("iaf.io/shard=b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Shard = Shard{Selector: selector}
+	ctx := context.Background()
+
+	for _, ns := range []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "iaf-mine", Labels: map[string]string{"iaf.io/shard": "b"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "iaf-other", Labels: map[string]string{"iaf.io/shard": "a"}}},
+	} {
+		if err := r.Create(ctx, ns); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// iaf-unknown has no Namespace object the shard can read.
+	for _, ns := range []string{"iaf-mine", "iaf-other", "iaf-unknown"} {
+		if err := r.Create(ctx, makeApp("web", ns)); err != nil {
+			t.Fatal(err)
+		}
+		reconcileApp(t, r, "web", ns)
+	}
+
+	var dep appsv1.Deployment
+	if err := r.Get(ctx, types.NamespacedName{Name: "web", Namespace: "iaf-mine"}, &dep); err != nil {
+		t.Errorf("expected the app in the shard to be deployed: %v", err)
+	}
+	for _, ns := range []string{"iaf-other", "iaf-unknown"} {
+		if err := r.Get(ctx, types.NamespacedName{Name: "web", Namespace: ns}, &dep); !apierrors.IsNotFound(err) {
+			t.Errorf("expected the app in %s to be left to its shard, got %v", ns, err)
+		}
+		var app iafv1alpha1.Application
+		if err := r.Get(ctx, types.NamespacedName{Name: "web", Namespace: ns}, &app); err != nil {
+			t.Fatal(err)
+		}
+		if app.Status.Phase != "" {
+			t.Errorf("expected no status for the app in %s, got phase %q", ns, app.Status.Phase)
+		}
+	}
+
+	reqs := listApplications(r.Client)(ctx, "iaf-mine")
+	if len(reqs) != 1 || reqs[0].Name != "web" || reqs[0].Namespace != "iaf-mine" {
+		t.Errorf("expected a namespace joining the shard to enqueue its app, got %v", reqs)
+	}
+}
+
+// TestShard_Unsharded verifies the zero value reconciles every namespace
+// and leaves the manager cache alone.
+func TestShard_Unsharded(t *testing.T) {
+	r := newReconciler(newTestScheme(t))
+	var s Shard
+	if !s.contains(context.Background(), r.Client, "iaf-anything") {
+		t.Error("expected an unsharded controller to reconcile every namespace")
+	}
+	if opts := s.CacheOptions(); opts.ByObject != nil {
+		t.Errorf("expected no cache filtering, got %+v", opts.ByObject)
+	}
+	if opts := (Shard{Selector: labels.Everything()}).CacheOptions(); opts.ByObject != nil {
+		t.Errorf("expected an empty selector not to filter the cache, got %+v", opts.ByObject)
+	}
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// purgeTime returns when app, marked deleted by delete_app, is removed for
+// good. An unreadable deletion time counts from now, so a bad annotation
+// never purges early.
+func purgeTime(app *iafv1alpha1.Application, retention time.Duration, now time.Time) time.Time {
+	deletedAt, err := time.Parse(time.RFC3339, app.Annotations[iafv1alpha1.DeletedAtAnnotation])
+	if err != nil {
+		deletedAt = now
+	}
+	return deletedAt.Add(retention)
+}
+
+// reconcileDeleted keeps an application marked deleted scaled to zero until
+// its retention window passes, then deletes it. Only its route is removed
+// meanwhile; its CRs, secrets and uploaded source stay so restore_app can
+// bring it back. Removing the annotation hands it back to the normal
+// reconcile.
+func (r *ApplicationReconciler) reconcileDeleted(ctx context.Context, app *iafv1alpha1.Application) (ctrl.Result, error) {
+	key := types.NamespacedName{Name: app.Name, Namespace: app.Namespace}
+	now := time.Now()
+	purgeAt := purgeTime(app, r.DeletedRetention, now)
+
+	if !now.Before(purgeAt) && !app.Spec.DeletionProtected {
+		// Owner references cascade the deletion to everything the app owns.
+		if err := r.Delete(ctx, app); err != nil && !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("purging deleted application: %w", err)
+		}
+		log.FromContext(ctx).Info("purged application after its retention window", "retention", r.DeletedRetention)
+		r.backoff.forget(key)
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.scaleToZero(ctx, key); err != nil {
+		return ctrl.Result{}, err
+	}
+	// Free the host for other apps while this one waits. The normal
+	// reconcile creates the route again after a restore.
+	for _, gvk := range []schema.GroupVersionKind{iafk8s.TraefikIngressRouteGVK, iafk8s.TraefikIngressRouteTCPGVK} {
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(gvk)
+		route.SetName(app.Name)
+		route.SetNamespace(app.Namespace)
+		if err := r.Delete(ctx, route); err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return ctrl.Result{}, fmt.Errorf("deleting %s: %w", gvk.Kind, err)
+		}
+	}
+
+	app.Status.Phase = iafv1alpha1.ApplicationPhaseDeleted
+	app.Status.AvailableReplicas = 0
+	app.Status.PurgeAt = &metav1.Time{Time: purgeAt}
+	meta.RemoveStatusCondition(&app.Status.Conditions, conditionExpiring)
+	message := fmt.Sprintf("Deleted; restore_app brings it back until %s, when it is removed for good", purgeAt.UTC().Format(time.RFC3339))
+	if !now.Before(purgeAt) {
+		// A deletion-protected app is only purged once the protection is
+		// cleared, which itself triggers a reconcile.
+		message = "Deleted and its retention window has passed, but deletion protection is on. It is removed once the protection is cleared."
+	}
+	setCondition(app, "Ready", metav1.ConditionFalse, "Deleted", message)
+	r.backoff.forget(key)
+	if !now.Before(purgeAt) {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{RequeueAfter: purgeAt.Sub(now)}, nil
+}
+
+// scaleToZero sets the replicas of the application's Deployment to zero.
+// The normal reconcile applies the desired count again after a restore.
+func (r *ApplicationReconciler) scaleToZero(ctx context.Context, key types.NamespacedName) error {
+	var dep appsv1.Deployment
+	if err := r.Get(ctx, key, &dep); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("getting deployment: %w", err)
+	}
+	if dep.Spec.Replicas != nil && *dep.Spec.Replicas == 0 {
+		return nil
+	}
+	patch := client.MergeFrom(dep.DeepCopy())
+	zero := int32(0)
+	dep.Spec.Replicas = &zero
+	if err := r.Patch(ctx, &dep, patch); err != nil {
+		return fmt.Errorf("scaling deployment to zero: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// patchStatus writes the status changes made to obj since it was read as
+// read, in one merge patch. The patch carries only the changed fields and,
+// without options, no resourceVersion, so it cannot conflict with MCP tools
+// or the conformance verifier writing other status fields. Nothing is sent
+// when nothing changed, and an object deleted meanwhile is not an error.
+func patchStatus(ctx context.Context, c client.Client, read, obj client.Object, opts ...client.MergeFromOption) error {
+	data, err := client.MergeFrom(read).Data(obj)
+	if err != nil {
+		return fmt.Errorf("computing status patch: %w", err)
+	}
+	if string(data) == "{}" {
+		return nil
+	}
+	err = c.Status().Patch(ctx, obj, client.MergeFromWithOptions(read, opts...))
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// TestReconcile_WritesStatusOnce verifies a pass that moves an app through
+// several phases writes its status in one request.
+func TestReconcile_WritesStatusOnce(t *testing.T) {
+	var calls apiCalls
+	r := newCountingReconciler(newTestScheme(t), &calls)
+	ctx := context.Background()
+	if err := r.Create(ctx, makeApp("web", "test-ns")); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileApp(t, r, "web", "test-ns")
+	if got := calls.statusWrites.Load(); got != 1 {
+		t.Errorf("expected one status write, got %d", got)
+	}
+	var app iafv1alpha1.Application
+	if err := r.Get(ctx, types.NamespacedName{Name: "web", Namespace: "test-ns"}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if app.Status.Phase != iafv1alpha1.ApplicationPhaseDeploying || app.Status.URL == "" {
+		t.Errorf("expected the whole pass in the status, got phase %q, url %q", app.Status.Phase, app.Status.URL)
+	}
+}
+
+// TestReconcile_ConcurrentStatusWriter verifies the controller's status
+// write neither conflicts with nor undoes a status field another writer
+// changed during the pass.
+func TestReconcile_ConcurrentStatusWriter(t *testing.T) {
+	scheme := newTestScheme(t)
+	ctx := context.Background()
+	var raced bool
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&iafv1alpha1.Application{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourcePatch: func(ctx context.Context, c client.Client, subResource string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				if !raced {
+					// The conformance verifier reports while the pass runs.
+					raced = true
+					var current iafv1alpha1.Application
+					if err := c.Get(ctx, client.ObjectKeyFromObject(obj), &current); err != nil {
+						return err
+					}
+					current.Status.Conformance = &iafv1alpha1.ConformanceReport{Image: "nginx:latest", Result: "Passed"}
+					if err := c.Status().Update(ctx, &current); err != nil {
+						return err
+					}
+				}
+				return c.SubResource(subResource).Patch(ctx, obj, patch, opts...)
+			},
+		}).
+		Build()
+	r := newReconciler(scheme)
+	r.Client = k8sClient
+	if err := r.Create(ctx, makeApp("web", "test-ns")); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileApp(t, r, "web", "test-ns")
+	var app iafv1alpha1.Application
+	if err := r.Get(ctx, types.NamespacedName{Name: "web", Namespace: "test-ns"}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if app.Status.Phase != iafv1alpha1.ApplicationPhaseDeploying {
+		t.Errorf("expected phase Deploying, got %q", app.Status.Phase)
+	}
+	if app.Status.Conformance == nil || app.Status.Conformance.Result != "Passed" {
+		t.Errorf("expected the concurrent conformance report to be kept, got %+v", app.Status.Conformance)
+	}
+}
+
+// TestManagedServiceReconcile_ConcurrentBind verifies the controller's
+// status write keeps an app bind_service added during the pass.
+func TestManagedServiceReconcile_ConcurrentBind(t *testing.T) {
+	scheme := newMSTestScheme(t)
+	ctx := context.Background()
+	var raced bool
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&iafv1alpha1.ManagedService{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourcePatch: func(ctx context.Context, c client.Client, subResource string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				if !raced {
+					raced = true
+					var current iafv1alpha1.ManagedService
+					if err := c.Get(ctx, client.ObjectKeyFromObject(obj), &current); err != nil {
+						return err
+					}
+					current.Status.BoundApps = append(current.Status.BoundApps, "web")
+					if err := c.Status().Update(ctx, &current); err != nil {
+						return err
+					}
+				}
+				return c.SubResource(subResource).Patch(ctx, obj, patch, opts...)
+			},
+		}).
+		Build()
+	r := newMSReconciler(scheme)
+	r.Client = k8sClient
+	svc := makeManagedSvc("db", "test-ns")
+	controllerutil.AddFinalizer(svc, managedServiceFinalizer)
+	if err := r.Create(ctx, svc); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "db", Namespace: "test-ns"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got iafv1alpha1.ManagedService
+	if err := r.Get(ctx, types.NamespacedName{Name: "db", Namespace: "test-ns"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.Phase == "" {
+		t.Error("expected the controller's status to be written")
+	}
+	if len(got.Status.BoundApps) != 1 || got.Status.BoundApps[0] != "web" {
+		t.Errorf("expected the concurrent binding to be kept, got %v", got.Status.BoundApps)
+	}
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// conditionExpiring reports the TTL of an Application or ManagedService.
+	// It turns True when deletion is near.
+	conditionExpiring = "Expiring"
+
+	// maxExpiryWarning caps how long before expiry a resource is reported as
+	// expiring soon.
+	maxExpiryWarning = 24 * time.Hour
+
+	// expiryBlockedRequeue is how often an expired ManagedService that is
+	// still bound to applications is checked again.
+	expiryBlockedRequeue = 5 * time.Minute
+)
+
+// expiry returns when obj is deleted under ttl. It reports false when no
+// TTL is set.
+func expiry(obj metav1.Object, ttl *metav1.Duration) (time.Time, bool) {
+	created := obj.GetCreationTimestamp()
+	if ttl == nil || ttl.Duration <= 0 || created.IsZero() {
+		return time.Time{}, false
+	}
+	return created.Add(ttl.Duration), true
+}
+
+// expiryState describes an unexpired TTL at now as an Expiring condition.
+// The warning starts a quarter of the TTL before expiry, at most a day.
+// next is the delay until the condition changes or the resource expires.
+type expiryState struct {
+	status  metav1.ConditionStatus
+	reason  string
+	message string
+	next    time.Duration
+}
+
+func newExpiryState(expiresAt time.Time, ttl time.Duration, now time.Time) expiryState {
+	warnAt := expiresAt.Add(-min(ttl/4, maxExpiryWarning))
+	at := expiresAt.UTC().Format(time.RFC3339)
+	if now.Before(warnAt) {
+		return expiryState{
+			status:  metav1.ConditionFalse,
+			reason:  "Scheduled",
+			message: fmt.Sprintf("Will be deleted at %s when its TTL elapses", at),
+			next:    warnAt.Sub(now),
+		}
+	}
+	left := expiresAt.Sub(now)
+	return expiryState{
+		status:  metav1.ConditionTrue,
+		reason:  "ExpiresSoon",
+		message: fmt.Sprintf("Will be deleted in %s (at %s) when its TTL elapses", left.Round(time.Minute), at),
+		next:    left,
+	}
+}
+
+// protectedExpiryState describes a TTL that elapsed at expiresAt while
+// deletion protection is on. Nothing changes until the protection is
+// cleared, which itself triggers a reconcile, so next is zero.
+func protectedExpiryState(expiresAt time.Time) expiryState {
+	return expiryState{
+		status:  metav1.ConditionTrue,
+		reason:  "ExpiryBlocked",
+		message: fmt.Sprintf("TTL elapsed at %s but deletion protection is on. It is deleted once the protection is cleared.", expiresAt.UTC().Format(time.RFC3339)),
+	}
+}
+
+// requeueBy shortens result so the resource is reconciled again within d.
+// An immediate requeue is kept.
+func requeueBy(result ctrl.Result, d time.Duration) ctrl.Result {
+	if result.Requeue && result.RequeueAfter == 0 {
+		return result
+	}
+	if result.RequeueAfter == 0 || d < result.RequeueAfter {
+		result.RequeueAfter = d
+	}
+	return result
+}
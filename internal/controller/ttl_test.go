@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestExpiry(t *testing.T) {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		created time.Time
+		ttl     *metav1.Duration
+		want    time.Time
+		wantOK  bool
+	}{
+		{name: "no ttl", created: created},
+		{name: "zero ttl", created: created, ttl: &metav1.Duration{}},
+		{name: "not yet created", ttl: &metav1.Duration{Duration: time.Hour}},
+		{name: "ttl", created: created, ttl: &metav1.Duration{Duration: 72 * time.Hour}, want: created.Add(72 * time.Hour), wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := makeApp("ttl", "ns")
+			app.CreationTimestamp = metav1.NewTime(tt.created)
+			got, ok := expiry(app, tt.ttl)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("expiry = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNewExpiryState(t *testing.T) {
+	expiresAt := time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		ttl        time.Duration
+		now        time.Time
+		wantStatus metav1.ConditionStatus
+		wantReason string
+		wantNext   time.Duration
+	}{
+		{
+			name:       "long ttl warns a day ahead",
+			ttl:        168 * time.Hour,
+			now:        expiresAt.Add(-48 * time.Hour),
+			wantStatus: metav1.ConditionFalse,
+			wantReason: "Scheduled",
+			wantNext:   24 * time.Hour,
+		},
+		{
+			name:       "long ttl inside warning",
+			ttl:        72 * time.Hour,
+			now:        expiresAt.Add(-3 * time.Hour),
+			wantStatus: metav1.ConditionTrue,
+			wantReason: "ExpiresSoon",
+			wantNext:   3 * time.Hour,
+		},
+		{
+			name:       "shorter ttl warns a quarter ahead",
+			ttl:        4 * time.Hour,
+			now:        expiresAt.Add(-2 * time.Hour),
+			wantStatus: metav1.ConditionFalse,
+			wantReason: "Scheduled",
+			wantNext:   time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newExpiryState(expiresAt, tt.ttl, tt.now)
+			if got.status != tt.wantStatus || got.reason != tt.wantReason || got.next != tt.wantNext {
+				t.Errorf("got %s/%s next %v; want %s/%s next %v",
+					got.status, got.reason, got.next, tt.wantStatus, tt.wantReason, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestRequeueBy(t *testing.T) {
+	tests := []struct {
+		name   string
+		result ctrl.Result
+		d      time.Duration
+		want   ctrl.Result
+	}{
+		{name: "no requeue", d: time.Hour, want: ctrl.Result{RequeueAfter: time.Hour}},
+		{name: "earlier requeue kept", result: ctrl.Result{RequeueAfter: time.Minute}, d: time.Hour, want: ctrl.Result{RequeueAfter: time.Minute}},
+		{name: "later requeue shortened", result: ctrl.Result{RequeueAfter: time.Hour}, d: time.Minute, want: ctrl.Result{RequeueAfter: time.Minute}},
+		{name: "immediate requeue kept", result: ctrl.Result{Requeue: true}, d: time.Hour, want: ctrl.Result{Requeue: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := requeueBy(tt.result, tt.d); got != tt.want {
+				t.Errorf("requeueBy = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// usesWildcardCertificate reports whether app is served by the platform
+// wildcard certificate: the platform is in wildcard mode and the app's host
+// is a single label under the base domain.
+func (r *ApplicationReconciler) usesWildcardCertificate(app *iafv1alpha1.Application) bool {
+	if r.WildcardTLS.IsZero() {
+		return false
+	}
+	host := app.Spec.Host
+	if host == "" {
+		host = fmt.Sprintf("%s.%s", app.Name, r.BaseDomain)
+	}
+	return iafk8s.CoveredByWildcard(host, r.BaseDomain)
+}
+
+// reconcileWildcardCertificate ensures the shared wildcard Certificate and
+// the Traefik default TLSStore serving it, and returns the Certificate.
+// Every app under the base domain converges them; they are never deleted,
+// since other apps rely on them.
+func (r *ApplicationReconciler) reconcileWildcardCertificate(ctx context.Context) (*unstructured.Unstructured, error) {
+	cert, err := r.applyCertificate(ctx, iafk8s.BuildWildcardCertificate(r.WildcardTLS, r.BaseDomain, r.TLSIssuer))
+	if err != nil {
+		return nil, err
+	}
+
+	desired := iafk8s.BuildDefaultTLSStore(r.WildcardTLS)
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(iafk8s.TraefikTLSStoreGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: desired.GetName(), Namespace: desired.GetNamespace()}, existing); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("getting tls store: %w", err)
+		}
+		if err := r.Create(ctx, desired); err != nil && !apierrors.IsAlreadyExists(err) {
+			return nil, fmt.Errorf("creating tls store: %w", err)
+		}
+		return cert, nil
+	}
+	existing.Object["spec"] = desired.Object["spec"]
+	if err := r.Update(ctx, existing); err != nil {
+		return nil, fmt.Errorf("updating tls store: %w", err)
+	}
+	return cert, nil
+}
+
+// deleteAppCertificate deletes the app's own Certificate, left over from
+// before the platform switched to wildcard mode.
+func (r *ApplicationReconciler) deleteAppCertificate(ctx context.Context, app *iafv1alpha1.Application) error {
+	cert := &unstructured.Unstructured{}
+	cert.SetGroupVersionKind(iafk8s.CertificateGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, cert); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("getting certificate: %w", err)
+	}
+	for _, ref := range cert.GetOwnerReferences() {
+		if ref.UID == app.UID {
+			if err := r.deleteIfExists(ctx, cert); err != nil {
+				return fmt.Errorf("deleting certificate: %w", err)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// mapWildcardCertificateToApplications enqueues every app served by the
+// wildcard certificate when it changes, so their status follows its
+// issuance and renewal.
+func (r *ApplicationReconciler) mapWildcardCertificateToApplications(ctx context.Context, obj client.Object) []reconcile.Request {
+	if obj.GetNamespace() != r.WildcardTLS.Namespace || obj.GetName() != iafk8s.WildcardCertificateName {
+		return nil
+	}
+	var apps iafv1alpha1.ApplicationList
+	if err := r.List(ctx, &apps); err != nil {
+		log.FromContext(ctx).Error(err, "listing applications for wildcard certificate")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, app := range apps.Items {
+		if iafv1alpha1.IsTLSEnabled(&app) && r.usesWildcardCertificate(&app) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: app.Name, Namespace: app.Namespace}})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// TestReconcile_WildcardTLS verifies that in wildcard mode apps under the
+// base domain share one Certificate served as Traefik's default, that a
+// per-app Certificate left from per-app mode is removed, and that apps on
+// other hosts keep their own.
+func TestReconcile_WildcardTLS(t *testing.T) {
+	scheme := newTestScheme(t)
+	r := newReconcilerWithTLS(scheme)
+	ctx := context.Background()
+
+	app := makeApp("myapp", "test-ns")
+	custom := makeApp("shop", "test-ns")
+	custom.UID = "shop-uid"
+	custom.Spec.Host = "shop.corp.example"
+	for _, a := range []*iafv1alpha1.Application{app, custom} {
+		if err := r.Create(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reconcileApp(t, r, "myapp", "test-ns")
+
+	getCert := func(name, namespace string) (*unstructured.Unstructured, error) {
+		cert := &unstructured.Unstructured{}
+		cert.SetGroupVersionKind(iafk8s.CertificateGVK)
+		return cert, r.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, cert)
+	}
+	if _, err := getCert("myapp", "test-ns"); err != nil {
+		t.Fatalf("expected a per-app Certificate before wildcard mode: %v", err)
+	}
+
+	r.WildcardTLS = iafk8s.WildcardTLS{Namespace: "iaf-system"}
+	reconcileApp(t, r, "myapp", "test-ns")
+	reconcileApp(t, r, "shop", "test-ns")
+
+	if _, err := getCert("myapp", "test-ns"); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the per-app Certificate to be deleted, got %v", err)
+	}
+	wildcard, err := getCert(iafk8s.WildcardCertificateName, "iaf-system")
+	if err != nil {
+		t.Fatalf("expected the wildcard Certificate: %v", err)
+	}
+	if names, _, _ := unstructured.NestedStringSlice(wildcard.Object, "spec", "dnsNames"); len(names) == 0 || names[0] != "*.example.com" {
+		t.Errorf("unexpected wildcard dnsNames %v", names)
+	}
+	store := &unstructured.Unstructured{}
+	store.SetGroupVersionKind(iafk8s.TraefikTLSStoreGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: "default", Namespace: "iaf-system"}, store); err != nil {
+		t.Errorf("expected the default TLSStore: %v", err)
+	}
+
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(iafk8s.TraefikIngressRouteGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, route); err != nil {
+		t.Fatal(err)
+	}
+	if tls, found, _ := unstructured.NestedMap(route.Object, "spec", "tls"); !found || len(tls) != 0 {
+		t.Errorf("expected the route to use the default certificate, got tls %v", tls)
+	}
+
+	// The custom host is not covered by the wildcard.
+	if _, err := getCert("shop", "test-ns"); err != nil {
+		t.Errorf("expected a Certificate for the custom host: %v", err)
+	}
+
+	// The app reports the shared certificate, and changes to it reach the app.
+	var got iafv1alpha1.Application
+	if err := r.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: "test-ns"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.TLS == nil || got.Status.TLS.Issuer != "selfsigned-issuer" {
+		t.Errorf("expected the TLS status of the wildcard certificate, got %+v", got.Status.TLS)
+	}
+	requests := r.mapWildcardCertificateToApplications(ctx, wildcard)
+	if len(requests) != 1 || requests[0].Name != "myapp" {
+		t.Errorf("expected the wildcard Certificate to enqueue myapp only, got %v", requests)
+	}
+	other := &unstructured.Unstructured{}
+	other.SetName("myapp")
+	other.SetNamespace("test-ns")
+	if requests := r.mapWildcardCertificateToApplications(ctx, other); len(requests) != 0 {
+		t.Errorf("expected other Certificates to enqueue nothing, got %v", requests)
+	}
+}
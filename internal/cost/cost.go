@@ -0,0 +1,216 @@
+// Package cost estimates what sessions and applications cost from the CPU
+// and memory their pods use, as recorded by the platform Prometheus, and
+// per-unit rates set by the operator.
+package cost
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	promapi "github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// DefaultWindow is the period costs are reported over when none is given.
+	DefaultWindow = 24 * time.Hour
+	// MaxWindow is the longest period costs can be reported over.
+	MaxWindow = 30 * 24 * time.Hour
+	// MaxDays is the most days the daily roll-up covers.
+	MaxDays = 31
+
+	// memorySampleStep is the resolution memory use is sampled at; each
+	// sample stands for this long.
+	memorySampleStep = 5 * time.Minute
+)
+
+// Rates are the prices of the resources apps use.
+type Rates struct {
+	// CPUCoreHour is the price of one CPU core used for one hour.
+	CPUCoreHour float64
+	// MemoryGBHour is the price of one GB of memory used for one hour.
+	MemoryGBHour float64
+	// Currency labels the prices, e.g. "USD".
+	Currency string
+}
+
+// Usage is the amount of resources used over a period.
+type Usage struct {
+	CPUCoreHours  float64
+	MemoryGBHours float64
+}
+
+// Add returns the sum of u and o.
+func (u Usage) Add(o Usage) Usage {
+	return Usage{CPUCoreHours: u.CPUCoreHours + o.CPUCoreHours, MemoryGBHours: u.MemoryGBHours + o.MemoryGBHours}
+}
+
+// Cost prices u at r, rounded to cents.
+func (r Rates) Cost(u Usage) float64 {
+	return Round(u.CPUCoreHours*r.CPUCoreHour + u.MemoryGBHours*r.MemoryGBHour)
+}
+
+// Round rounds v to two decimals for reporting.
+func Round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// DailyUsage is the usage of one namespace on one UTC day.
+type DailyUsage struct {
+	Day time.Time
+	Usage
+}
+
+// Querier reports resource usage.
+type Querier interface {
+	// Usage returns the resources used in namespace over the window ending
+	// now, by the pods of the application name or, when name is empty, by
+	// every pod.
+	Usage(ctx context.Context, namespace, name string, window time.Duration) (Usage, error)
+	// DailyUsage returns the usage of every session namespace on each of the
+	// last days UTC days, today included, keyed by namespace.
+	DailyUsage(ctx context.Context, days int) (map[string][]DailyUsage, error)
+}
+
+// Estimator prices the usage reported by a Querier.
+type Estimator struct {
+	Usage Querier
+	Rates Rates
+}
+
+// ParseWindow parses a reporting window such as "24h" or "7d". An empty
+// string returns DefaultWindow.
+func ParseWindow(s string) (time.Duration, error) {
+	if s == "" {
+		return DefaultWindow, nil
+	}
+	d, err := model.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid window %q: use a duration such as 24h or 7d", s)
+	}
+	if time.Duration(d) < time.Hour || time.Duration(d) > MaxWindow {
+		return 0, fmt.Errorf("window must be between 1h and 30d")
+	}
+	return time.Duration(d), nil
+}
+
+// FormatWindow formats a reporting window the way ParseWindow accepts it.
+func FormatWindow(d time.Duration) string {
+	return model.Duration(d).String()
+}
+
+// Prometheus is a Querier backed by a Prometheus server that scrapes the
+// kubelet's cAdvisor metrics.
+type Prometheus struct {
+	api promv1.API
+	now func() time.Time
+}
+
+// NewPrometheus returns a Querier for the Prometheus server at url.
+func NewPrometheus(url string) (*Prometheus, error) {
+	c, err := promapi.NewClient(promapi.Config{Address: url})
+	if err != nil {
+		return nil, fmt.Errorf("creating prometheus client: %w", err)
+	}
+	return &Prometheus{api: promv1.NewAPI(c), now: time.Now}, nil
+}
+
+// Usage queries the CPU and memory use of the pods selected by namespace
+// and name.
+func (p *Prometheus) Usage(ctx context.Context, namespace, name string, window time.Duration) (Usage, error) {
+	selector := fmt.Sprintf(`namespace=%q, container!="", container!="POD"`, namespace)
+	if name != "" {
+		selector += fmt.Sprintf(`, pod=~%q`, iafk8s.AppPodPattern(name))
+	}
+	byNamespace, err := p.usage(ctx, selector, window, p.now())
+	if err != nil {
+		return Usage{}, err
+	}
+	return byNamespace[namespace], nil
+}
+
+// sessionNamespaces matches the namespaces of sessions, iaf-<32 hex digits>,
+// and no platform namespace such as iaf-system.
+const sessionNamespaces = `iaf-[0-9a-f]{32}`
+
+// DailyUsage queries the usage of every session namespace one day at a time.
+// Today is counted up to now.
+func (p *Prometheus) DailyUsage(ctx context.Context, days int) (map[string][]DailyUsage, error) {
+	if days < 1 || days > MaxDays {
+		return nil, fmt.Errorf("days must be between 1 and %d", MaxDays)
+	}
+	selector := fmt.Sprintf(`namespace=~%q, container!="", container!="POD"`, sessionNamespaces)
+	now := p.now().UTC()
+	today := now.Truncate(24 * time.Hour)
+
+	result := map[string][]DailyUsage{}
+	for i := days - 1; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i)
+		end := day.Add(24 * time.Hour)
+		if end.After(now) {
+			end = now
+		}
+		window := end.Sub(day)
+		if window < time.Minute {
+			continue
+		}
+		byNamespace, err := p.usage(ctx, selector, window, end)
+		if err != nil {
+			return nil, err
+		}
+		for namespace, u := range byNamespace {
+			result[namespace] = append(result[namespace], DailyUsage{Day: day, Usage: u})
+		}
+	}
+	return result, nil
+}
+
+// usage returns the CPU core-hours and memory GB-hours of the containers
+// matching selector over the window ending at end, by namespace.
+func (p *Prometheus) usage(ctx context.Context, selector string, window time.Duration, end time.Time) (map[string]Usage, error) {
+	rng := model.Duration(window)
+	cpu, err := p.vector(ctx, fmt.Sprintf(`sum by (namespace) (increase(container_cpu_usage_seconds_total{%s}[%s])) / 3600`, selector, rng), end)
+	if err != nil {
+		return nil, err
+	}
+	// Each memory sample stands for memorySampleStep of use.
+	memory, err := p.vector(ctx, fmt.Sprintf(`sum by (namespace) (sum_over_time(container_memory_working_set_bytes{%s}[%s:%s])) * %g / 1e9`,
+		selector, rng, model.Duration(memorySampleStep), memorySampleStep.Hours()), end)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]Usage{}
+	for namespace, v := range cpu {
+		u := result[namespace]
+		u.CPUCoreHours = v
+		result[namespace] = u
+	}
+	for namespace, v := range memory {
+		u := result[namespace]
+		u.MemoryGBHours = v
+		result[namespace] = u
+	}
+	return result, nil
+}
+
+// vector runs query at the given time and returns its samples by namespace.
+func (p *Prometheus) vector(ctx context.Context, query string, at time.Time) (map[string]float64, error) {
+	result, _, err := p.api.Query(ctx, query, at)
+	if err != nil {
+		return nil, fmt.Errorf("querying resource usage: %w", err)
+	}
+	vector, ok := result.(model.Vector)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result type %s", result.Type())
+	}
+	samples := make(map[string]float64, len(vector))
+	for _, s := range vector {
+		samples[string(s.Metric["namespace"])] = float64(s.Value)
+	}
+	return samples, nil
+}
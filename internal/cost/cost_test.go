@@ -0,0 +1,116 @@
+package cost
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePrometheus answers instant queries with one sample per namespace in
+// cpu or memory, depending on the metric queried, and records the queries.
+func fakePrometheus(t *testing.T, cpu, memory map[string]string) (*Prometheus, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		query := r.Form.Get("query")
+		mu.Lock()
+		queries = append(queries, query)
+		mu.Unlock()
+		values := cpu
+		if strings.Contains(query, "container_memory_working_set_bytes") {
+			values = memory
+		}
+		var samples []string
+		for namespace, v := range values {
+			samples = append(samples, `{"metric":{"namespace":"`+namespace+`"},"value":[1700000000,"`+v+`"]}`)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[` + strings.Join(samples, ",") + `]}}`))
+	}))
+	t.Cleanup(srv.Close)
+	p, err := NewPrometheus(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, &queries
+}
+
+func TestPrometheus_Usage(t *testing.T) {
+	p, queries := fakePrometheus(t, map[string]string{"iaf-abc": "1.5"}, map[string]string{"iaf-abc": "12"})
+	usage, err := p.Usage(context.Background(), "iaf-abc", "web", 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.CPUCoreHours != 1.5 || usage.MemoryGBHours != 12 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+	for _, q := range *queries {
+		if !strings.Contains(q, `namespace="iaf-abc"`) || !strings.Contains(q, `pod=~"web-[a-z0-9]+-[a-z0-9]+"`) || !strings.Contains(q, "[1d") {
+			t.Errorf("unexpected query %q", q)
+		}
+	}
+}
+
+func TestPrometheus_DailyUsage(t *testing.T) {
+	p, queries := fakePrometheus(t, map[string]string{"iaf-abc": "2"}, map[string]string{"iaf-abc": "4"})
+	p.now = func() time.Time { return time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC) }
+
+	usage, err := p.DailyUsage(context.Background(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	days := usage["iaf-abc"]
+	if len(days) != 3 {
+		t.Fatalf("expected 3 days, got %d", len(days))
+	}
+	if !days[0].Day.Equal(time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)) || !days[2].Day.Equal(time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected days %v .. %v", days[0].Day, days[2].Day)
+	}
+	if days[1].CPUCoreHours != 2 || days[1].MemoryGBHours != 4 {
+		t.Errorf("unexpected usage %+v", days[1])
+	}
+	// Today is counted up to now.
+	if last := (*queries)[len(*queries)-1]; !strings.Contains(last, "[12h") || !strings.Contains(last, `namespace=~"iaf-[0-9a-f]{32}"`) {
+		t.Errorf("unexpected query for today %q", last)
+	}
+
+	if _, err := p.DailyUsage(context.Background(), 32); err == nil {
+		t.Error("expected an error for more than 31 days")
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 24 * time.Hour, false},
+		{"1h", time.Hour, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"30m", 0, true},
+		{"31d", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tc := range tests {
+		got, err := ParseWindow(tc.in)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("ParseWindow(%q) = %v, %v", tc.in, got, err)
+		}
+	}
+}
+
+func TestRates_Cost(t *testing.T) {
+	rates := Rates{CPUCoreHour: 0.04, MemoryGBHour: 0.005}
+	if got := rates.Cost(Usage{CPUCoreHours: 10, MemoryGBHours: 100}); got != 0.9 {
+		t.Errorf("expected 0.9, got %v", got)
+	}
+}
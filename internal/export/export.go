@@ -0,0 +1,247 @@
+// Package export renders the Kubernetes objects behind an Application as
+// plain manifests or a Helm chart skeleton, so a team can take an app off
+// the platform and manage it with their own GitOps tooling.
+//
+// Objects are read from the cluster rather than rebuilt, so the export
+// reflects exactly what is running, including the built image. Secrets are
+// never exported; the names of the Secrets the objects reference are
+// reported instead so they can be recreated out of band.
+package export
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// Formats lists the supported export formats.
+var Formats = []string{"yaml", "helm"}
+
+var (
+	deploymentGVK = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	serviceGVK    = schema.GroupVersionKind{Version: "v1", Kind: "Service"}
+)
+
+// droppedAnnotations are set by controllers or kubectl and carry no intent.
+var droppedAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"deployment.kubernetes.io/revision",
+}
+
+// Bundle is the set of cleaned objects backing one Application.
+type Bundle struct {
+	App     *iafv1alpha1.Application
+	Objects []*unstructured.Unstructured
+	// Secrets names the Secrets referenced by Objects. Their contents are not exported.
+	Secrets []string
+}
+
+// Collect reads the objects the controller created for app. Objects whose
+// CRD is not installed (e.g. cert-manager absent) are skipped.
+func Collect(ctx context.Context, c client.Client, app *iafv1alpha1.Application) (*Bundle, error) {
+	type ref struct {
+		gvk  schema.GroupVersionKind
+		name string
+	}
+	// Ordered so that dependencies come before the objects using them.
+	var refs []ref
+	if app.Spec.Git != nil || app.Spec.Blob != "" {
+		refs = append(refs, ref{iafk8s.KpackImageGVK, app.Name})
+	}
+	for _, bms := range app.Spec.BoundManagedServices {
+		refs = append(refs, ref{iafk8s.CNPGClusterGVK, bms.ServiceName})
+	}
+	refs = append(refs,
+		ref{iafk8s.TraefikMiddlewareGVK, iafk8s.AuthMiddlewareName(app.Name)},
+		ref{iafk8s.TraefikMiddlewareGVK, iafk8s.IPAllowListMiddlewareName(app.Name)},
+		ref{iafk8s.TraefikMiddlewareGVK, iafk8s.RateLimitMiddlewareName(app.Name)},
+		ref{deploymentGVK, app.Name},
+		ref{serviceGVK, app.Name},
+		ref{iafk8s.CertificateGVK, app.Name},
+		ref{iafk8s.IngressRouteGVKFor(app), app.Name},
+	)
+
+	b := &Bundle{App: app}
+	secrets := map[string]bool{}
+	for _, r := range refs {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(r.gvk)
+		if err := c.Get(ctx, types.NamespacedName{Name: r.name, Namespace: app.Namespace}, obj); err != nil {
+			if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, fmt.Errorf("getting %s %q: %w", r.gvk.Kind, r.name, err)
+		}
+		clean(obj)
+		collectSecretRefs(obj.Object, "", secrets)
+		b.Objects = append(b.Objects, obj)
+	}
+	for name := range secrets {
+		b.Secrets = append(b.Secrets, name)
+	}
+	slices.Sort(b.Secrets)
+	return b, nil
+}
+
+// clean strips status and cluster-assigned fields so the object can be
+// applied to another cluster or namespace.
+func clean(obj *unstructured.Unstructured) {
+	kept := map[string]any{"name": obj.GetName()}
+	if labels := obj.GetLabels(); len(labels) > 0 {
+		kept["labels"] = toAnyMap(labels)
+	}
+	annotations := obj.GetAnnotations()
+	for _, a := range droppedAnnotations {
+		delete(annotations, a)
+	}
+	if len(annotations) > 0 {
+		kept["annotations"] = toAnyMap(annotations)
+	}
+	obj.Object["metadata"] = kept
+	delete(obj.Object, "status")
+
+	switch obj.GroupVersionKind() {
+	case serviceGVK:
+		for _, f := range []string{"clusterIP", "clusterIPs", "ipFamilies", "ipFamilyPolicy"} {
+			unstructured.RemoveNestedField(obj.Object, "spec", f)
+		}
+	case deploymentGVK:
+		unstructured.RemoveNestedField(obj.Object, "spec", "template", "metadata", "creationTimestamp")
+	}
+}
+
+func toAnyMap(m map[string]string) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// collectSecretRefs records Secret names referenced anywhere in v: env
+// secretKeyRef and envFrom secretRef names, volume and TLS secretName, and
+// Traefik basicAuth secret.
+func collectSecretRefs(v any, parent string, out map[string]bool) {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			if s, ok := child.(string); ok && s != "" {
+				switch {
+				case k == "secretName",
+					k == "name" && (parent == "secretKeyRef" || parent == "secretRef"),
+					k == "secret" && parent == "basicAuth":
+					out[s] = true
+				}
+			}
+			collectSecretRefs(child, k, out)
+		}
+	case []any:
+		for _, child := range t {
+			collectSecretRefs(child, parent, out)
+		}
+	}
+}
+
+// YAML renders the bundle as a multi-document YAML stream without
+// namespaces, ready for kubectl apply -n or a GitOps repository.
+func (b *Bundle) YAML() (string, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Exported from IAF application %q.\n", b.App.Name)
+	if len(b.Secrets) > 0 {
+		fmt.Fprintf(&sb, "# Secrets referenced but not included (recreate them before applying): %s\n", strings.Join(b.Secrets, ", "))
+	}
+	for _, obj := range b.Objects {
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return "", fmt.Errorf("marshalling %s %q: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		sb.WriteString("---\n")
+		sb.Write(data)
+	}
+	return sb.String(), nil
+}
+
+const (
+	imagePlaceholder    = "__IAF_VALUES_IMAGE__"
+	replicasPlaceholder = "__IAF_VALUES_REPLICAS__"
+)
+
+// HelmChart renders the bundle as a Helm chart skeleton keyed by file path
+// relative to the chart directory. The image, replica count, and hostname
+// are lifted into values.yaml; everything else is templated verbatim.
+func (b *Bundle) HelmChart() (map[string]string, error) {
+	values := map[string]any{}
+	host := b.host()
+	if host != "" {
+		values["host"] = host
+	}
+
+	files := map[string]string{}
+	for _, src := range b.Objects {
+		obj := src.DeepCopy()
+		if obj.GroupVersionKind() == deploymentGVK {
+			if replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas"); found {
+				values["replicaCount"] = replicas
+				_ = unstructured.SetNestedField(obj.Object, replicasPlaceholder, "spec", "replicas")
+			}
+			containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+			for _, c := range containers {
+				if m, ok := c.(map[string]any); ok && m["name"] == "app" {
+					values["image"] = m["image"]
+					m["image"] = imagePlaceholder
+				}
+			}
+			_ = unstructured.SetNestedSlice(obj.Object, containers, "spec", "template", "spec", "containers")
+		}
+
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return nil, fmt.Errorf("marshalling %s %q: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		text := strings.NewReplacer(
+			imagePlaceholder, "{{ .Values.image }}",
+			replicasPlaceholder, "{{ .Values.replicaCount }}",
+		).Replace(string(data))
+		if host != "" {
+			text = strings.ReplaceAll(text, host, "{{ .Values.host }}")
+		}
+		name := path.Join("templates", strings.ToLower(obj.GetKind())+"-"+obj.GetName()+".yaml")
+		files[name] = text
+	}
+
+	valuesYAML, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling values: %w", err)
+	}
+	header := "# Default values exported from IAF application " + b.App.Name + ".\n"
+	if len(b.Secrets) > 0 {
+		header += "# The chart references these Secrets, which are not included: " + strings.Join(b.Secrets, ", ") + "\n"
+	}
+	files["values.yaml"] = header + string(valuesYAML)
+	files["Chart.yaml"] = fmt.Sprintf("apiVersion: v2\nname: %s\ndescription: Exported from IAF application %s\ntype: application\nversion: 0.1.0\n", b.App.Name, b.App.Name)
+	return files, nil
+}
+
+// host returns the hostname the app is served on.
+func (b *Bundle) host() string {
+	if b.App.Spec.Host != "" {
+		return b.App.Spec.Host
+	}
+	if u, err := url.Parse(b.App.Status.URL); err == nil {
+		return u.Hostname()
+	}
+	return ""
+}
@@ -0,0 +1,162 @@
+package export
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// setupExportTest returns a Running git app with basic auth and a bound
+// database, plus the objects the controller would have created for it.
+func setupExportTest(t *testing.T) (client.Client, *iafv1alpha1.Application) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+
+	const ns = "iaf-abc"
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: ns},
+		Spec: iafv1alpha1.ApplicationSpec{
+			Git:                  &iafv1alpha1.GitSource{URL: "https://github.com/org/web", Revision: "main"},
+			Authentication:       iafv1alpha1.AuthenticationBasic,
+			BoundManagedServices: []iafv1alpha1.BoundManagedService{{ServiceName: "db", SecretName: "db-app"}},
+		},
+		Status: iafv1alpha1.ApplicationStatus{URL: "https://web.apps.example.com"},
+	}
+	replicas := int32(2)
+	meta := metav1.ObjectMeta{
+		Name: "web", Namespace: ns, UID: "1234", ResourceVersion: "42",
+		Labels:      map[string]string{"iaf.io/application": "web"},
+		Annotations: map[string]string{"deployment.kubernetes.io/revision": "3"},
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: iafv1alpha1.GroupVersion.String(), Kind: "Application", Name: "web", UID: "app-uid",
+		}},
+	}
+	dep := &appsv1.Deployment{
+		ObjectMeta: meta,
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"iaf.io/application": "web"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"iaf.io/application": "web"}},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name:  "app",
+					Image: "registry.local/iaf/web@sha256:abc",
+					Env: []corev1.EnvVar{{Name: "PGPASSWORD", ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "db-app"}, Key: "password"},
+					}}},
+				}}},
+			},
+		},
+		Status: appsv1.DeploymentStatus{AvailableReplicas: 2},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: *meta.DeepCopy(),
+		Spec: corev1.ServiceSpec{
+			ClusterIP: "10.0.0.7",
+			Selector:  map[string]string{"iaf.io/application": "web"},
+			Ports:     []corev1.ServicePort{{Port: 8080}},
+		},
+	}
+	svc.Annotations = nil
+	route := iafk8s.BuildIngressRoute(app, "apps.example.com", true)
+	middleware := iafk8s.BuildBasicAuthMiddleware(app)
+	kpack := iafk8s.BuildKpackImage(app, "default", "registry.local/iaf", iafk8s.BuildCache{}, iafk8s.Proxy{})
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(iafk8s.CNPGClusterGVK)
+	cluster.SetName("db")
+	cluster.SetNamespace(ns)
+	_ = unstructured.SetNestedField(cluster.Object, int64(1), "spec", "instances")
+
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(app, dep, svc, route, middleware, kpack, cluster).
+		Build()
+	return c, app
+}
+
+func TestCollect(t *testing.T) {
+	c, app := setupExportTest(t)
+	b, err := Collect(context.Background(), c, app)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var kinds []string
+	for _, obj := range b.Objects {
+		kinds = append(kinds, obj.GetKind())
+	}
+	// No Certificate exists, so it is skipped.
+	want := "Image,Cluster,Middleware,Deployment,Service,IngressRoute"
+	if got := strings.Join(kinds, ","); got != want {
+		t.Errorf("kinds = %s, want %s", got, want)
+	}
+
+	wantSecrets := []string{"db-app", iafk8s.BasicAuthSecretName("web"), iafk8s.TLSSecretName("web")}
+	for _, s := range wantSecrets {
+		found := false
+		for _, got := range b.Secrets {
+			found = found || got == s
+		}
+		if !found {
+			t.Errorf("Secrets = %v, missing %q", b.Secrets, s)
+		}
+	}
+
+	out, err := b.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, banned := range []string{"resourceVersion", "uid:", "namespace:", "ownerReferences", "clusterIP", "status:", "deployment.kubernetes.io/revision"} {
+		if strings.Contains(out, banned) {
+			t.Errorf("exported YAML contains %q:\n%s", banned, out)
+		}
+	}
+	for _, want := range []string{"kind: Deployment", "registry.local/iaf/web@sha256:abc", "iaf.io/application: web", "# Secrets referenced but not included"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("exported YAML missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestHelmChart(t *testing.T) {
+	c, app := setupExportTest(t)
+	b, err := Collect(context.Background(), c, app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := b.HelmChart()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(files["Chart.yaml"], "name: web") {
+		t.Errorf("Chart.yaml = %q", files["Chart.yaml"])
+	}
+	values := files["values.yaml"]
+	for _, want := range []string{"image: registry.local/iaf/web@sha256:abc", "replicaCount: 2", "host: web.apps.example.com", "db-app"} {
+		if !strings.Contains(values, want) {
+			t.Errorf("values.yaml missing %q:\n%s", want, values)
+		}
+	}
+
+	deployment := files["templates/deployment-web.yaml"]
+	if !strings.Contains(deployment, "image: {{ .Values.image }}") || !strings.Contains(deployment, "replicas: {{ .Values.replicaCount }}") {
+		t.Errorf("deployment template not parameterized:\n%s", deployment)
+	}
+	route := files["templates/ingressroute-web.yaml"]
+	if !strings.Contains(route, "{{ .Values.host }}") || strings.Contains(route, "web.apps.example.com") {
+		t.Errorf("route template does not use .Values.host:\n%s", route)
+	}
+}
@@ -0,0 +1,96 @@
+// Package features lists the optional platform capabilities and whether
+// this installation turns them on, so agents can check before calling a
+// tool instead of discovering a disabled feature by failing calls.
+package features
+
+import (
+	"github.com/dlapiduz/iaf/internal/apierror"
+)
+
+// Name identifies a feature. Names are stable snake_case identifiers.
+type Name string
+
+// Optional features, in the order listings show them.
+const (
+	TLS                  Name = "tls"
+	BackendTLS           Name = "backend_tls"
+	OAuthProxy           Name = "oauth_proxy"
+	CustomDNS            Name = "custom_dns"
+	Idling               Name = "idling"
+	ManagedServices      Name = "managed_services"
+	GitHub               Name = "github"
+	Logs                 Name = "logs"
+	Traces               Name = "traces"
+	Dashboards           Name = "dashboards"
+	Uptime               Name = "uptime"
+	Costs                Name = "costs"
+	NamespaceCredentials Name = "namespace_credentials"
+)
+
+// Feature describes a feature and whether it is on.
+type Feature struct {
+	Name    Name `json:"name"`
+	Enabled bool `json:"enabled"`
+	// Description says what the feature gives apps and agents.
+	Description string `json:"description"`
+	// Settings are the configuration an operator sets to turn the feature
+	// on.
+	Settings string `json:"settings"`
+}
+
+var known = []Feature{
+	{Name: TLS, Description: "HTTPS certificates for app URLs from cert-manager", Settings: "IAF_TLS_ISSUER"},
+	{Name: BackendTLS, Description: "encrypted traffic from the ingress to apps (deploy_app backend_tls)", Settings: "IAF_BACKEND_TLS_ISSUER"},
+	{Name: OAuthProxy, Description: "single sign-on in front of apps (authentication 'oauth-proxy')", Settings: "IAF_OIDC_ISSUER_URL, IAF_OIDC_CLIENT_ID and IAF_OIDC_CLIENT_SECRET"},
+	{Name: CustomDNS, Description: "host aliases and resolver settings for apps (deploy_app host_aliases and dns_config)", Settings: "IAF_ALLOW_CUSTOM_DNS"},
+	{Name: Idling, Description: "scaling idle apps to zero and waking them on the next request (deploy_app idle_timeout)", Settings: "IAF_PROMETHEUS_URL, IAF_WAKE_SECRET and IAF_SUSPENDED_PAGE_SERVICE"},
+	{Name: ManagedServices, Description: "managed PostgreSQL databases (provision_service)", Settings: "IAF_POSTGRES_IMAGE"},
+	{Name: GitHub, Description: "GitHub repositories for apps (setup_github_repo)", Settings: "IAF_GITHUB_TOKEN or IAF_GITHUB_APP_ID, and IAF_GITHUB_ORG, with IAF_OFFLINE off"},
+	{Name: Logs, Description: "Loki log links in app_status", Settings: "IAF_GRAFANA_URL and IAF_GRAFANA_LOKI_UID"},
+	{Name: Traces, Description: "Tempo trace links in app_status", Settings: "IAF_GRAFANA_URL and IAF_GRAFANA_TEMPO_UID"},
+	{Name: Dashboards, Description: "Grafana metrics dashboard links in app_status", Settings: "IAF_GRAFANA_URL and IAF_GRAFANA_DASHBOARD_UID"},
+	{Name: Uptime, Description: "external uptime checks of app URLs (deploy_app uptime_check_path)", Settings: "IAF_BLACKBOX_EXPORTER"},
+	{Name: Costs, Description: "cost estimates (session_cost and app_cost)", Settings: "IAF_PROMETHEUS_URL"},
+	{Name: NamespaceCredentials, Description: "kubeconfigs for the session namespace (get_namespace_credentials)", Settings: "IAF_KUBE_API_SERVER"},
+}
+
+// Flags records which features are on. A nil Flags means the features are
+// not known, and Off reports none of them as off.
+type Flags map[Name]bool
+
+// Enabled reports whether feature name is on.
+func (f Flags) Enabled(name Name) bool {
+	return f[name]
+}
+
+// Off reports whether feature name is known to be off.
+func (f Flags) Off(name Name) bool {
+	return f != nil && !f[name]
+}
+
+// List returns every feature with whether it is on.
+func (f Flags) List() []Feature {
+	list := make([]Feature, len(known))
+	for i, feature := range known {
+		feature.Enabled = f[feature.Name]
+		list[i] = feature
+	}
+	return list
+}
+
+// Disabled returns the capability_disabled error for a request that needs
+// feature name.
+func Disabled(name Name) *apierror.Error {
+	settings := ""
+	for _, feature := range known {
+		if feature.Name == name {
+			settings = feature.Settings
+		}
+	}
+	err := apierror.Validation(apierror.CodeCapabilityDisabled, "the %s feature is not enabled on this platform", name).
+		WithDetails(map[string]string{"feature": string(name)})
+	if settings == "" {
+		return err
+	}
+	return err.WithHint("leave out what needs it, or ask the platform operator to set " + settings + "; get_capabilities lists the features this platform offers")
+}
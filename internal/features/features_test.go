@@ -0,0 +1,38 @@
+package features
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dlapiduz/iaf/internal/apierror"
+)
+
+func TestFlags(t *testing.T) {
+	var unknown Flags
+	if unknown.Off(TLS) || unknown.Enabled(TLS) {
+		t.Error("expected nil flags to report a feature neither on nor off")
+	}
+
+	flags := Flags{TLS: true}
+	if !flags.Enabled(TLS) || flags.Off(TLS) || !flags.Off(GitHub) {
+		t.Errorf("unexpected flags %v", flags)
+	}
+	list := flags.List()
+	if len(list) != len(known) || list[0].Name != TLS || !list[0].Enabled || list[1].Enabled {
+		t.Errorf("unexpected list %+v", list)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	var e *apierror.Error
+	if err := error(Disabled(Costs)); !errors.As(err, &e) {
+		t.Fatalf("expected an *apierror.Error, got %T", err)
+	}
+	if e.Code != apierror.CodeCapabilityDisabled || e.Retryable || !strings.Contains(e.Hint, "IAF_PROMETHEUS_URL") {
+		t.Errorf("unexpected error %+v", e)
+	}
+	if apierror.HTTPStatus(e) != 501 {
+		t.Errorf("expected 501, got %d", apierror.HTTPStatus(e))
+	}
+}
@@ -0,0 +1,154 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// appJWTLifetime is how long the JWT a GitHub App signs to request an
+	// installation token is valid; GitHub accepts at most 10 minutes.
+	appJWTLifetime = 9 * time.Minute
+	// appClockSkew backdates the JWT so a server clock slightly ahead of
+	// GitHub's is not rejected.
+	appClockSkew = time.Minute
+	// installationTokenRefresh is how long before it expires an
+	// installation token is replaced, so no request carries one that
+	// expires in flight.
+	installationTokenRefresh = 5 * time.Minute
+)
+
+// appAuth authenticates as one installation of a GitHub App. It exchanges a
+// JWT signed with the App's private key for an installation token, which
+// lasts an hour and carries only the permissions granted to the App, and
+// replaces it shortly before it expires.
+type appAuth struct {
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewAppClient creates an HTTPClient that authenticates as installation
+// installationID of the GitHub App appID, with the App's PEM-encoded
+// private key. Required permissions: Administration (read and write) and
+// Contents (read and write) on the repositories of the org.
+func NewAppClient(appID, installationID int64, privateKeyPEM []byte) (*HTTPClient, error) {
+	if appID <= 0 || installationID <= 0 {
+		return nil, errors.New("GitHub App ID and installation ID must be positive")
+	}
+	key, err := parseAppKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	c := NewHTTPClient("")
+	c.app = &appAuth{appID: appID, installationID: installationID, key: key}
+	return c, nil
+}
+
+// parseAppKey parses a GitHub App private key, which GitHub issues as a
+// PKCS #1 PEM block; PKCS #8 is accepted too.
+func parseAppKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("GitHub App private key is not PEM-encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GitHub App private key must be an RSA key")
+	}
+	return key, nil
+}
+
+// installationToken returns the current installation token, requesting a
+// new one from c's API when there is none or it is about to expire.
+func (a *appAuth) installationToken(ctx context.Context, c *HTTPClient) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	if a.token != "" && now.Before(a.expires.Add(-installationTokenRefresh)) {
+		return a.token, nil
+	}
+	jwt, err := a.jwt(now)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/app/installations/%d/access_tokens", c.baseURL, a.installationID), nil)
+	if err != nil {
+		return "", fmt.Errorf("building request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("GitHub API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", c.apiError(resp, "create installation token")
+	}
+	var result struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decoding installation token response: %w", err)
+	}
+	if result.Token == "" {
+		return "", errors.New("create installation token: GitHub returned no token")
+	}
+	a.token, a.expires = result.Token, result.ExpiresAt
+	return a.token, nil
+}
+
+// forget drops the cached installation token, such as after GitHub
+// rejected it because the installation was suspended or its key rotated.
+func (a *appAuth) forget() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = ""
+}
+
+// jwt returns the RS256 JWT identifying the App, valid from shortly before
+// now for appJWTLifetime.
+func (a *appAuth) jwt(now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iat": now.Add(-appClockSkew).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(a.appID, 10),
+	})
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("signing GitHub App JWT: %w", err)
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
@@ -0,0 +1,177 @@
+package github_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	iafgithub "github.com/dlapiduz/iaf/internal/github"
+)
+
+// TestAppClient verifies a GitHub App client signs a JWT for its App,
+// exchanges it for an installation token, reuses the token until shortly
+// before it expires, and requests a new one after GitHub rejects it.
+func TestAppClient(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	var (
+		mu       sync.Mutex
+		issued   int
+		lifetime = time.Hour
+		reject   bool
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		auth := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if r.URL.Path == "/app/installations/42/access_tokens" {
+			if r.Method != http.MethodPost {
+				t.Errorf("expected POST, got %s", r.Method)
+			}
+			if iss, err := verifyJWT(&key.PublicKey, auth); err != nil || iss != "7" {
+				t.Errorf("expected a valid JWT issued by the App, got iss %q, %v", iss, err)
+			}
+			issued++
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]any{
+				"token":      fmt.Sprintf("ghs_%d", issued),
+				"expires_at": time.Now().Add(lifetime).UTC().Format(time.RFC3339),
+			})
+			return
+		}
+		if auth != fmt.Sprintf("ghs_%d", issued) || reject {
+			reject = false
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Bad credentials"})
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{"name": "my-repo"})
+	}))
+	defer srv.Close()
+
+	c, err := iafgithub.NewAppClient(7, 42, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetBaseURL(srv.URL)
+	ctx := context.Background()
+	create := func() error {
+		_, err := c.CreateRepo(ctx, "acme", "my-repo", true)
+		return err
+	}
+
+	for range 2 {
+		if err := create(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if issued != 1 {
+		t.Errorf("expected the installation token to be reused, got %d tokens", issued)
+	}
+
+	mu.Lock()
+	reject = true
+	mu.Unlock()
+	if err := create(); err == nil {
+		t.Error("expected the rejected call to fail")
+	}
+	if err := create(); err != nil {
+		t.Fatalf("expected a new token after a rejection, got %v", err)
+	}
+	if issued != 2 {
+		t.Errorf("expected a second token after a rejection, got %d tokens", issued)
+	}
+
+	mu.Lock()
+	lifetime = 2 * time.Minute
+	mu.Unlock()
+	c, _ = iafgithub.NewAppClient(7, 42, keyPEM)
+	c.SetBaseURL(srv.URL)
+	for range 2 {
+		if err := create(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if issued != 4 {
+		t.Errorf("expected a token about to expire to be replaced, got %d tokens", issued)
+	}
+}
+
+func TestNewAppClient_Invalid(t *testing.T) {
+	ec, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(ec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	for name, tc := range map[string]struct {
+		appID, installationID int64
+		key                   string
+	}{
+		"no app ID":          {0, 42, ecKey},
+		"no installation ID": {7, 0, ecKey},
+		"not PEM":            {7, 42, "not a key"},
+		"not RSA":            {7, 42, ecKey},
+	} {
+		if _, err := iafgithub.NewAppClient(tc.appID, tc.installationID, []byte(tc.key)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// verifyJWT checks the RS256 signature and lifetime of token and returns
+// its issuer.
+func verifyJWT(pub *rsa.PublicKey, token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed JWT")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+		return "", err
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", err
+	}
+	var claims struct {
+		Iat int64  `json:"iat"`
+		Exp int64  `json:"exp"`
+		Iss string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", err
+	}
+	now := time.Now().Unix()
+	if claims.Iat > now || claims.Exp <= now || claims.Exp-claims.Iat > 600 {
+		return "", fmt.Errorf("JWT not valid now or for too long: iat %d, exp %d", claims.Iat, claims.Exp)
+	}
+	return claims.Iss, nil
+}
@@ -1,6 +1,7 @@
-// Package github provides a minimal client for the GitHub REST API v3.
-// Only the operations needed by the setup_github_repo MCP tool are
-// implemented. The Client interface is kept narrow so tests can inject
+// Package github provides a minimal client for the GitHub REST API v3,
+// authenticated with a personal access token or as a GitHub App
+// installation. Only the operations needed by the setup_github_repo MCP
+// tool are implemented. The Client interface is kept narrow so tests can inject
 // a mock without a real API call.
 package github
 
@@ -41,10 +42,12 @@ type Client interface {
 	CreateFile(ctx context.Context, owner, repo, path, message string, content []byte) error
 }
 
-// HTTPClient implements Client using GitHub REST API v3 and a Bearer token.
+// HTTPClient implements Client using GitHub REST API v3 and a Bearer token:
+// a personal access token, or the installation token of a GitHub App.
 type HTTPClient struct {
 	token   string
-	baseURL string // overridable for tests; default "https://api.github.com"
+	app     *appAuth // set when authenticating as a GitHub App
+	baseURL string   // overridable for tests; default "https://api.github.com"
 	http    *http.Client
 }
 
@@ -173,7 +176,13 @@ func (c *HTTPClient) doJSON(ctx context.Context, method, path string, body []byt
 	if err != nil {
 		return nil, fmt.Errorf("building request: %w", err)
 	}
-	req.Header.Set("Authorization", "Bearer "+c.token)
+	token := c.token
+	if c.app != nil {
+		if token, err = c.app.installationToken(ctx, c); err != nil {
+			return nil, err
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/vnd.github+json")
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
@@ -182,6 +191,10 @@ func (c *HTTPClient) doJSON(ctx context.Context, method, path string, body []byt
 	if err != nil {
 		return nil, fmt.Errorf("GitHub API request failed: %w", err)
 	}
+	if resp.StatusCode == http.StatusUnauthorized && c.app != nil {
+		// The next call requests a fresh installation token.
+		c.app.forget()
+	}
 	return resp, nil
 }
 
@@ -0,0 +1,269 @@
+package github
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// Kinds of files setup_github_repo can seed in a new repository besides
+// the starter CI workflow.
+const (
+	TemplateCodeowners  = "codeowners"
+	TemplatePullRequest = "pull_request_template"
+	TemplateIssues      = "issue_templates"
+	TemplateDependabot  = "dependabot"
+	TemplateLicense     = "license"
+)
+
+// TemplateKinds lists every template kind in the order files are committed.
+var TemplateKinds = []string{TemplateCodeowners, TemplatePullRequest, TemplateIssues, TemplateDependabot, TemplateLicense}
+
+// maxTemplateSize bounds a single operator template file.
+const maxTemplateSize = 64 << 10
+
+// RepoTemplate is one file seeded in a new repository. Content is a
+// text/template executed with TemplateData.
+type RepoTemplate struct {
+	Kind    string
+	Path    string
+	Content string
+}
+
+// TemplateData is what repo templates can refer to.
+type TemplateData struct {
+	// Org and Repo name the new repository.
+	Org  string
+	Repo string
+	// Year is the current year, for LICENSE headers.
+	Year int
+	// Owners are the CODEOWNERS entries, e.g. "@org/team" or "@user".
+	Owners []string
+}
+
+// RenderedFile is a repo template ready to commit.
+type RenderedFile struct {
+	Kind    string
+	Path    string
+	Content []byte
+}
+
+// RepoTemplates holds the repo templates by kind. A nil *RepoTemplates
+// holds the defaults.
+type RepoTemplates struct {
+	byKind map[string][]RepoTemplate
+}
+
+// Built-in templates. There is no default LICENSE: which license a
+// repository gets is the organisation's call, so the operator supplies it.
+const (
+	defaultCodeowners = `# Owners review every change to the files they own.
+{{- if .Owners}}
+* {{join .Owners " "}}
+{{- end}}
+`
+	defaultPullRequest = `## What
+
+<!-- What does this change do, in one or two sentences? -->
+
+## Why
+
+<!-- What was broken or missing without it? -->
+
+## Testing
+
+<!-- How did you verify it? CI must pass before merge. -->
+`
+	defaultBugReport = `---
+name: Bug report
+about: Something in {{.Repo}} does not work as expected
+labels: bug
+---
+
+## What happened
+
+## What you expected
+
+## How to reproduce
+`
+	defaultFeatureRequest = `---
+name: Feature request
+about: Suggest a change to {{.Repo}}
+labels: enhancement
+---
+
+## Problem
+
+## Proposed change
+`
+	defaultDependabot = `version: 2
+updates:
+  - package-ecosystem: github-actions
+    directory: /
+    schedule:
+      interval: weekly
+`
+)
+
+// DefaultRepoTemplates returns the built-in repo templates.
+func DefaultRepoTemplates() *RepoTemplates {
+	return &RepoTemplates{byKind: map[string][]RepoTemplate{
+		TemplateCodeowners:  {{Kind: TemplateCodeowners, Path: ".github/CODEOWNERS", Content: defaultCodeowners}},
+		TemplatePullRequest: {{Kind: TemplatePullRequest, Path: ".github/pull_request_template.md", Content: defaultPullRequest}},
+		TemplateIssues: {
+			{Kind: TemplateIssues, Path: ".github/ISSUE_TEMPLATE/bug_report.md", Content: defaultBugReport},
+			{Kind: TemplateIssues, Path: ".github/ISSUE_TEMPLATE/feature_request.md", Content: defaultFeatureRequest},
+		},
+		TemplateDependabot: {{Kind: TemplateDependabot, Path: ".github/dependabot.yml", Content: defaultDependabot}},
+	}}
+}
+
+// LoadRepoTemplates reads operator templates from dir, laid out at the
+// paths they get in the repository (e.g. dir/.github/CODEOWNERS,
+// dir/LICENSE). The templates of a kind found in dir replace the built-in
+// ones of that kind; other kinds keep their defaults. An empty dir returns
+// the defaults.
+func LoadRepoTemplates(dir string) (*RepoTemplates, error) {
+	t := DefaultRepoTemplates()
+	if dir == "" {
+		return t, nil
+	}
+	loaded := map[string][]RepoTemplate{}
+	err := fs.WalkDir(os.DirFS(dir), ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		kind := templateKind(p)
+		if kind == "" {
+			return fmt.Errorf("%s: not a CODEOWNERS, pull request or issue template, dependabot config or LICENSE", p)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > maxTemplateSize {
+			return fmt.Errorf("%s: larger than %d bytes", p, maxTemplateSize)
+		}
+		content, err := os.ReadFile(path.Join(dir, p))
+		if err != nil {
+			return err
+		}
+		if _, err := parseTemplate(p, string(content)); err != nil {
+			return err
+		}
+		loaded[kind] = append(loaded[kind], RepoTemplate{Kind: kind, Path: p, Content: string(content)})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading github templates from %s: %w", dir, err)
+	}
+	for kind, templates := range loaded {
+		t.byKind[kind] = templates
+	}
+	return t, nil
+}
+
+// templateKind returns the kind of the template at repository path p, or
+// "" when setup_github_repo does not seed such a file.
+func templateKind(p string) string {
+	lower := strings.ToLower(p)
+	switch {
+	case lower == "codeowners", lower == ".github/codeowners", lower == "docs/codeowners":
+		return TemplateCodeowners
+	case lower == ".github/pull_request_template.md", lower == "pull_request_template.md":
+		return TemplatePullRequest
+	case strings.HasPrefix(lower, ".github/issue_template/") && !strings.Contains(strings.TrimPrefix(lower, ".github/issue_template/"), "/"):
+		return TemplateIssues
+	case lower == ".github/dependabot.yml", lower == ".github/dependabot.yaml":
+		return TemplateDependabot
+	case lower == "license", lower == "license.md", lower == "license.txt":
+		return TemplateLicense
+	}
+	return ""
+}
+
+// Kinds returns the template kinds that have at least one template, in
+// the order of TemplateKinds.
+func (t *RepoTemplates) Kinds() []string {
+	if t == nil {
+		t = DefaultRepoTemplates()
+	}
+	var kinds []string
+	for _, kind := range TemplateKinds {
+		if len(t.byKind[kind]) > 0 {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+// Paths returns the repository paths of the templates of kind.
+func (t *RepoTemplates) Paths(kind string) []string {
+	if t == nil {
+		t = DefaultRepoTemplates()
+	}
+	var paths []string
+	for _, tmpl := range t.byKind[kind] {
+		paths = append(paths, tmpl.Path)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// Render executes the templates of kind with data. It returns an error when
+// no template of kind is configured or a template renders to nothing, e.g.
+// the default CODEOWNERS without owners.
+func (t *RepoTemplates) Render(kind string, data TemplateData) ([]RenderedFile, error) {
+	if t == nil {
+		t = DefaultRepoTemplates()
+	}
+	templates := t.byKind[kind]
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("no %s template is configured on this platform", kind)
+	}
+	var files []RenderedFile
+	for _, tmpl := range templates {
+		parsed, err := parseTemplate(tmpl.Path, tmpl.Content)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := parsed.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("rendering %s: %w", tmpl.Path, err)
+		}
+		if !hasContent(kind, buf.String()) {
+			return nil, fmt.Errorf("%s renders no entries", tmpl.Path)
+		}
+		files = append(files, RenderedFile{Kind: kind, Path: tmpl.Path, Content: buf.Bytes()})
+	}
+	slices.SortFunc(files, func(a, b RenderedFile) int { return strings.Compare(a.Path, b.Path) })
+	return files, nil
+}
+
+func parseTemplate(name, content string) (*template.Template, error) {
+	parsed, err := template.New(name).Funcs(template.FuncMap{"join": strings.Join}).Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", name, err)
+	}
+	return parsed, nil
+}
+
+// hasContent reports whether s has a line that is not blank or, in a
+// CODEOWNERS file, a comment.
+func hasContent(kind, s string) bool {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && (kind != TemplateCodeowners || !strings.HasPrefix(line, "#")) {
+			return true
+		}
+	}
+	return false
+}
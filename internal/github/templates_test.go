@@ -0,0 +1,86 @@
+package github_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	iafgithub "github.com/dlapiduz/iaf/internal/github"
+)
+
+func writeTemplate(t *testing.T, dir, path, content string) {
+	t.Helper()
+	full := filepath.Join(dir, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadRepoTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "LICENSE", "Copyright {{.Year}} {{.Org}}\n")
+	writeTemplate(t, dir, ".github/ISSUE_TEMPLATE/config.yml", "blank_issues_enabled: false\n")
+
+	templates, err := iafgithub.LoadRepoTemplates(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := iafgithub.TemplateKinds; !slices.Equal(templates.Kinds(), want) {
+		t.Errorf("kinds = %v, want %v", templates.Kinds(), want)
+	}
+	// Operator issue templates replace the built-in ones.
+	if got := templates.Paths(iafgithub.TemplateIssues); !slices.Equal(got, []string{".github/ISSUE_TEMPLATE/config.yml"}) {
+		t.Errorf("issue template paths = %v", got)
+	}
+
+	files, err := templates.Render(iafgithub.TemplateLicense, iafgithub.TemplateData{Org: "acme", Repo: "web", Year: 2026})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || string(files[0].Content) != "Copyright 2026 acme\n" {
+		t.Errorf("unexpected license %+v", files)
+	}
+}
+
+func TestLoadRepoTemplates_Invalid(t *testing.T) {
+	for name, tt := range map[string]struct{ path, content string }{
+		"unknown path":   {"README.md", "# readme"},
+		"nested issue":   {".github/ISSUE_TEMPLATE/sub/bug.md", "bug"},
+		"bad template":   {".github/CODEOWNERS", "* {{.Owners"},
+		"too large file": {"LICENSE", strings.Repeat("x", 64<<10+1)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTemplate(t, dir, tt.path, tt.content)
+			if _, err := iafgithub.LoadRepoTemplates(dir); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestRepoTemplates_Defaults(t *testing.T) {
+	var templates *iafgithub.RepoTemplates
+	if slices.Contains(templates.Kinds(), iafgithub.TemplateLicense) {
+		t.Error("expected no default license")
+	}
+	if _, err := templates.Render(iafgithub.TemplateCodeowners, iafgithub.TemplateData{}); err == nil {
+		t.Error("expected the default CODEOWNERS to need owners")
+	}
+	files, err := templates.Render(iafgithub.TemplateCodeowners, iafgithub.TemplateData{Owners: []string{"@acme/web", "@jo"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(files[0].Content), "\n* @acme/web @jo\n") {
+		t.Errorf("unexpected CODEOWNERS %q", files[0].Content)
+	}
+	files, err = templates.Render(iafgithub.TemplateIssues, iafgithub.TemplateData{Repo: "web"})
+	if err != nil || len(files) != 2 {
+		t.Fatalf("expected two issue templates, got %v, %v", files, err)
+	}
+}
@@ -0,0 +1,77 @@
+// Package grafana builds Grafana deep links for applications. Every part of
+// a link comes from platform configuration or from validated resource names,
+// never from agent input.
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+)
+
+// Config identifies the Grafana instance and datasources links point at.
+type Config struct {
+	// URL is the Grafana base URL, e.g. "https://grafana.example.com".
+	// No links are built when it is empty.
+	URL string
+	// LokiUID and TempoUID are the datasource UIDs used in Explore links.
+	LokiUID  string
+	TempoUID string
+	// DashboardUID is a dashboard with "namespace" and "app" template
+	// variables. The dashboard link is omitted when it is empty.
+	DashboardUID string
+}
+
+// Links are the deep links for one application. Unavailable links are empty.
+type Links struct {
+	LogExploreURL       string `json:"logExploreUrl,omitempty"`
+	TraceExploreURL     string `json:"traceExploreUrl,omitempty"`
+	MetricsDashboardURL string `json:"metricsDashboardUrl,omitempty"`
+}
+
+// AppLinks returns the deep links for the application name in namespace.
+func (c Config) AppLinks(namespace, name string) Links {
+	if c.URL == "" {
+		return Links{}
+	}
+	base := strings.TrimSuffix(c.URL, "/")
+	links := Links{
+		LogExploreURL: exploreURL(base, c.LokiUID, map[string]any{
+			"expr":      fmt.Sprintf(`{namespace=%q, pod=~%q}`, namespace, iafk8s.AppPodPattern(name)),
+			"queryType": "range",
+		}),
+		// The platform sets OTEL_SERVICE_NAME to the app name; the namespace
+		// keeps same-named apps of other sessions out of the results.
+		TraceExploreURL: exploreURL(base, c.TempoUID, map[string]any{
+			"query":     fmt.Sprintf(`{resource.service.name=%q && resource.k8s.namespace.name=%q}`, name, namespace),
+			"queryType": "traceql",
+		}),
+	}
+	if c.DashboardUID != "" {
+		params := url.Values{}
+		params.Set("orgId", "1")
+		params.Set("var-namespace", namespace)
+		params.Set("var-app", name)
+		links.MetricsDashboardURL = base + "/d/" + url.PathEscape(c.DashboardUID) + "?" + params.Encode()
+	}
+	return links
+}
+
+// exploreURL builds a Grafana Explore link running query against the
+// datasource with the given UID over the last hour.
+func exploreURL(base, datasourceUID string, query map[string]any) string {
+	query["refId"] = "A"
+	query["datasource"] = map[string]string{"uid": datasourceUID}
+	left, _ := json.Marshal(map[string]any{
+		"datasource": datasourceUID,
+		"queries":    []any{query},
+		"range":      map[string]string{"from": "now-1h", "to": "now"},
+	})
+	params := url.Values{}
+	params.Set("orgId", "1")
+	params.Set("left", string(left))
+	return base + "/explore?" + params.Encode()
+}
@@ -0,0 +1,65 @@
+package grafana
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// exploreQuery decodes the first query of an Explore link.
+func exploreQuery(t *testing.T, link string) (datasource string, query map[string]any) {
+	t.Helper()
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Path != "/explore" {
+		t.Fatalf("expected an Explore link, got %q", link)
+	}
+	var left struct {
+		Datasource string           `json:"datasource"`
+		Queries    []map[string]any `json:"queries"`
+	}
+	if err := json.Unmarshal([]byte(u.Query().Get("left")), &left); err != nil {
+		t.Fatalf("invalid left parameter: %v", err)
+	}
+	if len(left.Queries) != 1 {
+		t.Fatalf("expected one query, got %v", left.Queries)
+	}
+	return left.Datasource, left.Queries[0]
+}
+
+func TestConfig_AppLinks(t *testing.T) {
+	cfg := Config{URL: "https://grafana.example.com/", LokiUID: "loki", TempoUID: "tempo", DashboardUID: "iaf-app"}
+	links := cfg.AppLinks("iaf-abc", "web")
+
+	ds, q := exploreQuery(t, links.LogExploreURL)
+	if ds != "loki" || q["expr"] != `{namespace="iaf-abc", pod=~"web-[a-z0-9]+-[a-z0-9]+"}` {
+		t.Errorf("unexpected log query on %q: %v", ds, q)
+	}
+	ds, q = exploreQuery(t, links.TraceExploreURL)
+	if ds != "tempo" || q["query"] != `{resource.service.name="web" && resource.k8s.namespace.name="iaf-abc"}` {
+		t.Errorf("unexpected trace query on %q: %v", ds, q)
+	}
+	if !strings.HasPrefix(links.LogExploreURL, "https://grafana.example.com/explore?") {
+		t.Errorf("expected links under the base URL without a double slash, got %q", links.LogExploreURL)
+	}
+	want := "https://grafana.example.com/d/iaf-app?orgId=1&var-app=web&var-namespace=iaf-abc"
+	if links.MetricsDashboardURL != want {
+		t.Errorf("MetricsDashboardURL = %q, want %q", links.MetricsDashboardURL, want)
+	}
+}
+
+func TestConfig_AppLinks_Unconfigured(t *testing.T) {
+	if links := (Config{}).AppLinks("iaf-abc", "web"); links != (Links{}) {
+		t.Errorf("expected no links without a Grafana URL, got %+v", links)
+	}
+	links := Config{URL: "https://grafana.example.com", LokiUID: "loki", TempoUID: "tempo"}.AppLinks("iaf-abc", "web")
+	if links.MetricsDashboardURL != "" {
+		t.Errorf("expected no dashboard link without a dashboard UID, got %q", links.MetricsDashboardURL)
+	}
+	if links.LogExploreURL == "" || links.TraceExploreURL == "" {
+		t.Errorf("expected Explore links, got %+v", links)
+	}
+}
@@ -0,0 +1,81 @@
+// Package graphqlapi serves a read-only GraphQL view of a session for the
+// dashboard, which needs nested queries (session → applications → pods →
+// events) that would otherwise take one REST call per level. Applications
+// and services come from internal/service like the REST handlers; pods,
+// events and bound services are fetched through per-request dataloaders so
+// a query lists each kind once per namespace instead of once per parent.
+package graphqlapi
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/service"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	"github.com/graph-gophers/graphql-go"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// maxDepth bounds query nesting. The deepest useful path,
+// session → applications → pods → events → field, is five levels.
+const maxDepth = 8
+
+// maxParallelism bounds the resolvers run concurrently for one query.
+const maxParallelism = 16
+
+// Schema executes GraphQL queries against a session namespace.
+type Schema struct {
+	schema   *graphql.Schema
+	client   client.Client
+	apps     *service.Applications
+	services *service.Services
+}
+
+// New parses the schema and binds it to the cluster client.
+func New(c client.Client, store *sourcestore.Store) (*Schema, error) {
+	s := &Schema{
+		client:   c,
+		apps:     service.NewApplications(c, store, false),
+		services: service.NewServices(c),
+	}
+	parsed, err := graphql.ParseSchema(schemaSDL, &queryResolver{s: s},
+		graphql.MaxDepth(maxDepth),
+		graphql.MaxParallelism(maxParallelism),
+	)
+	if err != nil {
+		return nil, err
+	}
+	s.schema = parsed
+	return s, nil
+}
+
+// Exec runs query in namespace. Errors are reported in the response, as
+// GraphQL requires; the schema has no mutations, so nothing is changed.
+func (s *Schema) Exec(ctx context.Context, namespace, query, operationName string, variables map[string]any) *graphql.Response {
+	ctx = context.WithValue(ctx, requestKey{}, newRequest(s, namespace))
+	return s.schema.Exec(ctx, query, operationName, variables)
+}
+
+type requestKey struct{}
+
+// request is the state of one query: its namespace and dataloaders.
+type request struct {
+	namespace string
+	loaders   *loaders
+}
+
+func fromContext(ctx context.Context) *request {
+	return ctx.Value(requestKey{}).(*request)
+}
+
+// isNotFound reports whether err is a not-found error from the service
+// layer.
+func isNotFound(err error) bool {
+	var e *apierror.Error
+	return errors.As(err, &e) && e.Category == apierror.CategoryNotFound
+}
@@ -0,0 +1,208 @@
+package graphqlapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/graphqlapi"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// listCounter counts List calls by list type.
+type listCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (l *listCounter) get(kind string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.counts[kind]
+}
+
+func setup(t *testing.T, objs ...client.Object) (*graphqlapi.Schema, *listCounter) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := iafv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	counter := &listCounter{counts: map[string]int{}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithInterceptorFuncs(interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			kind := "other"
+			switch list.(type) {
+			case *corev1.PodList:
+				kind = "pods"
+			case *corev1.EventList:
+				kind = "events"
+			case *iafv1alpha1.ManagedServiceList:
+				kind = "services"
+			}
+			counter.mu.Lock()
+			counter.counts[kind]++
+			counter.mu.Unlock()
+			return c.List(ctx, list, opts...)
+		},
+	}).Build()
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := graphqlapi.New(c, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, counter
+}
+
+func app(name, namespace string, services ...string) *iafv1alpha1.Application {
+	a := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest", Port: 8080, Replicas: 1},
+	}
+	for _, svc := range services {
+		a.Spec.BoundManagedServices = append(a.Spec.BoundManagedServices, iafv1alpha1.BoundManagedService{ServiceName: svc})
+	}
+	return a
+}
+
+func pod(name, namespace, appName string, restarts int32) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"iaf.io/application": appName}},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "app", RestartCount: restarts, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}},
+		},
+	}
+}
+
+func event(name, namespace, kind, object, reason string) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: namespace},
+		InvolvedObject: corev1.ObjectReference{Kind: kind, Name: object, Namespace: namespace},
+		Type:           corev1.EventTypeWarning,
+		Reason:         reason,
+		Count:          2,
+	}
+}
+
+func exec(t *testing.T, s *graphqlapi.Schema, namespace, query string, out any) {
+	t.Helper()
+	resp := s.Exec(context.Background(), namespace, query, "", nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("errors: %v", resp.Errors)
+	}
+	if err := json.Unmarshal(resp.Data, out); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNestedQuery_BatchesLookups(t *testing.T) {
+	s, counter := setup(t,
+		app("web", "iaf-s1", "db"), app("worker", "iaf-s1"),
+		pod("web-1", "iaf-s1", "web", 0), pod("web-2", "iaf-s1", "web", 3), pod("worker-1", "iaf-s1", "worker", 0),
+		event("e1", "iaf-s1", "Pod", "web-2", "BackOff"),
+		event("e2", "iaf-s1", "Application", "worker", "BuildFailed"),
+		&iafv1alpha1.ManagedService{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "iaf-s1"},
+			Spec:       iafv1alpha1.ManagedServiceSpec{Type: "postgres", Plan: "micro"},
+			Status:     iafv1alpha1.ManagedServiceStatus{Phase: "Ready", BoundApps: []string{"web"}},
+		},
+	)
+
+	var data struct {
+		Session struct {
+			Namespace    string
+			Applications []struct {
+				Name     string
+				Events   []struct{ Reason string }
+				Services []struct{ Name, Type string }
+				Pods     []struct {
+					Name     string
+					Ready    bool
+					Restarts int
+					Events   []struct {
+						Reason string
+						Count  int
+					}
+				}
+			}
+		}
+	}
+	exec(t, s, "iaf-s1", `{ session { namespace applications { name events { reason } services { name type }
+		pods { name ready restarts events { reason count } } } } }`, &data)
+
+	if data.Session.Namespace != "iaf-s1" {
+		t.Errorf("namespace = %q", data.Session.Namespace)
+	}
+	apps := data.Session.Applications
+	if len(apps) != 2 || apps[0].Name != "web" || apps[1].Name != "worker" {
+		t.Fatalf("applications = %+v", apps)
+	}
+	web := apps[0]
+	if len(web.Pods) != 2 || web.Pods[1].Name != "web-2" || web.Pods[1].Restarts != 3 || !web.Pods[1].Ready {
+		t.Errorf("web pods = %+v", web.Pods)
+	}
+	if len(web.Pods[1].Events) != 1 || web.Pods[1].Events[0].Reason != "BackOff" || web.Pods[1].Events[0].Count != 2 {
+		t.Errorf("web-2 events = %+v", web.Pods[1].Events)
+	}
+	if len(web.Services) != 1 || web.Services[0].Type != "postgres" {
+		t.Errorf("web services = %+v", web.Services)
+	}
+	if len(apps[1].Events) != 1 || apps[1].Events[0].Reason != "BuildFailed" {
+		t.Errorf("worker events = %+v", apps[1].Events)
+	}
+
+	for kind, max := range map[string]int{"pods": 1, "events": 2, "services": 1} {
+		if n := counter.get(kind); n > max {
+			t.Errorf("%s listed %d times, want at most %d", kind, n, max)
+		}
+	}
+}
+
+func TestQuery_ScopedToNamespace(t *testing.T) {
+	s, _ := setup(t,
+		app("web", "iaf-s1"), app("other", "iaf-s2"),
+		pod("other-1", "iaf-s2", "web", 0),
+	)
+
+	var data struct {
+		Applications []struct {
+			Name string
+			Pods []struct{ Name string }
+		}
+		Other *struct{ Name string }
+	}
+	exec(t, s, "iaf-s1", `{ applications { name pods { name } } other: application(name: "other") { name } }`, &data)
+	if len(data.Applications) != 1 || data.Applications[0].Name != "web" {
+		t.Errorf("applications = %+v", data.Applications)
+	}
+	if len(data.Applications[0].Pods) != 0 {
+		t.Errorf("pods from another namespace leaked: %+v", data.Applications[0].Pods)
+	}
+	if data.Other != nil {
+		t.Errorf("application in another session resolved: %+v", data.Other)
+	}
+}
+
+func TestQuery_RejectsMutations(t *testing.T) {
+	s, _ := setup(t)
+	resp := s.Exec(context.Background(), "iaf-s1", `mutation { deleteApplication(name: "web") }`, "", nil)
+	if len(resp.Errors) == 0 {
+		t.Fatal("expected an error for a mutation")
+	}
+}
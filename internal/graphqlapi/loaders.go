@@ -0,0 +1,136 @@
+package graphqlapi
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/graph-gophers/dataloader/v7"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// appLabel is the pod label naming the owning application.
+const appLabel = "iaf.io/application"
+
+// batchWait is how long a loader collects keys before listing. Sibling
+// resolvers run concurrently, so a short window is enough to batch them.
+const batchWait = 2 * time.Millisecond
+
+// objectRef names the object an event is about.
+type objectRef struct {
+	kind, name string
+}
+
+// loaders batch the lookups made while resolving one query. Each batch is a
+// single List in the request namespace, grouped by key.
+type loaders struct {
+	pods     *dataloader.Loader[string, []corev1.Pod]
+	events   *dataloader.Loader[objectRef, []corev1.Event]
+	services *dataloader.Loader[string, *iafv1alpha1.ManagedService]
+}
+
+func newRequest(s *Schema, namespace string) *request {
+	return &request{
+		namespace: namespace,
+		loaders: &loaders{
+			pods:     dataloader.NewBatchedLoader(podsBatch(s.client, namespace), dataloader.WithWait[string, []corev1.Pod](batchWait)),
+			events:   dataloader.NewBatchedLoader(eventsBatch(s.client, namespace), dataloader.WithWait[objectRef, []corev1.Event](batchWait)),
+			services: dataloader.NewBatchedLoader(servicesBatch(s.client, namespace), dataloader.WithWait[string, *iafv1alpha1.ManagedService](batchWait)),
+		},
+	}
+}
+
+// podsBatch lists the pods of every requested application with one
+// set-based label selector.
+func podsBatch(c client.Client, namespace string) dataloader.BatchFunc[string, []corev1.Pod] {
+	return func(ctx context.Context, names []string) []*dataloader.Result[[]corev1.Pod] {
+		req, err := labels.NewRequirement(appLabel, selection.In, names)
+		if err != nil {
+			return failed[[]corev1.Pod](len(names), apierror.From(err))
+		}
+		var list corev1.PodList
+		if err := c.List(ctx, &list, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: labels.NewSelector().Add(*req)}); err != nil {
+			return failed[[]corev1.Pod](len(names), apierror.From(err))
+		}
+		byApp := map[string][]corev1.Pod{}
+		for _, pod := range list.Items {
+			app := pod.Labels[appLabel]
+			byApp[app] = append(byApp[app], pod)
+		}
+		results := make([]*dataloader.Result[[]corev1.Pod], len(names))
+		for i, name := range names {
+			pods := byApp[name]
+			sort.Slice(pods, func(a, b int) bool { return pods[a].Name < pods[b].Name })
+			results[i] = &dataloader.Result[[]corev1.Pod]{Data: pods}
+		}
+		return results
+	}
+}
+
+// eventsBatch lists the namespace's events once and groups them by the
+// object they are about, newest first.
+func eventsBatch(c client.Client, namespace string) dataloader.BatchFunc[objectRef, []corev1.Event] {
+	return func(ctx context.Context, refs []objectRef) []*dataloader.Result[[]corev1.Event] {
+		var list corev1.EventList
+		if err := c.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+			return failed[[]corev1.Event](len(refs), apierror.From(err))
+		}
+		byObject := map[objectRef][]corev1.Event{}
+		for _, ev := range list.Items {
+			ref := objectRef{kind: ev.InvolvedObject.Kind, name: ev.InvolvedObject.Name}
+			byObject[ref] = append(byObject[ref], ev)
+		}
+		results := make([]*dataloader.Result[[]corev1.Event], len(refs))
+		for i, ref := range refs {
+			events := byObject[ref]
+			sort.SliceStable(events, func(a, b int) bool { return eventTime(events[a]).After(eventTime(events[b])) })
+			results[i] = &dataloader.Result[[]corev1.Event]{Data: events}
+		}
+		return results
+	}
+}
+
+// servicesBatch lists the namespace's managed services once. Names with no
+// service resolve to nil.
+func servicesBatch(c client.Client, namespace string) dataloader.BatchFunc[string, *iafv1alpha1.ManagedService] {
+	return func(ctx context.Context, names []string) []*dataloader.Result[*iafv1alpha1.ManagedService] {
+		var list iafv1alpha1.ManagedServiceList
+		if err := c.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+			return failed[*iafv1alpha1.ManagedService](len(names), apierror.From(err))
+		}
+		byName := make(map[string]*iafv1alpha1.ManagedService, len(list.Items))
+		for i := range list.Items {
+			byName[list.Items[i].Name] = &list.Items[i]
+		}
+		results := make([]*dataloader.Result[*iafv1alpha1.ManagedService], len(names))
+		for i, name := range names {
+			results[i] = &dataloader.Result[*iafv1alpha1.ManagedService]{Data: byName[name]}
+		}
+		return results
+	}
+}
+
+func failed[V any](n int, err error) []*dataloader.Result[V] {
+	results := make([]*dataloader.Result[V], n)
+	for i := range results {
+		results[i] = &dataloader.Result[V]{Error: err}
+	}
+	return results
+}
+
+// eventTime is when an event was last seen, falling back to its first
+// occurrence for events recorded with the events.k8s.io API.
+func eventTime(ev corev1.Event) time.Time {
+	switch {
+	case !ev.LastTimestamp.IsZero():
+		return ev.LastTimestamp.Time
+	case !ev.EventTime.IsZero():
+		return ev.EventTime.Time
+	}
+	return ev.FirstTimestamp.Time
+}
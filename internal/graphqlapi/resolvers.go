@@ -0,0 +1,272 @@
+package graphqlapi
+
+import (
+	"context"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type queryResolver struct {
+	s *Schema
+}
+
+func (q *queryResolver) Session(ctx context.Context) *sessionResolver {
+	return &sessionResolver{q: q, namespace: fromContext(ctx).namespace}
+}
+
+func (q *queryResolver) Applications(ctx context.Context) ([]*applicationResolver, error) {
+	list, err := q.s.apps.List(ctx, fromContext(ctx).namespace)
+	if err != nil {
+		return nil, err
+	}
+	apps := make([]*applicationResolver, len(list))
+	for i := range list {
+		apps[i] = &applicationResolver{app: &list[i]}
+	}
+	return apps, nil
+}
+
+func (q *queryResolver) Application(ctx context.Context, args struct{ Name string }) (*applicationResolver, error) {
+	app, err := q.s.apps.Get(ctx, fromContext(ctx).namespace, args.Name)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &applicationResolver{app: app}, nil
+}
+
+func (q *queryResolver) Services(ctx context.Context) ([]*serviceResolver, error) {
+	list, err := q.s.services.List(ctx, fromContext(ctx).namespace)
+	if err != nil {
+		return nil, err
+	}
+	services := make([]*serviceResolver, len(list))
+	for i := range list {
+		services[i] = &serviceResolver{svc: &list[i]}
+	}
+	return services, nil
+}
+
+type sessionResolver struct {
+	q         *queryResolver
+	namespace string
+}
+
+func (r *sessionResolver) Namespace() string { return r.namespace }
+
+func (r *sessionResolver) Applications(ctx context.Context) ([]*applicationResolver, error) {
+	return r.q.Applications(ctx)
+}
+
+func (r *sessionResolver) Services(ctx context.Context) ([]*serviceResolver, error) {
+	return r.q.Services(ctx)
+}
+
+type applicationResolver struct {
+	app *iafv1alpha1.Application
+}
+
+func (r *applicationResolver) Name() string             { return r.app.Name }
+func (r *applicationResolver) Version() int32           { return int32(iafk8s.AppVersion(r.app)) }
+func (r *applicationResolver) Phase() string            { return string(r.app.Status.Phase) }
+func (r *applicationResolver) URL() string              { return r.app.Status.URL }
+func (r *applicationResolver) Image() string            { return r.app.Spec.Image }
+func (r *applicationResolver) Port() int32              { return r.app.Spec.Port }
+func (r *applicationResolver) Replicas() int32          { return r.app.Spec.Replicas }
+func (r *applicationResolver) AvailableReplicas() int32 { return r.app.Status.AvailableReplicas }
+func (r *applicationResolver) Suspended() bool          { return r.app.Spec.Suspended }
+func (r *applicationResolver) LatestImage() string      { return r.app.Status.LatestImage }
+func (r *applicationResolver) BuildStatus() string      { return r.app.Status.BuildStatus }
+func (r *applicationResolver) Protocol() string         { return string(iafv1alpha1.AppProtocol(r.app)) }
+func (r *applicationResolver) CreatedAt() string        { return formatTime(r.app.CreationTimestamp) }
+func (r *applicationResolver) Conditions() []*condition { return conditions(r.app.Status.Conditions) }
+
+func (r *applicationResolver) GitURL() string {
+	if r.app.Spec.Git == nil {
+		return ""
+	}
+	return r.app.Spec.Git.URL
+}
+
+func (r *applicationResolver) GitRevision() string {
+	if r.app.Spec.Git == nil {
+		return ""
+	}
+	return r.app.Spec.Git.Revision
+}
+
+func (r *applicationResolver) Pods(ctx context.Context) ([]*podResolver, error) {
+	pods, err := fromContext(ctx).loaders.pods.Load(ctx, r.app.Name)()
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*podResolver, len(pods))
+	for i := range pods {
+		resolvers[i] = &podResolver{pod: &pods[i]}
+	}
+	return resolvers, nil
+}
+
+func (r *applicationResolver) Events(ctx context.Context) ([]*eventResolver, error) {
+	return loadEvents(ctx, objectRef{kind: "Application", name: r.app.Name})
+}
+
+func (r *applicationResolver) Services(ctx context.Context) ([]*serviceResolver, error) {
+	loader := fromContext(ctx).loaders.services
+	var services []*serviceResolver
+	for _, bound := range r.app.Spec.BoundManagedServices {
+		svc, err := loader.Load(ctx, bound.ServiceName)()
+		if err != nil {
+			return nil, err
+		}
+		if svc != nil {
+			services = append(services, &serviceResolver{svc: svc})
+		}
+	}
+	return services, nil
+}
+
+type condition struct {
+	c metav1.Condition
+}
+
+func conditions(list []metav1.Condition) []*condition {
+	out := make([]*condition, len(list))
+	for i := range list {
+		out[i] = &condition{c: list[i]}
+	}
+	return out
+}
+
+func (c *condition) Type() string               { return c.c.Type }
+func (c *condition) Status() string             { return string(c.c.Status) }
+func (c *condition) Reason() string             { return c.c.Reason }
+func (c *condition) Message() string            { return c.c.Message }
+func (c *condition) LastTransitionTime() string { return formatTime(c.c.LastTransitionTime) }
+
+type podResolver struct {
+	pod *corev1.Pod
+}
+
+func (r *podResolver) Name() string  { return r.pod.Name }
+func (r *podResolver) Phase() string { return string(r.pod.Status.Phase) }
+func (r *podResolver) Node() string  { return r.pod.Spec.NodeName }
+
+func (r *podResolver) Ready() bool {
+	for _, c := range r.pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func (r *podResolver) Restarts() int32 {
+	var n int32
+	for _, cs := range r.pod.Status.ContainerStatuses {
+		n += cs.RestartCount
+	}
+	return n
+}
+
+func (r *podResolver) StartedAt() string {
+	if r.pod.Status.StartTime == nil {
+		return ""
+	}
+	return formatTime(*r.pod.Status.StartTime)
+}
+
+func (r *podResolver) Containers() []*containerResolver {
+	out := make([]*containerResolver, len(r.pod.Status.ContainerStatuses))
+	for i := range r.pod.Status.ContainerStatuses {
+		out[i] = &containerResolver{cs: &r.pod.Status.ContainerStatuses[i]}
+	}
+	return out
+}
+
+func (r *podResolver) Events(ctx context.Context) ([]*eventResolver, error) {
+	return loadEvents(ctx, objectRef{kind: "Pod", name: r.pod.Name})
+}
+
+type containerResolver struct {
+	cs *corev1.ContainerStatus
+}
+
+func (r *containerResolver) Name() string        { return r.cs.Name }
+func (r *containerResolver) Ready() bool         { return r.cs.Ready }
+func (r *containerResolver) RestartCount() int32 { return r.cs.RestartCount }
+
+func (r *containerResolver) State() string {
+	switch {
+	case r.cs.State.Running != nil:
+		return "running"
+	case r.cs.State.Terminated != nil:
+		return "terminated"
+	}
+	return "waiting"
+}
+
+func (r *containerResolver) Reason() string {
+	switch {
+	case r.cs.State.Waiting != nil:
+		return r.cs.State.Waiting.Reason
+	case r.cs.State.Terminated != nil:
+		return r.cs.State.Terminated.Reason
+	}
+	return ""
+}
+
+type eventResolver struct {
+	ev *corev1.Event
+}
+
+func loadEvents(ctx context.Context, ref objectRef) ([]*eventResolver, error) {
+	events, err := fromContext(ctx).loaders.events.Load(ctx, ref)()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*eventResolver, len(events))
+	for i := range events {
+		out[i] = &eventResolver{ev: &events[i]}
+	}
+	return out, nil
+}
+
+func (r *eventResolver) Type() string    { return r.ev.Type }
+func (r *eventResolver) Reason() string  { return r.ev.Reason }
+func (r *eventResolver) Message() string { return r.ev.Message }
+
+func (r *eventResolver) Count() int32 {
+	if r.ev.Count == 0 {
+		return 1
+	}
+	return r.ev.Count
+}
+
+func (r *eventResolver) LastTimestamp() string {
+	return eventTime(*r.ev).UTC().Format(time.RFC3339)
+}
+
+type serviceResolver struct {
+	svc *iafv1alpha1.ManagedService
+}
+
+func (r *serviceResolver) Name() string        { return r.svc.Name }
+func (r *serviceResolver) Type() string        { return r.svc.Spec.Type }
+func (r *serviceResolver) Plan() string        { return string(r.svc.Spec.Plan) }
+func (r *serviceResolver) Phase() string       { return string(r.svc.Status.Phase) }
+func (r *serviceResolver) Message() string     { return r.svc.Status.Message }
+func (r *serviceResolver) BoundApps() []string { return append([]string{}, r.svc.Status.BoundApps...) }
+
+func formatTime(t metav1.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,188 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/grafana"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/service"
+	iafv1 "github.com/dlapiduz/iaf/pkg/grpc/iaf/v1"
+)
+
+type applicationsServer struct {
+	iafv1.UnimplementedApplicationsServer
+	apps     *service.Applications
+	sessions *service.Sessions
+	grafana  grafana.Config
+}
+
+func (s *applicationsServer) ListApplications(ctx context.Context, _ *iafv1.ListApplicationsRequest) (*iafv1.ListApplicationsResponse, error) {
+	ns, err := namespace(ctx, s.sessions)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.apps.List(ctx, ns)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	resp := &iafv1.ListApplicationsResponse{Applications: make([]*iafv1.Application, 0, len(list))}
+	for i := range list {
+		resp.Applications = append(resp.Applications, toApplication(&list[i]))
+	}
+	return resp, nil
+}
+
+func (s *applicationsServer) GetApplication(ctx context.Context, req *iafv1.GetApplicationRequest) (*iafv1.Application, error) {
+	ns, err := namespace(ctx, s.sessions)
+	if err != nil {
+		return nil, err
+	}
+	app, err := s.apps.Get(ctx, ns, req.GetName())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	resp := toApplication(app)
+	links := s.grafana.AppLinks(app.Namespace, app.Name)
+	resp.LogExploreUrl = links.LogExploreURL
+	resp.TraceExploreUrl = links.TraceExploreURL
+	resp.MetricsDashboardUrl = links.MetricsDashboardURL
+	return resp, nil
+}
+
+func (s *applicationsServer) CreateApplication(ctx context.Context, req *iafv1.CreateApplicationRequest) (*iafv1.Application, error) {
+	ns, err := namespace(ctx, s.sessions)
+	if err != nil {
+		return nil, err
+	}
+	app, err := s.apps.Create(ctx, ns, toAppInput(req.GetSpec()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toApplication(app), nil
+}
+
+func (s *applicationsServer) UpdateApplication(ctx context.Context, req *iafv1.UpdateApplicationRequest) (*iafv1.Application, error) {
+	ns, err := namespace(ctx, s.sessions)
+	if err != nil {
+		return nil, err
+	}
+	in := toAppInput(req.GetSpec())
+	// Env lists cannot be told apart from unset ones in proto3, so an empty
+	// list leaves the variables unchanged.
+	if len(in.Env) == 0 {
+		in.Env = nil
+	}
+	if len(in.BuildEnv) == 0 {
+		in.BuildEnv = nil
+	}
+	app, err := s.apps.Update(ctx, ns, req.GetName(), in, req.GetExpectedVersion())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toApplication(app), nil
+}
+
+func (s *applicationsServer) DeleteApplication(ctx context.Context, req *iafv1.DeleteApplicationRequest) (*iafv1.DeleteApplicationResponse, error) {
+	ns, err := namespace(ctx, s.sessions)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apps.Delete(ctx, ns, req.GetName(), 0); err != nil {
+		return nil, statusError(err)
+	}
+	return &iafv1.DeleteApplicationResponse{Message: fmt.Sprintf("application %s deleted", req.GetName())}, nil
+}
+
+// toAppInput converts a request spec to the service layer's input.
+func toAppInput(spec *iafv1.ApplicationSpec) service.AppInput {
+	in := service.AppInput{
+		Name:           spec.GetName(),
+		Image:          spec.GetImage(),
+		GitURL:         spec.GetGitUrl(),
+		GitRevision:    spec.GetGitRevision(),
+		Port:           spec.GetPort(),
+		Replicas:       spec.GetReplicas(),
+		Env:            fromEnvVars(spec.GetEnv()),
+		BuildEnv:       fromEnvVars(spec.GetBuildEnv()),
+		Host:           spec.GetHost(),
+		Protocol:       spec.GetProtocol(),
+		StickySessions: spec.StickySessions,
+		Authentication: spec.GetAuthentication(),
+	}
+	if access := spec.GetAccess(); access != nil {
+		in.Access = &iafv1alpha1.AccessConfig{
+			IPAllowList:       access.GetIpAllowList(),
+			RequestsPerSecond: access.GetRequestsPerSecond(),
+		}
+	}
+	return in
+}
+
+// toApplication converts an Application to its API message, like the REST
+// API's ApplicationResponse.
+func toApplication(app *iafv1alpha1.Application) *iafv1.Application {
+	resp := &iafv1.Application{
+		Name:              app.Name,
+		Version:           iafk8s.AppVersion(app),
+		Phase:             string(app.Status.Phase),
+		Url:               app.Status.URL,
+		Image:             app.Spec.Image,
+		Blob:              app.Spec.Blob,
+		Port:              app.Spec.Port,
+		Replicas:          app.Spec.Replicas,
+		Suspended:         app.Spec.Suspended,
+		AvailableReplicas: app.Status.AvailableReplicas,
+		LatestImage:       app.Status.LatestImage,
+		BuildStatus:       app.Status.BuildStatus,
+		QueuePosition:     app.Status.BuildQueuePosition,
+		Env:               toEnvVars(app.Spec.Env),
+		BuildEnv:          toEnvVars(app.Spec.BuildEnv),
+		Host:              app.Spec.Host,
+		Protocol:          string(iafv1alpha1.AppProtocol(app)),
+		StickySessions:    app.Spec.StickySessions,
+		Authentication:    string(iafv1alpha1.AppAuthentication(app)),
+		CreatedAt:         app.CreationTimestamp.UTC().Format(time.RFC3339),
+	}
+	if app.Spec.Git != nil {
+		resp.GitUrl = app.Spec.Git.URL
+		resp.GitRevision = app.Spec.Git.Revision
+	}
+	if app.Spec.Access != nil {
+		resp.Access = &iafv1.AccessConfig{
+			IpAllowList:       app.Spec.Access.IPAllowList,
+			RequestsPerSecond: app.Spec.Access.RequestsPerSecond,
+		}
+	}
+	for _, c := range app.Status.Conditions {
+		resp.Conditions = append(resp.Conditions, &iafv1.Condition{
+			Type:               c.Type,
+			Status:             string(c.Status),
+			Reason:             c.Reason,
+			Message:            c.Message,
+			LastTransitionTime: c.LastTransitionTime.UTC().Format(time.RFC3339),
+		})
+	}
+	return resp
+}
+
+func toEnvVars(env []iafv1alpha1.EnvVar) []*iafv1.EnvVar {
+	out := make([]*iafv1.EnvVar, 0, len(env))
+	for _, e := range env {
+		out = append(out, &iafv1.EnvVar{Name: e.Name, Value: e.Value})
+	}
+	return out
+}
+
+func fromEnvVars(env []*iafv1.EnvVar) []iafv1alpha1.EnvVar {
+	if env == nil {
+		return nil
+	}
+	out := make([]iafv1alpha1.EnvVar, 0, len(env))
+	for _, e := range env {
+		out = append(out, iafv1alpha1.EnvVar{Name: e.GetName(), Value: e.GetValue()})
+	}
+	return out
+}
@@ -0,0 +1,265 @@
+// Package grpcapi serves the gRPC API defined in proto/iaf/v1, and its
+// grpc-gateway JSON mapping, for integrators who prefer generated clients
+// over the REST API. Both call the same internal/service operations as the
+// REST handlers, so validation, policy checks and errors match.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/grafana"
+	"github.com/dlapiduz/iaf/internal/maintenance"
+	"github.com/dlapiduz/iaf/internal/middleware"
+	"github.com/dlapiduz/iaf/internal/policy"
+	"github.com/dlapiduz/iaf/internal/service"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	iafv1 "github.com/dlapiduz/iaf/pkg/grpc/iaf/v1"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SessionMetadataKey is the metadata key naming the caller's session, the
+// gRPC equivalent of the X-IAF-Session header.
+const SessionMetadataKey = "x-iaf-session"
+
+// maxMessageSize bounds request messages, matching the REST API's body limit
+// so the same source uploads fit.
+const maxMessageSize = 32 << 20
+
+// errorDomain is the ErrorInfo domain of the errors the API returns.
+const errorDomain = "iaf.io"
+
+// Options configures the gRPC API.
+type Options struct {
+	// Tokens are the Bearer tokens accepted in the authorization metadata.
+	Tokens []string
+	// SessionTTL is the idle lifetime of sessions registered over gRPC.
+	SessionTTL time.Duration
+	// Grafana drives the deep links returned by GetApplication.
+	Grafana grafana.Config
+	// CustomDNS lets applications set host aliases and DNS settings.
+	CustomDNS bool
+	// Maintenance puts the API in read-only mode: calls that change
+	// something fail with code maintenance. Nil when maintenance is off.
+	Maintenance *maintenance.Mode
+	Logger      *slog.Logger
+}
+
+// NewServer returns a gRPC server with the Applications, ManagedServices,
+// Sessions and Sources services registered. Every call is authenticated
+// with a Bearer token and logged, and calls that change something are
+// refused while the platform is in maintenance.
+func NewServer(c client.Client, sessions *auth.SessionStore, store *sourcestore.Store, opts Options) *grpc.Server {
+	s := grpc.NewServer(
+		grpc.MaxRecvMsgSize(maxMessageSize),
+		grpc.ChainUnaryInterceptor(audit(opts.Logger), authenticate(opts.Tokens), readOnly(opts.Maintenance)),
+	)
+	sess := service.NewSessions(c, sessions, opts.SessionTTL)
+	iafv1.RegisterApplicationsServer(s, &applicationsServer{apps: service.NewApplications(c, store, opts.CustomDNS), sessions: sess, grafana: opts.Grafana})
+	iafv1.RegisterManagedServicesServer(s, &managedServicesServer{services: service.NewServices(c), sessions: sess})
+	iafv1.RegisterSessionsServer(s, &sessionsServer{sessions: sess})
+	iafv1.RegisterSourcesServer(s, &sourcesServer{apps: service.NewApplications(c, store, opts.CustomDNS), sessions: sess})
+	return s
+}
+
+// NewGateway returns the grpc-gateway JSON mapping of the services, which
+// forwards each request to the gRPC server at endpoint. The Authorization
+// and X-IAF-Session headers are passed on as metadata.
+func NewGateway(ctx context.Context, endpoint string) (http.Handler, error) {
+	mux := runtime.NewServeMux(runtime.WithIncomingHeaderMatcher(func(key string) (string, bool) {
+		if strings.EqualFold(key, SessionMetadataKey) {
+			return SessionMetadataKey, true
+		}
+		return runtime.DefaultHeaderMatcher(key)
+	}))
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(maxMessageSize)),
+	}
+	for _, register := range []func(context.Context, *runtime.ServeMux, string, []grpc.DialOption) error{
+		iafv1.RegisterApplicationsHandlerFromEndpoint,
+		iafv1.RegisterManagedServicesHandlerFromEndpoint,
+		iafv1.RegisterSessionsHandlerFromEndpoint,
+		iafv1.RegisterSourcesHandlerFromEndpoint,
+	} {
+		if err := register(ctx, mux, endpoint, opts); err != nil {
+			return nil, err
+		}
+	}
+	return mux, nil
+}
+
+// Handler serves gRPC requests with grpcServer and everything else with
+// gateway, so both can share one port. The listener must accept
+// unencrypted HTTP/2 for gRPC clients to connect.
+func Handler(grpcServer *grpc.Server, gateway http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		gateway.ServeHTTP(w, r)
+	})
+}
+
+// authenticate rejects calls without a valid Bearer token in the
+// authorization metadata.
+func authenticate(tokens []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("authorization")
+		if len(values) == 0 {
+			return nil, status.Error(codes.Unauthenticated, "missing authorization metadata")
+		}
+		token, ok := strings.CutPrefix(values[0], "Bearer ")
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "invalid authorization format, expected Bearer token")
+		}
+		if !middleware.MatchToken(token, tokens) {
+			return nil, status.Error(codes.Unauthenticated, "invalid API token")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// readOnlyMethods are the calls that change nothing.
+var readOnlyMethods = map[string]bool{
+	iafv1.Applications_ListApplications_FullMethodName: true,
+	iafv1.Applications_GetApplication_FullMethodName:   true,
+	iafv1.ManagedServices_ListServices_FullMethodName:  true,
+}
+
+// readOnly refuses every call except readOnlyMethods while mode is on, like
+// the REST API's maintenance middleware.
+func readOnly(mode *maintenance.Mode) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if mode.Active() && !readOnlyMethods[info.FullMethod] {
+			return nil, statusError(mode.Err())
+		}
+		return handler(ctx, req)
+	}
+}
+
+// audit logs every call like the REST API's audit middleware.
+func audit(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		if logger != nil {
+			logger.Info("grpc_request",
+				"method", info.FullMethod,
+				"code", status.Code(err).String(),
+				"duration_ms", time.Since(start).Milliseconds(),
+				"session_id", firstValue(ctx, SessionMetadataKey),
+			)
+		}
+		return resp, err
+	}
+}
+
+// namespace resolves the namespace of the session named in the call's
+// metadata.
+func namespace(ctx context.Context, sessions *service.Sessions) (string, error) {
+	ns, err := sessions.Namespace(firstValue(ctx, SessionMetadataKey))
+	if err != nil {
+		return "", statusError(err)
+	}
+	return ns, nil
+}
+
+func firstValue(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// statusError converts an error from the service layer to a gRPC status.
+// The structured error is attached as an ErrorInfo whose reason is the
+// error code, with the category, retryability and hint as metadata. Policy
+// violations also carry a PreconditionFailure listing the broken rules.
+func statusError(err error) error {
+	var violation *policy.ViolationError
+	e := apierror.From(err)
+	if errors.As(err, &violation) {
+		e = apierror.Validation(apierror.CodePolicyViolation, "%s", err.Error())
+	}
+	info := &errdetails.ErrorInfo{
+		Reason: e.Code,
+		Domain: errorDomain,
+		Metadata: map[string]string{
+			"category":  string(e.Category),
+			"retryable": strconv.FormatBool(e.Retryable),
+		},
+	}
+	if e.Hint != "" {
+		info.Metadata["hint"] = e.Hint
+	}
+	details := []protoadapt.MessageV1{info}
+	if violation != nil {
+		failure := &errdetails.PreconditionFailure{}
+		for _, v := range violation.Violations {
+			failure.Violations = append(failure.Violations, &errdetails.PreconditionFailure_Violation{
+				Type:        "policy",
+				Subject:     v.Policy + "/" + v.Rule,
+				Description: v.Message,
+			})
+		}
+		details = append(details, failure)
+	}
+	st := status.New(grpcCode(e), e.Message)
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// grpcCode maps an error to the gRPC code closest to its REST status.
+func grpcCode(e *apierror.Error) codes.Code {
+	switch e.Code {
+	case apierror.CodeUnauthorized:
+		return codes.Unauthenticated
+	case apierror.CodeForbidden, apierror.CodePolicyViolation:
+		return codes.PermissionDenied
+	case apierror.CodeVersionConflict:
+		return codes.Aborted
+	case apierror.CodeUnavailable, apierror.CodeUpstreamError, apierror.CodeMaintenance:
+		return codes.Unavailable
+	case apierror.CodeDeadlineExceeded:
+		return codes.DeadlineExceeded
+	case apierror.CodeCapabilityDisabled:
+		return codes.Unimplemented
+	case apierror.CodeDeletionProtected:
+		return codes.FailedPrecondition
+	}
+	switch e.Category {
+	case apierror.CategoryValidation:
+		return codes.InvalidArgument
+	case apierror.CategoryNotFound:
+		return codes.NotFound
+	case apierror.CategoryConflict:
+		if e.Retryable {
+			return codes.Aborted
+		}
+		return codes.AlreadyExists
+	case apierror.CategoryQuota:
+		return codes.ResourceExhausted
+	}
+	return codes.Internal
+}
@@ -0,0 +1,291 @@
+package grpcapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/grpcapi"
+	"github.com/dlapiduz/iaf/internal/maintenance"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	iafv1 "github.com/dlapiduz/iaf/pkg/grpc/iaf/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testToken = "test-token"
+
+type testEnv struct {
+	conn   *grpc.ClientConn
+	addr   string
+	client ctrlclient.Client
+}
+
+func setup(t *testing.T) *testEnv {
+	t.Helper()
+	return setupWith(t, grpcapi.Options{Tokens: []string{testToken}})
+}
+
+func setupWith(t *testing.T, opts grpcapi.Options) *testEnv {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := iafv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := grpcapi.NewServer(k8sClient, sessions, store, opts)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &testEnv{conn: conn, addr: lis.Addr().String(), client: k8sClient}
+}
+
+func authed(sessionID string) context.Context {
+	md := metadata.Pairs("authorization", "Bearer "+testToken)
+	if sessionID != "" {
+		md.Set(grpcapi.SessionMetadataKey, sessionID)
+	}
+	return metadata.NewOutgoingContext(context.Background(), md)
+}
+
+func (env *testEnv) newSession(t *testing.T) *iafv1.Session {
+	t.Helper()
+	sess, err := iafv1.NewSessionsClient(env.conn).CreateSession(authed(""), &iafv1.CreateSessionRequest{Name: "grpc-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sess
+}
+
+func TestAuthentication(t *testing.T) {
+	env := setup(t)
+	sessions := iafv1.NewSessionsClient(env.conn)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{"missing", context.Background()},
+		{"not bearer", metadata.AppendToOutgoingContext(context.Background(), "authorization", testToken)},
+		{"wrong token", metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer nope")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := sessions.CreateSession(tt.ctx, &iafv1.CreateSessionRequest{})
+			if status.Code(err) != codes.Unauthenticated {
+				t.Errorf("got %v, want Unauthenticated", err)
+			}
+		})
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	until := time.Date(2026, 5, 1, 18, 0, 0, 0, time.UTC)
+	env := setupWith(t, grpcapi.Options{Tokens: []string{testToken}, Maintenance: &maintenance.Mode{Until: until}})
+
+	_, err := iafv1.NewSessionsClient(env.conn).CreateSession(authed(""), &iafv1.CreateSessionRequest{Name: "grpc-test"})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("got %v, want Unavailable", err)
+	}
+	if info := errorInfo(t, err); info.Reason != "maintenance" || info.Metadata["retryable"] != "true" {
+		t.Errorf("unexpected ErrorInfo %+v", info)
+	}
+	// Reads are served: this one fails only for want of a session.
+	_, err = iafv1.NewApplicationsClient(env.conn).ListApplications(authed("missing"), &iafv1.ListApplicationsRequest{})
+	if status.Code(err) == codes.Unavailable {
+		t.Errorf("got %v, want the read served", err)
+	}
+}
+
+func TestApplications(t *testing.T) {
+	env := setup(t)
+	sess := env.newSession(t)
+	apps := iafv1.NewApplicationsClient(env.conn)
+	ctx := authed(sess.SessionId)
+
+	created, err := apps.CreateApplication(ctx, &iafv1.CreateApplicationRequest{Spec: &iafv1.ApplicationSpec{Name: "web", Image: "nginx:latest"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Port != 8080 || created.Replicas != 1 || created.Protocol != "http" {
+		t.Errorf("defaults not applied: %+v", created)
+	}
+	var stored iafv1alpha1.Application
+	if err := env.client.Get(context.Background(), types.NamespacedName{Name: "web", Namespace: sess.Namespace}, &stored); err != nil {
+		t.Fatalf("application not created in the session namespace: %v", err)
+	}
+
+	updated, err := apps.UpdateApplication(ctx, &iafv1.UpdateApplicationRequest{Name: "web", Spec: &iafv1.ApplicationSpec{Replicas: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Replicas != 3 || updated.Image != "nginx:latest" {
+		t.Errorf("update should change only replicas: %+v", updated)
+	}
+
+	list, err := apps.ListApplications(ctx, &iafv1.ListApplicationsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Applications) != 1 || list.Applications[0].Name != "web" {
+		t.Errorf("list = %+v", list.Applications)
+	}
+
+	if _, err := apps.DeleteApplication(ctx, &iafv1.DeleteApplicationRequest{Name: "web"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = apps.GetApplication(ctx, &iafv1.GetApplicationRequest{Name: "web"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("got %v, want NotFound", err)
+	}
+	info := errorInfo(t, err)
+	if info.Reason != "app_not_found" || info.Metadata["category"] != "not_found" || info.Metadata["retryable"] != "false" {
+		t.Errorf("error info = %+v", info)
+	}
+}
+
+func TestApplications_Errors(t *testing.T) {
+	env := setup(t)
+	sess := env.newSession(t)
+	apps := iafv1.NewApplicationsClient(env.conn)
+	ctx := authed(sess.SessionId)
+
+	if _, err := apps.ListApplications(authed(""), &iafv1.ListApplicationsRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("missing session: got %v, want InvalidArgument", err)
+	}
+	if _, err := apps.CreateApplication(ctx, &iafv1.CreateApplicationRequest{Spec: &iafv1.ApplicationSpec{Name: "web"}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("no image or git URL: got %v, want InvalidArgument", err)
+	}
+	spec := &iafv1.ApplicationSpec{Name: "web", Image: "nginx:latest"}
+	if _, err := apps.CreateApplication(ctx, &iafv1.CreateApplicationRequest{Spec: spec}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := apps.CreateApplication(ctx, &iafv1.CreateApplicationRequest{Spec: spec})
+	if status.Code(err) != codes.AlreadyExists || errorInfo(t, err).Reason != "name_taken" {
+		t.Errorf("duplicate name: got %v, want AlreadyExists name_taken", err)
+	}
+	_, err = apps.UpdateApplication(ctx, &iafv1.UpdateApplicationRequest{Name: "web", Spec: &iafv1.ApplicationSpec{Replicas: 2}, ExpectedVersion: 42})
+	if status.Code(err) != codes.Aborted || errorInfo(t, err).Reason != "version_conflict" {
+		t.Errorf("stale version: got %v, want Aborted version_conflict", err)
+	}
+}
+
+func TestSourcesAndServices(t *testing.T) {
+	env := setup(t)
+	sess := env.newSession(t)
+	ctx := authed(sess.SessionId)
+	if _, err := iafv1.NewApplicationsClient(env.conn).CreateApplication(ctx, &iafv1.CreateApplicationRequest{Spec: &iafv1.ApplicationSpec{Name: "web", Image: "nginx:latest"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	sources := iafv1.NewSourcesClient(env.conn)
+	resp, err := sources.UploadSource(ctx, &iafv1.UploadSourceRequest{Name: "web", Files: map[string]string{"main.go": "package main"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.BlobUrl == "" {
+		t.Error("expected a blob URL")
+	}
+	if _, err := sources.UploadSource(ctx, &iafv1.UploadSourceRequest{Name: "web"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("empty upload: got %v, want InvalidArgument", err)
+	}
+
+	services, err := iafv1.NewManagedServicesClient(env.conn).ListServices(ctx, &iafv1.ListServicesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services.Services) != 0 {
+		t.Errorf("services = %+v", services.Services)
+	}
+}
+
+func TestGateway(t *testing.T) {
+	env := setup(t)
+	sess := env.newSession(t)
+	gateway, err := grpcapi.NewGateway(context.Background(), env.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/applications", strings.NewReader(`{"name":"web","image":"nginx:latest"}`))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	req.Header.Set("X-IAF-Session", sess.SessionId)
+	rec := httptest.NewRecorder()
+	gateway.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d (body: %s)", rec.Code, rec.Body.String())
+	}
+	var app map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &app); err != nil {
+		t.Fatal(err)
+	}
+	if app["name"] != "web" {
+		t.Errorf("response = %v", app)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/applications/missing", nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	req.Header.Set("X-IAF-Session", sess.SessionId)
+	rec = httptest.NewRecorder()
+	gateway.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("missing app: status %d, want 404", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/applications", nil)
+	rec = httptest.NewRecorder()
+	gateway.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("no token: status %d, want 401", rec.Code)
+	}
+}
+
+func errorInfo(t *testing.T, err error) *errdetails.ErrorInfo {
+	t.Helper()
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+	t.Fatalf("no ErrorInfo in %v", err)
+	return nil
+}
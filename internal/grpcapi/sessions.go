@@ -0,0 +1,82 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/service"
+	iafv1 "github.com/dlapiduz/iaf/pkg/grpc/iaf/v1"
+)
+
+type sessionsServer struct {
+	iafv1.UnimplementedSessionsServer
+	sessions *service.Sessions
+}
+
+func (s *sessionsServer) CreateSession(ctx context.Context, req *iafv1.CreateSessionRequest) (*iafv1.Session, error) {
+	sess, err := s.sessions.Register(ctx, req.GetName(), nil)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &iafv1.Session{
+		SessionId:  sess.ID,
+		Namespace:  sess.Namespace,
+		TtlSeconds: int64(s.sessions.TTL().Seconds()),
+	}, nil
+}
+
+type managedServicesServer struct {
+	iafv1.UnimplementedManagedServicesServer
+	services *service.Services
+	sessions *service.Sessions
+}
+
+func (s *managedServicesServer) ListServices(ctx context.Context, _ *iafv1.ListServicesRequest) (*iafv1.ListServicesResponse, error) {
+	ns, err := namespace(ctx, s.sessions)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.services.List(ctx, ns)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	resp := &iafv1.ListServicesResponse{Services: make([]*iafv1.ManagedService, 0, len(list))}
+	for _, svc := range list {
+		resp.Services = append(resp.Services, &iafv1.ManagedService{
+			Name:      svc.Name,
+			Type:      svc.Spec.Type,
+			Plan:      string(svc.Spec.Plan),
+			Phase:     string(svc.Status.Phase),
+			Message:   svc.Status.Message,
+			BoundApps: svc.Status.BoundApps,
+		})
+	}
+	return resp, nil
+}
+
+type sourcesServer struct {
+	iafv1.UnimplementedSourcesServer
+	apps     *service.Applications
+	sessions *service.Sessions
+}
+
+func (s *sourcesServer) UploadSource(ctx context.Context, req *iafv1.UploadSourceRequest) (*iafv1.UploadSourceResponse, error) {
+	ns, err := namespace(ctx, s.sessions)
+	if err != nil {
+		return nil, err
+	}
+	var blobURL string
+	switch {
+	case len(req.GetFiles()) > 0 && len(req.GetTarball()) > 0:
+		return nil, statusError(apierror.Validation(apierror.CodeInvalidRequest, "set either files or tarball, not both"))
+	case len(req.GetTarball()) > 0:
+		blobURL, err = s.apps.UploadTarball(ctx, ns, req.GetName(), bytes.NewReader(req.GetTarball()))
+	default:
+		blobURL, err = s.apps.UploadFiles(ctx, ns, req.GetName(), req.GetFiles(), nil)
+	}
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &iafv1.UploadSourceResponse{Message: "source uploaded", BlobUrl: blobURL}, nil
+}
@@ -0,0 +1,146 @@
+// Package idempotency remembers the results of recent mutating requests by
+// the idempotency key the caller sent with them, so a client that retries
+// after a timeout gets the original result back instead of creating the
+// same resource twice or failing with "already exists".
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/dlapiduz/iaf/internal/apierror"
+)
+
+const (
+	// DefaultTTL is how long a result is replayed for its key.
+	DefaultTTL = 24 * time.Hour
+
+	// MaxKeysPerScope bounds the keys remembered per session; the oldest
+	// are forgotten first.
+	MaxKeysPerScope = 100
+
+	// sweepInterval is how often expired keys of every scope are dropped.
+	sweepInterval = time.Minute
+)
+
+type entry[T any] struct {
+	fingerprint string
+	created     time.Time
+	done        bool
+	result      T
+}
+
+// Store holds recent results of type T per scope, usually a session, and
+// idempotency key. Results live in memory, so keys are forgotten when the
+// process restarts. The zero value is not usable; use NewStore.
+type Store[T any] struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	scopes    map[string]map[string]*entry[T]
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewStore returns a store that replays results for ttl.
+func NewStore[T any](ttl time.Duration) *Store[T] {
+	return &Store[T]{
+		ttl:    ttl,
+		scopes: map[string]map[string]*entry[T]{},
+		now:    time.Now,
+	}
+}
+
+// Fingerprint returns a digest of the parts identifying a request, used to
+// detect a key reused for a different request.
+func Fingerprint(parts ...[]byte) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Do runs fn the first time key is used in scope and returns its result.
+// fn reports whether the result should be remembered; failed requests
+// usually are not, so a retry runs them again. Later calls with the same
+// key and fingerprint return the remembered result with replayed true,
+// without running fn. A key reused for a different request, or used while
+// the first request is still running, is rejected with a conflict error.
+func (s *Store[T]) Do(scope, key, fingerprint string, fn func() (T, bool)) (result T, replayed bool, err error) {
+	s.mu.Lock()
+	now := s.now()
+	s.sweepLocked(now)
+	keys := s.scopes[scope]
+	if e, ok := keys[key]; ok && now.Sub(e.created) < s.ttl {
+		s.mu.Unlock()
+		switch {
+		case e.fingerprint != fingerprint:
+			return result, false, apierror.Conflict(apierror.CodeIdempotencyKeyReused, "idempotency key %q was already used for a different request", key).
+				WithHint("use a new idempotency_key for each distinct request")
+		case !e.done:
+			conflict := apierror.Conflict(apierror.CodeRequestInProgress, "a request with idempotency key %q is still in progress", key).
+				WithHint("wait a few seconds and retry with the same idempotency_key")
+			conflict.Retryable = true
+			return result, false, conflict
+		}
+		return e.result, true, nil
+	}
+	if keys == nil {
+		keys = map[string]*entry[T]{}
+		s.scopes[scope] = keys
+	}
+	e := &entry[T]{fingerprint: fingerprint, created: now}
+	keys[key] = e
+	s.evictLocked(keys)
+	s.mu.Unlock()
+
+	keep := false
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if keep {
+			e.done, e.result = true, result
+		} else if s.scopes[scope][key] == e {
+			// The request failed or panicked; let a retry run it again.
+			delete(s.scopes[scope], key)
+		}
+	}()
+	result, keep = fn()
+	return result, false, nil
+}
+
+// evictLocked forgets the oldest keys beyond MaxKeysPerScope. Caller must
+// hold s.mu.
+func (s *Store[T]) evictLocked(keys map[string]*entry[T]) {
+	for len(keys) > MaxKeysPerScope {
+		var oldest string
+		for k, e := range keys {
+			if oldest == "" || e.created.Before(keys[oldest].created) {
+				oldest = k
+			}
+		}
+		delete(keys, oldest)
+	}
+}
+
+// sweepLocked drops expired keys at most once per sweepInterval. Caller
+// must hold s.mu.
+func (s *Store[T]) sweepLocked(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for scope, keys := range s.scopes {
+		for k, e := range keys {
+			if now.Sub(e.created) >= s.ttl {
+				delete(keys, k)
+			}
+		}
+		if len(keys) == 0 {
+			delete(s.scopes, scope)
+		}
+	}
+}
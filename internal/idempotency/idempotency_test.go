@@ -0,0 +1,84 @@
+package idempotency
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dlapiduz/iaf/internal/apierror"
+)
+
+func TestStore_Do(t *testing.T) {
+	s := NewStore[string](time.Hour)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	calls := 0
+	run := func(result string, keep bool) func() (string, bool) {
+		return func() (string, bool) {
+			calls++
+			return result, keep
+		}
+	}
+
+	got, replayed, err := s.Do("sess-a", "k1", "fp1", run("first", true))
+	if err != nil || replayed || got != "first" {
+		t.Fatalf("first call = %q, %v, %v", got, replayed, err)
+	}
+	got, replayed, err = s.Do("sess-a", "k1", "fp1", run("second", true))
+	if err != nil || !replayed || got != "first" || calls != 1 {
+		t.Fatalf("replay = %q, %v, %v after %d calls; want the first result without running", got, replayed, err, calls)
+	}
+
+	// Keys are scoped: another session runs its own request.
+	if got, replayed, _ := s.Do("sess-b", "k1", "fp1", run("other", true)); replayed || got != "other" {
+		t.Errorf("other scope = %q, replayed %v", got, replayed)
+	}
+
+	_, _, err = s.Do("sess-a", "k1", "fp2", run("changed", true))
+	var e *apierror.Error
+	if !errors.As(err, &e) || e.Code != apierror.CodeIdempotencyKeyReused || e.Retryable {
+		t.Errorf("reused key err = %v, want %s", err, apierror.CodeIdempotencyKeyReused)
+	}
+
+	// Results that are not kept run again on retry.
+	s.Do("sess-a", "k2", "fp1", run("failed", false))
+	if got, replayed, _ := s.Do("sess-a", "k2", "fp1", run("ok", true)); replayed || got != "ok" {
+		t.Errorf("retry after failure = %q, replayed %v; want a fresh run", got, replayed)
+	}
+
+	// Keys expire after the TTL.
+	now = now.Add(time.Hour)
+	if got, replayed, _ := s.Do("sess-a", "k1", "fp1", run("later", true)); replayed || got != "later" {
+		t.Errorf("after TTL = %q, replayed %v; want a fresh run", got, replayed)
+	}
+}
+
+func TestStore_InProgress(t *testing.T) {
+	s := NewStore[string](time.Hour)
+	var inner error
+	s.Do("sess", "k", "fp", func() (string, bool) {
+		_, _, inner = s.Do("sess", "k", "fp", func() (string, bool) { return "nested", true })
+		return "outer", true
+	})
+	var e *apierror.Error
+	if !errors.As(inner, &e) || e.Code != apierror.CodeRequestInProgress || !e.Retryable {
+		t.Errorf("concurrent use err = %v, want a retryable %s", inner, apierror.CodeRequestInProgress)
+	}
+}
+
+func TestStore_Evicts(t *testing.T) {
+	s := NewStore[int](time.Hour)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i <= MaxKeysPerScope; i++ {
+		s.now = func() time.Time { return start.Add(time.Duration(i) * time.Second) }
+		s.Do("sess", fmt.Sprint(i), "fp", func() (int, bool) { return i, true })
+	}
+	if n := len(s.scopes["sess"]); n != MaxKeysPerScope {
+		t.Errorf("kept %d keys, want %d", n, MaxKeysPerScope)
+	}
+	if _, ok := s.scopes["sess"]["0"]; ok {
+		t.Error("expected the oldest key to be evicted")
+	}
+}
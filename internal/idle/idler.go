@@ -0,0 +1,162 @@
+package idle
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	promapi "github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RequestCounter reports how many requests an app's route served recently.
+type RequestCounter interface {
+	Requests(ctx context.Context, app *iafv1alpha1.Application, window time.Duration) (float64, error)
+}
+
+// Idler puts idle applications to sleep by setting the IdleAnnotation; the
+// controller then scales them to zero.
+type Idler struct {
+	client   client.Client
+	requests RequestCounter
+	// defaultTimeout returns the platform idle timeout for apps that do not
+	// set spec.idleTimeout. Zero disables idling for them.
+	defaultTimeout func() time.Duration
+	logger         *slog.Logger
+	now            func() time.Time
+}
+
+// New creates an Idler.
+func New(c client.Client, requests RequestCounter, defaultTimeout func() time.Duration, logger *slog.Logger) *Idler {
+	return &Idler{
+		client:         c,
+		requests:       requests,
+		defaultTimeout: defaultTimeout,
+		logger:         logger,
+		now:            time.Now,
+	}
+}
+
+// Timeout returns the idle timeout that applies to app.
+func Timeout(app *iafv1alpha1.Application, defaultTimeout time.Duration) time.Duration {
+	if app.Spec.IdleTimeout != nil {
+		return app.Spec.IdleTimeout.Duration
+	}
+	return defaultTimeout
+}
+
+// RunOnce checks every running application once and puts those without
+// traffic for their idle timeout to sleep.
+func (i *Idler) RunOnce(ctx context.Context) {
+	var apps iafv1alpha1.ApplicationList
+	if err := i.client.List(ctx, &apps); err != nil {
+		i.logger.Error("idle: listing applications", "error", err)
+		return
+	}
+	defaultTimeout := i.defaultTimeout()
+	for idx := range apps.Items {
+		app := &apps.Items[idx]
+		timeout := Timeout(app, defaultTimeout)
+		if !i.eligible(app, timeout) {
+			continue
+		}
+		count, err := i.requests.Requests(ctx, app, timeout)
+		if err != nil {
+			i.logger.Warn("idle: counting requests", "namespace", app.Namespace, "app", app.Name, "error", err)
+			continue
+		}
+		if count > 0 {
+			continue
+		}
+		patch := client.MergeFrom(app.DeepCopy())
+		if app.Annotations == nil {
+			app.Annotations = map[string]string{}
+		}
+		app.Annotations[iafv1alpha1.IdleAnnotation] = iafv1alpha1.IdleSleeping
+		if err := i.client.Patch(ctx, app, patch); err != nil {
+			i.logger.Error("idle: putting app to sleep", "namespace", app.Namespace, "app", app.Name, "error", err)
+			continue
+		}
+		i.logger.Info("idle: app put to sleep", "namespace", app.Namespace, "app", app.Name, "idleTimeout", timeout)
+	}
+}
+
+// eligible reports whether app is an awake HTTP app that has been Running
+// for at least timeout, so a freshly deployed or woken app gets a full
+// window before it can be put back to sleep.
+func (i *Idler) eligible(app *iafv1alpha1.Application, timeout time.Duration) bool {
+	if timeout <= 0 || app.Spec.Suspended || app.Status.Phase != iafv1alpha1.ApplicationPhaseRunning ||
+		app.Annotations[iafv1alpha1.IdleAnnotation] != "" ||
+		iafv1alpha1.AppProtocol(app) == iafv1alpha1.ProtocolTCP {
+		return false
+	}
+	ready := meta.FindStatusCondition(app.Status.Conditions, "Ready")
+	return ready != nil && i.now().Sub(ready.LastTransitionTime.Time) >= timeout
+}
+
+// Start runs RunOnce every interval until ctx is cancelled. A zero interval
+// disables the idler.
+func (i *Idler) Start(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return nil
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			i.RunOnce(ctx)
+		}
+	}
+}
+
+// PrometheusCounter counts requests from Traefik's service metrics.
+type PrometheusCounter struct {
+	api promv1.API
+}
+
+// NewPrometheusCounter returns a RequestCounter backed by the Prometheus
+// server at url, which must scrape Traefik's metrics.
+func NewPrometheusCounter(url string) (*PrometheusCounter, error) {
+	c, err := promapi.NewClient(promapi.Config{Address: url})
+	if err != nil {
+		return nil, fmt.Errorf("creating prometheus client: %w", err)
+	}
+	return &PrometheusCounter{api: promv1.NewAPI(c)}, nil
+}
+
+// Requests sums traefik_service_requests_total for the app's route over
+// window. A service with no series has served no requests.
+func (p *PrometheusCounter) Requests(ctx context.Context, app *iafv1alpha1.Application, window time.Duration) (float64, error) {
+	query := fmt.Sprintf(`sum(increase(traefik_service_requests_total{service=%q}[%s]))`,
+		TraefikServiceName(app), model.Duration(window))
+	result, _, err := p.api.Query(ctx, query, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	vector, ok := result.(model.Vector)
+	if !ok {
+		return 0, fmt.Errorf("unexpected result type %s", result.Type())
+	}
+	if len(vector) == 0 {
+		return 0, nil
+	}
+	return float64(vector[0].Value), nil
+}
+
+// TraefikServiceName returns the name Traefik's Kubernetes CRD provider
+// gives the app's backend service in its metrics.
+func TraefikServiceName(app *iafv1alpha1.Application) string {
+	port := app.Spec.Port
+	if port == 0 {
+		port = 8080
+	}
+	return fmt.Sprintf("%s-%s-%d@kubernetescrd", app.Namespace, app.Name, port)
+}
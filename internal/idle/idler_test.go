@@ -0,0 +1,105 @@
+package idle_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/idle"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeCounter returns canned request counts keyed by app name.
+type fakeCounter map[string]float64
+
+func (f fakeCounter) Requests(_ context.Context, app *iafv1alpha1.Application, _ time.Duration) (float64, error) {
+	count, ok := f[app.Name]
+	if !ok {
+		return 0, errors.New("prometheus unavailable")
+	}
+	return count, nil
+}
+
+func runningApp(name string, readyFor time.Duration) *iafv1alpha1.Application {
+	return &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "iaf-test"},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx", Port: 8080},
+		Status: iafv1alpha1.ApplicationStatus{
+			Phase: iafv1alpha1.ApplicationPhaseRunning,
+			Conditions: []metav1.Condition{{
+				Type:               "Ready",
+				Status:             metav1.ConditionTrue,
+				Reason:             "Available",
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-readyFor)),
+			}},
+		},
+	}
+}
+
+func TestIdler_RunOnce(t *testing.T) {
+	idleApp := runningApp("idle", 3*time.Hour)
+	busy := runningApp("busy", 3*time.Hour)
+	fresh := runningApp("fresh", 10*time.Minute)
+	optedOut := runningApp("opted-out", 3*time.Hour)
+	optedOut.Spec.IdleTimeout = &metav1.Duration{}
+	custom := runningApp("custom", 3*time.Hour)
+	custom.Spec.IdleTimeout = &metav1.Duration{Duration: 4 * time.Hour}
+	suspended := runningApp("suspended", 3*time.Hour)
+	suspended.Spec.Suspended = true
+	tcp := runningApp("tcp", 3*time.Hour)
+	tcp.Spec.Protocol = iafv1alpha1.ProtocolTCP
+	unknown := runningApp("unknown", 3*time.Hour)
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(idleApp, busy, fresh, optedOut, custom, suspended, tcp, unknown).Build()
+
+	counter := fakeCounter{"idle": 0, "busy": 12, "fresh": 0, "opted-out": 0, "custom": 0, "suspended": 0, "tcp": 0}
+	idler := idle.New(k8sClient, counter, func() time.Duration { return time.Hour }, slog.Default())
+	idler.RunOnce(context.Background())
+
+	want := map[string]bool{"idle": true}
+	for _, name := range []string{"idle", "busy", "fresh", "opted-out", "custom", "suspended", "tcp", "unknown"} {
+		var app iafv1alpha1.Application
+		if err := k8sClient.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "iaf-test"}, &app); err != nil {
+			t.Fatal(err)
+		}
+		asleep := app.Annotations[iafv1alpha1.IdleAnnotation] == iafv1alpha1.IdleSleeping
+		if asleep != want[name] {
+			t.Errorf("%s: asleep = %v, want %v", name, asleep, want[name])
+		}
+	}
+}
+
+func TestWakePath(t *testing.T) {
+	secret := []byte("s3cret")
+	path := idle.WakePath(secret, "iaf-test", "myapp")
+	sig := path[len(idle.WakePathPrefix+"iaf-test/myapp/"):]
+
+	if !idle.VerifyWake(secret, "iaf-test", "myapp", sig) {
+		t.Errorf("expected signature in %q to verify", path)
+	}
+	if idle.VerifyWake(secret, "iaf-other", "myapp", sig) {
+		t.Error("signature must not verify for another namespace")
+	}
+	if idle.VerifyWake([]byte("other"), "iaf-test", "myapp", sig) {
+		t.Error("signature must not verify with another secret")
+	}
+	if idle.VerifyWake(nil, "iaf-test", "myapp", sig) {
+		t.Error("an empty secret must never verify")
+	}
+}
+
+func TestTraefikServiceName(t *testing.T) {
+	app := runningApp("myapp", 0)
+	if got := idle.TraefikServiceName(app); got != "iaf-test-myapp-8080@kubernetescrd" {
+		t.Errorf("got %q", got)
+	}
+}
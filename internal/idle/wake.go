@@ -0,0 +1,34 @@
+// Package idle scales applications to zero after a period without requests
+// and signs the wake-up links Traefik calls when a sleeping app is visited.
+package idle
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// WakePathPrefix is the API server path prefix for wake requests.
+const WakePathPrefix = "/wake/"
+
+// WakePath returns the signed API server path that wakes the named app.
+// The path is only ever seen by Traefik, which fetches it server-side from
+// the app's wake middleware.
+func WakePath(secret []byte, namespace, name string) string {
+	return fmt.Sprintf("%s%s/%s/%s", WakePathPrefix, namespace, name, sign(secret, namespace, name))
+}
+
+// VerifyWake reports whether sig was produced by WakePath for the app.
+func VerifyWake(secret []byte, namespace, name, sig string) bool {
+	if len(secret) == 0 {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(sign(secret, namespace, name)))
+}
+
+func sign(secret []byte, namespace, name string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(namespace + "/" + name))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,67 @@
+package k8s
+
+import (
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// IPAllowListMiddlewareName returns the name of the Traefik Middleware that
+// restricts an app's route to spec.access.ipAllowList.
+func IPAllowListMiddlewareName(appName string) string {
+	return appName + "-ipallowlist"
+}
+
+// RateLimitMiddlewareName returns the name of the Traefik Middleware that
+// enforces spec.access.requestsPerSecond on an app's route.
+func RateLimitMiddlewareName(appName string) string {
+	return appName + "-ratelimit"
+}
+
+// HasIPAllowList reports whether the application restricts source IPs.
+func HasIPAllowList(app *iafv1alpha1.Application) bool {
+	return app.Spec.Access != nil && len(app.Spec.Access.IPAllowList) > 0
+}
+
+// HasRateLimit reports whether the application is rate limited.
+func HasRateLimit(app *iafv1alpha1.Application) bool {
+	return app.Spec.Access != nil && app.Spec.Access.RequestsPerSecond > 0
+}
+
+// BuildIPAllowListMiddleware constructs an unstructured Traefik IPAllowList
+// (formerly IPWhiteList) Middleware from spec.access.ipAllowList.
+func BuildIPAllowListMiddleware(app *iafv1alpha1.Application) *unstructured.Unstructured {
+	obj := newRouteObject(app, TraefikMiddlewareGVK)
+	obj.SetName(IPAllowListMiddlewareName(app.Name))
+
+	sourceRange := []any{}
+	if app.Spec.Access != nil {
+		for _, cidr := range app.Spec.Access.IPAllowList {
+			sourceRange = append(sourceRange, cidr)
+		}
+	}
+	obj.Object["spec"] = map[string]any{
+		"ipAllowList": map[string]any{
+			"sourceRange": sourceRange,
+		},
+	}
+	return obj
+}
+
+// BuildRateLimitMiddleware constructs an unstructured Traefik RateLimit
+// Middleware from spec.access.requestsPerSecond. Burst is twice the average.
+func BuildRateLimitMiddleware(app *iafv1alpha1.Application) *unstructured.Unstructured {
+	obj := newRouteObject(app, TraefikMiddlewareGVK)
+	obj.SetName(RateLimitMiddlewareName(app.Name))
+
+	var rps int64
+	if app.Spec.Access != nil {
+		rps = int64(app.Spec.Access.RequestsPerSecond)
+	}
+	obj.Object["spec"] = map[string]any{
+		"rateLimit": map[string]any{
+			"average": rps,
+			"burst":   rps * 2,
+		},
+	}
+	return obj
+}
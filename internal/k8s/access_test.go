@@ -0,0 +1,59 @@
+package k8s
+
+import (
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+)
+
+func TestBuildAccessMiddlewares(t *testing.T) {
+	app := makeTestApp("my-app", "iaf-abc123")
+	app.Spec.Access = &iafv1alpha1.AccessConfig{
+		IPAllowList:       []string{"10.0.0.0/8", "203.0.113.7"},
+		RequestsPerSecond: 10,
+	}
+
+	allow := BuildIPAllowListMiddleware(app)
+	if allow.GetName() != "my-app-ipallowlist" {
+		t.Errorf("expected name 'my-app-ipallowlist', got %q", allow.GetName())
+	}
+	spec, _ := allow.Object["spec"].(map[string]any)
+	ipAllowList, _ := spec["ipAllowList"].(map[string]any)
+	sourceRange, _ := ipAllowList["sourceRange"].([]any)
+	if len(sourceRange) != 2 || sourceRange[0] != "10.0.0.0/8" {
+		t.Errorf("unexpected sourceRange %v", sourceRange)
+	}
+
+	limit := BuildRateLimitMiddleware(app)
+	spec, _ = limit.Object["spec"].(map[string]any)
+	rateLimit, _ := spec["rateLimit"].(map[string]any)
+	if rateLimit["average"] != int64(10) || rateLimit["burst"] != int64(20) {
+		t.Errorf("expected average 10 / burst 20, got %v", rateLimit)
+	}
+}
+
+func TestBuildIngressRoute_MiddlewareOrder(t *testing.T) {
+	app := makeTestApp("my-app", "iaf-abc123")
+	app.Spec.Authentication = iafv1alpha1.AuthenticationBasic
+	app.Spec.Access = &iafv1alpha1.AccessConfig{
+		IPAllowList:       []string{"10.0.0.0/8"},
+		RequestsPerSecond: 10,
+	}
+	route := BuildIngressRoute(app, "example.com", true)
+
+	spec, _ := route.Object["spec"].(map[string]any)
+	routes, _ := spec["routes"].([]any)
+	r, _ := routes[0].(map[string]any)
+	middlewares, _ := r["middlewares"].([]any)
+
+	want := []string{"my-app-ipallowlist", "my-app-ratelimit", "my-app-auth"}
+	if len(middlewares) != len(want) {
+		t.Fatalf("expected %d middlewares, got %v", len(want), middlewares)
+	}
+	for i, name := range want {
+		mw, _ := middlewares[i].(map[string]any)
+		if mw["name"] != name {
+			t.Errorf("middleware[%d] = %v, want %q", i, mw["name"], name)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package k8s
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// PrometheusRuleGVK is the GroupVersionKind for Prometheus Operator PrometheusRule CRs.
+var PrometheusRuleGVK = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "PrometheusRule",
+}
+
+// AlertType selects one of the alert templates agents may use.
+type AlertType string
+
+const (
+	// AlertTypeErrorRate fires when the percentage of 5xx responses exceeds the threshold.
+	AlertTypeErrorRate AlertType = "error_rate"
+	// AlertTypeLatencyP95 fires when the p95 request duration in seconds exceeds the threshold.
+	AlertTypeLatencyP95 AlertType = "latency_p95"
+	// AlertTypePodRestarts fires when the app's containers restart more than
+	// the threshold times in 15 minutes.
+	AlertTypePodRestarts AlertType = "pod_restarts"
+	// AlertTypeUptime fires when the percentage of successful uptime check
+	// probes over 15 minutes falls below the threshold.
+	AlertTypeUptime AlertType = "uptime"
+)
+
+const (
+	// LabelAlert marks PrometheusRules created by the alert tools.
+	LabelAlert = "iaf.io/alert"
+
+	// Annotations recording the template parameters of an alert.
+	AnnotationAlertType      = "iaf.io/alert-type"
+	AnnotationAlertThreshold = "iaf.io/alert-threshold"
+	AnnotationAlertFor       = "iaf.io/alert-for"
+	AnnotationAlertSeverity  = "iaf.io/alert-severity"
+)
+
+// Alert describes an alert on an application built from a template.
+type Alert struct {
+	Name      string
+	Type      AlertType
+	Threshold float64
+	// For is how long the condition must hold before the alert fires, e.g. "5m".
+	For string
+	// Severity is "warning" or "critical".
+	Severity string
+}
+
+// alertForRegex matches the durations accepted for Alert.For. Prometheus
+// rejects the fractional and compound forms Go also accepts.
+var alertForRegex = regexp.MustCompile(`^[1-9][0-9]*[smh]$`)
+
+// Validate checks the template parameters of the alert. Names are validated
+// separately as DNS labels.
+func (a Alert) Validate() error {
+	switch a.Type {
+	case AlertTypeErrorRate:
+		if a.Threshold <= 0 || a.Threshold > 100 {
+			return fmt.Errorf("error_rate threshold must be a percentage above 0 and at most 100")
+		}
+	case AlertTypeUptime:
+		if a.Threshold <= 0 || a.Threshold > 100 {
+			return fmt.Errorf("uptime threshold must be a percentage above 0 and at most 100")
+		}
+	case AlertTypeLatencyP95:
+		if a.Threshold <= 0 || a.Threshold > 60 {
+			return fmt.Errorf("latency_p95 threshold must be in seconds, above 0 and at most 60")
+		}
+	case AlertTypePodRestarts:
+		if a.Threshold < 0 || a.Threshold > 100 || a.Threshold != math.Trunc(a.Threshold) {
+			return fmt.Errorf("pod_restarts threshold must be a whole number of restarts from 0 to 100")
+		}
+	default:
+		return fmt.Errorf("unknown alert type %q: use 'error_rate', 'latency_p95', 'pod_restarts' or 'uptime'", a.Type)
+	}
+	if !alertForRegex.MatchString(a.For) {
+		return fmt.Errorf("for %q is invalid: use a whole number of seconds, minutes or hours such as '5m'", a.For)
+	}
+	if d, _ := time.ParseDuration(a.For); d < time.Minute || d > time.Hour {
+		return fmt.Errorf("for must be between 1m and 1h, got %s", a.For)
+	}
+	if a.Severity != "warning" && a.Severity != "critical" {
+		return fmt.Errorf("severity must be 'warning' or 'critical'")
+	}
+	return nil
+}
+
+// AlertExpr returns the PromQL expression of alert for the application name
+// in namespace, and a description of when it fires. HTTP metrics follow the
+// platform metrics standard and are selected by the app's Service. Uptime
+// alerts fire below the threshold, the others above it.
+func AlertExpr(namespace, name string, alert Alert) (expr, description string, err error) {
+	threshold := strconv.FormatFloat(alert.Threshold, 'f', -1, 64)
+	selector := fmt.Sprintf(`namespace=%q, service=%q`, namespace, name)
+	switch alert.Type {
+	case AlertTypeErrorRate:
+		return fmt.Sprintf(`100 * sum(rate(http_requests_total{%s, status_code=~"5.."}[5m])) / sum(rate(http_requests_total{%s}[5m])) > %s`, selector, selector, threshold),
+			fmt.Sprintf("More than %s%% of requests to %s fail with a 5xx status.", threshold, name), nil
+	case AlertTypeLatencyP95:
+		return fmt.Sprintf(`histogram_quantile(0.95, sum by (le) (rate(http_request_duration_seconds_bucket{%s}[5m]))) > %s`, selector, threshold),
+			fmt.Sprintf("The p95 latency of %s is above %ss.", name, threshold), nil
+	case AlertTypePodRestarts:
+		return fmt.Sprintf(`sum(increase(kube_pod_container_status_restarts_total{namespace=%q, pod=~%q}[15m])) > %s`, namespace, AppPodPattern(name), threshold),
+			fmt.Sprintf("Containers of %s restarted more than %s times in 15 minutes.", name, threshold), nil
+	case AlertTypeUptime:
+		return fmt.Sprintf(`100 * avg_over_time(probe_success{%s}[15m]) < %s`, UptimeSelector(namespace, name), threshold),
+			fmt.Sprintf("Fewer than %s%% of uptime checks of %s succeeded in 15 minutes.", threshold, name), nil
+	}
+	return "", "", fmt.Errorf("unknown alert type %q", alert.Type)
+}
+
+// BuildPrometheusRule constructs a PrometheusRule holding alert for app.
+// ruleLabels are added so the platform Prometheus selects the rule; the
+// alert itself carries namespace, application and severity labels that the
+// platform Alertmanager routes on.
+func BuildPrometheusRule(app *iafv1alpha1.Application, alert Alert, ruleLabels map[string]string) (*unstructured.Unstructured, error) {
+	expr, description, err := AlertExpr(app.Namespace, app.Name, alert)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{}
+	for k, v := range ruleLabels {
+		labels[k] = v
+	}
+	maps.Copy(labels, AppLabels(app))
+	labels[LabelAlert] = "true"
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(PrometheusRuleGVK)
+	obj.SetName(alert.Name)
+	obj.SetNamespace(app.Namespace)
+	obj.SetLabels(labels)
+	obj.SetAnnotations(map[string]string{
+		AnnotationAlertType:      string(alert.Type),
+		AnnotationAlertThreshold: strconv.FormatFloat(alert.Threshold, 'f', -1, 64),
+		AnnotationAlertFor:       alert.For,
+		AnnotationAlertSeverity:  alert.Severity,
+	})
+	// Alerts are deleted with the application they watch.
+	obj.SetOwnerReferences([]metav1.OwnerReference{
+		{
+			APIVersion: iafv1alpha1.GroupVersion.String(),
+			Kind:       "Application",
+			Name:       app.Name,
+			UID:        app.UID,
+		},
+	})
+	obj.Object["spec"] = map[string]any{
+		"groups": []any{
+			map[string]any{
+				"name": "iaf-" + alert.Name,
+				"rules": []any{
+					map[string]any{
+						// Prometheus alert names may not contain hyphens.
+						"alert": strings.ReplaceAll(alert.Name, "-", "_"),
+						"expr":  expr,
+						"for":   alert.For,
+						// The expressions aggregate away series labels,
+						// so the routing labels are set explicitly.
+						"labels": map[string]any{
+							"severity":    alert.Severity,
+							"namespace":   app.Namespace,
+							"application": app.Name,
+							"iaf_alert":   "true",
+						},
+						"annotations": map[string]any{
+							"summary":     fmt.Sprintf("%s alert %s on %s", alert.Severity, alert.Name, app.Name),
+							"description": description,
+						},
+					},
+				},
+			},
+		},
+	}
+	return obj, nil
+}
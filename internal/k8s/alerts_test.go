@@ -0,0 +1,104 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestAlertExpr(t *testing.T) {
+	tests := []struct {
+		alert   Alert
+		want    string
+		wantErr bool
+	}{
+		{
+			alert: Alert{Type: AlertTypeErrorRate, Threshold: 2.5},
+			want:  `100 * sum(rate(http_requests_total{namespace="iaf-abc", service="web", status_code=~"5.."}[5m])) / sum(rate(http_requests_total{namespace="iaf-abc", service="web"}[5m])) > 2.5`,
+		},
+		{
+			alert: Alert{Type: AlertTypeLatencyP95, Threshold: 0.5},
+			want:  `histogram_quantile(0.95, sum by (le) (rate(http_request_duration_seconds_bucket{namespace="iaf-abc", service="web"}[5m]))) > 0.5`,
+		},
+		{
+			alert: Alert{Type: AlertTypePodRestarts, Threshold: 3},
+			want:  `sum(increase(kube_pod_container_status_restarts_total{namespace="iaf-abc", pod=~"web-[a-z0-9]+-[a-z0-9]+"}[15m])) > 3`,
+		},
+		{
+			alert: Alert{Type: AlertTypeUptime, Threshold: 99},
+			want:  `100 * avg_over_time(probe_success{job="iaf-uptime", namespace="iaf-abc", application="web"}[15m]) < 99`,
+		},
+		{alert: Alert{Type: "cpu"}, wantErr: true},
+	}
+	for _, tc := range tests {
+		got, _, err := AlertExpr("iaf-abc", "web", tc.alert)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("AlertExpr(%s) error = %v, wantErr %v", tc.alert.Type, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("AlertExpr(%s) = %s, want %s", tc.alert.Type, got, tc.want)
+		}
+	}
+}
+
+func TestAlert_Validate(t *testing.T) {
+	valid := Alert{Type: AlertTypeErrorRate, Threshold: 5, For: "5m", Severity: "warning"}
+	tests := []struct {
+		name    string
+		modify  func(*Alert)
+		wantErr bool
+	}{
+		{"valid", func(*Alert) {}, false},
+		{"latency", func(a *Alert) { a.Type, a.Threshold = AlertTypeLatencyP95, 0.25 }, false},
+		{"restarts zero", func(a *Alert) { a.Type, a.Threshold = AlertTypePodRestarts, 0 }, false},
+		{"uptime", func(a *Alert) { a.Type, a.Threshold = AlertTypeUptime, 99.9 }, false},
+		{"uptime zero", func(a *Alert) { a.Type, a.Threshold = AlertTypeUptime, 0 }, true},
+		{"unknown type", func(a *Alert) { a.Type = "cpu" }, true},
+		{"error rate above 100", func(a *Alert) { a.Threshold = 150 }, true},
+		{"latency zero", func(a *Alert) { a.Type, a.Threshold = AlertTypeLatencyP95, 0 }, true},
+		{"fractional restarts", func(a *Alert) { a.Type, a.Threshold = AlertTypePodRestarts, 1.5 }, true},
+		{"compound for", func(a *Alert) { a.For = "1h30m" }, true},
+		{"for too short", func(a *Alert) { a.For = "30s" }, true},
+		{"for too long", func(a *Alert) { a.For = "2h" }, true},
+		{"bad severity", func(a *Alert) { a.Severity = "page" }, true},
+	}
+	for _, tc := range tests {
+		a := valid
+		tc.modify(&a)
+		if err := a.Validate(); (err != nil) != tc.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestBuildPrometheusRule(t *testing.T) {
+	app := &iafv1alpha1.Application{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "iaf-abc", UID: "uid-1"}}
+	alert := Alert{Name: "web-errors", Type: AlertTypeErrorRate, Threshold: 5, For: "10m", Severity: "critical"}
+	obj, err := BuildPrometheusRule(app, alert, map[string]string{"release": "kube-prometheus-stack"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.GetLabels()["release"] != "kube-prometheus-stack" || obj.GetLabels()[LabelAlert] != "true" {
+		t.Errorf("unexpected labels %v", obj.GetLabels())
+	}
+	if refs := obj.GetOwnerReferences(); len(refs) != 1 || refs[0].UID != "uid-1" {
+		t.Errorf("expected the rule to be owned by the app, got %v", refs)
+	}
+	groups, _, _ := unstructured.NestedSlice(obj.Object, "spec", "groups")
+	rules, _, _ := unstructured.NestedSlice(groups[0].(map[string]any), "rules")
+	rule := rules[0].(map[string]any)
+	if rule["alert"] != "web_errors" || rule["for"] != "10m" {
+		t.Errorf("unexpected rule %v", rule)
+	}
+	labels := rule["labels"].(map[string]any)
+	if labels["severity"] != "critical" || labels["namespace"] != "iaf-abc" || labels["application"] != "web" {
+		t.Errorf("unexpected alert labels %v", labels)
+	}
+	if !strings.Contains(rule["expr"].(string), "> 5") {
+		t.Errorf("expected the threshold in the expression, got %q", rule["expr"])
+	}
+}
@@ -0,0 +1,32 @@
+package k8s
+
+import (
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ArchitectureScheduling returns the node selector and affinity that keep an
+// application's pods on nodes of its architecture. A multi-architecture app
+// may run on amd64 or arm64 nodes. Both are nil when arch is empty.
+func ArchitectureScheduling(arch iafv1alpha1.ApplicationArchitecture) (map[string]string, *corev1.Affinity) {
+	switch arch {
+	case "":
+		return nil, nil
+	case iafv1alpha1.ArchitectureMulti:
+		return nil, &corev1.Affinity{
+			NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchExpressions: []corev1.NodeSelectorRequirement{{
+							Key:      corev1.LabelArchStable,
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   []string{string(iafv1alpha1.ArchitectureAMD64), string(iafv1alpha1.ArchitectureARM64)},
+						}},
+					}},
+				},
+			},
+		}
+	default:
+		return map[string]string{corev1.LabelArchStable: string(arch)}, nil
+	}
+}
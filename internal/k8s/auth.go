@@ -0,0 +1,144 @@
+package k8s
+
+import (
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// OAuthProxyPort is the port the oauth2-proxy sidecar listens on. The app
+	// Service targets this port when authentication is oauth-proxy.
+	OAuthProxyPort int32 = 4180
+
+	// BasicAuthUsername is the username written to generated basic-auth Secrets.
+	BasicAuthUsername = "iaf"
+
+	// AnnotationCredentialsRetrieved marks a basic-auth Secret whose password has
+	// already been returned by get_app_credentials.
+	AnnotationCredentialsRetrieved = "iaf.io/credentials-retrieved"
+)
+
+// TraefikMiddlewareGVK is the GroupVersionKind for Traefik Middleware CRs.
+var TraefikMiddlewareGVK = schema.GroupVersionKind{
+	Group:   "traefik.io",
+	Version: "v1alpha1",
+	Kind:    "Middleware",
+}
+
+// BasicAuthSecretName returns the name of the Secret holding an app's generated
+// basic-auth credentials.
+func BasicAuthSecretName(appName string) string {
+	return appName + "-basic-auth"
+}
+
+// OAuthProxySecretName returns the name of the Secret holding an app's
+// oauth2-proxy client and cookie secrets.
+func OAuthProxySecretName(appName string) string {
+	return appName + "-oauth-proxy"
+}
+
+// AuthMiddlewareName returns the name of the Traefik Middleware that enforces
+// basic auth on an app's route.
+func AuthMiddlewareName(appName string) string {
+	return appName + "-auth"
+}
+
+// BuildBasicAuthSecret constructs a kubernetes.io/basic-auth Secret for the
+// application. Traefik's BasicAuth middleware reads this Secret type directly.
+func BuildBasicAuthSecret(app *iafv1alpha1.Application, password string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: authObjectMeta(app, BasicAuthSecretName(app.Name)),
+		Type:       corev1.SecretTypeBasicAuth,
+		StringData: map[string]string{
+			corev1.BasicAuthUsernameKey: BasicAuthUsername,
+			corev1.BasicAuthPasswordKey: password,
+		},
+	}
+}
+
+// BuildOAuthProxySecret constructs the Secret mounted into the oauth2-proxy
+// sidecar as environment variables.
+func BuildOAuthProxySecret(app *iafv1alpha1.Application, clientSecret, cookieSecret string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: authObjectMeta(app, OAuthProxySecretName(app.Name)),
+		Type:       corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			"client-secret": clientSecret,
+			"cookie-secret": cookieSecret,
+		},
+	}
+}
+
+// BuildBasicAuthMiddleware constructs an unstructured Traefik Middleware that
+// requires the credentials in the app's basic-auth Secret.
+func BuildBasicAuthMiddleware(app *iafv1alpha1.Application) *unstructured.Unstructured {
+	obj := newRouteObject(app, TraefikMiddlewareGVK)
+	obj.SetName(AuthMiddlewareName(app.Name))
+	obj.Object["spec"] = map[string]any{
+		"basicAuth": map[string]any{
+			"secret": BasicAuthSecretName(app.Name),
+		},
+	}
+	return obj
+}
+
+// BuildOAuthProxyContainer returns the oauth2-proxy sidecar container that
+// authenticates requests against issuerURL before proxying them to the app
+// on localhost:upstreamPort.
+func BuildOAuthProxyContainer(app *iafv1alpha1.Application, image, issuerURL, clientID string, upstreamPort int32) corev1.Container {
+	secretName := OAuthProxySecretName(app.Name)
+	fromSecret := func(key string) *corev1.EnvVarSource {
+		return &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		}
+	}
+	return corev1.Container{
+		Name:  "oauth-proxy",
+		Image: image,
+		Args: []string{
+			"--provider=oidc",
+			"--oidc-issuer-url=" + issuerURL,
+			"--client-id=" + clientID,
+			fmt.Sprintf("--http-address=0.0.0.0:%d", OAuthProxyPort),
+			fmt.Sprintf("--upstream=http://127.0.0.1:%d", upstreamPort),
+			"--email-domain=*",
+			"--reverse-proxy=true",
+			"--skip-provider-button=true",
+		},
+		Env: []corev1.EnvVar{
+			{Name: "OAUTH2_PROXY_CLIENT_SECRET", ValueFrom: fromSecret("client-secret")},
+			{Name: "OAUTH2_PROXY_COOKIE_SECRET", ValueFrom: fromSecret("cookie-secret")},
+		},
+		Ports: []corev1.ContainerPort{
+			{Name: "oauth-proxy", ContainerPort: OAuthProxyPort, Protocol: corev1.ProtocolTCP},
+		},
+		SecurityContext: &corev1.SecurityContext{
+			RunAsNonRoot:             boolPtr(true),
+			AllowPrivilegeEscalation: boolPtr(false),
+		},
+	}
+}
+
+func authObjectMeta(app *iafv1alpha1.Application, name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: app.Namespace,
+		Labels:    AppLabels(app),
+		OwnerReferences: []metav1.OwnerReference{
+			{
+				APIVersion: iafv1alpha1.GroupVersion.String(),
+				Kind:       "Application",
+				Name:       app.Name,
+				UID:        app.UID,
+			},
+		},
+	}
+}
@@ -0,0 +1,103 @@
+package k8s
+
+import (
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestBuildBasicAuthMiddleware(t *testing.T) {
+	app := makeTestApp("my-app", "iaf-abc123")
+	mw := BuildBasicAuthMiddleware(app)
+
+	if mw.GetName() != "my-app-auth" {
+		t.Errorf("expected name 'my-app-auth', got %q", mw.GetName())
+	}
+	if mw.GroupVersionKind() != TraefikMiddlewareGVK {
+		t.Errorf("expected GVK %v, got %v", TraefikMiddlewareGVK, mw.GroupVersionKind())
+	}
+	spec, _ := mw.Object["spec"].(map[string]any)
+	basicAuth, _ := spec["basicAuth"].(map[string]any)
+	if basicAuth["secret"] != "my-app-basic-auth" {
+		t.Errorf("expected basicAuth.secret 'my-app-basic-auth', got %v", basicAuth["secret"])
+	}
+}
+
+func TestBuildBasicAuthSecret(t *testing.T) {
+	app := makeTestApp("my-app", "iaf-abc123")
+	secret := BuildBasicAuthSecret(app, "s3cret")
+
+	if secret.Type != corev1.SecretTypeBasicAuth {
+		t.Errorf("expected type %q, got %q", corev1.SecretTypeBasicAuth, secret.Type)
+	}
+	if secret.StringData["username"] != BasicAuthUsername || secret.StringData["password"] != "s3cret" {
+		t.Errorf("unexpected credentials in StringData: %v", secret.StringData)
+	}
+	if secret.Labels["iaf.io/application"] != "my-app" {
+		t.Errorf("expected iaf.io/application label, got %v", secret.Labels)
+	}
+	if len(secret.OwnerReferences) != 1 || secret.OwnerReferences[0].Name != "my-app" {
+		t.Errorf("expected owner reference to Application 'my-app', got %v", secret.OwnerReferences)
+	}
+}
+
+func TestBuildIngressRoute_BasicAuthMiddleware(t *testing.T) {
+	app := makeTestApp("my-app", "iaf-abc123")
+	app.Spec.Authentication = iafv1alpha1.AuthenticationBasic
+	route := BuildIngressRoute(app, "example.com", true)
+
+	spec, _ := route.Object["spec"].(map[string]any)
+	routes, _ := spec["routes"].([]any)
+	r, _ := routes[0].(map[string]any)
+	middlewares, _ := r["middlewares"].([]any)
+	if len(middlewares) != 1 {
+		t.Fatalf("expected 1 middleware, got %v", r["middlewares"])
+	}
+	mw, _ := middlewares[0].(map[string]any)
+	if mw["name"] != "my-app-auth" {
+		t.Errorf("expected middleware 'my-app-auth', got %v", mw["name"])
+	}
+
+	app.Spec.Authentication = iafv1alpha1.AuthenticationOAuthProxy
+	route = BuildIngressRoute(app, "example.com", true)
+	spec, _ = route.Object["spec"].(map[string]any)
+	routes, _ = spec["routes"].([]any)
+	r, _ = routes[0].(map[string]any)
+	if _, ok := r["middlewares"]; ok {
+		t.Error("expected no middleware for oauth-proxy (the sidecar enforces auth)")
+	}
+}
+
+func TestBuildOAuthProxyContainer(t *testing.T) {
+	app := makeTestApp("my-app", "iaf-abc123")
+	c := BuildOAuthProxyContainer(app, "oauth2-proxy:test", "https://idp.example.com", "iaf", 8080)
+
+	if c.Image != "oauth2-proxy:test" {
+		t.Errorf("expected image 'oauth2-proxy:test', got %q", c.Image)
+	}
+	wantArgs := map[string]bool{
+		"--oidc-issuer-url=https://idp.example.com": false,
+		"--client-id=iaf":                  false,
+		"--upstream=http://127.0.0.1:8080": false,
+		"--http-address=0.0.0.0:4180":      false,
+	}
+	for _, a := range c.Args {
+		if _, ok := wantArgs[a]; ok {
+			wantArgs[a] = true
+		}
+	}
+	for a, found := range wantArgs {
+		if !found {
+			t.Errorf("expected arg %q in %v", a, c.Args)
+		}
+	}
+	for _, e := range c.Env {
+		if e.Value != "" || e.ValueFrom == nil || e.ValueFrom.SecretKeyRef.Name != "my-app-oauth-proxy" {
+			t.Errorf("expected env %q to reference Secret my-app-oauth-proxy, got %+v", e.Name, e)
+		}
+	}
+	if c.SecurityContext == nil || c.SecurityContext.RunAsNonRoot == nil || !*c.SecurityContext.RunAsNonRoot {
+		t.Error("expected sidecar to run as non-root")
+	}
+}
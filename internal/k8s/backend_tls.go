@@ -0,0 +1,149 @@
+package k8s
+
+import (
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// TraefikServersTransportGVK is the GroupVersionKind for Traefik
+// ServersTransport CRs.
+var TraefikServersTransportGVK = schema.GroupVersionKind{
+	Group:   "traefik.io",
+	Version: "v1alpha1",
+	Kind:    "ServersTransport",
+}
+
+const (
+	// BackendTLSDir is where the app's serving certificate is mounted in its
+	// container: tls.crt, tls.key and the issuing CA in ca.crt.
+	BackendTLSDir = "/etc/iaf/backend-tls"
+	// BackendTLSVolume is the pod volume holding the serving certificate.
+	BackendTLSVolume = "iaf-backend-tls"
+)
+
+// BackendTLSName returns the name of the app's serving Certificate, of the
+// Secret cert-manager stores it in and of the ServersTransport that trusts
+// it.
+func BackendTLSName(appName string) string {
+	return appName + "-backend-tls"
+}
+
+// BackendServerName returns the name the app's serving certificate is issued
+// for and Traefik verifies: the cluster DNS name of its Service.
+func BackendServerName(app *iafv1alpha1.Application) string {
+	return fmt.Sprintf("%s.%s.svc", app.Name, app.Namespace)
+}
+
+// BuildBackendCertificate constructs the cert-manager Certificate the app
+// serves TLS to the ingress with, issued by issuerName (a CA ClusterIssuer)
+// for the cluster DNS names of its Service. Like the app's public
+// Certificate it is owned by the Application, and its Secret carries the
+// application labels so it is deleted with the Certificate.
+func BuildBackendCertificate(app *iafv1alpha1.Application, issuerName string) *unstructured.Unstructured {
+	obj := newBackendTLSObject(app, CertificateGVK)
+	secretLabels := map[string]any{}
+	for k, v := range AppLabels(app) {
+		secretLabels[k] = v
+	}
+	obj.Object["spec"] = map[string]any{
+		"secretName": BackendTLSName(app.Name),
+		"dnsNames": []any{
+			BackendServerName(app),
+			BackendServerName(app) + ".cluster.local",
+		},
+		"usages": []any{"server auth", "digital signature", "key encipherment"},
+		"secretTemplate": map[string]any{
+			"labels": secretLabels,
+		},
+		"issuerRef": map[string]any{
+			"name": issuerName,
+			"kind": "ClusterIssuer",
+		},
+	}
+	return obj
+}
+
+// BuildServersTransport constructs the Traefik ServersTransport that makes
+// the ingress verify the app's serving certificate against the CA cert-manager
+// stores next to it.
+func BuildServersTransport(app *iafv1alpha1.Application) *unstructured.Unstructured {
+	obj := newBackendTLSObject(app, TraefikServersTransportGVK)
+	obj.Object["spec"] = map[string]any{
+		"serverName":     BackendServerName(app),
+		"rootCAsSecrets": []any{BackendTLSName(app.Name)},
+	}
+	return obj
+}
+
+// UseBackendTLS makes a route built by BuildIngressRoute connect to the app
+// over TLS through its ServersTransport. For grpc this replaces h2c; Traefik
+// negotiates HTTP/2 over TLS instead.
+func UseBackendTLS(route *unstructured.Unstructured, app *iafv1alpha1.Application) {
+	routes, _, _ := unstructured.NestedSlice(route.Object, "spec", "routes")
+	for i, r := range routes {
+		m, ok := r.(map[string]any)
+		if !ok {
+			continue
+		}
+		services, _ := m["services"].([]any)
+		for j, s := range services {
+			svc, ok := s.(map[string]any)
+			if !ok {
+				continue
+			}
+			svc["scheme"] = "https"
+			svc["serversTransport"] = BackendTLSName(app.Name)
+			services[j] = svc
+		}
+		routes[i] = m
+	}
+	_ = unstructured.SetNestedSlice(route.Object, routes, "spec", "routes")
+}
+
+// BackendTLSMount returns the volume holding the app's serving certificate
+// and the mount placing it at BackendTLSDir. The directory is mounted whole
+// so renewed certificates appear in it.
+func BackendTLSMount(app *iafv1alpha1.Application) (corev1.Volume, corev1.VolumeMount) {
+	volume := corev1.Volume{
+		Name: BackendTLSVolume,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: BackendTLSName(app.Name)},
+		},
+	}
+	mount := corev1.VolumeMount{Name: BackendTLSVolume, MountPath: BackendTLSDir, ReadOnly: true}
+	return volume, mount
+}
+
+// BackendTLSEnv returns the paths of the mounted certificate and key, for
+// the app to serve TLS with.
+func BackendTLSEnv() []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{Name: "IAF_TLS_CERT_FILE", Value: BackendTLSDir + "/" + corev1.TLSCertKey},
+		{Name: "IAF_TLS_KEY_FILE", Value: BackendTLSDir + "/" + corev1.TLSPrivateKeyKey},
+	}
+}
+
+// newBackendTLSObject returns an empty object of the given kind named for
+// the app's backend TLS, with the standard IAF labels and an owner reference
+// to the application.
+func newBackendTLSObject(app *iafv1alpha1.Application, gvk schema.GroupVersionKind) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetName(BackendTLSName(app.Name))
+	obj.SetNamespace(app.Namespace)
+	obj.SetLabels(AppLabels(app))
+	obj.SetOwnerReferences([]metav1.OwnerReference{
+		{
+			APIVersion: iafv1alpha1.GroupVersion.String(),
+			Kind:       "Application",
+			Name:       app.Name,
+			UID:        app.UID,
+		},
+	})
+	return obj
+}
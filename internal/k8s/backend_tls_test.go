@@ -0,0 +1,49 @@
+package k8s
+
+import (
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestBuildBackendCertificate(t *testing.T) {
+	app := &iafv1alpha1.Application{}
+	app.Name, app.Namespace = "api", "iaf-s1"
+
+	cert := BuildBackendCertificate(app, "internal-ca")
+	if cert.GetName() != "api-backend-tls" || cert.GetNamespace() != "iaf-s1" || len(cert.GetOwnerReferences()) != 1 {
+		t.Errorf("unexpected certificate %s/%s owned by %v", cert.GetNamespace(), cert.GetName(), cert.GetOwnerReferences())
+	}
+	names, _, _ := unstructured.NestedStringSlice(cert.Object, "spec", "dnsNames")
+	if len(names) != 2 || names[0] != "api.iaf-s1.svc" || names[1] != "api.iaf-s1.svc.cluster.local" {
+		t.Errorf("unexpected dnsNames %v", names)
+	}
+	if secret, _, _ := unstructured.NestedString(cert.Object, "spec", "secretName"); secret != "api-backend-tls" {
+		t.Errorf("secretName = %q", secret)
+	}
+
+	// The ingress verifies the name the certificate is issued for, against
+	// the CA stored with it.
+	transport := BuildServersTransport(app)
+	serverName, _, _ := unstructured.NestedString(transport.Object, "spec", "serverName")
+	roots, _, _ := unstructured.NestedStringSlice(transport.Object, "spec", "rootCAsSecrets")
+	if serverName != names[0] || len(roots) != 1 || roots[0] != "api-backend-tls" {
+		t.Errorf("unexpected servers transport spec %v", transport.Object["spec"])
+	}
+}
+
+func TestUseBackendTLS(t *testing.T) {
+	app := &iafv1alpha1.Application{}
+	app.Name, app.Namespace = "api", "iaf-s1"
+	app.Spec.Protocol = iafv1alpha1.ProtocolGRPC
+
+	route := BuildIngressRoute(app, "example.com", true)
+	UseBackendTLS(route, app)
+	routes, _, _ := unstructured.NestedSlice(route.Object, "spec", "routes")
+	service := routes[0].(map[string]any)["services"].([]any)[0].(map[string]any)
+	// gRPC is carried over TLS in place of h2c.
+	if service["scheme"] != "https" || service["serversTransport"] != "api-backend-tls" {
+		t.Errorf("unexpected route service %v", service)
+	}
+}
@@ -0,0 +1,70 @@
+package k8s
+
+import (
+	"slices"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// CABundlePath is where the platform CA bundle is mounted in app
+	// containers, next to the system certificates.
+	CABundlePath = "/etc/ssl/certs/iaf-ca-bundle.crt"
+	// CABundleVolume is the pod volume holding the CA bundle.
+	CABundleVolume = "iaf-ca-bundle"
+	// caBundleKey is the key of the CA bundle in the app's copy.
+	caBundleKey = "ca.crt"
+)
+
+// CABundle is the ConfigMap key holding the PEM certificates app pods trust
+// for outbound TLS, e.g. the CA of a TLS-inspecting proxy or of internal
+// services.
+type CABundle struct {
+	Namespace string
+	Name      string
+	Key       string
+}
+
+// IsZero reports whether no CA bundle is configured.
+func (b CABundle) IsZero() bool {
+	return b.Name == ""
+}
+
+// CABundleConfigMapName returns the name of the app's copy of the platform
+// CA bundle; a pod can only mount ConfigMaps of its own namespace.
+func CABundleConfigMapName(appName string) string {
+	return appName + "-ca-bundle"
+}
+
+// BuildCABundleConfigMap returns the app's copy of the CA bundle pem.
+func BuildCABundleConfigMap(app *iafv1alpha1.Application, pem string) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{Data: map[string]string{caBundleKey: pem}}
+	cm.Name = CABundleConfigMapName(app.Name)
+	cm.Namespace = app.Namespace
+	cm.Labels = AppLabels(app)
+	return cm
+}
+
+// CABundleMount returns the volume holding the app's copy of the CA bundle
+// and the mount placing it at CABundlePath.
+func CABundleMount(app *iafv1alpha1.Application) (corev1.Volume, corev1.VolumeMount) {
+	volume := corev1.Volume{
+		Name: CABundleVolume,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: CABundleConfigMapName(app.Name)}},
+		},
+	}
+	mount := corev1.VolumeMount{Name: CABundleVolume, MountPath: CABundlePath, SubPath: caBundleKey, ReadOnly: true}
+	return volume, mount
+}
+
+// CABundleEnv returns SSL_CERT_FILE pointing at the mounted CA bundle, which
+// OpenSSL, Go, Python and Ruby read in place of the system certificates.
+// It is left out when app already sets it in own, so the app's value wins.
+func CABundleEnv(own []iafv1alpha1.EnvVar) []corev1.EnvVar {
+	if slices.ContainsFunc(own, func(e iafv1alpha1.EnvVar) bool { return e.Name == "SSL_CERT_FILE" }) {
+		return nil
+	}
+	return []corev1.EnvVar{{Name: "SSL_CERT_FILE", Value: CABundlePath}}
+}
@@ -0,0 +1,32 @@
+package k8s
+
+import (
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+)
+
+func TestCABundleMount(t *testing.T) {
+	app := &iafv1alpha1.Application{}
+	app.Name, app.Namespace = "api", "iaf-s1"
+
+	cm := BuildCABundleConfigMap(app, "-----BEGIN CERTIFICATE-----\n")
+	volume, mount := CABundleMount(app)
+	if cm.Name != "api-ca-bundle" || cm.Namespace != "iaf-s1" || volume.ConfigMap.Name != cm.Name {
+		t.Errorf("volume %+v does not mount the copy %s/%s", volume, cm.Namespace, cm.Name)
+	}
+	if _, ok := cm.Data[mount.SubPath]; !ok || mount.MountPath != CABundlePath || !mount.ReadOnly {
+		t.Errorf("unexpected mount %+v of %v", mount, cm.Data)
+	}
+}
+
+func TestCABundleEnv(t *testing.T) {
+	env := CABundleEnv(nil)
+	if len(env) != 1 || env[0].Name != "SSL_CERT_FILE" || env[0].Value != CABundlePath {
+		t.Errorf("unexpected env %v", env)
+	}
+	// The app's own value wins.
+	if env := CABundleEnv([]iafv1alpha1.EnvVar{{Name: "SSL_CERT_FILE", Value: "/app/ca.pem"}}); env != nil {
+		t.Errorf("expected the app's SSL_CERT_FILE to win, got %v", env)
+	}
+}
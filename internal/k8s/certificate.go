@@ -2,6 +2,7 @@ package k8s
 
 import (
 	"fmt"
+	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -34,10 +35,7 @@ func BuildCertificate(app *iafv1alpha1.Application, host, issuerName string) *un
 	obj.SetGroupVersionKind(CertificateGVK)
 	obj.SetName(app.Name)
 	obj.SetNamespace(app.Namespace)
-	obj.SetLabels(map[string]string{
-		"app.kubernetes.io/managed-by": "iaf",
-		"iaf.io/application":          app.Name,
-	})
+	obj.SetLabels(AppLabels(app))
 	obj.SetOwnerReferences([]metav1.OwnerReference{
 		{
 			APIVersion: iafv1alpha1.GroupVersion.String(),
@@ -64,3 +62,40 @@ func BuildCertificate(app *iafv1alpha1.Application, host, issuerName string) *un
 func TLSSecretName(appName string) string {
 	return fmt.Sprintf("%s-tls", appName)
 }
+
+// CertificateInfo is what cert-manager reports on a Certificate.
+type CertificateInfo struct {
+	// Ready, Reason and Message are from the Ready condition. A Certificate
+	// cert-manager has not processed yet is not ready, with no reason.
+	Ready   bool
+	Reason  string
+	Message string
+	// NotAfter is when the issued certificate expires and RenewalTime when
+	// cert-manager renews it; zero until a certificate is issued.
+	NotAfter    time.Time
+	RenewalTime time.Time
+}
+
+// GetCertificateInfo reads the Ready condition, expiry and renewal time from
+// the status of a cert-manager Certificate.
+func GetCertificateInfo(cert *unstructured.Unstructured) CertificateInfo {
+	var info CertificateInfo
+	conditions, _, _ := unstructured.NestedSlice(cert.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]any)
+		if !ok || cond["type"] != "Ready" {
+			continue
+		}
+		info.Ready = cond["status"] == "True"
+		info.Reason, _ = cond["reason"].(string)
+		info.Message, _ = cond["message"].(string)
+	}
+	for field, t := range map[string]*time.Time{"notAfter": &info.NotAfter, "renewalTime": &info.RenewalTime} {
+		if v, _, _ := unstructured.NestedString(cert.Object, "status", field); v != "" {
+			if parsed, err := time.Parse(time.RFC3339, v); err == nil {
+				*t = parsed
+			}
+		}
+	}
+	return info
+}
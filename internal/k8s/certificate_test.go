@@ -2,6 +2,7 @@ package k8s
 
 import (
 	"testing"
+	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -98,3 +99,121 @@ func TestBuildIngressRoute_NoTLS(t *testing.T) {
 		t.Error("expected no tls field when TLS is disabled")
 	}
 }
+
+func firstRouteService(t *testing.T, route map[string]any) map[string]any {
+	t.Helper()
+	spec, _ := route["spec"].(map[string]any)
+	routes, _ := spec["routes"].([]any)
+	if len(routes) != 1 {
+		t.Fatalf("expected 1 route, got %d", len(routes))
+	}
+	r, _ := routes[0].(map[string]any)
+	services, _ := r["services"].([]any)
+	if len(services) != 1 {
+		t.Fatalf("expected 1 service, got %d", len(services))
+	}
+	svc, _ := services[0].(map[string]any)
+	return svc
+}
+
+func TestBuildIngressRoute_GRPC(t *testing.T) {
+	app := makeTestApp("my-app", "iaf-abc123")
+	app.Spec.Protocol = iafv1alpha1.ProtocolGRPC
+	route := BuildIngressRoute(app, "example.com", true)
+
+	if route.GroupVersionKind() != TraefikIngressRouteGVK {
+		t.Errorf("expected GVK %v, got %v", TraefikIngressRouteGVK, route.GroupVersionKind())
+	}
+	svc := firstRouteService(t, route.Object)
+	if svc["scheme"] != "h2c" {
+		t.Errorf("expected service scheme 'h2c', got %v", svc["scheme"])
+	}
+}
+
+func TestBuildIngressRoute_StickySessions(t *testing.T) {
+	app := makeTestApp("my-app", "iaf-abc123")
+	app.Spec.Protocol = iafv1alpha1.ProtocolWebSocket
+	app.Spec.StickySessions = true
+	route := BuildIngressRoute(app, "example.com", true)
+
+	svc := firstRouteService(t, route.Object)
+	sticky, _ := svc["sticky"].(map[string]any)
+	cookie, _ := sticky["cookie"].(map[string]any)
+	if cookie["name"] != "iaf_sticky" {
+		t.Errorf("expected sticky cookie 'iaf_sticky', got %v", cookie["name"])
+	}
+	if cookie["secure"] != true {
+		t.Errorf("expected secure cookie when TLS is enabled, got %v", cookie["secure"])
+	}
+
+	app.Spec.StickySessions = false
+	svc = firstRouteService(t, BuildIngressRoute(app, "example.com", true).Object)
+	if _, ok := svc["sticky"]; ok {
+		t.Error("expected no sticky config when stickySessions is false")
+	}
+}
+
+func TestBuildIngressRoute_TCP(t *testing.T) {
+	app := makeTestApp("my-app", "iaf-abc123")
+	app.Spec.Protocol = iafv1alpha1.ProtocolTCP
+	route := BuildIngressRoute(app, "example.com", true)
+
+	if route.GroupVersionKind() != TraefikIngressRouteTCPGVK {
+		t.Fatalf("expected GVK %v, got %v", TraefikIngressRouteTCPGVK, route.GroupVersionKind())
+	}
+	if IngressRouteGVKFor(app) != TraefikIngressRouteTCPGVK {
+		t.Errorf("expected IngressRouteGVKFor to return IngressRouteTCP")
+	}
+
+	spec, _ := route.Object["spec"].(map[string]any)
+	entryPoints, _ := spec["entryPoints"].([]any)
+	if len(entryPoints) != 1 || entryPoints[0] != "websecure" {
+		t.Errorf("expected entryPoints [websecure], got %v", entryPoints)
+	}
+	routes, _ := spec["routes"].([]any)
+	r, _ := routes[0].(map[string]any)
+	if r["match"] != "HostSNI(`my-app.example.com`)" {
+		t.Errorf("expected HostSNI match, got %v", r["match"])
+	}
+	tls, _ := spec["tls"].(map[string]any)
+	if tls["secretName"] != "my-app-tls" {
+		t.Errorf("expected tls.secretName 'my-app-tls', got %v", tls["secretName"])
+	}
+
+	// SNI routing still needs TLS; without a cert the secret is omitted.
+	route = BuildIngressRoute(app, "example.com", false)
+	spec, _ = route.Object["spec"].(map[string]any)
+	tls, ok := spec["tls"].(map[string]any)
+	if !ok {
+		t.Fatal("expected tls block on IngressRouteTCP even when TLS is disabled")
+	}
+	if _, hasSecret := tls["secretName"]; hasSecret {
+		t.Error("expected no secretName when TLS is disabled")
+	}
+}
+
+func TestGetCertificateInfo(t *testing.T) {
+	cert := BuildCertificate(makeTestApp("my-app", "iaf-abc123"), "my-app.example.com", "letsencrypt")
+	if info := GetCertificateInfo(cert); info.Ready || info.Reason != "" || !info.NotAfter.IsZero() {
+		t.Errorf("expected an unprocessed certificate to be not ready, got %+v", info)
+	}
+
+	cert.Object["status"] = map[string]any{
+		"conditions": []any{
+			map[string]any{"type": "Issuing", "status": "False"},
+			map[string]any{"type": "Ready", "status": "True", "reason": "Ready", "message": "Certificate is up to date and has not expired"},
+		},
+		"notAfter":    "2026-12-01T10:00:00Z",
+		"renewalTime": "2026-11-01T10:00:00Z",
+	}
+	info := GetCertificateInfo(cert)
+	if !info.Ready || info.Reason != "Ready" {
+		t.Errorf("expected a ready certificate, got %+v", info)
+	}
+	if want := time.Date(2026, 12, 1, 10, 0, 0, 0, time.UTC); !info.NotAfter.Equal(want) {
+		t.Errorf("NotAfter = %v, want %v", info.NotAfter, want)
+	}
+	if want := time.Date(2026, 11, 1, 10, 0, 0, 0, time.UTC); !info.RenewalTime.Equal(want) {
+		t.Errorf("RenewalTime = %v, want %v", info.RenewalTime, want)
+	}
+}
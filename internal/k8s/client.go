@@ -29,7 +29,9 @@ func NewClient(kubeconfig string) (client.Client, error) {
 	}
 
 	scheme := NewScheme()
-	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	// A watch-capable client lets MCP resource subscriptions follow
+	// Application changes.
+	c, err := client.NewWithWatch(cfg, client.Options{Scheme: scheme})
 	if err != nil {
 		return nil, fmt.Errorf("creating kubernetes client: %w", err)
 	}
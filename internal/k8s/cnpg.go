@@ -1,7 +1,10 @@
 package k8s
 
 import (
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"slices"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
@@ -18,41 +21,87 @@ var CNPGClusterGVK = schema.GroupVersionKind{
 	Kind:    "Cluster",
 }
 
-// PlanConfig holds the resource configuration for a given ServicePlan.
-type PlanConfig struct {
-	Instances int
-	CPU       string
-	Memory    string
-	StorageGB int
+// CNPGScheduledBackupGVK is the GroupVersionKind for CloudNativePG
+// ScheduledBackup CRs.
+var CNPGScheduledBackupGVK = schema.GroupVersionKind{
+	Group:   "postgresql.cnpg.io",
+	Version: "v1",
+	Kind:    "ScheduledBackup",
 }
 
-// planConfigs maps service plans to their resource configurations.
-var planConfigs = map[iafv1alpha1.ServicePlan]PlanConfig{
-	iafv1alpha1.ServicePlanMicro: {Instances: 1, CPU: "250m", Memory: "256Mi", StorageGB: 1},
-	iafv1alpha1.ServicePlanSmall: {Instances: 1, CPU: "500m", Memory: "512Mi", StorageGB: 5},
-	iafv1alpha1.ServicePlanHA:    {Instances: 3, CPU: "1", Memory: "1Gi", StorageGB: 10},
-}
+// BuildCNPGCluster constructs an unstructured CloudNativePG Cluster CR for the given ManagedService
+// on plan. image is the PostgreSQL image the cluster runs; empty leaves it to the CNPG operator's default.
+func BuildCNPGCluster(svc *iafv1alpha1.ManagedService, plan iafv1alpha1.ServicePlanSpec, image string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(CNPGClusterGVK)
+	obj.SetName(svc.Name)
+	obj.SetNamespace(svc.Namespace)
+	obj.SetLabels(managedServiceLabels(svc))
+	obj.SetOwnerReferences(managedServiceOwner(svc))
 
-// PlanConfigFor returns the PlanConfig for the given ServicePlan.
-// Returns false if the plan is not found.
-func PlanConfigFor(plan iafv1alpha1.ServicePlan) (PlanConfig, bool) {
-	cfg, ok := planConfigs[plan]
-	return cfg, ok
+	resources := map[string]any{}
+	if len(plan.Resources.Requests) > 0 {
+		resources["requests"] = resourceList(plan.Resources.Requests)
+	}
+	if len(plan.Resources.Limits) > 0 {
+		resources["limits"] = resourceList(plan.Resources.Limits)
+	}
+	spec := map[string]any{
+		"instances": int64(plan.Instances),
+		"storage": map[string]any{
+			"size": plan.Storage.String(),
+		},
+		"resources": resources,
+	}
+	if image != "" {
+		spec["imageName"] = image
+	}
+	if b := plan.Backup; b != nil {
+		store := map[string]any{}
+		if len(b.BarmanObjectStore.Raw) > 0 {
+			_ = json.Unmarshal(b.BarmanObjectStore.Raw, &store)
+		}
+		if _, ok := store["serverName"]; !ok {
+			// Services in different sessions may share a name.
+			store["serverName"] = svc.Namespace + "-" + svc.Name
+		}
+		spec["backup"] = map[string]any{
+			"retentionPolicy":   b.RetentionPolicy,
+			"barmanObjectStore": store,
+		}
+	}
+	obj.Object["spec"] = spec
+	return obj
 }
 
-// BuildCNPGCluster constructs an unstructured CloudNativePG Cluster CR for the given ManagedService.
-func BuildCNPGCluster(svc *iafv1alpha1.ManagedService) *unstructured.Unstructured {
-	cfg := planConfigs[svc.Spec.Plan]
-
+// BuildCNPGScheduledBackup constructs an unstructured CloudNativePG
+// ScheduledBackup CR that backs up the cluster of svc on the schedule of
+// backup.
+func BuildCNPGScheduledBackup(svc *iafv1alpha1.ManagedService, backup *iafv1alpha1.ServicePlanBackup) *unstructured.Unstructured {
 	obj := &unstructured.Unstructured{}
-	obj.SetGroupVersionKind(CNPGClusterGVK)
+	obj.SetGroupVersionKind(CNPGScheduledBackupGVK)
 	obj.SetName(svc.Name)
 	obj.SetNamespace(svc.Namespace)
-	obj.SetLabels(map[string]string{
+	obj.SetLabels(managedServiceLabels(svc))
+	obj.SetOwnerReferences(managedServiceOwner(svc))
+	obj.Object["spec"] = map[string]any{
+		"schedule":             backup.Schedule,
+		"cluster":              map[string]any{"name": svc.Name},
+		"method":               "barmanObjectStore",
+		"backupOwnerReference": "self",
+	}
+	return obj
+}
+
+func managedServiceLabels(svc *iafv1alpha1.ManagedService) map[string]string {
+	return map[string]string{
 		"app.kubernetes.io/managed-by": "iaf",
-		"iaf.io/managed-service":      svc.Name,
-	})
-	obj.SetOwnerReferences([]metav1.OwnerReference{
+		"iaf.io/managed-service":       svc.Name,
+	}
+}
+
+func managedServiceOwner(svc *iafv1alpha1.ManagedService) []metav1.OwnerReference {
+	return []metav1.OwnerReference{
 		{
 			APIVersion: iafv1alpha1.GroupVersion.String(),
 			Kind:       "ManagedService",
@@ -60,21 +109,16 @@ func BuildCNPGCluster(svc *iafv1alpha1.ManagedService) *unstructured.Unstructure
 			UID:        svc.UID,
 			Controller: boolPtr(true),
 		},
-	})
+	}
+}
 
-	obj.Object["spec"] = map[string]any{
-		"instances": int64(cfg.Instances),
-		"storage": map[string]any{
-			"size": fmt.Sprintf("%dGi", cfg.StorageGB),
-		},
-		"resources": map[string]any{
-			"requests": map[string]any{
-				"cpu":    cfg.CPU,
-				"memory": cfg.Memory,
-			},
-		},
+// resourceList returns l as the map of quantity strings CNPG takes.
+func resourceList(l corev1.ResourceList) map[string]any {
+	m := map[string]any{}
+	for name, q := range l {
+		m[string(name)] = q.String()
 	}
-	return obj
+	return m
 }
 
 // GetCNPGClusterStatus reads the phase and connection secret name from a CNPG Cluster CR.
@@ -107,6 +151,86 @@ func GetCNPGClusterStatus(obj *unstructured.Unstructured) (phase string, secretN
 	return string(iafv1alpha1.ManagedServicePhaseProvisioning), secretName
 }
 
+// maxCNPGEvents is how many of the most recent events of a CNPG cluster
+// are kept in a ManagedService's progress.
+const maxCNPGEvents = 5
+
+// maxCNPGEventMessage bounds the event messages kept in the progress.
+const maxCNPGEventMessage = 256
+
+// CNPGProgress reads the progress of a CNPG cluster from its status, the
+// PersistentVolumeClaims of its instances and the events in its namespace.
+// Only events on the cluster and on its instances' pods, volumes and jobs
+// are kept, newest last.
+func CNPGProgress(cluster *unstructured.Unstructured, pvcs []corev1.PersistentVolumeClaim, events []corev1.Event) *iafv1alpha1.ManagedServiceProgress {
+	progress := &iafv1alpha1.ManagedServiceProgress{}
+	progress.ClusterPhase, _, _ = unstructured.NestedString(cluster.Object, "status", "phase")
+	progress.ClusterPhaseReason, _, _ = unstructured.NestedString(cluster.Object, "status", "phaseReason")
+	progress.CurrentPrimary, _, _ = unstructured.NestedString(cluster.Object, "status", "currentPrimary")
+	ready, _, _ := unstructured.NestedInt64(cluster.Object, "status", "readyInstances")
+	progress.ReadyInstances = int32(ready)
+
+	for _, pvc := range pvcs {
+		if pvc.DeletionTimestamp != nil {
+			continue
+		}
+		progress.Volumes++
+		if pvc.Status.Phase == corev1.ClaimBound {
+			progress.VolumesBound++
+		}
+	}
+
+	// Instances are named <cluster>-<n>; their volumes add a suffix such
+	// as -wal, their jobs one such as -initdb, and job pods a random one.
+	instance := regexp.MustCompile(`^` + regexp.QuoteMeta(cluster.GetName()) + `-\d+(-[a-z]+(-[a-z0-9]+)?)?$`)
+	var kept []corev1.Event
+	for _, e := range events {
+		switch e.InvolvedObject.Kind {
+		case "Cluster":
+			if e.InvolvedObject.Name != cluster.GetName() {
+				continue
+			}
+		case "Pod", "PersistentVolumeClaim", "Job":
+			if !instance.MatchString(e.InvolvedObject.Name) {
+				continue
+			}
+		default:
+			continue
+		}
+		kept = append(kept, e)
+	}
+	slices.SortStableFunc(kept, func(a, b corev1.Event) int { return eventTime(a).Compare(eventTime(b)) })
+	for _, e := range kept[max(0, len(kept)-maxCNPGEvents):] {
+		progress.Events = append(progress.Events, iafv1alpha1.ManagedServiceEvent{
+			Time:    metav1.Time{Time: eventTime(e)},
+			Type:    e.Type,
+			Reason:  e.Reason,
+			Object:  e.InvolvedObject.Kind + "/" + e.InvolvedObject.Name,
+			Message: truncateMessage(e.Message, maxCNPGEventMessage),
+		})
+	}
+	return progress
+}
+
+// CNPGProgressMessage summarizes progress for the status message of a
+// ManagedService that is still provisioning, out of instances.
+func CNPGProgressMessage(progress *iafv1alpha1.ManagedServiceProgress, instances int32) string {
+	msg := fmt.Sprintf("Provisioning in progress: %d/%d instances ready", progress.ReadyInstances, instances)
+	if progress.Volumes > progress.VolumesBound {
+		msg += fmt.Sprintf(", %d/%d volumes bound", progress.VolumesBound, progress.Volumes)
+	}
+	if progress.ClusterPhase != "" {
+		msg += " (" + progress.ClusterPhase + ")"
+	}
+	for i := len(progress.Events) - 1; i >= 0; i-- {
+		if e := progress.Events[i]; e.Type == corev1.EventTypeWarning {
+			msg += fmt.Sprintf(". Latest warning on %s: %s: %s", e.Object, e.Reason, e.Message)
+			break
+		}
+	}
+	return msg + ". Poll service_status every 10s."
+}
+
 // BuildNetworkPolicy constructs a NetworkPolicy that allows ingress to CNPG cluster pods
 // from pods in the same namespace and from the CNPG operator namespace (cnpg-system).
 // The CNPG operator must be able to reach database pods on its internal status port
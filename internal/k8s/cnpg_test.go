@@ -1,14 +1,17 @@
 package k8s
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
-func makeManagedService(name, namespace string, plan iafv1alpha1.ServicePlan) *iafv1alpha1.ManagedService {
+func makeManagedService(name, namespace string, plan iafv1alpha1.ServicePlanName) *iafv1alpha1.ManagedService {
 	return &iafv1alpha1.ManagedService{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
@@ -22,9 +25,18 @@ func makeManagedService(name, namespace string, plan iafv1alpha1.ServicePlan) *i
 	}
 }
 
+func builtinPlanSpec(t *testing.T, name iafv1alpha1.ServicePlanName) iafv1alpha1.ServicePlanSpec {
+	t.Helper()
+	plan, ok := BuiltinServicePlan(name)
+	if !ok {
+		t.Fatalf("no built-in plan %q", name)
+	}
+	return plan.Spec
+}
+
 func TestBuildCNPGCluster_Micro(t *testing.T) {
 	svc := makeManagedService("mydb", "iaf-test", iafv1alpha1.ServicePlanMicro)
-	obj := BuildCNPGCluster(svc)
+	obj := BuildCNPGCluster(svc, builtinPlanSpec(t, svc.Spec.Plan), "")
 
 	if obj.GetName() != "mydb" {
 		t.Errorf("expected name mydb, got %s", obj.GetName())
@@ -62,7 +74,7 @@ func TestBuildCNPGCluster_Micro(t *testing.T) {
 
 func TestBuildCNPGCluster_HA(t *testing.T) {
 	svc := makeManagedService("hadb", "iaf-test", iafv1alpha1.ServicePlanHA)
-	obj := BuildCNPGCluster(svc)
+	obj := BuildCNPGCluster(svc, builtinPlanSpec(t, svc.Spec.Plan), "")
 
 	spec, ok := obj.Object["spec"].(map[string]any)
 	if !ok {
@@ -133,6 +145,69 @@ func TestGetCNPGClusterStatus_ReadyFalse(t *testing.T) {
 	}
 }
 
+func TestCNPGProgress(t *testing.T) {
+	cluster := &unstructured.Unstructured{}
+	cluster.SetName("db")
+	cluster.Object["status"] = map[string]any{
+		"phase":          "Setting up primary",
+		"phaseReason":    "Creating primary instance db-1",
+		"readyInstances": int64(0),
+		"currentPrimary": "db-1",
+	}
+	pvcs := []corev1.PersistentVolumeClaim{
+		{ObjectMeta: metav1.ObjectMeta{Name: "db-1"}, Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "db-1-wal"}, Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending}},
+	}
+	start := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	event := func(minute int, kind, name, reason, message string) corev1.Event {
+		return corev1.Event{
+			InvolvedObject: corev1.ObjectReference{Kind: kind, Name: name},
+			Type:           corev1.EventTypeNormal,
+			Reason:         reason,
+			Message:        message,
+			LastTimestamp:  metav1.Time{Time: start.Add(time.Duration(minute) * time.Minute)},
+		}
+	}
+	pull := event(7, "Pod", "db-1-initdb-x7k2p", "Failed", "Failed to pull image \"postgres:16\": not found")
+	pull.Type = corev1.EventTypeWarning
+	events := []corev1.Event{
+		pull,
+		event(0, "Cluster", "db", "CreatingPodDisruptionBudget", "Creating PodDisruptionBudget db-primary"),
+		event(1, "PersistentVolumeClaim", "db-1", "ProvisioningSucceeded", "Successfully provisioned volume"),
+		event(2, "PersistentVolumeClaim", "db-1-wal", "ExternalProvisioning", "Waiting for a volume to be created"),
+		event(3, "Job", "db-1-initdb", "SuccessfulCreate", "Created pod: db-1-initdb-x7k2p"),
+		event(4, "Pod", "db-1-initdb-x7k2p", "Scheduled", "Successfully assigned"),
+		event(5, "Pod", "db-2-1", "Scheduled", "another service's pod"),
+		event(6, "Deployment", "db-1", "ScalingReplicaSet", "not a database resource"),
+		event(8, "Cluster", "db-2", "CreatingPodDisruptionBudget", "another service"),
+	}
+
+	progress := CNPGProgress(cluster, pvcs, events)
+	if progress.ClusterPhase != "Setting up primary" || progress.ClusterPhaseReason == "" || progress.CurrentPrimary != "db-1" {
+		t.Errorf("unexpected cluster status %+v", progress)
+	}
+	if progress.Volumes != 2 || progress.VolumesBound != 1 {
+		t.Errorf("expected 1/2 volumes bound, got %d/%d", progress.VolumesBound, progress.Volumes)
+	}
+	var reasons []string
+	for _, e := range progress.Events {
+		reasons = append(reasons, e.Reason)
+	}
+	if strings.Join(reasons, ",") != "ProvisioningSucceeded,ExternalProvisioning,SuccessfulCreate,Scheduled,Failed" {
+		t.Fatalf("expected the newest 5 events of the cluster, got %v", reasons)
+	}
+	if e := progress.Events[4]; e.Object != "Pod/db-1-initdb-x7k2p" || e.Type != corev1.EventTypeWarning {
+		t.Errorf("unexpected event %+v", e)
+	}
+
+	msg := CNPGProgressMessage(progress, 1)
+	for _, want := range []string{"0/1 instances ready", "1/2 volumes bound", "(Setting up primary)", "Failed to pull image"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected %q in message %q", want, msg)
+		}
+	}
+}
+
 func TestBuildNetworkPolicy(t *testing.T) {
 	svc := makeManagedService("mydb", "iaf-test", iafv1alpha1.ServicePlanMicro)
 	np := BuildNetworkPolicy(svc)
@@ -0,0 +1,27 @@
+package k8s
+
+import (
+	"slices"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+)
+
+// buildpackLauncher is the program in images built by buildpacks that
+// runs a command with the buildpack environment (PATH, runtime settings).
+const buildpackLauncher = "launcher"
+
+// ContainerCommand returns the command and args of the app container for
+// spec.command and spec.args. Images built from source run the command
+// through the buildpack launcher, since their entrypoint is the launcher
+// itself; args alone are passed to the web process. Pre-built images run
+// the command in place of their entrypoint. Both are nil when neither is
+// set, so the image default applies.
+func ContainerCommand(app *iafv1alpha1.Application) (command, args []string) {
+	if len(app.Spec.Command) == 0 {
+		return nil, slices.Clone(app.Spec.Args)
+	}
+	if app.Spec.Git != nil || app.Spec.Blob != "" {
+		return []string{buildpackLauncher}, append(slices.Clone(app.Spec.Command), app.Spec.Args...)
+	}
+	return slices.Clone(app.Spec.Command), slices.Clone(app.Spec.Args)
+}
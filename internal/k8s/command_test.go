@@ -0,0 +1,33 @@
+package k8s
+
+import (
+	"slices"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+)
+
+func TestContainerCommand(t *testing.T) {
+	git := &iafv1alpha1.GitSource{URL: "https://github.com/example/web"}
+	tests := []struct {
+		name        string
+		spec        iafv1alpha1.ApplicationSpec
+		wantCommand []string
+		wantArgs    []string
+	}{
+		{"image default", iafv1alpha1.ApplicationSpec{Image: "nginx:1.27"}, nil, nil},
+		{"image command", iafv1alpha1.ApplicationSpec{Image: "node:22", Command: []string{"node", "worker.js"}, Args: []string{"--queue", "mail"}}, []string{"node", "worker.js"}, []string{"--queue", "mail"}},
+		{"image args", iafv1alpha1.ApplicationSpec{Image: "nginx:1.27", Args: []string{"-g", "daemon off;"}}, nil, []string{"-g", "daemon off;"}},
+		{"git command", iafv1alpha1.ApplicationSpec{Git: git, Command: []string{"node", "worker.js"}, Args: []string{"--queue", "mail"}}, []string{"launcher"}, []string{"node", "worker.js", "--queue", "mail"}},
+		{"blob args", iafv1alpha1.ApplicationSpec{Blob: "http://store/web.tar.gz", Args: []string{"--verbose"}}, nil, []string{"--verbose"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &iafv1alpha1.Application{Spec: tt.spec}
+			command, args := ContainerCommand(app)
+			if !slices.Equal(command, tt.wantCommand) || !slices.Equal(args, tt.wantArgs) {
+				t.Errorf("got %q %q, want %q %q", command, args, tt.wantCommand, tt.wantArgs)
+			}
+		})
+	}
+}
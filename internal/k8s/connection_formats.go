@@ -0,0 +1,73 @@
+package k8s
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LabelConnectionFormats is set on the Secret of connection string variants
+// of a binding to the name of the bound managed service.
+const LabelConnectionFormats = "iaf.io/connection-formats"
+
+// Keys of the connection formats Secret.
+const (
+	ConnectionFormatJDBCKey       = "jdbc-url"
+	ConnectionFormatSQLAlchemyKey = "sqlalchemy-uri"
+)
+
+// ConnectionFormatsSecretName returns the name of the Secret holding the
+// connection string variants of app's binding to service.
+func ConnectionFormatsSecretName(app, service string) string {
+	return app + "-" + service + "-formats"
+}
+
+// BuildConnectionFormatsSecret constructs the Secret of connection string
+// variants of app's binding to service, derived from the host, port,
+// dbname, username and password of the service's CNPG connection Secret.
+// Credentials are escaped for their place in each URL.
+func BuildConnectionFormatsSecret(app *iafv1alpha1.Application, service string, formats []iafv1alpha1.ConnectionFormat, conn *corev1.Secret) *corev1.Secret {
+	host := net.JoinHostPort(string(conn.Data["host"]), string(conn.Data["port"]))
+	dbname, user, password := string(conn.Data["dbname"]), string(conn.Data["username"]), string(conn.Data["password"])
+
+	data := map[string][]byte{}
+	for _, format := range formats {
+		switch format {
+		case iafv1alpha1.ConnectionFormatJDBC:
+			data[ConnectionFormatJDBCKey] = []byte(fmt.Sprintf("jdbc:postgresql://%s/%s?user=%s&password=%s",
+				host, url.PathEscape(dbname), url.QueryEscape(user), url.QueryEscape(password)))
+		case iafv1alpha1.ConnectionFormatSQLAlchemy:
+			u := url.URL{Scheme: "postgresql", User: url.UserPassword(user, password), Host: host, Path: "/" + dbname}
+			data[ConnectionFormatSQLAlchemyKey] = []byte(u.String())
+		}
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConnectionFormatsSecretName(app.Name, service),
+			Namespace: app.Namespace,
+			Labels:    connectionFormatsLabels(app, service),
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: iafv1alpha1.GroupVersion.String(),
+					Kind:       "Application",
+					Name:       app.Name,
+					UID:        app.UID,
+				},
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}
+}
+
+// connectionFormatsLabels returns the labels of app's connection formats
+// Secret for service.
+func connectionFormatsLabels(app *iafv1alpha1.Application, service string) map[string]string {
+	labels := AppLabels(app)
+	labels[LabelConnectionFormats] = service
+	return labels
+}
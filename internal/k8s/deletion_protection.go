@@ -0,0 +1,45 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DeletionProtected returns the deletion_protected error for deleting the
+// kind ("application" or "service") named name while its
+// spec.deletionProtected is set.
+func DeletionProtected(kind, name string) *apierror.Error {
+	return apierror.Conflict(apierror.CodeDeletionProtected, "%s %q is deletion protected", kind, name).
+		WithHint("if deleting it is intended, call set_deletion_protection with protected=false first, then retry").
+		WithDetails(map[string]string{"kind": kind, "name": name})
+}
+
+// ProtectedResources returns the deletion-protected applications and
+// managed services in namespace, as "application/<name>" and
+// "service/<name>".
+func ProtectedResources(ctx context.Context, c client.Reader, namespace string) ([]string, error) {
+	var apps iafv1alpha1.ApplicationList
+	if err := c.List(ctx, &apps, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("listing applications: %w", err)
+	}
+	var services iafv1alpha1.ManagedServiceList
+	if err := c.List(ctx, &services, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("listing services: %w", err)
+	}
+	var protected []string
+	for _, app := range apps.Items {
+		if app.Spec.DeletionProtected {
+			protected = append(protected, "application/"+app.Name)
+		}
+	}
+	for _, svc := range services.Items {
+		if svc.Spec.DeletionProtected {
+			protected = append(protected, "service/"+svc.Name)
+		}
+	}
+	return protected, nil
+}
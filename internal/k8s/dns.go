@@ -0,0 +1,86 @@
+package k8s
+
+import (
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/validation"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ValidatePodDNS validates an application's spec.hostAliases and
+// spec.dnsConfig.
+func ValidatePodDNS(aliases []iafv1alpha1.HostAlias, dns *iafv1alpha1.DNSConfig) error {
+	if len(aliases) > 10 {
+		return fmt.Errorf("hostAliases has %d entries; the maximum is 10", len(aliases))
+	}
+	for _, a := range aliases {
+		if err := validation.ValidateHostAlias(a.IP, a.Hostnames); err != nil {
+			return err
+		}
+	}
+	if dns == nil {
+		return nil
+	}
+	if err := validation.ValidateDNSConfig(dns.Nameservers, dns.Searches); err != nil {
+		return err
+	}
+	if len(dns.Options) > 5 {
+		return fmt.Errorf("dnsConfig has %d options; the maximum is 5", len(dns.Options))
+	}
+	for _, o := range dns.Options {
+		if err := validation.ValidateDNSOption(o.Name, o.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckCustomDNS validates host aliases and DNS settings requested through
+// the API. They are rejected unless the platform enables custom DNS
+// (IAF_ALLOW_CUSTOM_DNS); empty values, which clear them, are always
+// accepted.
+func CheckCustomDNS(allowed bool, aliases []iafv1alpha1.HostAlias, dns *iafv1alpha1.DNSConfig) error {
+	if len(aliases) == 0 && emptyDNS(dns) {
+		return nil
+	}
+	if !allowed {
+		return apierror.Validation(apierror.CodeCapabilityDisabled, "custom DNS is not enabled on this platform").
+			WithHint("remove the host aliases and DNS settings, or ask the platform operator to set IAF_ALLOW_CUSTOM_DNS").
+			WithDetails(map[string]string{"feature": "custom_dns"})
+	}
+	if err := ValidatePodDNS(aliases, dns); err != nil {
+		return apierror.Validation(apierror.CodeInvalidRequest, "%s", err.Error())
+	}
+	return nil
+}
+
+// emptyDNS reports whether dns sets no resolver settings.
+func emptyDNS(dns *iafv1alpha1.DNSConfig) bool {
+	return dns == nil || len(dns.Nameservers)+len(dns.Searches)+len(dns.Options) == 0
+}
+
+// PodDNS returns the host aliases and DNS config of an application's pods.
+// The pods keep the ClusterFirst DNS policy, so the DNS config is merged
+// after the cluster's nameserver and search domains. Both are nil when the
+// application sets neither.
+func PodDNS(app *iafv1alpha1.Application) ([]corev1.HostAlias, *corev1.PodDNSConfig) {
+	var aliases []corev1.HostAlias
+	for _, a := range app.Spec.HostAliases {
+		aliases = append(aliases, corev1.HostAlias{IP: a.IP, Hostnames: a.Hostnames})
+	}
+	dns := app.Spec.DNSConfig
+	if emptyDNS(dns) {
+		return aliases, nil
+	}
+	config := &corev1.PodDNSConfig{Nameservers: dns.Nameservers, Searches: dns.Searches}
+	for _, o := range dns.Options {
+		opt := corev1.PodDNSConfigOption{Name: o.Name}
+		if o.Value != "" {
+			opt.Value = &o.Value
+		}
+		config.Options = append(config.Options, opt)
+	}
+	return aliases, config
+}
@@ -0,0 +1,33 @@
+package k8s
+
+import (
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+)
+
+func TestCheckCustomDNS(t *testing.T) {
+	aliases := []iafv1alpha1.HostAlias{{IP: "10.20.0.5", Hostnames: []string{"ldap.corp.example.com"}}}
+	tests := []struct {
+		name    string
+		allowed bool
+		aliases []iafv1alpha1.HostAlias
+		dns     *iafv1alpha1.DNSConfig
+		wantErr bool
+	}{
+		{name: "nothing set", allowed: false},
+		{name: "empty values clear settings when disabled", allowed: false, aliases: []iafv1alpha1.HostAlias{}, dns: &iafv1alpha1.DNSConfig{}},
+		{name: "disabled", allowed: false, aliases: aliases, wantErr: true},
+		{name: "enabled", allowed: true, aliases: aliases, dns: &iafv1alpha1.DNSConfig{Options: []iafv1alpha1.DNSOption{{Name: "ndots", Value: "2"}}}},
+		{name: "cluster name", allowed: true, aliases: []iafv1alpha1.HostAlias{{IP: "10.20.0.5", Hostnames: []string{"db.prod.svc"}}}, wantErr: true},
+		{name: "bad option", allowed: true, dns: &iafv1alpha1.DNSConfig{Options: []iafv1alpha1.DNSOption{{Name: "rotate", Value: "1"}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckCustomDNS(tt.allowed, tt.aliases, tt.dns)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckCustomDNS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
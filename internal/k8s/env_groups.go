@@ -0,0 +1,47 @@
+package k8s
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// LabelEnvGroup is set on every environment group Secret to the group name.
+	LabelEnvGroup = "iaf.io/env-group"
+
+	// AnnotationEnvGroupsHash is set on the pod template of apps bound to
+	// environment groups. It changes whenever a group's variables do, so the
+	// Deployment rolls and pods pick up the new values.
+	AnnotationEnvGroupsHash = "iaf.io/env-groups-hash"
+
+	envGroupSecretPrefix = "iaf-env-"
+)
+
+// EnvGroupSecretName returns the name of the Secret holding environment group
+// group. The prefix keeps groups apart from credentials, which are named by
+// the user.
+func EnvGroupSecretName(group string) string {
+	return envGroupSecretPrefix + group
+}
+
+// BuildEnvGroupSecret constructs the Secret for an environment group. Each
+// key is a variable name; bound apps load them all with envFrom. Values are
+// never read back by any IAF tool.
+func BuildEnvGroupSecret(namespace, group string, vars map[string]string) *corev1.Secret {
+	data := make(map[string][]byte, len(vars))
+	for name, value := range vars {
+		data[name] = []byte(value)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      EnvGroupSecretName(group),
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "iaf",
+				LabelEnvGroup:                  group,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}
+}
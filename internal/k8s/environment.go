@@ -0,0 +1,72 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// LabelEnvironmentOf is set on every Application of a promotion
+	// pipeline to the name of the app the environments were created from.
+	LabelEnvironmentOf = "iaf.io/environment-of"
+
+	// LabelEnvironment is set on every Application of a promotion pipeline
+	// to its environment, such as dev, staging or prod.
+	LabelEnvironment = "iaf.io/environment"
+
+	// AnnotationPromotedFrom records the environment whose image was last
+	// promoted to an Application.
+	AnnotationPromotedFrom = "iaf.io/promoted-from"
+)
+
+// EnvironmentName returns the Application name used for environment env of
+// appName.
+func EnvironmentName(appName, env string) string {
+	return fmt.Sprintf("%s-%s", appName, env)
+}
+
+// GetEnvironment returns the Application for environment env of appName in
+// namespace, or nil when there is none.
+func GetEnvironment(ctx context.Context, c client.Client, namespace, appName, env string) (*iafv1alpha1.Application, error) {
+	var list iafv1alpha1.ApplicationList
+	if err := c.List(ctx, &list, client.InNamespace(namespace), client.MatchingLabels{
+		LabelEnvironmentOf: appName,
+		LabelEnvironment:   env,
+	}); err != nil {
+		return nil, err
+	}
+	if len(list.Items) == 0 {
+		return nil, nil
+	}
+	return &list.Items[0], nil
+}
+
+// EnvironmentSpec returns the spec of a new environment copied from source:
+// the same settings, running the image source runs now instead of building
+// again. Build settings, the host, bound managed services, the TTL,
+// suspension and deletion protection are not copied; each environment has
+// its own.
+func EnvironmentSpec(source *iafv1alpha1.Application) iafv1alpha1.ApplicationSpec {
+	spec := *source.Spec.DeepCopy()
+	spec.Image = source.Status.LatestImage
+	spec.Git = nil
+	spec.Blob = ""
+	spec.Builder = ""
+	spec.BuildEnv = nil
+	spec.BuildCache = nil
+	spec.Host = ""
+	spec.BoundManagedServices = nil
+	spec.TTL = nil
+	spec.Suspended = false
+	spec.DeletionProtected = false
+	return spec
+}
+
+// IsDigestReference reports whether image names an image by digest.
+func IsDigestReference(image string) bool {
+	return strings.Contains(image, "@sha256:")
+}
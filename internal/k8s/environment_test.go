@@ -0,0 +1,49 @@
+package k8s
+
+import (
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEnvironmentSpec(t *testing.T) {
+	source := &iafv1alpha1.Application{
+		Spec: iafv1alpha1.ApplicationSpec{
+			Git:                  &iafv1alpha1.GitSource{URL: "https://github.com/org/web.git", Revision: "main"},
+			Port:                 3000,
+			Replicas:             2,
+			Host:                 "dev.example.com",
+			Env:                  []iafv1alpha1.EnvVar{{Name: "MODE", Value: "dev"}},
+			BuildEnv:             []iafv1alpha1.EnvVar{{Name: "NODE_ENV", Value: "production"}},
+			TTL:                  &metav1.Duration{},
+			BoundManagedServices: []iafv1alpha1.BoundManagedService{{ServiceName: "db"}},
+			Suspended:            true,
+			DeletionProtected:    true,
+		},
+		Status: iafv1alpha1.ApplicationStatus{LatestImage: "registry.local/web@sha256:abc"},
+	}
+	spec := EnvironmentSpec(source)
+	if spec.Image != "registry.local/web@sha256:abc" || spec.Git != nil || spec.BuildEnv != nil {
+		t.Errorf("expected the built image and no build settings, got %+v", spec)
+	}
+	if spec.Host != "" || spec.TTL != nil || spec.BoundManagedServices != nil || spec.Suspended || spec.DeletionProtected {
+		t.Errorf("expected per-environment settings cleared, got %+v", spec)
+	}
+	if spec.Port != 3000 || spec.Replicas != 2 || len(spec.Env) != 1 {
+		t.Errorf("expected runtime settings copied, got %+v", spec)
+	}
+	spec.Env[0].Value = "changed"
+	if source.Spec.Env[0].Value != "dev" {
+		t.Error("EnvironmentSpec must not share slices with the source")
+	}
+}
+
+func TestIsDigestReference(t *testing.T) {
+	if !IsDigestReference("registry.local/web@sha256:abc") {
+		t.Error("expected a digest reference")
+	}
+	if IsDigestReference("nginx:1.27") {
+		t.Error("expected a tag reference")
+	}
+}
@@ -3,8 +3,10 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -13,9 +15,17 @@ const (
 	// LabelCredentialType is set on every git credential Secret.
 	LabelCredentialType = "iaf.io/credential-type"
 
+	// LabelManagedCredential marks a credential copied from a shared
+	// platform credential. Agents can use it but not change or delete it.
+	LabelManagedCredential = "iaf.io/managed-credential"
+
 	// AnnotationGitServer records the server URL on the Secret for informational purposes.
 	AnnotationGitServer = "iaf.io/git-server"
 
+	// AnnotationRotatedAt records, in RFC 3339, when the credential material
+	// was last replaced in place.
+	AnnotationRotatedAt = "iaf.io/rotated-at"
+
 	// AnnotationKpackGit is the annotation kpack uses to match a credential to a git URL.
 	AnnotationKpackGit = "kpack.io/git"
 
@@ -24,10 +34,11 @@ const (
 )
 
 // BuildGitCredentialSecret constructs a Kubernetes Secret for kpack git auth.
-// credType must be "basic-auth" or "ssh".
+// credType must be "basic-auth" or "ssh". knownHosts holds the host keys an
+// ssh credential verifies its server against, and may be empty.
 // StringData is write-only; the API server converts it to base64 Data; credential
 // material is never read back by any IAF tool.
-func BuildGitCredentialSecret(namespace, name, credType, gitServerURL, username, password, privateKey string) *corev1.Secret {
+func BuildGitCredentialSecret(namespace, name, credType, gitServerURL, username, password, privateKey, knownHosts string) *corev1.Secret {
 	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
@@ -54,11 +65,115 @@ func BuildGitCredentialSecret(namespace, name, credType, gitServerURL, username,
 		secret.StringData = map[string]string{
 			corev1.SSHAuthPrivateKey: privateKey,
 		}
+		if knownHosts != "" {
+			secret.StringData[KnownHostsKey] = knownHosts
+		}
 	}
 
 	return secret
 }
 
+// RotateGitCredentialSecret replaces the credential material of secret, a
+// Secret built by BuildGitCredentialSecret, and records at as its rotation
+// time. An empty username keeps the current one of a basic-auth credential,
+// and empty knownHosts the current host keys of an ssh one.
+// The Secret keeps its name, type and server, so writing it back swaps the
+// material for builds in one update.
+func RotateGitCredentialSecret(secret *corev1.Secret, username, password, privateKey, knownHosts string, at time.Time) {
+	switch secret.Type {
+	case corev1.SecretTypeBasicAuth:
+		secret.StringData = map[string]string{"password": password}
+		if username != "" {
+			secret.StringData["username"] = username
+		}
+	case corev1.SecretTypeSSHAuth:
+		secret.StringData = map[string]string{corev1.SSHAuthPrivateKey: privateKey}
+		if knownHosts != "" {
+			secret.StringData[KnownHostsKey] = knownHosts
+		}
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[AnnotationRotatedAt] = at.UTC().Format(time.RFC3339)
+}
+
+// CopySharedGitCredentials copies the shared git credentials in source, the
+// basic-auth and ssh-auth Secrets labelled as git credentials, into the
+// session namespace, labelled with LabelManagedCredential, and adds them to
+// its kpack SA. A copy already there is refreshed; a credential of the
+// session's own with the same name is left alone. Shared credentials
+// without a kpack.io/git annotation are skipped, since kpack would never
+// use them. Copies whose shared credential is gone are deleted.
+func CopySharedGitCredentials(ctx context.Context, c client.Client, source, namespace string) error {
+	var shared corev1.SecretList
+	if err := c.List(ctx, &shared, client.InNamespace(source), client.MatchingLabels{LabelCredentialType: "git"}); err != nil {
+		return fmt.Errorf("listing shared git credentials: %w", err)
+	}
+	current := map[string]bool{}
+	for _, src := range shared.Items {
+		server := src.Annotations[AnnotationKpackGit]
+		if server == "" || (src.Type != corev1.SecretTypeBasicAuth && src.Type != corev1.SecretTypeSSHAuth) {
+			continue
+		}
+		current[src.Name] = true
+		copied := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      src.Name,
+				Namespace: namespace,
+				Labels: map[string]string{
+					LabelCredentialType:    "git",
+					LabelManagedCredential: "true",
+				},
+				Annotations: map[string]string{
+					AnnotationKpackGit:  server,
+					AnnotationGitServer: server,
+				},
+			},
+			Type: src.Type,
+			Data: src.Data,
+		}
+		if err := c.Create(ctx, copied); err != nil {
+			if !apierrors.IsAlreadyExists(err) {
+				return fmt.Errorf("copying shared git credential %q: %w", src.Name, err)
+			}
+			existing := &corev1.Secret{}
+			if err := c.Get(ctx, client.ObjectKeyFromObject(copied), existing); err != nil {
+				return fmt.Errorf("getting shared git credential %q: %w", src.Name, err)
+			}
+			if existing.Labels[LabelManagedCredential] != "true" || existing.Type != src.Type {
+				continue
+			}
+			existing.Annotations = copied.Annotations
+			existing.Data = src.Data
+			if err := c.Update(ctx, existing); err != nil {
+				return fmt.Errorf("refreshing shared git credential %q: %w", src.Name, err)
+			}
+		}
+		if err := AddSecretToKpackSA(ctx, c, namespace, src.Name); err != nil {
+			return err
+		}
+	}
+
+	var copies corev1.SecretList
+	if err := c.List(ctx, &copies, client.InNamespace(namespace), client.MatchingLabels{LabelManagedCredential: "true"}); err != nil {
+		return fmt.Errorf("listing shared git credential copies: %w", err)
+	}
+	for i := range copies.Items {
+		stale := &copies.Items[i]
+		if current[stale.Name] {
+			continue
+		}
+		if err := RemoveSecretFromKpackSA(ctx, c, namespace, stale.Name); err != nil {
+			return err
+		}
+		if err := c.Delete(ctx, stale); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("deleting revoked shared git credential %q: %w", stale.Name, err)
+		}
+	}
+	return nil
+}
+
 // AddSecretToKpackSA appends secretName to the iaf-kpack-sa ServiceAccount's
 // secrets list (required by kpack for credential lookup). Idempotent.
 func AddSecretToKpackSA(ctx context.Context, c client.Client, namespace, secretName string) error {
@@ -0,0 +1,66 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// KnownHostsKey is the key of the SSH known_hosts entries kpack verifies
+// git servers against, both in an ssh-auth git credential Secret and in the
+// platform known_hosts ConfigMap.
+const KnownHostsKey = "known_hosts"
+
+// KnownHosts is the platform ConfigMap holding the SSH host keys of the git
+// servers the operator trusts, such as a self-hosted GitLab or Gitea. They
+// are written into every ssh git credential when it is stored or rotated.
+type KnownHosts struct {
+	Namespace string
+	Name      string
+}
+
+// IsZero reports whether no known_hosts ConfigMap is configured.
+func (k KnownHosts) IsZero() bool {
+	return k.Name == ""
+}
+
+// Load returns the entries of the ConfigMap, or "" when none is configured
+// or it does not exist yet.
+func (k KnownHosts) Load(ctx context.Context, c client.Client) (string, error) {
+	if k.IsZero() {
+		return "", nil
+	}
+	var cm corev1.ConfigMap
+	if err := c.Get(ctx, client.ObjectKey{Namespace: k.Namespace, Name: k.Name}, &cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("reading known_hosts ConfigMap: %w", err)
+	}
+	return cm.Data[KnownHostsKey], nil
+}
+
+// MergeKnownHosts joins known_hosts contents, one entry per line, dropping
+// blank lines and repeated entries.
+func MergeKnownHosts(contents ...string) string {
+	var lines []string
+	seen := map[string]bool{}
+	for _, content := range contents {
+		for _, line := range strings.Split(content, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || seen[line] {
+				continue
+			}
+			seen[line] = true
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
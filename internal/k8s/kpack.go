@@ -1,12 +1,19 @@
 package k8s
 
 import (
+	"context"
 	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // KpackImageGVR is the GroupVersionResource for kpack Image CRs.
@@ -23,18 +30,85 @@ var KpackImageGVK = schema.GroupVersionKind{
 	Kind:    "Image",
 }
 
-// BuildKpackImage constructs an unstructured kpack Image CR for the given application.
-func BuildKpackImage(app *iafv1alpha1.Application, clusterBuilder, registryPrefix string) *unstructured.Unstructured {
+// KpackBuildGVK is the GroupVersionKind for kpack Build CRs.
+var KpackBuildGVK = schema.GroupVersionKind{
+	Group:   "kpack.io",
+	Version: "v1alpha2",
+	Kind:    "Build",
+}
+
+// KpackClusterBuilderGVK is the GroupVersionKind for kpack ClusterBuilder CRs.
+var KpackClusterBuilderGVK = schema.GroupVersionKind{
+	Group:   "kpack.io",
+	Version: "v1alpha2",
+	Kind:    "ClusterBuilder",
+}
+
+// ClusterBuilderImage returns the image the named kpack ClusterBuilder is
+// published to (its spec.tag).
+func ClusterBuilderImage(ctx context.Context, c client.Reader, name string) (string, error) {
+	builder := &unstructured.Unstructured{}
+	builder.SetGroupVersionKind(KpackClusterBuilderGVK)
+	if err := c.Get(ctx, client.ObjectKey{Name: name}, builder); err != nil {
+		return "", fmt.Errorf("getting ClusterBuilder %q: %w", name, err)
+	}
+	tag, _, _ := unstructured.NestedString(builder.Object, "spec", "tag")
+	return tag, nil
+}
+
+// LabelKpackImage is set by kpack on Builds and build pods to the name of
+// the Image that produced them.
+const LabelKpackImage = "image.kpack.io/image"
+
+// LabelKpackBuildNumber is set by kpack on Builds to their sequence number
+// within the Image.
+const LabelKpackBuildNumber = "image.kpack.io/buildNumber"
+
+// BuildCache is the cache kpack reuses between builds of an Image.
+type BuildCache struct {
+	// Type is where the cache is kept. Empty means no cache.
+	Type iafv1alpha1.BuildCacheType
+	// VolumeSize is the size of the cache volume for type volume.
+	VolumeSize resource.Quantity
+}
+
+// ResolveBuildCache returns the build cache of app: its spec.buildCache
+// with unset fields taken from the platform defaults. Setting only a volume
+// size selects a volume cache.
+func ResolveBuildCache(app *iafv1alpha1.Application, defaults BuildCache) BuildCache {
+	cache := defaults
+	if c := app.Spec.BuildCache; c != nil {
+		if c.VolumeSize != nil {
+			cache.Type = iafv1alpha1.BuildCacheVolume
+			cache.VolumeSize = *c.VolumeSize
+		}
+		if c.Type != "" {
+			cache.Type = c.Type
+		}
+	}
+	if cache.Type == iafv1alpha1.BuildCacheVolume && cache.VolumeSize.IsZero() {
+		cache.Type = ""
+	}
+	return cache
+}
+
+// BuildCacheTag returns the image a registry build cache is pushed to: a tag
+// of the application's image, so it stays under the registry prefix.
+func BuildCacheTag(registryPrefix, name string) string {
+	return fmt.Sprintf("%s/%s:build-cache", registryPrefix, name)
+}
+
+// BuildKpackImage constructs an unstructured kpack Image CR for the given
+// application. The proxy settings are added to the build env unless the app
+// opted out.
+func BuildKpackImage(app *iafv1alpha1.Application, clusterBuilder, registryPrefix string, cache BuildCache, proxy Proxy) *unstructured.Unstructured {
 	imageTag := fmt.Sprintf("%s/%s", registryPrefix, app.Name)
 
 	obj := &unstructured.Unstructured{}
 	obj.SetGroupVersionKind(KpackImageGVK)
 	obj.SetName(app.Name)
 	obj.SetNamespace(app.Namespace)
-	obj.SetLabels(map[string]string{
-		"app.kubernetes.io/managed-by": "iaf",
-		"iaf.io/application":          app.Name,
-	})
+	obj.SetLabels(AppLabels(app))
 
 	// Set owner reference so the kpack Image is cleaned up with the Application
 	obj.SetOwnerReferences([]metav1.OwnerReference{
@@ -57,14 +131,10 @@ func BuildKpackImage(app *iafv1alpha1.Application, clusterBuilder, registryPrefi
 
 	// Set source based on Application spec
 	if app.Spec.Git != nil {
-		revision := app.Spec.Git.Revision
-		if revision == "" {
-			revision = "main"
-		}
 		spec["source"] = map[string]any{
 			"git": map[string]any{
 				"url":      app.Spec.Git.URL,
-				"revision": revision,
+				"revision": KpackGitRevision(app),
 			},
 		}
 	} else if app.Spec.Blob != "" {
@@ -74,11 +144,208 @@ func BuildKpackImage(app *iafv1alpha1.Application, clusterBuilder, registryPrefi
 			},
 		}
 	}
+	if source, ok := spec["source"].(map[string]any); ok {
+		if subPath := SourceSubPath(app); subPath != "" {
+			source["subPath"] = subPath
+		}
+	}
+
+	buildEnv := slices.Clone(app.Spec.BuildEnv)
+	for _, e := range ProxyEnv(app, proxy, app.Spec.BuildEnv) {
+		buildEnv = append(buildEnv, iafv1alpha1.EnvVar{Name: e.Name, Value: e.Value})
+	}
+	if len(buildEnv) > 0 {
+		env := make([]any, 0, len(buildEnv))
+		for _, e := range buildEnv {
+			v := map[string]any{"name": e.Name}
+			// Omit empty values, as kpack's defaulting webhook does, so the
+			// spec compares equal to the stored Image.
+			if e.Value != "" {
+				v["value"] = e.Value
+			}
+			env = append(env, v)
+		}
+		spec["build"] = map[string]any{"env": env}
+	}
+
+	switch cache.Type {
+	case iafv1alpha1.BuildCacheVolume:
+		spec["cache"] = map[string]any{
+			"volume": map[string]any{
+				"size": cache.VolumeSize.String(),
+			},
+		}
+	case iafv1alpha1.BuildCacheRegistry:
+		spec["cache"] = map[string]any{
+			"registry": map[string]any{
+				"tag": BuildCacheTag(registryPrefix, app.Name),
+			},
+		}
+	}
 
 	obj.Object["spec"] = spec
 	return obj
 }
 
+// GitRevision returns the git revision app builds, defaulting to "main".
+func GitRevision(app *iafv1alpha1.Application) string {
+	if app.Spec.Git == nil || app.Spec.Git.Revision == "" {
+		return "main"
+	}
+	return app.Spec.Git.Revision
+}
+
+// GitAutoDeploy reports whether app builds and deploys each new commit on
+// its branch.
+func GitAutoDeploy(app *iafv1alpha1.Application) bool {
+	return app.Spec.Git != nil && app.Spec.Git.Track == iafv1alpha1.GitTrackBranch && !app.Spec.Git.Paused
+}
+
+// KpackGitRevision returns the revision the kpack Image of app builds.
+// kpack polls a branch revision and builds each new commit, so unless app
+// auto-deploys the branch, the Image is pinned to the commit app already
+// runs for its revision.
+func KpackGitRevision(app *iafv1alpha1.Application) string {
+	revision := GitRevision(app)
+	if GitAutoDeploy(app) {
+		return revision
+	}
+	if s := app.Status.Git; s != nil && s.Commit != "" && s.URL == app.Spec.Git.URL && s.Revision == revision {
+		return s.Commit
+	}
+	return revision
+}
+
+// SourceSubPath returns the directory of the app's git or blob source that
+// is built, or "" for the source root.
+func SourceSubPath(app *iafv1alpha1.Application) string {
+	if app.Spec.Git != nil {
+		return app.Spec.Git.SubPath
+	}
+	if app.Spec.Blob != "" {
+		return app.Spec.BlobSubPath
+	}
+	return ""
+}
+
+// KpackCacheShrinks reports whether changing an Image's spec.cache from
+// current to desired removes or shrinks its cache volume, which kpack
+// rejects: the Image must be replaced instead.
+func KpackCacheShrinks(current, desired map[string]any) bool {
+	currentSize, ok, _ := unstructured.NestedString(current, "volume", "size")
+	if !ok {
+		return false
+	}
+	desiredSize, ok, _ := unstructured.NestedString(desired, "volume", "size")
+	if !ok {
+		return true
+	}
+	cur, err := resource.ParseQuantity(currentSize)
+	if err != nil {
+		return true
+	}
+	want, err := resource.ParseQuantity(desiredSize)
+	if err != nil {
+		return true
+	}
+	return want.Cmp(cur) < 0
+}
+
+// KpackBuildInfo summarizes a kpack Build.
+type KpackBuildInfo struct {
+	Name   string
+	Number int
+	// Status is Building, Succeeded, or Failed.
+	Status    string
+	StartTime time.Time
+	// CompletionTime is zero while the build runs.
+	CompletionTime time.Time
+	// Cache is "hit" when the build could restore a cache populated by an
+	// earlier successful build, "miss" when it had a cache but nothing to
+	// restore, and "none" when it ran without a cache.
+	Cache string
+	// Commit is the git commit the build resolved its revision to. Empty
+	// for builds from a blob.
+	Commit string
+	// Image is the image the build pushed. Empty until it succeeds.
+	Image string
+}
+
+// Duration returns how long the build took, or has taken so far at now.
+func (b *KpackBuildInfo) Duration(now time.Time) time.Duration {
+	end := b.CompletionTime
+	if end.IsZero() {
+		end = now
+	}
+	return end.Sub(b.StartTime).Round(time.Second)
+}
+
+// LatestKpackBuild returns the most recent of an Image's builds, or nil when
+// there are none.
+func LatestKpackBuild(builds []unstructured.Unstructured) *KpackBuildInfo {
+	if len(builds) == 0 {
+		return nil
+	}
+	infos := make([]KpackBuildInfo, 0, len(builds))
+	for i := range builds {
+		infos = append(infos, kpackBuildInfo(&builds[i]))
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		if infos[i].Number != infos[j].Number {
+			return infos[i].Number < infos[j].Number
+		}
+		return infos[i].StartTime.Before(infos[j].StartTime)
+	})
+
+	latest := infos[len(infos)-1]
+	if latest.Cache == "" {
+		latest.Cache = "miss"
+		for _, b := range infos[:len(infos)-1] {
+			if b.Status == "Succeeded" && b.Cache != "none" {
+				latest.Cache = "hit"
+				break
+			}
+		}
+	}
+	return &latest
+}
+
+// kpackBuildInfo summarizes build. Cache is left empty when the build had a
+// cache, since hits depend on earlier builds.
+func kpackBuildInfo(build *unstructured.Unstructured) KpackBuildInfo {
+	info := KpackBuildInfo{
+		Name:      build.GetName(),
+		Status:    "Building",
+		StartTime: build.GetCreationTimestamp().Time,
+	}
+	info.Number, _ = strconv.Atoi(build.GetLabels()[LabelKpackBuildNumber])
+	info.Commit, _, _ = unstructured.NestedString(build.Object, "spec", "source", "git", "revision")
+	info.Image, _, _ = unstructured.NestedString(build.Object, "status", "latestImage")
+	if _, ok, _ := unstructured.NestedMap(build.Object, "spec", "cache"); !ok {
+		info.Cache = "none"
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(build.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]any)
+		if !ok || cond["type"] != "Succeeded" {
+			continue
+		}
+		switch cond["status"] {
+		case "True":
+			info.Status = "Succeeded"
+		case "False":
+			info.Status = "Failed"
+		default:
+			continue
+		}
+		if ts, ok := cond["lastTransitionTime"].(string); ok {
+			info.CompletionTime, _ = time.Parse(time.RFC3339, ts)
+		}
+	}
+	return info
+}
+
 // GetKpackImageStatus extracts build status information from a kpack Image CR.
 func GetKpackImageStatus(obj *unstructured.Unstructured) (buildStatus string, latestImage string) {
 	status, ok := obj.Object["status"].(map[string]any)
@@ -0,0 +1,248 @@
+package k8s
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestBuildKpackImage_Cache(t *testing.T) {
+	volume := BuildCache{Type: iafv1alpha1.BuildCacheVolume, VolumeSize: resource.MustParse("2Gi")}
+	size := resource.MustParse("5Gi")
+
+	tests := []struct {
+		name      string
+		cache     *iafv1alpha1.BuildCacheConfig
+		defaults  BuildCache
+		wantSize  string
+		wantTag   string
+		wantCache bool
+	}{
+		{"platform default", nil, volume, "2Gi", "", true},
+		{"no platform cache", nil, BuildCache{}, "", "", false},
+		{"larger volume", &iafv1alpha1.BuildCacheConfig{VolumeSize: &size}, BuildCache{Type: iafv1alpha1.BuildCacheNone}, "5Gi", "", true},
+		{"registry", &iafv1alpha1.BuildCacheConfig{Type: iafv1alpha1.BuildCacheRegistry}, volume, "", "registry.local/iaf/web:build-cache", true},
+		{"disabled", &iafv1alpha1.BuildCacheConfig{Type: iafv1alpha1.BuildCacheNone}, volume, "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &iafv1alpha1.Application{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "iaf-abc"},
+				Spec: iafv1alpha1.ApplicationSpec{
+					Git:        &iafv1alpha1.GitSource{URL: "https://github.com/example/web"},
+					BuildCache: tt.cache,
+				},
+			}
+			obj := BuildKpackImage(app, "default", "registry.local/iaf", ResolveBuildCache(app, tt.defaults), Proxy{})
+			_, hasCache, _ := unstructured.NestedMap(obj.Object, "spec", "cache")
+			if hasCache != tt.wantCache {
+				t.Fatalf("cache present = %v, want %v", hasCache, tt.wantCache)
+			}
+			if got, _, _ := unstructured.NestedString(obj.Object, "spec", "cache", "volume", "size"); got != tt.wantSize {
+				t.Errorf("volume size = %q, want %q", got, tt.wantSize)
+			}
+			if got, _, _ := unstructured.NestedString(obj.Object, "spec", "cache", "registry", "tag"); got != tt.wantTag {
+				t.Errorf("registry tag = %q, want %q", got, tt.wantTag)
+			}
+		})
+	}
+}
+
+func TestBuildKpackImage_BuildEnv(t *testing.T) {
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "iaf-abc"},
+		Spec: iafv1alpha1.ApplicationSpec{
+			Git: &iafv1alpha1.GitSource{URL: "https://github.com/example/web"},
+			Env: []iafv1alpha1.EnvVar{{Name: "PORT", Value: "8080"}},
+		},
+	}
+	obj := BuildKpackImage(app, "default", "registry.local/iaf", BuildCache{}, Proxy{})
+	if _, found, _ := unstructured.NestedMap(obj.Object, "spec", "build"); found {
+		t.Errorf("expected no build section without build env, got %v", obj.Object["spec"])
+	}
+
+	app.Spec.BuildEnv = []iafv1alpha1.EnvVar{{Name: "BP_GO_TARGETS", Value: "./cmd/server"}, {Name: "BP_DEBUG"}}
+	obj = BuildKpackImage(app, "default", "registry.local/iaf", BuildCache{}, Proxy{})
+	env, _, _ := unstructured.NestedSlice(obj.Object, "spec", "build", "env")
+	want := []any{
+		map[string]any{"name": "BP_GO_TARGETS", "value": "./cmd/server"},
+		map[string]any{"name": "BP_DEBUG"},
+	}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("build env = %v, want %v", env, want)
+	}
+
+	obj = BuildKpackImage(app, "default", "registry.local/iaf", BuildCache{}, Proxy{HTTPSProxy: "http://proxy.corp:3128"})
+	env, _, _ = unstructured.NestedSlice(obj.Object, "spec", "build", "env")
+	if len(env) != 6 || env[2].(map[string]any)["name"] != "HTTPS_PROXY" || env[3].(map[string]any)["name"] != "https_proxy" {
+		t.Errorf("expected the proxy settings after the app's build env, got %v", env)
+	}
+	if len(app.Spec.BuildEnv) != 2 {
+		t.Errorf("app build env modified: %v", app.Spec.BuildEnv)
+	}
+}
+
+func TestKpackCacheShrinks(t *testing.T) {
+	volume := func(size string) map[string]any {
+		return map[string]any{"volume": map[string]any{"size": size}}
+	}
+	registry := map[string]any{"registry": map[string]any{"tag": "registry.local/iaf/web:build-cache"}}
+
+	tests := []struct {
+		name             string
+		current, desired map[string]any
+		want             bool
+	}{
+		{"added", nil, volume("2Gi"), false},
+		{"grown", volume("2Gi"), volume("5Gi"), false},
+		{"unchanged", volume("2Gi"), volume("2Gi"), false},
+		{"shrunk", volume("5Gi"), volume("2Gi"), true},
+		{"removed", volume("2Gi"), nil, true},
+		{"moved to registry", volume("2Gi"), registry, true},
+		{"registry to volume", registry, volume("2Gi"), false},
+	}
+	for _, tt := range tests {
+		if got := KpackCacheShrinks(tt.current, tt.desired); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLatestKpackBuild(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	build := func(number string, cached bool, status string) unstructured.Unstructured {
+		b := unstructured.Unstructured{Object: map[string]any{}}
+		b.SetName("web-build-" + number)
+		b.SetLabels(map[string]string{LabelKpackImage: "web", LabelKpackBuildNumber: number})
+		b.SetCreationTimestamp(metav1.NewTime(start))
+		if cached {
+			b.Object["spec"] = map[string]any{"cache": map[string]any{"volume": map[string]any{"persistentVolumeClaimName": "web-cache"}}}
+		}
+		if status != "" {
+			b.Object["status"] = map[string]any{"conditions": []any{
+				map[string]any{"type": "Succeeded", "status": status, "lastTransitionTime": start.Add(90 * time.Second).Format(time.RFC3339)},
+			}}
+		}
+		return b
+	}
+
+	if LatestKpackBuild(nil) != nil {
+		t.Error("expected nil without builds")
+	}
+
+	tests := []struct {
+		name       string
+		builds     []unstructured.Unstructured
+		wantName   string
+		wantStatus string
+		wantCache  string
+	}{
+		{"first cached build", []unstructured.Unstructured{build("1", true, "")}, "web-build-1", "Building", "miss"},
+		{"uncached build", []unstructured.Unstructured{build("1", false, "True")}, "web-build-1", "Succeeded", "none"},
+		{"after a cached success", []unstructured.Unstructured{build("2", true, "False"), build("1", true, "True")}, "web-build-2", "Failed", "hit"},
+		{"after an uncached success", []unstructured.Unstructured{build("1", false, "True"), build("2", true, "True")}, "web-build-2", "Succeeded", "miss"},
+		{"after a failure", []unstructured.Unstructured{build("1", true, "False"), build("2", true, "")}, "web-build-2", "Building", "miss"},
+		{"ordered numerically", []unstructured.Unstructured{build("10", true, "True"), build("9", true, "True")}, "web-build-10", "Succeeded", "hit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LatestKpackBuild(tt.builds)
+			if got.Name != tt.wantName || got.Status != tt.wantStatus || got.Cache != tt.wantCache {
+				t.Errorf("got %s %s cache %s, want %s %s cache %s", got.Name, got.Status, got.Cache, tt.wantName, tt.wantStatus, tt.wantCache)
+			}
+			if tt.wantStatus == "Building" {
+				if !got.CompletionTime.IsZero() || got.Duration(start.Add(time.Minute)) != time.Minute {
+					t.Errorf("expected a running build to report elapsed time, got %+v", got)
+				}
+			} else if got.Duration(time.Time{}) != 90*time.Second {
+				t.Errorf("expected a 90s build, got %s", got.Duration(time.Time{}))
+			}
+		})
+	}
+}
+
+func TestClusterBuilderImage(t *testing.T) {
+	builder := &unstructured.Unstructured{}
+	builder.SetGroupVersionKind(KpackClusterBuilderGVK)
+	builder.SetName("iaf-cluster-builder")
+	if err := unstructured.SetNestedField(builder.Object, "harbor.corp.example/iaf/builder", "spec", "tag"); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithObjects(builder).Build()
+
+	got, err := ClusterBuilderImage(context.Background(), c, "iaf-cluster-builder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "harbor.corp.example/iaf/builder" {
+		t.Errorf("ClusterBuilderImage = %q", got)
+	}
+	if _, err := ClusterBuilderImage(context.Background(), c, "missing"); !apierrors.IsNotFound(err) {
+		t.Errorf("expected not found for a missing builder, got %v", err)
+	}
+}
+
+func TestKpackGitRevision(t *testing.T) {
+	const url = "https://github.com/example/web"
+	deployed := &iafv1alpha1.GitStatus{URL: url, Revision: "main", Commit: "0123abc"}
+
+	tests := []struct {
+		name   string
+		git    iafv1alpha1.GitSource
+		status *iafv1alpha1.GitStatus
+		want   string
+	}{
+		{"first build", iafv1alpha1.GitSource{URL: url}, nil, "main"},
+		{"pinned to deployed commit", iafv1alpha1.GitSource{URL: url}, deployed, "0123abc"},
+		{"tracking branch", iafv1alpha1.GitSource{URL: url, Track: iafv1alpha1.GitTrackBranch}, deployed, "main"},
+		{"paused branch", iafv1alpha1.GitSource{URL: url, Track: iafv1alpha1.GitTrackBranch, Paused: true}, deployed, "0123abc"},
+		{"new revision", iafv1alpha1.GitSource{URL: url, Revision: "v2"}, deployed, "v2"},
+		{"new url", iafv1alpha1.GitSource{URL: "https://github.com/example/api"}, deployed, "main"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &iafv1alpha1.Application{
+				Spec:   iafv1alpha1.ApplicationSpec{Git: &tt.git},
+				Status: iafv1alpha1.ApplicationStatus{Git: tt.status},
+			}
+			if got := KpackGitRevision(app); got != tt.want {
+				t.Errorf("KpackGitRevision = %q, want %q", got, tt.want)
+			}
+			obj := BuildKpackImage(app, "default", "registry.local/iaf", BuildCache{}, Proxy{})
+			if got, _, _ := unstructured.NestedString(obj.Object, "spec", "source", "git", "revision"); got != tt.want {
+				t.Errorf("image revision = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildKpackImage_SubPath(t *testing.T) {
+	tests := []struct {
+		name string
+		spec iafv1alpha1.ApplicationSpec
+		want string
+	}{
+		{"git root", iafv1alpha1.ApplicationSpec{Git: &iafv1alpha1.GitSource{URL: "https://github.com/example/mono"}}, ""},
+		{"git", iafv1alpha1.ApplicationSpec{Git: &iafv1alpha1.GitSource{URL: "https://github.com/example/mono", SubPath: "services/api"}}, "services/api"},
+		{"blob", iafv1alpha1.ApplicationSpec{Blob: "http://store/sources/ns/web/source.tar.gz", BlobSubPath: "web"}, "web"},
+		{"stale blob sub path", iafv1alpha1.ApplicationSpec{Git: &iafv1alpha1.GitSource{URL: "https://github.com/example/mono"}, BlobSubPath: "web"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &iafv1alpha1.Application{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "iaf-abc"}, Spec: tt.spec}
+			obj := BuildKpackImage(app, "default", "registry.local/iaf", BuildCache{}, Proxy{})
+			got, found, _ := unstructured.NestedString(obj.Object, "spec", "source", "subPath")
+			if got != tt.want || found != (tt.want != "") {
+				t.Errorf("subPath = %q (found %v), want %q", got, found, tt.want)
+			}
+		})
+	}
+}
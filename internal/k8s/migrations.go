@@ -0,0 +1,170 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// LabelMigrationOf is set on migration Jobs, their pods and the
+	// migration history ConfigMap to the application name. Migration pods
+	// deliberately do not carry iaf.io/application, so the app's Service
+	// never routes traffic to them.
+	LabelMigrationOf = "iaf.io/migration-of"
+
+	// MigrationHistoryKey is the ConfigMap key holding the JSON list of runs.
+	MigrationHistoryKey = "history"
+
+	// MaxMigrationHistory is the number of runs kept in the history.
+	MaxMigrationHistory = 20
+
+	// Migration run statuses.
+	MigrationRunning   = "Running"
+	MigrationSucceeded = "Succeeded"
+	MigrationFailed    = "Failed"
+	// MigrationUnknown is recorded for a run whose Job was deleted before
+	// it finished.
+	MigrationUnknown = "Unknown"
+)
+
+const (
+	// migrationDeadline bounds how long a migration may run, in seconds.
+	migrationDeadline = 30 * 60
+	// migrationJobTTL is how long finished Jobs are kept, in seconds; the
+	// history ConfigMap outlives them.
+	migrationJobTTL = 24 * 60 * 60
+)
+
+// MigrationRun is one entry in an application's migration history.
+type MigrationRun struct {
+	Run         int        `json:"run"`
+	Job         string     `json:"job"`
+	Command     []string   `json:"command"`
+	Status      string     `json:"status"`
+	Message     string     `json:"message,omitempty"`
+	StartedAt   time.Time  `json:"startedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// MigrationHistoryName returns the name of the ConfigMap recording the
+// migration runs of an application.
+func MigrationHistoryName(app string) string {
+	return app + "-migrations"
+}
+
+// MigrationJobName returns the name of the Job for migration run n of an
+// application, shortening the application name so the Job name stays a
+// valid label value.
+func MigrationJobName(app string, n int) string {
+	suffix := fmt.Sprintf("-migrate-%d", n)
+	if len(app)+len(suffix) > 63 {
+		app = strings.TrimRight(app[:63-len(suffix)], "-")
+	}
+	return app + suffix
+}
+
+// BuildMigrationJob constructs the Job for migration run n of app. The pod
+// copies the image, environment, config file mounts and placement of the
+// app container in dep, so the command reaches the same bound services
+// with the same credentials. Source-built images run the command through
+// the buildpack launcher so the buildpack environment applies; pre-built
+// images run it in place of their entrypoint. The Job is never retried.
+func BuildMigrationJob(app *iafv1alpha1.Application, dep *appsv1.Deployment, n int, command []string) (*batchv1.Job, error) {
+	var container *corev1.Container
+	for i := range dep.Spec.Template.Spec.Containers {
+		if dep.Spec.Template.Spec.Containers[i].Name == "app" {
+			container = &dep.Spec.Template.Spec.Containers[i]
+		}
+	}
+	if container == nil {
+		return nil, fmt.Errorf("deployment %q has no app container", dep.Name)
+	}
+
+	labels := OwnershipLabels(app.Spec.Metadata)
+	labels["app.kubernetes.io/managed-by"] = "iaf"
+	labels[LabelMigrationOf] = app.Name
+	migrate := corev1.Container{
+		Name:            "migrate",
+		Image:           container.Image,
+		Env:             container.Env,
+		EnvFrom:         container.EnvFrom,
+		VolumeMounts:    container.VolumeMounts,
+		Resources:       container.Resources,
+		SecurityContext: container.SecurityContext,
+	}
+	if app.Spec.Git != nil || app.Spec.Blob != "" {
+		migrate.Command = []string{buildpackLauncher}
+		migrate.Args = command
+	} else {
+		migrate.Command = command
+	}
+	pod := dep.Spec.Template.Spec
+	podSecurity := pod.SecurityContext.DeepCopy()
+	if podSecurity == nil {
+		podSecurity = &corev1.PodSecurityContext{}
+	}
+	podSecurity.RunAsNonRoot = boolPtr(true)
+
+	backoffLimit := int32(0)
+	deadline := int64(migrationDeadline)
+	ttl := int32(migrationJobTTL)
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      MigrationJobName(app.Name, n),
+			Namespace: app.Namespace,
+			Labels:    labels,
+			// Migration Jobs are deleted with the application they migrate.
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: iafv1alpha1.GroupVersion.String(),
+					Kind:       "Application",
+					Name:       app.Name,
+					UID:        app.UID,
+				},
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoffLimit,
+			ActiveDeadlineSeconds:   &deadline,
+			TTLSecondsAfterFinished: &ttl,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy:    corev1.RestartPolicyNever,
+					SecurityContext:  podSecurity,
+					Containers:       []corev1.Container{migrate},
+					Volumes:          pod.Volumes,
+					ImagePullSecrets: pod.ImagePullSecrets,
+					NodeSelector:     pod.NodeSelector,
+					Tolerations:      pod.Tolerations,
+					Affinity:         pod.Affinity,
+				},
+			},
+		},
+	}, nil
+}
+
+// MigrationJobStatus returns the status of a migration Job and, when it
+// failed, the reason Kubernetes gives.
+func MigrationJobStatus(job *batchv1.Job) (status, message string, completedAt *time.Time) {
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		at := c.LastTransitionTime.Time
+		switch c.Type {
+		case batchv1.JobComplete:
+			return MigrationSucceeded, "", &at
+		case batchv1.JobFailed:
+			return MigrationFailed, c.Message, &at
+		}
+	}
+	return MigrationRunning, "", nil
+}
@@ -0,0 +1,144 @@
+package k8s
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMigrationJobName(t *testing.T) {
+	if got := MigrationJobName("web", 3); got != "web-migrate-3" {
+		t.Errorf("MigrationJobName = %q, want web-migrate-3", got)
+	}
+	long := strings.Repeat("a", 50) + "-" + strings.Repeat("b", 12)
+	got := MigrationJobName(long, 12)
+	if len(got) > 63 || !strings.HasSuffix(got, "-migrate-12") || strings.Contains(got, "--") {
+		t.Errorf("MigrationJobName(%q) = %q, want at most 63 characters ending in -migrate-12", long, got)
+	}
+}
+
+func TestBuildMigrationJob(t *testing.T) {
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "iaf-abc"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"iaf.io/application": "web"}},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:    "app",
+							Image:   "registry.example.com/web@sha256:abc",
+							Env:     []corev1.EnvVar{{Name: "PGHOST", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "db-app"}, Key: "host"}}}},
+							EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "iaf-env-shared"}}}},
+						},
+						{Name: "oauth2-proxy", Image: "oauth2-proxy"},
+					},
+					ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+					NodeSelector:     map[string]string{"kubernetes.io/arch": "arm64"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		spec        iafv1alpha1.ApplicationSpec
+		wantCommand []string
+		wantArgs    []string
+	}{
+		{
+			name:        "pre-built image",
+			spec:        iafv1alpha1.ApplicationSpec{Image: "web:1.0"},
+			wantCommand: []string{"npm", "run", "migrate"},
+		},
+		{
+			name:        "git source runs through the launcher",
+			spec:        iafv1alpha1.ApplicationSpec{Git: &iafv1alpha1.GitSource{URL: "https://github.com/org/web"}},
+			wantCommand: []string{"launcher"},
+			wantArgs:    []string{"npm", "run", "migrate"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &iafv1alpha1.Application{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "iaf-abc", UID: "uid-1"},
+				Spec:       tt.spec,
+			}
+			job, err := BuildMigrationJob(app, dep, 2, []string{"npm", "run", "migrate"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if job.Name != "web-migrate-2" || job.Namespace != "iaf-abc" {
+				t.Errorf("job = %s/%s, want iaf-abc/web-migrate-2", job.Namespace, job.Name)
+			}
+			if len(job.OwnerReferences) != 1 || job.OwnerReferences[0].UID != "uid-1" {
+				t.Errorf("expected the job to be owned by the app, got %v", job.OwnerReferences)
+			}
+			if *job.Spec.BackoffLimit != 0 {
+				t.Errorf("backoffLimit = %d, want 0", *job.Spec.BackoffLimit)
+			}
+			pod := job.Spec.Template
+			if _, ok := pod.Labels["iaf.io/application"]; ok {
+				t.Error("migration pods must not carry the application label the Service selects")
+			}
+			if pod.Labels[LabelMigrationOf] != "web" {
+				t.Errorf("expected %s=web on the pod, got %v", LabelMigrationOf, pod.Labels)
+			}
+			if pod.Spec.RestartPolicy != corev1.RestartPolicyNever {
+				t.Errorf("restartPolicy = %s, want Never", pod.Spec.RestartPolicy)
+			}
+			if pod.Spec.SecurityContext == nil || pod.Spec.SecurityContext.RunAsNonRoot == nil || !*pod.Spec.SecurityContext.RunAsNonRoot {
+				t.Error("expected the migration pod to run as non-root")
+			}
+			if len(pod.Spec.Containers) != 1 {
+				t.Fatalf("expected only the migrate container, got %d", len(pod.Spec.Containers))
+			}
+			c := pod.Spec.Containers[0]
+			if c.Image != "registry.example.com/web@sha256:abc" || len(c.Env) != 1 || len(c.EnvFrom) != 1 {
+				t.Errorf("expected the app image and environment, got %+v", c)
+			}
+			if !slices.Equal(c.Command, tt.wantCommand) || !slices.Equal(c.Args, tt.wantArgs) {
+				t.Errorf("command = %v args = %v, want %v %v", c.Command, c.Args, tt.wantCommand, tt.wantArgs)
+			}
+			if pod.Spec.NodeSelector["kubernetes.io/arch"] != "arm64" || len(pod.Spec.ImagePullSecrets) != 1 {
+				t.Errorf("expected the app's placement and pull secrets, got %+v", pod.Spec)
+			}
+		})
+	}
+	if dep.Spec.Template.Spec.SecurityContext != nil {
+		t.Error("BuildMigrationJob must not modify the Deployment")
+	}
+}
+
+func TestMigrationJobStatus(t *testing.T) {
+	now := metav1.NewTime(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	tests := []struct {
+		name       string
+		conditions []batchv1.JobCondition
+		want       string
+	}{
+		{"running", nil, MigrationRunning},
+		{"complete", []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: now}}, MigrationSucceeded},
+		{"failed", []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, LastTransitionTime: now, Message: "Job has reached the specified backoff limit"}}, MigrationFailed},
+		{"suspended", []batchv1.JobCondition{{Type: batchv1.JobSuspended, Status: corev1.ConditionTrue}}, MigrationRunning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &batchv1.Job{Status: batchv1.JobStatus{Conditions: tt.conditions}}
+			status, _, completedAt := MigrationJobStatus(job)
+			if status != tt.want {
+				t.Errorf("status = %s, want %s", status, tt.want)
+			}
+			if (completedAt != nil) != (tt.want != MigrationRunning) {
+				t.Errorf("completedAt = %v for status %s", completedAt, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AgentServiceAccount is the ServiceAccount, Role and RoleBinding name
+	// behind the kubeconfigs issued for a session namespace.
+	AgentServiceAccount = "iaf-agent"
+	// rootCAConfigMap is published by Kubernetes in every namespace with the
+	// CA of the API server.
+	rootCAConfigMap = "kube-root-ca.crt"
+)
+
+// AgentRules are the permissions of issued kubeconfigs: reading the
+// session's applications and the workloads behind them, and pod logs. They
+// grant nothing on Secrets, nothing outside the namespace, and no writes,
+// exec or port-forward, so agents change apps only through the platform.
+var AgentRules = []rbacv1.PolicyRule{
+	{APIGroups: []string{iafv1alpha1.GroupVersion.Group}, Resources: []string{"applications"}, Verbs: []string{"get", "list", "watch"}},
+	{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get", "list", "watch"}},
+	{APIGroups: []string{""}, Resources: []string{"services", "configmaps"}, Verbs: []string{"get", "list", "watch"}},
+	{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}},
+	{APIGroups: []string{""}, Resources: []string{"pods/log"}, Verbs: []string{"get"}},
+	{APIGroups: []string{""}, Resources: []string{"events"}, Verbs: []string{"list"}},
+}
+
+// EnsureAgentAccess creates the agent ServiceAccount of namespace, bound to
+// a Role with AgentRules, and resets the Role to AgentRules if it was
+// changed.
+func EnsureAgentAccess(ctx context.Context, c client.Client, namespace string) error {
+	labels := map[string]string{"app.kubernetes.io/managed-by": "iaf"}
+	meta := func() metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: AgentServiceAccount, Namespace: namespace, Labels: labels}
+	}
+	sa := &corev1.ServiceAccount{ObjectMeta: meta(), AutomountServiceAccountToken: boolPtr(false)}
+	if err := c.Create(ctx, sa); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("creating agent service account: %w", err)
+	}
+
+	role := &rbacv1.Role{ObjectMeta: meta(), Rules: AgentRules}
+	if err := c.Create(ctx, role); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("creating agent role: %w", err)
+		}
+		if err := c.Get(ctx, client.ObjectKeyFromObject(role), role); err != nil {
+			return fmt.Errorf("getting agent role: %w", err)
+		}
+		role.Rules = AgentRules
+		if err := c.Update(ctx, role); err != nil {
+			return fmt.Errorf("updating agent role: %w", err)
+		}
+	}
+
+	binding := &rbacv1.RoleBinding{
+		ObjectMeta: meta(),
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: AgentServiceAccount},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: AgentServiceAccount, Namespace: namespace}},
+	}
+	if err := c.Create(ctx, binding); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("creating agent role binding: %w", err)
+	}
+	return nil
+}
+
+// IssueAgentToken mints a token of the agent ServiceAccount of namespace
+// valid for ttl, and returns it with its expiry. The token is not stored
+// anywhere; it lapses on its own.
+func IssueAgentToken(ctx context.Context, c client.Client, namespace string, ttl time.Duration) (string, time.Time, error) {
+	seconds := int64(ttl.Seconds())
+	request := &authenticationv1.TokenRequest{Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &seconds}}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: AgentServiceAccount, Namespace: namespace}}
+	if err := c.SubResource("token").Create(ctx, sa, request); err != nil {
+		return "", time.Time{}, fmt.Errorf("requesting agent token: %w", err)
+	}
+	return request.Status.Token, request.Status.ExpirationTimestamp.Time, nil
+}
+
+// APIServerCA returns the CA of the API server as published in namespace,
+// or nil when it is not published.
+func APIServerCA(ctx context.Context, c client.Client, namespace string) ([]byte, error) {
+	var cm corev1.ConfigMap
+	if err := c.Get(ctx, client.ObjectKey{Name: rootCAConfigMap, Namespace: namespace}, &cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getting API server CA: %w", err)
+	}
+	return []byte(cm.Data["ca.crt"]), nil
+}
+
+// BuildKubeconfig returns a kubeconfig for server that authenticates with
+// token and defaults to namespace. Without ca, the client verifies the
+// server against the system roots.
+func BuildKubeconfig(server string, ca []byte, namespace, token string) ([]byte, error) {
+	name := "iaf-" + namespace
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters[name] = &clientcmdapi.Cluster{Server: server, CertificateAuthorityData: ca}
+	cfg.AuthInfos[name] = &clientcmdapi.AuthInfo{Token: token}
+	cfg.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: name, Namespace: namespace}
+	cfg.CurrentContext = name
+	return clientcmd.Write(*cfg)
+}
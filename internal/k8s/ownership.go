@@ -0,0 +1,92 @@
+package k8s
+
+import (
+	"maps"
+	"strings"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// LabelTeam is set on every object generated for an application to the
+	// owning team from spec.metadata.
+	LabelTeam = "iaf.io/team"
+
+	// LabelOwner is set to the owner email from spec.metadata, with its '@'
+	// written as "_at_" since label values may not contain '@'.
+	LabelOwner = "iaf.io/owner"
+
+	// LabelPurpose is set to the purpose from spec.metadata.
+	LabelPurpose = "iaf.io/purpose"
+
+	// LabelCostCenter is set to the cost center from spec.metadata.
+	LabelCostCenter = "iaf.io/cost-center"
+)
+
+// ownershipLabelKeys are the labels OwnershipLabels may set.
+var ownershipLabelKeys = []string{LabelTeam, LabelOwner, LabelPurpose, LabelCostCenter}
+
+// OwnerLabelValue returns the LabelOwner value of an owner email.
+func OwnerLabelValue(email string) string {
+	return strings.Replace(email, "@", "_at_", 1)
+}
+
+// OwnershipLabels returns the labels recording the fields of m that are set.
+func OwnershipLabels(m *iafv1alpha1.OwnershipMetadata) map[string]string {
+	labels := map[string]string{}
+	if m == nil {
+		return labels
+	}
+	for key, value := range map[string]string{
+		LabelTeam:       m.Team,
+		LabelOwner:      OwnerLabelValue(m.Owner),
+		LabelPurpose:    m.Purpose,
+		LabelCostCenter: m.CostCenter,
+	} {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// AppLabels returns the labels of an object generated for app: the IAF
+// management and application labels and app's ownership labels.
+func AppLabels(app *iafv1alpha1.Application) map[string]string {
+	labels := OwnershipLabels(app.Spec.Metadata)
+	labels["app.kubernetes.io/managed-by"] = "iaf"
+	labels["iaf.io/application"] = app.Name
+	return labels
+}
+
+// PodLabels returns the labels of app's pods: the application label, which
+// its Deployment and Service select on, and app's ownership labels.
+func PodLabels(app *iafv1alpha1.Application) map[string]string {
+	labels := OwnershipLabels(app.Spec.Metadata)
+	labels["iaf.io/application"] = app.Name
+	return labels
+}
+
+// CopyOwnershipLabels sets the ownership labels of dst to those of src,
+// removing the ones src lacks, and leaves dst's other labels alone. It
+// brings an existing object's labels up to date with a rebuilt one and
+// reports whether any changed.
+func CopyOwnershipLabels(dst, src metav1.Object) bool {
+	labels := maps.Clone(dst.GetLabels())
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for _, key := range ownershipLabelKeys {
+		if value, ok := src.GetLabels()[key]; ok {
+			labels[key] = value
+		} else {
+			delete(labels, key)
+		}
+	}
+	if maps.Equal(labels, dst.GetLabels()) {
+		return false
+	}
+	dst.SetLabels(labels)
+	return true
+}
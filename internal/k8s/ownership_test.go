@@ -0,0 +1,60 @@
+package k8s
+
+import (
+	"maps"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOwnershipLabels(t *testing.T) {
+	if got := OwnershipLabels(nil); got == nil || len(got) != 0 {
+		t.Errorf("OwnershipLabels(nil) = %v, want an empty map", got)
+	}
+	got := OwnershipLabels(&iafv1alpha1.OwnershipMetadata{Team: "payments", Owner: "jane.doe@example.com", CostCenter: "CC-1042"})
+	want := map[string]string{
+		LabelTeam:       "payments",
+		LabelOwner:      "jane.doe_at_example.com",
+		LabelCostCenter: "CC-1042",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("OwnershipLabels = %v, want %v", got, want)
+	}
+
+	app := makeTestApp("my-app", "iaf-abc123")
+	app.Spec.Metadata = &iafv1alpha1.OwnershipMetadata{Team: "payments", Purpose: "checkout"}
+	route := BuildIngressRoute(app, "example.com", true)
+	labels := route.GetLabels()
+	if labels[LabelTeam] != "payments" || labels[LabelPurpose] != "checkout" || labels["iaf.io/application"] != "my-app" {
+		t.Errorf("route labels = %v", labels)
+	}
+	if pod := PodLabels(app); pod["app.kubernetes.io/managed-by"] != "" || pod[LabelTeam] != "payments" {
+		t.Errorf("PodLabels = %v", pod)
+	}
+}
+
+func TestCopyOwnershipLabels(t *testing.T) {
+	existing := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+		"iaf.io/application": "my-app",
+		"other":              "kept",
+		LabelTeam:            "payments",
+		LabelPurpose:         "checkout",
+	}}}
+	desired := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+		"iaf.io/application": "my-app",
+		LabelTeam:            "billing",
+	}}}
+
+	if !CopyOwnershipLabels(existing, desired) {
+		t.Fatal("expected a change")
+	}
+	want := map[string]string{"iaf.io/application": "my-app", "other": "kept", LabelTeam: "billing"}
+	if !maps.Equal(existing.Labels, want) {
+		t.Errorf("labels = %v, want %v", existing.Labels, want)
+	}
+	if CopyOwnershipLabels(existing, desired) {
+		t.Error("expected no change the second time")
+	}
+}
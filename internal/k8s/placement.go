@@ -0,0 +1,70 @@
+package k8s
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Placement confines pods to a set of nodes.
+type Placement struct {
+	// NodeSelector lists node labels the pods require.
+	NodeSelector map[string]string
+	// Tolerations let the pods run on nodes with matching taints.
+	Tolerations []corev1.Toleration
+}
+
+// IsZero reports whether p places pods on any node.
+func (p Placement) IsZero() bool {
+	return len(p.NodeSelector) == 0 && len(p.Tolerations) == 0
+}
+
+// Merge returns p with the node selector and tolerations of o added. The
+// node selector of o wins where both set the same label.
+func (p Placement) Merge(o Placement) Placement {
+	var merged Placement
+	if len(p.NodeSelector)+len(o.NodeSelector) > 0 {
+		merged.NodeSelector = make(map[string]string, len(p.NodeSelector)+len(o.NodeSelector))
+		maps.Copy(merged.NodeSelector, p.NodeSelector)
+		maps.Copy(merged.NodeSelector, o.NodeSelector)
+	}
+	if len(p.Tolerations)+len(o.Tolerations) > 0 {
+		merged.Tolerations = append(append([]corev1.Toleration{}, p.Tolerations...), o.Tolerations...)
+	}
+	return merged
+}
+
+// ParseTolerations parses comma-separated tolerations in the syntax of
+// kubectl taint: key=value:effect tolerates a taint with that value,
+// key:effect any value, and key alone any value and effect.
+func ParseTolerations(s string) ([]corev1.Toleration, error) {
+	var tolerations []corev1.Toleration
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		spec, effect, _ := strings.Cut(item, ":")
+		key, value, hasValue := strings.Cut(spec, "=")
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("toleration %q: invalid key: %s", item, strings.Join(errs, "; "))
+		}
+		t := corev1.Toleration{Key: key, Operator: corev1.TolerationOpExists}
+		if hasValue {
+			if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+				return nil, fmt.Errorf("toleration %q: invalid value: %s", item, strings.Join(errs, "; "))
+			}
+			t.Operator, t.Value = corev1.TolerationOpEqual, value
+		}
+		switch e := corev1.TaintEffect(effect); e {
+		case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+			t.Effect = e
+		default:
+			return nil, fmt.Errorf("toleration %q: effect must be NoSchedule, PreferNoSchedule or NoExecute", item)
+		}
+		tolerations = append(tolerations, t)
+	}
+	return tolerations, nil
+}
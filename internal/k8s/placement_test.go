@@ -0,0 +1,56 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestParseTolerations(t *testing.T) {
+	got, err := ParseTolerations("dedicated=agents:NoSchedule, gpu:NoExecute,spot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []corev1.Toleration{
+		{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "agents", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "gpu", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+		{Key: "spot", Operator: corev1.TolerationOpExists},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if got, err := ParseTolerations(""); err != nil || got != nil {
+		t.Errorf("expected no tolerations, got %v, %v", got, err)
+	}
+	for _, bad := range []string{"dedicated:Never", "bad key", "key=bad value"} {
+		if _, err := ParseTolerations(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestPlacement_Merge(t *testing.T) {
+	base := Placement{
+		NodeSelector: map[string]string{"pool": "agents", "zone": "a"},
+		Tolerations:  []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+	}
+	class := Placement{
+		NodeSelector: map[string]string{"pool": "gpu"},
+		Tolerations:  []corev1.Toleration{{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists}},
+	}
+	merged := base.Merge(class)
+	if !reflect.DeepEqual(merged.NodeSelector, map[string]string{"pool": "gpu", "zone": "a"}) {
+		t.Errorf("unexpected node selector %v", merged.NodeSelector)
+	}
+	if len(merged.Tolerations) != 2 || merged.Tolerations[1].Key != "nvidia.com/gpu" {
+		t.Errorf("unexpected tolerations %v", merged.Tolerations)
+	}
+	if base.NodeSelector["pool"] != "agents" {
+		t.Error("expected Merge to leave its receiver unchanged")
+	}
+	if empty := (Placement{}).Merge(Placement{}); empty.NodeSelector != nil || empty.Tolerations != nil || !empty.IsZero() {
+		t.Errorf("expected an empty placement, got %+v", empty)
+	}
+}
@@ -51,3 +51,9 @@ func FindPodByName(pods []corev1.Pod, podName, labelKey, labelValue string) (*co
 	return nil, fmt.Errorf("pod %q not found for application %q", podName, labelValue)
 }
 
+// AppPodPattern returns a regular expression matching the names of the pods
+// of an application's Deployment, <app>-<replicaset hash>-<pod hash>.
+// Matching both hash segments keeps "web" from matching the pods of "web-api".
+func AppPodPattern(appName string) string {
+	return appName + "-[a-z0-9]+-[a-z0-9]+"
+}
@@ -0,0 +1,182 @@
+package k8s
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// PostgresVersions are the PostgreSQL releases managed services run: an
+// image repository and, for each major version offered, the image tag of
+// its latest minor release.
+type PostgresVersions struct {
+	Image string
+	// Tags maps a major version, such as "16", to an image tag, such as "16.4".
+	Tags map[string]string
+	// Default is the newest major version, used by services without spec.version.
+	Default string
+}
+
+// ParsePostgresVersions parses a comma-separated list of image tags, one per
+// major version, such as "17.2,16.6".
+func ParsePostgresVersions(image, tags string) (PostgresVersions, error) {
+	v := PostgresVersions{Image: strings.TrimSpace(image), Tags: map[string]string{}}
+	if v.Image == "" || strings.ContainsAny(v.Image, " \t@") {
+		return PostgresVersions{}, fmt.Errorf("invalid postgres image %q", image)
+	}
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag == "" {
+			continue
+		}
+		major := PostgresMajor(tag)
+		if major == "" || strings.ContainsAny(tag, " \t:@") {
+			return PostgresVersions{}, fmt.Errorf("invalid postgres version %q: want an image tag such as 16.4", tag)
+		}
+		if _, dup := v.Tags[major]; dup {
+			return PostgresVersions{}, fmt.Errorf("postgres major version %s listed twice", major)
+		}
+		v.Tags[major] = tag
+		if v.Default == "" || ComparePostgresVersions(major, v.Default) > 0 {
+			v.Default = major
+		}
+	}
+	if len(v.Tags) == 0 {
+		return PostgresVersions{}, fmt.Errorf("no postgres versions offered")
+	}
+	return v, nil
+}
+
+// Majors returns the major versions offered, newest first.
+func (v PostgresVersions) Majors() []string {
+	var majors []string
+	for major := range v.Tags {
+		majors = append(majors, major)
+	}
+	slices.SortFunc(majors, func(a, b string) int { return ComparePostgresVersions(b, a) })
+	return majors
+}
+
+// ImageFor returns the image of the given version tag.
+func (v PostgresVersions) ImageFor(tag string) string {
+	return v.Image + ":" + tag
+}
+
+// PostgresMajor returns the major version of a PostgreSQL version or image
+// tag, such as "16" for "16.4-bookworm", or "" when it has none.
+func PostgresMajor(version string) string {
+	end := 0
+	for end < len(version) && version[end] >= '0' && version[end] <= '9' {
+		end++
+	}
+	return version[:end]
+}
+
+// PostgresImageTag returns the tag of a PostgreSQL image reference, or ""
+// when it has none.
+func PostgresImageTag(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	slash := strings.LastIndex(image, "/")
+	colon := strings.LastIndex(image, ":")
+	if colon <= slash {
+		return ""
+	}
+	return image[colon+1:]
+}
+
+// ComparePostgresVersions compares the numeric releases at the start of two
+// versions or image tags, such as 16.10 and 16.4, returning -1, 0 or +1.
+func ComparePostgresVersions(a, b string) int {
+	pa, pb := versionNumbers(a), versionNumbers(b)
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionNumbers returns the dot-separated numbers a version starts with.
+func versionNumbers(version string) []int {
+	var nums []int
+	for _, part := range strings.Split(version, ".") {
+		major := PostgresMajor(part)
+		if major == "" {
+			break
+		}
+		n, _ := strconv.Atoi(major)
+		nums = append(nums, n)
+		if len(major) != len(part) {
+			break
+		}
+	}
+	return nums
+}
+
+// CNPGClusterImage returns the PostgreSQL image a CNPG Cluster runs: its
+// spec.imageName, or the image CloudNativePG reports in its status when the
+// spec leaves it to the operator's default. "" when neither is known.
+func CNPGClusterImage(obj *unstructured.Unstructured) string {
+	if image, _, _ := unstructured.NestedString(obj.Object, "spec", "imageName"); image != "" {
+		return image
+	}
+	image, _, _ := unstructured.NestedString(obj.Object, "status", "image")
+	return image
+}
+
+// MaintenanceWindowAt reports whether w is open at now and when it next
+// opens after now. A nil window is always open.
+func MaintenanceWindowAt(w *iafv1alpha1.MaintenanceWindow, now time.Time) (open bool, next time.Time, err error) {
+	if w == nil {
+		return true, now, nil
+	}
+	start, err := time.Parse("15:04", w.Start)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("invalid maintenance window start %q: want HH:MM", w.Start)
+	}
+	weekday := -1
+	if w.Day != "" {
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if d.String() == w.Day {
+				weekday = int(d)
+			}
+		}
+		if weekday < 0 {
+			return false, time.Time{}, fmt.Errorf("invalid maintenance window day %q", w.Day)
+		}
+	}
+	duration := w.Duration.Duration
+	if duration <= 0 {
+		duration = time.Hour
+	}
+
+	now = now.UTC()
+	// Walk the openings from the one a week before today, so a window still
+	// open from an earlier day is found.
+	day := time.Date(now.Year(), now.Month(), now.Day(), start.Hour(), start.Minute(), 0, 0, time.UTC)
+	for opens := day.AddDate(0, 0, -7); ; opens = opens.AddDate(0, 0, 1) {
+		if weekday >= 0 && int(opens.Weekday()) != weekday {
+			continue
+		}
+		if opens.After(now) {
+			return open, opens, nil
+		}
+		if now.Before(opens.Add(duration)) {
+			open = true
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestComparePostgresVersions(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"16.10", "16.4", 1},
+		{"16.4", "16.4-bookworm", 0},
+		{"15.8", "16.1", -1},
+		{"17", "17.0", 0},
+	} {
+		if got := ComparePostgresVersions(tc.a, tc.b); got != tc.want {
+			t.Errorf("ComparePostgresVersions(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestPostgresImageTag(t *testing.T) {
+	for image, want := range map[string]string{
+		"ghcr.io/cloudnative-pg/postgresql:16.4":            "16.4",
+		"registry.local:5000/postgresql:16.4-bookworm":      "16.4-bookworm",
+		"registry.local:5000/postgresql":                    "",
+		"ghcr.io/cloudnative-pg/postgresql:16.4@sha256:abc": "16.4",
+	} {
+		if got := PostgresImageTag(image); got != want {
+			t.Errorf("PostgresImageTag(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+func TestCNPGClusterImage(t *testing.T) {
+	svc := makeManagedService("mydb", "iaf-test", iafv1alpha1.ServicePlanMicro)
+	obj := BuildCNPGCluster(svc, builtinPlanSpec(t, svc.Spec.Plan), "")
+	if got := CNPGClusterImage(obj); got != "" {
+		t.Errorf("expected no image, got %q", got)
+	}
+	_ = unstructured.SetNestedField(obj.Object, "ghcr.io/cloudnative-pg/postgresql:16.2", "status", "image")
+	if got := CNPGClusterImage(obj); got != "ghcr.io/cloudnative-pg/postgresql:16.2" {
+		t.Errorf("expected the image reported in status, got %q", got)
+	}
+	obj = BuildCNPGCluster(svc, builtinPlanSpec(t, svc.Spec.Plan), "ghcr.io/cloudnative-pg/postgresql:16.4")
+	if got := CNPGClusterImage(obj); got != "ghcr.io/cloudnative-pg/postgresql:16.4" {
+		t.Errorf("expected spec.imageName, got %q", got)
+	}
+}
+
+func TestMaintenanceWindowAt(t *testing.T) {
+	// 2026-10-17 is a Saturday.
+	at := func(s string) time.Time {
+		t.Helper()
+		ts, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+	sunday := &iafv1alpha1.MaintenanceWindow{Day: "Sunday", Start: "23:00", Duration: metav1.Duration{Duration: 2 * time.Hour}}
+	daily := &iafv1alpha1.MaintenanceWindow{Start: "03:00"}
+
+	for _, tc := range []struct {
+		name     string
+		window   *iafv1alpha1.MaintenanceWindow
+		now      string
+		wantOpen bool
+		wantNext string
+	}{
+		{"no window", nil, "2026-10-17T12:00:00Z", true, "2026-10-17T12:00:00Z"},
+		{"before weekly", sunday, "2026-10-17T12:00:00Z", false, "2026-10-18T23:00:00Z"},
+		{"open weekly", sunday, "2026-10-18T23:30:00Z", true, "2026-10-25T23:00:00Z"},
+		{"open past midnight", sunday, "2026-10-19T00:30:00Z", true, "2026-10-25T23:00:00Z"},
+		{"closed weekly", sunday, "2026-10-19T01:00:00Z", false, "2026-10-25T23:00:00Z"},
+		{"daily default duration", daily, "2026-10-17T03:59:00Z", true, "2026-10-18T03:00:00Z"},
+		{"daily closed", daily, "2026-10-17T04:00:00Z", false, "2026-10-18T03:00:00Z"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			open, next, err := MaintenanceWindowAt(tc.window, at(tc.now))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if open != tc.wantOpen || !next.Equal(at(tc.wantNext)) {
+				t.Errorf("got open=%v next=%s, want open=%v next=%s", open, next.Format(time.RFC3339), tc.wantOpen, tc.wantNext)
+			}
+		})
+	}
+
+	if _, _, err := MaintenanceWindowAt(&iafv1alpha1.MaintenanceWindow{Start: "25:00"}, time.Now()); err == nil {
+		t.Error("expected an invalid start to be rejected")
+	}
+}
@@ -0,0 +1,39 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// LabelPreviewOf is set on preview Applications to the name of the app they were cloned from.
+	LabelPreviewOf = "iaf.io/preview-of"
+
+	// LabelPreviewPR is set on preview Applications to the pull request number they track.
+	LabelPreviewPR = "iaf.io/preview-pr"
+
+	// AnnotationPreviewRepo records the normalized repository (host/owner/repo) a
+	// preview was built from, so webhook events only delete previews of that repo.
+	AnnotationPreviewRepo = "iaf.io/preview-repo"
+)
+
+// PreviewName returns the Application name used for the preview of appName for pull request pr.
+func PreviewName(appName string, pr int) string {
+	return fmt.Sprintf("%s-pr-%d", appName, pr)
+}
+
+// NormalizeRepoURL reduces a git URL to "host/owner/repo" in lowercase so that
+// https, ssh (git@host:owner/repo) and .git-suffixed forms compare equal.
+func NormalizeRepoURL(raw string) string {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	for _, prefix := range []string{"https://", "http://", "ssh://", "git://"} {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	if at := strings.Index(s, "@"); at >= 0 {
+		s = s[at+1:]
+	}
+	s = strings.Replace(s, ":", "/", 1)
+	s = strings.TrimSuffix(s, "/")
+	s = strings.TrimSuffix(s, ".git")
+	return s
+}
@@ -0,0 +1,29 @@
+package k8s
+
+import "testing"
+
+func TestNormalizeRepoURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://github.com/Org/Repo", "github.com/org/repo"},
+		{"https://github.com/org/repo.git", "github.com/org/repo"},
+		{"https://github.com/org/repo/", "github.com/org/repo"},
+		{"git@github.com:org/repo.git", "github.com/org/repo"},
+		{"ssh://git@github.com/org/repo", "github.com/org/repo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := NormalizeRepoURL(tt.in); got != tt.want {
+				t.Errorf("NormalizeRepoURL(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreviewName(t *testing.T) {
+	if got := PreviewName("my-app", 42); got != "my-app-pr-42" {
+		t.Errorf("expected 'my-app-pr-42', got %q", got)
+	}
+}
@@ -0,0 +1,85 @@
+package k8s
+
+import (
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ProbeGVK is the GroupVersionKind for Prometheus Operator Probe CRs.
+var ProbeGVK = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "Probe",
+}
+
+// UptimeJobName is the Prometheus job label of every uptime check.
+const UptimeJobName = "iaf-uptime"
+
+// Uptime check defaults, matching the Application CRD.
+const (
+	defaultUptimePath     = "/"
+	defaultUptimeInterval = 60
+)
+
+// HasUptimeCheck reports whether the application should be probed.
+func HasUptimeCheck(app *iafv1alpha1.Application) bool {
+	return app.Spec.UptimeCheck != nil && iafv1alpha1.AppProtocol(app) != iafv1alpha1.ProtocolTCP
+}
+
+// UptimeCheckPath returns the path probed on the application URL.
+func UptimeCheckPath(app *iafv1alpha1.Application) string {
+	if app.Spec.UptimeCheck == nil || app.Spec.UptimeCheck.Path == "" {
+		return defaultUptimePath
+	}
+	return app.Spec.UptimeCheck.Path
+}
+
+// UptimeCheckInterval returns the probe interval in seconds.
+func UptimeCheckInterval(app *iafv1alpha1.Application) int32 {
+	if app.Spec.UptimeCheck == nil || app.Spec.UptimeCheck.IntervalSeconds == 0 {
+		return defaultUptimeInterval
+	}
+	return app.Spec.UptimeCheck.IntervalSeconds
+}
+
+// UptimeSelector returns the PromQL label matchers selecting the
+// probe_success series of the application name in namespace.
+func UptimeSelector(namespace, name string) string {
+	return fmt.Sprintf(`job=%q, namespace=%q, application=%q`, UptimeJobName, namespace, name)
+}
+
+// BuildProbe constructs a Probe that has the blackbox exporter at prober
+// (host:port) request url with module at the app's uptime check interval.
+// probeLabels are added so the platform Prometheus selects the Probe.
+func BuildProbe(app *iafv1alpha1.Application, url, prober, module string, probeLabels map[string]string) *unstructured.Unstructured {
+	obj := newRouteObject(app, ProbeGVK)
+	labels := obj.GetLabels()
+	for k, v := range probeLabels {
+		if _, ok := labels[k]; !ok {
+			labels[k] = v
+		}
+	}
+	obj.SetLabels(labels)
+	obj.Object["spec"] = map[string]any{
+		"jobName":  UptimeJobName,
+		"interval": fmt.Sprintf("%ds", UptimeCheckInterval(app)),
+		"module":   module,
+		"prober": map[string]any{
+			"url": prober,
+		},
+		"targets": map[string]any{
+			"staticConfig": map[string]any{
+				"static": []any{url},
+				// Series are selected by these labels (see UptimeSelector).
+				"labels": map[string]any{
+					"namespace":   app.Namespace,
+					"application": app.Name,
+				},
+			},
+		},
+	}
+	return obj
+}
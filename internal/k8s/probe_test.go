@@ -0,0 +1,55 @@
+package k8s
+
+import (
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestBuildProbe(t *testing.T) {
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "iaf-abc", UID: "uid-1"},
+		Spec: iafv1alpha1.ApplicationSpec{
+			UptimeCheck: &iafv1alpha1.UptimeCheckConfig{Path: "/healthz", IntervalSeconds: 30},
+		},
+	}
+	obj := BuildProbe(app, "https://web.example.com/healthz", "blackbox.monitoring:9115", "http_2xx",
+		map[string]string{"release": "kube-prometheus-stack", "iaf.io/application": "other"})
+
+	if obj.GetName() != "web" || obj.GetNamespace() != "iaf-abc" {
+		t.Errorf("unexpected name %s/%s", obj.GetNamespace(), obj.GetName())
+	}
+	labels := obj.GetLabels()
+	if labels["release"] != "kube-prometheus-stack" || labels["iaf.io/application"] != "web" {
+		t.Errorf("expected probe labels without overriding platform labels, got %v", labels)
+	}
+	if refs := obj.GetOwnerReferences(); len(refs) != 1 || refs[0].UID != "uid-1" {
+		t.Errorf("expected the probe to be owned by the app, got %v", refs)
+	}
+	interval, _, _ := unstructured.NestedString(obj.Object, "spec", "interval")
+	prober, _, _ := unstructured.NestedString(obj.Object, "spec", "prober", "url")
+	targets, _, _ := unstructured.NestedSlice(obj.Object, "spec", "targets", "staticConfig", "static")
+	if interval != "30s" || prober != "blackbox.monitoring:9115" || len(targets) != 1 || targets[0] != "https://web.example.com/healthz" {
+		t.Errorf("unexpected spec %v", obj.Object["spec"])
+	}
+	app2, _, _ := unstructured.NestedString(obj.Object, "spec", "targets", "staticConfig", "labels", "application")
+	if app2 != "web" {
+		t.Errorf("expected the application target label, got %q", app2)
+	}
+}
+
+func TestUptimeCheckDefaults(t *testing.T) {
+	app := &iafv1alpha1.Application{Spec: iafv1alpha1.ApplicationSpec{UptimeCheck: &iafv1alpha1.UptimeCheckConfig{}}}
+	if UptimeCheckPath(app) != "/" || UptimeCheckInterval(app) != 60 {
+		t.Errorf("unexpected defaults %q %d", UptimeCheckPath(app), UptimeCheckInterval(app))
+	}
+	if !HasUptimeCheck(app) {
+		t.Error("expected an uptime check")
+	}
+	app.Spec.Protocol = iafv1alpha1.ProtocolTCP
+	if HasUptimeCheck(app) {
+		t.Error("expected no uptime check for tcp")
+	}
+}
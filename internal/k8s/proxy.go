@@ -0,0 +1,59 @@
+package k8s
+
+import (
+	"slices"
+	"strings"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultNoProxy lists the hosts always reached without the proxy: the pod
+// itself and cluster-internal service names.
+var DefaultNoProxy = []string{"localhost", "127.0.0.1", ".svc", ".cluster.local"}
+
+// Proxy is the outbound HTTP proxy that builds and app pods use.
+type Proxy struct {
+	// HTTPProxy and HTTPSProxy are the proxy URLs for http and https
+	// requests. An empty URL sends those requests directly.
+	HTTPProxy  string
+	HTTPSProxy string
+	// NoProxy lists the hosts, domains and CIDRs reached directly, in
+	// addition to DefaultNoProxy.
+	NoProxy []string
+}
+
+// IsZero reports whether p sends every request directly.
+func (p Proxy) IsZero() bool {
+	return p.HTTPProxy == "" && p.HTTPSProxy == ""
+}
+
+// ProxyEnv returns the proxy settings for app as environment variables,
+// each in upper and lower case since tools disagree on which they read.
+// Variables app already sets in own, in either case, are left out so the
+// app's value wins. Returns nil when p is zero or the app opted out.
+func ProxyEnv(app *iafv1alpha1.Application, p Proxy, own []iafv1alpha1.EnvVar) []corev1.EnvVar {
+	if p.IsZero() || !iafv1alpha1.IsProxyEnabled(app) {
+		return nil
+	}
+	noProxy := slices.Clone(DefaultNoProxy)
+	for _, h := range p.NoProxy {
+		if !slices.Contains(noProxy, h) {
+			noProxy = append(noProxy, h)
+		}
+	}
+	var env []corev1.EnvVar
+	for _, v := range []struct{ name, value string }{
+		{"HTTP_PROXY", p.HTTPProxy},
+		{"HTTPS_PROXY", p.HTTPSProxy},
+		{"NO_PROXY", strings.Join(noProxy, ",")},
+	} {
+		if v.value == "" || slices.ContainsFunc(own, func(e iafv1alpha1.EnvVar) bool { return strings.EqualFold(e.Name, v.name) }) {
+			continue
+		}
+		env = append(env,
+			corev1.EnvVar{Name: v.name, Value: v.value},
+			corev1.EnvVar{Name: strings.ToLower(v.name), Value: v.value})
+	}
+	return env
+}
@@ -0,0 +1,41 @@
+package k8s
+
+import (
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+)
+
+func TestProxyEnv(t *testing.T) {
+	proxy := Proxy{HTTPProxy: "http://proxy.corp:3128", HTTPSProxy: "http://proxy.corp:3128", NoProxy: []string{"10.0.0.0/8", ".svc"}}
+	app := &iafv1alpha1.Application{}
+
+	env := ProxyEnv(app, proxy, nil)
+	got := map[string]string{}
+	for _, e := range env {
+		got[e.Name] = e.Value
+	}
+	if len(env) != 6 || got["http_proxy"] != proxy.HTTPProxy || got["HTTPS_PROXY"] != proxy.HTTPSProxy {
+		t.Errorf("unexpected proxy env %v", env)
+	}
+	if want := "localhost,127.0.0.1,.svc,.cluster.local,10.0.0.0/8"; got["NO_PROXY"] != want || got["no_proxy"] != want {
+		t.Errorf("NO_PROXY = %q, want %q", got["NO_PROXY"], want)
+	}
+
+	// The app's own value wins, in either case.
+	env = ProxyEnv(app, proxy, []iafv1alpha1.EnvVar{{Name: "no_proxy", Value: "*"}})
+	for _, e := range env {
+		if e.Name == "NO_PROXY" || e.Name == "no_proxy" {
+			t.Errorf("expected the app's no_proxy to win, got %v", env)
+		}
+	}
+
+	if env := ProxyEnv(app, Proxy{}, nil); env != nil {
+		t.Errorf("expected no env without a proxy, got %v", env)
+	}
+	disabled := false
+	app.Spec.Proxy = &iafv1alpha1.ProxyConfig{Enabled: &disabled}
+	if env := ProxyEnv(app, proxy, nil); env != nil {
+		t.Errorf("expected no env for an app that opted out, got %v", env)
+	}
+}
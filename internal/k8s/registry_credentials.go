@@ -0,0 +1,53 @@
+package k8s
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// CredentialTypeRegistry is the LabelCredentialType value of registry credentials.
+	CredentialTypeRegistry = "registry"
+
+	// AnnotationRegistryServer records the registry server on the Secret for informational purposes.
+	AnnotationRegistryServer = "iaf.io/registry-server"
+
+	// AnnotationKpackDocker is the annotation kpack uses to match a credential to a registry.
+	AnnotationKpackDocker = "kpack.io/docker"
+)
+
+// BuildRegistryCredentialSecret constructs a dockerconfigjson Secret for a
+// container registry. kpack uses it to pull base images and push builds once
+// it is on the kpack service account; Deployments use it as an image pull
+// secret. Like git credentials, the material is never read back by any IAF tool.
+func BuildRegistryCredentialSecret(namespace, name, server, username, password string) *corev1.Secret {
+	config, _ := json.Marshal(map[string]any{
+		"auths": map[string]any{
+			server: map[string]string{
+				"username": username,
+				"password": password,
+				"auth":     base64.StdEncoding.EncodeToString([]byte(username + ":" + password)),
+			},
+		},
+	})
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				LabelCredentialType: CredentialTypeRegistry,
+			},
+			Annotations: map[string]string{
+				AnnotationKpackDocker:    server,
+				AnnotationRegistryServer: server,
+			},
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		StringData: map[string]string{
+			corev1.DockerConfigJsonKey: string(config),
+		},
+	}
+}
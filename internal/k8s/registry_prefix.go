@@ -0,0 +1,48 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dlapiduz/iaf/internal/validation"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AnnotationRegistryPrefix on a session namespace overrides the platform
+// registry prefix for images built in that namespace, so teams can push to
+// their own registry paths. Only operators can set it: no IAF tool or API
+// modifies namespaces.
+const AnnotationRegistryPrefix = "iaf.io/registry-prefix"
+
+// ErrInvalidRegistryPrefix marks a namespace whose AnnotationRegistryPrefix
+// is not a valid registry prefix.
+var ErrInvalidRegistryPrefix = errors.New("invalid " + AnnotationRegistryPrefix + " annotation")
+
+// ResolveRegistryPrefix returns the registry prefix for builds in namespace:
+// the namespace's AnnotationRegistryPrefix when set, otherwise fallback. An
+// invalid annotation is an error rather than a silent fallback, so images are
+// never pushed outside the registry an operator chose for the namespace.
+func ResolveRegistryPrefix(ctx context.Context, c client.Reader, namespace, fallback string) (string, error) {
+	var ns corev1.Namespace
+	if err := c.Get(ctx, client.ObjectKey{Name: namespace}, &ns); err != nil {
+		return "", fmt.Errorf("getting namespace %q: %w", namespace, err)
+	}
+	prefix, ok := ns.Annotations[AnnotationRegistryPrefix]
+	if !ok {
+		return fallback, nil
+	}
+	if err := validation.ValidateRegistryPrefix(prefix); err != nil {
+		return "", fmt.Errorf("%w on namespace %q: %w", ErrInvalidRegistryPrefix, namespace, err)
+	}
+	return prefix, nil
+}
+
+// ImageUnderPrefix reports whether image references a repository under
+// prefix, e.g. "ghcr.io/team/web@sha256:..." is under "ghcr.io/team" but
+// "ghcr.io/teammate/web" is not.
+func ImageUnderPrefix(image, prefix string) bool {
+	return strings.HasPrefix(image, prefix+"/")
+}
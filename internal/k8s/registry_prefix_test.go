@@ -0,0 +1,70 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestResolveRegistryPrefix(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	namespace := func(name string, annotations map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: annotations}}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		namespace("iaf-plain", nil),
+		namespace("iaf-payments", map[string]string{AnnotationRegistryPrefix: "ghcr.io/payments"}),
+		namespace("iaf-bad", map[string]string{AnnotationRegistryPrefix: "https://ghcr.io/payments"}),
+	).Build()
+
+	tests := []struct {
+		namespace string
+		want      string
+		wantErr   bool
+		invalid   bool
+	}{
+		{namespace: "iaf-plain", want: "registry.example.com/iaf"},
+		{namespace: "iaf-payments", want: "ghcr.io/payments"},
+		{namespace: "iaf-bad", wantErr: true, invalid: true},
+		{namespace: "iaf-missing", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := ResolveRegistryPrefix(context.Background(), c, tc.namespace, "registry.example.com/iaf")
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ResolveRegistryPrefix(%q) error = %v, wantErr %v", tc.namespace, err, tc.wantErr)
+			continue
+		}
+		if errors.Is(err, ErrInvalidRegistryPrefix) != tc.invalid {
+			t.Errorf("ResolveRegistryPrefix(%q) error = %v, want ErrInvalidRegistryPrefix: %v", tc.namespace, err, tc.invalid)
+		}
+		if got != tc.want {
+			t.Errorf("ResolveRegistryPrefix(%q) = %q, want %q", tc.namespace, got, tc.want)
+		}
+	}
+}
+
+func TestImageUnderPrefix(t *testing.T) {
+	tests := []struct {
+		image, prefix string
+		want          bool
+	}{
+		{"ghcr.io/team/web@sha256:abc", "ghcr.io/team", true},
+		{"ghcr.io/team/nested/web:1", "ghcr.io/team", true},
+		{"ghcr.io/teammate/web@sha256:abc", "ghcr.io/team", false},
+		{"docker.io/team/web@sha256:abc", "ghcr.io/team", false},
+		{"ghcr.io/team", "ghcr.io/team", false},
+	}
+	for _, tc := range tests {
+		if got := ImageUnderPrefix(tc.image, tc.prefix); got != tc.want {
+			t.Errorf("ImageUnderPrefix(%q, %q) = %v, want %v", tc.image, tc.prefix, got, tc.want)
+		}
+	}
+}
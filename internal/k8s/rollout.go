@@ -0,0 +1,65 @@
+package k8s
+
+import (
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Deployment condition reasons set by the Deployment controller.
+const (
+	reasonProgressDeadlineExceeded = "ProgressDeadlineExceeded"
+)
+
+// Rollout summarizes the rollout of dep the way kubectl rollout status
+// does, and reports it stalled when the Deployment's progress deadline has
+// passed or its ReplicaSet cannot create pods.
+func Rollout(dep *appsv1.Deployment) *iafv1alpha1.RolloutStatus {
+	desired := int32(1)
+	if dep.Spec.Replicas != nil {
+		desired = *dep.Spec.Replicas
+	}
+	st := dep.Status
+	rollout := &iafv1alpha1.RolloutStatus{
+		State:             iafv1alpha1.RolloutProgressing,
+		DesiredReplicas:   desired,
+		UpdatedReplicas:   st.UpdatedReplicas,
+		ReadyReplicas:     st.ReadyReplicas,
+		AvailableReplicas: st.AvailableReplicas,
+	}
+
+	for _, c := range st.Conditions {
+		switch {
+		case c.Type == appsv1.DeploymentReplicaFailure && c.Status == corev1.ConditionTrue:
+			rollout.State, rollout.Reason, rollout.Message = iafv1alpha1.RolloutStalled, c.Reason, c.Message
+			if rollout.Reason == "" {
+				rollout.Reason = string(appsv1.DeploymentReplicaFailure)
+			}
+			return rollout
+		case c.Type == appsv1.DeploymentProgressing && c.Reason == reasonProgressDeadlineExceeded:
+			rollout.State, rollout.Reason = iafv1alpha1.RolloutStalled, c.Reason
+			rollout.Message = fmt.Sprintf("rollout made no progress before its deadline: %d of %d updated replicas available", st.AvailableReplicas, desired)
+			if c.Message != "" {
+				rollout.Message += " (" + c.Message + ")"
+			}
+			return rollout
+		}
+	}
+
+	switch {
+	case dep.Generation > st.ObservedGeneration:
+		rollout.Message = "waiting for the Deployment change to be observed"
+	case st.UpdatedReplicas < desired:
+		rollout.Message = fmt.Sprintf("%d of %d replicas updated", st.UpdatedReplicas, desired)
+	case st.Replicas > st.UpdatedReplicas:
+		rollout.Message = fmt.Sprintf("%d old replicas pending termination", st.Replicas-st.UpdatedReplicas)
+	case st.AvailableReplicas < st.UpdatedReplicas:
+		rollout.Message = fmt.Sprintf("%d of %d updated replicas available", st.AvailableReplicas, st.UpdatedReplicas)
+	default:
+		rollout.State = iafv1alpha1.RolloutComplete
+		rollout.Message = fmt.Sprintf("%d of %d replicas updated and available", st.AvailableReplicas, desired)
+	}
+	return rollout
+}
@@ -0,0 +1,76 @@
+package k8s
+
+import (
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestRollout(t *testing.T) {
+	three := int32(3)
+	tests := []struct {
+		name       string
+		generation int64
+		status     appsv1.DeploymentStatus
+		want       iafv1alpha1.RolloutState
+		wantReason string
+	}{
+		{
+			name:   "complete",
+			status: appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, ReadyReplicas: 3, AvailableReplicas: 3},
+			want:   iafv1alpha1.RolloutComplete,
+		},
+		{
+			name:       "spec change not observed",
+			generation: 2,
+			status:     appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3},
+			want:       iafv1alpha1.RolloutProgressing,
+		},
+		{
+			name:   "updating replicas",
+			status: appsv1.DeploymentStatus{Replicas: 4, UpdatedReplicas: 1, AvailableReplicas: 3},
+			want:   iafv1alpha1.RolloutProgressing,
+		},
+		{
+			name:   "old replicas terminating",
+			status: appsv1.DeploymentStatus{Replicas: 4, UpdatedReplicas: 3, AvailableReplicas: 3},
+			want:   iafv1alpha1.RolloutProgressing,
+		},
+		{
+			name:   "updated replicas not available",
+			status: appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 2},
+			want:   iafv1alpha1.RolloutProgressing,
+		},
+		{
+			name: "progress deadline exceeded",
+			status: appsv1.DeploymentStatus{Replicas: 4, UpdatedReplicas: 1, AvailableReplicas: 3, Conditions: []appsv1.DeploymentCondition{
+				{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded"},
+			}},
+			want:       iafv1alpha1.RolloutStalled,
+			wantReason: "ProgressDeadlineExceeded",
+		},
+		{
+			name: "replica failure",
+			status: appsv1.DeploymentStatus{Conditions: []appsv1.DeploymentCondition{
+				{Type: appsv1.DeploymentReplicaFailure, Status: corev1.ConditionTrue, Reason: "FailedCreate", Message: "exceeded quota"},
+			}},
+			want:       iafv1alpha1.RolloutStalled,
+			wantReason: "FailedCreate",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dep := &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: &three}, Status: tt.status}
+			dep.Generation = tt.generation
+			got := Rollout(dep)
+			if got.State != tt.want || got.Reason != tt.wantReason {
+				t.Errorf("Rollout() = %s %q (%s), want %s %q", got.State, got.Reason, got.Message, tt.want, tt.wantReason)
+			}
+			if got.DesiredReplicas != 3 || got.UpdatedReplicas != tt.status.UpdatedReplicas || got.AvailableReplicas != tt.status.AvailableReplicas {
+				t.Errorf("unexpected replica counts %+v", got)
+			}
+		})
+	}
+}
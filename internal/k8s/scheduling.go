@@ -0,0 +1,231 @@
+package k8s
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Reasons a pod cannot be scheduled, as reported in SchedulingStatus.
+const (
+	ScheduleInsufficientCPU    = "insufficient cpu"
+	ScheduleInsufficientMemory = "insufficient memory"
+	ScheduleNoMatchingNodes    = "no nodes match selector"
+	ScheduleUntoleratedTaint   = "nodes have untolerated taints"
+	ScheduleTooManyPods        = "nodes are full"
+	ScheduleNodesCordoned      = "nodes are cordoned"
+	ScheduleUnboundVolumes     = "volumes are not bound"
+	ScheduleNoNodes            = "no nodes available"
+)
+
+// Event reasons of the cluster autoscaler and Karpenter on pods they are,
+// or are not, adding a node for.
+const (
+	eventTriggeredScaleUp   = "TriggeredScaleUp"
+	eventNotTriggerScaleUp  = "NotTriggerScaleUp"
+	eventKarpenterNominated = "Nominated"
+	eventFailedScheduling   = "FailedScheduling"
+)
+
+// maxSchedulingMessage bounds the scheduler message kept in the status; it
+// lists every node group and can be long on large clusters.
+const maxSchedulingMessage = 512
+
+// schedulingCount strips the node count the scheduler puts before each
+// reason, e.g. "2 Insufficient memory".
+var schedulingCount = regexp.MustCompile(`^\d+ `)
+
+// Scheduling summarizes the pods that no node can take, reading the
+// PodScheduled condition of pods and, for the message and autoscaler
+// activity, the events on them. It returns nil when every pod that is not
+// being deleted has been scheduled.
+func Scheduling(pods []corev1.Pod, events []corev1.Event) *iafv1alpha1.SchedulingStatus {
+	var status *iafv1alpha1.SchedulingStatus
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil || pod.Spec.NodeName != "" {
+			continue
+		}
+		cond := podScheduledCondition(&pod)
+		if cond == nil || cond.Status != corev1.ConditionFalse || cond.Reason != corev1.PodReasonUnschedulable {
+			continue
+		}
+		if status == nil {
+			status = &iafv1alpha1.SchedulingStatus{}
+		}
+		status.UnschedulablePods++
+
+		message := cond.Message
+		var autoscaler string
+		var autoscalerAt time.Time
+		for _, e := range events {
+			if e.InvolvedObject.Kind != "Pod" || e.InvolvedObject.Name != pod.Name {
+				continue
+			}
+			switch e.Reason {
+			case eventFailedScheduling:
+				if message == "" {
+					message = e.Message
+				}
+			case eventTriggeredScaleUp, eventKarpenterNominated, eventNotTriggerScaleUp:
+				if t := eventTime(e); autoscaler == "" || t.After(autoscalerAt) {
+					autoscaler, autoscalerAt = e.Reason, t
+				}
+			}
+		}
+		if autoscaler == eventTriggeredScaleUp || autoscaler == eventKarpenterNominated {
+			status.ScalingUp = true
+		}
+		for _, reason := range SchedulingReasons(message) {
+			if !slices.Contains(status.Reasons, reason) {
+				status.Reasons = append(status.Reasons, reason)
+			}
+		}
+		if status.Message == "" && message != "" {
+			status.Message = truncateMessage(message, maxSchedulingMessage)
+		}
+	}
+	return status
+}
+
+// SchedulingReasons turns a scheduler message such as "0/3 nodes are
+// available: 1 Insufficient memory, 2 node(s) didn't match Pod's node
+// affinity/selector. preemption: ..." into short reasons, e.g.
+// ["insufficient memory", "no nodes match selector"]. Parts it does not
+// recognize are kept as written.
+func SchedulingReasons(message string) []string {
+	message, _, _ = strings.Cut(message, ". preemption:")
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return nil
+	}
+	if _, rest, ok := strings.Cut(message, "nodes are available: "); ok {
+		message = rest
+	}
+	message = strings.TrimSuffix(message, ".")
+
+	var reasons []string
+	for _, part := range strings.Split(message, ", ") {
+		part = schedulingCount.ReplaceAllString(strings.TrimSpace(part), "")
+		if part == "" {
+			continue
+		}
+		reason := schedulingReason(part)
+		if !slices.Contains(reasons, reason) {
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons
+}
+
+// schedulingReason maps one part of a scheduler message to a short reason.
+func schedulingReason(part string) string {
+	lower := strings.ToLower(part)
+	switch {
+	case lower == "insufficient cpu":
+		return ScheduleInsufficientCPU
+	case lower == "insufficient memory":
+		return ScheduleInsufficientMemory
+	case strings.HasPrefix(lower, "insufficient "):
+		return lower
+	case strings.Contains(lower, "node affinity/selector"), strings.Contains(lower, "didn't match node selector"):
+		return ScheduleNoMatchingNodes
+	case strings.Contains(lower, "untolerated taint"):
+		return ScheduleUntoleratedTaint
+	case strings.Contains(lower, "too many pods"):
+		return ScheduleTooManyPods
+	case strings.Contains(lower, "were unschedulable"):
+		return ScheduleNodesCordoned
+	case strings.Contains(lower, "unbound immediate persistentvolumeclaims"):
+		return ScheduleUnboundVolumes
+	case strings.Contains(lower, "no nodes available"):
+		return ScheduleNoNodes
+	}
+	return part
+}
+
+// SchedulingMessage describes s in one line for the Application's
+// Scheduled condition.
+func SchedulingMessage(s *iafv1alpha1.SchedulingStatus) string {
+	msg := fmt.Sprintf("%d pod(s) cannot be scheduled", s.UnschedulablePods)
+	if len(s.Reasons) > 0 {
+		msg += ": " + strings.Join(s.Reasons, ", ")
+	}
+	if s.ScalingUp {
+		msg += "; the cluster autoscaler is adding a node for them"
+	}
+	return msg
+}
+
+// SchedulingHint suggests how to get the unschedulable pods of app placed.
+func SchedulingHint(app *iafv1alpha1.Application, s *iafv1alpha1.SchedulingStatus) string {
+	var hints []string
+	if s.ScalingUp {
+		hints = append(hints, "A node is being added for the pods; wait a few minutes before changing the app.")
+	}
+	add := func(hint string) {
+		if !slices.Contains(hints, hint) {
+			hints = append(hints, hint)
+		}
+	}
+	for _, reason := range s.Reasons {
+		switch {
+		case strings.HasPrefix(reason, "insufficient "):
+			add(fmt.Sprintf("No node has room for another pod: lower spec.replicas (now %d) or the resource requests set in spec.overrides.deployment, or delete apps you no longer need.", app.Spec.Replicas))
+		case reason == ScheduleTooManyPods:
+			add(fmt.Sprintf("The nodes run as many pods as they can: lower spec.replicas (now %d) or delete apps you no longer need.", app.Spec.Replicas))
+		case reason == ScheduleNoMatchingNodes, reason == ScheduleUntoleratedTaint:
+			add(placementHint(app))
+		}
+	}
+	if len(hints) == 0 {
+		add("Ask the platform operator whether the cluster has capacity for the app, or lower spec.replicas.")
+	}
+	return strings.Join(hints, " ")
+}
+
+// placementHint suggests placement changes for pods no node selects.
+func placementHint(app *iafv1alpha1.Application) string {
+	var placement []string
+	if app.Spec.WorkloadClass != "" {
+		placement = append(placement, fmt.Sprintf("workloadClass %q", app.Spec.WorkloadClass))
+	}
+	if app.Spec.Architecture != "" {
+		placement = append(placement, fmt.Sprintf("architecture %q", app.Spec.Architecture))
+	}
+	if len(placement) == 0 {
+		return "No node matches the platform's placement for the app: ask the platform operator to add nodes, or check spec.overrides.deployment for node selectors and tolerations."
+	}
+	return fmt.Sprintf("No node matches the app's %s: pick another value, or ask the platform operator to add nodes for it.", strings.Join(placement, " and "))
+}
+
+func podScheduledCondition(pod *corev1.Pod) *corev1.PodCondition {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == corev1.PodScheduled {
+			return &pod.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// eventTime returns when an event last occurred.
+func eventTime(e corev1.Event) time.Time {
+	if !e.LastTimestamp.IsZero() {
+		return e.LastTimestamp.Time
+	}
+	if !e.EventTime.IsZero() {
+		return e.EventTime.Time
+	}
+	return e.CreationTimestamp.Time
+}
+
+func truncateMessage(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
@@ -0,0 +1,120 @@
+package k8s
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSchedulingReasons(t *testing.T) {
+	tests := []struct {
+		message string
+		want    []string
+	}{
+		{
+			message: "0/3 nodes are available: 1 Insufficient memory, 2 node(s) didn't match Pod's node affinity/selector. preemption: 0/3 nodes are available: 3 Preemption is not helpful for scheduling.",
+			want:    []string{ScheduleInsufficientMemory, ScheduleNoMatchingNodes},
+		},
+		{
+			message: "0/2 nodes are available: 1 Insufficient cpu, 1 Insufficient memory.",
+			want:    []string{ScheduleInsufficientCPU, ScheduleInsufficientMemory},
+		},
+		{
+			message: "0/1 nodes are available: 1 Insufficient nvidia.com/gpu.",
+			want:    []string{"insufficient nvidia.com/gpu"},
+		},
+		{
+			message: "0/4 nodes are available: 1 node(s) had untolerated taint {dedicated: gpu}, 3 Too many pods.",
+			want:    []string{ScheduleUntoleratedTaint, ScheduleTooManyPods},
+		},
+		{
+			message: "no nodes available to schedule pods",
+			want:    []string{ScheduleNoNodes},
+		},
+		{
+			message: "0/2 nodes are available: 2 something new.",
+			want:    []string{"something new"},
+		},
+		{message: "", want: nil},
+	}
+	for _, tt := range tests {
+		if got := SchedulingReasons(tt.message); !slices.Equal(got, tt.want) {
+			t.Errorf("SchedulingReasons(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestScheduling(t *testing.T) {
+	pending := func(name, message string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.PodStatus{Phase: corev1.PodPending, Conditions: []corev1.PodCondition{{
+				Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable, Message: message,
+			}}},
+		}
+	}
+	event := func(pod, reason string, at time.Time) corev1.Event {
+		return corev1.Event{
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: pod},
+			Reason:         reason,
+			Message:        "0/2 nodes are available: 2 Insufficient cpu.",
+			LastTimestamp:  metav1.NewTime(at),
+		}
+	}
+	running := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1"}, Spec: corev1.PodSpec{NodeName: "node-a"}}
+
+	if got := Scheduling([]corev1.Pod{running}, nil); got != nil {
+		t.Errorf("expected nil for scheduled pods, got %+v", got)
+	}
+
+	now := time.Now()
+	pods := []corev1.Pod{running, pending("web-2", "0/2 nodes are available: 2 Insufficient memory."), pending("web-3", "")}
+	got := Scheduling(pods, []corev1.Event{
+		event("web-3", eventFailedScheduling, now),
+		event("web-2", eventNotTriggerScaleUp, now.Add(-time.Minute)),
+		event("web-2", eventTriggeredScaleUp, now),
+	})
+	if got == nil || got.UnschedulablePods != 2 {
+		t.Fatalf("expected 2 unschedulable pods, got %+v", got)
+	}
+	if !slices.Equal(got.Reasons, []string{ScheduleInsufficientMemory, ScheduleInsufficientCPU}) {
+		t.Errorf("expected reasons from the pod condition and the event, got %q", got.Reasons)
+	}
+	if !got.ScalingUp {
+		t.Error("expected the latest TriggeredScaleUp event to report a scale-up")
+	}
+	if !strings.Contains(got.Message, "Insufficient memory") {
+		t.Errorf("expected the scheduler message, got %q", got.Message)
+	}
+
+	got = Scheduling(pods[1:2], []corev1.Event{
+		event("web-2", eventKarpenterNominated, now.Add(-time.Minute)),
+		event("web-2", eventNotTriggerScaleUp, now),
+	})
+	if got.ScalingUp {
+		t.Error("expected a later NotTriggerScaleUp event to override the scale-up")
+	}
+}
+
+func TestSchedulingHint(t *testing.T) {
+	app := &iafv1alpha1.Application{Spec: iafv1alpha1.ApplicationSpec{Replicas: 4, WorkloadClass: iafv1alpha1.WorkloadClassGPU}}
+
+	hint := SchedulingHint(app, &iafv1alpha1.SchedulingStatus{Reasons: []string{ScheduleInsufficientMemory, ScheduleInsufficientCPU}})
+	if !strings.Contains(hint, "spec.replicas (now 4)") || strings.Count(hint, "No node has room") != 1 {
+		t.Errorf("expected one replicas hint, got %q", hint)
+	}
+
+	hint = SchedulingHint(app, &iafv1alpha1.SchedulingStatus{Reasons: []string{ScheduleNoMatchingNodes}, ScalingUp: true})
+	if !strings.Contains(hint, `workloadClass "gpu"`) || !strings.HasPrefix(hint, "A node is being added") {
+		t.Errorf("expected a scale-up note and a placement hint, got %q", hint)
+	}
+
+	if hint := SchedulingHint(app, &iafv1alpha1.SchedulingStatus{}); hint == "" {
+		t.Error("expected a fallback hint")
+	}
+}
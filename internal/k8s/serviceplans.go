@@ -0,0 +1,108 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// builtinServicePlans are offered when no ServicePlan of the same name
+// replaces them.
+var builtinServicePlans = []iafv1alpha1.ServicePlan{
+	builtinServicePlan(iafv1alpha1.ServicePlanMicro, "Development and ephemeral databases", 1, "250m", "256Mi", "1Gi"),
+	builtinServicePlan(iafv1alpha1.ServicePlanSmall, "Light production workloads", 1, "500m", "512Mi", "5Gi"),
+	builtinServicePlan(iafv1alpha1.ServicePlanHA, "High-availability production, with read replicas", 3, "1", "1Gi", "10Gi"),
+}
+
+func builtinServicePlan(name iafv1alpha1.ServicePlanName, description string, instances int32, cpu, memory, storage string) iafv1alpha1.ServicePlan {
+	return iafv1alpha1.ServicePlan{
+		ObjectMeta: metav1.ObjectMeta{Name: string(name)},
+		Spec: iafv1alpha1.ServicePlanSpec{
+			Type:        "postgres",
+			Description: description,
+			Instances:   instances,
+			Storage:     resource.MustParse(storage),
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				},
+			},
+		},
+	}
+}
+
+// BuiltinServicePlan returns the built-in plan named name, and false when
+// there is none.
+func BuiltinServicePlan(name iafv1alpha1.ServicePlanName) (iafv1alpha1.ServicePlan, bool) {
+	i := slices.IndexFunc(builtinServicePlans, func(p iafv1alpha1.ServicePlan) bool { return p.Name == string(name) })
+	if i < 0 {
+		return iafv1alpha1.ServicePlan{}, false
+	}
+	return *builtinServicePlans[i].DeepCopy(), true
+}
+
+// BuiltinServicePlans returns the built-in plans, offered where no
+// ServicePlan replaces them.
+func BuiltinServicePlans() []iafv1alpha1.ServicePlan {
+	plans := make([]iafv1alpha1.ServicePlan, len(builtinServicePlans))
+	for i := range builtinServicePlans {
+		builtinServicePlans[i].DeepCopyInto(&plans[i])
+	}
+	return plans
+}
+
+// ServicePlans returns the plans offered for managed services: the
+// ServicePlans, and the built-in plans none of them replaces, sorted by
+// type and name. Without the ServicePlan CRD only the built-in plans are
+// offered.
+func ServicePlans(ctx context.Context, c client.Reader) ([]iafv1alpha1.ServicePlan, error) {
+	var list iafv1alpha1.ServicePlanList
+	if err := c.List(ctx, &list); err != nil && !meta.IsNoMatchError(err) {
+		return nil, fmt.Errorf("listing service plans: %w", err)
+	}
+	plans := list.Items
+	for _, b := range builtinServicePlans {
+		if !slices.ContainsFunc(plans, func(p iafv1alpha1.ServicePlan) bool { return p.Name == b.Name }) {
+			plans = append(plans, *b.DeepCopy())
+		}
+	}
+	slices.SortFunc(plans, func(a, b iafv1alpha1.ServicePlan) int {
+		if n := strings.Compare(a.Spec.Type, b.Spec.Type); n != 0 {
+			return n
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return plans, nil
+}
+
+// LookupServicePlan returns the plan named name offered for services of
+// serviceType. A plan that is not offered is a validation error listing
+// the plans that are.
+func LookupServicePlan(ctx context.Context, c client.Reader, serviceType string, name iafv1alpha1.ServicePlanName) (*iafv1alpha1.ServicePlan, error) {
+	plans, err := ServicePlans(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	var offered []string
+	for i := range plans {
+		if plans[i].Spec.Type != serviceType {
+			continue
+		}
+		if plans[i].Name == string(name) {
+			return &plans[i], nil
+		}
+		offered = append(offered, plans[i].Name)
+	}
+	return nil, apierror.Validation(apierror.CodeInvalidRequest, "unsupported plan %q for %s — supported plans: %s", name, serviceType, strings.Join(offered, ", ")).
+		WithHint("call list_service_plans for the plans and their resources")
+}
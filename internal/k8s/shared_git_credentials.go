@@ -0,0 +1,96 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// sharedGitCredentialsRetry is how long WatchSharedGitCredentials waits
+// before listing again after its watch ends or fails.
+const sharedGitCredentialsRetry = 30 * time.Second
+
+// SyncSharedGitCredentials runs CopySharedGitCredentials for every session
+// namespace, so a shared credential that was rotated, added or removed
+// reaches sessions registered before the change. A namespace that fails is
+// logged and the others still sync; the error reports how many failed.
+func SyncSharedGitCredentials(ctx context.Context, c client.Client, source string, logger *slog.Logger) error {
+	var namespaces corev1.NamespaceList
+	if err := c.List(ctx, &namespaces, client.MatchingLabels{"app.kubernetes.io/managed-by": "iaf"}); err != nil {
+		return fmt.Errorf("listing session namespaces: %w", err)
+	}
+	failed := 0
+	for _, ns := range namespaces.Items {
+		if ns.Name == source || ns.DeletionTimestamp != nil {
+			continue
+		}
+		if err := CopySharedGitCredentials(ctx, c, source, ns.Name); err != nil {
+			logger.Error("syncing shared git credentials", "namespace", ns.Name, "error", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("shared git credentials failed to sync into %d namespace(s)", failed)
+	}
+	return nil
+}
+
+// WatchSharedGitCredentials keeps the copies of the shared git credentials
+// in source current in every session namespace, syncing on start and
+// whenever a git credential in source changes, until ctx is done.
+func WatchSharedGitCredentials(ctx context.Context, c client.WithWatch, source string, logger *slog.Logger) {
+	for {
+		if err := watchSharedGitCredentials(ctx, c, source, logger); err != nil && ctx.Err() == nil {
+			logger.Warn("watching shared git credentials", "namespace", source, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(sharedGitCredentialsRetry):
+		}
+	}
+}
+
+// watchSharedGitCredentials syncs the copies, then syncs again on every
+// change to the shared credentials until the watch ends. Each restart
+// syncs again, which also retries namespaces that failed.
+func watchSharedGitCredentials(ctx context.Context, c client.WithWatch, source string, logger *slog.Logger) error {
+	var shared corev1.SecretList
+	opts := []client.ListOption{client.InNamespace(source), client.MatchingLabels{LabelCredentialType: "git"}}
+	if err := c.List(ctx, &shared, opts...); err != nil {
+		return err
+	}
+	if err := SyncSharedGitCredentials(ctx, c, source, logger); err != nil {
+		logger.Warn("syncing shared git credentials", "namespace", source, "error", err)
+	}
+
+	w, err := c.Watch(ctx, &corev1.SecretList{}, append(opts, &client.ListOptions{
+		Raw: &metav1.ListOptions{ResourceVersion: shared.ResourceVersion},
+	})...)
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-w.ResultChan():
+			if !ok || event.Type == watch.Error {
+				return nil
+			}
+			if event.Type == watch.Bookmark {
+				continue
+			}
+			if err := SyncSharedGitCredentials(ctx, c, source, logger); err != nil {
+				logger.Warn("syncing shared git credentials", "namespace", source, "error", err)
+			}
+		}
+	}
+}
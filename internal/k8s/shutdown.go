@@ -0,0 +1,56 @@
+package k8s
+
+import (
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Defaults for spec.shutdown.
+const (
+	DefaultShutdownGracePeriodSeconds = 30
+	DefaultPreStopDelaySeconds        = 5
+)
+
+// ShutdownTiming returns an application's termination grace period and
+// pre-stop delay in seconds, with the platform defaults for unset fields.
+func ShutdownTiming(app *iafv1alpha1.Application) (grace, preStop int64) {
+	grace, preStop = DefaultShutdownGracePeriodSeconds, DefaultPreStopDelaySeconds
+	if s := app.Spec.Shutdown; s != nil {
+		if s.GracePeriodSeconds != nil {
+			grace = int64(*s.GracePeriodSeconds)
+		}
+		if s.PreStopDelaySeconds != nil {
+			preStop = int64(*s.PreStopDelaySeconds)
+		}
+	}
+	return grace, preStop
+}
+
+// ValidateShutdown checks that an application's pre-stop delay leaves time
+// to shut down within its grace period.
+func ValidateShutdown(app *iafv1alpha1.Application) error {
+	grace, preStop := ShutdownTiming(app)
+	if grace < 1 {
+		return fmt.Errorf("shutdown.gracePeriodSeconds must be at least 1, got %d", grace)
+	}
+	if preStop < 0 {
+		return fmt.Errorf("shutdown.preStopDelaySeconds must not be negative, got %d", preStop)
+	}
+	if preStop >= grace {
+		return fmt.Errorf("shutdown.preStopDelaySeconds (%d) must be less than shutdown.gracePeriodSeconds (%d)", preStop, grace)
+	}
+	return nil
+}
+
+// PreStopLifecycle returns a container lifecycle that waits delay seconds
+// before the container is sent SIGTERM, giving the router time to stop
+// sending it requests. It uses the kubelet's sleep action, so images need
+// no sleep binary. Nil when delay is 0.
+func PreStopLifecycle(delay int64) *corev1.Lifecycle {
+	if delay <= 0 {
+		return nil
+	}
+	return &corev1.Lifecycle{PreStop: &corev1.LifecycleHandler{Sleep: &corev1.SleepAction{Seconds: delay}}}
+}
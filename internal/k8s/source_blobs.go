@@ -0,0 +1,55 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ResignLegacyBlobs gives every Application still pointing at an unsigned
+// blob URL, issued before blob URLs were signed, a signed URL for its
+// current source, so kpack can keep fetching it. The new URL makes kpack
+// build the app once more. Apps whose source is gone are left alone and
+// logged. It returns how many apps it updated.
+func ResignLegacyBlobs(ctx context.Context, c client.Client, store *sourcestore.Store, logger *slog.Logger) (int, error) {
+	var apps iafv1alpha1.ApplicationList
+	if err := c.List(ctx, &apps); err != nil {
+		return 0, fmt.Errorf("listing applications: %w", err)
+	}
+	updated := 0
+	for i := range apps.Items {
+		app := &apps.Items[i]
+		if app.Spec.Blob == "" {
+			continue
+		}
+		blob, ok, err := store.ResignLegacyURL(app.Namespace, app.Spec.Blob)
+		if errors.Is(err, fs.ErrNotExist) {
+			logger.Warn("application has an unsigned blob URL and no stored source; push its source again", "namespace", app.Namespace, "app", app.Name)
+			continue
+		}
+		if err != nil {
+			return updated, fmt.Errorf("signing blob URL of %s/%s: %w", app.Namespace, app.Name, err)
+		}
+		if !ok {
+			continue
+		}
+		app.Spec.Blob = blob.URL
+		metav1.SetMetaDataAnnotation(&app.ObjectMeta, sourcestore.AnnotationSourceSHA256, blob.SHA256)
+		if err := c.Update(ctx, app); err != nil {
+			// A concurrent change, such as a push, issues a signed URL
+			// itself; the next start retries anything else.
+			logger.Warn("failed to sign blob URL", "namespace", app.Namespace, "app", app.Name, "error", err)
+			continue
+		}
+		logger.Info("signed legacy blob URL", "namespace", app.Namespace, "app", app.Name, "sha256", blob.SHA256)
+		updated++
+	}
+	return updated, nil
+}
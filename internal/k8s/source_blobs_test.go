@@ -0,0 +1,78 @@
+package k8s
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestResignLegacyBlobs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := iafv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store, err := sourcestore.New(t.TempDir(), "http://sources.test", logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := store.StoreFiles("iaf-a", "current", map[string]string{"main.go": "package main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.StoreFiles("iaf-a", "legacy", map[string]string{"main.go": "package main"}); err != nil {
+		t.Fatal(err)
+	}
+	app := func(namespace, name, blob string) *iafv1alpha1.Application {
+		return &iafv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       iafv1alpha1.ApplicationSpec{Blob: blob},
+		}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		app("iaf-a", "legacy", "http://sources.test/sources/iaf-a/legacy/source.tar.gz?rev=abc"),
+		app("iaf-a", "current", signed.URL),
+		app("iaf-a", "gone", "http://sources.test/sources/iaf-a/gone/source.tar.gz"),
+		// An unsigned URL naming another namespace is never signed.
+		app("iaf-b", "stolen", "http://sources.test/sources/iaf-a/legacy/source.tar.gz"),
+		app("iaf-b", "image", ""),
+	).Build()
+
+	n, err := ResignLegacyBlobs(context.Background(), c, store, logger)
+	if err != nil || n != 1 {
+		t.Fatalf("expected one app updated, got %d, %v", n, err)
+	}
+	get := func(namespace, name string) *iafv1alpha1.Application {
+		var a iafv1alpha1.Application
+		if err := c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, &a); err != nil {
+			t.Fatal(err)
+		}
+		return &a
+	}
+	legacy := get("iaf-a", "legacy")
+	if legacy.Annotations[sourcestore.AnnotationSourceSHA256] != signed.SHA256 || !strings.Contains(legacy.Spec.Blob, "sig=") {
+		t.Fatalf("expected a signed blob URL, got %q", legacy.Spec.Blob)
+	}
+	rec := httptest.NewRecorder()
+	http.StripPrefix("/sources/", store.Handler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, legacy.Spec.Blob, nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("signed URL served %d, want 200", rec.Code)
+	}
+	if got := get("iaf-a", "current").Spec.Blob; got != signed.URL {
+		t.Errorf("signed URL changed to %q", got)
+	}
+	if got := get("iaf-b", "stolen").Spec.Blob; strings.Contains(got, "sig=") {
+		t.Errorf("URL into another namespace was signed: %q", got)
+	}
+}
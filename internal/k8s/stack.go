@@ -0,0 +1,5 @@
+package k8s
+
+// LabelStack is set on Applications and ManagedServices created by deploy_stack
+// to the name of the stack they belong to.
+const LabelStack = "iaf.io/stack"
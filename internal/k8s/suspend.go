@@ -0,0 +1,114 @@
+package k8s
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// SuspendedPagePath is the API server path that renders the page shown in
+// place of a suspended application.
+const SuspendedPagePath = "/suspended"
+
+// ServiceRef identifies a Service port in another namespace.
+type ServiceRef struct {
+	Namespace string
+	Name      string
+	Port      int32
+}
+
+// ParseServiceRef parses a "namespace/name:port" reference. An empty string
+// yields the zero ServiceRef.
+func ParseServiceRef(s string) (ServiceRef, error) {
+	if s == "" {
+		return ServiceRef{}, nil
+	}
+	nsName, portStr, ok := strings.Cut(s, ":")
+	namespace, name, ok2 := strings.Cut(nsName, "/")
+	if !ok || !ok2 {
+		return ServiceRef{}, fmt.Errorf("service reference %q must have the form namespace/name:port", s)
+	}
+	for _, part := range []string{namespace, name} {
+		if errs := validation.IsDNS1123Label(part); len(errs) > 0 {
+			return ServiceRef{}, fmt.Errorf("service reference %q: %s", s, strings.Join(errs, "; "))
+		}
+	}
+	port, err := strconv.ParseInt(portStr, 10, 32)
+	if err != nil || port < 1 || port > 65535 {
+		return ServiceRef{}, fmt.Errorf("service reference %q: invalid port %q", s, portStr)
+	}
+	return ServiceRef{Namespace: namespace, Name: name, Port: int32(port)}, nil
+}
+
+// IsZero reports whether the reference is unset.
+func (r ServiceRef) IsZero() bool {
+	return r == ServiceRef{}
+}
+
+// SuspendedMiddlewareName returns the name of the Traefik Middleware that
+// serves the suspended page on an app's route.
+func SuspendedMiddlewareName(appName string) string {
+	return appName + "-suspended"
+}
+
+// WakeMiddlewareName returns the name of the Traefik Middleware that wakes
+// an idle app and serves the holding page while it starts.
+func WakeMiddlewareName(appName string) string {
+	return appName + "-wake"
+}
+
+// BuildSuspendedMiddleware constructs a Traefik Errors Middleware that
+// replaces the 502/503 responses of a suspended app (which has no ready
+// endpoints) with the platform page served by page. Referencing a Service
+// outside the app's namespace requires Traefik's
+// providers.kubernetesCRD.allowCrossNamespace.
+func BuildSuspendedMiddleware(app *iafv1alpha1.Application, page ServiceRef) *unstructured.Unstructured {
+	return buildErrorPageMiddleware(app, SuspendedMiddlewareName(app.Name), page, SuspendedPagePath)
+}
+
+// BuildWakeMiddleware constructs the Errors Middleware for a sleeping or
+// waking app. Traefik fetches wakePath, a signed API server path that wakes
+// the app and renders a holding page, for every request that finds no ready
+// endpoint.
+func BuildWakeMiddleware(app *iafv1alpha1.Application, page ServiceRef, wakePath string) *unstructured.Unstructured {
+	return buildErrorPageMiddleware(app, WakeMiddlewareName(app.Name), page, wakePath)
+}
+
+func buildErrorPageMiddleware(app *iafv1alpha1.Application, name string, page ServiceRef, query string) *unstructured.Unstructured {
+	obj := newRouteObject(app, TraefikMiddlewareGVK)
+	obj.SetName(name)
+	obj.Object["spec"] = map[string]any{
+		"errors": map[string]any{
+			"status": []any{"502-503"},
+			"query":  query,
+			"service": map[string]any{
+				"name":      page.Name,
+				"namespace": page.Namespace,
+				"port":      int64(page.Port),
+			},
+		},
+	}
+	return obj
+}
+
+// AddRouteMiddleware appends a reference to the named Middleware to every
+// route of an IngressRoute built by BuildIngressRoute. It runs after the
+// access and authentication middlewares, so only clients that pass them see
+// the page.
+func AddRouteMiddleware(route *unstructured.Unstructured, name string) {
+	routes, _, _ := unstructured.NestedSlice(route.Object, "spec", "routes")
+	for i, r := range routes {
+		m, ok := r.(map[string]any)
+		if !ok {
+			continue
+		}
+		middlewares, _ := m["middlewares"].([]any)
+		m["middlewares"] = append(middlewares, map[string]any{"name": name})
+		routes[i] = m
+	}
+	_ = unstructured.SetNestedSlice(route.Object, routes, "spec", "routes")
+}
@@ -0,0 +1,60 @@
+package k8s
+
+import (
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseServiceRef(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ServiceRef
+		wantErr bool
+	}{
+		{in: "", want: ServiceRef{}},
+		{in: "iaf-system/iaf-apiserver:8080", want: ServiceRef{Namespace: "iaf-system", Name: "iaf-apiserver", Port: 8080}},
+		{in: "iaf-apiserver:8080", wantErr: true},
+		{in: "iaf-system/iaf-apiserver", wantErr: true},
+		{in: "iaf-system/iaf-apiserver:0", wantErr: true},
+		{in: "iaf-system/Bad_Name:80", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := ParseServiceRef(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ParseServiceRef(%q) error = %v, wantErr %v", tc.in, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseServiceRef(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestSuspendedMiddleware(t *testing.T) {
+	app := makeTestApp("my-app", "iaf-abc123")
+	app.Spec.Authentication = iafv1alpha1.AuthenticationBasic
+	page := ServiceRef{Namespace: "iaf-system", Name: "iaf-apiserver", Port: 8080}
+
+	mw := BuildSuspendedMiddleware(app, page)
+	if mw.GetName() != "my-app-suspended" || mw.GetNamespace() != "iaf-abc123" {
+		t.Errorf("unexpected middleware %s/%s", mw.GetNamespace(), mw.GetName())
+	}
+	query, _, _ := unstructured.NestedString(mw.Object, "spec", "errors", "query")
+	svcNamespace, _, _ := unstructured.NestedString(mw.Object, "spec", "errors", "service", "namespace")
+	if query != SuspendedPagePath || svcNamespace != "iaf-system" {
+		t.Errorf("unexpected errors spec %v", mw.Object["spec"])
+	}
+
+	route := BuildIngressRoute(app, "example.com", true)
+	AddRouteMiddleware(route, SuspendedMiddlewareName(app.Name))
+	routes, _, _ := unstructured.NestedSlice(route.Object, "spec", "routes")
+	middlewares := routes[0].(map[string]any)["middlewares"].([]any)
+	if len(middlewares) != 2 {
+		t.Fatalf("expected auth and suspended middlewares, got %v", middlewares)
+	}
+	if last := middlewares[1].(map[string]any)["name"]; last != "my-app-suspended" {
+		t.Errorf("suspended middleware must run last, got %v", middlewares)
+	}
+}
@@ -23,9 +23,30 @@ var TraefikIngressRouteGVR = schema.GroupVersionResource{
 	Resource: "ingressroutes",
 }
 
-// BuildIngressRoute constructs an unstructured Traefik IngressRoute for the given application.
+// TraefikIngressRouteTCPGVK is the GroupVersionKind for Traefik IngressRouteTCP CRs.
+var TraefikIngressRouteTCPGVK = schema.GroupVersionKind{
+	Group:   "traefik.io",
+	Version: "v1alpha1",
+	Kind:    "IngressRouteTCP",
+}
+
+// IngressRouteGVKFor returns the Traefik route kind used for the application's
+// protocol: IngressRouteTCP for tcp, IngressRoute for everything else.
+func IngressRouteGVKFor(app *iafv1alpha1.Application) schema.GroupVersionKind {
+	if iafv1alpha1.AppProtocol(app) == iafv1alpha1.ProtocolTCP {
+		return TraefikIngressRouteTCPGVK
+	}
+	return TraefikIngressRouteGVK
+}
+
+// BuildIngressRoute constructs an unstructured Traefik route for the given application.
 // When tlsEnabled is true the route uses the "websecure" entrypoint and references the
 // cert-manager TLS Secret; otherwise it uses the "web" (HTTP) entrypoint.
+//
+// The route shape depends on spec.protocol: grpc sets the h2c scheme on the backend
+// service, http and websocket honour spec.stickySessions, and tcp produces an
+// IngressRouteTCP matched by TLS SNI (always on the "websecure" entrypoint).
+// spec.access and basic authentication attach the app's middlewares to the route.
 func BuildIngressRoute(app *iafv1alpha1.Application, baseDomain string, tlsEnabled bool) *unstructured.Unstructured {
 	host := app.Spec.Host
 	if host == "" {
@@ -37,29 +58,96 @@ func BuildIngressRoute(app *iafv1alpha1.Application, baseDomain string, tlsEnabl
 		port = 8080
 	}
 
-	obj := &unstructured.Unstructured{}
-	obj.SetGroupVersionKind(TraefikIngressRouteGVK)
-	obj.SetName(app.Name)
-	obj.SetNamespace(app.Namespace)
-	obj.SetLabels(map[string]string{
-		"app.kubernetes.io/managed-by": "iaf",
-		"iaf.io/application":          app.Name,
-	})
-	obj.SetOwnerReferences([]metav1.OwnerReference{
-		{
-			APIVersion: iafv1alpha1.GroupVersion.String(),
-			Kind:       "Application",
-			Name:       app.Name,
-			UID:        app.UID,
-		},
-	})
+	if iafv1alpha1.AppProtocol(app) == iafv1alpha1.ProtocolTCP {
+		return buildIngressRouteTCP(app, host, port, tlsEnabled)
+	}
+
+	obj := newRouteObject(app, TraefikIngressRouteGVK)
+
+	service := map[string]any{
+		"name": app.Name,
+		"port": int64(port),
+	}
+	switch iafv1alpha1.AppProtocol(app) {
+	case iafv1alpha1.ProtocolGRPC:
+		// gRPC backends speak HTTP/2 without TLS inside the cluster.
+		service["scheme"] = "h2c"
+	default:
+		if app.Spec.StickySessions {
+			service["sticky"] = map[string]any{
+				"cookie": map[string]any{
+					"name":     "iaf_sticky",
+					"httpOnly": true,
+					"secure":   tlsEnabled,
+				},
+			}
+		}
+	}
+
+	route := map[string]any{
+		"match":    fmt.Sprintf("Host(`%s`)", host),
+		"kind":     "Rule",
+		"services": []any{service},
+	}
+	if middlewares := routeMiddlewares(app); len(middlewares) > 0 {
+		route["middlewares"] = middlewares
+	}
 
 	entryPoints := []any{"web"}
 	spec := map[string]any{
+		"routes": []any{route},
+	}
+
+	if tlsEnabled {
+		entryPoints = []any{"websecure"}
+		spec["tls"] = map[string]any{
+			"secretName": TLSSecretName(app.Name),
+		}
+	}
+	spec["entryPoints"] = entryPoints
+
+	obj.Object["spec"] = spec
+	return obj
+}
+
+// routeMiddlewares returns the Traefik middleware references for the app's route.
+// Source IP filtering runs first so rejected clients never consume rate-limit
+// budget or reach the authentication prompt.
+func routeMiddlewares(app *iafv1alpha1.Application) []any {
+	var names []string
+	if HasIPAllowList(app) {
+		names = append(names, IPAllowListMiddlewareName(app.Name))
+	}
+	if HasRateLimit(app) {
+		names = append(names, RateLimitMiddlewareName(app.Name))
+	}
+	if iafv1alpha1.AppAuthentication(app) == iafv1alpha1.AuthenticationBasic {
+		names = append(names, AuthMiddlewareName(app.Name))
+	}
+
+	middlewares := make([]any, 0, len(names))
+	for _, name := range names {
+		middlewares = append(middlewares, map[string]any{"name": name})
+	}
+	return middlewares
+}
+
+// buildIngressRouteTCP constructs an IngressRouteTCP that routes TLS connections for
+// host to the application's Service. SNI matching requires TLS, so the route always
+// terminates TLS at Traefik; when tlsEnabled is false Traefik's default certificate is used.
+func buildIngressRouteTCP(app *iafv1alpha1.Application, host string, port int32, tlsEnabled bool) *unstructured.Unstructured {
+	obj := newRouteObject(app, TraefikIngressRouteTCPGVK)
+
+	tls := map[string]any{}
+	if tlsEnabled {
+		tls["secretName"] = TLSSecretName(app.Name)
+	}
+
+	obj.Object["spec"] = map[string]any{
+		"entryPoints": []any{"websecure"},
 		"routes": []any{
 			map[string]any{
-				"match": fmt.Sprintf("Host(`%s`)", host),
-				"kind":  "Rule",
+				"match": fmt.Sprintf("HostSNI(`%s`)", host),
 				"services": []any{
 					map[string]any{
 						"name": app.Name,
@@ -68,16 +156,27 @@ func BuildIngressRoute(app *iafv1alpha1.Application, baseDomain string, tlsEnabl
 				},
 			},
 		},
+		"tls": tls,
 	}
+	return obj
+}
 
-	if tlsEnabled {
-		entryPoints = []any{"websecure"}
-		spec["tls"] = map[string]any{
-			"secretName": TLSSecretName(app.Name),
-		}
-	}
-	spec["entryPoints"] = entryPoints
+// newRouteObject returns an empty Traefik route of the given kind with the
+// standard IAF labels and an owner reference to the application.
+func newRouteObject(app *iafv1alpha1.Application, gvk schema.GroupVersionKind) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetName(app.Name)
+	obj.SetNamespace(app.Namespace)
+	obj.SetLabels(AppLabels(app))
+	obj.SetOwnerReferences([]metav1.OwnerReference{
+		{
+			APIVersion: iafv1alpha1.GroupVersion.String(),
+			Kind:       "Application",
+			Name:       app.Name,
+			UID:        app.UID,
+		},
+	})
 
-	obj.Object["spec"] = spec
 	return obj
 }
@@ -0,0 +1,345 @@
+package k8s
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// UpdateEffect is what applying a spec change does to a running application.
+// Effects are ordered from least to most disruptive.
+type UpdateEffect string
+
+const (
+	// EffectNone: the spec is unchanged.
+	EffectNone UpdateEffect = "none"
+	// EffectInPlace: routing or platform settings change; running pods are
+	// not touched.
+	EffectInPlace UpdateEffect = "in_place"
+	// EffectScale: the replica count changes; running pods are not
+	// restarted.
+	EffectScale UpdateEffect = "scale"
+	// EffectRestart: the pod template changes and pods are replaced by a
+	// rolling update.
+	EffectRestart UpdateEffect = "restart"
+	// EffectRebuild: a new image is built from source, then rolled out.
+	EffectRebuild UpdateEffect = "rebuild"
+)
+
+var effectRank = map[UpdateEffect]int{
+	EffectNone:    0,
+	EffectInPlace: 1,
+	EffectScale:   2,
+	EffectRestart: 3,
+	EffectRebuild: 4,
+}
+
+var effectSummaries = map[UpdateEffect]string{
+	EffectNone:    "No changes; applying this update does nothing.",
+	EffectInPlace: "Applied in place; running pods are not restarted.",
+	EffectScale:   "Changes the replica count; running pods are not restarted.",
+	EffectRestart: "Triggers a rolling restart; new pods replace the old ones as they become ready.",
+	EffectRebuild: "Triggers a new build from source (about 2 minutes), then a rolling restart.",
+}
+
+// SpecChange is one changed field of an Application spec. Scalar fields
+// report From and To. List fields keyed by name report the keys Added,
+// Removed and Modified instead, so environment values and file contents are
+// not echoed back.
+type SpecChange struct {
+	Field    string       `json:"field"`
+	From     any          `json:"from,omitempty"`
+	To       any          `json:"to,omitempty"`
+	Added    []string     `json:"added,omitempty"`
+	Removed  []string     `json:"removed,omitempty"`
+	Modified []string     `json:"modified,omitempty"`
+	Effect   UpdateEffect `json:"effect"`
+}
+
+// UpdatePlan describes what updating an Application to a proposed spec
+// changes, and the most disruptive effect of applying it.
+type UpdatePlan struct {
+	Effect   UpdateEffect `json:"effect"`
+	Summary  string       `json:"summary"`
+	Changes  []SpecChange `json:"changes"`
+	Warnings []string     `json:"warnings,omitempty"`
+}
+
+// PlanUpdate compares the spec of current with the spec of proposed, an
+// updated copy of it, and classifies each changed field by the effect
+// reconciling it has. Defaulted fields are compared by their effective
+// values, so setting port 8080 on an app without a port is not a change.
+func PlanUpdate(current, proposed *iafv1alpha1.Application) UpdatePlan {
+	cur, next := &current.Spec, &proposed.Spec
+	fromSource := next.Git != nil || next.Blob != ""
+	plan := UpdatePlan{Changes: []SpecChange{}}
+
+	scalar := func(field string, from, to any, effect UpdateEffect) {
+		if !reflect.DeepEqual(from, to) {
+			plan.Changes = append(plan.Changes, SpecChange{Field: field, From: from, To: to, Effect: effect})
+		}
+	}
+	keyed := func(field string, from, to map[string]string, effect UpdateEffect) {
+		c := SpecChange{Field: field, Effect: effect}
+		for k, v := range to {
+			if old, ok := from[k]; !ok {
+				c.Added = append(c.Added, k)
+			} else if old != v {
+				c.Modified = append(c.Modified, k)
+			}
+		}
+		for k := range from {
+			if _, ok := to[k]; !ok {
+				c.Removed = append(c.Removed, k)
+			}
+		}
+		if len(c.Added)+len(c.Removed)+len(c.Modified) == 0 {
+			return
+		}
+		slices.Sort(c.Added)
+		slices.Sort(c.Removed)
+		slices.Sort(c.Modified)
+		plan.Changes = append(plan.Changes, c)
+	}
+	// Build settings only take effect for apps built from source.
+	buildEffect := EffectInPlace
+	if fromSource {
+		buildEffect = EffectRebuild
+	}
+
+	// Source.
+	scalar("image", cur.Image, next.Image, EffectRestart)
+	scalar("git.url", gitURL(cur), gitURL(next), EffectRebuild)
+	scalar("git.revision", gitRevision(cur), gitRevision(next), EffectRebuild)
+	scalar("git.subPath", gitSubPath(cur), gitSubPath(next), EffectRebuild)
+	scalar("git.track", gitTrack(cur), gitTrack(next), EffectRebuild)
+	scalar("git.paused", gitPaused(cur), gitPaused(next), EffectInPlace)
+	scalar("blob", cur.Blob, next.Blob, EffectRebuild)
+	scalar("blobSubPath", cur.BlobSubPath, next.BlobSubPath, EffectRebuild)
+	scalar("registryCredential", cur.RegistryCredential, next.RegistryCredential, EffectRestart)
+	keyed("buildEnv", envMap(cur.BuildEnv), envMap(next.BuildEnv), buildEffect)
+	scalar("builder", cur.Builder, next.Builder, buildEffect)
+	scalar("buildCache", cur.BuildCache, next.BuildCache, EffectInPlace)
+	archEffect := EffectRestart
+	if fromSource {
+		archEffect = EffectRebuild
+	}
+	scalar("architecture", string(cur.Architecture), string(next.Architecture), archEffect)
+	// The proxy settings are in both the build env and the pod env.
+	scalar("proxy.enabled", iafv1alpha1.IsProxyEnabled(current), iafv1alpha1.IsProxyEnabled(proposed), archEffect)
+
+	// Pod template.
+	scalar("port", effectivePort(cur), effectivePort(next), EffectRestart)
+	scalar("command", cur.Command, next.Command, EffectRestart)
+	scalar("args", cur.Args, next.Args, EffectRestart)
+	keyed("env", envMap(cur.Env), envMap(next.Env), EffectRestart)
+	keyed("envGroups", setMap(cur.EnvGroups), setMap(next.EnvGroups), EffectRestart)
+	keyed("configFiles", configFileMap(cur.ConfigFiles), configFileMap(next.ConfigFiles), EffectRestart)
+	scalar("caBundle.enabled", iafv1alpha1.IsCABundleEnabled(current), iafv1alpha1.IsCABundleEnabled(proposed), EffectRestart)
+	scalar("backendTLS.enabled", iafv1alpha1.IsBackendTLSEnabled(current), iafv1alpha1.IsBackendTLSEnabled(proposed), EffectRestart)
+	keyed("attachedDataSources", dataSourceMap(cur.AttachedDataSources), dataSourceMap(next.AttachedDataSources), EffectRestart)
+	keyed("boundManagedServices", managedServiceMap(cur.BoundManagedServices), managedServiceMap(next.BoundManagedServices), EffectRestart)
+	scalar("workloadClass", string(cur.WorkloadClass), string(next.WorkloadClass), EffectRestart)
+	keyed("hostAliases", hostAliasMap(cur.HostAliases), hostAliasMap(next.HostAliases), EffectRestart)
+	scalar("dnsConfig", cur.DNSConfig, next.DNSConfig, EffectRestart)
+	scalar("shutdown", shutdownTiming(current), shutdownTiming(proposed), EffectRestart)
+	authEffect := EffectInPlace
+	if iafv1alpha1.AppAuthentication(current) == iafv1alpha1.AuthenticationOAuthProxy ||
+		iafv1alpha1.AppAuthentication(proposed) == iafv1alpha1.AuthenticationOAuthProxy {
+		// The OAuth proxy runs as a sidecar in the pod.
+		authEffect = EffectRestart
+	}
+	scalar("authentication", string(iafv1alpha1.AppAuthentication(current)), string(iafv1alpha1.AppAuthentication(proposed)), authEffect)
+	if !rawEqual(overridesDeployment(cur), overridesDeployment(next)) {
+		plan.Changes = append(plan.Changes, SpecChange{Field: "overrides.deployment", Effect: EffectRestart})
+	}
+	if !rawEqual(overridesService(cur), overridesService(next)) {
+		plan.Changes = append(plan.Changes, SpecChange{Field: "overrides.service", Effect: EffectInPlace})
+	}
+
+	// Scaling.
+	scalar("replicas", effectiveReplicas(cur), effectiveReplicas(next), EffectScale)
+	scalar("suspended", cur.Suspended, next.Suspended, EffectScale)
+	scalar("idleTimeout", durationString(cur.IdleTimeout), durationString(next.IdleTimeout), EffectInPlace)
+	scalar("ttl", durationString(cur.TTL), durationString(next.TTL), EffectInPlace)
+
+	// Routing and platform settings.
+	scalar("host", cur.Host, next.Host, EffectInPlace)
+	scalar("tls", tlsEnabled(cur), tlsEnabled(next), EffectInPlace)
+	scalar("protocol", string(iafv1alpha1.AppProtocol(current)), string(iafv1alpha1.AppProtocol(proposed)), EffectInPlace)
+	scalar("stickySessions", cur.StickySessions, next.StickySessions, EffectInPlace)
+	scalar("access", cur.Access, next.Access, EffectInPlace)
+	scalar("uptimeCheck", cur.UptimeCheck, next.UptimeCheck, EffectInPlace)
+	scalar("verifyConformance", cur.VerifyConformance, next.VerifyConformance, EffectInPlace)
+	scalar("releaseCommand", cur.ReleaseCommand, next.ReleaseCommand, EffectInPlace)
+
+	plan.Effect = EffectNone
+	for _, c := range plan.Changes {
+		if effectRank[c.Effect] > effectRank[plan.Effect] {
+			plan.Effect = c.Effect
+		}
+	}
+	plan.Summary = effectSummaries[plan.Effect]
+
+	if p, q := effectivePort(cur), effectivePort(next); p != q {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("The Service switches from port %d to %d at once; requests fail until the new pods listen on %d.", p, q, q))
+	}
+	if !cur.Suspended && next.Suspended {
+		plan.Warnings = append(plan.Warnings, "Suspending scales the app to zero; it serves a suspended page until it is resumed.")
+	}
+	if cur.Host != next.Host {
+		plan.Warnings = append(plan.Warnings, "The app moves to a new hostname; the old URL stops working.")
+	}
+	if (cur.Git != nil || cur.Blob != "") && next.Image != "" {
+		plan.Warnings = append(plan.Warnings, "The app switches from source builds to a pre-built image; pushed source and build settings are no longer used.")
+	}
+	return plan
+}
+
+func gitURL(spec *iafv1alpha1.ApplicationSpec) string {
+	if spec.Git == nil {
+		return ""
+	}
+	return spec.Git.URL
+}
+
+func gitRevision(spec *iafv1alpha1.ApplicationSpec) string {
+	if spec.Git == nil {
+		return ""
+	}
+	return spec.Git.Revision
+}
+
+func gitSubPath(spec *iafv1alpha1.ApplicationSpec) string {
+	if spec.Git == nil {
+		return ""
+	}
+	return spec.Git.SubPath
+}
+
+func gitTrack(spec *iafv1alpha1.ApplicationSpec) iafv1alpha1.GitTrackMode {
+	if spec.Git == nil {
+		return ""
+	}
+	return spec.Git.Track
+}
+
+func gitPaused(spec *iafv1alpha1.ApplicationSpec) bool {
+	return spec.Git != nil && spec.Git.Paused
+}
+
+func effectivePort(spec *iafv1alpha1.ApplicationSpec) int32 {
+	if spec.Port == 0 {
+		return 8080
+	}
+	return spec.Port
+}
+
+func effectiveReplicas(spec *iafv1alpha1.ApplicationSpec) int32 {
+	if spec.Replicas == 0 {
+		return 1
+	}
+	return spec.Replicas
+}
+
+func tlsEnabled(spec *iafv1alpha1.ApplicationSpec) bool {
+	return spec.TLS == nil || spec.TLS.Enabled == nil || *spec.TLS.Enabled
+}
+
+func durationString(d *metav1.Duration) string {
+	if d == nil {
+		return ""
+	}
+	return d.Duration.String()
+}
+
+func envMap(env []iafv1alpha1.EnvVar) map[string]string {
+	m := make(map[string]string, len(env))
+	for _, e := range env {
+		m[e.Name] = e.Value
+	}
+	return m
+}
+
+func setMap(names []string) map[string]string {
+	m := make(map[string]string, len(names))
+	for _, n := range names {
+		m[n] = ""
+	}
+	return m
+}
+
+func configFileMap(files []iafv1alpha1.ConfigFile) map[string]string {
+	m := make(map[string]string, len(files))
+	for _, f := range files {
+		v := f.Content
+		if f.ConfigMap != nil {
+			v = "configmap:" + f.ConfigMap.Name + "/" + f.ConfigMap.Key
+		}
+		m[f.Path] = v
+	}
+	return m
+}
+
+func dataSourceMap(sources []iafv1alpha1.AttachedDataSource) map[string]string {
+	m := make(map[string]string, len(sources))
+	for _, s := range sources {
+		m[s.DataSourceName] = s.SecretName
+	}
+	return m
+}
+
+func managedServiceMap(services []iafv1alpha1.BoundManagedService) map[string]string {
+	m := make(map[string]string, len(services))
+	for _, s := range services {
+		m[s.ServiceName] = s.SecretName + "/" + string(s.Mode) + "/" + s.EnvPrefix + "/" + string(s.Projection) + "/" + fmt.Sprint(s.Formats)
+	}
+	return m
+}
+
+// shutdownTiming formats an app's effective shutdown settings, so setting
+// a field to its default is not a change.
+func shutdownTiming(app *iafv1alpha1.Application) string {
+	grace, preStop := ShutdownTiming(app)
+	return fmt.Sprintf("gracePeriodSeconds=%d preStopDelaySeconds=%d", grace, preStop)
+}
+
+// hostAliasMap keys host aliases by IP.
+func hostAliasMap(aliases []iafv1alpha1.HostAlias) map[string]string {
+	m := make(map[string]string, len(aliases))
+	for _, a := range aliases {
+		m[a.IP] = strings.Join(a.Hostnames, ",")
+	}
+	return m
+}
+
+func overridesDeployment(spec *iafv1alpha1.ApplicationSpec) *runtime.RawExtension {
+	if spec.Overrides == nil {
+		return nil
+	}
+	return spec.Overrides.Deployment
+}
+
+func overridesService(spec *iafv1alpha1.ApplicationSpec) *runtime.RawExtension {
+	if spec.Overrides == nil {
+		return nil
+	}
+	return spec.Overrides.Service
+}
+
+func rawEqual(a, b *runtime.RawExtension) bool {
+	var ra, rb []byte
+	if a != nil {
+		ra = a.Raw
+	}
+	if b != nil {
+		rb = b.Raw
+	}
+	return bytes.Equal(bytes.TrimSpace(ra), bytes.TrimSpace(rb))
+}
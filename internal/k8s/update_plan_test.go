@@ -0,0 +1,271 @@
+package k8s
+
+import (
+	"slices"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+)
+
+func TestPlanUpdate(t *testing.T) {
+	imageApp := func() *iafv1alpha1.Application {
+		app := makeTestApp("my-app", "iaf-abc123")
+		app.Spec = iafv1alpha1.ApplicationSpec{
+			Image: "nginx:1.25",
+			Env:   []iafv1alpha1.EnvVar{{Name: "MODE", Value: "prod"}, {Name: "DEBUG", Value: "0"}},
+		}
+		return app
+	}
+	gitApp := func() *iafv1alpha1.Application {
+		app := makeTestApp("my-app", "iaf-abc123")
+		app.Spec = iafv1alpha1.ApplicationSpec{
+			Git:      &iafv1alpha1.GitSource{URL: "https://github.com/org/app", Revision: "main"},
+			Port:     3000,
+			Replicas: 2,
+		}
+		return app
+	}
+
+	tests := []struct {
+		name       string
+		current    *iafv1alpha1.Application
+		update     func(*iafv1alpha1.ApplicationSpec)
+		wantEffect UpdateEffect
+		wantFields []string
+		warnings   int
+	}{
+		{
+			name:       "no change",
+			current:    imageApp(),
+			update:     func(s *iafv1alpha1.ApplicationSpec) {},
+			wantEffect: EffectNone,
+		},
+		{
+			name:    "defaults are not changes",
+			current: imageApp(),
+			update: func(s *iafv1alpha1.ApplicationSpec) {
+				s.Port, s.Replicas, s.Protocol = 8080, 1, iafv1alpha1.ProtocolHTTP
+			},
+			wantEffect: EffectNone,
+		},
+		{
+			name:    "shutdown set to the defaults is not a change",
+			current: imageApp(),
+			update: func(s *iafv1alpha1.ApplicationSpec) {
+				grace, preStop := int32(30), int32(5)
+				s.Shutdown = &iafv1alpha1.ShutdownConfig{GracePeriodSeconds: &grace, PreStopDelaySeconds: &preStop}
+			},
+			wantEffect: EffectNone,
+		},
+		{
+			name:    "shutdown",
+			current: imageApp(),
+			update: func(s *iafv1alpha1.ApplicationSpec) {
+				grace := int32(60)
+				s.Shutdown = &iafv1alpha1.ShutdownConfig{GracePeriodSeconds: &grace}
+			},
+			wantEffect: EffectRestart,
+			wantFields: []string{"shutdown"},
+		},
+		{
+			name:    "proxy opt-out rebuilds source apps",
+			current: gitApp(),
+			update: func(s *iafv1alpha1.ApplicationSpec) {
+				disabled := false
+				s.Proxy = &iafv1alpha1.ProxyConfig{Enabled: &disabled}
+			},
+			wantEffect: EffectRebuild,
+			wantFields: []string{"proxy.enabled"},
+		},
+		{
+			name:    "CA bundle opt-out restarts",
+			current: gitApp(),
+			update: func(s *iafv1alpha1.ApplicationSpec) {
+				disabled := false
+				s.CABundle = &iafv1alpha1.CABundleConfig{Enabled: &disabled}
+			},
+			wantEffect: EffectRestart,
+			wantFields: []string{"caBundle.enabled"},
+		},
+		{
+			name:    "backend TLS",
+			current: imageApp(),
+			update: func(s *iafv1alpha1.ApplicationSpec) {
+				s.BackendTLS = &iafv1alpha1.BackendTLSConfig{Enabled: true}
+			},
+			wantEffect: EffectRestart,
+			wantFields: []string{"backendTLS.enabled"},
+		},
+		{
+			name:    "host aliases and DNS",
+			current: imageApp(),
+			update: func(s *iafv1alpha1.ApplicationSpec) {
+				s.HostAliases = []iafv1alpha1.HostAlias{{IP: "10.20.0.5", Hostnames: []string{"ldap.corp.example.com"}}}
+				s.DNSConfig = &iafv1alpha1.DNSConfig{Searches: []string{"corp.example.com"}}
+			},
+			wantEffect: EffectRestart,
+			wantFields: []string{"hostAliases", "dnsConfig"},
+		},
+		{
+			name:       "image tag",
+			current:    imageApp(),
+			update:     func(s *iafv1alpha1.ApplicationSpec) { s.Image = "nginx:1.26" },
+			wantEffect: EffectRestart,
+			wantFields: []string{"image"},
+		},
+		{
+			name:       "replicas",
+			current:    gitApp(),
+			update:     func(s *iafv1alpha1.ApplicationSpec) { s.Replicas = 3 },
+			wantEffect: EffectScale,
+			wantFields: []string{"replicas"},
+		},
+		{
+			name:       "sticky sessions and host",
+			current:    imageApp(),
+			update:     func(s *iafv1alpha1.ApplicationSpec) { s.StickySessions, s.Host = true, "www.example.com" },
+			wantEffect: EffectInPlace,
+			wantFields: []string{"host", "stickySessions"},
+			warnings:   1,
+		},
+		{
+			name:       "git revision",
+			current:    gitApp(),
+			update:     func(s *iafv1alpha1.ApplicationSpec) { s.Git = &iafv1alpha1.GitSource{URL: s.Git.URL, Revision: "v2"} },
+			wantEffect: EffectRebuild,
+			wantFields: []string{"git.revision"},
+		},
+		{
+			name:       "start command",
+			current:    gitApp(),
+			update:     func(s *iafv1alpha1.ApplicationSpec) { s.Command = []string{"node", "worker.js"} },
+			wantEffect: EffectRestart,
+			wantFields: []string{"command"},
+		},
+		{
+			name:    "monorepo sub path",
+			current: gitApp(),
+			update: func(s *iafv1alpha1.ApplicationSpec) {
+				s.Git = &iafv1alpha1.GitSource{URL: s.Git.URL, Revision: "main", SubPath: "services/api"}
+			},
+			wantEffect: EffectRebuild,
+			wantFields: []string{"git.subPath"},
+		},
+		{
+			name:    "pause branch tracking",
+			current: gitApp(),
+			update: func(s *iafv1alpha1.ApplicationSpec) {
+				s.Git = &iafv1alpha1.GitSource{URL: s.Git.URL, Revision: "main", Track: iafv1alpha1.GitTrackBranch, Paused: true}
+			},
+			wantEffect: EffectRebuild,
+			wantFields: []string{"git.track", "git.paused"},
+		},
+		{
+			name:    "build env of a source app",
+			current: gitApp(),
+			update: func(s *iafv1alpha1.ApplicationSpec) {
+				s.BuildEnv = []iafv1alpha1.EnvVar{{Name: "BP_GO_TARGETS", Value: "./cmd/web"}}
+			},
+			wantEffect: EffectRebuild,
+			wantFields: []string{"buildEnv"},
+		},
+		{
+			name:    "build env of an image app",
+			current: imageApp(),
+			update: func(s *iafv1alpha1.ApplicationSpec) {
+				s.BuildEnv = []iafv1alpha1.EnvVar{{Name: "BP_GO_TARGETS", Value: "./cmd/web"}}
+			},
+			wantEffect: EffectInPlace,
+			wantFields: []string{"buildEnv"},
+		},
+		{
+			name:       "architecture of an image app",
+			current:    imageApp(),
+			update:     func(s *iafv1alpha1.ApplicationSpec) { s.Architecture = iafv1alpha1.ArchitectureARM64 },
+			wantEffect: EffectRestart,
+			wantFields: []string{"architecture"},
+		},
+		{
+			name:       "oauth proxy adds a sidecar",
+			current:    imageApp(),
+			update:     func(s *iafv1alpha1.ApplicationSpec) { s.Authentication = iafv1alpha1.AuthenticationOAuthProxy },
+			wantEffect: EffectRestart,
+			wantFields: []string{"authentication"},
+		},
+		{
+			name:       "basic auth is routing only",
+			current:    imageApp(),
+			update:     func(s *iafv1alpha1.ApplicationSpec) { s.Authentication = iafv1alpha1.AuthenticationBasic },
+			wantEffect: EffectInPlace,
+			wantFields: []string{"authentication"},
+		},
+		{
+			name:       "port",
+			current:    gitApp(),
+			update:     func(s *iafv1alpha1.ApplicationSpec) { s.Port = 8000 },
+			wantEffect: EffectRestart,
+			wantFields: []string{"port"},
+			warnings:   1,
+		},
+		{
+			name:       "suspend",
+			current:    imageApp(),
+			update:     func(s *iafv1alpha1.ApplicationSpec) { s.Suspended = true },
+			wantEffect: EffectScale,
+			wantFields: []string{"suspended"},
+			warnings:   1,
+		},
+		{
+			name:    "source to image",
+			current: gitApp(),
+			update: func(s *iafv1alpha1.ApplicationSpec) {
+				s.Git, s.Image = nil, "org/app:1.0"
+			},
+			wantEffect: EffectRebuild,
+			wantFields: []string{"image", "git.url", "git.revision"},
+			warnings:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proposed := tt.current.DeepCopy()
+			tt.update(&proposed.Spec)
+			plan := PlanUpdate(tt.current, proposed)
+			if plan.Effect != tt.wantEffect {
+				t.Errorf("effect = %q, want %q (%+v)", plan.Effect, tt.wantEffect, plan.Changes)
+			}
+			var fields []string
+			for _, c := range plan.Changes {
+				fields = append(fields, c.Field)
+			}
+			if !slices.Equal(fields, tt.wantFields) {
+				t.Errorf("changed fields = %v, want %v", fields, tt.wantFields)
+			}
+			if len(plan.Warnings) != tt.warnings {
+				t.Errorf("warnings = %v, want %d", plan.Warnings, tt.warnings)
+			}
+			if plan.Summary == "" {
+				t.Error("expected a summary")
+			}
+		})
+	}
+}
+
+func TestPlanUpdate_EnvReportsNamesOnly(t *testing.T) {
+	current := makeTestApp("my-app", "iaf-abc123")
+	current.Spec.Env = []iafv1alpha1.EnvVar{{Name: "KEEP", Value: "1"}, {Name: "DROP", Value: "x"}, {Name: "TOKEN", Value: "old"}}
+	proposed := current.DeepCopy()
+	proposed.Spec.Env = []iafv1alpha1.EnvVar{{Name: "KEEP", Value: "1"}, {Name: "TOKEN", Value: "new"}, {Name: "NEW", Value: "y"}}
+
+	plan := PlanUpdate(current, proposed)
+	if len(plan.Changes) != 1 {
+		t.Fatalf("expected one change, got %+v", plan.Changes)
+	}
+	c := plan.Changes[0]
+	if c.From != nil || c.To != nil {
+		t.Errorf("expected env values to be left out, got from=%v to=%v", c.From, c.To)
+	}
+	if !slices.Equal(c.Added, []string{"NEW"}) || !slices.Equal(c.Removed, []string{"DROP"}) || !slices.Equal(c.Modified, []string{"TOKEN"}) {
+		t.Errorf("unexpected env change %+v", c)
+	}
+}
@@ -0,0 +1,33 @@
+package k8s
+
+import (
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+)
+
+// AppVersion returns the version of app shown to clients. It is the
+// object's generation, which the API server increments on every spec change
+// but not on status updates, so it only moves when someone changes the app.
+func AppVersion(app *iafv1alpha1.Application) int64 {
+	return app.Generation
+}
+
+// VersionConflict is the details of a version_conflict error: the version
+// and spec the application has now, so the caller can merge its change
+// without another read.
+type VersionConflict struct {
+	CurrentVersion int64                       `json:"currentVersion"`
+	Current        iafv1alpha1.ApplicationSpec `json:"current"`
+}
+
+// CheckVersion returns a version_conflict error carrying the current state
+// of app when expected is set and app has moved on from it. An expected
+// version of 0 skips the check.
+func CheckVersion(app *iafv1alpha1.Application, expected int64) error {
+	if expected == 0 || expected == AppVersion(app) {
+		return nil
+	}
+	return apierror.Conflict(apierror.CodeVersionConflict, "application %q is at version %d, not the expected version %d; it was changed since you read it", app.Name, AppVersion(app), expected).
+		WithHint("review the current state in details, reapply your change to it, and retry expecting details.currentVersion").
+		WithDetails(VersionConflict{CurrentVersion: AppVersion(app), Current: app.Spec})
+}
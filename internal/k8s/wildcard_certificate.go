@@ -0,0 +1,92 @@
+package k8s
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// TraefikTLSStoreGVK is the GroupVersionKind for Traefik TLSStore CRs.
+var TraefikTLSStoreGVK = schema.GroupVersionKind{
+	Group:   "traefik.io",
+	Version: "v1alpha1",
+	Kind:    "TLSStore",
+}
+
+const (
+	// WildcardCertificateName names the shared Certificate for the base
+	// domain, and WildcardSecretName the Secret cert-manager stores it in.
+	WildcardCertificateName = "iaf-wildcard"
+	WildcardSecretName      = "iaf-wildcard-tls"
+	// defaultTLSStoreName is the only TLSStore name Traefik reads its
+	// default certificate from.
+	defaultTLSStoreName = "default"
+)
+
+// WildcardTLS is the platform mode where one certificate for
+// *.<base domain>, issued through DNS-01, serves every app under the base
+// domain instead of one Certificate per app. The zero value issues a
+// Certificate per app.
+type WildcardTLS struct {
+	// Namespace holds the wildcard Certificate, its Secret and the Traefik
+	// default TLSStore that serves it.
+	Namespace string
+}
+
+// IsZero reports whether apps get a Certificate each.
+func (w WildcardTLS) IsZero() bool {
+	return w.Namespace == ""
+}
+
+// CoveredByWildcard reports whether the certificate for *.baseDomain is
+// valid for host. A wildcard matches a single label only.
+func CoveredByWildcard(host, baseDomain string) bool {
+	label, ok := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(baseDomain))
+	return ok && label != "" && !strings.Contains(label, ".")
+}
+
+// BuildWildcardCertificate constructs the cert-manager Certificate for
+// *.baseDomain and baseDomain itself. Let's Encrypt only issues wildcards
+// through DNS-01, so issuerName must name a ClusterIssuer with a DNS-01
+// solver.
+func BuildWildcardCertificate(w WildcardTLS, baseDomain, issuerName string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(CertificateGVK)
+	obj.SetName(WildcardCertificateName)
+	obj.SetNamespace(w.Namespace)
+	obj.SetLabels(map[string]string{"app.kubernetes.io/managed-by": "iaf"})
+	obj.Object["spec"] = map[string]any{
+		"secretName": WildcardSecretName,
+		"dnsNames":   []any{"*." + baseDomain, baseDomain},
+		"issuerRef": map[string]any{
+			"name": issuerName,
+			"kind": "ClusterIssuer",
+		},
+	}
+	return obj
+}
+
+// BuildDefaultTLSStore constructs the Traefik TLSStore that makes the
+// wildcard certificate Traefik's default, served to routes without a
+// certificate of their own.
+func BuildDefaultTLSStore(w WildcardTLS) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(TraefikTLSStoreGVK)
+	obj.SetName(defaultTLSStoreName)
+	obj.SetNamespace(w.Namespace)
+	obj.SetLabels(map[string]string{"app.kubernetes.io/managed-by": "iaf"})
+	obj.Object["spec"] = map[string]any{
+		"defaultCertificate": map[string]any{"secretName": WildcardSecretName},
+	}
+	return obj
+}
+
+// UseDefaultCertificate drops the app's own TLS Secret from a route built by
+// BuildIngressRoute, so Traefik serves its default, wildcard certificate.
+// The route stays on the websecure entrypoint.
+func UseDefaultCertificate(route *unstructured.Unstructured) {
+	if _, found, _ := unstructured.NestedMap(route.Object, "spec", "tls"); found {
+		_ = unstructured.SetNestedMap(route.Object, map[string]any{}, "spec", "tls")
+	}
+}
@@ -0,0 +1,61 @@
+package k8s
+
+import (
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCoveredByWildcard(t *testing.T) {
+	for host, want := range map[string]bool{
+		"api.apps.example.com":       true,
+		"API.Apps.Example.com":       true,
+		"a.b.apps.example.com":       false,
+		"apps.example.com":           false,
+		"api.example.com":            false,
+		"api.other-apps.example.com": false,
+	} {
+		if got := CoveredByWildcard(host, "apps.example.com"); got != want {
+			t.Errorf("CoveredByWildcard(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestBuildWildcardCertificate(t *testing.T) {
+	w := WildcardTLS{Namespace: "iaf-system"}
+	cert := BuildWildcardCertificate(w, "apps.example.com", "letsencrypt-dns")
+	if cert.GetNamespace() != "iaf-system" || cert.GetName() != WildcardCertificateName || len(cert.GetOwnerReferences()) != 0 {
+		t.Errorf("unexpected certificate metadata %v", cert.Object["metadata"])
+	}
+	names, _, _ := unstructured.NestedStringSlice(cert.Object, "spec", "dnsNames")
+	if len(names) != 2 || names[0] != "*.apps.example.com" || names[1] != "apps.example.com" {
+		t.Errorf("dnsNames = %v", names)
+	}
+
+	store := BuildDefaultTLSStore(w)
+	secret, _, _ := unstructured.NestedString(store.Object, "spec", "defaultCertificate", "secretName")
+	if store.GetName() != "default" || store.GetNamespace() != "iaf-system" || secret != WildcardSecretName {
+		t.Errorf("unexpected TLSStore %v", store.Object)
+	}
+}
+
+func TestUseDefaultCertificate(t *testing.T) {
+	for _, protocol := range []iafv1alpha1.ApplicationProtocol{iafv1alpha1.ProtocolHTTP, iafv1alpha1.ProtocolTCP} {
+		app := makeTestApp("api", "iaf-abc123")
+		app.Spec.Protocol = protocol
+		route := BuildIngressRoute(app, "apps.example.com", true)
+		UseDefaultCertificate(route)
+		tls, found, _ := unstructured.NestedMap(route.Object, "spec", "tls")
+		if !found || len(tls) != 0 {
+			t.Errorf("%s: expected an empty tls block, got %v", protocol, tls)
+		}
+	}
+
+	// A route without TLS stays without it.
+	route := BuildIngressRoute(makeTestApp("api", "iaf-abc123"), "apps.example.com", false)
+	UseDefaultCertificate(route)
+	if _, found, _ := unstructured.NestedMap(route.Object, "spec", "tls"); found {
+		t.Error("expected no tls block on a plain http route")
+	}
+}
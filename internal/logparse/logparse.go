@@ -0,0 +1,216 @@
+// Package logparse turns application log output into structured entries.
+// Lines following the platform logging standard (JSON Lines with time, level,
+// and msg fields) are parsed; common aliases used by popular logging
+// libraries are accepted. Other lines are kept verbatim.
+package logparse
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Entry is one parsed log line.
+type Entry struct {
+	Time    string `json:"time,omitempty"`
+	Level   string `json:"level,omitempty"`
+	Message string `json:"msg,omitempty"`
+	// Fields holds the remaining JSON fields of a structured line.
+	Fields map[string]any `json:"fields,omitempty"`
+	// Raw is the original line when it is not a JSON object.
+	Raw string `json:"raw,omitempty"`
+}
+
+// Levels in increasing severity. Normalized entry levels are always one of these.
+var Levels = []string{"trace", "debug", "info", "warn", "error", "fatal"}
+
+var levelRank = map[string]int{"trace": 0, "debug": 1, "info": 2, "warn": 3, "error": 4, "fatal": 5}
+
+var levelAliases = map[string]string{
+	"warning":   "warn",
+	"err":       "error",
+	"critical":  "fatal",
+	"crit":      "fatal",
+	"panic":     "fatal",
+	"dpanic":    "fatal",
+	"alert":     "fatal",
+	"emergency": "fatal",
+	"severe":    "error",
+	"fine":      "debug",
+}
+
+var (
+	timeKeys    = []string{"time", "ts", "timestamp", "@timestamp"}
+	levelKeys   = []string{"level", "severity", "levelname", "lvl"}
+	messageKeys = []string{"msg", "message"}
+)
+
+// NormalizeLevel maps a level name to one of Levels. It reports false for
+// unrecognized names.
+func NormalizeLevel(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if alias, ok := levelAliases[s]; ok {
+		s = alias
+	}
+	_, ok := levelRank[s]
+	return s, ok
+}
+
+// Parse converts one log line into an Entry.
+func Parse(line string) Entry {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "{") {
+		var fields map[string]any
+		if err := json.Unmarshal([]byte(trimmed), &fields); err == nil {
+			return fromJSON(fields)
+		}
+	}
+	return Entry{Raw: line, Level: plainTextLevel(line)}
+}
+
+func fromJSON(fields map[string]any) Entry {
+	var e Entry
+	if k, v := take(fields, timeKeys); k != "" {
+		e.Time = formatTime(v)
+	}
+	if k, v := take(fields, levelKeys); k != "" {
+		e.Level = jsonLevel(v)
+	}
+	if k, v := take(fields, messageKeys); k != "" {
+		e.Message = fmt.Sprint(v)
+	}
+	if len(fields) > 0 {
+		e.Fields = fields
+	}
+	return e
+}
+
+// take removes and returns the first of keys present in fields.
+func take(fields map[string]any, keys []string) (string, any) {
+	for _, k := range keys {
+		if v, ok := fields[k]; ok {
+			delete(fields, k)
+			return k, v
+		}
+	}
+	return "", nil
+}
+
+// jsonLevel normalizes a level value, including pino's numeric levels
+// (10 trace … 60 fatal).
+func jsonLevel(v any) string {
+	switch lv := v.(type) {
+	case string:
+		if l, ok := NormalizeLevel(lv); ok {
+			return l
+		}
+		return strings.ToLower(lv)
+	case float64:
+		idx := int(lv)/10 - 1
+		if idx >= 0 && idx < len(Levels) {
+			return Levels[idx]
+		}
+	}
+	return ""
+}
+
+// formatTime renders string timestamps as-is and numeric epochs (seconds or
+// milliseconds) as RFC 3339.
+func formatTime(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		if t > 1e12 {
+			return time.UnixMilli(int64(t)).UTC().Format(time.RFC3339Nano)
+		}
+		sec, frac := math.Modf(t)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC().Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
+
+var (
+	logfmtLevel   = regexp.MustCompile(`\blevel=("?)(\w+)`)
+	upperLevel    = regexp.MustCompile(`\b(TRACE|DEBUG|INFO|WARN|WARNING|ERROR|FATAL|PANIC|CRITICAL)\b`)
+	crashPrefixes = []string{"panic:", "fatal error:", "Traceback (most recent call last)", "Exception in thread", "Unhandled exception"}
+)
+
+// plainTextLevel guesses the level of an unstructured line from a logfmt
+// level= pair, an upper-case level word near the start, or a crash banner.
+func plainTextLevel(line string) string {
+	if m := logfmtLevel.FindStringSubmatch(line); m != nil {
+		if l, ok := NormalizeLevel(m[2]); ok {
+			return l
+		}
+	}
+	head := line
+	if len(head) > 64 {
+		head = head[:64]
+	}
+	if m := upperLevel.FindString(head); m != "" {
+		l, _ := NormalizeLevel(m)
+		return l
+	}
+	for _, p := range crashPrefixes {
+		if strings.HasPrefix(strings.TrimSpace(line), p) {
+			return "error"
+		}
+	}
+	return ""
+}
+
+// Filter selects log entries.
+type Filter struct {
+	// MinLevel keeps entries at or above this normalized level. Entries
+	// without a recognizable level are dropped when it is set.
+	MinLevel string
+	// Pattern, when set, must match the original line.
+	Pattern *regexp.Regexp
+}
+
+// NewFilter validates level and builds a pattern from grep. grep is matched
+// as a case-insensitive substring unless isRegex is true, in which case it is
+// an RE2 regular expression.
+func NewFilter(level, grep string, isRegex bool) (Filter, error) {
+	var f Filter
+	if level != "" {
+		l, ok := NormalizeLevel(level)
+		if !ok {
+			return f, fmt.Errorf("invalid level %q: use one of %s", level, strings.Join(Levels, ", "))
+		}
+		f.MinLevel = l
+	}
+	if grep != "" {
+		if len(grep) > 256 {
+			return f, fmt.Errorf("grep pattern must be at most 256 characters")
+		}
+		expr := "(?i)" + regexp.QuoteMeta(grep)
+		if isRegex {
+			expr = grep
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return f, fmt.Errorf("invalid grep regular expression: %w", err)
+		}
+		f.Pattern = re
+	}
+	return f, nil
+}
+
+// Match reports whether the entry parsed from line passes the filter.
+func (f Filter) Match(e Entry, line string) bool {
+	if f.MinLevel != "" {
+		rank, ok := levelRank[e.Level]
+		if !ok || rank < levelRank[f.MinLevel] {
+			return false
+		}
+	}
+	if f.Pattern != nil && !f.Pattern.MatchString(line) {
+		return false
+	}
+	return true
+}
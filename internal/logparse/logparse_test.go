@@ -0,0 +1,126 @@
+package logparse
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		wantLevel string
+		wantMsg   string
+		wantTime  string
+		wantRaw   bool
+		wantField string
+	}{
+		{
+			name:      "logging standard",
+			line:      `{"time":"2026-01-02T03:04:05Z","level":"error","msg":"db timeout","req_id":"abc"}`,
+			wantLevel: "error", wantMsg: "db timeout", wantTime: "2026-01-02T03:04:05Z", wantField: "req_id",
+		},
+		{
+			name:      "pino numeric level and epoch millis",
+			line:      `{"level":40,"time":1767323045000,"msg":"slow request"}`,
+			wantLevel: "warn", wantMsg: "slow request", wantTime: "2026-01-02T03:04:05Z",
+		},
+		{
+			name:      "aliases",
+			line:      `{"@timestamp":"t","severity":"WARNING","message":"disk low"}`,
+			wantLevel: "warn", wantMsg: "disk low", wantTime: "t",
+		},
+		{
+			name:      "python levelname",
+			line:      `{"levelname":"CRITICAL","message":"boom"}`,
+			wantLevel: "fatal", wantMsg: "boom",
+		},
+		{name: "plain text upper-case level", line: "2026-01-02 03:04:05 ERROR failed to connect", wantLevel: "error", wantRaw: true},
+		{name: "logfmt", line: `ts=1 level=warn msg="retrying"`, wantLevel: "warn", wantRaw: true},
+		{name: "go panic", line: "panic: runtime error: index out of range", wantLevel: "error", wantRaw: true},
+		{name: "python traceback", line: "Traceback (most recent call last):", wantLevel: "error", wantRaw: true},
+		{name: "lower-case word is not a level", line: "updated user info", wantRaw: true},
+		{name: "malformed JSON", line: `{"level":"error"`, wantLevel: "", wantRaw: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Parse(tt.line)
+			if e.Level != tt.wantLevel {
+				t.Errorf("Level = %q, want %q", e.Level, tt.wantLevel)
+			}
+			if e.Message != tt.wantMsg {
+				t.Errorf("Message = %q, want %q", e.Message, tt.wantMsg)
+			}
+			if e.Time != tt.wantTime {
+				t.Errorf("Time = %q, want %q", e.Time, tt.wantTime)
+			}
+			if (e.Raw != "") != tt.wantRaw {
+				t.Errorf("Raw = %q, want raw %v", e.Raw, tt.wantRaw)
+			}
+			if tt.wantField != "" {
+				if _, ok := e.Fields[tt.wantField]; !ok {
+					t.Errorf("Fields = %v, want key %q", e.Fields, tt.wantField)
+				}
+			}
+			for _, k := range []string{"time", "level", "msg"} {
+				if _, ok := e.Fields[k]; ok {
+					t.Errorf("Fields still contains promoted key %q", k)
+				}
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	lines := []string{
+		`{"level":"debug","msg":"cache miss"}`,
+		`{"level":"info","msg":"GET /health"}`,
+		`{"level":"error","msg":"Payment declined","order":42}`,
+		`{"level":"fatal","msg":"out of memory"}`,
+		`no level here`,
+	}
+	tests := []struct {
+		name    string
+		level   string
+		grep    string
+		isRegex bool
+		want    int
+	}{
+		{name: "no filter", want: 5},
+		{name: "min level error", level: "error", want: 2},
+		{name: "min level warning alias", level: "WARNING", want: 2},
+		{name: "grep is case-insensitive", grep: "payment", want: 1},
+		{name: "grep matches JSON fields", grep: `"order":42`, want: 1},
+		{name: "grep treats metacharacters literally", grep: "/health", want: 1},
+		{name: "regex", grep: `^\{"level":"(info|debug)"`, isRegex: true, want: 2},
+		{name: "level and grep combined", level: "info", grep: "memory", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFilter(tt.level, tt.grep, tt.isRegex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := 0
+			for _, line := range lines {
+				if f.Match(Parse(line), line) {
+					got++
+				}
+			}
+			if got != tt.want {
+				t.Errorf("matched %d lines, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFilter_Invalid(t *testing.T) {
+	if _, err := NewFilter("loud", "", false); err == nil {
+		t.Error("expected error for unknown level")
+	}
+	if _, err := NewFilter("", "(", true); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+	if _, err := NewFilter("", "(", false); err != nil {
+		t.Errorf("plain-text grep should accept metacharacters: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+// Package maintenance is the platform's read-only switch. While an operator
+// has maintenance on, the API and MCP servers refuse every call that would
+// change something with code maintenance, so an upgrade can run against a
+// frozen platform, and keep serving status, list and log calls.
+package maintenance
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/dlapiduz/iaf/internal/apierror"
+)
+
+// Mode describes maintenance that is on. A nil *Mode means the platform is
+// not in maintenance.
+type Mode struct {
+	// Until is when the maintenance is expected to end. Zero when the
+	// operator did not say.
+	Until time.Time
+}
+
+// Active reports whether the platform is in maintenance.
+func (m *Mode) Active() bool {
+	return m != nil
+}
+
+// Err returns the maintenance error for a refused change, or nil when the
+// platform is not in maintenance. Its details carry the expected end time.
+func (m *Mode) Err() *apierror.Error {
+	if m == nil {
+		return nil
+	}
+	if m.Until.IsZero() {
+		return apierror.Platform(apierror.CodeMaintenance, true, "the platform is in maintenance and read-only").
+			WithHint("status, list and log calls keep working; retry changes once maintenance is over")
+	}
+	until := m.Until.UTC().Format(time.RFC3339)
+	return apierror.Platform(apierror.CodeMaintenance, true, "the platform is in maintenance and read-only until about %s", until).
+		WithHint("status, list and log calls keep working; retry changes after " + until).
+		WithDetails(map[string]string{"until": until})
+}
+
+// RetryAfter returns the seconds until the expected end of the maintenance
+// at now, at least 1, for a Retry-After header. It returns 0 when the end
+// is unknown.
+func (m *Mode) RetryAfter(now time.Time) int {
+	if m == nil || m.Until.IsZero() {
+		return 0
+	}
+	return int(max(1, math.Ceil(m.Until.Sub(now).Seconds())))
+}
+
+// Parse returns the maintenance mode for the IAF_MAINTENANCE and
+// IAF_MAINTENANCE_UNTIL settings: nil when on is false, and an error when
+// until is set but not an RFC 3339 time.
+func Parse(on bool, until string) (*Mode, error) {
+	if !on {
+		return nil, nil
+	}
+	m := &Mode{}
+	if until != "" {
+		t, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return nil, fmt.Errorf("IAF_MAINTENANCE_UNTIL must be an RFC 3339 time such as 2026-05-01T18:00:00Z: %w", err)
+		}
+		m.Until = t
+	}
+	return m, nil
+}
@@ -0,0 +1,45 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	if m, err := Parse(false, "not a time"); m != nil || err != nil {
+		t.Errorf("Parse off = %v, %v; want nil, nil", m, err)
+	}
+	if _, err := Parse(true, "tomorrow"); err == nil {
+		t.Error("expected an invalid end time to be rejected")
+	}
+	m, err := Parse(true, "2026-05-01T18:00:00Z")
+	if err != nil || !m.Active() || !m.Until.Equal(time.Date(2026, 5, 1, 18, 0, 0, 0, time.UTC)) {
+		t.Errorf("Parse = %+v, %v", m, err)
+	}
+}
+
+func TestMode(t *testing.T) {
+	var off *Mode
+	if off.Active() || off.Err() != nil || off.RetryAfter(time.Now()) != 0 {
+		t.Error("expected a nil mode to be off")
+	}
+
+	until := time.Date(2026, 5, 1, 18, 0, 0, 0, time.UTC)
+	m := &Mode{Until: until}
+	err := m.Err()
+	if err == nil || err.Code != "maintenance" || !err.Retryable {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	if details, _ := err.Details.(map[string]string); details["until"] != "2026-05-01T18:00:00Z" {
+		t.Errorf("details = %v", err.Details)
+	}
+	if got := m.RetryAfter(until.Add(-90 * time.Second)); got != 90 {
+		t.Errorf("RetryAfter = %d, want 90", got)
+	}
+	if got := m.RetryAfter(until.Add(time.Hour)); got != 1 {
+		t.Errorf("RetryAfter after the expected end = %d, want 1", got)
+	}
+	if got := (&Mode{}).RetryAfter(until); got != 0 {
+		t.Errorf("RetryAfter without an end = %d, want 0", got)
+	}
+}
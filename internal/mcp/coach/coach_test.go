@@ -2,6 +2,8 @@ package coach_test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -11,12 +13,17 @@ import (
 )
 
 func setupCoachClient(t *testing.T) *gomcp.ClientSession {
+	t.Helper()
+	return setupCoachClientWithStandards(t, orgstandards.New("", nil))
+}
+
+func setupCoachClientWithStandards(t *testing.T, standards *orgstandards.Loader) *gomcp.ClientSession {
 	t.Helper()
 	ctx := context.Background()
 
 	deps := &coach.Dependencies{
 		BaseDomain:   "test.example.com",
-		OrgStandards: orgstandards.New("", nil),
+		OrgStandards: standards,
 	}
 
 	server := coach.NewServer(deps)
@@ -126,6 +133,9 @@ func TestCoachLanguageGuide(t *testing.T) {
 			if !strings.Contains(text, tc.wantLang) {
 				t.Errorf("expected %q in response, got: %s", tc.wantLang, text[:200])
 			}
+			if !strings.Contains(text, "## Outbound Proxy") {
+				t.Error("expected outbound proxy guidance")
+			}
 		})
 	}
 }
@@ -143,6 +153,31 @@ func TestCoachLanguageGuideUnknown(t *testing.T) {
 	}
 }
 
+func TestCoachLanguageGuidePackageMirror(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "standards.yaml")
+	if err := os.WriteFile(path, []byte("packageMirrors:\n  npm: https://nexus.corp.example/repository/npm/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cs := setupCoachClientWithStandards(t, orgstandards.New(path, nil))
+
+	for lang, want := range map[string]bool{"nodejs": true, "python": false} {
+		res, err := cs.GetPrompt(context.Background(), &gomcp.GetPromptParams{
+			Name:      "language-guide",
+			Arguments: map[string]string{"language": lang},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		text := res.Messages[0].Content.(*gomcp.TextContent).Text
+		if got := strings.Contains(text, "## Package Mirror"); got != want {
+			t.Errorf("%s: package mirror section present = %v, want %v", lang, got, want)
+		}
+		if want && !strings.Contains(text, "registry=https://nexus.corp.example/repository/npm/") {
+			t.Errorf("%s: expected .npmrc instructions for the mirror, got: %s", lang, text)
+		}
+	}
+}
+
 func TestCoachLoggingGuide(t *testing.T) {
 	cs := setupCoachClient(t)
 	ctx := context.Background()
@@ -192,6 +227,33 @@ func TestCoachOrgStandards(t *testing.T) {
 	}
 }
 
+func TestCoachOrgStandards_HidesDependencyWaivers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "standards.yaml")
+	content := `
+dependencies:
+  deny:
+    - {ecosystem: npm, package: left-pad}
+  waivers:
+    - {ecosystem: npm, package: left-pad, namespace: iaf-other-tenant, reason: migration}
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cs := setupCoachClientWithStandards(t, orgstandards.New(path, nil))
+
+	res, err := cs.ReadResource(context.Background(), &gomcp.ReadResourceParams{URI: "iaf://org/coding-standards"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := res.Contents[0].Text
+	if !strings.Contains(text, "left-pad") {
+		t.Errorf("expected the deny list to be served, got %s", text)
+	}
+	if strings.Contains(text, "iaf-other-tenant") {
+		t.Errorf("expected waivers naming other tenants to be hidden, got %s", text)
+	}
+}
+
 func TestCoachLoggingStandards(t *testing.T) {
 	cs := setupCoachClient(t)
 	ctx := context.Background()
@@ -303,6 +365,9 @@ func TestCoachGitHubStandards(t *testing.T) {
 	if !strings.Contains(res.Contents[0].Text, "defaultBranch") {
 		t.Errorf("expected 'defaultBranch' in github-standards JSON, got: %s", res.Contents[0].Text[:200])
 	}
+	if !strings.Contains(res.Contents[0].Text, `"repoTemplates"`) {
+		t.Error("expected repoTemplates in github-standards JSON")
+	}
 }
 
 func TestCoachLicenseGuide(t *testing.T) {
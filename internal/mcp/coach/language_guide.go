@@ -14,6 +14,7 @@ type languageGuide struct {
 	requiredFiles  string
 	example        string
 	bestPractices  string
+	proxyNotes     string
 }
 
 var languageGuides = map[string]languageGuide{
@@ -53,6 +54,7 @@ func main() {
 - Use Go modules (go.mod) — GOPATH mode is not supported.
 - Provide a /health endpoint for readiness probes.
 - The buildpack compiles statically; CGO is disabled by default.`,
+		proxyNotes: `net/http honours HTTP_PROXY, HTTPS_PROXY and NO_PROXY through http.ProxyFromEnvironment, which the default transport uses. Custom transports must set Proxy: http.ProxyFromEnvironment. Module downloads at build time use the proxy automatically.`,
 	},
 	"nodejs": {
 		buildpackID:    "paketo-buildpacks/nodejs",
@@ -87,6 +89,7 @@ app.listen(port, () => console.log('Listening on port ' + port));`,
 - Do not include node_modules in uploaded source.
 - Provide a /health endpoint for readiness probes.
 - For frontend apps, start from a scaffold: read iaf://scaffold/nextjs (Next.js + Tailwind) or iaf://scaffold/html (plain HTML + Express).`,
+		proxyNotes: `The built-in http, https and fetch ignore proxy variables. Set NODE_USE_ENV_PROXY=1 on Node.js releases that support it, or use undici's EnvHttpProxyAgent (setGlobalDispatcher(new EnvHttpProxyAgent())). npm uses the proxy at build time automatically.`,
 	},
 	"python": {
 		buildpackID:    "paketo-buildpacks/python",
@@ -121,6 +124,7 @@ if __name__ == "__main__":
 - Bind to 0.0.0.0, not localhost, so the container is reachable.
 - Include requirements.txt with pinned versions for reproducible builds.
 - Provide a /health endpoint for readiness probes.`,
+		proxyNotes: `requests, httpx, urllib and pip honour HTTP_PROXY, HTTPS_PROXY and NO_PROXY. aiohttp needs trust_env=True on the ClientSession.`,
 	},
 	"java": {
 		buildpackID:    "paketo-buildpacks/java",
@@ -172,6 +176,7 @@ public class Application {
 - Spring Boot Actuator provides /actuator/health automatically — recommended for readiness probes.
 - The buildpack applies memory calculator settings for JVM tuning.
 - Executable JARs are preferred; the buildpack handles layer extraction.`,
+		proxyNotes: `The JVM ignores proxy environment variables. Pass -Dhttps.proxyHost, -Dhttps.proxyPort and -Dhttp.nonProxyHosts through JAVA_TOOL_OPTIONS in env, or configure the HTTP client's proxy from HTTPS_PROXY. Maven and Gradle need the proxy in settings.xml or gradle.properties to download dependencies.`,
 	},
 	"ruby": {
 		buildpackID:    "paketo-buildpacks/ruby",
@@ -208,6 +213,7 @@ end`,
 - Bind to 0.0.0.0 so the container is reachable.
 - Use config.ru for Rack-based apps or a Procfile for custom start commands.
 - Provide a /health endpoint for readiness probes.`,
+		proxyNotes: `Net::HTTP, Faraday and Bundler honour http_proxy, https_proxy and no_proxy.`,
 	},
 }
 
@@ -260,6 +266,15 @@ After writing your code, use push_code to upload the source, then deploy_app to
 			guide.example,
 			guide.bestPractices,
 			deps.BaseDomain)
+		text += fmt.Sprintf(`
+## Outbound Proxy
+When the iaf://platform resource reports proxy: true, builds and pods get HTTP_PROXY, HTTPS_PROXY and NO_PROXY. Calls to other apps and cluster services bypass the proxy. %s
+`, guide.proxyNotes)
+		if deps.OrgStandards != nil {
+			if mirror := deps.OrgStandards.Get().PackageMirrors.ForLanguage(canonical); mirror != "" {
+				text += packageMirrorSection(canonical, mirror)
+			}
+		}
 
 		return &gomcp.GetPromptResult{
 			Description: fmt.Sprintf("Buildpack-compatible %s application guide for IAF.", canonical),
@@ -272,3 +287,27 @@ After writing your code, use push_code to upload the source, then deploy_app to
 		}, nil
 	})
 }
+
+// packageMirrorSection tells agents how to install a language's packages
+// through the organisation's internal mirror, for clusters without internet
+// access. mirror has been validated by orgstandards.
+func packageMirrorSection(lang, mirror string) string {
+	var how string
+	switch lang {
+	case "go":
+		how = fmt.Sprintf("Pass build_env [{name: GOPROXY, value: %q}] to push_code or deploy_app. Also set GOSUMDB=off unless the proxy serves the checksum database.", mirror)
+	case "nodejs":
+		how = fmt.Sprintf("Add an .npmrc with `registry=%s` next to package.json, or pass build_env [{name: NPM_CONFIG_REGISTRY, value: %q}] to push_code or deploy_app.", mirror, mirror)
+	case "python":
+		how = fmt.Sprintf("Pass build_env [{name: PIP_INDEX_URL, value: %q}] to push_code or deploy_app.", mirror)
+	case "java":
+		how = fmt.Sprintf("Declare %s as the only repository and plugin repository in pom.xml (<repositories>, <pluginRepositories>) or build.gradle (repositories { maven { url '%s' } }).", mirror, mirror)
+	case "ruby":
+		how = fmt.Sprintf("Use `source %q` in the Gemfile instead of https://rubygems.org.", mirror)
+	}
+	return fmt.Sprintf(`
+## Package Mirror
+The organisation installs %s packages from an internal mirror: %s
+Builds cannot reach the public registry. %s
+`, lang, mirror, how)
+}
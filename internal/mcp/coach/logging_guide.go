@@ -251,7 +251,7 @@ When OTel tracing is enabled, extract `+"`trace_id`"+` and `+"`span_id`"+` from
 - Kubernetes captures stdout automatically; Grafana Alloy ships it to Loki.
 
 ## Verify
-After deploying, run `+"`app_logs <your-app>`"+` to confirm structured JSON log lines are appearing.
+After deploying, run `+"`app_logs <your-app>`"+` to confirm structured JSON log lines are appearing. When the platform has Grafana configured, `+"`app_status`"+` also returns a `+"`logExploreUrl`"+` for searching older logs.
 
 ## Full Standard
 Read `+"`iaf://org/logging-standards`"+` for the complete machine-readable standard.
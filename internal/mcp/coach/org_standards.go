@@ -23,7 +23,10 @@ func RegisterOrgStandards(server *gomcp.Server, deps *Dependencies) {
 		Description: "Organisation coding standards — health-check path, logging format, env-var naming, approved libraries, and per-language best practices.",
 		MIMEType:    "application/json",
 	}, func(ctx context.Context, req *gomcp.ReadResourceRequest) (*gomcp.ReadResourceResult, error) {
-		data, err := json.MarshalIndent(loader.Get(), "", "  ")
+		// Waivers name other tenants' namespaces and apps.
+		standards := *loader.Get()
+		standards.Dependencies.Waivers = nil
+		data, err := json.MarshalIndent(standards, "", "  ")
 		if err != nil {
 			return nil, fmt.Errorf("marshaling org standards: %w", err)
 		}
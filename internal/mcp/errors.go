@@ -0,0 +1,29 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/dlapiduz/iaf/internal/apierror"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// toolErrors is server middleware that replaces the text of an error a tool
+// handler returned with its classified JSON form, {"error", "code",
+// "category", "retryable", "hint"}, the same body the REST API returns.
+// Agents branch on code and retryable instead of parsing the message.
+func toolErrors(next gomcp.MethodHandler) gomcp.MethodHandler {
+	return func(ctx context.Context, method string, req gomcp.Request) (gomcp.Result, error) {
+		res, err := next(ctx, method, req)
+		if err != nil || method != "tools/call" {
+			return res, err
+		}
+		if result, ok := res.(*gomcp.CallToolResult); ok && result.IsError {
+			if toolErr := result.GetError(); toolErr != nil {
+				text, _ := json.MarshalIndent(apierror.From(toolErr).Response(), "", "  ")
+				result.Content = []gomcp.Content{&gomcp.TextContent{Text: string(text)}}
+			}
+		}
+		return res, nil
+	}
+}
@@ -2,11 +2,63 @@ package prompts
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/dlapiduz/iaf/internal/mcp/tools"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+// sourceHostingSection describes where application code and dependencies
+// come from: GitHub when online, internal mirrors on an air-gapped platform.
+func sourceHostingSection(deps *tools.Dependencies) string {
+	if deps.Offline {
+		return `## Air-Gapped Platform
+This platform has no internet access. Nothing may be fetched from public hosts:
+- Deploy images from your internal registry; images on Docker Hub, ghcr.io, quay.io and other public registries cannot be pulled.
+- Use git URLs on your internal git server. GitHub tools are not available.
+- Install packages through the internal mirrors listed under ` + "`packageMirrors`" + ` in ` + "`iaf://org/coding-standards`" + ` on the coach server; the ` + "`language-guide`" + ` prompt shows how to configure each language.
+
+`
+	}
+	return `## Git-Based Deployment with GitHub
+When your code lives in a GitHub repository:
+- Call ` + "`setup_github_repo`" + ` to create the repo, apply branch protection, and commit a CI template.
+- Read the ` + "`github-guide`" + ` prompt for branch naming, commit format, PR, and review workflow.
+- Read ` + "`iaf://org/github-standards`" + ` for the machine-readable GitHub standards document.
+- Use ` + "`deploy_app`" + ` with ` + "`git_url`" + ` set to the clone URL returned by ` + "`setup_github_repo`" + `.
+
+`
+}
+
+// toolsSection lists the tools registered on the server by category, from
+// deps.Capabilities.
+func toolsSection(deps *tools.Dependencies) string {
+	list := deps.Capabilities.List()
+	if len(list) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("## Available Tools\nEvery tool except `register` needs your `session_id`.\n")
+	var category tools.Category
+	for _, c := range list {
+		if c.Category != category {
+			category = c.Category
+			fmt.Fprintf(&sb, "\n### %s\n", category.Title())
+		}
+		fmt.Fprintf(&sb, "- `%s` — %s.", c.Name, c.Summary)
+		if len(c.Preconditions) > 0 {
+			fmt.Fprintf(&sb, " Requires: %s.", strings.Join(c.Preconditions, "; "))
+		}
+		for _, ex := range c.Examples {
+			fmt.Fprintf(&sb, " Example: `%s`", ex)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
 // RegisterDeployGuide registers the deploy-guide prompt that provides
 // comprehensive deployment workflow guidance.
 func RegisterDeployGuide(server *gomcp.Server, deps *tools.Dependencies) {
@@ -44,6 +96,13 @@ Use the push_code tool to upload source files as a tarball. The platform stores
 - Ideal for agent-generated code that isn't in a git repository.
 - Push code first, then deploy — the platform handles the rest.
 
+## Start Command
+Built images start their "web" process, and pre-built images their entrypoint.
+- For git and push_code builds, declare start commands in a Procfile at the source root (e.g. ` + "`web: node server.js`" + `): it is versioned with the code, and buildpacks use it for every build.
+- Use ` + "`command`" + ` and ` + "`args`" + ` on ` + "`deploy_app`" + ` when you cannot change the source or image, such as a worker from a shared image (` + "`command: [\"node\", \"worker.js\"]`" + `). Each item is one argument; no shell is added, so use ` + "`[\"sh\", \"-c\", \"...\"]`" + ` for pipes or variable expansion.
+- ` + "`args`" + ` alone passes arguments to the image entrypoint or web process.
+- A worker that listens on no port still runs; its URL just does not answer.
+
 ## Application Lifecycle Phases
 1. **Pending** — Application CR created, awaiting processing.
 2. **Building** — kpack is building the source into a container image (git/blob sources only).
@@ -75,6 +134,11 @@ For detailed secure coding practices: read the ` + "`security-guide`" + ` prompt
 - Set "replicas" field to scale horizontally.
 - The platform manages the Kubernetes Deployment; pods are spread across available nodes.
 
+## Graceful Shutdown
+- During rollouts, scale-downs and deletion, the platform keeps a stopping pod serving for 5 seconds while traffic is moved away, then sends SIGTERM and allows 30 seconds in total before killing it.
+- Handle SIGTERM: stop accepting new connections, finish in-flight requests, then exit. Apps that exit immediately on SIGTERM can drop requests.
+- Tune it with "shutdown.gracePeriodSeconds" (1-600) and "shutdown.preStopDelaySeconds" (0-60, less than the grace period) on the Application spec.
+
 ## Coding Standards
 Before writing any code, read the org-level coding standards:
 - Prompt: ` + "`coding-guide`" + ` (accepts optional ` + "`language`" + ` argument) — markdown guide merging platform and org standards
@@ -84,19 +148,18 @@ Before writing any code, read the org-level coding standards:
 To clone a private git repository, you must first store a credential:
 1. Call ` + "`add_git_credential`" + ` with your session_id, a name, the type (` + "`basic-auth`" + ` or ` + "`ssh`" + `), and the git server URL.
    - For ` + "`basic-auth`" + `: provide ` + "`git_server_url`" + ` as ` + "`https://github.com`" + ` (or your server), plus ` + "`username`" + ` and ` + "`password`" + ` (or a personal access token).
-   - For ` + "`ssh`" + `: provide ` + "`git_server_url`" + ` as ` + "`git@github.com`" + ` and ` + "`private_key`" + ` as your PEM-encoded SSH private key.
+   - For ` + "`ssh`" + `: provide ` + "`git_server_url`" + ` as ` + "`git@github.com`" + ` and ` + "`private_key`" + ` as your PEM-encoded SSH private key. For a self-hosted server such as GitLab or Gitea, also pass its host keys as ` + "`known_hosts`" + ` (the output of ` + "`ssh-keyscan <host>`" + `) unless the platform already trusts it; the tool warns when no host keys are stored.
 2. Pass the credential name as ` + "`git_credential`" + ` when calling ` + "`deploy_app`" + `.
-3. To rotate a credential, call ` + "`delete_git_credential`" + ` then ` + "`add_git_credential`" + ` again.
+3. To rotate a credential, call ` + "`update_git_credential`" + ` with the new password or key; builds keep working throughout.
 4. Credential material is never returned in any tool output — only name, type, and server URL are shown.
 
-## Git-Based Deployment with GitHub
-When your code lives in a GitHub repository:
-- Call ` + "`setup_github_repo`" + ` to create the repo, apply branch protection, and commit a CI template.
-- Read the ` + "`github-guide`" + ` prompt for branch naming, commit format, PR, and review workflow.
-- Read ` + "`iaf://org/github-standards`" + ` for the machine-readable GitHub standards document.
-- Use ` + "`deploy_app`" + ` with ` + "`git_url`" + ` set to the clone URL returned by ` + "`setup_github_repo`" + `.
+## Private Container Registries
+To deploy a private image, or build from a private base image, store a registry credential:
+1. Call ` + "`add_registry_credential`" + ` with your session_id, a name, ` + "`registry_server`" + ` (e.g. ` + "`ghcr.io`" + `, no https://), ` + "`username`" + ` and ` + "`password`" + ` (an access token).
+2. Builds pick it up automatically. To run a private image, pass the credential name as ` + "`registry_credential`" + ` when calling ` + "`deploy_app`" + `.
+3. A credential cannot be deleted while an app still uses it.
 
-## Persistent Data
+` + sourceHostingSection(deps) + toolsSection(deps) + `## Persistent Data
 
 **Do NOT deploy databases as Applications** (e.g. do not use a postgres Docker image as an Application spec). Use ` + "`provision_service`" + ` instead — it provisions a properly managed, isolated PostgreSQL database via CloudNativePG.
 
@@ -125,10 +188,10 @@ The platform injects ` + "`OTEL_EXPORTER_OTLP_ENDPOINT`" + ` and ` + "`OTEL_SERV
 1. Call ` + "`register`" + ` to get a session_id.
 2. Read the ` + "`coding-guide`" + ` prompt to understand org coding standards.
 3. Read the ` + "`language-guide`" + ` prompt for your target language to understand buildpack requirements.
-4. Write buildpack-compatible code following org standards (correct files, entry points, dependency manifests).
+4. Write buildpack-compatible code following org standards (correct files, entry points, dependency manifests), then run standards_check on the files and fix any failing rule.
 5. Use push_code to upload source or provide a git URL (always include session_id).
 6. Use deploy_app to create the Application CR (always include session_id).
-7. Use app_status to monitor build and deployment progress (always include session_id).
+7. Use app_status to monitor build and deployment progress (always include session_id). If your client supports resource subscriptions, subscribe to iaf://apps/<app-name>?session_id=<id> instead and re-read it when notified.
 8. Use app_logs to debug any issues (always include session_id).
 `
 
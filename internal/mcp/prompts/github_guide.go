@@ -38,7 +38,11 @@ func RegisterGitHubGuide(server *gomcp.Server, deps *tools.Dependencies) {
 		sb.WriteString("```\n")
 		sb.WriteString("setup_github_repo session_id=<your-session> repo_name=<name> visibility=private\n")
 		sb.WriteString("```\n\n")
-		sb.WriteString("Returns `clone_url` — use this with `deploy_app` to deploy from Git.\n\n")
+		sb.WriteString("Add `templates` to seed repo conventions from the platform's templates as well — `codeowners` (with `owners`), `pull_request_template`, `issue_templates`, `dependabot`, `license`, or `all`:\n\n")
+		sb.WriteString("```\n")
+		sb.WriteString("setup_github_repo session_id=<your-session> repo_name=<name> templates=[\"codeowners\",\"pull_request_template\",\"dependabot\"] owners=[\"@<org>/<team>\"]\n")
+		sb.WriteString("```\n\n")
+		sb.WriteString("Returns `clone_url` — use this with `deploy_app` to deploy from Git — and `files`, the commit result of each seeded file. Check `warnings` for anything that was not applied.\n\n")
 
 		sb.WriteString("## Step 2: Branch Naming\n\n")
 		sb.WriteString("Follow the org convention: `<type>/<slug>`\n\n")
@@ -66,7 +70,8 @@ func RegisterGitHubGuide(server *gomcp.Server, deps *tools.Dependencies) {
 			sb.WriteString("## Step 4: Human Review\n\n")
 			sb.WriteString("- Open a PR from your feature branch to `main`.\n")
 			sb.WriteString("- Assign a human reviewer — 1 approving review is required before merge.\n")
-			sb.WriteString("- CI must pass (`CI / ci` check) before the PR can be merged.\n\n")
+			sb.WriteString("- CI must pass (`CI / ci` check) before the PR can be merged.\n")
+			sb.WriteString("- Give the reviewer a running copy: once the app is deployed from git, call `create_preview session_id=<your-session> name=<app-name> pr_number=<n> git_revision=<branch>` and put the preview URL in the PR description. The preview (`<app-name>-pr-<n>`) is deleted automatically when the PR is closed or merged.\n\n")
 		default: // solo-agent
 			sb.WriteString("## Step 4: Solo-Agent Workflow\n\n")
 			sb.WriteString("- No PR reviews required — push directly to `main` or merge your own PR.\n")
@@ -78,7 +83,7 @@ func RegisterGitHubGuide(server *gomcp.Server, deps *tools.Dependencies) {
 		sb.WriteString("```\n")
 		sb.WriteString("deploy_app session_id=<your-session> name=<app-name> git_url=<clone_url>\n")
 		sb.WriteString("```\n\n")
-		sb.WriteString("IAF uses kpack to build the image automatically from the `main` branch.\n\n")
+		sb.WriteString("IAF uses kpack to build the image from the `main` branch and keeps the app on that commit. To deploy every commit merged to a branch, e.g. a staging app on `develop`, add `git_revision=<branch> git_track=branch`; `set_auto_deploy` pauses or resumes that.\n\n")
 
 		sb.WriteString("## Next Steps\n\n")
 		sb.WriteString("- Read `iaf://org/github-standards` for the machine-readable standards document.\n")
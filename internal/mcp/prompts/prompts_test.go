@@ -2,13 +2,21 @@ package prompts_test
 
 import (
 	"context"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
 	iafgithub "github.com/dlapiduz/iaf/internal/github"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
 	"github.com/dlapiduz/iaf/internal/mcp/prompts"
 	"github.com/dlapiduz/iaf/internal/mcp/tools"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func connectServer(t *testing.T, ctx context.Context, server *gomcp.Server) *gomcp.ClientSession {
@@ -129,6 +137,54 @@ func TestListPrompts(t *testing.T) {
 	}
 }
 
+func TestDeployGuide_MentionsGracefulShutdown(t *testing.T) {
+	cs := setupServer(t)
+
+	res, err := cs.GetPrompt(context.Background(), &gomcp.GetPromptParams{Name: "deploy-guide"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := res.Messages[0].Content.(*gomcp.TextContent).Text
+	for _, want := range []string{"SIGTERM", "shutdown.gracePeriodSeconds", "shutdown.preStopDelaySeconds"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("deploy-guide should mention %q", want)
+		}
+	}
+}
+
+func TestDeployGuide_MentionsStartCommand(t *testing.T) {
+	cs := setupServer(t)
+
+	res, err := cs.GetPrompt(context.Background(), &gomcp.GetPromptParams{Name: "deploy-guide"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := res.Messages[0].Content.(*gomcp.TextContent).Text
+	for _, want := range []string{"## Start Command", "Procfile", "`command`", "`args`"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("deploy-guide should mention %q", want)
+		}
+	}
+}
+
+func TestDeployGuide_Offline(t *testing.T) {
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	prompts.RegisterDeployGuide(server, &tools.Dependencies{BaseDomain: "test.example.com", Offline: true})
+	cs := connectServer(t, context.Background(), server)
+
+	res, err := cs.GetPrompt(context.Background(), &gomcp.GetPromptParams{Name: "deploy-guide"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := res.Messages[0].Content.(*gomcp.TextContent).Text
+	if !strings.Contains(text, "## Air-Gapped Platform") || !strings.Contains(text, "packageMirrors") {
+		t.Error("expected the air-gapped section in offline mode")
+	}
+	if strings.Contains(text, "setup_github_repo") {
+		t.Error("expected no GitHub workflow in offline mode")
+	}
+}
+
 func TestDeployGuide_MentionsCodingGuide(t *testing.T) {
 	cs := setupServer(t)
 	ctx := context.Background()
@@ -166,6 +222,23 @@ func TestDeployGuide_MentionsObservability(t *testing.T) {
 	}
 }
 
+func TestServicesGuide_MentionsMigrations(t *testing.T) {
+	cs := setupServer(t)
+	ctx := context.Background()
+
+	res, err := cs.GetPrompt(ctx, &gomcp.GetPromptParams{Name: "services-guide"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := res.Messages[0].Content.(*gomcp.TextContent).Text
+	for _, ref := range []string{"run_migration", "migration_status", "release_command", "Blue/green safe migrations"} {
+		if !strings.Contains(text, ref) {
+			t.Errorf("services-guide should reference %q", ref)
+		}
+	}
+}
+
 func TestGitHubGuide_DefaultWorkflow(t *testing.T) {
 	cs := setupGitHubPromptServer(t)
 	ctx := context.Background()
@@ -233,6 +306,9 @@ func TestGitHubGuide_HumanReviewWorkflow(t *testing.T) {
 	if !strings.Contains(text, "human") {
 		t.Error("expected 'human' in human-review guide text")
 	}
+	if !strings.Contains(text, "create_preview") {
+		t.Error("expected human-review guide to mention create_preview")
+	}
 }
 
 func TestGitHubGuide_ListedWhenConfigured(t *testing.T) {
@@ -252,3 +328,77 @@ func TestGitHubGuide_ListedWhenConfigured(t *testing.T) {
 		t.Error("expected 'github-guide' to be listed when GitHub is configured")
 	}
 }
+
+func TestTroubleshootGuide_DetectFailure(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess, err := sessions.Register("test", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := sessions.Register("other", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: sess.Namespace},
+		Spec:       iafv1alpha1.ApplicationSpec{Blob: "http://localhost:8080/sources/web.tar.gz"},
+		Status:     iafv1alpha1.ApplicationStatus{Phase: iafv1alpha1.ApplicationPhaseFailed, BuildStatus: "Failed"},
+	}
+	buildPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-build-1-build-pod",
+			Namespace: sess.Namespace,
+			Labels:    map[string]string{iafk8s.LabelKpackImage: "web"},
+		},
+		Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{
+				{Name: "prepare", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}},
+				{Name: "detect", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 20}}},
+			},
+		},
+	}
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "web-build.1", Namespace: sess.Namespace},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: buildPod.Name},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "BackOff",
+		Message:        "Back-off restarting failed container",
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(app, buildPod, event).Build()
+
+	deps := &tools.Dependencies{Client: k8sClient, BaseDomain: "test.example.com", Sessions: sessions}
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	prompts.RegisterTroubleshootGuide(server, deps, nil)
+	cs := connectServer(t, ctx, server)
+
+	res, err := cs.GetPrompt(ctx, &gomcp.GetPromptParams{
+		Name:      "troubleshoot-guide",
+		Arguments: map[string]string{"session_id": sess.ID, "name": "web"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := res.Messages[0].Content.(*gomcp.TextContent).Text
+	for _, want := range []string{"failed at the detect step", "go.mod", "push_code", "Back-off restarting failed container"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in troubleshoot guide, got:\n%s", want, text)
+		}
+	}
+
+	// Another session cannot troubleshoot the app.
+	if _, err := cs.GetPrompt(ctx, &gomcp.GetPromptParams{
+		Name:      "troubleshoot-guide",
+		Arguments: map[string]string{"session_id": other.ID, "name": "web"},
+	}); err == nil {
+		t.Error("expected an error for an app in another session")
+	}
+}
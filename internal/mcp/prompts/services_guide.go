@@ -36,6 +36,8 @@ IAF provides managed backing services — pre-provisioned, platform-managed reso
 | ` + "`small`" + ` | 1 | 512Mi | 5Gi | Light production workloads |
 | ` + "`ha`" + ` | 3 | 1Gi | 10Gi | High-availability production |
 
+These are the built-in plans. Operators can define more plans, or resize these, so call ` + "`list_service_plans`" + ` for the plans this platform offers, with their CPU, memory and backup policy.
+
 ## Complete Workflow
 
 ### Step 1: Provision the service
@@ -91,6 +93,14 @@ This injects 6 environment variables as Kubernetes Secret references into your a
 
 The application is automatically redeployed with the new credentials.
 
+**Several databases in one app**: pass ` + "`env_prefix=\"ORDERS_\"`" + ` when binding the second service. Its variables become ` + "`ORDERS_DATABASE_URL`" + `, ` + "`ORDERS_PGHOST`" + ` and so on, next to the first service's unprefixed ones.
+
+**Other connection string formats**: Rails and most frameworks read ` + "`DATABASE_URL`" + ` as is. For JDBC (Spring, Quarkus) pass ` + "`format=[\"jdbc\"]`" + ` to also get ` + "`JDBC_DATABASE_URL`" + `, ` + "`JDBC_DATABASE_USERNAME`" + ` and ` + "`JDBC_DATABASE_PASSWORD`" + `; for Flask-SQLAlchemy pass ` + "`format=[\"sqlalchemy\"]`" + ` to get ` + "`SQLALCHEMY_DATABASE_URI`" + `.
+
+**Binding directories**: for frameworks that read the Service Binding spec (Spring Cloud Bindings, Quarkus), pass ` + "`projection=\"files\"`" + ` to mount the credentials at ` + "`/bindings/<service_name>`" + ` with ` + "`SERVICE_BINDING_ROOT=/bindings`" + `, or ` + "`projection=\"both\"`" + ` to keep the env vars too.
+
+**Read replicas (plans with more than one instance, such as ` + "`ha`" + `)**: pass ` + "`bind_mode=\"both\"`" + ` to also inject ` + "`PGHOST_RO`" + ` and ` + "`DATABASE_READ_URL`" + `, which reach the two read replicas. Send reporting and other read-only queries there and writes to ` + "`DATABASE_URL`" + `. Replicas lag the primary slightly, so read your own writes from the primary. ` + "`bind_mode=\"ro\"`" + ` injects only the replica endpoint and the credentials, for apps that never write.
+
 ### Step 5: Use the connection in your code
 
 Your application code reads these as standard environment variables:
@@ -131,6 +141,50 @@ deprovision_service(session_id="<your-session-id>", name="mydb")
 
 **WARNING**: Permanently deletes the database and all its data. You must unbind all applications first.
 
+## Deploying a Whole Stack at Once
+
+For projects with several apps and services (e.g. frontend, API, worker, and database), ` + "`deploy_stack`" + ` does the steps above in one call:
+
+` + "```" + `
+deploy_stack(
+  session_id="<your-session-id>",
+  name="shop",
+  services=[{"name": "shop-db", "type": "postgres", "plan": "micro"}],
+  apps=[
+    {"name": "shop-api", "git_url": "https://github.com/org/api", "services": ["shop-db"]},
+    {"name": "shop-web", "image": "org/web:1.0", "env": [{"name": "API_URL", "value": "https://shop-api.<base-domain>"}]}
+  ]
+)
+` + "```" + `
+
+Services are provisioned first; apps are created only once their services are Ready, with bindings already in place. If the response status is ` + "`waiting_for_services`" + `, call ` + "`deploy_stack`" + ` again with the same manifest after a minute — existing components are left unchanged. Then poll ` + "`stack_status(session_id, name)`" + ` every 30 seconds until the stack is ` + "`Ready`" + `.
+
+## Database Migrations
+
+Run schema migrations with ` + "`run_migration`" + ` — never inside request handlers, and never automatically at app startup. With several replicas, startup migrations race each other, and a migration that fails half-way leaves the app crash-looping.
+
+` + "```" + `
+run_migration(
+  session_id="<your-session-id>",
+  app_name="myapp",
+  command=["npm", "run", "migrate"]
+)
+` + "```" + `
+
+The migration runs once as a Kubernetes Job with the app's image, environment and bound services, so it connects with the same ` + "`DATABASE_URL`" + `. Omit ` + "`command`" + ` to run the app's ` + "`release_command`" + ` (set it with ` + "`deploy_app`" + ` or ` + "`push_code`" + `). Only one migration runs at a time per app, and a failed migration is not retried.
+
+Poll ` + "`migration_status(session_id, app_name)`" + ` every 10 seconds until the latest run is ` + "`Succeeded`" + ` or ` + "`Failed`" + `. It lists past runs, newest first, with their command and status. Read a migration's output with ` + "`app_logs(session_id, name, migration_logs=true)`" + `.
+
+### Blue/green safe migrations
+
+During a rollout, old and new versions of the app run against the same database at the same time. Every migration must work with both:
+
+1. **Expand first**: add new tables, nullable columns, or columns with defaults. Never rename or drop in the same release that stops using them.
+2. **Migrate, then deploy**: run the migration with ` + "`run_migration`" + ` and wait for ` + "`Succeeded`" + ` before deploying code that depends on it.
+3. **Backfill in batches**: update large tables in small batches, not one long transaction that locks them.
+4. **Contract later**: drop old columns or tables in a later release, once no running version reads them.
+5. **Create indexes concurrently** (PostgreSQL: ` + "`CREATE INDEX CONCURRENTLY`" + `) so writes are not blocked.
+
 ## Listing Services
 
 ` + "```" + `
@@ -150,6 +204,8 @@ Returns all managed services in your namespace with their current phase and boun
 
 The ` + "`provision_service`" + ` tool returns immediately. Use ` + "`service_status`" + ` every 10 seconds to check progress. Provisioning typically takes 1–3 minutes for the ` + "`micro`" + ` plan.
 
+While the service provisions, ` + "`progress`" + ` shows ` + "`instancesReady`" + ` (such as ` + "`1/3`" + `), ` + "`volumesBound`" + `, the CloudNativePG ` + "`clusterPhase`" + `, the ` + "`currentPrimary`" + ` and the latest ` + "`events`" + ` on the database's pods, volumes and jobs. Keep polling while they move forward. A ` + "`Warning`" + ` event that repeats means provisioning is stuck rather than slow: ` + "`ProvisioningFailed`" + ` or ` + "`FailedScheduling`" + ` on a volume means the storage cannot be provisioned, and ` + "`Failed`" + ` or ` + "`BackOff`" + ` pulling an image means the database image is unavailable. Neither fixes itself; report it to the platform operator.
+
 ## Cross-references
 
 - See ` + "`deploy-guide`" + ` for deploying applications and understanding the application lifecycle.
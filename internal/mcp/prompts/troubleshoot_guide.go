@@ -0,0 +1,519 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/logparse"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// troubleshootLogLines is how many trailing log lines are scanned.
+	troubleshootLogLines = 200
+	// troubleshootExcerptLines is how many log lines are quoted.
+	troubleshootExcerptLines = 20
+	// troubleshootMaxEvents is how many warning events are quoted.
+	troubleshootMaxEvents = 10
+)
+
+// RegisterTroubleshootGuide registers the troubleshoot-guide prompt. clientset
+// reads build and runtime logs; when nil, the diagnosis uses the Application,
+// its pods and events only.
+func RegisterTroubleshootGuide(server *gomcp.Server, deps *tools.Dependencies, clientset kubernetes.Interface) {
+	server.AddPrompt(&gomcp.Prompt{
+		Name:        "troubleshoot-guide",
+		Description: "Diagnose a failing or stuck application from its live state — phase, conditions, pod status, warning events and recent build or runtime logs — and get specific fixes instead of generic advice.",
+		Arguments: []*gomcp.PromptArgument{
+			{
+				Name:        "session_id",
+				Description: "Session ID returned by the register tool.",
+				Required:    true,
+			},
+			{
+				Name:        "name",
+				Description: "Name of the application to troubleshoot.",
+				Required:    true,
+			},
+		},
+	}, func(ctx context.Context, req *gomcp.GetPromptRequest) (*gomcp.GetPromptResult, error) {
+		namespace, err := deps.ResolveNamespace(req.Params.Arguments["session_id"])
+		if err != nil {
+			return nil, err
+		}
+		name := req.Params.Arguments["name"]
+		if err := validation.ValidateAppName(name); err != nil {
+			return nil, err
+		}
+
+		var app iafv1alpha1.Application
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &app); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", name)
+			}
+			return nil, fmt.Errorf("getting application: %w", err)
+		}
+
+		state, err := gatherAppState(ctx, deps.Client, clientset, &app)
+		if err != nil {
+			return nil, err
+		}
+
+		return &gomcp.GetPromptResult{
+			Description: fmt.Sprintf("Troubleshooting guide for %s", name),
+			Messages: []*gomcp.PromptMessage{
+				{
+					Role:    "user",
+					Content: &gomcp.TextContent{Text: renderTroubleshootGuide(state, diagnose(state))},
+				},
+			},
+		}, nil
+	})
+}
+
+// appState is the live state of an application that the diagnosis reads.
+type appState struct {
+	app *iafv1alpha1.Application
+	// buildPod is the most recent kpack build pod, if any.
+	buildPod *corev1.Pod
+	// failedStep is the build step (init container) that failed, if any.
+	failedStep string
+	buildLog   string
+	// appPod is the most recent application pod, if any.
+	appPod *corev1.Pod
+	appLog string
+	// appCrashed is set when the app container has exited or restarted.
+	appCrashed bool
+	// events are the app's warning events, oldest first.
+	events []corev1.Event
+}
+
+// gatherAppState reads the app's pods, warning events and, when clientset is
+// set, the log of the failed build step or of the app container.
+func gatherAppState(ctx context.Context, c client.Client, clientset kubernetes.Interface, app *iafv1alpha1.Application) (*appState, error) {
+	state := &appState{app: app}
+
+	var buildPods corev1.PodList
+	if err := c.List(ctx, &buildPods, client.InNamespace(app.Namespace), client.MatchingLabels{iafk8s.LabelKpackImage: app.Name}); err != nil {
+		return nil, fmt.Errorf("listing build pods: %w", err)
+	}
+	if len(buildPods.Items) > 0 {
+		state.buildPod = iafk8s.SelectMostRecentPod(buildPods.Items)
+		state.failedStep = failedBuildStep(state.buildPod)
+		if state.failedStep != "" {
+			state.buildLog = readPodLog(ctx, clientset, state.buildPod, state.failedStep)
+		}
+	}
+
+	var appPods corev1.PodList
+	if err := c.List(ctx, &appPods, client.InNamespace(app.Namespace), client.MatchingLabels{"iaf.io/application": app.Name}); err != nil {
+		return nil, fmt.Errorf("listing application pods: %w", err)
+	}
+	if len(appPods.Items) > 0 {
+		state.appPod = iafk8s.SelectMostRecentPod(appPods.Items)
+		state.appLog = readPodLog(ctx, clientset, state.appPod, "app")
+		for _, s := range state.appPod.Status.ContainerStatuses {
+			if s.Name == "app" && (s.RestartCount > 0 || s.State.Terminated != nil) {
+				state.appCrashed = true
+			}
+		}
+	}
+
+	var events corev1.EventList
+	if err := c.List(ctx, &events, client.InNamespace(app.Namespace)); err != nil {
+		return nil, fmt.Errorf("listing events: %w", err)
+	}
+	for _, e := range events.Items {
+		involved := e.InvolvedObject.Name
+		if e.Type == corev1.EventTypeWarning && (involved == app.Name || strings.HasPrefix(involved, app.Name+"-")) {
+			state.events = append(state.events, e)
+		}
+	}
+	sort.SliceStable(state.events, func(i, j int) bool {
+		return eventTime(state.events[i]).Before(eventTime(state.events[j]))
+	})
+	if len(state.events) > troubleshootMaxEvents {
+		state.events = state.events[len(state.events)-troubleshootMaxEvents:]
+	}
+	return state, nil
+}
+
+// eventTime returns when an event last occurred.
+func eventTime(e corev1.Event) time.Time {
+	if !e.LastTimestamp.IsZero() {
+		return e.LastTimestamp.Time
+	}
+	if !e.EventTime.IsZero() {
+		return e.EventTime.Time
+	}
+	return e.CreationTimestamp.Time
+}
+
+// failedBuildStep returns the name of the first kpack build step that exited
+// with an error.
+func failedBuildStep(pod *corev1.Pod) string {
+	for _, s := range pod.Status.InitContainerStatuses {
+		if t := s.State.Terminated; t != nil && t.ExitCode != 0 {
+			return s.Name
+		}
+	}
+	return ""
+}
+
+// readPodLog returns the trailing log of container, or "" when it cannot be
+// read. Missing logs only make the diagnosis less specific.
+func readPodLog(ctx context.Context, clientset kubernetes.Interface, pod *corev1.Pod, container string) string {
+	if clientset == nil {
+		return ""
+	}
+	lines := int64(troubleshootLogLines)
+	stream, err := clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container: container,
+		TailLines: &lines,
+	}).Stream(ctx)
+	if err != nil {
+		return ""
+	}
+	defer stream.Close()
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// finding is one diagnosed problem and how to fix it.
+type finding struct {
+	problem string
+	fix     string
+}
+
+// buildStepPurpose describes what each kpack build step does.
+var buildStepPurpose = map[string]string{
+	"prepare": "fetching the source code",
+	"analyze": "reading the previous image",
+	"detect":  "detecting the language of the source code",
+	"restore": "restoring the build cache",
+	"build":   "compiling the application",
+	"export":  "pushing the built image to the registry",
+}
+
+// buildLogPattern maps a known build log message to its cause and fix.
+type buildLogPattern struct {
+	match   *regexp.Regexp
+	problem string
+	fix     string
+}
+
+var buildLogPatterns = []buildLogPattern{
+	{
+		regexp.MustCompile(`go: cannot find main module|go\.mod file not found`),
+		"the Go toolchain found no go.mod",
+		"Add a go.mod (`module <name>` and a `go` directive) at the root of the source and redeploy.",
+	},
+	{
+		regexp.MustCompile(`missing go\.sum entry`),
+		"go.sum is missing entries for the module's dependencies",
+		"Run `go mod tidy` and include the updated go.sum with the source.",
+	},
+	{
+		regexp.MustCompile(`\.go:\d+:\d+: `),
+		"the Go code does not compile",
+		"Fix the compile errors quoted in the build log excerpt below.",
+	},
+	{
+		regexp.MustCompile(`Missing script: "?start"?`),
+		"package.json has no start script",
+		"Add a `\"start\"` script to package.json (e.g. `\"start\": \"node server.js\"`).",
+	},
+	{
+		regexp.MustCompile(`npm ERR! (code E404|404 Not Found)`),
+		"npm could not find a dependency listed in package.json",
+		"Check the package names and versions in package.json.",
+	},
+	{
+		regexp.MustCompile(`No matching distribution found for|Could not find a version that satisfies`),
+		"pip could not install a dependency listed in requirements.txt",
+		"Check the package names and versions in requirements.txt.",
+	},
+	{
+		regexp.MustCompile(`COMPILATION ERROR|BUILD FAILURE`),
+		"the Java build failed",
+		"Fix the errors quoted in the build log excerpt below and make sure `mvn package` or `gradle build` succeeds locally.",
+	},
+	{
+		regexp.MustCompile(`Could not find gem|Bundler could not find compatible versions`),
+		"Bundler could not resolve the gems in the Gemfile",
+		"Run `bundle install` locally and include the updated Gemfile.lock with the source.",
+	},
+}
+
+// diagnose turns the live state of an app into findings, most likely cause
+// first.
+func diagnose(state *appState) []finding {
+	var findings []finding
+	findings = append(findings, diagnoseBuild(state)...)
+	findings = append(findings, diagnoseRuntime(state)...)
+	return findings
+}
+
+func diagnoseBuild(state *appState) []finding {
+	app := state.app
+	if state.failedStep == "" && app.Status.BuildStatus != "Failed" {
+		return nil
+	}
+	step := state.failedStep
+	if step == "" {
+		return []finding{{
+			problem: "the build failed",
+			fix:     "Read the build logs with `app_logs build_logs=true` to find the failing step.",
+		}}
+	}
+
+	var findings []finding
+	if step == "detect" || strings.Contains(state.buildLog, "No buildpack groups passed detection") {
+		fix := "Add the dependency manifest for your language at the root of the source: go.mod (Go), package.json (Node.js), requirements.txt, setup.py or pyproject.toml (Python), pom.xml or build.gradle (Java), or Gemfile (Ruby)."
+		if app.Spec.Git != nil {
+			fix += " The manifest must be at the repository root on the deployed revision."
+		} else {
+			fix += " Then call `push_code` again with all files."
+		}
+		findings = append(findings, finding{
+			problem: "your build failed at the detect step because no buildpack recognized the source code",
+			fix:     fix,
+		})
+	}
+	for _, p := range buildLogPatterns {
+		if p.match.MatchString(state.buildLog) {
+			findings = append(findings, finding{
+				problem: fmt.Sprintf("your build failed at the %s step because %s", step, p.problem),
+				fix:     p.fix,
+			})
+		}
+	}
+	if len(findings) > 0 {
+		return findings
+	}
+
+	problem := fmt.Sprintf("your build failed at the %s step", step)
+	if purpose, ok := buildStepPurpose[step]; ok {
+		problem += " (" + purpose + ")"
+	}
+	fix := "Fix the errors in the build log excerpt below and redeploy."
+	switch step {
+	case "prepare":
+		if app.Spec.Git != nil {
+			fix = "Check that the git URL and revision exist. For a private repository, add a credential with `add_git_credential` and pass it as `git_credential` to `deploy_app`."
+		}
+	case "export":
+		fix = "The image could not be pushed. If the app uses a private registry, check the credential added with `add_registry_credential`; otherwise the platform registry may be unavailable — retry later."
+	}
+	return []finding{{problem: problem, fix: fix}}
+}
+
+func diagnoseRuntime(state *appState) []finding {
+	app := state.app
+	var findings []finding
+	if pod := state.appPod; pod != nil {
+		for _, s := range pod.Status.ContainerStatuses {
+			if s.Name != "app" {
+				continue
+			}
+			if w := s.State.Waiting; w != nil && (w.Reason == "ImagePullBackOff" || w.Reason == "ErrImagePull") {
+				fix := "Check that the image name and tag exist."
+				if app.Spec.RegistryCredential == "" {
+					fix += " If the image is in a private registry, add a credential with `add_registry_credential` and redeploy with `registry_credential`."
+				} else {
+					fix += fmt.Sprintf(" Check that the registry credential %q is still valid.", app.Spec.RegistryCredential)
+				}
+				findings = append(findings, finding{
+					problem: fmt.Sprintf("the image %q cannot be pulled", s.Image),
+					fix:     fix,
+				})
+			}
+			last := s.LastTerminationState.Terminated
+			if last == nil {
+				last = s.State.Terminated
+			}
+			if last != nil && last.Reason == "OOMKilled" {
+				findings = append(findings, finding{
+					problem: "the app was killed because it ran out of memory",
+					fix:     "Reduce the app's memory use, or redeploy with a higher memory limit if the platform allows it.",
+				})
+			} else if last != nil && last.ExitCode != 0 {
+				problem := fmt.Sprintf("the app exits with code %d", last.ExitCode)
+				if s.RestartCount > 0 {
+					problem += fmt.Sprintf(" and has restarted %d times", s.RestartCount)
+				}
+				findings = append(findings, finding{
+					problem: problem,
+					fix:     "The application log excerpt below shows why it exits. Fix the error and redeploy.",
+				})
+			}
+		}
+	}
+
+	// Report each kind of event once; the newest is last.
+	seen := map[string]bool{}
+	for i := len(state.events) - 1; i >= 0; i-- {
+		e := state.events[i]
+		if seen[e.Reason] {
+			continue
+		}
+		switch {
+		case e.Reason == "Unhealthy" && strings.Contains(e.Message, "connection refused"):
+			findings = append(findings, finding{
+				problem: fmt.Sprintf("the app is not listening on port %d", appPort(app)),
+				fix:     fmt.Sprintf("Listen on 0.0.0.0 and the port in the PORT environment variable (%d), or redeploy with the port the app uses.", appPort(app)),
+			})
+		case e.Reason == "FailedScheduling":
+			findings = append(findings, finding{
+				problem: "the app's pods cannot be scheduled: " + strings.TrimSpace(e.Message),
+				fix:     iafk8s.SchedulingHint(app, &iafv1alpha1.SchedulingStatus{Reasons: iafk8s.SchedulingReasons(e.Message)}),
+			})
+		default:
+			continue
+		}
+		seen[e.Reason] = true
+	}
+	return findings
+}
+
+// appPort returns the port the app is expected to listen on.
+func appPort(app *iafv1alpha1.Application) int32 {
+	if app.Spec.Port != 0 {
+		return app.Spec.Port
+	}
+	return 8080
+}
+
+// renderTroubleshootGuide renders the diagnosis and the evidence behind it.
+func renderTroubleshootGuide(state *appState, findings []finding) string {
+	app := state.app
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Troubleshooting `%s`\n\n", app.Name))
+
+	sb.WriteString("## Current State\n\n")
+	sb.WriteString(fmt.Sprintf("- **Phase**: %s\n", valueOr(string(app.Status.Phase), "Pending")))
+	if app.Status.BuildStatus == "Queued" {
+		sb.WriteString(fmt.Sprintf("- **Build**: Queued (position %d; the platform limits concurrent builds)\n", app.Status.BuildQueuePosition))
+	} else if app.Status.BuildStatus != "" {
+		sb.WriteString(fmt.Sprintf("- **Build**: %s\n", app.Status.BuildStatus))
+	}
+	if app.Status.URL != "" {
+		sb.WriteString(fmt.Sprintf("- **URL**: %s\n", app.Status.URL))
+	}
+	sb.WriteString(fmt.Sprintf("- **Available replicas**: %d\n", app.Status.AvailableReplicas))
+	for _, c := range app.Status.Conditions {
+		if c.Status == metav1.ConditionFalse {
+			sb.WriteString(fmt.Sprintf("- **%s** is False (%s): %s\n", c.Type, c.Reason, c.Message))
+		}
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("## Diagnosis\n\n")
+	switch {
+	case len(findings) > 0:
+		for i, f := range findings {
+			sb.WriteString(fmt.Sprintf("%d. **%s.** %s\n", i+1, capitalize(f.problem), f.fix))
+		}
+	case app.Status.Phase == iafv1alpha1.ApplicationPhaseBuilding || app.Status.Phase == iafv1alpha1.ApplicationPhaseDeploying || app.Status.Phase == "":
+		sb.WriteString("No problem found yet — the app is still being built or deployed. Builds take about 2 minutes. Check again with `app_status`, waiting `pollIntervalSeconds` between calls.\n")
+	case app.Status.Phase == iafv1alpha1.ApplicationPhaseSuspended || app.Status.Phase == iafv1alpha1.ApplicationPhaseSleeping:
+		sb.WriteString(fmt.Sprintf("The app is %s, so it has no running pods. Call `resume_app` to start it again.\n", app.Status.Phase))
+	default:
+		sb.WriteString("No problem found in the app's state, pods or events. If requests still fail, read the runtime logs with `app_logs level=error`.\n")
+	}
+	sb.WriteString("\n")
+
+	if len(state.events) > 0 {
+		sb.WriteString("## Recent Warning Events\n\n")
+		for _, e := range state.events {
+			sb.WriteString(fmt.Sprintf("- %s/%s %s: %s\n", e.InvolvedObject.Kind, e.InvolvedObject.Name, e.Reason, strings.TrimSpace(e.Message)))
+		}
+		sb.WriteString("\n")
+	}
+
+	if excerpt := logExcerpt(state.buildLog, isBuildError, true); excerpt != "" {
+		sb.WriteString(fmt.Sprintf("## Build Log (%s step)\n\n```\n%s\n```\n\n", state.failedStep, excerpt))
+	}
+	if excerpt := logExcerpt(state.appLog, isRuntimeError, state.appCrashed); excerpt != "" {
+		sb.WriteString(fmt.Sprintf("## Application Log (errors)\n\n```\n%s\n```\n\n", excerpt))
+	}
+
+	sb.WriteString("## Next Steps\n\n")
+	sb.WriteString("1. Apply the fixes above to your source code or deployment settings.\n")
+	if app.Spec.Git != nil {
+		sb.WriteString("2. Push the fix to the repository and call `deploy_app` again.\n")
+	} else if app.Spec.Image != "" {
+		sb.WriteString("2. Call `deploy_app` again with the corrected settings.\n")
+	} else {
+		sb.WriteString("2. Call `push_code` again with the fixed files.\n")
+	}
+	sb.WriteString("3. Follow progress with `app_status`, or subscribe to the app's `iaf://apps/<name>` resource.\n")
+	sb.WriteString("4. Use `app_logs` (runtime) or `app_logs build_logs=true` (build) for the full logs.\n")
+	return sb.String()
+}
+
+// logExcerpt returns the last lines of log for which isError is true. When
+// none are and fallback is set, it returns the last lines of the log.
+func logExcerpt(log string, isError func(string) bool, fallback bool) string {
+	var all, errs []string
+	for _, line := range strings.Split(log, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		all = append(all, line)
+		if isError(line) {
+			errs = append(errs, line)
+		}
+	}
+	lines := errs
+	if len(lines) == 0 && fallback {
+		lines = all
+	}
+	if len(lines) > troubleshootExcerptLines {
+		lines = lines[len(lines)-troubleshootExcerptLines:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isBuildError(line string) bool {
+	return strings.Contains(strings.ToLower(line), "error")
+}
+
+func isRuntimeError(line string) bool {
+	level := logparse.Parse(line).Level
+	return level == "error" || level == "fatal"
+}
+
+func valueOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
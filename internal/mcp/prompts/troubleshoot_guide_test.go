@@ -0,0 +1,87 @@
+package prompts
+
+import (
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestDiagnose(t *testing.T) {
+	app := &iafv1alpha1.Application{Spec: iafv1alpha1.ApplicationSpec{Port: 3000}}
+	appPod := func(status corev1.ContainerStatus) *corev1.Pod {
+		status.Name = "app"
+		return &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{status}}}
+	}
+
+	tests := []struct {
+		name  string
+		state appState
+		want  string
+	}{
+		{
+			name:  "missing go.mod",
+			state: appState{failedStep: "build", buildLog: "go: go.mod file not found in current directory or any parent directory"},
+			want:  "failed at the build step because the Go toolchain found no go.mod",
+		},
+		{
+			name:  "go compile error",
+			state: appState{failedStep: "build", buildLog: "./main.go:12:2: undefined: handler"},
+			want:  "the Go code does not compile",
+		},
+		{
+			name:  "missing start script",
+			state: appState{failedStep: "build", buildLog: `npm ERR! Missing script: "start"`},
+			want:  "package.json has no start script",
+		},
+		{
+			name:  "unknown build error",
+			state: appState{failedStep: "export", buildLog: "ERROR: failed to export"},
+			want:  "failed at the export step (pushing the built image to the registry)",
+		},
+		{
+			name:  "out of memory",
+			state: appState{appPod: appPod(corev1.ContainerStatus{LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}}})},
+			want:  "ran out of memory",
+		},
+		{
+			name:  "crash",
+			state: appState{appPod: appPod(corev1.ContainerStatus{RestartCount: 4, LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}}})},
+			want:  "exits with code 1 and has restarted 4 times",
+		},
+		{
+			name:  "image pull",
+			state: appState{appPod: appPod(corev1.ContainerStatus{Image: "nginx:nope", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}})},
+			want:  `the image "nginx:nope" cannot be pulled`,
+		},
+		{
+			name: "wrong port",
+			state: appState{events: []corev1.Event{{
+				Reason:  "Unhealthy",
+				Message: "Readiness probe failed: dial tcp 10.0.0.1:3000: connect: connection refused",
+			}}},
+			want: "not listening on port 3000",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.state.app = app
+			findings := diagnose(&tc.state)
+			var problems []string
+			for _, f := range findings {
+				problems = append(problems, f.problem)
+			}
+			if !strings.Contains(strings.Join(problems, "\n"), tc.want) {
+				t.Errorf("expected a finding containing %q, got %q", tc.want, problems)
+			}
+		})
+	}
+}
+
+func TestDiagnose_Healthy(t *testing.T) {
+	app := &iafv1alpha1.Application{Status: iafv1alpha1.ApplicationStatus{Phase: iafv1alpha1.ApplicationPhaseRunning}}
+	if findings := diagnose(&appState{app: app}); len(findings) != 0 {
+		t.Errorf("expected no findings, got %v", findings)
+	}
+}
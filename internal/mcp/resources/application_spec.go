@@ -77,6 +77,49 @@ func RegisterApplicationSpec(server *gomcp.Server, deps *tools.Dependencies) {
 					"default":     fmt.Sprintf("<name>.%s", deps.BaseDomain),
 					"optional":    true,
 				},
+				"protocol": map[string]any{
+					"type":        "string",
+					"description": "Traffic protocol. 'websocket' keeps HTTP routing (upgrades pass through), 'grpc' routes HTTP/2 cleartext (h2c) to the container, 'tcp' routes raw TCP by TLS SNI on port 443.",
+					"enum":        []string{"http", "websocket", "grpc", "tcp"},
+					"default":     "http",
+					"optional":    true,
+				},
+				"stickySessions": map[string]any{
+					"type":        "boolean",
+					"description": "Pin each client to one replica with a cookie. Useful for websocket apps that keep in-memory session state. Ignored for tcp.",
+					"default":     false,
+					"optional":    true,
+				},
+				"authentication": map[string]any{
+					"type":        "string",
+					"description": "Protects the app URL. 'basic' requires a platform-generated username/password (retrieve once with get_app_credentials); 'oauth-proxy' requires login through the platform identity provider. Not supported with protocol tcp.",
+					"enum":        []string{"none", "basic", "oauth-proxy"},
+					"default":     "none",
+					"optional":    true,
+				},
+				"backendTLS": map[string]any{
+					"type":        "object",
+					"description": "Set {enabled: true} to encrypt traffic from the ingress to the app. The platform mounts a serving certificate and sets IAF_TLS_CERT_FILE and IAF_TLS_KEY_FILE; the app must serve HTTPS (or gRPC over TLS) on its port with them. Pods restart when the certificate renews. Not supported with protocol tcp or authentication oauth-proxy.",
+					"optional":    true,
+				},
+				"access": map[string]any{
+					"type":        "object",
+					"description": "Restricts inbound traffic. Not supported with protocol tcp.",
+					"optional":    true,
+					"fields": map[string]any{
+						"ipAllowList": map[string]any{
+							"type":        "array",
+							"description": "Source IPs or CIDR ranges allowed to reach the app (max 50). Empty allows everyone.",
+							"optional":    true,
+						},
+						"requestsPerSecond": map[string]any{
+							"type":        "integer",
+							"description": "Average requests per second per client IP; bursts up to 2x are allowed. 0 disables rate limiting.",
+							"default":     0,
+							"optional":    true,
+						},
+					},
+				},
 			},
 			"status": map[string]any{
 				"phase": map[string]any{
@@ -0,0 +1,346 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Application resource URI templates. Resource reads carry no session, so
+// the session ID is a query parameter, as on the REST API.
+const (
+	appURITemplate     = "iaf://apps/{name}{?session_id}"
+	appListURITemplate = "iaf://apps{?session_id}"
+)
+
+// appWatchRetryInterval is how long a failed Application watch waits before
+// retrying, and how often subscriptions of disconnected clients are pruned.
+var appWatchRetryInterval = 30 * time.Second
+
+// RegisterApplications registers the iaf://apps and iaf://apps/{name}
+// resource templates. subs delivers update notifications to subscribers; its
+// Subscribe and Unsubscribe methods must be the server's subscribe handlers.
+func RegisterApplications(server *gomcp.Server, deps *tools.Dependencies, subs *AppSubscriptions) {
+	subs.server = server
+
+	server.AddResourceTemplate(&gomcp.ResourceTemplate{
+		URITemplate: appListURITemplate,
+		Name:        "applications",
+		Description: "Live list of the applications in your session with their phase and URL. Append ?session_id=<id> from the register tool. Subscribe to be notified when an application is added, changes, or is deleted.",
+		MIMEType:    "application/json",
+	}, func(ctx context.Context, req *gomcp.ReadResourceRequest) (*gomcp.ReadResourceResult, error) {
+		ref, err := parseAppURI(req.Params.URI)
+		if err != nil || ref.name != "" {
+			return nil, gomcp.ResourceNotFoundError(req.Params.URI)
+		}
+		namespace, err := deps.ResolveNamespace(ref.sessionID)
+		if err != nil {
+			return nil, err
+		}
+
+		var list iafv1alpha1.ApplicationList
+		if err := deps.Client.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+			return nil, fmt.Errorf("listing applications: %w", err)
+		}
+		apps := make([]map[string]any, 0, len(list.Items))
+		for _, app := range list.Items {
+			apps = append(apps, map[string]any{
+				"name":  app.Name,
+				"phase": string(app.Status.Phase),
+				"url":   app.Status.URL,
+			})
+		}
+		return jsonResource(req.Params.URI, map[string]any{
+			"applications": apps,
+			"total":        len(apps),
+		})
+	})
+
+	server.AddResourceTemplate(&gomcp.ResourceTemplate{
+		URITemplate: appURITemplate,
+		Name:        "application",
+		Description: "Live status of one application in your session: phase, URL, image, replicas and conditions, as app_status reports them. Append ?session_id=<id> from the register tool. Subscribe to be notified when the application changes instead of polling app_status.",
+		MIMEType:    "application/json",
+	}, func(ctx context.Context, req *gomcp.ReadResourceRequest) (*gomcp.ReadResourceResult, error) {
+		ref, err := parseAppURI(req.Params.URI)
+		if err != nil || ref.name == "" {
+			return nil, gomcp.ResourceNotFoundError(req.Params.URI)
+		}
+		namespace, err := deps.ResolveNamespace(ref.sessionID)
+		if err != nil {
+			return nil, err
+		}
+
+		var app iafv1alpha1.Application
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: ref.name, Namespace: namespace}, &app); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, gomcp.ResourceNotFoundError(req.Params.URI)
+			}
+			return nil, fmt.Errorf("getting application: %w", err)
+		}
+		return jsonResource(req.Params.URI, map[string]any{
+			"name":   app.Name,
+			"status": app.Status,
+		})
+	})
+}
+
+// jsonResource returns payload as the JSON contents of the resource at uri.
+func jsonResource(uri string, payload any) (*gomcp.ReadResourceResult, error) {
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling %s: %w", uri, err)
+	}
+	return &gomcp.ReadResourceResult{
+		Contents: []*gomcp.ResourceContents{
+			{URI: uri, MIMEType: "application/json", Text: string(data)},
+		},
+	}, nil
+}
+
+// appRef is a parsed application resource URI. name is empty for the list.
+type appRef struct {
+	name      string
+	sessionID string
+}
+
+// parseAppURI parses iaf://apps?session_id=... and iaf://apps/{name}?session_id=....
+func parseAppURI(uri string) (appRef, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "iaf" || u.Host != "apps" {
+		return appRef{}, fmt.Errorf("not an application resource URI: %q", uri)
+	}
+	ref := appRef{
+		name:      strings.TrimPrefix(u.Path, "/"),
+		sessionID: u.Query().Get("session_id"),
+	}
+	if ref.sessionID == "" {
+		return appRef{}, errors.New("the session_id query parameter is required")
+	}
+	if ref.name != "" {
+		if err := validation.ValidateAppName(ref.name); err != nil {
+			return appRef{}, err
+		}
+	}
+	return ref, nil
+}
+
+// AppSubscriptions notifies clients subscribed to application resources when
+// the build or deploy progress of the Applications in their session namespace
+// changes. Each namespace with subscribers has one Application watch, stopped
+// once its last subscriber unsubscribes or disconnects.
+type AppSubscriptions struct {
+	deps   *tools.Dependencies
+	server *gomcp.Server
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	subs    map[string]map[appSubscriber]bool // namespace → subscribers
+	watches map[string]context.CancelFunc     // namespace → running watch
+}
+
+// appProgress is the part of an Application's status whose changes are
+// notified. Other status updates, such as replica counts, are not.
+type appProgress struct {
+	phase         iafv1alpha1.ApplicationPhase
+	buildStatus   string
+	queuePosition int32
+	url           string
+}
+
+func progressOf(app *iafv1alpha1.Application) appProgress {
+	return appProgress{
+		phase:         app.Status.Phase,
+		buildStatus:   app.Status.BuildStatus,
+		queuePosition: app.Status.BuildQueuePosition,
+		url:           app.Status.URL,
+	}
+}
+
+// appSubscriber is one client session subscribed to one resource URI.
+type appSubscriber struct {
+	session *gomcp.ServerSession
+	uri     string
+	name    string // empty for the application list
+}
+
+// NewAppSubscriptions returns an empty subscription registry.
+func NewAppSubscriptions(deps *tools.Dependencies, logger *slog.Logger) *AppSubscriptions {
+	return &AppSubscriptions{
+		deps:    deps,
+		logger:  logger,
+		subs:    map[string]map[appSubscriber]bool{},
+		watches: map[string]context.CancelFunc{},
+	}
+}
+
+// Subscribe is the server's subscribe handler. Only application resources
+// of a valid session can be subscribed to.
+func (s *AppSubscriptions) Subscribe(ctx context.Context, req *gomcp.SubscribeRequest) error {
+	ref, err := parseAppURI(req.Params.URI)
+	if err != nil {
+		return err
+	}
+	namespace, err := s.deps.ResolveNamespace(ref.sessionID)
+	if err != nil {
+		return err
+	}
+	wc, ok := s.deps.Client.(client.WithWatch)
+	if !ok {
+		return errors.New("resource subscriptions are not supported by this server")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subs[namespace] == nil {
+		s.subs[namespace] = map[appSubscriber]bool{}
+	}
+	s.subs[namespace][appSubscriber{session: req.Session, uri: req.Params.URI, name: ref.name}] = true
+	if _, running := s.watches[namespace]; !running {
+		watchCtx, cancel := context.WithCancel(context.Background())
+		s.watches[namespace] = cancel
+		go s.watch(watchCtx, wc, namespace)
+	}
+	return nil
+}
+
+// Unsubscribe is the server's unsubscribe handler.
+func (s *AppSubscriptions) Unsubscribe(ctx context.Context, req *gomcp.UnsubscribeRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for namespace, subs := range s.subs {
+		for sub := range subs {
+			if sub.session == req.Session && sub.uri == req.Params.URI {
+				delete(subs, sub)
+			}
+		}
+		s.stopIfUnused(namespace)
+	}
+	return nil
+}
+
+// stopIfUnused stops the watch of namespace when it has no subscribers.
+// s.mu must be held.
+func (s *AppSubscriptions) stopIfUnused(namespace string) {
+	if len(s.subs[namespace]) > 0 {
+		return
+	}
+	delete(s.subs, namespace)
+	if cancel, ok := s.watches[namespace]; ok {
+		cancel()
+		delete(s.watches, namespace)
+	}
+}
+
+// prune drops the subscribers whose client has disconnected; the server
+// forgets their subscriptions without calling Unsubscribe.
+func (s *AppSubscriptions) prune(namespace string) {
+	live := map[*gomcp.ServerSession]bool{}
+	for ss := range s.server.Sessions() {
+		live[ss] = true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subs[namespace] {
+		if !live[sub.session] {
+			delete(s.subs[namespace], sub)
+		}
+	}
+	s.stopIfUnused(namespace)
+}
+
+// watch notifies subscribers of namespace on every Application event until
+// ctx is cancelled, retrying after API errors.
+func (s *AppSubscriptions) watch(ctx context.Context, c client.WithWatch, namespace string) {
+	for {
+		if err := s.watchOnce(ctx, c, namespace); err != nil && ctx.Err() == nil {
+			s.logger.Warn("watching applications for resource subscriptions", "namespace", namespace, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(appWatchRetryInterval):
+			s.prune(namespace)
+		}
+	}
+}
+
+// watchOnce runs one Application watch on namespace until it ends.
+func (s *AppSubscriptions) watchOnce(ctx context.Context, c client.WithWatch, namespace string) error {
+	var list iafv1alpha1.ApplicationList
+	if err := c.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	w, err := c.Watch(ctx, &iafv1alpha1.ApplicationList{}, &client.ListOptions{
+		Namespace: namespace,
+		Raw:       &metav1.ListOptions{ResourceVersion: list.ResourceVersion},
+	})
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+	seen := make(map[string]appProgress, len(list.Items))
+	for i := range list.Items {
+		seen[list.Items[i].Name] = progressOf(&list.Items[i])
+	}
+	prune := time.NewTicker(appWatchRetryInterval)
+	defer prune.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-prune.C:
+			s.prune(namespace)
+		case event, ok := <-w.ResultChan():
+			if !ok || event.Type == watch.Error {
+				return nil
+			}
+			app, ok := event.Object.(*iafv1alpha1.Application)
+			if !ok {
+				continue
+			}
+			progress, known := seen[app.Name]
+			if event.Type == watch.Deleted {
+				delete(seen, app.Name)
+			} else {
+				seen[app.Name] = progressOf(app)
+				if known && progress == seen[app.Name] {
+					continue
+				}
+			}
+			s.notify(ctx, namespace, app.Name)
+		}
+	}
+}
+
+// notify sends resource update notifications for the application name in
+// namespace and for the application list.
+func (s *AppSubscriptions) notify(ctx context.Context, namespace, name string) {
+	s.mu.Lock()
+	uris := map[string]bool{}
+	for sub := range s.subs[namespace] {
+		if sub.name == "" || sub.name == name {
+			uris[sub.uri] = true
+		}
+	}
+	s.mu.Unlock()
+	for uri := range uris {
+		_ = s.server.ResourceUpdated(ctx, &gomcp.ResourceUpdatedNotificationParams{URI: uri})
+	}
+}
@@ -0,0 +1,226 @@
+package resources_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/mcp/resources"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// setupAppServer creates a server with the application resources registered
+// and a registered session. updated receives the URI of every resource update
+// notification the client gets.
+func setupAppServer(t *testing.T) (cs *gomcp.ClientSession, k8sClient client.Client, sessions *auth.SessionStore, sid, namespace string, updated chan string) {
+	t.Helper()
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&iafv1alpha1.Application{}).Build()
+
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess, err := sessions.Register("test", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+	}
+
+	subs := resources.NewAppSubscriptions(deps, slog.Default())
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, &gomcp.ServerOptions{
+		SubscribeHandler:   subs.Subscribe,
+		UnsubscribeHandler: subs.Unsubscribe,
+	})
+	resources.RegisterApplications(server, deps, subs)
+
+	updated = make(chan string, 16)
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mc := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, &gomcp.ClientOptions{
+		ResourceUpdatedHandler: func(_ context.Context, req *gomcp.ResourceUpdatedNotificationRequest) {
+			updated <- req.Params.URI
+		},
+	})
+	cs, err = mc.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs, k8sClient, sessions, sess.ID, sess.Namespace, updated
+}
+
+func createApp(t *testing.T, k8sClient client.Client, namespace, name string) *iafv1alpha1.Application {
+	t.Helper()
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest"},
+	}
+	if err := k8sClient.Create(context.Background(), app); err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func TestApplicationResources_Read(t *testing.T) {
+	cs, k8sClient, _, sid, namespace, _ := setupAppServer(t)
+	ctx := context.Background()
+
+	app := createApp(t, k8sClient, namespace, "web")
+	app.Status.Phase = iafv1alpha1.ApplicationPhaseRunning
+	app.Status.URL = "http://web.test.example.com"
+	if err := k8sClient.Status().Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	uri := "iaf://apps/web?session_id=" + sid
+	res, err := cs.ReadResource(ctx, &gomcp.ReadResourceParams{URI: uri})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Contents[0].URI != uri || res.Contents[0].MIMEType != "application/json" {
+		t.Errorf("unexpected contents %+v", res.Contents[0])
+	}
+	var got struct {
+		Name   string                        `json:"name"`
+		Status iafv1alpha1.ApplicationStatus `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(res.Contents[0].Text), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "web" || got.Status.Phase != iafv1alpha1.ApplicationPhaseRunning || got.Status.URL != "http://web.test.example.com" {
+		t.Errorf("unexpected application %+v", got)
+	}
+
+	res, err = cs.ReadResource(ctx, &gomcp.ReadResourceParams{URI: "iaf://apps?session_id=" + sid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list struct {
+		Applications []map[string]any `json:"applications"`
+		Total        int              `json:"total"`
+	}
+	if err := json.Unmarshal([]byte(res.Contents[0].Text), &list); err != nil {
+		t.Fatal(err)
+	}
+	if list.Total != 1 || list.Applications[0]["name"] != "web" || list.Applications[0]["phase"] != "Running" {
+		t.Errorf("unexpected list %+v", list)
+	}
+}
+
+func TestApplicationResources_Isolation(t *testing.T) {
+	cs, k8sClient, sessions, sid, _, _ := setupAppServer(t)
+	ctx := context.Background()
+
+	other, err := sessions.Register("other", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	createApp(t, k8sClient, other.Namespace, "secret-app")
+
+	tests := []struct {
+		name string
+		uri  string
+	}{
+		{"other session's app", "iaf://apps/secret-app?session_id=" + sid},
+		{"missing session_id", "iaf://apps/secret-app"},
+		{"unknown session", "iaf://apps/secret-app?session_id=nope"},
+		{"invalid name", "iaf://apps/Bad_Name?session_id=" + sid},
+	}
+	for _, tc := range tests {
+		if _, err := cs.ReadResource(ctx, &gomcp.ReadResourceParams{URI: tc.uri}); err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+	if err := cs.Subscribe(ctx, &gomcp.SubscribeParams{URI: "iaf://apps/secret-app?session_id=nope"}); err == nil {
+		t.Error("expected subscribing with an unknown session to fail")
+	}
+}
+
+func TestApplicationResources_Subscribe(t *testing.T) {
+	cs, k8sClient, _, sid, namespace, updated := setupAppServer(t)
+	ctx := context.Background()
+
+	app := createApp(t, k8sClient, namespace, "web")
+	uri := "iaf://apps/web?session_id=" + sid
+	if err := cs.Subscribe(ctx, &gomcp.SubscribeParams{URI: uri}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changes to other applications do not notify the app's subscribers.
+	createApp(t, k8sClient, namespace, "api")
+
+	// The watch starts in the background, so keep changing the app's phase
+	// until a notification arrives.
+	phases := []iafv1alpha1.ApplicationPhase{iafv1alpha1.ApplicationPhaseBuilding, iafv1alpha1.ApplicationPhaseDeploying}
+	deadline := time.After(5 * time.Second)
+	for i := 0; ; i++ {
+		app.Status.Phase = phases[i%2]
+		if err := k8sClient.Status().Update(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case got := <-updated:
+			if got != uri {
+				t.Fatalf("expected a notification for %s, got %s", uri, got)
+			}
+		case <-time.After(100 * time.Millisecond):
+			continue
+		case <-deadline:
+			t.Fatal("timed out waiting for a resource update notification")
+		}
+		break
+	}
+	// Drop notifications of updates made while waiting.
+	time.Sleep(200 * time.Millisecond)
+	for len(updated) > 0 {
+		<-updated
+	}
+
+	// Status changes that do not affect build or deploy progress are not
+	// notified.
+	app.Status.AvailableReplicas = 1
+	if err := k8sClient.Status().Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-updated:
+		t.Fatalf("unexpected notification for %s", got)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	if err := k8sClient.Delete(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-updated:
+		if got != uri {
+			t.Fatalf("expected a notification for %s, got %s", uri, got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the deletion notification")
+	}
+
+	if err := cs.Unsubscribe(ctx, &gomcp.UnsubscribeParams{URI: uri}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/dlapiduz/iaf/internal/features"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
 	"github.com/dlapiduz/iaf/internal/mcp/tools"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -15,9 +17,26 @@ func RegisterPlatformInfo(server *gomcp.Server, deps *tools.Dependencies) {
 	server.AddResource(&gomcp.Resource{
 		URI:         "iaf://platform",
 		Name:        "platform-info",
-		Description: "IAF platform configuration — supported languages, base stack, deployment methods, defaults, and routing.",
+		Description: "IAF platform configuration — supported languages, base stack, deployment methods, defaults, routing, and the capabilities (tools) this server offers.",
 		MIMEType:    "application/json",
 	}, func(ctx context.Context, req *gomcp.ReadResourceRequest) (*gomcp.ReadResourceResult, error) {
+		servicePlans := iafk8s.BuiltinServicePlans()
+		if deps.Client != nil {
+			var err error
+			if servicePlans, err = iafk8s.ServicePlans(ctx, deps.Client); err != nil {
+				return nil, err
+			}
+		}
+		plans := []map[string]any{}
+		for _, p := range servicePlans {
+			plans = append(plans, map[string]any{
+				"plan":        p.Name,
+				"instances":   p.Spec.Instances,
+				"storage":     p.Spec.Storage.String(),
+				"description": p.Spec.Description,
+			})
+		}
+
 		info := map[string]any{
 			"name":    "Intelligent Application Fabric",
 			"version": "0.1.0",
@@ -28,15 +47,32 @@ func RegisterPlatformInfo(server *gomcp.Server, deps *tools.Dependencies) {
 				"pattern":   fmt.Sprintf("<name>.%s", deps.BaseDomain),
 				"protocol":  "https",
 				"tlsNote":   "TLS is enabled by default via cert-manager. Set spec.tls.enabled=false to opt out.",
+				"protocols": []string{"http", "websocket", "grpc", "tcp"},
+				"protocolNote": "Set protocol on deploy_app. grpc apps must serve h2c (cleartext HTTP/2); tcp apps are reachable on port 443 via TLS SNI and require TLS.",
 			},
 			"supportedLanguages": []string{"go", "nodejs", "python", "java", "ruby"},
 			"buildStack":         "Paketo Jammy LTS (Ubuntu 22.04)",
 			"buildSystem":        "kpack with Cloud Native Buildpacks",
+			"builders":           deps.Builders,
+			"builderNote":        "Pass builder to deploy_app or push_code to build with one of builders instead of the default (the first).",
+			"architectures":      deps.Architectures,
+			"architectureNote":   "Pass architecture to deploy_app or push_code to build for and run on one of architectures; 'multi' runs on amd64 or arm64 nodes. Empty means this platform does not offer a choice.",
+			"workloadClasses":    deps.WorkloadClasses,
+			"workloadClassNote":  "Pass workload_class to deploy_app or push_code to run on the node pool for one of workloadClasses; standard is the default.",
+			"customDNS":          deps.CustomDNS,
+			"customDNSNote":      "When customDNS is true, deploy_app accepts host_aliases and dns_config for reaching systems outside cluster DNS. Cluster-internal names cannot be overridden.",
+			"proxy":              deps.Proxy,
+			"proxyNote":          "When proxy is true, builds and pods get HTTP_PROXY, HTTPS_PROXY and NO_PROXY (and lower-case copies) for the platform's outbound proxy. Make sure your HTTP client honours them (see the language-guide prompt); set spec.proxy.enabled=false to opt out.",
+			"offline":            deps.Offline,
+			"offlineNote":        "When offline is true the cluster has no internet access: deploy images from internal registries, use internal git servers, and install packages through the mirrors in iaf://org/coding-standards (packageMirrors). GitHub tools are unavailable.",
 			"deploymentMethods": []map[string]string{
 				{"method": "image", "description": "Deploy from a pre-built container image"},
 				{"method": "git", "description": "Build and deploy from a git repository"},
 				{"method": "source", "description": "Upload source code via push_code tool, then deploy"},
+				{"method": "copy", "description": "Copy the uploaded source of an app in your session or a template with deploy_app source_app or blob_url"},
 			},
+			"capabilities":     deps.Capabilities.List(),
+			"capabilitiesNote": "The tools this server offers, grouped by category, with what must be true before calling each and example arguments. Every tool except register needs session_id.",
 			"defaults": map[string]any{
 				"port":        8080,
 				"replicas":    1,
@@ -53,18 +89,45 @@ func RegisterPlatformInfo(server *gomcp.Server, deps *tools.Dependencies) {
 						"type":    "postgres",
 						"version": "16",
 						"engine":  "CloudNativePG",
-						"plans": []map[string]any{
-							{"plan": "micro", "instances": 1, "memory": "256Mi", "storage": "1Gi", "useCase": "development"},
-							{"plan": "small", "instances": 1, "memory": "512Mi", "storage": "5Gi", "useCase": "light production"},
-							{"plan": "ha", "instances": 3, "memory": "1Gi", "storage": "10Gi", "useCase": "high-availability production"},
-						},
+						"plans":           plans,
+						"plansNote":       "The plans offered on this platform; list_service_plans also returns their CPU, memory and backup policy.",
 						"injectedEnvVars": []string{"DATABASE_URL", "PGHOST", "PGPORT", "PGDATABASE", "PGUSER", "PGPASSWORD"},
+						"readReplicas":    "on plans with more than one instance, such as ha, bind_service with bind_mode 'ro' or 'both' also injects PGHOST_RO and DATABASE_READ_URL for the read replicas",
 					},
 				},
 				"workflow": "provision_service → poll service_status every 10s until Ready → bind_service → use DATABASE_URL in application",
 			},
 		}
 
+		templates := []map[string]string{}
+		if deps.Store != nil {
+			published, err := deps.Store.Templates()
+			if err != nil {
+				return nil, err
+			}
+			for _, t := range published {
+				templates = append(templates, map[string]string{"name": t.Name, "blobUrl": t.Blob.URL})
+			}
+		}
+		info["templates"] = templates
+		if deps.Features != nil {
+			info["features"] = deps.Features.List()
+			info["featuresNote"] = "Optional features and whether this platform turns them on; get_capabilities returns the same list. Calls that need a disabled feature fail with code capability_disabled."
+			if deps.Features.Off(features.TLS) {
+				routing := info["routing"].(map[string]any)
+				routing["protocol"] = "http"
+				routing["tlsNote"] = "TLS is not enabled on this platform: apps are served over plain HTTP."
+			}
+		}
+		if err := deps.Maintenance.Err(); err != nil {
+			info["maintenance"] = err.Response()
+		}
+		if deps.Health != nil {
+			info["health"] = deps.Health.Check(ctx)
+			info["healthNote"] = "Status of the systems the platform depends on. When status is failing, deploys and builds may not work until an operator fixes the failing component; not_configured components mean the features using them are off."
+		}
+		info["templatesNote"] = "Starter apps published by the platform operators. Pass a template's blobUrl as blob_url to deploy_app to build a copy as your own app, then change it with push_code."
+
 		data, err := json.MarshalIndent(info, "", "  ")
 		if err != nil {
 			return nil, fmt.Errorf("marshaling platform info: %w", err)
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/dlapiduz/iaf/internal/features"
 	"github.com/dlapiduz/iaf/internal/mcp/resources"
 	"github.com/dlapiduz/iaf/internal/mcp/tools"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
@@ -16,7 +17,11 @@ func setupServer(t *testing.T) *gomcp.ClientSession {
 	ctx := context.Background()
 
 	deps := &tools.Dependencies{
-		BaseDomain: "test.example.com",
+		BaseDomain:      "test.example.com",
+		Builders:        []string{"iaf-cluster-builder", "java-native"},
+		Architectures:   []string{"amd64", "arm64"},
+		WorkloadClasses: []string{"gpu", "standard"},
+		Features:        features.Flags{features.GitHub: true},
 	}
 
 	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
@@ -86,6 +91,35 @@ func TestPlatformInfo(t *testing.T) {
 		t.Errorf("expected 5 supported languages, got %d", len(langs))
 	}
 
+	if builders, _ := info["builders"].([]any); len(builders) != 2 || builders[1] != "java-native" {
+		t.Errorf("expected the allowed builders, got %v", info["builders"])
+	}
+	if archs, _ := info["architectures"].([]any); len(archs) != 2 || archs[1] != "arm64" {
+		t.Errorf("expected the available architectures, got %v", info["architectures"])
+	}
+	if classes, _ := info["workloadClasses"].([]any); len(classes) != 2 || classes[0] != "gpu" {
+		t.Errorf("expected the available workload classes, got %v", info["workloadClasses"])
+	}
+	if info["proxy"] != false {
+		t.Errorf("expected proxy false, got %v", info["proxy"])
+	}
+	if info["offline"] != false {
+		t.Errorf("expected offline false, got %v", info["offline"])
+	}
+
+	enabled := map[string]bool{}
+	flags, _ := info["features"].([]any)
+	for _, f := range flags {
+		f, _ := f.(map[string]any)
+		enabled[f["name"].(string)] = f["enabled"].(bool)
+	}
+	if tls, ok := enabled["tls"]; !ok || tls || !enabled["github"] {
+		t.Errorf("expected github on and tls off, got %v", info["features"])
+	}
+	if routing, _ := info["routing"].(map[string]any); routing["protocol"] != "http" {
+		t.Errorf("expected http routing without TLS, got %v", info["routing"])
+	}
+
 	defaults, ok := info["defaults"].(map[string]any)
 	if !ok {
 		t.Fatal("expected defaults to be an object")
@@ -1,14 +1,27 @@
 package mcp
 
 import (
+	"log/slog"
 	"time"
 
+	"github.com/dlapiduz/iaf/internal/audit"
 	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/budget"
+	"github.com/dlapiduz/iaf/internal/cost"
+	"github.com/dlapiduz/iaf/internal/features"
 	iafgithub "github.com/dlapiduz/iaf/internal/github"
+	"github.com/dlapiduz/iaf/internal/grafana"
+	"github.com/dlapiduz/iaf/internal/idempotency"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/maintenance"
 	"github.com/dlapiduz/iaf/internal/mcp/prompts"
 	"github.com/dlapiduz/iaf/internal/mcp/resources"
 	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+	"github.com/dlapiduz/iaf/internal/platformhealth"
+	"github.com/dlapiduz/iaf/internal/policy"
 	"github.com/dlapiduz/iaf/internal/sourcestore"
+	"github.com/dlapiduz/iaf/internal/uptime"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -22,32 +35,12 @@ const serverInstructions = `You are connected to the IAF (Intelligent Applicatio
 CRITICAL FIRST STEP: Before calling any other tool, you MUST call the "register" tool to get a session_id. Every other tool requires session_id as a parameter. Without it, all tool calls will fail.
 
 QUICK START:
-1. Call "register" (with an optional friendly name) → returns a session_id
+1. Call "register" (with an optional friendly name) → returns a session_id (CALL THIS FIRST)
 2. Call "push_code" with your session_id, an app name, and a map of file paths to contents → builds and deploys your code
 3. Call "app_status" with your session_id and app name → check build/deploy progress
 4. Once status is "Running", your app is live at http://<app-name>.<base-domain>
 
-AVAILABLE TOOLS (all require session_id except register):
-- register: Get a session_id (CALL THIS FIRST)
-- unregister: Clean up session and all its resources when you are done (irreversible)
-- push_code: Upload source code files to build and deploy (provide files as {"path": "content"} map)
-- deploy_app: Deploy from a container image or git repo (use git_credential for private repos)
-- list_apps: See all your deployed apps
-- app_status: Check build/deploy progress for an app
-- app_logs: View application or build logs
-- delete_app: Remove an app and its resources
-- add_git_credential: Store a git credential (username/password or SSH key) for private repo access
-- list_git_credentials: List stored git credentials (no secrets returned)
-- delete_git_credential: Remove a git credential
-- list_data_sources: List all platform data sources (databases, APIs, etc.)
-- get_data_source: Get details about a specific data source including env var names
-- attach_data_source: Attach a data source to your app (injects credentials as env vars)
-- provision_service: Provision a managed backing service (e.g. PostgreSQL) — poll service_status every 10s until Ready
-- service_status: Check provisioning status; returns connectionEnvVars when Ready
-- bind_service: Inject service credentials into an app as K8s Secret references
-- unbind_service: Remove service credentials from an app
-- deprovision_service: Delete a managed service (must unbind all apps first)
-- list_services: List all managed services in your namespace
+TOOLS: every tool except register requires session_id. Each tool's description explains it; the "Available Tools" section of the deploy-guide prompt and "capabilities" in the iaf://platform resource list the tools this server offers by task, with their preconditions and example arguments.
 
 KEY DETAILS:
 - Apps are built automatically using Cloud Native Buildpacks (Go, Node.js, Python, Java, Ruby)
@@ -56,6 +49,15 @@ KEY DETAILS:
 - Each session gets its own isolated Kubernetes namespace
 - Use app_status to monitor builds — WAIT 30 seconds between each poll during Building, 15 seconds during Deploying. The response includes a "pollIntervalSeconds" field — always respect it. Builds typically take ~2 minutes; do not poll faster than the hint.
 - Use app_logs with build_logs=true to debug build failures
+- When an app is Failed or stuck, get the troubleshoot-guide prompt with session_id and name — it diagnoses the app from its live state and tells you what to fix
+- Clients that support resource subscriptions can subscribe to iaf://apps/<app-name>?session_id=<id> (or iaf://apps?session_id=<id> for all apps) and read it when notified instead of polling app_status
+- deploy_app, push_code and provision_service accept an idempotency_key (e.g. a UUID). When a call times out, retry it with the same key and input: if the first attempt succeeded you get its result back instead of a duplicate or a name_taken error
+- app_status reports a "version" that changes whenever the app's configuration does. Pass it as expected_version to push_code or set_config_file so you do not overwrite a change someone else made since you read it; on version_conflict, the error's details hold the current version and spec — reapply your change to them and retry
+- Optional features such as TLS, GitHub, log links and managed services can be off on a platform. Call get_capabilities (or read iaf://platform) before relying on one; a call that needs a disabled feature fails with code capability_disabled, and retrying it will not help
+- During platform maintenance every tool that changes something fails with code maintenance; status, list and log tools keep working. Do not retry in a loop: wait until the time in the error's details.until (or its hint), then retry
+- When resuming work in a session you lost track of, call session_history to see the tool calls already made, then session_overview for the current state
+- A result too large for the response size limit has a "truncated" field; call continue_result with its continuation to read the rest
+- Failed tool calls return JSON with "error", "code", "category" (validation, not_found, conflict, quota or platform), "retryable" and sometimes "hint". Branch on code; retry unchanged only when retryable is true. On session_not_found, call register again
 
 CODING STANDARDS:
 - Read the coding-guide prompt for organisation coding standards before writing any code
@@ -66,50 +68,183 @@ IMPORTANT RULES (follow for every app):
 2. Use version control if the setup_github_repo tool is available — commit code before deploying
 3. No in-memory storage (arrays, maps, global variables for data) — apps restart and lose state; use a managed database instead (provision_service for PostgreSQL, or attach a data source)
 4. Follow 12-factor app principles: config via env vars, stateless processes, explicit dependencies, stdout logging
-5. Authentication on admin/sensitive routes is encouraged but NOT required on read-only public endpoints`
+5. Authentication on admin/sensitive routes is encouraged but NOT required on read-only public endpoints. For internal tools, deploy with authentication "basic" or "oauth-proxy" instead of writing your own login`
+
+// Options configures the MCP server NewServer creates. The zero value of a
+// field turns its feature off or uses the platform default.
+type Options struct {
+	// BaseDomain is the domain apps are served under, as <app>.<BaseDomain>.
+	BaseDomain string
+	// GitHub is the platform GitHub client; nil omits the GitHub tools.
+	// GitHubOrg is the org repositories are created in, and GitHubToken
+	// the token pushes use.
+	GitHub      iafgithub.Client
+	GitHubOrg   string
+	GitHubToken string
+	// GitHubTemplates are the repo templates setup_github_repo can seed;
+	// nil means the built-in ones.
+	GitHubTemplates *iafgithub.RepoTemplates
+	// Grafana drives the deep links in app_status responses.
+	Grafana grafana.Config
+	// AlertRuleLabels are added to the PrometheusRules set_alert creates.
+	AlertRuleLabels map[string]string
+	// Uptime reports uptime check results, and Costs prices resource
+	// usage. Both are nil without the platform Prometheus.
+	Uptime uptime.Querier
+	Costs  *cost.Estimator
+	// Builders lists the ClusterBuilders apps may select, the default
+	// first, Architectures the CPU architectures they may target, and
+	// WorkloadClasses the workload classes they may use.
+	Builders        []string
+	Architectures   []string
+	WorkloadClasses []string
+	// CustomDNS lets deploy_app set host aliases and DNS settings.
+	CustomDNS bool
+	// Offline marks an air-gapped platform.
+	Offline bool
+	// Proxy marks a platform that sends app traffic through an outbound
+	// HTTP proxy.
+	Proxy bool
+	// KubeAPIServer is the API server URL for get_namespace_credentials;
+	// empty omits the tool.
+	KubeAPIServer string
+	// SessionTTL is the idle TTL of new sessions; 0 means no expiry.
+	SessionTTL time.Duration
+	// OrgStandards are the org coding standards standards_check applies;
+	// nil means the platform defaults.
+	OrgStandards *orgstandards.Loader
+	// Features are the optional features the platform turns on, reported
+	// by get_capabilities.
+	Features features.Flags
+	// When EnabledTools is set only those tools are offered;
+	// DisabledTools are never offered.
+	EnabledTools  []string
+	DisabledTools []string
+	// MaxResultBytes shortens larger tool results; 0 means no limit.
+	MaxResultBytes int
+	// History records the tool calls of each session for session_history;
+	// nil omits the tool.
+	History *audit.Log
+	// SecurityAudit records credential and Secret access; nil records
+	// nothing.
+	SecurityAudit *audit.SecurityLog
+	// Health checks the systems the platform depends on, for the
+	// iaf://platform resource.
+	Health *platformhealth.Checker
+	// Maintenance, while non-nil, lets only read-only tools work.
+	Maintenance *maintenance.Mode
+	// Tool calls running longer than ToolTimeout, or their own limit in
+	// ToolTimeouts, fail with code deadline_exceeded; 0 means no limit.
+	ToolTimeout  time.Duration
+	ToolTimeouts map[string]time.Duration
+	// KnownHosts is the platform ConfigMap of trusted SSH git server host
+	// keys.
+	KnownHosts iafk8s.KnownHosts
+	// Clientset, when set, lets app_logs stream real logs from pods.
+	Clientset kubernetes.Interface
+}
 
 // NewServer creates and configures the MCP server with all tools.
-// ghClient may be nil — GitHub tools are omitted when it is not set.
-// If clientset is non-nil, app_logs will stream real logs from pods.
-// sessionTTL sets the idle TTL for new sessions (0 = no expiry).
-func NewServer(k8sClient client.Client, sessions *auth.SessionStore, store *sourcestore.Store, baseDomain string, ghClient iafgithub.Client, ghOrg, ghToken string, tempoURL string, sessionTTL time.Duration, clientset ...kubernetes.Interface) *gomcp.Server {
+func NewServer(k8sClient client.Client, sessions *auth.SessionStore, store *sourcestore.Store, opts Options) *gomcp.Server {
+	toolFilter := tools.NewToolFilter(opts.EnabledTools, opts.DisabledTools)
+	deps := &tools.Dependencies{
+		Client:          k8sClient,
+		Store:           store,
+		BaseDomain:      opts.BaseDomain,
+		Sessions:        sessions,
+		GitHub:          opts.GitHub,
+		GitHubOrg:       opts.GitHubOrg,
+		GitHubToken:     opts.GitHubToken,
+		GitHubTemplates: opts.GitHubTemplates,
+		Grafana:         opts.Grafana,
+		AlertRuleLabels: opts.AlertRuleLabels,
+		Uptime:          opts.Uptime,
+		Costs:           opts.Costs,
+		Builders:        opts.Builders,
+		Architectures:   opts.Architectures,
+		WorkloadClasses: opts.WorkloadClasses,
+		CustomDNS:       opts.CustomDNS,
+		Offline:         opts.Offline,
+		Proxy:           opts.Proxy,
+		KubeAPIServer:   opts.KubeAPIServer,
+		SessionTTL:      opts.SessionTTL,
+		Policy:          policy.New(k8sClient),
+		Idempotency:     idempotency.NewStore[*gomcp.CallToolResult](idempotency.DefaultTTL),
+		OrgStandards:    opts.OrgStandards,
+		Capabilities:    &tools.Capabilities{},
+		Health:          opts.Health,
+		Features:        opts.Features,
+		Tools:           toolFilter,
+		Budget:          budget.New(opts.MaxResultBytes),
+		History:         opts.History,
+		SecurityAudit:   opts.SecurityAudit,
+		Maintenance:     opts.Maintenance,
+		Timeouts:        tools.Timeouts{Default: opts.ToolTimeout, PerTool: opts.ToolTimeouts},
+		KnownHosts:      opts.KnownHosts,
+	}
+
+	appSubs := resources.NewAppSubscriptions(deps, slog.Default())
 	server := gomcp.NewServer(
 		&gomcp.Implementation{
 			Name:    "iaf",
 			Version: "0.1.0",
 		},
 		&gomcp.ServerOptions{
-			Instructions: serverInstructions,
+			Instructions:       serverInstructions,
+			SubscribeHandler:   appSubs.Subscribe,
+			UnsubscribeHandler: appSubs.Unsubscribe,
 		},
 	)
 
-	deps := &tools.Dependencies{
-		Client:      k8sClient,
-		Store:       store,
-		BaseDomain:  baseDomain,
-		Sessions:    sessions,
-		GitHub:      ghClient,
-		GitHubOrg:   ghOrg,
-		GitHubToken: ghToken,
-		TempoURL:    tempoURL,
-		SessionTTL:  sessionTTL,
-	}
+	server.AddReceivingMiddleware(toolErrors)
 
 	tools.RegisterRegisterTool(server, deps)
 	tools.RegisterUnregisterTool(server, deps)
+	tools.RegisterGetCapabilities(server, deps)
 	tools.RegisterDeployApp(server, deps)
 	tools.RegisterPushCode(server, deps)
+	tools.RegisterPushCodeChunk(server, deps)
+	tools.RegisterStandardsCheck(server, deps)
+	tools.RegisterPlanUpdate(server, deps)
+	tools.RegisterCreatePreview(server, deps)
+	tools.RegisterCreateEnvironment(server, deps)
+	tools.RegisterPromoteApp(server, deps)
+	tools.RegisterExportApp(server, deps)
 	tools.RegisterAddGitCredential(server, deps)
+	tools.RegisterUpdateGitCredential(server, deps)
 	tools.RegisterListGitCredentials(server, deps)
 	tools.RegisterDeleteGitCredential(server, deps)
+	tools.RegisterAddRegistryCredential(server, deps)
+	tools.RegisterListRegistryCredentials(server, deps)
+	tools.RegisterDeleteRegistryCredential(server, deps)
+	tools.RegisterCreateEnvGroup(server, deps)
+	tools.RegisterBindEnvGroup(server, deps)
+	tools.RegisterUnbindEnvGroup(server, deps)
+	tools.RegisterListEnvGroups(server, deps)
+	tools.RegisterDeleteEnvGroup(server, deps)
 	tools.RegisterAppStatus(server, deps)
-	if len(clientset) > 0 && clientset[0] != nil {
-		tools.RegisterAppLogsWithClientset(server, deps, clientset[0])
+	tools.RegisterSetAlert(server, deps)
+	tools.RegisterListAlerts(server, deps)
+	tools.RegisterDeleteAlert(server, deps)
+	tools.RegisterSessionCost(server, deps)
+	tools.RegisterAppCost(server, deps)
+	logClientset := opts.Clientset
+	if logClientset != nil {
+		tools.RegisterAppLogsWithClientset(server, deps, logClientset)
 	} else {
 		tools.RegisterAppLogs(server, deps)
 	}
 	tools.RegisterListApps(server, deps)
+	tools.RegisterSessionOverview(server, deps)
+	tools.RegisterDescribeTopology(server, deps)
 	tools.RegisterDeleteApp(server, deps)
+	tools.RegisterSetConfigFile(server, deps)
+	tools.RegisterSuspendApp(server, deps)
+	tools.RegisterResumeApp(server, deps)
+	tools.RegisterRestoreApp(server, deps)
+	tools.RegisterSetAutoDeploy(server, deps)
+	tools.RegisterSetDeletionProtection(server, deps)
+	tools.RegisterGetAppCredentials(server, deps)
 	tools.RegisterListDataSources(server, deps)
 	tools.RegisterGetDataSource(server, deps)
 	tools.RegisterAttachDataSource(server, deps)
@@ -119,18 +254,46 @@ func NewServer(k8sClient client.Client, sessions *auth.SessionStore, store *sour
 	tools.RegisterUnbindService(server, deps)
 	tools.RegisterDeprovisionService(server, deps)
 	tools.RegisterListServices(server, deps)
+	tools.RegisterListServicePlans(server, deps)
+	tools.RegisterRunMigration(server, deps)
+	tools.RegisterMigrationStatus(server, deps)
+	tools.RegisterDeployStack(server, deps)
+	tools.RegisterStackStatus(server, deps)
 
 	prompts.RegisterDeployGuide(server, deps)
 	prompts.RegisterServicesGuide(server, deps)
+	prompts.RegisterTroubleshootGuide(server, deps, logClientset)
 
 	resources.RegisterPlatformInfo(server, deps)
 	resources.RegisterApplicationSpec(server, deps)
 	resources.RegisterDataCatalog(server, deps)
+	resources.RegisterApplications(server, deps, appSubs)
+
+	// Result continuation — registered only when results are shortened.
+	if deps.Budget != nil {
+		tools.RegisterContinueResult(server, deps)
+	}
+
+	// Session history — registered only when tool calls are recorded.
+	if deps.History != nil {
+		tools.RegisterSessionHistory(server, deps)
+	}
+
+	// Namespace credentials — registered only when agents can reach the API server.
+	if deps.KubeAPIServer != "" {
+		tools.RegisterGetNamespaceCredentials(server, deps)
+	}
 
 	// GitHub components — registered only when a token and org are configured.
 	if deps.GitHub != nil {
 		tools.RegisterSetupGithubRepo(server, deps)
-		prompts.RegisterGitHubGuide(server, deps)
+		if toolFilter.Allows("setup_github_repo") {
+			prompts.RegisterGitHubGuide(server, deps)
+		}
+	}
+
+	if unknown := toolFilter.Unknown(); len(unknown) > 0 {
+		slog.Default().Warn("IAF_ENABLED_TOOLS or IAF_DISABLED_TOOLS names tools this server does not have", "tools", unknown)
 	}
 
 	return server
@@ -43,7 +43,7 @@ func setupIntegrationServer(t *testing.T) *gomcp.ClientSession {
 		t.Fatal(err)
 	}
 
-	server := iafmcp.NewServer(k8sClient, sessions, store, "test.example.com", nil, "", "", "", 0)
+	server := iafmcp.NewServer(k8sClient, sessions, store, iafmcp.Options{BaseDomain: "test.example.com"})
 
 	st, ct := gomcp.NewInMemoryTransports()
 	if _, err := server.Connect(ctx, st, nil); err != nil {
@@ -89,16 +89,49 @@ func TestNewServer_RegistersAllTools(t *testing.T) {
 		"register",
 		"deploy_app",
 		"push_code",
+		"standards_check",
+		"plan_update",
+		"create_preview",
+		"create_environment",
+		"promote_app",
+		"deploy_stack",
+		"stack_status",
+		"export_app",
 		"app_status",
 		"app_logs",
+		"set_alert",
+		"list_alerts",
+		"delete_alert",
+		"session_cost",
+		"app_cost",
 		"list_apps",
+		"session_overview",
+		"describe_topology",
 		"delete_app",
+		"set_config_file",
+		"suspend_app",
+		"resume_app",
+		"restore_app",
+		"set_auto_deploy",
+		"set_deletion_protection",
+		"get_app_credentials",
 		"add_git_credential",
+		"update_git_credential",
 		"list_git_credentials",
 		"delete_git_credential",
+		"add_registry_credential",
+		"list_registry_credentials",
+		"delete_registry_credential",
+		"create_env_group",
+		"bind_env_group",
+		"unbind_env_group",
+		"list_env_groups",
+		"delete_env_group",
 		"list_data_sources",
 		"get_data_source",
 		"attach_data_source",
+		"run_migration",
+		"migration_status",
 	}
 
 	toolNames := map[string]bool{}
@@ -112,6 +145,53 @@ func TestNewServer_RegistersAllTools(t *testing.T) {
 	}
 }
 
+// TestNewServer_CapabilitiesMatchTools verifies that the deploy-guide and
+// iaf://platform list exactly the tools the server offers, and that every
+// tool carries its capability metadata.
+func TestNewServer_CapabilitiesMatchTools(t *testing.T) {
+	cs := setupIntegrationServer(t)
+	ctx := context.Background()
+
+	res, err := cs.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	guide, err := cs.GetPrompt(ctx, &gomcp.GetPromptParams{Name: "deploy-guide"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	guideText := guide.Messages[0].Content.(*gomcp.TextContent).Text
+	platform, err := cs.ReadResource(ctx, &gomcp.ReadResourceParams{URI: "iaf://platform"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var info struct {
+		Capabilities []struct{ Name, Category string }
+	}
+	if err := json.Unmarshal([]byte(platform.Contents[0].Text), &info); err != nil {
+		t.Fatal(err)
+	}
+
+	listed := map[string]bool{}
+	for _, c := range info.Capabilities {
+		listed[c.Name] = true
+	}
+	if len(info.Capabilities) != len(res.Tools) {
+		t.Errorf("iaf://platform lists %d capabilities, the server offers %d tools", len(info.Capabilities), len(res.Tools))
+	}
+	for _, tool := range res.Tools {
+		if !listed[tool.Name] {
+			t.Errorf("tool %q missing from iaf://platform capabilities", tool.Name)
+		}
+		if !strings.Contains(guideText, "- `"+tool.Name+"` — ") {
+			t.Errorf("tool %q missing from the deploy-guide", tool.Name)
+		}
+		if tool.Meta["iaf.io/category"] == nil {
+			t.Errorf("tool %q has no category in its _meta", tool.Name)
+		}
+	}
+}
+
 func TestNewServer_RegistersAllPrompts(t *testing.T) {
 	cs := setupIntegrationServer(t)
 	ctx := context.Background()
@@ -121,7 +201,7 @@ func TestNewServer_RegistersAllPrompts(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	expectedPrompts := []string{"deploy-guide", "services-guide"}
+	expectedPrompts := []string{"deploy-guide", "services-guide", "troubleshoot-guide"}
 	promptNames := map[string]bool{}
 	for _, p := range res.Prompts {
 		promptNames[p.Name] = true
@@ -152,6 +232,20 @@ func TestNewServer_RegistersAllResources(t *testing.T) {
 			t.Errorf("expected resource %q to be registered", name)
 		}
 	}
+
+	templates, err := cs.ListResourceTemplates(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	templateNames := map[string]bool{}
+	for _, rt := range templates.ResourceTemplates {
+		templateNames[rt.Name] = true
+	}
+	for _, name := range []string{"applications", "application"} {
+		if !templateNames[name] {
+			t.Errorf("expected resource template %q to be registered", name)
+		}
+	}
 }
 
 func setupGitHubIntegrationServer(t *testing.T) *gomcp.ClientSession {
@@ -176,7 +270,7 @@ func setupGitHubIntegrationServer(t *testing.T) *gomcp.ClientSession {
 	}
 
 	ghClient := &iafgithub.MockClient{}
-	server := iafmcp.NewServer(k8sClient, sessions, store, "test.example.com", ghClient, "test-org", "test-token", "", 0)
+	server := iafmcp.NewServer(k8sClient, sessions, store, iafmcp.Options{BaseDomain: "test.example.com", GitHub: ghClient, GitHubOrg: "test-org", GitHubToken: "test-token"})
 
 	st, ct := gomcp.NewInMemoryTransports()
 	if _, err := server.Connect(ctx, st, nil); err != nil {
@@ -248,6 +342,67 @@ func TestNewServer_WithoutGitHub_NoGitHubComponents(t *testing.T) {
 	}
 }
 
+func TestNewServer_ToolFilter(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	listTools := func(enabled, disabled []string) (*gomcp.ClientSession, map[string]bool) {
+		t.Helper()
+		server := iafmcp.NewServer(k8sClient, sessions, store, iafmcp.Options{BaseDomain: "test.example.com", GitHub: &iafgithub.MockClient{}, GitHubOrg: "test-org", GitHubToken: "test-token", EnabledTools: enabled, DisabledTools: disabled})
+		st, ct := gomcp.NewInMemoryTransports()
+		if _, err := server.Connect(ctx, st, nil); err != nil {
+			t.Fatal(err)
+		}
+		cs, err := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil).Connect(ctx, ct, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { cs.Close() })
+		res, err := cs.ListTools(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := map[string]bool{}
+		for _, tool := range res.Tools {
+			names[tool.Name] = true
+		}
+		return cs, names
+	}
+
+	cs, names := listTools(nil, []string{"delete_app", " setup_github_repo", "register"})
+	if names["delete_app"] || names["setup_github_repo"] || !names["register"] || !names["deploy_app"] {
+		t.Errorf("unexpected tools with delete_app and setup_github_repo disabled: %v", names)
+	}
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "delete_app", Arguments: map[string]any{"session_id": "x", "name": "web"}})
+	if err == nil && !res.IsError {
+		t.Error("expected a call to a disabled tool to fail")
+	}
+	prompts, err := cs.ListPrompts(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range prompts.Prompts {
+		if p.Name == "github-guide" {
+			t.Error("expected no github-guide with setup_github_repo disabled")
+		}
+	}
+
+	_, names = listTools([]string{"app_status", "list_apps"}, []string{"list_apps"})
+	if len(names) != 2 || !names["register"] || !names["app_status"] {
+		t.Errorf("expected only register and app_status, got %v", names)
+	}
+}
+
 // setupServerForLogs creates a server+k8sClient pair, optionally wiring a
 // fake Kubernetes clientset for log streaming.
 func setupServerForLogs(t *testing.T, withClientset bool) (*gomcp.ClientSession, ctrlclient.Client) {
@@ -271,9 +426,9 @@ func setupServerForLogs(t *testing.T, withClientset bool) (*gomcp.ClientSession,
 	var server *gomcp.Server
 	if withClientset {
 		cs := k8sfake.NewSimpleClientset()
-		server = iafmcp.NewServer(k8sClient, sessions, store, "test.example.com", nil, "", "", "", 0, cs)
+		server = iafmcp.NewServer(k8sClient, sessions, store, iafmcp.Options{BaseDomain: "test.example.com", Clientset: cs})
 	} else {
-		server = iafmcp.NewServer(k8sClient, sessions, store, "test.example.com", nil, "", "", "", 0)
+		server = iafmcp.NewServer(k8sClient, sessions, store, iafmcp.Options{BaseDomain: "test.example.com"})
 	}
 
 	st, ct := gomcp.NewInMemoryTransports()
@@ -367,3 +522,56 @@ func TestAppLogs_EnabledWithClientset(t *testing.T) {
 		t.Errorf("expected 'No pods found' with no pods, got: %s", text)
 	}
 }
+
+func TestNewServer_StructuredToolErrors(t *testing.T) {
+	cs := setupIntegrationServer(t)
+	ctx := context.Background()
+
+	regRes, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "register",
+		Arguments: map[string]any{"name": "test"},
+	})
+	if err != nil || regRes.IsError {
+		t.Fatal("register failed")
+	}
+	var reg map[string]any
+	_ = json.Unmarshal([]byte(regRes.Content[0].(*gomcp.TextContent).Text), &reg)
+	sid := reg["session_id"].(string)
+
+	tests := []struct {
+		name         string
+		args         map[string]any
+		wantCode     string
+		wantCategory string
+		wantHint     bool
+	}{
+		{"unknown session", map[string]any{"session_id": "nope", "name": "web"}, "session_not_found", "not_found", true},
+		{"unknown app", map[string]any{"session_id": sid, "name": "web"}, "app_not_found", "not_found", false},
+		{"invalid name", map[string]any{"session_id": sid, "name": "Not_Valid"}, "invalid_request", "validation", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "app_status", Arguments: tt.args})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !res.IsError {
+				t.Fatal("expected an error result")
+			}
+			text := res.Content[0].(*gomcp.TextContent).Text
+			var out struct {
+				Error     string `json:"error"`
+				Code      string `json:"code"`
+				Category  string `json:"category"`
+				Retryable bool   `json:"retryable"`
+				Hint      string `json:"hint"`
+			}
+			if err := json.Unmarshal([]byte(text), &out); err != nil {
+				t.Fatalf("expected a JSON error, got %q", text)
+			}
+			if out.Code != tt.wantCode || out.Category != tt.wantCategory || out.Retryable || out.Error == "" || (out.Hint != "") != tt.wantHint {
+				t.Errorf("unexpected error %s", text)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const maxAlertsPerSession = 20
+
+// errAlertingUnavailable is returned when the PrometheusRule CRD is missing.
+var errAlertingUnavailable = errors.New("alerting is not available on this platform: the Prometheus Operator is not installed")
+
+// SetAlertInput is the input for the set_alert tool.
+type SetAlertInput struct {
+	SessionID string  `json:"session_id"         jsonschema:"required - session ID from the register tool"`
+	Name      string  `json:"name"               jsonschema:"required - alert name (DNS label: lowercase alphanumeric and hyphens); setting an existing alert replaces it"`
+	App       string  `json:"app"                jsonschema:"required - name of the application the alert watches"`
+	Type      string  `json:"type"               jsonschema:"required - alert template: 'error_rate' (percent of requests failing with 5xx), 'latency_p95' (95th percentile request duration in seconds), 'pod_restarts' (container restarts in 15 minutes), 'uptime' (percent of successful uptime checks in 15 minutes)"`
+	Threshold float64 `json:"threshold"          jsonschema:"required - the alert fires above this value: a percentage (0-100] for error_rate, seconds (0-60] for latency_p95, a whole number of restarts (0-100) for pod_restarts; uptime fires below a percentage (0-100]"`
+	For       string  `json:"for,omitempty"      jsonschema:"how long the condition must hold before the alert fires, e.g. '5m' (1m to 1h; default: 5m)"`
+	Severity  string  `json:"severity,omitempty" jsonschema:"'warning' (default) or 'critical'"`
+}
+
+// ListAlertsInput is the input for the list_alerts tool.
+type ListAlertsInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID from the register tool"`
+}
+
+// DeleteAlertInput is the input for the delete_alert tool.
+type DeleteAlertInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID from the register tool"`
+	Name      string `json:"name"       jsonschema:"required - alert name to delete"`
+}
+
+// RegisterSetAlert registers the set_alert MCP tool.
+func RegisterSetAlert(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "set_alert",
+		Category: CategoryObserve,
+		Summary:  "Alert on an app's error rate, p95 latency, pod restarts or uptime",
+		Examples: []string{`{"session_id": "<id>", "name": "web-errors", "app": "web", "type": "error_rate", "threshold": 5}`},
+	}, &gomcp.Tool{
+		Description: "Create or replace an alert on one of your applications from a fixed set of templates: error_rate, latency_p95, pod_restarts, or uptime. Notifications go through the platform Alertmanager; you cannot choose receivers. error_rate and latency_p95 need the app to expose the standard http_requests_total and http_request_duration_seconds metrics (see iaf://org/metrics-standards); uptime needs an app deployed with uptime_check_path. The alert is deleted with its application.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input SetAlertInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := validation.ValidateAppName(input.Name); err != nil {
+			return nil, nil, fmt.Errorf("invalid alert name: %w", err)
+		}
+		if err := validation.ValidateAppName(input.App); err != nil {
+			return nil, nil, err
+		}
+		alert := iafk8s.Alert{
+			Name:      input.Name,
+			Type:      iafk8s.AlertType(input.Type),
+			Threshold: input.Threshold,
+			For:       input.For,
+			Severity:  input.Severity,
+		}
+		if alert.For == "" {
+			alert.For = "5m"
+		}
+		if alert.Severity == "" {
+			alert.Severity = "warning"
+		}
+		if err := alert.Validate(); err != nil {
+			return nil, nil, err
+		}
+
+		var app iafv1alpha1.Application
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.App, Namespace: namespace}, &app); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.App)
+			}
+			return nil, nil, fmt.Errorf("getting application: %w", err)
+		}
+
+		if alert.Type == iafk8s.AlertTypeUptime && !iafk8s.HasUptimeCheck(&app) {
+			return nil, nil, fmt.Errorf("application %q has no uptime check; deploy it with uptime_check_path to use uptime alerts", input.App)
+		}
+
+		rule, err := iafk8s.BuildPrometheusRule(&app, alert, deps.AlertRuleLabels)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		existing := &unstructured.Unstructured{}
+		existing.SetGroupVersionKind(iafk8s.PrometheusRuleGVK)
+		err = deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, existing)
+		switch {
+		case meta.IsNoMatchError(err):
+			return nil, nil, errAlertingUnavailable
+		case apierrors.IsNotFound(err):
+			alerts, err := listAlerts(ctx, deps, namespace)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(alerts.Items) >= maxAlertsPerSession {
+				return nil, nil, apierror.Quota(apierror.CodeQuotaExceeded, "alert limit reached: a session may have at most %d alerts; delete an existing one before adding a new one", maxAlertsPerSession)
+			}
+			if err := deps.Client.Create(ctx, rule); err != nil {
+				return nil, nil, fmt.Errorf("creating alert: %w", err)
+			}
+		case err != nil:
+			return nil, nil, fmt.Errorf("getting alert: %w", err)
+		default:
+			if existing.GetLabels()[iafk8s.LabelAlert] != "true" {
+				return nil, nil, fmt.Errorf("PrometheusRule %q is not an alert managed by IAF", input.Name)
+			}
+			rule.SetResourceVersion(existing.GetResourceVersion())
+			if err := deps.Client.Update(ctx, rule); err != nil {
+				return nil, nil, fmt.Errorf("updating alert: %w", err)
+			}
+		}
+
+		result := alertInfo(rule)
+		result["message"] = fmt.Sprintf("Alert %q is set on %s. It fires when the condition holds for %s.", input.Name, input.App, alert.For)
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// RegisterListAlerts registers the list_alerts MCP tool.
+func RegisterListAlerts(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "list_alerts",
+		Category: CategoryObserve,
+		ReadOnly: true,
+		Summary:  "List the alerts in your session",
+	}, &gomcp.Tool{
+		Description: "List the alerts set with set_alert in the current session, with their application, template, threshold, duration and severity.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input ListAlertsInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		list, err := listAlerts(ctx, deps, namespace)
+		if err != nil {
+			return nil, nil, err
+		}
+		alerts := make([]map[string]any, 0, len(list.Items))
+		for i := range list.Items {
+			alerts = append(alerts, alertInfo(&list.Items[i]))
+		}
+
+		result := map[string]any{
+			"alerts": alerts,
+			"total":  len(alerts),
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// RegisterDeleteAlert registers the delete_alert MCP tool.
+func RegisterDeleteAlert(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "delete_alert",
+		Category: CategoryObserve,
+		Summary:  "Remove an alert",
+	}, &gomcp.Tool{
+		Description: "Delete an alert set with set_alert from the current session.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input DeleteAlertInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if input.Name == "" {
+			return nil, nil, fmt.Errorf("name is required")
+		}
+
+		// Fetch the rule and verify it is an IAF alert (label guard).
+		rule := &unstructured.Unstructured{}
+		rule.SetGroupVersionKind(iafk8s.PrometheusRuleGVK)
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, rule); err != nil {
+			if meta.IsNoMatchError(err) {
+				return nil, nil, errAlertingUnavailable
+			}
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeNotFound, "alert %q not found", input.Name)
+			}
+			return nil, nil, fmt.Errorf("getting alert: %w", err)
+		}
+		if rule.GetLabels()[iafk8s.LabelAlert] != "true" {
+			return nil, nil, fmt.Errorf("PrometheusRule %q is not an alert managed by IAF", input.Name)
+		}
+		if err := deps.Client.Delete(ctx, rule); err != nil && !apierrors.IsNotFound(err) {
+			return nil, nil, fmt.Errorf("deleting alert: %w", err)
+		}
+
+		result := map[string]any{
+			"name":    input.Name,
+			"deleted": true,
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// listAlerts returns the IAF-managed PrometheusRules in namespace.
+func listAlerts(ctx context.Context, deps *Dependencies, namespace string) (*unstructured.UnstructuredList, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(iafk8s.PrometheusRuleGVK.GroupVersion().WithKind("PrometheusRuleList"))
+	if err := deps.Client.List(ctx, list,
+		client.InNamespace(namespace),
+		client.MatchingLabels{iafk8s.LabelAlert: "true"},
+	); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, errAlertingUnavailable
+		}
+		return nil, fmt.Errorf("listing alerts: %w", err)
+	}
+	return list, nil
+}
+
+// alertInfo describes an alert from the labels and annotations of its rule.
+func alertInfo(rule *unstructured.Unstructured) map[string]any {
+	annotations := rule.GetAnnotations()
+	threshold, _ := strconv.ParseFloat(annotations[iafk8s.AnnotationAlertThreshold], 64)
+	return map[string]any{
+		"name":      rule.GetName(),
+		"app":       rule.GetLabels()["iaf.io/application"],
+		"type":      annotations[iafk8s.AnnotationAlertType],
+		"threshold": threshold,
+		"for":       annotations[iafk8s.AnnotationAlertFor],
+		"severity":  annotations[iafk8s.AnnotationAlertSeverity],
+	}
+}
@@ -0,0 +1,235 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// setupAlertServer creates a server with the alert tools registered.
+func setupAlertServer(t *testing.T) (*gomcp.ClientSession, client.Client) {
+	t.Helper()
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := &tools.Dependencies{
+		Client:          k8sClient,
+		Store:           store,
+		BaseDomain:      "test.example.com",
+		Sessions:        sessions,
+		AlertRuleLabels: map[string]string{"release": "kube-prometheus-stack"},
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterSetAlert(server, deps)
+	tools.RegisterListAlerts(server, deps)
+	tools.RegisterDeleteAlert(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mc := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mc.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs, k8sClient
+}
+
+// createAlertApp creates an Application named name in namespace.
+func createAlertApp(t *testing.T, k8sClient client.Client, namespace, name string) {
+	t.Helper()
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest"},
+	}
+	if err := k8sClient.Create(context.Background(), app); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAlert_Lifecycle(t *testing.T) {
+	cs, k8sClient := setupAlertServer(t)
+	ctx := context.Background()
+	sid, namespace := registerCredSession(t, cs, k8sClient)
+	createAlertApp(t, k8sClient, namespace, "web")
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name: "set_alert",
+		Arguments: map[string]any{
+			"session_id": sid,
+			"name":       "web-errors",
+			"app":        "web",
+			"type":       "error_rate",
+			"threshold":  5,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+
+	rule := &unstructured.Unstructured{}
+	rule.SetGroupVersionKind(iafk8s.PrometheusRuleGVK)
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web-errors", Namespace: namespace}, rule); err != nil {
+		t.Fatalf("expected PrometheusRule to be created: %v", err)
+	}
+	if rule.GetLabels()["release"] != "kube-prometheus-stack" {
+		t.Errorf("expected the configured rule labels, got %v", rule.GetLabels())
+	}
+	if rule.GetAnnotations()[iafk8s.AnnotationAlertFor] != "5m" || rule.GetAnnotations()[iafk8s.AnnotationAlertSeverity] != "warning" {
+		t.Errorf("expected default for and severity, got %v", rule.GetAnnotations())
+	}
+
+	// Setting the alert again replaces it.
+	res, err = cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name: "set_alert",
+		Arguments: map[string]any{
+			"session_id": sid,
+			"name":       "web-errors",
+			"app":        "web",
+			"type":       "latency_p95",
+			"threshold":  0.5,
+			"for":        "10m",
+			"severity":   "critical",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error on update: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+
+	res, err = cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "list_alerts",
+		Arguments: map[string]any{"session_id": sid},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listed struct {
+		Alerts []map[string]any `json:"alerts"`
+		Total  int              `json:"total"`
+	}
+	if err := json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &listed); err != nil {
+		t.Fatal(err)
+	}
+	if listed.Total != 1 {
+		t.Fatalf("expected 1 alert, got %d", listed.Total)
+	}
+	got := listed.Alerts[0]
+	if got["app"] != "web" || got["type"] != "latency_p95" || got["threshold"] != 0.5 || got["for"] != "10m" || got["severity"] != "critical" {
+		t.Errorf("unexpected alert %v", got)
+	}
+
+	res, err = cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "delete_alert",
+		Arguments: map[string]any{"session_id": sid, "name": "web-errors"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error on delete: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web-errors", Namespace: namespace}, rule); err == nil {
+		t.Error("expected PrometheusRule to be deleted")
+	}
+}
+
+func TestSetAlert_Invalid(t *testing.T) {
+	cs, k8sClient := setupAlertServer(t)
+	ctx := context.Background()
+	sid, namespace := registerCredSession(t, cs, k8sClient)
+	createAlertApp(t, k8sClient, namespace, "web")
+
+	tests := []struct {
+		name    string
+		args    map[string]any
+		wantErr string
+	}{
+		{"unknown type", map[string]any{"name": "a", "app": "web", "type": "cpu", "threshold": 1}, "unknown alert type"},
+		{"threshold out of range", map[string]any{"name": "a", "app": "web", "type": "error_rate", "threshold": 200}, "percentage"},
+		{"bad for", map[string]any{"name": "a", "app": "web", "type": "pod_restarts", "threshold": 3, "for": "1d"}, "for"},
+		{"bad name", map[string]any{"name": "Bad_Name", "app": "web", "type": "pod_restarts", "threshold": 3}, "invalid alert name"},
+		{"uptime without check", map[string]any{"name": "a", "app": "web", "type": "uptime", "threshold": 99}, "no uptime check"},
+		{"unknown app", map[string]any{"name": "a", "app": "missing", "type": "pod_restarts", "threshold": 3}, "not found"},
+	}
+	for _, tc := range tests {
+		tc.args["session_id"] = sid
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "set_alert", Arguments: tc.args})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !res.IsError {
+			t.Errorf("%s: expected an error", tc.name)
+			continue
+		}
+		if text := res.Content[0].(*gomcp.TextContent).Text; !strings.Contains(text, tc.wantErr) {
+			t.Errorf("%s: expected error containing %q, got %q", tc.name, tc.wantErr, text)
+		}
+	}
+}
+
+func TestDeleteAlert_NotIAFManaged(t *testing.T) {
+	cs, k8sClient := setupAlertServer(t)
+	ctx := context.Background()
+	sid, namespace := registerCredSession(t, cs, k8sClient)
+
+	rule := &unstructured.Unstructured{}
+	rule.SetGroupVersionKind(iafk8s.PrometheusRuleGVK)
+	rule.SetName("platform-rules")
+	rule.SetNamespace(namespace)
+	if err := k8sClient.Create(ctx, rule); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "delete_alert",
+		Arguments: map[string]any{"session_id": sid, "name": "platform-rules"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsError {
+		t.Fatal("expected an error deleting a PrometheusRule not managed by IAF")
+	}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "platform-rules", Namespace: namespace}, rule); err != nil {
+		t.Errorf("expected the PrometheusRule to remain: %v", err)
+	}
+}
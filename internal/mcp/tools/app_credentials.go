@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/audit"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type GetAppCredentialsInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Name      string `json:"name" jsonschema:"required - name of an application deployed with authentication 'basic'"`
+}
+
+// RegisterGetAppCredentials registers the get_app_credentials tool, which
+// returns the generated basic-auth credentials for an application exactly once.
+func RegisterGetAppCredentials(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:          "get_app_credentials",
+		Category:      CategoryConfigure,
+		Summary:       "Retrieve once the generated username and password of an app deployed with authentication basic",
+		Preconditions: []string{"the app was deployed with authentication basic"},
+	}, &gomcp.Tool{
+		Description: "Retrieve the generated username and password protecting an app deployed with authentication 'basic'. Credentials are returned ONLY ONCE — store them securely and share them with the user. Requires session_id from the register tool and the application name. To issue new credentials, redeploy the app with authentication 'none' and then 'basic' again.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input GetAppCredentialsInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.Name); err != nil {
+			return nil, nil, err
+		}
+
+		var app iafv1alpha1.Application
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, &app); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.Name)
+			}
+			return nil, nil, fmt.Errorf("getting application: %w", err)
+		}
+		if iafv1alpha1.AppAuthentication(&app) != iafv1alpha1.AuthenticationBasic {
+			return nil, nil, fmt.Errorf("application %q uses authentication %q; only 'basic' has platform-generated credentials", input.Name, iafv1alpha1.AppAuthentication(&app))
+		}
+
+		secretName := iafk8s.BasicAuthSecretName(input.Name)
+		var secret corev1.Secret
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: secretName, Namespace: namespace}, &secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, fmt.Errorf("credentials for %q have not been generated yet; wait until app_status reports Deploying or Running and try again", input.Name)
+			}
+			return nil, nil, fmt.Errorf("getting credentials: %w", err)
+		}
+		if secret.Labels["iaf.io/application"] != input.Name || secret.Type != corev1.SecretTypeBasicAuth {
+			return nil, nil, fmt.Errorf("secret %q is not a basic-auth credential managed by IAF", secretName)
+		}
+		if secret.Annotations[iafk8s.AnnotationCredentialsRetrieved] == "true" {
+			return nil, nil, fmt.Errorf("credentials for %q were already retrieved and cannot be shown again; redeploy with authentication 'none' and then 'basic' to issue new ones", input.Name)
+		}
+
+		// Mark as retrieved before returning: an Update conflict means another
+		// caller raced us, and that caller gets the credentials instead.
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		secret.Annotations[iafk8s.AnnotationCredentialsRetrieved] = "true"
+		if err := deps.Client.Update(ctx, &secret); err != nil {
+			return nil, nil, fmt.Errorf("marking credentials as retrieved: %w", err)
+		}
+		deps.SecurityAudit.Record(audit.SecurityEvent{
+			Action:    audit.ActionSecretRead,
+			Actor:     input.SessionID,
+			Namespace: namespace,
+			Kind:      "app_credentials",
+			Name:      input.Name,
+			Secret:    secretName,
+			App:       input.Name,
+		})
+
+		result := map[string]any{
+			"name":     input.Name,
+			"url":      app.Status.URL,
+			"username": string(secret.Data[corev1.BasicAuthUsernameKey]),
+			"password": string(secret.Data[corev1.BasicAuthPasswordKey]),
+			"message":  "These credentials will not be shown again. Share them with the user now.",
+		}
+
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
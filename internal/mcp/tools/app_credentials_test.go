@@ -0,0 +1,161 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// setupCredentialsServer creates a server with register and get_app_credentials registered.
+func setupCredentialsServer(t *testing.T) (*gomcp.ClientSession, client.Client) {
+	t.Helper()
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		Store:      store,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterGetAppCredentials(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mc := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mc.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs, k8sClient
+}
+
+func callGetAppCredentials(t *testing.T, cs *gomcp.ClientSession, sid, name string) *gomcp.CallToolResult {
+	t.Helper()
+	res, err := cs.CallTool(context.Background(), &gomcp.CallToolParams{
+		Name:      "get_app_credentials",
+		Arguments: map[string]any{"session_id": sid, "name": name},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func makeAuthApp(t *testing.T, k8sClient client.Client, name, namespace string, mode iafv1alpha1.ApplicationAuthentication) {
+	t.Helper()
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: iafv1alpha1.ApplicationSpec{
+			Image:          "nginx:latest",
+			Authentication: mode,
+		},
+	}
+	if err := k8sClient.Create(context.Background(), app); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetAppCredentials_ReturnsOnce(t *testing.T) {
+	cs, k8sClient := setupCredentialsServer(t)
+	sid, ns := registerDSSession(t, cs)
+
+	makeAuthApp(t, k8sClient, "tool", ns, iafv1alpha1.AuthenticationBasic)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      iafk8s.BasicAuthSecretName("tool"),
+			Namespace: ns,
+			Labels:    map[string]string{"iaf.io/application": "tool"},
+		},
+		Type: corev1.SecretTypeBasicAuth,
+		Data: map[string][]byte{"username": []byte("iaf"), "password": []byte("generated-pw")},
+	}
+	if err := k8sClient.Create(context.Background(), secret); err != nil {
+		t.Fatal(err)
+	}
+
+	res := callGetAppCredentials(t, cs, sid, "tool")
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	var out map[string]any
+	if err := json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out["username"] != "iaf" || out["password"] != "generated-pw" {
+		t.Errorf("unexpected credentials: %v", out)
+	}
+
+	res = callGetAppCredentials(t, cs, sid, "tool")
+	if !res.IsError {
+		t.Fatal("expected second retrieval to fail")
+	}
+	text := res.Content[0].(*gomcp.TextContent).Text
+	if strings.Contains(text, "generated-pw") {
+		t.Error("second retrieval must not leak the password")
+	}
+	if !strings.Contains(text, "already retrieved") {
+		t.Errorf("expected 'already retrieved' guidance, got %q", text)
+	}
+}
+
+func TestGetAppCredentials_NotBasicAuth(t *testing.T) {
+	cs, k8sClient := setupCredentialsServer(t)
+	sid, ns := registerDSSession(t, cs)
+
+	makeAuthApp(t, k8sClient, "public", ns, "")
+
+	res := callGetAppCredentials(t, cs, sid, "public")
+	if !res.IsError {
+		t.Fatal("expected error for app without basic auth")
+	}
+	if text := res.Content[0].(*gomcp.TextContent).Text; !strings.Contains(text, "'basic'") {
+		t.Errorf("expected guidance mentioning 'basic', got %q", text)
+	}
+}
+
+func TestGetAppCredentials_NotGeneratedYet(t *testing.T) {
+	cs, k8sClient := setupCredentialsServer(t)
+	sid, ns := registerDSSession(t, cs)
+
+	makeAuthApp(t, k8sClient, "pending", ns, iafv1alpha1.AuthenticationBasic)
+
+	res := callGetAppCredentials(t, cs, sid, "pending")
+	if !res.IsError {
+		t.Fatal("expected error when credentials have not been generated")
+	}
+}
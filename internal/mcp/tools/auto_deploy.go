@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type SetAutoDeployInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Name      string `json:"name" jsonschema:"required - name of an application deployed from git"`
+	Enabled   bool   `json:"enabled" jsonschema:"true to build and deploy every new commit on the app's git_revision branch, false to stay on the commit it runs now"`
+}
+
+func RegisterSetAutoDeploy(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:          "set_auto_deploy",
+		Category:      CategoryDeploy,
+		Summary:       "Turn deploying each new commit on a git app's branch on or off",
+		Preconditions: []string{"the app was deployed with git_url, tracking a branch"},
+		Examples:      []string{`{"name":"api-staging","enabled":false}`},
+	}, &gomcp.Tool{
+		Description: "Turn auto-deploy of a git application's branch on or off. Enabled, the platform builds and deploys every new commit pushed to the app's git_revision branch (the same as deploying with git_track 'branch'); new commits are picked up within a few minutes. Disabled, auto-deploy is paused: the app stays on the commit it runs until it is enabled again. app_status reports the deployed commit under git.commit. Requires session_id from the register tool and the application name.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input SetAutoDeployInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.Name); err != nil {
+			return nil, nil, err
+		}
+
+		var app iafv1alpha1.Application
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, &app); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.Name)
+			}
+			return nil, nil, fmt.Errorf("getting application: %w", err)
+		}
+		if app.Spec.Git == nil {
+			return nil, nil, apierror.Validation(apierror.CodeInvalidRequest, "application %q is not deployed from git", input.Name).
+				WithHint("auto-deploy follows a git branch; deploy the app with git_url to use it")
+		}
+
+		git := app.Spec.Git
+		changed := iafk8s.GitAutoDeploy(&app) != input.Enabled
+		if input.Enabled {
+			if err := validation.ValidateGitTrack(string(iafv1alpha1.GitTrackBranch), iafk8s.GitRevision(&app)); err != nil {
+				return nil, nil, err
+			}
+			git.Track, git.Paused = iafv1alpha1.GitTrackBranch, false
+		} else if git.Track == iafv1alpha1.GitTrackBranch {
+			git.Paused = true
+		}
+
+		revision := iafk8s.GitRevision(&app)
+		status, message := "enabled", fmt.Sprintf("Application %q now builds and deploys every new commit on branch %q.", input.Name, revision)
+		if !input.Enabled {
+			status, message = "paused", fmt.Sprintf("Auto-deploy of %q is paused: it stays on the commit it runs until you enable it again.", input.Name)
+		}
+		if !changed {
+			message = fmt.Sprintf("Auto-deploy of %q is already %s; nothing changed.", input.Name, status)
+		} else if err := deps.Client.Update(ctx, &app); err != nil {
+			return nil, nil, fmt.Errorf("updating application: %w", err)
+		}
+
+		result := map[string]any{
+			"name":     input.Name,
+			"revision": revision,
+			"status":   status,
+			"message":  message,
+		}
+		if app.Status.Git != nil && app.Status.Git.Commit != "" {
+			result["deployedCommit"] = app.Status.Git.Commit
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
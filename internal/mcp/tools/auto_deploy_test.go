@@ -0,0 +1,89 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestSetAutoDeploy(t *testing.T) {
+	cs, k8sClient := setupPolicyServer(t)
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	call := func(name string, args map[string]any) (*gomcp.CallToolResult, map[string]any) {
+		t.Helper()
+		args["session_id"] = sid
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out map[string]any
+		json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &out)
+		return res, out
+	}
+	gitSource := func(name string) *iafv1alpha1.GitSource {
+		t.Helper()
+		var app iafv1alpha1.Application
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: ns}, &app); err != nil {
+			t.Fatal(err)
+		}
+		return app.Spec.Git
+	}
+
+	res, out := call("deploy_app", map[string]any{"name": "staging", "git_url": "https://github.com/example/web", "git_revision": "develop", "git_track": "branch"})
+	if res.IsError {
+		t.Fatalf("deploy_app: %v", out)
+	}
+	if out["autoDeploy"] != true {
+		t.Errorf("expected autoDeploy in the deploy result, got %v", out)
+	}
+	if g := gitSource("staging"); g.Track != iafv1alpha1.GitTrackBranch || g.Paused {
+		t.Fatalf("expected staging to track its branch, got %+v", g)
+	}
+
+	res, out = call("set_auto_deploy", map[string]any{"name": "staging", "enabled": false})
+	if res.IsError || out["status"] != "paused" || out["revision"] != "develop" {
+		t.Fatalf("unexpected pause result %v", out)
+	}
+	if g := gitSource("staging"); g.Track != iafv1alpha1.GitTrackBranch || !g.Paused {
+		t.Errorf("expected auto-deploy paused, got %+v", g)
+	}
+	if _, out = call("set_auto_deploy", map[string]any{"name": "staging", "enabled": false}); out["status"] != "paused" {
+		t.Errorf("expected pausing twice to succeed, got %v", out)
+	}
+
+	// An app deployed at a fixed revision can start tracking its branch.
+	call("deploy_app", map[string]any{"name": "prod", "git_url": "https://github.com/example/web"})
+	if res, out = call("set_auto_deploy", map[string]any{"name": "prod", "enabled": true}); res.IsError || out["status"] != "enabled" {
+		t.Fatalf("unexpected enable result %v", out)
+	}
+	if g := gitSource("prod"); g.Track != iafv1alpha1.GitTrackBranch {
+		t.Errorf("expected prod to track main, got %+v", g)
+	}
+
+	for _, tt := range []struct {
+		name string
+		tool string
+		args map[string]any
+	}{
+		{"image app", "set_auto_deploy", map[string]any{"name": "img", "enabled": true}},
+		{"unknown app", "set_auto_deploy", map[string]any{"name": "missing", "enabled": true}},
+		{"commit revision", "deploy_app", map[string]any{"name": "pinned", "git_url": "https://github.com/example/web", "git_revision": "0123abc", "git_track": "branch"}},
+		{"bad track", "deploy_app", map[string]any{"name": "bad", "git_url": "https://github.com/example/web", "git_track": "tag"}},
+		{"track without git", "deploy_app", map[string]any{"name": "bad", "image": "nginx:1.27", "git_track": "branch"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.name == "image app" {
+				call("deploy_app", map[string]any{"name": "img", "image": "nginx:1.27"})
+			}
+			if res, out := call(tt.tool, tt.args); !res.IsError {
+				t.Errorf("expected an error, got %v", out)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Category groups tools in capability listings.
+type Category string
+
+// Tool categories, in the order listings show them.
+const (
+	CategorySession     Category = "session"
+	CategoryDeploy      Category = "deploy"
+	CategoryObserve     Category = "observe"
+	CategoryConfigure   Category = "configure"
+	CategoryCredentials Category = "credentials"
+	CategoryData        Category = "data"
+	CategorySource      Category = "source-control"
+)
+
+// Categories lists every category in display order.
+var Categories = []Category{
+	CategorySession, CategoryDeploy, CategoryObserve, CategoryConfigure,
+	CategoryCredentials, CategoryData, CategorySource,
+}
+
+// Title returns the heading listings use for c.
+func (c Category) Title() string {
+	switch c {
+	case CategorySession:
+		return "Sessions"
+	case CategoryDeploy:
+		return "Build and Deploy"
+	case CategoryObserve:
+		return "Status, Logs, Alerts and Cost"
+	case CategoryConfigure:
+		return "App Configuration"
+	case CategoryCredentials:
+		return "Credentials"
+	case CategoryData:
+		return "Data Sources and Managed Services"
+	case CategorySource:
+		return "Source Control"
+	}
+	return string(c)
+}
+
+// Capability describes a tool for the listings generated from the tools a
+// server registers: the deploy-guide prompt, the capabilities in the
+// iaf://platform resource and the _meta of each tool in ListTools. Every
+// tool except register needs a session_id, so that is not repeated in
+// Preconditions.
+type Capability struct {
+	// Name is the tool name.
+	Name string `json:"name"`
+	// Category groups the tool in listings.
+	Category Category `json:"category"`
+	// Summary says in one line what the tool is for. The tool description
+	// gives the details.
+	Summary string `json:"summary"`
+	// Preconditions are what must be true before the tool is called.
+	Preconditions []string `json:"preconditions,omitempty"`
+	// Examples are sample arguments, as JSON.
+	Examples []string `json:"examples,omitempty"`
+	// ReadOnly marks a tool that changes nothing. It is announced as the
+	// tool's readOnlyHint and keeps working while the platform is in
+	// maintenance.
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+// Keys of the tool _meta set from a Capability.
+const (
+	metaCategory      = "iaf.io/category"
+	metaPreconditions = "iaf.io/preconditions"
+	metaExamples      = "iaf.io/examples"
+)
+
+// meta returns the ListTools _meta of the tool described by c.
+func (c Capability) meta() gomcp.Meta {
+	m := gomcp.Meta{metaCategory: string(c.Category)}
+	if len(c.Preconditions) > 0 {
+		m[metaPreconditions] = c.Preconditions
+	}
+	if len(c.Examples) > 0 {
+		m[metaExamples] = c.Examples
+	}
+	return m
+}
+
+// Capabilities records the capabilities of the tools registered on a
+// server. The zero value is empty and ready to use; a nil *Capabilities
+// records nothing.
+type Capabilities struct {
+	mu   sync.RWMutex
+	list []Capability
+}
+
+// add records c, replacing an earlier capability with the same name.
+func (r *Capabilities) add(c Capability) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if i := slices.IndexFunc(r.list, func(o Capability) bool { return o.Name == c.Name }); i >= 0 {
+		r.list[i] = c
+		return
+	}
+	r.list = append(r.list, c)
+}
+
+// List returns the recorded capabilities grouped by category in the order
+// of Categories, each group in registration order.
+func (r *Capabilities) List() []Capability {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := slices.Clone(r.list)
+	slices.SortStableFunc(list, func(a, b Capability) int {
+		return categoryIndex(a.Category) - categoryIndex(b.Category)
+	})
+	return list
+}
+
+func categoryIndex(c Category) int {
+	if i := slices.Index(Categories, c); i >= 0 {
+		return i
+	}
+	return len(Categories)
+}
+
+// addTool registers a tool named after c, sets its _meta from c and records
+// c in deps.Capabilities. Tools deps.Tools does not allow are skipped. Calls
+// of the tool are recorded in deps.History and their results fitted to
+// deps.Budget; calls of tools that are not ReadOnly are refused while
+// deps.Maintenance is on. Calls are cut off after their limit in
+// deps.Timeouts.
+func addTool[In, Out any](server *gomcp.Server, deps *Dependencies, c Capability, tool *gomcp.Tool, handler gomcp.ToolHandlerFor[In, Out]) {
+	if !deps.Tools.Allows(c.Name) {
+		return
+	}
+	tool.Name = c.Name
+	tool.Meta = c.meta()
+	if c.ReadOnly {
+		if tool.Annotations == nil {
+			tool.Annotations = &gomcp.ToolAnnotations{}
+		}
+		tool.Annotations.ReadOnlyHint = true
+	} else {
+		handler = refuseInMaintenance(deps, handler)
+	}
+	handler = withDeadline(deps, c.Name, handler)
+	gomcp.AddTool(server, tool, fitResult(deps, recordCall(deps, c.Name, handler)))
+	deps.Capabilities.add(c)
+}
+
+// refuseInMaintenance fails calls of h with code maintenance while
+// deps.Maintenance is on.
+func refuseInMaintenance[In, Out any](deps *Dependencies, h gomcp.ToolHandlerFor[In, Out]) gomcp.ToolHandlerFor[In, Out] {
+	return func(ctx context.Context, req *gomcp.CallToolRequest, input In) (*gomcp.CallToolResult, Out, error) {
+		if err := deps.Maintenance.Err(); err != nil {
+			var zero Out
+			return nil, zero, err
+		}
+		return h(ctx, req, input)
+	}
+}
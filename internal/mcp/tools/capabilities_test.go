@@ -0,0 +1,130 @@
+package tools_test
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/maintenance"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCapabilities_RecordedOnRegistration(t *testing.T) {
+	ctx := context.Background()
+	deps := &tools.Dependencies{Capabilities: &tools.Capabilities{}}
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterBindService(server, deps)
+	tools.RegisterAppStatus(server, deps)
+	tools.RegisterRegisterTool(server, deps)
+	// A second registration of the same tool replaces the first.
+	tools.RegisterAppStatus(server, deps)
+
+	var names []string
+	for _, c := range deps.Capabilities.List() {
+		names = append(names, c.Name)
+	}
+	if want := []string{"register", "app_status", "bind_service"}; !slices.Equal(names, want) {
+		t.Errorf("capabilities = %v, want %v in category order", names, want)
+	}
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	cs, err := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil).Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+
+	res, err := cs.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tool := range res.Tools {
+		if tool.Name != "bind_service" {
+			continue
+		}
+		if tool.Meta["iaf.io/category"] != string(tools.CategoryData) {
+			t.Errorf("bind_service category = %v", tool.Meta["iaf.io/category"])
+		}
+		if pre, _ := tool.Meta["iaf.io/preconditions"].([]any); len(pre) == 0 {
+			t.Errorf("expected bind_service preconditions in _meta, got %v", tool.Meta)
+		}
+		return
+	}
+	t.Fatal("bind_service not listed")
+}
+
+func TestCapabilities_NilRecordsNothing(t *testing.T) {
+	deps := &tools.Dependencies{}
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterAppStatus(server, deps)
+	if list := deps.Capabilities.List(); list != nil {
+		t.Errorf("expected no capabilities, got %v", list)
+	}
+}
+
+func TestCapabilities_Maintenance(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := &tools.Dependencies{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).Build(),
+		Sessions: sessions,
+	}
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterListApps(server, deps)
+	tools.RegisterSuspendApp(server, deps)
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	cs, err := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil).Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	sid, _ := registerDSSession(t, cs)
+
+	deps.Maintenance = &maintenance.Mode{}
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "suspend_app", Arguments: map[string]any{"session_id": sid, "name": "web"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := res.Content[0].(*gomcp.TextContent).Text; !res.IsError || !strings.Contains(text, "maintenance") {
+		t.Errorf("expected suspend_app to be refused in maintenance, got %s", text)
+	}
+	res, err = cs.CallTool(ctx, &gomcp.CallToolParams{Name: "list_apps", Arguments: map[string]any{"session_id": sid}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Errorf("expected list_apps to work in maintenance, got %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+
+	list, err := cs.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tool := range list.Tools {
+		readOnly := tool.Annotations != nil && tool.Annotations.ReadOnlyHint
+		if readOnly != (tool.Name == "list_apps") {
+			t.Errorf("%s readOnlyHint = %v", tool.Name, readOnly)
+		}
+	}
+}
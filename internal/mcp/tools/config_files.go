@@ -0,0 +1,153 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// SetConfigFileInput is the input for the set_config_file tool.
+type SetConfigFileInput struct {
+	SessionID       string `json:"session_id" jsonschema:"required - session ID from the register tool"`
+	AppName         string `json:"app_name" jsonschema:"required - name of the application"`
+	Path            string `json:"path" jsonschema:"required - absolute path of the file in the container (e.g. '/app/config.yaml')"`
+	Content         string `json:"content,omitempty" jsonschema:"file content (max 256 KiB; 512 KiB across all of an app's files)"`
+	ConfigMap       string `json:"config_map,omitempty" jsonschema:"mount a key of this ConfigMap in your namespace instead of content"`
+	ConfigMapKey    string `json:"config_map_key,omitempty" jsonschema:"ConfigMap key whose value becomes the file. Requires config_map"`
+	Remove          bool   `json:"remove,omitempty" jsonschema:"remove the file at path instead of setting it"`
+	ExpectedVersion int64  `json:"expected_version,omitempty" jsonschema:"optional version of the app from app_status that you based this change on; the call fails with version_conflict, returning the current state, if the app changed since"`
+}
+
+// RegisterSetConfigFile registers the set_config_file MCP tool.
+func RegisterSetConfigFile(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "set_config_file",
+		Category: CategoryConfigure,
+		Summary:  "Mount a config file into an app at a path; the app restarts",
+	}, &gomcp.Tool{
+		Description: "Mount a config file into an application at an absolute path, for frameworks that read configuration from files rather than env vars. Give the content inline, or a config_map and config_map_key in your namespace. Setting an existing path replaces the file; remove=true deletes it. The app restarts with the change. Files are read-only. Requires session_id.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input SetConfigFileInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.AppName); err != nil {
+			return nil, nil, fmt.Errorf("invalid app name: %w", err)
+		}
+		if err := validation.ValidateConfigFilePath(input.Path); err != nil {
+			return nil, nil, err
+		}
+		if input.Remove && (input.Content != "" || input.ConfigMap != "" || input.ConfigMapKey != "") {
+			return nil, nil, fmt.Errorf("remove takes only path; do not set content or config_map")
+		}
+		if (input.ConfigMap == "") != (input.ConfigMapKey == "") {
+			return nil, nil, fmt.Errorf("config_map and config_map_key must be set together")
+		}
+
+		var app iafv1alpha1.Application
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.AppName, Namespace: namespace}, &app); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.AppName)
+			}
+			return nil, nil, fmt.Errorf("getting application: %w", err)
+		}
+		if err := iafk8s.CheckVersion(&app, input.ExpectedVersion); err != nil {
+			return nil, nil, err
+		}
+
+		i := slices.IndexFunc(app.Spec.ConfigFiles, func(f iafv1alpha1.ConfigFile) bool { return f.Path == input.Path })
+		var message string
+		if input.Remove {
+			if i < 0 {
+				return nil, nil, fmt.Errorf("application %q has no config file at %s", input.AppName, input.Path)
+			}
+			app.Spec.ConfigFiles = slices.Delete(app.Spec.ConfigFiles, i, i+1)
+			message = fmt.Sprintf("Removed %s from application %q; it restarts without the file.", input.Path, input.AppName)
+		} else {
+			file := iafv1alpha1.ConfigFile{Path: input.Path, Content: input.Content}
+			if input.ConfigMap != "" {
+				file.ConfigMap = &iafv1alpha1.ConfigMapKeyRef{Name: input.ConfigMap, Key: input.ConfigMapKey}
+			}
+			if i < 0 {
+				app.Spec.ConfigFiles = append(app.Spec.ConfigFiles, file)
+			} else {
+				app.Spec.ConfigFiles[i] = file
+			}
+			message = fmt.Sprintf("Mounted %s in application %q; it restarts with the new file.", input.Path, input.AppName)
+		}
+		if err := validateConfigFiles(app.Spec.ConfigFiles); err != nil {
+			return nil, nil, err
+		}
+		if err := deps.Client.Update(ctx, &app); err != nil {
+			return nil, nil, fmt.Errorf("updating application: %w", err)
+		}
+
+		paths := make([]string, 0, len(app.Spec.ConfigFiles))
+		for _, f := range app.Spec.ConfigFiles {
+			paths = append(paths, f.Path)
+		}
+		result := map[string]any{
+			"name":        input.AppName,
+			"version":     iafk8s.AppVersion(&app),
+			"configFiles": paths,
+			"message":     message,
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// validateConfigFiles validates the config files of an application: their
+// paths, sources, and the limits on their number and size.
+func validateConfigFiles(files []iafv1alpha1.ConfigFile) error {
+	if len(files) > validation.MaxConfigFiles {
+		return fmt.Errorf("an application may have at most %d config files", validation.MaxConfigFiles)
+	}
+	total := 0
+	for i, f := range files {
+		if err := validation.ValidateConfigFilePath(f.Path); err != nil {
+			return err
+		}
+		// Each file is mounted on its own: no path may repeat or sit inside another.
+		for _, other := range files[:i] {
+			if f.Path == other.Path {
+				return fmt.Errorf("config file path %s is set more than once", f.Path)
+			}
+			if strings.HasPrefix(f.Path, other.Path+"/") || strings.HasPrefix(other.Path, f.Path+"/") {
+				return fmt.Errorf("config file paths %s and %s overlap: one would be inside the other", other.Path, f.Path)
+			}
+		}
+		if f.ConfigMap != nil {
+			if f.Content != "" {
+				return fmt.Errorf("config file %s: set either content or a config map, not both", f.Path)
+			}
+			if err := validation.ValidateAppName(f.ConfigMap.Name); err != nil {
+				return fmt.Errorf("config file %s: invalid config map name: %w", f.Path, err)
+			}
+			if err := validation.ValidateConfigMapKey(f.ConfigMap.Key); err != nil {
+				return fmt.Errorf("config file %s: %w", f.Path, err)
+			}
+			continue
+		}
+		if len(f.Content) > validation.MaxConfigFileSize {
+			return fmt.Errorf("config file %s is larger than %d KiB", f.Path, validation.MaxConfigFileSize/1024)
+		}
+		total += len(f.Content)
+	}
+	if total > validation.MaxConfigFilesSize {
+		return fmt.Errorf("config files total more than %d KiB; move large files into the image", validation.MaxConfigFilesSize/1024)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package tools_test
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// setupConfigFileServer creates a server with deploy_app and set_config_file
+// registered.
+func setupConfigFileServer(t *testing.T) (*gomcp.ClientSession, client.Client) {
+	t.Helper()
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		Store:      store,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterDeployApp(server, deps)
+	tools.RegisterSetConfigFile(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mc := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mc.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs, k8sClient
+}
+
+func TestConfigFiles(t *testing.T) {
+	cs, k8sClient := setupConfigFileServer(t)
+	ctx := context.Background()
+	sid, namespace := registerCredSession(t, cs, k8sClient)
+
+	call := func(name string, args map[string]any) *gomcp.CallToolResult {
+		t.Helper()
+		args["session_id"] = sid
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	getApp := func() *iafv1alpha1.Application {
+		t.Helper()
+		var app iafv1alpha1.Application
+		if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "web"}, &app); err != nil {
+			t.Fatal(err)
+		}
+		return &app
+	}
+
+	res := call("deploy_app", map[string]any{
+		"name":  "web",
+		"image": "nginx:latest",
+		"config_files": []map[string]any{
+			{"path": "/etc/nginx/conf.d/default.conf", "content": "server { listen 8080; }"},
+		},
+	})
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	files := getApp().Spec.ConfigFiles
+	if len(files) != 1 || files[0].Path != "/etc/nginx/conf.d/default.conf" || files[0].Content != "server { listen 8080; }" {
+		t.Fatalf("unexpected config files %+v", files)
+	}
+
+	// Setting a new path adds a file; setting an existing one replaces it.
+	res = call("set_config_file", map[string]any{"app_name": "web", "path": "/etc/nginx/mime.types", "config_map": "nginx", "config_map_key": "mime.types"})
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	res = call("set_config_file", map[string]any{"app_name": "web", "path": "/etc/nginx/conf.d/default.conf", "content": "server { listen 9090; }"})
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	files = getApp().Spec.ConfigFiles
+	if len(files) != 2 || files[0].Content != "server { listen 9090; }" || files[1].ConfigMap == nil || files[1].ConfigMap.Key != "mime.types" {
+		t.Fatalf("unexpected config files %+v", files)
+	}
+
+	res = call("set_config_file", map[string]any{"app_name": "web", "path": "/etc/nginx/mime.types", "remove": true})
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	if files = getApp().Spec.ConfigFiles; len(files) != 1 {
+		t.Errorf("expected one config file after removal, got %+v", files)
+	}
+
+	// expected_version guards against changes made since the app was read.
+	app := getApp()
+	app.Generation = 4
+	if err := k8sClient.Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	res = call("set_config_file", map[string]any{"app_name": "web", "path": "/app/a.yml", "content": "x", "expected_version": 3})
+	if !res.IsError || !strings.Contains(res.Content[0].(*gomcp.TextContent).Text, "version 4") {
+		t.Fatalf("expected a version conflict, got %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	res = call("set_config_file", map[string]any{"app_name": "web", "path": "/app/a.yml", "content": "x", "expected_version": 4})
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	res = call("set_config_file", map[string]any{"app_name": "web", "path": "/app/a.yml", "remove": true})
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+
+	tests := []struct {
+		name string
+		tool string
+		args map[string]any
+	}{
+		{"relative path", "set_config_file", map[string]any{"app_name": "web", "path": "config.yaml", "content": "x"}},
+		{"reserved path", "set_config_file", map[string]any{"app_name": "web", "path": "/proc/self/environ", "content": "x"}},
+		{"too large", "set_config_file", map[string]any{"app_name": "web", "path": "/app/big.txt", "content": strings.Repeat("x", 256*1024+1)}},
+		{"content and config map", "set_config_file", map[string]any{"app_name": "web", "path": "/app/a.yml", "content": "x", "config_map": "nginx", "config_map_key": "a.yml"}},
+		{"config map without key", "set_config_file", map[string]any{"app_name": "web", "path": "/app/a.yml", "config_map": "nginx"}},
+		{"overlapping paths", "set_config_file", map[string]any{"app_name": "web", "path": "/etc/nginx/conf.d/default.conf/extra", "content": "x"}},
+		{"remove missing", "set_config_file", map[string]any{"app_name": "web", "path": "/app/missing.yml", "remove": true}},
+		{"missing app", "set_config_file", map[string]any{"app_name": "api", "path": "/app/a.yml", "content": "x"}},
+		{"duplicate path on deploy", "deploy_app", map[string]any{"name": "api", "image": "nginx:latest", "config_files": []map[string]any{
+			{"path": "/app/a.yml", "content": "1"}, {"path": "/app/a.yml", "content": "2"},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if res := call(tt.tool, tt.args); !res.IsError {
+				t.Errorf("expected error, got %s", res.Content[0].(*gomcp.TextContent).Text)
+			}
+		})
+	}
+	if files = getApp().Spec.ConfigFiles; len(files) != 1 || files[0].Content != "server { listen 9090; }" {
+		t.Errorf("expected rejected changes to leave the config files alone, got %+v", files)
+	}
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/budget"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// TruncatedMetaKey is set in the _meta of a tool result shortened to the
+// response size limit, to its budget.Truncation.
+const TruncatedMetaKey = "iaf.io/truncated"
+
+// fitResult shortens the text of successful results of h to deps.Budget.
+// The rest is kept for the session the call names.
+func fitResult[In, Out any](deps *Dependencies, h gomcp.ToolHandlerFor[In, Out]) gomcp.ToolHandlerFor[In, Out] {
+	if deps.Budget == nil {
+		return h
+	}
+	return func(ctx context.Context, req *gomcp.CallToolRequest, input In) (*gomcp.CallToolResult, Out, error) {
+		res, out, err := h(ctx, req, input)
+		if err != nil || res == nil || res.IsError || len(res.Content) != 1 {
+			return res, out, err
+		}
+		text, ok := res.Content[0].(*gomcp.TextContent)
+		if !ok {
+			return res, out, err
+		}
+		var args struct {
+			SessionID string `json:"session_id"`
+		}
+		if req != nil && req.Params != nil {
+			_ = json.Unmarshal(req.Params.Arguments, &args)
+		}
+		fitted, truncation := deps.Budget.Fit(args.SessionID, text.Text)
+		if truncation == nil {
+			return res, out, err
+		}
+		return truncatedResult(res.Meta, fitted, truncation), out, nil
+	}
+}
+
+// truncatedResult returns a result of text marked with truncation in its
+// _meta, which starts from meta.
+func truncatedResult(meta gomcp.Meta, text string, truncation *budget.Truncation) *gomcp.CallToolResult {
+	meta = maps.Clone(meta)
+	if meta == nil {
+		meta = gomcp.Meta{}
+	}
+	meta[TruncatedMetaKey] = truncation
+	return &gomcp.CallToolResult{
+		Meta:    meta,
+		Content: []gomcp.Content{&gomcp.TextContent{Text: text}},
+	}
+}
+
+type ContinueResultInput struct {
+	SessionID    string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Continuation string `json:"continuation" jsonschema:"required - the continuation in the truncated field of a shortened tool result"`
+}
+
+func RegisterContinueResult(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "continue_result",
+		Category: CategorySession,
+		ReadOnly: true,
+		Summary:  "Read the next part of a tool result that was shortened to fit the response size limit",
+		Examples: []string{`{"session_id": "<id>", "continuation": "<continuation>"}`},
+	}, &gomcp.Tool{
+		Description: "Read what was left out of a tool result too large to send at once. Requires session_id from the register tool. A shortened JSON result has a 'truncated' field: a long list keeps its first items and long text such as logs keeps its head and tail, and 'truncated.continuation' reads the next items, or the text that was left out, in order. Each page has its own 'truncated' with the continuation for the one after it, until none is left. Continuations expire 15 minutes after the original call. Narrowing the original call, for example with fewer log lines, is often better than reading every page.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input ContinueResultInput) (*gomcp.CallToolResult, any, error) {
+		if _, err := deps.ResolveNamespace(input.SessionID); err != nil {
+			return nil, nil, err
+		}
+		if input.Continuation == "" {
+			return nil, nil, errors.New("continuation is required")
+		}
+		text, truncation, err := deps.Budget.Continue(input.SessionID, input.Continuation)
+		if errors.Is(err, budget.ErrNotFound) {
+			return nil, nil, apierror.NotFound(apierror.CodeNotFound, "continuation not found").
+				WithHint("continuations are read once, in order, and expire 15 minutes after the original call; call the original tool again")
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return truncatedResult(nil, text, truncation), nil, nil
+	})
+}
@@ -0,0 +1,112 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/budget"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestContinueResult(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+		Budget:     budget.New(2048),
+	}
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterListApps(server, deps)
+	tools.RegisterContinueResult(server, deps)
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	cs, err := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil).Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	sid, ns := registerDSSession(t, cs)
+	otherSID, _ := registerDSSession(t, cs)
+
+	for i := range 40 {
+		app := &iafv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("app-%02d", i), Namespace: ns},
+			Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:1.27"},
+		}
+		if err := k8sClient.Create(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type page struct {
+		Applications []map[string]any   `json:"applications"`
+		Total        int                `json:"total"`
+		Truncated    *budget.Truncation `json:"truncated"`
+	}
+	call := func(name string, args map[string]any) (*gomcp.CallToolResult, page) {
+		t.Helper()
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		text := res.Content[0].(*gomcp.TextContent).Text
+		var p page
+		if !res.IsError {
+			if len(text) > 2048 {
+				t.Errorf("%s returned %d bytes, over the budget", name, len(text))
+			}
+			if err := json.Unmarshal([]byte(text), &p); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return res, p
+	}
+
+	res, first := call("list_apps", map[string]any{"session_id": sid})
+	if first.Truncated == nil || first.Truncated.Continuation == "" || first.Total != 40 {
+		t.Fatalf("expected a shortened list with a continuation, got %+v", first.Truncated)
+	}
+	if res.Meta[tools.TruncatedMetaKey] == nil {
+		t.Error("expected the truncation in _meta")
+	}
+
+	// Another session cannot read the rest.
+	if res, _ := call("continue_result", map[string]any{"session_id": otherSID, "continuation": first.Truncated.Continuation}); !res.IsError {
+		t.Error("expected another session's continuation to be refused")
+	}
+
+	names := len(first.Applications)
+	for token := first.Truncated.Continuation; token != ""; {
+		res, next := call("continue_result", map[string]any{"session_id": sid, "continuation": token})
+		if res.IsError {
+			t.Fatalf("continue_result failed: %s", res.Content[0].(*gomcp.TextContent).Text)
+		}
+		names += len(next.Applications)
+		token = next.Truncated.Continuation
+	}
+	if names != 40 {
+		t.Errorf("expected 40 apps over all pages, got %d", names)
+	}
+}
@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/cost"
+	"github.com/dlapiduz/iaf/internal/features"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type SessionCostInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Window    string `json:"window,omitempty" jsonschema:"reporting period ending now, e.g. 1h, 24h or 7d (default 24h, max 30d)"`
+}
+
+type AppCostInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Name      string `json:"name" jsonschema:"required - application name"`
+	Window    string `json:"window,omitempty" jsonschema:"reporting period ending now, e.g. 1h, 24h or 7d (default 24h, max 30d)"`
+}
+
+// RegisterSessionCost registers the session_cost tool.
+func RegisterSessionCost(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "session_cost",
+		Category: CategoryObserve,
+		ReadOnly: true,
+		Summary:  "Estimate what your session has cost, in total and per app",
+	}, &gomcp.Tool{
+		Description: "Estimate what your session has cost over a period (default the last 24 hours), from the CPU and memory its pods actually used and the platform's per-unit rates. Returns the session total and a per-app breakdown, most expensive first. Build pods and managed services count toward the total but not toward any app.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input SessionCostInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if deps.Costs == nil {
+			return nil, nil, features.Disabled(features.Costs)
+		}
+		window, err := cost.ParseWindow(input.Window)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var list iafv1alpha1.ApplicationList
+		if err := deps.Client.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+			return nil, nil, fmt.Errorf("listing applications: %w", err)
+		}
+		total, err := deps.Costs.Usage.Usage(ctx, namespace, "", window)
+		if err != nil {
+			return nil, nil, err
+		}
+		apps := make([]map[string]any, 0, len(list.Items))
+		for _, app := range list.Items {
+			usage, err := deps.Costs.Usage.Usage(ctx, namespace, app.Name, window)
+			if err != nil {
+				return nil, nil, err
+			}
+			entry := costEntry(deps.Costs.Rates, usage)
+			entry["name"] = app.Name
+			apps = append(apps, entry)
+		}
+		sort.SliceStable(apps, func(i, j int) bool {
+			return apps[i]["cost"].(float64) > apps[j]["cost"].(float64)
+		})
+
+		result := map[string]any{
+			"window":   cost.FormatWindow(window),
+			"currency": deps.Costs.Rates.Currency,
+			"rates":    costRates(deps.Costs.Rates),
+			"total":    costEntry(deps.Costs.Rates, total),
+			"apps":     apps,
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// RegisterAppCost registers the app_cost tool.
+func RegisterAppCost(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "app_cost",
+		Category: CategoryObserve,
+		ReadOnly: true,
+		Summary:  "Estimate what one app has cost",
+	}, &gomcp.Tool{
+		Description: "Estimate what one application has cost over a period (default the last 24 hours), from the CPU and memory its pods actually used and the platform's per-unit rates.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input AppCostInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.Name); err != nil {
+			return nil, nil, err
+		}
+		if deps.Costs == nil {
+			return nil, nil, features.Disabled(features.Costs)
+		}
+		window, err := cost.ParseWindow(input.Window)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var app iafv1alpha1.Application
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, &app); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.Name)
+			}
+			return nil, nil, fmt.Errorf("getting application: %w", err)
+		}
+		usage, err := deps.Costs.Usage.Usage(ctx, namespace, app.Name, window)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		result := costEntry(deps.Costs.Rates, usage)
+		result["name"] = app.Name
+		result["window"] = cost.FormatWindow(window)
+		result["currency"] = deps.Costs.Rates.Currency
+		result["rates"] = costRates(deps.Costs.Rates)
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// costEntry reports usage and its price.
+func costEntry(rates cost.Rates, usage cost.Usage) map[string]any {
+	return map[string]any{
+		"cpuCoreHours":  cost.Round(usage.CPUCoreHours),
+		"memoryGBHours": cost.Round(usage.MemoryGBHours),
+		"cost":          rates.Cost(usage),
+	}
+}
+
+func costRates(rates cost.Rates) map[string]float64 {
+	return map[string]float64{
+		"cpuCoreHour":  rates.CPUCoreHour,
+		"memoryGBHour": rates.MemoryGBHour,
+	}
+}
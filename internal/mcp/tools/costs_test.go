@@ -0,0 +1,184 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/cost"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeUsage is a cost.Querier returning fixed usage per app; "" is the
+// whole namespace.
+type fakeUsage struct {
+	byApp   map[string]cost.Usage
+	windows []time.Duration
+}
+
+func (f *fakeUsage) Usage(_ context.Context, _, name string, window time.Duration) (cost.Usage, error) {
+	f.windows = append(f.windows, window)
+	return f.byApp[name], nil
+}
+
+func (f *fakeUsage) DailyUsage(context.Context, int) (map[string][]cost.DailyUsage, error) {
+	return nil, nil
+}
+
+// setupCostServer creates a server with the cost tools registered. A nil
+// usage leaves cost reporting unconfigured.
+func setupCostServer(t *testing.T, usage cost.Querier) (*gomcp.ClientSession, client.Client) {
+	t.Helper()
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+	}
+	if usage != nil {
+		deps.Costs = &cost.Estimator{Usage: usage, Rates: cost.Rates{CPUCoreHour: 0.04, MemoryGBHour: 0.005, Currency: "USD"}}
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterSessionCost(server, deps)
+	tools.RegisterAppCost(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mc := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mc.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs, k8sClient
+}
+
+func TestSessionCost(t *testing.T) {
+	usage := &fakeUsage{byApp: map[string]cost.Usage{
+		"":    {CPUCoreHours: 30, MemoryGBHours: 200},
+		"web": {CPUCoreHours: 5, MemoryGBHours: 20},
+		"api": {CPUCoreHours: 20, MemoryGBHours: 100},
+	}}
+	cs, k8sClient := setupCostServer(t, usage)
+	ctx := context.Background()
+	sid, namespace := registerCredSession(t, cs, k8sClient)
+	createAlertApp(t, k8sClient, namespace, "web")
+	createAlertApp(t, k8sClient, namespace, "api")
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "session_cost",
+		Arguments: map[string]any{"session_id": sid, "window": "7d"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	var got struct {
+		Window   string           `json:"window"`
+		Currency string           `json:"currency"`
+		Total    map[string]any   `json:"total"`
+		Apps     []map[string]any `json:"apps"`
+	}
+	if err := json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Window != "1w" || got.Currency != "USD" || got.Total["cost"] != 2.2 {
+		t.Errorf("unexpected summary %+v", got)
+	}
+	if len(got.Apps) != 2 || got.Apps[0]["name"] != "api" || got.Apps[0]["cost"] != 1.3 || got.Apps[1]["cost"] != 0.3 {
+		t.Errorf("expected apps most expensive first, got %v", got.Apps)
+	}
+	for _, w := range usage.windows {
+		if w != 7*24*time.Hour {
+			t.Errorf("expected a 7d window, got %v", w)
+		}
+	}
+}
+
+func TestAppCost(t *testing.T) {
+	cs, k8sClient := setupCostServer(t, &fakeUsage{byApp: map[string]cost.Usage{"web": {CPUCoreHours: 5, MemoryGBHours: 20}}})
+	ctx := context.Background()
+	sid, namespace := registerCredSession(t, cs, k8sClient)
+	createAlertApp(t, k8sClient, namespace, "web")
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "app_cost",
+		Arguments: map[string]any{"session_id": sid, "name": "web"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["name"] != "web" || got["window"] != "1d" || got["cpuCoreHours"] != 5.0 || got["cost"] != 0.3 {
+		t.Errorf("unexpected result %v", got)
+	}
+
+	tests := []struct {
+		name    string
+		args    map[string]any
+		wantErr string
+	}{
+		{"unknown app", map[string]any{"name": "missing"}, "not found"},
+		{"bad window", map[string]any{"name": "web", "window": "90d"}, "window must be between"},
+		{"bad name", map[string]any{"name": "Bad_Name"}, "invalid"},
+	}
+	for _, tc := range tests {
+		tc.args["session_id"] = sid
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "app_cost", Arguments: tc.args})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !res.IsError {
+			t.Errorf("%s: expected an error", tc.name)
+			continue
+		}
+		if text := res.Content[0].(*gomcp.TextContent).Text; !strings.Contains(text, tc.wantErr) {
+			t.Errorf("%s: expected error containing %q, got %q", tc.name, tc.wantErr, text)
+		}
+	}
+}
+
+func TestSessionCost_NotConfigured(t *testing.T) {
+	cs, k8sClient := setupCostServer(t, nil)
+	sid, _ := registerCredSession(t, cs, k8sClient)
+
+	res, err := cs.CallTool(context.Background(), &gomcp.CallToolParams{
+		Name:      "session_cost",
+		Arguments: map[string]any{"session_id": sid},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsError || !strings.Contains(res.Content[0].(*gomcp.TextContent).Text, "costs feature is not enabled") {
+		t.Errorf("expected a capability disabled error, got %v", res.Content)
+	}
+}
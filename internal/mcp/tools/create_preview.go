@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/policy"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type CreatePreviewInput struct {
+	SessionID   string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Name        string `json:"name" jsonschema:"required - name of an existing git-based application to clone"`
+	PRNumber    int    `json:"pr_number" jsonschema:"required - pull request number; the preview is named <name>-pr-<pr_number>"`
+	GitRevision string `json:"git_revision" jsonschema:"required - branch, tag, or commit to build for the preview (usually the PR head branch)"`
+}
+
+// RegisterCreatePreview registers the create_preview tool, which clones a
+// git-based Application into a per-PR preview environment.
+func RegisterCreatePreview(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:          "create_preview",
+		Category:      CategoryDeploy,
+		Summary:       "Clone a git-based app into a per-PR preview (<name>-pr-<n>) built from the PR branch",
+		Preconditions: []string{"a git-based app to clone"},
+	}, &gomcp.Tool{
+		Description: "Create a preview environment for a pull request: clones an existing git-based app into '<name>-pr-<pr_number>' built from git_revision, with its own URL. Share the preview URL with human reviewers. The preview is deleted automatically when the PR is closed or merged (if the platform GitHub webhook is configured), or manually with delete_app. Calling it again for the same PR rebuilds the preview from the new revision.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input CreatePreviewInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.Name); err != nil {
+			return nil, nil, err
+		}
+		if input.PRNumber <= 0 {
+			return nil, nil, fmt.Errorf("pr_number must be a positive pull request number")
+		}
+		if input.GitRevision == "" {
+			return nil, nil, fmt.Errorf("git_revision is required (the PR head branch, tag, or commit)")
+		}
+		previewName := iafk8s.PreviewName(input.Name, input.PRNumber)
+		if err := validation.ValidateAppName(previewName); err != nil {
+			return nil, nil, fmt.Errorf("preview name %q is invalid: %w", previewName, err)
+		}
+
+		var source iafv1alpha1.Application
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, &source); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.Name)
+			}
+			return nil, nil, fmt.Errorf("getting application: %w", err)
+		}
+		if source.Spec.Git == nil {
+			return nil, nil, fmt.Errorf("application %q is not built from git; previews require an app deployed with git_url", input.Name)
+		}
+		if _, ok := source.Labels[iafk8s.LabelPreviewOf]; ok {
+			return nil, nil, fmt.Errorf("application %q is itself a preview; create previews from the original app", input.Name)
+		}
+
+		spec := *source.Spec.DeepCopy()
+		spec.Git.Revision = input.GitRevision
+		spec.Host = ""
+		spec.Replicas = 1
+		spec.DeletionProtected = false
+
+		var existing iafv1alpha1.Application
+		err = deps.Client.Get(ctx, types.NamespacedName{Name: previewName, Namespace: namespace}, &existing)
+		switch {
+		case apierrors.IsNotFound(err):
+			if err := deps.CheckAppNameAvailable(ctx, previewName, namespace); err != nil {
+				return nil, nil, err
+			}
+			preview := &iafv1alpha1.Application{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      previewName,
+					Namespace: namespace,
+					Labels: map[string]string{
+						iafk8s.LabelPreviewOf: input.Name,
+						iafk8s.LabelPreviewPR: strconv.Itoa(input.PRNumber),
+					},
+					Annotations: map[string]string{
+						iafk8s.AnnotationPreviewRepo: iafk8s.NormalizeRepoURL(source.Spec.Git.URL),
+					},
+				},
+				Spec: spec,
+			}
+			if res, err := deps.CheckPolicy(ctx, policy.Input{Operation: iafv1alpha1.PolicyOperationDeploy, Namespace: namespace, App: preview}); res != nil || err != nil {
+				return res, nil, err
+			}
+			if err := deps.Client.Create(ctx, preview); err != nil {
+				return nil, nil, fmt.Errorf("creating preview: %w", err)
+			}
+		case err != nil:
+			return nil, nil, fmt.Errorf("getting preview: %w", err)
+		default:
+			if existing.Labels[iafk8s.LabelPreviewOf] != input.Name {
+				return nil, nil, fmt.Errorf("application %q already exists and is not a preview of %q", previewName, input.Name)
+			}
+			spec.DeletionProtected = existing.Spec.DeletionProtected
+			existing.Spec = spec
+			if res, err := deps.CheckPolicy(ctx, policy.Input{Operation: iafv1alpha1.PolicyOperationDeploy, Namespace: namespace, App: &existing}); res != nil || err != nil {
+				return res, nil, err
+			}
+			if err := deps.Client.Update(ctx, &existing); err != nil {
+				return nil, nil, fmt.Errorf("updating preview: %w", err)
+			}
+		}
+
+		host := fmt.Sprintf("%s.%s", previewName, deps.BaseDomain)
+		result := map[string]any{
+			"name":        previewName,
+			"previewOf":   input.Name,
+			"prNumber":    input.PRNumber,
+			"gitRevision": input.GitRevision,
+			"status":      "building",
+			"message":     fmt.Sprintf("Preview %q is building from %q. It will be available at https://%s — poll app_status with name %q and share the URL with reviewers once Running.", previewName, input.GitRevision, host, previewName),
+		}
+
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
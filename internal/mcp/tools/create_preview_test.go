@@ -0,0 +1,164 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// setupPreviewServer creates a server with register and create_preview registered.
+func setupPreviewServer(t *testing.T) (*gomcp.ClientSession, client.Client) {
+	t.Helper()
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		Store:      store,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterCreatePreview(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mc := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mc.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs, k8sClient
+}
+
+func callCreatePreview(t *testing.T, cs *gomcp.ClientSession, args map[string]any) *gomcp.CallToolResult {
+	t.Helper()
+	res, err := cs.CallTool(context.Background(), &gomcp.CallToolParams{Name: "create_preview", Arguments: args})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestCreatePreview_ClonesGitApp(t *testing.T) {
+	cs, k8sClient := setupPreviewServer(t)
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	source := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: ns},
+		Spec: iafv1alpha1.ApplicationSpec{
+			Git:      &iafv1alpha1.GitSource{URL: "https://github.com/org/web.git", Revision: "main"},
+			Port:     3000,
+			Replicas: 3,
+			Host:     "web.custom.example.com",
+			Env:      []iafv1alpha1.EnvVar{{Name: "MODE", Value: "prod"}},
+		},
+	}
+	if err := k8sClient.Create(ctx, source); err != nil {
+		t.Fatal(err)
+	}
+
+	res := callCreatePreview(t, cs, map[string]any{"session_id": sid, "name": "web", "pr_number": 12, "git_revision": "feat/login"})
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	var out map[string]any
+	json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &out)
+	if out["name"] != "web-pr-12" {
+		t.Errorf("expected preview name 'web-pr-12', got %v", out["name"])
+	}
+
+	var preview iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web-pr-12", Namespace: ns}, &preview); err != nil {
+		t.Fatalf("expected preview app: %v", err)
+	}
+	if preview.Spec.Git.Revision != "feat/login" {
+		t.Errorf("expected revision 'feat/login', got %q", preview.Spec.Git.Revision)
+	}
+	if preview.Spec.Host != "" || preview.Spec.Replicas != 1 {
+		t.Errorf("expected default host and 1 replica, got host=%q replicas=%d", preview.Spec.Host, preview.Spec.Replicas)
+	}
+	if preview.Spec.Port != 3000 || len(preview.Spec.Env) != 1 {
+		t.Errorf("expected port and env copied from source, got %+v", preview.Spec)
+	}
+	if preview.Labels[iafk8s.LabelPreviewPR] != "12" || preview.Annotations[iafk8s.AnnotationPreviewRepo] != "github.com/org/web" {
+		t.Errorf("unexpected preview metadata: labels=%v annotations=%v", preview.Labels, preview.Annotations)
+	}
+
+	// The source app must be untouched.
+	var after iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web", Namespace: ns}, &after); err != nil {
+		t.Fatal(err)
+	}
+	if after.Spec.Git.Revision != "main" {
+		t.Errorf("source app revision changed to %q", after.Spec.Git.Revision)
+	}
+
+	// Calling again updates the existing preview.
+	res = callCreatePreview(t, cs, map[string]any{"session_id": sid, "name": "web", "pr_number": 12, "git_revision": "abc123"})
+	if res.IsError {
+		t.Fatalf("unexpected error on update: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web-pr-12", Namespace: ns}, &preview); err != nil {
+		t.Fatal(err)
+	}
+	if preview.Spec.Git.Revision != "abc123" {
+		t.Errorf("expected preview revision updated to 'abc123', got %q", preview.Spec.Git.Revision)
+	}
+}
+
+func TestCreatePreview_RejectsImageApp(t *testing.T) {
+	cs, k8sClient := setupPreviewServer(t)
+	sid, ns := registerDSSession(t, cs)
+
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: ns},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest"},
+	}
+	if err := k8sClient.Create(context.Background(), app); err != nil {
+		t.Fatal(err)
+	}
+
+	res := callCreatePreview(t, cs, map[string]any{"session_id": sid, "name": "nginx", "pr_number": 1, "git_revision": "main"})
+	if !res.IsError {
+		t.Fatal("expected error for image-based app")
+	}
+	if text := res.Content[0].(*gomcp.TextContent).Text; !strings.Contains(text, "git_url") {
+		t.Errorf("expected guidance mentioning git_url, got %q", text)
+	}
+}
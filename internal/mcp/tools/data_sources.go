@@ -4,10 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log/slog"
 	"strings"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/audit"
 	iafvalidation "github.com/dlapiduz/iaf/internal/validation"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
 	corev1 "k8s.io/api/core/v1"
@@ -44,8 +45,12 @@ type ListDataSourcesInput struct {
 
 // RegisterListDataSources registers the list_data_sources MCP tool.
 func RegisterListDataSources(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "list_data_sources",
+	addTool(server, deps, Capability{
+		Name:     "list_data_sources",
+		Category: CategoryData,
+		ReadOnly: true,
+		Summary:  "List the platform's data sources (databases, APIs)",
+	}, &gomcp.Tool{
 		Description: "List all data sources registered on the platform. Returns metadata only — no credentials are returned. Optionally filter by kind or tags. Use get_data_source for details on a specific source, and attach_data_source to make one available to your app.",
 	}, func(ctx context.Context, req *gomcp.CallToolRequest, input ListDataSourcesInput) (*gomcp.CallToolResult, any, error) {
 		if _, err := deps.ResolveNamespace(input.SessionID); err != nil {
@@ -113,8 +118,12 @@ type GetDataSourceInput struct {
 
 // RegisterGetDataSource registers the get_data_source MCP tool.
 func RegisterGetDataSource(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "get_data_source",
+	addTool(server, deps, Capability{
+		Name:     "get_data_source",
+		Category: CategoryData,
+		ReadOnly: true,
+		Summary:  "Details of a data source, including the env var names it injects",
+	}, &gomcp.Tool{
 		Description: "Get details about a specific data source: kind, description, schema, tags, and the environment variable names that will be injected into your app. Credential values are never returned.",
 	}, func(ctx context.Context, req *gomcp.CallToolRequest, input GetDataSourceInput) (*gomcp.CallToolResult, any, error) {
 		if _, err := deps.ResolveNamespace(input.SessionID); err != nil {
@@ -127,7 +136,7 @@ func RegisterGetDataSource(server *gomcp.Server, deps *Dependencies) {
 		var ds iafv1alpha1.DataSource
 		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.Name}, &ds); err != nil {
 			if apierrors.IsNotFound(err) {
-				return nil, nil, fmt.Errorf("data source %q not found; use list_data_sources to see available sources", input.Name)
+				return nil, nil, apierror.NotFound(apierror.CodeNotFound, "data source %q not found; use list_data_sources to see available sources", input.Name)
 			}
 			return nil, nil, fmt.Errorf("getting data source: %w", err)
 		}
@@ -165,8 +174,12 @@ type AttachDataSourceInput struct {
 
 // RegisterAttachDataSource registers the attach_data_source MCP tool.
 func RegisterAttachDataSource(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "attach_data_source",
+	addTool(server, deps, Capability{
+		Name:          "attach_data_source",
+		Category:      CategoryData,
+		Summary:       "Attach a data source to an app, injecting its credentials as env vars",
+		Preconditions: []string{"the data source exists (list_data_sources)"},
+	}, &gomcp.Tool{
 		Description: "Attach a data source to an application. The platform copies the data source credentials into your namespace and injects them as environment variables into the app container. The app will restart to pick up the new variables. Credential values are never returned.",
 	}, func(ctx context.Context, req *gomcp.CallToolRequest, input AttachDataSourceInput) (*gomcp.CallToolResult, any, error) {
 		namespace, err := deps.ResolveNamespace(input.SessionID)
@@ -185,7 +198,7 @@ func RegisterAttachDataSource(server *gomcp.Server, deps *Dependencies) {
 		var app iafv1alpha1.Application
 		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.AppName, Namespace: namespace}, &app); err != nil {
 			if apierrors.IsNotFound(err) {
-				return nil, nil, fmt.Errorf("application %q not found in your session namespace; use list_apps to see your apps", input.AppName)
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found in your session namespace; use list_apps to see your apps", input.AppName)
 			}
 			return nil, nil, fmt.Errorf("getting application: %w", err)
 		}
@@ -200,10 +213,10 @@ func RegisterAttachDataSource(server *gomcp.Server, deps *Dependencies) {
 					envVarNames = envVarNamesFromMapping(ds.Spec.EnvVarMapping)
 				}
 				result := map[string]any{
-					"datasource":  input.DataSourceName,
-					"app":         input.AppName,
-					"envVarNames": envVarNames,
-					"message":     fmt.Sprintf("Data source %q is already attached to app %q.", input.DataSourceName, input.AppName),
+					"datasource":      input.DataSourceName,
+					"app":             input.AppName,
+					"envVarNames":     envVarNames,
+					"message":         fmt.Sprintf("Data source %q is already attached to app %q.", input.DataSourceName, input.AppName),
 					"alreadyAttached": true,
 				}
 				text, _ := json.MarshalIndent(result, "", "  ")
@@ -217,7 +230,7 @@ func RegisterAttachDataSource(server *gomcp.Server, deps *Dependencies) {
 		var ds iafv1alpha1.DataSource
 		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.DataSourceName}, &ds); err != nil {
 			if apierrors.IsNotFound(err) {
-				return nil, nil, fmt.Errorf("data source %q not found; use list_data_sources to see available sources", input.DataSourceName)
+				return nil, nil, apierror.NotFound(apierror.CodeNotFound, "data source %q not found; use list_data_sources to see available sources", input.DataSourceName)
 			}
 			return nil, nil, fmt.Errorf("getting data source: %w", err)
 		}
@@ -234,7 +247,7 @@ func RegisterAttachDataSource(server *gomcp.Server, deps *Dependencies) {
 			Namespace: ds.Spec.SecretRef.Namespace,
 		}, &srcSecret); err != nil {
 			if apierrors.IsNotFound(err) {
-				return nil, nil, fmt.Errorf("data source %q credential secret not found — contact your platform administrator", input.DataSourceName)
+				return nil, nil, apierror.Platform(apierror.CodeInternal, false, "data source %q credential secret not found — contact your platform administrator", input.DataSourceName)
 			}
 			return nil, nil, fmt.Errorf("reading data source credentials: %w", err)
 		}
@@ -294,13 +307,17 @@ func RegisterAttachDataSource(server *gomcp.Server, deps *Dependencies) {
 			return nil, nil, fmt.Errorf("attaching data source to application: %w", err)
 		}
 
-		// Audit log: every attachment is logged.
-		slog.Info("data source attached",
-			"session", input.SessionID,
-			"datasource", input.DataSourceName,
-			"app", input.AppName,
-			"namespace", namespace,
-		)
+		// Every attachment is audited.
+		deps.SecurityAudit.Record(audit.SecurityEvent{
+			Action:    audit.ActionSecretCopied,
+			Actor:     input.SessionID,
+			Namespace: namespace,
+			Kind:      "data_source",
+			Name:      input.DataSourceName,
+			Secret:    secretName,
+			Source:    srcSecret.Namespace + "/" + srcSecret.Name,
+			App:       input.AppName,
+		})
 
 		envVarNames := envVarNamesFromMapping(ds.Spec.EnvVarMapping)
 		result := map[string]any{
@@ -4,23 +4,31 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
 	"github.com/dlapiduz/iaf/internal/validation"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 type DeleteAppInput struct {
 	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
 	Name      string `json:"name" jsonschema:"required - application name to delete"`
+	Purge     bool   `json:"purge,omitempty" jsonschema:"true to delete it for good right away instead of keeping it restorable with restore_app"`
 }
 
 func RegisterDeleteApp(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "delete_app",
-		Description: "Delete an application and all its associated Kubernetes resources (deployment, service, ingress route, build). Requires session_id from the register tool and the application name. This action is irreversible.",
+	addTool(server, deps, Capability{
+		Name:     "delete_app",
+		Category: CategoryDeploy,
+		Summary:  "Remove an app; restore_app undoes it until the retention window passes",
+		Examples: []string{`{"name":"web"}`, `{"name":"web","purge":true}`},
+	}, &gomcp.Tool{
+		Description: "Delete an application. It is scaled to zero and shown with phase Deleted, but its resources and uploaded source are kept until the platform's retention window passes (app_status reports purgeAt); until then restore_app brings it back as it was. After the window the app and all its Kubernetes resources (deployment, service, ingress route, build) are removed for good. Set purge to true to remove it for good right away, which is irreversible. Requires session_id from the register tool and the application name. Refused with code deletion_protected while the app is deletion protected (set_deletion_protection).",
 	}, func(ctx context.Context, req *gomcp.CallToolRequest, input DeleteAppInput) (*gomcp.CallToolResult, any, error) {
 		namespace, err := deps.ResolveNamespace(input.SessionID)
 		if err != nil {
@@ -30,16 +38,24 @@ func RegisterDeleteApp(server *gomcp.Server, deps *Dependencies) {
 			return nil, nil, err
 		}
 
-		app := &iafv1alpha1.Application{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      input.Name,
-				Namespace: namespace,
-			},
+		app := &iafv1alpha1.Application{}
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, app); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.Name)
+			}
+			return nil, nil, fmt.Errorf("getting application: %w", err)
+		}
+		if app.Spec.DeletionProtected {
+			return nil, nil, iafk8s.DeletionProtected("application", input.Name)
+		}
+
+		if !input.Purge {
+			return softDeleteApp(ctx, deps, app)
 		}
 
 		if err := deps.Client.Delete(ctx, app); err != nil {
 			if apierrors.IsNotFound(err) {
-				return nil, nil, fmt.Errorf("application %q not found", input.Name)
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.Name)
 			}
 			return nil, nil, fmt.Errorf("deleting application: %w", err)
 		}
@@ -49,8 +65,8 @@ func RegisterDeleteApp(server *gomcp.Server, deps *Dependencies) {
 
 		result := map[string]any{
 			"name":    input.Name,
-			"status":  "deleted",
-			"message": fmt.Sprintf("Application %q and all associated resources have been deleted.", input.Name),
+			"status":  "purged",
+			"message": fmt.Sprintf("Application %q and all associated resources have been deleted for good.", input.Name),
 		}
 
 		text, _ := json.MarshalIndent(result, "", "  ")
@@ -59,3 +75,33 @@ func RegisterDeleteApp(server *gomcp.Server, deps *Dependencies) {
 		}, nil, nil
 	})
 }
+
+// softDeleteApp marks app deleted. The controller scales it to zero and
+// removes it once the retention window has passed.
+func softDeleteApp(ctx context.Context, deps *Dependencies, app *iafv1alpha1.Application) (*gomcp.CallToolResult, any, error) {
+	status, message := "deleted", fmt.Sprintf("Application %q is deleted and scaled to zero. Its resources and source are kept until the retention window passes (app_status reports purgeAt); call restore_app to bring it back before then, or delete_app with purge=true to remove it for good now.", app.Name)
+	if _, deleted := app.Annotations[iafv1alpha1.DeletedAtAnnotation]; deleted {
+		message = fmt.Sprintf("Application %q is already deleted; nothing changed. Call restore_app to bring it back, or delete_app with purge=true to remove it for good now.", app.Name)
+	} else {
+		if app.Annotations == nil {
+			app.Annotations = map[string]string{}
+		}
+		app.Annotations[iafv1alpha1.DeletedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		if err := deps.Client.Update(ctx, app); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", app.Name)
+			}
+			return nil, nil, fmt.Errorf("marking application deleted: %w", err)
+		}
+	}
+
+	result := map[string]any{
+		"name":    app.Name,
+		"status":  status,
+		"message": message,
+	}
+	text, _ := json.MarshalIndent(result, "", "  ")
+	return &gomcp.CallToolResult{
+		Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+	}, nil, nil
+}
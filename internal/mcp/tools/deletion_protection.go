@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type SetDeletionProtectionInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Kind      string `json:"kind" jsonschema:"required - app or service"`
+	Name      string `json:"name" jsonschema:"required - name of the application or managed service"`
+	Protected bool   `json:"protected" jsonschema:"true to refuse deleting it until this is called again with false, false to allow deleting it"`
+}
+
+// RegisterSetDeletionProtection registers the set_deletion_protection MCP
+// tool, the only way to set or clear spec.deletionProtected.
+func RegisterSetDeletionProtection(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "set_deletion_protection",
+		Category: CategoryConfigure,
+		Summary:  "Turn deletion protection of an app or managed service on or off",
+		Examples: []string{`{"kind":"service","name":"orders-db","protected":true}`},
+	}, &gomcp.Tool{
+		Description: "Turn deletion protection of an application (kind app) or managed service (kind service) on or off. While it is on, delete_app, deprovision_service, unregister and the REST and kubectl deletes are refused with code deletion_protected, and a TTL that elapses waits for it to be turned off. Turning it off is a deliberate, separate step: only do it when deleting the resource is really intended. Requires session_id from the register tool.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input SetDeletionProtectionInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.Name); err != nil {
+			return nil, nil, err
+		}
+
+		var obj client.Object
+		var protected *bool
+		var kind string
+		switch input.Kind {
+		case "app":
+			app := &iafv1alpha1.Application{}
+			obj, protected, kind = app, &app.Spec.DeletionProtected, "application"
+		case "service":
+			svc := &iafv1alpha1.ManagedService{}
+			obj, protected, kind = svc, &svc.Spec.DeletionProtected, "service"
+		default:
+			return nil, nil, apierror.Validation(apierror.CodeInvalidRequest, "kind must be app or service, got %q", input.Kind)
+		}
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				if input.Kind == "app" {
+					return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.Name)
+				}
+				return nil, nil, apierror.NotFound(apierror.CodeNotFound, "service %q not found", input.Name)
+			}
+			return nil, nil, fmt.Errorf("getting %s: %w", kind, err)
+		}
+
+		status, message := "protected", fmt.Sprintf("Deletion of %s %q is now refused until set_deletion_protection is called with protected=false.", kind, input.Name)
+		if !input.Protected {
+			status, message = "unprotected", fmt.Sprintf("Deletion protection of %s %q is off: it can be deleted, and an elapsed TTL deletes it.", kind, input.Name)
+		}
+		if *protected == input.Protected {
+			message = fmt.Sprintf("The %s %q is already %s; nothing changed.", kind, input.Name, status)
+		} else {
+			*protected = input.Protected
+			if err := deps.Client.Update(ctx, obj); err != nil {
+				return nil, nil, fmt.Errorf("updating %s: %w", kind, err)
+			}
+		}
+
+		result := map[string]any{
+			"kind":      input.Kind,
+			"name":      input.Name,
+			"protected": input.Protected,
+			"status":    status,
+			"message":   message,
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
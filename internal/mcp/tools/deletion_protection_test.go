@@ -0,0 +1,135 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDeletionProtection(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		Store:      store,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+	}
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterUnregisterTool(server, deps)
+	tools.RegisterSetDeletionProtection(server, deps)
+	tools.RegisterDeleteApp(server, deps)
+	tools.RegisterAppStatus(server, deps)
+	tools.RegisterDeprovisionService(server, deps)
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	cs, err := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil).Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	sid, ns := registerDSSession(t, cs)
+
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: ns},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:alpine", Replicas: 1},
+	}
+	svc := &iafv1alpha1.ManagedService{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: ns},
+		Spec:       iafv1alpha1.ManagedServiceSpec{Type: "postgres", Plan: iafv1alpha1.ServicePlanMicro},
+	}
+	if err := k8sClient.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	if err := k8sClient.Create(ctx, svc); err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(name string, args map[string]any) (map[string]any, bool) {
+		t.Helper()
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		text := res.Content[0].(*gomcp.TextContent).Text
+		if res.IsError {
+			return map[string]any{"error": text}, true
+		}
+		var out map[string]any
+		if err := json.Unmarshal([]byte(text), &out); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return out, false
+	}
+	protect := func(kind, name string, protected bool) {
+		t.Helper()
+		if out, isErr := call("set_deletion_protection", map[string]any{"session_id": sid, "kind": kind, "name": name, "protected": protected}); isErr {
+			t.Fatalf("set_deletion_protection failed: %v", out)
+		}
+	}
+
+	protect("app", "web", true)
+	protect("service", "db", true)
+	if out, _ := call("app_status", map[string]any{"session_id": sid, "name": "web"}); out["deletionProtected"] != true {
+		t.Errorf("expected app_status to report deletion protection, got %v", out["deletionProtected"])
+	}
+
+	for _, c := range []struct{ tool, name string }{{"delete_app", "web"}, {"deprovision_service", "db"}} {
+		out, isErr := call(c.tool, map[string]any{"session_id": sid, "name": c.name})
+		if !isErr || !strings.Contains(out["error"].(string), "is deletion protected") {
+			t.Errorf("expected %s to be refused with deletion_protected, got %v", c.tool, out)
+		}
+	}
+	out, isErr := call("unregister", map[string]any{"session_id": sid})
+	if !isErr || !strings.Contains(out["error"].(string), "service/db") {
+		t.Errorf("expected unregister to be refused with deletion_protected, got %v", out)
+	}
+	if _, ok := sessions.Lookup(sid); !ok {
+		t.Fatal("expected the session to be kept")
+	}
+
+	if out, isErr := call("set_deletion_protection", map[string]any{"session_id": sid, "kind": "job", "name": "web", "protected": false}); !isErr {
+		t.Errorf("expected an unknown kind to be rejected, got %v", out)
+	}
+
+	protect("app", "web", false)
+	if out, isErr := call("delete_app", map[string]any{"session_id": sid, "name": "web", "purge": true}); isErr {
+		t.Fatalf("delete_app failed after clearing protection: %v", out)
+	}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web", Namespace: ns}, &iafv1alpha1.Application{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the app to be deleted, got %v", err)
+	}
+	var got iafv1alpha1.ManagedService
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "db", Namespace: ns}, &got); err != nil || !got.Spec.DeletionProtected {
+		t.Errorf("expected the service to stay protected, got %v, %+v", err, got.Spec)
+	}
+}
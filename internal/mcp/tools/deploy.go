@@ -3,10 +3,21 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/features"
 	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/policy"
+	"github.com/dlapiduz/iaf/internal/registry"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
 	"github.com/dlapiduz/iaf/internal/validation"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
 	corev1 "k8s.io/api/core/v1"
@@ -16,22 +27,60 @@ import (
 )
 
 type DeployAppInput struct {
-	SessionID     string               `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
-	Name          string               `json:"name" jsonschema:"required - application name (lowercase, hyphens allowed, becomes part of URL)"`
-	Image         string               `json:"image,omitempty" jsonschema:"container image to deploy (e.g. 'nginx:latest') - provide either image or git_url"`
-	GitURL        string               `json:"git_url,omitempty" jsonschema:"git repository URL to build from (e.g. 'https://github.com/user/repo') - provide either image or git_url"`
-	GitRevision   string               `json:"git_revision,omitempty" jsonschema:"git branch, tag, or commit (default: main)"`
-	GitCredential string               `json:"git_credential,omitempty" jsonschema:"name of a git credential (from add_git_credential) to use when cloning a private repository"`
-	Port          int32                `json:"port,omitempty" jsonschema:"port your app listens on (default: 8080)"`
-	Replicas      int32                `json:"replicas,omitempty" jsonschema:"number of replicas (default: 1)"`
-	Env           []iafv1alpha1.EnvVar `json:"env,omitempty" jsonschema:"environment variables as [{name, value}]"`
+	SessionID          string                         `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Name               string                         `json:"name" jsonschema:"required - application name (lowercase, hyphens allowed, becomes part of URL)"`
+	Image              string                         `json:"image,omitempty" jsonschema:"container image to deploy (e.g. 'nginx:1.27'); prefer a version tag or digest over latest - provide either image or git_url"`
+	GitURL             string                         `json:"git_url,omitempty" jsonschema:"git repository URL to build from (e.g. 'https://github.com/user/repo') - provide either image or git_url"`
+	SourceApp          string                         `json:"source_app,omitempty" jsonschema:"name of an app in your session deployed with push_code whose uploaded source to copy and build under the new name, to fork it. Instead of image or git_url"`
+	BlobURL            string                         `json:"blob_url,omitempty" jsonschema:"blob URL of uploaded source to copy and build: a template's blobUrl from the iaf://platform resource, or the blobUrl of source uploaded in your session. Instead of image or git_url"`
+	GitRevision        string                         `json:"git_revision,omitempty" jsonschema:"git branch, tag, or commit (default: main)"`
+	GitSubPath         string                         `json:"git_sub_path,omitempty" jsonschema:"directory of the repository to build, for monorepos with several services (e.g. 'services/api'). Default: the repository root"`
+	GitTrack           string                         `json:"git_track,omitempty" jsonschema:"'revision' (default) builds git_revision once; 'branch' builds and deploys every new commit pushed to the git_revision branch. Pause and resume it with set_auto_deploy"`
+	GitCredential      string                         `json:"git_credential,omitempty" jsonschema:"name of a git credential (from add_git_credential) to use when cloning a private repository"`
+	RegistryCredential string                         `json:"registry_credential,omitempty" jsonschema:"name of a registry credential (from add_registry_credential) used to pull a private image"`
+	Port               int32                          `json:"port,omitempty" jsonschema:"port your app listens on (default: 8080)"`
+	Replicas           int32                          `json:"replicas,omitempty" jsonschema:"number of replicas (default: 1)"`
+	Env                []iafv1alpha1.EnvVar           `json:"env,omitempty" jsonschema:"environment variables as [{name, value}]"`
+	BuildEnv           []iafv1alpha1.EnvVar           `json:"build_env,omitempty" jsonschema:"environment variables for git builds only, as [{name, value}] (e.g. BP_GO_TARGETS, NODE_ENV, BP_JVM_VERSION); not set when the app runs"`
+	Protocol           string                         `json:"protocol,omitempty" jsonschema:"routing protocol: 'http' (default), 'websocket', 'grpc' (HTTP/2 cleartext to your app), or 'tcp' (raw TCP routed by TLS SNI on port 443)"`
+	StickySessions     bool                           `json:"sticky_sessions,omitempty" jsonschema:"pin each client to one pod with a cookie (useful for websocket apps); ignored for tcp"`
+	Authentication     string                         `json:"authentication,omitempty" jsonschema:"protect the app URL: 'none' (default), 'basic' (generated username/password — fetch once with get_app_credentials), or 'oauth-proxy' (login via the platform identity provider)"`
+	BackendTLS         bool                           `json:"backend_tls,omitempty" jsonschema:"encrypt traffic from the ingress to your app: the platform mounts a certificate and sets IAF_TLS_CERT_FILE and IAF_TLS_KEY_FILE, and your app must serve HTTPS on its port with them. Not for tcp or oauth-proxy. Default: plain HTTP inside the cluster"`
+	IPAllowList        []string                       `json:"ip_allow_list,omitempty" jsonschema:"restrict access to these source IPs or CIDR ranges (e.g. ['10.0.0.0/8', '203.0.113.7']); empty allows everyone"`
+	RequestsPerSecond  int32                          `json:"requests_per_second,omitempty" jsonschema:"average requests per second allowed per client IP, bursts up to 2x (default: unlimited)"`
+	IdleTimeout        string                         `json:"idle_timeout,omitempty" jsonschema:"scale the app to zero after this long without requests (e.g. '30m', '2h'); the next visitor wakes it. '0' disables idling; default: the platform default"`
+	TTL                string                         `json:"ttl,omitempty" jsonschema:"delete the app automatically this long after it is created (e.g. '72h'; 10m to 720h). Use for demos and throwaway deployments; default: never"`
+	UptimeCheckPath    string                         `json:"uptime_check_path,omitempty" jsonschema:"probe this path of the app URL from outside the cluster (e.g. '/healthz'); app_status then reports uptime and you can alert on it with set_alert type 'uptime'. Default: no uptime check"`
+	UptimeCheckSeconds int32                          `json:"uptime_check_interval_seconds,omitempty" jsonschema:"seconds between uptime check probes (30-3600; default: 60). Requires uptime_check_path"`
+	VerifyConformance  bool                           `json:"verify_conformance,omitempty" jsonschema:"after each deploy, have the platform check the running app against the org standards: the health and /metrics endpoints, JSON logs and exported spans. app_status reports the result under 'conformance'. Not for tcp"`
+	BuildCache         string                         `json:"build_cache,omitempty" jsonschema:"where git builds keep their dependency cache between builds: 'volume' (a disk in your namespace), 'registry' (an image next to the app image), or 'none'. Default: the platform default"`
+	BuildCacheSize     string                         `json:"build_cache_size,omitempty" jsonschema:"size of the volume build cache (e.g. '5Gi'; 1Gi to 20Gi). Default: the platform default"`
+	Builder            string                         `json:"builder,omitempty" jsonschema:"buildpack builder for git builds, one of the builders listed in the iaf://platform resource (e.g. a tiny or Java-native stack). Default: the platform default"`
+	WorkloadClass      string                         `json:"workload_class,omitempty" jsonschema:"node pool to run on: 'standard' (default), 'burst' or 'gpu'; must be one of the workload classes listed in the iaf://platform resource. 'gpu' places the app on GPU nodes but does not request a GPU"`
+	ConfigFiles        []iafv1alpha1.ConfigFile       `json:"config_files,omitempty" jsonschema:"files mounted read-only into the container, as [{path, content}] or [{path, configMap: {name, key}}] for a ConfigMap in your namespace (e.g. [{path: '/app/config.yaml', content: 'port: 8080'}]). Max 20 files, 256 KiB each, 512 KiB in total. Change them later with set_config_file"`
+	Architecture       string                         `json:"architecture,omitempty" jsonschema:"CPU architecture to build for and run on: 'amd64', 'arm64', or 'multi' (runs on either); must be one of the architectures listed in the iaf://platform resource. For 'image', the image must support it. Default: any node"`
+	HostAliases        []iafv1alpha1.HostAlias        `json:"host_aliases,omitempty" jsonschema:"extra /etc/hosts entries for systems outside DNS, as [{ip, hostnames}] (e.g. [{ip: '10.20.0.5', hostnames: ['ldap.corp.example.com']}]). Max 10. Names under the cluster domain, *.svc and localhost are rejected. Only when the iaf://platform resource reports customDNS"`
+	DNSConfig          *iafv1alpha1.DNSConfig         `json:"dns_config,omitempty" jsonschema:"extra resolver settings added after cluster DNS, as {nameservers, searches, options: [{name, value}]}: up to 2 nameserver IPs, 3 search domains and 5 options (ndots, timeout, attempts, rotate, edns0, single-request, single-request-reopen, use-vc). Only when the iaf://platform resource reports customDNS"`
+	Command            []string                       `json:"command,omitempty" jsonschema:"command the container runs instead of the image entrypoint, one argument per item (e.g. ['node', 'worker.js']). For git builds prefer a Procfile in the repository; a command here runs through the buildpack launcher. Default: the image entrypoint, or the built web process"`
+	Args               []string                       `json:"args,omitempty" jsonschema:"arguments for command, or for the image entrypoint when command is not set (e.g. ['--port', '8080'])"`
+	ReleaseCommand     []string                       `json:"release_command,omitempty" jsonschema:"command run_migration runs by default, one argument per item (e.g. ['npm', 'run', 'migrate']). It runs only when you call run_migration, never on deploy"`
+	Metadata           *iafv1alpha1.OwnershipMetadata `json:"metadata,omitempty" jsonschema:"ownership of the app, as {team, owner, purpose, costCenter} (e.g. {team: 'payments', owner: 'jane@example.com', purpose: 'checkout'}); set as labels on everything the app runs. Default: the metadata given to register"`
+	IdempotencyKey     string                         `json:"idempotency_key,omitempty" jsonschema:"optional key you choose, such as a UUID, that makes retrying safe: repeating the call with the same key and input within 24 hours returns the original result instead of deploying again. Use a new key for each distinct deploy"`
+}
+
+func (in DeployAppInput) idempotencyKey() (sessionID, key string) {
+	return in.SessionID, in.IdempotencyKey
 }
 
 func RegisterDeployApp(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "deploy_app",
-		Description: "Deploy an application from a pre-built container image or git repository. Requires session_id from the register tool. Provide either 'image' (e.g. 'nginx:latest') or 'git_url' (e.g. 'https://github.com/user/repo'). The app will be available at http://<name>.<base-domain> once running. Default port: 8080.",
-	}, func(ctx context.Context, req *gomcp.CallToolRequest, input DeployAppInput) (*gomcp.CallToolResult, any, error) {
+	addTool(server, deps, Capability{
+		Name:     "deploy_app",
+		Category: CategoryDeploy,
+		Summary:  "Deploy from a container image, a git repository or a copy of uploaded source",
+		Examples: []string{`{"session_id": "<id>", "name": "web", "image": "nginx:1.27"}`, `{"session_id": "<id>", "name": "api", "git_url": "https://github.com/org/api", "git_revision": "main"}`, `{"session_id": "<id>", "name": "api-staging", "git_url": "https://github.com/org/api", "git_revision": "staging", "git_track": "branch"}`, `{"session_id": "<id>", "name": "billing", "git_url": "https://github.com/org/platform", "git_sub_path": "services/billing"}`, `{"session_id": "<id>", "name": "web-v2", "source_app": "web"}`},
+	}, &gomcp.Tool{
+		Description: "Deploy an application from a pre-built container image or git repository. Requires session_id from the register tool. Provide either 'image' (e.g. 'nginx:1.27') or 'git_url' (e.g. 'https://github.com/user/repo'). A git app builds 'git_revision' once; set 'git_track' to 'branch' to build and deploy every new commit on that branch, e.g. one app per environment branch. For a monorepo, set 'git_sub_path' to the directory of the service to build. To start from uploaded source instead, set 'source_app' to copy the source of an app in your session deployed with push_code, or 'blob_url' to copy a template listed in the iaf://platform resource; the copy is built as a new app you then change with push_code. Set 'command' and 'args' to run something other than the image's entrypoint, such as a worker; for git builds a Procfile is preferred. The app will be available at http://<name>.<base-domain> once running. Default port: 8080. Set 'protocol' to 'websocket', 'grpc', or 'tcp' for realtime, gRPC, or raw TCP services. Set 'authentication' to 'basic' or 'oauth-proxy' for internal tools that must not be public.",
+	}, idempotent(deps, "deploy_app", func(ctx context.Context, req *gomcp.CallToolRequest, input DeployAppInput) (*gomcp.CallToolResult, any, error) {
 		namespace, err := deps.ResolveNamespace(input.SessionID)
 		if err != nil {
 			return nil, nil, err
@@ -44,8 +93,114 @@ func RegisterDeployApp(server *gomcp.Server, deps *Dependencies) {
 				return nil, nil, err
 			}
 		}
-		if input.Image == "" && input.GitURL == "" {
-			return nil, nil, fmt.Errorf("either image or git_url is required")
+		for _, e := range input.BuildEnv {
+			if err := validation.ValidateBuildEnvVarName(e.Name); err != nil {
+				return nil, nil, err
+			}
+		}
+		sources := 0
+		for _, source := range []string{input.Image, input.GitURL, input.SourceApp, input.BlobURL} {
+			if source != "" {
+				sources++
+			}
+		}
+		if sources == 0 {
+			return nil, nil, fmt.Errorf("either image or git_url is required, or source_app or blob_url to copy uploaded source")
+		}
+		if sources > 1 {
+			return nil, nil, fmt.Errorf("set only one of image, git_url, source_app and blob_url")
+		}
+		if err := validateMetadata(input.Metadata); err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateProtocol(input.Protocol); err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAuthentication(input.Authentication, input.Protocol); err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateBackendTLS(input.BackendTLS, input.Protocol, input.Authentication); err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAccess(input.IPAllowList, input.RequestsPerSecond, input.Protocol); err != nil {
+			return nil, nil, err
+		}
+		var idleTimeout *metav1.Duration
+		if input.IdleTimeout != "" {
+			d, err := validation.ValidateIdleTimeout(input.IdleTimeout, input.Protocol)
+			if err != nil {
+				return nil, nil, err
+			}
+			idleTimeout = &metav1.Duration{Duration: d}
+		}
+		if input.UptimeCheckPath == "" && input.UptimeCheckSeconds != 0 {
+			return nil, nil, fmt.Errorf("uptime_check_interval_seconds requires uptime_check_path")
+		}
+		if input.UptimeCheckPath != "" {
+			if err := validation.ValidateUptimeCheck(input.UptimeCheckPath, input.UptimeCheckSeconds, input.Protocol); err != nil {
+				return nil, nil, err
+			}
+		}
+		if input.VerifyConformance && input.Protocol == string(iafv1alpha1.ProtocolTCP) {
+			return nil, nil, apierror.Validation(apierror.CodeInvalidRequest, "verify_conformance is not supported for tcp apps")
+		}
+		buildCacheSize, err := validation.ValidateBuildCache(input.BuildCache, input.BuildCacheSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateBuilder(input.Builder, deps.Builders); err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateArchitecture(input.Architecture, deps.Architectures); err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateWorkloadClass(input.WorkloadClass, deps.WorkloadClasses); err != nil {
+			return nil, nil, err
+		}
+		if err := validateConfigFiles(input.ConfigFiles); err != nil {
+			return nil, nil, err
+		}
+		if err := iafk8s.CheckCustomDNS(deps.CustomDNS, input.HostAliases, input.DNSConfig); err != nil {
+			return nil, nil, err
+		}
+		if err := requireDeployFeatures(deps, input); err != nil {
+			return nil, nil, err
+		}
+		if len(input.ReleaseCommand) > 0 {
+			if err := validation.ValidateCommand(input.ReleaseCommand); err != nil {
+				return nil, nil, fmt.Errorf("invalid release_command: %w", err)
+			}
+		}
+		if len(input.Command) > 0 {
+			if err := validation.ValidateCommand(input.Command); err != nil {
+				return nil, nil, fmt.Errorf("invalid command: %w", err)
+			}
+		}
+		if err := validation.ValidateArgs(input.Args); err != nil {
+			return nil, nil, fmt.Errorf("invalid args: %w", err)
+		}
+		if err := validation.ValidateGitTrack(input.GitTrack, input.GitRevision); err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateSubPath(input.GitSubPath); err != nil {
+			return nil, nil, err
+		}
+		if input.Builder != "" && input.Architecture != "" {
+			return nil, nil, fmt.Errorf("set either builder or architecture, not both; architecture selects its own builder")
+		}
+		if (input.GitTrack != "" || input.GitSubPath != "") && input.GitURL == "" {
+			return nil, nil, fmt.Errorf("git_track and git_sub_path require git_url")
+		}
+		if (input.BuildCache != "" || buildCacheSize != nil || len(input.BuildEnv) > 0 || input.Builder != "") && input.Image != "" {
+			return nil, nil, fmt.Errorf("build_cache, build_cache_size, build_env and builder are for git_url, source_app and blob_url; pre-built images are not built")
+		}
+		var ttl *metav1.Duration
+		if input.TTL != "" {
+			d, err := validation.ValidateTTL(input.TTL)
+			if err != nil {
+				return nil, nil, err
+			}
+			ttl = &metav1.Duration{Duration: d}
 		}
 
 		// Validate git_credential if provided: the Secret must exist in the session namespace
@@ -54,7 +209,7 @@ func RegisterDeployApp(server *gomcp.Server, deps *Dependencies) {
 			credSecret := &corev1.Secret{}
 			if err := deps.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: input.GitCredential}, credSecret); err != nil {
 				if apierrors.IsNotFound(err) {
-					return nil, nil, fmt.Errorf("git credential %q not found; create it with add_git_credential first", input.GitCredential)
+					return nil, nil, apierror.NotFound(apierror.CodeNotFound, "git credential %q not found; create it with add_git_credential first", input.GitCredential)
 				}
 				return nil, nil, fmt.Errorf("looking up git credential: %w", err)
 			}
@@ -63,22 +218,85 @@ func RegisterDeployApp(server *gomcp.Server, deps *Dependencies) {
 			}
 		}
 
+		// Validate registry_credential the same way.
+		if input.RegistryCredential != "" {
+			credSecret := &corev1.Secret{}
+			if err := deps.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: input.RegistryCredential}, credSecret); err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil, nil, apierror.NotFound(apierror.CodeNotFound, "registry credential %q not found; create it with add_registry_credential first", input.RegistryCredential)
+				}
+				return nil, nil, fmt.Errorf("looking up registry credential: %w", err)
+			}
+			if credSecret.Labels[iafk8s.LabelCredentialType] != iafk8s.CredentialTypeRegistry {
+				return nil, nil, fmt.Errorf("secret %q is not a registry credential managed by IAF", input.RegistryCredential)
+			}
+		}
+
 		if err := deps.CheckAppNameAvailable(ctx, input.Name, namespace); err != nil {
 			return nil, nil, err
 		}
 
+		// Resolve the uploaded source to copy, which must be in the session
+		// namespace or a platform template.
+		from, subPath, err := resolveDeploySource(ctx, deps, namespace, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		var sourceFiles map[string]string
+		if from != nil {
+			if from.Namespace == namespace && from.App == input.Name {
+				return nil, nil, apierror.Validation(apierror.CodeInvalidRequest, "cannot copy the source of %q onto itself", input.Name).
+					WithHint("choose a new name for the copy")
+			}
+			// Copying replaces the stored source of the new app, so refuse
+			// before that if the app already exists.
+			if err := deps.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: input.Name}, &iafv1alpha1.Application{}); err == nil {
+				return nil, nil, apierror.Conflict(apierror.CodeNameTaken, "application %q already exists", input.Name).
+					WithHint("choose a new name for the copy")
+			} else if !apierrors.IsNotFound(err) {
+				return nil, nil, fmt.Errorf("checking application: %w", err)
+			}
+			sourceFiles, err = deps.Store.ReadFiles(from.Namespace, from.App)
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, nil, apierror.NotFound(apierror.CodeNotFound, "the source to copy no longer exists")
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("reading source: %w", err)
+			}
+		}
+
 		app := &iafv1alpha1.Application{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      input.Name,
 				Namespace: namespace,
 			},
 			Spec: iafv1alpha1.ApplicationSpec{
-				Image:    input.Image,
-				Port:     input.Port,
-				Replicas: input.Replicas,
-				Env:      input.Env,
+				Image:              input.Image,
+				RegistryCredential: input.RegistryCredential,
+				Port:               input.Port,
+				Replicas:           input.Replicas,
+				Env:                input.Env,
+				BuildEnv:           input.BuildEnv,
+				Builder:            input.Builder,
+				Architecture:       iafv1alpha1.ApplicationArchitecture(input.Architecture),
+				WorkloadClass:      iafv1alpha1.WorkloadClass(input.WorkloadClass),
+				ConfigFiles:        input.ConfigFiles,
+				Command:            input.Command,
+				Args:               input.Args,
+				ReleaseCommand:     input.ReleaseCommand,
+				HostAliases:        input.HostAliases,
+				DNSConfig:          input.DNSConfig,
+				Protocol:           iafv1alpha1.ApplicationProtocol(input.Protocol),
+				StickySessions:     input.StickySessions,
+				Authentication:     iafv1alpha1.ApplicationAuthentication(input.Authentication),
+				IdleTimeout:        idleTimeout,
+				TTL:                ttl,
+				Metadata:           input.Metadata,
 			},
 		}
+		if app.Spec.Metadata == nil {
+			app.Spec.Metadata = deps.SessionMetadata(input.SessionID)
+		}
 
 		if input.GitURL != "" {
 			revision := input.GitRevision
@@ -88,6 +306,47 @@ func RegisterDeployApp(server *gomcp.Server, deps *Dependencies) {
 			app.Spec.Git = &iafv1alpha1.GitSource{
 				URL:      input.GitURL,
 				Revision: revision,
+				SubPath:  input.GitSubPath,
+				Track:    iafv1alpha1.GitTrackMode(input.GitTrack),
+			}
+		}
+
+		if from != nil {
+			app.Spec.BlobSubPath = subPath
+			if deps.OrgStandards != nil {
+				if err := pinRuntime(app, input.BuildEnv, sourceFiles, deps.OrgStandards.Get()); err != nil {
+					return nil, nil, err
+				}
+				if err := checkDependencies(app, sourceFiles, deps.OrgStandards.Get()); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+
+		if len(input.IPAllowList) > 0 || input.RequestsPerSecond > 0 {
+			app.Spec.Access = &iafv1alpha1.AccessConfig{
+				IPAllowList:       input.IPAllowList,
+				RequestsPerSecond: input.RequestsPerSecond,
+			}
+		}
+
+		if input.BackendTLS {
+			app.Spec.BackendTLS = &iafv1alpha1.BackendTLSConfig{Enabled: true}
+		}
+
+		if input.UptimeCheckPath != "" {
+			app.Spec.UptimeCheck = &iafv1alpha1.UptimeCheckConfig{
+				Path:            input.UptimeCheckPath,
+				IntervalSeconds: input.UptimeCheckSeconds,
+			}
+		}
+
+		app.Spec.VerifyConformance = input.VerifyConformance
+
+		if input.BuildCache != "" || buildCacheSize != nil {
+			app.Spec.BuildCache = &iafv1alpha1.BuildCacheConfig{
+				Type:       iafv1alpha1.BuildCacheType(input.BuildCache),
+				VolumeSize: buildCacheSize,
 			}
 		}
 
@@ -98,9 +357,47 @@ func RegisterDeployApp(server *gomcp.Server, deps *Dependencies) {
 			app.Spec.Replicas = 1
 		}
 
+		policyInput := policy.Input{Operation: iafv1alpha1.PolicyOperationDeploy, Namespace: namespace, App: app}
+		if from != nil {
+			policyInput.Files = slices.Sorted(maps.Keys(sourceFiles))
+		}
+		if res, err := deps.CheckPolicy(ctx, policyInput); res != nil || err != nil {
+			return res, nil, err
+		}
+
+		// storedSource is set once this call stored the new app's source, so
+		// a failed create removes only what it added.
+		storedSource := false
+		if from != nil {
+			hadSource := deps.Store.HasSource(namespace, input.Name)
+			blob, err := deps.Store.CopySource(*from, namespace, input.Name)
+			switch {
+			case errors.Is(err, sourcestore.ErrBlobChanged):
+				return nil, nil, apierror.Conflict(apierror.CodeConflict, "the source behind blob_url was replaced after the URL was issued").
+					WithHint("use the current blob URL, such as the one listed in the iaf://platform resource")
+			case errors.Is(err, fs.ErrNotExist):
+				return nil, nil, apierror.NotFound(apierror.CodeNotFound, "the source to copy no longer exists")
+			case errors.Is(err, sourcestore.ErrTooLarge):
+				return nil, nil, uploadError(err, deps.Store)
+			case err != nil:
+				return nil, nil, fmt.Errorf("copying source: %w", err)
+			}
+			storedSource = !hadSource
+			app.Spec.Blob = blob.URL + "&rev=" + strconv.FormatInt(time.Now().UnixNano(), 36)
+			metav1.SetMetaDataAnnotation(&app.ObjectMeta, sourcestore.AnnotationSourceSHA256, blob.SHA256)
+		}
+
 		if err := deps.Client.Create(ctx, app); err != nil {
+			if storedSource {
+				_ = deps.Store.Delete(namespace, input.Name)
+			}
 			if apierrors.IsAlreadyExists(err) {
-				return nil, nil, fmt.Errorf("application %q already exists", input.Name)
+				hint := "use push_code to update it, or pass the same idempotency_key when retrying a deploy"
+				var existing iafv1alpha1.Application
+				if deps.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: input.Name}, &existing) == nil && existing.Annotations[iafv1alpha1.DeletedAtAnnotation] != "" {
+					hint = "it was deleted with delete_app and is kept until its retention window passes: call restore_app to bring it back, or delete_app with purge=true to free the name"
+				}
+				return nil, nil, apierror.Conflict(apierror.CodeNameTaken, "application %q already exists", input.Name).WithHint(hint)
 			}
 			return nil, nil, fmt.Errorf("creating application: %w", err)
 		}
@@ -111,17 +408,96 @@ func RegisterDeployApp(server *gomcp.Server, deps *Dependencies) {
 			"status":  "created",
 			"message": fmt.Sprintf("Application %q created successfully. It will be available at https://%s once deployed (TLS enabled by default).", input.Name, host),
 		}
-		if input.GitURL != "" {
+		switch {
+		case input.GitURL != "":
 			result["source"] = "git"
 			result["buildRequired"] = true
-		} else {
+			result["autoDeploy"] = iafk8s.GitAutoDeploy(app)
+		case from != nil:
+			result["source"] = "blob"
+			result["buildRequired"] = true
+			if from.Namespace == sourcestore.TemplatesNamespace {
+				result["copiedFrom"] = "template " + from.App
+			} else {
+				result["copiedFrom"] = from.App
+			}
+		default:
 			result["source"] = "image"
 			result["buildRequired"] = false
 		}
+		if input.Image != "" && registry.IsLatest(input.Image) {
+			result["warning"] = fmt.Sprintf("%q uses the mutable latest tag. The platform pins the digest it points at now, but deploy a version tag or digest so upgrades are deliberate.", input.Image)
+		}
+		if deps.Offline && input.Image != "" && registry.IsPublic(input.Image) {
+			result["offlineWarning"] = fmt.Sprintf("This platform has no internet access and %q is on a public registry. Unless the cluster mirrors that registry the pull will fail — deploy the image from your internal registry instead.", input.Image)
+		}
+		if app.Spec.Authentication == iafv1alpha1.AuthenticationBasic {
+			result["credentialsHint"] = "The URL requires basic auth. Call get_app_credentials once the app is Deploying to retrieve the generated username and password — they are returned only once."
+		}
 
 		text, _ := json.MarshalIndent(result, "", "  ")
 		return &gomcp.CallToolResult{
 			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
 		}, nil, nil
-	})
+	}))
+}
+
+// resolveDeploySource returns the uploaded source deploy_app copies for
+// source_app or blob_url, and the sub path to build, or nil when the app is
+// deployed from an image or git. Only source in the session namespace and
+// the platform templates can be copied.
+func resolveDeploySource(ctx context.Context, deps *Dependencies, namespace string, input DeployAppInput) (*sourcestore.BlobRef, string, error) {
+	switch {
+	case input.SourceApp != "":
+		if err := validation.ValidateAppName(input.SourceApp); err != nil {
+			return nil, "", fmt.Errorf("invalid source_app: %w", err)
+		}
+		var src iafv1alpha1.Application
+		if err := deps.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: input.SourceApp}, &src); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, "", apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.SourceApp)
+			}
+			return nil, "", fmt.Errorf("looking up source_app: %w", err)
+		}
+		if src.Spec.Blob == "" {
+			return nil, "", apierror.Validation(apierror.CodeInvalidRequest, "application %q has no uploaded source to copy", input.SourceApp).
+				WithHint("only apps deployed with push_code can be copied; deploy from the same image or git_url instead")
+		}
+		return &sourcestore.BlobRef{Namespace: namespace, App: src.Name}, src.Spec.BlobSubPath, nil
+	case input.BlobURL != "":
+		ref, err := deps.Store.ResolveBlobURL(input.BlobURL)
+		if err != nil {
+			return nil, "", apierror.Validation(apierror.CodeInvalidRequest, "blob_url is not a source blob URL issued by this platform").
+				WithHint("use a template's blobUrl from the iaf://platform resource, or source_app to copy an app in your session")
+		}
+		if ref.Namespace != namespace && ref.Namespace != sourcestore.TemplatesNamespace {
+			return nil, "", apierror.Validation(apierror.CodeForbidden, "blob_url points at source outside your session; only your own source and platform templates can be copied")
+		}
+		return &ref, "", nil
+	}
+	return nil, "", nil
+}
+
+// requireDeployFeatures rejects options that need a feature the platform has
+// turned off, instead of deploying an app that cannot work as asked.
+func requireDeployFeatures(deps *Dependencies, input DeployAppInput) error {
+	if input.Authentication == string(iafv1alpha1.AuthenticationOAuthProxy) {
+		if err := deps.RequireFeature(features.OAuthProxy); err != nil {
+			return err
+		}
+	}
+	if input.BackendTLS {
+		if err := deps.RequireFeature(features.BackendTLS); err != nil {
+			return err
+		}
+	}
+	if input.IdleTimeout != "" {
+		if err := deps.RequireFeature(features.Idling); err != nil {
+			return err
+		}
+	}
+	if input.UptimeCheckPath != "" {
+		return deps.RequireFeature(features.Uptime)
+	}
+	return nil
 }
@@ -0,0 +1,380 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/policy"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	maxStackComponents      = 20
+	defaultStackWaitSeconds = 60
+	maxStackWaitSeconds     = 300
+)
+
+// stackPollInterval is how often deploy_stack re-reads services while waiting
+// for them to become Ready.
+var stackPollInterval = 2 * time.Second
+
+type StackService struct {
+	Name string `json:"name" jsonschema:"required - service name (lowercase, hyphens allowed)"`
+	Type string `json:"type" jsonschema:"required - service type: 'postgres'"`
+	Plan string `json:"plan" jsonschema:"required - service plan, such as 'micro', 'small' or 'ha'; list_service_plans lists the plans offered"`
+}
+
+type StackApp struct {
+	Name        string               `json:"name" jsonschema:"required - application name (lowercase, hyphens allowed, becomes part of URL)"`
+	Image       string               `json:"image,omitempty" jsonschema:"container image to deploy - provide either image or git_url"`
+	GitURL      string               `json:"git_url,omitempty" jsonschema:"git repository URL to build from - provide either image or git_url"`
+	GitRevision string               `json:"git_revision,omitempty" jsonschema:"git branch, tag, or commit (default: main)"`
+	Port        int32                `json:"port,omitempty" jsonschema:"port your app listens on (default: 8080)"`
+	Replicas    int32                `json:"replicas,omitempty" jsonschema:"number of replicas (default: 1)"`
+	Env         []iafv1alpha1.EnvVar `json:"env,omitempty" jsonschema:"environment variables as [{name, value}]"`
+	Services    []string             `json:"services,omitempty" jsonschema:"names of services from this manifest to bind to the app; connection env vars are injected"`
+}
+
+type DeployStackInput struct {
+	SessionID   string         `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Name        string         `json:"name" jsonschema:"required - stack name; labels every component so stack_status can find them"`
+	Services    []StackService `json:"services,omitempty" jsonschema:"managed services to provision before any app"`
+	Apps        []StackApp     `json:"apps,omitempty" jsonschema:"applications to deploy once their services are Ready"`
+	WaitSeconds int            `json:"wait_seconds,omitempty" jsonschema:"how long to wait for services to become Ready before returning (default: 60, max: 300)"`
+}
+
+// stackComponent reports what deploy_stack or stack_status did with one
+// manifest entry.
+type stackComponent struct {
+	Kind          string   `json:"kind"`
+	Name          string   `json:"name"`
+	Action        string   `json:"action,omitempty"`
+	Phase         string   `json:"phase,omitempty"`
+	Message       string   `json:"message,omitempty"`
+	URL           string   `json:"url,omitempty"`
+	BoundServices []string `json:"boundServices,omitempty"`
+}
+
+// RegisterDeployStack registers the deploy_stack MCP tool.
+func RegisterDeployStack(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "deploy_stack",
+		Category: CategoryDeploy,
+		Summary:  "Deploy several apps and managed services together from one manifest",
+	}, &gomcp.Tool{
+		Description: "Deploy several applications and managed services together from one manifest. Requires session_id from the register tool. Services are provisioned first; the tool waits up to wait_seconds for them to become Ready, then creates the apps with their service bindings already in place. Returns per-component status. If services are still provisioning when the wait ends, call deploy_stack again with the same manifest — components that already exist are left unchanged. Poll stack_status to follow builds and rollouts. Use deploy_app and bind_service for options not covered here.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input DeployStackInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.Name); err != nil {
+			return nil, nil, fmt.Errorf("invalid stack name: %w", err)
+		}
+		if err := validateStackManifest(input); err != nil {
+			return nil, nil, err
+		}
+		for _, s := range input.Services {
+			if _, err := iafk8s.LookupServicePlan(ctx, deps.Client, s.Type, iafv1alpha1.ServicePlanName(s.Plan)); err != nil {
+				return nil, nil, fmt.Errorf("service %q: %w", s.Name, err)
+			}
+		}
+		for _, app := range input.Apps {
+			if err := deps.CheckAppNameAvailable(ctx, app.Name, namespace); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		// Find components left by an earlier run, refusing to adopt objects
+		// that belong to something else.
+		existingServices := map[string]bool{}
+		for _, s := range input.Services {
+			var svc iafv1alpha1.ManagedService
+			found, err := getStackMember(ctx, deps.Client, namespace, s.Name, input.Name, "service", &svc)
+			if err != nil {
+				return nil, nil, err
+			}
+			if found && (svc.Spec.Type != s.Type || string(svc.Spec.Plan) != s.Plan) {
+				return nil, nil, fmt.Errorf("service %q already exists with type %s and plan %s; deploy_stack does not change existing services", s.Name, svc.Spec.Type, svc.Spec.Plan)
+			}
+			existingServices[s.Name] = found
+		}
+		existingApps := map[string]bool{}
+		for _, a := range input.Apps {
+			var app iafv1alpha1.Application
+			found, err := getStackMember(ctx, deps.Client, namespace, a.Name, input.Name, "application", &app)
+			if err != nil {
+				return nil, nil, err
+			}
+			existingApps[a.Name] = found
+			if !found {
+				app := newStackApp(namespace, input.Name, a)
+				if res, err := deps.CheckPolicy(ctx, policy.Input{Operation: iafv1alpha1.PolicyOperationDeploy, Namespace: namespace, App: app}); res != nil || err != nil {
+					return res, nil, err
+				}
+			}
+		}
+
+		for _, s := range input.Services {
+			if existingServices[s.Name] {
+				continue
+			}
+			svc := &iafv1alpha1.ManagedService{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      s.Name,
+					Namespace: namespace,
+					Labels:    map[string]string{iafk8s.LabelStack: input.Name},
+				},
+				Spec: iafv1alpha1.ManagedServiceSpec{
+					Type: s.Type,
+					Plan: iafv1alpha1.ServicePlanName(s.Plan),
+				},
+			}
+			if err := deps.Client.Create(ctx, svc); err != nil {
+				return nil, nil, fmt.Errorf("provisioning service %q: %w", s.Name, err)
+			}
+		}
+
+		wait := time.Duration(input.WaitSeconds) * time.Second
+		if input.WaitSeconds <= 0 {
+			wait = defaultStackWaitSeconds * time.Second
+		}
+		wait = min(wait, maxStackWaitSeconds*time.Second)
+		services, err := waitForStackServices(ctx, deps.Client, namespace, input.Services, wait)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		components := make([]stackComponent, 0, len(input.Services)+len(input.Apps))
+		allReady, anyFailed := true, false
+		for _, s := range input.Services {
+			svc := services[s.Name]
+			action := "created"
+			if existingServices[s.Name] {
+				action = "existing"
+			}
+			components = append(components, stackComponent{
+				Kind: "service", Name: s.Name, Action: action,
+				Phase: string(svc.Status.Phase), Message: svc.Status.Message,
+			})
+			switch svc.Status.Phase {
+			case iafv1alpha1.ManagedServicePhaseReady:
+			case iafv1alpha1.ManagedServicePhaseFailed:
+				allReady, anyFailed = false, true
+			default:
+				allReady = false
+			}
+		}
+
+		status := "deployed"
+		message := fmt.Sprintf("Stack %q is deploying. Poll stack_status every 30s until every component is Running or Ready.", input.Name)
+		for _, a := range input.Apps {
+			c := stackComponent{Kind: "app", Name: a.Name, BoundServices: a.Services}
+			switch {
+			case existingApps[a.Name]:
+				c.Action = "existing"
+			case !allReady:
+				c.Action = "pending"
+			default:
+				if err := createStackApp(ctx, deps.Client, namespace, input.Name, a, services); err != nil {
+					return nil, nil, err
+				}
+				c.Action = "created"
+				c.URL = fmt.Sprintf("https://%s.%s", a.Name, deps.BaseDomain)
+			}
+			components = append(components, c)
+		}
+		switch {
+		case anyFailed:
+			status = "failed"
+			message = "A service failed to provision, so apps were not created. Check service_status for details, deprovision_service the failed service, and run deploy_stack again."
+		case !allReady:
+			status = "waiting_for_services"
+			message = "Services are still provisioning, so apps were not created yet. Wait about a minute and call deploy_stack again with the same manifest."
+		}
+
+		result := map[string]any{
+			"stack":      input.Name,
+			"status":     status,
+			"components": components,
+			"message":    message,
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// validateStackManifest checks every component before anything is created so
+// that a bad manifest leaves no partial stack behind.
+func validateStackManifest(input DeployStackInput) error {
+	if len(input.Services)+len(input.Apps) == 0 {
+		return fmt.Errorf("the manifest must contain at least one app or service")
+	}
+	if len(input.Services)+len(input.Apps) > maxStackComponents {
+		return fmt.Errorf("a stack may contain at most %d components", maxStackComponents)
+	}
+
+	names := map[string]bool{}
+	declared := map[string]bool{}
+	for _, s := range input.Services {
+		if err := validation.ValidateAppName(s.Name); err != nil {
+			return fmt.Errorf("invalid service name: %w", err)
+		}
+		if names[s.Name] {
+			return fmt.Errorf("component name %q is used more than once", s.Name)
+		}
+		names[s.Name], declared[s.Name] = true, true
+		if !validServiceTypes[s.Type] {
+			return fmt.Errorf("service %q: unsupported service type %q — supported types: postgres", s.Name, s.Type)
+		}
+	}
+	for _, a := range input.Apps {
+		if err := validation.ValidateAppName(a.Name); err != nil {
+			return err
+		}
+		if names[a.Name] {
+			return fmt.Errorf("component name %q is used more than once", a.Name)
+		}
+		names[a.Name] = true
+		if (a.Image == "") == (a.GitURL == "") {
+			return fmt.Errorf("app %q: provide exactly one of image or git_url", a.Name)
+		}
+		for _, e := range a.Env {
+			if err := validation.ValidateEnvVarName(e.Name); err != nil {
+				return fmt.Errorf("app %q: %w", a.Name, err)
+			}
+		}
+		bound := map[string]bool{}
+		for _, s := range a.Services {
+			if !declared[s] {
+				return fmt.Errorf("app %q binds service %q, which is not declared in the manifest's services", a.Name, s)
+			}
+			if bound[s] {
+				return fmt.Errorf("app %q binds service %q more than once", a.Name, s)
+			}
+			bound[s] = true
+		}
+	}
+	return nil
+}
+
+// getStackMember fetches name into obj. It reports whether the object exists
+// and returns an error if it exists but is not labelled as part of stack.
+func getStackMember(ctx context.Context, c client.Client, namespace, name, stack, kind string, obj client.Object) (bool, error) {
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("getting %s %q: %w", kind, name, err)
+	}
+	if obj.GetLabels()[iafk8s.LabelStack] != stack {
+		return false, fmt.Errorf("%s %q already exists and is not part of stack %q; rename it in the manifest or delete the existing one", kind, name, stack)
+	}
+	return true, nil
+}
+
+// waitForStackServices polls the named services until all are Ready, any has
+// Failed, or timeout elapses, and returns their latest state.
+func waitForStackServices(ctx context.Context, c client.Client, namespace string, specs []StackService, timeout time.Duration) (map[string]*iafv1alpha1.ManagedService, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		services := make(map[string]*iafv1alpha1.ManagedService, len(specs))
+		done := true
+		for _, s := range specs {
+			var svc iafv1alpha1.ManagedService
+			if err := c.Get(ctx, types.NamespacedName{Name: s.Name, Namespace: namespace}, &svc); err != nil {
+				return nil, fmt.Errorf("getting service %q: %w", s.Name, err)
+			}
+			services[s.Name] = &svc
+			switch svc.Status.Phase {
+			case iafv1alpha1.ManagedServicePhaseReady:
+			case iafv1alpha1.ManagedServicePhaseFailed:
+				return services, nil
+			default:
+				done = false
+			}
+		}
+		if done || !time.Now().Before(deadline) {
+			return services, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(min(stackPollInterval, time.Until(deadline))):
+		}
+	}
+}
+
+// newStackApp builds a stack application from its definition, without
+// service bindings.
+func newStackApp(namespace, stack string, a StackApp) *iafv1alpha1.Application {
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      a.Name,
+			Namespace: namespace,
+			Labels:    map[string]string{iafk8s.LabelStack: stack},
+		},
+		Spec: iafv1alpha1.ApplicationSpec{
+			Image:    a.Image,
+			Port:     a.Port,
+			Replicas: a.Replicas,
+			Env:      a.Env,
+		},
+	}
+	if a.GitURL != "" {
+		revision := a.GitRevision
+		if revision == "" {
+			revision = "main"
+		}
+		app.Spec.Git = &iafv1alpha1.GitSource{URL: a.GitURL, Revision: revision}
+	}
+	if app.Spec.Port == 0 {
+		app.Spec.Port = 8080
+	}
+	if app.Spec.Replicas == 0 {
+		app.Spec.Replicas = 1
+	}
+	return app
+}
+
+// createStackApp creates one stack application with its service bindings
+// recorded up front, so it starts with connection env vars instead of being
+// restarted by a later bind.
+func createStackApp(ctx context.Context, c client.Client, namespace, stack string, a StackApp, services map[string]*iafv1alpha1.ManagedService) error {
+	app := newStackApp(namespace, stack, a)
+	for _, name := range a.Services {
+		// Same CNPG secret convention check as bind_service.
+		expectedSecret := name + "-app"
+		if ref := services[name].Status.ConnectionSecretRef; ref != expectedSecret {
+			return fmt.Errorf("service %q has unexpected connection secret %q (expected %q) — this is a platform error", name, ref, expectedSecret)
+		}
+		app.Spec.BoundManagedServices = append(app.Spec.BoundManagedServices, iafv1alpha1.BoundManagedService{
+			ServiceName: name,
+			SecretName:  expectedSecret,
+		})
+	}
+
+	if err := c.Create(ctx, app); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("application %q already exists", a.Name)
+		}
+		return fmt.Errorf("creating application %q: %w", a.Name, err)
+	}
+	for _, name := range a.Services {
+		if err := addBoundApp(ctx, c, namespace, name, a.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
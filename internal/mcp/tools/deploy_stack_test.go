@@ -0,0 +1,276 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func setupStackServer(t *testing.T) (*gomcp.ClientSession, client.Client) {
+	t.Helper()
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithStatusSubresource(&iafv1alpha1.Application{}, &iafv1alpha1.ManagedService{}).
+		Build()
+
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		Store:      store,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterDeployStack(server, deps)
+	tools.RegisterStackStatus(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mc := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mc.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs, k8sClient
+}
+
+func callStackTool(t *testing.T, cs *gomcp.ClientSession, name string, args map[string]any) (map[string]any, string, bool) {
+	t.Helper()
+	res, err := cs.CallTool(context.Background(), &gomcp.CallToolParams{Name: name, Arguments: args})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := res.Content[0].(*gomcp.TextContent).Text
+	var out map[string]any
+	if !res.IsError {
+		if err := json.Unmarshal([]byte(text), &out); err != nil {
+			t.Fatalf("parsing %s result: %v", name, err)
+		}
+	}
+	return out, text, res.IsError
+}
+
+func shopManifest(sid string) map[string]any {
+	return map[string]any{
+		"session_id": sid,
+		"name":       "shop",
+		"services":   []any{map[string]any{"name": "shop-db", "type": "postgres", "plan": "micro"}},
+		"apps": []any{
+			map[string]any{"name": "shop-api", "image": "org/api:1.0", "services": []any{"shop-db"}},
+			map[string]any{"name": "shop-web", "image": "org/web:1.0", "port": 3000},
+		},
+		"wait_seconds": 1,
+	}
+}
+
+func TestDeployStack_ServicesFirstThenApps(t *testing.T) {
+	cs, k8sClient := setupStackServer(t)
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	// First run: the service is created but never becomes Ready within the wait.
+	out, text, isErr := callStackTool(t, cs, "deploy_stack", shopManifest(sid))
+	if isErr {
+		t.Fatalf("deploy_stack: %s", text)
+	}
+	if out["status"] != "waiting_for_services" {
+		t.Errorf("status = %v, want waiting_for_services", out["status"])
+	}
+	var svc iafv1alpha1.ManagedService
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "shop-db", Namespace: ns}, &svc); err != nil {
+		t.Fatalf("service not created: %v", err)
+	}
+	if svc.Labels[iafk8s.LabelStack] != "shop" {
+		t.Errorf("service labels = %v, want stack label", svc.Labels)
+	}
+	var apps iafv1alpha1.ApplicationList
+	if err := k8sClient.List(ctx, &apps, client.InNamespace(ns)); err != nil {
+		t.Fatal(err)
+	}
+	if len(apps.Items) != 0 {
+		t.Fatalf("apps created before services were Ready: %d", len(apps.Items))
+	}
+
+	// The controller marks the service Ready; re-running the same manifest resumes.
+	svc.Status.Phase = iafv1alpha1.ManagedServicePhaseReady
+	svc.Status.ConnectionSecretRef = "shop-db-app"
+	if err := k8sClient.Status().Update(ctx, &svc); err != nil {
+		t.Fatal(err)
+	}
+	out, text, isErr = callStackTool(t, cs, "deploy_stack", shopManifest(sid))
+	if isErr {
+		t.Fatalf("deploy_stack rerun: %s", text)
+	}
+	if out["status"] != "deployed" {
+		t.Errorf("status = %v, want deployed", out["status"])
+	}
+	actions := map[string]any{}
+	for _, c := range out["components"].([]any) {
+		m := c.(map[string]any)
+		actions[m["name"].(string)] = m["action"]
+	}
+	if actions["shop-db"] != "existing" || actions["shop-api"] != "created" || actions["shop-web"] != "created" {
+		t.Errorf("actions = %v", actions)
+	}
+
+	var api iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "shop-api", Namespace: ns}, &api); err != nil {
+		t.Fatal(err)
+	}
+	if len(api.Spec.BoundManagedServices) != 1 || api.Spec.BoundManagedServices[0].SecretName != "shop-db-app" {
+		t.Errorf("bindings = %+v, want shop-db", api.Spec.BoundManagedServices)
+	}
+	if api.Labels[iafk8s.LabelStack] != "shop" || api.Spec.Port != 8080 || api.Spec.Replicas != 1 {
+		t.Errorf("app = %+v, want stack label and defaults", api)
+	}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "shop-db", Namespace: ns}, &svc); err != nil {
+		t.Fatal(err)
+	}
+	if len(svc.Status.BoundApps) != 1 || svc.Status.BoundApps[0] != "shop-api" {
+		t.Errorf("boundApps = %v, want [shop-api]", svc.Status.BoundApps)
+	}
+
+	// stack_status reports Progressing until every app is Running.
+	out, text, isErr = callStackTool(t, cs, "stack_status", map[string]any{"session_id": sid, "name": "shop"})
+	if isErr {
+		t.Fatalf("stack_status: %s", text)
+	}
+	if out["status"] != "Progressing" || len(out["components"].([]any)) != 3 {
+		t.Errorf("stack_status = %v, want Progressing with 3 components", out)
+	}
+	for _, name := range []string{"shop-api", "shop-web"} {
+		var app iafv1alpha1.Application
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: ns}, &app); err != nil {
+			t.Fatal(err)
+		}
+		app.Status.Phase = iafv1alpha1.ApplicationPhaseRunning
+		if err := k8sClient.Status().Update(ctx, &app); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out, _, _ = callStackTool(t, cs, "stack_status", map[string]any{"session_id": sid, "name": "shop"})
+	if out["status"] != "Ready" {
+		t.Errorf("status = %v, want Ready", out["status"])
+	}
+}
+
+func TestDeployStack_FailedService(t *testing.T) {
+	cs, k8sClient := setupStackServer(t)
+	sid, ns := registerDSSession(t, cs)
+
+	svc := &iafv1alpha1.ManagedService{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop-db", Namespace: ns, Labels: map[string]string{iafk8s.LabelStack: "shop"}},
+		Spec:       iafv1alpha1.ManagedServiceSpec{Type: "postgres", Plan: iafv1alpha1.ServicePlanMicro},
+		Status:     iafv1alpha1.ManagedServiceStatus{Phase: iafv1alpha1.ManagedServicePhaseFailed},
+	}
+	if err := k8sClient.Create(context.Background(), svc); err != nil {
+		t.Fatal(err)
+	}
+
+	out, text, isErr := callStackTool(t, cs, "deploy_stack", shopManifest(sid))
+	if isErr {
+		t.Fatalf("deploy_stack: %s", text)
+	}
+	if out["status"] != "failed" {
+		t.Errorf("status = %v, want failed", out["status"])
+	}
+	out, _, _ = callStackTool(t, cs, "stack_status", map[string]any{"session_id": sid, "name": "shop"})
+	if out["status"] != "Failed" {
+		t.Errorf("stack_status = %v, want Failed", out["status"])
+	}
+}
+
+func TestDeployStack_Errors(t *testing.T) {
+	cs, k8sClient := setupStackServer(t)
+	sid, ns := registerDSSession(t, cs)
+
+	// An app created outside the stack must not be adopted.
+	outsider := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: ns},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx"},
+	}
+	if err := k8sClient.Create(context.Background(), outsider); err != nil {
+		t.Fatal(err)
+	}
+
+	app := func(fields map[string]any) []any {
+		m := map[string]any{"name": "web", "image": "nginx"}
+		for k, v := range fields {
+			m[k] = v
+		}
+		return []any{m}
+	}
+	db := []any{map[string]any{"name": "db", "type": "postgres", "plan": "micro"}}
+
+	tests := []struct {
+		name    string
+		args    map[string]any
+		wantErr string
+	}{
+		{name: "empty manifest", args: map[string]any{}, wantErr: "at least one app or service"},
+		{name: "undeclared binding", args: map[string]any{"apps": app(map[string]any{"services": []any{"db"}})}, wantErr: "not declared"},
+		{name: "duplicate names", args: map[string]any{"services": []any{map[string]any{"name": "web", "type": "postgres", "plan": "micro"}}, "apps": app(nil)}, wantErr: "more than once"},
+		{name: "image and git", args: map[string]any{"apps": app(map[string]any{"git_url": "https://github.com/o/r"})}, wantErr: "exactly one of image or git_url"},
+		{name: "no source", args: map[string]any{"apps": app(map[string]any{"image": ""})}, wantErr: "exactly one of image or git_url"},
+		{name: "bad plan", args: map[string]any{"services": []any{map[string]any{"name": "db", "type": "postgres", "plan": "huge"}}}, wantErr: "unsupported plan"},
+		{name: "bad env", args: map[string]any{"services": db, "apps": app(map[string]any{"env": []any{map[string]any{"name": "1BAD", "value": "x"}}})}, wantErr: "app \"web\""},
+		{name: "foreign component", args: map[string]any{"apps": app(map[string]any{"name": "legacy"})}, wantErr: "not part of stack"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := map[string]any{"session_id": sid, "name": "shop", "wait_seconds": 1}
+			for k, v := range tt.args {
+				args[k] = v
+			}
+			_, text, isErr := callStackTool(t, cs, "deploy_stack", args)
+			if !isErr || !strings.Contains(text, tt.wantErr) {
+				t.Errorf("got %q (isError=%v), want error containing %q", text, isErr, tt.wantErr)
+			}
+		})
+	}
+
+	var services iafv1alpha1.ManagedServiceList
+	if err := k8sClient.List(context.Background(), &services, client.InNamespace(ns)); err != nil {
+		t.Fatal(err)
+	}
+	if len(services.Items) != 0 {
+		t.Errorf("invalid manifests left %d services behind", len(services.Items))
+	}
+
+	if _, text, isErr := callStackTool(t, cs, "stack_status", map[string]any{"session_id": sid, "name": "missing"}); !isErr || !strings.Contains(text, "not found") {
+		t.Errorf("stack_status for unknown stack = %q, want not found", text)
+	}
+}
@@ -0,0 +1,1039 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/idempotency"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+	"github.com/dlapiduz/iaf/internal/policy"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// setupPolicyServer registers deploy_app, push_code, push_code_chunk and
+// set_auto_deploy with a policy engine over the given policies.
+func setupPolicyServer(t *testing.T, policies ...client.Object) (*gomcp.ClientSession, client.Client) {
+	t.Helper()
+	return setupDeployServer(t, nil, policies...)
+}
+
+// setupDeployServer is setupPolicyServer with configure, when set, applied
+// to the dependencies before the tools are registered.
+func setupDeployServer(t *testing.T, configure func(*tools.Dependencies), policies ...client.Object) (*gomcp.ClientSession, client.Client) {
+	t.Helper()
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(policies...).Build()
+
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := &tools.Dependencies{
+		Client:          k8sClient,
+		Store:           store,
+		BaseDomain:      "test.example.com",
+		Sessions:        sessions,
+		Policy:          policy.New(k8sClient),
+		Builders:        []string{"iaf-cluster-builder", "java-native"},
+		Architectures:   []string{"amd64", "arm64"},
+		WorkloadClasses: []string{"gpu", "standard"},
+		Idempotency:     idempotency.NewStore[*gomcp.CallToolResult](idempotency.DefaultTTL),
+	}
+	if configure != nil {
+		configure(deps)
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterDeployApp(server, deps)
+	tools.RegisterPushCode(server, deps)
+	tools.RegisterPushCodeChunk(server, deps)
+	tools.RegisterSetAutoDeploy(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mc := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mc.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs, k8sClient
+}
+
+func TestDeployApp_PolicyViolation(t *testing.T) {
+	cs, k8sClient := setupPolicyServer(t, &iafv1alpha1.PlatformPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "images"},
+		Spec: iafv1alpha1.PlatformPolicySpec{
+			Operations: []iafv1alpha1.PolicyOperation{iafv1alpha1.PolicyOperationDeploy},
+			Rules: []iafv1alpha1.PolicyRule{{
+				Name:       "approved-registry",
+				Expression: `!has(app.spec.image) || app.spec.image.startsWith("registry.corp/")`,
+				Message:    "Use an image from registry.corp",
+			}},
+		},
+	})
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "deploy_app",
+		Arguments: map[string]any{"session_id": sid, "name": "web", "image": "nginx:latest"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsError {
+		t.Fatal("expected a policy violation")
+	}
+	var out struct {
+		Code       string             `json:"code"`
+		Category   string             `json:"category"`
+		Violations []policy.Violation `json:"violations"`
+	}
+	if err := json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &out); err != nil {
+		t.Fatalf("expected a structured error, got %v", err)
+	}
+	if out.Code != "policy_violation" || out.Category != "validation" || len(out.Violations) != 1 || out.Violations[0].Rule != "approved-registry" {
+		t.Errorf("unexpected violation report: %+v", out)
+	}
+	var app iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web", Namespace: ns}, &app); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the blocked app not to be created, got %v", err)
+	}
+
+	res, err = cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "deploy_app",
+		Arguments: map[string]any{"session_id": sid, "name": "web", "image": "registry.corp/web:1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("expected a compliant deploy to succeed: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+}
+
+func TestPushCode_PolicyViolation(t *testing.T) {
+	cs, k8sClient := setupPolicyServer(t, &iafv1alpha1.PlatformPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "secrets"},
+		Spec: iafv1alpha1.PlatformPolicySpec{
+			Operations: []iafv1alpha1.PolicyOperation{iafv1alpha1.PolicyOperationPushCode},
+			Rules: []iafv1alpha1.PolicyRule{{
+				Name:       "no-env-files",
+				Expression: `!files.exists(f, f.endsWith(".env"))`,
+				Message:    "Do not upload .env files; pass configuration as env vars",
+			}},
+		},
+	})
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name: "push_code",
+		Arguments: map[string]any{"session_id": sid, "name": "web", "files": map[string]any{
+			"main.go": "package main",
+			".env":    "SECRET=1",
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsError {
+		t.Fatal("expected a policy violation")
+	}
+	var app iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web", Namespace: ns}, &app); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the blocked app not to be created, got %v", err)
+	}
+
+	res, err = cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "push_code",
+		Arguments: map[string]any{"session_id": sid, "name": "web", "files": map[string]any{"main.go": "package main"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("expected a compliant push to succeed: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web", Namespace: ns}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if app.Spec.Blob == "" || app.Spec.Port != 8080 || app.Spec.Replicas != 1 {
+		t.Errorf("unexpected application spec after push: %+v", app.Spec)
+	}
+	if sum := app.Annotations[sourcestore.AnnotationSourceSHA256]; sum == "" || !strings.Contains(app.Spec.Blob, "sha256="+sum) {
+		t.Errorf("expected the blob URL %q to carry the recorded checksum %q", app.Spec.Blob, sum)
+	}
+}
+
+// TestPushCode_RuntimeVersion verifies the runtime version the source
+// declares must be one the org allows, and is pinned in the build env.
+func TestPushCode_RuntimeVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "standards.yaml")
+	if err := os.WriteFile(path, []byte("perLanguage:\n  nodejs:\n    runtimeVersions: [\"20\", \"22\"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cs, k8sClient := setupDeployServer(t, func(deps *tools.Dependencies) {
+		deps.OrgStandards = orgstandards.New(path, slog.Default())
+	})
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+	push := func(engines string, buildEnv ...map[string]any) *gomcp.CallToolResult {
+		t.Helper()
+		args := map[string]any{"session_id": sid, "name": "web", "files": map[string]any{
+			"package.json": `{"engines": {"node": "` + engines + `"}}`,
+			"index.js":     "require('http')",
+		}}
+		if buildEnv != nil {
+			args["build_env"] = buildEnv
+		}
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "push_code", Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	res := push("18.x")
+	if !res.IsError {
+		t.Fatal("expected a disallowed runtime version to be rejected")
+	}
+	if text := res.Content[0].(*gomcp.TextContent).Text; !strings.Contains(text, "package.json") || !strings.Contains(text, "20, 22") {
+		t.Errorf("expected the violation to name the file and the allowed versions, got %s", text)
+	}
+
+	if res := push("^22.1"); res.IsError {
+		t.Fatalf("expected an allowed version to be pushed: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	var app iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web", Namespace: ns}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if len(app.Spec.BuildEnv) != 1 || app.Spec.BuildEnv[0] != (iafv1alpha1.EnvVar{Name: "BP_NODE_VERSION", Value: "22"}) {
+		t.Errorf("expected the declared version to be pinned, got %v", app.Spec.BuildEnv)
+	}
+
+	// A version chosen in build_env wins but must be allowed too.
+	if res := push("22", map[string]any{"name": "BP_NODE_VERSION", "value": "16"}); !res.IsError {
+		t.Error("expected a disallowed build_env version to be rejected")
+	}
+	if res := push("22", map[string]any{"name": "BP_NODE_VERSION", "value": "20.11.1"}); res.IsError {
+		t.Fatalf("expected an allowed build_env version to be pushed: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	k8sClient.Get(ctx, types.NamespacedName{Name: "web", Namespace: ns}, &app)
+	if len(app.Spec.BuildEnv) != 1 || app.Spec.BuildEnv[0].Value != "20.11.1" {
+		t.Errorf("expected build_env to be kept, got %v", app.Spec.BuildEnv)
+	}
+}
+
+func TestPushCode_DependencyPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "standards.yaml")
+	content := `
+dependencies:
+  deny:
+    - {ecosystem: npm, package: left-pad, reason: unmaintained}
+  allow:
+    - {ecosystem: pypi, package: flask}
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cs, _ := setupDeployServer(t, func(deps *tools.Dependencies) {
+		deps.OrgStandards = orgstandards.New(path, slog.Default())
+	})
+	sid, _ := registerDSSession(t, cs)
+	push := func(files map[string]any) *gomcp.CallToolResult {
+		t.Helper()
+		res, err := cs.CallTool(context.Background(), &gomcp.CallToolParams{Name: "push_code", Arguments: map[string]any{
+			"session_id": sid, "name": "web", "files": files,
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	res := push(map[string]any{
+		"package.json": `{"dependencies": {"express": "^4.19.0", "left-pad": "1.3.0"}}`,
+		"index.js":     "require('http')",
+	})
+	if !res.IsError {
+		t.Fatal("expected a denied dependency to be rejected")
+	}
+	if text := res.Content[0].(*gomcp.TextContent).Text; !strings.Contains(text, `"left-pad" in package.json (denied: unmaintained)`) {
+		t.Errorf("expected the violation to name the package, file and reason, got %s", text)
+	}
+
+	if res := push(map[string]any{"requirements.txt": "flask==3.0.0\ndjango==5.0\n", "app.py": ""}); !res.IsError {
+		t.Error("expected a package missing from the allow list to be rejected")
+	}
+	if res := push(map[string]any{"requirements.txt": "Flask==3.0.0\n", "app.py": ""}); res.IsError {
+		t.Fatalf("expected allowed dependencies to be pushed: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+}
+
+func TestDeployApp_UptimeCheck(t *testing.T) {
+	cs, k8sClient := setupPolicyServer(t)
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name: "deploy_app",
+		Arguments: map[string]any{
+			"session_id":                    sid,
+			"name":                          "web",
+			"image":                         "nginx:latest",
+			"uptime_check_path":             "/healthz",
+			"uptime_check_interval_seconds": 120,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	var app iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web", Namespace: ns}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if uc := app.Spec.UptimeCheck; uc == nil || uc.Path != "/healthz" || uc.IntervalSeconds != 120 {
+		t.Errorf("unexpected uptime check %+v", app.Spec.UptimeCheck)
+	}
+
+	for _, args := range []map[string]any{
+		{"uptime_check_path": "healthz"},
+		{"uptime_check_interval_seconds": 60},
+		{"uptime_check_path": "/", "protocol": "tcp"},
+	} {
+		args["session_id"], args["name"], args["image"] = sid, "other", "nginx:latest"
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "deploy_app", Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.IsError {
+			t.Errorf("expected %v to be rejected", args)
+		}
+	}
+}
+
+func TestDeployApp_VerifyConformance(t *testing.T) {
+	cs, k8sClient := setupPolicyServer(t)
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	deploy := func(name, protocol string) *gomcp.CallToolResult {
+		t.Helper()
+		args := map[string]any{"session_id": sid, "name": name, "image": "nginx:latest", "verify_conformance": true}
+		if protocol != "" {
+			args["protocol"] = protocol
+		}
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "deploy_app", Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	if res := deploy("web", ""); res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	var app iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web", Namespace: ns}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if !app.Spec.VerifyConformance {
+		t.Error("expected verifyConformance to be set")
+	}
+	if res := deploy("db", "tcp"); !res.IsError {
+		t.Error("expected verify_conformance to be rejected for tcp")
+	}
+}
+
+func TestDeployApp_BackendTLS(t *testing.T) {
+	cs, k8sClient := setupPolicyServer(t)
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name: "deploy_app",
+		Arguments: map[string]any{
+			"session_id":  sid,
+			"name":        "web",
+			"image":       "nginx:latest",
+			"backend_tls": true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	var app iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web", Namespace: ns}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if !iafv1alpha1.IsBackendTLSEnabled(&app) {
+		t.Errorf("expected backend TLS, got %+v", app.Spec.BackendTLS)
+	}
+
+	for _, args := range []map[string]any{
+		{"backend_tls": true, "protocol": "tcp"},
+		{"backend_tls": true, "authentication": "oauth-proxy"},
+	} {
+		args["session_id"], args["name"], args["image"] = sid, "other", "nginx:latest"
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "deploy_app", Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.IsError {
+			t.Errorf("expected %v to be rejected", args)
+		}
+	}
+}
+
+func TestDeployApp_BuildCache(t *testing.T) {
+	cs, k8sClient := setupPolicyServer(t)
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name: "deploy_app",
+		Arguments: map[string]any{
+			"session_id":       sid,
+			"name":             "web",
+			"git_url":          "https://github.com/example/web",
+			"build_cache_size": "5Gi",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	var app iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web", Namespace: ns}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if bc := app.Spec.BuildCache; bc == nil || bc.Type != "" || bc.VolumeSize == nil || bc.VolumeSize.String() != "5Gi" {
+		t.Errorf("unexpected build cache %+v", app.Spec.BuildCache)
+	}
+
+	for _, args := range []map[string]any{
+		{"git_url": "https://github.com/example/web", "build_cache": "disk"},
+		{"git_url": "https://github.com/example/web", "build_cache": "registry", "build_cache_size": "5Gi"},
+		{"git_url": "https://github.com/example/web", "build_cache_size": "500Gi"},
+		{"image": "nginx:latest", "build_cache": "none"},
+	} {
+		args["session_id"], args["name"] = sid, "other"
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "deploy_app", Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.IsError {
+			t.Errorf("expected %v to be rejected", args)
+		}
+	}
+}
+
+func TestDeployApp_BuildEnv(t *testing.T) {
+	cs, k8sClient := setupPolicyServer(t)
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	buildEnv := []any{map[string]any{"name": "BP_GO_TARGETS", "value": "./cmd/server"}}
+	for _, call := range []*gomcp.CallToolParams{
+		{Name: "deploy_app", Arguments: map[string]any{"session_id": sid, "name": "web", "git_url": "https://github.com/example/web", "build_env": buildEnv}},
+		{Name: "push_code", Arguments: map[string]any{"session_id": sid, "name": "api", "files": map[string]any{"main.go": "package main"}, "build_env": buildEnv}},
+	} {
+		res, err := cs.CallTool(ctx, call)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.IsError {
+			t.Fatalf("%s: unexpected error: %s", call.Name, res.Content[0].(*gomcp.TextContent).Text)
+		}
+		var app iafv1alpha1.Application
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: call.Arguments.(map[string]any)["name"].(string), Namespace: ns}, &app); err != nil {
+			t.Fatal(err)
+		}
+		if len(app.Spec.BuildEnv) != 1 || app.Spec.BuildEnv[0].Name != "BP_GO_TARGETS" || len(app.Spec.Env) != 0 {
+			t.Errorf("%s: unexpected env %v, build env %v", call.Name, app.Spec.Env, app.Spec.BuildEnv)
+		}
+	}
+
+	for _, call := range []*gomcp.CallToolParams{
+		{Name: "deploy_app", Arguments: map[string]any{"session_id": sid, "name": "other", "git_url": "https://github.com/example/web", "build_env": []any{map[string]any{"name": "CNB_PLATFORM_API", "value": "0.9"}}}},
+		{Name: "deploy_app", Arguments: map[string]any{"session_id": sid, "name": "other", "image": "nginx:latest", "build_env": buildEnv}},
+		{Name: "push_code", Arguments: map[string]any{"session_id": sid, "name": "other", "files": map[string]any{"main.go": "package main"}, "build_env": []any{map[string]any{"name": "1BAD"}}}},
+	} {
+		res, err := cs.CallTool(ctx, call)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.IsError {
+			t.Errorf("expected %s %v to be rejected", call.Name, call.Arguments)
+		}
+	}
+}
+
+func TestDeployApp_Builder(t *testing.T) {
+	cs, k8sClient := setupPolicyServer(t)
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "deploy_app",
+		Arguments: map[string]any{"session_id": sid, "name": "web", "git_url": "https://github.com/example/web", "builder": "java-native"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	var app iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web", Namespace: ns}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if app.Spec.Builder != "java-native" {
+		t.Errorf("expected builder java-native, got %q", app.Spec.Builder)
+	}
+
+	for _, call := range []*gomcp.CallToolParams{
+		{Name: "deploy_app", Arguments: map[string]any{"session_id": sid, "name": "other", "git_url": "https://github.com/example/web", "builder": "full"}},
+		{Name: "deploy_app", Arguments: map[string]any{"session_id": sid, "name": "other", "image": "nginx:latest", "builder": "java-native"}},
+		{Name: "push_code", Arguments: map[string]any{"session_id": sid, "name": "other", "files": map[string]any{"main.go": "package main"}, "builder": "full"}},
+	} {
+		res, err := cs.CallTool(ctx, call)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.IsError {
+			t.Errorf("expected %s %v to be rejected", call.Name, call.Arguments)
+		}
+	}
+}
+
+func TestDeployApp_Architecture(t *testing.T) {
+	cs, k8sClient := setupPolicyServer(t)
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	for _, call := range []*gomcp.CallToolParams{
+		{Name: "deploy_app", Arguments: map[string]any{"session_id": sid, "name": "web", "image": "nginx:latest", "architecture": "arm64"}},
+		{Name: "push_code", Arguments: map[string]any{"session_id": sid, "name": "api", "files": map[string]any{"main.go": "package main"}, "architecture": "arm64"}},
+	} {
+		res, err := cs.CallTool(ctx, call)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.IsError {
+			t.Fatalf("%s: unexpected error: %s", call.Name, res.Content[0].(*gomcp.TextContent).Text)
+		}
+		var app iafv1alpha1.Application
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: call.Arguments.(map[string]any)["name"].(string), Namespace: ns}, &app); err != nil {
+			t.Fatal(err)
+		}
+		if app.Spec.Architecture != iafv1alpha1.ArchitectureARM64 {
+			t.Errorf("%s: expected architecture arm64, got %q", call.Name, app.Spec.Architecture)
+		}
+	}
+
+	for _, call := range []*gomcp.CallToolParams{
+		{Name: "deploy_app", Arguments: map[string]any{"session_id": sid, "name": "other", "image": "nginx:latest", "architecture": "multi"}},
+		{Name: "deploy_app", Arguments: map[string]any{"session_id": sid, "name": "other", "git_url": "https://github.com/example/web", "architecture": "arm64", "builder": "java-native"}},
+		{Name: "push_code", Arguments: map[string]any{"session_id": sid, "name": "other", "files": map[string]any{"main.go": "package main"}, "architecture": "x86"}},
+	} {
+		res, err := cs.CallTool(ctx, call)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.IsError {
+			t.Errorf("expected %s %v to be rejected", call.Name, call.Arguments)
+		}
+	}
+}
+
+func TestDeployApp_WorkloadClass(t *testing.T) {
+	cs, k8sClient := setupPolicyServer(t)
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	for _, call := range []*gomcp.CallToolParams{
+		{Name: "deploy_app", Arguments: map[string]any{"session_id": sid, "name": "web", "image": "nginx:latest", "workload_class": "gpu"}},
+		{Name: "push_code", Arguments: map[string]any{"session_id": sid, "name": "api", "files": map[string]any{"main.go": "package main"}, "workload_class": "gpu"}},
+	} {
+		res, err := cs.CallTool(ctx, call)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.IsError {
+			t.Fatalf("%s: unexpected error: %s", call.Name, res.Content[0].(*gomcp.TextContent).Text)
+		}
+		var app iafv1alpha1.Application
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: call.Arguments.(map[string]any)["name"].(string), Namespace: ns}, &app); err != nil {
+			t.Fatal(err)
+		}
+		if app.Spec.WorkloadClass != iafv1alpha1.WorkloadClassGPU {
+			t.Errorf("%s: expected workload class gpu, got %q", call.Name, app.Spec.WorkloadClass)
+		}
+	}
+
+	for _, class := range []string{"burst", "highmem"} {
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+			Name:      "deploy_app",
+			Arguments: map[string]any{"session_id": sid, "name": "other", "image": "nginx:latest", "workload_class": class},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.IsError {
+			t.Errorf("expected workload class %q to be rejected", class)
+		}
+	}
+}
+
+func TestDeployApp_CustomDNSDisabled(t *testing.T) {
+	cs, _ := setupPolicyServer(t)
+	sid, _ := registerDSSession(t, cs)
+
+	res, err := cs.CallTool(context.Background(), &gomcp.CallToolParams{
+		Name: "deploy_app",
+		Arguments: map[string]any{"session_id": sid, "name": "web", "image": "nginx:latest",
+			"host_aliases": []map[string]any{{"ip": "10.20.0.5", "hostnames": []string{"ldap.corp.example.com"}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsError || !strings.Contains(res.Content[0].(*gomcp.TextContent).Text, "custom DNS is not enabled") {
+		t.Errorf("expected host_aliases to be rejected, got %+v", res.Content)
+	}
+}
+
+func TestDeployApp_LatestTagWarning(t *testing.T) {
+	cs, _ := setupPolicyServer(t)
+	sid, _ := registerDSSession(t, cs)
+
+	for image, wantWarning := range map[string]bool{"nginx": true, "nginx:latest": true, "nginx:1.27": false} {
+		name := "web-" + strings.NewReplacer(":", "-", ".", "-").Replace(image)
+		res, err := cs.CallTool(context.Background(), &gomcp.CallToolParams{
+			Name:      "deploy_app",
+			Arguments: map[string]any{"session_id": sid, "name": name, "image": image},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.IsError {
+			t.Fatalf("%s: unexpected error: %s", image, res.Content[0].(*gomcp.TextContent).Text)
+		}
+		var out map[string]any
+		json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &out)
+		if _, ok := out["warning"]; ok != wantWarning {
+			t.Errorf("%s: warning present = %v, want %v", image, ok, wantWarning)
+		}
+	}
+}
+
+func TestDeployApp_OfflinePublicImageWarning(t *testing.T) {
+	cs, _ := setupDeployServer(t, func(d *tools.Dependencies) { d.Offline = true })
+	sid, _ := registerDSSession(t, cs)
+
+	for image, wantWarning := range map[string]bool{"nginx:1.27": true, "harbor.corp.example/mirror/nginx:1.27": false} {
+		name := "web-" + strings.NewReplacer(":", "-", ".", "-", "/", "-").Replace(image)
+		res, err := cs.CallTool(context.Background(), &gomcp.CallToolParams{
+			Name:      "deploy_app",
+			Arguments: map[string]any{"session_id": sid, "name": name[:min(len(name), 40)], "image": image},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.IsError {
+			t.Fatalf("%s: unexpected error: %s", image, res.Content[0].(*gomcp.TextContent).Text)
+		}
+		var out map[string]any
+		json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &out)
+		if _, ok := out["offlineWarning"]; ok != wantWarning {
+			t.Errorf("%s: offlineWarning present = %v, want %v", image, ok, wantWarning)
+		}
+	}
+}
+
+func TestDeployApp_ReleaseCommand(t *testing.T) {
+	cs, k8sClient := setupPolicyServer(t)
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	releaseCommand := []any{"npm", "run", "migrate"}
+	for _, call := range []*gomcp.CallToolParams{
+		{Name: "deploy_app", Arguments: map[string]any{"session_id": sid, "name": "web", "image": "nginx:latest", "release_command": releaseCommand}},
+		{Name: "push_code", Arguments: map[string]any{"session_id": sid, "name": "api", "files": map[string]any{"main.go": "package main"}, "release_command": releaseCommand}},
+	} {
+		res, err := cs.CallTool(ctx, call)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.IsError {
+			t.Fatalf("%s: unexpected error: %s", call.Name, res.Content[0].(*gomcp.TextContent).Text)
+		}
+		var app iafv1alpha1.Application
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: call.Arguments.(map[string]any)["name"].(string), Namespace: ns}, &app); err != nil {
+			t.Fatal(err)
+		}
+		if len(app.Spec.ReleaseCommand) != 3 || app.Spec.ReleaseCommand[2] != "migrate" {
+			t.Errorf("%s: unexpected release command %v", call.Name, app.Spec.ReleaseCommand)
+		}
+	}
+
+	// Pushing again without release_command keeps the current one.
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "push_code", Arguments: map[string]any{"session_id": sid, "name": "api", "files": map[string]any{"main.go": "package main"}}})
+	if err != nil || res.IsError {
+		t.Fatalf("push_code: %v %v", err, res)
+	}
+	var app iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "api", Namespace: ns}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if len(app.Spec.ReleaseCommand) != 3 {
+		t.Errorf("expected push_code to keep the release command, got %v", app.Spec.ReleaseCommand)
+	}
+
+	res, err = cs.CallTool(ctx, &gomcp.CallToolParams{Name: "deploy_app", Arguments: map[string]any{"session_id": sid, "name": "other", "image": "nginx:latest", "release_command": []any{""}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsError {
+		t.Error("expected a blank release_command to be rejected")
+	}
+}
+
+func TestDeployApp_IdempotencyKey(t *testing.T) {
+	cs, _ := setupPolicyServer(t)
+	sid, _ := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	call := func(name string, args map[string]any) *gomcp.CallToolResult {
+		t.Helper()
+		args["session_id"] = sid
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	text := func(res *gomcp.CallToolResult) string {
+		return res.Content[0].(*gomcp.TextContent).Text
+	}
+
+	first := call("deploy_app", map[string]any{"name": "web", "image": "nginx:latest", "idempotency_key": "deploy-web-1"})
+	if first.IsError {
+		t.Fatalf("unexpected error: %s", text(first))
+	}
+
+	// A retry with the same key returns the original result.
+	retry := call("deploy_app", map[string]any{"name": "web", "image": "nginx:latest", "idempotency_key": "deploy-web-1"})
+	if retry.IsError || text(retry) != text(first) {
+		t.Fatalf("expected the original result on replay, got %s", text(retry))
+	}
+	if retry.Meta[tools.ReplayedMetaKey] != true {
+		t.Errorf("expected the replay to be marked in _meta, got %v", retry.Meta)
+	}
+
+	tests := []struct {
+		name string
+		tool string
+		args map[string]any
+		want string
+	}{
+		{"retry without a key", "deploy_app", map[string]any{"name": "web", "image": "nginx:latest"}, "already exists"},
+		{"key reused with other input", "deploy_app", map[string]any{"name": "web", "image": "nginx:1.27", "idempotency_key": "deploy-web-1"}, "different request"},
+		{"key reused by another tool", "push_code", map[string]any{"name": "web", "files": map[string]any{"main.go": "package main"}, "idempotency_key": "deploy-web-1"}, "different request"},
+		{"invalid key", "deploy_app", map[string]any{"name": "api", "image": "nginx:latest", "idempotency_key": "not a key"}, "idempotency key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := call(tt.tool, tt.args)
+			if !res.IsError || !strings.Contains(text(res), tt.want) {
+				t.Errorf("expected an error mentioning %q, got %s", tt.want, text(res))
+			}
+		})
+	}
+
+}
+
+func TestDeployApp_SubPath(t *testing.T) {
+	cs, k8sClient := setupPolicyServer(t)
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	for _, call := range []*gomcp.CallToolParams{
+		{Name: "deploy_app", Arguments: map[string]any{"session_id": sid, "name": "billing", "git_url": "https://github.com/org/platform", "git_sub_path": "services/billing"}},
+		{Name: "push_code", Arguments: map[string]any{"session_id": sid, "name": "web", "files": map[string]any{"web/main.go": "package main", "api/main.go": "package main"}, "sub_path": "web"}},
+	} {
+		res, err := cs.CallTool(ctx, call)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.IsError {
+			t.Fatalf("%s: unexpected error: %s", call.Name, res.Content[0].(*gomcp.TextContent).Text)
+		}
+	}
+	var app iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "billing", Namespace: ns}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if app.Spec.Git == nil || app.Spec.Git.SubPath != "services/billing" {
+		t.Errorf("unexpected git source %+v", app.Spec.Git)
+	}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web", Namespace: ns}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if app.Spec.BlobSubPath != "web" {
+		t.Errorf("expected blob sub path web, got %q", app.Spec.BlobSubPath)
+	}
+
+	for _, args := range []map[string]any{
+		{"name": "bad", "git_url": "https://github.com/org/platform", "git_sub_path": "../secrets"},
+		{"name": "bad", "git_url": "https://github.com/org/platform", "git_sub_path": "/services/billing"},
+		{"name": "bad", "image": "nginx:1.27", "git_sub_path": "services/billing"},
+	} {
+		args["session_id"] = sid
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "deploy_app", Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.IsError {
+			t.Errorf("expected git_sub_path %v to be rejected", args["git_sub_path"])
+		}
+	}
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "push_code", Arguments: map[string]any{"session_id": sid, "name": "other", "files": map[string]any{"main.go": "package main"}, "sub_path": "web"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsError {
+		t.Error("expected a sub_path without files under it to be rejected")
+	}
+}
+
+func TestDeployApp_Command(t *testing.T) {
+	cs, k8sClient := setupPolicyServer(t)
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "deploy_app", Arguments: map[string]any{
+		"session_id": sid, "name": "worker", "image": "node:22", "command": []any{"node", "worker.js"}, "args": []any{"--queue", "mail"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	var app iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "worker", Namespace: ns}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(app.Spec.Command, " ") != "node worker.js" || strings.Join(app.Spec.Args, " ") != "--queue mail" {
+		t.Errorf("unexpected command %q args %q", app.Spec.Command, app.Spec.Args)
+	}
+
+	for _, args := range []map[string]any{
+		{"name": "bad", "image": "node:22", "command": []any{" "}},
+		{"name": "bad", "image": "node:22", "args": []any{"a\x00b"}},
+	} {
+		args["session_id"] = sid
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "deploy_app", Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.IsError {
+			t.Errorf("expected %v to be rejected", args)
+		}
+	}
+}
+
+func TestDeployApp_Metadata(t *testing.T) {
+	cs, k8sClient := setupPolicyServer(t)
+	ctx := context.Background()
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "register",
+		Arguments: map[string]any{"metadata": map[string]any{"team": "payments", "owner": "jane@example.com"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]any
+	json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &out)
+	sid, ns := out["session_id"].(string), out["namespace"].(string)
+
+	deploy := func(args map[string]any) *gomcp.CallToolResult {
+		t.Helper()
+		args["session_id"] = sid
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "deploy_app", Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	get := func(name string) *iafv1alpha1.Application {
+		t.Helper()
+		var app iafv1alpha1.Application
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: ns}, &app); err != nil {
+			t.Fatal(err)
+		}
+		return &app
+	}
+
+	if res := deploy(map[string]any{"name": "web", "image": "nginx:1.27"}); res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	if m := get("web").Spec.Metadata; m == nil || m.Team != "payments" || m.Owner != "jane@example.com" {
+		t.Errorf("expected the session metadata, got %+v", m)
+	}
+
+	if res := deploy(map[string]any{"name": "api", "image": "nginx:1.27", "metadata": map[string]any{"team": "billing", "purpose": "invoicing"}}); res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	if m := get("api").Spec.Metadata; m == nil || m.Team != "billing" || m.Owner != "" || m.Purpose != "invoicing" {
+		t.Errorf("expected the given metadata to replace the session's, got %+v", m)
+	}
+
+	res = deploy(map[string]any{"name": "bad", "image": "nginx:1.27", "metadata": map[string]any{"owner": "jane"}})
+	if !res.IsError || !strings.Contains(res.Content[0].(*gomcp.TextContent).Text, "owner") {
+		t.Errorf("expected an invalid owner to be rejected, got %+v", res.Content)
+	}
+
+	res, err = cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name: "push_code",
+		Arguments: map[string]any{
+			"session_id": sid,
+			"name":       "web",
+			"files":      map[string]any{"index.html": "hi"},
+			"metadata":   map[string]any{"team": "growth"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	if m := get("web").Spec.Metadata; m == nil || m.Team != "growth" {
+		t.Errorf("expected push_code to update the metadata, got %+v", m)
+	}
+}
+
+func TestDeployApp_CopySource(t *testing.T) {
+	var store *sourcestore.Store
+	cs, k8sClient := setupDeployServer(t, func(deps *tools.Dependencies) { store = deps.Store })
+	sid, ns := registerDSSession(t, cs)
+	otherSID, otherNS := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	call := func(args map[string]any) *gomcp.CallToolResult {
+		t.Helper()
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "deploy_app", Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "push_code", Arguments: map[string]any{"session_id": sid, "name": "web", "files": map[string]any{"web/main.go": "package main"}, "sub_path": "web"}})
+	if err != nil || res.IsError {
+		t.Fatalf("push_code failed: %v %v", err, res)
+	}
+
+	res = call(map[string]any{"session_id": sid, "name": "web-copy", "source_app": "web", "builder": "java-native"})
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	var app iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web-copy", Namespace: ns}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(app.Spec.Blob, "/"+ns+"/web-copy/") || app.Spec.BlobSubPath != "web" || app.Spec.Builder != "java-native" {
+		t.Errorf("unexpected copy spec %+v", app.Spec)
+	}
+	if sum := app.Annotations[sourcestore.AnnotationSourceSHA256]; sum == "" || !strings.Contains(app.Spec.Blob, "sha256="+sum) {
+		t.Errorf("expected the source checksum annotation, got %q", sum)
+	}
+	if files, err := store.ReadFiles(ns, "web-copy"); err != nil || files["web/main.go"] != "package main" {
+		t.Errorf("copied files %v, err %v", files, err)
+	}
+
+	template, err := store.StoreFiles(sourcestore.TemplatesNamespace, "starter", map[string]string{"main.go": "package main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res = call(map[string]any{"session_id": otherSID, "name": "starter-app", "blob_url": template.URL})
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "starter-app", Namespace: otherNS}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(app.Spec.Blob, "/"+otherNS+"/starter-app/") {
+		t.Errorf("expected the template copied into the session, got blob %q", app.Spec.Blob)
+	}
+
+	var webCopy iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web-copy", Namespace: ns}, &webCopy); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another session's source cannot be copied, by name or by URL.
+	otherBlob := strings.SplitN(app.Spec.Blob, "&rev=", 2)[0]
+	for _, args := range []map[string]any{
+		{"session_id": otherSID, "name": "stolen", "source_app": "web"},
+		{"session_id": sid, "name": "stolen", "blob_url": otherBlob},
+		{"session_id": sid, "name": "stolen", "blob_url": "https://example.com/sources/" + ns + "/web/source.tar.gz"},
+		{"session_id": sid, "name": "web-copy", "source_app": "web"},
+		{"session_id": sid, "name": "web", "source_app": "web"},
+		{"session_id": sid, "name": "web-copy", "blob_url": strings.SplitN(webCopy.Spec.Blob, "&rev=", 2)[0]},
+		{"session_id": sid, "name": "both", "source_app": "web", "image": "nginx:1.27"},
+		{"session_id": sid, "name": "tracked", "source_app": "web", "git_track": "branch"},
+	} {
+		if res := call(args); !res.IsError {
+			t.Errorf("expected %v to be rejected", args)
+		}
+	}
+	if _, err := store.ReadFiles(ns, "stolen"); err == nil {
+		t.Error("rejected copy left source behind")
+	}
+	// Copies refused because the name is taken keep the existing source.
+	for _, name := range []string{"web", "web-copy"} {
+		if files, err := store.ReadFiles(ns, name); err != nil || files["web/main.go"] != "package main" {
+			t.Errorf("%s: source changed by a rejected copy: %v, err %v", name, files, err)
+		}
+	}
+}
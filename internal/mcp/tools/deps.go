@@ -2,13 +2,30 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/audit"
 	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/budget"
+	"github.com/dlapiduz/iaf/internal/cost"
+	"github.com/dlapiduz/iaf/internal/features"
 	iafgithub "github.com/dlapiduz/iaf/internal/github"
+	"github.com/dlapiduz/iaf/internal/grafana"
+	"github.com/dlapiduz/iaf/internal/idempotency"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/maintenance"
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+	"github.com/dlapiduz/iaf/internal/platformhealth"
+	"github.com/dlapiduz/iaf/internal/policy"
 	"github.com/dlapiduz/iaf/internal/sourcestore"
+	"github.com/dlapiduz/iaf/internal/uptime"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -22,11 +39,83 @@ type Dependencies struct {
 	GitHub      iafgithub.Client
 	GitHubToken string // stored but never surfaced in output or logs
 	GitHubOrg   string
-	// TempoURL is the Grafana base URL used to build traceExploreUrl in
-	// app_status responses. Set from IAF_TEMPO_URL. Empty = feature disabled.
-	TempoURL string
+	// GitHubTemplates are the files setup_github_repo can seed in new
+	// repositories. Nil means the built-in templates.
+	GitHubTemplates *iafgithub.RepoTemplates
+	// Grafana builds the log, trace and dashboard deep links in app_status
+	// responses. An empty URL disables them.
+	Grafana grafana.Config
+	// AlertRuleLabels are added to the PrometheusRules created by set_alert
+	// so the platform Prometheus selects them.
+	AlertRuleLabels map[string]string
+	// Uptime reports uptime check results in app_status. Nil when the
+	// platform Prometheus is not configured.
+	Uptime uptime.Querier
+	// Costs prices resource usage for session_cost and app_cost. Nil when
+	// the platform Prometheus is not configured.
+	Costs *cost.Estimator
+	// Builders are the kpack ClusterBuilders apps may select, the platform
+	// default first.
+	Builders []string
+	// Architectures are the CPU architectures apps may select.
+	Architectures []string
+	// WorkloadClasses are the workload classes apps may select.
+	WorkloadClasses []string
+	// CustomDNS lets deploy_app set host aliases and DNS settings.
+	CustomDNS bool
+	// Offline marks an air-gapped platform: GitHub tools are not registered
+	// and deploy_app warns about images on public registries.
+	Offline bool
+	// Proxy reports that builds and pods get HTTP_PROXY, HTTPS_PROXY and
+	// NO_PROXY for the platform's outbound proxy.
+	Proxy bool
+	// KubeAPIServer is the Kubernetes API server URL agents reach, written
+	// into the kubeconfigs of get_namespace_credentials. Empty disables the
+	// tool.
+	KubeAPIServer string
 	// SessionTTL is the idle TTL for new sessions. 0 = sessions never expire.
 	SessionTTL time.Duration
+	// Policy checks deploys, pushes and repository creation against the
+	// PlatformPolicy resources. Nil disables the checks.
+	Policy *policy.Engine
+	// Idempotency remembers the results of deploy_app, push_code and
+	// provision_service calls by idempotency key. Nil disables the keys.
+	Idempotency *idempotency.Store[*gomcp.CallToolResult]
+	// OrgStandards are the organisation coding standards standards_check
+	// checks source against and push_code holds runtime versions to. Nil
+	// means the platform defaults.
+	OrgStandards *orgstandards.Loader
+	// Capabilities records the tools as they are registered, for the
+	// deploy-guide prompt and the iaf://platform resource. Nil records
+	// nothing.
+	Capabilities *Capabilities
+	// Health checks the systems the platform depends on, for the
+	// iaf://platform resource. Nil leaves health out.
+	Health *platformhealth.Checker
+	// Features records the optional features the platform turns on, for
+	// get_capabilities and the iaf://platform resource. Nil skips the
+	// feature checks of the tools.
+	Features features.Flags
+	// Tools selects the tools registered. Nil registers every tool.
+	Tools *ToolFilter
+	// Budget shortens tool results too large for an agent's context and
+	// keeps the rest for continue_result. Nil leaves results whole.
+	Budget *budget.Budget
+	// History records the tool calls of each session for session_history.
+	// Nil records nothing and omits the tool.
+	History *audit.Log
+	// SecurityAudit records credentials created, deleted, issued or read
+	// and Secrets copied into session namespaces. Nil records nothing.
+	SecurityAudit *audit.SecurityLog
+	// Maintenance puts the platform in read-only mode: tools not marked
+	// ReadOnly fail with code maintenance. Nil when maintenance is off.
+	Maintenance *maintenance.Mode
+	// Timeouts limits how long tool calls run. The zero value sets no
+	// limit.
+	Timeouts Timeouts
+	// KnownHosts is the platform ConfigMap of trusted SSH git server host
+	// keys, written into ssh git credentials. Zero when none is configured.
+	KnownHosts iafk8s.KnownHosts
 }
 
 // ResolveNamespace looks up the session and returns its namespace.
@@ -34,12 +123,40 @@ type Dependencies struct {
 func (d *Dependencies) ResolveNamespace(sessionID string) (string, error) {
 	sess, ok := d.Sessions.Lookup(sessionID)
 	if !ok {
-		return "", fmt.Errorf("session not found, call the register tool first")
+		return "", apierror.NotFound(apierror.CodeSessionNotFound, "session not found, call the register tool first").
+			WithHint("sessions expire when idle; call register for a new session_id")
 	}
 	d.Sessions.Touch(sessionID)
 	return sess.Namespace, nil
 }
 
+// RequireFeature returns a capability_disabled error when feature name is
+// off.
+func (d *Dependencies) RequireFeature(name features.Name) error {
+	if d.Features.Off(name) {
+		return features.Disabled(name)
+	}
+	return nil
+}
+
+// SessionMetadata returns a copy of the ownership metadata given when
+// session sessionID registered, or nil.
+func (d *Dependencies) SessionMetadata(sessionID string) *iafv1alpha1.OwnershipMetadata {
+	sess, ok := d.Sessions.Lookup(sessionID)
+	if !ok || sess.Metadata == nil {
+		return nil
+	}
+	return sess.Metadata.DeepCopy()
+}
+
+// validateMetadata validates optional ownership metadata input.
+func validateMetadata(m *iafv1alpha1.OwnershipMetadata) error {
+	if m == nil {
+		return nil
+	}
+	return validation.ValidateOwnership(m.Team, m.Owner, m.Purpose, m.CostCenter)
+}
+
 // CheckAppNameAvailable verifies that no application with the given name exists
 // in any other namespace. This prevents hostname collisions since all apps
 // share the same base domain regardless of namespace.
@@ -50,8 +167,33 @@ func (d *Dependencies) CheckAppNameAvailable(ctx context.Context, appName, curre
 	}
 	for _, app := range allApps.Items {
 		if app.Name == appName && app.Namespace != currentNamespace {
-			return fmt.Errorf("application name %q is already in use in namespace %q — choose a different name", appName, app.Namespace)
+			return apierror.Conflict(apierror.CodeNameTaken, "application name %q is already in use in namespace %q — choose a different name", appName, app.Namespace)
 		}
 	}
 	return nil
 }
+
+// CheckPolicy evaluates in against the platform policies. When the operation
+// is blocked it returns an error tool result listing the violations, so the
+// agent can fix its input and retry; the caller should return it as is. A
+// non-nil error means the policies could not be checked.
+func (d *Dependencies) CheckPolicy(ctx context.Context, in policy.Input) (*gomcp.CallToolResult, error) {
+	if d.Policy == nil {
+		return nil, nil
+	}
+	err := d.Policy.Check(ctx, in)
+	var violation *policy.ViolationError
+	if !errors.As(err, &violation) {
+		return nil, err
+	}
+	blocked := apierror.Validation(apierror.CodePolicyViolation, "%s blocked by %d platform policy rule(s)", in.Operation, len(violation.Violations)).
+		WithHint("change the request to satisfy the violated rules and retry")
+	text, _ := json.MarshalIndent(struct {
+		apierror.Response
+		Violations []policy.Violation `json:"violations"`
+	}{blocked.Response(), violation.Violations}, "", "  ")
+	return &gomcp.CallToolResult{
+		IsError: true,
+		Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+	}, nil
+}
@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dlapiduz/iaf/internal/topology"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type DescribeTopologyInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+}
+
+// RegisterDescribeTopology registers the describe_topology MCP tool.
+func RegisterDescribeTopology(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "describe_topology",
+		Category: CategoryObserve,
+		ReadOnly: true,
+		Summary:  "Graph of the session's apps, services and data sources, with bindings, attachments and calls between apps",
+	}, &gomcp.Tool{
+		Description: "Describe what connects to what in your session, as a graph. nodes are your apps, managed services and the data sources they attach, each with an id such as \"app:web\" and its phase. edges connect them by id: \"binding\" from an app to a bound service (with its bind mode), \"attachment\" from an app to a data source, and \"call\" from an app to another app that one of its env vars points at, such as API_URL=http://api:8080 (envVars names them). Calls are inferred from env var values only, so calls made through config files or environment groups are not shown. A service or data source an app refers to that no longer exists is marked missing. Requires session_id.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input DescribeTopologyInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		graph, err := topology.Build(ctx, deps.Client, namespace)
+		if err != nil {
+			return nil, nil, err
+		}
+		result := map[string]any{
+			"nodes":   graph.Nodes,
+			"edges":   graph.Edges,
+			"message": fmt.Sprintf("%d nodes and %d edges.", len(graph.Nodes), len(graph.Edges)),
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
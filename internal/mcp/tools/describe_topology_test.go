@@ -0,0 +1,100 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	"github.com/dlapiduz/iaf/internal/topology"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDescribeTopology(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := &tools.Dependencies{Client: k8sClient, Store: store, BaseDomain: "test.example.com", Sessions: sessions}
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterDescribeTopology(server, deps)
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	cs, err := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil).Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	sid, namespace := registerCredSession(t, cs, k8sClient)
+
+	for _, obj := range []client.Object{
+		&iafv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace},
+			Spec: iafv1alpha1.ApplicationSpec{
+				Image:                "nginx:1.25",
+				Env:                  []iafv1alpha1.EnvVar{{Name: "API_URL", Value: "http://api:8080"}},
+				BoundManagedServices: []iafv1alpha1.BoundManagedService{{ServiceName: "db"}},
+			},
+		},
+		&iafv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: namespace},
+			Spec:       iafv1alpha1.ApplicationSpec{Image: "api:1"},
+		},
+		&iafv1alpha1.ManagedService{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: namespace},
+			Spec:       iafv1alpha1.ManagedServiceSpec{Type: "postgres"},
+		},
+		// Apps in other namespaces are not reported.
+		&iafv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "iaf-other"},
+			Spec:       iafv1alpha1.ApplicationSpec{Env: []iafv1alpha1.EnvVar{{Name: "API_URL", Value: "http://api." + namespace + ":8080"}}},
+		},
+	} {
+		if err := k8sClient.Create(ctx, obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "describe_topology", Arguments: map[string]any{"session_id": sid}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := res.Content[0].(*gomcp.TextContent).Text
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	var graph topology.Graph
+	if err := json.Unmarshal([]byte(text), &graph); err != nil {
+		t.Fatal(err)
+	}
+	if len(graph.Nodes) != 3 {
+		t.Errorf("expected the session's 3 nodes, got %+v", graph.Nodes)
+	}
+	if len(graph.Edges) != 2 ||
+		graph.Edges[0].Kind != topology.EdgeBinding || graph.Edges[0].To != "service:db" || graph.Edges[0].Mode != "rw" ||
+		graph.Edges[1].Kind != topology.EdgeCall || graph.Edges[1].To != "app:api" {
+		t.Errorf("unexpected edges %+v", graph.Edges)
+	}
+}
@@ -0,0 +1,403 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/audit"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	maxEnvGroupsPerSession = 20
+	maxEnvGroupsPerApp     = 10
+	maxEnvGroupVars        = 100
+)
+
+// CreateEnvGroupInput is the input for the create_env_group tool.
+type CreateEnvGroupInput struct {
+	SessionID string               `json:"session_id" jsonschema:"required - session ID from the register tool"`
+	Name      string               `json:"name"       jsonschema:"required - group name (DNS label: lowercase alphanumeric and hyphens)"`
+	Vars      []iafv1alpha1.EnvVar `json:"vars"       jsonschema:"required - environment variables as [{name, value}]; replaces every variable of an existing group"`
+}
+
+// BindEnvGroupInput is the input for the bind_env_group and unbind_env_group tools.
+type BindEnvGroupInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID from the register tool"`
+	Name      string `json:"name"       jsonschema:"required - environment group name"`
+	AppName   string `json:"app_name"   jsonschema:"required - name of the application"`
+}
+
+// ListEnvGroupsInput is the input for the list_env_groups tool.
+type ListEnvGroupsInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID from the register tool"`
+}
+
+// DeleteEnvGroupInput is the input for the delete_env_group tool.
+type DeleteEnvGroupInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID from the register tool"`
+	Name      string `json:"name"       jsonschema:"required - environment group name to delete"`
+}
+
+// RegisterCreateEnvGroup registers the create_env_group MCP tool.
+func RegisterCreateEnvGroup(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "create_env_group",
+		Category: CategoryConfigure,
+		Summary:  "Create or replace a named set of env vars shared by several apps",
+		Examples: []string{`{"session_id": "<id>", "name": "shared", "vars": [{"name": "FEATURE_X", "value": "on"}]}`},
+	}, &gomcp.Tool{
+		Description: "Create or replace a named set of environment variables (API keys, feature flags) shared by apps in the session. Bind it to apps with bind_env_group instead of repeating the variables in every deploy. Replacing a group rolls every app bound to it. Values are stored in a Kubernetes Secret and never returned by any tool. Requires session_id.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input CreateEnvGroupInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.Name); err != nil {
+			return nil, nil, fmt.Errorf("invalid group name: %w", err)
+		}
+		if len(input.Vars) == 0 {
+			return nil, nil, fmt.Errorf("vars is required")
+		}
+		if len(input.Vars) > maxEnvGroupVars {
+			return nil, nil, fmt.Errorf("an environment group may have at most %d variables", maxEnvGroupVars)
+		}
+		vars := make(map[string]string, len(input.Vars))
+		for _, e := range input.Vars {
+			if err := validation.ValidateEnvVarName(e.Name); err != nil {
+				return nil, nil, err
+			}
+			if _, ok := vars[e.Name]; ok {
+				return nil, nil, fmt.Errorf("variable %q is set more than once", e.Name)
+			}
+			vars[e.Name] = e.Value
+		}
+
+		desired := iafk8s.BuildEnvGroupSecret(namespace, input.Name, vars)
+		var existing corev1.Secret
+		created := false
+		err = deps.Client.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: namespace}, &existing)
+		switch {
+		case apierrors.IsNotFound(err):
+			var secretList corev1.SecretList
+			if err := deps.Client.List(ctx, &secretList,
+				client.InNamespace(namespace),
+				client.HasLabels{iafk8s.LabelEnvGroup},
+			); err != nil {
+				return nil, nil, fmt.Errorf("listing environment groups: %w", err)
+			}
+			if len(secretList.Items) >= maxEnvGroupsPerSession {
+				return nil, nil, apierror.Quota(apierror.CodeQuotaExceeded, "environment group limit reached: a session may have at most %d groups; delete an existing one before adding a new one", maxEnvGroupsPerSession)
+			}
+			if err := deps.Client.Create(ctx, desired); err != nil {
+				return nil, nil, fmt.Errorf("creating environment group: %w", err)
+			}
+			created = true
+		case err != nil:
+			return nil, nil, fmt.Errorf("getting environment group: %w", err)
+		default:
+			if existing.Labels[iafk8s.LabelEnvGroup] != input.Name {
+				return nil, nil, fmt.Errorf("secret %q is not an environment group managed by IAF", existing.Name)
+			}
+			existing.Data = desired.Data
+			if err := deps.Client.Update(ctx, &existing); err != nil {
+				return nil, nil, fmt.Errorf("updating environment group: %w", err)
+			}
+		}
+		action := audit.ActionSecretRotated
+		if created {
+			action = audit.ActionCredentialCreated
+		}
+		deps.SecurityAudit.Record(audit.SecurityEvent{
+			Action:    action,
+			Actor:     input.SessionID,
+			Namespace: namespace,
+			Kind:      "env_group",
+			Name:      input.Name,
+			Secret:    desired.Name,
+		})
+
+		boundApps, err := envGroupApps(ctx, deps.Client, namespace, input.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		result := map[string]any{
+			"name":      input.Name,
+			"vars":      slices.Sorted(maps.Keys(vars)),
+			"created":   created,
+			"boundApps": boundApps,
+		}
+		if len(boundApps) > 0 {
+			result["message"] = fmt.Sprintf("Bound applications restart with the new values: %s.", strings.Join(boundApps, ", "))
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// RegisterBindEnvGroup registers the bind_env_group MCP tool.
+func RegisterBindEnvGroup(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:          "bind_env_group",
+		Category:      CategoryConfigure,
+		Summary:       "Load an env group's variables into an app",
+		Preconditions: []string{"the env group exists (create_env_group)"},
+	}, &gomcp.Tool{
+		Description: "Bind an environment group to an application. Every variable in the group is set in the app's environment and the app restarts. Variables the app sets itself with env, and those injected by data sources and services, take precedence over the group. Requires session_id.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input BindEnvGroupInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.Name); err != nil {
+			return nil, nil, fmt.Errorf("invalid group name: %w", err)
+		}
+		if err := validation.ValidateAppName(input.AppName); err != nil {
+			return nil, nil, fmt.Errorf("invalid app name: %w", err)
+		}
+
+		vars, err := getEnvGroupVars(ctx, deps.Client, namespace, input.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var app iafv1alpha1.Application
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.AppName, Namespace: namespace}, &app); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.AppName)
+			}
+			return nil, nil, fmt.Errorf("getting application: %w", err)
+		}
+		if slices.Contains(app.Spec.EnvGroups, input.Name) {
+			return nil, nil, fmt.Errorf("environment group %q is already bound to application %q", input.Name, input.AppName)
+		}
+		if len(app.Spec.EnvGroups) >= maxEnvGroupsPerApp {
+			return nil, nil, fmt.Errorf("an application may have at most %d environment groups", maxEnvGroupsPerApp)
+		}
+
+		// Record the binding; the controller loads the group with envFrom.
+		app.Spec.EnvGroups = append(app.Spec.EnvGroups, input.Name)
+		if err := deps.Client.Update(ctx, &app); err != nil {
+			return nil, nil, fmt.Errorf("updating application: %w", err)
+		}
+
+		result := map[string]any{
+			"bound":   true,
+			"vars":    vars,
+			"message": fmt.Sprintf("Application %q now loads environment group %q and restarts with its variables. Values are never returned by tools.", input.AppName, input.Name),
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// RegisterUnbindEnvGroup registers the unbind_env_group MCP tool.
+func RegisterUnbindEnvGroup(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "unbind_env_group",
+		Category: CategoryConfigure,
+		Summary:  "Remove an env group from an app",
+	}, &gomcp.Tool{
+		Description: "Remove an environment group from an application. The app restarts without the group's variables. Does not delete the group. Requires session_id.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input BindEnvGroupInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.Name); err != nil {
+			return nil, nil, fmt.Errorf("invalid group name: %w", err)
+		}
+		if err := validation.ValidateAppName(input.AppName); err != nil {
+			return nil, nil, fmt.Errorf("invalid app name: %w", err)
+		}
+
+		var app iafv1alpha1.Application
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.AppName, Namespace: namespace}, &app); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.AppName)
+			}
+			return nil, nil, fmt.Errorf("getting application: %w", err)
+		}
+		i := slices.Index(app.Spec.EnvGroups, input.Name)
+		if i < 0 {
+			return nil, nil, fmt.Errorf("environment group %q is not bound to application %q", input.Name, input.AppName)
+		}
+		app.Spec.EnvGroups = slices.Delete(app.Spec.EnvGroups, i, i+1)
+		if err := deps.Client.Update(ctx, &app); err != nil {
+			return nil, nil, fmt.Errorf("updating application: %w", err)
+		}
+
+		result := map[string]any{
+			"unbound": true,
+			"message": fmt.Sprintf("Application %q no longer loads environment group %q.", input.AppName, input.Name),
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// RegisterListEnvGroups registers the list_env_groups MCP tool.
+func RegisterListEnvGroups(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "list_env_groups",
+		Category: CategoryConfigure,
+		ReadOnly: true,
+		Summary:  "List env groups with their variable names and bound apps (no values)",
+	}, &gomcp.Tool{
+		Description: "List the environment groups in the current session with their variable names and bound apps — never values.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input ListEnvGroupsInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var secretList corev1.SecretList
+		if err := deps.Client.List(ctx, &secretList,
+			client.InNamespace(namespace),
+			client.HasLabels{iafk8s.LabelEnvGroup},
+		); err != nil {
+			return nil, nil, fmt.Errorf("listing environment groups: %w", err)
+		}
+		var apps iafv1alpha1.ApplicationList
+		if err := deps.Client.List(ctx, &apps, client.InNamespace(namespace)); err != nil {
+			return nil, nil, fmt.Errorf("listing applications: %w", err)
+		}
+
+		type groupInfo struct {
+			Name      string   `json:"name"`
+			Vars      []string `json:"vars"`
+			BoundApps []string `json:"boundApps"`
+			CreatedAt string   `json:"created_at"`
+		}
+		groups := make([]groupInfo, 0, len(secretList.Items))
+		for _, s := range secretList.Items {
+			name := s.Labels[iafk8s.LabelEnvGroup]
+			if s.Name != iafk8s.EnvGroupSecretName(name) {
+				continue
+			}
+			info := groupInfo{Name: name, Vars: slices.Sorted(maps.Keys(s.Data)), BoundApps: []string{}}
+			for _, app := range apps.Items {
+				if slices.Contains(app.Spec.EnvGroups, name) {
+					info.BoundApps = append(info.BoundApps, app.Name)
+				}
+			}
+			if !s.CreationTimestamp.IsZero() {
+				info.CreatedAt = s.CreationTimestamp.UTC().Format(time.RFC3339)
+			}
+			groups = append(groups, info)
+		}
+		slices.SortFunc(groups, func(a, b groupInfo) int { return strings.Compare(a.Name, b.Name) })
+
+		text, _ := json.MarshalIndent(map[string]any{"groups": groups, "total": len(groups)}, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// RegisterDeleteEnvGroup registers the delete_env_group MCP tool.
+func RegisterDeleteEnvGroup(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:          "delete_env_group",
+		Category:      CategoryConfigure,
+		Summary:       "Remove an env group",
+		Preconditions: []string{"no app is bound to it (unbind_env_group)"},
+	}, &gomcp.Tool{
+		Description: "Delete an environment group from the current session. Unbind it from every app first.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input DeleteEnvGroupInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.Name); err != nil {
+			return nil, nil, fmt.Errorf("invalid group name: %w", err)
+		}
+
+		if _, err := getEnvGroupVars(ctx, deps.Client, namespace, input.Name); err != nil {
+			return nil, nil, err
+		}
+		// Deleting a group still in use would silently drop variables apps rely on.
+		boundApps, err := envGroupApps(ctx, deps.Client, namespace, input.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(boundApps) > 0 {
+			return nil, nil, fmt.Errorf("environment group %q is bound to %s; unbind it first", input.Name, strings.Join(boundApps, ", "))
+		}
+
+		secret := iafk8s.BuildEnvGroupSecret(namespace, input.Name, nil)
+		if err := deps.Client.Delete(ctx, secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeNotFound, "environment group %q not found", input.Name)
+			}
+			return nil, nil, fmt.Errorf("deleting environment group: %w", err)
+		}
+		deps.SecurityAudit.Record(audit.SecurityEvent{
+			Action:    audit.ActionCredentialDeleted,
+			Actor:     input.SessionID,
+			Namespace: namespace,
+			Kind:      "env_group",
+			Name:      input.Name,
+			Secret:    secret.Name,
+		})
+
+		result := map[string]any{
+			"name":    input.Name,
+			"deleted": true,
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// getEnvGroupVars returns the variable names of environment group name.
+func getEnvGroupVars(ctx context.Context, c client.Client, namespace, name string) ([]string, error) {
+	var secret corev1.Secret
+	if err := c.Get(ctx, types.NamespacedName{Name: iafk8s.EnvGroupSecretName(name), Namespace: namespace}, &secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, apierror.NotFound(apierror.CodeNotFound, "environment group %q not found", name)
+		}
+		return nil, fmt.Errorf("getting environment group: %w", err)
+	}
+	if secret.Labels[iafk8s.LabelEnvGroup] != name {
+		return nil, fmt.Errorf("secret %q is not an environment group managed by IAF", secret.Name)
+	}
+	return slices.Sorted(maps.Keys(secret.Data)), nil
+}
+
+// envGroupApps returns the names of the applications bound to environment
+// group name.
+func envGroupApps(ctx context.Context, c client.Client, namespace, name string) ([]string, error) {
+	var apps iafv1alpha1.ApplicationList
+	if err := c.List(ctx, &apps, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("listing applications: %w", err)
+	}
+	bound := []string{}
+	for _, app := range apps.Items {
+		if slices.Contains(app.Spec.EnvGroups, name) {
+			bound = append(bound, app.Name)
+		}
+	}
+	return bound, nil
+}
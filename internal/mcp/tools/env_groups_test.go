@@ -0,0 +1,228 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// setupEnvGroupServer creates a server with the environment group tools and
+// deploy_app registered.
+func setupEnvGroupServer(t *testing.T) (*gomcp.ClientSession, client.Client) {
+	t.Helper()
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		Store:      store,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterCreateEnvGroup(server, deps)
+	tools.RegisterBindEnvGroup(server, deps)
+	tools.RegisterUnbindEnvGroup(server, deps)
+	tools.RegisterListEnvGroups(server, deps)
+	tools.RegisterDeleteEnvGroup(server, deps)
+	tools.RegisterDeployApp(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mc := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mc.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs, k8sClient
+}
+
+func TestEnvGroup_Lifecycle(t *testing.T) {
+	cs, k8sClient := setupEnvGroupServer(t)
+	ctx := context.Background()
+	sid, namespace := registerCredSession(t, cs, k8sClient)
+
+	call := func(name string, args map[string]any) (string, bool) {
+		t.Helper()
+		args["session_id"] = sid
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Content[0].(*gomcp.TextContent).Text, res.IsError
+	}
+	mustCall := func(name string, args map[string]any) map[string]any {
+		t.Helper()
+		text, isErr := call(name, args)
+		if isErr {
+			t.Fatalf("%s: unexpected error: %s", name, text)
+		}
+		if strings.Contains(text, "s3cr3t") {
+			t.Errorf("%s output must not contain variable values", name)
+		}
+		var out map[string]any
+		json.Unmarshal([]byte(text), &out)
+		return out
+	}
+
+	out := mustCall("create_env_group", map[string]any{
+		"name": "shared",
+		"vars": []map[string]any{{"name": "API_KEY", "value": "s3cr3t-one"}, {"name": "FEATURE_X", "value": "on"}},
+	})
+	if out["created"] != true {
+		t.Errorf("expected created, got %v", out)
+	}
+	secret := &corev1.Secret{}
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "iaf-env-shared"}, secret); err != nil {
+		t.Fatalf("secret not created: %v", err)
+	}
+	if secret.Labels[iafk8s.LabelEnvGroup] != "shared" || string(secret.Data["API_KEY"]) != "s3cr3t-one" {
+		t.Errorf("unexpected group secret: labels %v, %d keys", secret.Labels, len(secret.Data))
+	}
+
+	mustCall("deploy_app", map[string]any{"name": "web", "image": "nginx:latest"})
+	mustCall("bind_env_group", map[string]any{"name": "shared", "app_name": "web"})
+	var app iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "web"}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(app.Spec.EnvGroups, []string{"shared"}) {
+		t.Errorf("expected envGroups [shared], got %v", app.Spec.EnvGroups)
+	}
+	if _, isErr := call("bind_env_group", map[string]any{"name": "shared", "app_name": "web"}); !isErr {
+		t.Error("expected binding a group twice to fail")
+	}
+
+	// Replacing the group drops variables that are no longer set.
+	out = mustCall("create_env_group", map[string]any{
+		"name": "shared",
+		"vars": []map[string]any{{"name": "API_KEY", "value": "s3cr3t-two"}},
+	})
+	if out["created"] != false || !slices.Equal(toStrings(out["boundApps"]), []string{"web"}) {
+		t.Errorf("expected an update reporting bound app web, got %v", out)
+	}
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "iaf-env-shared"}, secret); err != nil {
+		t.Fatal(err)
+	}
+	if len(secret.Data) != 1 || string(secret.Data["API_KEY"]) != "s3cr3t-two" {
+		t.Errorf("expected only the new API_KEY, got %d keys", len(secret.Data))
+	}
+
+	out = mustCall("list_env_groups", map[string]any{})
+	groups, _ := out["groups"].([]any)
+	if len(groups) != 1 {
+		t.Fatalf("expected 1 group, got %v", out)
+	}
+	g := groups[0].(map[string]any)
+	if !slices.Equal(toStrings(g["vars"]), []string{"API_KEY"}) || !slices.Equal(toStrings(g["boundApps"]), []string{"web"}) {
+		t.Errorf("unexpected group listing %v", g)
+	}
+
+	// Bound: deletion is refused.
+	if _, isErr := call("delete_env_group", map[string]any{"name": "shared"}); !isErr {
+		t.Fatal("expected deleting a bound group to fail")
+	}
+	mustCall("unbind_env_group", map[string]any{"name": "shared", "app_name": "web"})
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "web"}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if len(app.Spec.EnvGroups) != 0 {
+		t.Errorf("expected no env groups after unbind, got %v", app.Spec.EnvGroups)
+	}
+	mustCall("delete_env_group", map[string]any{"name": "shared"})
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "iaf-env-shared"}, secret); err == nil {
+		t.Error("expected the group secret to be deleted")
+	}
+}
+
+func TestEnvGroup_Validation(t *testing.T) {
+	cs, k8sClient := setupEnvGroupServer(t)
+	ctx := context.Background()
+	sid, namespace := registerCredSession(t, cs, k8sClient)
+
+	// A Secret with a group's name that IAF did not create as a group.
+	if err := k8sClient.Create(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "iaf-env-foreign", Namespace: namespace}}); err != nil {
+		t.Fatal(err)
+	}
+	app := &iafv1alpha1.Application{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace}}
+	if err := k8sClient.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		tool string
+		args map[string]any
+	}{
+		{"invalid group name", "create_env_group", map[string]any{"name": "Bad_Name", "vars": []map[string]any{{"name": "A", "value": "1"}}}},
+		{"no vars", "create_env_group", map[string]any{"name": "shared", "vars": []map[string]any{}}},
+		{"invalid var name", "create_env_group", map[string]any{"name": "shared", "vars": []map[string]any{{"name": "1BAD", "value": "1"}}}},
+		{"duplicate var", "create_env_group", map[string]any{"name": "shared", "vars": []map[string]any{{"name": "A", "value": "1"}, {"name": "A", "value": "2"}}}},
+		{"overwrite foreign secret", "create_env_group", map[string]any{"name": "foreign", "vars": []map[string]any{{"name": "A", "value": "1"}}}},
+		{"bind missing group", "bind_env_group", map[string]any{"name": "missing", "app_name": "web"}},
+		{"bind foreign secret", "bind_env_group", map[string]any{"name": "foreign", "app_name": "web"}},
+		{"unbind unbound group", "unbind_env_group", map[string]any{"name": "foreign", "app_name": "web"}},
+		{"delete foreign secret", "delete_env_group", map[string]any{"name": "foreign"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args["session_id"] = sid
+			res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: tt.tool, Arguments: tt.args})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !res.IsError {
+				t.Errorf("expected error, got %s", res.Content[0].(*gomcp.TextContent).Text)
+			}
+		})
+	}
+
+	secret := &corev1.Secret{}
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "iaf-env-foreign"}, secret); err != nil {
+		t.Fatalf("expected the foreign secret to be left alone: %v", err)
+	}
+}
+
+// toStrings converts a decoded JSON array to strings.
+func toStrings(v any) []string {
+	items, _ := v.([]any)
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		s, _ := item.(string)
+		out = append(out, s)
+	}
+	return out
+}
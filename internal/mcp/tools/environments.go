@@ -0,0 +1,277 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/policy"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type CreateEnvironmentInput struct {
+	SessionID         string               `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Name              string               `json:"name" jsonschema:"required - name of the deployed application the environment is created from"`
+	Environment       string               `json:"environment" jsonschema:"required - name of the new environment (e.g. 'staging', 'prod'); the app is named <name>-<environment>"`
+	SourceEnvironment string               `json:"source_environment,omitempty" jsonschema:"environment name given to the source app if it is not part of a pipeline yet (default: 'dev')"`
+	Host              string               `json:"host,omitempty" jsonschema:"custom hostname for the new environment (default: <name>-<environment>.<base-domain>)"`
+	Env               []iafv1alpha1.EnvVar `json:"env,omitempty" jsonschema:"environment variables to set or override in the new environment, as [{name, value}]; other variables are copied from the source"`
+	Replicas          int32                `json:"replicas,omitempty" jsonschema:"number of replicas in the new environment (default: copied from the source)"`
+}
+
+// RegisterCreateEnvironment registers the create_environment tool, which
+// copies a deployed Application into a new environment of its promotion
+// pipeline.
+func RegisterCreateEnvironment(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:          "create_environment",
+		Category:      CategoryDeploy,
+		Summary:       "Create an environment (e.g. staging) of an app running the exact image it runs now",
+		Preconditions: []string{"the source app has a deployed image"},
+		Examples:      []string{`{"session_id": "<id>", "name": "web", "environment": "staging"}`},
+	}, &gomcp.Tool{
+		Description: "Create a new environment (e.g. staging or prod) of a deployed app: copies its settings into '<name>-<environment>' running the exact image the source runs now, with its own host, env overrides and replicas. The source becomes the first environment of the pipeline (named by source_environment, default 'dev'). Move later builds through the pipeline with promote_app instead of rebuilding.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input CreateEnvironmentInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.Name); err != nil {
+			return nil, nil, err
+		}
+		if input.SourceEnvironment == "" {
+			input.SourceEnvironment = "dev"
+		}
+		for _, env := range []string{input.Environment, input.SourceEnvironment} {
+			if err := validation.ValidateEnvironmentName(env); err != nil {
+				return nil, nil, err
+			}
+		}
+		for _, e := range input.Env {
+			if err := validation.ValidateEnvVarName(e.Name); err != nil {
+				return nil, nil, err
+			}
+		}
+		if input.Replicas < 0 {
+			return nil, nil, fmt.Errorf("replicas must not be negative")
+		}
+
+		var source iafv1alpha1.Application
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, &source); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.Name)
+			}
+			return nil, nil, fmt.Errorf("getting application: %w", err)
+		}
+		if _, ok := source.Labels[iafk8s.LabelPreviewOf]; ok {
+			return nil, nil, fmt.Errorf("application %q is a preview; create environments from the original app", input.Name)
+		}
+		if source.Status.LatestImage == "" {
+			return nil, nil, apierror.Conflict(apierror.CodeConflict, "application %q has no deployed image yet", input.Name).
+				WithHint("wait until app_status reports the app Running, then create the environment")
+		}
+
+		// The pipeline is named after the app it started from; a source that
+		// is already an environment keeps its pipeline.
+		pipeline := source.Labels[iafk8s.LabelEnvironmentOf]
+		sourceEnv := source.Labels[iafk8s.LabelEnvironment]
+		if pipeline == "" {
+			pipeline, sourceEnv = source.Name, input.SourceEnvironment
+		}
+		if input.Environment == sourceEnv {
+			return nil, nil, fmt.Errorf("application %q is already the %q environment", input.Name, sourceEnv)
+		}
+		existing, err := iafk8s.GetEnvironment(ctx, deps.Client, namespace, pipeline, input.Environment)
+		if err != nil {
+			return nil, nil, fmt.Errorf("listing environments: %w", err)
+		}
+		if existing != nil {
+			return nil, nil, apierror.Conflict(apierror.CodeConflict, "environment %q of %q already exists as %q", input.Environment, pipeline, existing.Name).
+				WithHint("use promote_app to move a new image into it")
+		}
+		envName := iafk8s.EnvironmentName(pipeline, input.Environment)
+		if err := validation.ValidateAppName(envName); err != nil {
+			return nil, nil, fmt.Errorf("environment app name %q is invalid: %w", envName, err)
+		}
+		if err := deps.CheckAppNameAvailable(ctx, envName, namespace); err != nil {
+			return nil, nil, err
+		}
+
+		spec := iafk8s.EnvironmentSpec(&source)
+		spec.Host = input.Host
+		spec.Env = mergeEnv(spec.Env, input.Env)
+		if input.Replicas > 0 {
+			spec.Replicas = input.Replicas
+		}
+		target := &iafv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      envName,
+				Namespace: namespace,
+				Labels: map[string]string{
+					iafk8s.LabelEnvironmentOf: pipeline,
+					iafk8s.LabelEnvironment:   input.Environment,
+				},
+				Annotations: map[string]string{
+					iafk8s.AnnotationPromotedFrom: sourceEnv,
+				},
+			},
+			Spec: spec,
+		}
+		if res, err := deps.CheckPolicy(ctx, policy.Input{Operation: iafv1alpha1.PolicyOperationDeploy, Namespace: namespace, App: target}); res != nil || err != nil {
+			return res, nil, err
+		}
+
+		if source.Labels[iafk8s.LabelEnvironmentOf] == "" {
+			if source.Labels == nil {
+				source.Labels = map[string]string{}
+			}
+			source.Labels[iafk8s.LabelEnvironmentOf] = pipeline
+			source.Labels[iafk8s.LabelEnvironment] = sourceEnv
+			if err := deps.Client.Update(ctx, &source); err != nil {
+				return nil, nil, fmt.Errorf("labeling source application: %w", err)
+			}
+		}
+		if err := deps.Client.Create(ctx, target); err != nil {
+			return nil, nil, fmt.Errorf("creating environment: %w", err)
+		}
+
+		host := input.Host
+		if host == "" {
+			host = fmt.Sprintf("%s.%s", envName, deps.BaseDomain)
+		}
+		result := map[string]any{
+			"name":        envName,
+			"app":         pipeline,
+			"environment": input.Environment,
+			"image":       spec.Image,
+			"status":      "deploying",
+			"message":     fmt.Sprintf("Environment %q of %q is deploying image %s at https://%s. Promote later builds with promote_app from %q to %q.", input.Environment, pipeline, spec.Image, host, sourceEnv, input.Environment),
+		}
+
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+type PromoteAppInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Name      string `json:"name" jsonschema:"required - name of the app the environments were created from"`
+	From      string `json:"from" jsonschema:"required - environment whose running image is promoted (e.g. 'dev')"`
+	To        string `json:"to" jsonschema:"required - environment that is updated to run that image (e.g. 'staging')"`
+}
+
+// RegisterPromoteApp registers the promote_app tool, which moves the image
+// one environment runs to another without rebuilding it.
+func RegisterPromoteApp(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:          "promote_app",
+		Category:      CategoryDeploy,
+		Summary:       "Promote the image of one environment to the next without rebuilding",
+		Preconditions: []string{"both environments exist (create_environment)"},
+		Examples:      []string{`{"session_id": "<id>", "name": "web", "from": "staging", "to": "prod"}`},
+	}, &gomcp.Tool{
+		Description: "Promote an app from one environment to the next (e.g. from 'dev' to 'staging'): the target environment is re-pointed at the exact image the source environment runs, without rebuilding. Each environment keeps its own host, env and replicas. Create environments first with create_environment.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input PromoteAppInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.Name); err != nil {
+			return nil, nil, err
+		}
+		for _, env := range []string{input.From, input.To} {
+			if err := validation.ValidateEnvironmentName(env); err != nil {
+				return nil, nil, err
+			}
+		}
+		if input.From == input.To {
+			return nil, nil, fmt.Errorf("from and to must be different environments")
+		}
+
+		environment := func(env string) (*iafv1alpha1.Application, error) {
+			app, err := iafk8s.GetEnvironment(ctx, deps.Client, namespace, input.Name, env)
+			if err != nil {
+				return nil, fmt.Errorf("listing environments: %w", err)
+			}
+			if app == nil {
+				return nil, apierror.NotFound(apierror.CodeAppNotFound, "environment %q of %q not found", env, input.Name).
+					WithHint("create it with create_environment")
+			}
+			return app, nil
+		}
+		source, err := environment(input.From)
+		if err != nil {
+			return nil, nil, err
+		}
+		target, err := environment(input.To)
+		if err != nil {
+			return nil, nil, err
+		}
+		image := source.Status.LatestImage
+		if image == "" {
+			return nil, nil, apierror.Conflict(apierror.CodeConflict, "environment %q (%s) has no deployed image yet", input.From, source.Name).
+				WithHint("wait until app_status reports it Running, then promote")
+		}
+
+		previous := target.Spec.Image
+		target.Spec.Image = image
+		target.Spec.Git = nil
+		target.Spec.Blob = ""
+		if target.Annotations == nil {
+			target.Annotations = map[string]string{}
+		}
+		target.Annotations[iafk8s.AnnotationPromotedFrom] = input.From
+		if res, err := deps.CheckPolicy(ctx, policy.Input{Operation: iafv1alpha1.PolicyOperationDeploy, Namespace: namespace, App: target}); res != nil || err != nil {
+			return res, nil, err
+		}
+		if err := deps.Client.Update(ctx, target); err != nil {
+			return nil, nil, fmt.Errorf("updating environment: %w", err)
+		}
+
+		result := map[string]any{
+			"name":          target.Name,
+			"from":          input.From,
+			"to":            input.To,
+			"image":         image,
+			"previousImage": previous,
+			"digestPinned":  iafk8s.IsDigestReference(image),
+			"message":       fmt.Sprintf("Promoted %s from %q to %q (%s). Poll app_status with name %q until it is Running.", image, input.From, input.To, target.Name, target.Name),
+		}
+		if !iafk8s.IsDigestReference(image) {
+			result["warning"] = "the promoted image is referenced by tag, not digest; if the tag is pushed again the environments may run different builds"
+		}
+
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// mergeEnv returns base with the variables in overrides set, replacing
+// variables of the same name in place and appending new ones.
+func mergeEnv(base, overrides []iafv1alpha1.EnvVar) []iafv1alpha1.EnvVar {
+	out := append([]iafv1alpha1.EnvVar{}, base...)
+	for _, o := range overrides {
+		replaced := false
+		for i := range out {
+			if out[i].Name == o.Name {
+				out[i].Value = o.Value
+				replaced = true
+			}
+		}
+		if !replaced {
+			out = append(out, o)
+		}
+	}
+	return out
+}
@@ -0,0 +1,216 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// setupEnvironmentServer creates a server with register, create_environment
+// and promote_app registered.
+func setupEnvironmentServer(t *testing.T) (*gomcp.ClientSession, client.Client) {
+	t.Helper()
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		Store:      store,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterCreateEnvironment(server, deps)
+	tools.RegisterPromoteApp(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mc := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mc.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs, k8sClient
+}
+
+func callEnvironmentTool(t *testing.T, cs *gomcp.ClientSession, name string, args map[string]any) (*gomcp.CallToolResult, map[string]any) {
+	t.Helper()
+	res, err := cs.CallTool(context.Background(), &gomcp.CallToolParams{Name: name, Arguments: args})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]any
+	json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &out)
+	return res, out
+}
+
+func TestEnvironments_CreateAndPromote(t *testing.T) {
+	cs, k8sClient := setupEnvironmentServer(t)
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	source := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: ns},
+		Spec: iafv1alpha1.ApplicationSpec{
+			Git:      &iafv1alpha1.GitSource{URL: "https://github.com/org/web.git", Revision: "main"},
+			Port:     3000,
+			Replicas: 1,
+			Env:      []iafv1alpha1.EnvVar{{Name: "MODE", Value: "dev"}, {Name: "LOG_LEVEL", Value: "debug"}},
+		},
+		Status: iafv1alpha1.ApplicationStatus{LatestImage: "registry.local/web@sha256:v1"},
+	}
+	if err := k8sClient.Create(ctx, source); err != nil {
+		t.Fatal(err)
+	}
+
+	res, out := callEnvironmentTool(t, cs, "create_environment", map[string]any{
+		"session_id": sid, "name": "web", "environment": "staging",
+		"host": "staging.example.org", "replicas": 3,
+		"env": []map[string]string{{"name": "MODE", "value": "staging"}},
+	})
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	if out["name"] != "web-staging" {
+		t.Errorf("expected environment app 'web-staging', got %v", out["name"])
+	}
+
+	var staging iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web-staging", Namespace: ns}, &staging); err != nil {
+		t.Fatalf("expected environment app: %v", err)
+	}
+	if staging.Spec.Image != "registry.local/web@sha256:v1" || staging.Spec.Git != nil {
+		t.Errorf("expected the dev image without a git source, got image=%q git=%v", staging.Spec.Image, staging.Spec.Git)
+	}
+	if staging.Spec.Host != "staging.example.org" || staging.Spec.Replicas != 3 || staging.Spec.Port != 3000 {
+		t.Errorf("unexpected environment spec: %+v", staging.Spec)
+	}
+	if len(staging.Spec.Env) != 2 || staging.Spec.Env[0].Value != "staging" || staging.Spec.Env[1].Value != "debug" {
+		t.Errorf("expected MODE overridden and LOG_LEVEL copied, got %+v", staging.Spec.Env)
+	}
+	if staging.Labels[iafk8s.LabelEnvironmentOf] != "web" || staging.Labels[iafk8s.LabelEnvironment] != "staging" {
+		t.Errorf("unexpected environment labels: %v", staging.Labels)
+	}
+
+	var dev iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web", Namespace: ns}, &dev); err != nil {
+		t.Fatal(err)
+	}
+	if dev.Labels[iafk8s.LabelEnvironment] != "dev" || dev.Spec.Git == nil {
+		t.Errorf("expected the source labeled dev and still built from git, got labels=%v", dev.Labels)
+	}
+
+	// Creating the same environment again is refused.
+	res, _ = callEnvironmentTool(t, cs, "create_environment", map[string]any{"session_id": sid, "name": "web", "environment": "staging"})
+	if !res.IsError || !strings.Contains(res.Content[0].(*gomcp.TextContent).Text, "already exists") {
+		t.Errorf("expected an already exists error, got %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+
+	// A new dev build is promoted without a rebuild.
+	dev.Status.LatestImage = "registry.local/web@sha256:v2"
+	if err := k8sClient.Update(ctx, &dev); err != nil {
+		t.Fatal(err)
+	}
+	res, out = callEnvironmentTool(t, cs, "promote_app", map[string]any{"session_id": sid, "name": "web", "from": "dev", "to": "staging"})
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	if out["digestPinned"] != true || out["previousImage"] != "registry.local/web@sha256:v1" {
+		t.Errorf("unexpected promote result: %v", out)
+	}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web-staging", Namespace: ns}, &staging); err != nil {
+		t.Fatal(err)
+	}
+	if staging.Spec.Image != "registry.local/web@sha256:v2" || staging.Spec.Host != "staging.example.org" {
+		t.Errorf("expected the new image with the staging host kept, got %+v", staging.Spec)
+	}
+	if staging.Annotations[iafk8s.AnnotationPromotedFrom] != "dev" {
+		t.Errorf("expected promoted-from annotation, got %v", staging.Annotations)
+	}
+}
+
+func TestCreateEnvironment_RequiresDeployedImage(t *testing.T) {
+	cs, k8sClient := setupEnvironmentServer(t)
+	sid, ns := registerDSSession(t, cs)
+
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: ns},
+		Spec:       iafv1alpha1.ApplicationSpec{Git: &iafv1alpha1.GitSource{URL: "https://github.com/org/web.git"}},
+	}
+	if err := k8sClient.Create(context.Background(), app); err != nil {
+		t.Fatal(err)
+	}
+
+	res, _ := callEnvironmentTool(t, cs, "create_environment", map[string]any{"session_id": sid, "name": "web", "environment": "prod"})
+	if !res.IsError || !strings.Contains(res.Content[0].(*gomcp.TextContent).Text, "no deployed image") {
+		t.Errorf("expected a no deployed image error, got %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+}
+
+func TestPromoteApp_TagWarningAndMissingEnvironment(t *testing.T) {
+	cs, k8sClient := setupEnvironmentServer(t)
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	for _, env := range []string{"dev", "prod"} {
+		app := &iafv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      iafk8s.EnvironmentName("api", env),
+				Namespace: ns,
+				Labels:    map[string]string{iafk8s.LabelEnvironmentOf: "api", iafk8s.LabelEnvironment: env},
+			},
+			Spec:   iafv1alpha1.ApplicationSpec{Image: "nginx:1.26"},
+			Status: iafv1alpha1.ApplicationStatus{LatestImage: "nginx:1.27"},
+		}
+		if err := k8sClient.Create(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, out := callEnvironmentTool(t, cs, "promote_app", map[string]any{"session_id": sid, "name": "api", "from": "dev", "to": "prod"})
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	if out["digestPinned"] != false || out["warning"] == nil {
+		t.Errorf("expected a tag warning, got %v", out)
+	}
+
+	res, _ = callEnvironmentTool(t, cs, "promote_app", map[string]any{"session_id": sid, "name": "api", "from": "dev", "to": "staging"})
+	if !res.IsError || !strings.Contains(res.Content[0].(*gomcp.TextContent).Text, "not found") {
+		t.Errorf("expected a missing environment error, got %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+}
@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/export"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type ExportAppInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Name      string `json:"name" jsonschema:"required - application name to export"`
+	Format    string `json:"format,omitempty" jsonschema:"'yaml' (default) for a multi-document manifest, or 'helm' for a Helm chart skeleton returned as a map of file paths to contents"`
+}
+
+// RegisterExportApp registers the export_app MCP tool.
+func RegisterExportApp(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:          "export_app",
+		Category:      CategoryDeploy,
+		ReadOnly:      true,
+		Summary:       "Export an app's Kubernetes objects as YAML or a Helm chart skeleton for GitOps",
+		Preconditions: []string{"the app exists"},
+	}, &gomcp.Tool{
+		Description: "Export the Kubernetes objects running an application — Deployment, Service, Traefik route and middlewares, Certificate, kpack Image, and bound database clusters — as YAML or a Helm chart skeleton for GitOps handoff. Requires session_id from the register tool. Secret contents are never exported; omittedSecrets lists the Secrets the manifests reference so they can be recreated.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input ExportAppInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.Name); err != nil {
+			return nil, nil, err
+		}
+		format := input.Format
+		if format == "" {
+			format = "yaml"
+		}
+		if !slices.Contains(export.Formats, format) {
+			return nil, nil, fmt.Errorf("unsupported format %q — use one of: %s", input.Format, strings.Join(export.Formats, ", "))
+		}
+
+		var app iafv1alpha1.Application
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, &app); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.Name)
+			}
+			return nil, nil, fmt.Errorf("getting application: %w", err)
+		}
+
+		bundle, err := export.Collect(ctx, deps.Client, &app)
+		if err != nil {
+			return nil, nil, fmt.Errorf("exporting application: %w", err)
+		}
+		if len(bundle.Objects) == 0 {
+			return nil, nil, fmt.Errorf("application %q has no deployed resources yet (phase: %s) — export it once it is Running", input.Name, app.Status.Phase)
+		}
+
+		result := map[string]any{
+			"name":           input.Name,
+			"format":         format,
+			"omittedSecrets": bundle.Secrets,
+		}
+		if format == "helm" {
+			files, err := bundle.HelmChart()
+			if err != nil {
+				return nil, nil, err
+			}
+			result["files"] = files
+		} else {
+			manifests, err := bundle.YAML()
+			if err != nil {
+				return nil, nil, err
+			}
+			result["manifests"] = manifests
+		}
+
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
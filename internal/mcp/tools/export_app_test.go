@@ -0,0 +1,138 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func setupExportServer(t *testing.T) (*gomcp.ClientSession, client.Client) {
+	t.Helper()
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		Store:      store,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterExportApp(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mc := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mc.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs, k8sClient
+}
+
+func TestExportApp(t *testing.T) {
+	cs, k8sClient := setupExportServer(t)
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	replicas := int32(1)
+	objs := []client.Object{
+		&iafv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: ns},
+			Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest"},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: ns},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "nginx:latest"}}}},
+			},
+		},
+	}
+	for _, obj := range objs {
+		if err := k8sClient.Create(ctx, obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, format := range []string{"", "helm"} {
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+			Name:      "export_app",
+			Arguments: map[string]any{"session_id": sid, "name": "web", "format": format},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		text := res.Content[0].(*gomcp.TextContent).Text
+		if res.IsError {
+			t.Fatalf("export_app format=%q: %s", format, text)
+		}
+		var out map[string]any
+		if err := json.Unmarshal([]byte(text), &out); err != nil {
+			t.Fatal(err)
+		}
+		if format == "helm" {
+			files, _ := out["files"].(map[string]any)
+			if files["templates/deployment-web.yaml"] == nil {
+				t.Errorf("helm files = %v, want deployment template", files)
+			}
+		} else if manifests, _ := out["manifests"].(string); !strings.Contains(manifests, "kind: Deployment") || strings.Contains(manifests, "namespace:") {
+			t.Errorf("manifests = %q, want namespace-free Deployment", manifests)
+		}
+	}
+
+	tests := []struct {
+		name, app, format, wantErr string
+	}{
+		{name: "unsupported format", app: "web", format: "kustomize", wantErr: "unsupported format"},
+		{name: "missing app", app: "missing", wantErr: "not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+				Name:      "export_app",
+				Arguments: map[string]any{"session_id": sid, "name": tt.app, "format": tt.format},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if text := res.Content[0].(*gomcp.TextContent).Text; !res.IsError || !strings.Contains(text, tt.wantErr) {
+				t.Errorf("got %q, want error containing %q", text, tt.wantErr)
+			}
+		})
+	}
+}
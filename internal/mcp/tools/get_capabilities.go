@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/dlapiduz/iaf/internal/features"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type GetCapabilitiesInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+}
+
+func RegisterGetCapabilities(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "get_capabilities",
+		Category: CategorySession,
+		ReadOnly: true,
+		Summary:  "List the optional platform features that are on, such as TLS, GitHub, logs and managed services",
+		Examples: []string{`{"session_id": "<id>"}`},
+	}, &gomcp.Tool{
+		Description: "List the optional features of this platform — TLS, oauth-proxy authentication, custom DNS, idling, managed services, GitHub, log, trace and dashboard links, uptime checks, cost estimates and namespace credentials — and whether each is enabled, with the tools and options that need it. Requires session_id from the register tool. Check it before relying on a feature: a call that needs a disabled feature fails with code capability_disabled. Also lists the tools this server offers. While the platform is in maintenance, \"maintenance\" describes it: only read-only tools work until it ends, and the others fail with code maintenance.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input GetCapabilitiesInput) (*gomcp.CallToolResult, any, error) {
+		if _, err := deps.ResolveNamespace(input.SessionID); err != nil {
+			return nil, nil, err
+		}
+
+		list := []features.Feature{}
+		if deps.Features != nil {
+			list = deps.Features.List()
+		}
+		tools := []string{}
+		for _, c := range deps.Capabilities.List() {
+			tools = append(tools, c.Name)
+		}
+		result := map[string]any{
+			"features": list,
+			"tools":    tools,
+			"message":  "Features with enabled false are off on this platform: calls that need them fail with code capability_disabled. The settings say what the platform operator would set to turn one on.",
+		}
+		if err := deps.Maintenance.Err(); err != nil {
+			result["maintenance"] = err.Response()
+		}
+
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
@@ -0,0 +1,123 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/features"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetCapabilities(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := &tools.Dependencies{
+		Client:       fake.NewClientBuilder().WithScheme(scheme).Build(),
+		Store:        store,
+		BaseDomain:   "test.example.com",
+		Sessions:     sessions,
+		Capabilities: &tools.Capabilities{},
+		Features:     features.Flags{features.Uptime: true},
+	}
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterGetCapabilities(server, deps)
+	tools.RegisterDeployApp(server, deps)
+	tools.RegisterProvisionService(server, deps)
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	cs, err := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil).Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	sid, _ := registerDSSession(t, cs)
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "get_capabilities",
+		Arguments: map[string]any{"session_id": sid},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %v", res.Content)
+	}
+	var out struct {
+		Features []features.Feature `json:"features"`
+		Tools    []string           `json:"tools"`
+	}
+	if err := json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &out); err != nil {
+		t.Fatal(err)
+	}
+	enabled := map[features.Name]bool{}
+	for _, f := range out.Features {
+		enabled[f.Name] = f.Enabled
+		if f.Settings == "" {
+			t.Errorf("%s: expected the settings that turn it on", f.Name)
+		}
+	}
+	if !enabled[features.Uptime] || enabled[features.OAuthProxy] || len(enabled) != len(out.Features) {
+		t.Errorf("unexpected features %+v", out.Features)
+	}
+	if !slices.Contains(out.Tools, "deploy_app") || !slices.Contains(out.Tools, "get_capabilities") {
+		t.Errorf("expected the registered tools, got %v", out.Tools)
+	}
+
+	// Calls that need a disabled feature fail before anything is created.
+	for _, tc := range []struct {
+		tool    string
+		args    map[string]any
+		feature features.Name
+	}{
+		{"deploy_app", map[string]any{"name": "web", "image": "nginx:1.27", "authentication": "oauth-proxy"}, features.OAuthProxy},
+		{"deploy_app", map[string]any{"name": "web", "image": "nginx:1.27", "idle_timeout": "30m"}, features.Idling},
+		{"provision_service", map[string]any{"name": "db", "type": "postgres", "plan": "micro"}, features.ManagedServices},
+	} {
+		tc.args["session_id"] = sid
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: tc.tool, Arguments: tc.args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "the " + string(tc.feature) + " feature is not enabled"
+		if text := res.Content[0].(*gomcp.TextContent).Text; !res.IsError || !strings.Contains(text, want) {
+			t.Errorf("%s %v: expected %q, got %s", tc.tool, tc.args, want, text)
+		}
+	}
+
+	// An enabled feature is accepted.
+	res, err = cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "deploy_app",
+		Arguments: map[string]any{"session_id": sid, "name": "web", "image": "nginx:1.27", "uptime_check_path": "/healthz"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Errorf("unexpected error: %v", res.Content[0].(*gomcp.TextContent).Text)
+	}
+}
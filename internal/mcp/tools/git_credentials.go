@@ -7,6 +7,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/audit"
 	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
 	"github.com/dlapiduz/iaf/internal/validation"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
@@ -20,8 +22,12 @@ const (
 	maxCredentialsPerSession = 20
 	maxPasswordLen           = 4096
 	maxSSHKeyLen             = 16 * 1024
+	maxKnownHostsLen         = 16 * 1024
 )
 
+// noKnownHostsWarning is returned for an ssh credential without host keys.
+const noKnownHostsWarning = "No known_hosts entries: builds cannot verify the git server's host key and may fail to clone. Pass the server's host keys as known_hosts (the output of ssh-keyscan <host>), or ask the platform operator to trust the server."
+
 // AddGitCredentialInput is the input for the add_git_credential tool.
 type AddGitCredentialInput struct {
 	SessionID    string `json:"session_id"    jsonschema:"required - session ID from the register tool"`
@@ -33,6 +39,19 @@ type AddGitCredentialInput struct {
 	Password string `json:"password,omitempty" jsonschema:"password or token (required for basic-auth, max 4096 bytes)"`
 	// ssh field
 	PrivateKey string `json:"private_key,omitempty" jsonschema:"PEM-encoded SSH private key (required for ssh, max 16 KB)"`
+	KnownHosts string `json:"known_hosts,omitempty" jsonschema:"host keys of the git server in known_hosts format, as printed by ssh-keyscan (ssh only, max 16 KB); needed for servers the platform does not already trust, such as a self-hosted GitLab or Gitea"`
+}
+
+// UpdateGitCredentialInput is the input for the update_git_credential tool.
+type UpdateGitCredentialInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID from the register tool"`
+	Name      string `json:"name"       jsonschema:"required - name of the credential to rotate"`
+	// basic-auth fields
+	Username string `json:"username,omitempty" jsonschema:"new username (basic-auth only; omit to keep the current one)"`
+	Password string `json:"password,omitempty" jsonschema:"new password or token (required for basic-auth, max 4096 bytes)"`
+	// ssh field
+	PrivateKey string `json:"private_key,omitempty" jsonschema:"new PEM-encoded SSH private key (required for ssh, max 16 KB)"`
+	KnownHosts string `json:"known_hosts,omitempty" jsonschema:"new host keys of the git server in known_hosts format (ssh only, max 16 KB); omit to keep the current ones"`
 }
 
 // ListGitCredentialsInput is the input for the list_git_credentials tool.
@@ -48,9 +67,12 @@ type DeleteGitCredentialInput struct {
 
 // RegisterAddGitCredential registers the add_git_credential MCP tool.
 func RegisterAddGitCredential(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "add_git_credential",
-		Description: "Store a git credential (username/password or SSH key) in the session namespace so kpack can clone private repositories. Requires session_id. Credential material is never returned in any tool output. To rotate a credential, delete it and re-create it.",
+	addTool(server, deps, Capability{
+		Name:     "add_git_credential",
+		Category: CategoryCredentials,
+		Summary:  "Store a git credential for private repositories (pass as git_credential to deploy_app)",
+	}, &gomcp.Tool{
+		Description: "Store a git credential (username/password or SSH key) in the session namespace so kpack can clone private repositories. Requires session_id. Credential material is never returned in any tool output. To rotate a credential, use update_git_credential.",
 	}, func(ctx context.Context, req *gomcp.CallToolRequest, input AddGitCredentialInput) (*gomcp.CallToolResult, any, error) {
 		namespace, err := deps.ResolveNamespace(input.SessionID)
 		if err != nil {
@@ -76,24 +98,21 @@ func RegisterAddGitCredential(server *gomcp.Server, deps *Dependencies) {
 			if input.Username == "" {
 				return nil, nil, fmt.Errorf("username is required for basic-auth")
 			}
-			if input.Password == "" {
-				return nil, nil, fmt.Errorf("password is required for basic-auth")
+			if err := validateGitPassword(input.Password); err != nil {
+				return nil, nil, err
 			}
-			if len(input.Password) > maxPasswordLen {
-				return nil, nil, fmt.Errorf("password must be %d bytes or fewer", maxPasswordLen)
+			if input.KnownHosts != "" {
+				return nil, nil, fmt.Errorf("known_hosts is only used by ssh credentials")
 			}
 		case "ssh":
 			if err := validation.ValidateSSHGitServerURL(input.GitServerURL); err != nil {
 				return nil, nil, err
 			}
-			if input.PrivateKey == "" {
-				return nil, nil, fmt.Errorf("private_key is required for ssh")
-			}
-			if len(input.PrivateKey) > maxSSHKeyLen {
-				return nil, nil, fmt.Errorf("private_key must be %d bytes or fewer", maxSSHKeyLen)
+			if err := validateGitPrivateKey(input.PrivateKey); err != nil {
+				return nil, nil, err
 			}
-			if !strings.HasPrefix(input.PrivateKey, "-----BEGIN ") {
-				return nil, nil, fmt.Errorf("private_key must be a PEM-encoded key (must start with '-----BEGIN ...')")
+			if err := validateKnownHosts(input.KnownHosts); err != nil {
+				return nil, nil, err
 			}
 		}
 
@@ -105,18 +124,35 @@ func RegisterAddGitCredential(server *gomcp.Server, deps *Dependencies) {
 		); err != nil {
 			return nil, nil, fmt.Errorf("listing git credentials: %w", err)
 		}
-		if len(secretList.Items) >= maxCredentialsPerSession {
-			return nil, nil, fmt.Errorf("credential limit reached: a session may have at most %d git credentials; delete an existing one before adding a new one", maxCredentialsPerSession)
+		// Shared credentials copied in by the platform do not count.
+		own := 0
+		for _, s := range secretList.Items {
+			if s.Labels[iafk8s.LabelManagedCredential] != "true" {
+				own++
+			}
+		}
+		if own >= maxCredentialsPerSession {
+			return nil, nil, apierror.Quota(apierror.CodeQuotaExceeded, "credential limit reached: a session may have at most %d git credentials; delete an existing one before adding a new one", maxCredentialsPerSession)
+		}
+
+		// An ssh credential trusts the platform's host keys and its own.
+		var knownHosts string
+		if input.Type == "ssh" {
+			platform, err := deps.KnownHosts.Load(ctx, deps.Client)
+			if err != nil {
+				return nil, nil, err
+			}
+			knownHosts = iafk8s.MergeKnownHosts(platform, input.KnownHosts)
 		}
 
 		// Build and create the Secret.
 		secret := iafk8s.BuildGitCredentialSecret(
 			namespace, input.Name, input.Type, input.GitServerURL,
-			input.Username, input.Password, input.PrivateKey,
+			input.Username, input.Password, input.PrivateKey, knownHosts,
 		)
 		if err := deps.Client.Create(ctx, secret); err != nil {
 			if apierrors.IsAlreadyExists(err) {
-				return nil, nil, fmt.Errorf("credential %q already exists; delete it first to replace it", input.Name)
+				return nil, nil, fmt.Errorf("credential %q already exists; use update_git_credential to rotate it", input.Name)
 			}
 			return nil, nil, fmt.Errorf("creating credential: %w", err)
 		}
@@ -127,6 +163,13 @@ func RegisterAddGitCredential(server *gomcp.Server, deps *Dependencies) {
 			_ = deps.Client.Delete(ctx, secret)
 			return nil, nil, fmt.Errorf("registering credential with kpack service account: %w", err)
 		}
+		deps.SecurityAudit.Record(audit.SecurityEvent{
+			Action:    audit.ActionCredentialCreated,
+			Actor:     input.SessionID,
+			Namespace: namespace,
+			Kind:      "git_credential",
+			Name:      input.Name,
+		})
 
 		result := map[string]any{
 			"name":           input.Name,
@@ -134,6 +177,118 @@ func RegisterAddGitCredential(server *gomcp.Server, deps *Dependencies) {
 			"git_server_url": input.GitServerURL,
 			"created":        true,
 		}
+		if input.Type == "ssh" && knownHosts == "" {
+			result["warning"] = noKnownHostsWarning
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// RegisterUpdateGitCredential registers the update_git_credential MCP tool.
+func RegisterUpdateGitCredential(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "update_git_credential",
+		Category: CategoryCredentials,
+		Summary:  "Rotate the material of a git credential in place",
+	}, &gomcp.Tool{
+		Description: "Replace the password, token or SSH key of a git credential without deleting it, so builds never run without it. The credential keeps its name, type and git server URL; to change those, delete it and add a new one. Requires session_id. Credential material is never returned in any tool output.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input UpdateGitCredentialInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if input.Name == "" {
+			return nil, nil, fmt.Errorf("name is required")
+		}
+
+		// Fetch the Secret and verify it is a git credential (label guard).
+		secret := &corev1.Secret{}
+		if err := deps.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: input.Name}, secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeNotFound, "credential %q not found", input.Name)
+			}
+			return nil, nil, fmt.Errorf("getting credential: %w", err)
+		}
+		if secret.Labels[iafk8s.LabelCredentialType] != "git" {
+			return nil, nil, fmt.Errorf("secret %q is not a git credential managed by IAF", input.Name)
+		}
+		if secret.Labels[iafk8s.LabelManagedCredential] == "true" {
+			return nil, nil, errManagedGitCredential(input.Name)
+		}
+
+		// The new material must match the credential's type, which a
+		// Secret cannot change.
+		credType := credTypeFromSecretType(secret.Type)
+		switch credType {
+		case "basic-auth":
+			if input.PrivateKey != "" || input.KnownHosts != "" {
+				return nil, nil, fmt.Errorf("credential %q is basic-auth; pass password, not private_key or known_hosts (delete it and add an ssh credential to switch)", input.Name)
+			}
+			if err := validateGitPassword(input.Password); err != nil {
+				return nil, nil, err
+			}
+		case "ssh":
+			if input.Username != "" || input.Password != "" {
+				return nil, nil, fmt.Errorf("credential %q is ssh; pass private_key, not username or password (delete it and add a basic-auth credential to switch)", input.Name)
+			}
+			if err := validateGitPrivateKey(input.PrivateKey); err != nil {
+				return nil, nil, err
+			}
+			if err := validateKnownHosts(input.KnownHosts); err != nil {
+				return nil, nil, err
+			}
+		default:
+			return nil, nil, fmt.Errorf("credential %q has unsupported type %q", input.Name, secret.Type)
+		}
+
+		// The host keys are refreshed from the platform's, keeping the
+		// credential's own unless new ones are given.
+		var knownHosts string
+		if credType == "ssh" {
+			platform, err := deps.KnownHosts.Load(ctx, deps.Client)
+			if err != nil {
+				return nil, nil, err
+			}
+			own := input.KnownHosts
+			if own == "" {
+				own = string(secret.Data[iafk8s.KnownHostsKey])
+			}
+			knownHosts = iafk8s.MergeKnownHosts(platform, own)
+		}
+
+		// One update, guarded by the resourceVersion just read, swaps the
+		// material; a concurrent change fails it with a retryable conflict.
+		now := time.Now()
+		iafk8s.RotateGitCredentialSecret(secret, input.Username, input.Password, input.PrivateKey, knownHosts, now)
+		if err := deps.Client.Update(ctx, secret); err != nil {
+			return nil, nil, fmt.Errorf("updating credential: %w", err)
+		}
+		// The service account already references the Secret by name;
+		// restore the reference in case it was lost.
+		if err := iafk8s.AddSecretToKpackSA(ctx, deps.Client, namespace, input.Name); err != nil {
+			return nil, nil, fmt.Errorf("registering credential with kpack service account: %w", err)
+		}
+		deps.SecurityAudit.Record(audit.SecurityEvent{
+			Action:    audit.ActionSecretRotated,
+			Actor:     input.SessionID,
+			Namespace: namespace,
+			Kind:      "git_credential",
+			Name:      input.Name,
+		})
+
+		result := map[string]any{
+			"name":           input.Name,
+			"type":           credType,
+			"git_server_url": secret.Annotations[iafk8s.AnnotationGitServer],
+			"rotated_at":     secret.Annotations[iafk8s.AnnotationRotatedAt],
+			"rotated":        true,
+		}
+		if credType == "ssh" && knownHosts == "" {
+			result["warning"] = noKnownHostsWarning
+		}
 		text, _ := json.MarshalIndent(result, "", "  ")
 		return &gomcp.CallToolResult{
 			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
@@ -143,9 +298,13 @@ func RegisterAddGitCredential(server *gomcp.Server, deps *Dependencies) {
 
 // RegisterListGitCredentials registers the list_git_credentials MCP tool.
 func RegisterListGitCredentials(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "list_git_credentials",
-		Description: "List all git credentials stored in the current session. Returns name, type, and server URL — never credential material.",
+	addTool(server, deps, Capability{
+		Name:     "list_git_credentials",
+		Category: CategoryCredentials,
+		ReadOnly: true,
+		Summary:  "List stored git credentials (no secrets returned)",
+	}, &gomcp.Tool{
+		Description: "List all git credentials stored in the current session. Returns name, type, server URL, when the material was last rotated, and whether the credential is managed — never credential material. Managed credentials are shared by the platform operator with every session: use them with deploy_app like your own, but they cannot be updated or deleted.",
 	}, func(ctx context.Context, req *gomcp.CallToolRequest, input ListGitCredentialsInput) (*gomcp.CallToolResult, any, error) {
 		namespace, err := deps.ResolveNamespace(input.SessionID)
 		if err != nil {
@@ -165,6 +324,8 @@ func RegisterListGitCredentials(server *gomcp.Server, deps *Dependencies) {
 			Type         string `json:"type"`
 			GitServerURL string `json:"git_server_url"`
 			CreatedAt    string `json:"created_at"`
+			RotatedAt    string `json:"rotated_at,omitempty"`
+			Managed      bool   `json:"managed"`
 		}
 		creds := make([]credInfo, 0, len(secretList.Items))
 		for _, s := range secretList.Items {
@@ -179,6 +340,8 @@ func RegisterListGitCredentials(server *gomcp.Server, deps *Dependencies) {
 				Type:         credType,
 				GitServerURL: serverURL,
 				CreatedAt:    createdAt,
+				RotatedAt:    s.Annotations[iafk8s.AnnotationRotatedAt],
+				Managed:      s.Labels[iafk8s.LabelManagedCredential] == "true",
 			})
 		}
 
@@ -195,8 +358,11 @@ func RegisterListGitCredentials(server *gomcp.Server, deps *Dependencies) {
 
 // RegisterDeleteGitCredential registers the delete_git_credential MCP tool.
 func RegisterDeleteGitCredential(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "delete_git_credential",
+	addTool(server, deps, Capability{
+		Name:     "delete_git_credential",
+		Category: CategoryCredentials,
+		Summary:  "Remove a git credential",
+	}, &gomcp.Tool{
 		Description: "Delete a git credential from the current session. Also removes it from the kpack service account so it will no longer be used for builds.",
 	}, func(ctx context.Context, req *gomcp.CallToolRequest, input DeleteGitCredentialInput) (*gomcp.CallToolResult, any, error) {
 		namespace, err := deps.ResolveNamespace(input.SessionID)
@@ -211,13 +377,16 @@ func RegisterDeleteGitCredential(server *gomcp.Server, deps *Dependencies) {
 		secret := &corev1.Secret{}
 		if err := deps.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: input.Name}, secret); err != nil {
 			if apierrors.IsNotFound(err) {
-				return nil, nil, fmt.Errorf("credential %q not found", input.Name)
+				return nil, nil, apierror.NotFound(apierror.CodeNotFound, "credential %q not found", input.Name)
 			}
 			return nil, nil, fmt.Errorf("getting credential: %w", err)
 		}
 		if secret.Labels[iafk8s.LabelCredentialType] != "git" {
 			return nil, nil, fmt.Errorf("secret %q is not a git credential managed by IAF", input.Name)
 		}
+		if secret.Labels[iafk8s.LabelManagedCredential] == "true" {
+			return nil, nil, errManagedGitCredential(input.Name)
+		}
 
 		// Remove from kpack SA before deleting the Secret.
 		if err := iafk8s.RemoveSecretFromKpackSA(ctx, deps.Client, namespace, input.Name); err != nil {
@@ -228,10 +397,17 @@ func RegisterDeleteGitCredential(server *gomcp.Server, deps *Dependencies) {
 			ObjectMeta: metav1.ObjectMeta{Name: input.Name, Namespace: namespace},
 		}); err != nil {
 			if apierrors.IsNotFound(err) {
-				return nil, nil, fmt.Errorf("credential %q not found", input.Name)
+				return nil, nil, apierror.NotFound(apierror.CodeNotFound, "credential %q not found", input.Name)
 			}
 			return nil, nil, fmt.Errorf("deleting credential: %w", err)
 		}
+		deps.SecurityAudit.Record(audit.SecurityEvent{
+			Action:    audit.ActionCredentialDeleted,
+			Actor:     input.SessionID,
+			Namespace: namespace,
+			Kind:      "git_credential",
+			Name:      input.Name,
+		})
 
 		result := map[string]any{
 			"name":    input.Name,
@@ -244,6 +420,46 @@ func RegisterDeleteGitCredential(server *gomcp.Server, deps *Dependencies) {
 	})
 }
 
+// errManagedGitCredential is the error for changing the shared credential
+// name, which the platform operator manages.
+func errManagedGitCredential(name string) error {
+	return apierror.Validation(apierror.CodeForbidden, "credential %q is shared by the platform operator and cannot be changed or deleted", name).
+		WithHint("Add a credential of your own under another name to use different material.")
+}
+
+// validateGitPassword checks the password or token of a basic-auth credential.
+func validateGitPassword(password string) error {
+	if password == "" {
+		return fmt.Errorf("password is required for basic-auth")
+	}
+	if len(password) > maxPasswordLen {
+		return fmt.Errorf("password must be %d bytes or fewer", maxPasswordLen)
+	}
+	return nil
+}
+
+// validateGitPrivateKey checks the private key of an ssh credential.
+func validateGitPrivateKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("private_key is required for ssh")
+	}
+	if len(key) > maxSSHKeyLen {
+		return fmt.Errorf("private_key must be %d bytes or fewer", maxSSHKeyLen)
+	}
+	if !strings.HasPrefix(key, "-----BEGIN ") {
+		return fmt.Errorf("private_key must be a PEM-encoded key (must start with '-----BEGIN ...')")
+	}
+	return nil
+}
+
+// validateKnownHosts checks the optional known_hosts of an ssh credential.
+func validateKnownHosts(knownHosts string) error {
+	if len(knownHosts) > maxKnownHostsLen {
+		return fmt.Errorf("known_hosts must be %d bytes or fewer", maxKnownHostsLen)
+	}
+	return validation.ValidateKnownHosts(knownHosts)
+}
+
 // credTypeFromSecretType converts a Kubernetes secret type to the IAF credential type string.
 func credTypeFromSecretType(t corev1.SecretType) string {
 	switch t {
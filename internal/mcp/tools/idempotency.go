@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	"github.com/dlapiduz/iaf/internal/idempotency"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ReplayedMetaKey is set to true in the _meta of a tool result replayed for
+// an idempotency key.
+const ReplayedMetaKey = "iaf.io/idempotent-replay"
+
+// idempotentInput is the input of a tool accepting an idempotency_key.
+type idempotentInput interface {
+	idempotencyKey() (sessionID, key string)
+}
+
+// idempotent wraps the handler of a mutating tool so that a call carrying an
+// idempotency key runs once per session: repeating it with the same input
+// returns the first successful result. Failed calls are not remembered, so
+// a retry runs them again.
+func idempotent[In idempotentInput](deps *Dependencies, tool string, h gomcp.ToolHandlerFor[In, any]) gomcp.ToolHandlerFor[In, any] {
+	return func(ctx context.Context, req *gomcp.CallToolRequest, input In) (*gomcp.CallToolResult, any, error) {
+		sessionID, key := input.idempotencyKey()
+		if key == "" || deps.Idempotency == nil {
+			return h(ctx, req, input)
+		}
+		if err := validation.ValidateIdempotencyKey(key); err != nil {
+			return nil, nil, err
+		}
+		data, err := json.Marshal(input)
+		if err != nil {
+			return nil, nil, fmt.Errorf("encoding input: %w", err)
+		}
+
+		var out any
+		var callErr error
+		res, replayed, err := deps.Idempotency.Do(sessionID, key, idempotency.Fingerprint([]byte(tool), data), func() (*gomcp.CallToolResult, bool) {
+			var res *gomcp.CallToolResult
+			res, out, callErr = h(ctx, req, input)
+			return res, callErr == nil && res != nil && !res.IsError
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if replayed {
+			replay := *res
+			replay.Meta = maps.Clone(res.Meta)
+			if replay.Meta == nil {
+				replay.Meta = gomcp.Meta{}
+			}
+			replay.Meta[ReplayedMetaKey] = true
+			return &replay, nil, nil
+		}
+		return res, out, callErr
+	}
+}
@@ -6,19 +6,24 @@ import (
 	"fmt"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type ListAppsInput struct {
 	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
-	Status    string `json:"status,omitempty" jsonschema:"filter by status: Pending, Building, Deploying, Running, or Failed"`
+	Status    string `json:"status,omitempty" jsonschema:"filter by status: Pending, Building, Deploying, Running, Suspended, Sleeping, or Failed"`
 }
 
 func RegisterListApps(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "list_apps",
-		Description: "List all applications in your session's workspace with their current status, source type, and URLs. Requires session_id from the register tool. Optionally filter by status (Pending, Building, Deploying, Running, Failed).",
+	addTool(server, deps, Capability{
+		Name:     "list_apps",
+		Category: CategoryObserve,
+		ReadOnly: true,
+		Summary:  "List your apps with their status, source and URL",
+	}, &gomcp.Tool{
+		Description: "List all applications in your session's workspace with their current status, source type, and URLs. Requires session_id from the register tool. Optionally filter by status (Pending, Building, Deploying, Running, Suspended, Sleeping, Failed).",
 	}, func(ctx context.Context, req *gomcp.CallToolRequest, input ListAppsInput) (*gomcp.CallToolResult, any, error) {
 		namespace, err := deps.ResolveNamespace(input.SessionID)
 		if err != nil {
@@ -38,6 +43,7 @@ func RegisterListApps(server *gomcp.Server, deps *Dependencies) {
 
 			entry := map[string]any{
 				"name":              app.Name,
+				"version":           iafk8s.AppVersion(&app),
 				"phase":             string(app.Status.Phase),
 				"url":               app.Status.URL,
 				"availableReplicas": app.Status.AvailableReplicas,
@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
 	k8shelper "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/logparse"
 	"github.com/dlapiduz/iaf/internal/validation"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
 	corev1 "k8s.io/api/core/v1"
@@ -20,17 +23,62 @@ import (
 type AppLogsInput struct {
 	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
 	Name      string `json:"name" jsonschema:"required - application name to get logs for"`
-	Lines     int64  `json:"lines,omitempty" jsonschema:"number of log lines to return (default: 100)"`
+	TailLines int64  `json:"tail_lines,omitempty" jsonschema:"number of trailing log lines to read before filtering (default: 100, max: 5000)"`
+	Lines     int64  `json:"lines,omitempty" jsonschema:"deprecated alias for tail_lines"`
 	BuildLogs bool   `json:"build_logs,omitempty" jsonschema:"set to true to get build logs instead of application runtime logs"`
+	Migration bool   `json:"migration_logs,omitempty" jsonschema:"set to true to get the output of the latest run_migration Job instead of application runtime logs"`
 	PodName   string `json:"pod_name,omitempty" jsonschema:"optional - specific pod name to get logs from; if omitted, uses most recently started pod"`
+	Container string `json:"container,omitempty" jsonschema:"optional - container to read logs from; defaults to the app container for runtime logs; for build logs, name a build step such as build or detect"`
+	Level     string `json:"level,omitempty" jsonschema:"optional - minimum severity to return: trace, debug, info, warn, error, or fatal; lines without a recognizable level are dropped"`
+	Grep      string `json:"grep,omitempty" jsonschema:"optional - only return lines containing this text (case-insensitive)"`
+	Regex     bool   `json:"regex,omitempty" jsonschema:"treat grep as an RE2 regular expression instead of plain text"`
 }
 
+const (
+	defaultLogLines = 100
+	maxLogLines     = 5000
+)
+
+// tailLines returns the number of lines to read, honoring the deprecated
+// lines field.
+func (in AppLogsInput) tailLines() int64 {
+	n := in.TailLines
+	if n <= 0 {
+		n = in.Lines
+	}
+	if n <= 0 {
+		return defaultLogLines
+	}
+	return min(n, maxLogLines)
+}
+
+// logFilter validates the level and grep parameters.
+func (in AppLogsInput) logFilter() (logparse.Filter, error) {
+	if in.BuildLogs && in.Migration {
+		return logparse.Filter{}, fmt.Errorf("set either build_logs or migration_logs, not both")
+	}
+	if in.BuildLogs && in.Level != "" {
+		return logparse.Filter{}, fmt.Errorf("level filtering applies to runtime logs only; use grep to search build logs")
+	}
+	return logparse.NewFilter(in.Level, in.Grep, in.Regex)
+}
+
+// appLogsCapability describes app_logs for both of its registrations.
+var appLogsCapability = Capability{
+	Name:     "app_logs",
+	Category: CategoryObserve,
+	ReadOnly: true,
+	Summary:  "Application, build or migration logs",
+	Examples: []string{`{"session_id": "<id>", "name": "web", "build_logs": true}`},
+}
+
+const appLogsDescription = "Get logs from an application's running pods, or build logs if build_logs=true. Requires session_id from the register tool and the application name. Use build_logs=true to debug build failures, or migration_logs=true for the output of run_migration. Reads the last tail_lines lines (default 100). Runtime logs are parsed as JSON Lines per the platform logging standard and returned as structured entries (time, level, msg, fields); non-JSON lines are returned as raw. Filter with level (minimum severity, e.g. level=error), grep (case-insensitive text, or RE2 with regex=true), and container. Use pod_name to fetch logs from a specific pod; omit to get logs from the most recently started pod."
+
 // RegisterAppLogs registers the app_logs tool. It needs both the controller-runtime
 // client (for listing pods) and the kubernetes clientset (for reading logs).
 func RegisterAppLogs(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "app_logs",
-		Description: "Get logs from an application's running pods, or build logs if build_logs=true. Requires session_id from the register tool and the application name. Use build_logs=true to debug build failures. Default: last 100 lines. Use pod_name to fetch logs from a specific pod; omit to get logs from the most recently started pod.",
+	addTool(server, deps, appLogsCapability, &gomcp.Tool{
+		Description: appLogsDescription,
 	}, func(ctx context.Context, req *gomcp.CallToolRequest, input AppLogsInput) (*gomcp.CallToolResult, any, error) {
 		namespace, err := deps.ResolveNamespace(input.SessionID)
 		if err != nil {
@@ -40,16 +88,15 @@ func RegisterAppLogs(server *gomcp.Server, deps *Dependencies) {
 			return nil, nil, err
 		}
 
-		lines := input.Lines
-		if lines <= 0 {
-			lines = 100
+		if _, err := input.logFilter(); err != nil {
+			return nil, nil, err
 		}
 
 		// Verify application exists
 		var app iafv1alpha1.Application
 		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, &app); err != nil {
 			if apierrors.IsNotFound(err) {
-				return nil, nil, fmt.Errorf("application %q not found", input.Name)
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.Name)
 			}
 			return nil, nil, fmt.Errorf("getting application: %w", err)
 		}
@@ -77,9 +124,8 @@ func RegisterAppLogs(server *gomcp.Server, deps *Dependencies) {
 
 // RegisterAppLogsWithClientset registers the app_logs tool with full log streaming support.
 func RegisterAppLogsWithClientset(server *gomcp.Server, deps *Dependencies, clientset kubernetes.Interface) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "app_logs",
-		Description: "Get logs from an application's running pods, or build logs if build_logs=true. Requires session_id from the register tool and the application name. Use build_logs=true to debug build failures. Default: last 100 lines. Use pod_name to fetch logs from a specific pod; omit to get logs from the most recently started pod.",
+	addTool(server, deps, appLogsCapability, &gomcp.Tool{
+		Description: appLogsDescription,
 	}, func(ctx context.Context, req *gomcp.CallToolRequest, input AppLogsInput) (*gomcp.CallToolResult, any, error) {
 		namespace, err := deps.ResolveNamespace(input.SessionID)
 		if err != nil {
@@ -89,15 +135,16 @@ func RegisterAppLogsWithClientset(server *gomcp.Server, deps *Dependencies, clie
 			return nil, nil, err
 		}
 
-		lines := input.Lines
-		if lines <= 0 {
-			lines = 100
+		lines := input.tailLines()
+		filter, err := input.logFilter()
+		if err != nil {
+			return nil, nil, err
 		}
 
 		var app iafv1alpha1.Application
 		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, &app); err != nil {
 			if apierrors.IsNotFound(err) {
-				return nil, nil, fmt.Errorf("application %q not found", input.Name)
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.Name)
 			}
 			return nil, nil, fmt.Errorf("getting application: %w", err)
 		}
@@ -105,8 +152,11 @@ func RegisterAppLogsWithClientset(server *gomcp.Server, deps *Dependencies, clie
 		var labelKey string
 		var container string
 		if input.BuildLogs {
-			labelKey = "image.kpack.io/image"
+			labelKey = k8shelper.LabelKpackImage
 			container = ""
+		} else if input.Migration {
+			labelKey = k8shelper.LabelMigrationOf
+			container = "migrate"
 		} else {
 			labelKey = "iaf.io/application"
 			container = "app"
@@ -145,6 +195,14 @@ func RegisterAppLogsWithClientset(server *gomcp.Server, deps *Dependencies, clie
 			pod = k8shelper.SelectMostRecentPod(podList.Items)
 		}
 
+		if input.Container != "" {
+			if !podHasContainer(pod, input.Container) {
+				return nil, nil, apierror.NotFound(apierror.CodeNotFound, "container %q not found in pod %q; available containers: %s",
+					input.Container, pod.Name, strings.Join(podContainerNames(pod), ", "))
+			}
+			container = input.Container
+		}
+
 		opts := &corev1.PodLogOptions{
 			TailLines: &lines,
 		}
@@ -165,11 +223,30 @@ func RegisterAppLogsWithClientset(server *gomcp.Server, deps *Dependencies, clie
 
 		result := map[string]any{
 			"name":          input.Name,
-			"logs":          string(data),
 			"podName":       pod.Name,
 			"availablePods": availablePods,
 			"phase":         string(app.Status.Phase),
 		}
+		if container != "" {
+			result["container"] = container
+		}
+		if input.BuildLogs {
+			// Build output is plain text from the buildpack lifecycle, so
+			// it is only grep-filtered.
+			var kept []string
+			for _, line := range logLines(string(data)) {
+				if filter.Match(logparse.Entry{}, line) {
+					kept = append(kept, line)
+				}
+			}
+			result["logs"] = strings.Join(kept, "\n")
+		} else {
+			entries, levelCounts, scanned := parseLogs(string(data), filter)
+			result["entries"] = entries
+			result["scanned"] = scanned
+			result["matched"] = len(entries)
+			result["levelCounts"] = levelCounts
+		}
 
 		text, _ := json.MarshalIndent(result, "", "  ")
 		return &gomcp.CallToolResult{
@@ -177,3 +254,54 @@ func RegisterAppLogsWithClientset(server *gomcp.Server, deps *Dependencies, clie
 		}, nil, nil
 	})
 }
+
+// parseLogs parses each line and returns the entries passing filter, the
+// number of lines at each level across all lines, and the number of lines
+// scanned.
+func parseLogs(data string, filter logparse.Filter) ([]logparse.Entry, map[string]int, int) {
+	entries := []logparse.Entry{}
+	levelCounts := map[string]int{}
+	lines := logLines(data)
+	for _, line := range lines {
+		entry := logparse.Parse(line)
+		if entry.Level != "" {
+			levelCounts[entry.Level]++
+		}
+		if filter.Match(entry, line) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, levelCounts, len(lines)
+}
+
+// logLines splits log output into non-empty lines.
+func logLines(data string) []string {
+	var lines []string
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func podContainerNames(pod *corev1.Pod) []string {
+	var names []string
+	for _, c := range pod.Spec.InitContainers {
+		names = append(names, c.Name)
+	}
+	for _, c := range pod.Spec.Containers {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func podHasContainer(pod *corev1.Pod, name string) bool {
+	for _, n := range podContainerNames(pod) {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
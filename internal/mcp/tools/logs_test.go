@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"log/slog"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -261,3 +262,78 @@ func TestAppLogs_InvalidSession(t *testing.T) {
 		t.Error("expected error for invalid session_id")
 	}
 }
+
+func TestAppLogs_StructuredEntries(t *testing.T) {
+	setup := setupLogsToolServer(t)
+	ctx := context.Background()
+	sid, ns := registerLogsSession(t, setup.cs, "agent")
+
+	pod := makeTestPod("myapp-pod", ns, "myapp", time.Now())
+	pod.Spec.Containers = []corev1.Container{{Name: "app"}, {Name: "sidecar"}}
+	migration := makeTestPod("myapp-migrate-1-abcde", ns, "", time.Now())
+	migration.Labels = map[string]string{"iaf.io/migration-of": "myapp"}
+	migration.Spec.Containers = []corev1.Container{{Name: "migrate"}}
+	for _, obj := range []ctrlclient.Object{makeTestApp("myapp", ns), pod, migration} {
+		if err := setup.k8sClient.Create(ctx, obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The fake clientset always returns the plain-text line "fake logs".
+	tests := []struct {
+		name        string
+		args        map[string]any
+		wantMatched float64
+		wantErr     string
+	}{
+		{name: "unfiltered", args: map[string]any{}, wantMatched: 1},
+		{name: "grep hit", args: map[string]any{"grep": "FAKE"}, wantMatched: 1},
+		{name: "grep miss", args: map[string]any{"grep": "timeout"}, wantMatched: 0},
+		{name: "regex", args: map[string]any{"grep": "^fake l.gs$", "regex": true}, wantMatched: 1},
+		{name: "unleveled lines dropped by level", args: map[string]any{"level": "error"}, wantMatched: 0},
+		{name: "named container", args: map[string]any{"container": "sidecar"}, wantMatched: 1},
+		{name: "unknown container", args: map[string]any{"container": "db"}, wantErr: "available containers: app, sidecar"},
+		{name: "invalid level", args: map[string]any{"level": "loud"}, wantErr: "invalid level"},
+		{name: "invalid regex", args: map[string]any{"grep": "(", "regex": true}, wantErr: "invalid grep regular expression"},
+		{name: "level on build logs", args: map[string]any{"level": "error", "build_logs": true}, wantErr: "runtime logs only"},
+		{name: "migration logs", args: map[string]any{"migration_logs": true}, wantMatched: 1},
+		{name: "migration pod by name", args: map[string]any{"migration_logs": true, "pod_name": "myapp-migrate-1-abcde"}, wantMatched: 1},
+		{name: "migration pod as app pod", args: map[string]any{"pod_name": "myapp-migrate-1-abcde"}, wantErr: "myapp-migrate-1-abcde"},
+		{name: "build and migration logs", args: map[string]any{"build_logs": true, "migration_logs": true}, wantErr: "not both"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := map[string]any{"session_id": sid, "name": "myapp"}
+			for k, v := range tt.args {
+				args[k] = v
+			}
+			res, err := setup.cs.CallTool(ctx, &gomcp.CallToolParams{Name: "app_logs", Arguments: args})
+			if err != nil {
+				t.Fatal(err)
+			}
+			text := res.Content[0].(*gomcp.TextContent).Text
+			if tt.wantErr != "" {
+				if !res.IsError || !strings.Contains(text, tt.wantErr) {
+					t.Errorf("got %q (isError=%v), want error containing %q", text, res.IsError, tt.wantErr)
+				}
+				return
+			}
+			if res.IsError {
+				t.Fatalf("unexpected error: %s", text)
+			}
+			var out map[string]any
+			if err := json.Unmarshal([]byte(text), &out); err != nil {
+				t.Fatal(err)
+			}
+			if out["matched"] != tt.wantMatched || out["scanned"] != float64(1) {
+				t.Errorf("matched=%v scanned=%v, want %v and 1", out["matched"], out["scanned"], tt.wantMatched)
+			}
+			entries, _ := out["entries"].([]any)
+			if len(entries) == 1 {
+				if raw := entries[0].(map[string]any)["raw"]; raw != "fake logs" {
+					t.Errorf("entry raw = %v, want %q", raw, "fake logs")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// RunMigrationInput is the input for the run_migration tool.
+type RunMigrationInput struct {
+	SessionID string   `json:"session_id" jsonschema:"required - session ID from the register tool"`
+	AppName   string   `json:"app_name" jsonschema:"required - name of the application whose image and bound services the migration uses"`
+	Command   []string `json:"command,omitempty" jsonschema:"command to run, one argument per item (e.g. ['npm', 'run', 'migrate'] or ['sh', '-c', 'alembic upgrade head']). Default: the app's release_command"`
+}
+
+// MigrationStatusInput is the input for the migration_status tool.
+type MigrationStatusInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID from the register tool"`
+	AppName   string `json:"app_name" jsonschema:"required - name of the application"`
+}
+
+// RegisterRunMigration registers the run_migration MCP tool.
+func RegisterRunMigration(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:          "run_migration",
+		Category:      CategoryData,
+		Summary:       "Run a migration as a Job with the app's image and bound services; poll migration_status every 10s",
+		Preconditions: []string{"the app has a deployed image"},
+		Examples:      []string{`{"session_id": "<id>", "app_name": "web", "command": "npm run migrate"}`},
+	}, &gomcp.Tool{
+		Description: "Run a database migration or other one-off release task as a Job, with the application's image, environment and bound services. Runs command, or the app's release_command when omitted. Use this instead of migrating inside request handlers or at app startup. The app must be deployed and bound to a service or data source; one migration runs at a time per app and is never retried. Returns immediately — poll migration_status every 10 seconds. Requires session_id.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input RunMigrationInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.AppName); err != nil {
+			return nil, nil, fmt.Errorf("invalid app name: %w", err)
+		}
+
+		var app iafv1alpha1.Application
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.AppName, Namespace: namespace}, &app); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.AppName)
+			}
+			return nil, nil, fmt.Errorf("getting application: %w", err)
+		}
+		command := input.Command
+		if len(command) == 0 {
+			command = app.Spec.ReleaseCommand
+		}
+		if len(command) == 0 {
+			return nil, nil, fmt.Errorf("application %q has no release_command; pass command, e.g. ['npm', 'run', 'migrate']", input.AppName)
+		}
+		if err := validation.ValidateCommand(command); err != nil {
+			return nil, nil, err
+		}
+		if len(app.Spec.BoundManagedServices) == 0 && len(app.Spec.AttachedDataSources) == 0 {
+			return nil, nil, fmt.Errorf("application %q has no bound service or data source to migrate; bind one with bind_service first", input.AppName)
+		}
+
+		// The Deployment carries the image and environment the app runs with.
+		var dep appsv1.Deployment
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.AppName, Namespace: namespace}, &dep); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, fmt.Errorf("application %q is not deployed yet; poll app_status until it is Running, then run the migration", input.AppName)
+			}
+			return nil, nil, fmt.Errorf("getting deployment: %w", err)
+		}
+		if !metav1.IsControlledBy(&dep, &app) {
+			return nil, nil, fmt.Errorf("deployment %q is not managed by application %q", dep.Name, input.AppName)
+		}
+
+		history, runs, err := loadMigrationHistory(ctx, deps, &app)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i := slices.IndexFunc(runs, func(r iafk8s.MigrationRun) bool { return r.Status == iafk8s.MigrationRunning }); i >= 0 {
+			return nil, nil, apierror.Conflict(apierror.CodeConflict, "migration run %d of %q is still running", runs[i].Run, input.AppName).
+				WithHint("poll migration_status until it finishes, then retry")
+		}
+
+		n := 1
+		if len(runs) > 0 {
+			n = runs[len(runs)-1].Run + 1
+		}
+		job, err := iafk8s.BuildMigrationJob(&app, &dep, n, command)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := deps.Client.Create(ctx, job); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				return nil, nil, fmt.Errorf("job %q already exists; delete it or retry", job.Name)
+			}
+			return nil, nil, fmt.Errorf("creating migration job: %w", err)
+		}
+
+		run := iafk8s.MigrationRun{
+			Run:       n,
+			Job:       job.Name,
+			Command:   command,
+			Status:    iafk8s.MigrationRunning,
+			StartedAt: time.Now().UTC().Truncate(time.Second),
+		}
+		runs = append(runs, run)
+		if len(runs) > iafk8s.MaxMigrationHistory {
+			runs = runs[len(runs)-iafk8s.MaxMigrationHistory:]
+		}
+		if err := saveMigrationHistory(ctx, deps, history, runs); err != nil {
+			return nil, nil, err
+		}
+
+		result := map[string]any{
+			"app":     input.AppName,
+			"run":     run.Run,
+			"job":     run.Job,
+			"command": run.Command,
+			"status":  run.Status,
+			"message": fmt.Sprintf("Migration run %d started. Poll migration_status every 10 seconds; read its output with app_logs migration_logs=true.", n),
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// RegisterMigrationStatus registers the migration_status MCP tool.
+func RegisterMigrationStatus(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "migration_status",
+		Category: CategoryData,
+		ReadOnly: true,
+		Summary:  "List an app's migration runs and their status",
+	}, &gomcp.Tool{
+		Description: "List the migration runs of an application started with run_migration, newest first, with their command and status: Running, Succeeded, Failed, or Unknown when the Job was deleted before it finished. Requires session_id.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input MigrationStatusInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.AppName); err != nil {
+			return nil, nil, fmt.Errorf("invalid app name: %w", err)
+		}
+
+		var app iafv1alpha1.Application
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.AppName, Namespace: namespace}, &app); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.AppName)
+			}
+			return nil, nil, fmt.Errorf("getting application: %w", err)
+		}
+		_, runs, err := loadMigrationHistory(ctx, deps, &app)
+		if err != nil {
+			return nil, nil, err
+		}
+		slices.Reverse(runs)
+
+		result := map[string]any{
+			"app":  input.AppName,
+			"runs": runs,
+		}
+		if len(runs) == 0 {
+			result["runs"] = []iafk8s.MigrationRun{}
+			result["message"] = "No migrations have run. Start one with run_migration."
+		}
+		if len(app.Spec.ReleaseCommand) > 0 {
+			result["releaseCommand"] = app.Spec.ReleaseCommand
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// loadMigrationHistory returns the migration history ConfigMap of app, or a
+// new one when none exists, and its runs oldest first. Runs still recorded
+// as running are updated from their Jobs, and the history saved if any
+// finished.
+func loadMigrationHistory(ctx context.Context, deps *Dependencies, app *iafv1alpha1.Application) (*corev1.ConfigMap, []iafk8s.MigrationRun, error) {
+	name := iafk8s.MigrationHistoryName(app.Name)
+	var cm corev1.ConfigMap
+	if err := deps.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: app.Namespace}, &cm); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, nil, fmt.Errorf("getting migration history: %w", err)
+		}
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: app.Namespace,
+				Labels: map[string]string{
+					"app.kubernetes.io/managed-by": "iaf",
+					iafk8s.LabelMigrationOf:        app.Name,
+				},
+				// The history is deleted with the application.
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: iafv1alpha1.GroupVersion.String(),
+						Kind:       "Application",
+						Name:       app.Name,
+						UID:        app.UID,
+					},
+				},
+			},
+		}, nil, nil
+	}
+	if cm.Labels[iafk8s.LabelMigrationOf] != app.Name {
+		return nil, nil, fmt.Errorf("config map %q is not the migration history of %q; rename it to run migrations", name, app.Name)
+	}
+
+	var runs []iafk8s.MigrationRun
+	if data := cm.Data[iafk8s.MigrationHistoryKey]; data != "" {
+		if err := json.Unmarshal([]byte(data), &runs); err != nil {
+			return nil, nil, fmt.Errorf("reading migration history: %w", err)
+		}
+	}
+	changed := false
+	for i := range runs {
+		if runs[i].Status != iafk8s.MigrationRunning {
+			continue
+		}
+		var job batchv1.Job
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: runs[i].Job, Namespace: app.Namespace}, &job); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, nil, fmt.Errorf("getting migration job: %w", err)
+			}
+			runs[i].Status, changed = iafk8s.MigrationUnknown, true
+			continue
+		}
+		if status, message, completedAt := iafk8s.MigrationJobStatus(&job); status != iafk8s.MigrationRunning {
+			runs[i].Status, runs[i].Message, runs[i].CompletedAt = status, message, completedAt
+			changed = true
+		}
+	}
+	if changed {
+		if err := saveMigrationHistory(ctx, deps, &cm, runs); err != nil {
+			return nil, nil, err
+		}
+	}
+	return &cm, runs, nil
+}
+
+// saveMigrationHistory writes runs to the migration history ConfigMap,
+// creating it on the first run.
+func saveMigrationHistory(ctx context.Context, deps *Dependencies, cm *corev1.ConfigMap, runs []iafk8s.MigrationRun) error {
+	data, err := json.Marshal(runs)
+	if err != nil {
+		return fmt.Errorf("encoding migration history: %w", err)
+	}
+	cm.Data = map[string]string{iafk8s.MigrationHistoryKey: string(data)}
+	if cm.ResourceVersion == "" {
+		err = deps.Client.Create(ctx, cm)
+	} else {
+		err = deps.Client.Update(ctx, cm)
+	}
+	if err != nil {
+		return fmt.Errorf("saving migration history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// setupMigrationServer creates a server with run_migration and
+// migration_status registered.
+func setupMigrationServer(t *testing.T) (*gomcp.ClientSession, client.Client) {
+	t.Helper()
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	_ = batchv1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		Store:      store,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterRunMigration(server, deps)
+	tools.RegisterMigrationStatus(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mc := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mc.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs, k8sClient
+}
+
+func TestMigrations(t *testing.T) {
+	cs, k8sClient := setupMigrationServer(t)
+	ctx := context.Background()
+	sid, namespace := registerCredSession(t, cs, k8sClient)
+
+	call := func(name string, args map[string]any) *gomcp.CallToolResult {
+		t.Helper()
+		args["session_id"] = sid
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	statusRuns := func() []map[string]any {
+		t.Helper()
+		res := call("migration_status", map[string]any{"app_name": "web"})
+		if res.IsError {
+			t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+		}
+		var out struct {
+			Runs []map[string]any `json:"runs"`
+		}
+		if err := json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &out); err != nil {
+			t.Fatal(err)
+		}
+		return out.Runs
+	}
+
+	newApp := func(name string, bound bool) *iafv1alpha1.Application {
+		app := &iafv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: types.UID("uid-" + name)},
+			Spec:       iafv1alpha1.ApplicationSpec{Image: "org/" + name + ":1.0"},
+		}
+		if bound {
+			app.Spec.BoundManagedServices = []iafv1alpha1.BoundManagedService{{ServiceName: "db", SecretName: "db-app"}}
+		}
+		if err := k8sClient.Create(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+		return app
+	}
+	deploy := func(app *iafv1alpha1.Application) {
+		controller := true
+		dep := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      app.Name,
+				Namespace: namespace,
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: iafv1alpha1.GroupVersion.String(), Kind: "Application", Name: app.Name, UID: app.UID, Controller: &controller,
+				}},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: app.Spec.Image}}},
+				},
+			},
+		}
+		if err := k8sClient.Create(ctx, dep); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	web := newApp("web", true)
+	web.Spec.ReleaseCommand = []string{"npm", "run", "migrate"}
+	if err := k8sClient.Update(ctx, web); err != nil {
+		t.Fatal(err)
+	}
+	deploy(web)
+	deploy(newApp("unbound", false))
+	newApp("undeployed", true)
+	noCommand := newApp("no-command", true)
+	deploy(noCommand)
+
+	if runs := statusRuns(); len(runs) != 0 {
+		t.Fatalf("expected no runs before the first migration, got %v", runs)
+	}
+
+	res := call("run_migration", map[string]any{"app_name": "web"})
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	var job batchv1.Job
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "web-migrate-1"}, &job); err != nil {
+		t.Fatalf("expected the migration job: %v", err)
+	}
+	if got := job.Spec.Template.Spec.Containers[0].Command; !slices.Equal(got, []string{"npm", "run", "migrate"}) {
+		t.Errorf("expected the release command, got %v", got)
+	}
+	var history corev1.ConfigMap
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "web-migrations"}, &history); err != nil {
+		t.Fatalf("expected the migration history: %v", err)
+	}
+	if runs := statusRuns(); len(runs) != 1 || runs[0]["status"] != "Running" || runs[0]["job"] != "web-migrate-1" {
+		t.Fatalf("unexpected runs %v", runs)
+	}
+
+	// Only one migration runs at a time.
+	if res := call("run_migration", map[string]any{"app_name": "web"}); !res.IsError {
+		t.Error("expected an error while a migration is running")
+	}
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}}
+	if err := k8sClient.Status().Update(ctx, &job); err != nil {
+		t.Fatal(err)
+	}
+	res = call("run_migration", map[string]any{"app_name": "web", "command": []string{"npm", "run", "seed"}})
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "web-migrate-2"}, &job); err != nil {
+		t.Fatalf("expected the second migration job: %v", err)
+	}
+	// A Job deleted before it finished is recorded as Unknown.
+	if err := k8sClient.Delete(ctx, &job); err != nil {
+		t.Fatal(err)
+	}
+	runs := statusRuns()
+	if len(runs) != 2 || runs[0]["status"] != "Unknown" || runs[1]["status"] != "Succeeded" {
+		t.Fatalf("expected runs newest first with Unknown and Succeeded, got %v", runs)
+	}
+
+	tests := []struct {
+		name string
+		args map[string]any
+	}{
+		{"missing app", map[string]any{"app_name": "api"}},
+		{"no bound service", map[string]any{"app_name": "unbound", "command": []string{"migrate"}}},
+		{"not deployed", map[string]any{"app_name": "undeployed", "command": []string{"migrate"}}},
+		{"no release command", map[string]any{"app_name": "no-command"}},
+		{"blank command", map[string]any{"app_name": "no-command", "command": []string{""}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if res := call("run_migration", tt.args); !res.IsError {
+				t.Errorf("expected error, got %s", res.Content[0].(*gomcp.TextContent).Text)
+			}
+		})
+	}
+}
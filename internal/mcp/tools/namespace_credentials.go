@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/audit"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// defaultCredentialTTL is how long namespace credentials are valid
+	// unless the agent asks otherwise, within min and maxCredentialTTL.
+	defaultCredentialTTL = time.Hour
+	minCredentialTTL     = 10 * time.Minute
+	maxCredentialTTL     = 8 * time.Hour
+)
+
+type GetNamespaceCredentialsInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Duration  string `json:"duration,omitempty" jsonschema:"how long the credentials stay valid (e.g. '30m', '2h'; 10m to 8h). Default: 1h"`
+}
+
+// RegisterGetNamespaceCredentials registers the get_namespace_credentials
+// tool, which issues a short-lived kubeconfig that can only read the
+// session's namespace. It is registered only when the platform publishes
+// an API server URL for agents.
+func RegisterGetNamespaceCredentials(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "get_namespace_credentials",
+		Category: CategoryCredentials,
+		Summary:  "Issue a short-lived, read-only kubeconfig for the session namespace, for kubectl",
+		Examples: []string{`{"session_id": "<id>", "duration": "2h"}`},
+	}, &gomcp.Tool{
+		Description: "Issue a kubeconfig for running kubectl against your session namespace, valid for 'duration' (default 1h, at most 8h). It can read applications, deployments, pods and their logs, services, configmaps and events in your namespace only: it cannot read secrets, change anything, exec into pods or port-forward. Keep using the IAF tools to deploy and configure apps. Write the kubeconfig to a file and pass it with --kubeconfig; call again for a new one when it expires. Every issuance is audit-logged. Requires session_id.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input GetNamespaceCredentialsInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		ttl := defaultCredentialTTL
+		if input.Duration != "" {
+			ttl, err = time.ParseDuration(input.Duration)
+			if err != nil || ttl < minCredentialTTL || ttl > maxCredentialTTL {
+				return nil, nil, apierror.Validation(apierror.CodeInvalidRequest, "duration %q is invalid: use a duration between %s and %s, such as '1h'", input.Duration, minCredentialTTL, maxCredentialTTL)
+			}
+		}
+
+		if err := iafk8s.EnsureAgentAccess(ctx, deps.Client, namespace); err != nil {
+			return nil, nil, err
+		}
+		token, expiresAt, err := iafk8s.IssueAgentToken(ctx, deps.Client, namespace, ttl)
+		if err != nil {
+			return nil, nil, err
+		}
+		ca, err := iafk8s.APIServerCA(ctx, deps.Client, namespace)
+		if err != nil {
+			return nil, nil, err
+		}
+		kubeconfig, err := iafk8s.BuildKubeconfig(deps.KubeAPIServer, ca, namespace, token)
+		if err != nil {
+			return nil, nil, fmt.Errorf("building kubeconfig: %w", err)
+		}
+
+		// Every issuance is audited, never the token.
+		deps.SecurityAudit.Record(audit.SecurityEvent{
+			Action:    audit.ActionCredentialIssued,
+			Actor:     input.SessionID,
+			Namespace: namespace,
+			Kind:      "namespace_token",
+			Name:      iafk8s.AgentServiceAccount,
+			Expires:   expiresAt.UTC(),
+		})
+
+		result := map[string]any{
+			"namespace":  namespace,
+			"expiresAt":  expiresAt.UTC().Format(time.RFC3339),
+			"kubeconfig": string(kubeconfig),
+			"message":    fmt.Sprintf("Read-only kubeconfig for namespace %q, valid until %s. Save it to a file and run e.g. kubectl --kubeconfig <file> get pods. Do not commit it or share it.", namespace, expiresAt.UTC().Format(time.RFC3339)),
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
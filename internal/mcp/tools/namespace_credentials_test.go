@@ -0,0 +1,189 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// setupNamespaceCredentialsServer creates a server with register and
+// get_namespace_credentials registered.
+func setupNamespaceCredentialsServer(t *testing.T) (*gomcp.ClientSession, client.Client) {
+	t.Helper()
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	_ = rbacv1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps := &tools.Dependencies{
+		Client:        k8sClient,
+		Store:         store,
+		BaseDomain:    "test.example.com",
+		Sessions:      sessions,
+		KubeAPIServer: "https://k8s.example.com:6443",
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterGetNamespaceCredentials(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mc := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mc.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs, k8sClient
+}
+
+func TestGetNamespaceCredentials(t *testing.T) {
+	cs, k8sClient := setupNamespaceCredentialsServer(t)
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+
+	ca := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-root-ca.crt", Namespace: ns},
+		Data:       map[string]string{"ca.crt": "-----BEGIN CERTIFICATE-----\n"},
+	}
+	if err := k8sClient.Create(ctx, ca); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "get_namespace_credentials",
+		Arguments: map[string]any{"session_id": sid, "duration": "2h"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	var out struct {
+		Namespace  string `json:"namespace"`
+		ExpiresAt  string `json:"expiresAt"`
+		Kubeconfig string `json:"kubeconfig"`
+	}
+	if err := json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Namespace != ns || out.ExpiresAt == "" {
+		t.Errorf("unexpected result %+v", out)
+	}
+
+	cfg, err := clientcmd.Load([]byte(out.Kubeconfig))
+	if err != nil {
+		t.Fatalf("invalid kubeconfig: %v", err)
+	}
+	current := cfg.Contexts[cfg.CurrentContext]
+	if current == nil || current.Namespace != ns {
+		t.Fatalf("expected the current context to default to %s, got %+v", ns, current)
+	}
+	cluster := cfg.Clusters[current.Cluster]
+	if cluster.Server != "https://k8s.example.com:6443" || string(cluster.CertificateAuthorityData) != ca.Data["ca.crt"] {
+		t.Errorf("unexpected cluster %+v", cluster)
+	}
+	if cfg.AuthInfos[current.AuthInfo].Token == "" {
+		t.Error("expected a token")
+	}
+
+	// The token belongs to a ServiceAccount bound to the read-only Role of
+	// the session namespace.
+	key := types.NamespacedName{Name: iafk8s.AgentServiceAccount, Namespace: ns}
+	var role rbacv1.Role
+	if err := k8sClient.Get(ctx, key, &role); err != nil {
+		t.Fatal(err)
+	}
+	for _, rule := range role.Rules {
+		for _, verb := range rule.Verbs {
+			if verb != "get" && verb != "list" && verb != "watch" {
+				t.Errorf("unexpected verb %q in %+v", verb, rule)
+			}
+		}
+		for _, resource := range rule.Resources {
+			if resource == "secrets" {
+				t.Errorf("agents must not read secrets: %+v", rule)
+			}
+		}
+	}
+	var binding rbacv1.RoleBinding
+	if err := k8sClient.Get(ctx, key, &binding); err != nil {
+		t.Fatal(err)
+	}
+	if binding.RoleRef.Name != role.Name || len(binding.Subjects) != 1 || binding.Subjects[0].Namespace != ns {
+		t.Errorf("unexpected role binding %+v", binding)
+	}
+
+	// Issuing again resets a Role widened since.
+	role.Rules = append(role.Rules, rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}})
+	if err := k8sClient.Update(ctx, &role); err != nil {
+		t.Fatal(err)
+	}
+	res, err = cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "get_namespace_credentials",
+		Arguments: map[string]any{"session_id": sid},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	if err := k8sClient.Get(ctx, key, &role); err != nil {
+		t.Fatal(err)
+	}
+	if len(role.Rules) != len(iafk8s.AgentRules) {
+		t.Errorf("expected the role to be reset, got %+v", role.Rules)
+	}
+}
+
+func TestGetNamespaceCredentials_InvalidDuration(t *testing.T) {
+	cs, _ := setupNamespaceCredentialsServer(t)
+	sid, _ := registerDSSession(t, cs)
+
+	for _, duration := range []string{"5m", "24h", "soon"} {
+		res, err := cs.CallTool(context.Background(), &gomcp.CallToolParams{
+			Name:      "get_namespace_credentials",
+			Arguments: map[string]any{"session_id": sid, "duration": duration},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.IsError {
+			t.Errorf("expected duration %q to be rejected", duration)
+		}
+	}
+}
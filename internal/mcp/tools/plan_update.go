@@ -0,0 +1,177 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// PlanUpdateInput is the input for the plan_update tool. Omitted fields are
+// left unchanged, as in an update.
+type PlanUpdateInput struct {
+	SessionID       string               `json:"session_id" jsonschema:"required - session ID from the register tool"`
+	Name            string               `json:"name" jsonschema:"required - name of the application to update"`
+	Image           string               `json:"image,omitempty" jsonschema:"new container image; replaces a git or pushed source"`
+	GitURL          string               `json:"git_url,omitempty" jsonschema:"new git repository URL to build from; replaces an image or pushed source"`
+	GitRevision     string               `json:"git_revision,omitempty" jsonschema:"git branch, tag, or commit to build; used with git_url"`
+	Port            int32                `json:"port,omitempty" jsonschema:"new port the app listens on"`
+	Replicas        int32                `json:"replicas,omitempty" jsonschema:"new number of replicas"`
+	Env             []iafv1alpha1.EnvVar `json:"env,omitempty" jsonschema:"new environment variables as [{name, value}]; replaces all current ones"`
+	BuildEnv        []iafv1alpha1.EnvVar `json:"build_env,omitempty" jsonschema:"new build environment variables as [{name, value}]; replaces all current ones"`
+	Builder         string               `json:"builder,omitempty" jsonschema:"new buildpack builder, one of the builders listed in the iaf://platform resource"`
+	WorkloadClass   string               `json:"workload_class,omitempty" jsonschema:"new node pool: 'standard', 'burst' or 'gpu'"`
+	Architecture    string               `json:"architecture,omitempty" jsonschema:"new CPU architecture: 'amd64', 'arm64', or 'multi'"`
+	Protocol        string               `json:"protocol,omitempty" jsonschema:"new routing protocol: 'http', 'websocket', 'grpc', or 'tcp'"`
+	StickySessions  *bool                `json:"sticky_sessions,omitempty" jsonschema:"turn cookie-based sticky sessions on or off"`
+	Authentication  string               `json:"authentication,omitempty" jsonschema:"new authentication: 'none', 'basic', or 'oauth-proxy'"`
+	BackendTLS      *bool                `json:"backend_tls,omitempty" jsonschema:"turn TLS from the ingress to your app on or off"`
+	ReleaseCommand  []string             `json:"release_command,omitempty" jsonschema:"new default command for run_migration, one argument per item"`
+	ExpectedVersion int64                `json:"expected_version,omitempty" jsonschema:"optional version of the app from app_status that you based this change on; the call fails with version_conflict, returning the current state, if the app changed since"`
+}
+
+// RegisterPlanUpdate registers the plan_update MCP tool.
+func RegisterPlanUpdate(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:          "plan_update",
+		Category:      CategoryDeploy,
+		ReadOnly:      true,
+		Summary:       "Preview which spec fields a change alters and whether it rebuilds, restarts or applies in place, without applying it",
+		Preconditions: []string{"the app exists"},
+	}, &gomcp.Tool{
+		Description: "Preview an update to an existing application without applying it. Pass only the fields you intend to change. Returns each spec field that would change with its old and new value (environment variables and files by name only) and its effect: 'rebuild' (new build from source, about 2 minutes), 'restart' (rolling replacement of pods), 'scale' (replica count only), or 'in_place' (routing or settings, no pod changes); the overall effect is the most disruptive one. Use it before push_code or an update to avoid unneeded rebuilds and restarts; note that push_code always uploads new source and rebuilds. Requires session_id.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input PlanUpdateInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.Name); err != nil {
+			return nil, nil, fmt.Errorf("invalid app name: %w", err)
+		}
+		if input.Image != "" && input.GitURL != "" {
+			return nil, nil, fmt.Errorf("set either image or git_url, not both")
+		}
+		for _, e := range input.Env {
+			if err := validation.ValidateEnvVarName(e.Name); err != nil {
+				return nil, nil, err
+			}
+		}
+		for _, e := range input.BuildEnv {
+			if err := validation.ValidateBuildEnvVarName(e.Name); err != nil {
+				return nil, nil, err
+			}
+		}
+		if err := validation.ValidateBuilder(input.Builder, deps.Builders); err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateArchitecture(input.Architecture, deps.Architectures); err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateWorkloadClass(input.WorkloadClass, deps.WorkloadClasses); err != nil {
+			return nil, nil, err
+		}
+		if input.Builder != "" && input.Architecture != "" {
+			return nil, nil, fmt.Errorf("set either builder or architecture, not both; architecture selects its own builder")
+		}
+		if err := validation.ValidateProtocol(input.Protocol); err != nil {
+			return nil, nil, err
+		}
+		if len(input.ReleaseCommand) > 0 {
+			if err := validation.ValidateCommand(input.ReleaseCommand); err != nil {
+				return nil, nil, fmt.Errorf("invalid release_command: %w", err)
+			}
+		}
+
+		var app iafv1alpha1.Application
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, &app); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.Name)
+			}
+			return nil, nil, fmt.Errorf("getting application: %w", err)
+		}
+		if err := iafk8s.CheckVersion(&app, input.ExpectedVersion); err != nil {
+			return nil, nil, err
+		}
+
+		proposed := app.DeepCopy()
+		spec := &proposed.Spec
+		if input.Image != "" {
+			spec.Image, spec.Git, spec.Blob = input.Image, nil, ""
+		}
+		if input.GitURL != "" {
+			spec.Git = &iafv1alpha1.GitSource{URL: input.GitURL, Revision: input.GitRevision}
+			spec.Image, spec.Blob = "", ""
+		} else if input.GitRevision != "" {
+			if spec.Git == nil {
+				return nil, nil, fmt.Errorf("application %q is not built from git; set git_url with git_revision", input.Name)
+			}
+			spec.Git = &iafv1alpha1.GitSource{URL: spec.Git.URL, Revision: input.GitRevision}
+		}
+		if input.Port > 0 {
+			spec.Port = input.Port
+		}
+		if input.Replicas > 0 {
+			spec.Replicas = input.Replicas
+		}
+		if input.Env != nil {
+			spec.Env = input.Env
+		}
+		if input.BuildEnv != nil {
+			spec.BuildEnv = input.BuildEnv
+		}
+		if input.Builder != "" {
+			spec.Builder, spec.Architecture = input.Builder, ""
+		}
+		if input.Architecture != "" {
+			spec.Architecture, spec.Builder = iafv1alpha1.ApplicationArchitecture(input.Architecture), ""
+		}
+		if input.WorkloadClass != "" {
+			spec.WorkloadClass = iafv1alpha1.WorkloadClass(input.WorkloadClass)
+		}
+		if input.Protocol != "" {
+			spec.Protocol = iafv1alpha1.ApplicationProtocol(input.Protocol)
+		}
+		if input.StickySessions != nil {
+			spec.StickySessions = *input.StickySessions
+		}
+		if input.Authentication != "" {
+			spec.Authentication = iafv1alpha1.ApplicationAuthentication(input.Authentication)
+		}
+		if input.BackendTLS != nil {
+			spec.BackendTLS = nil
+			if *input.BackendTLS {
+				spec.BackendTLS = &iafv1alpha1.BackendTLSConfig{Enabled: true}
+			}
+		}
+		if len(input.ReleaseCommand) > 0 {
+			spec.ReleaseCommand = input.ReleaseCommand
+		}
+		if err := validation.ValidateAuthentication(string(spec.Authentication), string(spec.Protocol)); err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateBackendTLS(iafv1alpha1.IsBackendTLSEnabled(proposed), string(spec.Protocol), string(spec.Authentication)); err != nil {
+			return nil, nil, err
+		}
+		if spec.Access != nil {
+			if err := validation.ValidateAccess(spec.Access.IPAllowList, spec.Access.RequestsPerSecond, string(spec.Protocol)); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		result := struct {
+			App string `json:"app"`
+			iafk8s.UpdatePlan
+		}{App: input.Name, UpdatePlan: iafk8s.PlanUpdate(&app, proposed)}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
@@ -0,0 +1,165 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// setupPlanUpdateServer creates a server with plan_update registered.
+func setupPlanUpdateServer(t *testing.T) (*gomcp.ClientSession, client.Client) {
+	t.Helper()
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		Store:      store,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterPlanUpdate(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mc := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mc.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs, k8sClient
+}
+
+func TestPlanUpdate(t *testing.T) {
+	cs, k8sClient := setupPlanUpdateServer(t)
+	ctx := context.Background()
+	sid, namespace := registerCredSession(t, cs, k8sClient)
+
+	web := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace},
+		Spec: iafv1alpha1.ApplicationSpec{
+			Git:      &iafv1alpha1.GitSource{URL: "https://github.com/org/web", Revision: "main"},
+			Port:     3000,
+			Replicas: 1,
+			Env:      []iafv1alpha1.EnvVar{{Name: "API_KEY", Value: "secret-value"}},
+		},
+	}
+	if err := k8sClient.Create(ctx, web); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		args       map[string]any
+		wantEffect string
+		wantFields []string
+	}{
+		{"nothing", map[string]any{"port": 3000}, "none", nil},
+		{"replicas", map[string]any{"replicas": 3}, "scale", []string{"replicas"}},
+		{"sticky sessions", map[string]any{"sticky_sessions": true}, "in_place", []string{"stickySessions"}},
+		{"env", map[string]any{"env": []map[string]string{{"name": "API_KEY", "value": "rotated"}}}, "restart", []string{"env"}},
+		{"revision", map[string]any{"git_revision": "v2"}, "rebuild", []string{"git.revision"}},
+		{"image", map[string]any{"image": "org/web:1.0"}, "rebuild", []string{"image", "git.url", "git.revision"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args["session_id"] = sid
+			tt.args["name"] = "web"
+			res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "plan_update", Arguments: tt.args})
+			if err != nil {
+				t.Fatal(err)
+			}
+			text := res.Content[0].(*gomcp.TextContent).Text
+			if res.IsError {
+				t.Fatalf("unexpected error: %s", text)
+			}
+			var out struct {
+				Effect  string `json:"effect"`
+				Changes []struct {
+					Field string `json:"field"`
+					From  any    `json:"from"`
+					To    any    `json:"to"`
+				} `json:"changes"`
+			}
+			if err := json.Unmarshal([]byte(text), &out); err != nil {
+				t.Fatal(err)
+			}
+			if out.Effect != tt.wantEffect {
+				t.Errorf("effect = %q, want %q", out.Effect, tt.wantEffect)
+			}
+			var fields []string
+			for _, c := range out.Changes {
+				fields = append(fields, c.Field)
+				if c.Field == "env" && (c.From != nil || c.To != nil) {
+					t.Errorf("expected env values to be left out, got %s", text)
+				}
+			}
+			if !slices.Equal(fields, tt.wantFields) {
+				t.Errorf("changed fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+
+	// Planning saves nothing.
+	var got iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, client.ObjectKey{Name: "web", Namespace: namespace}, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Spec.Replicas != 1 || got.Spec.Git == nil || got.Spec.Image != "" {
+		t.Errorf("expected the application to be unchanged, got %+v", got.Spec)
+	}
+
+	errorTests := []struct {
+		name string
+		args map[string]any
+	}{
+		{"missing app", map[string]any{"name": "api", "replicas": 2}},
+		{"image and git", map[string]any{"name": "web", "image": "org/web:1.0", "git_url": "https://github.com/org/other"}},
+		{"invalid protocol", map[string]any{"name": "web", "protocol": "udp"}},
+		{"auth over tcp", map[string]any{"name": "web", "protocol": "tcp", "authentication": "basic"}},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args["session_id"] = sid
+			res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "plan_update", Arguments: tt.args})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !res.IsError {
+				t.Errorf("expected error, got %s", res.Content[0].(*gomcp.TextContent).Text)
+			}
+		})
+	}
+}
@@ -3,11 +3,22 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"path"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/codecheck"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+	"github.com/dlapiduz/iaf/internal/policy"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
 	"github.com/dlapiduz/iaf/internal/validation"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -16,18 +27,37 @@ import (
 )
 
 type PushCodeInput struct {
-	SessionID string               `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
-	Name      string               `json:"name" jsonschema:"required - application name (lowercase, hyphens allowed, becomes part of URL)"`
-	Files     map[string]string    `json:"files" jsonschema:"required - map of file paths to file contents, e.g. {\"main.go\": \"package main...\", \"go.mod\": \"module app...\"}"`
-	Port      int32                `json:"port,omitempty" jsonschema:"port your app listens on (default: 8080)"`
-	Env       []iafv1alpha1.EnvVar `json:"env,omitempty" jsonschema:"environment variables as [{name, value}]"`
+	SessionID       string                         `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Name            string                         `json:"name" jsonschema:"required - application name (lowercase, hyphens allowed, becomes part of URL)"`
+	Files           map[string]string              `json:"files,omitempty" jsonschema:"map of file paths to file contents, e.g. {\"main.go\": \"package main...\", \"go.mod\": \"module app...\"}. Required unless upload_id is given"`
+	BinaryFiles     map[string]string              `json:"binary_files,omitempty" jsonschema:"map of file paths to base64-encoded contents, for images, fonts and other files that are not text, e.g. {\"static/logo.png\": \"iVBORw0KGgo...\"}. A path may not also be in files"`
+	UploadID        string                         `json:"upload_id,omitempty" jsonschema:"upload from push_code_chunk whose files to build, together with files; a path in files replaces the uploaded one. The upload is removed once the push succeeds"`
+	Port            int32                          `json:"port,omitempty" jsonschema:"port your app listens on (default: 8080)"`
+	SubPath         string                         `json:"sub_path,omitempty" jsonschema:"directory of the uploaded files to build when they hold several services (e.g. 'services/api'); some file must be under it. Default: the root, or the app's current sub_path"`
+	Env             []iafv1alpha1.EnvVar           `json:"env,omitempty" jsonschema:"environment variables as [{name, value}]"`
+	BuildEnv        []iafv1alpha1.EnvVar           `json:"build_env,omitempty" jsonschema:"environment variables for the build only, as [{name, value}] (e.g. BP_GO_TARGETS, NODE_ENV); not set when the app runs"`
+	Builder         string                         `json:"builder,omitempty" jsonschema:"buildpack builder, one of the builders listed in the iaf://platform resource (e.g. a tiny or Java-native stack). Default: the platform default, or the app's current builder"`
+	WorkloadClass   string                         `json:"workload_class,omitempty" jsonschema:"node pool to run on: 'standard', 'burst' or 'gpu'; must be one of the workload classes listed in the iaf://platform resource. Default: standard, or the app's current class"`
+	Architecture    string                         `json:"architecture,omitempty" jsonschema:"CPU architecture to build for and run on: 'amd64', 'arm64', or 'multi' (runs on either); must be one of the architectures listed in the iaf://platform resource. Default: any node, or the app's current architecture"`
+	ReleaseCommand  []string                       `json:"release_command,omitempty" jsonschema:"command run_migration runs by default, one argument per item (e.g. ['npm', 'run', 'migrate']). It runs only when you call run_migration, never on push. Default: the app's current release command"`
+	Metadata        *iafv1alpha1.OwnershipMetadata `json:"metadata,omitempty" jsonschema:"ownership of the app, as {team, owner, purpose, costCenter}; set as labels on everything the app runs. Default: the app's current metadata, or the metadata given to register for a new app"`
+	ExpectedVersion int64                          `json:"expected_version,omitempty" jsonschema:"optional version of the app from app_status that you based this change on; the call fails with version_conflict, returning the current state, if the app changed since. Only for updating an existing app"`
+	IdempotencyKey  string                         `json:"idempotency_key,omitempty" jsonschema:"optional key you choose, such as a UUID, that makes retrying safe: repeating the call with the same key and files within 24 hours returns the original result instead of uploading and building again. Use a new key for each distinct push"`
+}
+
+func (in PushCodeInput) idempotencyKey() (sessionID, key string) {
+	return in.SessionID, in.IdempotencyKey
 }
 
 func RegisterPushCode(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "push_code",
-		Description: `Upload source code and automatically build and deploy it as an application. Requires session_id from the register tool. The 'files' parameter is a JSON object mapping file paths to their contents, e.g. {"main.go": "package main\n...", "go.mod": "module myapp\n..."}. The platform auto-detects the language (Go, Node.js, Python, Java, Ruby) and builds a container. Your app must listen on the specified port (default 8080). Use app_status to monitor build progress (~2 min).`,
-	}, func(ctx context.Context, req *gomcp.CallToolRequest, input PushCodeInput) (*gomcp.CallToolResult, any, error) {
+	addTool(server, deps, Capability{
+		Name:     "push_code",
+		Category: CategoryDeploy,
+		Summary:  "Upload source files to build and deploy an app",
+		Examples: []string{`{"session_id": "<id>", "name": "hello", "files": {"main.go": "package main ...", "go.mod": "module hello"}}`},
+	}, &gomcp.Tool{
+		Description: `Upload source code and automatically build and deploy it as an application. Requires session_id from the register tool. The 'files' parameter is a JSON object mapping file paths to their contents, e.g. {"main.go": "package main\n...", "go.mod": "module myapp\n..."}; send images, fonts and other binary files base64-encoded in 'binary_files' instead. The platform auto-detects the language (Go, Node.js, Python, Java, Ruby) and builds a container. When the files hold several services, set 'sub_path' to the directory to build. For apps too large for one call, send the files with push_code_chunk first and pass its 'upload_id' here. A runtime version the source declares (e.g. package.json engines.node or the go.mod go directive) must be one the org coding standards allow, and dependencies the org dependency policy blocks are rejected. Your app must listen on the specified port (default 8080). Use app_status to monitor build progress (~2 min).`,
+	}, idempotent(deps, "push_code", func(ctx context.Context, req *gomcp.CallToolRequest, input PushCodeInput) (*gomcp.CallToolResult, any, error) {
 		namespace, err := deps.ResolveNamespace(input.SessionID)
 		if err != nil {
 			return nil, nil, err
@@ -40,65 +70,160 @@ func RegisterPushCode(server *gomcp.Server, deps *Dependencies) {
 				return nil, nil, err
 			}
 		}
+		for _, e := range input.BuildEnv {
+			if err := validation.ValidateBuildEnvVarName(e.Name); err != nil {
+				return nil, nil, err
+			}
+		}
+		if err := validation.ValidateBuilder(input.Builder, deps.Builders); err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateArchitecture(input.Architecture, deps.Architectures); err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateWorkloadClass(input.WorkloadClass, deps.WorkloadClasses); err != nil {
+			return nil, nil, err
+		}
+		if input.Builder != "" && input.Architecture != "" {
+			return nil, nil, fmt.Errorf("set either builder or architecture, not both; architecture selects its own builder")
+		}
+		if len(input.ReleaseCommand) > 0 {
+			if err := validation.ValidateCommand(input.ReleaseCommand); err != nil {
+				return nil, nil, fmt.Errorf("invalid release_command: %w", err)
+			}
+		}
+		if err := validateMetadata(input.Metadata); err != nil {
+			return nil, nil, err
+		}
+		input.Files, err = sourcestore.MergeBinaryFiles(input.Files, input.BinaryFiles)
+		if err != nil {
+			return nil, nil, err
+		}
+		if input.UploadID != "" {
+			uploaded, err := deps.Store.UploadFiles(namespace, input.Name, input.UploadID)
+			if err != nil {
+				return nil, nil, uploadError(err, deps.Store)
+			}
+			maps.Copy(uploaded, input.Files)
+			input.Files = uploaded
+		}
 		if len(input.Files) == 0 {
 			return nil, nil, fmt.Errorf("files map is required")
 		}
-
-		// Store source files — append revision to URL so kpack detects changes
-		blobURL, err := deps.Store.StoreFiles(namespace, input.Name, input.Files)
-		if err != nil {
-			return nil, nil, fmt.Errorf("storing source files: %w", err)
+		if err := validation.ValidateSubPath(input.SubPath); err != nil {
+			return nil, nil, err
+		}
+		if input.SubPath != "" && !hasFileUnder(input.Files, input.SubPath) {
+			return nil, nil, fmt.Errorf("no file is under sub_path %q; file paths are relative to the upload root (e.g. '%s/main.go')", input.SubPath, input.SubPath)
 		}
-		blobURL = blobURL + "?rev=" + strconv.FormatInt(time.Now().UnixNano(), 36)
 
 		port := input.Port
 		if port == 0 {
 			port = 8080
 		}
 
-		// Check if application already exists
-		var existing iafv1alpha1.Application
-		err = deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, &existing)
-		if err == nil {
-			// Update existing application
-			existing.Spec.Blob = blobURL
-			existing.Spec.Image = ""
-			existing.Spec.Git = nil
-			existing.Spec.Port = port
-			if input.Env != nil {
-				existing.Spec.Env = input.Env
+		// Build the application as it will be stored, so policies see the
+		// result of the push before any source is uploaded.
+		var app iafv1alpha1.Application
+		err = deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, &app)
+		exists := err == nil
+		if exists {
+			if err := iafk8s.CheckVersion(&app, input.ExpectedVersion); err != nil {
+				return nil, nil, err
 			}
-			if err := deps.Client.Update(ctx, &existing); err != nil {
-				return nil, nil, fmt.Errorf("updating application: %w", err)
+		} else if input.ExpectedVersion != 0 && apierrors.IsNotFound(err) {
+			return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found; omit expected_version to create it", input.Name)
+		}
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, nil, fmt.Errorf("checking application: %w", err)
 			}
-		} else if apierrors.IsNotFound(err) {
 			// Check name availability before creating
 			if err := deps.CheckAppNameAvailable(ctx, input.Name, namespace); err != nil {
 				return nil, nil, err
 			}
-			// Create new application
-			app := &iafv1alpha1.Application{
+			app = iafv1alpha1.Application{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      input.Name,
 					Namespace: namespace,
 				},
-				Spec: iafv1alpha1.ApplicationSpec{
-					Blob:     blobURL,
-					Port:     port,
-					Replicas: 1,
-					Env:      input.Env,
-				},
+				Spec: iafv1alpha1.ApplicationSpec{Replicas: 1, Metadata: deps.SessionMetadata(input.SessionID)},
+			}
+		}
+		app.Spec.Image = ""
+		app.Spec.Git = nil
+		app.Spec.Port = port
+		if input.Env != nil || !exists {
+			app.Spec.Env = input.Env
+		}
+		if input.BuildEnv != nil || !exists {
+			app.Spec.BuildEnv = input.BuildEnv
+		}
+		if input.Builder != "" {
+			app.Spec.Builder = input.Builder
+			app.Spec.Architecture = ""
+		}
+		if input.Architecture != "" {
+			app.Spec.Architecture = iafv1alpha1.ApplicationArchitecture(input.Architecture)
+			app.Spec.Builder = ""
+		}
+		if input.WorkloadClass != "" {
+			app.Spec.WorkloadClass = iafv1alpha1.WorkloadClass(input.WorkloadClass)
+		}
+		if len(input.ReleaseCommand) > 0 {
+			app.Spec.ReleaseCommand = input.ReleaseCommand
+		}
+		if input.SubPath != "" {
+			app.Spec.BlobSubPath = input.SubPath
+		}
+		if input.Metadata != nil {
+			app.Spec.Metadata = input.Metadata
+		}
+
+		if deps.OrgStandards != nil {
+			if err := pinRuntime(&app, input.BuildEnv, input.Files, deps.OrgStandards.Get()); err != nil {
+				return nil, nil, err
 			}
-			if err := deps.Client.Create(ctx, app); err != nil {
-				return nil, nil, fmt.Errorf("creating application: %w", err)
+			if err := checkDependencies(&app, input.Files, deps.OrgStandards.Get()); err != nil {
+				return nil, nil, err
 			}
-		} else {
-			return nil, nil, fmt.Errorf("checking application: %w", err)
+		}
+
+		if res, err := deps.CheckPolicy(ctx, policy.Input{
+			Operation: iafv1alpha1.PolicyOperationPushCode,
+			Namespace: namespace,
+			App:       &app,
+			Files:     slices.Sorted(maps.Keys(input.Files)),
+		}); res != nil || err != nil {
+			return res, nil, err
+		}
+
+		// Store source files — append revision to URL so kpack detects changes
+		blob, err := deps.Store.StoreFiles(namespace, input.Name, input.Files)
+		if errors.Is(err, sourcestore.ErrTooLarge) {
+			return nil, nil, uploadError(err, deps.Store)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("storing source files: %w", err)
+		}
+		app.Spec.Blob = blob.URL + "&rev=" + strconv.FormatInt(time.Now().UnixNano(), 36)
+		metav1.SetMetaDataAnnotation(&app.ObjectMeta, sourcestore.AnnotationSourceSHA256, blob.SHA256)
+
+		if exists {
+			if err := deps.Client.Update(ctx, &app); err != nil {
+				return nil, nil, fmt.Errorf("updating application: %w", err)
+			}
+		} else if err := deps.Client.Create(ctx, &app); err != nil {
+			return nil, nil, fmt.Errorf("creating application: %w", err)
+		}
+		if input.UploadID != "" {
+			_ = deps.Store.DeleteUpload(namespace, input.Name, input.UploadID)
 		}
 
 		host := fmt.Sprintf("%s.%s", input.Name, deps.BaseDomain)
 		result := map[string]any{
 			"name":    input.Name,
+			"version": iafk8s.AppVersion(&app),
 			"status":  "building",
 			"files":   len(input.Files),
 			"message": fmt.Sprintf("Source code uploaded and build started for %q. IMPORTANT: The build takes about 2 minutes. Wait at least 90 seconds before checking status. Then use app_status with name %q to check progress. Do NOT poll repeatedly — check once after 90s, then once more after another 30s if still building. Once status is Running, the app will be available at http://%s.", input.Name, input.Name, host),
@@ -108,5 +233,76 @@ func RegisterPushCode(server *gomcp.Server, deps *Dependencies) {
 		return &gomcp.CallToolResult{
 			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
 		}, nil, nil
-	})
+	}))
+}
+
+// pinRuntime holds the runtime version the pushed source declares to the
+// versions the org standards allow, and records it in the app's build env
+// so the build uses it rather than the default version the controller
+// pins. A version set in build_env takes precedence but must be allowed too.
+func pinRuntime(app *iafv1alpha1.Application, buildEnv []iafv1alpha1.EnvVar, files map[string]string, s *orgstandards.OrgStandards) error {
+	files = filesUnder(files, app.Spec.BlobSubPath)
+	lang := codecheck.DetectLanguage(files)
+	name := orgstandards.RuntimeEnvVars[lang]
+	version, file := codecheck.RuntimeVersion(files, lang)
+	if version != "" && len(s.PerLanguage[lang].RuntimeVersions) > 0 {
+		if !s.AllowsRuntime(lang, version) {
+			return apierror.Validation(apierror.CodePolicyViolation, "%s", s.RuntimeViolation(lang, version, file)).
+				WithHint(fmt.Sprintf("declare one of the allowed versions in %s and push again", file))
+		}
+		isName := func(e iafv1alpha1.EnvVar) bool { return e.Name == name }
+		if !slices.ContainsFunc(buildEnv, isName) {
+			app.Spec.BuildEnv = append(slices.DeleteFunc(slices.Clone(app.Spec.BuildEnv), isName),
+				iafv1alpha1.EnvVar{Name: name, Value: orgstandards.NormalizeRuntimeVersion(version)})
+		}
+	}
+	if _, err := s.RuntimeBuildEnv(app.Spec.BuildEnv); err != nil {
+		return apierror.Validation(apierror.CodePolicyViolation, "%s", err.Error()).
+			WithHint("set build_env to one of the allowed versions, or remove it to build with the default")
+	}
+	return nil
+}
+
+// checkDependencies rejects source whose manifests or lockfiles declare
+// dependencies the org dependency policy blocks. The controller checks the
+// built image's SBOM too, which also lists transitive dependencies.
+func checkDependencies(app *iafv1alpha1.Application, files map[string]string, s *orgstandards.OrgStandards) error {
+	if s.Dependencies.IsZero() {
+		return nil
+	}
+	found := codecheck.Dependencies(filesUnder(files, app.Spec.BlobSubPath))
+	violations := s.Dependencies.Check(found, app.Namespace, app.Name, time.Now())
+	if len(violations) == 0 {
+		return nil
+	}
+	return apierror.Validation(apierror.CodePolicyViolation,
+		"%d dependencies are blocked by the organisation dependency policy, starting with %s %q in %s (%s)",
+		len(violations), violations[0].Ecosystem, violations[0].Name, violations[0].Source, violations[0].Reason).
+		WithHint("remove or replace the blocked dependencies and push again; details.violations lists them all. Ask a platform operator for a waiver if one is required").
+		WithDetails(map[string]any{"violations": violations})
+}
+
+// filesUnder returns the files inside directory dir, or all of them when
+// dir is empty.
+func filesUnder(files map[string]string, dir string) map[string]string {
+	if dir == "" {
+		return files
+	}
+	out := map[string]string{}
+	for p, content := range files {
+		if strings.HasPrefix(path.Clean(p), dir+"/") {
+			out[p] = content
+		}
+	}
+	return out
+}
+
+// hasFileUnder reports whether a path of files is inside directory dir.
+func hasFileUnder(files map[string]string, dir string) bool {
+	for p := range files {
+		if strings.HasPrefix(path.Clean(p), dir+"/") {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type PushCodeChunkInput struct {
+	SessionID   string            `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Name        string            `json:"name" jsonschema:"required - application name the files are for; push_code must use the same name"`
+	UploadID    string            `json:"upload_id,omitempty" jsonschema:"upload to add the files to, as returned by the first push_code_chunk call. Omit to start a new upload"`
+	Files       map[string]string `json:"files,omitempty" jsonschema:"some of the app's files, as a map of file paths to contents like push_code's files. Sending a path again replaces its content"`
+	BinaryFiles map[string]string `json:"binary_files,omitempty" jsonschema:"some of the app's binary files, as a map of file paths to base64-encoded contents like push_code's binary_files"`
+}
+
+func RegisterPushCodeChunk(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "push_code_chunk",
+		Category: CategoryDeploy,
+		Summary:  "Upload the files of a large app over several calls before push_code",
+		Examples: []string{`{"session_id": "<id>", "name": "hello", "files": {"src/a.go": "package main ..."}}`},
+	}, &gomcp.Tool{
+		Description: `Upload source files in parts, for apps too large to send to push_code in one call. Requires session_id from the register tool. The first call, without upload_id, starts an upload and returns its upload_id; pass it to later calls to add more files. Then call push_code with the same name and the upload_id to build everything uploaded, plus any files push_code itself gets. Uploads expire 24 hours after they start, and the files together may not exceed the platform's max_size. Nothing is built until push_code is called.`,
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input PushCodeChunkInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.Name); err != nil {
+			return nil, nil, err
+		}
+		files, err := sourcestore.MergeBinaryFiles(input.Files, input.BinaryFiles)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(files) == 0 {
+			return nil, nil, fmt.Errorf("files or binary_files is required")
+		}
+
+		id := input.UploadID
+		if id == "" {
+			up, err := deps.Store.StartUpload(namespace, input.Name)
+			if err != nil {
+				return nil, nil, uploadError(err, deps.Store)
+			}
+			id = up.ID
+		}
+		up, err := deps.Store.AddUploadFiles(namespace, input.Name, id, files)
+		if err != nil {
+			if input.UploadID == "" {
+				_ = deps.Store.DeleteUpload(namespace, input.Name, id)
+			}
+			return nil, nil, uploadError(err, deps.Store)
+		}
+
+		result := map[string]any{
+			"upload_id":   up.ID,
+			"files_added": len(files),
+			"bytes":       up.Size,
+			"expires_at":  up.CreatedAt.Add(sourcestore.UploadTTL),
+			"message":     fmt.Sprintf("Files added to the upload. Add more with push_code_chunk and upload_id %q, then call push_code with name %q and that upload_id to build.", up.ID, input.Name),
+		}
+		if max := deps.Store.MaxUploadSize(); max > 0 {
+			result["max_size"] = max
+		}
+
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// uploadError classifies a source store error from a chunked upload.
+func uploadError(err error, store *sourcestore.Store) error {
+	switch {
+	case errors.Is(err, sourcestore.ErrUploadNotFound):
+		return apierror.NotFound(apierror.CodeUploadNotFound, "upload not found for this app").
+			WithHint("uploads expire 24 hours after they start; call push_code_chunk without upload_id to start a new one")
+	case errors.Is(err, sourcestore.ErrTooLarge):
+		return apierror.Validation(apierror.CodeSourceTooLarge, "source exceeds the maximum upload size of %d bytes", store.MaxUploadSize()).
+			WithHint("leave build outputs, dependencies and assets out of the source")
+	case errors.Is(err, sourcestore.ErrTooManyUploads):
+		return apierror.Quota(apierror.CodeTooManyUploads, "the session already has %d unfinished uploads", store.MaxOpenUploads()).
+			WithHint("finish an open upload with push_code, or wait for uploads to expire 24 hours after they start")
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestPushCodeChunk(t *testing.T) {
+	var store *sourcestore.Store
+	cs, k8sClient := setupDeployServer(t, func(deps *tools.Dependencies) { store = deps.Store })
+	sid, ns := registerDSSession(t, cs)
+	ctx := context.Background()
+	call := func(name string, args map[string]any) (*gomcp.CallToolResult, string) {
+		t.Helper()
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res, res.Content[0].(*gomcp.TextContent).Text
+	}
+
+	res, text := call("push_code_chunk", map[string]any{"session_id": sid, "name": "web", "files": map[string]any{"go.mod": "module web\n"}})
+	if res.IsError {
+		t.Fatalf("first chunk failed: %s", text)
+	}
+	var out struct {
+		UploadID string `json:"upload_id"`
+	}
+	if err := json.Unmarshal([]byte(text), &out); err != nil || out.UploadID == "" {
+		t.Fatalf("expected an upload_id, got %s", text)
+	}
+	res, text = call("push_code_chunk", map[string]any{
+		"session_id":   sid,
+		"name":         "web",
+		"upload_id":    out.UploadID,
+		"files":        map[string]any{"main.go": "package old"},
+		"binary_files": map[string]any{"static/logo.png": "iVBORw0KGgoA/w=="},
+	})
+	if res.IsError {
+		t.Fatalf("second chunk failed: %s", text)
+	}
+
+	// The upload belongs to its app.
+	res, text = call("push_code", map[string]any{"session_id": sid, "name": "other", "upload_id": out.UploadID})
+	if !res.IsError || !strings.Contains(text, "upload not found") {
+		t.Errorf("expected the upload not found for another app, got %s", text)
+	}
+
+	res, text = call("push_code", map[string]any{"session_id": sid, "name": "web", "upload_id": out.UploadID, "files": map[string]any{"main.go": "package main"}})
+	if res.IsError {
+		t.Fatalf("push_code with upload_id failed: %s", text)
+	}
+	var app iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "web", Namespace: ns}, &app); err != nil {
+		t.Fatal(err)
+	}
+	files, err := store.ReadFiles(ns, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.Spec.Blob == "" || len(files) != 3 || files["main.go"] != "package main" || files["static/logo.png"] != "\x89PNG\r\n\x1a\n\x00\xff" {
+		t.Errorf("expected the app to build from the upload, blob %q, files %q", app.Spec.Blob, files)
+	}
+
+	// The upload is removed once pushed.
+	res, text = call("push_code_chunk", map[string]any{"session_id": sid, "name": "web", "upload_id": out.UploadID, "files": map[string]any{"a.go": "package main"}})
+	if !res.IsError || !strings.Contains(text, "upload not found") {
+		t.Errorf("expected the pushed upload to be gone, got %s", text)
+	}
+}
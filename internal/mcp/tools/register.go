@@ -5,26 +5,35 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/dlapiduz/iaf/internal/auth"
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 type RegisterInput struct {
-	Name string `json:"name,omitempty" jsonschema:"optional friendly name for your workspace (e.g. 'my-project')"`
+	Name     string                         `json:"name,omitempty" jsonschema:"optional friendly name for your workspace (e.g. 'my-project')"`
+	Metadata *iafv1alpha1.OwnershipMetadata `json:"metadata,omitempty" jsonschema:"optional ownership of the apps you create in this session, as {team, owner, purpose, costCenter} (e.g. {team: 'payments', owner: 'jane@example.com'}); deploy_app and push_code use it when they are not given their own"`
 }
 
 func RegisterRegisterTool(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "register",
-		Description: "CALL THIS FIRST. Creates a new session and returns a session_id that is required by every other tool. You only need to call this once — store the session_id and pass it to all subsequent tool calls. Optionally provide a friendly name for your workspace.",
+	addTool(server, deps, Capability{
+		Name:     "register",
+		Category: CategorySession,
+		Summary:  "Get a session_id — call this first",
+		Examples: []string{`{"name": "my-agent"}`},
+	}, &gomcp.Tool{
+		Description: "CALL THIS FIRST. Creates a new session and returns a session_id that is required by every other tool. You only need to call this once — store the session_id and pass it to all subsequent tool calls. Optionally provide a friendly name for your workspace, and the team, owner, purpose and cost center the apps you create belong to.",
 	}, func(ctx context.Context, req *gomcp.CallToolRequest, input RegisterInput) (*gomcp.CallToolResult, any, error) {
-		sess, err := deps.Sessions.Register(input.Name, deps.SessionTTL)
+		if err := validateMetadata(input.Metadata); err != nil {
+			return nil, nil, err
+		}
+		sess, err := deps.Sessions.Provision(ctx, deps.Client, input.Name, deps.SessionTTL)
 		if err != nil {
 			return nil, nil, fmt.Errorf("registering session: %w", err)
 		}
-
-		if err := auth.EnsureNamespace(ctx, deps.Client, sess.Namespace); err != nil {
-			return nil, nil, fmt.Errorf("creating namespace: %w", err)
+		if input.Metadata != nil {
+			if err := deps.Sessions.SetMetadata(sess.ID, input.Metadata); err != nil {
+				return nil, nil, fmt.Errorf("registering session: %w", err)
+			}
 		}
 
 		result := map[string]any{
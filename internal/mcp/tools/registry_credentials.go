@@ -0,0 +1,245 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/audit"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AddRegistryCredentialInput is the input for the add_registry_credential tool.
+type AddRegistryCredentialInput struct {
+	SessionID      string `json:"session_id"      jsonschema:"required - session ID from the register tool"`
+	Name           string `json:"name"            jsonschema:"required - credential name (DNS label: lowercase alphanumeric and hyphens)"`
+	RegistryServer string `json:"registry_server" jsonschema:"required - registry host with optional port, as in image references (e.g. 'ghcr.io', 'registry.example.com:5000')"`
+	Username       string `json:"username"        jsonschema:"required - registry username"`
+	Password       string `json:"password"        jsonschema:"required - registry password or access token (max 4096 bytes)"`
+}
+
+// ListRegistryCredentialsInput is the input for the list_registry_credentials tool.
+type ListRegistryCredentialsInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID from the register tool"`
+}
+
+// DeleteRegistryCredentialInput is the input for the delete_registry_credential tool.
+type DeleteRegistryCredentialInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID from the register tool"`
+	Name      string `json:"name"       jsonschema:"required - credential name to delete"`
+}
+
+// RegisterAddRegistryCredential registers the add_registry_credential MCP tool.
+func RegisterAddRegistryCredential(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "add_registry_credential",
+		Category: CategoryCredentials,
+		Summary:  "Store a container registry credential for private images (pass as registry_credential to deploy_app)",
+	}, &gomcp.Tool{
+		Description: "Store a container registry credential in the session namespace. Builds use it to pull private base images and push to that registry; pass its name as registry_credential to deploy_app to run private images. Requires session_id. Credential material is never returned in any tool output. To rotate a credential, delete it and re-create it.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input AddRegistryCredentialInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := validation.ValidateAppName(input.Name); err != nil {
+			return nil, nil, fmt.Errorf("invalid credential name: %w", err)
+		}
+		if err := validation.ValidateRegistryServer(input.RegistryServer); err != nil {
+			return nil, nil, err
+		}
+		if input.Username == "" || strings.Contains(input.Username, ":") {
+			return nil, nil, fmt.Errorf("username is required and must not contain ':'")
+		}
+		if input.Password == "" {
+			return nil, nil, fmt.Errorf("password is required")
+		}
+		if len(input.Password) > maxPasswordLen {
+			return nil, nil, fmt.Errorf("password must be %d bytes or fewer", maxPasswordLen)
+		}
+
+		// Enforce per-session credential count limit, counted separately from git credentials.
+		var secretList corev1.SecretList
+		if err := deps.Client.List(ctx, &secretList,
+			client.InNamespace(namespace),
+			client.MatchingLabels{iafk8s.LabelCredentialType: iafk8s.CredentialTypeRegistry},
+		); err != nil {
+			return nil, nil, fmt.Errorf("listing registry credentials: %w", err)
+		}
+		if len(secretList.Items) >= maxCredentialsPerSession {
+			return nil, nil, apierror.Quota(apierror.CodeQuotaExceeded, "credential limit reached: a session may have at most %d registry credentials; delete an existing one before adding a new one", maxCredentialsPerSession)
+		}
+
+		secret := iafk8s.BuildRegistryCredentialSecret(namespace, input.Name, input.RegistryServer, input.Username, input.Password)
+		if err := deps.Client.Create(ctx, secret); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				return nil, nil, fmt.Errorf("credential %q already exists; delete it first to replace it", input.Name)
+			}
+			return nil, nil, fmt.Errorf("creating credential: %w", err)
+		}
+
+		// Patch the kpack SA. On failure, clean up the Secret before returning.
+		if err := iafk8s.AddSecretToKpackSA(ctx, deps.Client, namespace, input.Name); err != nil {
+			// Best-effort cleanup.
+			_ = deps.Client.Delete(ctx, secret)
+			return nil, nil, fmt.Errorf("registering credential with kpack service account: %w", err)
+		}
+		deps.SecurityAudit.Record(audit.SecurityEvent{
+			Action:    audit.ActionCredentialCreated,
+			Actor:     input.SessionID,
+			Namespace: namespace,
+			Kind:      "registry_credential",
+			Name:      input.Name,
+		})
+
+		result := map[string]any{
+			"name":            input.Name,
+			"registry_server": input.RegistryServer,
+			"created":         true,
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// RegisterListRegistryCredentials registers the list_registry_credentials MCP tool.
+func RegisterListRegistryCredentials(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "list_registry_credentials",
+		Category: CategoryCredentials,
+		ReadOnly: true,
+		Summary:  "List stored registry credentials (no secrets returned)",
+	}, &gomcp.Tool{
+		Description: "List all container registry credentials stored in the current session. Returns name and registry server — never credential material.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input ListRegistryCredentialsInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var secretList corev1.SecretList
+		if err := deps.Client.List(ctx, &secretList,
+			client.InNamespace(namespace),
+			client.MatchingLabels{iafk8s.LabelCredentialType: iafk8s.CredentialTypeRegistry},
+		); err != nil {
+			return nil, nil, fmt.Errorf("listing registry credentials: %w", err)
+		}
+
+		type credInfo struct {
+			Name           string `json:"name"`
+			RegistryServer string `json:"registry_server"`
+			CreatedAt      string `json:"created_at"`
+		}
+		creds := make([]credInfo, 0, len(secretList.Items))
+		for _, s := range secretList.Items {
+			var createdAt string
+			if !s.CreationTimestamp.IsZero() {
+				createdAt = s.CreationTimestamp.UTC().Format(time.RFC3339)
+			}
+			creds = append(creds, credInfo{
+				Name:           s.Name,
+				RegistryServer: s.Annotations[iafk8s.AnnotationRegistryServer],
+				CreatedAt:      createdAt,
+			})
+		}
+
+		result := map[string]any{
+			"credentials": creds,
+			"total":       len(creds),
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// RegisterDeleteRegistryCredential registers the delete_registry_credential MCP tool.
+func RegisterDeleteRegistryCredential(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:          "delete_registry_credential",
+		Category:      CategoryCredentials,
+		Summary:       "Remove a registry credential",
+		Preconditions: []string{"no app uses it as registry_credential"},
+	}, &gomcp.Tool{
+		Description: "Delete a container registry credential from the current session and remove it from the build service account. Fails while an application still uses it as registry_credential.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input DeleteRegistryCredentialInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if input.Name == "" {
+			return nil, nil, fmt.Errorf("name is required")
+		}
+
+		// Fetch the Secret and verify it is a registry credential (label guard).
+		secret := &corev1.Secret{}
+		if err := deps.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: input.Name}, secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeNotFound, "credential %q not found", input.Name)
+			}
+			return nil, nil, fmt.Errorf("getting credential: %w", err)
+		}
+		if secret.Labels[iafk8s.LabelCredentialType] != iafk8s.CredentialTypeRegistry {
+			return nil, nil, fmt.Errorf("secret %q is not a registry credential managed by IAF", input.Name)
+		}
+
+		// Deleting a credential still in use would leave pods unable to pull.
+		var apps iafv1alpha1.ApplicationList
+		if err := deps.Client.List(ctx, &apps, client.InNamespace(namespace)); err != nil {
+			return nil, nil, fmt.Errorf("listing applications: %w", err)
+		}
+		var users []string
+		for _, app := range apps.Items {
+			if app.Spec.RegistryCredential == input.Name {
+				users = append(users, app.Name)
+			}
+		}
+		if len(users) > 0 {
+			return nil, nil, fmt.Errorf("credential %q is used by %s; redeploy or delete those applications first", input.Name, strings.Join(users, ", "))
+		}
+
+		// Remove from kpack SA before deleting the Secret.
+		if err := iafk8s.RemoveSecretFromKpackSA(ctx, deps.Client, namespace, input.Name); err != nil {
+			return nil, nil, fmt.Errorf("removing credential from kpack service account: %w", err)
+		}
+
+		if err := deps.Client.Delete(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: input.Name, Namespace: namespace},
+		}); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeNotFound, "credential %q not found", input.Name)
+			}
+			return nil, nil, fmt.Errorf("deleting credential: %w", err)
+		}
+		deps.SecurityAudit.Record(audit.SecurityEvent{
+			Action:    audit.ActionCredentialDeleted,
+			Actor:     input.SessionID,
+			Namespace: namespace,
+			Kind:      "registry_credential",
+			Name:      input.Name,
+		})
+
+		result := map[string]any{
+			"name":    input.Name,
+			"deleted": true,
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
@@ -0,0 +1,258 @@
+package tools_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/audit"
+	"github.com/dlapiduz/iaf/internal/auth"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// setupRegistryCredServer creates a server with the registry credential tools
+// and deploy_app registered, recording its security audit in the file at the
+// returned path.
+func setupRegistryCredServer(t *testing.T) (*gomcp.ClientSession, client.Client, string) {
+	t.Helper()
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditPath := filepath.Join(t.TempDir(), "security.jsonl")
+	securityAudit, err := audit.NewSecurityLog("test", auditPath, nil, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := &tools.Dependencies{
+		Client:        k8sClient,
+		Store:         store,
+		BaseDomain:    "test.example.com",
+		Sessions:      sessions,
+		SecurityAudit: securityAudit,
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterAddRegistryCredential(server, deps)
+	tools.RegisterListRegistryCredentials(server, deps)
+	tools.RegisterDeleteRegistryCredential(server, deps)
+	tools.RegisterDeployApp(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mc := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mc.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs, k8sClient, auditPath
+}
+
+func TestRegistryCredential_Lifecycle(t *testing.T) {
+	cs, k8sClient, auditPath := setupRegistryCredServer(t)
+	ctx := context.Background()
+	sid, namespace := registerCredSession(t, cs, k8sClient)
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name: "add_registry_credential",
+		Arguments: map[string]any{
+			"session_id":      sid,
+			"name":            "ghcr",
+			"registry_server": "ghcr.io",
+			"username":        "bot",
+			"password":        "s3cr3t-token",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+
+	secret := &corev1.Secret{}
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "ghcr"}, secret); err != nil {
+		t.Fatalf("secret not created: %v", err)
+	}
+	if secret.Type != corev1.SecretTypeDockerConfigJson {
+		t.Errorf("expected dockerconfigjson secret, got %s", secret.Type)
+	}
+	if secret.Annotations[iafk8s.AnnotationKpackDocker] != "ghcr.io" {
+		t.Errorf("expected kpack.io/docker annotation, got %v", secret.Annotations)
+	}
+	if !strings.Contains(secret.StringData[corev1.DockerConfigJsonKey], `"ghcr.io"`) {
+		t.Errorf("expected docker config for ghcr.io, got %q", secret.StringData[corev1.DockerConfigJsonKey])
+	}
+	sa := &corev1.ServiceAccount{}
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: iafk8s.KpackServiceAccount}, sa); err != nil {
+		t.Fatal(err)
+	}
+	if len(sa.Secrets) != 1 || sa.Secrets[0].Name != "ghcr" {
+		t.Errorf("expected credential on kpack SA, got %v", sa.Secrets)
+	}
+
+	res, err = cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "list_registry_credentials",
+		Arguments: map[string]any{"session_id": sid},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := res.Content[0].(*gomcp.TextContent).Text
+	if strings.Contains(text, "s3cr3t-token") {
+		t.Error("list output must not contain credential material")
+	}
+	var list map[string]any
+	json.Unmarshal([]byte(text), &list)
+	if list["total"] != float64(1) {
+		t.Errorf("expected 1 credential, got %v", list["total"])
+	}
+
+	res, err = cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name: "deploy_app",
+		Arguments: map[string]any{
+			"session_id":          sid,
+			"name":                "private-app",
+			"image":               "ghcr.io/team/app:1",
+			"registry_credential": "ghcr",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	var app iafv1alpha1.Application
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "private-app"}, &app); err != nil {
+		t.Fatal(err)
+	}
+	if app.Spec.RegistryCredential != "ghcr" {
+		t.Errorf("expected registryCredential ghcr, got %q", app.Spec.RegistryCredential)
+	}
+
+	// In use: deletion is refused.
+	res, err = cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "delete_registry_credential",
+		Arguments: map[string]any{"session_id": sid, "name": "ghcr"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsError {
+		t.Fatal("expected deleting a credential in use to fail")
+	}
+
+	if err := k8sClient.Delete(ctx, &app); err != nil {
+		t.Fatal(err)
+	}
+	res, err = cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "delete_registry_credential",
+		Arguments: map[string]any{"session_id": sid, "name": "ghcr"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: iafk8s.KpackServiceAccount}, sa); err != nil {
+		t.Fatal(err)
+	}
+	if len(sa.Secrets) != 0 {
+		t.Errorf("expected credential removed from kpack SA, got %v", sa.Secrets)
+	}
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "s3cr3t-token") {
+		t.Error("the security audit must not contain credential material")
+	}
+	if n, err := audit.VerifySecurityLog(bytes.NewReader(data)); n != 2 || err != nil {
+		t.Fatalf("expected an intact stream of 2 events, got %d, %v", n, err)
+	}
+	var events []audit.SecurityEvent
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e audit.SecurityEvent
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e)
+	}
+	for i, want := range []audit.SecurityAction{audit.ActionCredentialCreated, audit.ActionCredentialDeleted} {
+		e := events[i]
+		if e.Action != want || e.Actor != sid || e.Namespace != namespace || e.Kind != "registry_credential" || e.Name != "ghcr" {
+			t.Errorf("event %d = %+v, want %s of ghcr by the session", i, e, want)
+		}
+	}
+}
+
+func TestAddRegistryCredential_Validation(t *testing.T) {
+	cs, k8sClient, _ := setupRegistryCredServer(t)
+	ctx := context.Background()
+	sid, _ := registerCredSession(t, cs, k8sClient)
+
+	tests := []struct {
+		name string
+		args map[string]any
+	}{
+		{"scheme in server", map[string]any{"registry_server": "https://ghcr.io", "username": "u", "password": "p"}},
+		{"empty password", map[string]any{"registry_server": "ghcr.io", "username": "u", "password": ""}},
+		{"colon in username", map[string]any{"registry_server": "ghcr.io", "username": "a:b", "password": "p"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args["session_id"] = sid
+			tt.args["name"] = "cred"
+			res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "add_registry_credential", Arguments: tt.args})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !res.IsError {
+				t.Error("expected a validation error")
+			}
+		})
+	}
+
+	// deploy_app rejects secrets that are not registry credentials.
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "deploy_app",
+		Arguments: map[string]any{"session_id": sid, "name": "app", "image": "nginx", "registry_credential": "missing"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsError {
+		t.Error("expected an unknown registry credential to be rejected")
+	}
+}
@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type RestoreAppInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Name      string `json:"name" jsonschema:"required - application name to restore"`
+}
+
+func RegisterRestoreApp(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:          "restore_app",
+		Category:      CategoryDeploy,
+		Summary:       "Bring back an app removed with delete_app",
+		Preconditions: []string{"the app was deleted with delete_app and its retention window has not passed"},
+	}, &gomcp.Tool{
+		Description: "Restore an application deleted with delete_app before its retention window passes (app_status reports purgeAt): restores its configured replicas with the same image, configuration and source. The app passes through Deploying before it is Running again; poll app_status to follow it. Apps deleted with purge=true or whose window has passed cannot be restored. Requires session_id from the register tool and the application name.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input RestoreAppInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.Name); err != nil {
+			return nil, nil, err
+		}
+
+		app := &iafv1alpha1.Application{}
+		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, app); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.Name).
+					WithHint("an app deleted with purge=true or whose retention window has passed cannot be restored; deploy it again")
+			}
+			return nil, nil, fmt.Errorf("getting application: %w", err)
+		}
+		if _, deleted := app.Annotations[iafv1alpha1.DeletedAtAnnotation]; !deleted {
+			return nil, nil, apierror.Conflict(apierror.CodeConflict, "application %q is not deleted", input.Name)
+		}
+
+		delete(app.Annotations, iafv1alpha1.DeletedAtAnnotation)
+		if err := deps.Client.Update(ctx, app); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.Name)
+			}
+			return nil, nil, fmt.Errorf("restoring application: %w", err)
+		}
+
+		result := map[string]any{
+			"name":    input.Name,
+			"status":  "restored",
+			"message": fmt.Sprintf("Application %q is restored and scaling back up. Poll app_status until it is Running.", input.Name),
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
@@ -0,0 +1,135 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDeleteRestoreApp(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		Store:      store,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+	}
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterDeleteApp(server, deps)
+	tools.RegisterRestoreApp(server, deps)
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	cs, err := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil).Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	sid, ns := registerDSSession(t, cs)
+
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: ns},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:alpine", Replicas: 1},
+	}
+	if err := k8sClient.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.StoreFiles(ns, "web", map[string]string{"main.go": "package main"}); err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(tool string, args map[string]any) (map[string]any, bool) {
+		t.Helper()
+		args["session_id"] = sid
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: tool, Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out map[string]any
+		if !res.IsError {
+			json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &out)
+		}
+		return out, res.IsError
+	}
+	key := types.NamespacedName{Name: "web", Namespace: ns}
+	deletedAt := func() (string, bool) {
+		t.Helper()
+		var got iafv1alpha1.Application
+		if err := k8sClient.Get(ctx, key, &got); err != nil {
+			t.Fatal(err)
+		}
+		v, ok := got.Annotations[iafv1alpha1.DeletedAtAnnotation]
+		return v, ok
+	}
+
+	if _, isErr := call("restore_app", map[string]any{"name": "web"}); !isErr {
+		t.Error("expected restore_app to refuse an app that is not deleted")
+	}
+
+	// delete_app marks the app deleted and keeps its source.
+	if out, isErr := call("delete_app", map[string]any{"name": "web"}); isErr || out["status"] != "deleted" {
+		t.Fatalf("delete_app failed: %v", out)
+	}
+	first, ok := deletedAt()
+	if !ok {
+		t.Fatal("expected the app to be marked deleted")
+	}
+	if _, err := store.ReadFiles(ns, "web"); err != nil {
+		t.Errorf("expected the source to be kept, got %v", err)
+	}
+	if _, isErr := call("delete_app", map[string]any{"name": "web"}); isErr {
+		t.Error("expected a repeated delete to be a no-op")
+	}
+	if again, _ := deletedAt(); again != first {
+		t.Errorf("expected a repeated delete to keep the deletion time %q, got %q", first, again)
+	}
+
+	if out, isErr := call("restore_app", map[string]any{"name": "web"}); isErr || out["status"] != "restored" {
+		t.Fatalf("restore_app failed: %v", out)
+	}
+	if _, ok := deletedAt(); ok {
+		t.Error("expected restore_app to clear the deletion mark")
+	}
+
+	// purge removes the app and its source at once.
+	if out, isErr := call("delete_app", map[string]any{"name": "web", "purge": true}); isErr || out["status"] != "purged" {
+		t.Fatalf("delete_app purge failed: %v", out)
+	}
+	if err := k8sClient.Get(ctx, key, &iafv1alpha1.Application{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the app to be deleted, got %v", err)
+	}
+	if _, err := store.ReadFiles(ns, "web"); err == nil {
+		t.Error("expected the source to be deleted")
+	}
+	if _, isErr := call("restore_app", map[string]any{"name": "web"}); !isErr {
+		t.Error("expected restore_app to fail for a purged app")
+	}
+}
@@ -0,0 +1,25 @@
+package tools
+
+import (
+	"context"
+
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type ResumeAppInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Name      string `json:"name" jsonschema:"required - application name to resume"`
+}
+
+func RegisterResumeApp(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:          "resume_app",
+		Category:      CategoryDeploy,
+		Summary:       "Restore an app parked with suspend_app",
+		Preconditions: []string{"the app was suspended with suspend_app"},
+	}, &gomcp.Tool{
+		Description: "Resume an application parked with suspend_app: restores its configured replicas with the same image and configuration. The app passes through Deploying before it is Running again; poll app_status to follow it. Requires session_id from the register tool and the application name.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input ResumeAppInput) (*gomcp.CallToolResult, any, error) {
+		return setAppSuspended(ctx, deps, input.SessionID, input.Name, false)
+	})
+}
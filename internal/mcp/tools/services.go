@@ -4,11 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/features"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
 	"github.com/dlapiduz/iaf/internal/validation"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -24,46 +33,148 @@ var serviceEnvVarNames = []string{
 	"PGPASSWORD",
 }
 
+// serviceReadEnvVarNames are the env vars injected for the read replicas
+// of a postgres service bound with bind_mode ro or both.
+var serviceReadEnvVarNames = []string{"PGHOST_RO", "DATABASE_READ_URL"}
+
+// serviceFormatEnvVarNames are the env vars each connection format injects
+// next to DATABASE_URL.
+var serviceFormatEnvVarNames = map[iafv1alpha1.ConnectionFormat][]string{
+	iafv1alpha1.ConnectionFormatJDBC:       {"JDBC_DATABASE_URL", "JDBC_DATABASE_USERNAME", "JDBC_DATABASE_PASSWORD"},
+	iafv1alpha1.ConnectionFormatSQLAlchemy: {"SQLALCHEMY_DATABASE_URI"},
+}
+
+// serviceFormats returns the connection formats bind_service accepts.
+func serviceFormats() []string {
+	var formats []string
+	for format := range serviceFormatEnvVarNames {
+		formats = append(formats, string(format))
+	}
+	slices.Sort(formats)
+	return formats
+}
+
+// maxEnvPrefixLength is the longest env_prefix bind_service accepts.
+const maxEnvPrefixLength = 32
+
+// serviceBindingRoot is where bindings projected as files are mounted, one
+// directory per service; the controller sets SERVICE_BINDING_ROOT to it.
+const serviceBindingRoot = "/bindings"
+
+// bindEnvVarNames returns the env vars a binding injects.
+func bindEnvVarNames(bms iafv1alpha1.BoundManagedService) []string {
+	if !iafv1alpha1.BindingProjectsEnv(bms) {
+		return []string{}
+	}
+	var names []string
+	switch bms.Mode {
+	case iafv1alpha1.BindModeReadOnly:
+		names = append([]string{"PGPORT", "PGDATABASE", "PGUSER", "PGPASSWORD"}, serviceReadEnvVarNames...)
+	case iafv1alpha1.BindModeBoth:
+		names = append(slices.Clone(serviceEnvVarNames), serviceReadEnvVarNames...)
+	default:
+		names = slices.Clone(serviceEnvVarNames)
+	}
+	for _, format := range bms.Formats {
+		names = append(names, serviceFormatEnvVarNames[format]...)
+	}
+	for i := range names {
+		names[i] = bms.EnvPrefix + names[i]
+	}
+	return names
+}
+
+// envPrefixFor suggests an env_prefix for a service name, such as ORDERS_DB
+// for "orders-db".
+func envPrefixFor(service string) string {
+	return strings.ToUpper(strings.ReplaceAll(service, "-", "_"))
+}
+
+// hasReadReplicas reports whether the plan of svc runs read replicas, as
+// the controller last recorded it, or for services it has not reconciled
+// since recording plans, as the built-in plan says.
+func hasReadReplicas(svc *iafv1alpha1.ManagedService) bool {
+	if svc.Status.Instances > 0 {
+		return svc.Status.Instances > 1
+	}
+	plan, ok := iafk8s.BuiltinServicePlan(svc.Spec.Plan)
+	return ok && plan.Spec.Instances > 1
+}
+
 // validServiceTypes is the set of supported managed service types.
 var validServiceTypes = map[string]bool{
 	"postgres": true,
 }
 
-// validServicePlans is the set of supported service plans.
-var validServicePlans = map[iafv1alpha1.ServicePlan]bool{
-	iafv1alpha1.ServicePlanMicro: true,
-	iafv1alpha1.ServicePlanSmall: true,
-	iafv1alpha1.ServicePlanHA:    true,
-}
-
 // --- provision_service ---
 
 type ProvisionServiceInput struct {
-	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
-	Name      string `json:"name" jsonschema:"required - service name (lowercase, hyphens allowed)"`
-	Type      string `json:"type" jsonschema:"required - service type: 'postgres'"`
-	Plan      string `json:"plan" jsonschema:"required - service plan: 'micro' (1 instance, 1Gi), 'small' (1 instance, 5Gi), 'ha' (3 instances, 10Gi)"`
+	SessionID         string                  `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Name              string                  `json:"name" jsonschema:"required - service name (lowercase, hyphens allowed)"`
+	Type              string                  `json:"type" jsonschema:"required - service type: 'postgres'"`
+	Plan              string                  `json:"plan" jsonschema:"required - service plan, such as 'micro' (1 instance, 1Gi), 'small' (1 instance, 5Gi) or 'ha' (3 instances, 10Gi); list_service_plans lists the plans offered"`
+	TTL               string                  `json:"ttl,omitempty" jsonschema:"delete the service and its data automatically this long after it is created (e.g. '72h'; 10m to 720h). Deletion waits until no apps are bound; default: never"`
+	Version           string                  `json:"version,omitempty" jsonschema:"PostgreSQL major version (e.g. '16'). It cannot be changed later. Default: the newest version the platform offers"`
+	MaintenanceWindow *MaintenanceWindowInput `json:"maintenance_window,omitempty" jsonschema:"when minor version upgrades, which restart the database, may start. Default: as soon as a new minor release is offered"`
+	IdempotencyKey    string                  `json:"idempotency_key,omitempty" jsonschema:"optional key you choose, such as a UUID, that makes retrying safe: repeating the call with the same key and input within 24 hours returns the original result instead of failing because the service exists. Use a new key for each distinct service"`
+}
+
+// MaintenanceWindowInput is a weekly or daily maintenance window in UTC.
+type MaintenanceWindowInput struct {
+	Day      string `json:"day,omitempty" jsonschema:"weekday the window opens on (e.g. 'Sunday'). Default: every day"`
+	Start    string `json:"start" jsonschema:"required - UTC time of day the window opens, as HH:MM (e.g. '03:00')"`
+	Duration string `json:"duration,omitempty" jsonschema:"how long the window stays open (30m to 24h). Default: 1h"`
+}
+
+func (in ProvisionServiceInput) idempotencyKey() (sessionID, key string) {
+	return in.SessionID, in.IdempotencyKey
 }
 
 // RegisterProvisionService registers the provision_service MCP tool.
 func RegisterProvisionService(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "provision_service",
+	addTool(server, deps, Capability{
+		Name:     "provision_service",
+		Category: CategoryData,
+		Summary:  "Provision a managed backing service such as PostgreSQL; poll service_status every 10s until Ready",
+		Examples: []string{`{"session_id": "<id>", "name": "db", "type": "postgres", "plan": "micro"}`},
+	}, &gomcp.Tool{
 		Description: "Provision a managed backing service (e.g. PostgreSQL). Returns immediately; the service provisions asynchronously. Poll service_status every 10s until phase is Ready, then use bind_service to connect it to an application.",
-	}, func(ctx context.Context, req *gomcp.CallToolRequest, input ProvisionServiceInput) (*gomcp.CallToolResult, any, error) {
+	}, idempotent(deps, "provision_service", func(ctx context.Context, req *gomcp.CallToolRequest, input ProvisionServiceInput) (*gomcp.CallToolResult, any, error) {
 		namespace, err := deps.ResolveNamespace(input.SessionID)
 		if err != nil {
 			return nil, nil, err
 		}
+		if err := deps.RequireFeature(features.ManagedServices); err != nil {
+			return nil, nil, err
+		}
 		if err := validation.ValidateAppName(input.Name); err != nil {
 			return nil, nil, fmt.Errorf("invalid service name: %w", err)
 		}
 		if !validServiceTypes[input.Type] {
 			return nil, nil, fmt.Errorf("unsupported service type %q — supported types: postgres", input.Type)
 		}
-		plan := iafv1alpha1.ServicePlan(input.Plan)
-		if !validServicePlans[plan] {
-			return nil, nil, fmt.Errorf("unsupported plan %q — supported plans: micro, small, ha", input.Plan)
+		plan := iafv1alpha1.ServicePlanName(input.Plan)
+		if _, err := iafk8s.LookupServicePlan(ctx, deps.Client, input.Type, plan); err != nil {
+			return nil, nil, err
+		}
+		var ttl *metav1.Duration
+		if input.TTL != "" {
+			d, err := validation.ValidateTTL(input.TTL)
+			if err != nil {
+				return nil, nil, err
+			}
+			ttl = &metav1.Duration{Duration: d}
+		}
+		if input.Version != "" && (len(input.Version) > 3 || strings.Trim(input.Version, "0123456789") != "") {
+			return nil, nil, apierror.Validation(apierror.CodeInvalidRequest, "version %q is invalid: use a PostgreSQL major version such as '16'", input.Version)
+		}
+		var window *iafv1alpha1.MaintenanceWindow
+		if w := input.MaintenanceWindow; w != nil {
+			day, d, err := validation.ValidateMaintenanceWindow(w.Day, w.Start, w.Duration)
+			if err != nil {
+				return nil, nil, err
+			}
+			window = &iafv1alpha1.MaintenanceWindow{Day: day, Start: w.Start, Duration: metav1.Duration{Duration: d}}
 		}
 
 		svc := &iafv1alpha1.ManagedService{
@@ -72,13 +183,16 @@ func RegisterProvisionService(server *gomcp.Server, deps *Dependencies) {
 				Namespace: namespace,
 			},
 			Spec: iafv1alpha1.ManagedServiceSpec{
-				Type: input.Type,
-				Plan: plan,
+				Type:              input.Type,
+				Plan:              plan,
+				TTL:               ttl,
+				Version:           input.Version,
+				MaintenanceWindow: window,
 			},
 		}
 		if err := deps.Client.Create(ctx, svc); err != nil {
 			if apierrors.IsAlreadyExists(err) {
-				return nil, nil, fmt.Errorf("service %q already exists", input.Name)
+				return nil, nil, apierror.Conflict(apierror.CodeNameTaken, "service %q already exists", input.Name)
 			}
 			return nil, nil, fmt.Errorf("provisioning service: %w", err)
 		}
@@ -93,7 +207,7 @@ func RegisterProvisionService(server *gomcp.Server, deps *Dependencies) {
 		return &gomcp.CallToolResult{
 			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
 		}, nil, nil
-	})
+	}))
 }
 
 // --- service_status ---
@@ -101,13 +215,18 @@ func RegisterProvisionService(server *gomcp.Server, deps *Dependencies) {
 type ServiceStatusInput struct {
 	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
 	Name      string `json:"name" jsonschema:"required - service name"`
+	Format    string `json:"format,omitempty" jsonschema:"connection format to list the env vars of: 'jdbc' or 'sqlalchemy'. Default: none"`
 }
 
 // RegisterServiceStatus registers the service_status MCP tool.
 func RegisterServiceStatus(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "service_status",
-		Description: "Get the current status of a managed service. When phase is Ready, also returns the list of environment variable names that will be injected when you call bind_service.",
+	addTool(server, deps, Capability{
+		Name:     "service_status",
+		Category: CategoryData,
+		ReadOnly: true,
+		Summary:  "Provisioning status of a service; lists connectionEnvVars when Ready",
+	}, &gomcp.Tool{
+		Description: "Get the current status of a managed service. When phase is Ready, also returns the list of environment variable names that will be injected when you call bind_service, and readEnvVars when the plan has read replicas. Lists the connection formats bind_service can also inject, or with format, the env vars of that format in formatEnvVars. While provisioning, progress shows instancesReady, volumesBound, the CloudNativePG clusterPhase, currentPrimary and recent events, so a volume that cannot be provisioned or an image that cannot be pulled shows up as a Warning event instead of a long wait. For PostgreSQL, reports currentVersion, targetVersion (the newest minor release offered; an upgrade to it is pending while they differ), the maintenance window and recent upgrades.",
 	}, func(ctx context.Context, req *gomcp.CallToolRequest, input ServiceStatusInput) (*gomcp.CallToolResult, any, error) {
 		namespace, err := deps.ResolveNamespace(input.SessionID)
 		if err != nil {
@@ -116,11 +235,14 @@ func RegisterServiceStatus(server *gomcp.Server, deps *Dependencies) {
 		if err := validation.ValidateAppName(input.Name); err != nil {
 			return nil, nil, fmt.Errorf("invalid service name: %w", err)
 		}
+		if _, ok := serviceFormatEnvVarNames[iafv1alpha1.ConnectionFormat(input.Format)]; input.Format != "" && !ok {
+			return nil, nil, apierror.Validation(apierror.CodeInvalidRequest, "format %q is invalid: use one of %s", input.Format, strings.Join(serviceFormats(), ", "))
+		}
 
 		var svc iafv1alpha1.ManagedService
 		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, &svc); err != nil {
 			if apierrors.IsNotFound(err) {
-				return nil, nil, fmt.Errorf("service %q not found", input.Name)
+				return nil, nil, apierror.NotFound(apierror.CodeNotFound, "service %q not found", input.Name)
 			}
 			return nil, nil, fmt.Errorf("getting service: %w", err)
 		}
@@ -134,6 +256,20 @@ func RegisterServiceStatus(server *gomcp.Server, deps *Dependencies) {
 		}
 		if svc.Status.Phase == iafv1alpha1.ManagedServicePhaseReady {
 			result["connectionEnvVars"] = serviceEnvVarNames
+			if hasReadReplicas(&svc) {
+				result["readEnvVars"] = serviceReadEnvVarNames
+			}
+			if input.Format != "" {
+				result["formatEnvVars"] = serviceFormatEnvVarNames[iafv1alpha1.ConnectionFormat(input.Format)]
+			} else {
+				result["formats"] = serviceFormats()
+			}
+		}
+		addProgress(result, &svc)
+		addVersions(result, &svc)
+		addExpiry(result, svc.Status.ExpiresAt, svc.Status.Conditions)
+		if svc.Spec.DeletionProtected {
+			result["deletionProtected"] = true
 		}
 
 		text, _ := json.MarshalIndent(result, "", "  ")
@@ -143,19 +279,92 @@ func RegisterServiceStatus(server *gomcp.Server, deps *Dependencies) {
 	})
 }
 
+// addProgress adds the progress of the database cluster of svc to a
+// service_status result.
+func addProgress(result map[string]any, svc *iafv1alpha1.ManagedService) {
+	p := svc.Status.Progress
+	if p == nil {
+		return
+	}
+	progress := map[string]any{
+		"instancesReady": fmt.Sprintf("%d/%d", p.ReadyInstances, svc.Status.Instances),
+		"volumesBound":   fmt.Sprintf("%d/%d", p.VolumesBound, p.Volumes),
+	}
+	if p.ClusterPhase != "" {
+		progress["clusterPhase"] = p.ClusterPhase
+	}
+	if p.ClusterPhaseReason != "" {
+		progress["clusterPhaseReason"] = p.ClusterPhaseReason
+	}
+	if p.CurrentPrimary != "" {
+		progress["currentPrimary"] = p.CurrentPrimary
+	}
+	if len(p.Events) > 0 {
+		var events []map[string]any
+		for _, e := range p.Events {
+			events = append(events, map[string]any{
+				"time":    e.Time.UTC().Format(time.RFC3339),
+				"type":    e.Type,
+				"reason":  e.Reason,
+				"object":  e.Object,
+				"message": e.Message,
+			})
+		}
+		progress["events"] = events
+	}
+	result["progress"] = progress
+}
+
+// addVersions adds the PostgreSQL versions of svc, its maintenance window
+// and its recent upgrades to a service_status result.
+func addVersions(result map[string]any, svc *iafv1alpha1.ManagedService) {
+	if svc.Status.CurrentVersion != "" {
+		result["currentVersion"] = svc.Status.CurrentVersion
+	}
+	if svc.Status.TargetVersion != "" {
+		result["targetVersion"] = svc.Status.TargetVersion
+	}
+	if w := svc.Spec.MaintenanceWindow; w != nil {
+		window := map[string]any{"start": w.Start + " UTC", "duration": w.Duration.Duration.String()}
+		if w.Day != "" {
+			window["day"] = w.Day
+		}
+		result["maintenanceWindow"] = window
+	}
+	if c := meta.FindStatusCondition(svc.Status.Conditions, "UpgradePending"); c != nil && c.Reason != "UpToDate" {
+		result["upgrade"] = c.Message
+	}
+	if len(svc.Status.UpgradeHistory) > 0 {
+		var history []map[string]any
+		for _, u := range svc.Status.UpgradeHistory {
+			history = append(history, map[string]any{"from": u.From, "to": u.To, "startedAt": u.StartedAt.UTC().Format(time.RFC3339)})
+		}
+		result["upgradeHistory"] = history
+	}
+}
+
 // --- bind_service ---
 
 type BindServiceInput struct {
-	SessionID   string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
-	ServiceName string `json:"service_name" jsonschema:"required - name of the managed service"`
-	AppName     string `json:"app_name" jsonschema:"required - name of the application to bind to"`
+	SessionID   string   `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	ServiceName string   `json:"service_name" jsonschema:"required - name of the managed service"`
+	AppName     string   `json:"app_name" jsonschema:"required - name of the application to bind to"`
+	BindMode    string   `json:"bind_mode,omitempty" jsonschema:"endpoints to inject: 'rw' (the primary, default), 'ro' (the read replicas: PGHOST_RO, DATABASE_READ_URL and the credentials) or 'both'. ro and both need a plan with more than one instance, such as 'ha'"`
+	EnvPrefix   string   `json:"env_prefix,omitempty" jsonschema:"prefix for the injected env var names (e.g. 'ORDERS_' gives ORDERS_DATABASE_URL), needed to bind a second service to the same app. Default: none"`
+	Format      []string `json:"format,omitempty" jsonschema:"connection string variants to also inject for frameworks that want another format: 'jdbc' (JDBC_DATABASE_URL, JDBC_DATABASE_USERNAME, JDBC_DATABASE_PASSWORD) and 'sqlalchemy' (SQLALCHEMY_DATABASE_URI). They describe the primary. Default: none"`
+	Projection  string   `json:"projection,omitempty" jsonschema:"how credentials reach the app: 'env' (env vars, default), 'files' (a servicebinding.io binding directory at /bindings/<service_name>, with SERVICE_BINDING_ROOT=/bindings) or 'both'. The binding directory describes the primary"`
 }
 
 // RegisterBindService registers the bind_service MCP tool.
 func RegisterBindService(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "bind_service",
-		Description: "Bind a ready managed service to an application. Injects connection credentials as Kubernetes Secret references into the application's environment variables (DATABASE_URL, PGHOST, PGPORT, PGDATABASE, PGUSER, PGPASSWORD). The service must be in Ready phase.",
+	addTool(server, deps, Capability{
+		Name:          "bind_service",
+		Category:      CategoryData,
+		Summary:       "Inject a service's credentials into an app as Secret references",
+		Preconditions: []string{"the service is Ready (service_status)"},
+		Examples:      []string{`{"session_id": "<id>", "service_name": "db", "app_name": "web"}`},
+	}, &gomcp.Tool{
+		Description: "Bind a ready managed service to an application. Injects connection credentials as Kubernetes Secret references into the application's environment variables (DATABASE_URL, PGHOST, PGPORT, PGDATABASE, PGUSER, PGPASSWORD). On a plan with more than one instance, such as 'ha', bind_mode 'ro' or 'both' also injects PGHOST_RO and DATABASE_READ_URL, which reach the read replicas; send read-only queries there to offload the primary. 'ro' leaves out DATABASE_URL and PGHOST. To bind several services to one app, give each an env_prefix such as 'ORDERS_', which is prepended to every injected name. For frameworks that read the Service Binding spec (servicebinding.io), such as Spring Cloud Bindings or Quarkus, set projection 'files' or 'both' to mount the credentials as a binding directory under SERVICE_BINDING_ROOT. For stacks that want another connection string format, format ['jdbc'] adds JDBC_DATABASE_URL and format ['sqlalchemy'] adds SQLALCHEMY_DATABASE_URI; Rails and most other frameworks read DATABASE_URL as is. The service must be in Ready phase.",
 	}, func(ctx context.Context, req *gomcp.CallToolRequest, input BindServiceInput) (*gomcp.CallToolResult, any, error) {
 		namespace, err := deps.ResolveNamespace(input.SessionID)
 		if err != nil {
@@ -167,18 +376,61 @@ func RegisterBindService(server *gomcp.Server, deps *Dependencies) {
 		if err := validation.ValidateAppName(input.AppName); err != nil {
 			return nil, nil, fmt.Errorf("invalid app name: %w", err)
 		}
+		mode := iafv1alpha1.BindMode(input.BindMode)
+		switch mode {
+		case "", iafv1alpha1.BindModeReadWrite:
+			mode = ""
+		case iafv1alpha1.BindModeReadOnly, iafv1alpha1.BindModeBoth:
+		default:
+			return nil, nil, apierror.Validation(apierror.CodeInvalidRequest, "bind_mode %q is invalid: use 'rw', 'ro' or 'both'", input.BindMode)
+		}
+		projection := iafv1alpha1.BindingProjection(input.Projection)
+		switch projection {
+		case "", iafv1alpha1.BindingProjectionEnv:
+			projection = ""
+		case iafv1alpha1.BindingProjectionFiles, iafv1alpha1.BindingProjectionBoth:
+			// The binding directory has the primary's host: read replicas
+			// are only reachable through the env vars.
+			if mode == iafv1alpha1.BindModeReadOnly || (mode == iafv1alpha1.BindModeBoth && projection == iafv1alpha1.BindingProjectionFiles) {
+				return nil, nil, apierror.Validation(apierror.CodeInvalidRequest, "projection %q describes the primary only and cannot carry bind_mode %q: use projection 'both' with bind_mode 'both' to get the read replicas as env vars", projection, mode)
+			}
+		default:
+			return nil, nil, apierror.Validation(apierror.CodeInvalidRequest, "projection %q is invalid: use 'env', 'files' or 'both'", input.Projection)
+		}
+		var formats []iafv1alpha1.ConnectionFormat
+		for _, f := range input.Format {
+			format := iafv1alpha1.ConnectionFormat(f)
+			if _, ok := serviceFormatEnvVarNames[format]; !ok {
+				return nil, nil, apierror.Validation(apierror.CodeInvalidRequest, "format %q is invalid: use one of %s", f, strings.Join(serviceFormats(), ", "))
+			}
+			if slices.Contains(formats, format) {
+				return nil, nil, apierror.Validation(apierror.CodeInvalidRequest, "format %q is listed twice", f)
+			}
+			formats = append(formats, format)
+		}
+		if len(formats) > 0 && (mode == iafv1alpha1.BindModeReadOnly || projection == iafv1alpha1.BindingProjectionFiles) {
+			return nil, nil, apierror.Validation(apierror.CodeInvalidRequest, "format injects env vars for the primary: it needs bind_mode 'rw' or 'both' and projection 'env' or 'both'")
+		}
+		if input.EnvPrefix != "" {
+			if err := validation.ValidateEnvVarName(input.EnvPrefix); err != nil || len(input.EnvPrefix) > maxEnvPrefixLength {
+				return nil, nil, apierror.Validation(apierror.CodeInvalidRequest, "env_prefix %q is invalid: use at most %d letters, digits and underscores, not starting with a digit, such as 'ORDERS_'", input.EnvPrefix, maxEnvPrefixLength)
+			}
+		}
 
 		// Fetch and validate the service.
 		var svc iafv1alpha1.ManagedService
 		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.ServiceName, Namespace: namespace}, &svc); err != nil {
 			if apierrors.IsNotFound(err) {
-				return nil, nil, fmt.Errorf("service %q not found", input.ServiceName)
+				return nil, nil, apierror.NotFound(apierror.CodeNotFound, "service %q not found", input.ServiceName)
 			}
 			return nil, nil, fmt.Errorf("getting service: %w", err)
 		}
 		if svc.Status.Phase != iafv1alpha1.ManagedServicePhaseReady {
 			return nil, nil, fmt.Errorf("service %q is not ready (phase: %s) — poll service_status until phase is Ready", input.ServiceName, svc.Status.Phase)
 		}
+		if mode != "" && !hasReadReplicas(&svc) {
+			return nil, nil, apierror.Validation(apierror.CodeInvalidRequest, "service %q has no read replicas on the %q plan; bind_mode %q needs a plan with more than one instance, such as 'ha', so bind it with 'rw'", input.ServiceName, svc.Spec.Plan, mode)
+		}
 
 		// Validate the secret name matches the expected CNPG convention.
 		expectedSecret := input.ServiceName + "-app"
@@ -191,7 +443,7 @@ func RegisterBindService(server *gomcp.Server, deps *Dependencies) {
 		var app iafv1alpha1.Application
 		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.AppName, Namespace: namespace}, &app); err != nil {
 			if apierrors.IsNotFound(err) {
-				return nil, nil, fmt.Errorf("application %q not found", input.AppName)
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.AppName)
 			}
 			return nil, nil, fmt.Errorf("getting application: %w", err)
 		}
@@ -202,12 +454,34 @@ func RegisterBindService(server *gomcp.Server, deps *Dependencies) {
 				return nil, nil, fmt.Errorf("service %q is already bound to application %q", input.ServiceName, input.AppName)
 			}
 		}
-
-		// Record the binding; the controller injects PG* env vars from the Secret.
-		app.Spec.BoundManagedServices = append(app.Spec.BoundManagedServices, iafv1alpha1.BoundManagedService{
+		binding := iafv1alpha1.BoundManagedService{
 			ServiceName: input.ServiceName,
 			SecretName:  secretName,
-		})
+			Mode:        mode,
+			EnvPrefix:   input.EnvPrefix,
+			Projection:  projection,
+			Formats:     formats,
+		}
+		// Two bindings must not inject the same variable, nor override the app's own.
+		injected := bindEnvVarNames(binding)
+		for _, bms := range app.Spec.BoundManagedServices {
+			for _, name := range bindEnvVarNames(bms) {
+				if slices.Contains(injected, name) {
+					return nil, nil, apierror.Conflict(apierror.CodeConflict, "service %q already injects %s into application %q; bind %q with a different env_prefix, such as '%s_'", bms.ServiceName, name, input.AppName, input.ServiceName, envPrefixFor(input.ServiceName))
+				}
+			}
+		}
+		for _, env := range app.Spec.Env {
+			if slices.Contains(injected, env.Name) {
+				return nil, nil, apierror.Conflict(apierror.CodeConflict, "application %q already sets %s; bind %q with an env_prefix, such as '%s_'", input.AppName, env.Name, input.ServiceName, envPrefixFor(input.ServiceName))
+			}
+			if env.Name == "SERVICE_BINDING_ROOT" && iafv1alpha1.BindingProjectsFiles(binding) {
+				return nil, nil, apierror.Conflict(apierror.CodeConflict, "application %q already sets SERVICE_BINDING_ROOT; remove it to mount binding directories under %s, or bind %q with projection 'env'", input.AppName, serviceBindingRoot, input.ServiceName)
+			}
+		}
+
+		// Record the binding; the controller injects PG* env vars from the Secret.
+		app.Spec.BoundManagedServices = append(app.Spec.BoundManagedServices, binding)
 		if err := deps.Client.Update(ctx, &app); err != nil {
 			return nil, nil, fmt.Errorf("updating application bindings: %w", err)
 		}
@@ -218,9 +492,13 @@ func RegisterBindService(server *gomcp.Server, deps *Dependencies) {
 		}
 
 		result := map[string]any{
-			"bound":            true,
-			"injectedEnvVars":  serviceEnvVarNames,
-			"message": fmt.Sprintf("Application %q is now bound to service %q. Credentials are injected as K8s Secret references — actual values are never returned by tools.", input.AppName, input.ServiceName),
+			"bound":           true,
+			"injectedEnvVars": injected,
+			"message":         fmt.Sprintf("Application %q is now bound to service %q. Credentials are injected as K8s Secret references — actual values are never returned by tools.", input.AppName, input.ServiceName),
+		}
+		if iafv1alpha1.BindingProjectsFiles(binding) {
+			result["bindingPath"] = path.Join(serviceBindingRoot, input.ServiceName)
+			result["bindingFiles"] = []string{"type", "provider", "host", "port", "database", "username", "password", "uri"}
 		}
 		text, _ := json.MarshalIndent(result, "", "  ")
 		return &gomcp.CallToolResult{
@@ -239,8 +517,11 @@ type UnbindServiceInput struct {
 
 // RegisterUnbindService registers the unbind_service MCP tool.
 func RegisterUnbindService(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "unbind_service",
+	addTool(server, deps, Capability{
+		Name:     "unbind_service",
+		Category: CategoryData,
+		Summary:  "Remove a service's credentials from an app",
+	}, &gomcp.Tool{
 		Description: "Remove the binding between a managed service and an application. Removes the injected environment variables from the application. Does not delete the service or its credentials.",
 	}, func(ctx context.Context, req *gomcp.CallToolRequest, input UnbindServiceInput) (*gomcp.CallToolResult, any, error) {
 		namespace, err := deps.ResolveNamespace(input.SessionID)
@@ -257,7 +538,7 @@ func RegisterUnbindService(server *gomcp.Server, deps *Dependencies) {
 		var svc iafv1alpha1.ManagedService
 		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.ServiceName, Namespace: namespace}, &svc); err != nil {
 			if apierrors.IsNotFound(err) {
-				return nil, nil, fmt.Errorf("service %q not found", input.ServiceName)
+				return nil, nil, apierror.NotFound(apierror.CodeNotFound, "service %q not found", input.ServiceName)
 			}
 			return nil, nil, fmt.Errorf("getting service: %w", err)
 		}
@@ -265,16 +546,20 @@ func RegisterUnbindService(server *gomcp.Server, deps *Dependencies) {
 		var app iafv1alpha1.Application
 		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.AppName, Namespace: namespace}, &app); err != nil {
 			if apierrors.IsNotFound(err) {
-				return nil, nil, fmt.Errorf("application %q not found", input.AppName)
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.AppName)
 			}
 			return nil, nil, fmt.Errorf("getting application: %w", err)
 		}
 
-		// Remove this service from BoundManagedServices.
+		// Remove this service from BoundManagedServices; the controller
+		// stops injecting exactly the variables the binding named.
 		filtered := make([]iafv1alpha1.BoundManagedService, 0, len(app.Spec.BoundManagedServices))
+		removed := []string{}
 		for _, bms := range app.Spec.BoundManagedServices {
 			if bms.ServiceName != input.ServiceName {
 				filtered = append(filtered, bms)
+			} else {
+				removed = bindEnvVarNames(bms)
 			}
 		}
 		app.Spec.BoundManagedServices = filtered
@@ -288,8 +573,9 @@ func RegisterUnbindService(server *gomcp.Server, deps *Dependencies) {
 		}
 
 		result := map[string]any{
-			"unbound": true,
-			"message": fmt.Sprintf("Application %q has been unbound from service %q. Removed injected environment variables.", input.AppName, input.ServiceName),
+			"unbound":        true,
+			"removedEnvVars": removed,
+			"message":        fmt.Sprintf("Application %q has been unbound from service %q. Removed injected environment variables.", input.AppName, input.ServiceName),
 		}
 		text, _ := json.MarshalIndent(result, "", "  ")
 		return &gomcp.CallToolResult{
@@ -307,9 +593,13 @@ type DeprovisionServiceInput struct {
 
 // RegisterDeprovisionService registers the deprovision_service MCP tool.
 func RegisterDeprovisionService(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "deprovision_service",
-		Description: "Delete a managed service and all its data. The service must have no bound applications (use unbind_service first). This action is irreversible.",
+	addTool(server, deps, Capability{
+		Name:          "deprovision_service",
+		Category:      CategoryData,
+		Summary:       "Delete a managed service and its data (irreversible)",
+		Preconditions: []string{"no app is bound to it (unbind_service)", "it is not deletion protected (set_deletion_protection)"},
+	}, &gomcp.Tool{
+		Description: "Delete a managed service and all its data. The service must have no bound applications (use unbind_service first), and is refused with code deletion_protected while the service is deletion protected (set_deletion_protection). This action is irreversible.",
 	}, func(ctx context.Context, req *gomcp.CallToolRequest, input DeprovisionServiceInput) (*gomcp.CallToolResult, any, error) {
 		namespace, err := deps.ResolveNamespace(input.SessionID)
 		if err != nil {
@@ -322,10 +612,13 @@ func RegisterDeprovisionService(server *gomcp.Server, deps *Dependencies) {
 		var svc iafv1alpha1.ManagedService
 		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, &svc); err != nil {
 			if apierrors.IsNotFound(err) {
-				return nil, nil, fmt.Errorf("service %q not found", input.Name)
+				return nil, nil, apierror.NotFound(apierror.CodeNotFound, "service %q not found", input.Name)
 			}
 			return nil, nil, fmt.Errorf("getting service: %w", err)
 		}
+		if svc.Spec.DeletionProtected {
+			return nil, nil, iafk8s.DeletionProtected("service", input.Name)
+		}
 
 		// UX guard: check bound apps. Filter out any apps that no longer exist (e.g.
 		// deleted before unbind_service was called) to avoid a permanent deadlock.
@@ -351,7 +644,7 @@ func RegisterDeprovisionService(server *gomcp.Server, deps *Dependencies) {
 
 		if err := deps.Client.Delete(ctx, &svc); err != nil {
 			if apierrors.IsNotFound(err) {
-				return nil, nil, fmt.Errorf("service %q not found", input.Name)
+				return nil, nil, apierror.NotFound(apierror.CodeNotFound, "service %q not found", input.Name)
 			}
 			return nil, nil, fmt.Errorf("deprovisioning service: %w", err)
 		}
@@ -376,8 +669,12 @@ type ListServicesInput struct {
 
 // RegisterListServices registers the list_services MCP tool.
 func RegisterListServices(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "list_services",
+	addTool(server, deps, Capability{
+		Name:     "list_services",
+		Category: CategoryData,
+		ReadOnly: true,
+		Summary:  "List the managed services in your session",
+	}, &gomcp.Tool{
 		Description: "List all managed services in the current session's namespace.",
 	}, func(ctx context.Context, req *gomcp.CallToolRequest, input ListServicesInput) (*gomcp.CallToolResult, any, error) {
 		namespace, err := deps.ResolveNamespace(input.SessionID)
@@ -411,6 +708,79 @@ func RegisterListServices(server *gomcp.Server, deps *Dependencies) {
 	})
 }
 
+// --- list_service_plans ---
+
+type ListServicePlansInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Type      string `json:"type,omitempty" jsonschema:"only plans for this service type, e.g. 'postgres'"`
+}
+
+// RegisterListServicePlans registers the list_service_plans MCP tool.
+func RegisterListServicePlans(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "list_service_plans",
+		Category: CategoryData,
+		ReadOnly: true,
+		Summary:  "List the plans provision_service accepts, with their instances, storage, CPU, memory and backups",
+		Examples: []string{`{"session_id": "<id>", "type": "postgres"}`},
+	}, &gomcp.Tool{
+		Description: "List the plans managed services can be provisioned on, for the plan argument of provision_service and deploy_stack. Requires session_id from the register tool. Each plan has its service type, a description, the number of instances (more than one adds read replicas for bind_mode 'ro' and 'both'), the storage, CPU and memory of each instance, and its backup schedule and retention when it is backed up. The platform operator defines the plans, so they differ between platforms.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input ListServicePlansInput) (*gomcp.CallToolResult, any, error) {
+		if _, err := deps.ResolveNamespace(input.SessionID); err != nil {
+			return nil, nil, err
+		}
+		plans, err := iafk8s.ServicePlans(ctx, deps.Client)
+		if err != nil {
+			return nil, nil, err
+		}
+		items := make([]map[string]any, 0, len(plans))
+		for _, p := range plans {
+			if input.Type != "" && p.Spec.Type != input.Type {
+				continue
+			}
+			items = append(items, servicePlanSummary(p))
+		}
+		result := map[string]any{
+			"plans": items,
+			"total": len(items),
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// servicePlanSummary describes plan to agents. The object store settings
+// of its backups are left out.
+func servicePlanSummary(plan iafv1alpha1.ServicePlan) map[string]any {
+	item := map[string]any{
+		"name":         plan.Name,
+		"type":         plan.Spec.Type,
+		"description":  plan.Spec.Description,
+		"instances":    plan.Spec.Instances,
+		"readReplicas": plan.Spec.Instances > 1,
+		"storage":      plan.Spec.Storage.String(),
+	}
+	if cpu, ok := plan.Spec.Resources.Requests[corev1.ResourceCPU]; ok {
+		item["cpu"] = cpu.String()
+	}
+	if memory, ok := plan.Spec.Resources.Requests[corev1.ResourceMemory]; ok {
+		item["memory"] = memory.String()
+	}
+	if len(plan.Spec.Resources.Limits) > 0 {
+		limits := map[string]string{}
+		for name, q := range plan.Spec.Resources.Limits {
+			limits[string(name)] = q.String()
+		}
+		item["limits"] = limits
+	}
+	if b := plan.Spec.Backup; b != nil {
+		item["backup"] = map[string]string{"schedule": b.Schedule, "retentionPolicy": b.RetentionPolicy}
+	}
+	return item
+}
+
 // --- helpers ---
 
 // addBoundApp adds appName to svc.Status.BoundApps with optimistic-concurrency retry on conflict.
@@ -464,4 +834,3 @@ func removeBoundApp(ctx context.Context, c client.Client, namespace, svcName, ap
 	}
 	return fmt.Errorf("failed to update service bound apps after retries")
 }
-
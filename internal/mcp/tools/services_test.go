@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"log/slog"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
 	"github.com/dlapiduz/iaf/internal/auth"
@@ -13,9 +16,11 @@ import (
 	"github.com/dlapiduz/iaf/internal/sourcestore"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -157,6 +162,104 @@ func TestProvisionService_InvalidPlan(t *testing.T) {
 	}
 }
 
+// TestListServicePlans verifies that ServicePlans add to and replace the
+// built-in plans, in list_service_plans and in provision_service.
+func TestListServicePlans(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	large := &iafv1alpha1.ServicePlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "large"},
+		Spec: iafv1alpha1.ServicePlanSpec{
+			Type:        "postgres",
+			Description: "Big production databases",
+			Instances:   2,
+			Storage:     resource.MustParse("100Gi"),
+			Resources:   corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourceMemory: resource.MustParse("8Gi")}},
+			Backup: &iafv1alpha1.ServicePlanBackup{
+				Schedule:          "0 0 2 * * *",
+				RetentionPolicy:   "14d",
+				BarmanObjectStore: runtime.RawExtension{Raw: []byte(`{"destinationPath": "s3://secret-bucket"}`)},
+			},
+		},
+	}
+	micro := &iafv1alpha1.ServicePlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "micro"},
+		Spec:       iafv1alpha1.ServicePlanSpec{Type: "postgres", Description: "Tiny", Instances: 1, Storage: resource.MustParse("2Gi")},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(large, micro).Build()
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := &tools.Dependencies{Client: k8sClient, BaseDomain: "test.example.com", Sessions: sessions}
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterProvisionService(server, deps)
+	tools.RegisterListServicePlans(server, deps)
+	ctx := context.Background()
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	cs, err := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil).Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	sid, ns := registerAndGetSession(t, cs)
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "list_service_plans", Arguments: map[string]any{"session_id": sid}})
+	if err != nil || res.IsError {
+		t.Fatalf("list_service_plans failed: %v", err)
+	}
+	text := res.Content[0].(*gomcp.TextContent).Text
+	var out struct {
+		Plans []map[string]any `json:"plans"`
+	}
+	json.Unmarshal([]byte(text), &out)
+	var names []string
+	byName := map[string]map[string]any{}
+	for _, p := range out.Plans {
+		names = append(names, p["name"].(string))
+		byName[p["name"].(string)] = p
+	}
+	if !slices.Equal(names, []string{"ha", "large", "micro", "small"}) {
+		t.Fatalf("unexpected plans %v", names)
+	}
+	if byName["micro"]["description"] != "Tiny" || byName["micro"]["storage"] != "2Gi" {
+		t.Errorf("expected the ServicePlan to replace the built-in micro plan, got %v", byName["micro"])
+	}
+	if l := byName["large"]; l["instances"] != float64(2) || l["readReplicas"] != true || l["cpu"] != "4" || l["memory"] != "8Gi" || l["backup"] == nil {
+		t.Errorf("unexpected large plan %v", l)
+	}
+	if strings.Contains(text, "secret-bucket") {
+		t.Error("expected the backup object store left out")
+	}
+
+	provision := func(plan string) *gomcp.CallToolResult {
+		t.Helper()
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "provision_service", Arguments: map[string]any{
+			"session_id": sid, "name": "db-" + plan, "type": "postgres", "plan": plan,
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	if res := provision("large"); res.IsError {
+		t.Fatalf("expected the large plan accepted: %s", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	var svc iafv1alpha1.ManagedService
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "db-large", Namespace: ns}, &svc); err != nil || svc.Spec.Plan != "large" {
+		t.Errorf("expected a service on the large plan, got %v", err)
+	}
+	res = provision("huge")
+	if !res.IsError || !strings.Contains(res.Content[0].(*gomcp.TextContent).Text, "ha, large, micro, small") {
+		t.Errorf("expected an unknown plan rejected with the plans offered, got %v", res.Content[0].(*gomcp.TextContent).Text)
+	}
+}
+
 // setupReadyService creates a ManagedService in the fake client with Ready status.
 func setupReadyService(t *testing.T, cs *gomcp.ClientSession, sessions *auth.SessionStore, sid, svcName string) {
 	t.Helper()
@@ -257,7 +360,7 @@ func TestServiceStatus_Ready(t *testing.T) {
 	_ = k8sClient.Status().Update(ctx, svc)
 
 	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
-		Name: "service_status",
+		Name:      "service_status",
 		Arguments: map[string]any{"session_id": sid, "name": "readydb"},
 	})
 	if err != nil || res.IsError {
@@ -275,6 +378,216 @@ func TestServiceStatus_Ready(t *testing.T) {
 	if _, ok := result["connectionSecretRef"]; ok {
 		t.Error("should never return connectionSecretRef to agents")
 	}
+	if formats, _ := result["formats"].([]any); len(formats) != 2 {
+		t.Errorf("expected the connection formats, got %v", result["formats"])
+	}
+
+	res, err = cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "service_status",
+		Arguments: map[string]any{"session_id": sid, "name": "readydb", "format": "jdbc"},
+	})
+	if err != nil || res.IsError {
+		t.Fatalf("service_status failed: %v", err)
+	}
+	json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &result)
+	if names, _ := result["formatEnvVars"].([]any); len(names) != 3 || names[0] != "JDBC_DATABASE_URL" {
+		t.Errorf("expected the JDBC env vars, got %v", result["formatEnvVars"])
+	}
+	res, _ = cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "service_status",
+		Arguments: map[string]any{"session_id": sid, "name": "readydb", "format": "odbc"},
+	})
+	if !res.IsError {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
+
+// TestServiceStatus_Versions verifies that provision_service records the
+// version and maintenance window and service_status reports the versions.
+func TestServiceStatus_Versions(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&iafv1alpha1.ManagedService{}).
+		Build()
+
+	store, _ := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	sessions, _ := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		Store:      store,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterProvisionService(server, deps)
+	tools.RegisterServiceStatus(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	server.Connect(ctx, st, nil)
+	client := gomcp.NewClient(&gomcp.Implementation{Name: "tc", Version: "0.0.1"}, nil)
+	cs, _ := client.Connect(ctx, ct, nil)
+	t.Cleanup(func() { cs.Close() })
+
+	sid, ns := registerAndGetSession(t, cs)
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name: "provision_service",
+		Arguments: map[string]any{
+			"session_id": sid, "name": "pgdb", "type": "postgres", "plan": "micro", "version": "16",
+			"maintenance_window": map[string]any{"day": "sunday", "start": "03:00", "duration": "2h"},
+		},
+	})
+	if err != nil || res.IsError {
+		t.Fatalf("provision_service failed: err=%v, res=%+v", err, res)
+	}
+
+	var svc iafv1alpha1.ManagedService
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "pgdb", Namespace: ns}, &svc); err != nil {
+		t.Fatal(err)
+	}
+	if w := svc.Spec.MaintenanceWindow; svc.Spec.Version != "16" || w == nil || w.Day != "Sunday" || w.Duration.Duration != 2*time.Hour {
+		t.Fatalf("unexpected spec %+v", svc.Spec)
+	}
+	svc.Status = iafv1alpha1.ManagedServiceStatus{
+		Phase:          iafv1alpha1.ManagedServicePhaseReady,
+		CurrentVersion: "16.4",
+		TargetVersion:  "16.6",
+		UpgradeHistory: []iafv1alpha1.VersionUpgrade{{From: "16.2", To: "16.4", StartedAt: metav1.Now()}},
+		Conditions: []metav1.Condition{{
+			Type: "UpgradePending", Status: metav1.ConditionTrue, Reason: "WaitingForMaintenanceWindow",
+			Message: "PostgreSQL 16.6 is available", LastTransitionTime: metav1.Now(),
+		}},
+	}
+	if err := k8sClient.Status().Update(ctx, &svc); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err = cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "service_status",
+		Arguments: map[string]any{"session_id": sid, "name": "pgdb"},
+	})
+	if err != nil || res.IsError {
+		t.Fatalf("service_status failed: %v", err)
+	}
+	var result map[string]any
+	json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &result)
+	if result["currentVersion"] != "16.4" || result["targetVersion"] != "16.6" {
+		t.Errorf("expected versions 16.4 and 16.6, got %v and %v", result["currentVersion"], result["targetVersion"])
+	}
+	if result["upgrade"] != "PostgreSQL 16.6 is available" {
+		t.Errorf("expected the pending upgrade, got %v", result["upgrade"])
+	}
+	if history, _ := result["upgradeHistory"].([]any); len(history) != 1 {
+		t.Errorf("expected one upgrade in the history, got %v", result["upgradeHistory"])
+	}
+	if window, _ := result["maintenanceWindow"].(map[string]any); window["day"] != "Sunday" || window["start"] != "03:00 UTC" {
+		t.Errorf("unexpected maintenance window %v", result["maintenanceWindow"])
+	}
+}
+
+// TestServiceStatus_Progress verifies that service_status reports the
+// progress of a provisioning database cluster.
+func TestServiceStatus_Progress(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&iafv1alpha1.ManagedService{}).
+		Build()
+	sessions, _ := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	deps := &tools.Dependencies{Client: k8sClient, BaseDomain: "test.example.com", Sessions: sessions}
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterProvisionService(server, deps)
+	tools.RegisterServiceStatus(server, deps)
+	st, ct := gomcp.NewInMemoryTransports()
+	server.Connect(ctx, st, nil)
+	cs, _ := gomcp.NewClient(&gomcp.Implementation{Name: "tc", Version: "0.0.1"}, nil).Connect(ctx, ct, nil)
+	t.Cleanup(func() { cs.Close() })
+
+	sid, ns := registerAndGetSession(t, cs)
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "provision_service",
+		Arguments: map[string]any{"session_id": sid, "name": "pgdb", "type": "postgres", "plan": "ha"},
+	})
+	if err != nil || res.IsError {
+		t.Fatalf("provision_service failed: err=%v, res=%+v", err, res)
+	}
+	var svc iafv1alpha1.ManagedService
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "pgdb", Namespace: ns}, &svc); err != nil {
+		t.Fatal(err)
+	}
+	svc.Status = iafv1alpha1.ManagedServiceStatus{
+		Phase:     iafv1alpha1.ManagedServicePhaseProvisioning,
+		Instances: 3,
+		Progress: &iafv1alpha1.ManagedServiceProgress{
+			ClusterPhase:   "Creating a new replica",
+			ReadyInstances: 1,
+			CurrentPrimary: "pgdb-1",
+			Volumes:        2,
+			VolumesBound:   1,
+			Events: []iafv1alpha1.ManagedServiceEvent{{
+				Time: metav1.Now(), Type: corev1.EventTypeWarning, Reason: "ProvisioningFailed",
+				Object: "PersistentVolumeClaim/pgdb-2", Message: "storageclass \"fast\" not found",
+			}},
+		},
+	}
+	if err := k8sClient.Status().Update(ctx, &svc); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err = cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "service_status",
+		Arguments: map[string]any{"session_id": sid, "name": "pgdb"},
+	})
+	if err != nil || res.IsError {
+		t.Fatalf("service_status failed: %v", err)
+	}
+	var result map[string]any
+	json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &result)
+	progress, _ := result["progress"].(map[string]any)
+	if progress["instancesReady"] != "1/3" || progress["volumesBound"] != "1/2" || progress["currentPrimary"] != "pgdb-1" || progress["clusterPhase"] != "Creating a new replica" {
+		t.Fatalf("unexpected progress %v", result["progress"])
+	}
+	events, _ := progress["events"].([]any)
+	if len(events) != 1 || events[0].(map[string]any)["reason"] != "ProvisioningFailed" {
+		t.Errorf("expected the volume's warning event, got %v", progress["events"])
+	}
+}
+
+// TestProvisionService_InvalidVersion verifies rejection of malformed
+// versions and maintenance windows.
+func TestProvisionService_InvalidVersion(t *testing.T) {
+	cs, _, _ := setupServiceToolServer(t)
+	ctx := context.Background()
+	sid, _ := registerAndGetSession(t, cs)
+
+	for name, extra := range map[string]map[string]any{
+		"version":  {"version": "16.4"},
+		"day":      {"maintenance_window": map[string]any{"day": "someday", "start": "03:00"}},
+		"start":    {"maintenance_window": map[string]any{"start": "3am"}},
+		"duration": {"maintenance_window": map[string]any{"start": "03:00", "duration": "5m"}},
+	} {
+		args := map[string]any{"session_id": sid, "name": "db-" + name, "type": "postgres", "plan": "micro"}
+		for k, v := range extra {
+			args[k] = v
+		}
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "provision_service", Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.IsError {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
 }
 
 // TestBindService_OK verifies that 6 SecretKeyRef env vars are injected and BoundApps is updated.
@@ -375,6 +688,267 @@ func TestBindService_OK(t *testing.T) {
 	}
 }
 
+// setupBindServer creates a server with bind_service and unbind_service,
+// a session, and a Ready service of each named plan.
+func setupBindServer(t *testing.T, services map[string]iafv1alpha1.ServicePlanName) (cs *gomcp.ClientSession, k8sClient client.Client, sid, ns string) {
+	t.Helper()
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient = fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&iafv1alpha1.ManagedService{}).
+		Build()
+
+	store, _ := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	sessions, _ := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		Store:      store,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterBindService(server, deps)
+	tools.RegisterUnbindService(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	server.Connect(ctx, st, nil)
+	cl := gomcp.NewClient(&gomcp.Implementation{Name: "tc", Version: "0.0.1"}, nil)
+	cs, _ = cl.Connect(ctx, ct, nil)
+	t.Cleanup(func() { cs.Close() })
+
+	sid, ns = registerAndGetSession(t, cs)
+	for name, plan := range services {
+		svc := &iafv1alpha1.ManagedService{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Spec:       iafv1alpha1.ManagedServiceSpec{Type: "postgres", Plan: plan},
+			Status:     iafv1alpha1.ManagedServiceStatus{Phase: iafv1alpha1.ManagedServicePhaseReady, ConnectionSecretRef: name + "-app"},
+		}
+		k8sClient.Create(ctx, svc)
+		k8sClient.Status().Update(ctx, svc)
+	}
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: ns},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest", Port: 8080, Replicas: 1},
+	}
+	k8sClient.Create(ctx, app)
+	return cs, k8sClient, sid, ns
+}
+
+// TestBindService_BindMode verifies that read replica bindings record their
+// mode and need a plan with replicas.
+func TestBindService_BindMode(t *testing.T) {
+	ctx := context.Background()
+	cs, k8sClient, sid, ns := setupBindServer(t, map[string]iafv1alpha1.ServicePlanName{"hadb": iafv1alpha1.ServicePlanHA, "smalldb": iafv1alpha1.ServicePlanSmall})
+
+	bind := func(service, mode string) *gomcp.CallToolResult {
+		t.Helper()
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+			Name:      "bind_service",
+			Arguments: map[string]any{"session_id": sid, "service_name": service, "app_name": "myapp", "bind_mode": mode},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	for _, tc := range []struct{ service, mode string }{{"smalldb", "ro"}, {"smalldb", "both"}, {"hadb", "replica"}} {
+		if res := bind(tc.service, tc.mode); !res.IsError {
+			t.Errorf("expected bind_mode %q on %s to be rejected", tc.mode, tc.service)
+		}
+	}
+
+	res := bind("hadb", "ro")
+	if res.IsError {
+		t.Fatalf("bind_service failed: %v", res.Content)
+	}
+	var result struct {
+		InjectedEnvVars []string `json:"injectedEnvVars"`
+	}
+	json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &result)
+	if !slices.Contains(result.InjectedEnvVars, "DATABASE_READ_URL") || slices.Contains(result.InjectedEnvVars, "DATABASE_URL") {
+		t.Errorf("unexpected env vars for a read-only binding: %v", result.InjectedEnvVars)
+	}
+
+	var updated iafv1alpha1.Application
+	k8sClient.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: ns}, &updated)
+	if len(updated.Spec.BoundManagedServices) != 1 || updated.Spec.BoundManagedServices[0].Mode != iafv1alpha1.BindModeReadOnly {
+		t.Errorf("expected a read-only binding, got %+v", updated.Spec.BoundManagedServices)
+	}
+}
+
+// TestBindService_EnvPrefix verifies that two services can be bound to one
+// app with different prefixes, and that unbinding removes exactly the
+// prefixed variables.
+func TestBindService_EnvPrefix(t *testing.T) {
+	ctx := context.Background()
+	cs, k8sClient, sid, ns := setupBindServer(t, map[string]iafv1alpha1.ServicePlanName{"orders": iafv1alpha1.ServicePlanMicro, "users": iafv1alpha1.ServicePlanMicro})
+
+	call := func(tool, service, prefix string) (*gomcp.CallToolResult, map[string]any) {
+		t.Helper()
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+			Name:      tool,
+			Arguments: map[string]any{"session_id": sid, "service_name": service, "app_name": "myapp", "env_prefix": prefix},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out map[string]any
+		json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &out)
+		return res, out
+	}
+
+	if res, _ := call("bind_service", "users", ""); res.IsError {
+		t.Fatalf("bind_service failed: %v", res.Content)
+	}
+	// A second unprefixed binding would inject DATABASE_URL twice.
+	if res, _ := call("bind_service", "orders", ""); !res.IsError {
+		t.Error("expected a colliding binding to be rejected")
+	}
+	if res, _ := call("bind_service", "orders", "9X"); !res.IsError {
+		t.Error("expected an invalid prefix to be rejected")
+	}
+	res, out := call("bind_service", "orders", "ORDERS_")
+	if res.IsError {
+		t.Fatalf("bind_service failed: %v", res.Content)
+	}
+	if injected, _ := out["injectedEnvVars"].([]any); len(injected) != 6 || injected[0] != "ORDERS_DATABASE_URL" {
+		t.Errorf("expected prefixed env vars, got %v", out["injectedEnvVars"])
+	}
+
+	var app iafv1alpha1.Application
+	k8sClient.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: ns}, &app)
+	if len(app.Spec.BoundManagedServices) != 2 || app.Spec.BoundManagedServices[1].EnvPrefix != "ORDERS_" {
+		t.Fatalf("unexpected bindings %+v", app.Spec.BoundManagedServices)
+	}
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "unbind_service", Arguments: map[string]any{"session_id": sid, "service_name": "orders", "app_name": "myapp"}})
+	if err != nil || res.IsError {
+		t.Fatalf("unbind_service failed: %v %v", err, res)
+	}
+	json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &out)
+	if removed, _ := out["removedEnvVars"].([]any); len(removed) != 6 || removed[0] != "ORDERS_DATABASE_URL" {
+		t.Errorf("expected the prefixed env vars to be removed, got %v", out["removedEnvVars"])
+	}
+	k8sClient.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: ns}, &app)
+	if len(app.Spec.BoundManagedServices) != 1 || app.Spec.BoundManagedServices[0].ServiceName != "users" {
+		t.Errorf("expected only the users binding to remain, got %+v", app.Spec.BoundManagedServices)
+	}
+}
+
+// TestBindService_Projection verifies that bindings projected as files
+// record the projection, report the binding directory and inject no env
+// vars of their own.
+func TestBindService_Projection(t *testing.T) {
+	ctx := context.Background()
+	cs, k8sClient, sid, ns := setupBindServer(t, map[string]iafv1alpha1.ServicePlanName{"db": iafv1alpha1.ServicePlanHA, "cache": iafv1alpha1.ServicePlanMicro})
+
+	call := func(args map[string]any) (*gomcp.CallToolResult, map[string]any) {
+		t.Helper()
+		args["session_id"], args["app_name"] = sid, "myapp"
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "bind_service", Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out map[string]any
+		json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &out)
+		return res, out
+	}
+
+	for _, args := range []map[string]any{
+		{"service_name": "db", "projection": "volume"},
+		{"service_name": "db", "projection": "files", "bind_mode": "ro"},
+		{"service_name": "db", "projection": "files", "bind_mode": "both"},
+	} {
+		if res, _ := call(args); !res.IsError {
+			t.Errorf("expected %v to be rejected", args)
+		}
+	}
+
+	res, out := call(map[string]any{"service_name": "db", "projection": "files"})
+	if res.IsError {
+		t.Fatalf("bind_service failed: %v", res.Content)
+	}
+	if out["bindingPath"] != "/bindings/db" {
+		t.Errorf("expected the binding directory, got %v", out["bindingPath"])
+	}
+	if injected, _ := out["injectedEnvVars"].([]any); len(injected) != 0 {
+		t.Errorf("expected no env vars, got %v", injected)
+	}
+	// Without env vars of its own, the files binding leaves DATABASE_URL free.
+	if res, _ := call(map[string]any{"service_name": "cache", "projection": "both"}); res.IsError {
+		t.Fatalf("bind_service failed: %v", res.Content)
+	}
+
+	var app iafv1alpha1.Application
+	k8sClient.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: ns}, &app)
+	if len(app.Spec.BoundManagedServices) != 2 ||
+		app.Spec.BoundManagedServices[0].Projection != iafv1alpha1.BindingProjectionFiles ||
+		app.Spec.BoundManagedServices[1].Projection != iafv1alpha1.BindingProjectionBoth {
+		t.Errorf("unexpected bindings %+v", app.Spec.BoundManagedServices)
+	}
+}
+
+// TestBindService_Format verifies that connection formats are recorded,
+// their env vars reported and checked for collisions.
+func TestBindService_Format(t *testing.T) {
+	ctx := context.Background()
+	cs, k8sClient, sid, ns := setupBindServer(t, map[string]iafv1alpha1.ServicePlanName{"db": iafv1alpha1.ServicePlanHA})
+
+	call := func(args map[string]any) (*gomcp.CallToolResult, map[string]any) {
+		t.Helper()
+		args["session_id"], args["app_name"], args["service_name"] = sid, "myapp", "db"
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "bind_service", Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out map[string]any
+		json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &out)
+		return res, out
+	}
+
+	for _, args := range []map[string]any{
+		{"format": []string{"odbc"}},
+		{"format": []string{"jdbc", "jdbc"}},
+		{"format": []string{"jdbc"}, "bind_mode": "ro"},
+		{"format": []string{"jdbc"}, "projection": "files"},
+	} {
+		if res, _ := call(args); !res.IsError {
+			t.Errorf("expected %v to be rejected", args)
+		}
+	}
+
+	// The app's own JDBC_DATABASE_URL is not overridden.
+	var app iafv1alpha1.Application
+	k8sClient.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: ns}, &app)
+	app.Spec.Env = []iafv1alpha1.EnvVar{{Name: "JDBC_DATABASE_URL", Value: "jdbc:h2:mem:test"}}
+	k8sClient.Update(ctx, &app)
+	if res, _ := call(map[string]any{"format": []string{"jdbc"}}); !res.IsError {
+		t.Error("expected a collision with the app's env to be rejected")
+	}
+	app.Spec.Env = nil
+	k8sClient.Update(ctx, &app)
+
+	res, out := call(map[string]any{"format": []string{"sqlalchemy", "jdbc"}})
+	if res.IsError {
+		t.Fatalf("bind_service failed: %v", res.Content)
+	}
+	injected, _ := out["injectedEnvVars"].([]any)
+	if len(injected) != 10 || !slices.Contains(injected, any("SQLALCHEMY_DATABASE_URI")) || !slices.Contains(injected, any("JDBC_DATABASE_URL")) {
+		t.Errorf("expected the format env vars, got %v", injected)
+	}
+	k8sClient.Get(ctx, types.NamespacedName{Name: "myapp", Namespace: ns}, &app)
+	if got := app.Spec.BoundManagedServices[0].Formats; len(got) != 2 || got[0] != iafv1alpha1.ConnectionFormatSQLAlchemy {
+		t.Errorf("unexpected formats %v", got)
+	}
+}
+
 // TestBindService_AlreadyBound verifies rejection when the service is already bound to the app.
 func TestBindService_AlreadyBound(t *testing.T) {
 	ctx := context.Background()
@@ -653,7 +1227,7 @@ func TestDeprovisionService_Blocked(t *testing.T) {
 	k8sClient.Status().Update(ctx, svc)
 
 	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
-		Name: "deprovision_service",
+		Name:      "deprovision_service",
 		Arguments: map[string]any{"session_id": sid, "name": "pgdb"},
 	})
 	if err == nil && !res.IsError {
@@ -708,7 +1282,7 @@ func TestDeprovisionService_OK(t *testing.T) {
 	k8sClient.Create(ctx, svc)
 
 	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
-		Name: "deprovision_service",
+		Name:      "deprovision_service",
 		Arguments: map[string]any{"session_id": sid, "name": "pgdb"},
 	})
 	if err != nil || res.IsError {
@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/audit"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 200
+)
+
+// recordCall logs every call of h as a tool_call and records it in the
+// history of its session in deps.History. The session is the session_id
+// argument, or for register the session_id of the result. Calls naming no
+// known session are only logged, and session_history calls are not
+// recorded.
+func recordCall[In, Out any](deps *Dependencies, name string, h gomcp.ToolHandlerFor[In, Out]) gomcp.ToolHandlerFor[In, Out] {
+	if deps.History == nil || name == "session_history" {
+		return h
+	}
+	return func(ctx context.Context, req *gomcp.CallToolRequest, input In) (*gomcp.CallToolResult, Out, error) {
+		start := time.Now()
+		res, out, err := h(ctx, req, input)
+
+		var raw json.RawMessage
+		if req != nil && req.Params != nil {
+			raw = req.Params.Arguments
+		}
+		var args struct {
+			SessionID string `json:"session_id"`
+		}
+		_ = json.Unmarshal(raw, &args)
+		entry := audit.Entry{Time: start.UTC(), Tool: name, Args: audit.Args(raw), Outcome: audit.OutcomeOK}
+		switch {
+		case err != nil:
+			apiErr := apierror.From(err)
+			entry.Outcome, entry.Code, entry.Summary = audit.OutcomeError, apiErr.Code, audit.Cut(apiErr.Message)
+		case res != nil:
+			var result struct {
+				SessionID string `json:"session_id"`
+				Message   string `json:"message"`
+				Error     string `json:"error"`
+				Code      string `json:"code"`
+			}
+			if len(res.Content) == 1 {
+				if text, ok := res.Content[0].(*gomcp.TextContent); ok {
+					_ = json.Unmarshal([]byte(text.Text), &result)
+				}
+			}
+			if args.SessionID == "" && name == "register" {
+				args.SessionID = result.SessionID
+			}
+			entry.Summary = audit.Cut(result.Message)
+			if res.IsError {
+				entry.Outcome, entry.Code, entry.Summary = audit.OutcomeError, result.Code, audit.Cut(result.Error)
+			}
+		}
+
+		slog.Info("tool_call",
+			"tool", name,
+			"session_id", args.SessionID,
+			"outcome", entry.Outcome,
+			"code", entry.Code,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+		if _, ok := deps.Sessions.Lookup(args.SessionID); ok {
+			if err := deps.History.Record(args.SessionID, entry); err != nil {
+				slog.Warn("failed to record tool call", "tool", name, "session_id", args.SessionID, "error", err)
+			}
+		}
+		return res, out, err
+	}
+}
+
+type SessionHistoryInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Since     string `json:"since,omitempty" jsonschema:"only calls made since then: a duration ago such as '2h', or an RFC 3339 time"`
+	Tool      string `json:"tool,omitempty" jsonschema:"only calls of this tool, e.g. 'deploy_app'"`
+	Limit     int    `json:"limit,omitempty" jsonschema:"newest calls to return (default 50, at most 200)"`
+}
+
+func RegisterSessionHistory(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "session_history",
+		Category: CategorySession,
+		ReadOnly: true,
+		Summary:  "List the tool calls made in this session, to pick up work after losing context",
+		Examples: []string{`{"session_id": "<id>"}`, `{"session_id": "<id>", "tool": "deploy_app", "since": "2h"}`},
+	}, &gomcp.Tool{
+		Description: "List the tool calls made in your session, oldest first: when each was made, the tool, its key arguments, whether it succeeded (with the error code if not) and the message of its result. Requires session_id from the register tool. Call it when resuming work in a fresh context to see what you already deployed, configured or provisioned, then check the current state with list_apps or app_status. Filter with 'tool' and 'since' (a duration ago such as '2h', or an RFC 3339 time); 'limit' returns the newest calls (default 50, at most 200). Secrets, source files and env var values are never recorded; lists are only counted. Calls to session_history are not recorded.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input SessionHistoryInput) (*gomcp.CallToolResult, any, error) {
+		if _, err := deps.ResolveNamespace(input.SessionID); err != nil {
+			return nil, nil, err
+		}
+		q := audit.Query{Tool: input.Tool, Limit: input.Limit}
+		if q.Limit <= 0 {
+			q.Limit = defaultHistoryLimit
+		}
+		if q.Limit > maxHistoryLimit {
+			return nil, nil, fmt.Errorf("limit must be at most %d", maxHistoryLimit)
+		}
+		if input.Since != "" {
+			if d, err := time.ParseDuration(input.Since); err == nil && d >= 0 {
+				q.Since = time.Now().Add(-d)
+			} else if t, err := time.Parse(time.RFC3339, input.Since); err == nil {
+				q.Since = t
+			} else {
+				return nil, nil, fmt.Errorf("invalid since %q: use a duration such as '2h' or an RFC 3339 time", input.Since)
+			}
+		}
+
+		calls, total, err := deps.History.List(input.SessionID, q)
+		if err != nil {
+			return nil, nil, apierror.Platform(apierror.CodeInternal, false, "reading session history: %v", err)
+		}
+		if calls == nil {
+			calls = []audit.Entry{}
+		}
+		message := fmt.Sprintf("%d of %d matching calls, oldest first.", len(calls), total)
+		if len(calls) < total {
+			message += " Raise limit, or filter by tool, to see older ones."
+		}
+		result := map[string]any{
+			"calls":    calls,
+			"total":    total,
+			"returned": len(calls),
+			"message":  message,
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
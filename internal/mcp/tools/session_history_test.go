@@ -0,0 +1,109 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/audit"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSessionHistory(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	history, err := audit.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+		History:    history,
+	}
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterListApps(server, deps)
+	tools.RegisterAppStatus(server, deps)
+	tools.RegisterAddRegistryCredential(server, deps)
+	tools.RegisterSessionHistory(server, deps)
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	cs, err := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil).Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	sid, _ := registerDSSession(t, cs)
+	otherSID, _ := registerDSSession(t, cs)
+
+	for _, call := range []*gomcp.CallToolParams{
+		{Name: "list_apps", Arguments: map[string]any{"session_id": sid}},
+		{Name: "app_status", Arguments: map[string]any{"session_id": sid, "name": "missing"}},
+		{Name: "add_registry_credential", Arguments: map[string]any{"session_id": sid, "name": "ghcr", "registry_server": "ghcr.io", "username": "bot", "password": "hunter2"}},
+		{Name: "list_apps", Arguments: map[string]any{"session_id": otherSID}},
+	} {
+		if _, err := cs.CallTool(ctx, call); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	readHistory := func(args map[string]any) (string, []audit.Entry) {
+		t.Helper()
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "session_history", Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		text := res.Content[0].(*gomcp.TextContent).Text
+		if res.IsError {
+			t.Fatalf("session_history failed: %s", text)
+		}
+		var out struct {
+			Calls []audit.Entry `json:"calls"`
+		}
+		if err := json.Unmarshal([]byte(text), &out); err != nil {
+			t.Fatal(err)
+		}
+		return text, out.Calls
+	}
+
+	text, calls := readHistory(map[string]any{"session_id": sid})
+	var names []string
+	for _, c := range calls {
+		names = append(names, c.Tool)
+	}
+	if strings.Join(names, ",") != "register,list_apps,app_status,add_registry_credential" {
+		t.Fatalf("unexpected history %v", names)
+	}
+	if calls[2].Outcome != audit.OutcomeError || calls[2].Args["name"] != "missing" {
+		t.Errorf("expected the failed app_status call, got %+v", calls[2])
+	}
+	if calls[3].Outcome != audit.OutcomeOK || calls[3].Args["password"] != "[redacted]" || strings.Contains(text, "hunter2") {
+		t.Errorf("expected the password redacted, got %+v", calls[3])
+	}
+
+	if _, calls := readHistory(map[string]any{"session_id": otherSID}); len(calls) != 2 {
+		t.Errorf("expected only the other session's 2 calls, got %d", len(calls))
+	}
+	if _, calls := readHistory(map[string]any{"session_id": sid, "tool": "list_apps", "since": "1h"}); len(calls) != 1 {
+		t.Errorf("expected 1 filtered call, got %d", len(calls))
+	}
+}
@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type SessionOverviewInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+}
+
+// RegisterSessionOverview registers the session_overview MCP tool.
+func RegisterSessionOverview(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "session_overview",
+		Category: CategoryObserve,
+		ReadOnly: true,
+		Summary:  "Phase, replicas, URL, build status and problems of every app and service in one call — use it instead of polling app_status per app",
+	}, &gomcp.Tool{
+		Description: "Get a compact status of every application and managed service in your session in one call: phase, version, URL, replicas and build status for apps, phase and bound apps for services. Anything unhealthy (a failed build or deploy, missing replicas, drift, an upcoming TTL deletion) is listed under \"problems\", and \"needsAttention\" counts the apps and services that have any. Use it instead of calling app_status for each app; call app_status for details on one app. The response includes a \"pollIntervalSeconds\" field while anything is still building, deploying or provisioning — wait that many seconds between polls. Requires session_id.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input SessionOverviewInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var appList iafv1alpha1.ApplicationList
+		if err := deps.Client.List(ctx, &appList, client.InNamespace(namespace)); err != nil {
+			return nil, nil, fmt.Errorf("listing applications: %w", err)
+		}
+		var svcList iafv1alpha1.ManagedServiceList
+		if err := deps.Client.List(ctx, &svcList, client.InNamespace(namespace)); err != nil {
+			return nil, nil, fmt.Errorf("listing services: %w", err)
+		}
+
+		phases := map[string]int{}
+		needsAttention := 0
+		pollInterval := 0
+		poll := func(seconds int) {
+			if pollInterval == 0 || seconds < pollInterval {
+				pollInterval = seconds
+			}
+		}
+
+		apps := make([]map[string]any, 0, len(appList.Items))
+		for _, app := range appList.Items {
+			entry := map[string]any{
+				"name":              app.Name,
+				"version":           iafk8s.AppVersion(&app),
+				"phase":             string(app.Status.Phase),
+				"url":               app.Status.URL,
+				"availableReplicas": app.Status.AvailableReplicas,
+				"replicas":          app.Spec.Replicas,
+			}
+			if app.Status.BuildStatus != "" {
+				entry["buildStatus"] = app.Status.BuildStatus
+			}
+			if problems := appProblems(&app); len(problems) > 0 {
+				entry["problems"] = problems
+				needsAttention++
+			}
+			switch app.Status.Phase {
+			case iafv1alpha1.ApplicationPhaseBuilding:
+				poll(30)
+			case iafv1alpha1.ApplicationPhaseDeploying:
+				poll(15)
+			}
+			phases[string(app.Status.Phase)]++
+			apps = append(apps, entry)
+		}
+
+		services := make([]map[string]any, 0, len(svcList.Items))
+		for _, svc := range svcList.Items {
+			entry := map[string]any{
+				"name":      svc.Name,
+				"type":      svc.Spec.Type,
+				"phase":     string(svc.Status.Phase),
+				"boundApps": svc.Status.BoundApps,
+			}
+			if problems := serviceProblems(&svc); len(problems) > 0 {
+				entry["problems"] = problems
+				needsAttention++
+			}
+			if svc.Status.Phase == iafv1alpha1.ManagedServicePhaseProvisioning {
+				poll(10)
+			}
+			services = append(services, entry)
+		}
+
+		result := map[string]any{
+			"applications":   apps,
+			"services":       services,
+			"appPhases":      phases,
+			"needsAttention": needsAttention,
+		}
+		if pollInterval > 0 {
+			result["pollIntervalSeconds"] = pollInterval
+		}
+
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
+
+// appProblems lists the reasons an application is unhealthy. Building,
+// deploying, suspended and sleeping apps are progressing as asked and have
+// none of their own.
+func appProblems(app *iafv1alpha1.Application) []string {
+	var problems []string
+	if app.Status.Phase == iafv1alpha1.ApplicationPhaseFailed {
+		msg := "failed"
+		if c := meta.FindStatusCondition(app.Status.Conditions, "Ready"); c != nil && c.Message != "" {
+			msg = fmt.Sprintf("%s: %s", c.Reason, c.Message)
+		}
+		problems = append(problems, msg)
+	}
+	if app.Status.Phase == iafv1alpha1.ApplicationPhaseRunning {
+		want := app.Spec.Replicas
+		if want == 0 {
+			want = 1
+		}
+		if app.Status.AvailableReplicas < want {
+			problems = append(problems, fmt.Sprintf("%d of %d replicas available", app.Status.AvailableReplicas, want))
+		}
+	}
+	problems = append(problems, conditionProblems(app.Status.Conditions)...)
+	return problems
+}
+
+// serviceProblems lists the reasons a managed service is unhealthy.
+func serviceProblems(svc *iafv1alpha1.ManagedService) []string {
+	var problems []string
+	if svc.Status.Phase == iafv1alpha1.ManagedServicePhaseFailed {
+		msg := "failed"
+		if svc.Status.Message != "" {
+			msg = svc.Status.Message
+		}
+		problems = append(problems, msg)
+	}
+	return append(problems, conditionProblems(svc.Status.Conditions)...)
+}
+
+// conditionProblems reports drift from the spec and an upcoming or blocked
+// TTL deletion.
+func conditionProblems(conditions []metav1.Condition) []string {
+	var problems []string
+	for _, condType := range []string{"Drifted", "Expiring"} {
+		if c := meta.FindStatusCondition(conditions, condType); c != nil && c.Status == metav1.ConditionTrue {
+			problems = append(problems, c.Message)
+		}
+	}
+	return problems
+}
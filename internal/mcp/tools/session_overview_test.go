@@ -0,0 +1,168 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// setupOverviewServer creates a server with session_overview registered.
+func setupOverviewServer(t *testing.T) (*gomcp.ClientSession, client.Client) {
+	t.Helper()
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		Store:      store,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterSessionOverview(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mc := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mc.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs, k8sClient
+}
+
+func TestSessionOverview(t *testing.T) {
+	cs, k8sClient := setupOverviewServer(t)
+	ctx := context.Background()
+	sid, namespace := registerCredSession(t, cs, k8sClient)
+
+	objects := []client.Object{
+		&iafv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace},
+			Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:1.25", Replicas: 2},
+			Status: iafv1alpha1.ApplicationStatus{
+				Phase:             iafv1alpha1.ApplicationPhaseRunning,
+				URL:               "http://web.test.example.com",
+				AvailableReplicas: 1,
+			},
+		},
+		&iafv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: namespace},
+			Spec:       iafv1alpha1.ApplicationSpec{Git: &iafv1alpha1.GitSource{URL: "https://github.com/org/api"}},
+			Status: iafv1alpha1.ApplicationStatus{
+				Phase:       iafv1alpha1.ApplicationPhaseFailed,
+				BuildStatus: "Failed",
+				Conditions: []metav1.Condition{{
+					Type: "Ready", Status: metav1.ConditionFalse, Reason: "BuildFailed", Message: "no buildpack detected",
+					LastTransitionTime: metav1.Now(),
+				}},
+			},
+		},
+		&iafv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: namespace},
+			Spec:       iafv1alpha1.ApplicationSpec{Blob: "http://localhost:8080/sources/worker.tar.gz"},
+			Status:     iafv1alpha1.ApplicationStatus{Phase: iafv1alpha1.ApplicationPhaseBuilding, BuildStatus: "Building"},
+		},
+		&iafv1alpha1.ManagedService{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: namespace},
+			Spec:       iafv1alpha1.ManagedServiceSpec{Type: "postgres"},
+			Status:     iafv1alpha1.ManagedServiceStatus{Phase: iafv1alpha1.ManagedServicePhaseProvisioning},
+		},
+		// Apps in other namespaces are not reported.
+		&iafv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "iaf-other"},
+			Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:1.25"},
+		},
+	}
+	for _, obj := range objects {
+		if err := k8sClient.Create(ctx, obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "session_overview", Arguments: map[string]any{"session_id": sid}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := res.Content[0].(*gomcp.TextContent).Text
+	if res.IsError {
+		t.Fatalf("unexpected error: %s", text)
+	}
+	var out struct {
+		Applications []struct {
+			Name        string   `json:"name"`
+			Phase       string   `json:"phase"`
+			BuildStatus string   `json:"buildStatus"`
+			Problems    []string `json:"problems"`
+		} `json:"applications"`
+		Services []struct {
+			Name  string `json:"name"`
+			Phase string `json:"phase"`
+		} `json:"services"`
+		AppPhases           map[string]int `json:"appPhases"`
+		NeedsAttention      int            `json:"needsAttention"`
+		PollIntervalSeconds int            `json:"pollIntervalSeconds"`
+	}
+	if err := json.Unmarshal([]byte(text), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(out.Applications) != 3 {
+		t.Fatalf("expected 3 applications, got %s", text)
+	}
+	problems := map[string][]string{}
+	for _, app := range out.Applications {
+		problems[app.Name] = app.Problems
+	}
+	if got := problems["web"]; len(got) != 1 || got[0] != "1 of 2 replicas available" {
+		t.Errorf("web problems = %v", got)
+	}
+	if got := problems["api"]; len(got) != 1 || got[0] != "BuildFailed: no buildpack detected" {
+		t.Errorf("api problems = %v", got)
+	}
+	if got := problems["worker"]; len(got) != 0 {
+		t.Errorf("expected a building app to have no problems, got %v", got)
+	}
+	if len(out.Services) != 1 || out.Services[0].Phase != "Provisioning" {
+		t.Errorf("unexpected services %s", text)
+	}
+	if out.NeedsAttention != 2 {
+		t.Errorf("needsAttention = %d, want 2", out.NeedsAttention)
+	}
+	if out.AppPhases["Running"] != 1 || out.AppPhases["Failed"] != 1 || out.AppPhases["Building"] != 1 {
+		t.Errorf("unexpected phase counts %v", out.AppPhases)
+	}
+	if out.PollIntervalSeconds != 10 {
+		t.Errorf("pollIntervalSeconds = %d, want 10", out.PollIntervalSeconds)
+	}
+}
@@ -4,8 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/features"
 	iafgithub "github.com/dlapiduz/iaf/internal/github"
+	"github.com/dlapiduz/iaf/internal/policy"
 	"github.com/dlapiduz/iaf/internal/validation"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -35,20 +41,42 @@ jobs:
 
 // SetupGithubRepoInput is the input struct for the setup_github_repo tool.
 type SetupGithubRepoInput struct {
-	SessionID  string `json:"session_id" jsonschema:"required - your session ID from the register tool"`
-	RepoName   string `json:"repo_name"  jsonschema:"required - repository name (alphanumeric, dots, hyphens, underscores; max 100 chars)"`
-	Visibility string `json:"visibility,omitempty" jsonschema:"repository visibility: 'private' (default) or 'public'"`
+	SessionID  string   `json:"session_id" jsonschema:"required - your session ID from the register tool"`
+	RepoName   string   `json:"repo_name"  jsonschema:"required - repository name (alphanumeric, dots, hyphens, underscores; max 100 chars)"`
+	Visibility string   `json:"visibility,omitempty" jsonschema:"repository visibility: 'private' (default) or 'public'"`
+	Templates  []string `json:"templates,omitempty" jsonschema:"files to seed from the platform's repo templates besides the CI workflow: codeowners, pull_request_template, issue_templates, dependabot, license, or all for every template the platform has"`
+	Owners     []string `json:"owners,omitempty" jsonschema:"CODEOWNERS entries for the codeowners template: GitHub users (@user) or teams (@org/team)"`
 }
 
+// seededFile reports one file setup_github_repo committed, or failed to
+// commit, to the new repository.
+type seededFile struct {
+	Kind      string `json:"kind"`
+	Path      string `json:"path,omitempty"`
+	Committed bool   `json:"committed"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ciWorkflowKind is the kind of the starter CI workflow in seededFile.
+const ciWorkflowKind = "ci_workflow"
+
 // RegisterSetupGithubRepo registers the setup_github_repo MCP tool.
 // This function must only be called when deps.GitHub != nil.
 func RegisterSetupGithubRepo(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "setup_github_repo",
-		Description: "Create a GitHub repository in the org, apply branch protection, and commit a starter CI workflow. Returns repo URL and a summary of applied settings. Requires IAF_GITHUB_TOKEN and IAF_GITHUB_ORG to be configured.",
+	addTool(server, deps, Capability{
+		Name:     "setup_github_repo",
+		Category: CategorySource,
+		Summary:  "Create a GitHub repository with branch protection, a CI template and optional repo templates",
+		Examples: []string{
+			`{"repo_name":"my-app"}`,
+			`{"repo_name":"my-app","templates":["codeowners","pull_request_template","dependabot"],"owners":["@my-org/web-team"]}`,
+		},
+	}, &gomcp.Tool{
+		Description: "Create a GitHub repository in the org, apply branch protection, and commit a starter CI workflow. Set templates to also seed files from the platform's repo templates: codeowners (needs owners unless the operator's template names them), pull_request_template, issue_templates, dependabot, license (only if the operator configured one), or all. Returns the repo URL, a summary of applied settings, the commit result of every file in files, and warnings for anything that failed. Requires the platform's GitHub integration (a token or GitHub App, and IAF_GITHUB_ORG) to be configured.",
 	}, func(ctx context.Context, req *gomcp.CallToolRequest, input SetupGithubRepoInput) (*gomcp.CallToolResult, any, error) {
 		// Resolve session first — every tool requires a valid session.
-		if _, err := deps.ResolveNamespace(input.SessionID); err != nil {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
 			return nil, nil, err
 		}
 
@@ -59,16 +87,35 @@ func RegisterSetupGithubRepo(server *gomcp.Server, deps *Dependencies) {
 
 		// Org must be set by the operator — never fall back to personal accounts.
 		if deps.GitHubOrg == "" {
-			return nil, nil, fmt.Errorf("IAF_GITHUB_ORG not configured; contact your platform operator")
+			return nil, nil, features.Disabled(features.GitHub)
+		}
+
+		kinds, err := templateKinds(deps.GitHubTemplates, input.Templates)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, owner := range input.Owners {
+			if err := validation.ValidateCodeOwner(owner); err != nil {
+				return nil, nil, err
+			}
 		}
 
 		private := input.Visibility != "public"
 
+		if res, err := deps.CheckPolicy(ctx, policy.Input{
+			Operation:      iafv1alpha1.PolicyOperationCreateRepo,
+			Namespace:      namespace,
+			RepoName:       input.RepoName,
+			RepoVisibility: visibilityString(private),
+		}); res != nil || err != nil {
+			return res, nil, err
+		}
+
 		result := map[string]any{
-			"repo_name":                  input.RepoName,
-			"visibility":                 visibilityString(private),
-			"branch_protection_applied":  false,
-			"ci_workflow_committed":       false,
+			"repo_name":                 input.RepoName,
+			"visibility":                visibilityString(private),
+			"branch_protection_applied": false,
+			"ci_workflow_committed":     false,
 		}
 
 		// Step 1: Create repository.
@@ -90,26 +137,81 @@ func RegisterSetupGithubRepo(server *gomcp.Server, deps *Dependencies) {
 		}
 
 		// Step 3: Commit CI workflow (partial-failure safe).
+		ci := seededFile{Kind: ciWorkflowKind, Path: ".github/workflows/ci.yml"}
 		if err := deps.GitHub.CreateFile(ctx, deps.GitHubOrg, input.RepoName,
-			".github/workflows/ci.yml", "Add starter CI workflow", []byte(ciYAML)); err != nil {
+			ci.Path, "Add starter CI workflow", []byte(ciYAML)); err != nil {
+			ci.Error = err.Error()
 			warnings, _ := result["warnings"].([]string)
 			result["warnings"] = append(warnings, fmt.Sprintf("CI workflow: %s", err.Error()))
 		} else {
+			ci.Committed = true
 			result["ci_workflow_committed"] = true
 		}
 
-		data, err := json.MarshalIndent(result, "", "  ")
+		// Step 4: Seed the requested repo templates (partial-failure safe).
+		files := []seededFile{ci}
+		data := iafgithub.TemplateData{
+			Org:    deps.GitHubOrg,
+			Repo:   input.RepoName,
+			Year:   time.Now().Year(),
+			Owners: input.Owners,
+		}
+		for _, kind := range kinds {
+			rendered, err := deps.GitHubTemplates.Render(kind, data)
+			if err != nil {
+				files = append(files, seededFile{Kind: kind, Error: err.Error()})
+				warnings, _ := result["warnings"].([]string)
+				result["warnings"] = append(warnings, fmt.Sprintf("%s: %s", kind, err.Error()))
+				continue
+			}
+			for _, f := range rendered {
+				file := seededFile{Kind: kind, Path: f.Path}
+				if err := deps.GitHub.CreateFile(ctx, deps.GitHubOrg, input.RepoName,
+					f.Path, "Add "+f.Path, f.Content); err != nil {
+					file.Error = err.Error()
+					warnings, _ := result["warnings"].([]string)
+					result["warnings"] = append(warnings, fmt.Sprintf("%s: %s", f.Path, err.Error()))
+				} else {
+					file.Committed = true
+				}
+				files = append(files, file)
+			}
+		}
+		result["files"] = files
+
+		out, err := json.MarshalIndent(result, "", "  ")
 		if err != nil {
 			return nil, nil, fmt.Errorf("marshaling result: %w", err)
 		}
 		return &gomcp.CallToolResult{
 			Content: []gomcp.Content{
-				&gomcp.TextContent{Text: string(data)},
+				&gomcp.TextContent{Text: string(out)},
 			},
 		}, nil, nil
 	})
 }
 
+// templateKinds resolves the templates argument of setup_github_repo to
+// template kinds in commit order. "all" selects every kind the platform
+// has a template for.
+func templateKinds(templates *iafgithub.RepoTemplates, requested []string) ([]string, error) {
+	if slices.Contains(requested, "all") {
+		return templates.Kinds(), nil
+	}
+	for _, kind := range requested {
+		if !slices.Contains(iafgithub.TemplateKinds, kind) {
+			return nil, fmt.Errorf("unknown template %q: must be one of %s, or all", kind, strings.Join(iafgithub.TemplateKinds, ", "))
+		}
+	}
+	var kinds []string
+	for _, kind := range iafgithub.TemplateKinds {
+		if slices.Contains(requested, kind) {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds, nil
+}
+
 func visibilityString(private bool) string {
 	if private {
 		return "private"
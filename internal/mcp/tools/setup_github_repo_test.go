@@ -310,10 +310,10 @@ func TestSetupGithubRepo_InvalidRepoName(t *testing.T) {
 	ctx := context.Background()
 
 	cases := []string{
-		"",                         // empty
-		"../secret",                // path traversal
-		"a b",                      // space
-		strings.Repeat("a", 101),   // too long
+		"",                       // empty
+		"../secret",              // path traversal
+		"a b",                    // space
+		strings.Repeat("a", 101), // too long
 	}
 
 	for _, name := range cases {
@@ -373,3 +373,93 @@ func TestSetupGithubRepo_StatusChecksApplied(t *testing.T) {
 		t.Errorf("expected required status check 'CI / ci', got %v", capturedChecks)
 	}
 }
+
+func TestSetupGithubRepo_SeedsTemplates(t *testing.T) {
+	committed := map[string]string{}
+	mock := &iafgithub.MockClient{
+		CreateFileFn: func(_ context.Context, _, _, path, _ string, content []byte) error {
+			if path == ".github/dependabot.yml" {
+				return errors.New("file write error")
+			}
+			committed[path] = string(content)
+			return nil
+		},
+	}
+	cs, _ := setupGitHubServer(t, mock)
+	sessionID := registerSession(t, cs)
+
+	res, err := cs.CallTool(context.Background(), &gomcp.CallToolParams{
+		Name: "setup_github_repo",
+		Arguments: map[string]any{
+			"session_id": sessionID,
+			"repo_name":  "my-app",
+			"templates":  []string{"dependabot", "codeowners", "license"},
+			"owners":     []string{"@test-org/web"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("unexpected error: %v", res.Content[0].(*gomcp.TextContent).Text)
+	}
+	var result struct {
+		Files []struct {
+			Kind      string `json:"kind"`
+			Path      string `json:"path"`
+			Committed bool   `json:"committed"`
+			Error     string `json:"error"`
+		} `json:"files"`
+		Warnings []string `json:"warnings"`
+	}
+	if err := json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	var kinds []string
+	for _, f := range result.Files {
+		kinds = append(kinds, f.Kind)
+	}
+	if want := "ci_workflow,codeowners,dependabot,license"; strings.Join(kinds, ",") != want {
+		t.Fatalf("files = %v, want kinds %s", kinds, want)
+	}
+	if !result.Files[1].Committed || !strings.Contains(committed[".github/CODEOWNERS"], "* @test-org/web") {
+		t.Errorf("expected CODEOWNERS with the owners, got %+v %q", result.Files[1], committed[".github/CODEOWNERS"])
+	}
+	if result.Files[2].Committed || result.Files[2].Error == "" {
+		t.Errorf("expected the dependabot commit failure, got %+v", result.Files[2])
+	}
+	if result.Files[3].Committed || !strings.Contains(result.Files[3].Error, "no license template") {
+		t.Errorf("expected no license template by default, got %+v", result.Files[3])
+	}
+	if len(result.Warnings) != 2 {
+		t.Errorf("expected a warning per failed file, got %v", result.Warnings)
+	}
+}
+
+func TestSetupGithubRepo_InvalidTemplatesRejected(t *testing.T) {
+	created := false
+	mock := &iafgithub.MockClient{
+		CreateRepoFn: func(_ context.Context, _, _ string, _ bool) (*iafgithub.RepoInfo, error) {
+			created = true
+			return &iafgithub.RepoInfo{}, nil
+		},
+	}
+	cs, _ := setupGitHubServer(t, mock)
+	sessionID := registerSession(t, cs)
+
+	for _, args := range []map[string]any{
+		{"templates": []string{"readme"}},
+		{"templates": []string{"codeowners"}, "owners": []string{"web-team\n* @attacker"}},
+	} {
+		args["session_id"] = sessionID
+		args["repo_name"] = "my-app"
+		res, err := cs.CallTool(context.Background(), &gomcp.CallToolParams{Name: "setup_github_repo", Arguments: args})
+		if err == nil && (res == nil || !res.IsError) {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+	if created {
+		t.Error("expected no repository to be created for invalid input")
+	}
+}
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type StackStatusInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Name      string `json:"name" jsonschema:"required - stack name passed to deploy_stack"`
+}
+
+// RegisterStackStatus registers the stack_status MCP tool.
+func RegisterStackStatus(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:          "stack_status",
+		Category:      CategoryDeploy,
+		ReadOnly:      true,
+		Summary:       "Status of every app and service created by deploy_stack",
+		Preconditions: []string{"a stack deployed with deploy_stack"},
+	}, &gomcp.Tool{
+		Description: "Get the status of every service and application created by deploy_stack. The overall status is Ready when all services are Ready and all apps are Running, Failed if any component failed, and Progressing otherwise. While Progressing the response includes pollIntervalSeconds — wait that long between polls.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input StackStatusInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := validation.ValidateAppName(input.Name); err != nil {
+			return nil, nil, fmt.Errorf("invalid stack name: %w", err)
+		}
+
+		selector := []client.ListOption{
+			client.InNamespace(namespace),
+			client.MatchingLabels{iafk8s.LabelStack: input.Name},
+		}
+		var services iafv1alpha1.ManagedServiceList
+		if err := deps.Client.List(ctx, &services, selector...); err != nil {
+			return nil, nil, fmt.Errorf("listing services: %w", err)
+		}
+		var apps iafv1alpha1.ApplicationList
+		if err := deps.Client.List(ctx, &apps, selector...); err != nil {
+			return nil, nil, fmt.Errorf("listing applications: %w", err)
+		}
+		if len(services.Items)+len(apps.Items) == 0 {
+			return nil, nil, apierror.NotFound(apierror.CodeNotFound, "stack %q not found; deploy it with deploy_stack first", input.Name)
+		}
+
+		components := make([]stackComponent, 0, len(services.Items)+len(apps.Items))
+		ready, failed := true, false
+		for _, svc := range services.Items {
+			components = append(components, stackComponent{
+				Kind: "service", Name: svc.Name,
+				Phase: string(svc.Status.Phase), Message: svc.Status.Message,
+			})
+			switch svc.Status.Phase {
+			case iafv1alpha1.ManagedServicePhaseReady:
+			case iafv1alpha1.ManagedServicePhaseFailed:
+				failed = true
+			default:
+				ready = false
+			}
+		}
+		for _, app := range apps.Items {
+			c := stackComponent{Kind: "app", Name: app.Name, Phase: string(app.Status.Phase), URL: app.Status.URL}
+			for _, b := range app.Spec.BoundManagedServices {
+				c.BoundServices = append(c.BoundServices, b.ServiceName)
+			}
+			switch app.Status.Phase {
+			case iafv1alpha1.ApplicationPhaseRunning:
+			case iafv1alpha1.ApplicationPhaseFailed:
+				failed = true
+			default:
+				ready = false
+			}
+			components = append(components, c)
+		}
+
+		result := map[string]any{
+			"stack":      input.Name,
+			"components": components,
+		}
+		switch {
+		case failed:
+			result["status"] = "Failed"
+			result["message"] = "At least one component failed. Use service_status, app_status, or app_logs on the failed component for details."
+		case ready:
+			result["status"] = "Ready"
+		default:
+			result["status"] = "Progressing"
+			result["pollIntervalSeconds"] = 30
+		}
+
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
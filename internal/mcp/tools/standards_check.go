@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/codecheck"
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type StandardsCheckInput struct {
+	SessionID string            `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Files     map[string]string `json:"files,omitempty" jsonschema:"map of file paths to contents to check before pushing, as for push_code. Give files or name"`
+	Name      string            `json:"name,omitempty" jsonschema:"application whose pushed source to check. Give files or name"`
+}
+
+// RegisterStandardsCheck registers the standards_check MCP tool.
+func RegisterStandardsCheck(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "standards_check",
+		Category: CategoryDeploy,
+		ReadOnly: true,
+		Summary:  "Check source against the org coding standards before deploying",
+		Examples: []string{`{"session_id": "<id>", "files": {"main.go": "package main ...", "go.mod": "module hello"}}`},
+	}, &gomcp.Tool{
+		Description: "Statically check source code against the organisation coding standards and return a pass/fail report with a hint for each failing rule. Rules: health-endpoint (a route at the org health-check path, /health by default), metrics-endpoint (Prometheus metrics at /metrics), json-logging (a structured JSON logging library) and port-env (listening on the PORT env var). Pass the files you are about to push_code, or the name of an app to check its pushed source. The checks look for common patterns and can miss unusual code; read the coding-guide prompt for the standards themselves.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input StandardsCheckInput) (*gomcp.CallToolResult, any, error) {
+		namespace, err := deps.ResolveNamespace(input.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if (len(input.Files) == 0) == (input.Name == "") {
+			return nil, nil, apierror.Validation(apierror.CodeInvalidRequest, "give either files or name")
+		}
+
+		files := input.Files
+		if input.Name != "" {
+			if err := validation.ValidateAppName(input.Name); err != nil {
+				return nil, nil, err
+			}
+			var app iafv1alpha1.Application
+			if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, &app); err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.Name)
+				}
+				return nil, nil, fmt.Errorf("getting application: %w", err)
+			}
+			files, err = deps.Store.ReadFiles(namespace, input.Name)
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, nil, apierror.NotFound(apierror.CodeNotFound, "application %q has no pushed source; pass the files instead", input.Name)
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("reading source: %w", err)
+			}
+			// Only the directory that is built counts.
+			files = filesUnder(files, app.Spec.BlobSubPath)
+		}
+
+		loader := deps.OrgStandards
+		if loader == nil {
+			loader = orgstandards.New("", nil)
+		}
+		report := codecheck.Check(files, loader.Get())
+
+		result := map[string]any{
+			"language": report.Language,
+			"passed":   report.Passed,
+			"results":  report.Results,
+		}
+		if report.Passed {
+			result["message"] = "The source meets the checked standards."
+		} else {
+			result["message"] = "Fix the failing rules using their hints, then check again before deploying."
+		}
+		text, _ := json.MarshalIndent(result, "", "  ")
+		return &gomcp.CallToolResult{
+			Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+		}, nil, nil
+	})
+}
@@ -0,0 +1,125 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// TestStandardsCheck verifies files and pushed source are checked against
+// the org standards, and that the input must name exactly one of them.
+func TestStandardsCheck(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+	store, _ := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	sessions, _ := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	deps := &tools.Dependencies{Client: k8sClient, Store: store, BaseDomain: "test.example.com", Sessions: sessions}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterStandardsCheck(server, deps)
+	st, ct := gomcp.NewInMemoryTransports()
+	server.Connect(ctx, st, nil)
+	cs, _ := gomcp.NewClient(&gomcp.Implementation{Name: "tc", Version: "0.0.1"}, nil).Connect(ctx, ct, nil)
+	t.Cleanup(func() { cs.Close() })
+	sid, ns := registerAndGetSession(t, cs)
+
+	type report struct {
+		Language string `json:"language"`
+		Passed   bool   `json:"passed"`
+		Results  []struct {
+			Rule   string `json:"rule"`
+			Status string `json:"status"`
+			Hint   string `json:"hint"`
+		} `json:"results"`
+	}
+	check := func(args map[string]any) (*gomcp.CallToolResult, report) {
+		t.Helper()
+		args["session_id"] = sid
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "standards_check", Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r report
+		json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &r)
+		return res, r
+	}
+
+	conforming := map[string]string{
+		"go.mod": "module app\n\nrequire github.com/prometheus/client_golang v1.19.0\n",
+		"main.go": `package main
+func main() {
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+	http.HandleFunc("/health", ok)
+	http.Handle("/metrics", promhttp.Handler())
+	http.ListenAndServe(":"+os.Getenv("PORT"), nil)
+}`,
+	}
+	nonConforming := map[string]string{
+		"go.mod":  "module app\n",
+		"main.go": `package main; func main() { http.ListenAndServe(":8080", nil) }`,
+	}
+
+	res, r := check(map[string]any{"files": conforming})
+	if res.IsError || r.Language != "go" || !r.Passed {
+		t.Errorf("expected conforming files to pass, got %+v", r)
+	}
+	res, r = check(map[string]any{"files": nonConforming})
+	if res.IsError || r.Passed {
+		t.Fatalf("expected non-conforming files to fail, got %+v", r)
+	}
+	for _, result := range r.Results {
+		if result.Status != "fail" || result.Hint == "" {
+			t.Errorf("expected %s to fail with a hint, got %+v", result.Rule, result)
+		}
+	}
+
+	// Pushed source is read from the store, limited to the app's sub-path.
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: ns},
+		Spec:       iafv1alpha1.ApplicationSpec{Port: 8080, Replicas: 1, BlobSubPath: "api"},
+	}
+	k8sClient.Create(ctx, app)
+	if res, _ := check(map[string]any{"name": "myapp"}); !res.IsError {
+		t.Error("expected an app without pushed source to be an error")
+	}
+	pushed := map[string]string{}
+	for p, content := range conforming {
+		pushed["api/"+p] = content
+	}
+	for p, content := range nonConforming {
+		pushed["worker/"+p] = content
+	}
+	if _, err := store.StoreFiles(ns, "myapp", pushed); err != nil {
+		t.Fatal(err)
+	}
+	res, r = check(map[string]any{"name": "myapp"})
+	if res.IsError || !r.Passed {
+		t.Errorf("expected the pushed api source to pass, got %+v", r)
+	}
+
+	for _, args := range []map[string]any{
+		{},
+		{"name": "myapp", "files": conforming},
+		{"name": "missing"},
+	} {
+		if res, _ := check(args); !res.IsError {
+			t.Errorf("expected %v to be rejected", args)
+		}
+	}
+}
@@ -4,13 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
+	"math"
+	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
 	"github.com/dlapiduz/iaf/internal/validation"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type AppStatusInput struct {
@@ -19,9 +26,14 @@ type AppStatusInput struct {
 }
 
 func RegisterAppStatus(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "app_status",
-		Description: "Check the current status of an application — phase (Pending/Building/Deploying/Running/Failed), URL, build progress, and replica count. The response includes a \"pollIntervalSeconds\" field when the app is still building or deploying — you MUST wait that many seconds between polls. Do not call this tool in a tight loop; builds take ~2 minutes.",
+	addTool(server, deps, Capability{
+		Name:     "app_status",
+		Category: CategoryObserve,
+		ReadOnly: true,
+		Summary:  "Build and deploy progress of an app; respect pollIntervalSeconds",
+		Examples: []string{`{"session_id": "<id>", "name": "web"}`},
+	}, &gomcp.Tool{
+		Description: "Check the current status of an application — phase (Pending/Building/Deploying/Running/Suspended/Sleeping/Deleted/Failed), URL, build progress, and replica count. \"rollout\" reports the latest rollout: state Progressing, Complete or Stalled, with desired, updated, ready and available replica counts; a Stalled rollout (e.g. reason ProgressDeadlineExceeded) will not finish on its own — check app_logs and conditions. When pods cannot be placed on any node, \"scheduling\" reports how many, the reasons (e.g. \"insufficient memory\", \"no nodes match selector\") and whether a cluster autoscaler is adding a node, and \"schedulingHint\" suggests replica, resource or placement changes. Apps built from git report \"gitTrack\" and \"git\": the deployed commit, the newest build's commit and whether new commits on the branch are auto-deployed (\"autoDeploy\"). Apps built from a directory of a monorepo or upload report it as \"subPath\", and apps with a start command override report \"command\" and \"args\". When the platform's concurrent build limit is reached, buildStatus is \"Queued\" and \"queuePosition\" is the build's place in line. Apps served over https report \"tls\": the certificate's issuer, whether it is ready, its expiry (\"notAfter\") and renewal time, and why it is not ready; a \"tlsWarning\" means the certificate expires within two weeks or has expired without renewal, which needs the platform operator. Apps deployed with a ttl also report \"expiresAt\" and, when deletion is near, an \"expiryWarning\". Apps deleted with delete_app have phase Deleted and report \"purgeAt\", when they are removed for good; restore_app brings them back until then. Apps built from source report \"lastBuild\" with the build's status, duration and whether it reused the build cache (\"cache\": hit, miss or none). Apps with an uptime check report \"uptimeCheck\" with the uptime percentage and last failed probe over the last 24 hours. Apps deployed with verify_conformance report \"conformance\": the image verified, a result of Pending, Passed or Failed, and the health, metrics, logs and traces checks, each with a message saying what was found; Pending checks wait up to two minutes for logs and spans. When the platform has Grafana configured, \"logExploreUrl\", \"traceExploreUrl\" and \"metricsDashboardUrl\" link to the app's logs, traces and metrics. The response includes a \"pollIntervalSeconds\" field when the app is still building or deploying — you MUST wait that many seconds between polls. Do not call this tool in a tight loop; builds take ~2 minutes.",
 	}, func(ctx context.Context, req *gomcp.CallToolRequest, input AppStatusInput) (*gomcp.CallToolResult, any, error) {
 		namespace, err := deps.ResolveNamespace(input.SessionID)
 		if err != nil {
@@ -34,13 +46,14 @@ func RegisterAppStatus(server *gomcp.Server, deps *Dependencies) {
 		var app iafv1alpha1.Application
 		if err := deps.Client.Get(ctx, types.NamespacedName{Name: input.Name, Namespace: namespace}, &app); err != nil {
 			if apierrors.IsNotFound(err) {
-				return nil, nil, fmt.Errorf("application %q not found", input.Name)
+				return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", input.Name)
 			}
 			return nil, nil, fmt.Errorf("getting application: %w", err)
 		}
 
 		result := map[string]any{
 			"name":              app.Name,
+			"version":           iafk8s.AppVersion(&app),
 			"phase":             string(app.Status.Phase),
 			"url":               app.Status.URL,
 			"latestImage":       app.Status.LatestImage,
@@ -50,12 +63,43 @@ func RegisterAppStatus(server *gomcp.Server, deps *Dependencies) {
 			"port":              app.Spec.Port,
 		}
 
+		if app.Status.Rollout != nil {
+			result["rollout"] = app.Status.Rollout
+		}
+		if app.Status.Scheduling != nil {
+			result["scheduling"] = app.Status.Scheduling
+			result["schedulingHint"] = iafk8s.SchedulingHint(&app, app.Status.Scheduling)
+		}
+		if app.Status.ImageDigest != "" {
+			result["imageDigest"] = app.Status.ImageDigest
+		}
+		if len(app.Status.Revisions) > 0 {
+			result["revisions"] = app.Status.Revisions
+		}
+		if c := meta.FindStatusCondition(app.Status.Conditions, "ImagePinned"); c != nil && c.Reason == "DigestResolutionFailed" {
+			result["imageWarning"] = c.Message
+		}
+		if app.Status.TLS != nil {
+			result["tls"] = app.Status.TLS
+		}
+		if c := meta.FindStatusCondition(app.Status.Conditions, "CertificateReady"); c != nil && (c.Reason == "ExpiresSoon" || c.Reason == "Expired") {
+			result["tlsWarning"] = c.Message
+		}
+
+		if app.Status.BuildStatus == "Queued" {
+			result["queuePosition"] = app.Status.BuildQueuePosition
+		}
+
 		// Provide a polling hint so agents don't busy-poll. Omitted once terminal.
 		switch app.Status.Phase {
 		case iafv1alpha1.ApplicationPhaseBuilding:
 			result["pollIntervalSeconds"] = 30
 		case iafv1alpha1.ApplicationPhaseDeploying:
 			result["pollIntervalSeconds"] = 15
+		case iafv1alpha1.ApplicationPhaseRunning:
+			if r := app.Status.Rollout; r != nil && r.State == iafv1alpha1.RolloutProgressing {
+				result["pollIntervalSeconds"] = 15
+			}
 		}
 
 		// Add source info
@@ -66,9 +110,25 @@ func RegisterAppStatus(server *gomcp.Server, deps *Dependencies) {
 			result["sourceType"] = "git"
 			result["gitUrl"] = app.Spec.Git.URL
 			result["gitRevision"] = app.Spec.Git.Revision
+			result["gitTrack"] = string(iafv1alpha1.GitTrackRevision)
+			if app.Spec.Git.Track != "" {
+				result["gitTrack"] = string(app.Spec.Git.Track)
+			}
+			if app.Status.Git != nil {
+				result["git"] = app.Status.Git
+			}
 		} else if app.Spec.Blob != "" {
 			result["sourceType"] = "code"
 		}
+		if subPath := iafk8s.SourceSubPath(&app); subPath != "" {
+			result["subPath"] = subPath
+		}
+		if len(app.Spec.Command) > 0 {
+			result["command"] = app.Spec.Command
+		}
+		if len(app.Spec.Args) > 0 {
+			result["args"] = app.Spec.Args
+		}
 
 		if len(app.Status.Conditions) > 0 {
 			conditions := make([]map[string]string, 0, len(app.Status.Conditions))
@@ -83,9 +143,42 @@ func RegisterAppStatus(server *gomcp.Server, deps *Dependencies) {
 			result["conditions"] = conditions
 		}
 
-		// Add Grafana Explore deep link when Tempo is configured.
-		if deps.TempoURL != "" {
-			result["traceExploreUrl"] = buildTraceExploreURL(deps.TempoURL, app.Name)
+		addExpiry(result, app.Status.ExpiresAt, app.Status.Conditions)
+		if app.Status.PurgeAt != nil {
+			result["purgeAt"] = app.Status.PurgeAt.UTC().Format(time.RFC3339)
+		}
+		if app.Spec.DeletionProtected {
+			result["deletionProtected"] = true
+		}
+
+		// Add Grafana deep links when Grafana is configured.
+		links := deps.Grafana.AppLinks(app.Namespace, app.Name)
+		for key, link := range map[string]string{
+			"logExploreUrl":       links.LogExploreURL,
+			"traceExploreUrl":     links.TraceExploreURL,
+			"metricsDashboardUrl": links.MetricsDashboardURL,
+		} {
+			if link != "" {
+				result[key] = link
+			}
+		}
+
+		if app.Spec.UptimeCheck != nil {
+			result["uptimeCheck"] = uptimeCheckInfo(ctx, deps, &app)
+		}
+
+		if app.Spec.VerifyConformance {
+			if app.Status.Conformance != nil {
+				result["conformance"] = app.Status.Conformance
+			} else {
+				result["conformance"] = map[string]string{"message": "verification starts once the app is Running and its rollout is complete"}
+			}
+		}
+
+		if app.Spec.Git != nil || app.Spec.Blob != "" {
+			if build := lastBuildInfo(ctx, deps, &app); build != nil {
+				result["lastBuild"] = build
+			}
 		}
 
 		text, _ := json.MarshalIndent(result, "", "  ")
@@ -95,21 +188,73 @@ func RegisterAppStatus(server *gomcp.Server, deps *Dependencies) {
 	})
 }
 
-// buildTraceExploreURL constructs a Grafana Explore deep link pre-filtered to
-// the given application's service.name using TraceQL. The grafanaURL comes from
-// platform config (IAF_TEMPO_URL), never from agent input.
-func buildTraceExploreURL(grafanaURL, appName string) string {
-	// TraceQL query scoped to this app's service.name.
-	query := fmt.Sprintf(`{service.name="%s"}`, appName)
-	left := fmt.Sprintf(`{"datasource":"Tempo","queries":[{"query":%s,"queryType":"traceql"}],"range":{"from":"now-1h","to":"now"}}`,
-		string(mustMarshalJSON(query)))
-	params := url.Values{}
-	params.Set("orgId", "1")
-	params.Set("left", left)
-	return grafanaURL + "/explore?" + params.Encode()
+// uptimeCheckInfo describes the app's uptime check and its results. Query
+// failures are reported in the result rather than failing app_status.
+func uptimeCheckInfo(ctx context.Context, deps *Dependencies, app *iafv1alpha1.Application) map[string]any {
+	info := map[string]any{
+		"path":            iafk8s.UptimeCheckPath(app),
+		"intervalSeconds": iafk8s.UptimeCheckInterval(app),
+	}
+	if deps.Uptime == nil {
+		info["message"] = "uptime results are not available: the platform Prometheus is not configured"
+		return info
+	}
+	report, err := deps.Uptime.Report(ctx, app.Namespace, app.Name)
+	if err != nil {
+		info["message"] = "uptime results are temporarily unavailable"
+		return info
+	}
+	if report.Percent == nil {
+		info["message"] = "no probe results yet"
+		return info
+	}
+	info["uptimePercent24h"] = math.Round(*report.Percent*100) / 100
+	if report.LastFailure != nil {
+		info["lastFailure"] = report.LastFailure.Format(time.RFC3339)
+	}
+	return info
+}
+
+// lastBuildInfo describes the app's most recent kpack build: its outcome,
+// how long it took and whether it reused the build cache. It returns nil
+// when there is no build or the builds cannot be listed.
+func lastBuildInfo(ctx context.Context, deps *Dependencies, app *iafv1alpha1.Application) map[string]any {
+	var builds unstructured.UnstructuredList
+	builds.SetGroupVersionKind(iafk8s.KpackBuildGVK.GroupVersion().WithKind(iafk8s.KpackBuildGVK.Kind + "List"))
+	if err := deps.Client.List(ctx, &builds, client.InNamespace(app.Namespace), client.MatchingLabels{iafk8s.LabelKpackImage: app.Name}); err != nil {
+		return nil
+	}
+	build := iafk8s.LatestKpackBuild(builds.Items)
+	if build == nil {
+		return nil
+	}
+	info := map[string]any{
+		"name":      build.Name,
+		"status":    build.Status,
+		"startedAt": build.StartTime.UTC().Format(time.RFC3339),
+		"cache":     build.Cache,
+	}
+	if build.Number > 0 {
+		info["number"] = build.Number
+	}
+	duration := build.Duration(time.Now())
+	if build.CompletionTime.IsZero() {
+		info["elapsedSeconds"] = int(duration.Seconds())
+	} else {
+		info["completedAt"] = build.CompletionTime.UTC().Format(time.RFC3339)
+		info["durationSeconds"] = int(duration.Seconds())
+	}
+	return info
 }
 
-func mustMarshalJSON(v any) []byte {
-	b, _ := json.Marshal(v)
-	return b
+// addExpiry reports the TTL expiry of an app or service and, once deletion
+// is near or blocked, a warning telling the agent why it will disappear.
+func addExpiry(result map[string]any, expiresAt *metav1.Time, conditions []metav1.Condition) {
+	if expiresAt == nil {
+		return
+	}
+	result["expiresAt"] = expiresAt.UTC().Format(time.RFC3339)
+	if c := meta.FindStatusCondition(conditions, "Expiring"); c != nil && c.Status == metav1.ConditionTrue {
+		result["expiryWarning"] = c.Message
+	}
 }
@@ -3,18 +3,24 @@ package tools_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
 	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/grafana"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
 	"github.com/dlapiduz/iaf/internal/mcp/tools"
 	"github.com/dlapiduz/iaf/internal/sourcestore"
+	"github.com/dlapiduz/iaf/internal/uptime"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
@@ -36,13 +42,17 @@ func TestAppStatus_TraceExploreURL_Integration(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	tempoURL := "https://grafana.example.com"
 	deps := &tools.Dependencies{
 		Client:     k8sClient,
 		Store:      store,
 		BaseDomain: "test.example.com",
 		Sessions:   sessions,
-		TempoURL:   tempoURL,
+		Grafana: grafana.Config{
+			URL:          "https://grafana.example.com",
+			LokiUID:      "loki",
+			TempoUID:     "tempo",
+			DashboardUID: "iaf-app",
+		},
 	}
 
 	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
@@ -113,6 +123,14 @@ func TestAppStatus_TraceExploreURL_Integration(t *testing.T) {
 	if !strings.Contains(traceURL, "/explore") {
 		t.Errorf("expected traceExploreUrl to contain /explore, got %q", traceURL)
 	}
+	logURL, _ := result["logExploreUrl"].(string)
+	if !strings.Contains(logURL, "/explore") || !strings.Contains(logURL, namespace) {
+		t.Errorf("expected logExploreUrl scoped to the session namespace, got %q", logURL)
+	}
+	dashboardURL, _ := result["metricsDashboardUrl"].(string)
+	if !strings.Contains(dashboardURL, "/d/iaf-app") || !strings.Contains(dashboardURL, "var-app=myapp") {
+		t.Errorf("expected metricsDashboardUrl for the app, got %q", dashboardURL)
+	}
 }
 
 func TestAppStatus_PollIntervalSeconds(t *testing.T) {
@@ -153,23 +171,29 @@ func TestAppStatus_PollIntervalSeconds(t *testing.T) {
 
 	cases := []struct {
 		phase           iafv1alpha1.ApplicationPhase
+		rollout         iafv1alpha1.RolloutState
 		wantPollSeconds float64
 		wantHint        bool
 	}{
-		{iafv1alpha1.ApplicationPhaseBuilding, 30, true},
-		{iafv1alpha1.ApplicationPhaseDeploying, 15, true},
-		{iafv1alpha1.ApplicationPhaseRunning, 0, false},
-		{iafv1alpha1.ApplicationPhaseFailed, 0, false},
+		{iafv1alpha1.ApplicationPhaseBuilding, "", 30, true},
+		{iafv1alpha1.ApplicationPhaseDeploying, "", 15, true},
+		{iafv1alpha1.ApplicationPhaseRunning, "", 0, false},
+		{iafv1alpha1.ApplicationPhaseRunning, iafv1alpha1.RolloutComplete, 0, false},
+		{iafv1alpha1.ApplicationPhaseRunning, iafv1alpha1.RolloutProgressing, 15, true},
+		{iafv1alpha1.ApplicationPhaseFailed, "", 0, false},
 	}
 
 	for _, tc := range cases {
-		t.Run(string(tc.phase), func(t *testing.T) {
+		t.Run(string(tc.phase)+string(tc.rollout), func(t *testing.T) {
 			app := &iafv1alpha1.Application{
 				ObjectMeta: metav1.ObjectMeta{Name: "poll-test", Namespace: namespace},
 				Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest", Port: 8080, Replicas: 1},
 			}
 			_ = k8sClient.Create(ctx, app)
 			app.Status.Phase = tc.phase
+			if tc.rollout != "" {
+				app.Status.Rollout = &iafv1alpha1.RolloutStatus{State: tc.rollout, DesiredReplicas: 3, UpdatedReplicas: 1}
+			}
 			_ = k8sClient.Status().Update(ctx, app)
 
 			res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
@@ -200,6 +224,147 @@ func TestAppStatus_PollIntervalSeconds(t *testing.T) {
 	}
 }
 
+func TestAppStatus_Scheduling(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithStatusSubresource(&iafv1alpha1.Application{}).
+		Build()
+
+	store, _ := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	sessions, _ := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	deps := &tools.Dependencies{Client: k8sClient, Store: store, BaseDomain: "test.example.com", Sessions: sessions}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterAppStatus(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mcpClient := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mcpClient.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+
+	regRes, _ := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "register", Arguments: map[string]any{"name": "test"}})
+	var reg map[string]any
+	_ = json.Unmarshal([]byte(regRes.Content[0].(*gomcp.TextContent).Text), &reg)
+	sid := reg["session_id"].(string)
+	namespace := reg["namespace"].(string)
+
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: namespace},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest", Port: 8080, Replicas: 3},
+	}
+	if err := k8sClient.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	app.Status.Phase = iafv1alpha1.ApplicationPhaseDeploying
+	app.Status.Scheduling = &iafv1alpha1.SchedulingStatus{UnschedulablePods: 3, Reasons: []string{iafk8s.ScheduleInsufficientMemory}}
+	if err := k8sClient.Status().Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "app_status",
+		Arguments: map[string]any{"session_id": sid, "name": "pending"},
+	})
+	if err != nil || res.IsError {
+		t.Fatalf("app_status failed: %v", err)
+	}
+	var result struct {
+		Scheduling     iafv1alpha1.SchedulingStatus `json:"scheduling"`
+		SchedulingHint string                       `json:"schedulingHint"`
+	}
+	_ = json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &result)
+	if result.Scheduling.UnschedulablePods != 3 || len(result.Scheduling.Reasons) != 1 {
+		t.Errorf("expected the scheduling status, got %+v", result.Scheduling)
+	}
+	if !strings.Contains(result.SchedulingHint, "spec.replicas (now 3)") {
+		t.Errorf("expected a hint to lower replicas, got %q", result.SchedulingHint)
+	}
+}
+
+func TestAppStatus_TLS(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithStatusSubresource(&iafv1alpha1.Application{}).
+		Build()
+
+	store, _ := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	sessions, _ := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	deps := &tools.Dependencies{Client: k8sClient, Store: store, BaseDomain: "test.example.com", Sessions: sessions}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterAppStatus(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mcpClient := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mcpClient.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+
+	regRes, _ := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "register", Arguments: map[string]any{"name": "test"}})
+	var reg map[string]any
+	_ = json.Unmarshal([]byte(regRes.Content[0].(*gomcp.TextContent).Text), &reg)
+	sid := reg["session_id"].(string)
+	namespace := reg["namespace"].(string)
+
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest", Port: 8080, Replicas: 1},
+	}
+	if err := k8sClient.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	notAfter := metav1.NewTime(time.Now().Add(72 * time.Hour))
+	app.Status.Phase = iafv1alpha1.ApplicationPhaseRunning
+	app.Status.TLS = &iafv1alpha1.TLSStatus{Issuer: "letsencrypt", Ready: true, NotAfter: &notAfter}
+	app.Status.Conditions = []metav1.Condition{{
+		Type: "CertificateReady", Status: metav1.ConditionTrue, Reason: "ExpiresSoon",
+		Message: "The certificate expires in 72h0m0s", LastTransitionTime: metav1.Now(),
+	}}
+	if err := k8sClient.Status().Update(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "app_status",
+		Arguments: map[string]any{"session_id": sid, "name": "web"},
+	})
+	if err != nil || res.IsError {
+		t.Fatalf("app_status failed: %v", err)
+	}
+	var result struct {
+		TLS        iafv1alpha1.TLSStatus `json:"tls"`
+		TLSWarning string                `json:"tlsWarning"`
+	}
+	_ = json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &result)
+	if result.TLS.Issuer != "letsencrypt" || !result.TLS.Ready || result.TLS.NotAfter == nil {
+		t.Errorf("expected the TLS status, got %+v", result.TLS)
+	}
+	if !strings.Contains(result.TLSWarning, "expires in 72h") {
+		t.Errorf("expected a TLS warning, got %q", result.TLSWarning)
+	}
+}
+
 func TestAppStatus_NoTraceExploreURL_WhenTempoNotConfigured(t *testing.T) {
 	ctx := context.Background()
 
@@ -222,7 +387,7 @@ func TestAppStatus_NoTraceExploreURL_WhenTempoNotConfigured(t *testing.T) {
 		Store:      store,
 		BaseDomain: "test.example.com",
 		Sessions:   sessions,
-		TempoURL:   "", // no Tempo configured
+		// no Grafana configured
 	}
 
 	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
@@ -267,7 +432,368 @@ func TestAppStatus_NoTraceExploreURL_WhenTempoNotConfigured(t *testing.T) {
 	var result map[string]any
 	_ = json.Unmarshal([]byte(statusRes.Content[0].(*gomcp.TextContent).Text), &result)
 
-	if _, ok := result["traceExploreUrl"]; ok {
-		t.Error("expected traceExploreUrl to be absent when TempoURL is not configured")
+	for _, key := range []string{"logExploreUrl", "traceExploreUrl", "metricsDashboardUrl"} {
+		if _, ok := result[key]; ok {
+			t.Errorf("expected %s to be absent when Grafana is not configured", key)
+		}
+	}
+}
+
+func TestAppStatus_ExpiryWarning(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithStatusSubresource(&iafv1alpha1.Application{}).
+		Build()
+
+	store, _ := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	sessions, _ := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	deps := &tools.Dependencies{Client: k8sClient, Store: store, BaseDomain: "test.example.com", Sessions: sessions}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterAppStatus(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mcpClient := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mcpClient.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+
+	regRes, _ := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "register", Arguments: map[string]any{"name": "test"}})
+	var reg map[string]any
+	_ = json.Unmarshal([]byte(regRes.Content[0].(*gomcp.TextContent).Text), &reg)
+	sid := reg["session_id"].(string)
+	namespace := reg["namespace"].(string)
+
+	expiresAt := metav1.NewTime(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	cases := []struct {
+		name        string
+		condition   metav1.ConditionStatus
+		wantWarning bool
+	}{
+		{"scheduled", metav1.ConditionFalse, false},
+		{"expires soon", metav1.ConditionTrue, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := &iafv1alpha1.Application{
+				ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: namespace},
+				Spec: iafv1alpha1.ApplicationSpec{
+					Image: "nginx:latest",
+					TTL:   &metav1.Duration{Duration: 72 * time.Hour},
+				},
+			}
+			if err := k8sClient.Create(ctx, app); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = k8sClient.Delete(ctx, app) })
+			app.Status.ExpiresAt = &expiresAt
+			app.Status.Conditions = []metav1.Condition{{
+				Type:               "Expiring",
+				Status:             tc.condition,
+				Reason:             "ExpiresSoon",
+				Message:            "Will be deleted in 3h0m0s",
+				LastTransitionTime: metav1.Now(),
+			}}
+			if err := k8sClient.Status().Update(ctx, app); err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+				Name:      "app_status",
+				Arguments: map[string]any{"session_id": sid, "name": "demo"},
+			})
+			if err != nil || res.IsError {
+				t.Fatalf("app_status failed: %v", err)
+			}
+			var result map[string]any
+			_ = json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &result)
+			if result["expiresAt"] != "2026-03-01T12:00:00Z" {
+				t.Errorf("expected expiresAt, got %v", result["expiresAt"])
+			}
+			if _, ok := result["expiryWarning"]; ok != tc.wantWarning {
+				t.Errorf("expiryWarning present = %v, want %v", ok, tc.wantWarning)
+			}
+		})
+	}
+}
+
+// fakeUptime is an uptime.Querier returning a fixed report.
+type fakeUptime struct {
+	report uptime.Report
+}
+
+func (f fakeUptime) Report(context.Context, string, string) (uptime.Report, error) {
+	return f.report, nil
+}
+
+func TestAppStatus_UptimeCheck(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	percent := 99.8765
+	lastFailure := time.Date(2026, 3, 1, 11, 30, 0, 0, time.UTC)
+	store, _ := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	sessions, _ := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		Store:      store,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+		Uptime:     fakeUptime{report: uptime.Report{Percent: &percent, LastFailure: &lastFailure}},
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterAppStatus(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mcpClient := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mcpClient.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+
+	regRes, _ := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "register", Arguments: map[string]any{"name": "test"}})
+	var reg map[string]any
+	_ = json.Unmarshal([]byte(regRes.Content[0].(*gomcp.TextContent).Text), &reg)
+	sid := reg["session_id"].(string)
+	namespace := reg["namespace"].(string)
+
+	for _, name := range []string{"probed", "unprobed"} {
+		app := &iafv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest"},
+		}
+		if name == "probed" {
+			app.Spec.UptimeCheck = &iafv1alpha1.UptimeCheckConfig{Path: "/healthz"}
+		}
+		if err := k8sClient.Create(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	status := func(name string) map[string]any {
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+			Name:      "app_status",
+			Arguments: map[string]any{"session_id": sid, "name": name},
+		})
+		if err != nil || res.IsError {
+			t.Fatalf("app_status failed: %v", err)
+		}
+		var result map[string]any
+		_ = json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &result)
+		return result
+	}
+
+	check, ok := status("probed")["uptimeCheck"].(map[string]any)
+	if !ok {
+		t.Fatal("expected uptimeCheck in app_status")
+	}
+	if check["path"] != "/healthz" || check["intervalSeconds"] != float64(60) {
+		t.Errorf("unexpected uptime check settings %v", check)
+	}
+	if check["uptimePercent24h"] != 99.88 || check["lastFailure"] != "2026-03-01T11:30:00Z" {
+		t.Errorf("unexpected uptime results %v", check)
+	}
+	if _, ok := status("unprobed")["uptimeCheck"]; ok {
+		t.Error("expected no uptimeCheck for an app without one")
+	}
+}
+
+func TestAppStatus_Conformance(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	store, _ := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	sessions, _ := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	deps := &tools.Dependencies{Client: k8sClient, Store: store, BaseDomain: "test.example.com", Sessions: sessions}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterAppStatus(server, deps)
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	cs, err := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil).Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+
+	regRes, _ := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "register", Arguments: map[string]any{"name": "test"}})
+	var reg map[string]any
+	_ = json.Unmarshal([]byte(regRes.Content[0].(*gomcp.TextContent).Text), &reg)
+	sid := reg["session_id"].(string)
+	namespace := reg["namespace"].(string)
+
+	for name, report := range map[string]*iafv1alpha1.ConformanceReport{
+		"verified": {
+			Image:  "registry/verified@sha256:abc",
+			Result: iafv1alpha1.ConformanceFailed,
+			Checks: []iafv1alpha1.ConformanceCheck{{Name: "health", Result: iafv1alpha1.ConformanceFailed, Message: "GET /health returned 404, want 2xx"}},
+		},
+		"waiting":    nil,
+		"unverified": nil,
+	} {
+		app := &iafv1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:latest", VerifyConformance: name != "unverified"},
+			Status:     iafv1alpha1.ApplicationStatus{Conformance: report},
+		}
+		if err := k8sClient.Create(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	status := func(name string) map[string]any {
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+			Name:      "app_status",
+			Arguments: map[string]any{"session_id": sid, "name": name},
+		})
+		if err != nil || res.IsError {
+			t.Fatalf("app_status failed: %v", err)
+		}
+		var result map[string]any
+		_ = json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &result)
+		return result
+	}
+
+	report, ok := status("verified")["conformance"].(map[string]any)
+	if !ok || report["result"] != "Failed" || report["image"] != "registry/verified@sha256:abc" {
+		t.Fatalf("unexpected conformance %v", report)
+	}
+	if checks, _ := report["checks"].([]any); len(checks) != 1 || checks[0].(map[string]any)["message"] != "GET /health returned 404, want 2xx" {
+		t.Errorf("unexpected checks %v", report["checks"])
+	}
+	if waiting, ok := status("waiting")["conformance"].(map[string]any); !ok || waiting["message"] == nil {
+		t.Errorf("expected a message for an app not verified yet, got %v", waiting)
+	}
+	if _, ok := status("unverified")["conformance"]; ok {
+		t.Error("expected no conformance for an app without verification")
+	}
+}
+
+func TestAppStatus_LastBuild(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	store, _ := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	sessions, _ := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		Store:      store,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterAppStatus(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mcpClient := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mcpClient.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+
+	regRes, _ := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "register", Arguments: map[string]any{"name": "test"}})
+	var reg map[string]any
+	_ = json.Unmarshal([]byte(regRes.Content[0].(*gomcp.TextContent).Text), &reg)
+	sid := reg["session_id"].(string)
+	namespace := reg["namespace"].(string)
+
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace},
+		Spec:       iafv1alpha1.ApplicationSpec{Git: &iafv1alpha1.GitSource{URL: "https://github.com/example/web"}},
+		Status: iafv1alpha1.ApplicationStatus{
+			Phase:              iafv1alpha1.ApplicationPhaseBuilding,
+			BuildStatus:        "Queued",
+			BuildQueuePosition: 3,
+		},
+	}
+	if err := k8sClient.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	status := func() map[string]any {
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+			Name:      "app_status",
+			Arguments: map[string]any{"session_id": sid, "name": "web"},
+		})
+		if err != nil || res.IsError {
+			t.Fatalf("app_status failed: %v", err)
+		}
+		var result map[string]any
+		_ = json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &result)
+		return result
+	}
+	// The first build waits in the build queue.
+	queued := status()
+	if _, ok := queued["lastBuild"]; ok {
+		t.Error("expected no lastBuild before the first build")
+	}
+	if queued["buildStatus"] != "Queued" || queued["queuePosition"] != float64(3) {
+		t.Errorf("expected queue position 3, got %v %v", queued["buildStatus"], queued["queuePosition"])
+	}
+
+	// Two builds with a cache: the first populated it, the second reused it.
+	for i, finished := range []string{"2026-03-01T10:02:30Z", "2026-03-01T11:00:45Z"} {
+		build := &unstructured.Unstructured{}
+		build.SetGroupVersionKind(iafk8s.KpackBuildGVK)
+		build.SetName(fmt.Sprintf("web-build-%d", i+1))
+		build.SetNamespace(namespace)
+		build.SetLabels(map[string]string{iafk8s.LabelKpackImage: "web", iafk8s.LabelKpackBuildNumber: fmt.Sprint(i + 1)})
+		started, _ := time.Parse(time.RFC3339, finished)
+		build.SetCreationTimestamp(metav1.NewTime(started.Add(-time.Duration(150-i*105) * time.Second)))
+		build.Object["spec"] = map[string]any{"cache": map[string]any{"volume": map[string]any{"persistentVolumeClaimName": "web-cache"}}}
+		build.Object["status"] = map[string]any{"conditions": []any{
+			map[string]any{"type": "Succeeded", "status": "True", "lastTransitionTime": finished},
+		}}
+		if err := k8sClient.Create(ctx, build); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	build, ok := status()["lastBuild"].(map[string]any)
+	if !ok {
+		t.Fatal("expected lastBuild in app_status")
+	}
+	if build["name"] != "web-build-2" || build["number"] != float64(2) || build["status"] != "Succeeded" {
+		t.Errorf("unexpected last build %v", build)
+	}
+	if build["durationSeconds"] != float64(45) || build["cache"] != "hit" || build["completedAt"] != "2026-03-01T11:00:45Z" {
+		t.Errorf("unexpected build timing or cache %v", build)
 	}
 }
@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/validation"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type SuspendAppInput struct {
+	SessionID string `json:"session_id" jsonschema:"required - session ID returned by the register tool"`
+	Name      string `json:"name" jsonschema:"required - application name to suspend"`
+}
+
+func RegisterSuspendApp(server *gomcp.Server, deps *Dependencies) {
+	addTool(server, deps, Capability{
+		Name:     "suspend_app",
+		Category: CategoryDeploy,
+		Summary:  "Park an app at zero replicas, keeping its configuration and URL",
+	}, &gomcp.Tool{
+		Description: "Park an application to save resources: scales it to zero replicas and sets its phase to Suspended while keeping its image, configuration, environment, and URL. Visitors see a \"suspended\" page until it is resumed. Use resume_app to bring it back. Requires session_id from the register tool and the application name.",
+	}, func(ctx context.Context, req *gomcp.CallToolRequest, input SuspendAppInput) (*gomcp.CallToolResult, any, error) {
+		return setAppSuspended(ctx, deps, input.SessionID, input.Name, true)
+	})
+}
+
+// setAppSuspended sets spec.suspended on an application. The controller
+// scales the Deployment and reports the phase; this only records intent.
+func setAppSuspended(ctx context.Context, deps *Dependencies, sessionID, name string, suspended bool) (*gomcp.CallToolResult, any, error) {
+	namespace, err := deps.ResolveNamespace(sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := validation.ValidateAppName(name); err != nil {
+		return nil, nil, err
+	}
+
+	var app iafv1alpha1.Application
+	if err := deps.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &app); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil, apierror.NotFound(apierror.CodeAppNotFound, "application %q not found", name)
+		}
+		return nil, nil, fmt.Errorf("getting application: %w", err)
+	}
+
+	status, message := "suspended", fmt.Sprintf("Application %q is being scaled to zero. Its configuration and URL are kept; call resume_app to bring it back.", name)
+	if !suspended {
+		status, message = "resumed", fmt.Sprintf("Application %q is starting with %d replica(s). Poll app_status until the phase is Running.", name, max(app.Spec.Replicas, 1))
+	}
+	changed := app.Spec.Suspended != suspended
+	app.Spec.Suspended = suspended
+	if !suspended && app.Annotations[iafv1alpha1.IdleAnnotation] != "" {
+		// Resuming also wakes an app the idler put to sleep.
+		delete(app.Annotations, iafv1alpha1.IdleAnnotation)
+		changed = true
+	}
+	if !changed {
+		message = fmt.Sprintf("Application %q is already %s; nothing changed.", name, status)
+	} else if err := deps.Client.Update(ctx, &app); err != nil {
+		return nil, nil, fmt.Errorf("updating application: %w", err)
+	}
+
+	result := map[string]any{
+		"name":    name,
+		"status":  status,
+		"message": message,
+	}
+	text, _ := json.MarshalIndent(result, "", "  ")
+	return &gomcp.CallToolResult{
+		Content: []gomcp.Content{&gomcp.TextContent{Text: string(text)}},
+	}, nil, nil
+}
@@ -0,0 +1,138 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSuspendResumeApp(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	other := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "elsewhere", Namespace: "iaf-other"},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:alpine", Replicas: 1},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(other).Build()
+
+	store, err := sourcestore.New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := &tools.Dependencies{
+		Client:     k8sClient,
+		Store:      store,
+		BaseDomain: "test.example.com",
+		Sessions:   sessions,
+	}
+
+	server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+	tools.RegisterRegisterTool(server, deps)
+	tools.RegisterSuspendApp(server, deps)
+	tools.RegisterResumeApp(server, deps)
+
+	st, ct := gomcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	mcpClient := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil)
+	cs, err := mcpClient.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+
+	regRes, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+		Name:      "register",
+		Arguments: map[string]any{"name": "test"},
+	})
+	if err != nil || regRes.IsError {
+		t.Fatal("register failed")
+	}
+	var regOut map[string]any
+	json.Unmarshal([]byte(regRes.Content[0].(*gomcp.TextContent).Text), &regOut)
+	sid := regOut["session_id"].(string)
+	sess, _ := sessions.Lookup(sid)
+
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: sess.Namespace},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: "nginx:alpine", Replicas: 2},
+	}
+	if err := k8sClient.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(tool, name string) (map[string]any, bool) {
+		t.Helper()
+		res, err := cs.CallTool(ctx, &gomcp.CallToolParams{
+			Name:      tool,
+			Arguments: map[string]any{"session_id": sid, "name": name},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out map[string]any
+		if !res.IsError {
+			json.Unmarshal([]byte(res.Content[0].(*gomcp.TextContent).Text), &out)
+		}
+		return out, res.IsError
+	}
+	suspended := func(key client.ObjectKey) bool {
+		t.Helper()
+		var got iafv1alpha1.Application
+		if err := k8sClient.Get(ctx, key, &got); err != nil {
+			t.Fatal(err)
+		}
+		return got.Spec.Suspended
+	}
+	key := types.NamespacedName{Name: "myapp", Namespace: sess.Namespace}
+
+	if out, isErr := call("suspend_app", "myapp"); isErr || out["status"] != "suspended" {
+		t.Fatalf("suspend_app failed: %v", out)
+	}
+	if !suspended(key) {
+		t.Error("expected spec.suspended to be set")
+	}
+	if out, isErr := call("suspend_app", "myapp"); isErr || out["message"] != `Application "myapp" is already suspended; nothing changed.` {
+		t.Errorf("expected repeated suspend to be a no-op, got %v", out)
+	}
+
+	if out, isErr := call("resume_app", "myapp"); isErr || out["status"] != "resumed" {
+		t.Fatalf("resume_app failed: %v", out)
+	}
+	if suspended(key) {
+		t.Error("expected spec.suspended to be cleared")
+	}
+
+	if _, isErr := call("suspend_app", "missing"); !isErr {
+		t.Error("expected error for unknown app")
+	}
+	// Apps in other sessions' namespaces are invisible.
+	if _, isErr := call("suspend_app", "elsewhere"); !isErr {
+		t.Error("expected error for app in another namespace")
+	}
+	if suspended(types.NamespacedName{Name: "elsewhere", Namespace: "iaf-other"}) {
+		t.Error("app in another namespace must not be modified")
+	}
+}
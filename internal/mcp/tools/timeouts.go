@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dlapiduz/iaf/internal/apierror"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// minToolTimeouts are the least time the tools that wait by design get, so
+// the default limit does not cut their longest wait short.
+var minToolTimeouts = map[string]time.Duration{
+	"deploy_stack": maxStackWaitSeconds*time.Second + time.Minute,
+}
+
+// Timeouts bounds how long tool calls run. Default applies to every tool
+// without an entry in PerTool. Zero means no limit.
+type Timeouts struct {
+	Default time.Duration
+	PerTool map[string]time.Duration
+}
+
+// For returns the limit of the named tool, or 0 for none.
+func (t Timeouts) For(name string) time.Duration {
+	if d, ok := t.PerTool[name]; ok {
+		return d
+	}
+	if t.Default <= 0 {
+		return 0
+	}
+	return max(t.Default, minToolTimeouts[name])
+}
+
+// errToolTimeout is the cause of the context of a call that ran out of
+// time, which tells it apart from a deadline set by the caller.
+var errToolTimeout = errors.New("tool call timed out")
+
+// withDeadline cancels the context of calls of h after the limit of the
+// named tool in deps.Timeouts. A call that fails because its limit passed
+// fails with code deadline_exceeded and a hint to narrow it. Calls the
+// client cancels end with their context too.
+func withDeadline[In, Out any](deps *Dependencies, name string, h gomcp.ToolHandlerFor[In, Out]) gomcp.ToolHandlerFor[In, Out] {
+	limit := deps.Timeouts.For(name)
+	if limit <= 0 {
+		return h
+	}
+	return func(ctx context.Context, req *gomcp.CallToolRequest, input In) (*gomcp.CallToolResult, Out, error) {
+		ctx, cancel := context.WithTimeoutCause(ctx, limit, errToolTimeout)
+		defer cancel()
+		res, out, err := h(ctx, req, input)
+		if err != nil && errors.Is(context.Cause(ctx), errToolTimeout) {
+			return nil, out, apierror.Platform(apierror.CodeDeadlineExceeded, true, "%s did not finish within its %s limit", name, limit).
+				WithHint("retry with a narrower scope, such as fewer log lines, a shorter time range or a single app, or try again later").
+				WithDetails(map[string]any{"tool": name, "timeout_seconds": limit.Seconds()})
+		}
+		return res, out, err
+	}
+}
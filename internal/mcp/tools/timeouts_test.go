@@ -0,0 +1,135 @@
+package tools_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/auth"
+	"github.com/dlapiduz/iaf/internal/mcp/tools"
+	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestTimeouts_For(t *testing.T) {
+	timeouts := tools.Timeouts{Default: time.Minute, PerTool: map[string]time.Duration{"app_logs": 2 * time.Minute, "list_apps": 0}}
+	for name, want := range map[string]time.Duration{
+		"app_status":   time.Minute,
+		"app_logs":     2 * time.Minute,
+		"list_apps":    0,
+		"deploy_stack": 6 * time.Minute,
+	} {
+		if got := timeouts.For(name); got != want {
+			t.Errorf("%s: limit = %s, want %s", name, got, want)
+		}
+	}
+	if got := (tools.Timeouts{}).For("deploy_stack"); got != 0 {
+		t.Errorf("expected no limit without a default, got %s", got)
+	}
+}
+
+// TestToolDeadline verifies a tool call that outlives its limit stops its
+// Kubernetes calls and fails with code deadline_exceeded, and that a call
+// the client cancels stops them too.
+func TestToolDeadline(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	listing := make(chan struct{}, 1)
+	listErr := make(chan error, 1)
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			if _, ok := list.(*iafv1alpha1.ApplicationList); !ok {
+				return c.List(ctx, list, opts...)
+			}
+			listing <- struct{}{}
+			<-ctx.Done()
+			listErr <- ctx.Err()
+			return ctx.Err()
+		},
+	}).Build()
+	sessions, err := auth.NewSessionStore(filepath.Join(t.TempDir(), "sessions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	toolErrs := make(chan error, 1)
+	connect := func(timeouts tools.Timeouts) (*gomcp.ClientSession, string) {
+		deps := &tools.Dependencies{
+			Client:     k8sClient,
+			BaseDomain: "test.example.com",
+			Sessions:   sessions,
+			Timeouts:   timeouts,
+		}
+		server := gomcp.NewServer(&gomcp.Implementation{Name: "test", Version: "0.0.1"}, nil)
+		server.AddReceivingMiddleware(func(next gomcp.MethodHandler) gomcp.MethodHandler {
+			return func(ctx context.Context, method string, req gomcp.Request) (gomcp.Result, error) {
+				res, err := next(ctx, method, req)
+				if result, ok := res.(*gomcp.CallToolResult); ok && result.IsError {
+					toolErrs <- result.GetError()
+				}
+				return res, err
+			}
+		})
+		tools.RegisterRegisterTool(server, deps)
+		tools.RegisterListApps(server, deps)
+		st, ct := gomcp.NewInMemoryTransports()
+		if _, err := server.Connect(ctx, st, nil); err != nil {
+			t.Fatal(err)
+		}
+		cs, err := gomcp.NewClient(&gomcp.Implementation{Name: "test-client", Version: "0.0.1"}, nil).Connect(ctx, ct, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { cs.Close() })
+		sid, _ := registerDSSession(t, cs)
+		return cs, sid
+	}
+
+	cs, sid := connect(tools.Timeouts{Default: time.Minute, PerTool: map[string]time.Duration{"list_apps": 50 * time.Millisecond}})
+
+	res, err := cs.CallTool(ctx, &gomcp.CallToolParams{Name: "list_apps", Arguments: map[string]any{"session_id": sid}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-listing
+	if !res.IsError {
+		t.Fatal("expected list_apps to fail once its limit passed")
+	}
+	if err := <-listErr; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the Kubernetes call to see the deadline, got %v", err)
+	}
+	apiErr := apierror.From(<-toolErrs)
+	if apiErr.Code != apierror.CodeDeadlineExceeded || !apiErr.Retryable || apiErr.Hint == "" {
+		t.Errorf("expected a retryable deadline_exceeded error with a hint, got %+v", apiErr)
+	}
+
+	cs, sid = connect(tools.Timeouts{})
+	callCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() {
+		_, err := cs.CallTool(callCtx, &gomcp.CallToolParams{Name: "list_apps", Arguments: map[string]any{"session_id": sid}})
+		done <- err
+	}()
+	<-listing
+	cancel()
+	if err := <-done; err == nil {
+		t.Error("expected the cancelled call to fail")
+	}
+	select {
+	case err := <-listErr:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the Kubernetes call to be cancelled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("expected cancelling the call to stop the Kubernetes call")
+	}
+}
@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ToolFilter selects the tools a server offers, so operators can turn off
+// tools they consider risky. A tool it rejects is not registered: it is
+// missing from ListTools and the capability listings, and calls to it fail
+// as calls to an unknown tool. register is always offered, since no other
+// tool works without a session. A nil *ToolFilter offers every tool.
+type ToolFilter struct {
+	enabled  []string
+	disabled []string
+
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+// NewToolFilter returns a filter offering only the enabled tools, or every
+// tool when enabled is empty, except the disabled ones. Names are trimmed
+// and empty names ignored.
+func NewToolFilter(enabled, disabled []string) *ToolFilter {
+	return &ToolFilter{enabled: toolNames(enabled), disabled: toolNames(disabled), seen: map[string]bool{}}
+}
+
+func toolNames(names []string) []string {
+	var out []string
+	for _, n := range names {
+		if n = strings.TrimSpace(n); n != "" {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// Allows reports whether the tool name is offered.
+func (f *ToolFilter) Allows(name string) bool {
+	if f == nil {
+		return true
+	}
+	f.mu.Lock()
+	f.seen[name] = true
+	f.mu.Unlock()
+	if name == "register" {
+		return true
+	}
+	if len(f.enabled) > 0 && !slices.Contains(f.enabled, name) {
+		return false
+	}
+	return !slices.Contains(f.disabled, name)
+}
+
+// Unknown returns the names in the filter that no tool registered so far
+// has, such as misspelled tool names.
+func (f *ToolFilter) Unknown() []string {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var unknown []string
+	for _, n := range slices.Concat(f.enabled, f.disabled) {
+		if !f.seen[n] && !slices.Contains(unknown, n) {
+			unknown = append(unknown, n)
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,28 @@
+package tools
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestToolFilter(t *testing.T) {
+	var all *ToolFilter
+	if !all.Allows("delete_app") || all.Unknown() != nil {
+		t.Error("expected a nil filter to allow every tool")
+	}
+
+	f := NewToolFilter([]string{""}, []string{"delete_app", " unregister ", "exec"})
+	for name, want := range map[string]bool{"deploy_app": true, "delete_app": false, "unregister": false, "register": true} {
+		if f.Allows(name) != want {
+			t.Errorf("%s: allowed = %v, want %v", name, !want, want)
+		}
+	}
+	if got := f.Unknown(); !slices.Equal(got, []string{"exec"}) {
+		t.Errorf("expected exec to be unknown, got %v", got)
+	}
+
+	allow := NewToolFilter([]string{"app_status"}, nil)
+	if !allow.Allows("app_status") || allow.Allows("deploy_app") || !allow.Allows("register") {
+		t.Error("expected only app_status and register to be allowed")
+	}
+}
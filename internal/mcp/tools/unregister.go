@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"strings"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
 	"github.com/dlapiduz/iaf/internal/sessiongc"
 	gomcp "github.com/modelcontextprotocol/go-sdk/mcp"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -20,16 +23,29 @@ type UnregisterInput struct {
 // It deletes all applications, source tarballs, and the session namespace,
 // then removes the session from the store.
 func RegisterUnregisterTool(server *gomcp.Server, deps *Dependencies) {
-	gomcp.AddTool(server, &gomcp.Tool{
-		Name:        "unregister",
-		Description: "Clean up a session and all its resources. Deletes all applications in the session namespace, removes source tarballs, deletes the Kubernetes namespace (cascading to all resources), and removes the session. This action is irreversible.",
+	addTool(server, deps, Capability{
+		Name:     "unregister",
+		Category: CategorySession,
+		Summary:  "Delete the session and everything in it when you are done (irreversible)",
+	}, &gomcp.Tool{
+		Description: "Clean up a session and all its resources. Deletes all applications in the session namespace, removes source tarballs, deletes the Kubernetes namespace (cascading to all resources), and removes the session. Refused with code deletion_protected while any app or service in the session is deletion protected. This action is irreversible.",
 	}, func(ctx context.Context, req *gomcp.CallToolRequest, input UnregisterInput) (*gomcp.CallToolResult, any, error) {
 		sess, ok := deps.Sessions.Lookup(input.SessionID)
 		if !ok {
-			return nil, nil, fmt.Errorf("session not found")
+			return nil, nil, apierror.NotFound(apierror.CodeSessionNotFound, "session not found")
 		}
 		namespace := sess.Namespace
 
+		protected, err := iafk8s.ProtectedResources(ctx, deps.Client, namespace)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(protected) > 0 {
+			return nil, nil, apierror.Conflict(apierror.CodeDeletionProtected, "the session has deletion-protected resources: %s", strings.Join(protected, ", ")).
+				WithHint("if deleting them is intended, call set_deletion_protection with protected=false on each first, then retry").
+				WithDetails(map[string]any{"protected": protected})
+		}
+
 		// Collect app names before deletion for the summary.
 		var appList iafv1alpha1.ApplicationList
 		if err := deps.Client.List(ctx, &appList, client.InNamespace(namespace)); err != nil {
@@ -41,7 +57,7 @@ func RegisterUnregisterTool(server *gomcp.Server, deps *Dependencies) {
 		}
 
 		// Delegate to the session GC cleaner for consistent cleanup logic.
-		cleaner := sessiongc.New(deps.Client, deps.Store, deps.Sessions, slog.Default())
+		cleaner := sessiongc.New(deps.Client, deps.Store, deps.Sessions, deps.History, slog.Default())
 		cleaner.CleanupSession(ctx, input.SessionID, namespace)
 
 		result := map[string]any{
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/dlapiduz/iaf/internal/apierror"
 	"github.com/labstack/echo/v4"
 )
 
@@ -13,30 +14,27 @@ import (
 func Auth(tokens []string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			// Skip auth for health and source store endpoints
+			// Skip auth for health, API documentation, the static suspended
+			// app page (fetched by Traefik), and source store endpoints, and
+			// for webhooks and wake links, which authenticate with their own
+			// signatures.
 			path := c.Request().URL.Path
-			if path == "/health" || path == "/ready" || strings.HasPrefix(path, "/sources/") {
+			if path == "/health" || path == "/ready" || path == "/openapi.json" || path == "/docs" || strings.HasPrefix(path, "/docs/assets/") || path == "/suspended" || strings.HasPrefix(path, "/sources/") || strings.HasPrefix(path, "/webhooks/") || strings.HasPrefix(path, "/wake/") {
 				return next(c)
 			}
 
 			auth := c.Request().Header.Get("Authorization")
 			if auth == "" {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "missing authorization header",
-				})
+				return c.JSON(http.StatusUnauthorized, apierror.FromStatus(http.StatusUnauthorized, "missing authorization header").Response())
 			}
 
 			token := strings.TrimPrefix(auth, "Bearer ")
 			if token == auth {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "invalid authorization format, expected Bearer token",
-				})
+				return c.JSON(http.StatusUnauthorized, apierror.FromStatus(http.StatusUnauthorized, "invalid authorization format, expected Bearer token").Response())
 			}
 
-			if !matchToken(token, tokens) {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "invalid API token",
-				})
+			if !MatchToken(token, tokens) {
+				return c.JSON(http.StatusUnauthorized, apierror.FromStatus(http.StatusUnauthorized, "invalid API token").Response())
 			}
 
 			return next(c)
@@ -44,7 +42,23 @@ func Auth(tokens []string) echo.MiddlewareFunc {
 	}
 }
 
-func matchToken(token string, valid []string) bool {
+// RequireToken returns an Echo middleware that admits only requests whose
+// Bearer token is one of tokens. It is layered on top of Auth to restrict a
+// route group, such as the operator endpoints, to a narrower set of tokens.
+func RequireToken(tokens []string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token, ok := strings.CutPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+			if !ok || !MatchToken(token, tokens) {
+				return c.JSON(http.StatusForbidden, apierror.FromStatus(http.StatusForbidden, "this endpoint requires an admin token").Response())
+			}
+			return next(c)
+		}
+	}
+}
+
+// MatchToken reports whether token is one of valid, in constant time.
+func MatchToken(token string, valid []string) bool {
 	for _, v := range valid {
 		if subtle.ConstantTimeCompare([]byte(token), []byte(v)) == 1 {
 			return true
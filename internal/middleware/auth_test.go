@@ -3,6 +3,7 @@ package middleware_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/dlapiduz/iaf/internal/middleware"
@@ -76,6 +77,30 @@ func TestAuth(t *testing.T) {
 			authHeader: "",
 			wantStatus: http.StatusOK,
 		},
+		{
+			name:       "suspended page bypasses auth",
+			path:       "/suspended",
+			authHeader: "",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "docs assets bypass auth",
+			path:       "/docs/assets/docs.js",
+			authHeader: "",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "wake path bypasses bearer auth (signature-verified)",
+			path:       "/wake/iaf-abc/myapp/deadbeef",
+			authHeader: "",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "webhooks path bypasses bearer auth (signature-verified)",
+			path:       "/webhooks/github",
+			authHeader: "",
+			wantStatus: http.StatusOK,
+		},
 		{
 			name:       "sources path bypasses auth",
 			path:       "/sources/myapp-abc123.tar.gz",
@@ -94,6 +119,34 @@ func TestAuth(t *testing.T) {
 			if rec.Code != tc.wantStatus {
 				t.Errorf("got status %d, want %d (body: %s)", rec.Code, tc.wantStatus, rec.Body.String())
 			}
+			if rec.Code == http.StatusUnauthorized && !strings.Contains(rec.Body.String(), `"code":"unauthorized"`) {
+				t.Errorf("expected a structured unauthorized error, got %s", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireToken(t *testing.T) {
+	handler := middleware.RequireToken([]string{"admin-token"})(okHandler)
+
+	tests := []struct {
+		name       string
+		authHeader string
+		wantStatus int
+	}{
+		{"admin token passes", "Bearer admin-token", http.StatusOK},
+		{"api token forbidden", "Bearer valid-token", http.StatusForbidden},
+		{"missing header forbidden", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, c := makeAuthRequest(http.MethodPost, "/api/v1/admin/sessions/x/suspend", tt.authHeader)
+			if err := handler(c); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
 		})
 	}
 }
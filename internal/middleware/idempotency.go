@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/idempotency"
+	"github.com/dlapiduz/iaf/internal/validation"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// HeaderIdempotencyKey carries the client-chosen key of a POST request.
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed is set to "true" on a response replayed for
+	// an idempotency key.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	// maxIdempotentBody is the largest request body an idempotency key
+	// applies to. Larger requests, such as source tarball uploads, are
+	// streamed to the handler as they are, without a key.
+	maxIdempotentBody = 1 << 20
+	// maxRecordedResponse is the largest response body kept for replay.
+	maxRecordedResponse = 1 << 20
+)
+
+// recordedResponse is a successful response kept for replay.
+type recordedResponse struct {
+	status      int
+	contentType string
+	body        []byte
+}
+
+// Idempotency returns an Echo middleware that makes POST requests carrying
+// an Idempotency-Key header safe to retry. The first successful response is
+// recorded per session and key for ttl; a retry with the same key, path and
+// body gets it back with Idempotent-Replayed: true instead of running the
+// handler again. Keys are scoped to the X-IAF-Session session, or to the
+// Bearer token for requests without one, such as registering a session.
+// Other methods, requests without the header and requests with a body over
+// maxIdempotentBody pass through. Keys live in this process's memory, so a
+// retry that reaches another API server replica runs the handler again.
+func Idempotency(ttl time.Duration) echo.MiddlewareFunc {
+	store := idempotency.NewStore[recordedResponse](ttl)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			key := req.Header.Get(HeaderIdempotencyKey)
+			if req.Method != http.MethodPost || key == "" {
+				return next(c)
+			}
+			if err := validation.ValidateIdempotencyKey(key); err != nil {
+				return c.JSON(http.StatusBadRequest, apierror.FromStatus(http.StatusBadRequest, err.Error()).Response())
+			}
+			body, err := io.ReadAll(io.LimitReader(req.Body, maxIdempotentBody+1))
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, apierror.FromStatus(http.StatusBadRequest, "reading request body").Response())
+			}
+			if len(body) > maxIdempotentBody {
+				req.Body = readCloser{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+				return next(c)
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			fingerprint := idempotency.Fingerprint([]byte(req.URL.RequestURI()), []byte(req.Header.Get(echo.HeaderContentType)), body)
+			var handlerErr error
+			rec, replayed, err := store.Do(idempotencyScope(req), key, fingerprint, func() (recordedResponse, bool) {
+				resp := c.Response()
+				tee := &teeWriter{ResponseWriter: resp.Writer}
+				resp.Writer = tee
+				handlerErr = next(c)
+				resp.Writer = tee.ResponseWriter
+				ok := handlerErr == nil && resp.Status >= 200 && resp.Status < 300 && !tee.truncated
+				return recordedResponse{status: resp.Status, contentType: resp.Header().Get(echo.HeaderContentType), body: tee.body.Bytes()}, ok
+			})
+			if err != nil {
+				return c.JSON(http.StatusConflict, apierror.From(err).Response())
+			}
+			if !replayed {
+				return handlerErr
+			}
+			c.Response().Header().Set(HeaderIdempotentReplayed, "true")
+			return c.Blob(rec.status, rec.contentType, rec.body)
+		}
+	}
+}
+
+// idempotencyScope returns the scope of a request's idempotency keys: its
+// session, or a digest of its Bearer token.
+func idempotencyScope(req *http.Request) string {
+	if sessionID := req.Header.Get("X-IAF-Session"); sessionID != "" {
+		return "session:" + sessionID
+	}
+	if sessionID := req.URL.Query().Get("session_id"); sessionID != "" {
+		return "session:" + sessionID
+	}
+	sum := sha256.Sum256([]byte(req.Header.Get(echo.HeaderAuthorization)))
+	return "token:" + hex.EncodeToString(sum[:])
+}
+
+// readCloser reads a request body from Reader and closes it with Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// teeWriter copies the response body written through it, up to
+// maxRecordedResponse bytes.
+type teeWriter struct {
+	http.ResponseWriter
+	body      bytes.Buffer
+	truncated bool // the body was larger and cannot be replayed
+}
+
+func (w *teeWriter) Write(b []byte) (int, error) {
+	if w.body.Len()+len(b) > maxRecordedResponse {
+		w.truncated = true
+		w.body.Reset()
+	} else if !w.truncated {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *teeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
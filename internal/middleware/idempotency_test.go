@@ -0,0 +1,100 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dlapiduz/iaf/internal/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+func TestIdempotency(t *testing.T) {
+	e := echo.New()
+	created := 0
+	e.POST("/apps", func(c echo.Context) error {
+		created++
+		if created > 1 {
+			return c.JSON(http.StatusConflict, map[string]string{"error": "application already exists"})
+		}
+		return c.JSON(http.StatusCreated, map[string]any{"name": "web", "n": created})
+	}, middleware.Idempotency(time.Hour))
+
+	send := func(session, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/apps", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("X-IAF-Session", session)
+		if key != "" {
+			req.Header.Set(middleware.HeaderIdempotencyKey, key)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("s1", "key-1", `{"name":"web"}`)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first status = %d", first.Code)
+	}
+	retry := send("s1", "key-1", `{"name":"web"}`)
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() || retry.Header().Get(middleware.HeaderIdempotentReplayed) != "true" {
+		t.Errorf("retry = %d %s (replayed %q), want the first response", retry.Code, retry.Body.String(), retry.Header().Get(middleware.HeaderIdempotentReplayed))
+	}
+	if created != 1 {
+		t.Errorf("handler ran %d times, want 1", created)
+	}
+
+	tests := []struct {
+		name     string
+		session  string
+		key      string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{"different body", "s1", "key-1", `{"name":"api"}`, http.StatusConflict, "idempotency_key_reused"},
+		{"invalid key", "s1", "bad key", `{"name":"web"}`, http.StatusBadRequest, "invalid_request"},
+		{"no key", "s1", "", `{"name":"web"}`, http.StatusConflict, "already exists"},
+		{"other session", "s2", "key-1", `{"name":"web"}`, http.StatusConflict, "already exists"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := send(tt.session, tt.key, tt.body)
+			if rec.Code != tt.wantCode || !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("got %d %s, want %d containing %q", rec.Code, rec.Body.String(), tt.wantCode, tt.wantBody)
+			}
+		})
+	}
+}
+
+// TestIdempotency_LargeBody verifies a body too large to remember reaches
+// the handler whole and is not replayed.
+func TestIdempotency_LargeBody(t *testing.T) {
+	e := echo.New()
+	var received []int
+	e.POST("/source", func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		received = append(received, len(body))
+		return c.NoContent(http.StatusAccepted)
+	}, middleware.Idempotency(time.Hour))
+
+	body := strings.Repeat("x", 3<<20)
+	for range 2 {
+		req := httptest.NewRequest(http.MethodPost, "/source", strings.NewReader(body))
+		req.Header.Set(middleware.HeaderIdempotencyKey, "upload-1")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if rec.Code != http.StatusAccepted || rec.Header().Get(middleware.HeaderIdempotentReplayed) != "" {
+			t.Fatalf("got %d (replayed %q)", rec.Code, rec.Header().Get(middleware.HeaderIdempotentReplayed))
+		}
+	}
+	if len(received) != 2 || received[0] != len(body) || received[1] != len(body) {
+		t.Errorf("handler received %v, want the whole body twice", received)
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/maintenance"
+	"github.com/labstack/echo/v4"
+)
+
+// readOnlyRoutes are the POST routes that change nothing: GraphQL, which
+// has no mutations, and the dry-run update plan. The MCP endpoint is left
+// to the tools, which refuse changes themselves.
+var readOnlyRoutes = map[string]bool{
+	"/mcp":                            true,
+	"/api/v1/graphql":                 true,
+	"/api/v1/applications/:name/plan": true,
+}
+
+// Maintenance returns an Echo middleware that refuses requests that could
+// change something with code maintenance while mode is on. GET, HEAD and
+// OPTIONS requests and readOnlyRoutes are served as usual. When the end of
+// the maintenance is known, Retry-After says how long is left.
+func Maintenance(mode *maintenance.Mode) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !mode.Active() || readOnlyRoutes[c.Path()] {
+				return next(c)
+			}
+			switch c.Request().Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				return next(c)
+			}
+			if secs := mode.RetryAfter(time.Now()); secs > 0 {
+				c.Response().Header().Set("Retry-After", strconv.Itoa(secs))
+			}
+			err := mode.Err()
+			return c.JSON(apierror.HTTPStatus(err), err.Response())
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dlapiduz/iaf/internal/maintenance"
+	"github.com/dlapiduz/iaf/internal/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+func TestMaintenance(t *testing.T) {
+	until := time.Now().Add(time.Hour)
+	for _, tt := range []struct {
+		name       string
+		mode       *maintenance.Mode
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"off", nil, http.MethodPost, "/api/v1/applications", http.StatusOK},
+		{"create", &maintenance.Mode{Until: until}, http.MethodPost, "/api/v1/applications", http.StatusServiceUnavailable},
+		{"delete", &maintenance.Mode{}, http.MethodDelete, "/api/v1/applications/web", http.StatusServiceUnavailable},
+		{"read", &maintenance.Mode{}, http.MethodGet, "/api/v1/applications/web", http.StatusOK},
+		{"plan", &maintenance.Mode{}, http.MethodPost, "/api/v1/applications/web/plan", http.StatusOK},
+		{"graphql", &maintenance.Mode{}, http.MethodPost, "/api/v1/graphql", http.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Use(middleware.Maintenance(tt.mode))
+			ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+			e.POST("/api/v1/applications", ok)
+			e.GET("/api/v1/applications/:name", ok)
+			e.DELETE("/api/v1/applications/:name", ok)
+			e.POST("/api/v1/applications/:name/plan", ok)
+			e.POST("/api/v1/graphql", ok)
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK {
+				return
+			}
+			if !strings.Contains(rec.Body.String(), `"code":"maintenance"`) {
+				t.Errorf("expected code maintenance, got %s", rec.Body.String())
+			}
+			if retry := rec.Header().Get("Retry-After"); (retry != "") != !tt.mode.Until.IsZero() {
+				t.Errorf("Retry-After = %q with until %v", retry, tt.mode.Until)
+			}
+		})
+	}
+}
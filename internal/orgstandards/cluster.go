@@ -0,0 +1,200 @@
+package orgstandards
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// watchRetryInterval is how long WatchCluster waits before listing again
+// after the API server ends or refuses the watch.
+const watchRetryInterval = 5 * time.Second
+
+// WatchCluster merges the OrgStandards resources in the cluster over the
+// file or platform defaults and keeps the result current. It blocks until
+// ctx is cancelled and retries after API errors, serving the last merged
+// standards meanwhile. Safe to call in a goroutine alongside Start.
+func (l *Loader) WatchCluster(ctx context.Context, c client.WithWatch) {
+	for {
+		if err := l.syncCluster(ctx, c); err != nil && ctx.Err() == nil {
+			l.logger.Warn("orgstandards: watching OrgStandards resources", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(watchRetryInterval):
+		}
+	}
+}
+
+// syncCluster lists the OrgStandards resources, applies them, and re-lists
+// on every change until the watch ends.
+func (l *Loader) syncCluster(ctx context.Context, c client.WithWatch) error {
+	var list iafv1alpha1.OrgStandardsList
+	if err := c.List(ctx, &list); err != nil {
+		return err
+	}
+	l.setOverrides(orderOverrides(list.Items))
+	l.logger.Info("orgstandards: loaded cluster overrides", "count", len(list.Items))
+
+	w, err := c.Watch(ctx, &iafv1alpha1.OrgStandardsList{}, &client.ListOptions{
+		Raw: &metav1.ListOptions{ResourceVersion: list.ResourceVersion},
+	})
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return nil
+			}
+			if event.Type == watch.Error {
+				return nil
+			}
+			if err := c.List(ctx, &list); err != nil {
+				return err
+			}
+			l.setOverrides(orderOverrides(list.Items))
+			l.logger.Info("orgstandards: reloaded cluster overrides", "count", len(list.Items))
+		}
+	}
+}
+
+// orderOverrides returns the specs of items in merge order: ascending
+// priority, then name.
+func orderOverrides(items []iafv1alpha1.OrgStandards) []iafv1alpha1.OrgStandardsSpec {
+	sorted := slices.Clone(items)
+	slices.SortFunc(sorted, func(a, b iafv1alpha1.OrgStandards) int {
+		return cmp.Or(cmp.Compare(a.Spec.Priority, b.Spec.Priority), strings.Compare(a.Name, b.Name))
+	})
+	specs := make([]iafv1alpha1.OrgStandardsSpec, 0, len(sorted))
+	for _, item := range sorted {
+		specs = append(specs, item.Spec)
+	}
+	return specs
+}
+
+// merge applies overrides to base in order. Set scalars replace the lower
+// layer's value, present lists replace the lower layer's list, and
+// PerLanguage merges per language. base is not modified.
+func merge(base *OrgStandards, overrides []iafv1alpha1.OrgStandardsSpec, logger *slog.Logger) *OrgStandards {
+	if len(overrides) == 0 {
+		return base
+	}
+	out := *base
+	out.PerLanguage = maps.Clone(base.PerLanguage)
+	if out.PerLanguage == nil {
+		out.PerLanguage = map[string]PerLanguageStandards{}
+	}
+	for _, o := range overrides {
+		if o.HealthCheckPath != "" {
+			out.HealthCheckPath = o.HealthCheckPath
+		}
+		if o.LoggingFormat != "" {
+			out.LoggingFormat = o.LoggingFormat
+		}
+		if o.DefaultPort != 0 {
+			out.DefaultPort = int(o.DefaultPort)
+		}
+		if o.EnvVarNaming != "" {
+			out.EnvVarNaming = o.EnvVarNaming
+		}
+		if o.RequiredEnvVars != nil {
+			out.RequiredEnvVars = o.RequiredEnvVars
+		}
+		if o.BestPractices != nil {
+			out.BestPractices = o.BestPractices
+		}
+		if o.IdleTimeout != "" {
+			out.IdleTimeout = o.IdleTimeout
+		}
+		if m := o.PackageMirrors; m != nil {
+			replaceIfSet(&out.PackageMirrors.NPM, m.NPM)
+			replaceIfSet(&out.PackageMirrors.PyPI, m.PyPI)
+			replaceIfSet(&out.PackageMirrors.GoProxy, m.GoProxy)
+			replaceIfSet(&out.PackageMirrors.Maven, m.Maven)
+			replaceIfSet(&out.PackageMirrors.RubyGems, m.RubyGems)
+		}
+		if d := o.Dependencies; d != nil {
+			if d.Deny != nil {
+				out.Dependencies.Deny = convertDependencyRules(d.Deny)
+			}
+			if d.Allow != nil {
+				out.Dependencies.Allow = convertDependencyRules(d.Allow)
+			}
+			if d.Waivers != nil {
+				out.Dependencies.Waivers = make([]DependencyWaiver, 0, len(d.Waivers))
+				for _, w := range d.Waivers {
+					out.Dependencies.Waivers = append(out.Dependencies.Waivers, DependencyWaiver(w))
+				}
+			}
+		}
+		for lang, ls := range o.PerLanguage {
+			std := out.PerLanguage[lang]
+			if ls.Notes != nil {
+				std.Notes = ls.Notes
+			}
+			if ls.ApprovedFrameworks != nil {
+				std.ApprovedFrameworks = convertFrameworks(ls.ApprovedFrameworks)
+			}
+			if ls.ProhibitedFrameworks != nil {
+				std.ProhibitedFrameworks = convertFrameworks(ls.ProhibitedFrameworks)
+			}
+			if ls.ApprovedLibraries != nil {
+				std.ApprovedLibraries = convertLibraries(ls.ApprovedLibraries)
+			}
+			if ls.ProhibitedLibraries != nil {
+				std.ProhibitedLibraries = convertLibraries(ls.ProhibitedLibraries)
+			}
+			if ls.RuntimeVersions != nil {
+				std.RuntimeVersions = ls.RuntimeVersions
+			}
+			out.PerLanguage[lang] = std
+		}
+	}
+	sanitize(&out, logger)
+	return &out
+}
+
+func replaceIfSet(dst *string, v string) {
+	if v != "" {
+		*dst = v
+	}
+}
+
+func convertFrameworks(in []iafv1alpha1.FrameworkStandard) []FrameworkStandard {
+	out := make([]FrameworkStandard, 0, len(in))
+	for _, f := range in {
+		out = append(out, FrameworkStandard(f))
+	}
+	return out
+}
+
+func convertLibraries(in []iafv1alpha1.LibraryStandard) []LibraryStandard {
+	out := make([]LibraryStandard, 0, len(in))
+	for _, l := range in {
+		out = append(out, LibraryStandard(l))
+	}
+	return out
+}
+
+func convertDependencyRules(in []iafv1alpha1.DependencyRule) []DependencyRule {
+	out := make([]DependencyRule, 0, len(in))
+	for _, r := range in {
+		out = append(out, DependencyRule(r))
+	}
+	return out
+}
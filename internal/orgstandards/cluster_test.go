@@ -0,0 +1,128 @@
+package orgstandards_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newOrgStandards(name string, spec iafv1alpha1.OrgStandardsSpec) *iafv1alpha1.OrgStandards {
+	return &iafv1alpha1.OrgStandards{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: spec}
+}
+
+// waitFor polls until cond holds or fails the test after two seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestLoader_WatchCluster_Merge(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := iafv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newOrgStandards("team", iafv1alpha1.OrgStandardsSpec{
+			Priority:       10,
+			DefaultPort:    3000,
+			BestPractices:  []string{"Team practice"},
+			PackageMirrors: &iafv1alpha1.PackageMirrors{GoProxy: "https://goproxy.corp.example"},
+		}),
+		newOrgStandards("platform", iafv1alpha1.OrgStandardsSpec{
+			DefaultPort:     9000,
+			HealthCheckPath: "/healthz",
+			RequiredEnvVars: []string{},
+			PackageMirrors:  &iafv1alpha1.PackageMirrors{NPM: "https://npm.corp.example", GoProxy: "https://old.corp.example"},
+			PerLanguage: map[string]iafv1alpha1.LanguageStandards{
+				"go": {ProhibitedLibraries: []iafv1alpha1.LibraryStandard{
+					{Name: "github.com/pkg/errors", Reason: "Use the standard errors package"},
+					{Name: "evil", MinVersion: "$(rm -rf /)"},
+				}, RuntimeVersions: []string{"1.23", "$(rm -rf /)"}},
+			},
+		}),
+	).Build()
+
+	l := orgstandards.New("", slog.Default())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go l.WatchCluster(ctx, c)
+
+	waitFor(t, "cluster overrides", func() bool { return l.Get().HealthCheckPath == "/healthz" })
+	s := l.Get()
+	if s.DefaultPort != 3000 {
+		t.Errorf("expected the higher priority port 3000, got %d", s.DefaultPort)
+	}
+	if len(s.BestPractices) != 1 || s.BestPractices[0] != "Team practice" {
+		t.Errorf("expected best practices to be replaced, got %v", s.BestPractices)
+	}
+	if s.PackageMirrors.NPM != "https://npm.corp.example" || s.PackageMirrors.GoProxy != "https://goproxy.corp.example" {
+		t.Errorf("expected mirrors to merge per field, got %+v", s.PackageMirrors)
+	}
+	if s.LoggingFormat != "json" {
+		t.Errorf("expected unset loggingFormat to keep the default, got %q", s.LoggingFormat)
+	}
+	goStd := s.PerLanguage["go"]
+	if len(goStd.ProhibitedLibraries) != 1 || goStd.ProhibitedLibraries[0].Name != "github.com/pkg/errors" {
+		t.Errorf("expected one sanitized prohibited library, got %v", goStd.ProhibitedLibraries)
+	}
+	if len(goStd.RuntimeVersions) != 1 || goStd.RuntimeVersions[0] != "1.23" {
+		t.Errorf("expected one sanitized runtime version, got %v", goStd.RuntimeVersions)
+	}
+	if len(goStd.ApprovedFrameworks) == 0 {
+		t.Error("expected go frameworks from the defaults to be kept")
+	}
+	if _, ok := s.PerLanguage["python"]; !ok {
+		t.Error("expected languages without overrides to be kept")
+	}
+
+	// Changes arrive through the watch.
+	if err := c.Create(ctx, newOrgStandards("idle", iafv1alpha1.OrgStandardsSpec{Priority: 20, IdleTimeout: "2h"})); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "new override", func() bool { return l.Get().IdleTimeoutDuration() == 2*time.Hour })
+
+	if err := c.DeleteAllOf(ctx, &iafv1alpha1.OrgStandards{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "overrides removed", func() bool { return l.Get().DefaultPort == 8080 })
+}
+
+func TestLoader_WatchCluster_OverFile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := iafv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newOrgStandards("port", iafv1alpha1.OrgStandardsSpec{DefaultPort: 9000}),
+	).Build()
+
+	path := filepath.Join(t.TempDir(), "standards.yaml")
+	if err := os.WriteFile(path, []byte("healthCheckPath: /ping\ndefaultPort: 3000\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	l := orgstandards.New(path, slog.Default())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go l.WatchCluster(ctx, c)
+
+	waitFor(t, "cluster overrides", func() bool { return l.Get().DefaultPort == 9000 })
+	if got := l.Get().HealthCheckPath; got != "/ping" {
+		t.Errorf("expected healthCheckPath from the file, got %q", got)
+	}
+}
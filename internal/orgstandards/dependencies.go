@@ -0,0 +1,210 @@
+package orgstandards
+
+import (
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Package ecosystems of dependency rules.
+const (
+	EcosystemGo       = "go"
+	EcosystemNPM      = "npm"
+	EcosystemPyPI     = "pypi"
+	EcosystemMaven    = "maven"
+	EcosystemRubyGems = "rubygems"
+)
+
+// Ecosystems are the package ecosystems dependency rules may name.
+var Ecosystems = []string{EcosystemGo, EcosystemNPM, EcosystemPyPI, EcosystemMaven, EcosystemRubyGems}
+
+// DependencyPolicy holds the packages apps may and may not depend on.
+type DependencyPolicy struct {
+	// Deny blocks the dependencies matching any rule.
+	Deny []DependencyRule `json:"deny,omitempty" yaml:"deny,omitempty"`
+	// Allow, when it has rules for an ecosystem, blocks that ecosystem's
+	// dependencies matching none of them.
+	Allow []DependencyRule `json:"allow,omitempty" yaml:"allow,omitempty"`
+	// Waivers exempt apps from the rules.
+	Waivers []DependencyWaiver `json:"waivers,omitempty" yaml:"waivers,omitempty"`
+}
+
+// DependencyRule matches dependencies of one ecosystem. Package and
+// Versions are patterns where * matches any characters; empty Versions
+// matches every version.
+type DependencyRule struct {
+	Ecosystem string `json:"ecosystem"          yaml:"ecosystem"`
+	Package   string `json:"package"            yaml:"package"`
+	Versions  string `json:"versions,omitempty" yaml:"versions,omitempty"`
+	Reason    string `json:"reason,omitempty"   yaml:"reason,omitempty"`
+}
+
+// DependencyWaiver exempts the dependencies matching Ecosystem and Package
+// in the apps matching Namespace and App (empty matches any) until
+// Expires, a YYYY-MM-DD date (empty never expires).
+type DependencyWaiver struct {
+	Ecosystem string `json:"ecosystem"           yaml:"ecosystem"`
+	Package   string `json:"package"             yaml:"package"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	App       string `json:"app,omitempty"       yaml:"app,omitempty"`
+	Reason    string `json:"reason"              yaml:"reason"`
+	Expires   string `json:"expires,omitempty"   yaml:"expires,omitempty"`
+}
+
+// Dependency is a package an app depends on.
+type Dependency struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	// Version is the declared or installed version, or "" when unknown.
+	Version string `json:"version,omitempty"`
+	// Source is the file or SBOM the dependency was found in.
+	Source string `json:"source,omitempty"`
+}
+
+// DependencyViolation is a dependency the policy blocks.
+type DependencyViolation struct {
+	Dependency
+	// Reason says which rule blocked the dependency.
+	Reason string `json:"reason"`
+}
+
+// IsZero reports whether the policy blocks nothing.
+func (p DependencyPolicy) IsZero() bool {
+	return len(p.Deny) == 0 && len(p.Allow) == 0
+}
+
+// Check returns the dependencies of the app namespace/app the policy
+// blocks on the day now falls on, each package once.
+func (p DependencyPolicy) Check(deps []Dependency, namespace, app string, now time.Time) []DependencyViolation {
+	var violations []DependencyViolation
+	seen := map[string]bool{}
+	for _, d := range deps {
+		key := d.Ecosystem + "\x00" + normalizePackage(d.Ecosystem, d.Name) + "\x00" + d.Version
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		reason := p.blocks(d)
+		if reason == "" || slices.ContainsFunc(p.Waivers, func(w DependencyWaiver) bool { return w.covers(d, namespace, app, now) }) {
+			continue
+		}
+		violations = append(violations, DependencyViolation{Dependency: d, Reason: reason})
+	}
+	return violations
+}
+
+// blocks returns why the policy blocks d, or "" when it does not.
+func (p DependencyPolicy) blocks(d Dependency) string {
+	for _, r := range p.Deny {
+		if r.matches(d, false) {
+			if r.Reason != "" {
+				return "denied: " + r.Reason
+			}
+			return "denied by the organisation dependency policy"
+		}
+	}
+	restricted := false
+	for _, r := range p.Allow {
+		if r.Ecosystem != d.Ecosystem {
+			continue
+		}
+		if r.matches(d, true) {
+			return ""
+		}
+		restricted = true
+	}
+	if restricted {
+		return "not on the organisation's allowed " + d.Ecosystem + " packages"
+	}
+	return ""
+}
+
+// matches reports whether r matches d. A version rule matches a
+// dependency of unknown version only when unknownMatches is set.
+func (r DependencyRule) matches(d Dependency, unknownMatches bool) bool {
+	if r.Ecosystem != d.Ecosystem || !matchPattern(normalizePackage(r.Ecosystem, r.Package), normalizePackage(d.Ecosystem, d.Name)) {
+		return false
+	}
+	if r.Versions == "" {
+		return true
+	}
+	version := strings.TrimLeft(strings.TrimSpace(d.Version), "v=^~ ")
+	if version == "" {
+		return unknownMatches
+	}
+	return matchPattern(r.Versions, version)
+}
+
+// covers reports whether w exempts d in the app namespace/app on now.
+func (w DependencyWaiver) covers(d Dependency, namespace, app string, now time.Time) bool {
+	if w.Ecosystem != d.Ecosystem || !matchPattern(normalizePackage(w.Ecosystem, w.Package), normalizePackage(d.Ecosystem, d.Name)) {
+		return false
+	}
+	if (w.Namespace != "" && w.Namespace != namespace) || (w.App != "" && w.App != app) {
+		return false
+	}
+	if w.Expires == "" {
+		return true
+	}
+	expires, err := time.Parse(time.DateOnly, w.Expires)
+	return err == nil && !now.After(expires.AddDate(0, 0, 1))
+}
+
+// pypiSeparators are the runs PEP 503 folds to one hyphen.
+var pypiSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizePackage folds the spellings an ecosystem treats as one name.
+func normalizePackage(ecosystem, name string) string {
+	switch ecosystem {
+	case EcosystemPyPI:
+		return pypiSeparators.ReplaceAllString(strings.ToLower(name), "-")
+	case EcosystemNPM, EcosystemRubyGems:
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+// matchPattern matches s against pattern, where * matches any characters.
+func matchPattern(pattern, s string) bool {
+	before, after, found := strings.Cut(pattern, "*")
+	if !found {
+		return s == pattern
+	}
+	if !strings.HasPrefix(s, before) {
+		return false
+	}
+	s = s[len(before):]
+	for i := 0; i <= len(s); i++ {
+		if matchPattern(after, s[i:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeDependencies drops rules and waivers that cannot match or whose
+// expiry cannot be read, and waivers without a reason.
+func sanitizeDependencies(p DependencyPolicy, logger *slog.Logger) DependencyPolicy {
+	invalidRule := func(kind string) func(DependencyRule) bool {
+		return func(r DependencyRule) bool {
+			if slices.Contains(Ecosystems, r.Ecosystem) && r.Package != "" {
+				return false
+			}
+			logger.Warn("orgstandards: invalid dependency rule — entry skipped", "list", kind, "ecosystem", r.Ecosystem, "package", r.Package)
+			return true
+		}
+	}
+	p.Deny = slices.DeleteFunc(slices.Clone(p.Deny), invalidRule("deny"))
+	p.Allow = slices.DeleteFunc(slices.Clone(p.Allow), invalidRule("allow"))
+	p.Waivers = slices.DeleteFunc(slices.Clone(p.Waivers), func(w DependencyWaiver) bool {
+		_, err := time.Parse(time.DateOnly, w.Expires)
+		if slices.Contains(Ecosystems, w.Ecosystem) && w.Package != "" && w.Reason != "" && (w.Expires == "" || err == nil) {
+			return false
+		}
+		logger.Warn("orgstandards: invalid dependency waiver — entry skipped", "ecosystem", w.Ecosystem, "package", w.Package, "expires", w.Expires)
+		return true
+	})
+	return p
+}
@@ -0,0 +1,89 @@
+package orgstandards_test
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+)
+
+func TestDependencyPolicy_Check(t *testing.T) {
+	p := orgstandards.DependencyPolicy{
+		Deny: []orgstandards.DependencyRule{
+			{Ecosystem: "npm", Package: "left-pad", Reason: "unmaintained"},
+			{Ecosystem: "maven", Package: "org.apache.logging.log4j:log4j-core", Versions: "2.1*"},
+			{Ecosystem: "go", Package: "github.com/evil/*"},
+		},
+		Allow: []orgstandards.DependencyRule{
+			{Ecosystem: "pypi", Package: "flask"},
+			{Ecosystem: "pypi", Package: "requests"},
+		},
+		Waivers: []orgstandards.DependencyWaiver{
+			{Ecosystem: "npm", Package: "left-pad", Namespace: "iaf-legacy", Reason: "migration", Expires: "2026-03-31"},
+		},
+	}
+	now := time.Date(2026, 3, 31, 18, 0, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		name      string
+		dep       orgstandards.Dependency
+		namespace string
+		blocked   bool
+	}{
+		{"denied package", orgstandards.Dependency{Ecosystem: "npm", Name: "Left-Pad", Version: "1.3.0"}, "iaf-web", true},
+		{"waived in namespace until expiry day", orgstandards.Dependency{Ecosystem: "npm", Name: "left-pad"}, "iaf-legacy", false},
+		{"denied version", orgstandards.Dependency{Ecosystem: "maven", Name: "org.apache.logging.log4j:log4j-core", Version: "2.14.1"}, "iaf-web", true},
+		{"fixed version", orgstandards.Dependency{Ecosystem: "maven", Name: "org.apache.logging.log4j:log4j-core", Version: "2.24.0"}, "iaf-web", false},
+		{"unknown version of a denied range", orgstandards.Dependency{Ecosystem: "maven", Name: "org.apache.logging.log4j:log4j-core"}, "iaf-web", false},
+		{"denied prefix", orgstandards.Dependency{Ecosystem: "go", Name: "github.com/evil/pkg", Version: "v1.0.0"}, "iaf-web", true},
+		{"allowed package", orgstandards.Dependency{Ecosystem: "pypi", Name: "Flask", Version: "3.0.0"}, "iaf-web", false},
+		{"not on the allow list", orgstandards.Dependency{Ecosystem: "pypi", Name: "django"}, "iaf-web", true},
+		{"ecosystem without an allow list", orgstandards.Dependency{Ecosystem: "rubygems", Name: "rails"}, "iaf-web", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.Check([]orgstandards.Dependency{tt.dep}, tt.namespace, "app", now)
+			if blocked := len(got) > 0; blocked != tt.blocked {
+				t.Errorf("blocked = %v, want %v (%+v)", blocked, tt.blocked, got)
+			}
+		})
+	}
+
+	got := p.Check([]orgstandards.Dependency{{Ecosystem: "npm", Name: "left-pad"}}, "iaf-legacy", "app", now.Add(24*time.Hour))
+	if len(got) != 1 || got[0].Reason != "denied: unmaintained" {
+		t.Errorf("expired waiver: got %+v", got)
+	}
+
+	dup := orgstandards.Dependency{Ecosystem: "pypi", Name: "django", Version: "5.0"}
+	if got := p.Check([]orgstandards.Dependency{dup, dup}, "iaf-web", "app", now); len(got) != 1 {
+		t.Errorf("expected one violation per package, got %+v", got)
+	}
+}
+
+func TestLoader_Dependencies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "standards.yaml")
+	content := `
+dependencies:
+  deny:
+    - {ecosystem: npm, package: left-pad}
+    - {ecosystem: cargo, package: serde}
+    - {ecosystem: pypi, package: ""}
+  waivers:
+    - {ecosystem: npm, package: left-pad, reason: migration, expires: "2026-03-31"}
+    - {ecosystem: npm, package: left-pad, expires: "2026-03-31"}
+    - {ecosystem: npm, package: left-pad, reason: migration, expires: "next week"}
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := orgstandards.New(path, slog.Default()).Get().Dependencies
+	if len(d.Deny) != 1 || d.Deny[0].Package != "left-pad" {
+		t.Errorf("deny = %+v, want only the valid rule", d.Deny)
+	}
+	if len(d.Waivers) != 1 || d.Waivers[0].Reason != "migration" || d.Waivers[0].Expires != "2026-03-31" {
+		t.Errorf("waivers = %+v, want only the valid waiver", d.Waivers)
+	}
+}
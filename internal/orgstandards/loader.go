@@ -2,6 +2,8 @@
 // Standards are read from a YAML or JSON file at startup and hot-reloaded
 // via fsnotify whenever the file changes.  If no file is configured or the
 // file cannot be read, compile-time platform defaults are served instead.
+// OrgStandards custom resources, watched through the Kubernetes API, are
+// merged on top so every replica serves the same operator overrides.
 package orgstandards
 
 import (
@@ -10,12 +12,15 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
 	"github.com/fsnotify/fsnotify"
 	"gopkg.in/yaml.v3"
 )
@@ -50,6 +55,9 @@ type PerLanguageStandards struct {
 	ProhibitedFrameworks []FrameworkStandard `json:"prohibitedFrameworks" yaml:"prohibitedFrameworks"`
 	ApprovedLibraries    []LibraryStandard   `json:"approvedLibraries"    yaml:"approvedLibraries"`
 	ProhibitedLibraries  []LibraryStandard   `json:"prohibitedLibraries"  yaml:"prohibitedLibraries"`
+	// RuntimeVersions are the runtime versions apps may build with, e.g.
+	// ["20", "22"] for Node.js; the first is the default. Empty allows any.
+	RuntimeVersions []string `json:"runtimeVersions,omitempty" yaml:"runtimeVersions,omitempty"`
 }
 
 // OrgStandards is the full set of coding standards served to agents.
@@ -61,6 +69,49 @@ type OrgStandards struct {
 	RequiredEnvVars []string                        `json:"requiredEnvVars"  yaml:"requiredEnvVars"`
 	BestPractices   []string                        `json:"bestPractices"    yaml:"bestPractices"`
 	PerLanguage     map[string]PerLanguageStandards `json:"perLanguage"      yaml:"perLanguage"`
+	// IdleTimeout is the default idle window (e.g. "2h") after which apps
+	// without spec.idleTimeout are scaled to zero. Empty disables idling.
+	IdleTimeout string `json:"idleTimeout,omitempty" yaml:"idleTimeout,omitempty"`
+	// PackageMirrors are the internal package registries builds and agents
+	// use instead of the public ones, e.g. in air-gapped clusters.
+	PackageMirrors PackageMirrors `json:"packageMirrors" yaml:"packageMirrors"`
+	// Dependencies are the packages apps may and may not depend on,
+	// checked at push_code and against the SBOM of each build.
+	Dependencies DependencyPolicy `json:"dependencies" yaml:"dependencies"`
+}
+
+// PackageMirrors are internal mirror URLs per package ecosystem. An empty
+// field means the public registry is used.
+type PackageMirrors struct {
+	NPM      string `json:"npm,omitempty"      yaml:"npm,omitempty"`
+	PyPI     string `json:"pypi,omitempty"     yaml:"pypi,omitempty"`
+	GoProxy  string `json:"goproxy,omitempty"  yaml:"goproxy,omitempty"`
+	Maven    string `json:"maven,omitempty"    yaml:"maven,omitempty"`
+	RubyGems string `json:"rubygems,omitempty" yaml:"rubygems,omitempty"`
+}
+
+// ForLanguage returns the mirror for a canonical language name (go, nodejs,
+// python, java, ruby), or "" when none is set.
+func (m PackageMirrors) ForLanguage(lang string) string {
+	switch lang {
+	case "go":
+		return m.GoProxy
+	case "nodejs":
+		return m.NPM
+	case "python":
+		return m.PyPI
+	case "java":
+		return m.Maven
+	case "ruby":
+		return m.RubyGems
+	}
+	return ""
+}
+
+// IdleTimeoutDuration returns IdleTimeout as a duration, or zero when unset.
+func (s *OrgStandards) IdleTimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(s.IdleTimeout)
+	return d
 }
 
 // platformDefaults returns the built-in standards used when no config file is set.
@@ -157,6 +208,16 @@ func platformDefaults() *OrgStandards {
 	}
 }
 
+// validMirrorURL reports whether s is an absolute http(s) URL that is safe
+// to quote in generated build files and shell commands.
+func validMirrorURL(s string) bool {
+	if strings.ContainsAny(s, " \t\r\n\"'`$\\") {
+		return false
+	}
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
 // validateVersionString returns true if s is a valid version string or empty.
 func validateVersionString(s string) bool {
 	if s == "" {
@@ -174,6 +235,7 @@ func sanitizePerLanguage(langs map[string]PerLanguageStandards, logger *slog.Log
 		std.ProhibitedFrameworks = filterFrameworks(std.ProhibitedFrameworks, lang, logger)
 		std.ApprovedLibraries = filterLibraries(std.ApprovedLibraries, lang, logger)
 		std.ProhibitedLibraries = filterLibraries(std.ProhibitedLibraries, lang, logger)
+		std.RuntimeVersions = filterRuntimeVersions(std.RuntimeVersions, lang, logger)
 		out[lang] = std
 	}
 	return out
@@ -192,6 +254,20 @@ func filterFrameworks(fs []FrameworkStandard, lang string, logger *slog.Logger)
 	return out
 }
 
+// filterRuntimeVersions drops runtime versions that are not plain dotted
+// versions, which are injected into build env as they are.
+func filterRuntimeVersions(vs []string, lang string, logger *slog.Logger) []string {
+	out := vs[:0:0]
+	for _, v := range vs {
+		if NormalizeRuntimeVersion(v) == "" {
+			logger.Warn("orgstandards: invalid runtime version — entry skipped", "lang", lang, "version", v)
+			continue
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
 func filterLibraries(ls []LibraryStandard, lang string, logger *slog.Logger) []LibraryStandard {
 	out := ls[:0:0]
 	for _, l := range ls {
@@ -207,10 +283,14 @@ func filterLibraries(ls []LibraryStandard, lang string, logger *slog.Logger) []L
 
 // Loader reads org standards from a file and provides a goroutine-safe Get().
 type Loader struct {
-	path    string
-	mu      sync.RWMutex
-	current *OrgStandards
-	logger  *slog.Logger
+	path   string
+	mu     sync.RWMutex
+	logger *slog.Logger
+	// base holds the file or platform defaults, and overrides the specs of
+	// the OrgStandards resources in merge order. current is their merge.
+	base      *OrgStandards
+	overrides []iafv1alpha1.OrgStandardsSpec
+	current   *OrgStandards
 }
 
 // New creates a Loader.  path may be empty, in which case platform defaults
@@ -220,7 +300,7 @@ func New(path string, logger *slog.Logger) *Loader {
 		logger = slog.Default()
 	}
 	l := &Loader{path: path, logger: logger}
-	l.current = l.load()
+	l.setBase(l.load())
 	return l
 }
 
@@ -264,10 +344,7 @@ func (l *Loader) Start(ctx context.Context) {
 				continue
 			}
 			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) {
-				updated := l.load()
-				l.mu.Lock()
-				l.current = updated
-				l.mu.Unlock()
+				l.setBase(l.load())
 				l.logger.Info("orgstandards: reloaded", "path", l.path)
 			}
 		case err, ok := <-watcher.Errors:
@@ -317,7 +394,7 @@ func (l *Loader) load() *OrgStandards {
 	}
 	if err != nil {
 		l.logger.Warn("orgstandards: parse error — retaining previous", "path", clean, "error", err)
-		return l.Get() // retain current value on parse failure
+		return l.previousBase() // retain current value on parse failure
 	}
 
 	// Ensure slices are non-nil for clean JSON output.
@@ -331,13 +408,58 @@ func (l *Loader) load() *OrgStandards {
 		standards.PerLanguage = map[string]PerLanguageStandards{}
 	}
 
-	// Validate and sanitize version strings to prevent injection.
-	standards.PerLanguage = sanitizePerLanguage(standards.PerLanguage, l.logger)
+	sanitize(&standards, l.logger)
 
 	l.logger.Info("orgstandards: loaded", "path", clean)
 	return &standards
 }
 
+// sanitize validates version strings to prevent injection, clears an
+// invalid idle timeout or package mirror and drops invalid dependency rules.
+func sanitize(s *OrgStandards, logger *slog.Logger) {
+	s.PerLanguage = sanitizePerLanguage(s.PerLanguage, logger)
+	s.Dependencies = sanitizeDependencies(s.Dependencies, logger)
+
+	for _, mirror := range []*string{&s.PackageMirrors.NPM, &s.PackageMirrors.PyPI, &s.PackageMirrors.GoProxy, &s.PackageMirrors.Maven, &s.PackageMirrors.RubyGems} {
+		if *mirror != "" && !validMirrorURL(*mirror) {
+			logger.Warn("orgstandards: invalid package mirror URL — dropped", "url", *mirror)
+			*mirror = ""
+		}
+	}
+
+	if d, err := time.ParseDuration(s.IdleTimeout); s.IdleTimeout != "" && (err != nil || d < 0) {
+		logger.Warn("orgstandards: invalid idleTimeout — idling disabled", "idleTimeout", s.IdleTimeout)
+		s.IdleTimeout = ""
+	}
+}
+
+// previousBase returns the last successfully loaded base standards, or the
+// platform defaults before the first load.
+func (l *Loader) previousBase() *OrgStandards {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.base == nil {
+		return platformDefaults()
+	}
+	return l.base
+}
+
+// setBase replaces the file or default standards and re-applies overrides.
+func (l *Loader) setBase(base *OrgStandards) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.base = base
+	l.current = merge(base, l.overrides, l.logger)
+}
+
+// setOverrides replaces the OrgStandards overrides, in merge order.
+func (l *Loader) setOverrides(overrides []iafv1alpha1.OrgStandardsSpec) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.overrides = overrides
+	l.current = merge(l.base, overrides, l.logger)
+}
+
 // validatePath returns a descriptive error if path contains traversal sequences.
 func validatePath(path string) error {
 	if strings.Contains(filepath.Clean(path), "..") {
@@ -121,6 +121,26 @@ perLanguage:
 	}
 }
 
+func TestLoader_IdleTimeout(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: `"2h"`, want: 2 * time.Hour},
+		{value: `"soon"`, want: 0},
+		{value: `"-1h"`, want: 0},
+	}
+	for _, tc := range tests {
+		path := filepath.Join(t.TempDir(), "standards.yaml")
+		if err := os.WriteFile(path, []byte("idleTimeout: "+tc.value+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if got := orgstandards.New(path, slog.Default()).Get().IdleTimeoutDuration(); got != tc.want {
+			t.Errorf("idleTimeout %s: got %v, want %v", tc.value, got, tc.want)
+		}
+	}
+}
+
 func TestLoader_DefaultsHaveFrameworks(t *testing.T) {
 	l := orgstandards.New("", slog.Default())
 	s := l.Get()
@@ -215,3 +235,27 @@ func TestLoader_InvalidYAML_RetainsDefaults(t *testing.T) {
 		t.Fatal("expected non-nil result even for invalid YAML")
 	}
 }
+
+func TestLoader_PackageMirrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "standards.yaml")
+	content := `
+packageMirrors:
+  npm: https://nexus.corp.example/repository/npm/
+  pypi: "https://pypi.corp.example/simple; curl evil"
+  goproxy: ftp://goproxy.corp.example
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := orgstandards.New(path, slog.Default()).Get().PackageMirrors
+	if m.NPM != "https://nexus.corp.example/repository/npm/" {
+		t.Errorf("npm mirror = %q", m.NPM)
+	}
+	if m.PyPI != "" || m.GoProxy != "" {
+		t.Errorf("expected unsafe mirrors to be dropped, got pypi=%q goproxy=%q", m.PyPI, m.GoProxy)
+	}
+	if got := m.ForLanguage("nodejs"); got != m.NPM {
+		t.Errorf("ForLanguage(nodejs) = %q", got)
+	}
+}
@@ -0,0 +1,104 @@
+package orgstandards
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+)
+
+// RuntimeEnvVars maps each canonical language to the buildpack build env
+// variable that selects its runtime version.
+var RuntimeEnvVars = map[string]string{
+	"go":     "BP_GO_VERSION",
+	"nodejs": "BP_NODE_VERSION",
+	"python": "BP_CPYTHON_VERSION",
+	"java":   "BP_JVM_VERSION",
+	"ruby":   "BP_MRI_VERSION",
+}
+
+// runtimeVersionPattern matches a plain dotted version such as "20" or "3.12.1".
+var runtimeVersionPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*$`)
+
+// NormalizeRuntimeVersion reduces a declared runtime version to the prefix
+// every version it admits starts with: "20.x" and "^20.1" give "20",
+// "~20.11" gives "20.11" and Ruby's "~> 3.2" gives "3". It returns "" for
+// anything else, such as ">=18" or "lts/*", which does not pin one line.
+func NormalizeRuntimeVersion(v string) string {
+	v = strings.TrimSpace(v)
+	keep := -1 // components kept after a range operator
+	switch {
+	case strings.HasPrefix(v, "~>"):
+		v = strings.TrimSpace(v[2:])
+		keep = strings.Count(v, ".")
+	case strings.HasPrefix(v, "^"):
+		v, keep = v[1:], 1
+	case strings.HasPrefix(v, "~"):
+		v, keep = v[1:], 2
+	}
+	v = strings.TrimPrefix(strings.TrimPrefix(v, "="), "v")
+	for _, wildcard := range []string{".x", ".X", ".*"} {
+		for strings.HasSuffix(v, wildcard) {
+			v = strings.TrimSuffix(v, wildcard)
+		}
+	}
+	if !runtimeVersionPattern.MatchString(v) {
+		return ""
+	}
+	if parts := strings.Split(v, "."); keep > 0 && len(parts) > keep {
+		v = strings.Join(parts[:keep], ".")
+	}
+	return v
+}
+
+// AllowsRuntime reports whether the runtime version declared for lang is
+// allowed. Every version is allowed when lang has no RuntimeVersions;
+// otherwise the version must lie within one of them, so "20" allows
+// "20.11.1" and "^20" but not ">=18".
+func (s *OrgStandards) AllowsRuntime(lang, version string) bool {
+	allowed := s.PerLanguage[lang].RuntimeVersions
+	if len(allowed) == 0 {
+		return true
+	}
+	v := NormalizeRuntimeVersion(version)
+	if v == "" {
+		return false
+	}
+	return slices.ContainsFunc(allowed, func(a string) bool {
+		a = NormalizeRuntimeVersion(a)
+		return v == a || strings.HasPrefix(v, a+".")
+	})
+}
+
+// RuntimeViolation describes a runtime version outside the allowed ones.
+func (s *OrgStandards) RuntimeViolation(lang, version, source string) string {
+	return fmt.Sprintf("%s declares %s runtime version %q, but the organisation allows only %s",
+		source, lang, version, strings.Join(s.PerLanguage[lang].RuntimeVersions, ", "))
+}
+
+// RuntimeBuildEnv returns the build env that pins the runtime of every
+// language with allowed versions to its first, default version. Languages
+// whose variable is set in own keep it, but it must name an allowed
+// version. Buildpacks ignore the variables of other languages.
+func (s *OrgStandards) RuntimeBuildEnv(own []iafv1alpha1.EnvVar) ([]iafv1alpha1.EnvVar, error) {
+	var env []iafv1alpha1.EnvVar
+	for _, lang := range slices.Sorted(maps.Keys(RuntimeEnvVars)) {
+		allowed := s.PerLanguage[lang].RuntimeVersions
+		if len(allowed) == 0 {
+			continue
+		}
+		name := RuntimeEnvVars[lang]
+		if i := slices.IndexFunc(own, func(e iafv1alpha1.EnvVar) bool { return e.Name == name }); i >= 0 {
+			if !s.AllowsRuntime(lang, own[i].Value) {
+				return nil, errors.New(s.RuntimeViolation(lang, own[i].Value, "build env "+name))
+			}
+			continue
+		}
+		env = append(env, iafv1alpha1.EnvVar{Name: name, Value: NormalizeRuntimeVersion(allowed[0])})
+	}
+	return env, nil
+}
@@ -0,0 +1,86 @@
+package orgstandards_test
+
+import (
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+)
+
+func TestNormalizeRuntimeVersion(t *testing.T) {
+	for in, want := range map[string]string{
+		"20":       "20",
+		"v20.11.1": "20.11.1",
+		"20.x":     "20",
+		"3.12.*":   "3.12",
+		"^20.1":    "20",
+		"~20.11.0": "20.11",
+		"~> 3.2":   "3",
+		"~> 3.2.1": "3.2",
+		"=1.22":    "1.22",
+		">=18":     "",
+		"18 || 20": "",
+		"lts/*":    "",
+		"":         "",
+	} {
+		if got := orgstandards.NormalizeRuntimeVersion(in); got != want {
+			t.Errorf("NormalizeRuntimeVersion(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAllowsRuntime(t *testing.T) {
+	s := &orgstandards.OrgStandards{PerLanguage: map[string]orgstandards.PerLanguageStandards{
+		"nodejs": {RuntimeVersions: []string{"20", "22.x"}},
+		"go":     {RuntimeVersions: []string{"1.23"}},
+	}}
+	for _, tt := range []struct {
+		lang, version string
+		want          bool
+	}{
+		{"nodejs", "20", true},
+		{"nodejs", "20.11.1", true},
+		{"nodejs", "^22.3", true},
+		{"nodejs", "18", false},
+		{"nodejs", "2", false},
+		{"nodejs", ">=20", false},
+		{"go", "1.23.4", true},
+		{"go", "1.22", false},
+		{"go", "1.2", false},
+		{"python", "2.7", true},
+	} {
+		if got := s.AllowsRuntime(tt.lang, tt.version); got != tt.want {
+			t.Errorf("AllowsRuntime(%s, %q) = %v, want %v", tt.lang, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestRuntimeBuildEnv(t *testing.T) {
+	s := &orgstandards.OrgStandards{PerLanguage: map[string]orgstandards.PerLanguageStandards{
+		"nodejs": {RuntimeVersions: []string{"20.x", "22"}},
+		"python": {RuntimeVersions: []string{"3.12"}},
+		"go":     {},
+	}}
+
+	env, err := s.RuntimeBuildEnv(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []iafv1alpha1.EnvVar{{Name: "BP_NODE_VERSION", Value: "20"}, {Name: "BP_CPYTHON_VERSION", Value: "3.12"}}
+	if len(env) != len(want) || env[0] != want[0] || env[1] != want[1] {
+		t.Errorf("expected the default of each pinned language, got %v", env)
+	}
+
+	// An allowed version the app selects is kept.
+	env, err = s.RuntimeBuildEnv([]iafv1alpha1.EnvVar{{Name: "BP_NODE_VERSION", Value: "22.1.0"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(env) != 1 || env[0].Name != "BP_CPYTHON_VERSION" {
+		t.Errorf("expected only python to be pinned, got %v", env)
+	}
+
+	if _, err := s.RuntimeBuildEnv([]iafv1alpha1.EnvVar{{Name: "BP_NODE_VERSION", Value: "18"}}); err == nil {
+		t.Error("expected a disallowed version to be an error")
+	}
+}
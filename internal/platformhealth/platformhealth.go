@@ -0,0 +1,313 @@
+// Package platformhealth checks the systems the platform itself depends on
+// — the controller, kpack, cert-manager, the image registry, the source
+// store and the observability stack — so operators can verify an
+// installation with one call.
+package platformhealth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ControllerLeaseName is the leader election Lease of the controller
+// manager.
+const ControllerLeaseName = "iaf-controller.iaf.io"
+
+// ShardLeaseName is the leader election Lease of the controller deployment
+// reconciling the named shard; the unnamed one uses ControllerLeaseName.
+// The health check reads only ControllerLeaseName.
+func ShardLeaseName(shard string) string {
+	if shard == "" {
+		return ControllerLeaseName
+	}
+	return "iaf-controller-" + shard + ".iaf.io"
+}
+
+// checkTimeout bounds each component check.
+const checkTimeout = 5 * time.Second
+
+// cacheTTL is how long a report is reused, so frequent reads of the
+// iaf://platform resource do not probe every system each time.
+const cacheTTL = 15 * time.Second
+
+// Status is the health of a component or of the platform.
+type Status string
+
+const (
+	// StatusOK: the component works.
+	StatusOK Status = "ok"
+	// StatusNotConfigured: the optional component is not set up, so the
+	// features using it are off.
+	StatusNotConfigured Status = "not_configured"
+	// StatusFailing: the component is set up but does not work.
+	StatusFailing Status = "failing"
+	// StatusDegraded is only reported for the platform: an optional
+	// component is failing.
+	StatusDegraded Status = "degraded"
+)
+
+// Component is the result of checking one system.
+type Component struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	// Required components are needed to deploy apps at all; the others
+	// back optional features.
+	Required bool   `json:"required"`
+	Message  string `json:"message,omitempty"`
+}
+
+// Report is the health of the platform.
+type Report struct {
+	// Status is failing when a required component fails, degraded when an
+	// optional one does, and ok otherwise.
+	Status     Status      `json:"status"`
+	CheckedAt  time.Time   `json:"checkedAt"`
+	Components []Component `json:"components"`
+}
+
+// Pinger checks that an image registry is reachable.
+type Pinger interface {
+	Ping(ctx context.Context, host string) error
+}
+
+// Checker checks the platform's components. Fields left empty turn the
+// matching optional check into not_configured.
+type Checker struct {
+	Client client.Client
+	// Store checks that source uploads can be stored.
+	Store interface{ CheckWritable() error }
+	// Registry reaches the registry of RegistryPrefix.
+	Registry Pinger
+	// HTTP probes the observability endpoints; nil uses
+	// http.DefaultClient, bounded by the check timeout.
+	HTTP *http.Client
+
+	// ControllerNamespace holds the controller's leader election Lease.
+	ControllerNamespace string
+	// ClusterBuilder is the default kpack ClusterBuilder.
+	ClusterBuilder string
+	// RegistryPrefix is where built images are pushed, e.g.
+	// "registry.example.com/iaf".
+	RegistryPrefix string
+	// TLSIssuer is the ClusterIssuer app certificates come from; cert-manager
+	// is required when it is set.
+	TLSIssuer string
+	// PrometheusURL, TempoQueryURL and GrafanaURL are probed when set.
+	// Loki is only reached through Grafana, as the LokiUID datasource.
+	PrometheusURL string
+	TempoQueryURL string
+	GrafanaURL    string
+	LokiUID       string
+
+	mu     sync.Mutex
+	cached *Report
+}
+
+// Check returns the health of every component. Results are cached for a
+// few seconds.
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cached != nil && time.Since(c.cached.CheckedAt) < cacheTTL {
+		return *c.cached
+	}
+
+	checks := []func(context.Context) Component{
+		c.checkController,
+		c.checkKpack,
+		c.checkCertManager,
+		c.checkRegistry,
+		c.checkSourceStore,
+		c.checkPrometheus,
+		c.checkLoki,
+		c.checkTempo,
+	}
+	components := make([]Component, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Go(func() {
+			ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+			components[i] = check(ctx)
+		})
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, CheckedAt: time.Now().UTC(), Components: components}
+	for _, comp := range components {
+		if comp.Status != StatusFailing {
+			continue
+		}
+		if comp.Required {
+			report.Status = StatusFailing
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	c.cached = &report
+	return report
+}
+
+func result(name string, required bool, err error, okMessage string) Component {
+	if err != nil {
+		return Component{Name: name, Status: StatusFailing, Required: required, Message: err.Error()}
+	}
+	return Component{Name: name, Status: StatusOK, Required: required, Message: okMessage}
+}
+
+// checkController checks that a controller replica holds a current leader
+// election lease.
+func (c *Checker) checkController(ctx context.Context) Component {
+	const name = "controller"
+	var lease coordinationv1.Lease
+	err := c.Client.Get(ctx, client.ObjectKey{Namespace: c.ControllerNamespace, Name: ControllerLeaseName}, &lease)
+	if apierrors.IsNotFound(err) {
+		return result(name, true, fmt.Errorf("no leader election lease in %s; the controller is not running, or runs without IAF_LEADER_ELECT", c.ControllerNamespace), "")
+	}
+	if err != nil {
+		return result(name, true, fmt.Errorf("reading leader lease: %w", err), "")
+	}
+	holder := ""
+	if lease.Spec.HolderIdentity != nil {
+		holder = *lease.Spec.HolderIdentity
+	}
+	duration := 15 * time.Second
+	if lease.Spec.LeaseDurationSeconds != nil {
+		duration = time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	}
+	if holder == "" || lease.Spec.RenewTime == nil || time.Since(lease.Spec.RenewTime.Time) > 2*duration {
+		return result(name, true, errors.New("no controller replica holds the leader lease; reconciliation has stopped"), "")
+	}
+	return result(name, true, nil, "leader "+holder)
+}
+
+// checkKpack checks that the default ClusterBuilder exists and is ready.
+func (c *Checker) checkKpack(ctx context.Context) Component {
+	const name = "kpack"
+	builder := &unstructured.Unstructured{}
+	builder.SetGroupVersionKind(iafk8s.KpackClusterBuilderGVK)
+	if err := c.Client.Get(ctx, client.ObjectKey{Name: c.ClusterBuilder}, builder); err != nil {
+		return result(name, true, fmt.Errorf("getting ClusterBuilder %q: %w", c.ClusterBuilder, err), "")
+	}
+	if err := readyCondition(builder); err != nil {
+		return result(name, true, fmt.Errorf("ClusterBuilder %q: %w", c.ClusterBuilder, err), "")
+	}
+	return result(name, true, nil, "ClusterBuilder "+c.ClusterBuilder+" is ready")
+}
+
+// checkCertManager checks that the cert-manager API is installed and, when
+// TLS is enabled, that its ClusterIssuer is ready.
+func (c *Checker) checkCertManager(ctx context.Context) Component {
+	const name = "cert-manager"
+	required := c.TLSIssuer != ""
+	if _, err := c.Client.RESTMapper().RESTMapping(iafk8s.CertificateGVK.GroupKind(), iafk8s.CertificateGVK.Version); err != nil {
+		if !required {
+			return Component{Name: name, Status: StatusNotConfigured, Message: "cert-manager is not installed; apps are served without TLS certificates"}
+		}
+		return result(name, true, errors.New("cert-manager is not installed, but IAF_TLS_ISSUER is set"), "")
+	}
+	if !required {
+		return result(name, false, nil, "installed; no TLS issuer configured")
+	}
+	issuer := &unstructured.Unstructured{}
+	issuer.SetGroupVersionKind(schema.GroupVersionKind{Group: iafk8s.CertificateGVK.Group, Version: iafk8s.CertificateGVK.Version, Kind: "ClusterIssuer"})
+	if err := c.Client.Get(ctx, client.ObjectKey{Name: c.TLSIssuer}, issuer); err != nil {
+		return result(name, true, fmt.Errorf("getting ClusterIssuer %q: %w", c.TLSIssuer, err), "")
+	}
+	if err := readyCondition(issuer); err != nil {
+		return result(name, true, fmt.Errorf("ClusterIssuer %q: %w", c.TLSIssuer, err), "")
+	}
+	return result(name, true, nil, "ClusterIssuer "+c.TLSIssuer+" is ready")
+}
+
+// checkRegistry checks that the registry built images are pushed to
+// answers.
+func (c *Checker) checkRegistry(ctx context.Context) Component {
+	const name = "registry"
+	host, _, _ := strings.Cut(c.RegistryPrefix, "/")
+	if err := c.Registry.Ping(ctx, host); err != nil {
+		return result(name, true, err, "")
+	}
+	return result(name, true, nil, host+" is reachable")
+}
+
+func (c *Checker) checkSourceStore(context.Context) Component {
+	return result("sourcestore", true, c.Store.CheckWritable(), "writable")
+}
+
+func (c *Checker) checkPrometheus(ctx context.Context) Component {
+	if c.PrometheusURL == "" {
+		return Component{Name: "prometheus", Status: StatusNotConfigured, Message: "IAF_PROMETHEUS_URL is not set; idling, uptime and cost reports are off"}
+	}
+	return result("prometheus", false, c.probe(ctx, c.PrometheusURL, "/-/ready"), "ready")
+}
+
+// checkLoki checks Loki through Grafana, the only way the platform reaches
+// it.
+func (c *Checker) checkLoki(ctx context.Context) Component {
+	if c.GrafanaURL == "" || c.LokiUID == "" {
+		return Component{Name: "loki", Status: StatusNotConfigured, Message: "IAF_GRAFANA_URL is not set; apps get no log links"}
+	}
+	if err := c.probe(ctx, c.GrafanaURL, "/api/health"); err != nil {
+		return result("loki", false, fmt.Errorf("grafana: %w", err), "")
+	}
+	return result("loki", false, nil, "linked through Grafana datasource "+c.LokiUID)
+}
+
+func (c *Checker) checkTempo(ctx context.Context) Component {
+	if c.TempoQueryURL == "" {
+		return Component{Name: "tempo", Status: StatusNotConfigured, Message: "IAF_TEMPO_QUERY_URL is not set; conformance checks skip traces"}
+	}
+	return result("tempo", false, c.probe(ctx, c.TempoQueryURL, "/ready"), "ready")
+}
+
+// probe expects a 200 from path under baseURL.
+func (c *Checker) probe(ctx context.Context, baseURL, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	hc := c.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", path, resp.Status)
+	}
+	return nil
+}
+
+// readyCondition returns an error unless obj has a Ready condition that is
+// True.
+func readyCondition(obj *unstructured.Unstructured) error {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, _ := c.(map[string]any)
+		if cond["type"] != "Ready" {
+			continue
+		}
+		if cond["status"] == "True" {
+			return nil
+		}
+		msg, _ := cond["message"].(string)
+		return fmt.Errorf("not ready: %s", msg)
+	}
+	return errors.New("not ready yet")
+}
@@ -0,0 +1,115 @@
+package platformhealth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/registry"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type writableStore struct{ err error }
+
+func (s writableStore) CheckWritable() error { return s.err }
+
+func TestChecker(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/-/ready":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer backend.Close()
+	host := strings.TrimPrefix(backend.URL, "http://")
+
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: ControllerLeaseName, Namespace: "iaf-system"},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       ptr.To("iaf-controller-abc"),
+			LeaseDurationSeconds: ptr.To[int32](15),
+			RenewTime:            &metav1.MicroTime{Time: time.Now()},
+		},
+	}
+	builder := &unstructured.Unstructured{}
+	builder.SetGroupVersionKind(iafk8s.KpackClusterBuilderGVK)
+	builder.SetName("iaf-cluster-builder")
+	_ = unstructured.SetNestedSlice(builder.Object, []any{map[string]any{"type": "Ready", "status": "True"}}, "status", "conditions")
+
+	newChecker := func(objs ...client.Object) *Checker {
+		return &Checker{
+			Client:              fake.NewClientBuilder().WithObjects(objs...).Build(),
+			Store:               writableStore{},
+			Registry:            registry.NewClient([]string{host}),
+			ControllerNamespace: "iaf-system",
+			ClusterBuilder:      "iaf-cluster-builder",
+			RegistryPrefix:      host + "/iaf",
+			PrometheusURL:       backend.URL,
+			TempoQueryURL:       backend.URL,
+		}
+	}
+	status := func(r Report, name string) Component {
+		t.Helper()
+		for _, c := range r.Components {
+			if c.Name == name {
+				return c
+			}
+		}
+		t.Fatalf("no %s component in %+v", name, r.Components)
+		return Component{}
+	}
+
+	report := newChecker(lease, builder).Check(context.Background())
+	for name, want := range map[string]Status{
+		"controller":   StatusOK,
+		"kpack":        StatusOK,
+		"cert-manager": StatusNotConfigured,
+		"registry":     StatusOK,
+		"sourcestore":  StatusOK,
+		"prometheus":   StatusOK,
+		"loki":         StatusNotConfigured,
+		"tempo":        StatusFailing,
+	} {
+		if got := status(report, name); got.Status != want {
+			t.Errorf("%s: status %s (%s), want %s", name, got.Status, got.Message, want)
+		}
+	}
+	if report.Status != StatusDegraded {
+		t.Errorf("expected an optional failure to degrade the platform, got %s", report.Status)
+	}
+
+	// A stale lease and an unwritable store fail the platform.
+	stale := lease.DeepCopy()
+	stale.Spec.RenewTime = &metav1.MicroTime{Time: time.Now().Add(-time.Hour)}
+	checker := newChecker(stale)
+	checker.Store = writableStore{err: errors.New("read-only file system")}
+	report = checker.Check(context.Background())
+	if report.Status != StatusFailing {
+		t.Errorf("expected failing, got %s", report.Status)
+	}
+	for _, name := range []string{"controller", "kpack", "sourcestore"} {
+		if got := status(report, name); got.Status != StatusFailing || !got.Required {
+			t.Errorf("%s: got %+v, want a failing required component", name, got)
+		}
+	}
+
+	// Reports are cached.
+	checker.Store = writableStore{}
+	if again := checker.Check(context.Background()); !again.CheckedAt.Equal(report.CheckedAt) {
+		t.Error("expected the cached report")
+	}
+}
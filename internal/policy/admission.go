@@ -0,0 +1,55 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// AdmissionPath is where the controller serves Admission.
+const AdmissionPath = "/validate-platform-policy"
+
+// Admission is a validating admission handler that checks every
+// Application created or changed, by any client, against the deploy rules.
+// The API server and MCP tools check the same rules first, so agents get a
+// friendly error before the write; this keeps writes that bypass them,
+// such as kubectl, to the same rules.
+type Admission struct {
+	Engine  *Engine
+	Decoder admission.Decoder
+}
+
+// Handle implements admission.Handler.
+func (a *Admission) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var app iafv1alpha1.Application
+	if err := a.Decoder.Decode(req, &app); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if req.Operation == admissionv1.Update {
+		var old iafv1alpha1.Application
+		if err := a.Decoder.DecodeRaw(req.OldObject, &old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		// Writes that leave the spec alone, such as the controller's
+		// finalizers and annotations, do not change what is deployed, so an
+		// app created before a policy can still be cleaned up.
+		if equality.Semantic.DeepEqual(old.Spec, app.Spec) {
+			return admission.Allowed("")
+		}
+	}
+
+	err := a.Engine.Check(ctx, Input{Operation: iafv1alpha1.PolicyOperationDeploy, Namespace: req.Namespace, App: &app})
+	var violation *ViolationError
+	switch {
+	case errors.As(err, &violation):
+		return admission.Denied(violation.Error())
+	case err != nil:
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.Allowed("")
+}
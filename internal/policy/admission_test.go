@@ -0,0 +1,90 @@
+package policy_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/policy"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func admissionRequest(t *testing.T, op admissionv1.Operation, app, old *iafv1alpha1.Application) admission.Request {
+	t.Helper()
+	raw := func(app *iafv1alpha1.Application) runtime.RawExtension {
+		if app == nil {
+			return runtime.RawExtension{}
+		}
+		app = app.DeepCopy()
+		app.APIVersion = iafv1alpha1.GroupVersion.String()
+		app.Kind = "Application"
+		data, err := json.Marshal(app)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return runtime.RawExtension{Raw: data}
+	}
+	return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Operation: op,
+		Namespace: app.Namespace,
+		Name:      app.Name,
+		Object:    raw(app),
+		OldObject: raw(old),
+	}}
+}
+
+// TestAdmission verifies Applications written past the API server, such
+// as with kubectl, are held to the deploy rules, while writes that leave
+// the spec alone still pass.
+func TestAdmission(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := iafv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newPolicy("images", []iafv1alpha1.PolicyOperation{iafv1alpha1.PolicyOperationDeploy},
+			iafv1alpha1.PolicyRule{
+				Name:       "approved-registry",
+				Expression: `!has(app.spec.image) || app.spec.image.startsWith("registry.corp/")`,
+				Message:    "images must come from registry.corp",
+			},
+		),
+		newPolicy("secrets", []iafv1alpha1.PolicyOperation{iafv1alpha1.PolicyOperationPushCode},
+			iafv1alpha1.PolicyRule{Name: "never", Expression: `false`, Message: "push_code only"},
+		),
+	).Build()
+	h := &policy.Admission{Engine: policy.New(c), Decoder: admission.NewDecoder(scheme)}
+	ctx := context.Background()
+
+	compliant := newApp("registry.corp/web:1")
+	violating := newApp("docker.io/web:1")
+
+	if resp := h.Handle(ctx, admissionRequest(t, admissionv1.Create, compliant, nil)); !resp.Allowed {
+		t.Errorf("expected a compliant app to be admitted, got %+v", resp.Result)
+	}
+
+	resp := h.Handle(ctx, admissionRequest(t, admissionv1.Create, violating, nil))
+	if resp.Allowed {
+		t.Fatal("expected an app breaking a deploy rule to be denied")
+	}
+	if msg := resp.Result.Message; !strings.Contains(msg, "images/approved-registry") || strings.Contains(msg, "push_code only") {
+		t.Errorf("expected only the deploy rule in the denial, got %q", msg)
+	}
+
+	if resp := h.Handle(ctx, admissionRequest(t, admissionv1.Update, violating, compliant)); resp.Allowed {
+		t.Error("expected changing an app to break a deploy rule to be denied")
+	}
+
+	// An app created before the policy keeps getting finalizers and
+	// annotations.
+	annotated := violating.DeepCopy()
+	annotated.Finalizers = []string{"iaf.io/cleanup"}
+	if resp := h.Handle(ctx, admissionRequest(t, admissionv1.Update, annotated, violating)); !resp.Allowed {
+		t.Errorf("expected a write leaving the spec alone to be admitted, got %+v", resp.Result)
+	}
+}
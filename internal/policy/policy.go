@@ -0,0 +1,199 @@
+// Package policy evaluates operator-defined PlatformPolicy rules, written in
+// CEL, against agent operations before they reach the cluster.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/google/cel-go/cel"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// costLimit bounds the work a single rule may do, so a careless expression
+// cannot stall deploys.
+const costLimit = 1_000_000
+
+// Input describes the operation being checked.
+type Input struct {
+	Operation iafv1alpha1.PolicyOperation
+	Namespace string
+	// App is the application as it would be stored. Nil for create_repo.
+	App *iafv1alpha1.Application
+	// Files lists uploaded file paths for push_code.
+	Files []string
+	// RepoName and RepoVisibility describe the repository for create_repo.
+	RepoName       string
+	RepoVisibility string
+}
+
+// Violation is one rule an operation does not satisfy.
+type Violation struct {
+	Policy  string `json:"policy"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ViolationError is returned by Check when an operation breaks one or more
+// rules.
+type ViolationError struct {
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, fmt.Sprintf("%s/%s: %s", v.Policy, v.Rule, v.Message))
+	}
+	return "blocked by platform policy: " + strings.Join(msgs, "; ")
+}
+
+// Engine checks operations against the PlatformPolicy resources in the
+// cluster. Compiled rules are cached per policy generation.
+type Engine struct {
+	client client.Reader
+	env    *cel.Env
+
+	mu       sync.Mutex
+	compiled map[string]compiledPolicy
+}
+
+type compiledPolicy struct {
+	uid        types.UID
+	generation int64
+	programs   []cel.Program
+	// errs holds the compile error of each rule, if any.
+	errs []error
+}
+
+// New creates an Engine that reads policies with c.
+func New(c client.Reader) *Engine {
+	env, err := cel.NewEnv(
+		cel.Variable("operation", cel.StringType),
+		// "namespace" is reserved in CEL.
+		cel.Variable("sessionNamespace", cel.StringType),
+		cel.Variable("app", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("files", cel.ListType(cel.StringType)),
+		cel.Variable("repo", cel.MapType(cel.StringType, cel.StringType)),
+	)
+	if err != nil {
+		// The declarations are static, so this only fails on a programming error.
+		panic(fmt.Sprintf("policy: creating CEL environment: %v", err))
+	}
+	return &Engine{client: c, env: env, compiled: map[string]compiledPolicy{}}
+}
+
+// Check evaluates every policy that applies to in.Operation. It returns a
+// *ViolationError listing each unsatisfied rule. Rules that fail to compile
+// or evaluate count as violated, so a broken policy blocks rather than
+// silently allows.
+func (e *Engine) Check(ctx context.Context, in Input) error {
+	var list iafv1alpha1.PlatformPolicyList
+	if err := e.client.List(ctx, &list); err != nil {
+		return fmt.Errorf("listing platform policies: %w", err)
+	}
+	if len(list.Items) == 0 {
+		return nil
+	}
+	slices.SortFunc(list.Items, func(a, b iafv1alpha1.PlatformPolicy) int { return strings.Compare(a.Name, b.Name) })
+
+	vars, err := activation(in)
+	if err != nil {
+		return err
+	}
+	var violations []Violation
+	for i := range list.Items {
+		p := &list.Items[i]
+		if len(p.Spec.Operations) > 0 && !slices.Contains(p.Spec.Operations, in.Operation) {
+			continue
+		}
+		compiled := e.compile(p)
+		for j, rule := range p.Spec.Rules {
+			if err := compiled.errs[j]; err != nil {
+				violations = append(violations, Violation{Policy: p.Name, Rule: rule.Name,
+					Message: fmt.Sprintf("rule is invalid (%v); contact your platform operator", err)})
+				continue
+			}
+			out, _, err := compiled.programs[j].ContextEval(ctx, vars)
+			if err != nil {
+				violations = append(violations, Violation{Policy: p.Name, Rule: rule.Name,
+					Message: fmt.Sprintf("%s (rule could not be evaluated: %v)", rule.Message, err)})
+				continue
+			}
+			if allowed, ok := out.Value().(bool); !ok || !allowed {
+				violations = append(violations, Violation{Policy: p.Name, Rule: rule.Name, Message: rule.Message})
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return &ViolationError{Violations: violations}
+	}
+	return nil
+}
+
+// compile returns the programs for p, compiling them on first use and
+// whenever its spec changes.
+func (e *Engine) compile(p *iafv1alpha1.PlatformPolicy) compiledPolicy {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if c, ok := e.compiled[p.Name]; ok && c.uid == p.UID && c.generation == p.Generation && len(c.programs) == len(p.Spec.Rules) {
+		return c
+	}
+	c := compiledPolicy{
+		uid:        p.UID,
+		generation: p.Generation,
+		programs:   make([]cel.Program, len(p.Spec.Rules)),
+		errs:       make([]error, len(p.Spec.Rules)),
+	}
+	for i, rule := range p.Spec.Rules {
+		c.programs[i], c.errs[i] = e.program(rule.Expression)
+	}
+	e.compiled[p.Name] = c
+	return c
+}
+
+func (e *Engine) program(expression string) (cel.Program, error) {
+	ast, issues := e.env.Compile(expression)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, fmt.Errorf("expression must evaluate to a bool, not %s", ast.OutputType())
+	}
+	return e.env.Program(ast, cel.CostLimit(costLimit), cel.InterruptCheckFrequency(100))
+}
+
+// activation builds the CEL variables for in.
+func activation(in Input) (map[string]any, error) {
+	app := map[string]any{}
+	if in.App != nil {
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(in.App)
+		if err != nil {
+			return nil, fmt.Errorf("converting application for policy checks: %w", err)
+		}
+		delete(obj, "status")
+		app = obj
+	}
+	repo := map[string]string{}
+	if in.Operation == iafv1alpha1.PolicyOperationCreateRepo {
+		repo["name"] = in.RepoName
+		repo["visibility"] = in.RepoVisibility
+	}
+	files := in.Files
+	if files == nil {
+		files = []string{}
+	}
+	return map[string]any{
+		"operation":        string(in.Operation),
+		"sessionNamespace": in.Namespace,
+		"app":              app,
+		"files":            files,
+		"repo":             repo,
+	}, nil
+}
@@ -0,0 +1,205 @@
+package policy_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/policy"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newPolicy(name string, ops []iafv1alpha1.PolicyOperation, rules ...iafv1alpha1.PolicyRule) *iafv1alpha1.PlatformPolicy {
+	return &iafv1alpha1.PlatformPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       iafv1alpha1.PlatformPolicySpec{Operations: ops, Rules: rules},
+	}
+}
+
+func newApp(image string) *iafv1alpha1.Application {
+	return &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "iaf-abc"},
+		Spec:       iafv1alpha1.ApplicationSpec{Image: image, Port: 8080, Replicas: 1},
+	}
+}
+
+func TestEngine_Check(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := iafv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newPolicy("images", []iafv1alpha1.PolicyOperation{iafv1alpha1.PolicyOperationDeploy},
+			iafv1alpha1.PolicyRule{
+				Name:       "approved-registry",
+				Expression: `!has(app.spec.image) || app.spec.image.startsWith("registry.corp/")`,
+				Message:    "images must come from registry.corp",
+			},
+			iafv1alpha1.PolicyRule{
+				Name:       "replicas",
+				Expression: `app.spec.replicas <= 3`,
+				Message:    "at most 3 replicas",
+			},
+		),
+		newPolicy("secrets", []iafv1alpha1.PolicyOperation{iafv1alpha1.PolicyOperationPushCode},
+			iafv1alpha1.PolicyRule{
+				Name:       "no-env-files",
+				Expression: `!files.exists(f, f.endsWith(".env"))`,
+				Message:    "do not upload .env files",
+			},
+		),
+		newPolicy("repos", nil,
+			iafv1alpha1.PolicyRule{
+				Name:       "private",
+				Expression: `operation != "create_repo" || repo.visibility == "private"`,
+				Message:    "repositories must be private",
+			},
+		),
+	).Build()
+	engine := policy.New(c)
+
+	tests := []struct {
+		name  string
+		in    policy.Input
+		rules []string
+	}{
+		{
+			name: "approved image",
+			in:   policy.Input{Operation: iafv1alpha1.PolicyOperationDeploy, Namespace: "iaf-abc", App: newApp("registry.corp/web:1")},
+		},
+		{
+			name:  "unapproved image",
+			in:    policy.Input{Operation: iafv1alpha1.PolicyOperationDeploy, Namespace: "iaf-abc", App: newApp("nginx:latest")},
+			rules: []string{"approved-registry"},
+		},
+		{
+			name: "policy scoped to other operations is skipped",
+			in:   policy.Input{Operation: iafv1alpha1.PolicyOperationPushCode, Namespace: "iaf-abc", App: newApp(""), Files: []string{"main.go"}},
+		},
+		{
+			name:  "file rule",
+			in:    policy.Input{Operation: iafv1alpha1.PolicyOperationPushCode, Namespace: "iaf-abc", App: newApp(""), Files: []string{"main.go", "prod.env"}},
+			rules: []string{"no-env-files"},
+		},
+		{
+			name:  "public repo",
+			in:    policy.Input{Operation: iafv1alpha1.PolicyOperationCreateRepo, Namespace: "iaf-abc", RepoName: "web", RepoVisibility: "public"},
+			rules: []string{"private"},
+		},
+		{
+			name: "private repo",
+			in:   policy.Input{Operation: iafv1alpha1.PolicyOperationCreateRepo, Namespace: "iaf-abc", RepoName: "web", RepoVisibility: "private"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := engine.Check(context.Background(), tt.in)
+			if len(tt.rules) == 0 {
+				if err != nil {
+					t.Fatalf("expected no violations, got %v", err)
+				}
+				return
+			}
+			var violation *policy.ViolationError
+			if !errors.As(err, &violation) {
+				t.Fatalf("expected a ViolationError, got %v", err)
+			}
+			var got []string
+			for _, v := range violation.Violations {
+				got = append(got, v.Rule)
+			}
+			if len(got) != len(tt.rules) || got[0] != tt.rules[0] {
+				t.Errorf("expected violated rules %v, got %v", tt.rules, got)
+			}
+		})
+	}
+}
+
+func TestEngine_Check_InvalidRuleBlocks(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := iafv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newPolicy("broken", nil,
+			iafv1alpha1.PolicyRule{Name: "syntax", Expression: `app.spec.image.startsWith(`, Message: "broken"},
+			iafv1alpha1.PolicyRule{Name: "not-bool", Expression: `sessionNamespace`, Message: "not a bool"},
+			iafv1alpha1.PolicyRule{Name: "missing-field", Expression: `app.spec.git.url != ""`, Message: "needs git"},
+		),
+	).Build()
+	engine := policy.New(c)
+
+	err := engine.Check(context.Background(), policy.Input{Operation: iafv1alpha1.PolicyOperationDeploy, Namespace: "iaf-abc", App: newApp("nginx")})
+	var violation *policy.ViolationError
+	if !errors.As(err, &violation) {
+		t.Fatalf("expected a ViolationError, got %v", err)
+	}
+	if len(violation.Violations) != 3 {
+		t.Fatalf("expected every broken rule to be reported, got %+v", violation.Violations)
+	}
+}
+
+func TestEngine_Check_RecompilesOnChange(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := iafv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	p := newPolicy("ns", nil, iafv1alpha1.PolicyRule{Name: "ns", Expression: `sessionNamespace == "iaf-abc"`, Message: "wrong namespace"})
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(p).Build()
+	engine := policy.New(c)
+	ctx := context.Background()
+	in := policy.Input{Operation: iafv1alpha1.PolicyOperationDeploy, Namespace: "iaf-abc", App: newApp("nginx")}
+
+	if err := engine.Check(ctx, in); err != nil {
+		t.Fatalf("expected no violations, got %v", err)
+	}
+
+	var stored iafv1alpha1.PlatformPolicy
+	if err := c.Get(ctx, client.ObjectKeyFromObject(p), &stored); err != nil {
+		t.Fatal(err)
+	}
+	stored.Spec.Rules[0].Expression = `sessionNamespace == "iaf-other"`
+	stored.Generation++
+	if err := c.Update(ctx, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Check(ctx, in); err == nil {
+		t.Fatal("expected the updated rule to be enforced")
+	}
+}
+
+// TestEngine_Check_NoLatestTag checks the rule the operator guide suggests
+// for rejecting images deployed by the latest tag.
+func TestEngine_Check_NoLatestTag(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := iafv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newPolicy("tags", nil, iafv1alpha1.PolicyRule{
+			Name:       "no-latest-tag",
+			Expression: `!has(app.spec.image) || (app.spec.image.matches(":[^/]+$") && !app.spec.image.endsWith(":latest"))`,
+			Message:    "Deploy a version tag or digest, not latest",
+		}),
+	).Build()
+	engine := policy.New(c)
+
+	for image, allowed := range map[string]bool{
+		"nginx":                             false,
+		"nginx:latest":                      false,
+		"registry.local:5000/web":           false,
+		"nginx:1.27":                        true,
+		"registry.local:5000/web:v2":        true,
+		"nginx:latest@sha256:0123456789abc": true,
+		"":                                  true,
+	} {
+		err := engine.Check(context.Background(), policy.Input{Operation: iafv1alpha1.PolicyOperationDeploy, Namespace: "iaf-abc", App: newApp(image)})
+		if (err == nil) != allowed {
+			t.Errorf("%q: error = %v, want allowed %v", image, err, allowed)
+		}
+	}
+}
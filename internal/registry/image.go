@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+)
+
+// maxManifestSize bounds the manifests and image configs read.
+const maxManifestSize = 4 << 20
+
+// manifest is the part of an image manifest or index the client reads.
+type manifest struct {
+	Config struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Manifests []descriptor `json:"manifests"`
+}
+
+// descriptor is an entry of an index.
+type descriptor struct {
+	Digest   string `json:"digest"`
+	Platform struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+	} `json:"platform"`
+}
+
+// Labels returns the labels of image's config. For a multi-architecture
+// image it reads the linux/amd64 image, or the first one.
+func (c *Client) Labels(ctx context.Context, image string, auths map[string]Auth) (map[string]string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := c.getJSON(ctx, ref, "/manifests/"+ref.manifestRef(), manifestTypes, auths, &m); err != nil {
+		return nil, fmt.Errorf("reading manifest of %s: %w", image, err)
+	}
+	if len(m.Manifests) > 0 {
+		i := slices.IndexFunc(m.Manifests, func(d descriptor) bool {
+			return d.Platform.OS == "linux" && d.Platform.Architecture == "amd64"
+		})
+		digest := m.Manifests[max(i, 0)].Digest
+		m = manifest{}
+		if err := c.getJSON(ctx, ref, "/manifests/"+digest, manifestTypes, auths, &m); err != nil {
+			return nil, fmt.Errorf("reading manifest of %s: %w", image, err)
+		}
+	}
+	if m.Config.Digest == "" {
+		return nil, fmt.Errorf("manifest of %s has no config", image)
+	}
+	var config struct {
+		Config struct {
+			Labels map[string]string `json:"Labels"`
+		} `json:"config"`
+	}
+	if err := c.getJSON(ctx, ref, "/blobs/"+m.Config.Digest, nil, auths, &config); err != nil {
+		return nil, fmt.Errorf("reading config of %s: %w", image, err)
+	}
+	return config.Config.Labels, nil
+}
+
+// Blob returns the content of the blob with digest in image's repository.
+// The caller closes it.
+func (c *Client) Blob(ctx context.Context, image, digest string, auths map[string]Auth) (io.ReadCloser, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.get(ctx, ref, "/blobs/"+digest, nil, auths)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("reading blob %s of %s: registry returned %s", digest, image, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// getJSON decodes the JSON document at path into v.
+func (c *Client) getJSON(ctx context.Context, ref Reference, path string, accept []string, auths map[string]Auth, v any) error {
+	resp, err := c.get(ctx, ref, path, accept, auths)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("registry returned %s", resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(v)
+}
@@ -0,0 +1,394 @@
+// Package registry resolves container image tags to the digests they point
+// at, so applications deploy an immutable image.
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultRegistry is the registry of image references without a host.
+const DefaultRegistry = "docker.io"
+
+// manifestTypes are the manifest media types a tag may point at. Indexes
+// come first so multi-architecture images resolve to their index digest.
+var manifestTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Reference is a parsed image reference.
+type Reference struct {
+	// Registry is the registry host, such as docker.io or ghcr.io:443.
+	Registry string
+	// Repository is the repository path, such as library/nginx.
+	Repository string
+	// Tag is the tag, empty when the reference has none.
+	Tag string
+	// Digest is the sha256 digest, empty when the reference has none.
+	Digest string
+}
+
+// ParseReference parses an image reference such as "nginx",
+// "ghcr.io/org/app:v1" or "registry.local:5000/app@sha256:...".
+func ParseReference(image string) (Reference, error) {
+	var ref Reference
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+		if !strings.HasPrefix(ref.Digest, "sha256:") || len(ref.Digest) != len("sha256:")+64 {
+			return Reference{}, fmt.Errorf("image %q has an invalid digest", image)
+		}
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+	}
+	if name == "" {
+		return Reference{}, fmt.Errorf("image %q is not a valid image reference", image)
+	}
+	if first, rest, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.Registry, ref.Repository = first, rest
+	} else {
+		ref.Registry, ref.Repository = DefaultRegistry, name
+	}
+	if ref.Registry == DefaultRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+	if ref.Repository == "" || strings.ToLower(ref.Repository) != ref.Repository {
+		return Reference{}, fmt.Errorf("image %q is not a valid image reference", image)
+	}
+	return ref, nil
+}
+
+// manifestRef returns the digest or tag the manifest of r is fetched by,
+// defaulting to latest.
+func (r Reference) manifestRef() string {
+	switch {
+	case r.Digest != "":
+		return r.Digest
+	case r.Tag != "":
+		return r.Tag
+	}
+	return "latest"
+}
+
+// IsLatest reports whether image is referenced by the mutable "latest" tag,
+// explicitly or by leaving the tag out, rather than by digest or a version.
+func IsLatest(image string) bool {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return false
+	}
+	return ref.Digest == "" && (ref.Tag == "" || ref.Tag == "latest")
+}
+
+// publicRegistries are the hosts of well-known internet registries.
+var publicRegistries = []string{
+	DefaultRegistry, "ghcr.io", "quay.io", "gcr.io", "registry.k8s.io", "k8s.gcr.io",
+	"mcr.microsoft.com", "public.ecr.aws", "nvcr.io", "registry.gitlab.com",
+}
+
+// publicRegistrySuffixes match the regional hosts of public registries.
+var publicRegistrySuffixes = []string{".gcr.io", ".pkg.dev"}
+
+// IsPublic reports whether image (or a repository prefix such as
+// "ghcr.io/org") lives on a well-known internet registry. References without
+// a registry host resolve to Docker Hub and are public.
+func IsPublic(image string) bool {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(registryHost(ref.Registry))
+	if slices.Contains(publicRegistries, host) {
+		return true
+	}
+	for _, suffix := range publicRegistrySuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Digest returns the digest image is pinned to, or "" when it has none.
+func Digest(image string) string {
+	if _, digest, ok := strings.Cut(image, "@"); ok {
+		return digest
+	}
+	return ""
+}
+
+// Pin returns image pinned to digest. The tag is kept for readability; the
+// runtime pulls by digest.
+func Pin(image, digest string) string {
+	if name, _, ok := strings.Cut(image, "@"); ok {
+		image = name
+	}
+	return image + "@" + digest
+}
+
+// Auth is a username and password for a registry.
+type Auth struct {
+	Username string
+	Password string
+}
+
+// AuthsFromDockerConfig returns the credentials of a .dockerconfigjson
+// document by registry host.
+func AuthsFromDockerConfig(data []byte) (map[string]Auth, error) {
+	var config struct {
+		Auths map[string]struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Auth     string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("parsing docker config: %w", err)
+	}
+	auths := make(map[string]Auth, len(config.Auths))
+	for server, a := range config.Auths {
+		auth := Auth{Username: a.Username, Password: a.Password}
+		if auth.Username == "" && a.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(a.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("decoding auth for %s: %w", server, err)
+			}
+			auth.Username, auth.Password, _ = strings.Cut(string(decoded), ":")
+		}
+		auths[registryHost(server)] = auth
+	}
+	return auths, nil
+}
+
+// registryHost normalizes a docker config server key, which may be a URL,
+// to the host used in image references.
+func registryHost(server string) string {
+	if u, err := url.Parse(server); err == nil && u.Host != "" {
+		server = u.Host
+	}
+	server = strings.TrimSuffix(server, "/")
+	switch server {
+	case "index.docker.io", "registry-1.docker.io":
+		return DefaultRegistry
+	}
+	return server
+}
+
+// Resolver resolves image tags to digests.
+type Resolver interface {
+	// Resolve returns the digest image currently points at, using auths
+	// (by registry host) for private registries.
+	Resolve(ctx context.Context, image string, auths map[string]Auth) (string, error)
+}
+
+// Client is a Resolver that queries registries over the OCI distribution
+// API.
+type Client struct {
+	// HTTP is the client used for registry requests.
+	HTTP *http.Client
+	// Insecure lists registry hosts reached over plain HTTP, such as an
+	// in-cluster development registry.
+	Insecure []string
+}
+
+// NewClient returns a Client with a 10 second request timeout.
+func NewClient(insecure []string) *Client {
+	return &Client{HTTP: &http.Client{Timeout: 10 * time.Second}, Insecure: insecure}
+}
+
+// Resolve returns the digest of image's manifest. Images already pinned to a
+// digest are returned unchanged without a request.
+func (c *Client) Resolve(ctx context.Context, image string, auths map[string]Auth) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.Digest != "" {
+		return ref.Digest, nil
+	}
+	resp, err := c.get(ctx, ref, "/manifests/"+ref.manifestRef(), manifestTypes, auths)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("resolving %s: registry returned %s", image, resp.Status)
+	}
+	if digest := resp.Header.Get("Docker-Content-Digest"); strings.HasPrefix(digest, "sha256:") {
+		return digest, nil
+	}
+	// Registries need not send the digest header; hash the manifest.
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return "", fmt.Errorf("reading manifest of %s: %w", image, err)
+	}
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// Ping checks that the registry at host serves the OCI distribution API.
+// An authentication challenge counts as an answer: only reachability is
+// checked, not credentials.
+func (c *Client) Ping(ctx context.Context, host string) error {
+	scheme := "https"
+	if slices.Contains(c.Insecure, host) {
+		scheme = "http"
+	}
+	resp, err := c.request(ctx, fmt.Sprintf("%s://%s/v2/", scheme, host), nil, "")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnauthorized {
+		return fmt.Errorf("registry %s returned %s", host, resp.Status)
+	}
+	return nil
+}
+
+// get requests path under the API URL of ref's repository, answering an
+// authentication challenge with the credentials in auths for ref's
+// registry, or anonymously when it has none.
+func (c *Client) get(ctx context.Context, ref Reference, path string, accept []string, auths map[string]Auth) (*http.Response, error) {
+	host := ref.Registry
+	if host == DefaultRegistry {
+		host = "registry-1.docker.io"
+	}
+	scheme := "https"
+	if slices.Contains(c.Insecure, ref.Registry) {
+		scheme = "http"
+	}
+	u := fmt.Sprintf("%s://%s/v2/%s%s", scheme, host, ref.Repository, path)
+	auth, hasAuth := auths[ref.Registry]
+
+	resp, err := c.request(ctx, u, accept, "")
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+	var authorization string
+	switch {
+	case strings.HasPrefix(strings.ToLower(challenge), "bearer "):
+		token, err := c.token(ctx, challenge, auth, hasAuth)
+		if err != nil {
+			return nil, err
+		}
+		authorization = "Bearer " + token
+	case hasAuth:
+		authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(auth.Username+":"+auth.Password))
+	default:
+		return nil, fmt.Errorf("registry %s requires credentials for %s", ref.Registry, ref.Repository)
+	}
+	return c.request(ctx, u, accept, authorization)
+}
+
+// request sends a GET, so registries that omit the digest header of a
+// manifest still return the body to hash.
+func (c *Client) request(ctx context.Context, u string, accept []string, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(accept) > 0 {
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("querying registry: %w", err)
+	}
+	return resp, nil
+}
+
+// token fetches a bearer token for a WWW-Authenticate challenge, as
+// anonymous when the registry has no credentials.
+func (c *Client) token(ctx context.Context, challenge string, auth Auth, hasAuth bool) (string, error) {
+	params := parseChallenge(challenge[len("bearer "):])
+	realm := params["realm"]
+	if realm == "" {
+		return "", errors.New("registry token challenge has no realm")
+	}
+	u, err := url.Parse(realm)
+	if err != nil {
+		return "", fmt.Errorf("parsing token realm: %w", err)
+	}
+	q := u.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			q.Set(key, params[key])
+		}
+	}
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if hasAuth {
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("requesting registry token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("requesting registry token: %s", resp.Status)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("decoding registry token: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", errors.New("registry token response has no token")
+}
+
+// parseChallenge parses the comma-separated key="value" parameters of a
+// WWW-Authenticate challenge.
+func parseChallenge(s string) map[string]string {
+	params := map[string]string{}
+	for s != "" {
+		key, rest, ok := strings.Cut(strings.TrimLeft(s, " ,"), "=")
+		if !ok {
+			break
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = value
+		s = rest
+	}
+	return params
+}
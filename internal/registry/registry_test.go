@@ -0,0 +1,223 @@
+package registry_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dlapiduz/iaf/internal/registry"
+)
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image string
+		want  registry.Reference
+	}{
+		{"nginx", registry.Reference{Registry: "docker.io", Repository: "library/nginx"}},
+		{"nginx:1.27", registry.Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "1.27"}},
+		{"org/app:v1", registry.Reference{Registry: "docker.io", Repository: "org/app", Tag: "v1"}},
+		{"ghcr.io/org/app:v1", registry.Reference{Registry: "ghcr.io", Repository: "org/app", Tag: "v1"}},
+		{"registry.local:5000/iaf/web@" + testDigest, registry.Reference{Registry: "registry.local:5000", Repository: "iaf/web", Digest: testDigest}},
+		{"localhost/app:dev@" + testDigest, registry.Reference{Registry: "localhost", Repository: "app", Tag: "dev", Digest: testDigest}},
+	}
+	for _, tt := range tests {
+		got, err := registry.ParseReference(tt.image)
+		if err != nil {
+			t.Errorf("%s: %v", tt.image, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.image, got, tt.want)
+		}
+	}
+	for _, bad := range []string{"nginx@sha256:abc", "Org/App:v1", ""} {
+		if _, err := registry.ParseReference(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestIsLatest(t *testing.T) {
+	for image, want := range map[string]bool{
+		"nginx":                      true,
+		"nginx:latest":               true,
+		"ghcr.io/org/app:latest":     true,
+		"nginx:1.27":                 false,
+		"nginx:latest@" + testDigest: false,
+	} {
+		if got := registry.IsLatest(image); got != want {
+			t.Errorf("IsLatest(%q) = %v, want %v", image, got, want)
+		}
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	for image, want := range map[string]bool{
+		"nginx:1.27":                            true,
+		"docker.io/library/nginx":               true,
+		"index.docker.io/library/nginx":         true,
+		"quay.io/oauth2-proxy/oauth2-proxy:v7":  true,
+		"us-docker.pkg.dev/project/repo/app":    true,
+		"eu.gcr.io/project/app":                 true,
+		"registry.localhost:5000/iaf":           false,
+		"harbor.corp.example/mirror/nginx:1.27": false,
+		"localhost:5000/app":                    false,
+	} {
+		if got := registry.IsPublic(image); got != want {
+			t.Errorf("IsPublic(%q) = %v, want %v", image, got, want)
+		}
+	}
+}
+
+func TestPin(t *testing.T) {
+	if got := registry.Pin("nginx:1.27", testDigest); got != "nginx:1.27@"+testDigest {
+		t.Errorf("Pin = %q", got)
+	}
+	if got := registry.Digest(registry.Pin("nginx@sha256:old", testDigest)); got != testDigest {
+		t.Errorf("Digest = %q", got)
+	}
+}
+
+func TestAuthsFromDockerConfig(t *testing.T) {
+	auths, err := registry.AuthsFromDockerConfig([]byte(`{"auths":{
+		"https://index.docker.io/v1/": {"auth": "dXNlcjpwYXNz"},
+		"ghcr.io": {"username": "bot", "password": "token"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auths["docker.io"] != (registry.Auth{Username: "user", Password: "pass"}) {
+		t.Errorf("docker.io auth = %+v", auths["docker.io"])
+	}
+	if auths["ghcr.io"] != (registry.Auth{Username: "bot", Password: "token"}) {
+		t.Errorf("ghcr.io auth = %+v", auths["ghcr.io"])
+	}
+}
+
+// fakeRegistry serves one manifest behind bearer token auth. With
+// sendDigest false it omits the Docker-Content-Digest header.
+func fakeRegistry(t *testing.T, sendDigest bool) (*httptest.Server, string) {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if user, pass, ok := r.BasicAuth(); !ok || user != "bot" || pass != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("scope") != "repository:team/web:pull" {
+				t.Errorf("unexpected token scope %q", r.URL.Query().Get("scope"))
+			}
+			fmt.Fprint(w, `{"token":"t0k"}`)
+		case "/v2/team/web/manifests/v1":
+			if r.Header.Get("Authorization") != "Bearer t0k" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:team/web:pull"`, srv.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if !strings.Contains(r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json") {
+				t.Errorf("expected index media types in Accept, got %q", r.Header.Get("Accept"))
+			}
+			if sendDigest {
+				w.Header().Set("Docker-Content-Digest", testDigest)
+			}
+			fmt.Fprint(w, `{"schemaVersion":2}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, strings.TrimPrefix(srv.URL, "http://")
+}
+
+func TestClientResolve(t *testing.T) {
+	_, host := fakeRegistry(t, true)
+	c := registry.NewClient([]string{host})
+	auths := map[string]registry.Auth{host: {Username: "bot", Password: "secret"}}
+
+	digest, err := c.Resolve(context.Background(), host+"/team/web:v1", auths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != testDigest {
+		t.Errorf("digest = %q, want %q", digest, testDigest)
+	}
+
+	if _, err := c.Resolve(context.Background(), host+"/team/web:v1", nil); err == nil {
+		t.Error("expected an error without credentials")
+	}
+	if _, err := c.Resolve(context.Background(), host+"/team/web:v2", auths); err == nil {
+		t.Error("expected an error for a missing tag")
+	}
+}
+
+func TestClientResolve_HashesManifestWithoutDigestHeader(t *testing.T) {
+	_, host := fakeRegistry(t, false)
+	c := registry.NewClient([]string{host})
+	digest, err := c.Resolve(context.Background(), host+"/team/web:v1", map[string]registry.Auth{host: {Username: "bot", Password: "secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(`{"schemaVersion":2}`))
+	if want := "sha256:" + hex.EncodeToString(sum[:]); digest != want {
+		t.Errorf("digest = %q, want %q", digest, want)
+	}
+}
+
+func TestClientResolve_PinnedImageSkipsRegistry(t *testing.T) {
+	c := registry.NewClient(nil)
+	digest, err := c.Resolve(context.Background(), "unreachable.invalid/app@"+testDigest, nil)
+	if err != nil || digest != testDigest {
+		t.Errorf("got %q, %v", digest, err)
+	}
+}
+
+func TestClientLabelsAndBlob(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/team/web/manifests/v1":
+			fmt.Fprint(w, `{"manifests":[
+				{"digest":"sha256:arm","platform":{"os":"linux","architecture":"arm64"}},
+				{"digest":"sha256:amd","platform":{"os":"linux","architecture":"amd64"}}]}`)
+		case "/v2/team/web/manifests/sha256:amd":
+			fmt.Fprint(w, `{"config":{"digest":"sha256:config"}}`)
+		case "/v2/team/web/blobs/sha256:config":
+			fmt.Fprint(w, `{"config":{"Labels":{"io.buildpacks.lifecycle.metadata":"{}"}}}`)
+		case "/v2/team/web/blobs/sha256:layer":
+			fmt.Fprint(w, "layer")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+	c := registry.NewClient([]string{host})
+
+	labels, err := c.Labels(context.Background(), host+"/team/web:v1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if labels["io.buildpacks.lifecycle.metadata"] != "{}" {
+		t.Errorf("labels = %v", labels)
+	}
+
+	blob, err := c.Blob(context.Background(), host+"/team/web:v1", "sha256:layer", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blob.Close()
+	if b, _ := io.ReadAll(blob); string(b) != "layer" {
+		t.Errorf("blob = %q", b)
+	}
+	if _, err := c.Blob(context.Background(), host+"/team/web:v1", "sha256:missing", nil); err == nil {
+		t.Error("expected an error for a missing blob")
+	}
+}
@@ -0,0 +1,161 @@
+// Package sbom reads the software bill of materials Cloud Native Buildpacks
+// attach to the images they build, so the packages an app ships can be
+// checked against the organisation dependency policy.
+package sbom
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+	"github.com/dlapiduz/iaf/internal/registry"
+)
+
+// lifecycleMetadataLabel is the image label in which the buildpacks
+// lifecycle records the digest of the SBOM layer.
+const lifecycleMetadataLabel = "io.buildpacks.lifecycle.metadata"
+
+// maxDocumentSize bounds each SBOM document read from the layer.
+const maxDocumentSize = 32 << 20
+
+// ErrNoSBOM marks an image without a buildpacks SBOM layer, such as one
+// built without SBOM support.
+var ErrNoSBOM = errors.New("image has no buildpacks SBOM")
+
+// Reader lists the packages in an image.
+type Reader interface {
+	Packages(ctx context.Context, image string) ([]orgstandards.Dependency, error)
+}
+
+// Registry is a Reader that fetches an image's SBOM layer from its
+// registry.
+type Registry struct {
+	Client *registry.Client
+}
+
+// Packages returns the packages the CycloneDX documents of image's SBOM
+// layer list, or ErrNoSBOM.
+func (r Registry) Packages(ctx context.Context, image string) ([]orgstandards.Dependency, error) {
+	labels, err := r.Client.Labels(ctx, image, nil)
+	if err != nil {
+		return nil, err
+	}
+	var metadata struct {
+		SBOM *struct {
+			SHA string `json:"sha"`
+		} `json:"sbom"`
+	}
+	if raw := labels[lifecycleMetadataLabel]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &metadata); err != nil {
+			return nil, fmt.Errorf("parsing %s label: %w", lifecycleMetadataLabel, err)
+		}
+	}
+	if metadata.SBOM == nil || metadata.SBOM.SHA == "" {
+		return nil, ErrNoSBOM
+	}
+	layer, err := r.Client.Blob(ctx, image, metadata.SBOM.SHA, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer layer.Close()
+	return ReadLayer(layer, image)
+}
+
+// ReadLayer returns the packages the CycloneDX documents (*.cdx.json) in a
+// gzipped SBOM layer list, with source as their Source.
+func ReadLayer(r io.Reader, source string) ([]orgstandards.Dependency, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading SBOM layer: %w", err)
+	}
+	defer gz.Close()
+	var deps []orgstandards.Dependency
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return deps, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading SBOM layer: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || !strings.HasSuffix(hdr.Name, ".cdx.json") {
+			continue
+		}
+		var doc struct {
+			Components []component `json:"components"`
+		}
+		if err := json.NewDecoder(io.LimitReader(tr, maxDocumentSize)).Decode(&doc); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", hdr.Name, err)
+		}
+		for _, c := range doc.Components {
+			deps = c.appendTo(deps, source)
+		}
+	}
+}
+
+// component is a CycloneDX component, which may nest others.
+type component struct {
+	PURL       string      `json:"purl"`
+	Components []component `json:"components"`
+}
+
+// appendTo appends the packages of c and its nested components to deps.
+func (c component) appendTo(deps []orgstandards.Dependency, source string) []orgstandards.Dependency {
+	if d, ok := ParsePURL(c.PURL); ok {
+		d.Source = source
+		deps = append(deps, d)
+	}
+	for _, nested := range c.Components {
+		deps = nested.appendTo(deps, source)
+	}
+	return deps
+}
+
+// purlEcosystems maps package URL types to dependency rule ecosystems.
+var purlEcosystems = map[string]string{
+	"golang": orgstandards.EcosystemGo,
+	"npm":    orgstandards.EcosystemNPM,
+	"pypi":   orgstandards.EcosystemPyPI,
+	"maven":  orgstandards.EcosystemMaven,
+	"gem":    orgstandards.EcosystemRubyGems,
+}
+
+// ParsePURL returns the package a package URL such as
+// "pkg:npm/%40scope/name@1.0.0" names, in the naming of dependency rules:
+// Maven packages are group:artifact and Go and scoped npm packages keep
+// their namespace path. It reports false for other package types.
+func ParsePURL(purl string) (orgstandards.Dependency, bool) {
+	rest, ok := strings.CutPrefix(purl, "pkg:")
+	if !ok {
+		return orgstandards.Dependency{}, false
+	}
+	rest, _, _ = strings.Cut(rest, "#")
+	rest, _, _ = strings.Cut(rest, "?")
+	typ, rest, ok := strings.Cut(rest, "/")
+	ecosystem := purlEcosystems[strings.ToLower(typ)]
+	if !ok || ecosystem == "" {
+		return orgstandards.Dependency{}, false
+	}
+	rest, version, _ := strings.Cut(rest, "@")
+	segments := strings.Split(strings.Trim(rest, "/"), "/")
+	for i, s := range segments {
+		segments[i], _ = url.PathUnescape(s)
+	}
+	name := strings.Join(segments, "/")
+	if ecosystem == orgstandards.EcosystemMaven && len(segments) > 1 {
+		name = strings.Join(segments[:len(segments)-1], ".") + ":" + segments[len(segments)-1]
+	}
+	if name == "" {
+		return orgstandards.Dependency{}, false
+	}
+	version, _ = url.PathUnescape(version)
+	return orgstandards.Dependency{Ecosystem: ecosystem, Name: name, Version: version}, true
+}
@@ -0,0 +1,69 @@
+package sbom_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"slices"
+	"testing"
+
+	"github.com/dlapiduz/iaf/internal/orgstandards"
+	"github.com/dlapiduz/iaf/internal/sbom"
+)
+
+func TestParsePURL(t *testing.T) {
+	for purl, want := range map[string]orgstandards.Dependency{
+		"pkg:golang/github.com/gin-gonic/gin@v1.9.1":                    {Ecosystem: "go", Name: "github.com/gin-gonic/gin", Version: "v1.9.1"},
+		"pkg:npm/%40babel/core@7.24.0":                                  {Ecosystem: "npm", Name: "@babel/core", Version: "7.24.0"},
+		"pkg:pypi/flask@3.0.0?extra=async":                              {Ecosystem: "pypi", Name: "flask", Version: "3.0.0"},
+		"pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1?type=jar": {Ecosystem: "maven", Name: "org.apache.logging.log4j:log4j-core", Version: "2.14.1"},
+		"pkg:gem/rails@7.1.3":                                           {Ecosystem: "rubygems", Name: "rails", Version: "7.1.3"},
+	} {
+		got, ok := sbom.ParsePURL(purl)
+		if !ok || got != want {
+			t.Errorf("ParsePURL(%q) = %+v, %v; want %+v", purl, got, ok, want)
+		}
+	}
+	for _, purl := range []string{"pkg:deb/debian/openssl@3.0.11", "pkg:npm/", "npm/left-pad", ""} {
+		if got, ok := sbom.ParsePURL(purl); ok {
+			t.Errorf("ParsePURL(%q) = %+v, want no package", purl, got)
+		}
+	}
+}
+
+func TestReadLayer(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{
+		"layers/sbom/launch/paketo-buildpacks_npm-install/launch/sbom.cdx.json": `{"components":[
+			{"purl":"pkg:npm/express@4.19.2","components":[{"purl":"pkg:npm/qs@6.11.0"}]},
+			{"purl":"pkg:deb/debian/openssl@3.0.11"}]}`,
+		"layers/sbom/launch/paketo-buildpacks_npm-install/launch/sbom.syft.json": `{"artifacts":[]}`,
+	} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := sbom.ReadLayer(&buf, "registry.local/iaf-web/app@sha256:abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []orgstandards.Dependency{
+		{Ecosystem: "npm", Name: "express", Version: "4.19.2", Source: "registry.local/iaf-web/app@sha256:abc"},
+		{Ecosystem: "npm", Name: "qs", Version: "6.11.0", Source: "registry.local/iaf-web/app@sha256:abc"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ReadLayer = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,741 @@
+// Package service holds the operations behind the REST and gRPC APIs, so
+// both surfaces validate, check policy and write to the cluster the same
+// way. Operations take the session namespace already resolved and report
+// failures as *apierror.Error, or *policy.ViolationError when a platform
+// policy blocks the request.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/apierror"
+	"github.com/dlapiduz/iaf/internal/auth"
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	"github.com/dlapiduz/iaf/internal/policy"
+	"github.com/dlapiduz/iaf/internal/sourcestore"
+	"github.com/dlapiduz/iaf/internal/validation"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AppInput holds the settings of an application create or update. On
+// update, zero fields are left unchanged.
+type AppInput struct {
+	Name           string
+	Image          string
+	GitURL         string
+	GitRevision    string
+	GitTrack       string
+	GitPaused      *bool
+	SubPath        *string
+	Port           int32
+	Replicas       int32
+	Env            []iafv1alpha1.EnvVar
+	BuildEnv       []iafv1alpha1.EnvVar
+	Host           string
+	Protocol       string
+	StickySessions *bool
+	Authentication string
+	BackendTLS     *bool
+	Access         *iafv1alpha1.AccessConfig
+	HostAliases    []iafv1alpha1.HostAlias
+	DNSConfig      *iafv1alpha1.DNSConfig
+	Metadata       *iafv1alpha1.OwnershipMetadata
+}
+
+// Applications manages the Applications in a session namespace.
+type Applications struct {
+	client    client.Client
+	store     *sourcestore.Store
+	policy    *policy.Engine
+	customDNS bool
+}
+
+// NewApplications returns the application operations. store may be nil
+// when source uploads are not served. Host aliases and DNS settings are
+// accepted only when customDNS is set.
+func NewApplications(c client.Client, store *sourcestore.Store, customDNS bool) *Applications {
+	return &Applications{client: c, store: store, policy: policy.New(c), customDNS: customDNS}
+}
+
+// List returns the applications in namespace.
+func (s *Applications) List(ctx context.Context, namespace string) ([]iafv1alpha1.Application, error) {
+	var list iafv1alpha1.ApplicationList
+	if err := s.client.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		return nil, apierror.From(err)
+	}
+	return list.Items, nil
+}
+
+// Get returns the named application.
+func (s *Applications) Get(ctx context.Context, namespace, name string) (*iafv1alpha1.Application, error) {
+	var app iafv1alpha1.Application
+	if err := s.client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &app); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, apierror.NotFound(apierror.CodeAppNotFound, "application not found")
+		}
+		return nil, apierror.From(err)
+	}
+	return &app, nil
+}
+
+// Create validates in and creates the application it describes, defaulting
+// the port to 8080 and replicas to 1.
+func (s *Applications) Create(ctx context.Context, namespace string, in AppInput) (*iafv1alpha1.Application, error) {
+	if err := validation.ValidateAppName(in.Name); err != nil {
+		return nil, invalid(err)
+	}
+	if err := validateInput(in); err != nil {
+		return nil, invalid(err)
+	}
+	if in.Image == "" && in.GitURL == "" {
+		return nil, apierror.Validation(apierror.CodeInvalidRequest, "either image or gitUrl is required")
+	}
+	if err := validation.ValidateAuthentication(in.Authentication, in.Protocol); err != nil {
+		return nil, invalid(err)
+	}
+	if err := validation.ValidateBackendTLS(in.BackendTLS != nil && *in.BackendTLS, in.Protocol, in.Authentication); err != nil {
+		return nil, invalid(err)
+	}
+	if in.Access != nil {
+		if err := validation.ValidateAccess(in.Access.IPAllowList, in.Access.RequestsPerSecond, in.Protocol); err != nil {
+			return nil, invalid(err)
+		}
+	}
+	if err := iafk8s.CheckCustomDNS(s.customDNS, in.HostAliases, in.DNSConfig); err != nil {
+		return nil, err
+	}
+
+	app := &iafv1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      in.Name,
+			Namespace: namespace,
+		},
+		Spec: iafv1alpha1.ApplicationSpec{
+			Image:          in.Image,
+			Port:           in.Port,
+			Replicas:       in.Replicas,
+			Env:            in.Env,
+			BuildEnv:       in.BuildEnv,
+			Host:           in.Host,
+			Protocol:       iafv1alpha1.ApplicationProtocol(in.Protocol),
+			Authentication: iafv1alpha1.ApplicationAuthentication(in.Authentication),
+			Access:         in.Access,
+			HostAliases:    in.HostAliases,
+			DNSConfig:      in.DNSConfig,
+			Metadata:       in.Metadata,
+		},
+	}
+	if in.GitURL != "" {
+		app.Spec.Git = &iafv1alpha1.GitSource{
+			URL:      in.GitURL,
+			Revision: in.GitRevision,
+		}
+	}
+	if err := applyGitTracking(app, in); err != nil {
+		return nil, invalid(err)
+	}
+	if err := applySubPath(app, in); err != nil {
+		return nil, invalid(err)
+	}
+	if in.StickySessions != nil {
+		app.Spec.StickySessions = *in.StickySessions
+	}
+	if in.BackendTLS != nil && *in.BackendTLS {
+		app.Spec.BackendTLS = &iafv1alpha1.BackendTLSConfig{Enabled: true}
+	}
+	setDefaults(app)
+
+	if err := s.policy.Check(ctx, policy.Input{Operation: iafv1alpha1.PolicyOperationDeploy, Namespace: namespace, App: app}); err != nil {
+		return nil, policyError(err)
+	}
+	if err := s.client.Create(ctx, app); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return nil, apierror.Conflict(apierror.CodeNameTaken, "application already exists")
+		}
+		return nil, apierror.From(err)
+	}
+	return app, nil
+}
+
+// Update applies the fields set in in to the named application. When
+// expectedVersion is non-zero the update fails with version_conflict unless
+// the application is still at that version. An empty Access removes IP and
+// rate-limit restrictions, and empty HostAliases or DNSConfig remove the
+// custom DNS settings.
+func (s *Applications) Update(ctx context.Context, namespace, name string, in AppInput, expectedVersion int64) (*iafv1alpha1.Application, error) {
+	_, app, err := s.proposed(ctx, namespace, name, in, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.write(ctx, namespace, app); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// Replace sets the named application to exactly the settings in in, as
+// Create would make them: omitted fields return to their defaults. It
+// creates the application when it does not exist and expectedVersion is
+// 0, and reports whether it did. An uploaded source is kept unless in sets
+// an image or git repository, and settings outside AppInput are left as
+// they are.
+func (s *Applications) Replace(ctx context.Context, namespace, name string, in AppInput, expectedVersion int64) (*iafv1alpha1.Application, bool, error) {
+	in.Name = name
+	if err := validation.ValidateAppName(name); err != nil {
+		return nil, false, invalid(err)
+	}
+	if err := validateInput(in); err != nil {
+		return nil, false, invalid(err)
+	}
+	if err := iafk8s.CheckCustomDNS(s.customDNS, in.HostAliases, in.DNSConfig); err != nil {
+		return nil, false, err
+	}
+	current, err := s.Get(ctx, namespace, name)
+	if err != nil {
+		if e := apierror.From(err); e.Code != apierror.CodeAppNotFound || expectedVersion != 0 {
+			return nil, false, err
+		}
+		app, err := s.Create(ctx, namespace, in)
+		return app, err == nil, err
+	}
+	if err := iafk8s.CheckVersion(current, expectedVersion); err != nil {
+		return nil, false, apierror.From(err)
+	}
+	app := current.DeepCopy()
+	if err := replaceInput(app, in); err != nil {
+		return nil, false, invalid(err)
+	}
+	if err := s.write(ctx, namespace, app); err != nil {
+		return nil, false, err
+	}
+	return app, false, nil
+}
+
+// write checks app against platform policy and saves it. The read's
+// resourceVersion makes the write fail if the application changed after it
+// was read.
+func (s *Applications) write(ctx context.Context, namespace string, app *iafv1alpha1.Application) error {
+	if err := s.policy.Check(ctx, policy.Input{Operation: iafv1alpha1.PolicyOperationDeploy, Namespace: namespace, App: app}); err != nil {
+		return policyError(err)
+	}
+	if err := s.client.Update(ctx, app); err != nil {
+		if apierrors.IsConflict(err) {
+			return apierror.From(err).WithHint("the application changed while it was being updated; read it again and retry")
+		}
+		return apierror.From(err)
+	}
+	return nil
+}
+
+// Plan previews Update: it reports the spec fields that would change and
+// whether applying them rebuilds, restarts or only rescales the
+// application, without saving anything.
+func (s *Applications) Plan(ctx context.Context, namespace, name string, in AppInput, expectedVersion int64) (iafk8s.UpdatePlan, error) {
+	current, proposed, err := s.proposed(ctx, namespace, name, in, expectedVersion)
+	if err != nil {
+		return iafk8s.UpdatePlan{}, err
+	}
+	return iafk8s.PlanUpdate(current, proposed), nil
+}
+
+// proposed reads the named application and returns it as read and with in
+// applied.
+func (s *Applications) proposed(ctx context.Context, namespace, name string, in AppInput, expectedVersion int64) (current, proposed *iafv1alpha1.Application, err error) {
+	if err := validation.ValidateAppName(name); err != nil {
+		return nil, nil, invalid(err)
+	}
+	if err := validateInput(in); err != nil {
+		return nil, nil, invalid(err)
+	}
+	if err := iafk8s.CheckCustomDNS(s.customDNS, in.HostAliases, in.DNSConfig); err != nil {
+		return nil, nil, err
+	}
+	current, err = s.Get(ctx, namespace, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := iafk8s.CheckVersion(current, expectedVersion); err != nil {
+		return nil, nil, apierror.From(err)
+	}
+	proposed = current.DeepCopy()
+	if err := applyInput(proposed, in); err != nil {
+		return nil, nil, invalid(err)
+	}
+	return current, proposed, nil
+}
+
+// Delete deletes the named application and its stored source. When
+// expectedVersion is non-zero the delete fails with version_conflict unless
+// the application is still at that version. A deletion-protected
+// application is not deleted.
+func (s *Applications) Delete(ctx context.Context, namespace, name string, expectedVersion int64) error {
+	current, err := s.Get(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+	if expectedVersion != 0 {
+		if err := iafk8s.CheckVersion(current, expectedVersion); err != nil {
+			return apierror.From(err)
+		}
+	}
+	if current.Spec.DeletionProtected {
+		return iafk8s.DeletionProtected("application", name)
+	}
+	// Deleting only the object that was checked keeps a change made in
+	// between, such as turning deletion protection on, from being deleted
+	// unseen.
+	opts := []client.DeleteOption{client.Preconditions{UID: &current.UID, ResourceVersion: &current.ResourceVersion}}
+	if err := s.client.Delete(ctx, current, opts...); err != nil {
+		if apierrors.IsConflict(err) {
+			return apierror.From(err).WithHint("the application changed while it was being deleted; read it again and retry")
+		}
+		if apierrors.IsNotFound(err) {
+			return apierror.NotFound(apierror.CodeAppNotFound, "application not found")
+		}
+		return apierror.From(err)
+	}
+	if s.store != nil {
+		_ = s.store.Delete(namespace, name)
+	}
+	return nil
+}
+
+// UploadFiles stores files, a map of path to content, and binaryFiles, a
+// map of path to base64-encoded content, as the source of the named
+// application and points the application at it. It returns the blob URL
+// the build fetches.
+func (s *Applications) UploadFiles(ctx context.Context, namespace, name string, files, binaryFiles map[string]string) (string, error) {
+	files, err := sourcestore.MergeBinaryFiles(files, binaryFiles)
+	if err != nil {
+		return "", invalid(err)
+	}
+	if len(files) == 0 {
+		return "", apierror.Validation(apierror.CodeInvalidRequest, "files map is required")
+	}
+	return s.upload(ctx, namespace, name, slices.Sorted(maps.Keys(files)), func() (sourcestore.Blob, error) {
+		return s.store.StoreFiles(namespace, name, files)
+	})
+}
+
+// UploadTarball is like UploadFiles for a gzipped tarball. The tarball is
+// spooled to disk so its file list can be checked against policy first.
+func (s *Applications) UploadTarball(ctx context.Context, namespace, name string, r io.Reader) (string, error) {
+	tmp, err := os.CreateTemp("", "iaf-source-*.tar.gz")
+	if err != nil {
+		return "", apierror.From(err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if max := s.store.MaxUploadSize(); max > 0 {
+		r = io.LimitReader(r, max+1)
+	}
+	n, err := io.Copy(tmp, r)
+	if err != nil {
+		return "", apierror.Validation(apierror.CodeInvalidRequest, "reading tarball: %v", err)
+	}
+	if max := s.store.MaxUploadSize(); max > 0 && n > max {
+		return "", uploadError(sourcestore.ErrTooLarge, s.store)
+	}
+	return s.uploadTarball(ctx, namespace, name, tmp)
+}
+
+// uploadTarball uploads the gzipped tarball in tmp.
+func (s *Applications) uploadTarball(ctx context.Context, namespace, name string, tmp *os.File) (string, error) {
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return "", apierror.From(err)
+	}
+	files, err := sourcestore.ListTarball(tmp)
+	if err != nil {
+		return "", invalid(err)
+	}
+	return s.upload(ctx, namespace, name, files, func() (sourcestore.Blob, error) {
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return sourcestore.Blob{}, err
+		}
+		return s.store.StoreTarball(namespace, name, tmp)
+	})
+}
+
+// StartUpload begins a chunked tarball upload for the named application,
+// for sources too large to send in one request.
+func (s *Applications) StartUpload(ctx context.Context, namespace, name string) (sourcestore.Upload, error) {
+	if _, err := s.Get(ctx, namespace, name); err != nil {
+		return sourcestore.Upload{}, err
+	}
+	up, err := s.store.StartUpload(namespace, name)
+	if err != nil {
+		return sourcestore.Upload{}, uploadError(err, s.store)
+	}
+	return up, nil
+}
+
+// GetUpload returns a chunked upload, whose size is the offset to resume
+// from.
+func (s *Applications) GetUpload(namespace, name, id string) (sourcestore.Upload, error) {
+	up, err := s.store.GetUpload(namespace, name, id)
+	if err != nil {
+		return sourcestore.Upload{}, uploadError(err, s.store)
+	}
+	return up, nil
+}
+
+// AppendUpload adds the tarball chunk in r, which starts at offset, to a
+// chunked upload.
+func (s *Applications) AppendUpload(namespace, name, id string, offset int64, r io.Reader) (sourcestore.Upload, error) {
+	up, err := s.store.AppendUpload(namespace, name, id, offset, r)
+	if err != nil {
+		return up, uploadError(err, s.store).WithDetails(map[string]int64{"offset": up.Size})
+	}
+	return up, nil
+}
+
+// CompleteUpload uploads the tarball a chunked upload's chunks make up,
+// like UploadTarball, and removes the upload once it succeeds.
+func (s *Applications) CompleteUpload(ctx context.Context, namespace, name, id string) (string, error) {
+	f, err := s.store.OpenUpload(namespace, name, id)
+	if err != nil {
+		return "", uploadError(err, s.store)
+	}
+	defer f.Close()
+	blobURL, err := s.uploadTarball(ctx, namespace, name, f)
+	if err != nil {
+		return "", err
+	}
+	if err := s.store.DeleteUpload(namespace, name, id); err != nil {
+		return "", uploadError(err, s.store)
+	}
+	return blobURL, nil
+}
+
+// AbortUpload discards a chunked upload.
+func (s *Applications) AbortUpload(namespace, name, id string) error {
+	if err := s.store.DeleteUpload(namespace, name, id); err != nil {
+		return uploadError(err, s.store)
+	}
+	return nil
+}
+
+// uploadError classifies a source store error.
+func uploadError(err error, store *sourcestore.Store) *apierror.Error {
+	switch {
+	case errors.Is(err, sourcestore.ErrUploadNotFound):
+		return apierror.NotFound(apierror.CodeUploadNotFound, "upload not found").
+			WithHint("uploads expire 24 hours after they start; start a new one")
+	case errors.Is(err, sourcestore.ErrOffsetMismatch):
+		return apierror.Conflict(apierror.CodeUploadOffsetMismatch, "%s", err.Error()).
+			WithHint("read the upload to get its current offset and resume from there")
+	case errors.Is(err, sourcestore.ErrTooLarge):
+		return apierror.Validation(apierror.CodeSourceTooLarge, "source exceeds the maximum upload size of %d bytes", store.MaxUploadSize()).
+			WithHint("leave build outputs, dependencies and assets out of the source")
+	case errors.Is(err, sourcestore.ErrTooManyUploads):
+		return apierror.Quota(apierror.CodeTooManyUploads, "the namespace already has %d unfinished uploads", store.MaxOpenUploads()).
+			WithHint("complete or abort an open upload first; unfinished uploads expire 24 hours after they start")
+	}
+	return apierror.Platform(apierror.CodeInternal, false, "%s", err.Error())
+}
+
+// upload checks files against policy, stores them with put and sets the
+// resulting blob URL and checksum on the application.
+func (s *Applications) upload(ctx context.Context, namespace, name string, files []string, put func() (sourcestore.Blob, error)) (string, error) {
+	app, err := s.Get(ctx, namespace, name)
+	if err != nil {
+		return "", err
+	}
+	// The source is checked against platform policies before it replaces
+	// the stored source.
+	app.Spec.Image = ""
+	app.Spec.Git = nil
+	in := policy.Input{Operation: iafv1alpha1.PolicyOperationPushCode, Namespace: namespace, App: app, Files: files}
+	if err := s.policy.Check(ctx, in); err != nil {
+		return "", policyError(err)
+	}
+	blob, err := put()
+	if err != nil {
+		return "", uploadError(err, s.store)
+	}
+	app.Spec.Blob = blob.URL
+	metav1.SetMetaDataAnnotation(&app.ObjectMeta, sourcestore.AnnotationSourceSHA256, blob.SHA256)
+	if err := s.client.Update(ctx, app); err != nil {
+		return "", apierror.From(err)
+	}
+	return blob.URL, nil
+}
+
+// validateInput checks the fields of in that do not depend on the
+// application.
+func validateInput(in AppInput) error {
+	for _, e := range in.Env {
+		if err := validation.ValidateEnvVarName(e.Name); err != nil {
+			return err
+		}
+	}
+	for _, e := range in.BuildEnv {
+		if err := validation.ValidateBuildEnvVarName(e.Name); err != nil {
+			return err
+		}
+	}
+	if m := in.Metadata; m != nil {
+		if err := validation.ValidateOwnership(m.Team, m.Owner, m.Purpose, m.CostCenter); err != nil {
+			return err
+		}
+	}
+	return validation.ValidateProtocol(in.Protocol)
+}
+
+// applyInput sets the fields present in in on app and validates the
+// resulting combination of settings.
+func applyInput(app *iafv1alpha1.Application, in AppInput) error {
+	if in.Image != "" {
+		app.Spec.Image = in.Image
+		app.Spec.Git = nil
+		app.Spec.Blob = ""
+		app.Spec.BlobSubPath = ""
+	}
+	if in.GitURL != "" {
+		app.Spec.Git = &iafv1alpha1.GitSource{
+			URL:      in.GitURL,
+			Revision: in.GitRevision,
+		}
+		app.Spec.Image = ""
+		app.Spec.Blob = ""
+		app.Spec.BlobSubPath = ""
+	}
+	if err := applyGitTracking(app, in); err != nil {
+		return err
+	}
+	if err := applySubPath(app, in); err != nil {
+		return err
+	}
+	if in.Port > 0 {
+		app.Spec.Port = in.Port
+	}
+	if in.Replicas > 0 {
+		app.Spec.Replicas = in.Replicas
+	}
+	if in.Env != nil {
+		app.Spec.Env = in.Env
+	}
+	if in.BuildEnv != nil {
+		app.Spec.BuildEnv = in.BuildEnv
+	}
+	if in.Host != "" {
+		app.Spec.Host = in.Host
+	}
+	if in.Protocol != "" {
+		app.Spec.Protocol = iafv1alpha1.ApplicationProtocol(in.Protocol)
+	}
+	if in.StickySessions != nil {
+		app.Spec.StickySessions = *in.StickySessions
+	}
+	if in.Authentication != "" {
+		app.Spec.Authentication = iafv1alpha1.ApplicationAuthentication(in.Authentication)
+	}
+	if in.BackendTLS != nil {
+		app.Spec.BackendTLS = nil
+		if *in.BackendTLS {
+			app.Spec.BackendTLS = &iafv1alpha1.BackendTLSConfig{Enabled: true}
+		}
+	}
+	if in.Access != nil {
+		app.Spec.Access = in.Access
+		if len(in.Access.IPAllowList) == 0 && in.Access.RequestsPerSecond == 0 {
+			app.Spec.Access = nil
+		}
+	}
+	if in.HostAliases != nil {
+		app.Spec.HostAliases = in.HostAliases
+		if len(in.HostAliases) == 0 {
+			app.Spec.HostAliases = nil
+		}
+	}
+	if in.DNSConfig != nil {
+		app.Spec.DNSConfig = in.DNSConfig
+		if len(in.DNSConfig.Nameservers)+len(in.DNSConfig.Searches)+len(in.DNSConfig.Options) == 0 {
+			app.Spec.DNSConfig = nil
+		}
+	}
+	if in.Metadata != nil {
+		app.Spec.Metadata = in.Metadata
+		if *in.Metadata == (iafv1alpha1.OwnershipMetadata{}) {
+			app.Spec.Metadata = nil
+		}
+	}
+	if err := validation.ValidateAuthentication(string(app.Spec.Authentication), string(app.Spec.Protocol)); err != nil {
+		return err
+	}
+	if err := validation.ValidateBackendTLS(iafv1alpha1.IsBackendTLSEnabled(app), string(app.Spec.Protocol), string(app.Spec.Authentication)); err != nil {
+		return err
+	}
+	if app.Spec.Access != nil {
+		if err := validation.ValidateAccess(app.Spec.Access.IPAllowList, app.Spec.Access.RequestsPerSecond, string(app.Spec.Protocol)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceInput resets the settings AppInput covers on app and applies in,
+// so app ends up with exactly the settings in. An uploaded source is kept
+// when in names no image or git repository.
+func replaceInput(app *iafv1alpha1.Application, in AppInput) error {
+	if in.Image == "" && in.GitURL == "" && app.Spec.Blob == "" {
+		return fmt.Errorf("either image or gitUrl is required")
+	}
+	spec := &app.Spec
+	spec.Image, spec.Git, spec.BlobSubPath = "", nil, ""
+	spec.Port, spec.Replicas = 0, 0
+	spec.Env, spec.BuildEnv = nil, nil
+	spec.Host, spec.Protocol = "", ""
+	spec.StickySessions = false
+	spec.Authentication = ""
+	spec.BackendTLS, spec.Access = nil, nil
+	spec.HostAliases, spec.DNSConfig = nil, nil
+	spec.Metadata = nil
+	if err := applyInput(app, in); err != nil {
+		return err
+	}
+	setDefaults(app)
+	return nil
+}
+
+// setDefaults defaults the port of app to 8080 and its replicas to 1.
+func setDefaults(app *iafv1alpha1.Application) {
+	if app.Spec.Port == 0 {
+		app.Spec.Port = 8080
+	}
+	if app.Spec.Replicas == 0 {
+		app.Spec.Replicas = 1
+	}
+}
+
+// applyGitTracking sets the git track mode and pause of in on the git
+// source of app.
+func applyGitTracking(app *iafv1alpha1.Application, in AppInput) error {
+	if in.GitTrack == "" && in.GitPaused == nil {
+		return nil
+	}
+	if app.Spec.Git == nil {
+		return fmt.Errorf("gitTrack and gitPaused require a git source")
+	}
+	if in.GitTrack != "" {
+		app.Spec.Git.Track = iafv1alpha1.GitTrackMode(in.GitTrack)
+	}
+	if in.GitPaused != nil {
+		app.Spec.Git.Paused = *in.GitPaused
+	}
+	return validation.ValidateGitTrack(string(app.Spec.Git.Track), iafk8s.GitRevision(app))
+}
+
+// applySubPath sets the sub path of in on the git or uploaded source of
+// app. An empty sub path builds the source root.
+func applySubPath(app *iafv1alpha1.Application, in AppInput) error {
+	if in.SubPath == nil {
+		return nil
+	}
+	if err := validation.ValidateSubPath(*in.SubPath); err != nil {
+		return err
+	}
+	switch {
+	case app.Spec.Git != nil:
+		app.Spec.Git.SubPath = *in.SubPath
+	case app.Spec.Blob != "":
+		app.Spec.BlobSubPath = *in.SubPath
+	case *in.SubPath != "":
+		return fmt.Errorf("subPath requires a git or uploaded source; pre-built images are not built")
+	}
+	return nil
+}
+
+// Services lists the ManagedServices in a session namespace.
+type Services struct {
+	client client.Client
+}
+
+// NewServices returns the managed service operations.
+func NewServices(c client.Client) *Services {
+	return &Services{client: c}
+}
+
+// List returns the managed services in namespace.
+func (s *Services) List(ctx context.Context, namespace string) ([]iafv1alpha1.ManagedService, error) {
+	var list iafv1alpha1.ManagedServiceList
+	if err := s.client.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		return nil, apierror.From(err)
+	}
+	return list.Items, nil
+}
+
+// Sessions registers and resolves agent sessions.
+type Sessions struct {
+	client   client.Client
+	sessions *auth.SessionStore
+	ttl      time.Duration
+}
+
+// NewSessions returns the session operations. Sessions registered through
+// it expire after ttl; 0 disables expiry.
+func NewSessions(c client.Client, sessions *auth.SessionStore, ttl time.Duration) *Sessions {
+	return &Sessions{client: c, sessions: sessions, ttl: ttl}
+}
+
+// TTL returns the idle lifetime of new sessions.
+func (s *Sessions) TTL() time.Duration { return s.ttl }
+
+// Register creates a session and its namespace, or hands it a warm one from
+// the session store's pool. metadata, which may be
+// nil, is the default ownership of the applications created in the session.
+func (s *Sessions) Register(ctx context.Context, name string, metadata *iafv1alpha1.OwnershipMetadata) (*auth.Session, error) {
+	if m := metadata; m != nil {
+		if err := validation.ValidateOwnership(m.Team, m.Owner, m.Purpose, m.CostCenter); err != nil {
+			return nil, invalid(err)
+		}
+	}
+	sess, err := s.sessions.Provision(ctx, s.client, name, s.ttl)
+	if err != nil {
+		return nil, apierror.Platform(apierror.CodeInternal, false, "registering session: %v", err)
+	}
+	if metadata != nil {
+		if err := s.sessions.SetMetadata(sess.ID, metadata); err != nil {
+			return nil, apierror.From(err)
+		}
+	}
+	return sess, nil
+}
+
+// Namespace returns the namespace of session id.
+func (s *Sessions) Namespace(id string) (string, error) {
+	if id == "" {
+		return "", apierror.Validation(apierror.CodeInvalidRequest, "missing session ID: provide X-IAF-Session header or session_id query parameter")
+	}
+	sess, ok := s.sessions.Lookup(id)
+	if !ok {
+		return "", apierror.NotFound(apierror.CodeSessionNotFound, "session not found, call register first").
+			WithHint("sessions expire when idle; register a new session")
+	}
+	return sess.Namespace, nil
+}
+
+// invalid reports a validation failure with err's message.
+func invalid(err error) *apierror.Error {
+	return apierror.Validation(apierror.CodeInvalidRequest, "%s", err.Error())
+}
+
+// policyError passes a *policy.ViolationError through and classifies any
+// other failure to evaluate the policies.
+func policyError(err error) error {
+	if _, ok := err.(*policy.ViolationError); ok {
+		return err
+	}
+	return apierror.From(fmt.Errorf("checking platform policies: %w", err))
+}
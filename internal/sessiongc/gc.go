@@ -1,6 +1,6 @@
 // Package sessiongc provides background garbage collection for expired agent sessions.
 // It deletes the session's Kubernetes namespace (cascading to all resources within),
-// cleans up source tarballs, and removes the session from the store.
+// cleans up source tarballs and the tool call history, and removes the session from the store.
 package sessiongc
 
 import (
@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/dlapiduz/iaf/internal/audit"
 	"github.com/dlapiduz/iaf/internal/auth"
 	"github.com/dlapiduz/iaf/internal/sourcestore"
 	corev1 "k8s.io/api/core/v1"
@@ -21,15 +22,18 @@ type Cleaner struct {
 	client   client.Client
 	store    *sourcestore.Store
 	sessions *auth.SessionStore
+	history  *audit.Log
 	logger   *slog.Logger
 }
 
-// New creates a new Cleaner.
-func New(c client.Client, store *sourcestore.Store, sessions *auth.SessionStore, logger *slog.Logger) *Cleaner {
+// New creates a new Cleaner. history may be nil when tool calls are not
+// recorded.
+func New(c client.Client, store *sourcestore.Store, sessions *auth.SessionStore, history *audit.Log, logger *slog.Logger) *Cleaner {
 	return &Cleaner{
 		client:   c,
 		store:    store,
 		sessions: sessions,
+		history:  history,
 		logger:   logger,
 	}
 }
@@ -62,6 +66,14 @@ func (cl *Cleaner) CleanupSession(ctx context.Context, sessionID, namespace stri
 		)
 	}
 
+	// Remove the tool call history of the session.
+	if err := cl.history.Delete(sessionID); err != nil {
+		cl.logger.Error("failed to delete session history",
+			"session_id", sessionID,
+			"error", err,
+		)
+	}
+
 	// Remove the session from the store.
 	if err := cl.sessions.Delete(sessionID); err != nil {
 		cl.logger.Error("failed to delete session",
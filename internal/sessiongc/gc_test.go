@@ -8,6 +8,7 @@ import (
 	"time"
 
 	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	"github.com/dlapiduz/iaf/internal/audit"
 	"github.com/dlapiduz/iaf/internal/auth"
 	"github.com/dlapiduz/iaf/internal/sessiongc"
 	"github.com/dlapiduz/iaf/internal/sourcestore"
@@ -36,7 +37,7 @@ func setupGCTest(t *testing.T) (*sessiongc.Cleaner, *auth.SessionStore, *sources
 		t.Fatal(err)
 	}
 
-	cleaner := sessiongc.New(k8sClient, store, sessions, slog.Default())
+	cleaner := sessiongc.New(k8sClient, store, sessions, nil, slog.Default())
 	return cleaner, sessions, store, k8sClient
 }
 
@@ -183,3 +184,22 @@ func TestStart_CancelsOnContextDone(t *testing.T) {
 		t.Error("Start should return when context is cancelled")
 	}
 }
+
+func TestCleanupSession_DeletesHistory(t *testing.T) {
+	_, sessions, store, k8sClient := setupGCTest(t)
+	history, err := audit.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleaner := sessiongc.New(k8sClient, store, sessions, history, slog.Default())
+	sess, _ := sessions.Register("agent", 0)
+	if err := history.Record(sess.ID, audit.Entry{Tool: "list_apps", Outcome: audit.OutcomeOK}); err != nil {
+		t.Fatal(err)
+	}
+
+	cleaner.CleanupSession(context.Background(), sess.ID, sess.Namespace)
+
+	if _, total, _ := history.List(sess.ID, audit.Query{}); total != 0 {
+		t.Error("history should be removed after CleanupSession")
+	}
+}
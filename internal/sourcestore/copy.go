@@ -0,0 +1,131 @@
+package sourcestore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TemplatesNamespace is the store directory holding the templates operators
+// publish for every session to start from, each at
+// <dir>/_templates/<name>/source.tar.gz. Namespace names cannot contain an
+// underscore, so it never clashes with a session namespace.
+const TemplatesNamespace = "_templates"
+
+var (
+	// ErrInvalidBlobURL is returned for a URL the store did not issue.
+	ErrInvalidBlobURL = errors.New("not a source blob URL issued by this platform")
+	// ErrBlobChanged is returned when the source a blob URL points at was
+	// replaced after the URL was issued.
+	ErrBlobChanged = errors.New("source has changed since the blob URL was issued")
+)
+
+// BlobRef identifies stored source: an app's tarball, or a template's when
+// Namespace is TemplatesNamespace.
+type BlobRef struct {
+	Namespace string
+	App       string
+	// SHA256 is the digest the tarball must still have; empty accepts the
+	// current tarball.
+	SHA256 string
+}
+
+// Template is a source template published by the operator.
+type Template struct {
+	Name string
+	Blob Blob
+}
+
+// ResolveBlobURL returns the stored source a blob URL issued by the store
+// points at. The signature is checked but not the expiry: the URL only
+// names the source, and the caller decides whether the namespace it is in
+// may be read.
+func (s *Store) ResolveBlobURL(raw string) (BlobRef, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return BlobRef{}, ErrInvalidBlobURL
+	}
+	q := u.Query()
+	u.RawQuery, u.Fragment = "", ""
+	path, ok := strings.CutPrefix(u.String(), s.baseURL+"/sources/")
+	if !ok {
+		return BlobRef{}, ErrInvalidBlobURL
+	}
+	parts := strings.Split(path, "/")
+	if len(parts) != 3 || parts[2] != "source.tar.gz" || !validSegment(parts[0]) || !validSegment(parts[1]) {
+		return BlobRef{}, ErrInvalidBlobURL
+	}
+	ref := BlobRef{Namespace: parts[0], App: parts[1], SHA256: q.Get("sha256")}
+	if ref.SHA256 == "" || !hmac.Equal([]byte(q.Get("sig")), []byte(s.sign(ref.Namespace, ref.App, ref.SHA256, q.Get("expires")))) {
+		return BlobRef{}, ErrInvalidBlobURL
+	}
+	return ref, nil
+}
+
+// CopySource stores the source from points at as an app's source, and
+// returns its new blob. It fails with an error wrapping fs.ErrNotExist when
+// there is no such source, and with ErrBlobChanged when from.SHA256 is set
+// and the source no longer has it.
+func (s *Store) CopySource(from BlobRef, namespace, appName string) (Blob, error) {
+	if from.Namespace == namespace && from.App == appName {
+		return Blob{}, errors.New("cannot copy source onto itself")
+	}
+	f, err := os.Open(filepath.Join(s.dir, from.Namespace, from.App, "source.tar.gz"))
+	if err != nil {
+		return Blob{}, fmt.Errorf("opening source: %w", err)
+	}
+	defer f.Close()
+	if from.SHA256 != "" {
+		hash := sha256.New()
+		if _, err := io.Copy(hash, f); err != nil {
+			return Blob{}, fmt.Errorf("reading source: %w", err)
+		}
+		if hex.EncodeToString(hash.Sum(nil)) != from.SHA256 {
+			return Blob{}, ErrBlobChanged
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return Blob{}, fmt.Errorf("reading source: %w", err)
+		}
+	}
+	return s.StoreTarball(namespace, appName, f)
+}
+
+// Templates returns the published templates, by name, with blob URLs to
+// deploy them from.
+func (s *Store) Templates() ([]Template, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, TemplatesNamespace))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing templates: %w", err)
+	}
+	var templates []Template
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		f, err := os.Open(filepath.Join(s.dir, TemplatesNamespace, e.Name(), "source.tar.gz"))
+		if err != nil {
+			continue
+		}
+		hash := sha256.New()
+		_, err = io.Copy(hash, f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading template %s: %w", e.Name(), err)
+		}
+		templates = append(templates, Template{
+			Name: e.Name(),
+			Blob: s.blob(TemplatesNamespace, e.Name(), hex.EncodeToString(hash.Sum(nil))),
+		})
+	}
+	return templates, nil
+}
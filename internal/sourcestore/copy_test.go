@@ -0,0 +1,98 @@
+package sourcestore
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestCopySource(t *testing.T) {
+	store, err := New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob, err := store.StoreFiles("ns1", "web", map[string]string{"main.go": "package main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := store.ResolveBlobURL(blob.URL + "&rev=abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref != (BlobRef{Namespace: "ns1", App: "web", SHA256: blob.SHA256}) {
+		t.Fatalf("unexpected ref %+v", ref)
+	}
+	for _, raw := range []string{
+		"https://evil.example.com/sources/ns1/web/source.tar.gz?" + strings.SplitN(blob.URL, "?", 2)[1],
+		strings.Replace(blob.URL, "/ns1/", "/ns2/", 1),
+		strings.Replace(blob.URL, "sig=", "sig=0", 1),
+		"http://localhost:8080/sources/ns1/web/source.tar.gz",
+	} {
+		if _, err := store.ResolveBlobURL(raw); !errors.Is(err, ErrInvalidBlobURL) {
+			t.Errorf("%s: expected ErrInvalidBlobURL, got %v", raw, err)
+		}
+	}
+
+	copied, err := store.CopySource(ref, "ns1", "web-copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied.SHA256 != blob.SHA256 || !strings.Contains(copied.URL, "/ns1/web-copy/") {
+		t.Errorf("unexpected copy %+v", copied)
+	}
+	files, err := store.ReadFiles("ns1", "web-copy")
+	if err != nil || files["main.go"] != "package main" {
+		t.Errorf("copied files %v, err %v", files, err)
+	}
+
+	// A URL issued before the source was replaced no longer resolves to
+	// copyable source.
+	if _, err := store.StoreFiles("ns1", "web", map[string]string{"main.go": "package changed"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CopySource(ref, "ns1", "web-old"); !errors.Is(err, ErrBlobChanged) {
+		t.Errorf("expected ErrBlobChanged, got %v", err)
+	}
+	if _, err := store.CopySource(BlobRef{Namespace: "ns1", App: "missing"}, "ns1", "x"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+	if _, err := store.CopySource(BlobRef{Namespace: "ns1", App: "web"}, "ns1", "web"); err == nil {
+		t.Error("expected a copy onto itself to be rejected")
+	}
+	if !store.HasSource("ns1", "web") || store.HasSource("ns1", "x") {
+		t.Error("HasSource does not match the stored source")
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	store, err := New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	templates, err := store.Templates()
+	if err != nil || len(templates) != 0 {
+		t.Fatalf("expected no templates, got %v (err %v)", templates, err)
+	}
+	for _, name := range []string{"node-api", "go-api"} {
+		if _, err := store.StoreFiles(TemplatesNamespace, name, map[string]string{"README.md": name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	templates, err = store.Templates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != 2 || templates[0].Name != "go-api" || templates[1].Name != "node-api" {
+		t.Fatalf("unexpected templates %+v", templates)
+	}
+	ref, err := store.ResolveBlobURL(templates[0].Blob.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref.Namespace != TemplatesNamespace || ref.App != "go-api" {
+		t.Errorf("unexpected ref %+v", ref)
+	}
+}
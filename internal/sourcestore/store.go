@@ -4,40 +4,129 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
+	"maps"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
+// AnnotationSourceSHA256 records on an Application the SHA256 of the
+// source tarball its blob URL points at.
+const AnnotationSourceSHA256 = "iaf.io/source-sha256"
+
+// signingKeyFile holds the generated signing key in the store directory
+// when none is configured, so every server sharing the directory signs
+// alike and URLs survive restarts.
+const signingKeyFile = ".signing-key"
+
 // Store manages uploaded source code as tarballs and serves them over HTTP.
+// Blob URLs are signed: they carry the tarball's SHA256 and an HMAC over it
+// and the app, so they cannot be guessed for another namespace's source.
 type Store struct {
 	dir     string // directory for storing tarballs
 	baseURL string // base URL for serving tarballs
 	logger  *slog.Logger
+	key     []byte        // signs blob URLs
+	ttl     time.Duration // lifetime of blob URLs, zero for no expiry
+	maxSize int64         // largest source accepted, zero for no limit
+	maxOpen int           // unfinished uploads allowed per namespace, zero for no limit
+
+	mu          sync.Mutex             // guards uploadLocks and serializes upload expiry
+	uploadLocks map[string]*uploadLock // locks of uploads in use, by namespace/ID
 }
 
-// New creates a new source store.
+// Blob is a stored source tarball.
+type Blob struct {
+	// URL is the signed URL kpack fetches the tarball from.
+	URL string
+	// SHA256 is the hex digest of the tarball.
+	SHA256 string
+}
+
+// New creates a new source store. It signs blob URLs with the key in the
+// store directory, generating one on first use; SetSigningKey replaces it.
 func New(dir, baseURL string, logger *slog.Logger) (*Store, error) {
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return nil, fmt.Errorf("creating source store directory: %w", err)
 	}
+	key, err := loadSigningKey(filepath.Join(dir, signingKeyFile))
+	if err != nil {
+		return nil, err
+	}
 	return &Store{
 		dir:     dir,
 		baseURL: strings.TrimRight(baseURL, "/"),
 		logger:  logger,
+		key:     key,
+		maxSize: DefaultMaxUploadSize,
+		maxOpen: DefaultMaxOpenUploads,
 	}, nil
 }
 
+// loadSigningKey reads the key at path, creating it if it does not exist.
+func loadSigningKey(path string) ([]byte, error) {
+	key, err := os.ReadFile(path)
+	if err == nil && len(key) > 0 {
+		return key, nil
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading source signing key: %w", err)
+	}
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generating source signing key: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		// Another server sharing the directory created it first.
+		return os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating source signing key: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(key); err != nil {
+		return nil, fmt.Errorf("writing source signing key: %w", err)
+	}
+	return key, nil
+}
+
+// SetSigningKey replaces the generated key that signs blob URLs. Every
+// server that stores or serves source must use the same key.
+func (s *Store) SetSigningKey(key []byte) {
+	if len(key) > 0 {
+		s.key = key
+	}
+}
+
+// SetURLTTL makes blob URLs expire ttl after they are issued. A build that
+// starts later, such as a rebuild for a new stack, fails until the source
+// is pushed again, so zero, the default, means no expiry.
+func (s *Store) SetURLTTL(ttl time.Duration) {
+	s.ttl = ttl
+}
+
 // StoreFiles takes a map of file paths to contents and stores them as a gzipped tarball.
-// Returns the blob URL that kpack can fetch.
-func (s *Store) StoreFiles(namespace, appName string, files map[string]string) (string, error) {
+// Returns the blob kpack can fetch.
+func (s *Store) StoreFiles(namespace, appName string, files map[string]string) (Blob, error) {
 	appDir := filepath.Join(s.dir, namespace, appName)
 	if err := os.MkdirAll(appDir, 0o755); err != nil {
-		return "", fmt.Errorf("creating app source directory: %w", err)
+		return Blob{}, fmt.Errorf("creating app source directory: %w", err)
 	}
 
 	tarballPath := filepath.Join(appDir, "source.tar.gz")
@@ -48,15 +137,10 @@ func (s *Store) StoreFiles(namespace, appName string, files map[string]string) (
 
 	for path, content := range files {
 		// Sanitize path to prevent directory traversal.
-		// Use join-and-confirm: clean the joined path and verify it stays within appDir.
-		cleanPath := filepath.Clean(path)
-		if filepath.IsAbs(cleanPath) {
-			return "", fmt.Errorf("invalid file path %q: must not be an absolute path", path)
-		}
-		fullPath := filepath.Join(appDir, cleanPath)
-		if !strings.HasPrefix(filepath.Clean(fullPath)+string(filepath.Separator), filepath.Clean(appDir)+string(filepath.Separator)) {
-			return "", fmt.Errorf("invalid file path %q: must not escape upload directory", path)
+		if err := validFilePath(path); err != nil {
+			return Blob{}, err
 		}
+		cleanPath := filepath.Clean(path)
 
 		header := &tar.Header{
 			Name: cleanPath,
@@ -64,57 +148,327 @@ func (s *Store) StoreFiles(namespace, appName string, files map[string]string) (
 			Size: int64(len(content)),
 		}
 		if err := tarWriter.WriteHeader(header); err != nil {
-			return "", fmt.Errorf("writing tar header for %s: %w", path, err)
+			return Blob{}, fmt.Errorf("writing tar header for %s: %w", path, err)
 		}
 		if _, err := tarWriter.Write([]byte(content)); err != nil {
-			return "", fmt.Errorf("writing tar content for %s: %w", path, err)
+			return Blob{}, fmt.Errorf("writing tar content for %s: %w", path, err)
 		}
 	}
 
 	if err := tarWriter.Close(); err != nil {
-		return "", fmt.Errorf("closing tar writer: %w", err)
+		return Blob{}, fmt.Errorf("closing tar writer: %w", err)
 	}
 	if err := gzWriter.Close(); err != nil {
-		return "", fmt.Errorf("closing gzip writer: %w", err)
+		return Blob{}, fmt.Errorf("closing gzip writer: %w", err)
+	}
+	if s.maxSize > 0 && int64(buf.Len()) > s.maxSize {
+		return Blob{}, ErrTooLarge
 	}
 
 	if err := os.WriteFile(tarballPath, buf.Bytes(), 0o644); err != nil {
-		return "", fmt.Errorf("writing tarball: %w", err)
+		return Blob{}, fmt.Errorf("writing tarball: %w", err)
 	}
 
-	blobURL := fmt.Sprintf("%s/sources/%s/%s/source.tar.gz", s.baseURL, namespace, appName)
-	s.logger.Info("stored source code", "namespace", namespace, "app", appName, "url", blobURL, "files", len(files))
-	return blobURL, nil
+	sum := sha256.Sum256(buf.Bytes())
+	blob := s.blob(namespace, appName, hex.EncodeToString(sum[:]))
+	s.logger.Info("stored source code", "namespace", namespace, "app", appName, "sha256", blob.SHA256, "files", len(files))
+	return blob, nil
 }
 
 // StoreTarball stores a raw tarball for an application.
-// Returns the blob URL.
-func (s *Store) StoreTarball(namespace, appName string, r io.Reader) (string, error) {
+// Returns the blob.
+func (s *Store) StoreTarball(namespace, appName string, r io.Reader) (Blob, error) {
 	appDir := filepath.Join(s.dir, namespace, appName)
 	if err := os.MkdirAll(appDir, 0o755); err != nil {
-		return "", fmt.Errorf("creating app source directory: %w", err)
+		return Blob{}, fmt.Errorf("creating app source directory: %w", err)
 	}
 
-	tarballPath := filepath.Join(appDir, "source.tar.gz")
-	f, err := os.Create(tarballPath)
+	// Write next to the current tarball and swap it in once complete, so a
+	// failed upload leaves the current source in place.
+	f, err := os.CreateTemp(appDir, "source-*.tmp")
 	if err != nil {
-		return "", fmt.Errorf("creating tarball file: %w", err)
+		return Blob{}, fmt.Errorf("creating tarball file: %w", err)
 	}
+	defer os.Remove(f.Name())
 	defer f.Close()
 
-	if _, err := io.Copy(f, r); err != nil {
-		return "", fmt.Errorf("writing tarball: %w", err)
+	if s.maxSize > 0 {
+		r = io.LimitReader(r, s.maxSize+1)
+	}
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, hash), r)
+	if err != nil {
+		return Blob{}, fmt.Errorf("writing tarball: %w", err)
+	}
+	if s.maxSize > 0 && n > s.maxSize {
+		return Blob{}, ErrTooLarge
+	}
+	if err := f.Chmod(0o644); err != nil {
+		return Blob{}, fmt.Errorf("writing tarball: %w", err)
+	}
+	if err := os.Rename(f.Name(), filepath.Join(appDir, "source.tar.gz")); err != nil {
+		return Blob{}, fmt.Errorf("writing tarball: %w", err)
+	}
+
+	blob := s.blob(namespace, appName, hex.EncodeToString(hash.Sum(nil)))
+	s.logger.Info("stored source tarball", "namespace", namespace, "app", appName, "sha256", blob.SHA256)
+	return blob, nil
+}
+
+// blob returns the signed blob of an app's tarball with the given digest.
+func (s *Store) blob(namespace, appName, digest string) Blob {
+	var expires string
+	if s.ttl > 0 {
+		expires = strconv.FormatInt(time.Now().Add(s.ttl).Unix(), 10)
+	}
+	q := url.Values{"sha256": {digest}, "sig": {s.sign(namespace, appName, digest, expires)}}
+	if expires != "" {
+		q.Set("expires", expires)
+	}
+	return Blob{
+		URL:    fmt.Sprintf("%s/sources/%s/%s/source.tar.gz?%s", s.baseURL, namespace, appName, q.Encode()),
+		SHA256: digest,
+	}
+}
+
+// ResignLegacyURL returns a signed blob for raw when raw is an unsigned
+// URL the store issued for an app in namespace before blob URLs were
+// signed, so the app keeps building from its current source. ok is false
+// for any other URL.
+func (s *Store) ResignLegacyURL(namespace, raw string) (blob Blob, ok bool, err error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Query().Has("sig") {
+		return Blob{}, false, nil
+	}
+	u.RawQuery, u.Fragment = "", ""
+	path, found := strings.CutPrefix(u.String(), s.baseURL+"/sources/")
+	if !found {
+		return Blob{}, false, nil
+	}
+	parts := strings.Split(path, "/")
+	if len(parts) != 3 || parts[0] != namespace || parts[2] != "source.tar.gz" || !validSegment(parts[1]) {
+		return Blob{}, false, nil
+	}
+	f, err := os.Open(filepath.Join(s.dir, namespace, parts[1], "source.tar.gz"))
+	if err != nil {
+		return Blob{}, false, fmt.Errorf("opening source: %w", err)
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return Blob{}, false, fmt.Errorf("reading source: %w", err)
+	}
+	return s.blob(namespace, parts[1], hex.EncodeToString(hash.Sum(nil))), true, nil
+}
+
+func (s *Store) sign(namespace, appName, digest, expires string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(namespace + "/" + appName + "\n" + digest + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// MergeBinaryFiles returns files together with binaryFiles, a map of path
+// to base64-encoded content, decoded. File maps sent as JSON can only hold
+// text, so images, fonts and other binary files are sent in binaryFiles.
+// A path may appear in only one of the maps.
+func MergeBinaryFiles(files, binaryFiles map[string]string) (map[string]string, error) {
+	if len(binaryFiles) == 0 {
+		return files, nil
+	}
+	merged := make(map[string]string, len(files)+len(binaryFiles))
+	maps.Copy(merged, files)
+	for path, encoded := range binaryFiles {
+		if _, ok := files[path]; ok {
+			return nil, fmt.Errorf("file %q is in both the text and the binary files", path)
+		}
+		content, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("binary file %q is not valid base64: %w", path, err)
+		}
+		merged[path] = string(content)
+	}
+	return merged, nil
+}
+
+// validFilePath checks that a file path stays within the upload root once
+// cleaned: it must be relative and must not climb out with "..".
+func validFilePath(path string) error {
+	cleanPath := filepath.Clean(path)
+	if filepath.IsAbs(cleanPath) {
+		return fmt.Errorf("invalid file path %q: must not be an absolute path", path)
+	}
+	if cleanPath == ".." || strings.HasPrefix(cleanPath, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("invalid file path %q: must not escape upload directory", path)
+	}
+	return nil
+}
+
+// ListTarball returns the paths of the regular files in a gzipped tarball.
+func ListTarball(r io.Reader) ([]string, error) {
+	gzReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("source must be a gzipped tarball: %w", err)
 	}
+	defer gzReader.Close()
+	tarReader := tar.NewReader(gzReader)
+	var files []string
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading tarball: %w", err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			files = append(files, strings.TrimPrefix(header.Name, "./"))
+		}
+	}
+}
+
+// Limits on the source ReadFiles returns.
+const (
+	maxReadFileSize  = 1 << 20
+	maxReadTotalSize = 20 << 20
+)
 
-	blobURL := fmt.Sprintf("%s/sources/%s/%s/source.tar.gz", s.baseURL, namespace, appName)
-	s.logger.Info("stored source tarball", "namespace", namespace, "app", appName, "url", blobURL)
-	return blobURL, nil
+// ReadFiles returns the regular files of an application's stored source,
+// by path. Files over 1 MB, typically assets, are left out, and reading
+// stops once 20 MB have been read. It fails with an error wrapping
+// fs.ErrNotExist when the application has no stored source.
+func (s *Store) ReadFiles(namespace, appName string) (map[string]string, error) {
+	f, err := os.Open(filepath.Join(s.dir, namespace, appName, "source.tar.gz"))
+	if err != nil {
+		return nil, fmt.Errorf("opening source: %w", err)
+	}
+	defer f.Close()
+	gzReader, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("source must be a gzipped tarball: %w", err)
+	}
+	defer gzReader.Close()
+	tarReader := tar.NewReader(gzReader)
+	files := map[string]string{}
+	var total int64
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading tarball: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || header.Size > maxReadFileSize {
+			continue
+		}
+		if total += header.Size; total > maxReadTotalSize {
+			return files, nil
+		}
+		content, err := io.ReadAll(io.LimitReader(tarReader, maxReadFileSize))
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", header.Name, err)
+		}
+		files[strings.TrimPrefix(header.Name, "./")] = string(content)
+	}
 }
 
-// Handler returns an HTTP handler that serves source tarballs.
+// Handler returns an HTTP handler that serves source tarballs at the blob
+// URLs the store issued. Requests without a valid, unexpired signature are
+// refused, and a tarball is only served while its content still has the
+// SHA256 the URL carries. Every request is logged.
 // The caller is responsible for stripping the URL prefix before calling this handler.
 func (s *Store) Handler() http.Handler {
-	return http.FileServer(http.Dir(s.dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		reason := s.serve(rec, r)
+		s.logger.Info("source fetch", "path", r.URL.Path, "remote", r.RemoteAddr, "status", rec.status, "reason", reason)
+	})
+}
+
+// serve handles a source request. It returns why it was refused, or an
+// empty string when the tarball was served.
+func (s *Store) serve(w http.ResponseWriter, r *http.Request) string {
+	fail := func(status int, msg string) string {
+		http.Error(w, msg, status)
+		return msg
+	}
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) != 3 || parts[2] != "source.tar.gz" || !validSegment(parts[0]) || !validSegment(parts[1]) {
+		return fail(http.StatusNotFound, "not found")
+	}
+	namespace, appName := parts[0], parts[1]
+	q := r.URL.Query()
+	digest, expires := q.Get("sha256"), q.Get("expires")
+	if !hmac.Equal([]byte(q.Get("sig")), []byte(s.sign(namespace, appName, digest, expires))) {
+		return fail(http.StatusForbidden, "invalid signature")
+	}
+	if expires != "" {
+		if t, err := strconv.ParseInt(expires, 10, 64); err != nil || time.Now().Unix() > t {
+			return fail(http.StatusForbidden, "URL expired")
+		}
+	}
+
+	f, err := os.Open(filepath.Join(s.dir, namespace, appName, "source.tar.gz"))
+	if err != nil {
+		return fail(http.StatusNotFound, "not found")
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return fail(http.StatusInternalServerError, "reading source")
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != digest {
+		// The source was replaced after the URL was issued, or altered.
+		return fail(http.StatusConflict, "source does not match its checksum")
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fail(http.StatusInternalServerError, "reading source")
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return fail(http.StatusInternalServerError, "reading source")
+	}
+	w.Header().Set("Content-Type", "application/gzip")
+	http.ServeContent(w, r, "source.tar.gz", info.ModTime(), f)
+	return ""
+}
+
+// statusRecorder remembers the status written through it for logging.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// validSegment reports whether a namespace or app name taken from a request
+// path names a directory inside the store.
+func validSegment(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.ContainsAny(s, `\`)
+}
+
+// CheckWritable reports whether source can be stored, by writing and
+// removing a file in the store directory.
+func (s *Store) CheckWritable() error {
+	f, err := os.CreateTemp(s.dir, ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("source store is not writable: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString("ok"); err != nil {
+		f.Close()
+		return fmt.Errorf("source store is not writable: %w", err)
+	}
+	return f.Close()
+}
+
+// HasSource reports whether an application has stored source.
+func (s *Store) HasSource(namespace, appName string) bool {
+	_, err := os.Stat(filepath.Join(s.dir, namespace, appName, "source.tar.gz"))
+	return err == nil
 }
 
 // Delete removes stored source for an application.
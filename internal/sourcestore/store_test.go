@@ -1,10 +1,19 @@
 package sourcestore
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestStoreFiles_AndServe(t *testing.T) {
@@ -19,20 +28,23 @@ func TestStoreFiles_AndServe(t *testing.T) {
 		"go.mod":  "module test\ngo 1.22\n",
 	}
 
-	blobURL, err := store.StoreFiles("test-ns", "myapp", files)
+	blob, err := store.StoreFiles("test-ns", "myapp", files)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	if blobURL != "http://localhost:8080/sources/test-ns/myapp/source.tar.gz" {
-		t.Errorf("unexpected blob URL: %s", blobURL)
+	if !strings.HasPrefix(blob.URL, "http://localhost:8080/sources/test-ns/myapp/source.tar.gz?") {
+		t.Errorf("unexpected blob URL: %s", blob.URL)
+	}
+	if len(blob.SHA256) != 64 || !strings.Contains(blob.URL, "sha256="+blob.SHA256) {
+		t.Errorf("blob URL %s does not carry the checksum %q", blob.URL, blob.SHA256)
 	}
 
 	// Simulate the HTTP serving chain as mounted in the apiserver:
 	// e.GET("/sources/*", echo.WrapHandler(http.StripPrefix("/sources/", store.Handler())))
 	handler := http.StripPrefix("/sources/", store.Handler())
 
-	req := httptest.NewRequest("GET", "/sources/test-ns/myapp/source.tar.gz", nil)
+	req := httptest.NewRequest("GET", blob.URL, nil)
 	w := httptest.NewRecorder()
 	handler.ServeHTTP(w, req)
 
@@ -68,7 +80,7 @@ func TestStoreFiles_Delete(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	_, err = store.StoreFiles("test-ns", "myapp", map[string]string{"f.txt": "hello"})
+	blob, err := store.StoreFiles("test-ns", "myapp", map[string]string{"f.txt": "hello"})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -78,7 +90,7 @@ func TestStoreFiles_Delete(t *testing.T) {
 	}
 
 	handler := http.StripPrefix("/sources/", store.Handler())
-	req := httptest.NewRequest("GET", "/sources/test-ns/myapp/source.tar.gz", nil)
+	req := httptest.NewRequest("GET", blob.URL, nil)
 	w := httptest.NewRecorder()
 	handler.ServeHTTP(w, req)
 
@@ -87,6 +99,79 @@ func TestStoreFiles_Delete(t *testing.T) {
 	}
 }
 
+func TestHandler_Signatures(t *testing.T) {
+	dir := t.TempDir()
+	store, err := New(dir, "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob, err := store.StoreFiles("ns1", "myapp", map[string]string{"main.go": "package main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := store.StoreFiles("ns2", "myapp", map[string]string{"main.go": "package other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := http.StripPrefix("/sources/", store.Handler())
+	get := func(target string) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		return w.Code
+	}
+
+	if code := get(blob.URL + "&rev=abc"); code != http.StatusOK {
+		t.Errorf("signed URL with extra parameters: expected 200, got %d", code)
+	}
+	for name, target := range map[string]string{
+		"unsigned":            "/sources/ns1/myapp/source.tar.gz",
+		"another namespace":   strings.Replace(blob.URL, "/ns1/", "/ns2/", 1),
+		"tampered checksum":   strings.Replace(blob.URL, blob.SHA256, other.SHA256, 1),
+		"signing key file":    "/sources/" + signingKeyFile,
+		"other store content": "/sources/sessions.json",
+	} {
+		if code := get(target); code != http.StatusForbidden && code != http.StatusNotFound {
+			t.Errorf("%s: expected the request refused, got %d", name, code)
+		}
+	}
+
+	// A URL issued for earlier content is not served once it changes.
+	if _, err := store.StoreFiles("ns1", "myapp", map[string]string{"main.go": "package changed"}); err != nil {
+		t.Fatal(err)
+	}
+	if code := get(blob.URL); code != http.StatusConflict {
+		t.Errorf("replaced source: expected 409, got %d", code)
+	}
+
+	// Expired URLs are refused.
+	store.SetURLTTL(time.Minute)
+	current, err := store.StoreFiles("ns1", "myapp", map[string]string{"main.go": "package main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := get(current.URL); code != http.StatusOK {
+		t.Errorf("unexpired URL: expected 200, got %d", code)
+	}
+	past := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	expired := fmt.Sprintf("/sources/ns1/myapp/source.tar.gz?sha256=%s&expires=%s&sig=%s",
+		current.SHA256, past, store.sign("ns1", "myapp", current.SHA256, past))
+	if code := get(expired); code != http.StatusForbidden {
+		t.Errorf("expired URL: expected 403, got %d", code)
+	}
+
+	// The generated key is kept, so URLs survive a restart.
+	restarted, err := New(dir, "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(restarted.key) != string(store.key) {
+		t.Error("expected the signing key to be reused")
+	}
+	if info, err := os.Stat(filepath.Join(dir, signingKeyFile)); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("signing key file: %v, %v", info, err)
+	}
+}
+
 func TestStoreFiles_PathTraversal(t *testing.T) {
 	dir := t.TempDir()
 	store, err := New(dir, "http://localhost:8080", slog.Default())
@@ -101,3 +186,90 @@ func TestStoreFiles_PathTraversal(t *testing.T) {
 		t.Error("expected error for path traversal")
 	}
 }
+
+func TestListTarball(t *testing.T) {
+	store, err := New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.StoreFiles("ns1", "myapp", map[string]string{"main.go": "package main", "web/index.html": "<p>hi</p>"}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(filepath.Join(store.dir, "ns1", "myapp", "source.tar.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	files, err := ListTarball(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(files)
+	if !slices.Equal(files, []string{"main.go", "web/index.html"}) {
+		t.Errorf("unexpected files %v", files)
+	}
+
+	if _, err := ListTarball(strings.NewReader("not a tarball")); err == nil {
+		t.Error("expected an error for data that is not a gzipped tarball")
+	}
+}
+
+func TestReadFiles(t *testing.T) {
+	store, err := New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.ReadFiles("test-ns", "myapp"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist without source, got %v", err)
+	}
+
+	files := map[string]string{
+		"main.go":        "package main\n",
+		"static/big.bin": strings.Repeat("x", maxReadFileSize+1),
+	}
+	if _, err := store.StoreFiles("test-ns", "myapp", files); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.ReadFiles("test-ns", "myapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got["main.go"] != "package main\n" {
+		t.Errorf("expected only main.go, got %d files", len(got))
+	}
+}
+
+func TestMergeBinaryFiles(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n\x00\xff"
+	files, err := MergeBinaryFiles(map[string]string{"index.html": "<img src=logo.png>"}, map[string]string{"logo.png": "iVBORw0KGgoA/w=="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files["logo.png"] != png {
+		t.Fatalf("unexpected files %q", files)
+	}
+
+	// Binary content survives storing.
+	store, err := New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.StoreFiles("ns1", "myapp", files); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := store.ReadFiles("ns1", "myapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored["logo.png"] != png {
+		t.Errorf("stored binary file %q, want %q", stored["logo.png"], png)
+	}
+
+	if _, err := MergeBinaryFiles(nil, map[string]string{"logo.png": "not base64!"}); err == nil {
+		t.Error("expected an error for invalid base64")
+	}
+	if _, err := MergeBinaryFiles(map[string]string{"a": "x"}, map[string]string{"a": "eA=="}); err == nil {
+		t.Error("expected an error for a path in both maps")
+	}
+}
@@ -0,0 +1,347 @@
+package sourcestore
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors returned for chunked uploads.
+var (
+	// ErrUploadNotFound is returned for an upload that does not exist, has
+	// expired or belongs to another app.
+	ErrUploadNotFound = errors.New("upload not found")
+	// ErrOffsetMismatch is returned when a chunk does not start where the
+	// upload ends, typically after a lost response; resume from the
+	// upload's current size.
+	ErrOffsetMismatch = errors.New("chunk offset does not match the upload size")
+	// ErrTooLarge is returned when source would exceed the maximum size.
+	ErrTooLarge = errors.New("source exceeds the maximum upload size")
+	// ErrTooManyUploads is returned when a namespace already has the
+	// maximum number of unfinished uploads.
+	ErrTooManyUploads = errors.New("too many unfinished uploads")
+)
+
+// Limits a new Store starts with.
+const (
+	DefaultMaxUploadSize  = 512 << 20
+	DefaultMaxOpenUploads = 10
+)
+
+// UploadTTL is how long an upload may stay unfinished before it is removed.
+const UploadTTL = 24 * time.Hour
+
+// uploadsDir is the directory, in a namespace's store directory, holding
+// its unfinished uploads. App names cannot start with a dot, so it never
+// clashes with an app's directory.
+const uploadsDir = ".uploads"
+
+// Files in an upload's directory.
+const (
+	uploadMetaFile  = "upload.json"
+	uploadDataFile  = "source.tar.gz" // tarball chunks, appended in order
+	uploadFilesFile = "files.jsonl"   // file chunks, one JSON object per file
+)
+
+// Upload is an unfinished source upload, sent in chunks and completed
+// once all have arrived. Chunks are either parts of a gzipped tarball or
+// sets of files; an upload holds one kind or the other.
+type Upload struct {
+	ID        string    `json:"id"`
+	App       string    `json:"app"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Size is the number of bytes received so far, the offset of the next
+	// tarball chunk.
+	Size int64 `json:"-"`
+}
+
+// uploadFile is one line of an upload's files.jsonl. The content is kept
+// as bytes, which JSON encodes as base64, so binary files survive.
+type uploadFile struct {
+	Path    string `json:"path"`
+	Content []byte `json:"content"`
+}
+
+// SetMaxUploadSize limits the size of a stored tarball, and of the files
+// of a chunked upload, to n bytes, DefaultMaxUploadSize unless set. Zero
+// means no limit.
+func (s *Store) SetMaxUploadSize(n int64) {
+	s.maxSize = n
+}
+
+// MaxUploadSize returns the limit set by SetMaxUploadSize.
+func (s *Store) MaxUploadSize() int64 {
+	return s.maxSize
+}
+
+// SetMaxOpenUploads limits how many unfinished uploads a namespace may
+// have, DefaultMaxOpenUploads unless set. Zero means no limit.
+func (s *Store) SetMaxOpenUploads(n int) {
+	s.maxOpen = n
+}
+
+// MaxOpenUploads returns the limit set by SetMaxOpenUploads.
+func (s *Store) MaxOpenUploads() int {
+	return s.maxOpen
+}
+
+// StartUpload begins a chunked upload of an app's source. It also removes
+// the namespace's uploads older than UploadTTL, and fails with
+// ErrTooManyUploads when the namespace has as many unfinished uploads as
+// SetMaxOpenUploads allows.
+func (s *Store) StartUpload(namespace, appName string) (Upload, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireUploads(namespace)
+	if s.maxOpen > 0 {
+		entries, err := os.ReadDir(filepath.Join(s.dir, namespace, uploadsDir))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return Upload{}, fmt.Errorf("reading uploads: %w", err)
+		}
+		if len(entries) >= s.maxOpen {
+			return Upload{}, ErrTooManyUploads
+		}
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return Upload{}, fmt.Errorf("generating upload ID: %w", err)
+	}
+	up := Upload{ID: hex.EncodeToString(b), App: appName, CreatedAt: time.Now().UTC()}
+	dir := s.uploadDir(namespace, up.ID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Upload{}, fmt.Errorf("creating upload directory: %w", err)
+	}
+	meta, _ := json.Marshal(up)
+	if err := os.WriteFile(filepath.Join(dir, uploadMetaFile), meta, 0o644); err != nil {
+		return Upload{}, fmt.Errorf("writing upload: %w", err)
+	}
+	return up, nil
+}
+
+// GetUpload returns an upload of an app's source.
+func (s *Store) GetUpload(namespace, appName, id string) (Upload, error) {
+	defer s.lockUpload(namespace, id)()
+	return s.upload(namespace, appName, id)
+}
+
+// AppendUpload appends a tarball chunk to an upload. The chunk must start
+// at offset, the upload's current size.
+func (s *Store) AppendUpload(namespace, appName, id string, offset int64, r io.Reader) (Upload, error) {
+	defer s.lockUpload(namespace, id)()
+	up, err := s.upload(namespace, appName, id)
+	if err != nil {
+		return Upload{}, err
+	}
+	if offset != up.Size {
+		return up, ErrOffsetMismatch
+	}
+	f, err := os.OpenFile(filepath.Join(s.uploadDir(namespace, id), uploadDataFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return Upload{}, fmt.Errorf("opening upload: %w", err)
+	}
+	defer f.Close()
+	if s.maxSize > 0 {
+		r = io.LimitReader(r, s.maxSize-up.Size+1)
+	}
+	n, err := io.Copy(f, r)
+	up.Size += n
+	if err == nil && s.maxSize > 0 && up.Size > s.maxSize {
+		err = ErrTooLarge
+	}
+	if err != nil {
+		// Drop the partial chunk so the client can resend it.
+		if terr := f.Truncate(offset); terr != nil {
+			return Upload{}, fmt.Errorf("discarding chunk: %w", terr)
+		}
+		up.Size = offset
+		if errors.Is(err, ErrTooLarge) {
+			return up, err
+		}
+		return up, fmt.Errorf("writing chunk: %w", err)
+	}
+	return up, nil
+}
+
+// AddUploadFiles adds files, a map of path to content, to an upload. A
+// file added again replaces the earlier content.
+func (s *Store) AddUploadFiles(namespace, appName, id string, files map[string]string) (Upload, error) {
+	defer s.lockUpload(namespace, id)()
+	up, err := s.upload(namespace, appName, id)
+	if err != nil {
+		return Upload{}, err
+	}
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	for path, content := range files {
+		if err := validFilePath(path); err != nil {
+			return up, err
+		}
+		if err := enc.Encode(uploadFile{Path: path, Content: []byte(content)}); err != nil {
+			return up, fmt.Errorf("encoding %s: %w", path, err)
+		}
+	}
+	if s.maxSize > 0 && up.Size+int64(buf.Len()) > s.maxSize {
+		return up, ErrTooLarge
+	}
+	f, err := os.OpenFile(filepath.Join(s.uploadDir(namespace, id), uploadFilesFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return Upload{}, fmt.Errorf("opening upload: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(buf.String()); err != nil {
+		return Upload{}, fmt.Errorf("writing files: %w", err)
+	}
+	up.Size += int64(buf.Len())
+	return up, nil
+}
+
+// OpenUpload opens the tarball an upload's chunks make up.
+func (s *Store) OpenUpload(namespace, appName, id string) (*os.File, error) {
+	defer s.lockUpload(namespace, id)()
+	if _, err := s.upload(namespace, appName, id); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filepath.Join(s.uploadDir(namespace, id), uploadDataFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("upload %s has no tarball chunks", id)
+	}
+	return f, err
+}
+
+// UploadFiles returns the files added to an upload, by path.
+func (s *Store) UploadFiles(namespace, appName, id string) (map[string]string, error) {
+	defer s.lockUpload(namespace, id)()
+	if _, err := s.upload(namespace, appName, id); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filepath.Join(s.uploadDir(namespace, id), uploadFilesFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening upload: %w", err)
+	}
+	defer f.Close()
+	files := map[string]string{}
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var file uploadFile
+		if err := dec.Decode(&file); err == io.EOF {
+			return files, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("reading upload: %w", err)
+		}
+		files[file.Path] = string(file.Content)
+	}
+}
+
+// DeleteUpload removes an upload.
+func (s *Store) DeleteUpload(namespace, appName, id string) error {
+	defer s.lockUpload(namespace, id)()
+	if _, err := s.upload(namespace, appName, id); err != nil {
+		return err
+	}
+	return os.RemoveAll(s.uploadDir(namespace, id))
+}
+
+// uploadLock serializes the changes to one upload.
+type uploadLock struct {
+	sync.Mutex
+	users int // callers holding or waiting for the lock; s.mu guards it
+}
+
+// lockUpload locks an upload and returns the function that unlocks it.
+// Chunks are written under this lock rather than s.mu, so a slow or
+// stalled chunk holds up only its own upload.
+func (s *Store) lockUpload(namespace, id string) func() {
+	key := namespace + "/" + id
+	s.mu.Lock()
+	l := s.uploadLocks[key]
+	if l == nil {
+		if s.uploadLocks == nil {
+			s.uploadLocks = map[string]*uploadLock{}
+		}
+		l = &uploadLock{}
+		s.uploadLocks[key] = l
+	}
+	l.users++
+	s.mu.Unlock()
+	l.Lock()
+	return func() {
+		l.Unlock()
+		s.mu.Lock()
+		if l.users--; l.users == 0 {
+			delete(s.uploadLocks, key)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// upload reads an upload's metadata and size. The upload's lock must be
+// held.
+func (s *Store) upload(namespace, appName, id string) (Upload, error) {
+	if len(id) != 32 || strings.Trim(id, "0123456789abcdef") != "" {
+		return Upload{}, ErrUploadNotFound
+	}
+	dir := s.uploadDir(namespace, id)
+	meta, err := os.ReadFile(filepath.Join(dir, uploadMetaFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Upload{}, ErrUploadNotFound
+	}
+	if err != nil {
+		return Upload{}, fmt.Errorf("reading upload: %w", err)
+	}
+	var up Upload
+	if err := json.Unmarshal(meta, &up); err != nil {
+		return Upload{}, fmt.Errorf("reading upload: %w", err)
+	}
+	if up.App != appName || time.Since(up.CreatedAt) > UploadTTL {
+		return Upload{}, ErrUploadNotFound
+	}
+	for _, name := range []string{uploadDataFile, uploadFilesFile} {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			up.Size += info.Size()
+		}
+	}
+	return up, nil
+}
+
+// expireUploads removes a namespace's uploads older than UploadTTL. s.mu
+// must be held.
+func (s *Store) expireUploads(namespace string) {
+	dir := filepath.Join(s.dir, namespace, uploadsDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || time.Since(info.ModTime()) <= UploadTTL {
+			continue
+		}
+		if meta, err := os.ReadFile(filepath.Join(dir, e.Name(), uploadMetaFile)); err == nil {
+			var up Upload
+			if json.Unmarshal(meta, &up) == nil && time.Since(up.CreatedAt) <= UploadTTL {
+				continue
+			}
+		}
+		if err := os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+			s.logger.Error("removing expired upload", "namespace", namespace, "upload", e.Name(), "error", err)
+		}
+	}
+}
+
+func (s *Store) uploadDir(namespace, id string) string {
+	return filepath.Join(s.dir, namespace, uploadsDir, id)
+}
@@ -0,0 +1,162 @@
+package sourcestore
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUploads(t *testing.T) {
+	store, err := New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	up, err := store.StartUpload("ns1", "myapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.AddUploadFiles("ns1", "myapp", up.ID, map[string]string{"main.go": "package old", "go.mod": "module x"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.AddUploadFiles("ns1", "myapp", up.ID, map[string]string{"main.go": "package main"}); err != nil {
+		t.Fatal(err)
+	}
+	files, err := store.UploadFiles("ns1", "myapp", up.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files["main.go"] != "package main" {
+		t.Errorf("unexpected upload files %v", files)
+	}
+	if _, err := store.AddUploadFiles("ns1", "myapp", up.ID, map[string]string{"../escape": "x"}); err == nil {
+		t.Error("expected an error for a path escaping the upload")
+	}
+
+	// Uploads are found only for their own namespace and app.
+	for _, tc := range []struct{ namespace, app, id string }{
+		{"ns2", "myapp", up.ID},
+		{"ns1", "other", up.ID},
+		{"ns1", "myapp", "../../ns2/.uploads/x"},
+	} {
+		if _, err := store.GetUpload(tc.namespace, tc.app, tc.id); !errors.Is(err, ErrUploadNotFound) {
+			t.Errorf("GetUpload(%q, %q, %q): expected ErrUploadNotFound, got %v", tc.namespace, tc.app, tc.id, err)
+		}
+	}
+
+	store.SetMaxUploadSize(10)
+	if _, err := store.AddUploadFiles("ns1", "myapp", up.ID, map[string]string{"big.txt": strings.Repeat("x", 20)}); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+	if _, err := store.StoreFiles("ns1", "myapp", map[string]string{"big.txt": strings.Repeat("x", 20)}); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("StoreFiles: expected ErrTooLarge, got %v", err)
+	}
+	if _, err := store.StoreTarball("ns1", "myapp", strings.NewReader(strings.Repeat("x", 20))); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("StoreTarball: expected ErrTooLarge, got %v", err)
+	}
+
+	// Expired uploads are not found, and are removed when the next starts.
+	dir := store.uploadDir("ns1", up.ID)
+	up.CreatedAt = time.Now().Add(-UploadTTL - time.Minute)
+	meta, _ := json.Marshal(up)
+	if err := os.WriteFile(filepath.Join(dir, uploadMetaFile), meta, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-UploadTTL - time.Minute)
+	if err := os.Chtimes(dir, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetUpload("ns1", "myapp", up.ID); !errors.Is(err, ErrUploadNotFound) {
+		t.Errorf("expected an expired upload not found, got %v", err)
+	}
+	if _, err := store.StartUpload("ns1", "myapp"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected the expired upload removed, got %v", err)
+	}
+}
+
+// TestUploads_SlowChunk verifies a chunk still arriving holds up only its
+// own upload.
+func TestUploads_SlowChunk(t *testing.T) {
+	store, err := New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	slow, err := store.StartUpload("ns1", "myapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, pw := io.Pipe()
+	done := make(chan error)
+	go func() {
+		_, err := store.AppendUpload("ns1", "myapp", slow.ID, 0, pr)
+		done <- err
+	}()
+	if _, err := pw.Write([]byte("part")); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := store.StartUpload("ns2", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if up, err := store.AppendUpload("ns2", "web", other.ID, 0, strings.NewReader("chunk")); err != nil || up.Size != 5 {
+		t.Fatalf("expected another upload to proceed, got %+v, %v", up, err)
+	}
+	if err := store.DeleteUpload("ns2", "web", other.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	pw.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if up, err := store.GetUpload("ns1", "myapp", slow.ID); err != nil || up.Size != 4 {
+		t.Errorf("unexpected upload %+v, %v", up, err)
+	}
+	if len(store.uploadLocks) != 0 {
+		t.Errorf("expected upload locks to be released, got %v", store.uploadLocks)
+	}
+}
+
+// TestUploads_OpenLimit verifies a namespace cannot hold more unfinished
+// uploads than the limit, so abandoned uploads cannot fill the store, and
+// that finishing one makes room.
+func TestUploads_OpenLimit(t *testing.T) {
+	store, err := New(t.TempDir(), "http://localhost:8080", slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.MaxUploadSize() != DefaultMaxUploadSize || store.MaxOpenUploads() != DefaultMaxOpenUploads {
+		t.Errorf("expected the default limits, got %d bytes and %d uploads", store.MaxUploadSize(), store.MaxOpenUploads())
+	}
+	store.SetMaxOpenUploads(2)
+	var ids []string
+	for range 2 {
+		up, err := store.StartUpload("ns1", "myapp")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, up.ID)
+	}
+	if _, err := store.StartUpload("ns1", "other"); !errors.Is(err, ErrTooManyUploads) {
+		t.Fatalf("expected ErrTooManyUploads, got %v", err)
+	}
+	if _, err := store.StartUpload("ns2", "myapp"); err != nil {
+		t.Errorf("expected another namespace to have its own limit, got %v", err)
+	}
+	if err := store.DeleteUpload("ns1", "myapp", ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.StartUpload("ns1", "myapp"); err != nil {
+		t.Errorf("expected room for an upload once one was removed, got %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+// Package topology builds the graph of what connects to what in a session
+// namespace: applications, the managed services bound to them, the data
+// sources attached to them, and the calls between applications inferred
+// from their environment variables.
+//
+// Only the literal values of an application's spec.env are read to infer
+// calls. Secrets, including environment groups and connection credentials,
+// are never read, so the graph reveals no credential.
+package topology
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NodeKind is the kind of resource a node stands for.
+type NodeKind string
+
+const (
+	NodeApp        NodeKind = "app"
+	NodeService    NodeKind = "service"
+	NodeDataSource NodeKind = "data_source"
+)
+
+// EdgeKind is how the source of an edge connects to its target.
+type EdgeKind string
+
+const (
+	// EdgeBinding connects an app to a managed service bound to it.
+	EdgeBinding EdgeKind = "binding"
+	// EdgeAttachment connects an app to a data source attached to it.
+	EdgeAttachment EdgeKind = "attachment"
+	// EdgeCall connects an app to another app one of its env vars points at.
+	EdgeCall EdgeKind = "call"
+)
+
+// Node is an application, managed service or data source.
+type Node struct {
+	// ID is unique in the graph: the kind and name, such as "app:web".
+	ID   string   `json:"id"`
+	Kind NodeKind `json:"kind"`
+	Name string   `json:"name"`
+	// Phase is the phase of an app or service.
+	Phase string `json:"phase,omitempty"`
+	// Type is the type of a service, such as "postgres", or the kind of a
+	// data source.
+	Type string `json:"type,omitempty"`
+	// URL is the public URL of an app.
+	URL string `json:"url,omitempty"`
+	// Missing is set on a service or data source an app refers to that
+	// does not exist.
+	Missing bool `json:"missing,omitempty"`
+}
+
+// Edge connects two nodes by their IDs.
+type Edge struct {
+	From string   `json:"from"`
+	To   string   `json:"to"`
+	Kind EdgeKind `json:"kind"`
+	// Mode is the bind mode of a binding: rw, ro or both.
+	Mode string `json:"mode,omitempty"`
+	// EnvVars names the env vars of a call that hold the target's address.
+	EnvVars []string `json:"envVars,omitempty"`
+}
+
+// Graph is the topology of a namespace. Nodes are ordered apps, services,
+// then data sources, each by name; edges by source, kind and target.
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// NodeID returns the ID of the node of kind named name.
+func NodeID(kind NodeKind, name string) string {
+	return string(kind) + ":" + name
+}
+
+// Build returns the topology of the applications and managed services in
+// namespace and of the data sources they attach.
+func Build(ctx context.Context, c client.Reader, namespace string) (*Graph, error) {
+	var apps iafv1alpha1.ApplicationList
+	if err := c.List(ctx, &apps, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("listing applications: %w", err)
+	}
+	var services iafv1alpha1.ManagedServiceList
+	if err := c.List(ctx, &services, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("listing services: %w", err)
+	}
+	slices.SortFunc(apps.Items, func(a, b iafv1alpha1.Application) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(services.Items, func(a, b iafv1alpha1.ManagedService) int { return strings.Compare(a.Name, b.Name) })
+
+	g := &Graph{Nodes: []Node{}, Edges: []Edge{}}
+	for _, app := range apps.Items {
+		g.Nodes = append(g.Nodes, Node{
+			ID:    NodeID(NodeApp, app.Name),
+			Kind:  NodeApp,
+			Name:  app.Name,
+			Phase: string(app.Status.Phase),
+			URL:   app.Status.URL,
+		})
+	}
+	serviceNodes := map[string]bool{}
+	for _, svc := range services.Items {
+		serviceNodes[svc.Name] = true
+		g.Nodes = append(g.Nodes, Node{
+			ID:    NodeID(NodeService, svc.Name),
+			Kind:  NodeService,
+			Name:  svc.Name,
+			Phase: string(svc.Status.Phase),
+			Type:  svc.Spec.Type,
+		})
+	}
+
+	hosts := appHosts(apps.Items, namespace)
+	var missingServices []string
+	dataSources := map[string]bool{}
+	for _, app := range apps.Items {
+		from := NodeID(NodeApp, app.Name)
+		for _, bms := range app.Spec.BoundManagedServices {
+			if !serviceNodes[bms.ServiceName] && !slices.Contains(missingServices, bms.ServiceName) {
+				missingServices = append(missingServices, bms.ServiceName)
+			}
+			mode := string(bms.Mode)
+			if mode == "" {
+				mode = string(iafv1alpha1.BindModeReadWrite)
+			}
+			g.Edges = append(g.Edges, Edge{From: from, To: NodeID(NodeService, bms.ServiceName), Kind: EdgeBinding, Mode: mode})
+		}
+		for _, ads := range app.Spec.AttachedDataSources {
+			dataSources[ads.DataSourceName] = true
+			g.Edges = append(g.Edges, Edge{From: from, To: NodeID(NodeDataSource, ads.DataSourceName), Kind: EdgeAttachment})
+		}
+		g.Edges = append(g.Edges, calls(&app, hosts)...)
+	}
+
+	slices.Sort(missingServices)
+	for _, name := range missingServices {
+		g.Nodes = append(g.Nodes, Node{ID: NodeID(NodeService, name), Kind: NodeService, Name: name, Missing: true})
+	}
+	names := make([]string, 0, len(dataSources))
+	for name := range dataSources {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		node := Node{ID: NodeID(NodeDataSource, name), Kind: NodeDataSource, Name: name}
+		var ds iafv1alpha1.DataSource
+		switch err := c.Get(ctx, types.NamespacedName{Name: name}, &ds); {
+		case apierrors.IsNotFound(err):
+			node.Missing = true
+		case err != nil:
+			return nil, fmt.Errorf("getting data source %q: %w", name, err)
+		default:
+			node.Type = ds.Spec.Kind
+		}
+		g.Nodes = append(g.Nodes, node)
+	}
+
+	slices.SortStableFunc(g.Edges, func(a, b Edge) int {
+		if n := strings.Compare(a.From, b.From); n != 0 {
+			return n
+		}
+		if n := strings.Compare(string(a.Kind), string(b.Kind)); n != 0 {
+			return n
+		}
+		return strings.Compare(a.To, b.To)
+	})
+	return g, nil
+}
+
+// appHosts maps the host names that reach each app to its name: its
+// in-cluster Service names and its public host.
+func appHosts(apps []iafv1alpha1.Application, namespace string) map[string]string {
+	hosts := map[string]string{}
+	for _, app := range apps {
+		for _, h := range []string{
+			app.Name + "." + namespace,
+			app.Name + "." + namespace + ".svc",
+			app.Name + "." + namespace + ".svc.cluster.local",
+			app.Spec.Host,
+		} {
+			if h != "" {
+				hosts[strings.ToLower(h)] = app.Name
+			}
+		}
+		if u, err := url.Parse(app.Status.URL); err == nil && u.Hostname() != "" {
+			hosts[strings.ToLower(u.Hostname())] = app.Name
+		}
+	}
+	return hosts
+}
+
+// calls returns the call edges from app to the apps its env vars point at.
+// A bare app name only counts with a scheme or port, as in "http://api" or
+// "api:8080", so a value such as "api" for a mode is not taken for a call.
+func calls(app *iafv1alpha1.Application, hosts map[string]string) []Edge {
+	var edges []Edge
+	for _, env := range app.Spec.Env {
+		for _, token := range strings.FieldsFunc(env.Value, func(r rune) bool {
+			return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n'
+		}) {
+			host, explicit := hostOf(token)
+			target, ok := hosts[host]
+			if !ok && explicit && !strings.Contains(host, ".") {
+				target, ok = hosts[host+"."+app.Namespace]
+			}
+			if !ok || target == app.Name {
+				continue
+			}
+			to := NodeID(NodeApp, target)
+			i := slices.IndexFunc(edges, func(e Edge) bool { return e.To == to })
+			if i < 0 {
+				edges = append(edges, Edge{From: NodeID(NodeApp, app.Name), To: to, Kind: EdgeCall})
+				i = len(edges) - 1
+			}
+			if !slices.Contains(edges[i].EnvVars, env.Name) {
+				edges[i].EnvVars = append(edges[i].EnvVars, env.Name)
+			}
+		}
+	}
+	return edges
+}
+
+// hostOf returns the lower-cased host of a URL or host[:port] token, and
+// whether the token named a scheme or port.
+func hostOf(token string) (host string, explicit bool) {
+	if strings.Contains(token, "://") {
+		u, err := url.Parse(token)
+		if err != nil {
+			return "", false
+		}
+		return strings.ToLower(u.Hostname()), true
+	}
+	token, _, _ = strings.Cut(token, "/")
+	if h, _, err := net.SplitHostPort(token); err == nil {
+		return strings.ToLower(h), true
+	}
+	return strings.ToLower(token), false
+}
@@ -0,0 +1,104 @@
+package topology
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	iafv1alpha1 "github.com/dlapiduz/iaf/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestBuild(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	app := func(name string, spec iafv1alpha1.ApplicationSpec) *iafv1alpha1.Application {
+		return &iafv1alpha1.Application{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "iaf-s1"}, Spec: spec}
+	}
+	web := app("web", iafv1alpha1.ApplicationSpec{
+		Env: []iafv1alpha1.EnvVar{
+			{Name: "API_URL", Value: "http://api:8080/v1"},
+			{Name: "API_BACKUP", Value: "api.iaf-s1.svc.cluster.local:8080"},
+			{Name: "AUTH_URL", Value: "https://auth.example.com/login"},
+			{Name: "MODE", Value: "api"},
+			{Name: "SELF", Value: "http://web:8080"},
+			{Name: "OTHER_NS", Value: "http://api.iaf-s2:8080"},
+		},
+		BoundManagedServices: []iafv1alpha1.BoundManagedService{{ServiceName: "db", Mode: iafv1alpha1.BindMode("both")}},
+	})
+	web.Status.Phase = iafv1alpha1.ApplicationPhaseRunning
+	api := app("api", iafv1alpha1.ApplicationSpec{
+		BoundManagedServices: []iafv1alpha1.BoundManagedService{{ServiceName: "db"}, {ServiceName: "gone"}},
+		AttachedDataSources:  []iafv1alpha1.AttachedDataSource{{DataSourceName: "crm"}, {DataSourceName: "retired"}},
+	})
+	auth := app("auth", iafv1alpha1.ApplicationSpec{Host: "auth.example.com"})
+	db := &iafv1alpha1.ManagedService{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "iaf-s1"},
+		Spec:       iafv1alpha1.ManagedServiceSpec{Type: "postgres", Plan: iafv1alpha1.ServicePlanMicro},
+	}
+	crm := &iafv1alpha1.DataSource{ObjectMeta: metav1.ObjectMeta{Name: "crm"}, Spec: iafv1alpha1.DataSourceSpec{Kind: "http-api"}}
+	unattached := &iafv1alpha1.DataSource{ObjectMeta: metav1.ObjectMeta{Name: "warehouse"}, Spec: iafv1alpha1.DataSourceSpec{Kind: "postgres"}}
+	otherSession := app("billing", iafv1alpha1.ApplicationSpec{Env: []iafv1alpha1.EnvVar{{Name: "API_URL", Value: "http://api:8080"}}})
+	otherSession.Namespace = "iaf-s2"
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects([]client.Object{web, api, auth, db, crm, unattached, otherSession}...).Build()
+
+	g, err := Build(context.Background(), c, "iaf-s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, n := range g.Nodes {
+		ids = append(ids, n.ID)
+	}
+	want := []string{"app:api", "app:auth", "app:web", "service:db", "service:gone", "data_source:crm", "data_source:retired"}
+	if !slices.Equal(ids, want) {
+		t.Fatalf("nodes = %v, want %v", ids, want)
+	}
+	byID := map[string]Node{}
+	for _, n := range g.Nodes {
+		byID[n.ID] = n
+	}
+	if n := byID["app:web"]; n.Phase != "Running" {
+		t.Errorf("unexpected web node %+v", n)
+	}
+	if byID["service:db"].Type != "postgres" || byID["service:db"].Missing || !byID["service:gone"].Missing {
+		t.Errorf("unexpected service nodes %+v, %+v", byID["service:db"], byID["service:gone"])
+	}
+	if byID["data_source:crm"].Type != "http-api" || !byID["data_source:retired"].Missing {
+		t.Errorf("unexpected data source nodes %+v, %+v", byID["data_source:crm"], byID["data_source:retired"])
+	}
+
+	wantEdges := []Edge{
+		{From: "app:api", To: "data_source:crm", Kind: EdgeAttachment},
+		{From: "app:api", To: "data_source:retired", Kind: EdgeAttachment},
+		{From: "app:api", To: "service:db", Kind: EdgeBinding, Mode: "rw"},
+		{From: "app:api", To: "service:gone", Kind: EdgeBinding, Mode: "rw"},
+		{From: "app:web", To: "service:db", Kind: EdgeBinding, Mode: "both"},
+		{From: "app:web", To: "app:api", Kind: EdgeCall, EnvVars: []string{"API_URL", "API_BACKUP"}},
+		{From: "app:web", To: "app:auth", Kind: EdgeCall, EnvVars: []string{"AUTH_URL"}},
+	}
+	if len(g.Edges) != len(wantEdges) {
+		t.Fatalf("edges = %+v, want %+v", g.Edges, wantEdges)
+	}
+	for i, e := range g.Edges {
+		w := wantEdges[i]
+		if e.From != w.From || e.To != w.To || e.Kind != w.Kind || e.Mode != w.Mode || !slices.Equal(e.EnvVars, w.EnvVars) {
+			t.Errorf("edge %d = %+v, want %+v", i, e, w)
+		}
+	}
+}
+
+func TestBuild_Empty(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = iafv1alpha1.AddToScheme(scheme)
+	g, err := Build(context.Background(), fake.NewClientBuilder().WithScheme(scheme).Build(), "iaf-s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Nodes == nil || g.Edges == nil || len(g.Nodes) != 0 {
+		t.Errorf("expected an empty graph with non-nil lists, got %+v", g)
+	}
+}
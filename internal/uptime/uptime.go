@@ -0,0 +1,88 @@
+// Package uptime reads the results of application uptime checks from the
+// Prometheus that scrapes the platform's Probes.
+package uptime
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	iafk8s "github.com/dlapiduz/iaf/internal/k8s"
+	promapi "github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+// Window is the period uptime is reported over.
+const Window = 24 * time.Hour
+
+// Report summarizes the uptime check results of one application over Window.
+type Report struct {
+	// Percent is the percentage of successful probes, or nil when the app
+	// has no results yet.
+	Percent *float64
+	// LastFailure is the time of the most recent failed probe, or nil when
+	// every probe succeeded.
+	LastFailure *time.Time
+}
+
+// Querier reports uptime check results.
+type Querier interface {
+	Report(ctx context.Context, namespace, name string) (Report, error)
+}
+
+// Prometheus is a Querier backed by a Prometheus server.
+type Prometheus struct {
+	api promv1.API
+}
+
+// NewPrometheus returns a Querier for the Prometheus server at url.
+func NewPrometheus(url string) (*Prometheus, error) {
+	c, err := promapi.NewClient(promapi.Config{Address: url})
+	if err != nil {
+		return nil, fmt.Errorf("creating prometheus client: %w", err)
+	}
+	return &Prometheus{api: promv1.NewAPI(c)}, nil
+}
+
+// Report queries the probe_success series of the application name in namespace.
+func (p *Prometheus) Report(ctx context.Context, namespace, name string) (Report, error) {
+	selector := iafk8s.UptimeSelector(namespace, name)
+	window := model.Duration(Window)
+	now := time.Now()
+
+	var report Report
+	percent, ok, err := p.scalar(ctx, fmt.Sprintf(`100 * avg_over_time(probe_success{%s}[%s])`, selector, window), now)
+	if err != nil {
+		return Report{}, err
+	}
+	if ok {
+		report.Percent = &percent
+	}
+	lastFailure, ok, err := p.scalar(ctx, fmt.Sprintf(`max_over_time(timestamp(probe_success{%s} == 0)[%s:])`, selector, window), now)
+	if err != nil {
+		return Report{}, err
+	}
+	if ok {
+		t := time.Unix(int64(lastFailure), 0).UTC()
+		report.LastFailure = &t
+	}
+	return report, nil
+}
+
+// scalar runs query and returns the value of its first sample. ok is false
+// when the query returns no series.
+func (p *Prometheus) scalar(ctx context.Context, query string, at time.Time) (value float64, ok bool, err error) {
+	result, _, err := p.api.Query(ctx, query, at)
+	if err != nil {
+		return 0, false, fmt.Errorf("querying uptime: %w", err)
+	}
+	vector, isVector := result.(model.Vector)
+	if !isVector {
+		return 0, false, fmt.Errorf("unexpected result type %s", result.Type())
+	}
+	if len(vector) == 0 {
+		return 0, false, nil
+	}
+	return float64(vector[0].Value), true, nil
+}
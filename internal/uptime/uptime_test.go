@@ -0,0 +1,66 @@
+package uptime
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakePrometheus answers instant queries: the uptime query with percent and
+// the last failure query with lastFailure. Empty values return no series.
+func fakePrometheus(t *testing.T, percent, lastFailure string) *Prometheus {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		query := r.Form.Get("query")
+		if !strings.Contains(query, `probe_success{job="iaf-uptime", namespace="iaf-abc", application="web"}`) {
+			t.Errorf("unexpected selector in %q", query)
+		}
+		value := percent
+		if strings.HasPrefix(query, "max_over_time") {
+			value = lastFailure
+		}
+		result := "[]"
+		if value != "" {
+			result = `[{"metric":{},"value":[1700000000,"` + value + `"]}]`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":` + result + `}}`))
+	}))
+	t.Cleanup(srv.Close)
+	p, err := NewPrometheus(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPrometheus_Report(t *testing.T) {
+	p := fakePrometheus(t, "99.5", "1699990000")
+	report, err := p.Report(context.Background(), "iaf-abc", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Percent == nil || *report.Percent != 99.5 {
+		t.Errorf("unexpected percent %v", report.Percent)
+	}
+	if report.LastFailure == nil || !report.LastFailure.Equal(time.Unix(1699990000, 0)) {
+		t.Errorf("unexpected last failure %v", report.LastFailure)
+	}
+}
+
+func TestPrometheus_Report_NoResults(t *testing.T) {
+	p := fakePrometheus(t, "", "")
+	report, err := p.Report(context.Background(), "iaf-abc", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Percent != nil || report.LastFailure != nil {
+		t.Errorf("expected an empty report, got %+v", report)
+	}
+}
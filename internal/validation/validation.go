@@ -4,14 +4,26 @@ import (
 	"fmt"
 	"net"
 	"net/url"
+	"path"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 var (
 	appNameRegex       = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
 	envVarNameRegex    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
 	githubRepoRegex    = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+	codeOwnerRegex     = regexp.MustCompile(`^@[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(/[a-zA-Z0-9_.-]+)?$`)
+	registryHostRegex  = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+	registryPathRegex  = regexp.MustCompile(`^[a-z0-9]+([._-][a-z0-9]+)*$`)
+	uptimePathRegex    = regexp.MustCompile(`^/[A-Za-z0-9/._~%!$&'()*+,;=:@?-]*$`)
+	configMapKeyRegex  = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
 
 	reservedPrefixes = []string{"kube-", "iaf-"}
 
@@ -47,6 +59,22 @@ func ValidateAppName(name string) error {
 	return nil
 }
 
+// ValidateEnvironmentName validates the name of an application environment
+// such as dev, staging or prod: a lowercase DNS label of up to 20
+// characters, since it becomes part of the environment's app name.
+func ValidateEnvironmentName(name string) error {
+	if name == "" {
+		return fmt.Errorf("environment name is required")
+	}
+	if len(name) > 20 {
+		return fmt.Errorf("environment name must be 20 characters or less (got %d)", len(name))
+	}
+	if !appNameRegex.MatchString(name) {
+		return fmt.Errorf("environment name %q is invalid: use lowercase letters, digits and hyphens, starting and ending with a letter or digit", name)
+	}
+	return nil
+}
+
 // ValidateBasicAuthGitServerURL validates a git server URL for basic-auth (HTTPS).
 // Rejects internal/RFC 1918 addresses to prevent SSRF.
 func ValidateBasicAuthGitServerURL(rawURL string) error {
@@ -101,6 +129,22 @@ func ValidateSSHGitServerURL(rawURL string) error {
 	return nil
 }
 
+// ValidateKnownHosts validates SSH known_hosts content: one host key entry
+// per line in the OpenSSH format ("host[,host...] key-type base64-key"),
+// as printed by ssh-keyscan. Blank lines and # comments are allowed.
+func ValidateKnownHosts(knownHosts string) error {
+	for i, line := range strings.Split(knownHosts, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, _, _, _, _, err := ssh.ParseKnownHosts([]byte(line)); err != nil {
+			return fmt.Errorf("known_hosts line %d is not a host key entry (want \"host key-type base64-key\", as printed by ssh-keyscan): %w", i+1, err)
+		}
+	}
+	return nil
+}
+
 // ValidateGitHubRepoName validates a GitHub repository name (not org/repo — just the repo part).
 func ValidateGitHubRepoName(name string) error {
 	if name == "" {
@@ -115,6 +159,15 @@ func ValidateGitHubRepoName(name string) error {
 	return nil
 }
 
+// ValidateCodeOwner validates a CODEOWNERS entry: a GitHub user ("@user")
+// or team ("@org/team").
+func ValidateCodeOwner(owner string) error {
+	if len(owner) > 100 || !codeOwnerRegex.MatchString(owner) {
+		return fmt.Errorf("owner %q is invalid: must be a GitHub user (@user) or team (@org/team)", owner)
+	}
+	return nil
+}
+
 // rejectPrivateHost returns an error if the hostname resolves to a private/internal IP.
 func rejectPrivateHost(host string) error {
 	// Parse private CIDR ranges once; ignore parse errors (they won't happen for hardcoded values)
@@ -167,3 +220,598 @@ func ValidateEnvVarName(name string) error {
 	}
 	return nil
 }
+
+// ValidateBuildEnvVarName validates the name of a build-time environment
+// variable. Names starting with CNB_ are reserved for the buildpack lifecycle.
+func ValidateBuildEnvVarName(name string) error {
+	if err := ValidateEnvVarName(name); err != nil {
+		return err
+	}
+	if strings.HasPrefix(strings.ToUpper(name), "CNB_") {
+		return fmt.Errorf("build env var name %q is reserved: names starting with CNB_ configure the buildpack lifecycle", name)
+	}
+	return nil
+}
+
+// ValidateBuilder validates that builder is one of the allowed kpack
+// ClusterBuilders. An empty value is accepted and means the platform default.
+func ValidateBuilder(builder string, allowed []string) error {
+	if builder == "" || slices.Contains(allowed, builder) {
+		return nil
+	}
+	if len(allowed) == 0 {
+		return fmt.Errorf("builder %q is not available: this platform only offers its default builder; leave builder empty", builder)
+	}
+	return fmt.Errorf("builder %q is not available: must be one of %s", builder, strings.Join(allowed, ", "))
+}
+
+// ValidateArchitecture validates an application CPU architecture against the
+// architectures the platform offers. An empty value is accepted and means
+// no particular architecture.
+func ValidateArchitecture(arch string, available []string) error {
+	switch arch {
+	case "":
+		return nil
+	case "amd64", "arm64", "multi":
+	default:
+		return fmt.Errorf("architecture %q is invalid: must be amd64, arm64 or multi", arch)
+	}
+	if slices.Contains(available, arch) {
+		return nil
+	}
+	if len(available) == 0 {
+		return fmt.Errorf("architecture %q is not available: this platform does not offer architecture selection; leave architecture empty", arch)
+	}
+	return fmt.Errorf("architecture %q is not available: must be one of %s", arch, strings.Join(available, ", "))
+}
+
+// ValidateWorkloadClass validates an application workload class against the
+// classes the platform offers. An empty value is accepted and means standard.
+func ValidateWorkloadClass(class string, available []string) error {
+	switch class {
+	case "":
+		return nil
+	case "standard", "burst", "gpu":
+	default:
+		return fmt.Errorf("workload class %q is invalid: must be standard, burst or gpu", class)
+	}
+	if !slices.Contains(available, class) {
+		return fmt.Errorf("workload class %q is not available: must be one of %s", class, strings.Join(available, ", "))
+	}
+	return nil
+}
+
+// ValidateProtocol validates an application routing protocol. An empty value is
+// accepted and means the default (http).
+func ValidateProtocol(protocol string) error {
+	switch protocol {
+	case "", "http", "websocket", "grpc", "tcp":
+		return nil
+	}
+	return fmt.Errorf("protocol %q is invalid: must be one of http, websocket, grpc, tcp", protocol)
+}
+
+// commitSHARegex matches an abbreviated or full git commit SHA.
+var commitSHARegex = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// ValidateGitTrack validates a git track mode for revision. An empty value
+// is accepted and means the default (revision). Tracking needs a branch, so
+// a commit SHA cannot be tracked.
+func ValidateGitTrack(track, revision string) error {
+	switch track {
+	case "", "revision":
+		return nil
+	case "branch":
+		if commitSHARegex.MatchString(revision) {
+			return fmt.Errorf("track \"branch\" needs a branch revision, not commit %q", revision)
+		}
+		return nil
+	}
+	return fmt.Errorf("track %q is invalid: must be one of revision, branch", track)
+}
+
+var subPathSegmentRegex = regexp.MustCompile(`^[A-Za-z0-9_.@+-]+$`)
+
+// ValidateSubPath validates the directory of a git repository or source
+// archive to build. An empty value is accepted and means the root. It must
+// be a clean relative path that stays inside the source.
+func ValidateSubPath(p string) error {
+	if p == "" {
+		return nil
+	}
+	if len(p) > 1024 {
+		return fmt.Errorf("sub path must be 1024 characters or fewer")
+	}
+	if path.Clean(p) != p || strings.HasPrefix(p, "/") {
+		return fmt.Errorf("sub path %q is invalid: must be a relative directory without a leading or trailing '/' (e.g. 'services/api')", p)
+	}
+	for _, segment := range strings.Split(p, "/") {
+		if segment == "." || segment == ".." || !subPathSegmentRegex.MatchString(segment) {
+			return fmt.Errorf("sub path %q is invalid: must stay inside the source and use letters, digits, '-', '_', '.', '@' and '+'", p)
+		}
+	}
+	return nil
+}
+
+// ValidateAuthentication validates an application authentication mode against
+// its routing protocol. An empty value is accepted and means the default (none).
+// Authentication is enforced at the HTTP layer, so it cannot be combined with tcp.
+func ValidateAuthentication(authentication, protocol string) error {
+	switch authentication {
+	case "", "none":
+		return nil
+	case "basic", "oauth-proxy":
+		if protocol == "tcp" {
+			return fmt.Errorf("authentication %q is not supported with protocol \"tcp\": use http, websocket, or grpc, or set authentication to none", authentication)
+		}
+		return nil
+	}
+	return fmt.Errorf("authentication %q is invalid: must be one of none, basic, oauth-proxy", authentication)
+}
+
+// ValidateBackendTLS validates TLS from the ingress to the app's pods against
+// its routing protocol and authentication. A tcp route passes the client's
+// connection through unchanged, and an oauth-proxy sidecar receives the
+// traffic in place of the app, so neither can be combined with it.
+func ValidateBackendTLS(enabled bool, protocol, authentication string) error {
+	if !enabled {
+		return nil
+	}
+	if protocol == "tcp" {
+		return fmt.Errorf("backend TLS is not supported with protocol \"tcp\": serve TLS from the app directly, or use http, websocket, or grpc")
+	}
+	if authentication == "oauth-proxy" {
+		return fmt.Errorf("backend TLS is not supported with authentication \"oauth-proxy\": use basic or none")
+	}
+	return nil
+}
+
+// maxIPAllowListEntries caps spec.access.ipAllowList to keep middlewares small.
+const maxIPAllowListEntries = 50
+
+// ValidateAccess validates spec.access settings. Each allowlist entry must be an
+// IP address or CIDR range; requestsPerSecond must not be negative. Access
+// restrictions are enforced by HTTP middlewares and cannot be combined with tcp.
+func ValidateAccess(ipAllowList []string, requestsPerSecond int32, protocol string) error {
+	if len(ipAllowList) == 0 && requestsPerSecond == 0 {
+		return nil
+	}
+	if protocol == "tcp" {
+		return fmt.Errorf("ip allowlist and rate limiting are not supported with protocol \"tcp\"")
+	}
+	if len(ipAllowList) > maxIPAllowListEntries {
+		return fmt.Errorf("ip allowlist has %d entries; the maximum is %d", len(ipAllowList), maxIPAllowListEntries)
+	}
+	for _, entry := range ipAllowList {
+		if net.ParseIP(entry) != nil {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(entry); err != nil {
+			return fmt.Errorf("ip allowlist entry %q is invalid: must be an IP address (e.g. '203.0.113.7') or CIDR range (e.g. '10.0.0.0/8')", entry)
+		}
+	}
+	if requestsPerSecond < 0 {
+		return fmt.Errorf("requests per second must be 0 (unlimited) or greater, got %d", requestsPerSecond)
+	}
+	return nil
+}
+
+// minIdleTimeout keeps apps from being put to sleep between the idler's
+// checks of an otherwise active app.
+const minIdleTimeout = 5 * time.Minute
+
+// ValidateIdleTimeout parses an idle timeout such as "30m" or "2h". "0"
+// disables idling; anything else must be at least five minutes. Idling
+// relies on HTTP request metrics and cannot be combined with tcp.
+func ValidateIdleTimeout(value, protocol string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("idle timeout %q is invalid: use a duration such as '30m' or '2h', or '0' to disable", value)
+	}
+	if d == 0 {
+		return 0, nil
+	}
+	if protocol == "tcp" {
+		return 0, fmt.Errorf("idle timeout is not supported with protocol \"tcp\"")
+	}
+	if d < minIdleTimeout {
+		return 0, fmt.Errorf("idle timeout must be 0 (disabled) or at least %v, got %v", minIdleTimeout, d)
+	}
+	return d, nil
+}
+
+// TTL bounds: a TTL shorter than minTTL would delete a resource before it
+// finishes provisioning, and one longer than maxTTL is not a demo.
+const (
+	minTTL = 10 * time.Minute
+	maxTTL = 30 * 24 * time.Hour
+)
+
+// ValidateTTL parses the lifetime of a self-destructing resource, such as
+// "72h". It must be between ten minutes and 30 days.
+func ValidateTTL(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("ttl %q is invalid: use a duration such as '72h' or '90m'", value)
+	}
+	if d < minTTL || d > maxTTL {
+		return 0, fmt.Errorf("ttl must be between %v and %v, got %v", minTTL, maxTTL, d)
+	}
+	return d, nil
+}
+
+const (
+	minMaintenanceWindow     = 30 * time.Minute
+	maxMaintenanceWindow     = 24 * time.Hour
+	defaultMaintenanceWindow = time.Hour
+)
+
+// ValidateMaintenanceWindow validates a weekly or daily maintenance window:
+// an optional weekday (any case), a UTC start time as HH:MM, and an optional
+// duration between 30 minutes and 24 hours (default 1h). It returns the
+// weekday as spelled in the API, such as "Sunday", and the duration.
+func ValidateMaintenanceWindow(day, start, duration string) (string, time.Duration, error) {
+	if day != "" {
+		found := false
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if strings.EqualFold(day, d.String()) {
+				day, found = d.String(), true
+			}
+		}
+		if !found {
+			return "", 0, fmt.Errorf("maintenance window day %q is invalid: use a weekday such as 'Sunday', or omit it for a daily window", day)
+		}
+	}
+	if t, err := time.Parse("15:04", start); err != nil || t.Format("15:04") != start {
+		return "", 0, fmt.Errorf("maintenance window start %q is invalid: use a UTC time of day as HH:MM, such as '03:00'", start)
+	}
+	d := defaultMaintenanceWindow
+	if duration != "" {
+		var err error
+		if d, err = time.ParseDuration(duration); err != nil || d < minMaintenanceWindow || d > maxMaintenanceWindow {
+			return "", 0, fmt.Errorf("maintenance window duration %q is invalid: use a duration between %v and %v, such as '2h'", duration, minMaintenanceWindow, maxMaintenanceWindow)
+		}
+	}
+	return day, d, nil
+}
+
+// ValidateRegistryServer validates a container registry server as written in
+// image references: a hostname with an optional port, e.g. "ghcr.io" or
+// "registry.corp:5000". Schemes and paths are rejected. The server is only
+// contacted by kpack and the kubelet, never by IAF itself.
+func ValidateRegistryServer(server string) error {
+	if server == "" {
+		return fmt.Errorf("registry_server is required")
+	}
+	host, port := server, ""
+	if h, p, err := net.SplitHostPort(server); err == nil {
+		host, port = h, p
+	}
+	if port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("registry_server %q has an invalid port", server)
+		}
+	}
+	if net.ParseIP(host) == nil && (len(host) > 253 || !registryHostRegex.MatchString(host)) {
+		return fmt.Errorf("registry_server %q is invalid: use a hostname with an optional port, e.g. 'ghcr.io' or 'registry.example.com:5000', without https:// or a path", server)
+	}
+	return nil
+}
+
+// ValidateRegistryPrefix validates a registry prefix that images are pushed
+// under: a registry server as accepted by ValidateRegistryServer followed by
+// optional lowercase repository path components, e.g. "ghcr.io/team" or
+// "registry.corp:5000/iaf/payments".
+func ValidateRegistryPrefix(prefix string) error {
+	server, path, _ := strings.Cut(prefix, "/")
+	if err := ValidateRegistryServer(server); err != nil {
+		return fmt.Errorf("registry prefix %q is invalid: %w", prefix, err)
+	}
+	if path == "" {
+		return nil
+	}
+	for _, component := range strings.Split(path, "/") {
+		if !registryPathRegex.MatchString(component) {
+			return fmt.Errorf("registry prefix %q is invalid: path components must be lowercase alphanumerics separated by '.', '_' or '-'", prefix)
+		}
+	}
+	return nil
+}
+
+// Uptime check bounds, matching the Application CRD.
+const (
+	minUptimeInterval = 30
+	maxUptimeInterval = 3600
+	maxUptimePathLen  = 256
+)
+
+// ValidateUptimeCheck validates spec.uptimeCheck settings. An empty path and
+// a zero interval select the defaults. Uptime checks probe the HTTP URL and
+// cannot be combined with tcp.
+func ValidateUptimeCheck(path string, intervalSeconds int32, protocol string) error {
+	if protocol == "tcp" {
+		return fmt.Errorf("uptime checks are not supported with protocol \"tcp\"")
+	}
+	if path != "" && (len(path) > maxUptimePathLen || !uptimePathRegex.MatchString(path)) {
+		return fmt.Errorf("uptime check path %q is invalid: it must start with '/' and contain only URL path and query characters (at most %d)", path, maxUptimePathLen)
+	}
+	if intervalSeconds != 0 && (intervalSeconds < minUptimeInterval || intervalSeconds > maxUptimeInterval) {
+		return fmt.Errorf("uptime check interval must be between %d and %d seconds, got %d", minUptimeInterval, maxUptimeInterval, intervalSeconds)
+	}
+	return nil
+}
+
+// Build cache volume bounds.
+var (
+	minBuildCacheSize = resource.MustParse("1Gi")
+	maxBuildCacheSize = resource.MustParse("20Gi")
+)
+
+// ValidateBuildCache validates build cache settings and returns the parsed
+// volume size, or nil when volumeSize is empty. An empty type selects the
+// platform default; a volume size cannot be combined with registry or none.
+func ValidateBuildCache(cacheType, volumeSize string) (*resource.Quantity, error) {
+	switch cacheType {
+	case "", "volume", "registry", "none":
+	default:
+		return nil, fmt.Errorf("build cache type %q is invalid: use volume, registry, or none", cacheType)
+	}
+	if volumeSize == "" {
+		return nil, nil
+	}
+	if cacheType == "registry" || cacheType == "none" {
+		return nil, fmt.Errorf("build cache volume size cannot be set with build cache type %q", cacheType)
+	}
+	size, err := resource.ParseQuantity(volumeSize)
+	if err != nil {
+		return nil, fmt.Errorf("build cache volume size %q is invalid: use a quantity such as 2Gi", volumeSize)
+	}
+	if size.Cmp(minBuildCacheSize) < 0 || size.Cmp(maxBuildCacheSize) > 0 {
+		return nil, fmt.Errorf("build cache volume size must be between %s and %s, got %s", minBuildCacheSize.String(), maxBuildCacheSize.String(), volumeSize)
+	}
+	return &size, nil
+}
+
+const (
+	// MaxConfigFiles is the most config files an application may mount.
+	MaxConfigFiles = 20
+	// MaxConfigFileSize is the largest inline config file, in bytes.
+	MaxConfigFileSize = 256 * 1024
+	// MaxConfigFilesSize is the largest total size of an application's
+	// inline config files, in bytes, keeping their ConfigMap well under the
+	// 1 MiB object limit.
+	MaxConfigFilesSize = 512 * 1024
+)
+
+// reservedConfigFileDirs are directories config files may not be mounted in:
+// the kernel and device filesystems, and the service account token mount.
+var reservedConfigFileDirs = []string{"/proc", "/sys", "/dev", "/var/run/secrets", "/run/secrets"}
+
+// ValidateConfigFilePath validates the container path a config file is
+// mounted at. It must be an absolute, clean file path outside the
+// directories the container runtime and Kubernetes manage.
+func ValidateConfigFilePath(p string) error {
+	if p == "" {
+		return fmt.Errorf("config file path is required")
+	}
+	if len(p) > 1024 {
+		return fmt.Errorf("config file path must be 1024 characters or fewer")
+	}
+	if !strings.HasPrefix(p, "/") || path.Clean(p) != p || p == "/" {
+		return fmt.Errorf("config file path %q is invalid: must be an absolute file path without '..', '.' or trailing '/' (e.g. '/app/config.yaml')", p)
+	}
+	for _, dir := range reservedConfigFileDirs {
+		if p == dir || strings.HasPrefix(p, dir+"/") {
+			return fmt.Errorf("config file path %q is not allowed: %s is managed by the platform", p, dir)
+		}
+	}
+	return nil
+}
+
+// ValidateConfigMapKey validates a ConfigMap key a config file reads.
+func ValidateConfigMapKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("config map key is required")
+	}
+	if len(key) > 253 || !configMapKeyRegex.MatchString(key) || key == "." || key == ".." {
+		return fmt.Errorf("config map key %q is invalid: use letters, digits, '-', '_' and '.'", key)
+	}
+	return nil
+}
+
+const (
+	// MaxCommandArgs is the most arguments a container, release or
+	// migration command may have.
+	MaxCommandArgs = 32
+	// maxCommandArgLength is the longest argument of a command, in bytes.
+	maxCommandArgLength = 4096
+)
+
+// ValidateCommand validates a command run in an application's image, such
+// as its release command. The command is passed to the container as-is,
+// never through a shell the platform adds.
+func ValidateCommand(command []string) error {
+	if len(command) == 0 {
+		return fmt.Errorf("command is required")
+	}
+	if len(command) > MaxCommandArgs {
+		return fmt.Errorf("command may have at most %d arguments", MaxCommandArgs)
+	}
+	if strings.TrimSpace(command[0]) == "" {
+		return fmt.Errorf("command must start with a program name")
+	}
+	for _, arg := range command {
+		if len(arg) > maxCommandArgLength {
+			return fmt.Errorf("command arguments must be %d bytes or fewer", maxCommandArgLength)
+		}
+		if strings.ContainsRune(arg, 0) {
+			return fmt.Errorf("command arguments may not contain NUL bytes")
+		}
+	}
+	return nil
+}
+
+// ValidateArgs validates the arguments passed to an application's command
+// or image entrypoint. Unlike a command, they may be empty.
+func ValidateArgs(args []string) error {
+	if len(args) > MaxCommandArgs {
+		return fmt.Errorf("args may have at most %d items", MaxCommandArgs)
+	}
+	for _, arg := range args {
+		if len(arg) > maxCommandArgLength {
+			return fmt.Errorf("args must be %d bytes or fewer each", maxCommandArgLength)
+		}
+		if strings.ContainsRune(arg, 0) {
+			return fmt.Errorf("args may not contain NUL bytes")
+		}
+	}
+	return nil
+}
+
+// dnsNameRegex matches a lowercase DNS name of one or more labels.
+var dnsNameRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+
+// clusterSuffixes are the DNS suffixes the cluster resolves itself. Host
+// aliases and search domains under them could hijack Service names.
+var clusterSuffixes = []string{"cluster.local", "svc"}
+
+// validateExternalName checks that name is a fully qualified DNS name the
+// cluster does not resolve itself.
+func validateExternalName(kind, name string) error {
+	if len(name) > 253 || !dnsNameRegex.MatchString(name) {
+		return fmt.Errorf("%s %q is invalid: must be a lowercase DNS name (e.g. 'ldap.corp.example.com')", kind, name)
+	}
+	if !strings.Contains(name, ".") {
+		return fmt.Errorf("%s %q is invalid: must be fully qualified; single-label names could shadow Services in your namespace", kind, name)
+	}
+	for _, suffix := range clusterSuffixes {
+		if strings.HasSuffix(name, "."+suffix) || strings.Contains(name, "."+suffix+".") {
+			return fmt.Errorf("%s %q is not allowed: cluster-internal names cannot be overridden", kind, name)
+		}
+	}
+	if name == "localhost" || strings.HasSuffix(name, ".localhost") {
+		return fmt.Errorf("%s %q is not allowed: localhost cannot be overridden", kind, name)
+	}
+	return nil
+}
+
+// ValidateHostAlias validates an /etc/hosts entry added to an
+// application's pods. The IP must be a routable unicast address and every
+// hostname a fully qualified name outside the cluster's DNS domains.
+func ValidateHostAlias(ip string, hostnames []string) error {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return fmt.Errorf("host alias IP %q is invalid: must be an IP address", ip)
+	}
+	if addr.IsLoopback() || addr.IsUnspecified() || addr.IsMulticast() {
+		return fmt.Errorf("host alias IP %q is not allowed: use a unicast address of the target system", ip)
+	}
+	if len(hostnames) == 0 {
+		return fmt.Errorf("host alias for %s needs at least one hostname", ip)
+	}
+	if len(hostnames) > 10 {
+		return fmt.Errorf("host alias for %s has %d hostnames; the maximum is 10", ip, len(hostnames))
+	}
+	for _, h := range hostnames {
+		if err := validateExternalName("hostname", h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateDNSConfig validates the nameservers and search domains added to
+// an application's pods. Cluster DNS stays first, so at most two
+// nameservers and three search domains fit the resolver's limits.
+func ValidateDNSConfig(nameservers, searches []string) error {
+	if len(nameservers) > 2 {
+		return fmt.Errorf("dns config has %d nameservers; the maximum is 2", len(nameservers))
+	}
+	for _, ns := range nameservers {
+		addr := net.ParseIP(ns)
+		if addr == nil || addr.IsUnspecified() || addr.IsMulticast() {
+			return fmt.Errorf("nameserver %q is invalid: must be the IP address of a DNS server", ns)
+		}
+	}
+	if len(searches) > 3 {
+		return fmt.Errorf("dns config has %d search domains; the maximum is 3", len(searches))
+	}
+	for _, domain := range searches {
+		if err := validateExternalName("search domain", domain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dnsOptionsWithValue are the resolver options that take a numeric value;
+// the others in dnsOptions are flags.
+var (
+	dnsOptionsWithValue = []string{"ndots", "timeout", "attempts"}
+	dnsOptions          = []string{"ndots", "timeout", "attempts", "rotate", "edns0", "single-request", "single-request-reopen", "use-vc"}
+)
+
+// ValidateDNSOption validates a resolver option added to an application's
+// pods against the resolv.conf(5) options the platform allows.
+func ValidateDNSOption(name, value string) error {
+	if !slices.Contains(dnsOptions, name) {
+		return fmt.Errorf("dns option %q is not supported: must be one of %s", name, strings.Join(dnsOptions, ", "))
+	}
+	if !slices.Contains(dnsOptionsWithValue, name) {
+		if value != "" {
+			return fmt.Errorf("dns option %q does not take a value", name)
+		}
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n > 15 {
+		return fmt.Errorf("dns option %q needs a value from 0 to 15, got %q", name, value)
+	}
+	return nil
+}
+
+// MaxIdempotencyKeyLength is the longest idempotency key accepted.
+const MaxIdempotencyKeyLength = 255
+
+var idempotencyKeyRegex = regexp.MustCompile(`^[A-Za-z0-9._:-]+$`)
+
+// ValidateIdempotencyKey validates a client-chosen idempotency key, such as
+// a UUID. Keys are compared exactly.
+func ValidateIdempotencyKey(key string) error {
+	if len(key) > MaxIdempotencyKeyLength {
+		return fmt.Errorf("idempotency key must be %d characters or fewer", MaxIdempotencyKeyLength)
+	}
+	if !idempotencyKeyRegex.MatchString(key) {
+		return fmt.Errorf("idempotency key %q is invalid: use letters, digits, '.', '_', ':' and '-' (e.g. a UUID)", key)
+	}
+	return nil
+}
+
+var (
+	ownerEmailRegex = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?@[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)+$`)
+	costCenterRegex = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?$`)
+)
+
+// maxOwnerLength keeps an owner email within the 63-character label value
+// limit once its '@' is encoded.
+const maxOwnerLength = 60
+
+// ValidateOwnership validates application ownership metadata, every field
+// of which becomes a label value; empty fields are allowed. The owner is an
+// email whose local part uses only letters, digits, '.', '_' and '-'.
+func ValidateOwnership(team, owner, purpose, costCenter string) error {
+	if team != "" && (len(team) > 63 || !appNameRegex.MatchString(team)) {
+		return fmt.Errorf("team %q is invalid: use up to 63 lowercase letters, digits and hyphens (e.g. 'payments')", team)
+	}
+	if owner != "" && (len(owner) > maxOwnerLength || !ownerEmailRegex.MatchString(owner) || strings.Contains(owner, "_at_")) {
+		return fmt.Errorf("owner %q is invalid: must be an email address of up to %d characters, with only letters, digits, '.', '_' and '-' before the '@' and a lowercase domain", owner, maxOwnerLength)
+	}
+	if purpose != "" && (len(purpose) > 63 || !appNameRegex.MatchString(purpose)) {
+		return fmt.Errorf("purpose %q is invalid: use a short slug of up to 63 lowercase letters, digits and hyphens (e.g. 'customer-portal')", purpose)
+	}
+	if costCenter != "" && (len(costCenter) > 63 || !costCenterRegex.MatchString(costCenter)) {
+		return fmt.Errorf("cost center %q is invalid: use up to 63 letters, digits, '.', '_' and '-' (e.g. 'CC-1042')", costCenter)
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package validation_test
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/dlapiduz/iaf/internal/validation"
 )
@@ -96,6 +98,362 @@ func TestValidateEnvVarName(t *testing.T) {
 	}
 }
 
+func TestValidateBuildEnvVarName(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr bool
+	}{
+		{"BP_GO_TARGETS", false},
+		{"NODE_ENV", false},
+		{"1BAD", true},
+		{"CNB_PLATFORM_API", true},
+		{"cnb_app_dir", true},
+	}
+	for _, tt := range tests {
+		if err := validation.ValidateBuildEnvVarName(tt.input); (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateEnvironmentName(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr bool
+	}{
+		{"dev", false},
+		{"staging", false},
+		{"prod-eu1", false},
+		{"", true},
+		{"Prod", true},
+		{"prod-", true},
+		{"qa_1", true},
+		{"a-very-long-environment", true},
+	}
+	for _, tt := range tests {
+		if err := validation.ValidateEnvironmentName(tt.input); (err != nil) != tt.wantErr {
+			t.Errorf("%q: error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateBuilder(t *testing.T) {
+	allowed := []string{"iaf-cluster-builder", "tiny", "java-native"}
+	tests := []struct {
+		name    string
+		input   string
+		allowed []string
+		wantErr bool
+	}{
+		{"empty uses the default", "", allowed, false},
+		{"default", "iaf-cluster-builder", allowed, false},
+		{"allowed", "java-native", allowed, false},
+		{"not allowed", "full", allowed, true},
+		{"case sensitive", "Tiny", allowed, true},
+		{"no builders configured", "tiny", nil, true},
+		{"empty without builders", "", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validation.ValidateBuilder(tt.input, tt.allowed); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateArchitecture(t *testing.T) {
+	available := []string{"amd64", "arm64"}
+	tests := []struct {
+		name      string
+		input     string
+		available []string
+		wantErr   bool
+	}{
+		{"empty", "", available, false},
+		{"amd64", "amd64", available, false},
+		{"arm64", "arm64", available, false},
+		{"not offered", "multi", available, true},
+		{"unknown", "x86_64", []string{"x86_64"}, true},
+		{"no architectures configured", "amd64", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validation.ValidateArchitecture(tt.input, tt.available); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateWorkloadClass(t *testing.T) {
+	available := []string{"gpu", "standard"}
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"empty", "", false},
+		{"standard", "standard", false},
+		{"gpu", "gpu", false},
+		{"not offered", "burst", true},
+		{"unknown", "highmem", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validation.ValidateWorkloadClass(tt.input, available); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateProtocol(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"empty defaults to http", "", false},
+		{"http", "http", false},
+		{"websocket", "websocket", false},
+		{"grpc", "grpc", false},
+		{"tcp", "tcp", false},
+		{"uppercase rejected", "GRPC", true},
+		{"unknown", "udp", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validation.ValidateProtocol(tt.input)
+			if tt.wantErr && err == nil {
+				t.Errorf("expected error for %q, got nil", tt.input)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %q", err.Error())
+			}
+		})
+	}
+}
+
+func TestValidateAuthentication(t *testing.T) {
+	tests := []struct {
+		name           string
+		authentication string
+		protocol       string
+		wantErr        bool
+	}{
+		{"empty defaults to none", "", "", false},
+		{"none with tcp", "none", "tcp", false},
+		{"basic", "basic", "http", false},
+		{"oauth-proxy with grpc", "oauth-proxy", "grpc", false},
+		{"basic with tcp rejected", "basic", "tcp", true},
+		{"oauth-proxy with tcp rejected", "oauth-proxy", "tcp", true},
+		{"unknown", "ldap", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validation.ValidateAuthentication(tt.authentication, tt.protocol)
+			if tt.wantErr && err == nil {
+				t.Errorf("expected error for %q/%q, got nil", tt.authentication, tt.protocol)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %q", err.Error())
+			}
+		})
+	}
+}
+
+func TestValidateBackendTLS(t *testing.T) {
+	tests := []struct {
+		name           string
+		enabled        bool
+		protocol       string
+		authentication string
+		wantErr        bool
+	}{
+		{"disabled with tcp", false, "tcp", "", false},
+		{"http", true, "", "", false},
+		{"grpc with basic", true, "grpc", "basic", false},
+		{"tcp rejected", true, "tcp", "", true},
+		{"oauth-proxy rejected", true, "http", "oauth-proxy", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validation.ValidateBackendTLS(tt.enabled, tt.protocol, tt.authentication)
+			if tt.wantErr && err == nil {
+				t.Errorf("expected error for %q/%q, got nil", tt.protocol, tt.authentication)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %q", err.Error())
+			}
+		})
+	}
+}
+
+func TestValidateAccess(t *testing.T) {
+	tests := []struct {
+		name     string
+		allow    []string
+		rps      int32
+		protocol string
+		wantErr  bool
+	}{
+		{"no restrictions", nil, 0, "tcp", false},
+		{"cidr and ip", []string{"10.0.0.0/8", "203.0.113.7", "2001:db8::/32"}, 10, "http", false},
+		{"invalid cidr", []string{"10.0.0.0/33"}, 0, "", true},
+		{"hostname rejected", []string{"example.com"}, 0, "", true},
+		{"negative rps", nil, -1, "", true},
+		{"tcp rejected", []string{"10.0.0.0/8"}, 0, "tcp", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validation.ValidateAccess(tt.allow, tt.rps, tt.protocol)
+			if tt.wantErr && err == nil {
+				t.Error("expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %q", err.Error())
+			}
+		})
+	}
+}
+
+func TestValidateIdleTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		protocol string
+		want     time.Duration
+		wantErr  bool
+	}{
+		{"disabled", "0", "tcp", 0, false},
+		{"hours", "2h", "http", 2 * time.Hour, false},
+		{"too short", "1m", "", 0, true},
+		{"negative", "-1h", "", 0, true},
+		{"not a duration", "soon", "", 0, true},
+		{"tcp rejected", "1h", "tcp", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validation.ValidateIdleTimeout(tt.value, tt.protocol)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"days", "72h", 72 * time.Hour, false},
+		{"minimum", "10m", 10 * time.Minute, false},
+		{"too short", "5m", 0, true},
+		{"too long", "1000h", 0, true},
+		{"zero", "0", 0, true},
+		{"not a duration", "3d", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validation.ValidateTTL(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateMaintenanceWindow(t *testing.T) {
+	tests := []struct {
+		name                 string
+		day, start, duration string
+		wantDay              string
+		want                 time.Duration
+		wantErr              bool
+	}{
+		{"weekly", "sunday", "03:00", "2h", "Sunday", 2 * time.Hour, false},
+		{"daily default", "", "23:30", "", "", time.Hour, false},
+		{"unknown day", "Funday", "03:00", "", "", 0, true},
+		{"bad start", "", "3am", "", "", 0, true},
+		{"unpadded start", "", "3:00", "", "", 0, true},
+		{"too short", "", "03:00", "10m", "", 0, true},
+		{"too long", "", "03:00", "48h", "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day, got, err := validation.ValidateMaintenanceWindow(tt.day, tt.start, tt.duration)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if day != tt.wantDay || got != tt.want {
+				t.Errorf("got %q %v, want %q %v", day, got, tt.wantDay, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRegistryServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		server  string
+		wantErr bool
+	}{
+		{"hostname", "ghcr.io", false},
+		{"with port", "registry.example.com:5000", false},
+		{"ip with port", "203.0.113.7:5000", false},
+		{"empty", "", true},
+		{"scheme", "https://ghcr.io", true},
+		{"path", "ghcr.io/team", true},
+		{"bad port", "ghcr.io:99999", true},
+		{"uppercase", "GHCR.io", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validation.ValidateRegistryServer(tt.server)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRegistryPrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		prefix  string
+		wantErr bool
+	}{
+		{"server only", "ghcr.io", false},
+		{"with path", "ghcr.io/team", false},
+		{"port and nested path", "registry.corp:5000/iaf/payments", false},
+		{"separators", "ghcr.io/team_a/web-apps.v2", false},
+		{"empty", "", true},
+		{"scheme", "https://ghcr.io/team", true},
+		{"trailing slash", "ghcr.io/team/", true},
+		{"empty component", "ghcr.io//team", true},
+		{"uppercase path", "ghcr.io/Team", true},
+		{"tag", "ghcr.io/team:latest", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validation.ValidateRegistryPrefix(tt.prefix)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
 		func() bool {
@@ -107,3 +465,374 @@ func contains(s, substr string) bool {
 			return false
 		}())
 }
+
+func TestValidateUptimeCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		interval int32
+		protocol string
+		wantErr  bool
+	}{
+		{"defaults", "", 0, "", false},
+		{"health path", "/healthz?full=1", 60, "http", false},
+		{"relative path", "healthz", 0, "", true},
+		{"space in path", "/health check", 0, "", true},
+		{"too long", "/" + strings.Repeat("a", 256), 0, "", true},
+		{"interval too short", "/", 10, "", true},
+		{"interval too long", "/", 7200, "", true},
+		{"tcp rejected", "", 0, "tcp", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validation.ValidateUptimeCheck(tt.path, tt.interval, tt.protocol)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateBuildCache(t *testing.T) {
+	tests := []struct {
+		name      string
+		cacheType string
+		size      string
+		wantSize  string
+		wantErr   bool
+	}{
+		{"defaults", "", "", "", false},
+		{"volume with size", "volume", "5Gi", "5Gi", false},
+		{"size only", "", "2Gi", "2Gi", false},
+		{"registry", "registry", "", "", false},
+		{"none", "none", "", "", false},
+		{"unknown type", "disk", "", "", true},
+		{"size with registry", "registry", "2Gi", "", true},
+		{"size with none", "none", "2Gi", "", true},
+		{"not a quantity", "volume", "lots", "", true},
+		{"too small", "volume", "100Mi", "", true},
+		{"too large", "volume", "100Gi", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size, err := validation.ValidateBuildCache(tt.cacheType, tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantSize == "" && size != nil {
+				t.Errorf("expected no size, got %s", size.String())
+			}
+			if tt.wantSize != "" && (size == nil || size.String() != tt.wantSize) {
+				t.Errorf("expected size %s, got %v", tt.wantSize, size)
+			}
+		})
+	}
+}
+
+func TestValidateConfigFilePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"file", "/app/config.yaml", false},
+		{"etc", "/etc/nginx/nginx.conf", false},
+		{"dotfile", "/home/app/.npmrc", false},
+		{"empty", "", true},
+		{"relative", "config.yaml", true},
+		{"root", "/", true},
+		{"trailing slash", "/app/config/", true},
+		{"parent", "/app/../etc/passwd", true},
+		{"dot", "/app/./config.yaml", true},
+		{"double slash", "/app//config.yaml", true},
+		{"proc", "/proc/self/environ", true},
+		{"service account", "/var/run/secrets/kubernetes.io/serviceaccount/token", true},
+		{"dev", "/dev", true},
+		{"too long", "/" + strings.Repeat("a", 1024), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validation.ValidateConfigFilePath(tt.input); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateConfigMapKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"file name", "application.yml", false},
+		{"dashes and underscores", "log-config_v2", false},
+		{"empty", "", true},
+		{"dot", ".", true},
+		{"parent", "..", true},
+		{"slash", "conf/app.yml", true},
+		{"space", "app config", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validation.ValidateConfigMapKey(tt.input); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []string
+		wantErr bool
+	}{
+		{"program and args", []string{"npm", "run", "migrate"}, false},
+		{"shell script", []string{"sh", "-c", "bundle exec rake db:migrate && echo done"}, false},
+		{"empty", nil, true},
+		{"blank program", []string{" ", "migrate"}, true},
+		{"too many args", make([]string, 33), true},
+		{"too long", []string{"echo", strings.Repeat("x", 4097)}, true},
+		{"nul byte", []string{"echo", "a\x00b"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validation.ValidateCommand(tt.input); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []string
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"flags", []string{"--port", "8080", ""}, false},
+		{"too many", make([]string, 33), true},
+		{"too long", []string{strings.Repeat("x", 4097)}, true},
+		{"nul byte", []string{"a\x00b"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validation.ValidateArgs(tt.input); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateIdempotencyKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"uuid", "3f2b8c1e-9d4a-4f6b-8e2d-1a7c5b9e0f34", false},
+		{"prefixed", "deploy:web.v2_1", false},
+		{"empty", "", true},
+		{"space", "my key", true},
+		{"too long", strings.Repeat("k", 256), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validation.ValidateIdempotencyKey(tt.input); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateHostAlias(t *testing.T) {
+	tests := []struct {
+		name      string
+		ip        string
+		hostnames []string
+		wantErr   bool
+	}{
+		{"on-prem host", "10.20.0.5", []string{"ldap.corp.example.com", "ldap2.corp.example.com"}, false},
+		{"ipv6", "fd00::5", []string{"db.corp.example.com"}, false},
+		{"invalid ip", "10.20.0", []string{"ldap.corp.example.com"}, true},
+		{"loopback", "127.0.0.1", []string{"ldap.corp.example.com"}, true},
+		{"no hostnames", "10.20.0.5", nil, true},
+		{"too many hostnames", "10.20.0.5", make([]string, 11), true},
+		{"single label", "10.20.0.5", []string{"postgres"}, true},
+		{"service name", "10.20.0.5", []string{"db.iaf-abc.svc"}, true},
+		{"cluster domain", "10.20.0.5", []string{"kubernetes.default.svc.cluster.local"}, true},
+		{"localhost", "10.20.0.5", []string{"app.localhost"}, true},
+		{"uppercase", "10.20.0.5", []string{"LDAP.corp.example.com"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validation.ValidateHostAlias(tt.ip, tt.hostnames); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateDNSConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		nameservers []string
+		searches    []string
+		wantErr     bool
+	}{
+		{"empty", nil, nil, false},
+		{"corporate resolver", []string{"10.0.0.53", "10.0.1.53"}, []string{"corp.example.com"}, false},
+		{"too many nameservers", []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, nil, true},
+		{"invalid nameserver", []string{"dns.corp"}, nil, true},
+		{"too many searches", nil, []string{"a.example.com", "b.example.com", "c.example.com", "d.example.com"}, true},
+		{"cluster search domain", nil, []string{"other-ns.svc.cluster.local"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validation.ValidateDNSConfig(tt.nameservers, tt.searches); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateDNSOption(t *testing.T) {
+	tests := []struct {
+		name, option, value string
+		wantErr             bool
+	}{
+		{"ndots", "ndots", "2", false},
+		{"flag", "edns0", "", false},
+		{"missing value", "timeout", "", true},
+		{"value out of range", "attempts", "99", true},
+		{"flag with value", "rotate", "1", true},
+		{"unknown", "inet6", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validation.ValidateDNSOption(tt.option, tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateGitTrack(t *testing.T) {
+	tests := []struct {
+		name, track, revision string
+		wantErr               bool
+	}{
+		{"default", "", "main", false},
+		{"revision", "revision", "3f2a9c1", false},
+		{"branch", "branch", "release/1.x", false},
+		{"branch commit", "branch", "3f2a9c1d", true},
+		{"unknown", "tag", "v1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validation.ValidateGitTrack(tt.track, tt.revision); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSubPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"root", "", false},
+		{"directory", "services/api", false},
+		{"dotted", "apps/web.v2/@scope+x", false},
+		{"absolute", "/services/api", true},
+		{"trailing slash", "services/api/", true},
+		{"parent", "../other", true},
+		{"inner parent", "services/../../etc", true},
+		{"dot", ".", true},
+		{"double slash", "services//api", true},
+		{"backslash", `services\api`, true},
+		{"space", "my app", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validation.ValidateSubPath(tt.input); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSubPath(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCodeOwner(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"user", "@octocat", false},
+		{"team", "@my-org/platform_team", false},
+		{"missing at", "octocat", true},
+		{"empty", "", true},
+		{"trailing dash", "@octocat-", true},
+		{"nested team", "@org/team/sub", true},
+		{"newline", "@octocat\n* @evil", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validation.ValidateCodeOwner(tt.input); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateKnownHosts(t *testing.T) {
+	const key = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIAldg9j9pBrWR2hocOZUdLqA1+iXmKKSAKDaVxIqRvbh"
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"empty", "", false},
+		{"entry", "gitlab.corp.example " + key, false},
+		{"port and comments", "# self-hosted\n\n[gitea.corp.example]:2222,10.0.0.5 " + key + " gitea\n", false},
+		{"no key", "gitlab.corp.example", true},
+		{"fingerprint", "gitlab.corp.example SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8", true},
+		{"bad base64", "gitlab.corp.example ssh-ed25519 not-base64!", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validation.ValidateKnownHosts(tt.input); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateOwnership(t *testing.T) {
+	tests := []struct {
+		name                             string
+		team, owner, purpose, costCenter string
+		wantErr                          bool
+	}{
+		{"all set", "payments", "jane.doe@corp.example", "customer-portal", "CC-1042", false},
+		{"none set", "", "", "", "", false},
+		{"uppercase team", "Payments", "", "", "", true},
+		{"owner without domain", "", "jane", "", "", true},
+		{"owner with plus", "", "jane+iaf@corp.example", "", "", true},
+		{"owner too long", "", strings.Repeat("j", 50) + "@corp.example", "", "", true},
+		{"purpose with spaces", "", "", "customer portal", "", true},
+		{"cost center with slash", "", "", "", "CC/1042", true},
+		{"cost center too long", "", "", "", strings.Repeat("c", 64), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validation.ValidateOwnership(tt.team, tt.owner, tt.purpose, tt.costCenter); (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
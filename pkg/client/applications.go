@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListApplications returns the applications in the session.
+func (c *Client) ListApplications(ctx context.Context) ([]Application, error) {
+	var apps []Application
+	if err := c.do(ctx, http.MethodGet, "/applications", nil, nil, &apps); err != nil {
+		return nil, err
+	}
+	return apps, nil
+}
+
+// GetApplication returns one application. Use IsNotFound to detect a
+// missing application.
+func (c *Client) GetApplication(ctx context.Context, name string) (*Application, error) {
+	var app Application
+	if err := c.do(ctx, http.MethodGet, appPath(name), nil, nil, &app); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// CreateApplication creates an application from an image or git repository.
+// Use IsConflict to detect an existing application with the same name.
+func (c *Client) CreateApplication(ctx context.Context, req ApplicationRequest) (*Application, error) {
+	req.ExpectedVersion = 0
+	var app Application
+	if err := c.do(ctx, http.MethodPost, "/applications", nil, req, &app); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// UpdateApplication changes an existing application. Zero-valued fields in
+// req are left unchanged. Use IsVersionConflict to detect a change made since
+// req.ExpectedVersion.
+func (c *Client) UpdateApplication(ctx context.Context, name string, req ApplicationRequest) (*Application, error) {
+	var app Application
+	if err := c.do(ctx, http.MethodPatch, appPath(name), nil, req, &app); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// ReplaceApplication sets an application to exactly the settings in req,
+// creating it if it does not exist. Unlike UpdateApplication, zero-valued
+// fields return to their defaults. Use IsVersionConflict to detect a change
+// made since req.ExpectedVersion.
+func (c *Client) ReplaceApplication(ctx context.Context, name string, req ApplicationRequest) (*Application, error) {
+	var app Application
+	if err := c.do(ctx, http.MethodPut, appPath(name), nil, req, &app); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// ApplyApplication creates the application, or updates it if one with the
+// same name already exists. The bool result reports whether it was created.
+func (c *Client) ApplyApplication(ctx context.Context, req ApplicationRequest) (*Application, bool, error) {
+	app, err := c.CreateApplication(ctx, req)
+	if IsConflict(err) {
+		app, err = c.UpdateApplication(ctx, req.Name, req)
+		return app, false, err
+	}
+	return app, err == nil, err
+}
+
+// DeleteApplication deletes an application and its uploaded source.
+func (c *Client) DeleteApplication(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, appPath(name), nil, nil, nil)
+}
+
+// BatchDeleteApplications deletes several applications, or all of them in
+// the session. Per-application failures are reported in the result.
+func (c *Client) BatchDeleteApplications(ctx context.Context, req BatchDeleteRequest) (*BatchResult, error) {
+	var result BatchResult
+	if err := c.do(ctx, http.MethodPost, "/applications:batchDelete", nil, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// UploadSource replaces an application's source with files (path → contents)
+// and triggers a build.
+func (c *Client) UploadSource(ctx context.Context, name string, files map[string]string) (*SourceUpload, error) {
+	var upload SourceUpload
+	body := map[string]any{"files": files}
+	if err := c.do(ctx, http.MethodPost, appPath(name)+"/source", nil, body, &upload); err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// LogOptions selects which runtime logs to fetch.
+type LogOptions struct {
+	// Lines is the number of trailing lines to return (server default: 100).
+	Lines int
+	// Pod selects a specific pod; the most recent pod is used when empty.
+	Pod string
+}
+
+// GetLogs returns recent runtime logs for an application.
+func (c *Client) GetLogs(ctx context.Context, name string, opts LogOptions) (*Logs, error) {
+	var logs Logs
+	if err := c.do(ctx, http.MethodGet, appPath(name)+"/logs", opts.query(), nil, &logs); err != nil {
+		return nil, err
+	}
+	return &logs, nil
+}
+
+// GetBuildLogs returns logs from an application's most recent build.
+func (c *Client) GetBuildLogs(ctx context.Context, name string) (*BuildLogs, error) {
+	var logs BuildLogs
+	if err := c.do(ctx, http.MethodGet, appPath(name)+"/build", nil, nil, &logs); err != nil {
+		return nil, err
+	}
+	return &logs, nil
+}
+
+func (o LogOptions) query() url.Values {
+	q := url.Values{}
+	if o.Lines > 0 {
+		q.Set("lines", strconv.Itoa(o.Lines))
+	}
+	if o.Pod != "" {
+		q.Set("pod_name", o.Pod)
+	}
+	return q
+}
+
+func appPath(name string) string {
+	return "/applications/" + url.PathEscape(name)
+}
@@ -0,0 +1,305 @@
+// Package client is a typed Go client for the IAF REST API.
+//
+// A Client authenticates with an API token and acts on behalf of one session:
+//
+//	c := client.New("https://iaf.example.com", token)
+//	sess, err := c.RegisterSession(ctx, "ci")
+//	c = c.ForSession(sess.SessionID)
+//	app, err := c.CreateApplication(ctx, client.ApplicationRequest{Name: "web", Image: "nginx:alpine"})
+//
+// Idempotent requests are retried with exponential backoff on network errors
+// and on 429, 502, 503, and 504 responses.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Values below 1 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry. It doubles on each
+	// subsequent retry, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy is used unless WithRetryPolicy is given.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 250 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+}
+
+// Client calls the IAF REST API. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	token      string
+	sessionID  string
+	httpClient *http.Client
+	retry      RetryPolicy
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithSession scopes the client to an existing session.
+func WithSession(sessionID string) Option {
+	return func(c *Client) { c.sessionID = sessionID }
+}
+
+// WithHTTPClient replaces the default HTTP client (60s timeout).
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) { c.retry = p }
+}
+
+// New returns a client for the API server at baseURL (e.g.
+// "https://iaf.example.com") authenticating with token.
+func New(baseURL, token string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		retry:      DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ForSession returns a copy of the client scoped to sessionID.
+func (c *Client) ForSession(sessionID string) *Client {
+	cp := *c
+	cp.sessionID = sessionID
+	return &cp
+}
+
+// SessionID returns the session the client acts on, if any.
+func (c *Client) SessionID() string {
+	return c.sessionID
+}
+
+// APIError is a non-2xx response from the API server. Code, Category,
+// Retryable, Hint and Details come from the structured error body; see the
+// error format in docs/usage.md.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Code       string
+	Category   string
+	Retryable  bool
+	Hint       string
+	// Details is the raw "details" object of the body, if any. On
+	// version_conflict it holds currentVersion and the current spec.
+	Details json.RawMessage
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// IsNotFound reports whether err is an API 404 response.
+func IsNotFound(err error) bool {
+	return statusCode(err) == http.StatusNotFound
+}
+
+// IsConflict reports whether err is an API 409 response.
+func IsConflict(err error) bool {
+	return statusCode(err) == http.StatusConflict
+}
+
+// IsVersionConflict reports whether err is a version_conflict error: the
+// application changed since the ExpectedVersion of an update.
+func IsVersionConflict(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == "version_conflict"
+}
+
+func statusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// RegisterSession creates a new session. The returned ID is passed to
+// ForSession or WithSession for all other calls.
+func (c *Client) RegisterSession(ctx context.Context, name string) (*Session, error) {
+	var sess Session
+	if err := c.do(ctx, http.MethodPost, "/sessions", nil, map[string]string{"name": name}, &sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// ListServices returns the managed services in the session.
+func (c *Client) ListServices(ctx context.Context) ([]Service, error) {
+	var services []Service
+	if err := c.do(ctx, http.MethodGet, "/services", nil, nil, &services); err != nil {
+		return nil, err
+	}
+	return services, nil
+}
+
+// ListDataSources returns the platform data sources, optionally filtered by kind.
+func (c *Client) ListDataSources(ctx context.Context, kind string) ([]DataSource, error) {
+	var query url.Values
+	if kind != "" {
+		query = url.Values{"kind": {kind}}
+	}
+	var sources []DataSource
+	if err := c.do(ctx, http.MethodGet, "/data-sources", query, nil, &sources); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// do sends a JSON request to path (relative to /api/v1), retrying according
+// to the client's policy, and decodes the response into out.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+	}
+
+	u := c.baseURL + "/api/v1" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	attempts := max(c.retry.MaxAttempts, 1)
+	backoff := c.retry.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		data, retryAfter, err := c.send(ctx, method, u, payload)
+		if err == nil {
+			if out == nil {
+				return nil
+			}
+			if err := json.Unmarshal(data, out); err != nil {
+				return fmt.Errorf("decoding response: %w", err)
+			}
+			return nil
+		}
+		if attempt >= attempts || !retryable(method, err) {
+			return err
+		}
+
+		wait := backoff
+		if retryAfter > 0 {
+			wait = retryAfter
+		} else if wait > 0 {
+			// Up to 25% jitter keeps concurrent clients from retrying in lockstep.
+			wait += time.Duration(rand.Int64N(int64(wait)/4 + 1))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		backoff = min(backoff*2, c.retry.MaxBackoff)
+	}
+}
+
+// send performs one attempt and returns the response body, or an error and
+// the server's Retry-After hint.
+func (c *Client) send(ctx context.Context, method, u string, payload []byte) ([]byte, time.Duration, error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.sessionID != "" {
+		req.Header.Set("X-IAF-Session", c.sessionID)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, &transportError{err: err}
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, &transportError{err: fmt.Errorf("reading response: %w", err)}
+	}
+
+	if resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+		var body struct {
+			Error     string          `json:"error"`
+			Code      string          `json:"code"`
+			Category  string          `json:"category"`
+			Retryable bool            `json:"retryable"`
+			Hint      string          `json:"hint"`
+			Details   json.RawMessage `json:"details"`
+		}
+		if json.Unmarshal(data, &body) == nil && body.Error != "" {
+			apiErr.Message = body.Error
+			apiErr.Code, apiErr.Category, apiErr.Retryable, apiErr.Hint, apiErr.Details = body.Code, body.Category, body.Retryable, body.Hint, body.Details
+		}
+		var retryAfter time.Duration
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+			retryAfter = time.Duration(secs) * time.Second
+		}
+		return nil, retryAfter, apiErr
+	}
+	return data, 0, nil
+}
+
+// transportError is a failure to get any response from the server.
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string { return e.err.Error() }
+func (e *transportError) Unwrap() error { return e.err }
+
+// retryable reports whether a failed request may be sent again. POST is not
+// idempotent, so it is only retried when the server rejected it unprocessed.
+func retryable(method string, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	idempotent := method != http.MethodPost
+	var tErr *transportError
+	if errors.As(err, &tErr) {
+		return idempotent
+	}
+	switch statusCode(err) {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent
+	}
+	return false
+}
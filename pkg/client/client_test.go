@@ -0,0 +1,192 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dlapiduz/iaf/pkg/client"
+)
+
+var fastRetry = client.WithRetryPolicy(client.RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     time.Millisecond,
+})
+
+func TestClient_Headers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer tok" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if got := r.Header.Get("X-IAF-Session"); got != "sess-1" {
+			t.Errorf("X-IAF-Session = %q", got)
+		}
+		if r.URL.Path != "/api/v1/applications" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		_ = json.NewEncoder(w).Encode([]client.Application{{Name: "web", Phase: client.PhaseRunning}})
+	}))
+	defer srv.Close()
+
+	c := client.New(srv.URL+"/", "tok").ForSession("sess-1")
+	apps, err := c.ListApplications(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(apps) != 1 || apps[0].Name != "web" {
+		t.Errorf("apps = %+v", apps)
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		status       int
+		wantAttempts int32
+	}{
+		{name: "GET retried on 503", method: http.MethodGet, status: http.StatusServiceUnavailable, wantAttempts: 3},
+		{name: "GET retried on 429", method: http.MethodGet, status: http.StatusTooManyRequests, wantAttempts: 3},
+		{name: "POST retried on 429", method: http.MethodPost, status: http.StatusTooManyRequests, wantAttempts: 3},
+		{name: "POST not retried on 503", method: http.MethodPost, status: http.StatusServiceUnavailable, wantAttempts: 1},
+		{name: "GET not retried on 400", method: http.MethodGet, status: http.StatusBadRequest, wantAttempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"error":"try later"}`))
+			}))
+			defer srv.Close()
+
+			c := client.New(srv.URL, "tok", fastRetry, client.WithSession("s"))
+			var err error
+			if tt.method == http.MethodPost {
+				_, err = c.CreateApplication(context.Background(), client.ApplicationRequest{Name: "web", Image: "nginx"})
+			} else {
+				_, err = c.GetApplication(context.Background(), "web")
+			}
+			var apiErr *client.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status || apiErr.Message != "try later" {
+				t.Errorf("err = %v, want APIError %d with server message", err, tt.status)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestClient_RetryRecovers(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(client.Application{Name: "web"})
+	}))
+	defer srv.Close()
+
+	app, err := client.New(srv.URL, "tok", fastRetry).GetApplication(context.Background(), "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.Name != "web" || attempts.Load() != 2 {
+		t.Errorf("app = %+v after %d attempts", app, attempts.Load())
+	}
+}
+
+func TestApplyApplication(t *testing.T) {
+	var methods []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"error":"application already exists"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(client.Application{Name: "web", Image: "nginx:2"})
+	}))
+	defer srv.Close()
+
+	app, created, err := client.New(srv.URL, "tok").ApplyApplication(context.Background(), client.ApplicationRequest{Name: "web", Image: "nginx:2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created || app.Image != "nginx:2" {
+		t.Errorf("created = %v, app = %+v; want update", created, app)
+	}
+	if len(methods) != 2 || methods[1] != "PATCH /api/v1/applications/web" {
+		t.Errorf("requests = %v, want POST then PATCH", methods)
+	}
+}
+
+func TestReplaceApplication(t *testing.T) {
+	var method string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method + " " + r.URL.Path
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(client.Application{Name: "web", Image: "nginx:2"})
+	}))
+	defer srv.Close()
+
+	app, err := client.New(srv.URL, "tok").ReplaceApplication(context.Background(), "web", client.ApplicationRequest{Image: "nginx:2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.Name != "web" || method != "PUT /api/v1/applications/web" {
+		t.Errorf("app = %+v after %s; want PUT", app, method)
+	}
+}
+
+func TestErrorHelpers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"application not found","code":"app_not_found","category":"not_found","retryable":false}`))
+	}))
+	defer srv.Close()
+
+	_, err := client.New(srv.URL, "tok").GetApplication(context.Background(), "missing")
+	if !client.IsNotFound(err) || client.IsConflict(err) {
+		t.Errorf("IsNotFound(%v) = false", err)
+	}
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "app_not_found" || apiErr.Category != "not_found" || apiErr.Retryable {
+		t.Errorf("err = %+v, want the structured error fields", apiErr)
+	}
+}
+
+func TestIsVersionConflict(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["expectedVersion"] != float64(2) {
+			t.Errorf("expected expectedVersion in the body, got %v", body)
+		}
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"error":"application \"web\" is at version 3","code":"version_conflict","category":"conflict","retryable":false,"details":{"currentVersion":3,"current":{"image":"nginx:1.26"}}}`))
+	}))
+	defer srv.Close()
+
+	_, err := client.New(srv.URL, "tok").UpdateApplication(context.Background(), "web", client.ApplicationRequest{Replicas: 2, ExpectedVersion: 2})
+	if !client.IsVersionConflict(err) || !client.IsConflict(err) {
+		t.Fatalf("IsVersionConflict(%v) = false", err)
+	}
+	var apiErr *client.APIError
+	errors.As(err, &apiErr)
+	var details struct {
+		CurrentVersion int64 `json:"currentVersion"`
+	}
+	if err := json.Unmarshal(apiErr.Details, &details); err != nil || details.CurrentVersion != 3 {
+		t.Errorf("details = %s, want currentVersion 3", apiErr.Details)
+	}
+}
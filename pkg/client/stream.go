@@ -0,0 +1,212 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPollInterval is used by FollowLogs and WatchApplication when no
+// interval is given.
+const DefaultPollInterval = 2 * time.Second
+
+// followBatchLines bounds how many lines one FollowLogs poll can return.
+const followBatchLines = 1000
+
+// LogLine is one line of application output.
+type LogLine struct {
+	Time    time.Time
+	Pod     string
+	Message string
+}
+
+// FollowLogs streams an application's runtime logs to fn, starting with the
+// last opts.Lines lines, until ctx is cancelled or fn returns an error. The
+// API has no push channel, so new lines are fetched every interval.
+// Cancelling ctx is the normal way to stop and returns nil.
+func (c *Client) FollowLogs(ctx context.Context, name string, opts LogOptions, interval time.Duration, fn func(LogLine) error) error {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	var last time.Time
+	for {
+		q := opts.query()
+		q.Set("timestamps", "true")
+		if !last.IsZero() {
+			q.Set("since_time", last.Format(time.RFC3339Nano))
+			q.Set("lines", strconv.Itoa(followBatchLines))
+		}
+		var logs Logs
+		err := c.do(ctx, http.MethodGet, appPath(name)+"/logs", q, nil, &logs)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		for _, raw := range strings.Split(logs.Logs, "\n") {
+			if raw == "" {
+				continue
+			}
+			line := parseLogLine(raw)
+			line.Pod = logs.PodName
+			// since_time is inclusive, so lines at or before the last one
+			// delivered were already sent.
+			if !last.IsZero() && !line.Time.After(last) {
+				continue
+			}
+			if err := fn(line); err != nil {
+				return err
+			}
+			if line.Time.After(last) {
+				last = line.Time
+			}
+		}
+
+		if !sleep(ctx, interval) {
+			return nil
+		}
+	}
+}
+
+// parseLogLine splits a "<RFC 3339 timestamp> <message>" line. Lines without
+// a timestamp are returned whole with a zero Time.
+func parseLogLine(raw string) LogLine {
+	ts, msg, ok := strings.Cut(raw, " ")
+	if ok {
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			return LogLine{Time: t, Message: msg}
+		}
+	}
+	return LogLine{Message: raw}
+}
+
+// EventType describes an application change observed by WatchApplication.
+type EventType string
+
+const (
+	// EventInitial carries the application state when the watch starts.
+	EventInitial EventType = "Initial"
+	// EventUpdated is sent when a watched status field changes.
+	EventUpdated EventType = "Updated"
+	// EventDeleted is sent once when the application disappears; the watch
+	// then ends.
+	EventDeleted EventType = "Deleted"
+)
+
+// Event is a change to an application's status.
+type Event struct {
+	Type EventType
+	// Application is the latest state; nil for EventDeleted.
+	Application *Application
+	// Changed lists the JSON names of the fields that changed, e.g. "phase".
+	Changed []string
+}
+
+// WatchApplication sends fn an Event whenever the application's phase, URL,
+// build status, image, replicas, or conditions change. It returns nil when the
+// application is deleted or ctx is cancelled, and fn's error if it returns one.
+func (c *Client) WatchApplication(ctx context.Context, name string, interval time.Duration, fn func(Event) error) error {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	var prev *Application
+	for {
+		app, err := c.GetApplication(ctx, name)
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case IsNotFound(err) && prev != nil:
+			return fn(Event{Type: EventDeleted})
+		case err != nil:
+			return err
+		}
+
+		if prev == nil {
+			if err := fn(Event{Type: EventInitial, Application: app}); err != nil {
+				return err
+			}
+		} else if changed := statusChanges(prev, app); len(changed) > 0 {
+			if err := fn(Event{Type: EventUpdated, Application: app, Changed: changed}); err != nil {
+				return err
+			}
+		}
+		prev = app
+
+		if !sleep(ctx, interval) {
+			return nil
+		}
+	}
+}
+
+// errStopWatch ends a watch started by WaitForPhase.
+var errStopWatch = errors.New("stop watch")
+
+// WaitForPhase blocks until the application reaches one of phases and
+// returns it. Bound the wait with a ctx deadline.
+func (c *Client) WaitForPhase(ctx context.Context, name string, interval time.Duration, phases ...string) (*Application, error) {
+	var found *Application
+	err := c.WatchApplication(ctx, name, interval, func(ev Event) error {
+		if ev.Type == EventDeleted {
+			return &APIError{StatusCode: http.StatusNotFound, Message: "application deleted while waiting"}
+		}
+		for _, p := range phases {
+			if ev.Application.Phase == p {
+				found = ev.Application
+				return errStopWatch
+			}
+		}
+		return nil
+	})
+	if found != nil {
+		return found, nil
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	return nil, err
+}
+
+func statusChanges(prev, cur *Application) []string {
+	var changed []string
+	check := func(field string, differs bool) {
+		if differs {
+			changed = append(changed, field)
+		}
+	}
+	check("phase", prev.Phase != cur.Phase)
+	check("url", prev.URL != cur.URL)
+	check("buildStatus", prev.BuildStatus != cur.BuildStatus)
+	check("queuePosition", prev.QueuePosition != cur.QueuePosition)
+	check("latestImage", prev.LatestImage != cur.LatestImage)
+	check("availableReplicas", prev.AvailableReplicas != cur.AvailableReplicas)
+	check("replicas", prev.Replicas != cur.Replicas)
+	check("conditions", !equalConditions(prev.Conditions, cur.Conditions))
+	return changed
+}
+
+func equalConditions(a, b []Condition) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sleep waits for d and reports false if ctx ended first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
@@ -0,0 +1,136 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dlapiduz/iaf/pkg/client"
+)
+
+func TestFollowLogs(t *testing.T) {
+	// Each poll returns the next batch. The second batch repeats the last line
+	// of the first, as an inclusive since_time would.
+	batches := []string{
+		"2026-01-01T00:00:01Z starting\n2026-01-01T00:00:02Z listening\n",
+		"2026-01-01T00:00:02Z listening\n2026-01-01T00:00:03Z GET /\n",
+	}
+	var (
+		mu      sync.Mutex
+		queries []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		i := len(queries)
+		queries = append(queries, r.URL.RawQuery)
+		mu.Unlock()
+		logs := ""
+		if i < len(batches) {
+			logs = batches[i]
+		}
+		_ = json.NewEncoder(w).Encode(client.Logs{Logs: logs, PodName: "web-abc"})
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var got []string
+	err := client.New(srv.URL, "tok").FollowLogs(ctx, "web", client.LogOptions{Lines: 10}, time.Millisecond, func(l client.LogLine) error {
+		if l.Pod != "web-abc" {
+			t.Errorf("pod = %q", l.Pod)
+		}
+		got = append(got, l.Message)
+		if len(got) == 3 {
+			cancel()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"starting", "listening", "GET /"}
+	if len(got) != len(want) {
+		t.Fatalf("lines = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if queries[0] != "lines=10&timestamps=true" {
+		t.Errorf("first query = %q", queries[0])
+	}
+	if want := "since_time=2026-01-01T00%3A00%3A02Z"; len(queries) < 2 || !strings.Contains(queries[1], want) {
+		t.Errorf("second query = %q, want %s", queries[1], want)
+	}
+}
+
+func TestWaitForPhase(t *testing.T) {
+	phases := []string{client.PhaseBuilding, client.PhaseBuilding, client.PhaseDeploying, client.PhaseRunning}
+	var mu sync.Mutex
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		phase := phases[min(calls, len(phases)-1)]
+		calls++
+		_ = json.NewEncoder(w).Encode(client.Application{Name: "web", Phase: phase})
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	app, err := client.New(srv.URL, "tok").WaitForPhase(ctx, "web", time.Millisecond, client.PhaseRunning, client.PhaseFailed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.Phase != client.PhaseRunning {
+		t.Errorf("phase = %q", app.Phase)
+	}
+}
+
+func TestWatchApplication_Events(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		switch calls {
+		case 1, 2:
+			_ = json.NewEncoder(w).Encode(client.Application{Name: "web", Phase: client.PhaseDeploying})
+		case 3:
+			_ = json.NewEncoder(w).Encode(client.Application{Name: "web", Phase: client.PhaseRunning, AvailableReplicas: 1})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"application not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	var events []client.Event
+	err := client.New(srv.URL, "tok").WatchApplication(context.Background(), "web", time.Millisecond, func(ev client.Event) error {
+		events = append(events, ev)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("events = %+v, want Initial, Updated, Deleted", events)
+	}
+	if events[0].Type != client.EventInitial || events[1].Type != client.EventUpdated || events[2].Type != client.EventDeleted {
+		t.Errorf("event types = %s, %s, %s", events[0].Type, events[1].Type, events[2].Type)
+	}
+	if ch := events[1].Changed; len(ch) != 2 || ch[0] != "phase" || ch[1] != "availableReplicas" {
+		t.Errorf("changed = %v, want [phase availableReplicas]", ch)
+	}
+}
@@ -0,0 +1,262 @@
+package client
+
+// The models below mirror the JSON bodies served by the IAF REST API
+// (internal/api/handlers). They are defined here, without Kubernetes
+// dependencies, so integrations can import this package on its own.
+// TestModelsMatchAPI keeps the two in sync.
+
+// Session is a registered workspace. Every other call is scoped to a session.
+type Session struct {
+	SessionID  string `json:"sessionId"`
+	Namespace  string `json:"namespace"`
+	TTLSeconds int64  `json:"ttlSeconds,omitempty"`
+}
+
+// EnvVar is an environment variable set on an application.
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// AccessConfig restricts who can reach an application's URL.
+type AccessConfig struct {
+	IPAllowList       []string `json:"ipAllowList,omitempty"`
+	RequestsPerSecond int32    `json:"requestsPerSecond,omitempty"`
+}
+
+// OwnershipMetadata records who an application belongs to. The platform
+// sets each field as a label on everything the application runs.
+type OwnershipMetadata struct {
+	Team       string `json:"team,omitempty"`
+	Owner      string `json:"owner,omitempty"`
+	Purpose    string `json:"purpose,omitempty"`
+	CostCenter string `json:"costCenter,omitempty"`
+}
+
+// HostAlias maps hostnames to an IP address in an application's pods.
+type HostAlias struct {
+	IP        string   `json:"ip"`
+	Hostnames []string `json:"hostnames"`
+}
+
+// DNSConfig holds resolver settings added after cluster DNS.
+type DNSConfig struct {
+	Nameservers []string    `json:"nameservers,omitempty"`
+	Searches    []string    `json:"searches,omitempty"`
+	Options     []DNSOption `json:"options,omitempty"`
+}
+
+// DNSOption is a resolver option such as ndots.
+type DNSOption struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
+// Condition is a status condition reported by the platform.
+type Condition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	ObservedGeneration int64  `json:"observedGeneration,omitempty"`
+	LastTransitionTime string `json:"lastTransitionTime"`
+	Reason             string `json:"reason"`
+	Message            string `json:"message"`
+}
+
+// Application phases reported in Application.Phase.
+const (
+	PhasePending   = "Pending"
+	PhaseBuilding  = "Building"
+	PhaseDeploying = "Deploying"
+	PhaseRunning   = "Running"
+	PhaseFailed    = "Failed"
+)
+
+// Application is the API representation of a deployed application.
+type Application struct {
+	Name              string             `json:"name"`
+	UID               string             `json:"uid"`
+	Version           int64              `json:"version"`
+	Phase             string             `json:"phase"`
+	URL               string             `json:"url"`
+	Image             string             `json:"image,omitempty"`
+	GitURL            string             `json:"gitUrl,omitempty"`
+	GitRevision       string             `json:"gitRevision,omitempty"`
+	GitTrack          string             `json:"gitTrack,omitempty"`
+	GitPaused         bool               `json:"gitPaused,omitempty"`
+	Git               *GitStatus         `json:"git,omitempty"`
+	Blob              string             `json:"blob,omitempty"`
+	SubPath           string             `json:"subPath,omitempty"`
+	Port              int32              `json:"port"`
+	Replicas          int32              `json:"replicas"`
+	Suspended         bool               `json:"suspended,omitempty"`
+	DeletionProtected bool               `json:"deletionProtected,omitempty"`
+	AvailableReplicas int32              `json:"availableReplicas"`
+	Rollout           *Rollout           `json:"rollout,omitempty"`
+	Scheduling        *Scheduling        `json:"scheduling,omitempty"`
+	TLS               *TLS               `json:"tls,omitempty"`
+	LatestImage       string             `json:"latestImage,omitempty"`
+	ImageDigest       string             `json:"imageDigest,omitempty"`
+	BuildStatus       string             `json:"buildStatus,omitempty"`
+	QueuePosition     int32              `json:"queuePosition,omitempty"`
+	Env               []EnvVar           `json:"env,omitempty"`
+	BuildEnv          []EnvVar           `json:"buildEnv,omitempty"`
+	Host              string             `json:"host,omitempty"`
+	Protocol          string             `json:"protocol"`
+	StickySessions    bool               `json:"stickySessions,omitempty"`
+	Authentication    string             `json:"authentication"`
+	BackendTLS        bool               `json:"backendTLS,omitempty"`
+	Access            *AccessConfig      `json:"access,omitempty"`
+	Metadata          *OwnershipMetadata `json:"metadata,omitempty"`
+	Conditions        []Condition        `json:"conditions,omitempty"`
+	CreatedAt         string             `json:"createdAt"`
+	// PurgeAt is when an application deleted with delete_app is removed
+	// for good. Until then restore_app brings it back.
+	PurgeAt string `json:"purgeAt,omitempty"`
+	// Grafana deep links, set on single-application responses when the
+	// platform has Grafana configured.
+	LogExploreURL       string `json:"logExploreUrl,omitempty"`
+	TraceExploreURL     string `json:"traceExploreUrl,omitempty"`
+	MetricsDashboardURL string `json:"metricsDashboardUrl,omitempty"`
+}
+
+// Rollout reports the progress of an application's latest rollout. State
+// is Progressing, Complete or Stalled.
+type Rollout struct {
+	State             string `json:"state"`
+	Reason            string `json:"reason,omitempty"`
+	Message           string `json:"message,omitempty"`
+	DesiredReplicas   int32  `json:"desiredReplicas"`
+	UpdatedReplicas   int32  `json:"updatedReplicas"`
+	ReadyReplicas     int32  `json:"readyReplicas"`
+	AvailableReplicas int32  `json:"availableReplicas"`
+}
+
+// GitStatus reports the commits of an application built from git: the one
+// the deployed image was built from and the newest build's. AutoDeploy is
+// set while new commits on the branch are deployed.
+type GitStatus struct {
+	URL          string `json:"url"`
+	Revision     string `json:"revision"`
+	Commit       string `json:"commit,omitempty"`
+	LatestCommit string `json:"latestCommit,omitempty"`
+	AutoDeploy   bool   `json:"autoDeploy,omitempty"`
+}
+
+// Scheduling reports an application's pods that no node can take. Reasons
+// summarize why, e.g. "insufficient memory"; ScalingUp is set while a
+// cluster autoscaler adds a node for them.
+type Scheduling struct {
+	UnschedulablePods int32    `json:"unschedulablePods"`
+	Reasons           []string `json:"reasons,omitempty"`
+	ScalingUp         bool     `json:"scalingUp,omitempty"`
+	Message           string   `json:"message,omitempty"`
+}
+
+// TLS reports the certificate serving an application's hostname. NotAfter
+// and RenewalTime are RFC 3339 timestamps; Message says why a certificate
+// that is not Ready was not issued.
+type TLS struct {
+	Issuer      string `json:"issuer"`
+	Ready       bool   `json:"ready"`
+	NotAfter    string `json:"notAfter,omitempty"`
+	RenewalTime string `json:"renewalTime,omitempty"`
+	Message     string `json:"message,omitempty"`
+}
+
+// ApplicationRequest is the body for creating or updating an application.
+// On update, zero-valued fields are left unchanged, and a non-zero
+// ExpectedVersion makes the update fail with a version_conflict error if the
+// application changed since that version was read.
+type ApplicationRequest struct {
+	Name           string        `json:"name"`
+	Image          string        `json:"image,omitempty"`
+	GitURL         string        `json:"gitUrl,omitempty"`
+	GitRevision    string        `json:"gitRevision,omitempty"`
+	GitTrack       string        `json:"gitTrack,omitempty"`
+	GitPaused      *bool         `json:"gitPaused,omitempty"`
+	SubPath        *string       `json:"subPath,omitempty"`
+	Port           int32         `json:"port,omitempty"`
+	Replicas       int32         `json:"replicas,omitempty"`
+	Env            []EnvVar      `json:"env,omitempty"`
+	BuildEnv       []EnvVar      `json:"buildEnv,omitempty"`
+	Host           string        `json:"host,omitempty"`
+	Protocol       string        `json:"protocol,omitempty"`
+	StickySessions *bool         `json:"stickySessions,omitempty"`
+	Authentication string        `json:"authentication,omitempty"`
+	BackendTLS     *bool         `json:"backendTLS,omitempty"`
+	Access         *AccessConfig `json:"access,omitempty"`
+	// HostAliases and DNSConfig need custom DNS enabled on the platform.
+	HostAliases []HostAlias `json:"hostAliases,omitempty"`
+	DNSConfig   *DNSConfig  `json:"dnsConfig,omitempty"`
+	// Metadata defaults to the session's on create; on update an empty
+	// OwnershipMetadata removes it.
+	Metadata *OwnershipMetadata `json:"metadata,omitempty"`
+	// ExpectedVersion is only sent on update.
+	ExpectedVersion int64 `json:"expectedVersion,omitempty"`
+}
+
+// SourceUpload is the result of uploading application source.
+type SourceUpload struct {
+	Message string `json:"message"`
+	BlobURL string `json:"blobUrl"`
+}
+
+// Logs is a snapshot of an application's runtime logs.
+type Logs struct {
+	Logs          string   `json:"logs"`
+	Pods          int      `json:"pods"`
+	PodName       string   `json:"podName,omitempty"`
+	AvailablePods []string `json:"availablePods"`
+}
+
+// BuildLogs is a snapshot of an application's most recent build logs.
+type BuildLogs struct {
+	BuildLogs   string `json:"buildLogs"`
+	BuildStatus string `json:"buildStatus,omitempty"`
+	PodName     string `json:"podName,omitempty"`
+}
+
+// Service is a managed backing service such as a Postgres database.
+type Service struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Plan      string   `json:"plan"`
+	Phase     string   `json:"phase"`
+	Message   string   `json:"message,omitempty"`
+	BoundApps []string `json:"boundApps,omitempty"`
+}
+
+// DataSource is an operator-registered data source. Credentials are never
+// exposed; only the env var names injected on attach are listed.
+type DataSource struct {
+	Name        string   `json:"name"`
+	Kind        string   `json:"kind"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	EnvVarNames []string `json:"envVarNames"`
+}
+
+// BatchDeleteRequest selects applications for BatchDeleteApplications.
+// Set exactly one of Names or All.
+type BatchDeleteRequest struct {
+	Names  []string `json:"names,omitempty"`
+	All    bool     `json:"all,omitempty"`
+	DryRun bool     `json:"dryRun,omitempty"`
+}
+
+// BatchItem reports why one application was skipped or failed.
+type BatchItem struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// BatchResult summarizes a bulk operation. With DryRun set, Affected lists
+// what would have changed and nothing was modified.
+type BatchResult struct {
+	Action    string      `json:"action"`
+	Namespace string      `json:"namespace"`
+	DryRun    bool        `json:"dryRun"`
+	Affected  []string    `json:"affected"`
+	Skipped   []BatchItem `json:"skipped,omitempty"`
+	Failed    []BatchItem `json:"failed,omitempty"`
+}
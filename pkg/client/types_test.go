@@ -0,0 +1,59 @@
+package client_test
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/dlapiduz/iaf/internal/api/handlers"
+	"github.com/dlapiduz/iaf/pkg/client"
+)
+
+// TestModelsMatchAPI fails when a handler response type gains, loses, or
+// renames a JSON field without the matching change to the client models.
+func TestModelsMatchAPI(t *testing.T) {
+	tests := []struct {
+		name   string
+		server any
+		client any
+	}{
+		{name: "Application", server: handlers.ApplicationResponse{}, client: client.Application{}},
+		// The client sends one request type for create and update.
+		{name: "ApplicationRequest", server: handlers.UpdateApplicationRequest{}, client: client.ApplicationRequest{}},
+		{name: "Session", server: handlers.SessionResponse{}, client: client.Session{}},
+		{name: "Service", server: handlers.ServiceResponse{}, client: client.Service{}},
+		{name: "DataSource", server: handlers.DataSourceResponse{}, client: client.DataSource{}},
+		{name: "Logs", server: handlers.LogsResponse{}, client: client.Logs{}},
+		{name: "BuildLogs", server: handlers.BuildLogsResponse{}, client: client.BuildLogs{}},
+		{name: "SourceUpload", server: handlers.SourceUploadResponse{}, client: client.SourceUpload{}},
+		{name: "BatchDeleteRequest", server: handlers.BatchDeleteRequest{}, client: client.BatchDeleteRequest{}},
+		{name: "BatchResult", server: handlers.BatchResponse{}, client: client.BatchResult{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := jsonFields(reflect.TypeOf(tt.server))
+			got := jsonFields(reflect.TypeOf(tt.client))
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("client fields %v, API fields %v", got, want)
+			}
+		})
+	}
+}
+
+func jsonFields(typ reflect.Type) []string {
+	var names []string
+	for i := range typ.NumField() {
+		if f := typ.Field(i); f.Anonymous && f.Tag.Get("json") == "" {
+			names = append(names, jsonFields(f.Type)...)
+			continue
+		}
+		tag := typ.Field(i).Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,1091 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: iaf/v1/applications.proto
+
+package iafv1
+
+import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type EnvVar struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnvVar) Reset() {
+	*x = EnvVar{}
+	mi := &file_iaf_v1_applications_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnvVar) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnvVar) ProtoMessage() {}
+
+func (x *EnvVar) ProtoReflect() protoreflect.Message {
+	mi := &file_iaf_v1_applications_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnvVar.ProtoReflect.Descriptor instead.
+func (*EnvVar) Descriptor() ([]byte, []int) {
+	return file_iaf_v1_applications_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EnvVar) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *EnvVar) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+// AccessConfig restricts who can reach an application.
+type AccessConfig struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Source IPs or CIDR ranges allowed to connect; empty allows all.
+	IpAllowList []string `protobuf:"bytes,1,rep,name=ip_allow_list,json=ipAllowList,proto3" json:"ip_allow_list,omitempty"`
+	// Average requests per second allowed per client IP; 0 disables the limit.
+	RequestsPerSecond int32 `protobuf:"varint,2,opt,name=requests_per_second,json=requestsPerSecond,proto3" json:"requests_per_second,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *AccessConfig) Reset() {
+	*x = AccessConfig{}
+	mi := &file_iaf_v1_applications_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AccessConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccessConfig) ProtoMessage() {}
+
+func (x *AccessConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_iaf_v1_applications_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AccessConfig.ProtoReflect.Descriptor instead.
+func (*AccessConfig) Descriptor() ([]byte, []int) {
+	return file_iaf_v1_applications_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AccessConfig) GetIpAllowList() []string {
+	if x != nil {
+		return x.IpAllowList
+	}
+	return nil
+}
+
+func (x *AccessConfig) GetRequestsPerSecond() int32 {
+	if x != nil {
+		return x.RequestsPerSecond
+	}
+	return 0
+}
+
+type Condition struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Type    string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Status  string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Reason  string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	Message string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	// RFC 3339 time of the last status change.
+	LastTransitionTime string `protobuf:"bytes,5,opt,name=last_transition_time,json=lastTransitionTime,proto3" json:"last_transition_time,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Condition) Reset() {
+	*x = Condition{}
+	mi := &file_iaf_v1_applications_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Condition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Condition) ProtoMessage() {}
+
+func (x *Condition) ProtoReflect() protoreflect.Message {
+	mi := &file_iaf_v1_applications_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Condition.ProtoReflect.Descriptor instead.
+func (*Condition) Descriptor() ([]byte, []int) {
+	return file_iaf_v1_applications_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Condition) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Condition) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Condition) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Condition) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Condition) GetLastTransitionTime() string {
+	if x != nil {
+		return x.LastTransitionTime
+	}
+	return ""
+}
+
+// ApplicationSpec holds the settings of a create or update.
+type ApplicationSpec struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Name        string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Image       string                 `protobuf:"bytes,2,opt,name=image,proto3" json:"image,omitempty"`
+	GitUrl      string                 `protobuf:"bytes,3,opt,name=git_url,json=gitUrl,proto3" json:"git_url,omitempty"`
+	GitRevision string                 `protobuf:"bytes,4,opt,name=git_revision,json=gitRevision,proto3" json:"git_revision,omitempty"`
+	Port        int32                  `protobuf:"varint,5,opt,name=port,proto3" json:"port,omitempty"`
+	Replicas    int32                  `protobuf:"varint,6,opt,name=replicas,proto3" json:"replicas,omitempty"`
+	Env         []*EnvVar              `protobuf:"bytes,7,rep,name=env,proto3" json:"env,omitempty"`
+	BuildEnv    []*EnvVar              `protobuf:"bytes,8,rep,name=build_env,json=buildEnv,proto3" json:"build_env,omitempty"`
+	Host        string                 `protobuf:"bytes,9,opt,name=host,proto3" json:"host,omitempty"`
+	// http (default), websocket, grpc or tcp.
+	Protocol       string `protobuf:"bytes,10,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	StickySessions *bool  `protobuf:"varint,11,opt,name=sticky_sessions,json=stickySessions,proto3,oneof" json:"sticky_sessions,omitempty"`
+	// none (default), basic or oauth-proxy.
+	Authentication string        `protobuf:"bytes,12,opt,name=authentication,proto3" json:"authentication,omitempty"`
+	Access         *AccessConfig `protobuf:"bytes,13,opt,name=access,proto3" json:"access,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ApplicationSpec) Reset() {
+	*x = ApplicationSpec{}
+	mi := &file_iaf_v1_applications_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApplicationSpec) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApplicationSpec) ProtoMessage() {}
+
+func (x *ApplicationSpec) ProtoReflect() protoreflect.Message {
+	mi := &file_iaf_v1_applications_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApplicationSpec.ProtoReflect.Descriptor instead.
+func (*ApplicationSpec) Descriptor() ([]byte, []int) {
+	return file_iaf_v1_applications_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ApplicationSpec) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ApplicationSpec) GetImage() string {
+	if x != nil {
+		return x.Image
+	}
+	return ""
+}
+
+func (x *ApplicationSpec) GetGitUrl() string {
+	if x != nil {
+		return x.GitUrl
+	}
+	return ""
+}
+
+func (x *ApplicationSpec) GetGitRevision() string {
+	if x != nil {
+		return x.GitRevision
+	}
+	return ""
+}
+
+func (x *ApplicationSpec) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *ApplicationSpec) GetReplicas() int32 {
+	if x != nil {
+		return x.Replicas
+	}
+	return 0
+}
+
+func (x *ApplicationSpec) GetEnv() []*EnvVar {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *ApplicationSpec) GetBuildEnv() []*EnvVar {
+	if x != nil {
+		return x.BuildEnv
+	}
+	return nil
+}
+
+func (x *ApplicationSpec) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *ApplicationSpec) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *ApplicationSpec) GetStickySessions() bool {
+	if x != nil && x.StickySessions != nil {
+		return *x.StickySessions
+	}
+	return false
+}
+
+func (x *ApplicationSpec) GetAuthentication() string {
+	if x != nil {
+		return x.Authentication
+	}
+	return ""
+}
+
+func (x *ApplicationSpec) GetAccess() *AccessConfig {
+	if x != nil {
+		return x.Access
+	}
+	return nil
+}
+
+type Application struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Changes whenever the spec changes; pass it as expected_version to
+	// reject updates based on a stale read.
+	Version           int64         `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Phase             string        `protobuf:"bytes,3,opt,name=phase,proto3" json:"phase,omitempty"`
+	Url               string        `protobuf:"bytes,4,opt,name=url,proto3" json:"url,omitempty"`
+	Image             string        `protobuf:"bytes,5,opt,name=image,proto3" json:"image,omitempty"`
+	GitUrl            string        `protobuf:"bytes,6,opt,name=git_url,json=gitUrl,proto3" json:"git_url,omitempty"`
+	GitRevision       string        `protobuf:"bytes,7,opt,name=git_revision,json=gitRevision,proto3" json:"git_revision,omitempty"`
+	Blob              string        `protobuf:"bytes,8,opt,name=blob,proto3" json:"blob,omitempty"`
+	Port              int32         `protobuf:"varint,9,opt,name=port,proto3" json:"port,omitempty"`
+	Replicas          int32         `protobuf:"varint,10,opt,name=replicas,proto3" json:"replicas,omitempty"`
+	Suspended         bool          `protobuf:"varint,11,opt,name=suspended,proto3" json:"suspended,omitempty"`
+	AvailableReplicas int32         `protobuf:"varint,12,opt,name=available_replicas,json=availableReplicas,proto3" json:"available_replicas,omitempty"`
+	LatestImage       string        `protobuf:"bytes,13,opt,name=latest_image,json=latestImage,proto3" json:"latest_image,omitempty"`
+	BuildStatus       string        `protobuf:"bytes,14,opt,name=build_status,json=buildStatus,proto3" json:"build_status,omitempty"`
+	QueuePosition     int32         `protobuf:"varint,15,opt,name=queue_position,json=queuePosition,proto3" json:"queue_position,omitempty"`
+	Env               []*EnvVar     `protobuf:"bytes,16,rep,name=env,proto3" json:"env,omitempty"`
+	BuildEnv          []*EnvVar     `protobuf:"bytes,17,rep,name=build_env,json=buildEnv,proto3" json:"build_env,omitempty"`
+	Host              string        `protobuf:"bytes,18,opt,name=host,proto3" json:"host,omitempty"`
+	Protocol          string        `protobuf:"bytes,19,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	StickySessions    bool          `protobuf:"varint,20,opt,name=sticky_sessions,json=stickySessions,proto3" json:"sticky_sessions,omitempty"`
+	Authentication    string        `protobuf:"bytes,21,opt,name=authentication,proto3" json:"authentication,omitempty"`
+	Access            *AccessConfig `protobuf:"bytes,22,opt,name=access,proto3" json:"access,omitempty"`
+	Conditions        []*Condition  `protobuf:"bytes,23,rep,name=conditions,proto3" json:"conditions,omitempty"`
+	// RFC 3339 creation time.
+	CreatedAt string `protobuf:"bytes,24,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Grafana deep links, set by GetApplication when Grafana is configured.
+	LogExploreUrl       string `protobuf:"bytes,25,opt,name=log_explore_url,json=logExploreUrl,proto3" json:"log_explore_url,omitempty"`
+	TraceExploreUrl     string `protobuf:"bytes,26,opt,name=trace_explore_url,json=traceExploreUrl,proto3" json:"trace_explore_url,omitempty"`
+	MetricsDashboardUrl string `protobuf:"bytes,27,opt,name=metrics_dashboard_url,json=metricsDashboardUrl,proto3" json:"metrics_dashboard_url,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Application) Reset() {
+	*x = Application{}
+	mi := &file_iaf_v1_applications_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Application) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Application) ProtoMessage() {}
+
+func (x *Application) ProtoReflect() protoreflect.Message {
+	mi := &file_iaf_v1_applications_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Application.ProtoReflect.Descriptor instead.
+func (*Application) Descriptor() ([]byte, []int) {
+	return file_iaf_v1_applications_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Application) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Application) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Application) GetPhase() string {
+	if x != nil {
+		return x.Phase
+	}
+	return ""
+}
+
+func (x *Application) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Application) GetImage() string {
+	if x != nil {
+		return x.Image
+	}
+	return ""
+}
+
+func (x *Application) GetGitUrl() string {
+	if x != nil {
+		return x.GitUrl
+	}
+	return ""
+}
+
+func (x *Application) GetGitRevision() string {
+	if x != nil {
+		return x.GitRevision
+	}
+	return ""
+}
+
+func (x *Application) GetBlob() string {
+	if x != nil {
+		return x.Blob
+	}
+	return ""
+}
+
+func (x *Application) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *Application) GetReplicas() int32 {
+	if x != nil {
+		return x.Replicas
+	}
+	return 0
+}
+
+func (x *Application) GetSuspended() bool {
+	if x != nil {
+		return x.Suspended
+	}
+	return false
+}
+
+func (x *Application) GetAvailableReplicas() int32 {
+	if x != nil {
+		return x.AvailableReplicas
+	}
+	return 0
+}
+
+func (x *Application) GetLatestImage() string {
+	if x != nil {
+		return x.LatestImage
+	}
+	return ""
+}
+
+func (x *Application) GetBuildStatus() string {
+	if x != nil {
+		return x.BuildStatus
+	}
+	return ""
+}
+
+func (x *Application) GetQueuePosition() int32 {
+	if x != nil {
+		return x.QueuePosition
+	}
+	return 0
+}
+
+func (x *Application) GetEnv() []*EnvVar {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *Application) GetBuildEnv() []*EnvVar {
+	if x != nil {
+		return x.BuildEnv
+	}
+	return nil
+}
+
+func (x *Application) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *Application) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *Application) GetStickySessions() bool {
+	if x != nil {
+		return x.StickySessions
+	}
+	return false
+}
+
+func (x *Application) GetAuthentication() string {
+	if x != nil {
+		return x.Authentication
+	}
+	return ""
+}
+
+func (x *Application) GetAccess() *AccessConfig {
+	if x != nil {
+		return x.Access
+	}
+	return nil
+}
+
+func (x *Application) GetConditions() []*Condition {
+	if x != nil {
+		return x.Conditions
+	}
+	return nil
+}
+
+func (x *Application) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *Application) GetLogExploreUrl() string {
+	if x != nil {
+		return x.LogExploreUrl
+	}
+	return ""
+}
+
+func (x *Application) GetTraceExploreUrl() string {
+	if x != nil {
+		return x.TraceExploreUrl
+	}
+	return ""
+}
+
+func (x *Application) GetMetricsDashboardUrl() string {
+	if x != nil {
+		return x.MetricsDashboardUrl
+	}
+	return ""
+}
+
+type ListApplicationsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListApplicationsRequest) Reset() {
+	*x = ListApplicationsRequest{}
+	mi := &file_iaf_v1_applications_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListApplicationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListApplicationsRequest) ProtoMessage() {}
+
+func (x *ListApplicationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iaf_v1_applications_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListApplicationsRequest.ProtoReflect.Descriptor instead.
+func (*ListApplicationsRequest) Descriptor() ([]byte, []int) {
+	return file_iaf_v1_applications_proto_rawDescGZIP(), []int{5}
+}
+
+type ListApplicationsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Applications  []*Application         `protobuf:"bytes,1,rep,name=applications,proto3" json:"applications,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListApplicationsResponse) Reset() {
+	*x = ListApplicationsResponse{}
+	mi := &file_iaf_v1_applications_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListApplicationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListApplicationsResponse) ProtoMessage() {}
+
+func (x *ListApplicationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iaf_v1_applications_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListApplicationsResponse.ProtoReflect.Descriptor instead.
+func (*ListApplicationsResponse) Descriptor() ([]byte, []int) {
+	return file_iaf_v1_applications_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListApplicationsResponse) GetApplications() []*Application {
+	if x != nil {
+		return x.Applications
+	}
+	return nil
+}
+
+type GetApplicationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetApplicationRequest) Reset() {
+	*x = GetApplicationRequest{}
+	mi := &file_iaf_v1_applications_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetApplicationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetApplicationRequest) ProtoMessage() {}
+
+func (x *GetApplicationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iaf_v1_applications_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetApplicationRequest.ProtoReflect.Descriptor instead.
+func (*GetApplicationRequest) Descriptor() ([]byte, []int) {
+	return file_iaf_v1_applications_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetApplicationRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type CreateApplicationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Spec          *ApplicationSpec       `protobuf:"bytes,1,opt,name=spec,proto3" json:"spec,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateApplicationRequest) Reset() {
+	*x = CreateApplicationRequest{}
+	mi := &file_iaf_v1_applications_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateApplicationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateApplicationRequest) ProtoMessage() {}
+
+func (x *CreateApplicationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iaf_v1_applications_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateApplicationRequest.ProtoReflect.Descriptor instead.
+func (*CreateApplicationRequest) Descriptor() ([]byte, []int) {
+	return file_iaf_v1_applications_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CreateApplicationRequest) GetSpec() *ApplicationSpec {
+	if x != nil {
+		return x.Spec
+	}
+	return nil
+}
+
+type UpdateApplicationRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Spec  *ApplicationSpec       `protobuf:"bytes,2,opt,name=spec,proto3" json:"spec,omitempty"`
+	// When set, the update fails with ABORTED (version_conflict) unless the
+	// application is still at this version.
+	ExpectedVersion int64 `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *UpdateApplicationRequest) Reset() {
+	*x = UpdateApplicationRequest{}
+	mi := &file_iaf_v1_applications_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateApplicationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateApplicationRequest) ProtoMessage() {}
+
+func (x *UpdateApplicationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iaf_v1_applications_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateApplicationRequest.ProtoReflect.Descriptor instead.
+func (*UpdateApplicationRequest) Descriptor() ([]byte, []int) {
+	return file_iaf_v1_applications_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UpdateApplicationRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateApplicationRequest) GetSpec() *ApplicationSpec {
+	if x != nil {
+		return x.Spec
+	}
+	return nil
+}
+
+func (x *UpdateApplicationRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+type DeleteApplicationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteApplicationRequest) Reset() {
+	*x = DeleteApplicationRequest{}
+	mi := &file_iaf_v1_applications_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteApplicationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteApplicationRequest) ProtoMessage() {}
+
+func (x *DeleteApplicationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iaf_v1_applications_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteApplicationRequest.ProtoReflect.Descriptor instead.
+func (*DeleteApplicationRequest) Descriptor() ([]byte, []int) {
+	return file_iaf_v1_applications_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeleteApplicationRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type DeleteApplicationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteApplicationResponse) Reset() {
+	*x = DeleteApplicationResponse{}
+	mi := &file_iaf_v1_applications_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteApplicationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteApplicationResponse) ProtoMessage() {}
+
+func (x *DeleteApplicationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iaf_v1_applications_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteApplicationResponse.ProtoReflect.Descriptor instead.
+func (*DeleteApplicationResponse) Descriptor() ([]byte, []int) {
+	return file_iaf_v1_applications_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *DeleteApplicationResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+var File_iaf_v1_applications_proto protoreflect.FileDescriptor
+
+const file_iaf_v1_applications_proto_rawDesc = "" +
+	"\n" +
+	"\x19iaf/v1/applications.proto\x12\x06iaf.v1\x1a\x1cgoogle/api/annotations.proto\"2\n" +
+	"\x06EnvVar\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\"b\n" +
+	"\fAccessConfig\x12\"\n" +
+	"\rip_allow_list\x18\x01 \x03(\tR\vipAllowList\x12.\n" +
+	"\x13requests_per_second\x18\x02 \x01(\x05R\x11requestsPerSecond\"\x9b\x01\n" +
+	"\tCondition\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\x120\n" +
+	"\x14last_transition_time\x18\x05 \x01(\tR\x12lastTransitionTime\"\xbe\x03\n" +
+	"\x0fApplicationSpec\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05image\x18\x02 \x01(\tR\x05image\x12\x17\n" +
+	"\agit_url\x18\x03 \x01(\tR\x06gitUrl\x12!\n" +
+	"\fgit_revision\x18\x04 \x01(\tR\vgitRevision\x12\x12\n" +
+	"\x04port\x18\x05 \x01(\x05R\x04port\x12\x1a\n" +
+	"\breplicas\x18\x06 \x01(\x05R\breplicas\x12 \n" +
+	"\x03env\x18\a \x03(\v2\x0e.iaf.v1.EnvVarR\x03env\x12+\n" +
+	"\tbuild_env\x18\b \x03(\v2\x0e.iaf.v1.EnvVarR\bbuildEnv\x12\x12\n" +
+	"\x04host\x18\t \x01(\tR\x04host\x12\x1a\n" +
+	"\bprotocol\x18\n" +
+	" \x01(\tR\bprotocol\x12,\n" +
+	"\x0fsticky_sessions\x18\v \x01(\bH\x00R\x0estickySessions\x88\x01\x01\x12&\n" +
+	"\x0eauthentication\x18\f \x01(\tR\x0eauthentication\x12,\n" +
+	"\x06access\x18\r \x01(\v2\x14.iaf.v1.AccessConfigR\x06accessB\x12\n" +
+	"\x10_sticky_sessions\"\x8b\a\n" +
+	"\vApplication\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12\x14\n" +
+	"\x05phase\x18\x03 \x01(\tR\x05phase\x12\x10\n" +
+	"\x03url\x18\x04 \x01(\tR\x03url\x12\x14\n" +
+	"\x05image\x18\x05 \x01(\tR\x05image\x12\x17\n" +
+	"\agit_url\x18\x06 \x01(\tR\x06gitUrl\x12!\n" +
+	"\fgit_revision\x18\a \x01(\tR\vgitRevision\x12\x12\n" +
+	"\x04blob\x18\b \x01(\tR\x04blob\x12\x12\n" +
+	"\x04port\x18\t \x01(\x05R\x04port\x12\x1a\n" +
+	"\breplicas\x18\n" +
+	" \x01(\x05R\breplicas\x12\x1c\n" +
+	"\tsuspended\x18\v \x01(\bR\tsuspended\x12-\n" +
+	"\x12available_replicas\x18\f \x01(\x05R\x11availableReplicas\x12!\n" +
+	"\flatest_image\x18\r \x01(\tR\vlatestImage\x12!\n" +
+	"\fbuild_status\x18\x0e \x01(\tR\vbuildStatus\x12%\n" +
+	"\x0equeue_position\x18\x0f \x01(\x05R\rqueuePosition\x12 \n" +
+	"\x03env\x18\x10 \x03(\v2\x0e.iaf.v1.EnvVarR\x03env\x12+\n" +
+	"\tbuild_env\x18\x11 \x03(\v2\x0e.iaf.v1.EnvVarR\bbuildEnv\x12\x12\n" +
+	"\x04host\x18\x12 \x01(\tR\x04host\x12\x1a\n" +
+	"\bprotocol\x18\x13 \x01(\tR\bprotocol\x12'\n" +
+	"\x0fsticky_sessions\x18\x14 \x01(\bR\x0estickySessions\x12&\n" +
+	"\x0eauthentication\x18\x15 \x01(\tR\x0eauthentication\x12,\n" +
+	"\x06access\x18\x16 \x01(\v2\x14.iaf.v1.AccessConfigR\x06access\x121\n" +
+	"\n" +
+	"conditions\x18\x17 \x03(\v2\x11.iaf.v1.ConditionR\n" +
+	"conditions\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x18 \x01(\tR\tcreatedAt\x12&\n" +
+	"\x0flog_explore_url\x18\x19 \x01(\tR\rlogExploreUrl\x12*\n" +
+	"\x11trace_explore_url\x18\x1a \x01(\tR\x0ftraceExploreUrl\x122\n" +
+	"\x15metrics_dashboard_url\x18\x1b \x01(\tR\x13metricsDashboardUrl\"\x19\n" +
+	"\x17ListApplicationsRequest\"S\n" +
+	"\x18ListApplicationsResponse\x127\n" +
+	"\fapplications\x18\x01 \x03(\v2\x13.iaf.v1.ApplicationR\fapplications\"+\n" +
+	"\x15GetApplicationRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"G\n" +
+	"\x18CreateApplicationRequest\x12+\n" +
+	"\x04spec\x18\x01 \x01(\v2\x17.iaf.v1.ApplicationSpecR\x04spec\"\x86\x01\n" +
+	"\x18UpdateApplicationRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12+\n" +
+	"\x04spec\x18\x02 \x01(\v2\x17.iaf.v1.ApplicationSpecR\x04spec\x12)\n" +
+	"\x10expected_version\x18\x03 \x01(\x03R\x0fexpectedVersion\".\n" +
+	"\x18DeleteApplicationRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"5\n" +
+	"\x19DeleteApplicationResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage2\xbd\x04\n" +
+	"\fApplications\x12o\n" +
+	"\x10ListApplications\x12\x1f.iaf.v1.ListApplicationsRequest\x1a .iaf.v1.ListApplicationsResponse\"\x18\x82\xd3\xe4\x93\x02\x12\x12\x10/v1/applications\x12e\n" +
+	"\x0eGetApplication\x12\x1d.iaf.v1.GetApplicationRequest\x1a\x13.iaf.v1.Application\"\x1f\x82\xd3\xe4\x93\x02\x19\x12\x17/v1/applications/{name}\x12j\n" +
+	"\x11CreateApplication\x12 .iaf.v1.CreateApplicationRequest\x1a\x13.iaf.v1.Application\"\x1e\x82\xd3\xe4\x93\x02\x18:\x04spec\"\x10/v1/applications\x12n\n" +
+	"\x11UpdateApplication\x12 .iaf.v1.UpdateApplicationRequest\x1a\x13.iaf.v1.Application\"\"\x82\xd3\xe4\x93\x02\x1c:\x01*\x1a\x17/v1/applications/{name}\x12y\n" +
+	"\x11DeleteApplication\x12 .iaf.v1.DeleteApplicationRequest\x1a!.iaf.v1.DeleteApplicationResponse\"\x1f\x82\xd3\xe4\x93\x02\x19*\x17/v1/applications/{name}B/Z-github.com/dlapiduz/iaf/pkg/grpc/iaf/v1;iafv1b\x06proto3"
+
+var (
+	file_iaf_v1_applications_proto_rawDescOnce sync.Once
+	file_iaf_v1_applications_proto_rawDescData []byte
+)
+
+func file_iaf_v1_applications_proto_rawDescGZIP() []byte {
+	file_iaf_v1_applications_proto_rawDescOnce.Do(func() {
+		file_iaf_v1_applications_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_iaf_v1_applications_proto_rawDesc), len(file_iaf_v1_applications_proto_rawDesc)))
+	})
+	return file_iaf_v1_applications_proto_rawDescData
+}
+
+var file_iaf_v1_applications_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_iaf_v1_applications_proto_goTypes = []any{
+	(*EnvVar)(nil),                    // 0: iaf.v1.EnvVar
+	(*AccessConfig)(nil),              // 1: iaf.v1.AccessConfig
+	(*Condition)(nil),                 // 2: iaf.v1.Condition
+	(*ApplicationSpec)(nil),           // 3: iaf.v1.ApplicationSpec
+	(*Application)(nil),               // 4: iaf.v1.Application
+	(*ListApplicationsRequest)(nil),   // 5: iaf.v1.ListApplicationsRequest
+	(*ListApplicationsResponse)(nil),  // 6: iaf.v1.ListApplicationsResponse
+	(*GetApplicationRequest)(nil),     // 7: iaf.v1.GetApplicationRequest
+	(*CreateApplicationRequest)(nil),  // 8: iaf.v1.CreateApplicationRequest
+	(*UpdateApplicationRequest)(nil),  // 9: iaf.v1.UpdateApplicationRequest
+	(*DeleteApplicationRequest)(nil),  // 10: iaf.v1.DeleteApplicationRequest
+	(*DeleteApplicationResponse)(nil), // 11: iaf.v1.DeleteApplicationResponse
+}
+var file_iaf_v1_applications_proto_depIdxs = []int32{
+	0,  // 0: iaf.v1.ApplicationSpec.env:type_name -> iaf.v1.EnvVar
+	0,  // 1: iaf.v1.ApplicationSpec.build_env:type_name -> iaf.v1.EnvVar
+	1,  // 2: iaf.v1.ApplicationSpec.access:type_name -> iaf.v1.AccessConfig
+	0,  // 3: iaf.v1.Application.env:type_name -> iaf.v1.EnvVar
+	0,  // 4: iaf.v1.Application.build_env:type_name -> iaf.v1.EnvVar
+	1,  // 5: iaf.v1.Application.access:type_name -> iaf.v1.AccessConfig
+	2,  // 6: iaf.v1.Application.conditions:type_name -> iaf.v1.Condition
+	4,  // 7: iaf.v1.ListApplicationsResponse.applications:type_name -> iaf.v1.Application
+	3,  // 8: iaf.v1.CreateApplicationRequest.spec:type_name -> iaf.v1.ApplicationSpec
+	3,  // 9: iaf.v1.UpdateApplicationRequest.spec:type_name -> iaf.v1.ApplicationSpec
+	5,  // 10: iaf.v1.Applications.ListApplications:input_type -> iaf.v1.ListApplicationsRequest
+	7,  // 11: iaf.v1.Applications.GetApplication:input_type -> iaf.v1.GetApplicationRequest
+	8,  // 12: iaf.v1.Applications.CreateApplication:input_type -> iaf.v1.CreateApplicationRequest
+	9,  // 13: iaf.v1.Applications.UpdateApplication:input_type -> iaf.v1.UpdateApplicationRequest
+	10, // 14: iaf.v1.Applications.DeleteApplication:input_type -> iaf.v1.DeleteApplicationRequest
+	6,  // 15: iaf.v1.Applications.ListApplications:output_type -> iaf.v1.ListApplicationsResponse
+	4,  // 16: iaf.v1.Applications.GetApplication:output_type -> iaf.v1.Application
+	4,  // 17: iaf.v1.Applications.CreateApplication:output_type -> iaf.v1.Application
+	4,  // 18: iaf.v1.Applications.UpdateApplication:output_type -> iaf.v1.Application
+	11, // 19: iaf.v1.Applications.DeleteApplication:output_type -> iaf.v1.DeleteApplicationResponse
+	15, // [15:20] is the sub-list for method output_type
+	10, // [10:15] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_iaf_v1_applications_proto_init() }
+func file_iaf_v1_applications_proto_init() {
+	if File_iaf_v1_applications_proto != nil {
+		return
+	}
+	file_iaf_v1_applications_proto_msgTypes[3].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_iaf_v1_applications_proto_rawDesc), len(file_iaf_v1_applications_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_iaf_v1_applications_proto_goTypes,
+		DependencyIndexes: file_iaf_v1_applications_proto_depIdxs,
+		MessageInfos:      file_iaf_v1_applications_proto_msgTypes,
+	}.Build()
+	File_iaf_v1_applications_proto = out.File
+	file_iaf_v1_applications_proto_goTypes = nil
+	file_iaf_v1_applications_proto_depIdxs = nil
+}
@@ -0,0 +1,261 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: iaf/v1/services.proto
+
+package iafv1
+
+import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ManagedService is a provisioned backing service. Connection credentials
+// are never included; bind the service to an app instead.
+type ManagedService struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Plan          string                 `protobuf:"bytes,3,opt,name=plan,proto3" json:"plan,omitempty"`
+	Phase         string                 `protobuf:"bytes,4,opt,name=phase,proto3" json:"phase,omitempty"`
+	Message       string                 `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	BoundApps     []string               `protobuf:"bytes,6,rep,name=bound_apps,json=boundApps,proto3" json:"bound_apps,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ManagedService) Reset() {
+	*x = ManagedService{}
+	mi := &file_iaf_v1_services_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ManagedService) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ManagedService) ProtoMessage() {}
+
+func (x *ManagedService) ProtoReflect() protoreflect.Message {
+	mi := &file_iaf_v1_services_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ManagedService.ProtoReflect.Descriptor instead.
+func (*ManagedService) Descriptor() ([]byte, []int) {
+	return file_iaf_v1_services_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ManagedService) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ManagedService) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ManagedService) GetPlan() string {
+	if x != nil {
+		return x.Plan
+	}
+	return ""
+}
+
+func (x *ManagedService) GetPhase() string {
+	if x != nil {
+		return x.Phase
+	}
+	return ""
+}
+
+func (x *ManagedService) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ManagedService) GetBoundApps() []string {
+	if x != nil {
+		return x.BoundApps
+	}
+	return nil
+}
+
+type ListServicesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListServicesRequest) Reset() {
+	*x = ListServicesRequest{}
+	mi := &file_iaf_v1_services_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListServicesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListServicesRequest) ProtoMessage() {}
+
+func (x *ListServicesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iaf_v1_services_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListServicesRequest.ProtoReflect.Descriptor instead.
+func (*ListServicesRequest) Descriptor() ([]byte, []int) {
+	return file_iaf_v1_services_proto_rawDescGZIP(), []int{1}
+}
+
+type ListServicesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Services      []*ManagedService      `protobuf:"bytes,1,rep,name=services,proto3" json:"services,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListServicesResponse) Reset() {
+	*x = ListServicesResponse{}
+	mi := &file_iaf_v1_services_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListServicesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListServicesResponse) ProtoMessage() {}
+
+func (x *ListServicesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iaf_v1_services_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListServicesResponse.ProtoReflect.Descriptor instead.
+func (*ListServicesResponse) Descriptor() ([]byte, []int) {
+	return file_iaf_v1_services_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListServicesResponse) GetServices() []*ManagedService {
+	if x != nil {
+		return x.Services
+	}
+	return nil
+}
+
+var File_iaf_v1_services_proto protoreflect.FileDescriptor
+
+const file_iaf_v1_services_proto_rawDesc = "" +
+	"\n" +
+	"\x15iaf/v1/services.proto\x12\x06iaf.v1\x1a\x1cgoogle/api/annotations.proto\"\x9b\x01\n" +
+	"\x0eManagedService\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x12\n" +
+	"\x04plan\x18\x03 \x01(\tR\x04plan\x12\x14\n" +
+	"\x05phase\x18\x04 \x01(\tR\x05phase\x12\x18\n" +
+	"\amessage\x18\x05 \x01(\tR\amessage\x12\x1d\n" +
+	"\n" +
+	"bound_apps\x18\x06 \x03(\tR\tboundApps\"\x15\n" +
+	"\x13ListServicesRequest\"J\n" +
+	"\x14ListServicesResponse\x122\n" +
+	"\bservices\x18\x01 \x03(\v2\x16.iaf.v1.ManagedServiceR\bservices2r\n" +
+	"\x0fManagedServices\x12_\n" +
+	"\fListServices\x12\x1b.iaf.v1.ListServicesRequest\x1a\x1c.iaf.v1.ListServicesResponse\"\x14\x82\xd3\xe4\x93\x02\x0e\x12\f/v1/servicesB/Z-github.com/dlapiduz/iaf/pkg/grpc/iaf/v1;iafv1b\x06proto3"
+
+var (
+	file_iaf_v1_services_proto_rawDescOnce sync.Once
+	file_iaf_v1_services_proto_rawDescData []byte
+)
+
+func file_iaf_v1_services_proto_rawDescGZIP() []byte {
+	file_iaf_v1_services_proto_rawDescOnce.Do(func() {
+		file_iaf_v1_services_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_iaf_v1_services_proto_rawDesc), len(file_iaf_v1_services_proto_rawDesc)))
+	})
+	return file_iaf_v1_services_proto_rawDescData
+}
+
+var file_iaf_v1_services_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_iaf_v1_services_proto_goTypes = []any{
+	(*ManagedService)(nil),       // 0: iaf.v1.ManagedService
+	(*ListServicesRequest)(nil),  // 1: iaf.v1.ListServicesRequest
+	(*ListServicesResponse)(nil), // 2: iaf.v1.ListServicesResponse
+}
+var file_iaf_v1_services_proto_depIdxs = []int32{
+	0, // 0: iaf.v1.ListServicesResponse.services:type_name -> iaf.v1.ManagedService
+	1, // 1: iaf.v1.ManagedServices.ListServices:input_type -> iaf.v1.ListServicesRequest
+	2, // 2: iaf.v1.ManagedServices.ListServices:output_type -> iaf.v1.ListServicesResponse
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_iaf_v1_services_proto_init() }
+func file_iaf_v1_services_proto_init() {
+	if File_iaf_v1_services_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_iaf_v1_services_proto_rawDesc), len(file_iaf_v1_services_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_iaf_v1_services_proto_goTypes,
+		DependencyIndexes: file_iaf_v1_services_proto_depIdxs,
+		MessageInfos:      file_iaf_v1_services_proto_msgTypes,
+	}.Build()
+	File_iaf_v1_services_proto = out.File
+	file_iaf_v1_services_proto_goTypes = nil
+	file_iaf_v1_services_proto_depIdxs = nil
+}
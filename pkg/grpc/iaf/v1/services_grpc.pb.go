@@ -0,0 +1,127 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: iaf/v1/services.proto
+
+package iafv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ManagedServices_ListServices_FullMethodName = "/iaf.v1.ManagedServices/ListServices"
+)
+
+// ManagedServicesClient is the client API for ManagedServices service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ManagedServices lists the managed services in the caller's session. It
+// mirrors GET /api/v1/services.
+type ManagedServicesClient interface {
+	ListServices(ctx context.Context, in *ListServicesRequest, opts ...grpc.CallOption) (*ListServicesResponse, error)
+}
+
+type managedServicesClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewManagedServicesClient(cc grpc.ClientConnInterface) ManagedServicesClient {
+	return &managedServicesClient{cc}
+}
+
+func (c *managedServicesClient) ListServices(ctx context.Context, in *ListServicesRequest, opts ...grpc.CallOption) (*ListServicesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListServicesResponse)
+	err := c.cc.Invoke(ctx, ManagedServices_ListServices_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ManagedServicesServer is the server API for ManagedServices service.
+// All implementations must embed UnimplementedManagedServicesServer
+// for forward compatibility.
+//
+// ManagedServices lists the managed services in the caller's session. It
+// mirrors GET /api/v1/services.
+type ManagedServicesServer interface {
+	ListServices(context.Context, *ListServicesRequest) (*ListServicesResponse, error)
+	mustEmbedUnimplementedManagedServicesServer()
+}
+
+// UnimplementedManagedServicesServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedManagedServicesServer struct{}
+
+func (UnimplementedManagedServicesServer) ListServices(context.Context, *ListServicesRequest) (*ListServicesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListServices not implemented")
+}
+func (UnimplementedManagedServicesServer) mustEmbedUnimplementedManagedServicesServer() {}
+func (UnimplementedManagedServicesServer) testEmbeddedByValue()                         {}
+
+// UnsafeManagedServicesServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ManagedServicesServer will
+// result in compilation errors.
+type UnsafeManagedServicesServer interface {
+	mustEmbedUnimplementedManagedServicesServer()
+}
+
+func RegisterManagedServicesServer(s grpc.ServiceRegistrar, srv ManagedServicesServer) {
+	// If the following call pancis, it indicates UnimplementedManagedServicesServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ManagedServices_ServiceDesc, srv)
+}
+
+func _ManagedServices_ListServices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListServicesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagedServicesServer).ListServices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ManagedServices_ListServices_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagedServicesServer).ListServices(ctx, req.(*ListServicesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ManagedServices_ServiceDesc is the grpc.ServiceDesc for ManagedServices service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ManagedServices_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "iaf.v1.ManagedServices",
+	HandlerType: (*ManagedServicesServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListServices",
+			Handler:    _ManagedServices_ListServices_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "iaf/v1/services.proto",
+}
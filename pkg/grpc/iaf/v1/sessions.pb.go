@@ -0,0 +1,196 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: iaf/v1/sessions.proto
+
+package iafv1
+
+import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CreateSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSessionRequest) Reset() {
+	*x = CreateSessionRequest{}
+	mi := &file_iaf_v1_sessions_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSessionRequest) ProtoMessage() {}
+
+func (x *CreateSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iaf_v1_sessions_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSessionRequest.ProtoReflect.Descriptor instead.
+func (*CreateSessionRequest) Descriptor() ([]byte, []int) {
+	return file_iaf_v1_sessions_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateSessionRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type Session struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Pass as the x-iaf-session metadata key on every other call.
+	SessionId string `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Namespace string `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// Idle lifetime of the session; 0 when sessions do not expire.
+	TtlSeconds    int64 `protobuf:"varint,3,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Session) Reset() {
+	*x = Session{}
+	mi := &file_iaf_v1_sessions_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Session) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Session) ProtoMessage() {}
+
+func (x *Session) ProtoReflect() protoreflect.Message {
+	mi := &file_iaf_v1_sessions_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Session.ProtoReflect.Descriptor instead.
+func (*Session) Descriptor() ([]byte, []int) {
+	return file_iaf_v1_sessions_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Session) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *Session) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *Session) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+var File_iaf_v1_sessions_proto protoreflect.FileDescriptor
+
+const file_iaf_v1_sessions_proto_rawDesc = "" +
+	"\n" +
+	"\x15iaf/v1/sessions.proto\x12\x06iaf.v1\x1a\x1cgoogle/api/annotations.proto\"*\n" +
+	"\x14CreateSessionRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"g\n" +
+	"\aSession\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1c\n" +
+	"\tnamespace\x18\x02 \x01(\tR\tnamespace\x12\x1f\n" +
+	"\vttl_seconds\x18\x03 \x01(\x03R\n" +
+	"ttlSeconds2c\n" +
+	"\bSessions\x12W\n" +
+	"\rCreateSession\x12\x1c.iaf.v1.CreateSessionRequest\x1a\x0f.iaf.v1.Session\"\x17\x82\xd3\xe4\x93\x02\x11:\x01*\"\f/v1/sessionsB/Z-github.com/dlapiduz/iaf/pkg/grpc/iaf/v1;iafv1b\x06proto3"
+
+var (
+	file_iaf_v1_sessions_proto_rawDescOnce sync.Once
+	file_iaf_v1_sessions_proto_rawDescData []byte
+)
+
+func file_iaf_v1_sessions_proto_rawDescGZIP() []byte {
+	file_iaf_v1_sessions_proto_rawDescOnce.Do(func() {
+		file_iaf_v1_sessions_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_iaf_v1_sessions_proto_rawDesc), len(file_iaf_v1_sessions_proto_rawDesc)))
+	})
+	return file_iaf_v1_sessions_proto_rawDescData
+}
+
+var file_iaf_v1_sessions_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_iaf_v1_sessions_proto_goTypes = []any{
+	(*CreateSessionRequest)(nil), // 0: iaf.v1.CreateSessionRequest
+	(*Session)(nil),              // 1: iaf.v1.Session
+}
+var file_iaf_v1_sessions_proto_depIdxs = []int32{
+	0, // 0: iaf.v1.Sessions.CreateSession:input_type -> iaf.v1.CreateSessionRequest
+	1, // 1: iaf.v1.Sessions.CreateSession:output_type -> iaf.v1.Session
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_iaf_v1_sessions_proto_init() }
+func file_iaf_v1_sessions_proto_init() {
+	if File_iaf_v1_sessions_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_iaf_v1_sessions_proto_rawDesc), len(file_iaf_v1_sessions_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_iaf_v1_sessions_proto_goTypes,
+		DependencyIndexes: file_iaf_v1_sessions_proto_depIdxs,
+		MessageInfos:      file_iaf_v1_sessions_proto_msgTypes,
+	}.Build()
+	File_iaf_v1_sessions_proto = out.File
+	file_iaf_v1_sessions_proto_goTypes = nil
+	file_iaf_v1_sessions_proto_depIdxs = nil
+}
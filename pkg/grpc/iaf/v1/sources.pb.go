@@ -0,0 +1,209 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: iaf/v1/sources.proto
+
+package iafv1
+
+import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type UploadSourceRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// File contents keyed by relative path.
+	Files map[string]string `protobuf:"bytes,2,rep,name=files,proto3" json:"files,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// A gzipped tarball of the source tree.
+	Tarball       []byte `protobuf:"bytes,3,opt,name=tarball,proto3" json:"tarball,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadSourceRequest) Reset() {
+	*x = UploadSourceRequest{}
+	mi := &file_iaf_v1_sources_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadSourceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadSourceRequest) ProtoMessage() {}
+
+func (x *UploadSourceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iaf_v1_sources_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadSourceRequest.ProtoReflect.Descriptor instead.
+func (*UploadSourceRequest) Descriptor() ([]byte, []int) {
+	return file_iaf_v1_sources_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *UploadSourceRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UploadSourceRequest) GetFiles() map[string]string {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *UploadSourceRequest) GetTarball() []byte {
+	if x != nil {
+		return x.Tarball
+	}
+	return nil
+}
+
+type UploadSourceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	BlobUrl       string                 `protobuf:"bytes,2,opt,name=blob_url,json=blobUrl,proto3" json:"blob_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadSourceResponse) Reset() {
+	*x = UploadSourceResponse{}
+	mi := &file_iaf_v1_sources_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadSourceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadSourceResponse) ProtoMessage() {}
+
+func (x *UploadSourceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iaf_v1_sources_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadSourceResponse.ProtoReflect.Descriptor instead.
+func (*UploadSourceResponse) Descriptor() ([]byte, []int) {
+	return file_iaf_v1_sources_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *UploadSourceResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *UploadSourceResponse) GetBlobUrl() string {
+	if x != nil {
+		return x.BlobUrl
+	}
+	return ""
+}
+
+var File_iaf_v1_sources_proto protoreflect.FileDescriptor
+
+const file_iaf_v1_sources_proto_rawDesc = "" +
+	"\n" +
+	"\x14iaf/v1/sources.proto\x12\x06iaf.v1\x1a\x1cgoogle/api/annotations.proto\"\xbb\x01\n" +
+	"\x13UploadSourceRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12<\n" +
+	"\x05files\x18\x02 \x03(\v2&.iaf.v1.UploadSourceRequest.FilesEntryR\x05files\x12\x18\n" +
+	"\atarball\x18\x03 \x01(\fR\atarball\x1a8\n" +
+	"\n" +
+	"FilesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"K\n" +
+	"\x14UploadSourceResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x19\n" +
+	"\bblob_url\x18\x02 \x01(\tR\ablobUrl2\x7f\n" +
+	"\aSources\x12t\n" +
+	"\fUploadSource\x12\x1b.iaf.v1.UploadSourceRequest\x1a\x1c.iaf.v1.UploadSourceResponse\")\x82\xd3\xe4\x93\x02#:\x01*\"\x1e/v1/applications/{name}/sourceB/Z-github.com/dlapiduz/iaf/pkg/grpc/iaf/v1;iafv1b\x06proto3"
+
+var (
+	file_iaf_v1_sources_proto_rawDescOnce sync.Once
+	file_iaf_v1_sources_proto_rawDescData []byte
+)
+
+func file_iaf_v1_sources_proto_rawDescGZIP() []byte {
+	file_iaf_v1_sources_proto_rawDescOnce.Do(func() {
+		file_iaf_v1_sources_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_iaf_v1_sources_proto_rawDesc), len(file_iaf_v1_sources_proto_rawDesc)))
+	})
+	return file_iaf_v1_sources_proto_rawDescData
+}
+
+var file_iaf_v1_sources_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_iaf_v1_sources_proto_goTypes = []any{
+	(*UploadSourceRequest)(nil),  // 0: iaf.v1.UploadSourceRequest
+	(*UploadSourceResponse)(nil), // 1: iaf.v1.UploadSourceResponse
+	nil,                          // 2: iaf.v1.UploadSourceRequest.FilesEntry
+}
+var file_iaf_v1_sources_proto_depIdxs = []int32{
+	2, // 0: iaf.v1.UploadSourceRequest.files:type_name -> iaf.v1.UploadSourceRequest.FilesEntry
+	0, // 1: iaf.v1.Sources.UploadSource:input_type -> iaf.v1.UploadSourceRequest
+	1, // 2: iaf.v1.Sources.UploadSource:output_type -> iaf.v1.UploadSourceResponse
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_iaf_v1_sources_proto_init() }
+func file_iaf_v1_sources_proto_init() {
+	if File_iaf_v1_sources_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_iaf_v1_sources_proto_rawDesc), len(file_iaf_v1_sources_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_iaf_v1_sources_proto_goTypes,
+		DependencyIndexes: file_iaf_v1_sources_proto_depIdxs,
+		MessageInfos:      file_iaf_v1_sources_proto_msgTypes,
+	}.Build()
+	File_iaf_v1_sources_proto = out.File
+	file_iaf_v1_sources_proto_goTypes = nil
+	file_iaf_v1_sources_proto_depIdxs = nil
+}
@@ -0,0 +1,133 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: iaf/v1/sources.proto
+
+package iafv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Sources_UploadSource_FullMethodName = "/iaf.v1.Sources/UploadSource"
+)
+
+// SourcesClient is the client API for Sources service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Sources uploads application source code. It mirrors
+// POST /api/v1/applications/{name}/source.
+type SourcesClient interface {
+	// UploadSource stores the source of an existing application and points the
+	// application at it, which starts a build. Exactly one of files and
+	// tarball must be set.
+	UploadSource(ctx context.Context, in *UploadSourceRequest, opts ...grpc.CallOption) (*UploadSourceResponse, error)
+}
+
+type sourcesClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSourcesClient(cc grpc.ClientConnInterface) SourcesClient {
+	return &sourcesClient{cc}
+}
+
+func (c *sourcesClient) UploadSource(ctx context.Context, in *UploadSourceRequest, opts ...grpc.CallOption) (*UploadSourceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UploadSourceResponse)
+	err := c.cc.Invoke(ctx, Sources_UploadSource_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SourcesServer is the server API for Sources service.
+// All implementations must embed UnimplementedSourcesServer
+// for forward compatibility.
+//
+// Sources uploads application source code. It mirrors
+// POST /api/v1/applications/{name}/source.
+type SourcesServer interface {
+	// UploadSource stores the source of an existing application and points the
+	// application at it, which starts a build. Exactly one of files and
+	// tarball must be set.
+	UploadSource(context.Context, *UploadSourceRequest) (*UploadSourceResponse, error)
+	mustEmbedUnimplementedSourcesServer()
+}
+
+// UnimplementedSourcesServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSourcesServer struct{}
+
+func (UnimplementedSourcesServer) UploadSource(context.Context, *UploadSourceRequest) (*UploadSourceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UploadSource not implemented")
+}
+func (UnimplementedSourcesServer) mustEmbedUnimplementedSourcesServer() {}
+func (UnimplementedSourcesServer) testEmbeddedByValue()                 {}
+
+// UnsafeSourcesServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SourcesServer will
+// result in compilation errors.
+type UnsafeSourcesServer interface {
+	mustEmbedUnimplementedSourcesServer()
+}
+
+func RegisterSourcesServer(s grpc.ServiceRegistrar, srv SourcesServer) {
+	// If the following call pancis, it indicates UnimplementedSourcesServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Sources_ServiceDesc, srv)
+}
+
+func _Sources_UploadSource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UploadSourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SourcesServer).UploadSource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sources_UploadSource_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SourcesServer).UploadSource(ctx, req.(*UploadSourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Sources_ServiceDesc is the grpc.ServiceDesc for Sources service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Sources_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "iaf.v1.Sources",
+	HandlerType: (*SourcesServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "UploadSource",
+			Handler:    _Sources_UploadSource_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "iaf/v1/sources.proto",
+}
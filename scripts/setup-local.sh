@@ -95,6 +95,9 @@ echo "--- Setting up RBAC ---"
 kubectl apply -f "$ROOT_DIR/config/rbac/"
 echo "RBAC configured."
 
+# Refuse kubectl deletes of deletion-protected apps and services
+kubectl apply -f "$ROOT_DIR/config/admission/"
+
 # 5. Create service account for kpack in iaf-apps namespace
 echo ""
 echo "--- Creating kpack service account ---"
@@ -0,0 +1,73 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dlapiduz/iaf/pkg/client"
+)
+
+// TestREST_DeployImage drives the REST API through the pkg/client SDK:
+// register, deploy an image, wait for Running, read logs, and delete.
+func TestREST_DeployImage(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	c := client.New(apiURL(), apiToken())
+	sess, err := c.RegisterSession(ctx, "integration-rest")
+	if err != nil {
+		t.Fatalf("registering session: %v", err)
+	}
+	c = c.ForSession(sess.SessionID)
+	t.Logf("session_id: %s namespace: %s", sess.SessionID, sess.Namespace)
+
+	appName := "rest-" + sess.SessionID[:8]
+	if _, err := c.CreateApplication(ctx, client.ApplicationRequest{
+		Name:  appName,
+		Image: "nginxinc/nginx-unprivileged:alpine",
+	}); err != nil {
+		t.Fatalf("creating application: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := c.DeleteApplication(context.Background(), appName); err != nil && !client.IsNotFound(err) {
+			t.Logf("delete (non-fatal): %v", err)
+		}
+	})
+
+	app, err := c.WaitForPhase(ctx, appName, 5*time.Second, client.PhaseRunning, client.PhaseFailed)
+	if err != nil {
+		t.Fatalf("waiting for Running: %v", err)
+	}
+	if app.Phase != client.PhaseRunning {
+		t.Fatalf("app entered phase %s: %+v", app.Phase, app.Conditions)
+	}
+
+	resp, err := http.Get(app.URL) //nolint:noctx
+	if err != nil {
+		t.Fatalf("GET %s: %v", app.URL, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("app responded with %d, expected 200", resp.StatusCode)
+	}
+
+	logs, err := c.GetLogs(ctx, appName, client.LogOptions{Lines: 20})
+	if err != nil {
+		t.Fatalf("getting logs: %v", err)
+	}
+	if logs.PodName == "" {
+		t.Errorf("logs response has no pod name: %+v", logs)
+	}
+
+	apps, err := c.ListApplications(ctx)
+	if err != nil {
+		t.Fatalf("listing applications: %v", err)
+	}
+	if len(apps) != 1 || apps[0].Name != appName {
+		t.Errorf("apps = %+v, want only %s", apps, appName)
+	}
+}
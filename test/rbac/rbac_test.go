@@ -28,22 +28,34 @@ type permCheck struct {
 // Each entry maps to a concrete operation the platform performs:
 //
 //   - namespaces create/get       — register tool: EnsureNamespace
+//   - namespaces list/watch       — controller: per-namespace registry prefix
 //   - pods get/list               — app_logs tool: list build and runtime pods
 //   - pods/log get                — app_logs tool: stream log content
+//   - events list                 — troubleshoot-guide prompt: warning events of an app
 //   - secrets create/get/list/delete — copy data-source credentials into session namespaces
 //   - serviceaccounts create/...  — EnsureNamespace: create iaf-kpack-sa
 //   - services create/...         — controller: reconcileService
 //   - deployments create/...      — controller: reconcileDeployment
 //   - kpack.io images create/...  — controller: build via kpack
+//   - kpack.io builds list/watch  — controller: build progress events
 //   - traefik.io ingressroutes    — controller: reconcileIngressRoute
+//   - iaf.io orgstandards list/watch — controller: org standards overrides
+//   - iaf.io platformpolicies list — API and MCP: platform policy checks
+//   - monitoring.coreos.com prometheusrules — MCP: set_alert/list_alerts/delete_alert
+//   - monitoring.coreos.com probes — controller: spec.uptimeCheck
+//   - batch jobs create/get       — MCP: run_migration/migration_status
+//   - configmaps create/get/update — MCP: migration history
 var required = []permCheck{
 	// Session provisioning
 	{Group: "", Resource: "namespaces", Verb: "create"},
 	{Group: "", Resource: "namespaces", Verb: "get"},
+	{Group: "", Resource: "namespaces", Verb: "list"},
+	{Group: "", Resource: "namespaces", Verb: "watch"},
 	// Pod log access for app_logs tool
 	{Group: "", Resource: "pods", Verb: "get"},
 	{Group: "", Resource: "pods", Verb: "list"},
 	{Group: "", Resource: "pods/log", Verb: "get"},
+	{Group: "", Resource: "events", Verb: "list"},
 	// Credential management
 	{Group: "", Resource: "secrets", Verb: "create"},
 	{Group: "", Resource: "secrets", Verb: "get"},
@@ -56,24 +68,47 @@ var required = []permCheck{
 	{Group: "", Resource: "services", Verb: "create"},
 	{Group: "", Resource: "services", Verb: "get"},
 	{Group: "", Resource: "services", Verb: "delete"},
+	{Group: "", Resource: "services", Verb: "patch"}, // server-side apply
 	// Workloads
 	{Group: "apps", Resource: "deployments", Verb: "create"},
 	{Group: "apps", Resource: "deployments", Verb: "get"},
 	{Group: "apps", Resource: "deployments", Verb: "delete"},
+	{Group: "apps", Resource: "deployments", Verb: "patch"}, // server-side apply
 	// IAF CRDs
 	{Group: "iaf.io", Resource: "applications", Verb: "create"},
 	{Group: "iaf.io", Resource: "applications", Verb: "get"},
 	{Group: "iaf.io", Resource: "applications", Verb: "list"},
 	{Group: "iaf.io", Resource: "applications", Verb: "update"},
 	{Group: "iaf.io", Resource: "applications", Verb: "delete"},
+	{Group: "iaf.io", Resource: "orgstandards", Verb: "list"},
+	{Group: "iaf.io", Resource: "orgstandards", Verb: "watch"},
+	{Group: "iaf.io", Resource: "platformpolicies", Verb: "list"},
 	// kpack builds
 	{Group: "kpack.io", Resource: "images", Verb: "create"},
 	{Group: "kpack.io", Resource: "images", Verb: "get"},
 	{Group: "kpack.io", Resource: "images", Verb: "delete"},
+	{Group: "kpack.io", Resource: "builds", Verb: "list"},
+	{Group: "kpack.io", Resource: "builds", Verb: "watch"},
 	// Ingress
 	{Group: "traefik.io", Resource: "ingressroutes", Verb: "create"},
 	{Group: "traefik.io", Resource: "ingressroutes", Verb: "get"},
 	{Group: "traefik.io", Resource: "ingressroutes", Verb: "delete"},
+	// Alerts
+	{Group: "monitoring.coreos.com", Resource: "prometheusrules", Verb: "create"},
+	{Group: "monitoring.coreos.com", Resource: "prometheusrules", Verb: "get"},
+	{Group: "monitoring.coreos.com", Resource: "prometheusrules", Verb: "list"},
+	{Group: "monitoring.coreos.com", Resource: "prometheusrules", Verb: "update"},
+	{Group: "monitoring.coreos.com", Resource: "prometheusrules", Verb: "delete"},
+	{Group: "monitoring.coreos.com", Resource: "probes", Verb: "create"},
+	{Group: "monitoring.coreos.com", Resource: "probes", Verb: "get"},
+	{Group: "monitoring.coreos.com", Resource: "probes", Verb: "update"},
+	{Group: "monitoring.coreos.com", Resource: "probes", Verb: "delete"},
+	// Migrations
+	{Group: "batch", Resource: "jobs", Verb: "create"},
+	{Group: "batch", Resource: "jobs", Verb: "get"},
+	{Group: "", Resource: "configmaps", Verb: "create"},
+	{Group: "", Resource: "configmaps", Verb: "get"},
+	{Group: "", Resource: "configmaps", Verb: "update"},
 }
 
 // TestClusterRoleHasRequiredPermissions parses config/rbac/role.yaml and